@@ -87,6 +87,20 @@ func main() {
 	}
 	logger.Info("authentication configured", "mode", engine.AuthMode())
 
+	if strings.ToLower(strings.TrimSpace(os.Getenv("ROCKETSHIP_TEMPORAL_SEARCH_ATTRIBUTES"))) != "false" {
+		attrCtx, attrCancel := context.WithTimeout(context.Background(), 15*time.Second)
+		if err := engine.RegisterSearchAttributes(attrCtx, temporalNamespace); err != nil {
+			logger.Warn("temporal search attributes disabled", "error", err)
+		} else {
+			logger.Debug("temporal search attributes registered", "namespace", temporalNamespace)
+		}
+		attrCancel()
+	}
+
+	if count := engine.ConfigureResultWebhooksFromEnv(); count > 0 {
+		logger.Info("test result webhooks configured", "endpoints", count)
+	}
+
 	// Start the scheduler if we have a database store that supports scheduling
 	var scheduler *orchestrator.Scheduler
 	var reconciler *orchestrator.Reconciler
@@ -117,10 +131,15 @@ func main() {
 		if scheduler != nil {
 			scheduler.Stop()
 		}
+		// Cancel in-flight runs so they record reason "shutdown" with their partial results
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		engine.Shutdown(shutdownCtx)
+		cancel()
 		os.Exit(0)
 	}()
 
 	startHealthServer()
+	startCallbackServer(engine)
 	startGRPCServer(engine)
 }
 
@@ -405,3 +424,24 @@ func startHealthServer() {
 		}
 	}()
 }
+
+// startCallbackServer serves the callback URLs of webhook_wait steps
+func startCallbackServer(engine *orchestrator.Engine) {
+	logger := cli.Logger
+	addr := strings.TrimSpace(os.Getenv("ROCKETSHIP_WEBHOOK_ADDR"))
+	if addr == "" {
+		addr = ":7702"
+	}
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           engine.CallbackHandler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	logger.Info("webhook callback server listening", "addr", addr)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("webhook callback server error", "error", err)
+		}
+	}()
+}
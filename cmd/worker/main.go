@@ -1,22 +1,54 @@
 package main
 
 import (
+	"fmt"
 	"os"
 
+	"github.com/rocketship-ai/rocketship/internal/buildinfo"
 	"github.com/rocketship-ai/rocketship/internal/cli"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
 	"github.com/rocketship-ai/rocketship/internal/interpreter"
 	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/vault"
 
 	// Import plugins to trigger auto-registration
+	_ "github.com/rocketship-ai/rocketship/internal/plugins/a11y"
 	_ "github.com/rocketship-ai/rocketship/internal/plugins/agent"
+	_ "github.com/rocketship-ai/rocketship/internal/plugins/amqp"
+	_ "github.com/rocketship-ai/rocketship/internal/plugins/browser"
 	_ "github.com/rocketship-ai/rocketship/internal/plugins/browser_use"
+	_ "github.com/rocketship-ai/rocketship/internal/plugins/chaos"
+	_ "github.com/rocketship-ai/rocketship/internal/plugins/clickhouse"
 	_ "github.com/rocketship-ai/rocketship/internal/plugins/delay"
+	_ "github.com/rocketship-ai/rocketship/internal/plugins/docker"
+	_ "github.com/rocketship-ai/rocketship/internal/plugins/email"
+	_ "github.com/rocketship-ai/rocketship/internal/plugins/etcd"
+	_ "github.com/rocketship-ai/rocketship/internal/plugins/exec"
+	_ "github.com/rocketship-ai/rocketship/internal/plugins/faker"
+	_ "github.com/rocketship-ai/rocketship/internal/plugins/file"
+	_ "github.com/rocketship-ai/rocketship/internal/plugins/firestore"
 	_ "github.com/rocketship-ai/rocketship/internal/plugins/http"
+	_ "github.com/rocketship-ai/rocketship/internal/plugins/jwt"
+	_ "github.com/rocketship-ai/rocketship/internal/plugins/kafka"
+	_ "github.com/rocketship-ai/rocketship/internal/plugins/kinesis"
+	_ "github.com/rocketship-ai/rocketship/internal/plugins/kubernetes"
+	_ "github.com/rocketship-ai/rocketship/internal/plugins/load"
 	_ "github.com/rocketship-ai/rocketship/internal/plugins/log"
+	_ "github.com/rocketship-ai/rocketship/internal/plugins/mock"
+	_ "github.com/rocketship-ai/rocketship/internal/plugins/mongodb"
+	_ "github.com/rocketship-ai/rocketship/internal/plugins/neo4j"
 	_ "github.com/rocketship-ai/rocketship/internal/plugins/playwright"
+	_ "github.com/rocketship-ai/rocketship/internal/plugins/poll"
+	_ "github.com/rocketship-ai/rocketship/internal/plugins/s3"
 	_ "github.com/rocketship-ai/rocketship/internal/plugins/script"
 	_ "github.com/rocketship-ai/rocketship/internal/plugins/sql"
+	_ "github.com/rocketship-ai/rocketship/internal/plugins/ssh"
 	_ "github.com/rocketship-ai/rocketship/internal/plugins/supabase"
+	_ "github.com/rocketship-ai/rocketship/internal/plugins/visual"
+	_ "github.com/rocketship-ai/rocketship/internal/plugins/webhook_wait"
+	_ "github.com/rocketship-ai/rocketship/internal/plugins/websocket"
+	_ "github.com/rocketship-ai/rocketship/internal/plugins/zap"
 
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/worker"
@@ -37,6 +69,24 @@ func main() {
 		temporalNamespace = "default"
 	}
 
+	enforced, err := egress.LoadFromEnv()
+	if err != nil {
+		logger.Error("failed to load egress policy", "error", err)
+		os.Exit(1)
+	}
+	if enforced {
+		logger.Info("egress policy enabled", "file", os.Getenv(egress.PolicyFileEnv))
+	}
+
+	vaultEnabled, err := vault.LoadFromEnv()
+	if err != nil {
+		logger.Error("failed to configure vault", "error", err)
+		os.Exit(1)
+	}
+	if vaultEnabled {
+		logger.Info("vault secrets enabled", "addr", os.Getenv(vault.AddrEnv))
+	}
+
 	logger.Debug("connecting to temporal", "host", temporalHost)
 	c, err := client.Dial(client.Options{
 		HostPort:  temporalHost,
@@ -48,8 +98,15 @@ func main() {
 	}
 	defer c.Close()
 
-	logger.Debug("creating worker for task queue", "queue", "test-workflows")
-	w := worker.New(c, "test-workflows", worker.Options{})
+	// Advertise the build and what it can run in the worker identity so the engine can
+	// enforce suite worker requirements and reject suites this worker can't run
+	build := buildinfo.CurrentWorker()
+	caps := buildinfo.Capabilities{Plugins: plugins.RegisteredTypes(), Features: dsl.SupportedFeatures}
+	hostname, _ := os.Hostname()
+	identity := caps.AppendTo(build.Identity(fmt.Sprintf("%d@%s", os.Getpid(), hostname)))
+
+	logger.Debug("creating worker for task queue", "queue", "test-workflows", "version", build.Version, "image", build.Image, "plugins", caps.Plugins)
+	w := worker.New(c, "test-workflows", worker.Options{Identity: identity})
 
 	logger.Debug("registering workflow and plugins")
 	w.RegisterWorkflow(interpreter.TestWorkflow)
@@ -57,6 +114,8 @@ func main() {
 	w.RegisterActivity(interpreter.LogForwarderActivity)
 	w.RegisterActivity(interpreter.StepReporterActivity)
 	w.RegisterActivity(interpreter.TemplateResolverActivity)
+	w.RegisterActivity(interpreter.EnvCheckActivity)
+	w.RegisterActivity(interpreter.HARWriterActivity)
 
 	plugins.RegisterAllWithTemporal(w)
 
@@ -259,8 +259,10 @@ type LogLine struct {
 	Msg           string                 `protobuf:"bytes,2,opt,name=msg,proto3" json:"msg,omitempty"`
 	Color         string                 `protobuf:"bytes,3,opt,name=color,proto3" json:"color,omitempty"` // "green" | "red" | "purple" | "" (default)
 	Bold          bool                   `protobuf:"varint,4,opt,name=bold,proto3" json:"bold,omitempty"`
-	TestName      string                 `protobuf:"bytes,5,opt,name=test_name,json=testName,proto3" json:"test_name,omitempty"` // Name of the test this log belongs to
-	StepName      string                 `protobuf:"bytes,6,opt,name=step_name,json=stepName,proto3" json:"step_name,omitempty"` // Name of the step this log belongs to (if applicable)
+	TestName      string                 `protobuf:"bytes,5,opt,name=test_name,json=testName,proto3" json:"test_name,omitempty"`                                                       // Name of the test this log belongs to
+	StepName      string                 `protobuf:"bytes,6,opt,name=step_name,json=stepName,proto3" json:"step_name,omitempty"`                                                       // Name of the step this log belongs to (if applicable)
+	Level         string                 `protobuf:"bytes,7,opt,name=level,proto3" json:"level,omitempty"`                                                                             // "info" | "warn" | "error" | "" (engine messages)
+	Fields        map[string]string      `protobuf:"bytes,8,rep,name=fields,proto3" json:"fields,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Structured fields logged with the message
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -337,6 +339,20 @@ func (x *LogLine) GetStepName() string {
 	return ""
 }
 
+func (x *LogLine) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+func (x *LogLine) GetFields() map[string]string {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
 type ListRunsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ProjectId     string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
@@ -348,6 +364,8 @@ type ListRunsRequest struct {
 	Cursor        string                 `protobuf:"bytes,7,opt,name=cursor,proto3" json:"cursor,omitempty"`                                 // Pagination cursor
 	OrderBy       string                 `protobuf:"bytes,8,opt,name=order_by,json=orderBy,proto3" json:"order_by,omitempty"`                // "started_at" | "ended_at" | "duration"
 	Descending    bool                   `protobuf:"varint,9,opt,name=descending,proto3" json:"descending,omitempty"`                        // Sort order (default true for recent first)
+	SuiteName     string                 `protobuf:"bytes,10,opt,name=suite_name,json=suiteName,proto3" json:"suite_name,omitempty"`         // Filter by suite name
+	Tags          string                 `protobuf:"bytes,11,opt,name=tags,proto3" json:"tags,omitempty"`                                    // Filter by test tags, e.g. "smoke,!slow"
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -445,6 +463,20 @@ func (x *ListRunsRequest) GetDescending() bool {
 	return false
 }
 
+func (x *ListRunsRequest) GetSuiteName() string {
+	if x != nil {
+		return x.SuiteName
+	}
+	return ""
+}
+
+func (x *ListRunsRequest) GetTags() string {
+	if x != nil {
+		return x.Tags
+	}
+	return ""
+}
+
 type ListRunsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Runs          []*RunSummary          `protobuf:"bytes,1,rep,name=runs,proto3" json:"runs,omitempty"`
@@ -518,6 +550,7 @@ type RunSummary struct {
 	FailedTests   int32                  `protobuf:"varint,9,opt,name=failed_tests,json=failedTests,proto3" json:"failed_tests,omitempty"`
 	TimeoutTests  int32                  `protobuf:"varint,10,opt,name=timeout_tests,json=timeoutTests,proto3" json:"timeout_tests,omitempty"`
 	Context       *RunContext            `protobuf:"bytes,11,opt,name=context,proto3" json:"context,omitempty"`
+	Tags          []string               `protobuf:"bytes,12,rep,name=tags,proto3" json:"tags,omitempty"` // Tags of the tests the run ran
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -629,6 +662,13 @@ func (x *RunSummary) GetContext() *RunContext {
 	return nil
 }
 
+func (x *RunSummary) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
 type GetRunRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	RunId         string                 `protobuf:"bytes,1,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
@@ -727,6 +767,8 @@ type RunDetails struct {
 	DurationMs    int64                  `protobuf:"varint,6,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
 	Context       *RunContext            `protobuf:"bytes,7,opt,name=context,proto3" json:"context,omitempty"`
 	Tests         []*TestDetails         `protobuf:"bytes,8,rep,name=tests,proto3" json:"tests,omitempty"`
+	Progress      *RunProgress           `protobuf:"bytes,9,opt,name=progress,proto3" json:"progress,omitempty"`          // Set while the run is in progress
+	Cancellation  *RunCancellation       `protobuf:"bytes,10,opt,name=cancellation,proto3" json:"cancellation,omitempty"` // Set when the run was cancelled or stopped before every test ran
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -817,11 +859,169 @@ func (x *RunDetails) GetTests() []*TestDetails {
 	return nil
 }
 
+func (x *RunDetails) GetProgress() *RunProgress {
+	if x != nil {
+		return x.Progress
+	}
+	return nil
+}
+
+func (x *RunDetails) GetCancellation() *RunCancellation {
+	if x != nil {
+		return x.Cancellation
+	}
+	return nil
+}
+
+type RunCancellation struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Reason           string                 `protobuf:"bytes,1,opt,name=reason,proto3" json:"reason,omitempty"`                                              // user, timeout, quota or shutdown
+	Message          string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`                                            // Human-readable detail, e.g. the budget that was exceeded
+	CompletedTests   int32                  `protobuf:"varint,3,opt,name=completed_tests,json=completedTests,proto3" json:"completed_tests,omitempty"`       // Tests that finished (passed, failed or timed out)
+	InterruptedTests int32                  `protobuf:"varint,4,opt,name=interrupted_tests,json=interruptedTests,proto3" json:"interrupted_tests,omitempty"` // Tests stopped part way through
+	SkippedTests     int32                  `protobuf:"varint,5,opt,name=skipped_tests,json=skippedTests,proto3" json:"skipped_tests,omitempty"`             // Tests that never started a step
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *RunCancellation) Reset() {
+	*x = RunCancellation{}
+	mi := &file_engine_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunCancellation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunCancellation) ProtoMessage() {}
+
+func (x *RunCancellation) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunCancellation.ProtoReflect.Descriptor instead.
+func (*RunCancellation) Descriptor() ([]byte, []int) {
+	return file_engine_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *RunCancellation) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *RunCancellation) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *RunCancellation) GetCompletedTests() int32 {
+	if x != nil {
+		return x.CompletedTests
+	}
+	return 0
+}
+
+func (x *RunCancellation) GetInterruptedTests() int32 {
+	if x != nil {
+		return x.InterruptedTests
+	}
+	return 0
+}
+
+func (x *RunCancellation) GetSkippedTests() int32 {
+	if x != nil {
+		return x.SkippedTests
+	}
+	return 0
+}
+
+type RunProgress struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	CompletedSteps int32                  `protobuf:"varint,1,opt,name=completed_steps,json=completedSteps,proto3" json:"completed_steps,omitempty"` // Steps finished so far, counting steps skipped by a failure as finished
+	PlannedSteps   int32                  `protobuf:"varint,2,opt,name=planned_steps,json=plannedSteps,proto3" json:"planned_steps,omitempty"`       // Suite init steps plus every test's init steps and steps
+	Percent        int32                  `protobuf:"varint,3,opt,name=percent,proto3" json:"percent,omitempty"`                                     // completed_steps / planned_steps, 0-100
+	EtaMs          int64                  `protobuf:"varint,4,opt,name=eta_ms,json=etaMs,proto3" json:"eta_ms,omitempty"`                            // Estimated time left; 0 when there's nothing to estimate from yet
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RunProgress) Reset() {
+	*x = RunProgress{}
+	mi := &file_engine_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunProgress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunProgress) ProtoMessage() {}
+
+func (x *RunProgress) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunProgress.ProtoReflect.Descriptor instead.
+func (*RunProgress) Descriptor() ([]byte, []int) {
+	return file_engine_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *RunProgress) GetCompletedSteps() int32 {
+	if x != nil {
+		return x.CompletedSteps
+	}
+	return 0
+}
+
+func (x *RunProgress) GetPlannedSteps() int32 {
+	if x != nil {
+		return x.PlannedSteps
+	}
+	return 0
+}
+
+func (x *RunProgress) GetPercent() int32 {
+	if x != nil {
+		return x.Percent
+	}
+	return 0
+}
+
+func (x *RunProgress) GetEtaMs() int64 {
+	if x != nil {
+		return x.EtaMs
+	}
+	return 0
+}
+
 type TestDetails struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	TestId        string                 `protobuf:"bytes,1,opt,name=test_id,json=testId,proto3" json:"test_id,omitempty"`
 	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"` // PENDING, PASSED, FAILED, TIMEOUT, or INTERRUPTED / SKIPPED when the run was cancelled
 	StartedAt     string                 `protobuf:"bytes,4,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
 	EndedAt       string                 `protobuf:"bytes,5,opt,name=ended_at,json=endedAt,proto3" json:"ended_at,omitempty"`
 	DurationMs    int64                  `protobuf:"varint,6,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
@@ -832,7 +1032,7 @@ type TestDetails struct {
 
 func (x *TestDetails) Reset() {
 	*x = TestDetails{}
-	mi := &file_engine_proto_msgTypes[11]
+	mi := &file_engine_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -844,7 +1044,7 @@ func (x *TestDetails) String() string {
 func (*TestDetails) ProtoMessage() {}
 
 func (x *TestDetails) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_proto_msgTypes[11]
+	mi := &file_engine_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -857,7 +1057,7 @@ func (x *TestDetails) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TestDetails.ProtoReflect.Descriptor instead.
 func (*TestDetails) Descriptor() ([]byte, []int) {
-	return file_engine_proto_rawDescGZIP(), []int{11}
+	return file_engine_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *TestDetails) GetTestId() string {
@@ -916,15 +1116,17 @@ type AddLogRequest struct {
 	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
 	Color         string                 `protobuf:"bytes,4,opt,name=color,proto3" json:"color,omitempty"`
 	Bold          bool                   `protobuf:"varint,5,opt,name=bold,proto3" json:"bold,omitempty"`
-	TestName      string                 `protobuf:"bytes,6,opt,name=test_name,json=testName,proto3" json:"test_name,omitempty"` // Name of the test this log belongs to
-	StepName      string                 `protobuf:"bytes,7,opt,name=step_name,json=stepName,proto3" json:"step_name,omitempty"` // Name of the step this log belongs to (if applicable)
+	TestName      string                 `protobuf:"bytes,6,opt,name=test_name,json=testName,proto3" json:"test_name,omitempty"`                                                       // Name of the test this log belongs to
+	StepName      string                 `protobuf:"bytes,7,opt,name=step_name,json=stepName,proto3" json:"step_name,omitempty"`                                                       // Name of the step this log belongs to (if applicable)
+	Level         string                 `protobuf:"bytes,8,opt,name=level,proto3" json:"level,omitempty"`                                                                             // "info" | "warn" | "error" | "" (engine messages)
+	Fields        map[string]string      `protobuf:"bytes,9,rep,name=fields,proto3" json:"fields,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Structured fields logged with the message
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *AddLogRequest) Reset() {
 	*x = AddLogRequest{}
-	mi := &file_engine_proto_msgTypes[12]
+	mi := &file_engine_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -936,7 +1138,7 @@ func (x *AddLogRequest) String() string {
 func (*AddLogRequest) ProtoMessage() {}
 
 func (x *AddLogRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_proto_msgTypes[12]
+	mi := &file_engine_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -949,7 +1151,7 @@ func (x *AddLogRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AddLogRequest.ProtoReflect.Descriptor instead.
 func (*AddLogRequest) Descriptor() ([]byte, []int) {
-	return file_engine_proto_rawDescGZIP(), []int{12}
+	return file_engine_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *AddLogRequest) GetRunId() string {
@@ -1001,6 +1203,20 @@ func (x *AddLogRequest) GetStepName() string {
 	return ""
 }
 
+func (x *AddLogRequest) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+func (x *AddLogRequest) GetFields() map[string]string {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
 type AddLogResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
@@ -1009,7 +1225,7 @@ type AddLogResponse struct {
 
 func (x *AddLogResponse) Reset() {
 	*x = AddLogResponse{}
-	mi := &file_engine_proto_msgTypes[13]
+	mi := &file_engine_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1021,7 +1237,7 @@ func (x *AddLogResponse) String() string {
 func (*AddLogResponse) ProtoMessage() {}
 
 func (x *AddLogResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_proto_msgTypes[13]
+	mi := &file_engine_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1034,7 +1250,7 @@ func (x *AddLogResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AddLogResponse.ProtoReflect.Descriptor instead.
 func (*AddLogResponse) Descriptor() ([]byte, []int) {
-	return file_engine_proto_rawDescGZIP(), []int{13}
+	return file_engine_proto_rawDescGZIP(), []int{15}
 }
 
 type CancelRunRequest struct {
@@ -1046,7 +1262,7 @@ type CancelRunRequest struct {
 
 func (x *CancelRunRequest) Reset() {
 	*x = CancelRunRequest{}
-	mi := &file_engine_proto_msgTypes[14]
+	mi := &file_engine_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1058,7 +1274,7 @@ func (x *CancelRunRequest) String() string {
 func (*CancelRunRequest) ProtoMessage() {}
 
 func (x *CancelRunRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_proto_msgTypes[14]
+	mi := &file_engine_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1071,7 +1287,7 @@ func (x *CancelRunRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CancelRunRequest.ProtoReflect.Descriptor instead.
 func (*CancelRunRequest) Descriptor() ([]byte, []int) {
-	return file_engine_proto_rawDescGZIP(), []int{14}
+	return file_engine_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *CancelRunRequest) GetRunId() string {
@@ -1091,7 +1307,7 @@ type CancelRunResponse struct {
 
 func (x *CancelRunResponse) Reset() {
 	*x = CancelRunResponse{}
-	mi := &file_engine_proto_msgTypes[15]
+	mi := &file_engine_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1103,7 +1319,7 @@ func (x *CancelRunResponse) String() string {
 func (*CancelRunResponse) ProtoMessage() {}
 
 func (x *CancelRunResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_proto_msgTypes[15]
+	mi := &file_engine_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1116,7 +1332,7 @@ func (x *CancelRunResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CancelRunResponse.ProtoReflect.Descriptor instead.
 func (*CancelRunResponse) Descriptor() ([]byte, []int) {
-	return file_engine_proto_rawDescGZIP(), []int{15}
+	return file_engine_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *CancelRunResponse) GetSuccess() bool {
@@ -1133,6 +1349,360 @@ func (x *CancelRunResponse) GetMessage() string {
 	return ""
 }
 
+type PruneRunsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OlderThan     string                 `protobuf:"bytes,1,opt,name=older_than,json=olderThan,proto3" json:"older_than,omitempty"` // Go duration (e.g. "720h"); only runs created before now - older_than are pruned
+	KeepLast      int32                  `protobuf:"varint,2,opt,name=keep_last,json=keepLast,proto3" json:"keep_last,omitempty"`   // Always keep the newest N runs; 0 disables the count limit
+	ProjectId     string                 `protobuf:"bytes,3,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"` // Restrict pruning to one project
+	DryRun        bool                   `protobuf:"varint,4,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`         // Report matching runs without deleting them
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PruneRunsRequest) Reset() {
+	*x = PruneRunsRequest{}
+	mi := &file_engine_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PruneRunsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PruneRunsRequest) ProtoMessage() {}
+
+func (x *PruneRunsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PruneRunsRequest.ProtoReflect.Descriptor instead.
+func (*PruneRunsRequest) Descriptor() ([]byte, []int) {
+	return file_engine_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *PruneRunsRequest) GetOlderThan() string {
+	if x != nil {
+		return x.OlderThan
+	}
+	return ""
+}
+
+func (x *PruneRunsRequest) GetKeepLast() int32 {
+	if x != nil {
+		return x.KeepLast
+	}
+	return 0
+}
+
+func (x *PruneRunsRequest) GetProjectId() string {
+	if x != nil {
+		return x.ProjectId
+	}
+	return ""
+}
+
+func (x *PruneRunsRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+type PruneRunsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RunIds        []string               `protobuf:"bytes,1,rep,name=run_ids,json=runIds,proto3" json:"run_ids,omitempty"`
+	PrunedCount   int32                  `protobuf:"varint,2,opt,name=pruned_count,json=prunedCount,proto3" json:"pruned_count,omitempty"`
+	DryRun        bool                   `protobuf:"varint,3,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PruneRunsResponse) Reset() {
+	*x = PruneRunsResponse{}
+	mi := &file_engine_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PruneRunsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PruneRunsResponse) ProtoMessage() {}
+
+func (x *PruneRunsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PruneRunsResponse.ProtoReflect.Descriptor instead.
+func (*PruneRunsResponse) Descriptor() ([]byte, []int) {
+	return file_engine_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *PruneRunsResponse) GetRunIds() []string {
+	if x != nil {
+		return x.RunIds
+	}
+	return nil
+}
+
+func (x *PruneRunsResponse) GetPrunedCount() int32 {
+	if x != nil {
+		return x.PrunedCount
+	}
+	return 0
+}
+
+func (x *PruneRunsResponse) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+// Preview environments are per-PR environments named pr-<number>. They are deleted
+// when the PR closes, and the runs that used them are archived.
+type CreatePreviewEnvironmentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProjectId     string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	PrNumber      int32                  `protobuf:"varint,2,opt,name=pr_number,json=prNumber,proto3" json:"pr_number,omitempty"`
+	Fallback      string                 `protobuf:"bytes,3,opt,name=fallback,proto3" json:"fallback,omitempty"` // Environment the preview inherits secrets and variables from, e.g. "staging"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreatePreviewEnvironmentRequest) Reset() {
+	*x = CreatePreviewEnvironmentRequest{}
+	mi := &file_engine_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreatePreviewEnvironmentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreatePreviewEnvironmentRequest) ProtoMessage() {}
+
+func (x *CreatePreviewEnvironmentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreatePreviewEnvironmentRequest.ProtoReflect.Descriptor instead.
+func (*CreatePreviewEnvironmentRequest) Descriptor() ([]byte, []int) {
+	return file_engine_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *CreatePreviewEnvironmentRequest) GetProjectId() string {
+	if x != nil {
+		return x.ProjectId
+	}
+	return ""
+}
+
+func (x *CreatePreviewEnvironmentRequest) GetPrNumber() int32 {
+	if x != nil {
+		return x.PrNumber
+	}
+	return 0
+}
+
+func (x *CreatePreviewEnvironmentRequest) GetFallback() string {
+	if x != nil {
+		return x.Fallback
+	}
+	return ""
+}
+
+type CreatePreviewEnvironmentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EnvironmentId string                 `protobuf:"bytes,1,opt,name=environment_id,json=environmentId,proto3" json:"environment_id,omitempty"`
+	Slug          string                 `protobuf:"bytes,2,opt,name=slug,proto3" json:"slug,omitempty"`        // Pass to `rocketship run --env`
+	Created       bool                   `protobuf:"varint,3,opt,name=created,proto3" json:"created,omitempty"` // False when the preview environment already existed
+	Fallback      string                 `protobuf:"bytes,4,opt,name=fallback,proto3" json:"fallback,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreatePreviewEnvironmentResponse) Reset() {
+	*x = CreatePreviewEnvironmentResponse{}
+	mi := &file_engine_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreatePreviewEnvironmentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreatePreviewEnvironmentResponse) ProtoMessage() {}
+
+func (x *CreatePreviewEnvironmentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreatePreviewEnvironmentResponse.ProtoReflect.Descriptor instead.
+func (*CreatePreviewEnvironmentResponse) Descriptor() ([]byte, []int) {
+	return file_engine_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *CreatePreviewEnvironmentResponse) GetEnvironmentId() string {
+	if x != nil {
+		return x.EnvironmentId
+	}
+	return ""
+}
+
+func (x *CreatePreviewEnvironmentResponse) GetSlug() string {
+	if x != nil {
+		return x.Slug
+	}
+	return ""
+}
+
+func (x *CreatePreviewEnvironmentResponse) GetCreated() bool {
+	if x != nil {
+		return x.Created
+	}
+	return false
+}
+
+func (x *CreatePreviewEnvironmentResponse) GetFallback() string {
+	if x != nil {
+		return x.Fallback
+	}
+	return ""
+}
+
+type DeletePreviewEnvironmentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProjectId     string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	PrNumber      int32                  `protobuf:"varint,2,opt,name=pr_number,json=prNumber,proto3" json:"pr_number,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeletePreviewEnvironmentRequest) Reset() {
+	*x = DeletePreviewEnvironmentRequest{}
+	mi := &file_engine_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeletePreviewEnvironmentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeletePreviewEnvironmentRequest) ProtoMessage() {}
+
+func (x *DeletePreviewEnvironmentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeletePreviewEnvironmentRequest.ProtoReflect.Descriptor instead.
+func (*DeletePreviewEnvironmentRequest) Descriptor() ([]byte, []int) {
+	return file_engine_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *DeletePreviewEnvironmentRequest) GetProjectId() string {
+	if x != nil {
+		return x.ProjectId
+	}
+	return ""
+}
+
+func (x *DeletePreviewEnvironmentRequest) GetPrNumber() int32 {
+	if x != nil {
+		return x.PrNumber
+	}
+	return 0
+}
+
+type DeletePreviewEnvironmentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ArchivedRuns  int32                  `protobuf:"varint,1,opt,name=archived_runs,json=archivedRuns,proto3" json:"archived_runs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeletePreviewEnvironmentResponse) Reset() {
+	*x = DeletePreviewEnvironmentResponse{}
+	mi := &file_engine_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeletePreviewEnvironmentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeletePreviewEnvironmentResponse) ProtoMessage() {}
+
+func (x *DeletePreviewEnvironmentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeletePreviewEnvironmentResponse.ProtoReflect.Descriptor instead.
+func (*DeletePreviewEnvironmentResponse) Descriptor() ([]byte, []int) {
+	return file_engine_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *DeletePreviewEnvironmentResponse) GetArchivedRuns() int32 {
+	if x != nil {
+		return x.ArchivedRuns
+	}
+	return 0
+}
+
 type HealthRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
@@ -1141,7 +1711,7 @@ type HealthRequest struct {
 
 func (x *HealthRequest) Reset() {
 	*x = HealthRequest{}
-	mi := &file_engine_proto_msgTypes[16]
+	mi := &file_engine_proto_msgTypes[24]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1153,7 +1723,7 @@ func (x *HealthRequest) String() string {
 func (*HealthRequest) ProtoMessage() {}
 
 func (x *HealthRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_proto_msgTypes[16]
+	mi := &file_engine_proto_msgTypes[24]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1166,7 +1736,7 @@ func (x *HealthRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HealthRequest.ProtoReflect.Descriptor instead.
 func (*HealthRequest) Descriptor() ([]byte, []int) {
-	return file_engine_proto_rawDescGZIP(), []int{16}
+	return file_engine_proto_rawDescGZIP(), []int{24}
 }
 
 type HealthResponse struct {
@@ -1178,7 +1748,7 @@ type HealthResponse struct {
 
 func (x *HealthResponse) Reset() {
 	*x = HealthResponse{}
-	mi := &file_engine_proto_msgTypes[17]
+	mi := &file_engine_proto_msgTypes[25]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1190,7 +1760,7 @@ func (x *HealthResponse) String() string {
 func (*HealthResponse) ProtoMessage() {}
 
 func (x *HealthResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_proto_msgTypes[17]
+	mi := &file_engine_proto_msgTypes[25]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1203,7 +1773,7 @@ func (x *HealthResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HealthResponse.ProtoReflect.Descriptor instead.
 func (*HealthResponse) Descriptor() ([]byte, []int) {
-	return file_engine_proto_rawDescGZIP(), []int{17}
+	return file_engine_proto_rawDescGZIP(), []int{25}
 }
 
 func (x *HealthResponse) GetStatus() string {
@@ -1222,7 +1792,7 @@ type GetServerInfoRequest struct {
 
 func (x *GetServerInfoRequest) Reset() {
 	*x = GetServerInfoRequest{}
-	mi := &file_engine_proto_msgTypes[18]
+	mi := &file_engine_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1234,7 +1804,7 @@ func (x *GetServerInfoRequest) String() string {
 func (*GetServerInfoRequest) ProtoMessage() {}
 
 func (x *GetServerInfoRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_proto_msgTypes[18]
+	mi := &file_engine_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1247,7 +1817,7 @@ func (x *GetServerInfoRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetServerInfoRequest.ProtoReflect.Descriptor instead.
 func (*GetServerInfoRequest) Descriptor() ([]byte, []int) {
-	return file_engine_proto_rawDescGZIP(), []int{18}
+	return file_engine_proto_rawDescGZIP(), []int{26}
 }
 
 type ServerEndpoint struct {
@@ -1260,7 +1830,7 @@ type ServerEndpoint struct {
 
 func (x *ServerEndpoint) Reset() {
 	*x = ServerEndpoint{}
-	mi := &file_engine_proto_msgTypes[19]
+	mi := &file_engine_proto_msgTypes[27]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1272,7 +1842,7 @@ func (x *ServerEndpoint) String() string {
 func (*ServerEndpoint) ProtoMessage() {}
 
 func (x *ServerEndpoint) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_proto_msgTypes[19]
+	mi := &file_engine_proto_msgTypes[27]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1285,7 +1855,7 @@ func (x *ServerEndpoint) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ServerEndpoint.ProtoReflect.Descriptor instead.
 func (*ServerEndpoint) Descriptor() ([]byte, []int) {
-	return file_engine_proto_rawDescGZIP(), []int{19}
+	return file_engine_proto_rawDescGZIP(), []int{27}
 }
 
 func (x *ServerEndpoint) GetType() string {
@@ -1322,7 +1892,7 @@ type GetServerInfoResponse struct {
 
 func (x *GetServerInfoResponse) Reset() {
 	*x = GetServerInfoResponse{}
-	mi := &file_engine_proto_msgTypes[20]
+	mi := &file_engine_proto_msgTypes[28]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1334,7 +1904,7 @@ func (x *GetServerInfoResponse) String() string {
 func (*GetServerInfoResponse) ProtoMessage() {}
 
 func (x *GetServerInfoResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_proto_msgTypes[20]
+	mi := &file_engine_proto_msgTypes[28]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1347,7 +1917,7 @@ func (x *GetServerInfoResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetServerInfoResponse.ProtoReflect.Descriptor instead.
 func (*GetServerInfoResponse) Descriptor() ([]byte, []int) {
-	return file_engine_proto_rawDescGZIP(), []int{20}
+	return file_engine_proto_rawDescGZIP(), []int{28}
 }
 
 func (x *GetServerInfoResponse) GetVersion() string {
@@ -1443,7 +2013,7 @@ type WaitForCleanupRequest struct {
 
 func (x *WaitForCleanupRequest) Reset() {
 	*x = WaitForCleanupRequest{}
-	mi := &file_engine_proto_msgTypes[21]
+	mi := &file_engine_proto_msgTypes[29]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1455,7 +2025,7 @@ func (x *WaitForCleanupRequest) String() string {
 func (*WaitForCleanupRequest) ProtoMessage() {}
 
 func (x *WaitForCleanupRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_proto_msgTypes[21]
+	mi := &file_engine_proto_msgTypes[29]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1468,7 +2038,7 @@ func (x *WaitForCleanupRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WaitForCleanupRequest.ProtoReflect.Descriptor instead.
 func (*WaitForCleanupRequest) Descriptor() ([]byte, []int) {
-	return file_engine_proto_rawDescGZIP(), []int{21}
+	return file_engine_proto_rawDescGZIP(), []int{29}
 }
 
 func (x *WaitForCleanupRequest) GetTimeoutSeconds() int32 {
@@ -1487,7 +2057,7 @@ type WaitForCleanupResponse struct {
 
 func (x *WaitForCleanupResponse) Reset() {
 	*x = WaitForCleanupResponse{}
-	mi := &file_engine_proto_msgTypes[22]
+	mi := &file_engine_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1499,7 +2069,7 @@ func (x *WaitForCleanupResponse) String() string {
 func (*WaitForCleanupResponse) ProtoMessage() {}
 
 func (x *WaitForCleanupResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_proto_msgTypes[22]
+	mi := &file_engine_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1512,7 +2082,7 @@ func (x *WaitForCleanupResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WaitForCleanupResponse.ProtoReflect.Descriptor instead.
 func (*WaitForCleanupResponse) Descriptor() ([]byte, []int) {
-	return file_engine_proto_rawDescGZIP(), []int{22}
+	return file_engine_proto_rawDescGZIP(), []int{30}
 }
 
 func (x *WaitForCleanupResponse) GetCompleted() bool {
@@ -1542,13 +2112,15 @@ type UpsertRunStepRequest struct {
 	AssertionsJson   []byte                 `protobuf:"bytes,15,opt,name=assertions_json,json=assertionsJson,proto3" json:"assertions_json,omitempty"`   // JSON-encoded array of assertion results
 	VariablesJson    []byte                 `protobuf:"bytes,16,opt,name=variables_json,json=variablesJson,proto3" json:"variables_json,omitempty"`      // JSON-encoded array of saved variables
 	StepConfigJson   []byte                 `protobuf:"bytes,17,opt,name=step_config_json,json=stepConfigJson,proto3" json:"step_config_json,omitempty"` // JSON-encoded step configuration snapshot
+	WorkerVersion    string                 `protobuf:"bytes,18,opt,name=worker_version,json=workerVersion,proto3" json:"worker_version,omitempty"`      // Version of the worker that executed the plugin activity
+	ArtifactsJson    []byte                 `protobuf:"bytes,19,opt,name=artifacts_json,json=artifactsJson,proto3" json:"artifacts_json,omitempty"`      // JSON-encoded array of artifacts the step saved on the worker
 	unknownFields    protoimpl.UnknownFields
 	sizeCache        protoimpl.SizeCache
 }
 
 func (x *UpsertRunStepRequest) Reset() {
 	*x = UpsertRunStepRequest{}
-	mi := &file_engine_proto_msgTypes[23]
+	mi := &file_engine_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1560,7 +2132,7 @@ func (x *UpsertRunStepRequest) String() string {
 func (*UpsertRunStepRequest) ProtoMessage() {}
 
 func (x *UpsertRunStepRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_proto_msgTypes[23]
+	mi := &file_engine_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1573,7 +2145,7 @@ func (x *UpsertRunStepRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpsertRunStepRequest.ProtoReflect.Descriptor instead.
 func (*UpsertRunStepRequest) Descriptor() ([]byte, []int) {
-	return file_engine_proto_rawDescGZIP(), []int{23}
+	return file_engine_proto_rawDescGZIP(), []int{31}
 }
 
 func (x *UpsertRunStepRequest) GetRunId() string {
@@ -1695,6 +2267,20 @@ func (x *UpsertRunStepRequest) GetStepConfigJson() []byte {
 	return nil
 }
 
+func (x *UpsertRunStepRequest) GetWorkerVersion() string {
+	if x != nil {
+		return x.WorkerVersion
+	}
+	return ""
+}
+
+func (x *UpsertRunStepRequest) GetArtifactsJson() []byte {
+	if x != nil {
+		return x.ArtifactsJson
+	}
+	return nil
+}
+
 type UpsertRunStepResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	StepId        string                 `protobuf:"bytes,1,opt,name=step_id,json=stepId,proto3" json:"step_id,omitempty"` // The created/updated step ID
@@ -1704,7 +2290,7 @@ type UpsertRunStepResponse struct {
 
 func (x *UpsertRunStepResponse) Reset() {
 	*x = UpsertRunStepResponse{}
-	mi := &file_engine_proto_msgTypes[24]
+	mi := &file_engine_proto_msgTypes[32]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1716,7 +2302,7 @@ func (x *UpsertRunStepResponse) String() string {
 func (*UpsertRunStepResponse) ProtoMessage() {}
 
 func (x *UpsertRunStepResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_proto_msgTypes[24]
+	mi := &file_engine_proto_msgTypes[32]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1729,7 +2315,7 @@ func (x *UpsertRunStepResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpsertRunStepResponse.ProtoReflect.Descriptor instead.
 func (*UpsertRunStepResponse) Descriptor() ([]byte, []int) {
-	return file_engine_proto_rawDescGZIP(), []int{24}
+	return file_engine_proto_rawDescGZIP(), []int{32}
 }
 
 func (x *UpsertRunStepResponse) GetStepId() string {
@@ -1764,14 +2350,19 @@ const file_engine_proto_rawDesc = "" +
 	"\x11CreateRunResponse\x12\x15\n" +
 	"\x06run_id\x18\x01 \x01(\tR\x05runId\")\n" +
 	"\x10LogStreamRequest\x12\x15\n" +
-	"\x06run_id\x18\x01 \x01(\tR\x05runId\"\x8f\x01\n" +
+	"\x06run_id\x18\x01 \x01(\tR\x05runId\"\x9c\x02\n" +
 	"\aLogLine\x12\x0e\n" +
 	"\x02ts\x18\x01 \x01(\tR\x02ts\x12\x10\n" +
 	"\x03msg\x18\x02 \x01(\tR\x03msg\x12\x14\n" +
 	"\x05color\x18\x03 \x01(\tR\x05color\x12\x12\n" +
 	"\x04bold\x18\x04 \x01(\bR\x04bold\x12\x1b\n" +
 	"\ttest_name\x18\x05 \x01(\tR\btestName\x12\x1b\n" +
-	"\tstep_name\x18\x06 \x01(\tR\bstepName\"\x86\x02\n" +
+	"\tstep_name\x18\x06 \x01(\tR\bstepName\x12\x14\n" +
+	"\x05level\x18\a \x01(\tR\x05level\x12:\n" +
+	"\x06fields\x18\b \x03(\v2\".rocketship.v1.LogLine.FieldsEntryR\x06fields\x1a9\n" +
+	"\vFieldsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xb9\x02\n" +
 	"\x0fListRunsRequest\x12\x1d\n" +
 	"\n" +
 	"project_id\x18\x01 \x01(\tR\tprojectId\x12\x16\n" +
@@ -1784,13 +2375,17 @@ const file_engine_proto_rawDesc = "" +
 	"\border_by\x18\b \x01(\tR\aorderBy\x12\x1e\n" +
 	"\n" +
 	"descending\x18\t \x01(\bR\n" +
-	"descending\"\x83\x01\n" +
+	"descending\x12\x1d\n" +
+	"\n" +
+	"suite_name\x18\n" +
+	" \x01(\tR\tsuiteName\x12\x12\n" +
+	"\x04tags\x18\v \x01(\tR\x04tags\"\x83\x01\n" +
 	"\x10ListRunsResponse\x12-\n" +
 	"\x04runs\x18\x01 \x03(\v2\x19.rocketship.v1.RunSummaryR\x04runs\x12\x1f\n" +
 	"\vnext_cursor\x18\x02 \x01(\tR\n" +
 	"nextCursor\x12\x1f\n" +
 	"\vtotal_count\x18\x03 \x01(\x05R\n" +
-	"totalCount\"\xf6\x02\n" +
+	"totalCount\"\x8a\x03\n" +
 	"\n" +
 	"RunSummary\x12\x15\n" +
 	"\x06run_id\x18\x01 \x01(\tR\x05runId\x12\x1d\n" +
@@ -1808,11 +2403,12 @@ const file_engine_proto_rawDesc = "" +
 	"\ffailed_tests\x18\t \x01(\x05R\vfailedTests\x12#\n" +
 	"\rtimeout_tests\x18\n" +
 	" \x01(\x05R\ftimeoutTests\x123\n" +
-	"\acontext\x18\v \x01(\v2\x19.rocketship.v1.RunContextR\acontext\"&\n" +
+	"\acontext\x18\v \x01(\v2\x19.rocketship.v1.RunContextR\acontext\x12\x12\n" +
+	"\x04tags\x18\f \x03(\tR\x04tags\"&\n" +
 	"\rGetRunRequest\x12\x15\n" +
 	"\x06run_id\x18\x01 \x01(\tR\x05runId\"=\n" +
 	"\x0eGetRunResponse\x12+\n" +
-	"\x03run\x18\x01 \x01(\v2\x19.rocketship.v1.RunDetailsR\x03run\"\x9c\x02\n" +
+	"\x03run\x18\x01 \x01(\v2\x19.rocketship.v1.RunDetailsR\x03run\"\x98\x03\n" +
 	"\n" +
 	"RunDetails\x12\x15\n" +
 	"\x06run_id\x18\x01 \x01(\tR\x05runId\x12\x1d\n" +
@@ -1825,7 +2421,21 @@ const file_engine_proto_rawDesc = "" +
 	"\vduration_ms\x18\x06 \x01(\x03R\n" +
 	"durationMs\x123\n" +
 	"\acontext\x18\a \x01(\v2\x19.rocketship.v1.RunContextR\acontext\x120\n" +
-	"\x05tests\x18\b \x03(\v2\x1a.rocketship.v1.TestDetailsR\x05tests\"\xd2\x01\n" +
+	"\x05tests\x18\b \x03(\v2\x1a.rocketship.v1.TestDetailsR\x05tests\x126\n" +
+	"\bprogress\x18\t \x01(\v2\x1a.rocketship.v1.RunProgressR\bprogress\x12B\n" +
+	"\fcancellation\x18\n" +
+	" \x01(\v2\x1e.rocketship.v1.RunCancellationR\fcancellation\"\xbe\x01\n" +
+	"\x0fRunCancellation\x12\x16\n" +
+	"\x06reason\x18\x01 \x01(\tR\x06reason\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12'\n" +
+	"\x0fcompleted_tests\x18\x03 \x01(\x05R\x0ecompletedTests\x12+\n" +
+	"\x11interrupted_tests\x18\x04 \x01(\x05R\x10interruptedTests\x12#\n" +
+	"\rskipped_tests\x18\x05 \x01(\x05R\fskippedTests\"\x8c\x01\n" +
+	"\vRunProgress\x12'\n" +
+	"\x0fcompleted_steps\x18\x01 \x01(\x05R\x0ecompletedSteps\x12#\n" +
+	"\rplanned_steps\x18\x02 \x01(\x05R\fplannedSteps\x12\x18\n" +
+	"\apercent\x18\x03 \x01(\x05R\apercent\x12\x15\n" +
+	"\x06eta_ms\x18\x04 \x01(\x03R\x05etaMs\"\xd2\x01\n" +
 	"\vTestDetails\x12\x17\n" +
 	"\atest_id\x18\x01 \x01(\tR\x06testId\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12\x16\n" +
@@ -1835,7 +2445,7 @@ const file_engine_proto_rawDesc = "" +
 	"\bended_at\x18\x05 \x01(\tR\aendedAt\x12\x1f\n" +
 	"\vduration_ms\x18\x06 \x01(\x03R\n" +
 	"durationMs\x12#\n" +
-	"\rerror_message\x18\a \x01(\tR\ferrorMessage\"\xc5\x01\n" +
+	"\rerror_message\x18\a \x01(\tR\ferrorMessage\"\xd8\x02\n" +
 	"\rAddLogRequest\x12\x15\n" +
 	"\x06run_id\x18\x01 \x01(\tR\x05runId\x12\x1f\n" +
 	"\vworkflow_id\x18\x02 \x01(\tR\n" +
@@ -1844,13 +2454,45 @@ const file_engine_proto_rawDesc = "" +
 	"\x05color\x18\x04 \x01(\tR\x05color\x12\x12\n" +
 	"\x04bold\x18\x05 \x01(\bR\x04bold\x12\x1b\n" +
 	"\ttest_name\x18\x06 \x01(\tR\btestName\x12\x1b\n" +
-	"\tstep_name\x18\a \x01(\tR\bstepName\"\x10\n" +
+	"\tstep_name\x18\a \x01(\tR\bstepName\x12\x14\n" +
+	"\x05level\x18\b \x01(\tR\x05level\x12@\n" +
+	"\x06fields\x18\t \x03(\v2(.rocketship.v1.AddLogRequest.FieldsEntryR\x06fields\x1a9\n" +
+	"\vFieldsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x10\n" +
 	"\x0eAddLogResponse\")\n" +
 	"\x10CancelRunRequest\x12\x15\n" +
 	"\x06run_id\x18\x01 \x01(\tR\x05runId\"G\n" +
 	"\x11CancelRunResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage\"\x0f\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"\x86\x01\n" +
+	"\x10PruneRunsRequest\x12\x1d\n" +
+	"\n" +
+	"older_than\x18\x01 \x01(\tR\tolderThan\x12\x1b\n" +
+	"\tkeep_last\x18\x02 \x01(\x05R\bkeepLast\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x03 \x01(\tR\tprojectId\x12\x17\n" +
+	"\adry_run\x18\x04 \x01(\bR\x06dryRun\"h\n" +
+	"\x11PruneRunsResponse\x12\x17\n" +
+	"\arun_ids\x18\x01 \x03(\tR\x06runIds\x12!\n" +
+	"\fpruned_count\x18\x02 \x01(\x05R\vprunedCount\x12\x17\n" +
+	"\adry_run\x18\x03 \x01(\bR\x06dryRun\"y\n" +
+	"\x1fCreatePreviewEnvironmentRequest\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x01 \x01(\tR\tprojectId\x12\x1b\n" +
+	"\tpr_number\x18\x02 \x01(\x05R\bprNumber\x12\x1a\n" +
+	"\bfallback\x18\x03 \x01(\tR\bfallback\"\x93\x01\n" +
+	" CreatePreviewEnvironmentResponse\x12%\n" +
+	"\x0eenvironment_id\x18\x01 \x01(\tR\renvironmentId\x12\x12\n" +
+	"\x04slug\x18\x02 \x01(\tR\x04slug\x12\x18\n" +
+	"\acreated\x18\x03 \x01(\bR\acreated\x12\x1a\n" +
+	"\bfallback\x18\x04 \x01(\tR\bfallback\"]\n" +
+	"\x1fDeletePreviewEnvironmentRequest\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x01 \x01(\tR\tprojectId\x12\x1b\n" +
+	"\tpr_number\x18\x02 \x01(\x05R\bprNumber\"G\n" +
+	" DeletePreviewEnvironmentResponse\x12#\n" +
+	"\rarchived_runs\x18\x01 \x01(\x05R\farchivedRuns\"\x0f\n" +
 	"\rHealthRequest\"(\n" +
 	"\x0eHealthResponse\x12\x16\n" +
 	"\x06status\x18\x01 \x01(\tR\x06status\"\x16\n" +
@@ -1875,7 +2517,7 @@ const file_engine_proto_rawDesc = "" +
 	"\x15WaitForCleanupRequest\x12'\n" +
 	"\x0ftimeout_seconds\x18\x01 \x01(\x05R\x0etimeoutSeconds\"6\n" +
 	"\x16WaitForCleanupResponse\x12\x1c\n" +
-	"\tcompleted\x18\x01 \x01(\bR\tcompleted\"\xd6\x04\n" +
+	"\tcompleted\x18\x01 \x01(\bR\tcompleted\"\xa4\x05\n" +
 	"\x14UpsertRunStepRequest\x12\x15\n" +
 	"\x06run_id\x18\x01 \x01(\tR\x05runId\x12\x1f\n" +
 	"\vworkflow_id\x18\x02 \x01(\tR\n" +
@@ -1898,9 +2540,11 @@ const file_engine_proto_rawDesc = "" +
 	"\rresponse_json\x18\x0e \x01(\fR\fresponseJson\x12'\n" +
 	"\x0fassertions_json\x18\x0f \x01(\fR\x0eassertionsJson\x12%\n" +
 	"\x0evariables_json\x18\x10 \x01(\fR\rvariablesJson\x12(\n" +
-	"\x10step_config_json\x18\x11 \x01(\fR\x0estepConfigJson\"0\n" +
+	"\x10step_config_json\x18\x11 \x01(\fR\x0estepConfigJson\x12%\n" +
+	"\x0eworker_version\x18\x12 \x01(\tR\rworkerVersion\x12%\n" +
+	"\x0eartifacts_json\x18\x13 \x01(\fR\rartifactsJson\"0\n" +
 	"\x15UpsertRunStepResponse\x12\x17\n" +
-	"\astep_id\x18\x01 \x01(\tR\x06stepId2\xaa\x06\n" +
+	"\astep_id\x18\x01 \x01(\tR\x06stepId2\xf4\b\n" +
 	"\x06Engine\x12N\n" +
 	"\tCreateRun\x12\x1f.rocketship.v1.CreateRunRequest\x1a .rocketship.v1.CreateRunResponse\x12G\n" +
 	"\n" +
@@ -1908,7 +2552,10 @@ const file_engine_proto_rawDesc = "" +
 	"\x06AddLog\x12\x1c.rocketship.v1.AddLogRequest\x1a\x1d.rocketship.v1.AddLogResponse\x12K\n" +
 	"\bListRuns\x12\x1e.rocketship.v1.ListRunsRequest\x1a\x1f.rocketship.v1.ListRunsResponse\x12E\n" +
 	"\x06GetRun\x12\x1c.rocketship.v1.GetRunRequest\x1a\x1d.rocketship.v1.GetRunResponse\x12N\n" +
-	"\tCancelRun\x12\x1f.rocketship.v1.CancelRunRequest\x1a .rocketship.v1.CancelRunResponse\x12E\n" +
+	"\tCancelRun\x12\x1f.rocketship.v1.CancelRunRequest\x1a .rocketship.v1.CancelRunResponse\x12N\n" +
+	"\tPruneRuns\x12\x1f.rocketship.v1.PruneRunsRequest\x1a .rocketship.v1.PruneRunsResponse\x12{\n" +
+	"\x18CreatePreviewEnvironment\x12..rocketship.v1.CreatePreviewEnvironmentRequest\x1a/.rocketship.v1.CreatePreviewEnvironmentResponse\x12{\n" +
+	"\x18DeletePreviewEnvironment\x12..rocketship.v1.DeletePreviewEnvironmentRequest\x1a/.rocketship.v1.DeletePreviewEnvironmentResponse\x12E\n" +
 	"\x06Health\x12\x1c.rocketship.v1.HealthRequest\x1a\x1d.rocketship.v1.HealthResponse\x12]\n" +
 	"\x0eWaitForCleanup\x12$.rocketship.v1.WaitForCleanupRequest\x1a%.rocketship.v1.WaitForCleanupResponse\x12Z\n" +
 	"\rUpsertRunStep\x12#.rocketship.v1.UpsertRunStepRequest\x1a$.rocketship.v1.UpsertRunStepResponse\x12Z\n" +
@@ -1926,69 +2573,89 @@ func file_engine_proto_rawDescGZIP() []byte {
 	return file_engine_proto_rawDescData
 }
 
-var file_engine_proto_msgTypes = make([]protoimpl.MessageInfo, 26)
+var file_engine_proto_msgTypes = make([]protoimpl.MessageInfo, 36)
 var file_engine_proto_goTypes = []any{
-	(*CreateRunRequest)(nil),       // 0: rocketship.v1.CreateRunRequest
-	(*RunContext)(nil),             // 1: rocketship.v1.RunContext
-	(*CreateRunResponse)(nil),      // 2: rocketship.v1.CreateRunResponse
-	(*LogStreamRequest)(nil),       // 3: rocketship.v1.LogStreamRequest
-	(*LogLine)(nil),                // 4: rocketship.v1.LogLine
-	(*ListRunsRequest)(nil),        // 5: rocketship.v1.ListRunsRequest
-	(*ListRunsResponse)(nil),       // 6: rocketship.v1.ListRunsResponse
-	(*RunSummary)(nil),             // 7: rocketship.v1.RunSummary
-	(*GetRunRequest)(nil),          // 8: rocketship.v1.GetRunRequest
-	(*GetRunResponse)(nil),         // 9: rocketship.v1.GetRunResponse
-	(*RunDetails)(nil),             // 10: rocketship.v1.RunDetails
-	(*TestDetails)(nil),            // 11: rocketship.v1.TestDetails
-	(*AddLogRequest)(nil),          // 12: rocketship.v1.AddLogRequest
-	(*AddLogResponse)(nil),         // 13: rocketship.v1.AddLogResponse
-	(*CancelRunRequest)(nil),       // 14: rocketship.v1.CancelRunRequest
-	(*CancelRunResponse)(nil),      // 15: rocketship.v1.CancelRunResponse
-	(*HealthRequest)(nil),          // 16: rocketship.v1.HealthRequest
-	(*HealthResponse)(nil),         // 17: rocketship.v1.HealthResponse
-	(*GetServerInfoRequest)(nil),   // 18: rocketship.v1.GetServerInfoRequest
-	(*ServerEndpoint)(nil),         // 19: rocketship.v1.ServerEndpoint
-	(*GetServerInfoResponse)(nil),  // 20: rocketship.v1.GetServerInfoResponse
-	(*WaitForCleanupRequest)(nil),  // 21: rocketship.v1.WaitForCleanupRequest
-	(*WaitForCleanupResponse)(nil), // 22: rocketship.v1.WaitForCleanupResponse
-	(*UpsertRunStepRequest)(nil),   // 23: rocketship.v1.UpsertRunStepRequest
-	(*UpsertRunStepResponse)(nil),  // 24: rocketship.v1.UpsertRunStepResponse
-	nil,                            // 25: rocketship.v1.RunContext.MetadataEntry
+	(*CreateRunRequest)(nil),                 // 0: rocketship.v1.CreateRunRequest
+	(*RunContext)(nil),                       // 1: rocketship.v1.RunContext
+	(*CreateRunResponse)(nil),                // 2: rocketship.v1.CreateRunResponse
+	(*LogStreamRequest)(nil),                 // 3: rocketship.v1.LogStreamRequest
+	(*LogLine)(nil),                          // 4: rocketship.v1.LogLine
+	(*ListRunsRequest)(nil),                  // 5: rocketship.v1.ListRunsRequest
+	(*ListRunsResponse)(nil),                 // 6: rocketship.v1.ListRunsResponse
+	(*RunSummary)(nil),                       // 7: rocketship.v1.RunSummary
+	(*GetRunRequest)(nil),                    // 8: rocketship.v1.GetRunRequest
+	(*GetRunResponse)(nil),                   // 9: rocketship.v1.GetRunResponse
+	(*RunDetails)(nil),                       // 10: rocketship.v1.RunDetails
+	(*RunCancellation)(nil),                  // 11: rocketship.v1.RunCancellation
+	(*RunProgress)(nil),                      // 12: rocketship.v1.RunProgress
+	(*TestDetails)(nil),                      // 13: rocketship.v1.TestDetails
+	(*AddLogRequest)(nil),                    // 14: rocketship.v1.AddLogRequest
+	(*AddLogResponse)(nil),                   // 15: rocketship.v1.AddLogResponse
+	(*CancelRunRequest)(nil),                 // 16: rocketship.v1.CancelRunRequest
+	(*CancelRunResponse)(nil),                // 17: rocketship.v1.CancelRunResponse
+	(*PruneRunsRequest)(nil),                 // 18: rocketship.v1.PruneRunsRequest
+	(*PruneRunsResponse)(nil),                // 19: rocketship.v1.PruneRunsResponse
+	(*CreatePreviewEnvironmentRequest)(nil),  // 20: rocketship.v1.CreatePreviewEnvironmentRequest
+	(*CreatePreviewEnvironmentResponse)(nil), // 21: rocketship.v1.CreatePreviewEnvironmentResponse
+	(*DeletePreviewEnvironmentRequest)(nil),  // 22: rocketship.v1.DeletePreviewEnvironmentRequest
+	(*DeletePreviewEnvironmentResponse)(nil), // 23: rocketship.v1.DeletePreviewEnvironmentResponse
+	(*HealthRequest)(nil),                    // 24: rocketship.v1.HealthRequest
+	(*HealthResponse)(nil),                   // 25: rocketship.v1.HealthResponse
+	(*GetServerInfoRequest)(nil),             // 26: rocketship.v1.GetServerInfoRequest
+	(*ServerEndpoint)(nil),                   // 27: rocketship.v1.ServerEndpoint
+	(*GetServerInfoResponse)(nil),            // 28: rocketship.v1.GetServerInfoResponse
+	(*WaitForCleanupRequest)(nil),            // 29: rocketship.v1.WaitForCleanupRequest
+	(*WaitForCleanupResponse)(nil),           // 30: rocketship.v1.WaitForCleanupResponse
+	(*UpsertRunStepRequest)(nil),             // 31: rocketship.v1.UpsertRunStepRequest
+	(*UpsertRunStepResponse)(nil),            // 32: rocketship.v1.UpsertRunStepResponse
+	nil,                                      // 33: rocketship.v1.RunContext.MetadataEntry
+	nil,                                      // 34: rocketship.v1.LogLine.FieldsEntry
+	nil,                                      // 35: rocketship.v1.AddLogRequest.FieldsEntry
 }
 var file_engine_proto_depIdxs = []int32{
 	1,  // 0: rocketship.v1.CreateRunRequest.context:type_name -> rocketship.v1.RunContext
-	25, // 1: rocketship.v1.RunContext.metadata:type_name -> rocketship.v1.RunContext.MetadataEntry
-	7,  // 2: rocketship.v1.ListRunsResponse.runs:type_name -> rocketship.v1.RunSummary
-	1,  // 3: rocketship.v1.RunSummary.context:type_name -> rocketship.v1.RunContext
-	10, // 4: rocketship.v1.GetRunResponse.run:type_name -> rocketship.v1.RunDetails
-	1,  // 5: rocketship.v1.RunDetails.context:type_name -> rocketship.v1.RunContext
-	11, // 6: rocketship.v1.RunDetails.tests:type_name -> rocketship.v1.TestDetails
-	19, // 7: rocketship.v1.GetServerInfoResponse.endpoints:type_name -> rocketship.v1.ServerEndpoint
-	0,  // 8: rocketship.v1.Engine.CreateRun:input_type -> rocketship.v1.CreateRunRequest
-	3,  // 9: rocketship.v1.Engine.StreamLogs:input_type -> rocketship.v1.LogStreamRequest
-	12, // 10: rocketship.v1.Engine.AddLog:input_type -> rocketship.v1.AddLogRequest
-	5,  // 11: rocketship.v1.Engine.ListRuns:input_type -> rocketship.v1.ListRunsRequest
-	8,  // 12: rocketship.v1.Engine.GetRun:input_type -> rocketship.v1.GetRunRequest
-	14, // 13: rocketship.v1.Engine.CancelRun:input_type -> rocketship.v1.CancelRunRequest
-	16, // 14: rocketship.v1.Engine.Health:input_type -> rocketship.v1.HealthRequest
-	21, // 15: rocketship.v1.Engine.WaitForCleanup:input_type -> rocketship.v1.WaitForCleanupRequest
-	23, // 16: rocketship.v1.Engine.UpsertRunStep:input_type -> rocketship.v1.UpsertRunStepRequest
-	18, // 17: rocketship.v1.Engine.GetServerInfo:input_type -> rocketship.v1.GetServerInfoRequest
-	2,  // 18: rocketship.v1.Engine.CreateRun:output_type -> rocketship.v1.CreateRunResponse
-	4,  // 19: rocketship.v1.Engine.StreamLogs:output_type -> rocketship.v1.LogLine
-	13, // 20: rocketship.v1.Engine.AddLog:output_type -> rocketship.v1.AddLogResponse
-	6,  // 21: rocketship.v1.Engine.ListRuns:output_type -> rocketship.v1.ListRunsResponse
-	9,  // 22: rocketship.v1.Engine.GetRun:output_type -> rocketship.v1.GetRunResponse
-	15, // 23: rocketship.v1.Engine.CancelRun:output_type -> rocketship.v1.CancelRunResponse
-	17, // 24: rocketship.v1.Engine.Health:output_type -> rocketship.v1.HealthResponse
-	22, // 25: rocketship.v1.Engine.WaitForCleanup:output_type -> rocketship.v1.WaitForCleanupResponse
-	24, // 26: rocketship.v1.Engine.UpsertRunStep:output_type -> rocketship.v1.UpsertRunStepResponse
-	20, // 27: rocketship.v1.Engine.GetServerInfo:output_type -> rocketship.v1.GetServerInfoResponse
-	18, // [18:28] is the sub-list for method output_type
-	8,  // [8:18] is the sub-list for method input_type
-	8,  // [8:8] is the sub-list for extension type_name
-	8,  // [8:8] is the sub-list for extension extendee
-	0,  // [0:8] is the sub-list for field type_name
+	33, // 1: rocketship.v1.RunContext.metadata:type_name -> rocketship.v1.RunContext.MetadataEntry
+	34, // 2: rocketship.v1.LogLine.fields:type_name -> rocketship.v1.LogLine.FieldsEntry
+	7,  // 3: rocketship.v1.ListRunsResponse.runs:type_name -> rocketship.v1.RunSummary
+	1,  // 4: rocketship.v1.RunSummary.context:type_name -> rocketship.v1.RunContext
+	10, // 5: rocketship.v1.GetRunResponse.run:type_name -> rocketship.v1.RunDetails
+	1,  // 6: rocketship.v1.RunDetails.context:type_name -> rocketship.v1.RunContext
+	13, // 7: rocketship.v1.RunDetails.tests:type_name -> rocketship.v1.TestDetails
+	12, // 8: rocketship.v1.RunDetails.progress:type_name -> rocketship.v1.RunProgress
+	11, // 9: rocketship.v1.RunDetails.cancellation:type_name -> rocketship.v1.RunCancellation
+	35, // 10: rocketship.v1.AddLogRequest.fields:type_name -> rocketship.v1.AddLogRequest.FieldsEntry
+	27, // 11: rocketship.v1.GetServerInfoResponse.endpoints:type_name -> rocketship.v1.ServerEndpoint
+	0,  // 12: rocketship.v1.Engine.CreateRun:input_type -> rocketship.v1.CreateRunRequest
+	3,  // 13: rocketship.v1.Engine.StreamLogs:input_type -> rocketship.v1.LogStreamRequest
+	14, // 14: rocketship.v1.Engine.AddLog:input_type -> rocketship.v1.AddLogRequest
+	5,  // 15: rocketship.v1.Engine.ListRuns:input_type -> rocketship.v1.ListRunsRequest
+	8,  // 16: rocketship.v1.Engine.GetRun:input_type -> rocketship.v1.GetRunRequest
+	16, // 17: rocketship.v1.Engine.CancelRun:input_type -> rocketship.v1.CancelRunRequest
+	18, // 18: rocketship.v1.Engine.PruneRuns:input_type -> rocketship.v1.PruneRunsRequest
+	20, // 19: rocketship.v1.Engine.CreatePreviewEnvironment:input_type -> rocketship.v1.CreatePreviewEnvironmentRequest
+	22, // 20: rocketship.v1.Engine.DeletePreviewEnvironment:input_type -> rocketship.v1.DeletePreviewEnvironmentRequest
+	24, // 21: rocketship.v1.Engine.Health:input_type -> rocketship.v1.HealthRequest
+	29, // 22: rocketship.v1.Engine.WaitForCleanup:input_type -> rocketship.v1.WaitForCleanupRequest
+	31, // 23: rocketship.v1.Engine.UpsertRunStep:input_type -> rocketship.v1.UpsertRunStepRequest
+	26, // 24: rocketship.v1.Engine.GetServerInfo:input_type -> rocketship.v1.GetServerInfoRequest
+	2,  // 25: rocketship.v1.Engine.CreateRun:output_type -> rocketship.v1.CreateRunResponse
+	4,  // 26: rocketship.v1.Engine.StreamLogs:output_type -> rocketship.v1.LogLine
+	15, // 27: rocketship.v1.Engine.AddLog:output_type -> rocketship.v1.AddLogResponse
+	6,  // 28: rocketship.v1.Engine.ListRuns:output_type -> rocketship.v1.ListRunsResponse
+	9,  // 29: rocketship.v1.Engine.GetRun:output_type -> rocketship.v1.GetRunResponse
+	17, // 30: rocketship.v1.Engine.CancelRun:output_type -> rocketship.v1.CancelRunResponse
+	19, // 31: rocketship.v1.Engine.PruneRuns:output_type -> rocketship.v1.PruneRunsResponse
+	21, // 32: rocketship.v1.Engine.CreatePreviewEnvironment:output_type -> rocketship.v1.CreatePreviewEnvironmentResponse
+	23, // 33: rocketship.v1.Engine.DeletePreviewEnvironment:output_type -> rocketship.v1.DeletePreviewEnvironmentResponse
+	25, // 34: rocketship.v1.Engine.Health:output_type -> rocketship.v1.HealthResponse
+	30, // 35: rocketship.v1.Engine.WaitForCleanup:output_type -> rocketship.v1.WaitForCleanupResponse
+	32, // 36: rocketship.v1.Engine.UpsertRunStep:output_type -> rocketship.v1.UpsertRunStepResponse
+	28, // 37: rocketship.v1.Engine.GetServerInfo:output_type -> rocketship.v1.GetServerInfoResponse
+	25, // [25:38] is the sub-list for method output_type
+	12, // [12:25] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
 }
 
 func init() { file_engine_proto_init() }
@@ -2002,7 +2669,7 @@ func file_engine_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_engine_proto_rawDesc), len(file_engine_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   26,
+			NumMessages:   36,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,16 +19,19 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	Engine_CreateRun_FullMethodName      = "/rocketship.v1.Engine/CreateRun"
-	Engine_StreamLogs_FullMethodName     = "/rocketship.v1.Engine/StreamLogs"
-	Engine_AddLog_FullMethodName         = "/rocketship.v1.Engine/AddLog"
-	Engine_ListRuns_FullMethodName       = "/rocketship.v1.Engine/ListRuns"
-	Engine_GetRun_FullMethodName         = "/rocketship.v1.Engine/GetRun"
-	Engine_CancelRun_FullMethodName      = "/rocketship.v1.Engine/CancelRun"
-	Engine_Health_FullMethodName         = "/rocketship.v1.Engine/Health"
-	Engine_WaitForCleanup_FullMethodName = "/rocketship.v1.Engine/WaitForCleanup"
-	Engine_UpsertRunStep_FullMethodName  = "/rocketship.v1.Engine/UpsertRunStep"
-	Engine_GetServerInfo_FullMethodName  = "/rocketship.v1.Engine/GetServerInfo"
+	Engine_CreateRun_FullMethodName                = "/rocketship.v1.Engine/CreateRun"
+	Engine_StreamLogs_FullMethodName               = "/rocketship.v1.Engine/StreamLogs"
+	Engine_AddLog_FullMethodName                   = "/rocketship.v1.Engine/AddLog"
+	Engine_ListRuns_FullMethodName                 = "/rocketship.v1.Engine/ListRuns"
+	Engine_GetRun_FullMethodName                   = "/rocketship.v1.Engine/GetRun"
+	Engine_CancelRun_FullMethodName                = "/rocketship.v1.Engine/CancelRun"
+	Engine_PruneRuns_FullMethodName                = "/rocketship.v1.Engine/PruneRuns"
+	Engine_CreatePreviewEnvironment_FullMethodName = "/rocketship.v1.Engine/CreatePreviewEnvironment"
+	Engine_DeletePreviewEnvironment_FullMethodName = "/rocketship.v1.Engine/DeletePreviewEnvironment"
+	Engine_Health_FullMethodName                   = "/rocketship.v1.Engine/Health"
+	Engine_WaitForCleanup_FullMethodName           = "/rocketship.v1.Engine/WaitForCleanup"
+	Engine_UpsertRunStep_FullMethodName            = "/rocketship.v1.Engine/UpsertRunStep"
+	Engine_GetServerInfo_FullMethodName            = "/rocketship.v1.Engine/GetServerInfo"
 )
 
 // EngineClient is the client API for Engine service.
@@ -41,6 +44,9 @@ type EngineClient interface {
 	ListRuns(ctx context.Context, in *ListRunsRequest, opts ...grpc.CallOption) (*ListRunsResponse, error)
 	GetRun(ctx context.Context, in *GetRunRequest, opts ...grpc.CallOption) (*GetRunResponse, error)
 	CancelRun(ctx context.Context, in *CancelRunRequest, opts ...grpc.CallOption) (*CancelRunResponse, error)
+	PruneRuns(ctx context.Context, in *PruneRunsRequest, opts ...grpc.CallOption) (*PruneRunsResponse, error)
+	CreatePreviewEnvironment(ctx context.Context, in *CreatePreviewEnvironmentRequest, opts ...grpc.CallOption) (*CreatePreviewEnvironmentResponse, error)
+	DeletePreviewEnvironment(ctx context.Context, in *DeletePreviewEnvironmentRequest, opts ...grpc.CallOption) (*DeletePreviewEnvironmentResponse, error)
 	Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error)
 	WaitForCleanup(ctx context.Context, in *WaitForCleanupRequest, opts ...grpc.CallOption) (*WaitForCleanupResponse, error)
 	UpsertRunStep(ctx context.Context, in *UpsertRunStepRequest, opts ...grpc.CallOption) (*UpsertRunStepResponse, error)
@@ -125,6 +131,36 @@ func (c *engineClient) CancelRun(ctx context.Context, in *CancelRunRequest, opts
 	return out, nil
 }
 
+func (c *engineClient) PruneRuns(ctx context.Context, in *PruneRunsRequest, opts ...grpc.CallOption) (*PruneRunsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PruneRunsResponse)
+	err := c.cc.Invoke(ctx, Engine_PruneRuns_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineClient) CreatePreviewEnvironment(ctx context.Context, in *CreatePreviewEnvironmentRequest, opts ...grpc.CallOption) (*CreatePreviewEnvironmentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreatePreviewEnvironmentResponse)
+	err := c.cc.Invoke(ctx, Engine_CreatePreviewEnvironment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineClient) DeletePreviewEnvironment(ctx context.Context, in *DeletePreviewEnvironmentRequest, opts ...grpc.CallOption) (*DeletePreviewEnvironmentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeletePreviewEnvironmentResponse)
+	err := c.cc.Invoke(ctx, Engine_DeletePreviewEnvironment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *engineClient) Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(HealthResponse)
@@ -175,6 +211,9 @@ type EngineServer interface {
 	ListRuns(context.Context, *ListRunsRequest) (*ListRunsResponse, error)
 	GetRun(context.Context, *GetRunRequest) (*GetRunResponse, error)
 	CancelRun(context.Context, *CancelRunRequest) (*CancelRunResponse, error)
+	PruneRuns(context.Context, *PruneRunsRequest) (*PruneRunsResponse, error)
+	CreatePreviewEnvironment(context.Context, *CreatePreviewEnvironmentRequest) (*CreatePreviewEnvironmentResponse, error)
+	DeletePreviewEnvironment(context.Context, *DeletePreviewEnvironmentRequest) (*DeletePreviewEnvironmentResponse, error)
 	Health(context.Context, *HealthRequest) (*HealthResponse, error)
 	WaitForCleanup(context.Context, *WaitForCleanupRequest) (*WaitForCleanupResponse, error)
 	UpsertRunStep(context.Context, *UpsertRunStepRequest) (*UpsertRunStepResponse, error)
@@ -208,6 +247,15 @@ func (UnimplementedEngineServer) GetRun(context.Context, *GetRunRequest) (*GetRu
 func (UnimplementedEngineServer) CancelRun(context.Context, *CancelRunRequest) (*CancelRunResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method CancelRun not implemented")
 }
+func (UnimplementedEngineServer) PruneRuns(context.Context, *PruneRunsRequest) (*PruneRunsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method PruneRuns not implemented")
+}
+func (UnimplementedEngineServer) CreatePreviewEnvironment(context.Context, *CreatePreviewEnvironmentRequest) (*CreatePreviewEnvironmentResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreatePreviewEnvironment not implemented")
+}
+func (UnimplementedEngineServer) DeletePreviewEnvironment(context.Context, *DeletePreviewEnvironmentRequest) (*DeletePreviewEnvironmentResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeletePreviewEnvironment not implemented")
+}
 func (UnimplementedEngineServer) Health(context.Context, *HealthRequest) (*HealthResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method Health not implemented")
 }
@@ -342,6 +390,60 @@ func _Engine_CancelRun_Handler(srv interface{}, ctx context.Context, dec func(in
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Engine_PruneRuns_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PruneRunsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineServer).PruneRuns(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Engine_PruneRuns_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineServer).PruneRuns(ctx, req.(*PruneRunsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Engine_CreatePreviewEnvironment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreatePreviewEnvironmentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineServer).CreatePreviewEnvironment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Engine_CreatePreviewEnvironment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineServer).CreatePreviewEnvironment(ctx, req.(*CreatePreviewEnvironmentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Engine_DeletePreviewEnvironment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletePreviewEnvironmentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineServer).DeletePreviewEnvironment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Engine_DeletePreviewEnvironment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineServer).DeletePreviewEnvironment(ctx, req.(*DeletePreviewEnvironmentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Engine_Health_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(HealthRequest)
 	if err := dec(in); err != nil {
@@ -441,6 +543,18 @@ var Engine_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CancelRun",
 			Handler:    _Engine_CancelRun_Handler,
 		},
+		{
+			MethodName: "PruneRuns",
+			Handler:    _Engine_PruneRuns_Handler,
+		},
+		{
+			MethodName: "CreatePreviewEnvironment",
+			Handler:    _Engine_CreatePreviewEnvironment_Handler,
+		},
+		{
+			MethodName: "DeletePreviewEnvironment",
+			Handler:    _Engine_DeletePreviewEnvironment_Handler,
+		},
 		{
 			MethodName: "Health",
 			Handler:    _Engine_Health_Handler,
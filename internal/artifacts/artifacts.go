@@ -0,0 +1,109 @@
+// Package artifacts keeps the files steps produce, such as screenshots and
+// image diffs, so they can be inspected after a run.
+package artifacts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// DirEnv overrides where the worker keeps artifacts
+const DirEnv = "ROCKETSHIP_ARTIFACTS_DIR"
+
+// ErrorType is the application error type of a failed step that saved
+// artifacts. Its details hold them under "artifacts", so they are reported
+// with the failure.
+const ErrorType = "step_artifacts"
+
+// Artifact is a file kept for a run
+type Artifact struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"` // e.g. screenshot, diff
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size"`
+	Path     string `json:"path"` // Location on the worker
+}
+
+// Dir returns the directory artifacts are kept in: ROCKETSHIP_ARTIFACTS_DIR,
+// or rocketship-artifacts in the system temp directory
+func Dir() string {
+	if dir := os.Getenv(DirEnv); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.TempDir(), "rocketship-artifacts")
+}
+
+// Save writes data as an artifact of the run. Files are grouped by run, and a
+// name already used in the run gets a numeric suffix rather than being replaced.
+func Save(runID, name, artifactType, mimeType string, data []byte) (Artifact, error) {
+	if runID == "" {
+		runID = "local"
+	}
+	dir := filepath.Join(Dir(), sanitize(runID))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return Artifact{}, fmt.Errorf("failed to create artifacts directory: %w", err)
+	}
+
+	name = sanitize(name)
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; ; i++ {
+		candidate := name
+		if i > 1 {
+			candidate = fmt.Sprintf("%s-%d%s", base, i, ext)
+		}
+		path := filepath.Join(dir, candidate)
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return Artifact{}, fmt.Errorf("failed to create artifact %s: %w", candidate, err)
+		}
+		_, err = f.Write(data)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return Artifact{}, fmt.Errorf("failed to write artifact %s: %w", candidate, err)
+		}
+		return Artifact{
+			Name:     candidate,
+			Type:     artifactType,
+			MimeType: mimeType,
+			Size:     int64(len(data)),
+			Path:     path,
+		}, nil
+	}
+}
+
+// Failure returns err as a step failure carrying the artifacts saved for it,
+// or err itself when there are none
+func Failure(err error, saved []Artifact) error {
+	if len(saved) == 0 {
+		return err
+	}
+	return temporal.NewApplicationError(err.Error(), ErrorType, map[string]interface{}{"artifacts": saved})
+}
+
+// sanitize turns a step or run name into a single safe path element
+func sanitize(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	cleaned := strings.Trim(b.String(), ".")
+	if cleaned == "" {
+		return "artifact"
+	}
+	return cleaned
+}
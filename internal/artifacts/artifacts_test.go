@@ -0,0 +1,50 @@
+package artifacts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSave(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(DirEnv, dir)
+
+	first, err := Save("run-1", "Home page diff.png", "diff", "image/png", []byte("one"))
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if first.Name != "Home_page_diff.png" || first.Size != 3 {
+		t.Fatalf("unexpected artifact: %+v", first)
+	}
+	if want := filepath.Join(dir, "run-1", "Home_page_diff.png"); first.Path != want {
+		t.Fatalf("Path = %q, want %q", first.Path, want)
+	}
+
+	second, err := Save("run-1", "Home page diff.png", "diff", "image/png", []byte("two"))
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if second.Name != "Home_page_diff-2.png" {
+		t.Fatalf("second Name = %q, want a suffixed name", second.Name)
+	}
+
+	data, err := os.ReadFile(first.Path)
+	if err != nil || string(data) != "one" {
+		t.Fatalf("first artifact was overwritten: %q, %v", data, err)
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	cases := map[string]string{
+		"../../etc/passwd": "_.._etc_passwd",
+		"..":               "artifact",
+		"":                 "artifact",
+		"ok-name_1.png":    "ok-name_1.png",
+	}
+	for in, want := range cases {
+		if got := sanitize(in); got != want {
+			t.Errorf("sanitize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
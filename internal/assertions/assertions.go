@@ -0,0 +1,630 @@
+// Package assertions implements the assertion types shared by every plugin.
+//
+// Plugins keep their protocol-specific assertions (status_code, row_count, ...)
+// and hand everything else to Evaluate together with the value the assertion
+// runs against: the decoded HTTP body, the list of received messages, the SQL
+// result and so on. This keeps the YAML syntax and failure messages identical
+// across plugins:
+//
+//	assertions:
+//	  - type: greater_than
+//	    path: ".items | length"
+//	    expected: 2
+package assertions
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/itchyny/gojq"
+
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+)
+
+// Shared assertion types. Every type except json_path and exists accepts an
+// optional jq path; without one it checks the whole subject.
+const (
+	TypeJSONPath           = "json_path"
+	TypeEquals             = "equals"
+	TypeContains           = "contains"
+	TypeNotContains        = "not_contains"
+	TypeRegex              = "regex"
+	TypeGreaterThan        = "greater_than"
+	TypeGreaterThanOrEqual = "greater_than_or_equal"
+	TypeLessThan           = "less_than"
+	TypeLessThanOrEqual    = "less_than_or_equal"
+	TypeBetween            = "between"
+	TypeLength             = "length"
+	TypeIsNull             = "is_null"
+	TypeNotNull            = "not_null"
+	TypeTypeIs             = "type_is"
+	TypeExists             = "exists"
+)
+
+// valueTypes are the names type_is accepts
+var valueTypes = []string{"string", "number", "integer", "boolean", "array", "object", "null"}
+
+// Result represents a single assertion result for UI display
+type Result struct {
+	Type     string      `json:"type"`
+	Name     string      `json:"name,omitempty"`     // Header or field name for plugin-specific assertions
+	Path     string      `json:"path,omitempty"`     // jq expression the value was read from
+	Expected interface{} `json:"expected,omitempty"` // Expected value
+	Actual   interface{} `json:"actual,omitempty"`   // Actual value received
+	Passed   bool        `json:"passed"`             // Whether the assertion passed
+	Message  string      `json:"message,omitempty"`  // Error message if failed
+	Soft     bool        `json:"soft,omitempty"`     // A failure doesn't fail the step; the workflow reports it at the end of the test
+}
+
+// IsSoft reports whether an assertion is marked soft: true
+func IsSoft(assertion map[string]interface{}) bool {
+	soft, _ := assertion["soft"].(bool)
+	return soft
+}
+
+// IsShared reports whether Evaluate handles assertionType
+func IsShared(assertionType string) bool {
+	switch assertionType {
+	case TypeJSONPath, TypeEquals, TypeContains, TypeNotContains, TypeRegex,
+		TypeGreaterThan, TypeGreaterThanOrEqual, TypeLessThan, TypeLessThanOrEqual, TypeBetween,
+		TypeLength, TypeIsNull, TypeNotNull, TypeTypeIs, TypeExists:
+		return true
+	}
+	return false
+}
+
+// Evaluate runs a shared assertion against subject. expected must already have
+// its templates rendered. subject must hold JSON-compatible values (see Normalize).
+func Evaluate(assertion map[string]interface{}, subject interface{}, expected interface{}) Result {
+	assertionType, _ := assertion["type"].(string)
+	path, _ := assertion["path"].(string)
+	result := Result{
+		Type:     assertionType,
+		Path:     path,
+		Expected: expected,
+	}
+
+	if !IsShared(assertionType) {
+		result.Message = fmt.Sprintf("unknown assertion type: %s", assertionType)
+		return result
+	}
+	if path == "" && (assertionType == TypeJSONPath || assertionType == TypeExists) {
+		result.Message = fmt.Sprintf("path is required for %s assertion", assertionType)
+		return result
+	}
+
+	actual, found := subject, true
+	if path != "" {
+		var err error
+		actual, found, err = Query(path, subject)
+		if err != nil {
+			result.Message = err.Error()
+			return result
+		}
+	}
+	result.Actual = actual
+
+	// json_path with exists: true only checks presence
+	checkExists := assertionType == TypeExists
+	if exists, ok := assertion["exists"].(bool); ok && exists && assertionType == TypeJSONPath {
+		checkExists = true
+	}
+	if checkExists {
+		want := true
+		if b, ok := expected.(bool); ok && assertionType == TypeExists {
+			want = b
+		}
+		present := found && actual != nil
+		switch {
+		case present == want:
+			result.Passed = true
+		case want:
+			result.Message = fmt.Sprintf("path %q does not exist", path)
+		default:
+			result.Message = fmt.Sprintf("expected path %q not to exist, got %v", path, actual)
+		}
+		return result
+	}
+
+	// A path that produces nothing counts as null
+	if assertionType == TypeIsNull || assertionType == TypeNotNull {
+		isNull := !found || actual == nil
+		switch {
+		case isNull == (assertionType == TypeIsNull):
+			result.Passed = true
+		case isNull:
+			result.Message = "expected a value, got null"
+		default:
+			result.Message = fmt.Sprintf("expected null, got %v", format(actual))
+		}
+		return result
+	}
+
+	if !found {
+		result.Message = fmt.Sprintf("no results from jq expression %q", path)
+		return result
+	}
+
+	switch assertionType {
+	case TypeJSONPath, TypeEquals:
+		// json_path without an expected value passes as long as the path produced a result
+		if expected == nil && assertionType == TypeJSONPath {
+			result.Passed = true
+		} else if Equal(actual, expected) {
+			result.Passed = true
+		} else {
+			result.Message = fmt.Sprintf("expected %v, got %v", format(expected), format(actual))
+		}
+
+	case TypeContains:
+		if containsValue(actual, expected) {
+			result.Passed = true
+		} else {
+			result.Message = fmt.Sprintf("expected %v to contain %v", format(actual), format(expected))
+		}
+
+	case TypeNotContains:
+		if containsValue(actual, expected) {
+			result.Message = fmt.Sprintf("expected %v not to contain %v", format(actual), format(expected))
+		} else {
+			result.Passed = true
+		}
+
+	case TypeRegex:
+		pattern, ok := expected.(string)
+		if !ok {
+			result.Message = fmt.Sprintf("regex expected value must be a string: got type %T", expected)
+			break
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			result.Message = fmt.Sprintf("invalid regex %q: %v", pattern, err)
+			break
+		}
+		if re.MatchString(stringify(actual)) {
+			result.Passed = true
+		} else {
+			result.Message = fmt.Sprintf("expected %v to match %q", format(actual), pattern)
+		}
+
+	case TypeLength:
+		length, ok := lengthOf(actual)
+		if !ok {
+			result.Message = fmt.Sprintf("cannot take the length of %v (%s)", format(actual), typeName(actual))
+			break
+		}
+		result.Actual = length
+		if Equal(length, expected) {
+			result.Passed = true
+		} else {
+			result.Message = fmt.Sprintf("expected length %v, got %d", format(expected), length)
+		}
+
+	case TypeBetween:
+		bounds, ok := expected.([]interface{})
+		if !ok || len(bounds) != 2 {
+			result.Message = fmt.Sprintf("between expected value must be a list of two bounds, e.g. [1, 10]: got %v", format(expected))
+			break
+		}
+		low, err := Compare(actual, bounds[0])
+		if err != nil {
+			result.Message = err.Error()
+			break
+		}
+		high, err := Compare(actual, bounds[1])
+		if err != nil {
+			result.Message = err.Error()
+			break
+		}
+		if low >= 0 && high <= 0 {
+			result.Passed = true
+		} else {
+			result.Message = fmt.Sprintf("expected %v to be between %v and %v", format(actual), format(bounds[0]), format(bounds[1]))
+		}
+
+	case TypeTypeIs:
+		want, _ := expected.(string)
+		if !isValueType(want) {
+			result.Message = fmt.Sprintf("type_is expected value must be one of %s: got %v", strings.Join(valueTypes, ", "), format(expected))
+			break
+		}
+		if hasType(actual, want) {
+			result.Passed = true
+		} else {
+			result.Message = fmt.Sprintf("expected %v to be of type %s, got %s", format(actual), want, typeName(actual))
+		}
+
+	default:
+		cmp, err := Compare(actual, expected)
+		if err != nil {
+			result.Message = err.Error()
+			break
+		}
+		var ok bool
+		var relation string
+		switch assertionType {
+		case TypeGreaterThan:
+			ok, relation = cmp > 0, "greater than"
+		case TypeGreaterThanOrEqual:
+			ok, relation = cmp >= 0, "greater than or equal to"
+		case TypeLessThan:
+			ok, relation = cmp < 0, "less than"
+		case TypeLessThanOrEqual:
+			ok, relation = cmp <= 0, "less than or equal to"
+		}
+		if ok {
+			result.Passed = true
+		} else {
+			result.Message = fmt.Sprintf("expected %v to be %s %v", format(actual), relation, format(expected))
+		}
+	}
+
+	return result
+}
+
+// Custom evaluates a plugin's own assertion types, e.g. exit_code. result comes
+// with its Type and rendered Expected set. Custom returns false for types it
+// doesn't define, which Process then runs as shared assertions.
+type Custom func(assertion map[string]interface{}, result *Result) bool
+
+// Process runs the step's assertions block against subject and returns one
+// result per assertion. Templates in string expected values are rendered with
+// context first; a template that doesn't render fails the step, since comparing
+// against the raw {{ ... }} text would only hide the typo. custom may be nil.
+func Process(p map[string]interface{}, subject interface{}, context dsl.TemplateContext, custom Custom) ([]Result, error) {
+	assertionList, ok := p["assertions"].([]interface{})
+	if !ok || len(assertionList) == 0 {
+		return nil, nil
+	}
+
+	var results []Result
+	for _, assertion := range assertionList {
+		assertionMap, ok := assertion.(map[string]interface{})
+		if !ok {
+			results = append(results, Result{
+				Type:    "unknown",
+				Message: fmt.Sprintf("invalid assertion format: got type %T", assertion),
+			})
+			continue
+		}
+
+		assertionType, _ := assertionMap["type"].(string)
+		expected, err := renderExpected(assertionMap["expected"], context)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render expected value of %s assertion: %w", assertionType, err)
+		}
+
+		result := Result{
+			Type:     assertionType,
+			Expected: expected,
+		}
+		if custom == nil || !custom(assertionMap, &result) {
+			result = Evaluate(assertionMap, subject, expected)
+		}
+		result.Soft = IsSoft(assertionMap)
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// renderExpected renders the templates in a string expected value, or in the
+// strings of a list such as the bounds of between
+func renderExpected(expected interface{}, context dsl.TemplateContext) (interface{}, error) {
+	switch v := expected.(type) {
+	case string:
+		return dsl.ProcessTemplate(v, context)
+	case []interface{}:
+		rendered := make([]interface{}, len(v))
+		for i, item := range v {
+			var err error
+			if rendered[i], err = renderExpected(item, context); err != nil {
+				return nil, err
+			}
+		}
+		return rendered, nil
+	}
+	return expected, nil
+}
+
+// Query evaluates a jq expression against data and returns its first result
+func Query(expr string, data interface{}) (interface{}, bool, error) {
+	query, err := gojq.Parse(expr)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to parse jq expression %q: %w", expr, err)
+	}
+	iter := query.Run(data)
+	v, ok := iter.Next()
+	if !ok {
+		return nil, false, nil
+	}
+	if err, ok := v.(error); ok {
+		return nil, false, fmt.Errorf("error evaluating jq expression %q: %w", expr, err)
+	}
+	return v, true, nil
+}
+
+// Normalize converts typed values (structs, int32, []byte...) into the generic
+// JSON values jq and the comparisons expect. Numbers are kept as json.Number,
+// so 64-bit IDs don't lose precision on their way through float64.
+func Normalize(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var out interface{}
+	if err := decoder.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Equal compares an actual value with an expected one. Numbers compare by value, and a
+// string matches a number or boolean with the same text since rendered templates,
+// headers and SQL text columns are always strings.
+func Equal(actual, expected interface{}) bool {
+	// Integers compare exactly, since float64 can't tell large IDs apart. Two
+	// strings still compare as text.
+	_, actualIsString := actual.(string)
+	_, expectedIsString := expected.(string)
+	if !actualIsString || !expectedIsString {
+		if a, ok := toInteger(actual); ok {
+			if e, ok := toInteger(expected); ok {
+				return a.Cmp(e) == 0
+			}
+		}
+	}
+	if a, ok := toNumber(actual); ok {
+		if e, ok := toNumber(expected); ok {
+			return a == e
+		}
+		if s, ok := expected.(string); ok {
+			if e, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+				return a == e
+			}
+		}
+		return false
+	}
+	switch a := actual.(type) {
+	case string:
+		if e, ok := expected.(string); ok {
+			return a == e
+		}
+		if e, ok := toNumber(expected); ok {
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(a), 64)
+			return err == nil && parsed == e
+		}
+		return false
+	case bool:
+		switch e := expected.(type) {
+		case bool:
+			return a == e
+		case string:
+			return strconv.FormatBool(a) == e
+		}
+		return false
+	case nil:
+		return expected == nil || expected == "null"
+	}
+	return reflect.DeepEqual(actual, expected)
+}
+
+// Compare orders two numbers, or two strings lexically. It returns -1, 0 or 1.
+func Compare(actual, expected interface{}) (int, error) {
+	a, aok := toNumber(actual)
+	e, eok := toNumber(expected)
+	if s, ok := expected.(string); ok && aok {
+		if parsed, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+			e, eok = parsed, true
+		}
+	}
+	if aok && eok {
+		switch {
+		case a < e:
+			return -1, nil
+		case a > e:
+			return 1, nil
+		}
+		return 0, nil
+	}
+
+	as, aIsString := actual.(string)
+	es, eIsString := expected.(string)
+	if aIsString && eIsString {
+		return strings.Compare(as, es), nil
+	}
+	return 0, fmt.Errorf("cannot compare %v (%s) with %v (%s)", format(actual), typeName(actual), format(expected), typeName(expected))
+}
+
+// Err returns the failure as an error for plugins that stop at the first failed assertion
+func (r Result) Err() error {
+	if r.Passed || r.Soft {
+		return nil
+	}
+	return fmt.Errorf("%s", Summary([]Result{r}))
+}
+
+// Summary joins the failed results into the error message plugins return. Soft
+// failures are left out, since they don't fail the step.
+func Summary(results []Result) string {
+	var failed []string
+	for _, r := range results {
+		if !r.Passed && !r.Soft {
+			failed = append(failed, fmt.Sprintf("%s: %s", r.Type, r.Message))
+		}
+	}
+	switch len(failed) {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprintf("Assertion failed: %s", failed[0])
+	}
+	return fmt.Sprintf("Assertions failed: %s", strings.Join(failed, "; "))
+}
+
+func containsValue(actual, expected interface{}) bool {
+	switch a := actual.(type) {
+	case string:
+		return strings.Contains(a, stringify(expected))
+	case []interface{}:
+		for _, item := range a {
+			if Equal(item, expected) {
+				return true
+			}
+		}
+		return false
+	case map[string]interface{}:
+		key, ok := expected.(string)
+		if !ok {
+			return false
+		}
+		_, exists := a[key]
+		return exists
+	}
+	return false
+}
+
+// lengthOf counts the characters of a string, the items of an array or the keys of an object
+func lengthOf(v interface{}) (int, bool) {
+	switch val := v.(type) {
+	case string:
+		return utf8.RuneCountInString(val), true
+	case []interface{}:
+		return len(val), true
+	case map[string]interface{}:
+		return len(val), true
+	}
+	return 0, false
+}
+
+func isValueType(name string) bool {
+	for _, valueType := range valueTypes {
+		if name == valueType {
+			return true
+		}
+	}
+	return false
+}
+
+// hasType reports whether v is of the named type; integers are whole numbers
+func hasType(v interface{}, name string) bool {
+	if name == "integer" {
+		n, ok := toNumber(v)
+		return ok && n == math.Trunc(n)
+	}
+	return typeName(v) == name
+}
+
+// toInteger returns whole numbers, and strings holding one, as a big.Int
+func toInteger(v interface{}) (*big.Int, bool) {
+	switch n := v.(type) {
+	case int:
+		return big.NewInt(int64(n)), true
+	case int32:
+		return big.NewInt(int64(n)), true
+	case int64:
+		return big.NewInt(n), true
+	case uint:
+		return new(big.Int).SetUint64(uint64(n)), true
+	case uint32:
+		return big.NewInt(int64(n)), true
+	case uint64:
+		return new(big.Int).SetUint64(n), true
+	case *big.Int:
+		return n, true
+	case json.Number:
+		return new(big.Int).SetString(n.String(), 10)
+	case string:
+		return new(big.Int).SetString(strings.TrimSpace(n), 10)
+	}
+	return nil, false
+}
+
+func toNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(n).Float64()
+		return f, true
+	}
+	return 0, false
+}
+
+// stringify renders scalars as text and everything else as JSON
+func stringify(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case nil:
+		return "null"
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	case json.Number:
+		return val.String()
+	}
+	if i, ok := toInteger(v); ok {
+		return i.String()
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// format renders a value for failure messages, quoting strings so "1" and 1 read differently
+func format(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	return stringify(v)
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	if _, ok := toNumber(v); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,187 @@
+package assertions
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+)
+
+func TestEvaluate(t *testing.T) {
+	subject := map[string]interface{}{
+		"id":     float64(42),
+		"name":   "rocketship",
+		"active": true,
+		"tags":   []interface{}{"api", "beta"},
+		"price":  float64(9.5),
+		"note":   nil,
+	}
+
+	tests := []struct {
+		name       string
+		assertion  map[string]interface{}
+		expected   interface{}
+		wantPassed bool
+		wantMsg    string
+	}{
+		{"json_path equal number", map[string]interface{}{"type": "json_path", "path": ".id"}, float64(42), true, ""},
+		{"json_path templated string matches number", map[string]interface{}{"type": "json_path", "path": ".id"}, "42", true, ""},
+		{"json_path mismatch", map[string]interface{}{"type": "json_path", "path": ".name"}, "other", false, `expected "other", got "rocketship"`},
+		{"json_path exists flag", map[string]interface{}{"type": "json_path", "path": ".name", "exists": true}, nil, true, ""},
+		{"json_path missing path", map[string]interface{}{"type": "json_path"}, "x", false, "path is required"},
+		{"equals bool from template", map[string]interface{}{"type": "equals", "path": ".active"}, "true", true, ""},
+		{"contains substring", map[string]interface{}{"type": "contains", "path": ".name"}, "ship", true, ""},
+		{"contains array element", map[string]interface{}{"type": "contains", "path": ".tags"}, "beta", true, ""},
+		{"contains missing element", map[string]interface{}{"type": "contains", "path": ".tags"}, "ga", false, "to contain"},
+		{"contains object key without path", map[string]interface{}{"type": "contains"}, "price", true, ""},
+		{"regex match", map[string]interface{}{"type": "regex", "path": ".name"}, "^rocket", true, ""},
+		{"regex number", map[string]interface{}{"type": "regex", "path": ".id"}, `^\d+$`, true, ""},
+		{"regex mismatch", map[string]interface{}{"type": "regex", "path": ".name"}, "^ship", false, "to match"},
+		{"greater_than", map[string]interface{}{"type": "greater_than", "path": ".price"}, float64(9), true, ""},
+		{"greater_than fails", map[string]interface{}{"type": "greater_than", "path": ".price"}, float64(10), false, "to be greater than 10"},
+		{"less_than_or_equal string number", map[string]interface{}{"type": "less_than_or_equal", "path": ".tags | length"}, "2", true, ""},
+		{"greater_than_or_equal", map[string]interface{}{"type": "greater_than_or_equal", "path": ".id"}, float64(42), true, ""},
+		{"less_than type mismatch", map[string]interface{}{"type": "less_than", "path": ".tags"}, float64(1), false, "cannot compare"},
+		{"not_contains", map[string]interface{}{"type": "not_contains", "path": ".tags"}, "ga", true, ""},
+		{"not_contains present element", map[string]interface{}{"type": "not_contains", "path": ".name"}, "ship", false, "not to contain"},
+		{"between", map[string]interface{}{"type": "between", "path": ".price"}, []interface{}{float64(5), "10"}, true, ""},
+		{"between inclusive", map[string]interface{}{"type": "between", "path": ".id"}, []interface{}{float64(1), float64(42)}, true, ""},
+		{"between out of range", map[string]interface{}{"type": "between", "path": ".price"}, []interface{}{float64(10), float64(20)}, false, "to be between 10 and 20"},
+		{"between strings", map[string]interface{}{"type": "between", "path": ".name"}, []interface{}{"a", "z"}, true, ""},
+		{"between one bound", map[string]interface{}{"type": "between", "path": ".price"}, float64(10), false, "list of two bounds"},
+		{"length array", map[string]interface{}{"type": "length", "path": ".tags"}, float64(2), true, ""},
+		{"length string from template", map[string]interface{}{"type": "length", "path": ".name"}, "10", true, ""},
+		{"length object", map[string]interface{}{"type": "length"}, float64(6), true, ""},
+		{"length mismatch", map[string]interface{}{"type": "length", "path": ".tags"}, float64(3), false, "expected length 3, got 2"},
+		{"length number", map[string]interface{}{"type": "length", "path": ".id"}, float64(2), false, "cannot take the length of 42 (number)"},
+		{"is_null", map[string]interface{}{"type": "is_null", "path": ".note"}, nil, true, ""},
+		{"is_null missing path", map[string]interface{}{"type": "is_null", "path": ".missing"}, nil, true, ""},
+		{"is_null value", map[string]interface{}{"type": "is_null", "path": ".name"}, nil, false, `expected null, got "rocketship"`},
+		{"not_null", map[string]interface{}{"type": "not_null", "path": ".id"}, nil, true, ""},
+		{"not_null null value", map[string]interface{}{"type": "not_null", "path": ".note"}, nil, false, "expected a value, got null"},
+		{"type_is string", map[string]interface{}{"type": "type_is", "path": ".name"}, "string", true, ""},
+		{"type_is integer", map[string]interface{}{"type": "type_is", "path": ".id"}, "integer", true, ""},
+		{"type_is integer fraction", map[string]interface{}{"type": "type_is", "path": ".price"}, "integer", false, "to be of type integer, got number"},
+		{"type_is array", map[string]interface{}{"type": "type_is", "path": ".tags"}, "array", true, ""},
+		{"type_is null", map[string]interface{}{"type": "type_is", "path": ".note"}, "null", true, ""},
+		{"type_is mismatch", map[string]interface{}{"type": "type_is", "path": ".active"}, "string", false, "to be of type string, got boolean"},
+		{"type_is unknown type", map[string]interface{}{"type": "type_is", "path": ".id"}, "float", false, "must be one of"},
+		{"exists", map[string]interface{}{"type": "exists", "path": ".name"}, nil, true, ""},
+		{"exists null value", map[string]interface{}{"type": "exists", "path": ".note"}, nil, false, "does not exist"},
+		{"exists false", map[string]interface{}{"type": "exists", "path": ".missing"}, false, true, ""},
+		{"unknown type", map[string]interface{}{"type": "status_code"}, float64(200), false, "unknown assertion type"},
+		{"bad jq", map[string]interface{}{"type": "equals", "path": ".["}, "x", false, "failed to parse jq expression"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Evaluate(tt.assertion, subject, tt.expected)
+			if result.Passed != tt.wantPassed {
+				t.Fatalf("Passed = %v, want %v (message: %s)", result.Passed, tt.wantPassed, result.Message)
+			}
+			if tt.wantMsg != "" && !strings.Contains(result.Message, tt.wantMsg) {
+				t.Errorf("Message = %q, want it to contain %q", result.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestSummaryAndErr(t *testing.T) {
+	passed := Result{Type: "equals", Passed: true}
+	failed := Result{Type: "regex", Message: "expected \"a\" to match \"b\""}
+
+	if Summary([]Result{passed}) != "" {
+		t.Error("expected empty summary when all assertions pass")
+	}
+	if got := Summary([]Result{passed, failed}); got != `Assertion failed: regex: expected "a" to match "b"` {
+		t.Errorf("unexpected summary %q", got)
+	}
+	if got := Summary([]Result{failed, failed}); !strings.HasPrefix(got, "Assertions failed: ") {
+		t.Errorf("unexpected summary %q", got)
+	}
+	if passed.Err() != nil || failed.Err() == nil {
+		t.Error("Err should only report failed results")
+	}
+
+	soft := Result{Type: "equals", Message: "expected 1, got 2", Soft: true}
+	if Summary([]Result{passed, soft}) != "" || soft.Err() != nil {
+		t.Error("soft failures shouldn't fail the step")
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	type row struct {
+		Count int32 `json:"count"`
+	}
+	normalized, err := Normalize(row{Count: 3})
+	if err != nil {
+		t.Fatalf("Normalize: %v", err)
+	}
+	if r := Evaluate(map[string]interface{}{"type": "equals", "path": ".count"}, normalized, float64(3)); !r.Passed {
+		t.Errorf("expected normalized value to be queryable: %s", r.Message)
+	}
+
+	big, err := Normalize(map[string]int64{"id": 1234567890123456789})
+	if err != nil {
+		t.Fatalf("Normalize: %v", err)
+	}
+	if r := Evaluate(map[string]interface{}{"type": "equals", "path": ".id"}, big, "1234567890123456789"); !r.Passed {
+		t.Errorf("expected a bigint to keep its precision: %s", r.Message)
+	}
+	if r := Evaluate(map[string]interface{}{"type": "equals", "path": ".id"}, big, "1234567890123456800"); r.Passed {
+		t.Error("expected a neighbouring bigint not to match")
+	}
+}
+
+func TestProcess(t *testing.T) {
+	subject := map[string]interface{}{"status": "active", "count": float64(2)}
+	p := map[string]interface{}{
+		"assertions": []interface{}{
+			map[string]interface{}{"type": "equals", "path": ".status", "expected": "{{ status }}"},
+			map[string]interface{}{"type": "item_count", "expected": float64(2), "soft": true},
+			"not an assertion",
+		},
+	}
+	context := dsl.TemplateContext{Runtime: map[string]interface{}{"status": "active"}}
+	custom := func(assertion map[string]interface{}, result *Result) bool {
+		if result.Type != "item_count" {
+			return false
+		}
+		result.Actual = subject["count"]
+		result.Passed = Equal(result.Actual, result.Expected)
+		return true
+	}
+
+	results, err := Process(p, subject, context, custom)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if len(results) != 3 || !results[0].Passed || !results[1].Passed || results[2].Passed {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	if results[0].Expected != "active" {
+		t.Errorf("expected the template to be rendered, got %v", results[0].Expected)
+	}
+	if results[0].Soft || !results[1].Soft {
+		t.Errorf("expected only the assertion with soft: true to be soft, got %+v", results)
+	}
+
+	// The bounds of between are rendered too
+	results, err = Process(map[string]interface{}{
+		"assertions": []interface{}{map[string]interface{}{"type": "between", "path": ".count", "expected": []interface{}{float64(1), "{{ max }}"}}},
+	}, subject, dsl.TemplateContext{Runtime: map[string]interface{}{"max": "3"}}, nil)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if len(results) != 1 || !results[0].Passed {
+		t.Fatalf("unexpected results %+v", results)
+	}
+
+	// A template that doesn't render fails instead of comparing against its text
+	_, err = Process(map[string]interface{}{
+		"assertions": []interface{}{map[string]interface{}{"type": "equals", "expected": "{{ missing }}"}},
+	}, subject, context, nil)
+	if err == nil || !strings.Contains(err.Error(), "failed to render expected value of equals assertion") {
+		t.Errorf("expected a template error, got %v", err)
+	}
+}
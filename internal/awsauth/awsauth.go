@@ -0,0 +1,257 @@
+// Package awsauth signs requests to AWS APIs with Signature Version 4. Plugins
+// that talk to AWS use it instead of the AWS SDK, which would add dozens of
+// modules to the worker for a handful of API calls.
+package awsauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	algorithm        = "AWS4-HMAC-SHA256"
+	amzDateFormat    = "20060102T150405Z"
+	shortDateFormat  = "20060102"
+	headerAmzDate    = "X-Amz-Date"
+	headerAmzToken   = "X-Amz-Security-Token"
+	headerAmzContent = "X-Amz-Content-Sha256"
+	unsignedPayload  = "UNSIGNED-PAYLOAD"
+)
+
+// Credentials are the keys a request is signed with
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// CredentialsFromEnv reads credentials from the standard AWS environment variables
+func CredentialsFromEnv() Credentials {
+	return Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+}
+
+// RegionFromEnv returns $AWS_REGION, then $AWS_DEFAULT_REGION
+func RegionFromEnv() string {
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		return region
+	}
+	return os.Getenv("AWS_DEFAULT_REGION")
+}
+
+// Valid reports whether the credentials can sign a request
+func (c Credentials) Valid() bool {
+	return c.AccessKeyID != "" && c.SecretAccessKey != ""
+}
+
+// Sign adds the X-Amz-Date and Authorization headers to req. body must be the
+// request body, which Sign hashes but doesn't read from req.
+func Sign(req *http.Request, body []byte, creds Credentials, region, service string, now time.Time) error {
+	if !creds.Valid() {
+		return fmt.Errorf("AWS credentials are missing an access key ID or secret access key")
+	}
+	if region == "" {
+		return fmt.Errorf("AWS region is required to sign requests")
+	}
+
+	now = now.UTC()
+	amzDate := now.Format(amzDateFormat)
+	shortDate := now.Format(shortDateFormat)
+
+	req.Header.Set(headerAmzDate, amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set(headerAmzToken, creds.SessionToken)
+	}
+	payloadHash := hashHex(body)
+	if service == "s3" {
+		req.Header.Set(headerAmzContent, payloadHash)
+	}
+
+	signedHeaders, canonicalHeaders := canonicalizeHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalPath(req.URL, service),
+		canonicalQuery(req.URL),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{shortDate, region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{algorithm, amzDate, scope, hashHex([]byte(canonicalRequest))}, "\n")
+
+	signature := sign(creds, shortDate, region, service, stringToSign)
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		algorithm, creds.AccessKeyID, scope, signedHeaders, signature))
+	return nil
+}
+
+// Presign returns u with a signature in its query string, so whoever holds the
+// URL can send method to it until expires passes. Only the host header is
+// signed and the payload isn't, as S3 expects of presigned URLs.
+func Presign(method string, u *url.URL, creds Credentials, region, service string, expires time.Duration, now time.Time) (string, error) {
+	if !creds.Valid() {
+		return "", fmt.Errorf("AWS credentials are missing an access key ID or secret access key")
+	}
+	if region == "" {
+		return "", fmt.Errorf("AWS region is required to sign requests")
+	}
+
+	now = now.UTC()
+	amzDate := now.Format(amzDateFormat)
+	shortDate := now.Format(shortDateFormat)
+	scope := strings.Join([]string{shortDate, region, service, "aws4_request"}, "/")
+
+	signed := *u
+	query := signed.Query()
+	query.Set("X-Amz-Algorithm", algorithm)
+	query.Set("X-Amz-Credential", creds.AccessKeyID+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", fmt.Sprint(int64(expires/time.Second)))
+	query.Set("X-Amz-SignedHeaders", "host")
+	if creds.SessionToken != "" {
+		query.Set(headerAmzToken, creds.SessionToken)
+	}
+	signed.RawQuery = query.Encode()
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		canonicalPath(&signed, service),
+		canonicalQuery(&signed),
+		"host:" + signed.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+	stringToSign := strings.Join([]string{algorithm, amzDate, scope, hashHex([]byte(canonicalRequest))}, "\n")
+
+	signed.RawQuery = canonicalQuery(&signed) + "&X-Amz-Signature=" + sign(creds, shortDate, region, service, stringToSign)
+	return signed.String(), nil
+}
+
+// EscapePath URI-encodes each segment of path the way SigV4 expects, e.g. for
+// an S3 object key. Set it as a URL's RawPath so the request is sent as signed.
+func EscapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// sign derives the signing key for the scope and signs stringToSign with it
+func sign(creds Credentials, shortDate, region, service, stringToSign string) string {
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), shortDate)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// canonicalizeHeaders returns the signed header list and the canonical header
+// block. Host is always signed, and so is every header already on the request.
+func canonicalizeHeaders(req *http.Request) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	values := map[string]string{"host": host}
+	for name, vals := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "authorization" || lower == "user-agent" {
+			continue
+		}
+		trimmed := make([]string, len(vals))
+		for i, v := range vals {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		values[lower] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteString(":")
+		b.WriteString(values[name])
+		b.WriteString("\n")
+	}
+	return strings.Join(names, ";"), b.String()
+}
+
+// canonicalPath URI-encodes each path segment. S3 signs the path as sent; other
+// services encode it a second time.
+func canonicalPath(u *url.URL, service string) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	if service == "s3" {
+		return path
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func canonicalQuery(u *url.URL) string {
+	query := u.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, key := range keys {
+		vals := append([]string(nil), query[key]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			pairs = append(pairs, uriEncode(key)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode escapes everything except the unreserved characters, as SigV4 requires
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,309 @@
+// Package pw opens Chromium pages with playwright-go for the browser, visual
+// and a11y plugins. Pages either come from a browser launched for the step or
+// from the test's shared session, and their traffic is held to the run's
+// egress policy.
+package pw
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/rocketship-ai/rocketship/internal/browser/sessionfile"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+)
+
+const (
+	// ExecutableEnv overrides the Chromium the worker launches
+	ExecutableEnv = "ROCKETSHIP_CHROME_PATH"
+
+	// InstallCommand installs the Playwright driver and Chromium on a worker
+	InstallCommand = "go run github.com/playwright-community/playwright-go/cmd/playwright@v0.5200.1 install --with-deps chromium"
+
+	// closeTimeout bounds closing what Open opened
+	closeTimeout = 10 * time.Second
+)
+
+// OpenOptions selects the browser a step's page comes from
+type OpenOptions struct {
+	SessionID  string         // Browser started by playwright role start; a new one is launched when empty
+	Executable string         // Chromium to launch; defaults to ROCKETSHIP_CHROME_PATH, then Playwright's own
+	Headless   bool           // Runs the launched browser without a window
+	Policy     *egress.Policy // Where the page may connect; nil allows everything
+	// Context configures the browser context of a launched browser. Session
+	// pages keep the context they are in.
+	Context playwright.BrowserNewContextOptions
+}
+
+// Tab is a page opened by Open
+type Tab struct {
+	Page     playwright.Page
+	Context  playwright.BrowserContext
+	Attached bool // The page belongs to a session and stays open after Close
+
+	close []func() // Run in reverse order by Close
+}
+
+// Open attaches to the session's open page, or launches a browser with a new
+// page for the caller. Close releases what Open opened.
+//
+// A launched browser sends all its traffic, loopback included, through an
+// egress proxy that dials with the policy. A session's browser was started
+// without one, so its requests and WebSockets are checked as they are made.
+func Open(ctx context.Context, opts OpenOptions) (*Tab, error) {
+	driver, err := playwright.Run(&playwright.RunOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start playwright (install it on the worker with %q): %w", InstallCommand, err)
+	}
+	tab := &Tab{close: []func(){func() { _ = driver.Stop() }}}
+
+	if opts.SessionID != "" {
+		err = tab.attach(ctx, driver, opts)
+	} else {
+		err = tab.launch(ctx, driver, opts)
+	}
+	if err != nil {
+		tab.Close()
+		return nil, err
+	}
+	return tab, nil
+}
+
+func (t *Tab) attach(ctx context.Context, driver *playwright.Playwright, opts OpenOptions) error {
+	endpoint, _, err := sessionfile.Read(ctx, opts.SessionID)
+	if err != nil {
+		return fmt.Errorf("session %q is not active: %w", opts.SessionID, err)
+	}
+	browser, err := driver.Chromium.ConnectOverCDP(endpoint, playwright.BrowserTypeConnectOverCDPOptions{Timeout: Timeout(ctx)})
+	if err != nil {
+		return fmt.Errorf("failed to connect to session %q: %w", opts.SessionID, Reason(err))
+	}
+	// Disconnects and leaves the session's browser running
+	t.close = append(t.close, func() { _ = browser.Close() })
+
+	contexts := browser.Contexts()
+	if len(contexts) == 0 {
+		return fmt.Errorf("session %q has no browser context", opts.SessionID)
+	}
+	t.Context, t.Attached = contexts[0], true
+	if pages := t.Context.Pages(); len(pages) > 0 {
+		t.Page = pages[0]
+	} else if t.Page, err = t.Context.NewPage(); err != nil {
+		return fmt.Errorf("failed to open page: %w", Reason(err))
+	}
+
+	if opts.Policy != nil {
+		if err := guard(ctx, t.Context, opts.Policy); err != nil {
+			return err
+		}
+		t.close = append(t.close, func() {
+			_ = t.Context.UnrouteAll(playwright.BrowserContextUnrouteAllOptions{Behavior: playwright.UnrouteBehaviorIgnoreErrors})
+		})
+	}
+	return nil
+}
+
+func (t *Tab) launch(ctx context.Context, driver *playwright.Playwright, opts OpenOptions) error {
+	launch := playwright.BrowserTypeLaunchOptions{Headless: playwright.Bool(opts.Headless), Timeout: Timeout(ctx)}
+	executable := opts.Executable
+	if executable == "" {
+		executable = os.Getenv(ExecutableEnv)
+	}
+	if executable != "" {
+		launch.ExecutablePath = playwright.String(executable)
+	}
+	if opts.Policy != nil {
+		proxy, err := opts.Policy.StartProxy()
+		if err != nil {
+			return err
+		}
+		t.close = append(t.close, func() { _ = proxy.Close() })
+		// Playwright also sends loopback through the proxy, so local servers are covered
+		launch.Proxy = &playwright.Proxy{Server: proxy.URL}
+	}
+
+	browser, err := driver.Chromium.Launch(launch)
+	if err != nil {
+		return fmt.Errorf("failed to launch browser: %w", Reason(err))
+	}
+	t.close = append(t.close, func() { _ = browser.Close() })
+
+	if t.Context, err = browser.NewContext(opts.Context); err != nil {
+		return fmt.Errorf("failed to create browser context: %w", Reason(err))
+	}
+	// Closing the context finishes its videos
+	t.close = append(t.close, func() { _ = t.Context.Close() })
+	if t.Page, err = t.Context.NewPage(); err != nil {
+		return fmt.Errorf("failed to open page: %w", Reason(err))
+	}
+	return nil
+}
+
+// guard aborts the context's requests and WebSockets to destinations the
+// policy doesn't allow. Routes on the page run first, so requests they answer
+// never reach the network or the guard.
+func guard(ctx context.Context, browserContext playwright.BrowserContext, policy *egress.Policy) error {
+	all := func(string) bool { return true }
+	err := browserContext.Route(all, func(route playwright.Route) {
+		if CheckURL(ctx, policy, route.Request().URL()) != nil {
+			_ = route.Abort("blockedbyclient")
+			return
+		}
+		_ = route.Fallback()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply the egress policy: %w", Reason(err))
+	}
+	err = browserContext.RouteWebSocket(all, func(ws playwright.WebSocketRoute) {
+		if err := CheckURL(ctx, policy, ws.URL()); err != nil {
+			ws.Close(playwright.WebSocketRouteCloseOptions{Code: playwright.Int(1008), Reason: playwright.String(err.Error())})
+			return
+		}
+		_, _ = ws.ConnectToServer()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply the egress policy: %w", Reason(err))
+	}
+	return nil
+}
+
+// CheckURL verifies that the policy allows the host of an http(s) or ws(s)
+// URL. Other schemes, such as data: and blob:, don't leave the browser.
+func CheckURL(ctx context.Context, policy *egress.Policy, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "ws", "wss":
+		return policy.Check(ctx, u.Host)
+	}
+	return nil
+}
+
+// Close releases what Open opened. A session's page stays open for the next
+// step.
+func (t *Tab) Close() {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := len(t.close) - 1; i >= 0; i-- {
+			t.close[i]()
+		}
+	}()
+	// A browser that stopped answering must not hold up the step
+	select {
+	case <-done:
+	case <-time.After(closeTimeout):
+	}
+}
+
+// Navigate loads url in the page. It returns once the navigation has committed;
+// callers wait for whatever they need from the new document.
+func (t *Tab) Navigate(ctx context.Context, url string) error {
+	_, err := t.Page.Goto(url, playwright.PageGotoOptions{Timeout: Timeout(ctx), WaitUntil: playwright.WaitUntilStateCommit})
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", url, Reason(err))
+	}
+	return nil
+}
+
+// Evaluate runs a JavaScript expression in the page, awaiting it if it returns a
+// promise, and decodes its JSON value into out unless out is nil
+func (t *Tab) Evaluate(ctx context.Context, expression string, out interface{}) error {
+	result, err := Await(ctx, func() (interface{}, error) {
+		return t.Page.Evaluate(expression)
+	})
+	if err != nil {
+		return fmt.Errorf("script error: %w", Reason(err))
+	}
+	if out == nil || result == nil {
+		return nil
+	}
+	data, err := json.Marshal(result)
+	if err == nil {
+		err = json.Unmarshal(data, out)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decode script result: %w", err)
+	}
+	return nil
+}
+
+// Screenshot captures the viewport as a PNG, or clip of the page when it is
+// set, in page coordinates
+func (t *Tab) Screenshot(ctx context.Context, clip *playwright.Rect) ([]byte, error) {
+	opts := playwright.PageScreenshotOptions{Type: playwright.ScreenshotTypePng, Timeout: Timeout(ctx)}
+	if clip != nil {
+		opts.Clip = clip
+		opts.FullPage = playwright.Bool(true)
+	}
+	shot, err := t.Page.Screenshot(opts)
+	if err != nil {
+		return nil, Reason(err)
+	}
+	return shot, nil
+}
+
+// Timeout returns what is left of ctx in milliseconds, the unit Playwright
+// takes timeouts in, or nil for Playwright's default when ctx has no deadline
+func Timeout(ctx context.Context) *float64 {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+	// 0 would turn the timeout off
+	ms := max(float64(time.Until(deadline).Milliseconds()), 1)
+	return &ms
+}
+
+// Await runs call, which Playwright can't cancel, and stops waiting for it
+// when ctx ends. A call left behind ends when its page closes.
+func Await[T any](ctx context.Context, call func() (T, error)) (T, error) {
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := call()
+		done <- result{value, err}
+	}()
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// reasonError is a Playwright error reduced to its first line
+type reasonError struct {
+	reason string
+	err    error
+}
+
+func (e *reasonError) Error() string { return e.reason }
+func (e *reasonError) Unwrap() error { return e.err }
+
+// Reason drops the "playwright:" prefix and the call log from a Playwright
+// error, so step errors say what went wrong in one line. errors.Is still finds
+// playwright.ErrTimeout and the other sentinels.
+func Reason(err error) error {
+	var pwErr *playwright.Error
+	if !errors.As(err, &pwErr) {
+		return err
+	}
+	reason, _, _ := strings.Cut(pwErr.Message, "\n")
+	if reason = strings.TrimSpace(reason); reason == "" {
+		return err
+	}
+	return &reasonError{reason: reason, err: err}
+}
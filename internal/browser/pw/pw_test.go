@@ -0,0 +1,131 @@
+package pw
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+)
+
+func TestCheckURL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "egress.yaml")
+	if err := os.WriteFile(path, []byte("default:\n  allow: [\"127.0.0.1\"]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := egress.LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	egress.Configure(cfg)
+	t.Cleanup(func() { egress.Configure(nil) })
+	policy, err := egress.Resolve(egress.Scope{ProjectID: "p1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for _, allowed := range []string{"http://127.0.0.1:8080/login", "ws://127.0.0.1/socket", "data:text/html,hi", "about:blank", "blob:https://10.0.0.1/1"} {
+		if err := CheckURL(ctx, policy, allowed); err != nil {
+			t.Errorf("expected %s to be allowed, got %v", allowed, err)
+		}
+	}
+	for _, denied := range []string{"https://10.0.0.1/admin", "wss://10.0.0.1:8443/socket", "HTTP://10.0.0.1"} {
+		if err := CheckURL(ctx, policy, denied); err == nil || !strings.Contains(err.Error(), "not allowed by the network policy") {
+			t.Errorf("expected %s to be denied, got %v", denied, err)
+		}
+	}
+	if err := CheckURL(ctx, nil, "https://10.0.0.1/admin"); err != nil {
+		t.Errorf("expected a nil policy to allow everything, got %v", err)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	if Timeout(context.Background()) != nil {
+		t.Error("expected Playwright's default without a deadline")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if ms := *Timeout(ctx); ms < 1900 || ms > 2000 {
+		t.Errorf("expected about 2000ms, got %v", ms)
+	}
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	if ms := *Timeout(expired); ms != 1 {
+		t.Errorf("expected an expired context to time out at once rather than never, got %v", ms)
+	}
+}
+
+func TestAwait(t *testing.T) {
+	value, err := Await(context.Background(), func() (int, error) { return 42, nil })
+	if value != 42 || err != nil {
+		t.Errorf("expected the call's result, got %d (%v)", value, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	release := make(chan struct{})
+	defer close(release)
+	_, err = Await(ctx, func() (int, error) {
+		<-release
+		return 0, nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected to stop waiting at the deadline, got %v", err)
+	}
+}
+
+func TestReason(t *testing.T) {
+	timeout := fmt.Errorf("%w: %w: %w", playwright.ErrPlaywright, playwright.ErrTimeout,
+		&playwright.Error{Name: "TimeoutError", Message: "Timeout 300ms exceeded.\nCall log:\n  - waiting for locator('#pay')"})
+	err := Reason(timeout)
+	if err.Error() != "Timeout 300ms exceeded." || !errors.Is(err, playwright.ErrTimeout) {
+		t.Errorf("expected the first line, still a timeout, got %q", err)
+	}
+
+	other := errors.New("connection refused")
+	if Reason(other) != other {
+		t.Error("expected errors from outside Playwright to be kept")
+	}
+}
+
+func TestStorageStateCookies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	err := os.WriteFile(path, []byte(`{"cookies":[
+		{"name":"sid","value":"abc","domain":".app.test","httpOnly":true,"sameSite":"Lax","expires":1893456000},
+		{"name":"theme","value":"dark","url":"https://app.test"}],
+		"origins":[{"origin":"https://app.test","localStorage":[{"name":"token","value":"tok-1"}]}]}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := LoadStorageState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Origins) != 1 || state.Origins[0].LocalStorage[0].Value != "tok-1" {
+		t.Errorf("unexpected origins %+v", state.Origins)
+	}
+
+	cookies := state.playwrightCookies()
+	if len(cookies) != 2 {
+		t.Fatalf("expected 2 cookies, got %d", len(cookies))
+	}
+	sid := cookies[0]
+	if *sid.Domain != ".app.test" || *sid.Path != "/" || !*sid.HttpOnly || *sid.SameSite != *playwright.SameSiteAttributeLax || *sid.Expires != 1893456000 || sid.URL != nil {
+		t.Errorf("unexpected domain cookie %+v", sid)
+	}
+	theme := cookies[1]
+	if *theme.URL != "https://app.test" || theme.Domain != nil || theme.Path != nil || theme.Expires != nil {
+		t.Errorf("unexpected URL cookie %+v", theme)
+	}
+
+	if _, err := LoadStorageState(filepath.Join(t.TempDir(), "missing.json")); err == nil || !strings.Contains(err.Error(), "failed to read storage state") {
+		t.Errorf("expected a missing file to fail, got %v", err)
+	}
+}
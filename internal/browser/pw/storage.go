@@ -0,0 +1,142 @@
+package pw
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// StorageState is a browser's cookies and local storage, in the format of the
+// files Playwright's storage_state saves
+type StorageState struct {
+	Cookies []Cookie        `json:"cookies,omitempty"`
+	Origins []OriginStorage `json:"origins,omitempty"`
+}
+
+// Cookie is a cookie to set. Either URL, or Domain and Path, say where it applies.
+type Cookie struct {
+	Name     string  `json:"name"`
+	Value    string  `json:"value"`
+	URL      string  `json:"url,omitempty"`
+	Domain   string  `json:"domain,omitempty"`
+	Path     string  `json:"path,omitempty"`
+	Expires  float64 `json:"expires,omitempty"` // Unix seconds; a session cookie when 0 or -1
+	HTTPOnly bool    `json:"httpOnly,omitempty"`
+	Secure   bool    `json:"secure,omitempty"`
+	SameSite string  `json:"sameSite,omitempty"` // Strict, Lax or None
+}
+
+// OriginStorage is the local storage of one origin, e.g. https://app.example.com
+type OriginStorage struct {
+	Origin       string        `json:"origin"`
+	LocalStorage []StorageItem `json:"localStorage"`
+}
+
+// StorageItem is a local storage entry
+type StorageItem struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// LoadStorageState reads a storage state file
+func LoadStorageState(path string) (*StorageState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read storage state: %w", err)
+	}
+	state := &StorageState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse storage state %s: %w", path, err)
+	}
+	return state, nil
+}
+
+// playwrightCookies converts the state's cookies for BrowserContext.AddCookies
+func (s *StorageState) playwrightCookies() []playwright.OptionalCookie {
+	cookies := make([]playwright.OptionalCookie, 0, len(s.Cookies))
+	for _, c := range s.Cookies {
+		cookie := playwright.OptionalCookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			HttpOnly: playwright.Bool(c.HTTPOnly),
+			Secure:   playwright.Bool(c.Secure),
+		}
+		if c.URL != "" {
+			cookie.URL = playwright.String(c.URL)
+		} else {
+			path := c.Path
+			if path == "" {
+				path = "/"
+			}
+			cookie.Domain, cookie.Path = playwright.String(c.Domain), playwright.String(path)
+		}
+		if c.Expires > 0 {
+			cookie.Expires = playwright.Float(c.Expires)
+		}
+		if c.SameSite != "" {
+			sameSite := playwright.SameSiteAttribute(c.SameSite)
+			cookie.SameSite = &sameSite
+		}
+		cookies = append(cookies, cookie)
+	}
+	return cookies
+}
+
+// SetStorageState adds the state's cookies to the page's browser context and
+// fills in each origin's local storage. Local storage is written from a
+// temporary page whose requests are answered with an empty document, so nothing
+// is sent to the sites and the tab keeps its document.
+func (t *Tab) SetStorageState(ctx context.Context, state *StorageState) error {
+	if len(state.Cookies) > 0 {
+		if err := t.Context.AddCookies(state.playwrightCookies()); err != nil {
+			return fmt.Errorf("failed to set cookies: %w", Reason(err))
+		}
+	}
+
+	var origins []OriginStorage
+	for _, origin := range state.Origins {
+		if len(origin.LocalStorage) > 0 {
+			origins = append(origins, origin)
+		}
+	}
+	if len(origins) == 0 {
+		return nil
+	}
+
+	page, err := t.Context.NewPage()
+	if err != nil {
+		return fmt.Errorf("failed to open a page for local storage: %w", Reason(err))
+	}
+	defer func() { _ = page.Close() }()
+	err = page.Route(func(string) bool { return true }, func(route playwright.Route) {
+		_ = route.Fulfill(playwright.RouteFulfillOptions{
+			Status:      playwright.Int(200),
+			ContentType: playwright.String("text/html"),
+			Body:        "<!doctype html><title></title>",
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to intercept the local storage page: %w", Reason(err))
+	}
+
+	tab := &Tab{Page: page, Context: t.Context}
+	for _, origin := range origins {
+		u, err := url.Parse(origin.Origin)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid origin %q: expected e.g. https://app.example.com", origin.Origin)
+		}
+		if err := tab.Navigate(ctx, u.Scheme+"://"+u.Host+"/"); err != nil {
+			return fmt.Errorf("failed to open %s for local storage: %w", origin.Origin, err)
+		}
+		items, _ := json.Marshal(origin.LocalStorage)
+		script := fmt.Sprintf("(items => { for (const item of items) localStorage.setItem(item.name, item.value) })(%s)", items)
+		if err := tab.Evaluate(ctx, script, nil); err != nil {
+			return fmt.Errorf("failed to set local storage for %s: %w", origin.Origin, err)
+		}
+	}
+	return nil
+}
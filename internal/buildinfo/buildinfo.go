@@ -0,0 +1,154 @@
+// Package buildinfo identifies the running Rocketship build. Workers advertise
+// their version and image through their Temporal identity so the engine can
+// check a suite's worker requirements before starting any workflows.
+package buildinfo
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rocketship-ai/rocketship/internal/embedded"
+)
+
+// Version is stamped at build time with
+// -ldflags "-X github.com/rocketship-ai/rocketship/internal/buildinfo.Version=v0.6.0"
+var Version = ""
+
+const (
+	// EnvWorkerVersion overrides the version a worker reports
+	EnvWorkerVersion = "ROCKETSHIP_WORKER_VERSION"
+	// EnvWorkerImage is the container image the worker runs, set by the Helm chart
+	EnvWorkerImage = "ROCKETSHIP_WORKER_IMAGE"
+)
+
+var (
+	identityVersionRegex  = regexp.MustCompile(`\brocketship-worker version=(\S+)`)
+	identityImageRegex    = regexp.MustCompile(`\bimage=(\S+)`)
+	identityPluginsRegex  = regexp.MustCompile(`\bplugins=(\S*)`)
+	identityFeaturesRegex = regexp.MustCompile(`\bfeatures=(\S*)`)
+)
+
+// WorkerBuild describes the build a worker is running
+type WorkerBuild struct {
+	Version string `json:"version"`
+	Image   string `json:"image,omitempty"`
+}
+
+// CurrentWorker returns the build of this process
+func CurrentWorker() WorkerBuild {
+	version := strings.TrimSpace(os.Getenv(EnvWorkerVersion))
+	if version == "" {
+		version = Version
+	}
+	if version == "" {
+		version = embedded.DefaultVersion
+	}
+	return WorkerBuild{
+		Version: version,
+		Image:   strings.TrimSpace(os.Getenv(EnvWorkerImage)),
+	}
+}
+
+// Identity appends the build to a Temporal worker identity, e.g.
+// "1234@host rocketship-worker version=v0.6.0 image=rocketshipai/rocketship-worker:v0.6.0"
+func (b WorkerBuild) Identity(base string) string {
+	identity := fmt.Sprintf("%s rocketship-worker version=%s", base, b.Version)
+	if b.Image != "" {
+		identity += " image=" + b.Image
+	}
+	return identity
+}
+
+// ParseIdentity extracts the build from a worker identity. Workers that predate
+// version reporting return false.
+func ParseIdentity(identity string) (WorkerBuild, bool) {
+	match := identityVersionRegex.FindStringSubmatch(identity)
+	if match == nil {
+		return WorkerBuild{}, false
+	}
+	build := WorkerBuild{Version: match[1]}
+	if image := identityImageRegex.FindStringSubmatch(identity); image != nil {
+		build.Image = image[1]
+	}
+	return build, true
+}
+
+// Capabilities lists what a worker can run: the plugins it registered and the
+// DSL features its workflows understand. Plugins ship with the worker, so their
+// version is the worker's version.
+type Capabilities struct {
+	Plugins  []string `json:"plugins,omitempty"`
+	Features []string `json:"features,omitempty"`
+}
+
+// AppendTo adds the capabilities to a worker identity, e.g.
+// "... rocketship-worker version=v0.6.0 plugins=http,sql features=step_retry"
+func (c Capabilities) AppendTo(identity string) string {
+	return fmt.Sprintf("%s plugins=%s features=%s", identity, strings.Join(c.Plugins, ","), strings.Join(c.Features, ","))
+}
+
+// ParseCapabilities extracts the capabilities from a worker identity. Workers that
+// predate capability reporting return false.
+func ParseCapabilities(identity string) (Capabilities, bool) {
+	plugins := identityPluginsRegex.FindStringSubmatch(identity)
+	if plugins == nil {
+		return Capabilities{}, false
+	}
+	caps := Capabilities{Plugins: splitList(plugins[1])}
+	if features := identityFeaturesRegex.FindStringSubmatch(identity); features != nil {
+		caps.Features = splitList(features[1])
+	}
+	return caps, true
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// CompareVersions compares two versions like "v0.6.0" or "0.6". Pre-release and
+// build suffixes are ignored. It returns -1, 0 or 1.
+func CompareVersions(a, b string) (int, error) {
+	pa, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	pb, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	for i := range pa {
+		switch {
+		case pa[i] < pb[i]:
+			return -1, nil
+		case pa[i] > pb[i]:
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func parseVersion(v string) ([3]int, error) {
+	var parts [3]int
+	trimmed := strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(trimmed, "-+"); i >= 0 {
+		trimmed = trimmed[:i]
+	}
+	fields := strings.Split(trimmed, ".")
+	if trimmed == "" || len(fields) > 3 {
+		return parts, fmt.Errorf("invalid version %q", v)
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return parts, fmt.Errorf("invalid version %q", v)
+		}
+		parts[i] = n
+	}
+	return parts, nil
+}
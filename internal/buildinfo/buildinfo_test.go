@@ -0,0 +1,87 @@
+package buildinfo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIdentityRoundTrip(t *testing.T) {
+	build := WorkerBuild{Version: "v0.6.0", Image: "rocketshipai/rocketship-worker:v0.6.0"}
+	parsed, ok := ParseIdentity(build.Identity("42@worker-0"))
+	if !ok {
+		t.Fatal("expected identity to be parsed")
+	}
+	if parsed != build {
+		t.Errorf("got %+v, want %+v", parsed, build)
+	}
+
+	parsed, ok = ParseIdentity(WorkerBuild{Version: "v0.6.0"}.Identity("42@worker-0"))
+	if !ok || parsed.Version != "v0.6.0" || parsed.Image != "" {
+		t.Errorf("unexpected build without image: %+v", parsed)
+	}
+
+	if _, ok := ParseIdentity("42@worker-0"); ok {
+		t.Error("expected identities without a version to be rejected")
+	}
+}
+
+func TestCapabilitiesRoundTrip(t *testing.T) {
+	build := WorkerBuild{Version: "v0.6.0", Image: "rocketshipai/rocketship-worker:v0.6.0"}
+	caps := Capabilities{Plugins: []string{"http", "sql"}, Features: []string{"step_retry"}}
+	identity := caps.AppendTo(build.Identity("42@worker-0"))
+
+	parsed, ok := ParseCapabilities(identity)
+	if !ok {
+		t.Fatal("expected capabilities to be parsed")
+	}
+	if !reflect.DeepEqual(parsed, caps) {
+		t.Errorf("got %+v, want %+v", parsed, caps)
+	}
+	if parsedBuild, ok := ParseIdentity(identity); !ok || parsedBuild != build {
+		t.Errorf("capabilities broke build parsing: %+v", parsedBuild)
+	}
+
+	parsed, ok = ParseCapabilities(Capabilities{Plugins: []string{"http"}}.AppendTo("42@worker-0"))
+	if !ok || len(parsed.Features) != 0 {
+		t.Errorf("unexpected capabilities without features: %+v", parsed)
+	}
+
+	if _, ok := ParseCapabilities(build.Identity("42@worker-0")); ok {
+		t.Error("expected identities without capabilities to be rejected")
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v0.6.0", "v0.6.0", 0},
+		{"v0.5.42", "v0.6.0", -1},
+		{"v0.10.0", "v0.9.9", 1},
+		{"0.6", "v0.6.0", 0},
+		{"v1.0.0-rc1", "v1.0.0", 0},
+	}
+	for _, tt := range tests {
+		got, err := CompareVersions(tt.a, tt.b)
+		if err != nil {
+			t.Fatalf("CompareVersions(%q, %q): %v", tt.a, tt.b, err)
+		}
+		if got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+
+	if _, err := CompareVersions("latest", "v0.6.0"); err == nil {
+		t.Error("expected non-numeric versions to be rejected")
+	}
+}
+
+func TestCurrentWorker(t *testing.T) {
+	t.Setenv(EnvWorkerVersion, "v9.9.9")
+	t.Setenv(EnvWorkerImage, "example/worker:v9.9.9")
+	build := CurrentWorker()
+	if build.Version != "v9.9.9" || build.Image != "example/worker:v9.9.9" {
+		t.Errorf("unexpected build %+v", build)
+	}
+}
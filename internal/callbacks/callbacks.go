@@ -0,0 +1,173 @@
+// Package callbacks holds what the engine, the test workflows and the
+// webhook_wait plugin share about inbound callbacks: the captured request, how
+// a step filters and answers calls, and the signal and query that carry them
+// from the engine to the workflow waiting on them.
+package callbacks
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// PathPrefix is where the engine serves callback URLs, <base>/hooks/<run id>/<id>
+	PathPrefix   = "/hooks/"
+	MaxBodyBytes = 1 << 20
+
+	// CallSignal carries a Call from the engine to a workflow
+	CallSignal = "webhook_wait_call"
+	// HookQuery asks a workflow whether a step is waiting on a callback id and
+	// gets back a Hook
+	HookQuery = "webhook_wait_hook"
+
+	// Phases of a webhook_wait step, passed to the plugin's activity as the phase
+	// param. The workflow waits for the call between trigger and finish.
+	PhaseStart   = "start"   // Render the config and return the Wait
+	PhaseTrigger = "trigger" // Send the trigger once the workflow waits on the callback
+	PhaseFinish  = "finish"  // Check and save the captured call
+
+	// Calls for a callback no step is waiting on yet are kept this long, so a call
+	// made by an earlier step isn't lost
+	EarlyCallTTL  = 5 * time.Minute
+	MaxEarlyCalls = 100
+)
+
+// Request is a captured inbound call
+type Request struct {
+	Method     string            `json:"method"`
+	Path       string            `json:"path"` // Path after the callback URL, e.g. /status for <url>/status
+	Query      map[string]string `json:"query"`
+	Headers    map[string]string `json:"headers"`
+	Body       interface{}       `json:"body"` // Decoded when the body is JSON, the raw text otherwise
+	RawBody    string            `json:"raw_body"`
+	ReceivedAt string            `json:"received_at"`
+}
+
+// Match selects the call to capture. Calls that don't match are answered and
+// ignored. Every set field must match.
+type Match struct {
+	Method   string            `json:"method,omitempty" yaml:"method,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`   // Exact values, header names are case-insensitive
+	Contains string            `json:"contains,omitempty" yaml:"contains,omitempty"` // Substring of the raw body
+}
+
+// Respond is the reply sent to the caller
+type Respond struct {
+	Status  int               `json:"status,omitempty" yaml:"status,omitempty"` // Defaults to 200
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body    string            `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// Call is a call to the callback id, as signaled to a workflow
+type Call struct {
+	ID      string   `json:"id"`
+	Request *Request `json:"request"`
+}
+
+// Hook is a workflow's answer to HookQuery
+type Hook struct {
+	Waiting bool    `json:"waiting"`
+	Respond Respond `json:"respond"`
+}
+
+// Wait is the rendered callback a webhook_wait step waits on. The plugin
+// returns it to the workflow, which waits for the call and hands it back.
+type Wait struct {
+	ID      string        `json:"id"`
+	URL     string        `json:"url"`
+	Timeout time.Duration `json:"timeout"`
+	Match   *Match        `json:"match,omitempty"`
+	Respond Respond       `json:"respond"`
+	Trigger interface{}   `json:"trigger,omitempty"` // The rendered trigger, sent by the plugin once the workflow waits
+}
+
+// URL returns the callback URL for id in the run
+func URL(baseURL, runID, id string) string {
+	return strings.TrimRight(baseURL, "/") + PathPrefix + url.PathEscape(runID) + "/" + url.PathEscape(id)
+}
+
+// NewRequest captures r, with path the part of the URL after the callback URL
+func NewRequest(r *http.Request, path string, raw []byte, at time.Time) *Request {
+	request := &Request{
+		Method:     r.Method,
+		Path:       path,
+		Query:      make(map[string]string),
+		Headers:    make(map[string]string),
+		RawBody:    string(raw),
+		ReceivedAt: at.UTC().Format(time.RFC3339Nano),
+	}
+	for name, values := range r.URL.Query() {
+		request.Query[name] = strings.Join(values, ",")
+	}
+	for name, values := range r.Header {
+		request.Headers[name] = strings.Join(values, ", ")
+	}
+
+	request.Body = request.RawBody
+	var decoded interface{}
+	if len(raw) > 0 && json.Unmarshal(raw, &decoded) == nil {
+		request.Body = decoded
+	}
+	return request
+}
+
+// Matches reports whether request satisfies every field of the filter. A nil
+// filter matches every call.
+func (m *Match) Matches(request *Request) bool {
+	if m == nil {
+		return true
+	}
+	if m.Method != "" && !strings.EqualFold(request.Method, m.Method) {
+		return false
+	}
+	for name, want := range m.Headers {
+		got, found := LookupHeader(request.Headers, name)
+		if !found || got != want {
+			return false
+		}
+	}
+	if m.Contains != "" && !strings.Contains(request.RawBody, m.Contains) {
+		return false
+	}
+	return true
+}
+
+// LookupHeader finds a header regardless of the case of its name
+func LookupHeader(headers map[string]string, name string) (string, bool) {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// NoCallError is the failure of a step no matching call reached in time
+func NoCallError(callbackURL string, match *Match, timeout time.Duration) error {
+	var parts []string
+	if match != nil {
+		if match.Method != "" {
+			parts = append(parts, fmt.Sprintf("method %s", strings.ToUpper(match.Method)))
+		}
+		names := make([]string, 0, len(match.Headers))
+		for name := range match.Headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			parts = append(parts, fmt.Sprintf("header %s=%q", name, match.Headers[name]))
+		}
+		if match.Contains != "" {
+			parts = append(parts, fmt.Sprintf("body containing %q", match.Contains))
+		}
+	}
+	if len(parts) == 0 {
+		return fmt.Errorf("no call to %s arrived within %s", callbackURL, timeout)
+	}
+	return fmt.Errorf("no call to %s with %s arrived within %s", callbackURL, strings.Join(parts, ", "), timeout)
+}
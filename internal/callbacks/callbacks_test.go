@@ -0,0 +1,48 @@
+package callbacks
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewRequestAndMatch(t *testing.T) {
+	r := httptest.NewRequest("POST", "/hooks/run-1/charge/events?attempt=1&attempt=2", strings.NewReader(`{"status":"succeeded"}`))
+	r.Header.Set("X-Signature", "sig")
+	request := NewRequest(r, "/events", []byte(`{"status":"succeeded"}`), time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	if request.Path != "/events" || request.Query["attempt"] != "1,2" || request.ReceivedAt != "2026-01-02T03:04:05Z" {
+		t.Errorf("unexpected request %+v", request)
+	}
+	if body, ok := request.Body.(map[string]interface{}); !ok || body["status"] != "succeeded" {
+		t.Errorf("expected a decoded JSON body, got %#v", request.Body)
+	}
+
+	tests := []struct {
+		match *Match
+		want  bool
+	}{
+		{nil, true},
+		{&Match{Method: "post", Headers: map[string]string{"x-signature": "sig"}, Contains: "succeeded"}, true},
+		{&Match{Method: "PUT"}, false},
+		{&Match{Headers: map[string]string{"X-Signature": "other"}}, false},
+		{&Match{Contains: "failed"}, false},
+	}
+	for _, tc := range tests {
+		if got := tc.match.Matches(request); got != tc.want {
+			t.Errorf("%+v: expected %v, got %v", tc.match, tc.want, got)
+		}
+	}
+}
+
+func TestURLAndNoCallError(t *testing.T) {
+	if got := URL("https://hooks.example.com/", "run-1", "order 7"); got != "https://hooks.example.com/hooks/run-1/order%207" {
+		t.Errorf("unexpected URL %s", got)
+	}
+
+	err := NoCallError("https://hooks.example.com/hooks/run-1/never", &Match{Method: "post", Contains: "paid"}, 50*time.Millisecond)
+	if err.Error() != `no call to https://hooks.example.com/hooks/run-1/never with method POST, body containing "paid" arrived within 50ms` {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
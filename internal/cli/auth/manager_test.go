@@ -91,3 +91,25 @@ func TestFileStorePathSanitisation(t *testing.T) {
 		t.Fatalf("expected file to exist: %v", err)
 	}
 }
+
+func TestFileStoreEngineKey(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	key := EngineKey("Staging.Example.com:443")
+	if key != "engine:staging.example.com:443" {
+		t.Fatalf("unexpected engine key %q", key)
+	}
+	td := TokenData{AccessToken: "abc", TokenType: "Bearer"}
+	if err := store.Save(key, td); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "engine_staging.example.com_443.json")); err != nil {
+		t.Fatalf("expected file to exist: %v", err)
+	}
+	if _, err := store.Load("staging"); !errors.Is(err, ErrTokenNotFound) {
+		t.Fatalf("expected profile lookup to miss engine token, got %v", err)
+	}
+}
@@ -14,6 +14,20 @@ var (
 	ErrTokenNotFound = errors.New("token not found")
 )
 
+const enginePrefix = "engine:"
+
+// EngineKey returns the store key for tokens obtained with `rocketship login --engine`.
+// They are keyed by dial target (host:port) so every command pointed at the same
+// engine picks them up, whichever form of the address it was given.
+func EngineKey(target string) string {
+	return enginePrefix + strings.ToLower(target)
+}
+
+// IsEngineKey reports whether key was produced by EngineKey
+func IsEngineKey(key string) bool {
+	return strings.HasPrefix(key, enginePrefix)
+}
+
 // Store persists per-profile token data.
 type Store interface {
 	Save(profile string, data TokenData) error
@@ -32,6 +46,9 @@ func NewKeyringStore(service string) *KeyringStore {
 
 func (s *KeyringStore) key(profile string) string {
 	// Keep key deterministic yet human readable.
+	if IsEngineKey(profile) {
+		return profile
+	}
 	return fmt.Sprintf("profile:%s", profile)
 }
 
@@ -81,6 +98,10 @@ func NewFileStore(dir string) (*FileStore, error) {
 
 func (s *FileStore) path(profile string) string {
 	sanitized := strings.ReplaceAll(profile, string(os.PathSeparator), "_")
+	if IsEngineKey(profile) {
+		// Colons aren't valid in Windows file names
+		sanitized = strings.ReplaceAll(sanitized, ":", "_")
+	}
 	return filepath.Join(s.dir, sanitized+".json")
 }
 
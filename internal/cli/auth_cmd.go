@@ -17,51 +17,125 @@ import (
 )
 
 func NewLoginCmd() *cobra.Command {
-	var profileName string
+	var profileName, engine, projectID string
+	var ciOIDC, githubOIDC bool
 	cmd := &cobra.Command{
 		Use:   "login",
 		Short: "Authenticate the CLI via OIDC device flow",
+		Long: `Authenticate the CLI via OIDC device flow.
+
+Tokens are stored per profile, or per engine when --engine is given, so you can stay
+logged in to several engines at once. Commands pick the matching token from the
+profile they use or the address passed to their --engine flag.
+
+In CI, --ci-oidc exchanges the job's OIDC ID token at the auth broker for a short-lived
+token scoped to the projects connected to the repository, so no long-lived secret is
+needed. GitHub Actions jobs need the id-token: write permission; GitLab CI jobs declare
+a ROCKETSHIP_ID_TOKEN entry under id_tokens with the engine's audience.`,
+		Example: `  rocketship login
+  rocketship login --engine grpcs://grpc.staging.example.com
+  rocketship login --engine grpcs://grpc.example.com --ci-oidc --project-id <id>`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runLogin(cmd.Context(), profileName)
+			if ciOIDC || githubOIDC {
+				return runCIOIDCLogin(cmd.Context(), profileName, engine, projectID)
+			}
+			if projectID != "" {
+				return errors.New("--project-id is only supported with --ci-oidc")
+			}
+			return runLogin(cmd.Context(), profileName, engine)
 		},
 	}
 	cmd.Flags().StringVarP(&profileName, "profile", "p", "", "Profile to authenticate (defaults to active profile)")
+	cmd.Flags().StringVarP(&engine, "engine", "e", "", "Engine address to authenticate against instead of a profile")
+	cmd.Flags().BoolVar(&ciOIDC, "ci-oidc", false, "Exchange the CI job's OIDC token (GitHub Actions, GitLab CI) for a short-lived token")
+	cmd.Flags().BoolVar(&githubOIDC, "github-oidc", false, "Alias for --ci-oidc, kept for existing GitHub Actions workflows")
+	cmd.Flags().StringVar(&projectID, "project-id", "", "Limit the exchanged token to one project (with --ci-oidc)")
 	return cmd
 }
 
 func NewLogoutCmd() *cobra.Command {
-	var profileName string
+	var profileName, engine string
 	cmd := &cobra.Command{
 		Use:   "logout",
 		Short: "Remove stored authentication tokens",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runLogout(profileName)
+			return runLogout(profileName, engine)
 		},
 	}
 	cmd.Flags().StringVarP(&profileName, "profile", "p", "", "Profile to clear (defaults to active profile)")
+	cmd.Flags().StringVarP(&engine, "engine", "e", "", "Engine address to clear instead of a profile")
 	return cmd
 }
 
 func NewAuthStatusCmd() *cobra.Command {
-	var profileName string
+	var profileName, engine string
 	cmd := &cobra.Command{
 		Use:   "status",
 		Short: "Show authentication status",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runAuthStatus(profileName)
+			return runAuthStatus(profileName, engine)
 		},
 	}
 	cmd.Flags().StringVarP(&profileName, "profile", "p", "", "Profile to inspect (defaults to active profile)")
+	cmd.Flags().StringVarP(&engine, "engine", "e", "", "Engine address to inspect instead of a profile")
 	return cmd
 }
 
-func runLogin(ctx context.Context, profileFlag string) error {
+// authTarget is where a login's tokens are stored: a profile, or an engine address
+// given with --engine.
+type authTarget struct {
+	kind    string // "profile" or "engine"
+	name    string // profile name or engine dial target
+	key     string // token store key
+	address string // engine address, set for engine targets
+	cfg     *Config
+	profile Profile
+}
+
+func (t authTarget) String() string {
+	return t.kind + " " + t.name
+}
+
+func (t authTarget) title() string {
+	if t.kind == "engine" {
+		return "Engine"
+	}
+	return "Profile"
+}
+
+func (t authTarget) client() (*EngineClient, error) {
+	if t.kind == "engine" {
+		return NewEngineClient(t.address)
+	}
+	return newProfileClient(t.profile)
+}
+
+func resolveAuthTarget(profileFlag, engineFlag string) (authTarget, error) {
+	if engineFlag != "" {
+		if profileFlag != "" {
+			return authTarget{}, errors.New("--profile and --engine cannot be used together")
+		}
+		target, _, _, err := parseExplicitAddress(engineFlag)
+		if err != nil {
+			return authTarget{}, fmt.Errorf("invalid engine address: %w", err)
+		}
+		return authTarget{kind: "engine", name: target, key: auth.EngineKey(target), address: engineFlag}, nil
+	}
+
 	cfg, profile, name, err := resolveProfile(profileFlag)
+	if err != nil {
+		return authTarget{}, err
+	}
+	return authTarget{kind: "profile", name: name, key: name, cfg: cfg, profile: profile}, nil
+}
+
+func runLogin(ctx context.Context, profileFlag, engineFlag string) error {
+	target, err := resolveAuthTarget(profileFlag, engineFlag)
 	if err != nil {
 		return err
 	}
 
-	client, err := newProfileClient(profile)
+	client, err := target.client()
 	if err != nil {
 		return err
 	}
@@ -76,10 +150,10 @@ func runLogin(ctx context.Context, profileFlag string) error {
 		return fmt.Errorf("failed to fetch server info: %w", err)
 	}
 	if !strings.EqualFold(info.AuthType, "oidc") {
-		return fmt.Errorf("profile %s is not configured for OIDC auth (reported type: %s)", name, info.AuthType)
+		return fmt.Errorf("%s is not configured for OIDC auth (reported type: %s)", target, info.AuthType)
 	}
 
-	flowCfg, err := buildFlowConfig(profile, info)
+	flowCfg, err := buildFlowConfig(target.profile, info)
 	if err != nil {
 		return err
 	}
@@ -111,23 +185,26 @@ func runLogin(ctx context.Context, profileFlag string) error {
 	if err != nil {
 		return err
 	}
-	if err := manager.Save(name, tokenData); err != nil {
+	if err := manager.Save(target.key, tokenData); err != nil {
 		return err
 	}
 
 	// Persist issuer/client metadata for the profile so status and future logins have context
-	profile.Auth.Issuer = flowCfg.Issuer
-	profile.Auth.ClientID = flowCfg.ClientID
-	cfg.AddProfile(profile)
-	if err := cfg.SaveConfig(); err != nil {
-		return fmt.Errorf("failed to persist profile metadata: %w", err)
+	if target.cfg != nil {
+		profile := target.profile
+		profile.Auth.Issuer = flowCfg.Issuer
+		profile.Auth.ClientID = flowCfg.ClientID
+		target.cfg.AddProfile(profile)
+		if err := target.cfg.SaveConfig(); err != nil {
+			return fmt.Errorf("failed to persist profile metadata: %w", err)
+		}
 	}
 
 	fmt.Println("✅ Login complete")
-	if err := maybeRunOnboarding(ctx, name, tokenData, manager); err != nil {
+	if err := maybeRunOnboarding(ctx, target.key, tokenData, manager); err != nil {
 		return err
 	}
-	if updated, err := manager.Load(name); err == nil && updated.IDToken != "" {
+	if updated, err := manager.Load(target.key); err == nil && updated.IDToken != "" {
 		if user, derr := decodeIDToken(updated.IDToken); derr == nil {
 			fmt.Printf("Authenticated as %s\n", user)
 		}
@@ -135,8 +212,86 @@ func runLogin(ctx context.Context, profileFlag string) error {
 	return nil
 }
 
-func runLogout(profileFlag string) error {
-	_, _, name, err := resolveProfile(profileFlag)
+// runCIOIDCLogin stores a token obtained by exchanging the CI job's ID token at the
+// auth broker, which checks the token's repository claims against the projects it
+// may access.
+func runCIOIDCLogin(ctx context.Context, profileFlag, engineFlag, projectID string) error {
+	target, err := resolveAuthTarget(profileFlag, engineFlag)
+	if err != nil {
+		return err
+	}
+
+	client, err := target.client()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := client.Close(); cerr != nil {
+			Logger.Debug("failed to close login client", "error", cerr)
+		}
+	}()
+
+	info, err := client.GetServerInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch server info: %w", err)
+	}
+	if !strings.EqualFold(info.AuthType, "oidc") {
+		return fmt.Errorf("%s is not configured for OIDC auth (reported type: %s)", target, info.AuthType)
+	}
+	if info.TokenEndpoint == "" {
+		return errors.New("token endpoint missing from discovery")
+	}
+
+	audience := info.Audience
+	if audience == "" {
+		audience = "rocketship"
+	}
+	provider, idToken, err := oidc.CIIDToken(ctx, audience)
+	if err != nil {
+		return err
+	}
+
+	tokenData, err := oidc.ExchangeIDToken(ctx, oidc.ExchangeConfig{
+		TokenEndpoint: info.TokenEndpoint,
+		ClientID:      info.ClientID,
+		Audience:      info.Audience,
+		ProjectID:     projectID,
+		SubjectToken:  idToken,
+	})
+	if err != nil {
+		return err
+	}
+	tokenData.Issuer = info.Issuer
+
+	manager, err := auth.NewManager()
+	if err != nil {
+		return err
+	}
+	if err := manager.Save(target.key, tokenData); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Logged in to %s with the %s OIDC token", target, ciProviderLabel(provider))
+	if !tokenData.Expiry.IsZero() {
+		fmt.Printf(" (expires in %s)", time.Until(tokenData.Expiry).Round(time.Second))
+	}
+	fmt.Println()
+	return nil
+}
+
+func ciProviderLabel(provider string) string {
+	switch provider {
+	case oidc.CIProviderGitHub:
+		return "GitHub Actions"
+	case oidc.CIProviderGitLab:
+		return "GitLab CI"
+	default:
+		return provider
+	}
+}
+
+func runLogout(profileFlag, engineFlag string) error {
+	target, err := resolveAuthTarget(profileFlag, engineFlag)
 	if err != nil {
 		return err
 	}
@@ -145,15 +300,15 @@ func runLogout(profileFlag string) error {
 	if err != nil {
 		return err
 	}
-	if err := manager.Delete(name); err != nil {
+	if err := manager.Delete(target.key); err != nil {
 		return err
 	}
-	fmt.Printf("Logged out of profile %s\n", name)
+	fmt.Printf("Logged out of %s\n", target)
 	return nil
 }
 
-func runAuthStatus(profileFlag string) error {
-	_, profile, name, err := resolveProfile(profileFlag)
+func runAuthStatus(profileFlag, engineFlag string) error {
+	target, err := resolveAuthTarget(profileFlag, engineFlag)
 	if err != nil {
 		return err
 	}
@@ -162,18 +317,20 @@ func runAuthStatus(profileFlag string) error {
 	if err != nil {
 		return err
 	}
-	tokenData, err := manager.Load(name)
+	tokenData, err := manager.Load(target.key)
 	if err != nil {
 		if errors.Is(err, auth.ErrTokenNotFound) {
-			fmt.Printf("Profile %s: not logged in\n", name)
+			fmt.Printf("%s %s: not logged in\n", target.title(), target.name)
 			return nil
 		}
 		return err
 	}
 
-	fmt.Printf("Profile: %s\n", name)
-	if profile.Auth.Issuer != "" {
-		fmt.Printf("Issuer: %s\n", profile.Auth.Issuer)
+	fmt.Printf("%s: %s\n", target.title(), target.name)
+	if target.profile.Auth.Issuer != "" {
+		fmt.Printf("Issuer: %s\n", target.profile.Auth.Issuer)
+	} else if tokenData.Issuer != "" {
+		fmt.Printf("Issuer: %s\n", tokenData.Issuer)
 	}
 	if tokenData.Audience != "" {
 		fmt.Printf("Audience: %s\n", tokenData.Audience)
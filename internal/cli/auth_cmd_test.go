@@ -41,3 +41,20 @@ func TestDecodeIDToken(t *testing.T) {
 		t.Fatalf("expected email, got %s", user)
 	}
 }
+
+func TestResolveAuthTargetEngine(t *testing.T) {
+	target, err := resolveAuthTarget("", "grpcs://Grpc.Staging.example.com")
+	if err != nil {
+		t.Fatalf("resolveAuthTarget failed: %v", err)
+	}
+	if target.key != "engine:grpc.staging.example.com:443" {
+		t.Fatalf("unexpected token key %q", target.key)
+	}
+	if target.String() != "engine Grpc.Staging.example.com:443" {
+		t.Fatalf("unexpected label %q", target.String())
+	}
+
+	if _, err := resolveAuthTarget("prod", "grpc.staging.example.com:7700"); err == nil {
+		t.Fatal("expected error when both --profile and --engine are set")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"net"
 	"net/url"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -37,6 +38,7 @@ const (
 	authorizationValue             = "authorization"
 	sourceEnv          tokenSource = "env"
 	sourceStore        tokenSource = "profile"
+	sourceEngine       tokenSource = "engine"
 )
 
 func NewEngineClient(address string) (*EngineClient, error) {
@@ -50,7 +52,7 @@ func NewEngineClient(address string) (*EngineClient, error) {
 
 	dialOpts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
 	hasAuth := false
-	if token, src, err := resolveAuthToken(usedProfile, profileName); err != nil {
+	if token, src, err := resolveAuthToken(target, usedProfile, profileName); err != nil {
 		return nil, err
 	} else if token != "" {
 		Logger.Debug("attaching bearer token", "source", string(src))
@@ -172,7 +174,7 @@ func (c *EngineClient) AddLog(ctx context.Context, runID, workflowID, message, c
 	return err
 }
 
-func (c *EngineClient) AddLogWithContext(ctx context.Context, runID, workflowID, message, color string, bold bool, testName, stepName string) error {
+func (c *EngineClient) AddLogWithContext(ctx context.Context, runID, workflowID, message, color string, bold bool, testName, stepName, level string, fields map[string]string) error {
 	_, err := c.client.AddLog(ctx, &generated.AddLogRequest{
 		RunId:      runID,
 		WorkflowId: workflowID,
@@ -181,6 +183,8 @@ func (c *EngineClient) AddLogWithContext(ctx context.Context, runID, workflowID,
 		Bold:       bold,
 		TestName:   testName,
 		StepName:   stepName,
+		Level:      level,
+		Fields:     fields,
 	})
 	if err != nil {
 		if wrapped := translateAuthError("failed to add log", err); wrapped != nil {
@@ -209,6 +213,8 @@ type UpsertRunStepRequest struct {
 	AssertionsJSON   []byte
 	VariablesJSON    []byte
 	StepConfigJSON   []byte
+	ArtifactsJSON    []byte
+	WorkerVersion    string
 }
 
 // UpsertRunStep reports a step result to the engine
@@ -231,6 +237,8 @@ func (c *EngineClient) UpsertRunStep(ctx context.Context, req UpsertRunStepReque
 		AssertionsJson:   req.AssertionsJSON,
 		VariablesJson:    req.VariablesJSON,
 		StepConfigJson:   req.StepConfigJSON,
+		ArtifactsJson:    req.ArtifactsJSON,
+		WorkerVersion:    req.WorkerVersion,
 	})
 	if err != nil {
 		if wrapped := translateAuthError("failed to upsert run step", err); wrapped != nil {
@@ -342,28 +350,78 @@ func (c *EngineClient) GetServerInfo(ctx context.Context) (*ServerInfo, error) {
 	return info, nil
 }
 
-func resolveAuthToken(usedProfile bool, profileName string) (string, tokenSource, error) {
+// resolveAuthToken picks the bearer token for the engine at target. ROCKETSHIP_TOKEN
+// wins; otherwise the active profile's token is used, then a token from
+// `rocketship login --engine`. For an explicit --engine address, tokens of profiles
+// pointing at the same engine are tried as well.
+func resolveAuthToken(target string, usedProfile bool, profileName string) (string, tokenSource, error) {
 	token := strings.TrimSpace(os.Getenv(tokenEnvVar))
 	if token != "" {
 		return token, sourceEnv, nil
 	}
-	if !usedProfile || profileName == "" {
-		return "", "", nil
-	}
 
 	manager, err := auth.NewManager()
 	if err != nil {
+		if !usedProfile {
+			Logger.Debug("skipping stored token lookup", "error", err)
+			return "", "", nil
+		}
 		return "", "", fmt.Errorf("failed to initialise token manager: %w", err)
 	}
 
-	token, err = manager.AccessToken(profileName, refreshStoredToken)
+	var keys []string
+	if usedProfile && profileName != "" {
+		keys = append(keys, profileName)
+	}
+	keys = append(keys, auth.EngineKey(target))
+	if !usedProfile {
+		keys = append(keys, profilesForTarget(target)...)
+	}
+
+	for i, key := range keys {
+		token, err = manager.AccessToken(key, refreshStoredToken)
+		if err != nil {
+			if errors.Is(err, auth.ErrTokenNotFound) {
+				continue
+			}
+			if i > 0 {
+				// Fallback tokens are best effort; a stale one shouldn't block the command
+				Logger.Debug("skipping unusable stored token", "key", key, "error", err)
+				continue
+			}
+			return "", "", fmt.Errorf("failed to obtain access token: %w", err)
+		}
+		if auth.IsEngineKey(key) {
+			return token, sourceEngine, nil
+		}
+		return token, sourceStore, nil
+	}
+	return "", "", nil
+}
+
+// profilesForTarget returns the names of profiles whose engine address dials target
+func profilesForTarget(target string) []string {
+	config, err := LoadConfig()
 	if err != nil {
-		if errors.Is(err, auth.ErrTokenNotFound) {
-			return "", "", nil
+		Logger.Debug("skipping profile token lookup", "error", err)
+		return nil
+	}
+
+	var names []string
+	for name, profile := range config.Profiles {
+		if profile.EngineAddress == "" {
+			continue
+		}
+		host, port, err := profileHostPort(profile)
+		if err != nil {
+			continue
+		}
+		if strings.EqualFold(net.JoinHostPort(host, port), target) {
+			names = append(names, name)
 		}
-		return "", "", fmt.Errorf("failed to obtain access token: %w", err)
 	}
-	return token, sourceStore, nil
+	sort.Strings(names)
+	return names
 }
 
 func refreshStoredToken(current auth.TokenData) (auth.TokenData, error) {
@@ -458,64 +516,48 @@ func resolveDialOptions(explicitAddress string) (string, credentials.TransportCr
 	}
 
 	Logger.Debug("Using engine address from active profile", "profile", profile.Name, "address", profile.EngineAddress)
-	// Parse profile.EngineAddress which may be a URL or bare host[:port]
+	host, port, err := profileHostPort(profile)
+	if err != nil {
+		return "", nil, profileName, true, err
+	}
+	target := net.JoinHostPort(host, port)
 	if profile.TLS.Enabled {
-		// TLS path
-		var host, port string
-		if hasScheme(profile.EngineAddress) {
-			u, perr := url.Parse(profile.EngineAddress)
-			if perr != nil {
-				return "", nil, profileName, true, fmt.Errorf("invalid profile engine address: %w", perr)
-			}
-			host = u.Hostname()
-			port = u.Port()
-			if port == "" {
-				port = "443"
-			}
-		} else {
-			h, p, errSplit := net.SplitHostPort(profile.EngineAddress)
-			if errSplit != nil {
-				// Assume missing port, default 443
-				host = profile.EngineAddress
-				port = "443"
-			} else {
-				host = h
-				port = p
-			}
-		}
 		sni := profile.TLS.Domain
 		if sni == "" {
 			sni = host
 		}
-		target := net.JoinHostPort(host, port)
 		tlsCfg := &tls.Config{ServerName: sni}
 		Logger.Debug("TLS enabled from profile", "address", target, "server_name", sni)
 		return target, credentials.NewTLS(tlsCfg), profileName, true, nil
 	}
-	// Insecure path
+	Logger.Debug("Using insecure transport from profile", "address", target)
+	return target, insecure.NewCredentials(), profileName, true, nil
+}
+
+// profileHostPort parses a profile's engine address, which may be a URL or bare
+// host[:port]. A missing port defaults to 443 with TLS and 7700 without.
+func profileHostPort(profile Profile) (string, string, error) {
+	defaultPort := "7700"
+	if profile.TLS.Enabled {
+		defaultPort = "443"
+	}
 	if hasScheme(profile.EngineAddress) {
-		u, perr := url.Parse(profile.EngineAddress)
-		if perr != nil {
-			return "", nil, profileName, true, fmt.Errorf("invalid profile engine address: %w", perr)
+		u, err := url.Parse(profile.EngineAddress)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid profile engine address: %w", err)
 		}
-		host := u.Hostname()
 		port := u.Port()
 		if port == "" {
-			port = "7700"
+			port = defaultPort
 		}
-		target := net.JoinHostPort(host, port)
-		Logger.Debug("Using insecure transport from profile", "address", target)
-		return target, insecure.NewCredentials(), profileName, true, nil
-	}
-	// Bare host[:port]
-	host, port, errSplit := net.SplitHostPort(profile.EngineAddress)
-	if errSplit != nil {
-		host = profile.EngineAddress
-		port = "7700"
+		return u.Hostname(), port, nil
 	}
-	target := net.JoinHostPort(host, port)
-	Logger.Debug("Using insecure transport from profile", "address", target)
-	return target, insecure.NewCredentials(), profileName, true, nil
+	host, port, err := net.SplitHostPort(profile.EngineAddress)
+	if err != nil {
+		// Assume missing port
+		return profile.EngineAddress, defaultPort, nil
+	}
+	return host, port, nil
 }
 
 // parseExplicitAddress parses an explicit address which may be a URL with a scheme
@@ -469,6 +469,67 @@ func TestEngineClientUsesStoredToken(t *testing.T) {
 	}
 }
 
+func TestEngineClientSelectsTokenForExplicitEngine(t *testing.T) {
+	InitLogging()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("ROCKETSHIP_DISABLE_KEYRING", "1")
+	t.Setenv(tokenEnvVar, "")
+
+	staging := &mockEngineServer{runResponse: &generated.CreateRunResponse{RunId: "abc"}}
+	stagingAddr, stopStaging := setupMockServer(t, staging)
+	defer stopStaging()
+	prod := &mockEngineServer{runResponse: &generated.CreateRunResponse{RunId: "def"}}
+	prodAddr, stopProd := setupMockServer(t, prod)
+	defer stopProd()
+
+	// prod is reached through a profile, staging through `login --engine`
+	cfg := DefaultConfig()
+	cfg.AddProfile(Profile{Name: "prod", EngineAddress: prodAddr})
+	if err := cfg.SaveConfig(); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+
+	store, err := auth.NewFileStore(filepath.Join(home, ".rocketship", "tokens"))
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	save := func(key, token string) {
+		if err := store.Save(key, auth.TokenData{AccessToken: token, TokenType: "Bearer", Expiry: time.Now().Add(time.Hour)}); err != nil {
+			t.Fatalf("failed to save token: %v", err)
+		}
+	}
+	save(auth.EngineKey(stagingAddr), "staging-token")
+	save("prod", "prod-token")
+
+	time.Sleep(100 * time.Millisecond)
+
+	for _, tc := range []struct {
+		address string
+		mock    *mockEngineServer
+		want    string
+	}{
+		{"grpc://" + stagingAddr, staging, "Bearer staging-token"},
+		{prodAddr, prod, "Bearer prod-token"},
+	} {
+		client, err := NewEngineClient(tc.address)
+		if err != nil {
+			t.Fatalf("NewEngineClient(%s) failed: %v", tc.address, err)
+		}
+		if _, err := client.RunTest(context.Background(), []byte("name: test")); err != nil {
+			t.Fatalf("RunTest failed: %v", err)
+		}
+		_ = client.Close()
+
+		if len(tc.mock.authHeaders) == 0 {
+			t.Fatalf("expected authorization header for %s", tc.address)
+		}
+		if got := tc.mock.authHeaders[0]; got != tc.want {
+			t.Fatalf("authorization header for %s: got %q, want %q", tc.address, got, tc.want)
+		}
+	}
+}
+
 func TestTranslateAuthError(t *testing.T) {
 	unauth := status.Error(codes.Unauthenticated, "missing metadata")
 	if err := translateAuthError("failed op", unauth); err == nil || !strings.Contains(err.Error(), "requires a token") {
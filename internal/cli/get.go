@@ -128,6 +128,19 @@ func displayRunDetails(run *generated.RunDetails, showLogs bool) error {
 	if run.DurationMs > 0 {
 		fmt.Printf("Duration:    %s\n", formatDuration(run.DurationMs))
 	}
+	if p := run.Progress; p != nil && p.PlannedSteps > 0 {
+		fmt.Printf("Progress:    %d%% (%d/%d steps)\n", p.Percent, p.CompletedSteps, p.PlannedSteps)
+		if p.EtaMs > 0 {
+			fmt.Printf("ETA:         %s\n", formatDuration(p.EtaMs))
+		}
+	}
+	if c := run.Cancellation; c != nil {
+		fmt.Printf("Cancelled:   %s", c.Reason)
+		if c.Message != "" {
+			fmt.Printf(" (%s)", c.Message)
+		}
+		fmt.Printf("\n             %d completed, %d interrupted, %d skipped\n", c.CompletedTests, c.InterruptedTests, c.SkippedTests)
+	}
 
 	// Context info
 	if run.Context != nil {
@@ -218,7 +231,7 @@ func displayTestsTable(tests []*generated.TestDetails) error {
 	}
 
 	// Print summary
-	var passed, failed, timeout int
+	var passed, failed, timeout, interrupted, skipped, xfailed int
 	for _, test := range tests {
 		switch test.Status {
 		case "PASSED":
@@ -227,11 +240,26 @@ func displayTestsTable(tests []*generated.TestDetails) error {
 			failed++
 		case "TIMEOUT":
 			timeout++
+		case "INTERRUPTED":
+			interrupted++
+		case "SKIPPED":
+			skipped++
+		case "XFAIL":
+			xfailed++
 		}
 	}
 
-	fmt.Printf("\nSummary: %d passed, %d failed, %d timeout out of %d total\n",
-		passed, failed, timeout, len(tests))
+	switch {
+	case interrupted == 0 && skipped == 0 && xfailed == 0:
+		fmt.Printf("\nSummary: %d passed, %d failed, %d timeout out of %d total\n",
+			passed, failed, timeout, len(tests))
+	case xfailed == 0:
+		fmt.Printf("\nSummary: %d passed, %d failed, %d timeout, %d interrupted, %d skipped out of %d total\n",
+			passed, failed, timeout, interrupted, skipped, len(tests))
+	default:
+		fmt.Printf("\nSummary: %d passed, %d failed, %d timeout, %d interrupted, %d skipped, %d xfailed out of %d total\n",
+			passed, failed, timeout, interrupted, skipped, xfailed, len(tests))
+	}
 
 	return nil
 }
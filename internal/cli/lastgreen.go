@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/rocketship-ai/rocketship/internal/api/generated"
+)
+
+// lastGreenLookback is how many of the suite's recent runs are searched for the
+// last one that passed
+const lastGreenLookback = 200
+
+// maxDurationDeltas and maxRangeCommits keep the summary short enough to read in a
+// CI log
+const (
+	maxDurationDeltas = 5
+	maxRangeCommits   = 10
+)
+
+// durationDelta is how much slower or faster a test ran than in the last green run
+type durationDelta struct {
+	name   string
+	before time.Duration
+	after  time.Duration
+}
+
+func (d durationDelta) change() time.Duration {
+	return d.after - d.before
+}
+
+// lastGreenDiff is what changed between a failed run and the suite's last passing run
+type lastGreenDiff struct {
+	green        *generated.RunDetails
+	newlyFailing []reportTest // Failed now; passed in the green run
+	newTests     []reportTest // Failed now; not in the green run at all
+	deltas       []durationDelta
+	suiteDelta   time.Duration
+	fromCommit   string
+	toCommit     string
+}
+
+// findLastGreenRun returns the most recent passing run of the suite on the same
+// branch, or nil when there isn't one among the last lastGreenLookback runs
+func findLastGreenRun(ctx context.Context, client *EngineClient, run *generated.RunDetails) (*generated.RunDetails, error) {
+	req := &generated.ListRunsRequest{
+		SuiteName:  run.GetSuiteName(),
+		Branch:     run.GetContext().GetBranch(),
+		Status:     "PASSED",
+		Limit:      lastGreenLookback,
+		OrderBy:    "started_at",
+		Descending: true,
+	}
+	listCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	resp, err := client.client.ListRuns(listCtx, req)
+	if err != nil {
+		if wrapped := translateAuthError("failed to list runs", err); wrapped != nil {
+			return nil, wrapped
+		}
+		return nil, fmt.Errorf("failed to list runs: %w", err)
+	}
+
+	for _, summary := range resp.GetRuns() {
+		if summary.GetRunId() == run.GetRunId() {
+			continue
+		}
+		getResp, err := client.client.GetRun(listCtx, &generated.GetRunRequest{RunId: summary.GetRunId()})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load run %s: %w", summary.GetRunId(), err)
+		}
+		return getResp.GetRun(), nil
+	}
+	return nil, nil
+}
+
+// buildLastGreenDiff compares a failed run with the last green run of its suite
+func buildLastGreenDiff(result TestSuiteResult, green *generated.RunDetails) lastGreenDiff {
+	diff := lastGreenDiff{
+		green:      green,
+		fromCommit: green.GetContext().GetCommitSha(),
+		toCommit:   result.Run.GetContext().GetCommitSha(),
+		suiteDelta: time.Duration(result.Run.GetDurationMs()-green.GetDurationMs()) * time.Millisecond,
+	}
+
+	before := make(map[string]time.Duration, len(green.GetTests()))
+	for _, t := range green.GetTests() {
+		before[t.GetName()] = time.Duration(t.GetDurationMs()) * time.Millisecond
+	}
+
+	for _, t := range result.tests() {
+		previous, existed := before[t.name]
+		if t.failed() {
+			if existed {
+				diff.newlyFailing = append(diff.newlyFailing, t)
+			} else {
+				diff.newTests = append(diff.newTests, t)
+			}
+			continue
+		}
+		if existed && significantChange(previous, t.duration) {
+			diff.deltas = append(diff.deltas, durationDelta{name: t.name, before: previous, after: t.duration})
+		}
+	}
+
+	sort.Slice(diff.deltas, func(i, j int) bool {
+		return diff.deltas[i].change().Abs() > diff.deltas[j].change().Abs()
+	})
+	if len(diff.deltas) > maxDurationDeltas {
+		diff.deltas = diff.deltas[:maxDurationDeltas]
+	}
+	return diff
+}
+
+// significantChange ignores the jitter every test has: a change counts when it is
+// at least a second and at least a quarter of the earlier duration
+func significantChange(before, after time.Duration) bool {
+	change := (after - before).Abs()
+	return change >= time.Second && change*4 >= before
+}
+
+// printLastGreenDiff prints the triage summary for one failed suite
+func printLastGreenDiff(result TestSuiteResult, diff lastGreenDiff) {
+	fmt.Printf("\n=== %s: changes since last green run ===\n", result.displayName())
+	fmt.Printf("Last green run: %s (%s)\n", diff.green.GetRunId(), diff.green.GetStartedAt())
+
+	for _, t := range diff.newlyFailing {
+		fmt.Printf("%s Newly failing: %s - %s\n", color.RedString("✗"), t.name, firstLine(t.failureText()))
+	}
+	for _, t := range diff.newTests {
+		fmt.Printf("%s New test, failing: %s - %s\n", color.RedString("✗"), t.name, firstLine(t.failureText()))
+	}
+
+	if diff.suiteDelta != 0 && diff.green.GetDurationMs() > 0 {
+		fmt.Printf("Suite duration: %s → %s (%s)\n",
+			formatDuration(diff.green.GetDurationMs()), formatDuration(result.Run.GetDurationMs()), signedDuration(diff.suiteDelta))
+	}
+	if len(diff.deltas) > 0 {
+		fmt.Println("Duration changes:")
+		for _, d := range diff.deltas {
+			fmt.Printf("  %s: %s → %s (%s)\n", d.name,
+				formatDuration(d.before.Milliseconds()), formatDuration(d.after.Milliseconds()), signedDuration(d.change()))
+		}
+	}
+
+	printCommitRange(diff.fromCommit, diff.toCommit)
+}
+
+// printCommitRange shows the commits between the green run and this one, listing
+// them when the local clone has both
+func printCommitRange(from, to string) {
+	switch {
+	case from == "" || to == "":
+		fmt.Println("Commits: unknown (a run has no commit recorded)")
+		return
+	case from == to:
+		fmt.Printf("Commits: none, both runs used %s; suspect flakiness or an environment change\n", shortSHA(to))
+		return
+	}
+
+	fmt.Printf("Commits: %s..%s\n", shortSHA(from), shortSHA(to))
+	out, err := runGitCommand("log", "--oneline", "--no-decorate", from+".."+to)
+	if err != nil {
+		Logger.Debug("failed to list commits in range", "from", from, "to", to, "error", err)
+		return
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		return
+	}
+	for i, line := range lines {
+		if i == maxRangeCommits {
+			fmt.Printf("  ... and %d more\n", len(lines)-maxRangeCommits)
+			break
+		}
+		fmt.Printf("  %s\n", line)
+	}
+}
+
+// diffAgainstLastGreen prints, for every failed suite, what changed since its last
+// passing run. Lookup errors are reported but never fail the command.
+func diffAgainstLastGreen(ctx context.Context, client *EngineClient, results []TestSuiteResult) {
+	for _, result := range results {
+		if result.passed() || result.Run == nil {
+			continue
+		}
+		green, err := findLastGreenRun(ctx, client, result.Run)
+		if err != nil {
+			Logger.Warn("failed to find last green run", "suite", result.displayName(), "error", err)
+			continue
+		}
+		if green == nil {
+			fmt.Printf("\n=== %s: no earlier green run on this branch ===\n", result.displayName())
+			continue
+		}
+		printLastGreenDiff(result, buildLastGreenDiff(result, green))
+	}
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+func signedDuration(d time.Duration) string {
+	if d < 0 {
+		return "-" + formatDuration((-d).Milliseconds())
+	}
+	return "+" + formatDuration(d.Milliseconds())
+}
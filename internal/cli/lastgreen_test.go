@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rocketship-ai/rocketship/internal/api/generated"
+)
+
+func TestBuildLastGreenDiff(t *testing.T) {
+	failed := TestSuiteResult{
+		Name:        "checkout",
+		FailedSteps: map[string]string{"pay": "submit payment"},
+		Run: &generated.RunDetails{
+			RunId:      "run-2",
+			DurationMs: 9000,
+			Context:    &generated.RunContext{CommitSha: "bbbbbbbbbb"},
+			Tests: []*generated.TestDetails{
+				{Name: "browse", Status: "PASSED", DurationMs: 4500},
+				{Name: "search", Status: "PASSED", DurationMs: 1300},
+				{Name: "pay", Status: "FAILED", DurationMs: 2000, ErrorMessage: "expected status 200, got 500"},
+				{Name: "gift cards", Status: "FAILED", DurationMs: 1200},
+			},
+		},
+	}
+	green := &generated.RunDetails{
+		RunId:      "run-1",
+		DurationMs: 6000,
+		Context:    &generated.RunContext{CommitSha: "aaaaaaaaaa"},
+		Tests: []*generated.TestDetails{
+			{Name: "browse", Status: "PASSED", DurationMs: 1500},
+			{Name: "search", Status: "PASSED", DurationMs: 1000},
+			{Name: "pay", Status: "PASSED", DurationMs: 2100},
+		},
+	}
+
+	diff := buildLastGreenDiff(failed, green)
+
+	require.Len(t, diff.newlyFailing, 1)
+	assert.Equal(t, "pay", diff.newlyFailing[0].name)
+	assert.Equal(t, `step "submit payment": expected status 200, got 500`, diff.newlyFailing[0].failureText())
+	require.Len(t, diff.newTests, 1)
+	assert.Equal(t, "gift cards", diff.newTests[0].name)
+
+	// search only moved by 300ms, which is noise
+	require.Len(t, diff.deltas, 1)
+	assert.Equal(t, "browse", diff.deltas[0].name)
+	assert.Equal(t, 3*time.Second, diff.deltas[0].change())
+
+	assert.Equal(t, 3*time.Second, diff.suiteDelta)
+	assert.Equal(t, "aaaaaaaaaa", diff.fromCommit)
+	assert.Equal(t, "bbbbbbbbbb", diff.toCommit)
+}
+
+func TestSignificantChange(t *testing.T) {
+	assert.False(t, significantChange(500*time.Millisecond, 900*time.Millisecond))
+	assert.False(t, significantChange(10*time.Second, 12*time.Second))
+	assert.True(t, significantChange(2*time.Second, 4*time.Second))
+	assert.True(t, significantChange(8*time.Second, 5*time.Second))
+}
+
+func TestSignedDuration(t *testing.T) {
+	assert.Equal(t, "+1.5s", signedDuration(1500*time.Millisecond))
+	assert.Equal(t, "-2m5s", signedDuration(-125*time.Second))
+}
@@ -19,6 +19,8 @@ type ListFlags struct {
 	Branch       string
 	Status       string
 	ScheduleName string
+	SuiteName    string
+	Tags         string
 	Limit        int32
 	OrderBy      string
 	Ascending    bool
@@ -52,6 +54,9 @@ Examples:
   # List runs from a specific branch
   rocketship list --branch feature/new-api
 
+  # List runs of smoke tests that ran no slow tests
+  rocketship list --tags smoke,!slow
+
   # List runs with custom limit and ordering
   rocketship list --limit 50 --order-by duration --ascending`,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -66,8 +71,10 @@ Examples:
 	cmd.Flags().StringVar(&flags.ProjectID, "project-id", "", "Filter by project ID")
 	cmd.Flags().StringVar(&flags.Source, "source", "", "Filter by source (cli-local, github-actions, ci-token, scheduler)")
 	cmd.Flags().StringVar(&flags.Branch, "branch", "", "Filter by git branch")
-	cmd.Flags().StringVar(&flags.Status, "status", "", "Filter by status (PENDING, RUNNING, PASSED, FAILED, TIMEOUT)")
+	cmd.Flags().StringVar(&flags.Status, "status", "", "Filter by status (PENDING, RUNNING, PASSED, FAILED, TIMEOUT, BUDGET_EXCEEDED)")
 	cmd.Flags().StringVar(&flags.ScheduleName, "schedule-name", "", "Filter by schedule name")
+	cmd.Flags().StringVar(&flags.SuiteName, "suite", "", "Filter by suite name")
+	cmd.Flags().StringVar(&flags.Tags, "tags", "", "Filter by the tags of the tests runs ran (e.g. smoke,!slow)")
 
 	// Display options
 	cmd.Flags().Int32Var(&flags.Limit, "limit", flags.Limit, "Maximum number of runs to display")
@@ -104,6 +111,8 @@ func runList(cmd *cobra.Command, flags *ListFlags) error {
 		Branch:       flags.Branch,
 		Status:       flags.Status,
 		ScheduleName: flags.ScheduleName,
+		SuiteName:    flags.SuiteName,
+		Tags:         flags.Tags,
 		Limit:        flags.Limit,
 		OrderBy:      flags.OrderBy,
 		Descending:   !flags.Ascending,
@@ -221,7 +230,7 @@ func getStatusIcon(status string) string {
 	switch status {
 	case "PASSED":
 		return "✓"
-	case "FAILED":
+	case "FAILED", "BUDGET_EXCEEDED":
 		return "✗"
 	case "RUNNING":
 		return "↻"
@@ -229,6 +238,12 @@ func getStatusIcon(status string) string {
 		return "⏳"
 	case "TIMEOUT":
 		return "⏱"
+	case "CANCELLED", "INTERRUPTED":
+		return "⊘"
+	case "SKIPPED":
+		return "-"
+	case "XFAIL":
+		return "x"
 	default:
 		return "?"
 	}
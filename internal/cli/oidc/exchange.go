@@ -0,0 +1,213 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/cli/auth"
+)
+
+// RFC 8693 token exchange identifiers
+const (
+	GrantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	TokenTypeIDToken       = "urn:ietf:params:oauth:token-type:id_token"
+	TokenTypeAccessToken   = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+// ExchangeConfig describes a token exchange at the auth broker's token endpoint
+type ExchangeConfig struct {
+	TokenEndpoint string
+	ClientID      string
+	Audience      string
+	ProjectID     string // Narrows the issued token to one project when set
+	SubjectToken  string // ID token issued by the CI provider
+}
+
+// ExchangeIDToken trades a CI provider's ID token for a short-lived Rocketship
+// access token. The returned token has no refresh token; exchange a fresh ID token
+// when it expires.
+func ExchangeIDToken(ctx context.Context, cfg ExchangeConfig) (auth.TokenData, error) {
+	if cfg.TokenEndpoint == "" {
+		return auth.TokenData{}, fmt.Errorf("token endpoint is not set")
+	}
+	if cfg.SubjectToken == "" {
+		return auth.TokenData{}, fmt.Errorf("subject token missing")
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", GrantTypeTokenExchange)
+	form.Set("subject_token", cfg.SubjectToken)
+	form.Set("subject_token_type", TokenTypeIDToken)
+	form.Set("requested_token_type", TokenTypeAccessToken)
+	if cfg.ClientID != "" {
+		form.Set("client_id", cfg.ClientID)
+	}
+	if cfg.Audience != "" {
+		form.Set("audience", cfg.Audience)
+	}
+	if cfg.ProjectID != "" {
+		form.Set("project_id", cfg.ProjectID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return auth.TokenData{}, fmt.Errorf("failed to create token exchange request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{Timeout: httpTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return auth.TokenData{}, fmt.Errorf("token exchange request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		var terr tokenErrorResponse
+		if json.Unmarshal(body, &terr) == nil && terr.Error != "" {
+			if terr.ErrorDescription != "" {
+				return auth.TokenData{}, fmt.Errorf("token exchange failed: %s: %s", terr.Error, terr.ErrorDescription)
+			}
+			return auth.TokenData{}, fmt.Errorf("token exchange failed: %s", terr.Error)
+		}
+		snippet := strings.TrimSpace(string(body))
+		if snippet == "" {
+			snippet = resp.Status
+		}
+		return auth.TokenData{}, fmt.Errorf("token exchange failed: %s", snippet)
+	}
+
+	var tr tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+		return auth.TokenData{}, fmt.Errorf("failed to decode token exchange response: %w", err)
+	}
+	if tr.AccessToken == "" {
+		return auth.TokenData{}, fmt.Errorf("token exchange response did not include an access token")
+	}
+
+	data := auth.TokenData{
+		AccessToken:   tr.AccessToken,
+		TokenType:     tr.TokenType,
+		ClientID:      cfg.ClientID,
+		Audience:      cfg.Audience,
+		TokenEndpoint: cfg.TokenEndpoint,
+	}
+	if data.TokenType == "" {
+		data.TokenType = "Bearer"
+	}
+	if tr.ExpiresIn > 0 {
+		data.Expiry = time.Now().Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+	if tr.Scope != "" {
+		data.Scopes = strings.Fields(tr.Scope)
+	}
+	return data, nil
+}
+
+// GitHubActionsIDToken requests an ID token for the running GitHub Actions job. The
+// workflow needs the `id-token: write` permission.
+func GitHubActionsIDToken(ctx context.Context, audience string) (string, error) {
+	requestURL := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL")
+	requestToken := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN")
+	if requestURL == "" || requestToken == "" {
+		return "", fmt.Errorf("GitHub Actions ID token is not available; grant the job the id-token: write permission")
+	}
+
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid ACTIONS_ID_TOKEN_REQUEST_URL: %w", err)
+	}
+	if audience != "" {
+		query := u.Query()
+		query.Set("audience", audience)
+		u.RawQuery = query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create ID token request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+requestToken)
+	req.Header.Set("Accept", "application/json")
+
+	client := &http.Client{Timeout: httpTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("ID token request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		snippet := strings.TrimSpace(string(body))
+		if snippet == "" {
+			snippet = resp.Status
+		}
+		return "", fmt.Errorf("ID token request failed: %s", snippet)
+	}
+
+	var payload struct {
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return "", fmt.Errorf("failed to decode ID token response: %w", err)
+	}
+	if payload.Value == "" {
+		return "", fmt.Errorf("GitHub returned an empty ID token")
+	}
+	return payload.Value, nil
+}
+
+// GitLabIDTokenEnv holds the GitLab CI job's ID token. Declare it in the job with
+// `id_tokens: { ROCKETSHIP_ID_TOKEN: { aud: <audience> } }`.
+const GitLabIDTokenEnv = "ROCKETSHIP_ID_TOKEN"
+
+// CI providers whose job ID tokens the auth broker accepts
+const (
+	CIProviderGitHub = "github"
+	CIProviderGitLab = "gitlab"
+)
+
+// DetectCIProvider reports the CI system the CLI is running in, or "" outside a supported one
+func DetectCIProvider() string {
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		return CIProviderGitHub
+	case os.Getenv("GITLAB_CI") == "true":
+		return CIProviderGitLab
+	default:
+		return ""
+	}
+}
+
+// CIIDToken returns an ID token for the running CI job, along with the provider that issued it
+func CIIDToken(ctx context.Context, audience string) (string, string, error) {
+	switch provider := DetectCIProvider(); provider {
+	case CIProviderGitHub:
+		token, err := GitHubActionsIDToken(ctx, audience)
+		return provider, token, err
+	case CIProviderGitLab:
+		token, err := GitLabCIIDToken()
+		return provider, token, err
+	default:
+		return "", "", fmt.Errorf("no supported CI provider detected; CI OIDC login works in GitHub Actions and GitLab CI")
+	}
+}
+
+// GitLabCIIDToken reads the ID token GitLab issued to the job. GitLab mints it
+// with the audience declared under id_tokens, so there is nothing to request.
+func GitLabCIIDToken() (string, error) {
+	token := strings.TrimSpace(os.Getenv(GitLabIDTokenEnv))
+	if token == "" {
+		return "", fmt.Errorf("GitLab CI ID token is not available; declare %s under the job's id_tokens", GitLabIDTokenEnv)
+	}
+	return token, nil
+}
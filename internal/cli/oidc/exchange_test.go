@@ -0,0 +1,108 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExchangeIDToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("failed to parse form: %v", err)
+		}
+		if got := r.Form.Get("grant_type"); got != GrantTypeTokenExchange {
+			t.Errorf("unexpected grant_type %q", got)
+		}
+		if got := r.Form.Get("subject_token_type"); got != TokenTypeIDToken {
+			t.Errorf("unexpected subject_token_type %q", got)
+		}
+		if r.Form.Get("subject_token") != "gh-id-token" || r.Form.Get("project_id") != "proj-1" {
+			t.Errorf("unexpected form: %v", r.Form)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "rs-token",
+			"token_type":   "Bearer",
+			"expires_in":   900,
+		})
+	}))
+	defer srv.Close()
+
+	data, err := ExchangeIDToken(context.Background(), ExchangeConfig{
+		TokenEndpoint: srv.URL,
+		ProjectID:     "proj-1",
+		SubjectToken:  "gh-id-token",
+	})
+	if err != nil {
+		t.Fatalf("exchange failed: %v", err)
+	}
+	if data.AccessToken != "rs-token" || data.Expiry.IsZero() || data.HasRefresh() {
+		t.Fatalf("unexpected token data: %+v", data)
+	}
+}
+
+func TestExchangeIDTokenError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_grant","error_description":"repository is not trusted"}`))
+	}))
+	defer srv.Close()
+
+	_, err := ExchangeIDToken(context.Background(), ExchangeConfig{TokenEndpoint: srv.URL, SubjectToken: "x"})
+	if err == nil || !strings.Contains(err.Error(), "repository is not trusted") {
+		t.Fatalf("expected the broker's error description, got %v", err)
+	}
+}
+
+func TestGitHubActionsIDToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer request-token" {
+			t.Errorf("missing request token")
+		}
+		if got := r.URL.Query().Get("audience"); got != "rocketship" {
+			t.Errorf("unexpected audience %q", got)
+		}
+		_, _ = w.Write([]byte(`{"value":"gh-id-token"}`))
+	}))
+	defer srv.Close()
+
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", srv.URL+"/token?api-version=2.0")
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN", "request-token")
+
+	token, err := GitHubActionsIDToken(context.Background(), "rocketship")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if token != "gh-id-token" {
+		t.Fatalf("unexpected token %q", token)
+	}
+
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", "")
+	if _, err := GitHubActionsIDToken(context.Background(), "rocketship"); err == nil {
+		t.Fatal("expected an error without the request environment")
+	}
+}
+
+func TestCIIDTokenGitLab(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "")
+	t.Setenv("GITLAB_CI", "true")
+	t.Setenv(GitLabIDTokenEnv, "gl-id-token")
+
+	provider, token, err := CIIDToken(context.Background(), "rocketship")
+	if err != nil || provider != CIProviderGitLab || token != "gl-id-token" {
+		t.Fatalf("unexpected result %q %q (%v)", provider, token, err)
+	}
+
+	t.Setenv(GitLabIDTokenEnv, "")
+	if _, _, err := CIIDToken(context.Background(), "rocketship"); err == nil || !strings.Contains(err.Error(), "id_tokens") {
+		t.Fatalf("expected a hint about id_tokens, got %v", err)
+	}
+
+	t.Setenv("GITLAB_CI", "")
+	if _, _, err := CIIDToken(context.Background(), "rocketship"); err == nil {
+		t.Fatal("expected an error outside CI")
+	}
+}
@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/api/generated"
+	"github.com/spf13/cobra"
+)
+
+// PreviewFlags holds the flags for the preview commands
+type PreviewFlags struct {
+	Engine    string
+	ProjectID string
+	PR        int32
+	Fallback  string
+}
+
+// NewPreviewCmd creates the preview command group
+func NewPreviewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "preview",
+		Short: "Manage per-pull-request preview environments",
+		Long: `Preview environments keep the runs of a pull request apart from the project's
+other environments. Create one when the pull request's deployment is ready and pass
+its slug to rocketship run --env. When the pull request closes, the GitHub App
+deletes the environment and archives its runs so they drop out of the dashboards.`,
+	}
+	cmd.AddCommand(newPreviewCreateCmd(), newPreviewDeleteCmd())
+	return cmd
+}
+
+func newPreviewCreateCmd() *cobra.Command {
+	flags := &PreviewFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create the preview environment for a pull request",
+		Long: `Create the pr-<number> environment for a pull request and print its slug.
+Running it again for the same pull request prints the existing environment.
+
+Examples:
+  # Preview environment that takes unset secrets and variables from staging
+  rocketship preview create --project-id 6f1c... --pr 42 --fallback staging
+
+  # Use it for the pull request's runs
+  rocketship run --dir .rocketship --project-id 6f1c... --env pr-42`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPreviewCreate(cmd, flags)
+		},
+	}
+
+	addPreviewFlags(cmd, flags)
+	cmd.Flags().StringVar(&flags.Fallback, "fallback", "", "Environment that fills in secrets and variables the preview doesn't set")
+
+	return cmd
+}
+
+func newPreviewDeleteCmd() *cobra.Command {
+	flags := &PreviewFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete the preview environment for a pull request",
+		Long: `Delete a pull request's preview environment and archive its runs. The GitHub App
+does this when the pull request closes; use this command to clean up earlier or for
+repositories without the app.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPreviewDelete(cmd, flags)
+		},
+	}
+
+	addPreviewFlags(cmd, flags)
+
+	return cmd
+}
+
+func addPreviewFlags(cmd *cobra.Command, flags *PreviewFlags) {
+	cmd.Flags().StringVarP(&flags.Engine, "engine", "e", "", "Address of the rocketship engine (defaults to active profile)")
+	cmd.Flags().StringVar(&flags.ProjectID, "project-id", "", "Project the preview environment belongs to")
+	cmd.Flags().Int32Var(&flags.PR, "pr", 0, "Pull request number")
+	_ = cmd.MarkFlagRequired("project-id")
+	_ = cmd.MarkFlagRequired("pr")
+}
+
+func runPreviewCreate(cmd *cobra.Command, flags *PreviewFlags) error {
+	client, err := previewClient(cmd, flags)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := client.Close(); err != nil {
+			Logger.Debug("failed to close client", "error", err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	resp, err := client.client.CreatePreviewEnvironment(ctx, &generated.CreatePreviewEnvironmentRequest{
+		ProjectId: flags.ProjectID,
+		PrNumber:  flags.PR,
+		Fallback:  flags.Fallback,
+	})
+	if err != nil {
+		if wrapped := translateAuthError("failed to create preview environment", err); wrapped != nil {
+			return wrapped
+		}
+		return fmt.Errorf("failed to create preview environment: %w", err)
+	}
+
+	Logger.Debug("preview environment ready", "slug", resp.Slug, "created", resp.Created, "fallback", resp.Fallback)
+	fmt.Println(resp.Slug)
+	return nil
+}
+
+func runPreviewDelete(cmd *cobra.Command, flags *PreviewFlags) error {
+	client, err := previewClient(cmd, flags)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := client.Close(); err != nil {
+			Logger.Debug("failed to close client", "error", err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	resp, err := client.client.DeletePreviewEnvironment(ctx, &generated.DeletePreviewEnvironmentRequest{
+		ProjectId: flags.ProjectID,
+		PrNumber:  flags.PR,
+	})
+	if err != nil {
+		if wrapped := translateAuthError("failed to delete preview environment", err); wrapped != nil {
+			return wrapped
+		}
+		return fmt.Errorf("failed to delete preview environment: %w", err)
+	}
+
+	fmt.Printf("Deleted preview environment pr-%d and archived %d run(s).\n", flags.PR, resp.ArchivedRuns)
+	return nil
+}
+
+func previewClient(cmd *cobra.Command, flags *PreviewFlags) (*EngineClient, error) {
+	if flags.PR <= 0 {
+		return nil, fmt.Errorf("--pr must be a positive pull request number")
+	}
+	engineAddr := ""
+	if cmd.Flags().Changed("engine") {
+		engineAddr = flags.Engine
+	}
+	client, err := NewEngineClient(engineAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to engine: %w", err)
+	}
+	return client, nil
+}
@@ -0,0 +1,429 @@
+package cli
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/api/generated"
+)
+
+// fetchRunDetails loads the per-test results of each suite's run for reports
+func fetchRunDetails(ctx context.Context, client *EngineClient, results []TestSuiteResult) {
+	for i := range results {
+		if results[i].RunID == "" {
+			continue
+		}
+		getCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		resp, err := client.client.GetRun(getCtx, &generated.GetRunRequest{RunId: results[i].RunID})
+		cancel()
+		if err != nil {
+			Logger.Warn("failed to load run details for report", "run_id", results[i].RunID, "error", err)
+			continue
+		}
+		results[i].Run = resp.GetRun()
+	}
+}
+
+// reportTest is one test of a suite as it appears in reports
+type reportTest struct {
+	name     string
+	status   string
+	step     string
+	message  string
+	duration time.Duration
+}
+
+func (t reportTest) failed() bool {
+	return t.status == "FAILED" || t.status == "TIMEOUT"
+}
+
+// failureText describes why a test failed
+func (t reportTest) failureText() string {
+	message := t.message
+	if message == "" {
+		if t.status == "TIMEOUT" {
+			message = "test timed out"
+		} else {
+			message = "test failed"
+		}
+	}
+	if t.step != "" {
+		return fmt.Sprintf("step %q: %s", t.step, message)
+	}
+	return message
+}
+
+// skipText describes a test that neither passed nor failed, e.g. "skipped: flaky upstream"
+func (t reportTest) skipText() string {
+	status := strings.ToLower(t.status)
+	switch {
+	case t.message == "":
+		return status
+	case strings.HasPrefix(t.message, status):
+		return t.message
+	}
+	return status + ": " + t.message
+}
+
+// uncountedTests lists the tests that neither passed nor failed, e.g. "2 skipped, 1 xfailed"
+func uncountedTests(skipped, xfailed int) string {
+	var parts []string
+	if skipped > 0 {
+		parts = append(parts, fmt.Sprintf("%d skipped", skipped))
+	}
+	if xfailed > 0 {
+		parts = append(parts, fmt.Sprintf("%d xfailed", xfailed))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (r TestSuiteResult) displayName() string {
+	if r.Name != "" && r.Name != "unknown" {
+		return r.Name
+	}
+	if r.Path != "" {
+		return filepath.Base(r.Path)
+	}
+	return "unknown"
+}
+
+func (r TestSuiteResult) passed() bool {
+	return r.Error == "" && r.FailedTests == 0 && r.TotalTests > 0
+}
+
+func (r TestSuiteResult) duration() time.Duration {
+	if r.Run == nil {
+		return 0
+	}
+	return time.Duration(r.Run.GetDurationMs()) * time.Millisecond
+}
+
+func (r TestSuiteResult) tests() []reportTest {
+	if r.Run == nil {
+		return nil
+	}
+	tests := make([]reportTest, 0, len(r.Run.GetTests()))
+	for _, t := range r.Run.GetTests() {
+		tests = append(tests, reportTest{
+			name:     t.GetName(),
+			status:   t.GetStatus(),
+			step:     r.FailedSteps[t.GetName()],
+			message:  t.GetErrorMessage(),
+			duration: time.Duration(t.GetDurationMs()) * time.Millisecond,
+		})
+	}
+	return tests
+}
+
+// relativePath returns path relative to the working directory, with forward slashes,
+// as GitHub expects for annotations
+func relativePath(path string) string {
+	if path == "" {
+		return ""
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	rel, err := filepath.Rel(wd, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	File      string          `xml:"file,attr,omitempty"`
+	ID        string          `xml:"id,attr,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// buildJUnitReport converts suite results to JUnit XML. A suite that could not be run
+// is reported as a single errored test case.
+func buildJUnitReport(results []TestSuiteResult) junitTestSuites {
+	report := junitTestSuites{Name: "rocketship"}
+	var total time.Duration
+
+	for _, r := range results {
+		suite := junitTestSuite{
+			Name: r.displayName(),
+			File: relativePath(r.Path),
+			ID:   r.RunID,
+			Time: junitSeconds(r.duration()),
+		}
+		if r.Run != nil {
+			suite.Timestamp = r.Run.GetStartedAt()
+		}
+
+		if r.Error != "" {
+			suite.Tests, suite.Errors = 1, 1
+			suite.Cases = append(suite.Cases, junitTestCase{
+				Name:      suite.Name,
+				Classname: suite.Name,
+				Time:      junitSeconds(0),
+				File:      suite.File,
+				Error:     &junitProblem{Message: r.Error, Type: "error"},
+			})
+		}
+
+		for _, t := range r.tests() {
+			tc := junitTestCase{
+				Name:      t.name,
+				Classname: suite.Name,
+				Time:      junitSeconds(t.duration),
+				File:      suite.File,
+			}
+			switch {
+			case t.failed():
+				failureType := "failure"
+				if t.status == "TIMEOUT" {
+					failureType = "timeout"
+				}
+				text := t.failureText()
+				tc.Failure = &junitProblem{Message: firstLine(text), Type: failureType, Text: text}
+				suite.Failures++
+			case t.status != "PASSED":
+				tc.Skipped = &junitSkipped{Message: t.skipText()}
+				suite.Skipped++
+			}
+			suite.Tests++
+			suite.Cases = append(suite.Cases, tc)
+		}
+
+		// Without run details only the counts from the log stream are known
+		if r.Run == nil && r.Error == "" {
+			suite.Tests = r.TotalTests
+			suite.Failures = r.FailedTests
+			suite.Skipped = r.SkippedTests + r.XFailedTests
+		}
+
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+		report.Skipped += suite.Skipped
+		total += r.duration()
+		report.Suites = append(report.Suites, suite)
+	}
+	report.Time = junitSeconds(total)
+	return report
+}
+
+// writeJUnitReport writes the results as a JUnit XML file at path
+func writeJUnitReport(path string, results []TestSuiteResult) error {
+	data, err := xml.MarshalIndent(buildJUnitReport(results), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JUnit report: %w", err)
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create JUnit report directory: %w", err)
+		}
+	}
+	data = append([]byte(xml.Header), data...)
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write JUnit report: %w", err)
+	}
+	return nil
+}
+
+// githubAnnotations returns an ::error workflow command for every failed test and
+// every suite that could not be run
+func githubAnnotations(results []TestSuiteResult) []string {
+	var lines []string
+	for _, r := range results {
+		file := relativePath(r.Path)
+		if r.Error != "" {
+			lines = append(lines, githubCommand("error", file, r.displayName(), r.Error))
+		}
+		for _, t := range r.tests() {
+			if t.failed() {
+				lines = append(lines, githubCommand("error", file, r.displayName()+" › "+t.name, t.failureText()))
+			}
+		}
+	}
+	return lines
+}
+
+func githubCommand(command, file, title, message string) string {
+	var props []string
+	if file != "" {
+		props = append(props, "file="+escapeGitHubProperty(file))
+	}
+	if title != "" {
+		props = append(props, "title="+escapeGitHubProperty(title))
+	}
+	line := "::" + command
+	if len(props) > 0 {
+		line += " " + strings.Join(props, ",")
+	}
+	return line + "::" + escapeGitHubData(message)
+}
+
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// buildGitHubSummary renders the results as Markdown for the job summary
+func buildGitHubSummary(results []TestSuiteResult) string {
+	summary := summarizeResults(results)
+	var b strings.Builder
+
+	b.WriteString("## Rocketship results\n\n")
+	fmt.Fprintf(&b, "**%d of %d suites passed**, %d of %d tests passed",
+		summary.passedSuites, summary.totalSuites, summary.passedTests, summary.totalTests)
+	if uncounted := uncountedTests(summary.skippedTests, summary.xfailedTests); uncounted != "" {
+		b.WriteString(", " + uncounted)
+	}
+	b.WriteString("\n\n")
+
+	b.WriteString("| | Suite | Tests | Duration | Run |\n")
+	b.WriteString("|---|---|---|---|---|\n")
+	for _, r := range results {
+		icon := "✅"
+		if !r.passed() {
+			icon = "❌"
+		}
+		duration := "-"
+		if d := r.duration(); d > 0 {
+			duration = d.Round(100 * time.Millisecond).String()
+		}
+		runID := "-"
+		if r.RunID != "" {
+			runID = "`" + r.RunID + "`"
+		}
+		tests := fmt.Sprintf("%d/%d", r.PassedTests, r.TotalTests)
+		if uncounted := uncountedTests(r.SkippedTests, r.XFailedTests); uncounted != "" {
+			tests += " (" + uncounted + ")"
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+			icon, escapeMarkdownCell(r.displayName()), tests, duration, runID)
+	}
+
+	var failures strings.Builder
+	for _, r := range results {
+		if r.Error != "" {
+			fmt.Fprintf(&failures, "#### %s\n\n", r.displayName())
+			writeCodeBlock(&failures, r.Error)
+		}
+		for _, t := range r.tests() {
+			if !t.failed() {
+				continue
+			}
+			fmt.Fprintf(&failures, "#### %s › %s\n\n", r.displayName(), t.name)
+			if t.step != "" {
+				fmt.Fprintf(&failures, "Failed at step `%s`", t.step)
+				if file := relativePath(r.Path); file != "" {
+					fmt.Fprintf(&failures, " in `%s`", file)
+				}
+				failures.WriteString("\n\n")
+			}
+			message := t.message
+			if message == "" {
+				message = t.failureText()
+			}
+			writeCodeBlock(&failures, message)
+		}
+	}
+	if failures.Len() > 0 {
+		b.WriteString("\n### Failures\n\n")
+		b.WriteString(failures.String())
+	}
+	return b.String()
+}
+
+// writeGitHubSummary appends the job summary to $GITHUB_STEP_SUMMARY and prints
+// annotations for failed tests
+func writeGitHubSummary(results []TestSuiteResult) error {
+	for _, line := range githubAnnotations(results) {
+		fmt.Println(line)
+	}
+
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		Logger.Debug("GITHUB_STEP_SUMMARY is not set; skipping job summary")
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open job summary: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := f.WriteString(buildGitHubSummary(results)); err != nil {
+		return fmt.Errorf("failed to write job summary: %w", err)
+	}
+	return nil
+}
+
+func escapeMarkdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
+func writeCodeBlock(b *strings.Builder, text string) {
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	fmt.Fprintf(b, "%s\n%s\n%s\n\n", fence, strings.TrimRight(text, "\n"), fence)
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rocketship-ai/rocketship/internal/api/generated"
+)
+
+func sampleReportResults() []TestSuiteResult {
+	return []TestSuiteResult{
+		{
+			Name:        "checkout",
+			Path:        "suites/checkout.yaml",
+			RunID:       "run-1",
+			TotalTests:  3,
+			PassedTests: 1,
+			FailedTests: 2,
+			FailedSteps: map[string]string{"pay": "submit payment"},
+			Run: &generated.RunDetails{
+				RunId:      "run-1",
+				StartedAt:  "2026-01-02T03:04:05Z",
+				DurationMs: 4200,
+				Tests: []*generated.TestDetails{
+					{Name: "browse", Status: "PASSED", DurationMs: 1000},
+					{Name: "pay", Status: "FAILED", DurationMs: 2000, ErrorMessage: "expected status 200, got 500"},
+					{Name: "refund", Status: "TIMEOUT", DurationMs: 1200},
+				},
+			},
+		},
+		{
+			Name:  "unknown",
+			Path:  "suites/broken.yaml",
+			Error: "failed to parse YAML: line 3: mapping values are not allowed",
+		},
+	}
+}
+
+func TestBuildJUnitReport(t *testing.T) {
+	report := buildJUnitReport(sampleReportResults())
+
+	assert.Equal(t, 4, report.Tests)
+	assert.Equal(t, 2, report.Failures)
+	assert.Equal(t, 1, report.Errors)
+	require.Len(t, report.Suites, 2)
+
+	checkout := report.Suites[0]
+	assert.Equal(t, "checkout", checkout.Name)
+	assert.Equal(t, "suites/checkout.yaml", checkout.File)
+	assert.Equal(t, "4.200", checkout.Time)
+	require.Len(t, checkout.Cases, 3)
+	assert.Nil(t, checkout.Cases[0].Failure)
+	require.NotNil(t, checkout.Cases[1].Failure)
+	assert.Equal(t, `step "submit payment": expected status 200, got 500`, checkout.Cases[1].Failure.Message)
+	require.NotNil(t, checkout.Cases[2].Failure)
+	assert.Equal(t, "timeout", checkout.Cases[2].Failure.Type)
+
+	broken := report.Suites[1]
+	assert.Equal(t, "broken.yaml", broken.Name)
+	require.Len(t, broken.Cases, 1)
+	require.NotNil(t, broken.Cases[0].Error)
+	assert.Contains(t, broken.Cases[0].Error.Message, "failed to parse YAML")
+}
+
+func TestWriteJUnitReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reports", "junit.xml")
+	require.NoError(t, writeJUnitReport(path, sampleReportResults()))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), xml.Header))
+
+	var parsed junitTestSuites
+	require.NoError(t, xml.Unmarshal(data, &parsed))
+	assert.Equal(t, 4, parsed.Tests)
+}
+
+func TestGitHubAnnotations(t *testing.T) {
+	lines := githubAnnotations(sampleReportResults())
+	require.Len(t, lines, 3)
+	assert.Equal(t, `::error file=suites/checkout.yaml,title=checkout › pay::step "submit payment": expected status 200, got 500`, lines[0])
+	assert.Equal(t, "::error file=suites/checkout.yaml,title=checkout › refund::test timed out", lines[1])
+	assert.True(t, strings.HasPrefix(lines[2], "::error file=suites/broken.yaml,title=broken.yaml::failed to parse YAML"))
+
+	assert.Equal(t, "::error title=a%2C b%3A c::line 1%0Aline 2 100%25", githubCommand("error", "", "a, b: c", "line 1\nline 2 100%"))
+}
+
+func TestWriteGitHubSummary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary.md")
+	t.Setenv("GITHUB_STEP_SUMMARY", path)
+
+	require.NoError(t, writeGitHubSummary(sampleReportResults()))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	summary := string(data)
+	assert.Contains(t, summary, "**0 of 2 suites passed**, 1 of 3 tests passed")
+	assert.Contains(t, summary, "| ❌ | checkout | 1/3 | 4.2s | `run-1` |")
+	assert.Contains(t, summary, "#### checkout › pay")
+	assert.Contains(t, summary, "Failed at step `submit payment` in `suites/checkout.yaml`")
+	assert.Contains(t, summary, "expected status 200, got 500")
+	assert.Contains(t, summary, "#### broken.yaml")
+}
+
+func TestReportSkippedAndXFailTests(t *testing.T) {
+	results := []TestSuiteResult{{
+		Name:         "orders",
+		Path:         "suites/orders.yaml",
+		RunID:        "run-2",
+		TotalTests:   3,
+		PassedTests:  1,
+		SkippedTests: 1,
+		XFailedTests: 1,
+		Run: &generated.RunDetails{
+			RunId:      "run-2",
+			DurationMs: 1000,
+			Tests: []*generated.TestDetails{
+				{Name: "create", Status: "PASSED"},
+				{Name: "refund", Status: "SKIPPED", ErrorMessage: "skipped: refunds are down"},
+				{Name: "known bug", Status: "XFAIL", ErrorMessage: "expected status 200, got 500"},
+			},
+		},
+	}}
+
+	report := buildJUnitReport(results)
+	assert.Equal(t, 0, report.Failures)
+	assert.Equal(t, 2, report.Skipped)
+	cases := report.Suites[0].Cases
+	require.NotNil(t, cases[1].Skipped)
+	assert.Equal(t, "skipped: refunds are down", cases[1].Skipped.Message)
+	require.NotNil(t, cases[2].Skipped)
+	assert.Equal(t, "xfail: expected status 200, got 500", cases[2].Skipped.Message)
+
+	assert.Empty(t, githubAnnotations(results))
+	summary := buildGitHubSummary(results)
+	assert.Contains(t, summary, "**1 of 1 suites passed**, 1 of 3 tests passed, 1 skipped, 1 xfailed")
+	assert.Contains(t, summary, "| ✅ | orders | 1/3 (1 skipped, 1 xfailed) | 1s | `run-2` |")
+}
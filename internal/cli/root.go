@@ -37,6 +37,8 @@ func NewRootCmd() *cobra.Command {
 		NewValidateCmd(),
 		NewListCmd(),
 		NewGetCmd(),
+		NewRunsCmd(),
+		NewPreviewCmd(),
 		NewProfileCmd(),
 		NewLoginCmd(),
 		NewLogoutCmd(),
@@ -7,6 +7,9 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -24,9 +27,18 @@ import (
 
 type TestSuiteResult struct {
 	Name        string
+	Path        string
+	RunID       string
 	TotalTests  int
 	PassedTests int
 	FailedTests int
+	// Tests marked skip, or skipped because a dependency didn't pass, and tests
+	// marked xfail that failed as expected. Neither fails the suite.
+	SkippedTests int
+	XFailedTests int
+	Error        string            // Why the suite could not be run
+	FailedSteps  map[string]string // Test name → name of the step that failed
+	Run          *generated.RunDetails
 }
 
 type testSummary struct {
@@ -36,6 +48,8 @@ type testSummary struct {
 	totalTests   int
 	passedTests  int
 	failedTests  int
+	skippedTests int
+	xfailedTests int
 }
 
 func summarizeResults(results []TestSuiteResult) testSummary {
@@ -44,6 +58,8 @@ func summarizeResults(results []TestSuiteResult) testSummary {
 	totalTests := 0
 	passedTests := 0
 	failedTests := 0
+	skippedTests := 0
+	xfailedTests := 0
 
 	for _, r := range results {
 		if r.FailedTests == 0 && r.TotalTests > 0 {
@@ -52,6 +68,8 @@ func summarizeResults(results []TestSuiteResult) testSummary {
 		totalTests += r.TotalTests
 		passedTests += r.PassedTests
 		failedTests += r.FailedTests
+		skippedTests += r.SkippedTests
+		xfailedTests += r.XFailedTests
 	}
 
 	return testSummary{
@@ -61,9 +79,45 @@ func summarizeResults(results []TestSuiteResult) testSummary {
 		totalTests:   totalTests,
 		passedTests:  passedTests,
 		failedTests:  failedTests,
+		skippedTests: skippedTests,
+		xfailedTests: xfailedTests,
 	}
 }
 
+// finishedCountPattern matches the counts the engine's final run log adds after
+// the passed and failed ones when there are any, e.g. "2/6 tests skipped"
+var finishedCountPattern = regexp.MustCompile(`(\d+)/\d+ tests (skipped|xfailed)`)
+
+// parseRunFinished reads the test counts of the engine's final run log into result
+func parseRunFinished(msg string, result *TestSuiteResult) error {
+	if strings.Contains(msg, "All") {
+		// Format: "Test run: "XXX" finished. All N tests passed."
+		var count int
+		if _, err := fmt.Sscanf(msg, "Test run: %q finished. All %d tests passed.", &result.Name, &count); err != nil {
+			return err
+		}
+		result.TotalTests = count
+		result.PassedTests = count
+		return nil
+	}
+
+	// Format: "Test run: "XXX" finished. N/M tests passed, P/M tests failed[, S/M tests skipped]."
+	if _, err := fmt.Sscanf(msg, "Test run: %q finished. %d/%d tests passed, %d/%d tests failed",
+		&result.Name, &result.PassedTests, &result.TotalTests, &result.FailedTests, &result.TotalTests); err != nil {
+		return err
+	}
+	for _, match := range finishedCountPattern.FindAllStringSubmatch(msg, -1) {
+		count, _ := strconv.Atoi(match[1])
+		switch match[2] {
+		case "skipped":
+			result.SkippedTests = count
+		case "xfailed":
+			result.XFailedTests = count
+		}
+	}
+	return nil
+}
+
 // injectVarsIntoYAML updates the vars: block in the YAML with the final merged vars.
 // This does NOT substitute {{ .vars.* }} templates - that happens server-side after
 // merging with environment config vars from the database.
@@ -87,11 +141,26 @@ func injectVarsIntoYAML(yamlData []byte, vars map[string]interface{}) ([]byte, e
 	return updatedYaml, nil
 }
 
+// resolveIncludes inlines the files a suite includes and its data files, reading
+// them relative to the suite. It also returns the paths of the files it read.
+func resolveIncludes(yamlData []byte, yamlPath string) ([]byte, []string, error) {
+	resolved, included, err := dsl.ResolveIncludes(yamlData, filepath.ToSlash(yamlPath), func(path string) ([]byte, error) {
+		return os.ReadFile(filepath.FromSlash(path))
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, path := range included {
+		included[i] = filepath.FromSlash(path)
+	}
+	return resolved, included, nil
+}
+
 // isReservedTestDir returns true if a directory name is reserved for internal use
-// within the .rocketship tree (e.g. tmp scratch space).
+// within the .rocketship tree (e.g. tmp scratch space, or lib for included files).
 func isReservedTestDir(name string) bool {
 	switch name {
-	case "tmp":
+	case "tmp", "lib":
 		return true
 	default:
 		return false
@@ -115,7 +184,7 @@ func findRocketshipFiles(dir string) ([]string, error) {
 
 // findYamlTestFiles recursively finds all YAML test files in the given directory.
 // Used for the .rocketship directory, where any *.yaml file is considered a test suite,
-// except for files under a tmp/ directory (e.g. .rocketship/tmp/).
+// except for files under a tmp/ or lib/ directory (e.g. .rocketship/tmp/).
 func findYamlTestFiles(dir string) ([]string, error) {
 	var files []string
 	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
@@ -157,15 +226,17 @@ func cloneRunContext(ctx *generated.RunContext) *generated.RunContext {
 }
 
 // runSingleTest runs a single test file and streams its logs
-func runSingleTest(ctx context.Context, client *EngineClient, yamlPath string, cliVars map[string]string, varFile string, showTimestamp bool, runContext *generated.RunContext, resultChan chan<- TestSuiteResult) {
+func runSingleTest(ctx context.Context, client *EngineClient, yamlPath string, cliVars map[string]string, varFile string, showTimestamp bool, tags dsl.TagFilter, runContext *generated.RunContext, resultChan chan<- TestSuiteResult) {
 	defer func() {
 		// Ensure we always send a result, even on panic
 		if r := recover(); r != nil {
 			resultChan <- TestSuiteResult{
 				Name:        "unknown",
+				Path:        yamlPath,
 				TotalTests:  0,
 				PassedTests: 0,
 				FailedTests: 0,
+				Error:       fmt.Sprintf("panic: %v", r),
 			}
 		}
 	}()
@@ -174,7 +245,15 @@ func runSingleTest(ctx context.Context, client *EngineClient, yamlPath string, c
 	yamlData, err := os.ReadFile(yamlPath)
 	if err != nil {
 		Logger.Error("failed to read test file", "path", yamlPath, "error", err)
-		resultChan <- TestSuiteResult{Name: "unknown"}
+		resultChan <- TestSuiteResult{Name: "unknown", Path: yamlPath, Error: "failed to read test file: " + err.Error()}
+		return
+	}
+
+	// Inline the files the suite includes and its data files; the engine only sees the result
+	yamlData, includedFiles, err := resolveIncludes(yamlData, yamlPath)
+	if err != nil {
+		Logger.Error("failed to resolve includes", "path", yamlPath, "error", err)
+		resultChan <- TestSuiteResult{Name: "unknown", Path: yamlPath, Error: "failed to resolve includes: " + err.Error()}
 		return
 	}
 
@@ -182,7 +261,18 @@ func runSingleTest(ctx context.Context, client *EngineClient, yamlPath string, c
 	config, err := dsl.ParseYAML(yamlData)
 	if err != nil {
 		Logger.Error("failed to parse YAML", "path", yamlPath, "error", err)
-		resultChan <- TestSuiteResult{Name: "unknown"}
+		resultChan <- TestSuiteResult{Name: "unknown", Path: yamlPath, Error: "failed to parse YAML: " + err.Error()}
+		return
+	}
+
+	// The engine runs only the tests --tags selects; suites with none of them don't run
+	if err := dsl.FilterTests(&config, tags); err != nil {
+		Logger.Error("failed to select tests by tags", "path", yamlPath, "error", err)
+		resultChan <- TestSuiteResult{Name: config.Name, Path: yamlPath, Error: err.Error()}
+		return
+	}
+	if len(config.Tests) == 0 {
+		Logger.Info("no tests match tags, skipping suite", "path", yamlPath, "tags", tags.String())
 		return
 	}
 
@@ -192,13 +282,13 @@ func runSingleTest(ctx context.Context, client *EngineClient, yamlPath string, c
 		varFileData, err := os.ReadFile(varFile)
 		if err != nil {
 			Logger.Error("failed to read variable file", "path", varFile, "error", err)
-			resultChan <- TestSuiteResult{Name: config.Name}
+			resultChan <- TestSuiteResult{Name: config.Name, Path: yamlPath, Error: "failed to read variable file: " + err.Error()}
 			return
 		}
 		var varFileConfig map[string]interface{}
 		if err := yaml.Unmarshal(varFileData, &varFileConfig); err != nil {
 			Logger.Error("failed to parse variable file", "path", varFile, "error", err)
-			resultChan <- TestSuiteResult{Name: config.Name}
+			resultChan <- TestSuiteResult{Name: config.Name, Path: yamlPath, Error: "failed to parse variable file: " + err.Error()}
 			return
 		}
 		varFileVars = varFileConfig
@@ -222,7 +312,7 @@ func runSingleTest(ctx context.Context, client *EngineClient, yamlPath string, c
 	processedYamlData, err := injectVarsIntoYAML(yamlData, finalVars)
 	if err != nil {
 		Logger.Error("failed to inject vars into YAML", "path", yamlPath, "error", err)
-		resultChan <- TestSuiteResult{Name: config.Name}
+		resultChan <- TestSuiteResult{Name: config.Name, Path: yamlPath, Error: "failed to inject vars into YAML: " + err.Error()}
 		return
 	}
 
@@ -231,12 +321,19 @@ func runSingleTest(ctx context.Context, client *EngineClient, yamlPath string, c
 		if runContext.Metadata == nil {
 			runContext.Metadata = make(map[string]string)
 		}
-		// Check if this specific suite file is dirty (modified/staged/untracked)
-		isDirty, err := IsFileDirty(yamlPath)
-		if err != nil {
-			// If we can't determine dirty status (not a git repo, etc.), log and default to uncommitted
-			Logger.Debug("could not determine file dirty status, defaulting to uncommitted", "path", yamlPath, "error", err)
-			isDirty = true
+		// Check if this specific suite file, or a file it reads, is dirty (modified/staged/untracked)
+		isDirty := false
+		for _, path := range append([]string{yamlPath}, includedFiles...) {
+			dirty, err := IsFileDirty(path)
+			if err != nil {
+				// If we can't determine dirty status (not a git repo, etc.), log and default to uncommitted
+				Logger.Debug("could not determine file dirty status, defaulting to uncommitted", "path", path, "error", err)
+				dirty = true
+			}
+			if dirty {
+				isDirty = true
+				break
+			}
 		}
 
 		if isDirty {
@@ -285,7 +382,7 @@ func runSingleTest(ctx context.Context, client *EngineClient, yamlPath string, c
 	}
 	if err != nil {
 		Logger.Error("failed to create run", "path", yamlPath, "error", err)
-		resultChan <- TestSuiteResult{Name: config.Name}
+		resultChan <- TestSuiteResult{Name: config.Name, Path: yamlPath, Error: "failed to create run: " + err.Error()}
 		return
 	}
 
@@ -294,13 +391,15 @@ func runSingleTest(ctx context.Context, client *EngineClient, yamlPath string, c
 	logStream, err := client.StreamLogs(ctx, runID)
 	if err != nil {
 		Logger.Error("failed to stream logs", "path", yamlPath, "error", err)
-		resultChan <- TestSuiteResult{Name: config.Name}
+		resultChan <- TestSuiteResult{Name: config.Name, Path: yamlPath, RunID: runID, Error: "failed to stream logs: " + err.Error()}
 		return
 	}
 	Logger.Debug("Log stream established, entering monitoring loop", "run_id", runID)
 
 	var result TestSuiteResult
 	result.Name = config.Name
+	result.Path = yamlPath
+	result.RunID = runID
 
 	// Create a channel to receive logs
 	logChan := make(chan *generated.LogLine)
@@ -362,30 +461,26 @@ func runSingleTest(ctx context.Context, client *EngineClient, yamlPath string, c
 				brackets += " [" + log.StepName + "]"
 			}
 
+			// Remember which step failed for reports
+			if log.Color == "red" && log.TestName != "" && log.StepName != "" {
+				if result.FailedSteps == nil {
+					result.FailedSteps = make(map[string]string)
+				}
+				result.FailedSteps[log.TestName] = log.StepName
+			}
+
 			// Print the log with multi-level bracket prefix and optional timestamp
+			message := formatLogMessage(log)
 			if showTimestamp {
-				fmt.Printf("%s [%s] %s\n", printer.Sprint(brackets), log.Ts, log.Msg)
+				fmt.Printf("%s [%s] %s\n", printer.Sprint(brackets), log.Ts, message)
 			} else {
-				fmt.Printf("%s %s\n", printer.Sprint(brackets), log.Msg)
+				fmt.Printf("%s %s\n", printer.Sprint(brackets), message)
 			}
 
 			// Parse final summary message to extract results
 			if strings.Contains(log.Msg, "Test run:") && strings.Contains(log.Msg, "finished") {
-				if strings.Contains(log.Msg, "All") {
-					// Format: "Test run: "XXX" finished. All N tests passed."
-					var count int
-					_, err := fmt.Sscanf(log.Msg, "Test run: %q finished. All %d tests passed.", &result.Name, &count)
-					if err == nil {
-						result.TotalTests = count
-						result.PassedTests = count
-					}
-				} else {
-					// Format: "Test run: "XXX" finished. N/M tests passed, P/M tests failed."
-					_, err := fmt.Sscanf(log.Msg, "Test run: %q finished. %d/%d tests passed, %d/%d tests failed.",
-						&result.Name, &result.PassedTests, &result.TotalTests, &result.FailedTests, &result.TotalTests)
-					if err != nil {
-						Logger.Error("failed to parse test results", "message", log.Msg, "error", err)
-					}
+				if err := parseRunFinished(log.Msg, &result); err != nil {
+					Logger.Error("failed to parse test results", "message", log.Msg, "error", err)
 				}
 			}
 		case err := <-errChan:
@@ -408,6 +503,36 @@ func runSingleTest(ctx context.Context, client *EngineClient, yamlPath string, c
 }
 
 // printFinalSummary prints the aggregated results of all test suites
+// formatLogMessage renders a log step's level and fields around its message:
+// warnings and errors are labeled, and fields follow as sorted key=value pairs
+func formatLogMessage(log *generated.LogLine) string {
+	message := log.Msg
+	switch log.Level {
+	case "warn":
+		message = color.YellowString("WARN") + " " + message
+	case "error":
+		message = color.RedString("ERROR") + " " + message
+	}
+	if len(log.Fields) == 0 {
+		return message
+	}
+	keys := make([]string, 0, len(log.Fields))
+	for key := range log.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(message)
+	for _, key := range keys {
+		value := log.Fields[key]
+		if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(&b, " %s=%s", key, value)
+	}
+	return b.String()
+}
+
 func printFinalSummary(summary testSummary) {
 	fmt.Println("\n=== Final Summary ===")
 	fmt.Printf("Total Test Suites: %d\n", summary.totalSuites)
@@ -416,6 +541,12 @@ func printFinalSummary(summary testSummary) {
 	fmt.Printf("\nTotal Tests: %d\n", summary.totalTests)
 	fmt.Printf("%s Passed Tests: %d\n", color.GreenString("✓"), summary.passedTests)
 	fmt.Printf("%s Failed Tests: %d\n", color.RedString("✗"), summary.failedTests)
+	if summary.skippedTests > 0 {
+		fmt.Printf("%s Skipped Tests: %d\n", color.YellowString("-"), summary.skippedTests)
+	}
+	if summary.xfailedTests > 0 {
+		fmt.Printf("%s XFailed Tests: %d\n", color.YellowString("x"), summary.xfailedTests)
+	}
 }
 
 // displayRecentRuns shows recent test runs after an auto run completes
@@ -573,6 +704,20 @@ func NewRunCmd() *cobra.Command {
 				return err
 			}
 
+			// Get report flags
+			junitPath, err := cmd.Flags().GetString("junit")
+			if err != nil {
+				return err
+			}
+			githubSummary, err := cmd.Flags().GetBool("github-summary")
+			if err != nil {
+				return err
+			}
+			diffLastGreen, err := cmd.Flags().GetBool("diff-last-green")
+			if err != nil {
+				return err
+			}
+
 			// Get context flags
 			projectID, _ := cmd.Flags().GetString("project-id")
 			source, _ := cmd.Flags().GetString("source")
@@ -592,6 +737,19 @@ func NewRunCmd() *cobra.Command {
 				environment = envAlias
 			}
 
+			tagsFlag, _ := cmd.Flags().GetString("tags")
+			tagFilter, err := dsl.ParseTagFilter(tagsFlag)
+			if err != nil {
+				return fmt.Errorf("invalid --tags: %w", err)
+			}
+			// The engine selects the tests by the tags in the run's metadata
+			if !tagFilter.IsEmpty() {
+				if metadata == nil {
+					metadata = make(map[string]string)
+				}
+				metadata["rs_tags"] = tagFilter.String()
+			}
+
 			// Set environment slug in metadata if provided (for project environment lookup)
 			if environment != "" {
 				if metadata == nil {
@@ -772,7 +930,7 @@ func NewRunCmd() *cobra.Command {
 					defer wg.Done()
 					// Clone RunContext for each file so they can have per-file config_source metadata
 					fileRunContext := cloneRunContext(runContext)
-					runSingleTest(ctx, client, testFile, cliVars, varFile, showTimestamp, fileRunContext, resultChan)
+					runSingleTest(ctx, client, testFile, cliVars, varFile, showTimestamp, tagFilter, fileRunContext, resultChan)
 				}(tf)
 			}
 
@@ -825,6 +983,28 @@ func NewRunCmd() *cobra.Command {
 			summary := summarizeResults(results)
 			printFinalSummary(summary)
 
+			if junitPath != "" || githubSummary || diffLastGreen {
+				// Suites finish in any order; report them in file order
+				sort.SliceStable(results, func(i, j int) bool { return results[i].Path < results[j].Path })
+				fetchRunDetails(ctx, client, results)
+			}
+			if diffLastGreen && summary.failedSuites > 0 {
+				diffAgainstLastGreen(ctx, client, results)
+			}
+			if junitPath != "" || githubSummary {
+				if junitPath != "" {
+					if err := writeJUnitReport(junitPath, results); err != nil {
+						return err
+					}
+					fmt.Printf("\nJUnit report written to %s\n", junitPath)
+				}
+				if githubSummary {
+					if err := writeGitHubSummary(results); err != nil {
+						Logger.Warn("failed to write GitHub job summary", "error", err)
+					}
+				}
+			}
+
 			// If this was an auto run, also display recent test runs
 			if isAuto {
 				if err := displayRecentRuns(client); err != nil {
@@ -851,6 +1031,10 @@ func NewRunCmd() *cobra.Command {
 	cmd.Flags().StringP("var-file", "", "", "Load variables from YAML file")
 	cmd.Flags().StringP("env-file", "", "", "Load environment variables from .env file")
 	cmd.Flags().BoolP("timestamp", "t", false, "Show timestamps in log output")
+	cmd.Flags().String("junit", "", "Write a JUnit XML report to this path")
+	cmd.Flags().Bool("github-summary", false, "Write a GitHub Actions job summary and annotate failed tests")
+	cmd.Flags().Bool("diff-last-green", false, "On failure, show what changed since the suite's last passing run on the same branch")
+	cmd.Flags().String("tags", "", "Run only tests with these tags, comma-separated; prefix a tag with ! to leave its tests out (e.g. smoke,!slow)")
 
 	// Context flags for enhanced metadata tracking
 	cmd.Flags().String("project-id", "", "Project identifier for test run tracking")
@@ -7,8 +7,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/fatih/color"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/rocketship-ai/rocketship/internal/api/generated"
 )
 
 func TestNewRunCmd(t *testing.T) {
@@ -31,6 +34,33 @@ func TestNewRunCmd(t *testing.T) {
 	assert.Equal(t, "Path to directory containing test files (for .rocketship, runs all YAML test files recursively)", dirFlag.Usage)
 }
 
+func TestFormatLogMessage(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	t.Cleanup(func() { color.NoColor = noColor })
+
+	assert.Equal(t, "Suite init completed", formatLogMessage(&generated.LogLine{Msg: "Suite init completed"}))
+	assert.Equal(t, "Created user", formatLogMessage(&generated.LogLine{Msg: "Created user", Level: "info"}))
+	assert.Equal(t, `WARN Slow response status=200 took="1.2 s" user=ada`, formatLogMessage(&generated.LogLine{
+		Msg:    "Slow response",
+		Level:  "warn",
+		Fields: map[string]string{"user": "ada", "status": "200", "took": "1.2 s"},
+	}))
+	assert.Equal(t, `ERROR Lookup failed id=""`, formatLogMessage(&generated.LogLine{Msg: "Lookup failed", Level: "error", Fields: map[string]string{"id": ""}}))
+}
+
+func TestParseRunFinished(t *testing.T) {
+	var result TestSuiteResult
+	require.NoError(t, parseRunFinished(`Test run: "Orders" finished. All 3 tests passed.`, &result))
+	assert.Equal(t, TestSuiteResult{Name: "Orders", TotalTests: 3, PassedTests: 3}, result)
+
+	result = TestSuiteResult{}
+	require.NoError(t, parseRunFinished(`Test run: "Orders" finished. 2/6 tests passed, 1/6 tests failed, 1/6 tests timed out, 1/6 tests skipped, 1/6 tests xfailed.`, &result))
+	assert.Equal(t, TestSuiteResult{Name: "Orders", TotalTests: 6, PassedTests: 2, FailedTests: 1, SkippedTests: 1, XFailedTests: 1}, result)
+
+	assert.Error(t, parseRunFinished(`Test run: "Orders" finished.`, &TestSuiteResult{}))
+}
+
 func TestFindRocketshipFiles(t *testing.T) {
 	// Create a temporary directory structure for testing
 	tmpDir, err := os.MkdirTemp("", "rocketship-test-*")
@@ -125,12 +155,18 @@ func TestFindYamlTestFilesInRocketshipDir(t *testing.T) {
 	scratchPath := filepath.Join(tmpSubdir, "scratch.yaml")
 	require.NoError(t, os.WriteFile(scratchPath, []byte("scratch"), 0644))
 
+	// Create lib directory with an included file that should be excluded
+	libSubdir := filepath.Join(rocketDir, "lib")
+	require.NoError(t, os.MkdirAll(libSubdir, 0755))
+	libPath := filepath.Join(libSubdir, "auth.yaml")
+	require.NoError(t, os.WriteFile(libPath, []byte("step_templates: {}"), 0644))
+
 	// Find YAML test files
 	found, err := findYamlTestFiles(rocketDir)
 	require.NoError(t, err)
 
-	// Should find exactly the non-tmp YAML files
-	assert.Equal(t, len(includeFiles), len(found), "Should find only YAML files outside tmp/")
+	// Should find exactly the non-tmp, non-lib YAML files
+	assert.Equal(t, len(includeFiles), len(found), "Should find only YAML files outside tmp/ and lib/")
 
 	for _, expected := range includeFiles {
 		wasFound := false
@@ -146,6 +182,7 @@ func TestFindYamlTestFilesInRocketshipDir(t *testing.T) {
 	// Ensure tmp YAML is not included
 	for _, actual := range found {
 		assert.NotEqual(t, filepath.Clean(scratchPath), filepath.Clean(actual), "tmp directory files should be excluded")
+		assert.NotEqual(t, filepath.Clean(libPath), filepath.Clean(actual), "lib directory files should be excluded")
 	}
 }
 
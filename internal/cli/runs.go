@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/api/generated"
+	"github.com/spf13/cobra"
+)
+
+// PruneFlags holds the flags for the runs prune command
+type PruneFlags struct {
+	Engine    string
+	OlderThan string
+	KeepLast  int32
+	ProjectID string
+	DryRun    bool
+	Yes       bool
+}
+
+// NewRunsCmd creates the runs command group
+func NewRunsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "runs",
+		Short: "Manage stored test runs",
+	}
+	cmd.AddCommand(newRunsPruneCmd())
+	return cmd
+}
+
+func newRunsPruneCmd() *cobra.Command {
+	flags := &PruneFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete old test runs from the engine",
+		Long: `Delete finished test runs older than a duration and/or beyond the most recent N.
+Running and pending runs are never pruned. When both --older-than and --keep-last
+are given, a run must match both to be deleted.
+
+Examples:
+  # Preview which runs would be deleted
+  rocketship runs prune --older-than 720h --dry-run
+
+  # Keep only the 100 most recent runs of a project
+  rocketship runs prune --keep-last 100 --project-id my-app --yes`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPrune(cmd, flags)
+		},
+	}
+
+	cmd.Flags().StringVarP(&flags.Engine, "engine", "e", "", "Address of the rocketship engine (defaults to active profile)")
+	cmd.Flags().StringVar(&flags.OlderThan, "older-than", "", "Delete runs created more than this long ago (e.g. 72h, 30m)")
+	cmd.Flags().Int32Var(&flags.KeepLast, "keep-last", 0, "Keep the N most recent runs and delete the rest")
+	cmd.Flags().StringVar(&flags.ProjectID, "project-id", "", "Only prune runs for this project")
+	cmd.Flags().BoolVar(&flags.DryRun, "dry-run", false, "List matching runs without deleting them")
+	cmd.Flags().BoolVarP(&flags.Yes, "yes", "y", false, "Skip the confirmation prompt")
+
+	return cmd
+}
+
+func runPrune(cmd *cobra.Command, flags *PruneFlags) error {
+	if flags.OlderThan == "" && flags.KeepLast <= 0 {
+		return fmt.Errorf("at least one of --older-than or --keep-last is required")
+	}
+	if flags.OlderThan != "" {
+		if _, err := time.ParseDuration(flags.OlderThan); err != nil {
+			return fmt.Errorf("invalid --older-than: %w", err)
+		}
+	}
+
+	engineAddr := ""
+	if cmd.Flags().Changed("engine") {
+		engineAddr = flags.Engine
+	}
+	client, err := NewEngineClient(engineAddr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to engine: %w", err)
+	}
+	defer func() {
+		if err := client.Close(); err != nil {
+			Logger.Debug("failed to close client", "error", err)
+		}
+	}()
+
+	req := &generated.PruneRunsRequest{
+		OlderThan: flags.OlderThan,
+		KeepLast:  flags.KeepLast,
+		ProjectId: flags.ProjectID,
+		DryRun:    flags.DryRun,
+	}
+
+	if !flags.DryRun && !flags.Yes {
+		// Preview first so the prompt can say how many runs are affected
+		preview, err := pruneRuns(client, &generated.PruneRunsRequest{
+			OlderThan: req.OlderThan,
+			KeepLast:  req.KeepLast,
+			ProjectId: req.ProjectId,
+			DryRun:    true,
+		})
+		if err != nil {
+			return err
+		}
+		if preview.PrunedCount == 0 {
+			fmt.Println("No runs match the prune criteria.")
+			return nil
+		}
+		fmt.Printf("Delete %d run(s)? [y/N]: ", preview.PrunedCount)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			fmt.Println("Aborted.")
+			return nil
+		}
+	}
+
+	resp, err := pruneRuns(client, req)
+	if err != nil {
+		return err
+	}
+
+	if resp.DryRun {
+		if resp.PrunedCount == 0 {
+			fmt.Println("No runs match the prune criteria.")
+			return nil
+		}
+		fmt.Printf("%d run(s) would be deleted:\n", resp.PrunedCount)
+		for _, id := range resp.RunIds {
+			fmt.Printf("  %s\n", id)
+		}
+		return nil
+	}
+
+	fmt.Printf("Deleted %d run(s).\n", resp.PrunedCount)
+	return nil
+}
+
+func pruneRuns(client *EngineClient, req *generated.PruneRunsRequest) (*generated.PruneRunsResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	Logger.Debug("pruning test runs",
+		"older_than", req.OlderThan,
+		"keep_last", req.KeepLast,
+		"project_id", req.ProjectId,
+		"dry_run", req.DryRun)
+
+	resp, err := client.client.PruneRuns(ctx, req)
+	if err != nil {
+		if wrapped := translateAuthError("failed to prune runs", err); wrapped != nil {
+			return nil, wrapped
+		}
+		return nil, fmt.Errorf("failed to prune runs: %w", err)
+	}
+	return resp, nil
+}
@@ -16,9 +16,11 @@ func NewValidateCmd() *cobra.Command {
 		Short: "Validate Rocketship test files against the JSON schema",
 		Long: `Validate one or more Rocketship test files against the JSON schema.
 This command checks test file syntax, structure, and configuration without executing tests.
+It also checks that jq expressions parse and that every runtime variable ({{ name }})
+is saved by an earlier step, reporting the test and step of each problem.
 
 When validating a directory, Rocketship uses the same discovery logic as the run command:
-- For a .rocketship directory, all *.yaml test files are validated (excluding .rocketship/tmp/)
+- For a .rocketship directory, all *.yaml test files are validated (excluding .rocketship/tmp/ and .rocketship/lib/)
 - For other directories, only files named "rocketship.yaml" are validated
 
 Examples:
@@ -50,7 +52,7 @@ func runValidate(cmd *cobra.Command, args []string) error {
 
 		if stat.IsDir() {
 			// Match run command behavior:
-			// - For .rocketship directories, validate all YAML test files (excluding .rocketship/tmp/)
+			// - For .rocketship directories, validate all YAML test files (excluding .rocketship/tmp/ and lib/)
 			// - For other directories, only validate rocketship.yaml files
 			var dirFiles []string
 			if filepath.Base(arg) == ".rocketship" {
@@ -103,11 +105,20 @@ func validateFile(filePath string) error {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
 
+	yamlData, _, err = resolveIncludes(yamlData, filePath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve includes: %w", err)
+	}
+
 	config, err := dsl.ParseYAML(yamlData)
 	if err != nil {
 		return fmt.Errorf("validation failed: %w", err)
 	}
 
+	if err := dsl.ValidateReferences(config); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+
 	// Additional summary for verbose output
 	Logger.Debug("file details",
 		"name", config.Name,
@@ -0,0 +1,100 @@
+// Package confine keeps the paths steps read and write on a worker inside the
+// directories the worker was configured to let them use.
+//
+// Paths come from test files, so a step could otherwise reach anything the
+// worker can, such as its credentials, through an absolute path, "..", or a
+// symlink planted by an earlier step. Resolve follows symlinks before checking,
+// and callers use the path it returns, so the checked path is the one opened.
+package confine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrOutside is returned for a path that leads outside every root
+var ErrOutside = errors.New("path is outside the allowed directories")
+
+// Root returns dir as an absolute path with symlinks resolved. A directory
+// that doesn't exist yet is kept as written, so steps can create it.
+func Root(dir string) (string, error) {
+	if !filepath.IsAbs(dir) {
+		return "", fmt.Errorf("root %q must be an absolute path", dir)
+	}
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return "", fmt.Errorf("failed to resolve root %s: %w", dir, err)
+		}
+		resolved = filepath.Clean(dir)
+	}
+	return resolved, nil
+}
+
+// Resolve returns path with its symlinks followed, once it is sure to stay
+// inside one of roots. Relative paths are taken from the first root. Parts of
+// the path that don't exist yet are kept as written.
+func Resolve(roots []string, path string) (string, error) {
+	if len(roots) == 0 {
+		return "", ErrOutside
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(roots[0], path)
+	}
+	path = filepath.Clean(path)
+
+	real, err := resolveExisting(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	for _, root := range roots {
+		if Within(root, real) {
+			return real, nil
+		}
+	}
+	return "", fmt.Errorf("%s: %w", path, ErrOutside)
+}
+
+// Relative checks that path is relative and can't climb out of the directory
+// it is taken from, before any symlinks are considered
+func Relative(path string) error {
+	if filepath.IsAbs(path) || filepath.VolumeName(path) != "" {
+		return fmt.Errorf("%s must be a relative path", path)
+	}
+	clean := filepath.Clean(path)
+	if clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%s must not leave its directory with ..", path)
+	}
+	return nil
+}
+
+// Within reports whether path is root or inside it. Both must be clean.
+func Within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// resolveExisting follows symlinks in the longest existing prefix of path and
+// appends the rest unchanged
+func resolveExisting(path string) (string, error) {
+	var rest []string
+	current := path
+	for {
+		resolved, err := filepath.EvalSymlinks(current)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, rest...)...), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return path, nil
+		}
+		rest = append([]string{filepath.Base(current)}, rest...)
+		current = parent
+	}
+}
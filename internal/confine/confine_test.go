@@ -0,0 +1,65 @@
+package confine
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	root, err := Root(t.TempDir())
+	if err != nil {
+		t.Fatalf("Root: %v", err)
+	}
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(root, "real"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(root, "real"), filepath.Join(root, "inside")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	for _, path := range []string{"../secret", "/etc/passwd", "escape/new.txt", filepath.Join(outside, "x")} {
+		if _, err := Resolve([]string{root}, path); !errors.Is(err, ErrOutside) {
+			t.Errorf("Resolve(%q) = %v, want ErrOutside", path, err)
+		}
+	}
+
+	// The path returned is the one checked, with symlinks followed
+	got, err := Resolve([]string{root}, "inside/new/file.txt")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if want := filepath.Join(root, "real", "new", "file.txt"); got != want {
+		t.Errorf("Resolve = %q, want %q", got, want)
+	}
+}
+
+func TestRelative(t *testing.T) {
+	for path, ok := range map[string]bool{
+		"baselines/home.png":    true,
+		"a/../b.png":            true,
+		"../home.png":           false,
+		"baselines/../../x.png": false,
+		"..":                    false,
+		"/tmp/home.png":         false,
+	} {
+		if err := Relative(path); (err == nil) != ok {
+			t.Errorf("Relative(%q) = %v, want ok %v", path, err, ok)
+		}
+	}
+}
+
+func TestRoot(t *testing.T) {
+	if _, err := Root("relative/dir"); err == nil {
+		t.Error("expected relative roots to be rejected")
+	}
+	missing := filepath.Join(t.TempDir(), "missing")
+	if got, err := Root(missing); err != nil || got != missing {
+		t.Errorf("Root(%q) = %q, %v", missing, got, err)
+	}
+}
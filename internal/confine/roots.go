@@ -0,0 +1,84 @@
+package confine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RootsEnv lists the directories steps may read and write on this worker,
+// separated like PATH. Without it, each run gets a directory of its own under
+// the worker's temporary directory, so runs can't see each other's files.
+const RootsEnv = "ROCKETSHIP_FILE_ROOTS"
+
+// runsDir holds the per-run directories, under the worker's temporary directory
+const runsDir = "rocketship-files"
+
+// WorkerRoots returns the directories steps of the run may use, with symlinks
+// resolved, in the order they were configured, or the run's own directory when
+// none are
+func WorkerRoots(runID string) ([]string, error) {
+	configured := filepath.SplitList(os.Getenv(RootsEnv))
+	if len(configured) == 0 {
+		dir, err := RunDir(runID)
+		if err != nil {
+			return nil, err
+		}
+		return []string{dir}, nil
+	}
+
+	roots := make([]string, 0, len(configured))
+	for _, root := range configured {
+		root = strings.TrimSpace(root)
+		if root == "" {
+			continue
+		}
+		if !filepath.IsAbs(root) {
+			return nil, fmt.Errorf("%s entries must be absolute paths, got %q", RootsEnv, root)
+		}
+		resolved, err := Root(root)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", RootsEnv, err)
+		}
+		roots = append(roots, resolved)
+	}
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("%s has no directories", RootsEnv)
+	}
+	return roots, nil
+}
+
+// RunDir creates the run's own directory. Run IDs come from the engine, but
+// anything other than letters, digits, - and _ is replaced to be safe.
+func RunDir(runID string) (string, error) {
+	if runID == "" {
+		runID = "local"
+	}
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, runID)
+
+	tmp, err := Root(os.TempDir())
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(tmp, runsDir, name)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create the run's file directory: %w", err)
+	}
+	return dir, nil
+}
+
+// ResolveForRun resolves path against the run's worker roots, see WorkerRoots
+// and Resolve
+func ResolveForRun(runID, path string) (string, error) {
+	roots, err := WorkerRoots(runID)
+	if err != nil {
+		return "", err
+	}
+	return Resolve(roots, path)
+}
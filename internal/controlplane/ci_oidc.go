@@ -0,0 +1,359 @@
+package controlplane
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/rocketship-ai/rocketship/internal/controlplane/persistence"
+)
+
+// RFC 8693 token exchange identifiers
+const (
+	grantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	tokenTypeIDToken       = "urn:ietf:params:oauth:token-type:id_token"
+	tokenTypeAccessToken   = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+// ciKeyRefreshInterval limits how often an issuer's JWKS is refetched for an unknown key ID
+const ciKeyRefreshInterval = time.Minute
+
+// ciProvider describes a CI system whose jobs can exchange their ID tokens at the
+// token endpoint. RepoClaim names the claim holding the repository path, which is
+// joined to RepoBaseURL to match the repo_url of connected projects.
+type ciProvider struct {
+	Name        string
+	Issuer      string
+	RepoBaseURL string
+	RepoClaim   string
+}
+
+// ciProvidersFromConfig returns the enabled CI providers. An empty issuer disables a provider.
+func ciProvidersFromConfig(cfg CIOIDCConfig) []ciProvider {
+	var providers []ciProvider
+	if issuer := strings.TrimRight(cfg.GitHubIssuer, "/"); issuer != "" {
+		// GitHub Enterprise Server issues tokens from https://<host>/_services/token
+		repoBase := "https://github.com"
+		if base, ok := strings.CutSuffix(issuer, "/_services/token"); ok {
+			repoBase = base
+		}
+		providers = append(providers, ciProvider{Name: "github", Issuer: issuer, RepoBaseURL: repoBase, RepoClaim: "repository"})
+	}
+	if issuer := strings.TrimRight(cfg.GitLabIssuer, "/"); issuer != "" {
+		providers = append(providers, ciProvider{Name: "gitlab", Issuer: issuer, RepoBaseURL: issuer, RepoClaim: "project_path"})
+	}
+	return providers
+}
+
+// repoURL builds the repository URL the ID token was issued for
+func (p ciProvider) repoURL(claims jwt.MapClaims) (string, error) {
+	repo := strings.Trim(stringClaim(claims[p.RepoClaim]), "/")
+	if repo == "" {
+		return "", fmt.Errorf("%s ID token missing %s claim", p.Name, p.RepoClaim)
+	}
+	return p.RepoBaseURL + "/" + repo, nil
+}
+
+// ciTokenVerifier validates CI ID tokens against their issuer's published keys
+type ciTokenVerifier struct {
+	providers  []ciProvider
+	audience   string
+	httpClient *http.Client
+	now        func() time.Time
+
+	mu   sync.Mutex
+	keys map[string]ciIssuerKeys
+}
+
+type ciIssuerKeys struct {
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+func newCITokenVerifier(providers []ciProvider, audience string, httpClient *http.Client) *ciTokenVerifier {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &ciTokenVerifier{
+		providers:  providers,
+		audience:   audience,
+		httpClient: httpClient,
+		now:        time.Now,
+		keys:       make(map[string]ciIssuerKeys),
+	}
+}
+
+// verify checks the ID token's signature, issuer, audience and expiry and returns
+// the provider that issued it
+func (v *ciTokenVerifier) verify(ctx context.Context, token string) (ciProvider, jwt.MapClaims, error) {
+	unverified := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, unverified); err != nil {
+		return ciProvider{}, nil, fmt.Errorf("malformed ID token: %w", err)
+	}
+	issuer := strings.TrimRight(stringClaim(unverified["iss"]), "/")
+
+	var provider ciProvider
+	found := false
+	for _, p := range v.providers {
+		if p.Issuer == issuer {
+			provider, found = p, true
+			break
+		}
+	}
+	if !found {
+		return ciProvider{}, nil, fmt.Errorf("ID token issuer %q is not a supported CI provider", issuer)
+	}
+
+	claims := jwt.MapClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{"RS256"}))
+	parsed, err := parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.key(ctx, provider.Issuer, kid)
+	})
+	if err != nil {
+		return ciProvider{}, nil, fmt.Errorf("invalid %s ID token: %w", provider.Name, err)
+	}
+	if !parsed.Valid {
+		return ciProvider{}, nil, fmt.Errorf("invalid %s ID token", provider.Name)
+	}
+	if _, ok := claims["exp"]; !ok {
+		return ciProvider{}, nil, errors.New("ID token has no expiry")
+	}
+	if !matchAudience(claims["aud"], v.audience) {
+		return ciProvider{}, nil, fmt.Errorf("ID token audience must be %q", v.audience)
+	}
+	return provider, claims, nil
+}
+
+// key returns the issuer's signing key for kid, refetching the JWKS when the key
+// is unknown so rotated keys are picked up
+func (v *ciTokenVerifier) key(ctx context.Context, issuer, kid string) (interface{}, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	cached, ok := v.keys[issuer]
+	if key := lookupCIKey(cached.keys, kid); key != nil {
+		return key, nil
+	}
+	if ok && v.now().Sub(cached.fetchedAt) < ciKeyRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	keys, err := v.fetchKeys(ctx, issuer)
+	if err != nil {
+		return nil, err
+	}
+	v.keys[issuer] = ciIssuerKeys{keys: keys, fetchedAt: v.now()}
+	if key := lookupCIKey(keys, kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func lookupCIKey(keys map[string]*rsa.PublicKey, kid string) *rsa.PublicKey {
+	if key, ok := keys[kid]; ok {
+		return key
+	}
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key
+		}
+	}
+	return nil
+}
+
+// fetchKeys resolves the issuer's JWKS through its OpenID discovery document
+func (v *ciTokenVerifier) fetchKeys(ctx context.Context, issuer string) (map[string]*rsa.PublicKey, error) {
+	var discovery struct {
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := v.getJSON(ctx, issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, fmt.Errorf("failed to load OpenID configuration for %s: %w", issuer, err)
+	}
+	if discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("OpenID configuration for %s has no jwks_uri", issuer)
+	}
+
+	var doc JWKS
+	if err := v.getJSON(ctx, discovery.JWKSURI, &doc); err != nil {
+		return nil, fmt.Errorf("failed to load signing keys for %s: %w", issuer, err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if !strings.EqualFold(k.Kty, "RSA") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no RSA signing keys published by %s", issuer)
+	}
+	return keys, nil
+}
+
+func (v *ciTokenVerifier) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// handleTokenExchangeGrant trades a CI job's ID token for a short-lived access
+// token scoped to the projects connected to the job's repository
+func (s *Server) handleTokenExchangeGrant(w http.ResponseWriter, r *http.Request) {
+	if tokenType := strings.TrimSpace(r.Form.Get("subject_token_type")); tokenType != tokenTypeIDToken {
+		writeOAuthError(w, "invalid_request", "subject_token_type must be "+tokenTypeIDToken)
+		return
+	}
+	if requested := strings.TrimSpace(r.Form.Get("requested_token_type")); requested != "" && requested != tokenTypeAccessToken {
+		writeOAuthError(w, "invalid_request", "requested_token_type must be "+tokenTypeAccessToken)
+		return
+	}
+	subjectToken := strings.TrimSpace(r.Form.Get("subject_token"))
+	if subjectToken == "" {
+		writeOAuthError(w, "invalid_request", "subject_token is required")
+		return
+	}
+	if clientID := strings.TrimSpace(r.Form.Get("client_id")); clientID != "" && clientID != s.cfg.ClientID {
+		writeOAuthError(w, "invalid_client", "unknown client")
+		return
+	}
+
+	var projectID uuid.UUID
+	if raw := strings.TrimSpace(r.Form.Get("project_id")); raw != "" {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			writeOAuthError(w, "invalid_request", "project_id must be a UUID")
+			return
+		}
+		projectID = parsed
+	}
+
+	ctx := r.Context()
+	provider, claims, err := s.ciVerifier.verify(ctx, subjectToken)
+	if err != nil {
+		writeOAuthError(w, "invalid_grant", err.Error())
+		return
+	}
+	repoURL, err := provider.repoURL(claims)
+	if err != nil {
+		writeOAuthError(w, "invalid_grant", err.Error())
+		return
+	}
+
+	projects, err := s.store.ListActiveProjectsByRepoURL(ctx, repoURL)
+	if err != nil {
+		log.Printf("failed to list projects for %s: %v", repoURL, err)
+		writeError(w, http.StatusInternalServerError, "failed to resolve projects")
+		return
+	}
+	if projectID != uuid.Nil {
+		projects = filterProjectsByID(projects, projectID)
+		if len(projects) == 0 {
+			writeOAuthError(w, "invalid_target", fmt.Sprintf("project %s is not connected to %s", projectID, repoURL))
+			return
+		}
+	}
+	if len(projects) == 0 {
+		writeOAuthError(w, "invalid_grant", fmt.Sprintf("no Rocketship project is connected to %s", repoURL))
+		return
+	}
+	orgID := projects[0].OrganizationID
+	for _, p := range projects[1:] {
+		if p.OrganizationID != orgID {
+			writeOAuthError(w, "invalid_request", fmt.Sprintf("%s is connected in more than one organization; pass project_id", repoURL))
+			return
+		}
+	}
+
+	response, err := s.mintCIToken(provider, claims, repoURL, orgID, projects)
+	if err != nil {
+		log.Printf("failed to mint CI token: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to issue token")
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func filterProjectsByID(projects []persistence.Project, projectID uuid.UUID) []persistence.Project {
+	for _, p := range projects {
+		if p.ID == projectID {
+			return []persistence.Project{p}
+		}
+	}
+	return nil
+}
+
+// mintCIToken signs an access token with write access to the given projects. CI
+// tokens carry no refresh token; jobs exchange a fresh ID token instead.
+func (s *Server) mintCIToken(provider ciProvider, claims jwt.MapClaims, repoURL string, orgID uuid.UUID, projects []persistence.Project) (oauthTokenResponse, error) {
+	now := s.nowUTC()
+	ttl := s.cfg.CIOIDC.TokenTTL
+	if ttl <= 0 {
+		ttl = defaultCITokenTTL
+	}
+
+	jti, err := generateRandomToken()
+	if err != nil {
+		return oauthTokenResponse{}, fmt.Errorf("failed to generate jti: %w", err)
+	}
+
+	scopes := make([]map[string]string, 0, len(projects))
+	for _, p := range projects {
+		scopes = append(scopes, map[string]string{"project_id": p.ID.String(), "scope": "write"})
+	}
+
+	accessToken, err := s.signer.Sign(jwt.MapClaims{
+		"iss":           s.cfg.Issuer,
+		"aud":           s.cfg.Audience,
+		"sub":           fmt.Sprintf("ci:%s:%s", provider.Name, stringClaim(claims["sub"])),
+		"exp":           now.Add(ttl).Unix(),
+		"iat":           now.Unix(),
+		"jti":           jti,
+		"roles":         []string{"editor"},
+		"org_id":        orgID.String(),
+		"ci_provider":   provider.Name,
+		"ci_repository": repoURL,
+		"ci_projects":   scopes,
+	})
+	if err != nil {
+		return oauthTokenResponse{}, fmt.Errorf("failed to sign access token: %w", err)
+	}
+
+	return oauthTokenResponse{
+		AccessToken:     accessToken,
+		TokenType:       "Bearer",
+		ExpiresIn:       int(ttl.Seconds()),
+		IssuedTokenType: tokenTypeAccessToken,
+	}, nil
+}
@@ -0,0 +1,229 @@
+package controlplane
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/rocketship-ai/rocketship/internal/controlplane/persistence"
+)
+
+// fakeCIIssuer serves OpenID discovery and JWKS for ID tokens signed by its key
+type fakeCIIssuer struct {
+	server     *httptest.Server
+	signer     *Signer
+	jwksHits   int
+	rotatedKey *Signer
+}
+
+func newFakeCIIssuer(t *testing.T) *fakeCIIssuer {
+	t.Helper()
+	issuer := &fakeCIIssuer{signer: newTestRSASigner(t, "ci-key-1")}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"issuer": issuer.server.URL, "jwks_uri": issuer.server.URL + "/jwks"})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, _ *http.Request) {
+		issuer.jwksHits++
+		jwks, _ := issuer.signer.JWKS()
+		if issuer.rotatedKey != nil {
+			rotated, _ := issuer.rotatedKey.JWKS()
+			jwks.Keys = append(jwks.Keys, rotated.Keys...)
+		}
+		writeJSON(w, http.StatusOK, jwks)
+	})
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func (f *fakeCIIssuer) idToken(t *testing.T, signer *Signer, claims jwt.MapClaims) string {
+	t.Helper()
+	base := jwt.MapClaims{
+		"iss": f.server.URL,
+		"aud": "rocketship-cli",
+		"sub": "repo:acme/shop:ref:refs/heads/main",
+		"exp": time.Now().Add(5 * time.Minute).Unix(),
+	}
+	for k, v := range claims {
+		base[k] = v
+	}
+	token, err := signer.Sign(base)
+	if err != nil {
+		t.Fatalf("failed to sign ID token: %v", err)
+	}
+	return token
+}
+
+func newTestRSASigner(t *testing.T, kid string) *Signer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	signer, err := buildSigner(key, kid)
+	if err != nil {
+		t.Fatalf("failed to build signer: %v", err)
+	}
+	return signer
+}
+
+func exchangeIDToken(srv *Server, idToken string, extra url.Values) *httptest.ResponseRecorder {
+	form := url.Values{
+		"grant_type":           {grantTypeTokenExchange},
+		"subject_token":        {idToken},
+		"subject_token_type":   {tokenTypeIDToken},
+		"requested_token_type": {tokenTypeAccessToken},
+		"client_id":            {"rocketship-cli"},
+	}
+	for k, v := range extra {
+		form[k] = v
+	}
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	srv.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestCITokenExchange(t *testing.T) {
+	github := newFakeCIIssuer(t)
+	gitlab := newFakeCIIssuer(t)
+
+	store := newFakeStore()
+	orgID, otherOrg := uuid.New(), uuid.New()
+	shopMain, shopAPI, gitlabProject, sharedA, sharedB := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	store.repoProjects = []persistence.Project{
+		{ID: shopMain, OrganizationID: orgID, RepoURL: "https://github.com/acme/shop"},
+		{ID: shopAPI, OrganizationID: orgID, RepoURL: "https://github.com/acme/shop"},
+		{ID: gitlabProject, OrganizationID: orgID, RepoURL: gitlab.server.URL + "/platform/billing"},
+		{ID: sharedA, OrganizationID: orgID, RepoURL: "https://github.com/acme/shared"},
+		{ID: sharedB, OrganizationID: otherOrg, RepoURL: "https://github.com/acme/shared"},
+	}
+
+	cfg := Config{
+		Issuer:   "https://auth.test",
+		Audience: "rocketship-cli",
+		ClientID: "rocketship-cli",
+		CIOIDC: CIOIDCConfig{
+			TokenTTL:     10 * time.Minute,
+			GitHubIssuer: github.server.URL,
+			GitLabIssuer: gitlab.server.URL,
+		},
+	}
+	srv, err := newServerWithComponents(cfg, newTestRSASigner(t, "broker"), &fakeGitHub{}, nil, store, &stubMailer{})
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	// The fake GitHub issuer isn't at the github.com token host, so match repos on github.com
+	srv.ciVerifier.providers[0].RepoBaseURL = "https://github.com"
+
+	decode := func(t *testing.T, recorder *httptest.ResponseRecorder) jwt.MapClaims {
+		t.Helper()
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+		}
+		var resp oauthTokenResponse
+		if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if resp.RefreshToken != "" || resp.ExpiresIn != 600 || resp.IssuedTokenType != tokenTypeAccessToken {
+			t.Errorf("unexpected token response %+v", resp)
+		}
+		claims, err := srv.parseToken(resp.AccessToken)
+		if err != nil {
+			t.Fatalf("issued token did not validate: %v", err)
+		}
+		return claims
+	}
+
+	t.Run("github token scoped to the repository's projects", func(t *testing.T) {
+		claims := decode(t, exchangeIDToken(srv, github.idToken(t, github.signer, jwt.MapClaims{"repository": "acme/shop"}), nil))
+		if claims["sub"] != "ci:github:repo:acme/shop:ref:refs/heads/main" || claims["org_id"] != orgID.String() || claims["ci_provider"] != "github" {
+			t.Errorf("unexpected claims %v", claims)
+		}
+		projects, _ := claims["ci_projects"].([]interface{})
+		if len(projects) != 2 {
+			t.Fatalf("expected both shop projects, got %v", claims["ci_projects"])
+		}
+		first := projects[0].(map[string]interface{})
+		if first["project_id"] != shopMain.String() || first["scope"] != "write" {
+			t.Errorf("unexpected project scope %v", first)
+		}
+	})
+
+	t.Run("project_id narrows the scope", func(t *testing.T) {
+		token := github.idToken(t, github.signer, jwt.MapClaims{"repository": "acme/shop"})
+		claims := decode(t, exchangeIDToken(srv, token, url.Values{"project_id": {shopAPI.String()}}))
+		if projects := claims["ci_projects"].([]interface{}); len(projects) != 1 {
+			t.Errorf("expected one project, got %v", projects)
+		}
+
+		recorder := exchangeIDToken(srv, token, url.Values{"project_id": {gitlabProject.String()}})
+		if recorder.Code != http.StatusBadRequest || !strings.Contains(recorder.Body.String(), "invalid_target") {
+			t.Errorf("expected invalid_target for another repository's project, got %d: %s", recorder.Code, recorder.Body.String())
+		}
+	})
+
+	t.Run("gitlab token", func(t *testing.T) {
+		token := gitlab.idToken(t, gitlab.signer, jwt.MapClaims{
+			"sub":          "project_path:platform/billing:ref_type:branch:ref:main",
+			"project_path": "platform/billing",
+		})
+		claims := decode(t, exchangeIDToken(srv, token, nil))
+		if claims["ci_provider"] != "gitlab" || claims["ci_repository"] != gitlab.server.URL+"/platform/billing" {
+			t.Errorf("unexpected claims %v", claims)
+		}
+	})
+
+	t.Run("repository connected in several organizations needs project_id", func(t *testing.T) {
+		token := github.idToken(t, github.signer, jwt.MapClaims{"repository": "acme/shared"})
+		recorder := exchangeIDToken(srv, token, nil)
+		if recorder.Code != http.StatusBadRequest || !strings.Contains(recorder.Body.String(), "pass project_id") {
+			t.Errorf("expected an ambiguity error, got %d: %s", recorder.Code, recorder.Body.String())
+		}
+		claims := decode(t, exchangeIDToken(srv, token, url.Values{"project_id": {sharedB.String()}}))
+		if claims["org_id"] != otherOrg.String() {
+			t.Errorf("expected the project's organization, got %v", claims["org_id"])
+		}
+	})
+
+	t.Run("rotated signing key is fetched", func(t *testing.T) {
+		github.rotatedKey = newTestRSASigner(t, "ci-key-2")
+		srv.ciVerifier.now = func() time.Time { return time.Now().Add(time.Hour) }
+		hits := github.jwksHits
+		decode(t, exchangeIDToken(srv, github.idToken(t, github.rotatedKey, jwt.MapClaims{"repository": "acme/shop"}), nil))
+		if github.jwksHits != hits+1 {
+			t.Errorf("expected one JWKS refetch, got %d", github.jwksHits-hits)
+		}
+	})
+
+	rejected := []struct {
+		name    string
+		token   string
+		message string
+	}{
+		{"wrong audience", github.idToken(t, github.signer, jwt.MapClaims{"repository": "acme/shop", "aud": "someone-else"}), "audience must be"},
+		{"expired", github.idToken(t, github.signer, jwt.MapClaims{"repository": "acme/shop", "exp": time.Now().Add(-time.Minute).Unix()}), "expired"},
+		{"unknown issuer", github.idToken(t, github.signer, jwt.MapClaims{"repository": "acme/shop", "iss": "https://ci.example.com"}), "not a supported CI provider"},
+		{"signed by another key", github.idToken(t, newTestRSASigner(t, "ci-key-1"), jwt.MapClaims{"repository": "acme/shop"}), "invalid github ID token"},
+		{"unconnected repository", github.idToken(t, github.signer, jwt.MapClaims{"repository": "acme/unknown"}), "no Rocketship project is connected to https://github.com/acme/unknown"},
+		{"missing repository claim", github.idToken(t, github.signer, nil), "missing repository claim"},
+	}
+	for _, tc := range rejected {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := exchangeIDToken(srv, tc.token, nil)
+			if recorder.Code != http.StatusBadRequest || !strings.Contains(recorder.Body.String(), tc.message) {
+				t.Errorf("expected %q, got %d: %s", tc.message, recorder.Code, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -25,6 +25,7 @@ type Config struct {
 	DatabaseURL         string
 	RefreshTokenKey     []byte
 	Email               EmailConfig
+	CIOIDC              CIOIDCConfig
 }
 
 type GitHubConfig struct {
@@ -42,6 +43,15 @@ type EmailConfig struct {
 	PostmarkToken string
 }
 
+// CIOIDCConfig controls the exchange of CI provider ID tokens for short-lived
+// Rocketship tokens
+type CIOIDCConfig struct {
+	Audience     string        // Audience CI ID tokens must carry; defaults to the broker audience
+	TokenTTL     time.Duration // Lifetime of exchanged tokens
+	GitHubIssuer string
+	GitLabIssuer string // Empty disables GitLab
+}
+
 type GitHubAppConfig struct {
 	AppID         int64
 	Slug          string
@@ -56,6 +66,9 @@ const (
 	defaultGitHubToken  = "https://github.com/login/oauth/access_token"
 	defaultGitHubUser   = "https://api.github.com/user"
 	defaultGitHubEmails = "https://api.github.com/user/emails"
+	defaultCITokenTTL   = 15 * time.Minute
+	defaultGitHubOIDC   = "https://token.actions.githubusercontent.com"
+	defaultGitLabOIDC   = "https://gitlab.com"
 )
 
 func LoadConfigFromEnv() (Config, error) {
@@ -169,6 +182,27 @@ func LoadConfigFromEnv() (Config, error) {
 	// GitHub webhook secret (optional - only needed for webhook ingestion)
 	cfg.GitHubWebhookSecret = strings.TrimSpace(os.Getenv("ROCKETSHIP_GITHUB_WEBHOOK_SECRET"))
 
+	// CI ID token exchange (GitHub Actions and GitLab CI)
+	cfg.CIOIDC = CIOIDCConfig{
+		Audience:     getEnvDefault("ROCKETSHIP_CI_OIDC_AUDIENCE", cfg.Audience),
+		TokenTTL:     defaultCITokenTTL,
+		GitHubIssuer: getEnvDefault("ROCKETSHIP_CI_OIDC_GITHUB_ISSUER", defaultGitHubOIDC),
+		GitLabIssuer: defaultGitLabOIDC,
+	}
+	if issuer, ok := os.LookupEnv("ROCKETSHIP_CI_OIDC_GITLAB_ISSUER"); ok {
+		cfg.CIOIDC.GitLabIssuer = strings.TrimSpace(issuer)
+	}
+	if ttlStr := strings.TrimSpace(os.Getenv("ROCKETSHIP_CI_OIDC_TOKEN_TTL")); ttlStr != "" {
+		ttl, err := time.ParseDuration(ttlStr)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid ROCKETSHIP_CI_OIDC_TOKEN_TTL: %w", err)
+		}
+		if ttl <= 0 || ttl > time.Hour {
+			return Config{}, fmt.Errorf("ROCKETSHIP_CI_OIDC_TOKEN_TTL must be between 0 and 1h")
+		}
+		cfg.CIOIDC.TokenTTL = ttl
+	}
+
 	return cfg, nil
 }
 
@@ -13,6 +13,93 @@ import (
 	"github.com/rocketship-ai/rocketship/internal/controlplane/persistence"
 )
 
+// projectListSpec is how GET /api/projects can be paginated, sorted and filtered
+var projectListSpec = listSpec{
+	sortFields:  []string{"name", "suite_count", "test_count"},
+	defaultSort: "name",
+	filters:     map[string]string{"repo_url": "repo_url", "source_ref": "source_ref"},
+}
+
+// suiteListSpec is how GET /api/projects/{projectId}/suites can be paginated,
+// sorted and filtered
+var suiteListSpec = listSpec{
+	sortFields:  []string{"name", "file_path", "test_count", "last_run_at"},
+	defaultSort: "name",
+	filters:     map[string]string{"source_ref": "source_ref", "status": "status"},
+}
+
+// projectItem is a project in GET /api/projects and GET /api/projects/{projectId}
+type projectItem struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	RepoURL       string    `json:"repo_url"`
+	DefaultBranch string    `json:"default_branch"`
+	PathScope     []string  `json:"path_scope"`
+	SourceRef     string    `json:"source_ref"`
+	SuiteCount    int       `json:"suite_count"`
+	TestCount     int       `json:"test_count"`
+	LastScan      *scanItem `json:"last_scan"`
+}
+
+// scanItem is the latest scan of a project's repository
+type scanItem struct {
+	Status       string `json:"status"`
+	CreatedAt    string `json:"created_at"`
+	HeadSHA      string `json:"head_sha"`
+	ErrorMessage string `json:"error_message"`
+	SuitesFound  int    `json:"suites_found"`
+	TestsFound   int    `json:"tests_found"`
+}
+
+// suiteItem is a suite in GET /api/projects/{projectId}/suites
+type suiteItem struct {
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	Description   *string `json:"description,omitempty"`
+	FilePath      *string `json:"file_path,omitempty"`
+	SourceRef     string  `json:"source_ref"`
+	TestCount     int     `json:"test_count"`
+	LastRunStatus *string `json:"last_run_status,omitempty"`
+	LastRunAt     *string `json:"last_run_at,omitempty"`
+}
+
+func newScanItem(scan *persistence.ScanSummary) *scanItem {
+	if scan == nil {
+		return nil
+	}
+	return &scanItem{
+		Status:       scan.Status,
+		CreatedAt:    scan.CreatedAt.Format(time.RFC3339),
+		HeadSHA:      scan.HeadSHA,
+		ErrorMessage: scan.ErrorMessage,
+		SuitesFound:  scan.SuitesFound,
+		TestsFound:   scan.TestsFound,
+	}
+}
+
+func newSuiteItem(suite persistence.CanonicalSuiteRow) suiteItem {
+	item := suiteItem{
+		ID:        suite.ID.String(),
+		Name:      suite.Name,
+		SourceRef: suite.SourceRef,
+		TestCount: suite.TestCount,
+	}
+	if suite.Description.Valid {
+		item.Description = &suite.Description.String
+	}
+	if suite.FilePath.Valid {
+		item.FilePath = &suite.FilePath.String
+	}
+	if suite.LastRunStatus.Valid {
+		item.LastRunStatus = &suite.LastRunStatus.String
+	}
+	if suite.LastRunAt.Valid {
+		lastRunAt := suite.LastRunAt.Time.Format(time.RFC3339)
+		item.LastRunAt = &lastRunAt
+	}
+	return item
+}
+
 // handleConsoleProjects handles GET /api/projects (list projects the user can access)
 func (s *Server) handleConsoleProjects(w http.ResponseWriter, r *http.Request, principal brokerPrincipal) {
 	if r.Method != http.MethodGet {
@@ -25,42 +112,33 @@ func (s *Server) handleConsoleProjects(w http.ResponseWriter, r *http.Request, p
 		return
 	}
 
+	q, err := projectListSpec.parseListQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	// Use scoped query - only returns projects user can access
-	projects, err := s.store.ListProjectSummariesForUser(r.Context(), principal.OrgID, principal.UserID)
+	page, err := s.store.ListProjectSummariesForUser(r.Context(), principal.OrgID, principal.UserID, q)
 	if err != nil {
 		log.Printf("failed to list project summaries: %v", err)
 		writeError(w, http.StatusInternalServerError, "failed to list projects")
 		return
 	}
 
-	payload := make([]map[string]interface{}, 0, len(projects))
-	for _, p := range projects {
-		item := map[string]interface{}{
-			"id":             p.ID.String(),
-			"name":           p.Name,
-			"repo_url":       p.RepoURL,
-			"default_branch": p.DefaultBranch,
-			"path_scope":     p.PathScope,
-			"source_ref":     p.SourceRef,
-			"suite_count":    p.SuiteCount,
-			"test_count":     p.TestCount,
-		}
-		if p.LastScan != nil {
-			item["last_scan"] = map[string]interface{}{
-				"status":        p.LastScan.Status,
-				"created_at":    p.LastScan.CreatedAt.Format(time.RFC3339),
-				"head_sha":      p.LastScan.HeadSHA,
-				"error_message": p.LastScan.ErrorMessage,
-				"suites_found":  p.LastScan.SuitesFound,
-				"tests_found":   p.LastScan.TestsFound,
-			}
-		} else {
-			item["last_scan"] = nil
+	writeListPage(w, q, page, func(p persistence.ProjectSummary) projectItem {
+		return projectItem{
+			ID:            p.ID.String(),
+			Name:          p.Name,
+			RepoURL:       p.RepoURL,
+			DefaultBranch: p.DefaultBranch,
+			PathScope:     p.PathScope,
+			SourceRef:     p.SourceRef,
+			SuiteCount:    p.SuiteCount,
+			TestCount:     p.TestCount,
+			LastScan:      newScanItem(p.LastScan),
 		}
-		payload = append(payload, item)
-	}
-
-	writeJSON(w, http.StatusOK, payload)
+	})
 }
 
 // handleConsoleProjectDetail handles GET /api/projects/{projectId}
@@ -119,31 +197,17 @@ func (s *Server) handleConsoleProjectDetail(w http.ResponseWriter, r *http.Reque
 		// Non-fatal, continue without scan info
 	}
 
-	payload := map[string]interface{}{
-		"id":             project.ID.String(),
-		"name":           project.Name,
-		"repo_url":       project.RepoURL,
-		"default_branch": project.DefaultBranch,
-		"path_scope":     project.PathScope,
-		"source_ref":     project.SourceRef,
-		"suite_count":    suiteCount,
-		"test_count":     testCount,
-	}
-
-	if lastScan != nil {
-		payload["last_scan"] = map[string]interface{}{
-			"status":        lastScan.Status,
-			"created_at":    lastScan.CreatedAt.Format(time.RFC3339),
-			"head_sha":      lastScan.HeadSHA,
-			"error_message": lastScan.ErrorMessage,
-			"suites_found":  lastScan.SuitesFound,
-			"tests_found":   lastScan.TestsFound,
-		}
-	} else {
-		payload["last_scan"] = nil
-	}
-
-	writeJSON(w, http.StatusOK, payload)
+	writeJSON(w, http.StatusOK, projectItem{
+		ID:            project.ID.String(),
+		Name:          project.Name,
+		RepoURL:       project.RepoURL,
+		DefaultBranch: project.DefaultBranch,
+		PathScope:     project.PathScope,
+		SourceRef:     project.SourceRef,
+		SuiteCount:    suiteCount,
+		TestCount:     testCount,
+		LastScan:      newScanItem(lastScan),
+	})
 }
 
 // handleConsoleProjectSuites handles GET /api/projects/{projectId}/suites
@@ -170,38 +234,21 @@ func (s *Server) handleConsoleProjectSuites(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	q, err := suiteListSpec.parseListQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	// Use canonical suites list (deduped by file_path, prefer default branch)
-	suites, err := s.store.ListSuitesForProjectCanonical(r.Context(), projectID)
+	page, err := s.store.ListSuitesForProjectCanonicalPage(r.Context(), projectID, q)
 	if err != nil {
 		log.Printf("failed to list canonical suites: %v", err)
 		writeError(w, http.StatusInternalServerError, "failed to list suites")
 		return
 	}
 
-	payload := make([]map[string]interface{}, 0, len(suites))
-	for _, suite := range suites {
-		item := map[string]interface{}{
-			"id":         suite.ID.String(),
-			"name":       suite.Name,
-			"source_ref": suite.SourceRef,
-			"test_count": suite.TestCount,
-		}
-		if suite.Description.Valid {
-			item["description"] = suite.Description.String
-		}
-		if suite.FilePath.Valid {
-			item["file_path"] = suite.FilePath.String
-		}
-		if suite.LastRunStatus.Valid {
-			item["last_run_status"] = suite.LastRunStatus.String
-		}
-		if suite.LastRunAt.Valid {
-			item["last_run_at"] = suite.LastRunAt.Time.Format(time.RFC3339)
-		}
-		payload = append(payload, item)
-	}
-
-	writeJSON(w, http.StatusOK, payload)
+	writeListPage(w, q, page, newSuiteItem)
 }
 
 // handleSuiteActivity handles GET /api/suites/activity
@@ -469,6 +516,7 @@ func (s *Server) formatSuiteRuns(runs []persistence.SuiteRunRow) []map[string]in
 			"failed_tests":  run.FailedTests,
 			"timeout_tests": run.TimeoutTests,
 			"skipped_tests": run.SkippedTests,
+			"xfail_tests":   run.XFailTests,
 		}
 
 		// Nullable fields
@@ -666,6 +714,9 @@ func (s *Server) handleConsoleProjectRoutesDispatch(w http.ResponseWriter, r *ht
 	case "project-schedules":
 		// Create project schedule
 		s.handleCreateProjectSchedule(w, r, principal, projectID)
+	case "digest":
+		// Email digest of scheduled suite health
+		s.handleProjectDigest(w, r, principal, projectID)
 	default:
 		writeError(w, http.StatusNotFound, "resource not found")
 	}
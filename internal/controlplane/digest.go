@@ -0,0 +1,228 @@
+package controlplane
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rocketship-ai/rocketship/internal/controlplane/persistence"
+)
+
+const (
+	// digestPollInterval is how often the digest job looks for digests that are due
+	digestPollInterval = 5 * time.Minute
+	// digestBatchSize caps the digests claimed per poll
+	digestBatchSize = 20
+	// maxDigestRecipients caps a digest's recipient list
+	maxDigestRecipients = 20
+)
+
+// RunDigests sends project digests as they come due until ctx is cancelled. Every
+// control plane replica can run it; each digest is claimed by exactly one.
+func (s *Server) RunDigests(ctx context.Context) {
+	ticker := time.NewTicker(digestPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sendDueDigests(ctx)
+		}
+	}
+}
+
+// sendDueDigests claims the digests that are due and mails them. A digest that
+// fails to send is not retried; the next one covers the following period.
+func (s *Server) sendDueDigests(ctx context.Context) {
+	now := s.nowUTC()
+	digests, err := s.store.ClaimDueProjectDigests(ctx, now, digestBatchSize)
+	if err != nil {
+		log.Printf("digest: failed to claim due digests: %v", err)
+		return
+	}
+
+	for _, digest := range digests {
+		since := now.Add(-persistence.DigestPeriod(digest.Frequency))
+		stats, err := s.store.GetProjectDigestStats(ctx, digest.ProjectID, since, now)
+		if err != nil {
+			log.Printf("digest: failed to summarize project %s: %v", digest.ProjectID, err)
+			continue
+		}
+
+		email := ProjectDigestEmail{
+			ProjectName: digest.ProjectName,
+			Frequency:   digest.Frequency,
+			PeriodStart: since,
+			PeriodEnd:   now,
+			Stats:       stats,
+			ProjectURL:  strings.TrimRight(s.cfg.Issuer, "/") + "/projects/" + digest.ProjectID.String(),
+		}
+		for _, recipient := range digest.Recipients {
+			if err := s.mailer.SendProjectDigest(ctx, recipient, email); err != nil {
+				log.Printf("digest: failed to send project %s digest to %s: %v", digest.ProjectID, recipient, err)
+			}
+		}
+	}
+}
+
+// handleProjectDigest handles /api/projects/{projectId}/digest
+// GET: Get the project's digest settings
+// PUT: Turn the digest on or change it
+// DELETE: Turn the digest off
+func (s *Server) handleProjectDigest(w http.ResponseWriter, r *http.Request, principal brokerPrincipal, projectID uuid.UUID) {
+	if principal.RequiresOrgMembership() {
+		writeError(w, http.StatusForbidden, "organization membership required")
+		return
+	}
+
+	ctx := r.Context()
+
+	switch r.Method {
+	case http.MethodGet:
+		canAccess, err := s.store.UserCanAccessProject(ctx, principal.OrgID, principal.UserID, projectID)
+		if err != nil {
+			log.Printf("failed to check project access: %v", err)
+			writeError(w, http.StatusInternalServerError, "failed to check access")
+			return
+		}
+		if !canAccess {
+			writeError(w, http.StatusNotFound, "project not found")
+			return
+		}
+
+		digest, err := s.store.GetProjectDigest(ctx, projectID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				writeError(w, http.StatusNotFound, "digest not enabled")
+				return
+			}
+			log.Printf("failed to get project digest: %v", err)
+			writeError(w, http.StatusInternalServerError, "failed to get digest")
+			return
+		}
+		writeJSON(w, http.StatusOK, formatDigestResponse(digest))
+
+	case http.MethodPut, http.MethodDelete:
+		hasWrite, err := s.store.UserHasProjectWriteAccess(ctx, principal.OrgID, principal.UserID, projectID)
+		if err != nil {
+			log.Printf("failed to check project write access: %v", err)
+			writeError(w, http.StatusInternalServerError, "failed to check access")
+			return
+		}
+		if !hasWrite {
+			writeError(w, http.StatusForbidden, "write access required")
+			return
+		}
+
+		if r.Method == http.MethodDelete {
+			if err := s.store.DeleteProjectDigest(ctx, projectID); err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					writeError(w, http.StatusNotFound, "digest not enabled")
+					return
+				}
+				log.Printf("failed to delete project digest: %v", err)
+				writeError(w, http.StatusInternalServerError, "failed to delete digest")
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		s.handlePutProjectDigest(w, r, principal, projectID)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) handlePutProjectDigest(w http.ResponseWriter, r *http.Request, principal brokerPrincipal, projectID uuid.UUID) {
+	var body struct {
+		Frequency  string   `json:"frequency"`
+		Recipients []string `json:"recipients"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+
+	frequency := strings.ToLower(strings.TrimSpace(body.Frequency))
+	if frequency != persistence.DigestDaily && frequency != persistence.DigestWeekly {
+		writeError(w, http.StatusBadRequest, "frequency must be daily or weekly")
+		return
+	}
+
+	recipients, msg := normalizeDigestRecipients(body.Recipients)
+	if msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+	// Default to the person turning the digest on
+	if len(recipients) == 0 {
+		if principal.Email == "" {
+			writeError(w, http.StatusBadRequest, "recipients is required")
+			return
+		}
+		recipients = []string{principal.Email}
+	}
+
+	digest, err := s.store.UpsertProjectDigest(r.Context(), persistence.ProjectDigest{
+		ProjectID:  projectID,
+		Frequency:  frequency,
+		Recipients: recipients,
+		CreatedBy:  uuid.NullUUID{UUID: principal.UserID, Valid: principal.UserID != uuid.Nil},
+	})
+	if err != nil {
+		log.Printf("failed to save project digest: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to save digest")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, formatDigestResponse(digest))
+}
+
+// normalizeDigestRecipients trims, lowercases and dedupes the addresses, returning
+// a message when one is invalid
+func normalizeDigestRecipients(raw []string) ([]string, string) {
+	if len(raw) > maxDigestRecipients {
+		return nil, "a digest can have at most 20 recipients"
+	}
+
+	seen := make(map[string]bool, len(raw))
+	recipients := make([]string, 0, len(raw))
+	for _, address := range raw {
+		address = strings.ToLower(strings.TrimSpace(address))
+		if address == "" || seen[address] {
+			continue
+		}
+		if parsed, err := mail.ParseAddress(address); err != nil || parsed.Address != address {
+			return nil, "invalid recipient email: " + address
+		}
+		seen[address] = true
+		recipients = append(recipients, address)
+	}
+	return recipients, ""
+}
+
+func formatDigestResponse(digest persistence.ProjectDigest) map[string]interface{} {
+	resp := map[string]interface{}{
+		"project_id":   digest.ProjectID.String(),
+		"frequency":    digest.Frequency,
+		"recipients":   []string(digest.Recipients),
+		"next_send_at": digest.NextSendAt.Format(time.RFC3339),
+		"created_at":   digest.CreatedAt.Format(time.RFC3339),
+		"updated_at":   digest.UpdatedAt.Format(time.RFC3339),
+	}
+	if digest.LastSentAt.Valid {
+		resp["last_sent_at"] = digest.LastSentAt.Time.Format(time.RFC3339)
+	}
+	return resp
+}
@@ -0,0 +1,155 @@
+package controlplane
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rocketship-ai/rocketship/internal/controlplane/persistence"
+)
+
+func newDigestTestServer(t *testing.T) (*Server, *fakeStore, *stubMailer) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	signer, err := buildSigner(key, "test-key")
+	if err != nil {
+		t.Fatalf("failed to build signer: %v", err)
+	}
+
+	cfg := Config{
+		Issuer:          "https://app.rocketship.test",
+		Audience:        "rocketship-cli",
+		ClientID:        "rocketship-cli",
+		AccessTokenTTL:  time.Minute,
+		RefreshTokenTTL: time.Hour,
+		GitHub:          GitHubConfig{ClientID: "gh", ClientSecret: "secret"},
+	}
+
+	store := newFakeStore()
+	mail := &stubMailer{}
+	srv, err := newServerWithComponents(cfg, signer, &fakeGitHub{}, nil, store, mail)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	return srv, store, mail
+}
+
+func TestProjectDigestEndpoints(t *testing.T) {
+	srv, store, _ := newDigestTestServer(t)
+	principal := brokerPrincipal{
+		UserID: store.user.ID,
+		OrgID:  store.primaryOrg,
+		Roles:  []string{"owner"},
+		Email:  "owner@example.com",
+	}
+	path := "/api/projects/" + store.primaryProject.String() + "/digest"
+
+	do := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		srv.handleConsoleProjectRoutesDispatch(rec, req, principal)
+		return rec
+	}
+
+	if rec := do(http.MethodGet, ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 before the digest is enabled, got %d", rec.Code)
+	}
+
+	rec := do(http.MethodPut, `{"frequency":"hourly"}`)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "frequency must be daily or weekly") {
+		t.Fatalf("expected a frequency error, got %d %s", rec.Code, rec.Body.String())
+	}
+	rec = do(http.MethodPut, `{"frequency":"weekly","recipients":["not an email"]}`)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid recipient email") {
+		t.Fatalf("expected a recipient error, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	// Recipients default to the caller
+	rec = do(http.MethodPut, `{"frequency":"Weekly"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Frequency  string   `json:"frequency"`
+		Recipients []string `json:"recipients"`
+		NextSendAt string   `json:"next_send_at"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Frequency != "weekly" || len(resp.Recipients) != 1 || resp.Recipients[0] != "owner@example.com" || resp.NextSendAt == "" {
+		t.Errorf("unexpected digest: %+v", resp)
+	}
+
+	rec = do(http.MethodPut, `{"frequency":"daily","recipients":["QA@example.com","qa@example.com "," dev@example.com"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %s", rec.Code, rec.Body.String())
+	}
+	digest := store.digests[store.primaryProject]
+	if digest.Frequency != "daily" || strings.Join(digest.Recipients, ",") != "qa@example.com,dev@example.com" {
+		t.Errorf("expected deduped recipients, got %+v", digest)
+	}
+
+	if rec := do(http.MethodGet, ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if rec := do(http.MethodDelete, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+	if rec := do(http.MethodDelete, ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 once the digest is off, got %d", rec.Code)
+	}
+}
+
+func TestSendDueDigests(t *testing.T) {
+	srv, store, mail := newDigestTestServer(t)
+	now := time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC)
+	srv.now = func() time.Time { return now }
+
+	store.digests = map[uuid.UUID]persistence.ProjectDigest{
+		store.primaryProject: {
+			ProjectID:   store.primaryProject,
+			ProjectName: "Storefront",
+			Frequency:   persistence.DigestWeekly,
+			Recipients:  []string{"qa@example.com", "dev@example.com"},
+			NextSendAt:  now.Add(-time.Minute),
+		},
+	}
+	store.digestStats = persistence.ProjectDigestStats{
+		PassRates: []persistence.DigestPassRate{{Environment: "staging", Runs: 20, Passed: 18}},
+	}
+
+	srv.sendDueDigests(t.Context())
+
+	if len(mail.digests) != 2 || mail.digests[0].email != "qa@example.com" || mail.digests[1].email != "dev@example.com" {
+		t.Fatalf("expected the digest mailed to both recipients, got %+v", mail.digests)
+	}
+	sent := mail.digests[0].digest
+	if sent.ProjectName != "Storefront" || !sent.PeriodStart.Equal(now.Add(-7*24*time.Hour)) || !sent.PeriodEnd.Equal(now) {
+		t.Errorf("unexpected digest period: %+v", sent)
+	}
+	if sent.ProjectURL != "https://app.rocketship.test/projects/"+store.primaryProject.String() {
+		t.Errorf("unexpected project url %q", sent.ProjectURL)
+	}
+	if len(sent.Stats.PassRates) != 1 {
+		t.Errorf("expected the project's stats, got %+v", sent.Stats)
+	}
+	if next := store.digests[store.primaryProject].NextSendAt; !next.Equal(now.Add(7 * 24 * time.Hour)) {
+		t.Errorf("expected the next digest in a week, got %s", next)
+	}
+
+	// Nothing is due until next week
+	srv.sendDueDigests(t.Context())
+	if len(mail.digests) != 2 {
+		t.Errorf("expected no more digests, got %d", len(mail.digests))
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strings"
@@ -17,6 +18,7 @@ import (
 type EnvironmentCreateRequest struct {
 	Name       string                 `json:"name"`
 	Slug       string                 `json:"slug"`
+	Fallback   string                 `json:"fallback,omitempty"`
 	EnvSecrets map[string]string      `json:"env_secrets,omitempty"`
 	ConfigVars map[string]interface{} `json:"config_vars,omitempty"`
 }
@@ -25,10 +27,14 @@ type EnvironmentCreateRequest struct {
 type EnvironmentUpdateRequest struct {
 	Name       string                 `json:"name,omitempty"`
 	Slug       string                 `json:"slug,omitempty"`
+	Fallback   *string                `json:"fallback,omitempty"` // Empty string removes the fallback
 	EnvSecrets map[string]string      `json:"env_secrets,omitempty"`
 	ConfigVars map[string]interface{} `json:"config_vars,omitempty"`
 }
 
+// maxEnvironmentFallbacks bounds how long a fallback chain may be
+const maxEnvironmentFallbacks = 10
+
 // handleProjectEnvironments handles all /api/projects/{projectId}/environments routes
 func (s *Server) handleProjectEnvironments(w http.ResponseWriter, r *http.Request, principal brokerPrincipal, projectID uuid.UUID, segments []string) {
 	if principal.RequiresOrgMembership() {
@@ -85,21 +91,30 @@ func (s *Server) handleProjectEnvironments(w http.ResponseWriter, r *http.Reques
 	writeError(w, http.StatusNotFound, "not found")
 }
 
+// environmentListSpec is how GET /api/projects/{projectId}/environments can be
+// paginated, sorted and filtered
+var environmentListSpec = listSpec{
+	sortFields:  []string{"name", "slug", "created_at", "updated_at"},
+	defaultSort: "name",
+	filters:     map[string]string{"fallback": "fallback"},
+}
+
 // handleListEnvironments handles GET /api/projects/{projectId}/environments
 func (s *Server) handleListEnvironments(w http.ResponseWriter, r *http.Request, _ brokerPrincipal, projectID uuid.UUID) {
-	envs, err := s.store.ListEnvironments(r.Context(), projectID)
+	q, err := environmentListSpec.parseListQuery(r)
 	if err != nil {
-		log.Printf("failed to list environments: %v", err)
-		writeError(w, http.StatusInternalServerError, "failed to list environments")
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	payload := make([]map[string]interface{}, 0, len(envs))
-	for _, env := range envs {
-		payload = append(payload, formatEnvironmentResponse(env))
+	page, err := s.store.ListEnvironmentsPage(r.Context(), projectID, q)
+	if err != nil {
+		log.Printf("failed to list environments: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to list environments")
+		return
 	}
 
-	writeJSON(w, http.StatusOK, payload)
+	writeListPage(w, q, page, formatEnvironmentResponse)
 }
 
 // handleCreateEnvironment handles POST /api/projects/{projectId}/environments
@@ -123,10 +138,22 @@ func (s *Server) handleCreateEnvironment(w http.ResponseWriter, r *http.Request,
 		ProjectID:  projectID,
 		Name:       strings.TrimSpace(req.Name),
 		Slug:       strings.ToLower(strings.TrimSpace(req.Slug)),
+		Fallback:   strings.ToLower(strings.TrimSpace(req.Fallback)),
 		EnvSecrets: req.EnvSecrets,
 		ConfigVars: req.ConfigVars,
 	}
 
+	if env.Fallback != "" {
+		if msg, err := s.checkEnvironmentFallback(r, projectID, env.Slug, env.Fallback); err != nil {
+			log.Printf("failed to check environment fallback: %v", err)
+			writeError(w, http.StatusInternalServerError, "failed to create environment")
+			return
+		} else if msg != "" {
+			writeError(w, http.StatusBadRequest, msg)
+			return
+		}
+	}
+
 	created, err := s.store.CreateEnvironment(r.Context(), env)
 	if err != nil {
 		if strings.Contains(err.Error(), "already exists") {
@@ -182,7 +209,31 @@ func (s *Server) handleUpdateEnvironment(w http.ResponseWriter, r *http.Request,
 		existing.Name = strings.TrimSpace(req.Name)
 	}
 	if req.Slug != "" {
-		existing.Slug = strings.ToLower(strings.TrimSpace(req.Slug))
+		slug := strings.ToLower(strings.TrimSpace(req.Slug))
+		if slug != existing.Slug {
+			if msg, err := s.checkEnvironmentDependents(r, projectID, existing.Slug, "renamed"); err != nil {
+				log.Printf("failed to check environment dependents: %v", err)
+				writeError(w, http.StatusInternalServerError, "failed to update environment")
+				return
+			} else if msg != "" {
+				writeError(w, http.StatusConflict, msg)
+				return
+			}
+		}
+		existing.Slug = slug
+	}
+	if req.Fallback != nil {
+		existing.Fallback = strings.ToLower(strings.TrimSpace(*req.Fallback))
+	}
+	if req.Fallback != nil && existing.Fallback != "" {
+		if msg, err := s.checkEnvironmentFallback(r, projectID, existing.Slug, existing.Fallback); err != nil {
+			log.Printf("failed to check environment fallback: %v", err)
+			writeError(w, http.StatusInternalServerError, "failed to update environment")
+			return
+		} else if msg != "" {
+			writeError(w, http.StatusBadRequest, msg)
+			return
+		}
 	}
 
 	// For env_secrets, we merge with existing (allow partial updates)
@@ -225,6 +276,25 @@ func (s *Server) handleUpdateEnvironment(w http.ResponseWriter, r *http.Request,
 
 // handleDeleteEnvironment handles DELETE /api/projects/{projectId}/environments/{envId}
 func (s *Server) handleDeleteEnvironment(w http.ResponseWriter, r *http.Request, _ brokerPrincipal, projectID, envID uuid.UUID) {
+	env, err := s.store.GetEnvironment(r.Context(), projectID, envID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "environment not found")
+			return
+		}
+		log.Printf("failed to get environment: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to delete environment")
+		return
+	}
+	if msg, err := s.checkEnvironmentDependents(r, projectID, env.Slug, "deleted"); err != nil {
+		log.Printf("failed to check environment dependents: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to delete environment")
+		return
+	} else if msg != "" {
+		writeError(w, http.StatusConflict, msg)
+		return
+	}
+
 	if err := s.store.DeleteEnvironment(r.Context(), projectID, envID); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			writeError(w, http.StatusNotFound, "environment not found")
@@ -238,30 +308,86 @@ func (s *Server) handleDeleteEnvironment(w http.ResponseWriter, r *http.Request,
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// checkEnvironmentFallback returns why slug can't fall back to fallback, or "" when
+// it can: the fallback has to exist in the project and its own chain must neither
+// lead back to slug nor get too long
+func (s *Server) checkEnvironmentFallback(r *http.Request, projectID uuid.UUID, slug, fallback string) (string, error) {
+	chain := []string{slug}
+	for next := fallback; next != ""; {
+		if next == slug {
+			return fmt.Sprintf("fallback would create a cycle: %s", strings.Join(append(chain, next), " → ")), nil
+		}
+		if len(chain) > maxEnvironmentFallbacks {
+			return fmt.Sprintf("fallback chain is longer than %d environments", maxEnvironmentFallbacks), nil
+		}
+		env, err := s.store.GetEnvironmentBySlug(r.Context(), projectID, next)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Sprintf("fallback environment %q not found", next), nil
+			}
+			return "", err
+		}
+		chain = append(chain, env.Slug)
+		next = env.Fallback
+	}
+	return "", nil
+}
+
+// checkEnvironmentDependents returns why the environment can't be renamed or deleted
+// while others fall back to it, or "" when none do
+func (s *Server) checkEnvironmentDependents(r *http.Request, projectID uuid.UUID, slug, action string) (string, error) {
+	envs, err := s.store.ListEnvironments(r.Context(), projectID)
+	if err != nil {
+		return "", err
+	}
+	var dependents []string
+	for _, env := range envs {
+		if env.Fallback == slug {
+			dependents = append(dependents, env.Slug)
+		}
+	}
+	if len(dependents) == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("environment %q is the fallback of %s; change their fallback before it can be %s", slug, strings.Join(dependents, ", "), action), nil
+}
+
+// environmentResponse is a ProjectEnvironment in API responses
+type environmentResponse struct {
+	ID             string                 `json:"id"`
+	ProjectID      string                 `json:"project_id"`
+	Name           string                 `json:"name"`
+	Slug           string                 `json:"slug"`
+	Fallback       string                 `json:"fallback"`
+	EnvSecretsKeys []string               `json:"env_secrets_keys"`
+	ConfigVars     map[string]interface{} `json:"config_vars"`
+	CreatedAt      string                 `json:"created_at"`
+	UpdatedAt      string                 `json:"updated_at"`
+}
+
 // formatEnvironmentResponse formats a ProjectEnvironment for API response
 // Secret values are NOT returned; only keys are exposed
-func formatEnvironmentResponse(env persistence.ProjectEnvironment) map[string]interface{} {
-	resp := map[string]interface{}{
-		"id":         env.ID.String(),
-		"project_id": env.ProjectID.String(),
-		"name":       env.Name,
-		"slug":       env.Slug,
-		"created_at": env.CreatedAt.Format(time.RFC3339),
-		"updated_at": env.UpdatedAt.Format(time.RFC3339),
+func formatEnvironmentResponse(env persistence.ProjectEnvironment) environmentResponse {
+	resp := environmentResponse{
+		ID:        env.ID.String(),
+		ProjectID: env.ProjectID.String(),
+		Name:      env.Name,
+		Slug:      env.Slug,
+		Fallback:  env.Fallback,
+		CreatedAt: env.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: env.UpdatedAt.Format(time.RFC3339),
 	}
 
 	// Return only secret keys, not values
-	secretKeys := make([]string, 0, len(env.EnvSecrets))
+	resp.EnvSecretsKeys = make([]string, 0, len(env.EnvSecrets))
 	for k := range env.EnvSecrets {
-		secretKeys = append(secretKeys, k)
+		resp.EnvSecretsKeys = append(resp.EnvSecretsKeys, k)
 	}
-	resp["env_secrets_keys"] = secretKeys
 
 	// Return full config vars (values are visible)
-	if env.ConfigVars == nil {
-		resp["config_vars"] = map[string]interface{}{}
-	} else {
-		resp["config_vars"] = env.ConfigVars
+	resp.ConfigVars = env.ConfigVars
+	if resp.ConfigVars == nil {
+		resp.ConfigVars = map[string]interface{}{}
 	}
 
 	return resp
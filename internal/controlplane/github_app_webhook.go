@@ -360,6 +360,25 @@ func (s *Server) handlePRLifecycleEvent(ctx context.Context, payload *webhookPay
 					"count", suiteCount,
 				)
 			}
+
+			// Delete the PR's preview environments and archive their runs
+			envCount, runCount, err := s.store.DeletePreviewEnvironmentsForRepoAndPR(ctx, orgID, repoURL, payload.PullRequest.Number)
+			if err != nil {
+				slog.Error("webhook: failed to delete preview environments",
+					"org_id", orgID,
+					"repo", payload.Repository.FullName,
+					"pr_number", payload.PullRequest.Number,
+					"error", err,
+				)
+			} else if envCount > 0 {
+				slog.Info("webhook: deleted preview environments on PR close",
+					"org_id", orgID,
+					"repo", payload.Repository.FullName,
+					"pr_number", payload.PullRequest.Number,
+					"count", envCount,
+					"archived_runs", runCount,
+				)
+			}
 		}
 		return
 	}
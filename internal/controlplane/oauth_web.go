@@ -32,6 +32,8 @@ func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
 		s.handleAuthorizationCodeGrant(w, r)
 	case "refresh_token":
 		s.handleRefreshGrant(w, r)
+	case grantTypeTokenExchange:
+		s.handleTokenExchangeGrant(w, r)
 	default:
 		writeOAuthError(w, "unsupported_grant_type", "grant_type not supported")
 	}
@@ -0,0 +1,123 @@
+package controlplane
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rocketship-ai/rocketship/internal/controlplane/persistence"
+)
+
+// OrgDefaultEnvironmentRequest is the request body for setting an organization's
+// default environment
+type OrgDefaultEnvironmentRequest struct {
+	EnvSecrets map[string]string      `json:"env_secrets,omitempty"`
+	ConfigVars map[string]interface{} `json:"config_vars,omitempty"`
+}
+
+// handleOrgDefaultEnvironment handles /api/orgs/{orgId}/default-environment.
+// Any org member can view it; only owners can set or remove it, since its
+// secrets reach every project's runs.
+func (s *Server) handleOrgDefaultEnvironment(w http.ResponseWriter, r *http.Request, principal brokerPrincipal, orgID uuid.UUID, tail []string) {
+	if len(tail) > 0 {
+		writeError(w, http.StatusNotFound, "resource not found")
+		return
+	}
+	if principal.RequiresOrgMembership() {
+		writeError(w, http.StatusForbidden, "organization membership required")
+		return
+	}
+	if principal.OrgID != orgID {
+		writeError(w, http.StatusForbidden, "organization access required")
+		return
+	}
+
+	ctx := r.Context()
+	if r.Method == http.MethodGet {
+		env, err := s.store.GetOrgDefaultEnvironment(ctx, orgID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				writeError(w, http.StatusNotFound, "organization has no default environment")
+				return
+			}
+			log.Printf("failed to get org default environment: %v", err)
+			writeError(w, http.StatusInternalServerError, "failed to get default environment")
+			return
+		}
+		writeJSON(w, http.StatusOK, formatOrgDefaultEnvironmentResponse(env))
+		return
+	}
+	if r.Method != http.MethodPut && r.Method != http.MethodDelete {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	isOwner, err := s.store.IsOrganizationOwner(ctx, orgID, principal.UserID)
+	if err != nil {
+		log.Printf("failed to check org owner: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to authorize request")
+		return
+	}
+	if !isOwner {
+		writeError(w, http.StatusForbidden, "owner role required")
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		if err := s.store.DeleteOrgDefaultEnvironment(ctx, orgID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				writeError(w, http.StatusNotFound, "organization has no default environment")
+				return
+			}
+			log.Printf("failed to delete org default environment: %v", err)
+			writeError(w, http.StatusInternalServerError, "failed to delete default environment")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var req OrgDefaultEnvironmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	env, err := s.store.SetOrgDefaultEnvironment(ctx, persistence.OrgDefaultEnvironment{
+		OrganizationID: orgID,
+		EnvSecrets:     req.EnvSecrets,
+		ConfigVars:     req.ConfigVars,
+	})
+	if err != nil {
+		log.Printf("failed to set org default environment: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to set default environment")
+		return
+	}
+	writeJSON(w, http.StatusOK, formatOrgDefaultEnvironmentResponse(env))
+}
+
+// formatOrgDefaultEnvironmentResponse formats an organization's default
+// environment like a project environment: secret keys, not values
+func formatOrgDefaultEnvironmentResponse(env persistence.OrgDefaultEnvironment) map[string]interface{} {
+	secretKeys := make([]string, 0, len(env.EnvSecrets))
+	for k := range env.EnvSecrets {
+		secretKeys = append(secretKeys, k)
+	}
+	sort.Strings(secretKeys)
+
+	configVars := env.ConfigVars
+	if configVars == nil {
+		configVars = map[string]interface{}{}
+	}
+	return map[string]interface{}{
+		"organization_id":  env.OrganizationID.String(),
+		"env_secrets_keys": secretKeys,
+		"config_vars":      configVars,
+		"created_at":       env.CreatedAt.Format(time.RFC3339),
+		"updated_at":       env.UpdatedAt.Format(time.RFC3339),
+	}
+}
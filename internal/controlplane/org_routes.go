@@ -41,6 +41,8 @@ func (s *Server) handleOrgRoutes(w http.ResponseWriter, r *http.Request, princip
 		s.handleOrgOwners(w, r, principal, orgID, segments[2:])
 	case "project-members":
 		s.handleOrgProjectMembers(w, r, principal, orgID, segments[2:])
+	case "default-environment":
+		s.handleOrgDefaultEnvironment(w, r, principal, orgID, segments[2:])
 	default:
 		writeError(w, http.StatusNotFound, "resource not found")
 	}
@@ -0,0 +1,133 @@
+package controlplane
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rocketship-ai/rocketship/internal/controlplane/persistence"
+)
+
+// Page sizes for paginated list endpoints
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// listSpec describes what a list endpoint can be sorted and filtered by. The
+// store turns the parsed query into SQL, so the fields here must be ones its
+// list accepts.
+type listSpec struct {
+	sortFields  []string          // Fields accepted by ?sort=
+	defaultSort string            // Sort used without ?sort=
+	filters     map[string]string // Query parameter -> store filter key
+}
+
+// listPage is the response of a list request
+type listPage[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	TotalCount int    `json:"total_count"`
+}
+
+// listCursor marks the last item of the previous page. It records the sort it was
+// issued for so it can't be replayed against a different order.
+type listCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+// parseListQuery reads limit, cursor, sort, q and the spec's filters from the
+// request. Requests without a limit get defaultPageSize items.
+func (spec listSpec) parseListQuery(r *http.Request) (persistence.ListQuery, error) {
+	params := r.URL.Query()
+	q := persistence.ListQuery{
+		Limit:   defaultPageSize,
+		Sort:    spec.defaultSort,
+		Search:  strings.TrimSpace(params.Get("q")),
+		Filters: map[string]string{},
+	}
+
+	if raw := params.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return q, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+		}
+		q.Limit = limit
+	}
+
+	if raw := params.Get("sort"); raw != "" {
+		field := strings.TrimPrefix(raw, "-")
+		if !slices.Contains(spec.sortFields, field) {
+			return q, fmt.Errorf("sort must be one of: %s (prefix with - for descending)", strings.Join(spec.sortFields, ", "))
+		}
+		q.Sort = field
+		q.Desc = strings.HasPrefix(raw, "-")
+	}
+
+	if raw := params.Get("cursor"); raw != "" {
+		cursor, err := decodeListCursor(raw)
+		if err != nil {
+			return q, fmt.Errorf("invalid cursor")
+		}
+		if cursor.Sort != listSortKey(q) {
+			return q, fmt.Errorf("cursor was issued for a different sort")
+		}
+		id, err := uuid.Parse(cursor.ID)
+		if err != nil {
+			return q, fmt.Errorf("invalid cursor")
+		}
+		q.After = &persistence.ListCursor{Value: cursor.Value, ID: id}
+	}
+
+	for param, key := range spec.filters {
+		if value := strings.TrimSpace(params.Get(param)); value != "" {
+			q.Filters[key] = value
+		}
+	}
+	return q, nil
+}
+
+// listSortKey identifies the order, e.g. "-name"
+func listSortKey(q persistence.ListQuery) string {
+	if q.Desc {
+		return "-" + q.Sort
+	}
+	return q.Sort
+}
+
+// writeListPage responds with a page from the store, converting its rows to the
+// endpoint's items
+func writeListPage[R any, T any](w http.ResponseWriter, q persistence.ListQuery, page persistence.ListPage[R], item func(R) T) {
+	resp := listPage[T]{Items: make([]T, 0, len(page.Items)), TotalCount: page.Total}
+	for _, row := range page.Items {
+		resp.Items = append(resp.Items, item(row))
+	}
+	if page.Next != nil {
+		resp.NextCursor = encodeListCursor(listCursor{Sort: listSortKey(q), Value: page.Next.Value, ID: page.Next.ID.String()})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func encodeListCursor(c listCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeListCursor(raw string) (*listCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, err
+	}
+	var c listCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
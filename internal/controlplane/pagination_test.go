@@ -0,0 +1,104 @@
+package controlplane
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rocketship-ai/rocketship/internal/controlplane/persistence"
+)
+
+func parseSuiteQuery(t *testing.T, query string) (persistence.ListQuery, error) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/projects/p/suites?"+query, nil)
+	return suiteListSpec.parseListQuery(req)
+}
+
+func TestParseListQueryDefaults(t *testing.T) {
+	q, err := parseSuiteQuery(t, "")
+	if err != nil {
+		t.Fatalf("parseListQuery: %v", err)
+	}
+	if q.Limit != defaultPageSize || q.Sort != "name" || q.Desc || q.After != nil {
+		t.Errorf("expected the first default-sized page by name, got %+v", q)
+	}
+
+	q, err = parseSuiteQuery(t, "limit=3&sort=-test_count&q=+auth+&status=PASSED&owner=me")
+	if err != nil {
+		t.Fatalf("parseListQuery: %v", err)
+	}
+	if q.Limit != 3 || q.Sort != "test_count" || !q.Desc || q.Search != "auth" {
+		t.Errorf("unexpected query %+v", q)
+	}
+	if len(q.Filters) != 1 || q.Filters["status"] != "PASSED" {
+		t.Errorf("expected only the status filter, got %v", q.Filters)
+	}
+}
+
+func TestWriteListPageCursorRoundTrip(t *testing.T) {
+	q, err := parseSuiteQuery(t, "limit=2&sort=-name")
+	if err != nil {
+		t.Fatalf("parseListQuery: %v", err)
+	}
+	lastID := uuid.New()
+	page := persistence.ListPage[persistence.CanonicalSuiteRow]{
+		Items: []persistence.CanonicalSuiteRow{
+			{ID: uuid.New(), Name: "search", SourceRef: "main", TestCount: 4},
+			{ID: lastID, Name: "orders", SourceRef: "main", FilePath: sql.NullString{String: "orders.yaml", Valid: true}},
+		},
+		Next:  &persistence.ListCursor{Value: "orders", ID: lastID},
+		Total: 7,
+	}
+
+	rec := httptest.NewRecorder()
+	writeListPage(rec, q, page, newSuiteItem)
+	var resp listPage[suiteItem]
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("expected a page, got %s", rec.Body.String())
+	}
+	if resp.TotalCount != 7 || len(resp.Items) != 2 || resp.NextCursor == "" {
+		t.Fatalf("unexpected page %s", rec.Body.String())
+	}
+	if resp.Items[0].FilePath != nil || resp.Items[1].FilePath == nil || *resp.Items[1].FilePath != "orders.yaml" {
+		t.Errorf("expected file_path only where the suite has one, got %s", rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "description") {
+		t.Errorf("expected missing fields to be omitted, got %s", rec.Body.String())
+	}
+
+	// The cursor continues after the last item, in the same order
+	next, err := parseSuiteQuery(t, "limit=2&sort=-name&cursor="+resp.NextCursor)
+	if err != nil {
+		t.Fatalf("parseListQuery: %v", err)
+	}
+	if next.After == nil || next.After.Value != "orders" || next.After.ID != lastID {
+		t.Errorf("expected the cursor to point after orders, got %+v", next.After)
+	}
+
+	if _, err := parseSuiteQuery(t, "limit=2&sort=name&cursor="+resp.NextCursor); err == nil || err.Error() != "cursor was issued for a different sort" {
+		t.Errorf("expected the cursor to be rejected for another sort, got %v", err)
+	}
+}
+
+func TestParseListQueryRejectsBadParameters(t *testing.T) {
+	tests := []struct {
+		query   string
+		wantErr string
+	}{
+		{"limit=0", "limit must be between 1 and 500"},
+		{"limit=501", "limit must be between 1 and 500"},
+		{"limit=abc", "limit must be between 1 and 500"},
+		{"sort=owner", "sort must be one of: name, file_path, test_count, last_run_at"},
+		{"cursor=not-a-cursor", "invalid cursor"},
+		{"cursor=" + encodeListCursor(listCursor{Sort: "name", Value: "a", ID: "not-a-uuid"}), "invalid cursor"},
+	}
+	for _, tt := range tests {
+		if _, err := parseSuiteQuery(t, tt.query); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: expected %q, got %v", tt.query, tt.wantErr, err)
+		}
+	}
+}
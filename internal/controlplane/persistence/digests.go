@@ -0,0 +1,252 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Digest frequencies
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// DigestPeriod is how much history a digest of the given frequency covers, and
+// how long until the next one
+func DigestPeriod(frequency string) time.Duration {
+	if frequency == DigestWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// ProjectDigest is a project's opt-in email digest of scheduled suite health
+type ProjectDigest struct {
+	ProjectID   uuid.UUID      `db:"project_id"`
+	ProjectName string         `db:"project_name"`
+	Frequency   string         `db:"frequency"`
+	Recipients  pq.StringArray `db:"recipients"`
+	NextSendAt  time.Time      `db:"next_send_at"`
+	LastSentAt  sql.NullTime   `db:"last_sent_at"`
+	CreatedBy   uuid.NullUUID  `db:"created_by"`
+	CreatedAt   time.Time      `db:"created_at"`
+	UpdatedAt   time.Time      `db:"updated_at"`
+}
+
+// DigestPassRate is how many scheduled runs passed in one environment
+type DigestPassRate struct {
+	Environment string `db:"environment"`
+	Runs        int    `db:"runs"`
+	Passed      int    `db:"passed"`
+}
+
+// DigestFlakyTest is a test that both passed and failed in scheduled runs during
+// the period, but not in the period before
+type DigestFlakyTest struct {
+	SuiteName string `db:"suite_name"`
+	TestName  string `db:"test_name"`
+	Runs      int    `db:"runs"`
+	Failures  int    `db:"failures"`
+}
+
+// DigestSlowSuite is a suite's average scheduled run duration over the period
+type DigestSlowSuite struct {
+	SuiteName     string `db:"suite_name"`
+	Runs          int    `db:"runs"`
+	AvgDurationMs int64  `db:"avg_duration_ms"`
+}
+
+// ProjectDigestStats summarizes a project's scheduled runs over a period
+type ProjectDigestStats struct {
+	PassRates     []DigestPassRate
+	NewFlakyTests []DigestFlakyTest
+	SlowestSuites []DigestSlowSuite
+}
+
+// digestListLimit caps the flaky test and slowest suite lists
+const digestListLimit = 5
+
+// UpsertProjectDigest enables a project's digest or changes its frequency and
+// recipients. The next digest goes out one period from now.
+func (s *Store) UpsertProjectDigest(ctx context.Context, digest ProjectDigest) (ProjectDigest, error) {
+	if digest.ProjectID == uuid.Nil {
+		return ProjectDigest{}, errors.New("project id required")
+	}
+	if digest.Frequency != DigestDaily && digest.Frequency != DigestWeekly {
+		return ProjectDigest{}, fmt.Errorf("frequency must be %s or %s", DigestDaily, DigestWeekly)
+	}
+	if len(digest.Recipients) == 0 {
+		return ProjectDigest{}, errors.New("at least one recipient required")
+	}
+
+	const query = `
+		INSERT INTO project_digests (project_id, frequency, recipients, next_send_at, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+		ON CONFLICT (project_id) DO UPDATE SET
+			frequency = EXCLUDED.frequency,
+			recipients = EXCLUDED.recipients,
+			next_send_at = EXCLUDED.next_send_at,
+			updated_at = NOW()
+		RETURNING next_send_at, last_sent_at, created_by, created_at, updated_at
+	`
+
+	dest := struct {
+		NextSendAt time.Time     `db:"next_send_at"`
+		LastSentAt sql.NullTime  `db:"last_sent_at"`
+		CreatedBy  uuid.NullUUID `db:"created_by"`
+		CreatedAt  time.Time     `db:"created_at"`
+		UpdatedAt  time.Time     `db:"updated_at"`
+	}{}
+
+	nextSendAt := time.Now().UTC().Add(DigestPeriod(digest.Frequency))
+	if err := s.db.GetContext(ctx, &dest, query,
+		digest.ProjectID, digest.Frequency, digest.Recipients, nextSendAt, digest.CreatedBy); err != nil {
+		return ProjectDigest{}, fmt.Errorf("failed to save project digest: %w", err)
+	}
+
+	digest.NextSendAt = dest.NextSendAt
+	digest.LastSentAt = dest.LastSentAt
+	digest.CreatedBy = dest.CreatedBy
+	digest.CreatedAt = dest.CreatedAt
+	digest.UpdatedAt = dest.UpdatedAt
+	return digest, nil
+}
+
+// GetProjectDigest returns a project's digest, or sql.ErrNoRows when it has none
+func (s *Store) GetProjectDigest(ctx context.Context, projectID uuid.UUID) (ProjectDigest, error) {
+	const query = `
+		SELECT d.project_id, p.name AS project_name, d.frequency, d.recipients, d.next_send_at,
+		       d.last_sent_at, d.created_by, d.created_at, d.updated_at
+		FROM project_digests d
+		JOIN projects p ON p.id = d.project_id
+		WHERE d.project_id = $1
+	`
+
+	var digest ProjectDigest
+	if err := s.db.GetContext(ctx, &digest, query, projectID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ProjectDigest{}, sql.ErrNoRows
+		}
+		return ProjectDigest{}, fmt.Errorf("failed to get project digest: %w", err)
+	}
+	return digest, nil
+}
+
+// DeleteProjectDigest turns a project's digest off
+func (s *Store) DeleteProjectDigest(ctx context.Context, projectID uuid.UUID) error {
+	const query = `DELETE FROM project_digests WHERE project_id = $1`
+
+	res, err := s.db.ExecContext(ctx, query, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to delete project digest: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ClaimDueProjectDigests claims up to limit digests due at now and moves each to
+// its next send time in the same statement. SKIP LOCKED keeps concurrent control
+// plane replicas from claiming the same digest, so each is sent at most once.
+func (s *Store) ClaimDueProjectDigests(ctx context.Context, now time.Time, limit int) ([]ProjectDigest, error) {
+	const query = `
+		WITH claimed AS (
+			UPDATE project_digests d SET
+				last_sent_at = $1,
+				next_send_at = $1 + CASE WHEN d.frequency = 'weekly' THEN INTERVAL '7 days' ELSE INTERVAL '1 day' END,
+				updated_at = NOW()
+			WHERE d.project_id IN (
+				SELECT project_id FROM project_digests
+				WHERE next_send_at <= $1
+				ORDER BY next_send_at ASC
+				LIMIT $2
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING d.*
+		)
+		SELECT c.project_id, p.name AS project_name, c.frequency, c.recipients, c.next_send_at,
+		       c.last_sent_at, c.created_by, c.created_at, c.updated_at
+		FROM claimed c
+		JOIN projects p ON p.id = c.project_id
+	`
+
+	var digests []ProjectDigest
+	if err := s.db.SelectContext(ctx, &digests, query, now, limit); err != nil {
+		return nil, fmt.Errorf("failed to claim due project digests: %w", err)
+	}
+	return digests, nil
+}
+
+// GetProjectDigestStats summarizes the project's scheduled runs that ended in
+// [since, until). A test counts as newly flaky when it both passed and failed in
+// the period but not in the period of the same length before it.
+func (s *Store) GetProjectDigestStats(ctx context.Context, projectID uuid.UUID, since, until time.Time) (ProjectDigestStats, error) {
+	var stats ProjectDigestStats
+
+	const passRateQuery = `
+		SELECT COALESCE(NULLIF(r.environment, ''), 'default') AS environment,
+		       COUNT(*)::int AS runs,
+		       COUNT(*) FILTER (WHERE r.status = 'PASSED')::int AS passed
+		FROM runs r
+		WHERE r.project_id = $1
+			AND r.archived_at IS NULL
+			AND r.trigger = 'schedule'
+			AND r.status IN ('PASSED', 'FAILED', 'CANCELLED', 'TIMEOUT', 'BUDGET_EXCEEDED')
+			AND r.ended_at >= $2 AND r.ended_at < $3
+		GROUP BY 1
+		ORDER BY 1
+	`
+	if err := s.db.SelectContext(ctx, &stats.PassRates, passRateQuery, projectID, since, until); err != nil {
+		return ProjectDigestStats{}, fmt.Errorf("failed to query digest pass rates: %w", err)
+	}
+
+	const flakyQuery = `
+		SELECT r.suite_name, rt.name AS test_name,
+		       COUNT(*) FILTER (WHERE r.ended_at >= $2)::int AS runs,
+		       COUNT(*) FILTER (WHERE r.ended_at >= $2 AND rt.status IN ('FAILED', 'TIMEOUT'))::int AS failures
+		FROM run_tests rt
+		JOIN runs r ON r.id = rt.run_id
+		WHERE r.project_id = $1
+			AND r.trigger = 'schedule'
+			AND r.ended_at >= $4 AND r.ended_at < $3
+		GROUP BY r.suite_name, rt.name
+		HAVING bool_or(r.ended_at >= $2 AND rt.status = 'PASSED')
+			AND bool_or(r.ended_at >= $2 AND rt.status IN ('FAILED', 'TIMEOUT'))
+			AND NOT (
+				COALESCE(bool_or(r.ended_at < $2 AND rt.status = 'PASSED'), FALSE)
+				AND COALESCE(bool_or(r.ended_at < $2 AND rt.status IN ('FAILED', 'TIMEOUT')), FALSE)
+			)
+		ORDER BY failures DESC, r.suite_name, rt.name
+		LIMIT $5
+	`
+	previous := since.Add(-until.Sub(since))
+	if err := s.db.SelectContext(ctx, &stats.NewFlakyTests, flakyQuery, projectID, since, until, previous, digestListLimit); err != nil {
+		return ProjectDigestStats{}, fmt.Errorf("failed to query digest flaky tests: %w", err)
+	}
+
+	const slowestQuery = `
+		SELECT r.suite_name, COUNT(*)::int AS runs,
+		       AVG(EXTRACT(EPOCH FROM (r.ended_at - r.started_at)) * 1000)::bigint AS avg_duration_ms
+		FROM runs r
+		WHERE r.project_id = $1
+			AND r.archived_at IS NULL
+			AND r.trigger = 'schedule'
+			AND r.started_at IS NOT NULL
+			AND r.ended_at >= $2 AND r.ended_at < $3
+		GROUP BY r.suite_name
+		ORDER BY avg_duration_ms DESC
+		LIMIT $4
+	`
+	if err := s.db.SelectContext(ctx, &stats.SlowestSuites, slowestQuery, projectID, since, until, digestListLimit); err != nil {
+		return ProjectDigestStats{}, fmt.Errorf("failed to query digest slowest suites: %w", err)
+	}
+
+	return stats, nil
+}
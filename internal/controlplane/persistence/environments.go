@@ -28,8 +28,9 @@ func (s *Store) CreateEnvironment(ctx context.Context, env ProjectEnvironment) (
 		env.ID = uuid.New()
 	}
 
-	// Normalize slug to lowercase
+	// Normalize slugs to lowercase
 	env.Slug = strings.ToLower(strings.TrimSpace(env.Slug))
+	env.Fallback = strings.ToLower(strings.TrimSpace(env.Fallback))
 
 	// Encode env_secrets
 	if env.EnvSecrets == nil {
@@ -50,8 +51,8 @@ func (s *Store) CreateEnvironment(ctx context.Context, env ProjectEnvironment) (
 	}
 
 	const query = `
-		INSERT INTO project_environments (id, project_id, name, slug, env_secrets, config_vars, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5::jsonb, $6::jsonb, NOW(), NOW())
+		INSERT INTO project_environments (id, project_id, name, slug, env_secrets, config_vars, fallback_slug, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5::jsonb, $6::jsonb, NULLIF($7, ''), NOW(), NOW())
 		RETURNING created_at, updated_at
 	`
 
@@ -62,7 +63,7 @@ func (s *Store) CreateEnvironment(ctx context.Context, env ProjectEnvironment) (
 
 	if err := s.db.GetContext(ctx, &dest, query,
 		env.ID, env.ProjectID, env.Name, env.Slug,
-		string(secretsJSON), string(varsJSON)); err != nil {
+		string(secretsJSON), string(varsJSON), env.Fallback); err != nil {
 		if isUniqueViolation(err, "project_environments_project_slug_idx") {
 			return ProjectEnvironment{}, fmt.Errorf("environment slug already exists in project")
 		}
@@ -76,14 +77,15 @@ func (s *Store) CreateEnvironment(ctx context.Context, env ProjectEnvironment) (
 
 // envRow is a helper type for scanning environment rows with JSONB columns
 type envRow struct {
-	ID         uuid.UUID `db:"id"`
-	ProjectID  uuid.UUID `db:"project_id"`
-	Name       string    `db:"name"`
-	Slug       string    `db:"slug"`
-	EnvSecrets []byte    `db:"env_secrets"`
-	ConfigVars []byte    `db:"config_vars"`
-	CreatedAt  time.Time `db:"created_at"`
-	UpdatedAt  time.Time `db:"updated_at"`
+	ID         uuid.UUID      `db:"id"`
+	ProjectID  uuid.UUID      `db:"project_id"`
+	Name       string         `db:"name"`
+	Slug       string         `db:"slug"`
+	Fallback   sql.NullString `db:"fallback_slug"`
+	EnvSecrets []byte         `db:"env_secrets"`
+	ConfigVars []byte         `db:"config_vars"`
+	CreatedAt  time.Time      `db:"created_at"`
+	UpdatedAt  time.Time      `db:"updated_at"`
 }
 
 func (r envRow) toEnvironment() (ProjectEnvironment, error) {
@@ -92,6 +94,7 @@ func (r envRow) toEnvironment() (ProjectEnvironment, error) {
 		ProjectID: r.ProjectID,
 		Name:      r.Name,
 		Slug:      r.Slug,
+		Fallback:  r.Fallback.String,
 		CreatedAt: r.CreatedAt,
 		UpdatedAt: r.UpdatedAt,
 	}
@@ -126,7 +129,7 @@ func (r envRow) toEnvironment() (ProjectEnvironment, error) {
 // GetEnvironment retrieves an environment by ID
 func (s *Store) GetEnvironment(ctx context.Context, projectID, envID uuid.UUID) (ProjectEnvironment, error) {
 	const query = `
-		SELECT id, project_id, name, slug, env_secrets, config_vars, created_at, updated_at
+		SELECT id, project_id, name, slug, fallback_slug, env_secrets, config_vars, created_at, updated_at
 		FROM project_environments
 		WHERE project_id = $1 AND id = $2
 	`
@@ -145,7 +148,7 @@ func (s *Store) GetEnvironment(ctx context.Context, projectID, envID uuid.UUID)
 // ListEnvironments returns all environments for a project
 func (s *Store) ListEnvironments(ctx context.Context, projectID uuid.UUID) ([]ProjectEnvironment, error) {
 	const query = `
-		SELECT id, project_id, name, slug, env_secrets, config_vars, created_at, updated_at
+		SELECT id, project_id, name, slug, fallback_slug, env_secrets, config_vars, created_at, updated_at
 		FROM project_environments
 		WHERE project_id = $1
 		ORDER BY name ASC
@@ -168,6 +171,50 @@ func (s *Store) ListEnvironments(ctx context.Context, projectID uuid.UUID) ([]Pr
 	return envs, nil
 }
 
+// environmentListColumns is how environments can be sorted and filtered
+var environmentListColumns = listColumns{
+	sort: map[string]listSortColumn{
+		"name":       {expr: "lower(name)", cast: "text"},
+		"slug":       {expr: "slug", cast: "text"},
+		"created_at": {expr: "created_at", cast: "timestamptz"},
+		"updated_at": {expr: "updated_at", cast: "timestamptz"},
+	},
+	defaultSort: "name",
+	search:      "name",
+	filters:     map[string]string{"fallback": "fallback_slug"},
+}
+
+// ListEnvironmentsPage returns a page of a project's environments
+func (s *Store) ListEnvironmentsPage(ctx context.Context, projectID uuid.UUID, q ListQuery) (ListPage[ProjectEnvironment], error) {
+	const query = `
+		SELECT id, project_id, name, slug, fallback_slug, env_secrets, config_vars, created_at, updated_at
+		FROM project_environments
+		WHERE project_id = $1
+	`
+
+	stmt, err := environmentListColumns.build(query, []interface{}{projectID}, q)
+	if err != nil {
+		return ListPage[ProjectEnvironment]{}, err
+	}
+
+	type pageRow struct {
+		envRow
+		SortKey string `db:"list_sort_key"`
+	}
+	var rows []pageRow
+	if err := s.db.SelectContext(ctx, &rows, stmt.page, stmt.pageArgs...); err != nil {
+		return ListPage[ProjectEnvironment]{}, fmt.Errorf("failed to list environments: %w", err)
+	}
+	var total int
+	if err := s.db.GetContext(ctx, &total, stmt.count, stmt.countArgs...); err != nil {
+		return ListPage[ProjectEnvironment]{}, fmt.Errorf("failed to count environments: %w", err)
+	}
+
+	return newListPage(q, rows, total,
+		func(r pageRow) ListCursor { return ListCursor{Value: r.SortKey, ID: r.ID} },
+		func(r pageRow) (ProjectEnvironment, error) { return r.toEnvironment() })
+}
+
 // UpdateEnvironment updates an existing environment
 func (s *Store) UpdateEnvironment(ctx context.Context, env ProjectEnvironment) (ProjectEnvironment, error) {
 	if env.ID == uuid.Nil {
@@ -177,8 +224,9 @@ func (s *Store) UpdateEnvironment(ctx context.Context, env ProjectEnvironment) (
 		return ProjectEnvironment{}, errors.New("project id required")
 	}
 
-	// Normalize slug to lowercase
+	// Normalize slugs to lowercase
 	env.Slug = strings.ToLower(strings.TrimSpace(env.Slug))
+	env.Fallback = strings.ToLower(strings.TrimSpace(env.Fallback))
 
 	// Encode env_secrets
 	if env.EnvSecrets == nil {
@@ -200,7 +248,7 @@ func (s *Store) UpdateEnvironment(ctx context.Context, env ProjectEnvironment) (
 
 	const query = `
 		UPDATE project_environments
-		SET name = $3, slug = $4, env_secrets = $5::jsonb, config_vars = $6::jsonb, updated_at = NOW()
+		SET name = $3, slug = $4, env_secrets = $5::jsonb, config_vars = $6::jsonb, fallback_slug = NULLIF($7, ''), updated_at = NOW()
 		WHERE id = $1 AND project_id = $2
 		RETURNING updated_at
 	`
@@ -208,7 +256,7 @@ func (s *Store) UpdateEnvironment(ctx context.Context, env ProjectEnvironment) (
 	var updatedAt time.Time
 	if err := s.db.GetContext(ctx, &updatedAt, query,
 		env.ID, env.ProjectID, env.Name, env.Slug,
-		string(secretsJSON), string(varsJSON)); err != nil {
+		string(secretsJSON), string(varsJSON), env.Fallback); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return ProjectEnvironment{}, sql.ErrNoRows
 		}
@@ -245,7 +293,7 @@ func (s *Store) DeleteEnvironment(ctx context.Context, projectID, envID uuid.UUI
 // GetEnvironmentBySlug retrieves an environment by its slug within a project
 func (s *Store) GetEnvironmentBySlug(ctx context.Context, projectID uuid.UUID, slug string) (ProjectEnvironment, error) {
 	const query = `
-		SELECT id, project_id, name, slug, env_secrets, config_vars, created_at, updated_at
+		SELECT id, project_id, name, slug, fallback_slug, env_secrets, config_vars, created_at, updated_at
 		FROM project_environments
 		WHERE project_id = $1 AND lower(slug) = lower($2)
 	`
@@ -183,13 +183,25 @@ func (s *Store) getLatestScansForProjects(ctx context.Context, orgID uuid.UUID,
 	return result, nil
 }
 
-// ListProjectSummariesForUser returns active projects the user can access with counts and last scan info.
+// projectListColumns is how project summaries can be sorted and filtered
+var projectListColumns = listColumns{
+	sort: map[string]listSortColumn{
+		"name":        {expr: "lower(name)", cast: "text"},
+		"suite_count": {expr: "suite_count", cast: "bigint"},
+		"test_count":  {expr: "test_count", cast: "bigint"},
+	},
+	defaultSort: "name",
+	search:      "name",
+	filters:     map[string]string{"repo_url": "repo_url", "source_ref": "source_ref"},
+}
+
+// ListProjectSummariesForUser returns a page of the active projects the user can access with counts and last scan info.
 // Org owners see all projects; non-owners see only projects they're members of.
-func (s *Store) ListProjectSummariesForUser(ctx context.Context, orgID, userID uuid.UUID) ([]ProjectSummary, error) {
+func (s *Store) ListProjectSummariesForUser(ctx context.Context, orgID, userID uuid.UUID, q ListQuery) (ListPage[ProjectSummary], error) {
 	// Check if user is org owner
 	isOwner, err := s.IsOrganizationOwner(ctx, orgID, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check org ownership: %w", err)
+		return ListPage[ProjectSummary]{}, fmt.Errorf("failed to check org ownership: %w", err)
 	}
 
 	var projectQuery string
@@ -210,7 +222,6 @@ func (s *Store) ListProjectSummariesForUser(ctx context.Context, orgID, userID u
 				COALESCE((SELECT COUNT(*) FROM tests WHERE project_id = p.id AND is_active = true), 0) AS test_count
 			FROM projects p
 			WHERE p.organization_id = $1 AND p.is_active = true
-			ORDER BY p.name ASC, p.source_ref ASC
 		`
 		args = []interface{}{orgID}
 	} else {
@@ -229,12 +240,16 @@ func (s *Store) ListProjectSummariesForUser(ctx context.Context, orgID, userID u
 			FROM projects p
 			JOIN project_members pm ON pm.project_id = p.id
 			WHERE p.organization_id = $1 AND pm.user_id = $2 AND p.is_active = true
-			ORDER BY p.name ASC, p.source_ref ASC
 		`
 		args = []interface{}{orgID, userID}
 	}
 
-	rows := []struct {
+	stmt, err := projectListColumns.build(projectQuery, args, q)
+	if err != nil {
+		return ListPage[ProjectSummary]{}, err
+	}
+
+	type projectRow struct {
 		ID            uuid.UUID `db:"id"`
 		Name          string    `db:"name"`
 		RepoURL       string    `db:"repo_url"`
@@ -244,47 +259,56 @@ func (s *Store) ListProjectSummariesForUser(ctx context.Context, orgID, userID u
 		CreatedAt     time.Time `db:"created_at"`
 		SuiteCount    int       `db:"suite_count"`
 		TestCount     int       `db:"test_count"`
-	}{}
-
-	if err := s.db.SelectContext(ctx, &rows, projectQuery, args...); err != nil {
-		return nil, fmt.Errorf("failed to list project summaries for user: %w", err)
+		SortKey       string    `db:"list_sort_key"`
+	}
+	var rows []projectRow
+	if err := s.db.SelectContext(ctx, &rows, stmt.page, stmt.pageArgs...); err != nil {
+		return ListPage[ProjectSummary]{}, fmt.Errorf("failed to list project summaries for user: %w", err)
+	}
+	var total int
+	if err := s.db.GetContext(ctx, &total, stmt.count, stmt.countArgs...); err != nil {
+		return ListPage[ProjectSummary]{}, fmt.Errorf("failed to count project summaries for user: %w", err)
 	}
 
-	projects := make([]ProjectSummary, 0, len(rows))
-	for _, r := range rows {
-		p := ProjectSummary{
-			ID:            r.ID,
-			Name:          r.Name,
-			RepoURL:       r.RepoURL,
-			DefaultBranch: r.DefaultBranch,
-			SourceRef:     r.SourceRef,
-			SuiteCount:    r.SuiteCount,
-			TestCount:     r.TestCount,
-			CreatedAt:     r.CreatedAt,
-		}
-		if r.PathScope != "" {
-			if err := json.Unmarshal([]byte(r.PathScope), &p.PathScope); err != nil {
-				return nil, fmt.Errorf("failed to parse path_scope: %w", err)
+	page, err := newListPage(q, rows, total,
+		func(r projectRow) ListCursor { return ListCursor{Value: r.SortKey, ID: r.ID} },
+		func(r projectRow) (ProjectSummary, error) {
+			p := ProjectSummary{
+				ID:            r.ID,
+				Name:          r.Name,
+				RepoURL:       r.RepoURL,
+				DefaultBranch: r.DefaultBranch,
+				SourceRef:     r.SourceRef,
+				SuiteCount:    r.SuiteCount,
+				TestCount:     r.TestCount,
+				CreatedAt:     r.CreatedAt,
 			}
-		}
-		projects = append(projects, p)
+			if r.PathScope != "" {
+				if err := json.Unmarshal([]byte(r.PathScope), &p.PathScope); err != nil {
+					return ProjectSummary{}, fmt.Errorf("failed to parse path_scope: %w", err)
+				}
+			}
+			return p, nil
+		})
+	if err != nil {
+		return ListPage[ProjectSummary]{}, err
 	}
 
-	// Fetch latest scan for each project (batched)
-	if len(projects) > 0 {
-		scans, err := s.getLatestScansForProjects(ctx, orgID, projects)
+	// Fetch latest scan for each project on the page (batched)
+	if len(page.Items) > 0 {
+		scans, err := s.getLatestScansForProjects(ctx, orgID, page.Items)
 		if err != nil {
-			return nil, err
+			return ListPage[ProjectSummary]{}, err
 		}
-		for i := range projects {
-			key := RepoURLToFullName(projects[i].RepoURL) + "|" + projects[i].SourceRef
+		for i := range page.Items {
+			key := RepoURLToFullName(page.Items[i].RepoURL) + "|" + page.Items[i].SourceRef
 			if scan, ok := scans[key]; ok {
-				projects[i].LastScan = &scan
+				page.Items[i].LastScan = &scan
 			}
 		}
 	}
 
-	return projects, nil
+	return page, nil
 }
 
 // SuiteActivityRow represents a suite with project info for activity list
@@ -375,9 +399,10 @@ func (s *Store) ListSuitesForOrg(ctx context.Context, orgID uuid.UUID, limit int
 					r.status
 				FROM runs r
 				WHERE r.project_id = rs.project_id
+					AND r.archived_at IS NULL
 					AND r.organization_id = $1
 					AND r.suite_name = rs.suite_name
-					AND r.status IN ('PASSED', 'FAILED', 'CANCELLED', 'TIMEOUT')
+					AND r.status IN ('PASSED', 'FAILED', 'CANCELLED', 'TIMEOUT', 'BUDGET_EXCEEDED')
 					AND r.started_at IS NOT NULL
 					AND r.ended_at IS NOT NULL
 				ORDER BY r.created_at DESC
@@ -389,9 +414,10 @@ func (s *Store) ListSuitesForOrg(ctx context.Context, orgID uuid.UUID, limit int
 			SELECT COUNT(*)::bigint AS runs_per_week
 			FROM runs r
 			WHERE r.project_id = rs.project_id
+				AND r.archived_at IS NULL
 				AND r.organization_id = $1
 				AND r.suite_name = rs.suite_name
-				AND r.status IN ('PASSED', 'FAILED', 'CANCELLED', 'TIMEOUT')
+				AND r.status IN ('PASSED', 'FAILED', 'CANCELLED', 'TIMEOUT', 'BUDGET_EXCEEDED')
 				AND r.created_at >= NOW() - INTERVAL '7 days'
 		) weekly ON true
 		WHERE rs.rn = 1
@@ -489,9 +515,10 @@ func (s *Store) ListSuitesForUserProjects(ctx context.Context, orgID, userID uui
 					r.status
 				FROM runs r
 				WHERE r.project_id = rs.project_id
+					AND r.archived_at IS NULL
 					AND r.organization_id = rs.organization_id
 					AND r.suite_name = rs.suite_name
-					AND r.status IN ('PASSED', 'FAILED', 'CANCELLED', 'TIMEOUT')
+					AND r.status IN ('PASSED', 'FAILED', 'CANCELLED', 'TIMEOUT', 'BUDGET_EXCEEDED')
 					AND r.started_at IS NOT NULL
 					AND r.ended_at IS NOT NULL
 				ORDER BY r.created_at DESC
@@ -503,9 +530,10 @@ func (s *Store) ListSuitesForUserProjects(ctx context.Context, orgID, userID uui
 			SELECT COUNT(*)::bigint AS runs_per_week
 			FROM runs r
 			WHERE r.project_id = rs.project_id
+				AND r.archived_at IS NULL
 				AND r.organization_id = rs.organization_id
 				AND r.suite_name = rs.suite_name
-				AND r.status IN ('PASSED', 'FAILED', 'CANCELLED', 'TIMEOUT')
+				AND r.status IN ('PASSED', 'FAILED', 'CANCELLED', 'TIMEOUT', 'BUDGET_EXCEEDED')
 				AND r.created_at >= NOW() - INTERVAL '7 days'
 		) weekly ON true
 		WHERE rs.rn = 1
@@ -633,52 +661,63 @@ type CanonicalSuiteRow struct {
 	LastRunAt     sql.NullTime   `db:"last_run_at"`
 }
 
+// canonicalSuitesQuery selects a project's active suites, deduplicated by file_path.
+// A CTE with ROW_NUMBER prefers suites where source_ref matches the project's
+// default_branch.
+const canonicalSuitesQuery = `
+	WITH ranked_suites AS (
+		SELECT
+			s.id,
+			s.name,
+			s.description,
+			s.file_path,
+			s.source_ref,
+			s.test_count,
+			s.last_run_status,
+			s.last_run_at,
+			ROW_NUMBER() OVER (
+				PARTITION BY s.file_path
+				ORDER BY
+					-- Prefer default branch first
+					CASE WHEN s.source_ref = p.default_branch THEN 0 ELSE 1 END,
+					-- Then by most recently updated
+					s.updated_at DESC
+			) AS rn
+		FROM suites s
+		JOIN projects p ON p.id = s.project_id
+		WHERE s.project_id = $1 AND p.is_active = true AND s.is_active = true
+	)
+	SELECT
+		id,
+		name,
+		description,
+		file_path,
+		source_ref,
+		test_count,
+		last_run_status,
+		last_run_at
+	FROM ranked_suites
+	WHERE rn = 1
+`
+
+// suiteListColumns is how canonical suites can be sorted and filtered
+var suiteListColumns = listColumns{
+	sort: map[string]listSortColumn{
+		"name":        {expr: "lower(name)", cast: "text"},
+		"file_path":   {expr: "COALESCE(lower(file_path), '')", cast: "text"},
+		"test_count":  {expr: "test_count", cast: "integer"},
+		"last_run_at": {expr: "COALESCE(last_run_at, '-infinity'::timestamptz)", cast: "timestamptz"},
+	},
+	defaultSort: "name",
+	search:      "name",
+	filters:     map[string]string{"source_ref": "source_ref", "status": "last_run_status"},
+}
+
 // ListSuitesForProjectCanonical returns active suites for a project with deduplication.
 // Deduplicates suites by (project_id, file_path), preferring the default branch version.
-// Suites that only exist on a PR branch (no default branch version) are also shown.
-// Only returns active suites from active projects.
 func (s *Store) ListSuitesForProjectCanonical(ctx context.Context, projectID uuid.UUID) ([]CanonicalSuiteRow, error) {
-	// Use a CTE with ROW_NUMBER to deduplicate suites by (project_id, file_path).
-	// Prefer suites where source_ref matches the project's default_branch.
-	const query = `
-		WITH ranked_suites AS (
-			SELECT
-				s.id,
-				s.name,
-				s.description,
-				s.file_path,
-				s.source_ref,
-				s.test_count,
-				s.last_run_status,
-				s.last_run_at,
-				ROW_NUMBER() OVER (
-					PARTITION BY s.file_path
-					ORDER BY
-						-- Prefer default branch first
-						CASE WHEN s.source_ref = p.default_branch THEN 0 ELSE 1 END,
-						-- Then by most recently updated
-						s.updated_at DESC
-				) AS rn
-			FROM suites s
-			JOIN projects p ON p.id = s.project_id
-			WHERE s.project_id = $1 AND p.is_active = true AND s.is_active = true
-		)
-		SELECT
-			id,
-			name,
-			description,
-			file_path,
-			source_ref,
-			test_count,
-			last_run_status,
-			last_run_at
-		FROM ranked_suites
-		WHERE rn = 1
-		ORDER BY name ASC
-	`
-
 	var rows []CanonicalSuiteRow
-	if err := s.db.SelectContext(ctx, &rows, query, projectID); err != nil {
+	if err := s.db.SelectContext(ctx, &rows, canonicalSuitesQuery+" ORDER BY name ASC", projectID); err != nil {
 		return nil, fmt.Errorf("failed to list canonical suites for project: %w", err)
 	}
 	if rows == nil {
@@ -688,6 +727,31 @@ func (s *Store) ListSuitesForProjectCanonical(ctx context.Context, projectID uui
 	return rows, nil
 }
 
+// ListSuitesForProjectCanonicalPage returns a page of a project's canonical suites
+func (s *Store) ListSuitesForProjectCanonicalPage(ctx context.Context, projectID uuid.UUID, q ListQuery) (ListPage[CanonicalSuiteRow], error) {
+	stmt, err := suiteListColumns.build(canonicalSuitesQuery, []interface{}{projectID}, q)
+	if err != nil {
+		return ListPage[CanonicalSuiteRow]{}, err
+	}
+
+	type suiteRow struct {
+		CanonicalSuiteRow
+		SortKey string `db:"list_sort_key"`
+	}
+	var rows []suiteRow
+	if err := s.db.SelectContext(ctx, &rows, stmt.page, stmt.pageArgs...); err != nil {
+		return ListPage[CanonicalSuiteRow]{}, fmt.Errorf("failed to list canonical suites for project: %w", err)
+	}
+	var total int
+	if err := s.db.GetContext(ctx, &total, stmt.count, stmt.countArgs...); err != nil {
+		return ListPage[CanonicalSuiteRow]{}, fmt.Errorf("failed to count canonical suites for project: %w", err)
+	}
+
+	return newListPage(q, rows, total,
+		func(r suiteRow) ListCursor { return ListCursor{Value: r.SortKey, ID: r.ID} },
+		func(r suiteRow) (CanonicalSuiteRow, error) { return r.CanonicalSuiteRow, nil })
+}
+
 // GetLatestScanForProject returns the most recent scan for a project
 func (s *Store) GetLatestScanForProject(ctx context.Context, orgID uuid.UUID, repoURL, sourceRef string) (*ScanSummary, error) {
 	repoFullName := RepoURLToFullName(repoURL)
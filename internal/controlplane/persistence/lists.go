@@ -0,0 +1,147 @@
+package persistence
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ListQuery asks for one page of a list. The store turns it into WHERE, ORDER BY
+// and LIMIT clauses, so only the page is read from the database.
+type ListQuery struct {
+	Limit   int
+	Sort    string            // One of the list's sort keys; empty uses its default
+	Desc    bool              // Descending order
+	After   *ListCursor       // Last row of the previous page
+	Search  string            // Case-insensitive substring of the list's search column
+	Filters map[string]string // Filter key -> value the column must equal, ignoring case
+}
+
+// ListCursor marks the last row of a page. Value is the row's sort column as
+// text, which the next query casts back to the column's type.
+type ListCursor struct {
+	Value string
+	ID    uuid.UUID
+}
+
+// ListPage is one page of a list, with the number of rows matching the query
+// across all pages. Next is nil on the last page.
+type ListPage[T any] struct {
+	Items []T
+	Next  *ListCursor
+	Total int
+}
+
+// listSortColumn is an expression a list can be sorted by, and the type its text
+// form is cast back to for keyset comparisons. Expressions must not be NULL, so
+// the keyset comparison holds for every row.
+type listSortColumn struct {
+	expr string
+	cast string
+}
+
+// listColumns describes how a list's base query can be sorted and filtered. The
+// expressions refer to the base query's output columns.
+type listColumns struct {
+	sort        map[string]listSortColumn
+	defaultSort string
+	search      string            // Column ?q= matches
+	filters     map[string]string // Filter key -> column
+}
+
+// listSortKey is the column every list page query adds for its cursor
+const listSortKey = "list_sort_key"
+
+// listStatement is a list query as SQL: the page, and the count of every
+// matching row
+type listStatement struct {
+	page      string
+	pageArgs  []interface{}
+	count     string
+	countArgs []interface{}
+}
+
+// build wraps base, a query whose placeholders are baseArgs, in the query's
+// filters, order and limit. It reads one row more than the limit, so the caller
+// can tell whether there is a next page.
+func (c listColumns) build(base string, baseArgs []interface{}, q ListQuery) (listStatement, error) {
+	if q.Limit < 1 {
+		return listStatement{}, fmt.Errorf("list limit must be positive")
+	}
+	sortKey := q.Sort
+	if sortKey == "" {
+		sortKey = c.defaultSort
+	}
+	column, ok := c.sort[sortKey]
+	if !ok {
+		return listStatement{}, fmt.Errorf("unknown sort %q", q.Sort)
+	}
+
+	args := append([]interface{}{}, baseArgs...)
+	placeholder := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	var conditions []string
+	if q.Search != "" && c.search != "" {
+		conditions = append(conditions, fmt.Sprintf("strpos(lower(COALESCE(%s, '')), lower(%s)) > 0", c.search, placeholder(q.Search)))
+	}
+	filterKeys := make([]string, 0, len(q.Filters))
+	for key := range q.Filters {
+		filterKeys = append(filterKeys, key)
+	}
+	sort.Strings(filterKeys)
+	for _, key := range filterKeys {
+		col, ok := c.filters[key]
+		if !ok {
+			return listStatement{}, fmt.Errorf("unknown filter %q", key)
+		}
+		conditions = append(conditions, fmt.Sprintf("lower(%s) = lower(%s)", col, placeholder(q.Filters[key])))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+	count := fmt.Sprintf("SELECT COUNT(*) FROM (%s) AS list%s", base, where)
+	countArgs := append([]interface{}{}, args...)
+
+	direction, compare := "ASC", ">"
+	if q.Desc {
+		direction, compare = "DESC", "<"
+	}
+	if q.After != nil {
+		keyset := fmt.Sprintf("(%s, id) %s (%s::%s, %s::uuid)", column.expr, compare, placeholder(q.After.Value), column.cast, placeholder(q.After.ID))
+		if where == "" {
+			where = " WHERE " + keyset
+		} else {
+			where += " AND " + keyset
+		}
+	}
+
+	page := fmt.Sprintf("SELECT list.*, (%s)::text AS %s FROM (%s) AS list%s ORDER BY %s %s, id %s LIMIT %s",
+		column.expr, listSortKey, base, where, column.expr, direction, direction, placeholder(q.Limit+1))
+	return listStatement{page: page, pageArgs: args, count: count, countArgs: countArgs}, nil
+}
+
+// newListPage cuts the extra row build asked for off rows and turns it into the
+// next page's cursor
+func newListPage[R any, T any](q ListQuery, rows []R, total int, key func(R) ListCursor, item func(R) (T, error)) (ListPage[T], error) {
+	page := ListPage[T]{Items: make([]T, 0, len(rows)), Total: total}
+	for i, row := range rows {
+		if i == q.Limit {
+			last := key(rows[i-1])
+			page.Next = &last
+			break
+		}
+		converted, err := item(row)
+		if err != nil {
+			return ListPage[T]{}, err
+		}
+		page.Items = append(page.Items, converted)
+	}
+	return page, nil
+}
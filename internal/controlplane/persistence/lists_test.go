@@ -0,0 +1,121 @@
+package persistence
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestListColumnsBuild(t *testing.T) {
+	const base = "SELECT id, name, test_count, last_run_status FROM suites WHERE project_id = $1"
+	projectID := uuid.New()
+	after := uuid.New()
+
+	stmt, err := suiteListColumns.build(base, []interface{}{projectID}, ListQuery{
+		Limit:   20,
+		Sort:    "test_count",
+		Desc:    true,
+		After:   &ListCursor{Value: "7", ID: after},
+		Search:  "Auth",
+		Filters: map[string]string{"status": "passed"},
+	})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+
+	wantPage := "SELECT list.*, (test_count)::text AS list_sort_key FROM (" + base + ") AS list " +
+		"WHERE strpos(lower(COALESCE(name, '')), lower($2)) > 0 AND lower(last_run_status) = lower($3) " +
+		"AND (test_count, id) < ($4::integer, $5::uuid) ORDER BY test_count DESC, id DESC LIMIT $6"
+	if stmt.page != wantPage {
+		t.Errorf("page query:\n got %s\nwant %s", stmt.page, wantPage)
+	}
+	if want := []interface{}{projectID, "Auth", "passed", "7", after, 21}; !reflect.DeepEqual(stmt.pageArgs, want) {
+		t.Errorf("page args = %v, want %v", stmt.pageArgs, want)
+	}
+
+	// The count ignores the cursor and the limit
+	wantCount := "SELECT COUNT(*) FROM (" + base + ") AS list " +
+		"WHERE strpos(lower(COALESCE(name, '')), lower($2)) > 0 AND lower(last_run_status) = lower($3)"
+	if stmt.count != wantCount {
+		t.Errorf("count query:\n got %s\nwant %s", stmt.count, wantCount)
+	}
+	if want := []interface{}{projectID, "Auth", "passed"}; !reflect.DeepEqual(stmt.countArgs, want) {
+		t.Errorf("count args = %v, want %v", stmt.countArgs, want)
+	}
+}
+
+func TestListColumnsBuildDefaults(t *testing.T) {
+	stmt, err := environmentListColumns.build("SELECT * FROM project_environments", nil, ListQuery{Limit: 50})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if !strings.HasSuffix(stmt.page, "AS list ORDER BY lower(name) ASC, id ASC LIMIT $1") {
+		t.Errorf("expected the default sort without conditions, got %s", stmt.page)
+	}
+
+	for _, q := range []ListQuery{
+		{Limit: 0},
+		{Limit: 10, Sort: "secret"},
+		{Limit: 10, Filters: map[string]string{"owner": "me"}},
+	} {
+		if _, err := environmentListColumns.build("SELECT * FROM project_environments", nil, q); err == nil {
+			t.Errorf("expected %+v to be rejected", q)
+		}
+	}
+}
+
+func TestNewListPage(t *testing.T) {
+	type row struct {
+		id  uuid.UUID
+		key string
+	}
+	rows := []row{{uuid.New(), "a"}, {uuid.New(), "b"}, {uuid.New(), "c"}}
+	key := func(r row) ListCursor { return ListCursor{Value: r.key, ID: r.id} }
+	item := func(r row) (string, error) { return r.key, nil }
+
+	// build reads one row past the limit when there is a next page
+	page, err := newListPage(ListQuery{Limit: 2}, rows, 9, key, item)
+	if err != nil {
+		t.Fatalf("newListPage: %v", err)
+	}
+	if !reflect.DeepEqual(page.Items, []string{"a", "b"}) || page.Total != 9 {
+		t.Errorf("unexpected page %+v", page)
+	}
+	if page.Next == nil || page.Next.Value != "b" || page.Next.ID != rows[1].id {
+		t.Errorf("expected the cursor to point at the last item, got %+v", page.Next)
+	}
+
+	page, err = newListPage(ListQuery{Limit: 3}, rows, 3, key, item)
+	if err != nil {
+		t.Fatalf("newListPage: %v", err)
+	}
+	if len(page.Items) != 3 || page.Next != nil {
+		t.Errorf("expected the last page without a cursor, got %+v", page)
+	}
+}
+
+// The API's sorts and filters must be ones the store can turn into SQL
+func TestListColumnsCoverAPI(t *testing.T) {
+	for name, tt := range map[string]struct {
+		columns listColumns
+		sorts   []string
+		filters []string
+	}{
+		"projects":     {projectListColumns, []string{"name", "suite_count", "test_count"}, []string{"repo_url", "source_ref"}},
+		"suites":       {suiteListColumns, []string{"name", "file_path", "test_count", "last_run_at"}, []string{"source_ref", "status"}},
+		"environments": {environmentListColumns, []string{"name", "slug", "created_at", "updated_at"}, []string{"fallback"}},
+	} {
+		for _, sort := range tt.sorts {
+			if _, ok := tt.columns.sort[sort]; !ok {
+				t.Errorf("%s: missing sort %q", name, sort)
+			}
+		}
+		for _, filter := range tt.filters {
+			if _, ok := tt.columns.filters[filter]; !ok {
+				t.Errorf("%s: missing filter %q", name, filter)
+			}
+		}
+	}
+}
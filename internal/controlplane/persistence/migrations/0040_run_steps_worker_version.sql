@@ -0,0 +1,4 @@
+-- Record which worker build executed each step so failures on mixed or outdated
+-- deployments can be traced back to the worker version
+
+ALTER TABLE run_steps ADD COLUMN IF NOT EXISTS worker_version TEXT;
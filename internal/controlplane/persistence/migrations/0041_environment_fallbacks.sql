@@ -0,0 +1,7 @@
+-- Let an environment fall back to another environment in the same project for
+-- secrets and config vars it doesn't set, so preview environments can inherit from
+-- staging. Runs record the chain that was resolved.
+
+ALTER TABLE project_environments ADD COLUMN IF NOT EXISTS fallback_slug TEXT;
+
+ALTER TABLE runs ADD COLUMN IF NOT EXISTS environment_chain TEXT[];
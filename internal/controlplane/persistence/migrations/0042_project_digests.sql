@@ -0,0 +1,21 @@
+-- Opt-in email digest of a project's scheduled suite health
+-- At most one digest per project, sent daily or weekly to its recipients
+CREATE TABLE IF NOT EXISTS project_digests (
+    project_id UUID PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+    frequency TEXT NOT NULL CHECK (frequency IN ('daily', 'weekly')),
+    recipients TEXT[] NOT NULL,
+    next_send_at TIMESTAMPTZ NOT NULL,
+    last_sent_at TIMESTAMPTZ,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Index for the digest job: find digests due to be sent
+CREATE INDEX IF NOT EXISTS project_digests_due_idx
+    ON project_digests (next_send_at ASC);
+
+-- Index for the digest's scheduled run queries
+CREATE INDEX IF NOT EXISTS runs_project_schedule_ended_idx
+    ON runs (project_id, ended_at)
+    WHERE trigger = 'schedule';
@@ -0,0 +1,7 @@
+-- Record why a run was cut short (user, timeout, quota or shutdown) and how many of
+-- its tests were interrupted, so reports can tell "failed" from "never ran".
+-- Tests that never started count towards skipped_tests.
+
+ALTER TABLE runs ADD COLUMN IF NOT EXISTS cancel_reason TEXT;
+ALTER TABLE runs ADD COLUMN IF NOT EXISTS cancel_message TEXT;
+ALTER TABLE runs ADD COLUMN IF NOT EXISTS interrupted_tests INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,15 @@
+-- Preview environments are short-lived environments tied to a pull request. They
+-- are deleted when the PR closes, and the runs that used them are archived so they
+-- stay out of project dashboards.
+
+ALTER TABLE project_environments ADD COLUMN IF NOT EXISTS pr_number INTEGER;
+
+CREATE UNIQUE INDEX IF NOT EXISTS project_environments_project_pr_idx
+    ON project_environments (project_id, pr_number)
+    WHERE pr_number IS NOT NULL;
+
+ALTER TABLE runs ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS runs_archived_idx
+    ON runs (environment_id)
+    WHERE archived_at IS NOT NULL;
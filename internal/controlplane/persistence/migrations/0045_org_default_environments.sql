@@ -0,0 +1,11 @@
+-- An organization's default environment holds the secrets and config vars every
+-- project in it shares. Runs fall back to it after their project's environments,
+-- and use it on its own when they don't ask for an environment.
+
+CREATE TABLE IF NOT EXISTS organization_default_environments (
+    organization_id UUID PRIMARY KEY REFERENCES organizations(id) ON DELETE CASCADE,
+    env_secrets JSONB NOT NULL DEFAULT '{}'::jsonb,
+    config_vars JSONB NOT NULL DEFAULT '{}'::jsonb,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,4 @@
+-- Record the tags of the tests a run ran, so runs can be listed by tag
+-- (e.g. only smoke runs).
+
+ALTER TABLE runs ADD COLUMN IF NOT EXISTS tags TEXT[];
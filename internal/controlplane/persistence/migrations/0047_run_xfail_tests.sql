@@ -0,0 +1,4 @@
+-- Count the tests marked xfail that failed, as expected, apart from the passed
+-- and failed ones. Like skipped tests they don't fail the run.
+
+ALTER TABLE runs ADD COLUMN IF NOT EXISTS xfail_tests INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,140 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// orgEnvRow is a helper type for scanning org default environments with JSONB columns
+type orgEnvRow struct {
+	OrganizationID uuid.UUID `db:"organization_id"`
+	EnvSecrets     []byte    `db:"env_secrets"`
+	ConfigVars     []byte    `db:"config_vars"`
+	CreatedAt      time.Time `db:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at"`
+}
+
+func (r orgEnvRow) toOrgDefaultEnvironment() (OrgDefaultEnvironment, error) {
+	env := OrgDefaultEnvironment{
+		OrganizationID: r.OrganizationID,
+		EnvSecrets:     make(map[string]string),
+		ConfigVars:     make(map[string]interface{}),
+		CreatedAt:      r.CreatedAt,
+		UpdatedAt:      r.UpdatedAt,
+	}
+	if len(r.EnvSecrets) > 0 {
+		if err := json.Unmarshal(r.EnvSecrets, &env.EnvSecrets); err != nil {
+			return OrgDefaultEnvironment{}, fmt.Errorf("failed to parse env_secrets: %w", err)
+		}
+	}
+	if len(r.ConfigVars) > 0 {
+		if err := json.Unmarshal(r.ConfigVars, &env.ConfigVars); err != nil {
+			return OrgDefaultEnvironment{}, fmt.Errorf("failed to parse config_vars: %w", err)
+		}
+	}
+	return env, nil
+}
+
+// GetOrgDefaultEnvironment returns an organization's default environment, or
+// sql.ErrNoRows when it hasn't set one
+func (s *Store) GetOrgDefaultEnvironment(ctx context.Context, orgID uuid.UUID) (OrgDefaultEnvironment, error) {
+	const query = `
+		SELECT organization_id, env_secrets, config_vars, created_at, updated_at
+		FROM organization_default_environments
+		WHERE organization_id = $1
+	`
+
+	var row orgEnvRow
+	if err := s.db.GetContext(ctx, &row, query, orgID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return OrgDefaultEnvironment{}, sql.ErrNoRows
+		}
+		return OrgDefaultEnvironment{}, fmt.Errorf("failed to get organization default environment: %w", err)
+	}
+	return row.toOrgDefaultEnvironment()
+}
+
+// GetProjectOrgDefaultEnvironment returns the default environment of the
+// organization a project belongs to, or sql.ErrNoRows when it hasn't set one
+func (s *Store) GetProjectOrgDefaultEnvironment(ctx context.Context, projectID uuid.UUID) (OrgDefaultEnvironment, error) {
+	const query = `
+		SELECT d.organization_id, d.env_secrets, d.config_vars, d.created_at, d.updated_at
+		FROM organization_default_environments d
+		JOIN projects p ON p.organization_id = d.organization_id
+		WHERE p.id = $1
+	`
+
+	var row orgEnvRow
+	if err := s.db.GetContext(ctx, &row, query, projectID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return OrgDefaultEnvironment{}, sql.ErrNoRows
+		}
+		return OrgDefaultEnvironment{}, fmt.Errorf("failed to get organization default environment: %w", err)
+	}
+	return row.toOrgDefaultEnvironment()
+}
+
+// SetOrgDefaultEnvironment creates or replaces an organization's default environment
+func (s *Store) SetOrgDefaultEnvironment(ctx context.Context, env OrgDefaultEnvironment) (OrgDefaultEnvironment, error) {
+	if env.OrganizationID == uuid.Nil {
+		return OrgDefaultEnvironment{}, errors.New("organization id required")
+	}
+	if env.EnvSecrets == nil {
+		env.EnvSecrets = make(map[string]string)
+	}
+	secretsJSON, err := json.Marshal(env.EnvSecrets)
+	if err != nil {
+		return OrgDefaultEnvironment{}, fmt.Errorf("failed to encode env_secrets: %w", err)
+	}
+	if env.ConfigVars == nil {
+		env.ConfigVars = make(map[string]interface{})
+	}
+	varsJSON, err := json.Marshal(env.ConfigVars)
+	if err != nil {
+		return OrgDefaultEnvironment{}, fmt.Errorf("failed to encode config_vars: %w", err)
+	}
+
+	const query = `
+		INSERT INTO organization_default_environments (organization_id, env_secrets, config_vars)
+		VALUES ($1, $2::jsonb, $3::jsonb)
+		ON CONFLICT (organization_id) DO UPDATE
+		SET env_secrets = EXCLUDED.env_secrets, config_vars = EXCLUDED.config_vars, updated_at = NOW()
+		RETURNING created_at, updated_at
+	`
+
+	var times struct {
+		CreatedAt time.Time `db:"created_at"`
+		UpdatedAt time.Time `db:"updated_at"`
+	}
+	if err := s.db.GetContext(ctx, &times, query, env.OrganizationID, string(secretsJSON), string(varsJSON)); err != nil {
+		return OrgDefaultEnvironment{}, fmt.Errorf("failed to set organization default environment: %w", err)
+	}
+	env.CreatedAt = times.CreatedAt
+	env.UpdatedAt = times.UpdatedAt
+	return env, nil
+}
+
+// DeleteOrgDefaultEnvironment removes an organization's default environment,
+// returning sql.ErrNoRows when it hasn't set one
+func (s *Store) DeleteOrgDefaultEnvironment(ctx context.Context, orgID uuid.UUID) error {
+	const query = `DELETE FROM organization_default_environments WHERE organization_id = $1`
+
+	res, err := s.db.ExecContext(ctx, query, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to delete organization default environment: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read delete result: %w", err)
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
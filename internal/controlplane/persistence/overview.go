@@ -222,7 +222,7 @@ func (s *Store) GetOverviewMetrics(ctx context.Context, orgID, userID uuid.UUID,
 func (s *Store) getFailingMonitorsCount(ctx context.Context, orgID uuid.UUID, projectIDs []uuid.UUID, environmentID *uuid.UUID) (int, error) {
 	// Count from both project_schedules and suite_schedules
 	// A "failing monitor" is an enabled schedule whose last_run_at >= now()-24h
-	// and last_run_status IN ('FAILED','TIMEOUT','CANCELLED')
+	// and last_run_status IN ('FAILED','TIMEOUT','CANCELLED','BUDGET_EXCEEDED')
 
 	var envFilter string
 	args := []interface{}{pq.Array(projectIDs), time.Now().Add(-24 * time.Hour)}
@@ -238,7 +238,7 @@ func (s *Store) getFailingMonitorsCount(ctx context.Context, orgID uuid.UUID, pr
 		WHERE ps.project_id = ANY($1)
 			AND ps.enabled = TRUE
 			AND ps.last_run_at >= $2
-			AND ps.last_run_status IN ('FAILED', 'TIMEOUT', 'CANCELLED')
+			AND ps.last_run_status IN ('FAILED', 'TIMEOUT', 'CANCELLED', 'BUDGET_EXCEEDED')
 			%s
 	`, envFilter)
 
@@ -261,7 +261,7 @@ func (s *Store) getFailingMonitorsCount(ctx context.Context, orgID uuid.UUID, pr
 		WHERE ss.project_id = ANY($1)
 			AND ss.enabled = TRUE
 			AND ss.last_run_at >= $2
-			AND ss.last_run_status IN ('FAILED', 'TIMEOUT', 'CANCELLED')
+			AND ss.last_run_status IN ('FAILED', 'TIMEOUT', 'CANCELLED', 'BUDGET_EXCEEDED')
 			%s
 	`, envFilter)
 
@@ -286,8 +286,9 @@ func (s *Store) getFailingTestsCount24h(ctx context.Context, orgID uuid.UUID, pr
 		SELECT COALESCE(SUM(r.failed_tests + r.timeout_tests), 0)::int
 		FROM runs r
 		WHERE r.organization_id = $1
+			AND r.archived_at IS NULL
 			AND r.project_id = ANY($2)
-			AND r.status IN ('PASSED', 'FAILED', 'CANCELLED', 'TIMEOUT')
+			AND r.status IN ('PASSED', 'FAILED', 'CANCELLED', 'TIMEOUT', 'BUDGET_EXCEEDED')
 			AND r.ended_at >= $3
 			%s
 	`, envFilter)
@@ -312,6 +313,7 @@ func (s *Store) getRunsInProgressCount(ctx context.Context, orgID uuid.UUID, pro
 		SELECT COUNT(*)
 		FROM runs r
 		WHERE r.organization_id = $1
+			AND r.archived_at IS NULL
 			AND r.project_id = ANY($2)
 			AND r.status IN ('RUNNING', 'PENDING')
 			%s
@@ -335,12 +337,13 @@ func (s *Store) getPassRateAndDuration24h(ctx context.Context, orgID uuid.UUID,
 
 	query := fmt.Sprintf(`
 		SELECT
-			100.0 * SUM(r.passed_tests) / NULLIF(SUM(r.total_tests - r.skipped_tests), 0) AS pass_rate,
+			100.0 * SUM(r.passed_tests) / NULLIF(SUM(r.total_tests - r.skipped_tests - r.xfail_tests), 0) AS pass_rate,
 			percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM (r.ended_at - r.started_at)) * 1000)::bigint AS median_duration_ms
 		FROM runs r
 		WHERE r.organization_id = $1
+			AND r.archived_at IS NULL
 			AND r.project_id = ANY($2)
-			AND r.status IN ('PASSED', 'FAILED', 'CANCELLED', 'TIMEOUT')
+			AND r.status IN ('PASSED', 'FAILED', 'CANCELLED', 'TIMEOUT', 'BUDGET_EXCEEDED')
 			AND r.started_at IS NOT NULL
 			AND r.ended_at IS NOT NULL
 			AND r.ended_at >= $3
@@ -378,12 +381,13 @@ func (s *Store) getPassRateOverTime(ctx context.Context, orgID uuid.UUID, projec
 	query := fmt.Sprintf(`
 		SELECT
 			date_trunc('day', r.ended_at)::date AS day,
-			100.0 * SUM(r.passed_tests) / NULLIF(SUM(r.total_tests - r.skipped_tests), 0) AS pass_rate,
+			100.0 * SUM(r.passed_tests) / NULLIF(SUM(r.total_tests - r.skipped_tests - r.xfail_tests), 0) AS pass_rate,
 			COUNT(*)::int AS volume
 		FROM runs r
 		WHERE r.organization_id = $1
+			AND r.archived_at IS NULL
 			AND r.project_id = ANY($2)
-			AND r.status IN ('PASSED', 'FAILED', 'CANCELLED', 'TIMEOUT')
+			AND r.status IN ('PASSED', 'FAILED', 'CANCELLED', 'TIMEOUT', 'BUDGET_EXCEEDED')
 			AND r.started_at IS NOT NULL
 			AND r.ended_at IS NOT NULL
 			AND r.ended_at >= $3
@@ -434,8 +438,9 @@ func (s *Store) getFailuresBySuite24h(ctx context.Context, orgID uuid.UUID, proj
 			COALESCE(SUM(r.failed_tests + r.timeout_tests), 0)::int AS failures
 		FROM runs r
 		WHERE r.organization_id = $1
+			AND r.archived_at IS NULL
 			AND r.project_id = ANY($2)
-			AND r.status IN ('PASSED', 'FAILED', 'CANCELLED', 'TIMEOUT')
+			AND r.status IN ('PASSED', 'FAILED', 'CANCELLED', 'TIMEOUT', 'BUDGET_EXCEEDED')
 			AND r.ended_at >= $3
 			%s
 		GROUP BY r.suite_name
@@ -0,0 +1,142 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// PreviewEnvironmentSlug is the slug of the preview environment for a pull request
+func PreviewEnvironmentSlug(prNumber int) string {
+	return fmt.Sprintf("pr-%d", prNumber)
+}
+
+// UpsertPreviewEnvironment returns the project's preview environment for a pull
+// request, creating it when it doesn't exist. The boolean reports whether it was created.
+func (s *Store) UpsertPreviewEnvironment(ctx context.Context, projectID uuid.UUID, prNumber int, fallback string) (ProjectEnvironment, bool, error) {
+	if projectID == uuid.Nil {
+		return ProjectEnvironment{}, false, errors.New("project id required")
+	}
+	if prNumber <= 0 {
+		return ProjectEnvironment{}, false, errors.New("pull request number must be positive")
+	}
+
+	const selectQuery = `
+		SELECT id, project_id, name, slug, fallback_slug, env_secrets, config_vars, created_at, updated_at
+		FROM project_environments
+		WHERE project_id = $1 AND pr_number = $2
+	`
+	var row envRow
+	err := s.db.GetContext(ctx, &row, selectQuery, projectID, prNumber)
+	if err == nil {
+		env, err := row.toEnvironment()
+		return env, false, err
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return ProjectEnvironment{}, false, fmt.Errorf("failed to get preview environment: %w", err)
+	}
+
+	// A concurrent create for the same PR loses the race on the partial index and
+	// returns the winner's row instead
+	const insertQuery = `
+		INSERT INTO project_environments (id, project_id, name, slug, env_secrets, config_vars, fallback_slug, pr_number, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, '{}'::jsonb, '{}'::jsonb, NULLIF($5, ''), $6, NOW(), NOW())
+		ON CONFLICT (project_id, pr_number) WHERE pr_number IS NOT NULL DO NOTHING
+	`
+	res, err := s.db.ExecContext(ctx, insertQuery,
+		uuid.New(), projectID, fmt.Sprintf("PR #%d", prNumber), PreviewEnvironmentSlug(prNumber),
+		strings.ToLower(strings.TrimSpace(fallback)), prNumber)
+	if err != nil {
+		if isUniqueViolation(err, "project_environments_project_slug_idx") {
+			return ProjectEnvironment{}, false, fmt.Errorf("environment slug %s already exists in project", PreviewEnvironmentSlug(prNumber))
+		}
+		return ProjectEnvironment{}, false, fmt.Errorf("failed to create preview environment: %w", err)
+	}
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return ProjectEnvironment{}, false, fmt.Errorf("failed to check rows affected: %w", err)
+	}
+
+	if err := s.db.GetContext(ctx, &row, selectQuery, projectID, prNumber); err != nil {
+		return ProjectEnvironment{}, false, fmt.Errorf("failed to get preview environment: %w", err)
+	}
+	env, err := row.toEnvironment()
+	return env, inserted > 0, err
+}
+
+// DeletePreviewEnvironment deletes a project's preview environment for a pull
+// request and archives its runs. It returns sql.ErrNoRows when there is none.
+func (s *Store) DeletePreviewEnvironment(ctx context.Context, projectID uuid.UUID, prNumber int) (int, error) {
+	envs, runs, err := s.deletePreviewEnvironments(ctx, `project_id = $1 AND pr_number = $2`, projectID, prNumber)
+	if err != nil {
+		return 0, err
+	}
+	if envs == 0 {
+		return 0, sql.ErrNoRows
+	}
+	return runs, nil
+}
+
+// DeletePreviewEnvironmentsForRepoAndPR deletes the preview environments for a
+// pull request across the organization's projects for a repository, and archives
+// their runs. Used when the pull request closes.
+func (s *Store) DeletePreviewEnvironmentsForRepoAndPR(ctx context.Context, orgID uuid.UUID, repoURL string, prNumber int) (int, int, error) {
+	return s.deletePreviewEnvironments(ctx, `
+		pr_number = $3 AND project_id IN (
+			SELECT id FROM projects WHERE organization_id = $1 AND repo_url = $2
+		)`, orgID, repoURL, prNumber)
+}
+
+// deletePreviewEnvironments archives the runs of the matching preview environments,
+// then deletes the environments. It returns the environment and run counts.
+func (s *Store) deletePreviewEnvironments(ctx context.Context, where string, args ...interface{}) (int, int, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var envIDs []uuid.UUID
+	selectQuery := `SELECT id FROM project_environments WHERE pr_number IS NOT NULL AND ` + where + ` FOR UPDATE`
+	if err := tx.SelectContext(ctx, &envIDs, selectQuery, args...); err != nil {
+		return 0, 0, fmt.Errorf("failed to find preview environments: %w", err)
+	}
+	if len(envIDs) == 0 {
+		return 0, 0, nil
+	}
+
+	runs, err := archiveEnvironmentRuns(ctx, tx, envIDs)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM project_environments WHERE id = ANY($1)`, pq.Array(envIDs)); err != nil {
+		return 0, 0, fmt.Errorf("failed to delete preview environments: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return len(envIDs), runs, nil
+}
+
+// archiveEnvironmentRuns marks the runs of the given environments archived, so
+// dashboards skip them once the environments are gone
+func archiveEnvironmentRuns(ctx context.Context, tx *sqlx.Tx, envIDs []uuid.UUID) (int, error) {
+	const query = `UPDATE runs SET archived_at = NOW() WHERE archived_at IS NULL AND environment_id = ANY($1)`
+	res, err := tx.ExecContext(ctx, query, pq.Array(envIDs))
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive runs: %w", err)
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	return int(count), nil
+}
@@ -320,6 +320,54 @@ func (s *Store) ListProjects(ctx context.Context, orgID uuid.UUID) ([]Project, e
 	return projects, nil
 }
 
+// ListActiveProjectsByRepoURL returns the active projects for a repository across
+// all organizations. CI token exchange uses it to scope a token to the projects
+// discovered from the repository the CI job runs in.
+func (s *Store) ListActiveProjectsByRepoURL(ctx context.Context, repoURL string) ([]Project, error) {
+	const query = `
+		SELECT id, organization_id, name, repo_url, default_branch, path_scope, source_ref, created_at
+		FROM projects
+		WHERE lower(repo_url) = lower($1) AND is_active = true
+		ORDER BY organization_id, name ASC, source_ref ASC
+	`
+
+	rows := []struct {
+		ID             uuid.UUID `db:"id"`
+		OrganizationID uuid.UUID `db:"organization_id"`
+		Name           string    `db:"name"`
+		RepoURL        string    `db:"repo_url"`
+		DefaultBranch  string    `db:"default_branch"`
+		PathScope      string    `db:"path_scope"`
+		SourceRef      string    `db:"source_ref"`
+		CreatedAt      time.Time `db:"created_at"`
+	}{}
+
+	if err := s.db.SelectContext(ctx, &rows, query, repoURL); err != nil {
+		return nil, fmt.Errorf("failed to list projects for repository: %w", err)
+	}
+
+	projects := make([]Project, 0, len(rows))
+	for _, r := range rows {
+		p := Project{
+			ID:             r.ID,
+			OrganizationID: r.OrganizationID,
+			Name:           r.Name,
+			RepoURL:        r.RepoURL,
+			DefaultBranch:  r.DefaultBranch,
+			SourceRef:      r.SourceRef,
+			CreatedAt:      r.CreatedAt,
+		}
+		if r.PathScope != "" {
+			if err := json.Unmarshal([]byte(r.PathScope), &p.PathScope); err != nil {
+				return nil, fmt.Errorf("failed to parse path_scope: %w", err)
+			}
+		}
+		projects = append(projects, p)
+	}
+
+	return projects, nil
+}
+
 // ProjectNameExists checks if a project name already exists in an organization for a given source_ref
 func (s *Store) ProjectNameExists(ctx context.Context, orgID uuid.UUID, name, sourceRef string) (bool, error) {
 	const query = `SELECT EXISTS(SELECT 1 FROM projects WHERE organization_id = $1 AND lower(name) = lower($2) AND lower(source_ref) = lower($3))`
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 // InsertRunTest creates a new run test record
@@ -268,7 +269,7 @@ func (s *Store) ListRunSteps(ctx context.Context, runTestID uuid.UUID) ([]RunSte
         SELECT id, run_test_id, step_index, name, plugin, status, error_message,
                request_data, response_data, assertions_data, variables_data, step_config,
                assertions_passed, assertions_failed,
-               started_at, ended_at, duration_ms, created_at
+               started_at, ended_at, duration_ms, worker_version, created_at
         FROM run_steps
         WHERE run_test_id = $1
         ORDER BY step_index ASC
@@ -657,6 +658,39 @@ func (s *Store) GetRunTestWithRun(ctx context.Context, orgID uuid.UUID, runTestI
 	}, nil
 }
 
+// MedianStepDurations returns the median step duration of each test across its
+// runs in the last 30 days. Tests without history are left out.
+func (s *Store) MedianStepDurations(ctx context.Context, testIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	result := make(map[uuid.UUID]int64)
+	if len(testIDs) == 0 {
+		return result, nil
+	}
+
+	const query = `
+        SELECT rt.test_id,
+               percentile_cont(0.5) WITHIN GROUP (ORDER BY rs.duration_ms)::bigint AS median_duration_ms
+        FROM run_steps rs
+        JOIN run_tests rt ON rt.id = rs.run_test_id
+        WHERE rt.test_id = ANY($1)
+          AND rt.created_at >= NOW() - INTERVAL '30 days'
+          AND rs.duration_ms IS NOT NULL
+          AND rs.status IN ('PASSED', 'FAILED')
+        GROUP BY rt.test_id
+    `
+
+	var rows []struct {
+		TestID           uuid.UUID `db:"test_id"`
+		MedianDurationMs int64     `db:"median_duration_ms"`
+	}
+	if err := s.db.SelectContext(ctx, &rows, query, pq.Array(testIDs)); err != nil {
+		return nil, fmt.Errorf("failed to load step durations: %w", err)
+	}
+	for _, row := range rows {
+		result[row.TestID] = row.MedianDurationMs
+	}
+	return result, nil
+}
+
 // UpsertRunStep creates or updates a run step record
 // Uses ON CONFLICT with the (run_test_id, step_index) unique constraint
 func (s *Store) UpsertRunStep(ctx context.Context, step RunStep) (RunStep, error) {
@@ -682,9 +716,9 @@ func (s *Store) UpsertRunStep(ctx context.Context, step RunStep) (RunStep, error
             id, run_test_id, step_index, name, plugin, status, error_message,
             request_data, response_data, assertions_data, variables_data, step_config,
             assertions_passed, assertions_failed,
-            started_at, ended_at, duration_ms, created_at
+            started_at, ended_at, duration_ms, worker_version, created_at
         )
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8::jsonb, $9::jsonb, $10::jsonb, $11::jsonb, $12::jsonb, $13, $14, $15, $16, $17, NOW())
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8::jsonb, $9::jsonb, $10::jsonb, $11::jsonb, $12::jsonb, $13, $14, $15, $16, $17, $18, NOW())
         ON CONFLICT (run_test_id, step_index) DO UPDATE SET
             name = EXCLUDED.name,
             plugin = EXCLUDED.plugin,
@@ -699,7 +733,8 @@ func (s *Store) UpsertRunStep(ctx context.Context, step RunStep) (RunStep, error
             assertions_failed = EXCLUDED.assertions_failed,
             started_at = COALESCE(run_steps.started_at, EXCLUDED.started_at),
             ended_at = EXCLUDED.ended_at,
-            duration_ms = EXCLUDED.duration_ms
+            duration_ms = EXCLUDED.duration_ms,
+            worker_version = COALESCE(EXCLUDED.worker_version, run_steps.worker_version)
         RETURNING id, created_at
     `
 
@@ -766,7 +801,7 @@ func (s *Store) UpsertRunStep(ctx context.Context, step RunStep) (RunStep, error
 		step.ID, step.RunTestID, step.StepIndex, step.Name, step.Plugin, step.Status, errMsg,
 		string(requestJSON), string(responseJSON), string(assertionsJSON), string(variablesJSON), string(stepConfigJSON),
 		step.AssertionsPassed, step.AssertionsFailed,
-		startedAt, endedAt, durationMs)
+		startedAt, endedAt, durationMs, step.WorkerVersion)
 
 	if err := row.Scan(&step.ID, &step.CreatedAt); err != nil {
 		return RunStep{}, fmt.Errorf("failed to upsert run step: %w", err)
@@ -62,10 +62,10 @@ func (s *Store) InsertRun(ctx context.Context, run RunRecord) (RunRecord, error)
             id, organization_id, project_id, status, suite_name, suite_file_path, initiator, trigger, schedule_name, schedule_type,
             config_source, source, branch, environment, commit_sha, bundle_sha,
             total_tests, passed_tests, failed_tests, timeout_tests, skipped_tests,
-            environment_id, schedule_id, commit_message,
+            environment_id, environment_chain, schedule_id, commit_message, tags,
             created_at, updated_at, started_at, ended_at
         )
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, NOW(), NOW(), $25, $26)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, NOW(), NOW(), $27, $28)
         RETURNING created_at, updated_at
     `
 
@@ -79,7 +79,7 @@ func (s *Store) InsertRun(ctx context.Context, run RunRecord) (RunRecord, error)
 		run.Initiator, run.Trigger, run.ScheduleName, scheduleType, run.ConfigSource,
 		run.Source, run.Branch, run.Environment, commitSHA, bundleSHA,
 		run.TotalTests, run.PassedTests, run.FailedTests, run.TimeoutTests, run.SkippedTests,
-		environmentID, scheduleID, commitMessage,
+		environmentID, run.EnvironmentChain, scheduleID, commitMessage, run.Tags,
 		startedAt, endedAt); err != nil {
 		return RunRecord{}, fmt.Errorf("failed to insert run: %w", err)
 	}
@@ -128,9 +128,15 @@ func (s *Store) UpdateRun(ctx context.Context, update RunUpdate) (RunRecord, err
 		argIdx++
 	}
 	if update.Totals != nil {
-		sets = append(sets, fmt.Sprintf("total_tests = $%d, passed_tests = $%d, failed_tests = $%d, timeout_tests = $%d",
-			argIdx, argIdx+1, argIdx+2, argIdx+3))
-		args = append(args, update.Totals.Total, update.Totals.Passed, update.Totals.Failed, update.Totals.Timeout)
+		sets = append(sets, fmt.Sprintf("total_tests = $%d, passed_tests = $%d, failed_tests = $%d, timeout_tests = $%d, skipped_tests = $%d, interrupted_tests = $%d, xfail_tests = $%d",
+			argIdx, argIdx+1, argIdx+2, argIdx+3, argIdx+4, argIdx+5, argIdx+6))
+		args = append(args, update.Totals.Total, update.Totals.Passed, update.Totals.Failed, update.Totals.Timeout,
+			update.Totals.Skipped, update.Totals.Interrupted, update.Totals.XFail)
+		argIdx += 7
+	}
+	if update.CancelReason != nil {
+		sets = append(sets, fmt.Sprintf("cancel_reason = $%d, cancel_message = NULLIF($%d, '')", argIdx, argIdx+1))
+		args = append(args, *update.CancelReason, update.CancelMessage)
 	}
 
 	if len(sets) == 0 {
@@ -152,8 +158,8 @@ func (s *Store) UpdateRun(ctx context.Context, update RunUpdate) (RunRecord, err
         WHERE id = $1 AND organization_id = $2
         RETURNING id, organization_id, project_id, status, suite_name, suite_file_path, initiator, trigger, schedule_name, schedule_type,
                   config_source, source, branch, environment, commit_sha, bundle_sha,
-                  total_tests, passed_tests, failed_tests, timeout_tests, skipped_tests,
-                  environment_id, schedule_id, commit_message,
+                  total_tests, passed_tests, failed_tests, timeout_tests, skipped_tests, interrupted_tests, xfail_tests,
+                  environment_id, environment_chain, schedule_id, commit_message, cancel_reason, cancel_message, tags,
                   created_at, updated_at, started_at, ended_at
     `, setsStr)
 
@@ -172,8 +178,8 @@ func (s *Store) GetRun(ctx context.Context, orgID uuid.UUID, runID string) (RunR
 	const query = `
         SELECT id, organization_id, project_id, status, suite_name, suite_file_path, initiator, trigger, schedule_name, schedule_type,
                config_source, source, branch, environment, commit_sha, bundle_sha,
-               total_tests, passed_tests, failed_tests, timeout_tests, skipped_tests,
-               environment_id, schedule_id, commit_message,
+               total_tests, passed_tests, failed_tests, timeout_tests, skipped_tests, interrupted_tests, xfail_tests,
+               environment_id, environment_chain, schedule_id, commit_message, cancel_reason, cancel_message, tags,
                created_at, updated_at, started_at, ended_at
         FROM runs
         WHERE organization_id = $1 AND id = $2
@@ -200,8 +206,8 @@ func (s *Store) ListRuns(ctx context.Context, orgID uuid.UUID, limit int) ([]Run
 	const query = `
         SELECT id, organization_id, project_id, status, suite_name, suite_file_path, initiator, trigger, schedule_name, schedule_type,
                config_source, source, branch, environment, commit_sha, bundle_sha,
-               total_tests, passed_tests, failed_tests, timeout_tests, skipped_tests,
-               environment_id, schedule_id, commit_message,
+               total_tests, passed_tests, failed_tests, timeout_tests, skipped_tests, interrupted_tests, xfail_tests,
+               environment_id, environment_chain, schedule_id, commit_message, cancel_reason, cancel_message, tags,
                created_at, updated_at, started_at, ended_at
         FROM runs
         WHERE organization_id = $1
@@ -230,8 +236,8 @@ func (s *Store) ListRunsByProject(ctx context.Context, projectID uuid.UUID, limi
 	const query = `
         SELECT id, organization_id, project_id, status, suite_name, suite_file_path, initiator, trigger, schedule_name, schedule_type,
                config_source, source, branch, environment, commit_sha, bundle_sha,
-               total_tests, passed_tests, failed_tests, timeout_tests, skipped_tests,
-               environment_id, schedule_id, commit_message,
+               total_tests, passed_tests, failed_tests, timeout_tests, skipped_tests, interrupted_tests, xfail_tests,
+               environment_id, environment_chain, schedule_id, commit_message, cancel_reason, cancel_message, tags,
                created_at, updated_at, started_at, ended_at
         FROM runs
         WHERE project_id = $1
@@ -248,6 +254,47 @@ func (s *Store) ListRunsByProject(ctx context.Context, projectID uuid.UUID, limi
 	return runs, nil
 }
 
+// PruneRuns deletes runs matching the filter and returns their IDs.
+// Run tests, steps and logs are removed by ON DELETE CASCADE.
+func (s *Store) PruneRuns(ctx context.Context, filter RunPruneFilter) ([]string, error) {
+	if filter.OrganizationID == uuid.Nil {
+		return nil, errors.New("organization id required")
+	}
+	if filter.KeepLast < 0 {
+		return nil, errors.New("keep_last must not be negative")
+	}
+
+	const selection = `
+        SELECT id FROM (
+            SELECT id, status, created_at, ROW_NUMBER() OVER (ORDER BY created_at DESC) AS rn
+            FROM runs
+            WHERE organization_id = $1 AND ($2::uuid IS NULL OR project_id = $2)
+        ) ranked
+        WHERE status NOT IN ('RUNNING', 'PENDING')
+          AND ($3::timestamptz IS NULL OR created_at < $3)
+          AND ($4::int = 0 OR rn > $4)
+        ORDER BY created_at DESC
+    `
+	createdBefore := sql.NullTime{Time: filter.CreatedBefore, Valid: !filter.CreatedBefore.IsZero()}
+	args := []interface{}{filter.OrganizationID, filter.ProjectID, createdBefore, filter.KeepLast}
+
+	var ids []string
+	if filter.DryRun {
+		if err := s.db.SelectContext(ctx, &ids, selection, args...); err != nil {
+			return nil, fmt.Errorf("failed to select runs to prune: %w", err)
+		}
+	} else {
+		query := `DELETE FROM runs WHERE id IN (` + selection + `) RETURNING id`
+		if err := s.db.SelectContext(ctx, &ids, query, args...); err != nil {
+			return nil, fmt.Errorf("failed to prune runs: %w", err)
+		}
+	}
+	if ids == nil {
+		ids = []string{}
+	}
+	return ids, nil
+}
+
 // UpdateRunStatusByID updates a run's status directly by run_id, without requiring org_id.
 // This is used for DB-only completion checks when in-memory state is not available.
 func (s *Store) UpdateRunStatusByID(ctx context.Context, runID string, status string, endedAt time.Time, totals *RunTotals) error {
@@ -266,10 +313,12 @@ func (s *Store) UpdateRunStatusByID(ctx context.Context, runID string, status st
             UPDATE runs
             SET status = $2, ended_at = $3,
                 total_tests = $4, passed_tests = $5, failed_tests = $6, timeout_tests = $7,
+                skipped_tests = $8, interrupted_tests = $9, xfail_tests = $10,
                 updated_at = NOW()
             WHERE id = $1
         `
-		args = []interface{}{runID, status, endedAt, totals.Total, totals.Passed, totals.Failed, totals.Timeout}
+		args = []interface{}{runID, status, endedAt, totals.Total, totals.Passed, totals.Failed, totals.Timeout,
+			totals.Skipped, totals.Interrupted, totals.XFail}
 	} else {
 		query = `
             UPDATE runs
@@ -291,6 +340,27 @@ func (s *Store) UpdateRunStatusByID(ctx context.Context, runID string, status st
 	return nil
 }
 
+// SetRunCancelReason records why a run was cut short, without requiring org_id
+func (s *Store) SetRunCancelReason(ctx context.Context, runID, reason, message string) error {
+	if runID == "" {
+		return errors.New("run id required")
+	}
+
+	const query = `
+        UPDATE runs
+        SET cancel_reason = $2, cancel_message = NULLIF($3, ''), updated_at = NOW()
+        WHERE id = $1
+    `
+	res, err := s.db.ExecContext(ctx, query, runID, reason, message)
+	if err != nil {
+		return fmt.Errorf("failed to set run cancel reason: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
 // ListStaleRunningRuns returns runs that are stuck in RUNNING status and are older than the specified time.
 // This is used for reconciliation to clean up stale runs after engine restarts.
 func (s *Store) ListStaleRunningRuns(ctx context.Context, olderThan time.Time, limit int) ([]RunRecord, error) {
@@ -304,8 +374,8 @@ func (s *Store) ListStaleRunningRuns(ctx context.Context, olderThan time.Time, l
 	const query = `
         SELECT id, organization_id, project_id, status, suite_name, suite_file_path, initiator, trigger, schedule_name, schedule_type,
                config_source, source, branch, environment, commit_sha, bundle_sha,
-               total_tests, passed_tests, failed_tests, timeout_tests, skipped_tests,
-               environment_id, schedule_id, commit_message,
+               total_tests, passed_tests, failed_tests, timeout_tests, skipped_tests, interrupted_tests, xfail_tests,
+               environment_id, environment_chain, schedule_id, commit_message, cancel_reason, cancel_message, tags,
                created_at, updated_at, started_at, ended_at
         FROM runs
         WHERE status = 'RUNNING'
@@ -326,7 +396,7 @@ func (s *Store) ListStaleRunningRuns(ctx context.Context, olderThan time.Time, l
 // ForceCompleteStaleRunTests marks all PENDING/RUNNING run_tests for a run as complete.
 // This is used during reconciliation when the parent run is being marked as complete.
 // If status is "PASSED", only PENDING/RUNNING tests are marked as PASSED.
-// If status is "FAILED", all non-terminal tests are marked as FAILED.
+// If status is "FAILED" or "INTERRUPTED", all non-terminal tests get that status.
 func (s *Store) ForceCompleteStaleRunTests(ctx context.Context, runID string, status string) error {
 	if runID == "" {
 		return errors.New("run id required")
@@ -31,6 +31,7 @@ type SuiteRunRow struct {
 	FailedTests   int            `db:"failed_tests"`
 	TimeoutTests  int            `db:"timeout_tests"`
 	SkippedTests  int            `db:"skipped_tests"`
+	XFailTests    int            `db:"xfail_tests"`
 	CreatedAt     time.Time      `db:"created_at"`
 	StartedAt     sql.NullTime   `db:"started_at"`
 	EndedAt       sql.NullTime   `db:"ended_at"`
@@ -115,6 +116,7 @@ func (s *Store) ListRunsForSuiteGroup(ctx context.Context, orgID uuid.UUID, proj
 				CASE WHEN branch = $5 THEN 1 ELSE 0 END as is_default
 			FROM runs
 			WHERE organization_id = $1
+			  AND archived_at IS NULL
 			  AND project_id = ANY($2)
 			  AND (
 			      (suite_file_path IS NOT NULL AND lower(suite_file_path) = lower($3))
@@ -164,6 +166,7 @@ func (s *Store) ListRunsForSuiteGroup(ctx context.Context, orgID uuid.UUID, proj
 				r.failed_tests,
 				r.timeout_tests,
 				r.skipped_tests,
+				r.xfail_tests,
 				r.created_at,
 				r.started_at,
 				r.ended_at,
@@ -172,6 +175,7 @@ func (s *Store) ListRunsForSuiteGroup(ctx context.Context, orgID uuid.UUID, proj
 			FROM runs r
 			INNER JOIN top_branches tb ON r.branch = tb.branch
 			WHERE r.organization_id = $1
+			  AND r.archived_at IS NULL
 			  AND r.project_id = ANY($2)
 			  AND (
 			      (r.suite_file_path IS NOT NULL AND lower(r.suite_file_path) = lower($3))
@@ -200,6 +204,7 @@ func (s *Store) ListRunsForSuiteGroup(ctx context.Context, orgID uuid.UUID, proj
 			failed_tests,
 			timeout_tests,
 			skipped_tests,
+			xfail_tests,
 			created_at,
 			started_at,
 			ended_at
@@ -276,12 +281,14 @@ func (s *Store) ListRunsForSuiteBranch(ctx context.Context, orgID uuid.UUID, pro
 			failed_tests,
 			timeout_tests,
 			skipped_tests,
+			xfail_tests,
 			created_at,
 			started_at,
 			ended_at,
 			COUNT(*) OVER() as total_count
 		FROM runs
 		WHERE organization_id = $1
+		  AND archived_at IS NULL
 		  AND project_id = ANY($2)
 		  AND (
 		      (suite_file_path IS NOT NULL AND lower(suite_file_path) = lower($3))
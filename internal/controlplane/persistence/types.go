@@ -162,6 +162,17 @@ type RunRecord struct {
 	UpdatedAt      time.Time      `db:"updated_at"`
 	StartedAt      sql.NullTime   `db:"started_at"`
 	EndedAt        sql.NullTime   `db:"ended_at"`
+	// Slugs of the environment and the fallbacks it inherited from, in order
+	EnvironmentChain pq.StringArray `db:"environment_chain"`
+	// Why the run was cut short (user, timeout, quota or shutdown), if it was, and
+	// how many tests it stopped part way through
+	CancelReason     sql.NullString `db:"cancel_reason"`
+	CancelMessage    sql.NullString `db:"cancel_message"`
+	InterruptedTests int            `db:"interrupted_tests"`
+	// Tests marked xfail that failed, as expected
+	XFailTests int `db:"xfail_tests"`
+	// Tags of the tests the run ran
+	Tags pq.StringArray `db:"tags"`
 }
 
 // ProjectEnvironment represents a deployment environment for a project
@@ -174,6 +185,18 @@ type ProjectEnvironment struct {
 	ConfigVars map[string]interface{} `db:"-"` // Parsed from JSONB - accessed via {{ .vars.* }}
 	CreatedAt  time.Time              `db:"created_at"`
 	UpdatedAt  time.Time              `db:"updated_at"`
+	// Slug of the environment that fills in secrets and vars this one doesn't set
+	Fallback string `db:"fallback_slug"`
+}
+
+// OrgDefaultEnvironment holds the secrets and config vars shared by every
+// project in an organization. Runs use it below their project's environments.
+type OrgDefaultEnvironment struct {
+	OrganizationID uuid.UUID              `db:"organization_id"`
+	EnvSecrets     map[string]string      `db:"-"`
+	ConfigVars     map[string]interface{} `db:"-"`
+	CreatedAt      time.Time              `db:"created_at"`
+	UpdatedAt      time.Time              `db:"updated_at"`
 }
 
 // ProjectEnvironmentSelection tracks which environment a user has selected for a project
@@ -217,11 +240,22 @@ type StepSummary struct {
 
 // StepRetryPolicy mirrors dsl.RetryPolicy for storage in step summaries
 type StepRetryPolicy struct {
-	InitialInterval    string   `json:"initial_interval,omitempty"`
-	MaximumInterval    string   `json:"maximum_interval,omitempty"`
-	MaximumAttempts    int      `json:"maximum_attempts,omitempty"`
-	BackoffCoefficient float64  `json:"backoff_coefficient,omitempty"`
-	NonRetryableErrors []string `json:"non_retryable_errors,omitempty"`
+	InitialInterval    string            `json:"initial_interval,omitempty"`
+	MaximumInterval    string            `json:"maximum_interval,omitempty"`
+	MaximumAttempts    int               `json:"maximum_attempts,omitempty"`
+	BackoffCoefficient float64           `json:"backoff_coefficient,omitempty"`
+	NonRetryableErrors []string          `json:"non_retryable_errors,omitempty"`
+	Attempts           int               `json:"attempts,omitempty"`
+	Backoff            *StepRetryBackoff `json:"backoff,omitempty"`
+	On                 []string          `json:"on,omitempty"`
+}
+
+// StepRetryBackoff is the wait between the attempts of a step with retry attempts
+type StepRetryBackoff struct {
+	Delay      string   `json:"delay,omitempty"`
+	MaxDelay   string   `json:"max_delay,omitempty"`
+	Multiplier float64  `json:"multiplier,omitempty"`
+	Jitter     *float64 `json:"jitter,omitempty"`
 }
 
 // Test represents an individual test definition
@@ -341,6 +375,7 @@ type RunStep struct {
 	StartedAt        sql.NullTime           `db:"started_at"`
 	EndedAt          sql.NullTime           `db:"ended_at"`
 	DurationMs       sql.NullInt64          `db:"duration_ms"`
+	WorkerVersion    sql.NullString         `db:"worker_version"` // Worker build that ran the plugin activity
 	CreatedAt        time.Time              `db:"created_at"`
 }
 
@@ -423,10 +458,13 @@ type CITokenRecord struct {
 }
 
 type RunTotals struct {
-	Total   int
-	Passed  int
-	Failed  int
-	Timeout int
+	Total       int
+	Passed      int
+	Failed      int
+	Timeout     int
+	Skipped     int // Never started, because the run was cut short
+	Interrupted int // Stopped part way through, because the run was cut short
+	XFail       int // Marked xfail and failed, as expected
 }
 
 type RunUpdate struct {
@@ -438,6 +476,17 @@ type RunUpdate struct {
 	Totals         *RunTotals
 	CommitSHA      *string
 	BundleSHA      *string
+	CancelReason   *string // user, timeout, quota or shutdown
+	CancelMessage  string  // Set alongside CancelReason
+}
+
+// RunPruneFilter selects runs to delete. Runs that are still RUNNING or PENDING are never pruned.
+type RunPruneFilter struct {
+	OrganizationID uuid.UUID
+	ProjectID      uuid.NullUUID // Restrict to one project when valid
+	CreatedBefore  time.Time     // Zero disables the age limit
+	KeepLast       int           // Newest runs always kept; 0 disables the count limit
+	DryRun         bool          // Return matching run IDs without deleting
 }
 
 // RoleSummary aggregates user memberships and roles
@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/controlplane/persistence"
 )
 
 // ProjectInviteProject is a lightweight struct for project+role in email context
@@ -17,10 +19,21 @@ type ProjectInviteProject struct {
 	Role        string
 }
 
+// ProjectDigestEmail is the content of a project's scheduled suite health digest
+type ProjectDigestEmail struct {
+	ProjectName string
+	Frequency   string // daily or weekly
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Stats       persistence.ProjectDigestStats
+	ProjectURL  string
+}
+
 type mailer interface {
 	SendOrgVerification(ctx context.Context, toEmail, orgName, code string, expiresAt time.Time) error
 	SendOrgInvite(ctx context.Context, toEmail, orgName, code string, expiresAt time.Time, inviter string) error
 	SendProjectInvite(ctx context.Context, toEmail, orgName string, projects []ProjectInviteProject, code string, expiresAt time.Time, inviter, acceptURL string) error
+	SendProjectDigest(ctx context.Context, toEmail string, digest ProjectDigestEmail) error
 }
 
 type postmarkMailer struct {
@@ -139,6 +152,64 @@ func (m *postmarkMailer) SendProjectInvite(ctx context.Context, toEmail, orgName
 	return m.send(ctx, toEmail, subject, builder.String())
 }
 
+func (m *postmarkMailer) SendProjectDigest(ctx context.Context, toEmail string, digest ProjectDigestEmail) error {
+	period := "Weekly"
+	if digest.Frequency == persistence.DigestDaily {
+		period = "Daily"
+	}
+	subject := fmt.Sprintf("%s Rocketship digest for %s", period, strings.TrimSpace(digest.ProjectName))
+	return m.send(ctx, toEmail, subject, formatProjectDigest(digest))
+}
+
+// formatProjectDigest renders the digest as plain text
+func formatProjectDigest(digest ProjectDigestEmail) string {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "Scheduled suite health for %s, %s to %s.\n\n",
+		strings.TrimSpace(digest.ProjectName),
+		digest.PeriodStart.UTC().Format("Jan 2 15:04"), digest.PeriodEnd.UTC().Format("Jan 2 15:04 MST"))
+
+	builder.WriteString("Pass rates:\n")
+	if len(digest.Stats.PassRates) == 0 {
+		builder.WriteString("  No scheduled runs finished in this period.\n")
+	}
+	for _, rate := range digest.Stats.PassRates {
+		percent := 0
+		if rate.Runs > 0 {
+			percent = rate.Passed * 100 / rate.Runs
+		}
+		fmt.Fprintf(&builder, "  - %s: %d%% (%d of %d runs passed)\n", rate.Environment, percent, rate.Passed, rate.Runs)
+	}
+	builder.WriteString("\n")
+
+	builder.WriteString("New flaky tests:\n")
+	if len(digest.Stats.NewFlakyTests) == 0 {
+		builder.WriteString("  None.\n")
+	}
+	for _, test := range digest.Stats.NewFlakyTests {
+		fmt.Fprintf(&builder, "  - %s / %s: failed %d of %d runs\n", test.SuiteName, test.TestName, test.Failures, test.Runs)
+	}
+	builder.WriteString("\n")
+
+	if len(digest.Stats.SlowestSuites) > 0 {
+		builder.WriteString("Slowest suites:\n")
+		for _, suite := range digest.Stats.SlowestSuites {
+			avg := (time.Duration(suite.AvgDurationMs) * time.Millisecond).Round(time.Second)
+			fmt.Fprintf(&builder, "  - %s: %s on average over %d runs\n", suite.SuiteName, avg, suite.Runs)
+		}
+		builder.WriteString("\n")
+	}
+
+	if projectURL := strings.TrimSpace(digest.ProjectURL); projectURL != "" {
+		builder.WriteString("View the project: ")
+		builder.WriteString(projectURL)
+		builder.WriteString("\n\n")
+	}
+	builder.WriteString("You get this email because the project's digest lists you as a recipient. A project editor can change or turn it off in the project settings.")
+
+	return builder.String()
+}
+
 func (m *postmarkMailer) send(ctx context.Context, toEmail, subject, body string) error {
 	msg := postmarkMessage{
 		From:          m.from,
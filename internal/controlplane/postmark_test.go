@@ -9,6 +9,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/controlplane/persistence"
 )
 
 func TestNewPostmarkMailer(t *testing.T) {
@@ -374,3 +376,36 @@ func TestPostmarkMailer_ContextCancellation(t *testing.T) {
 		t.Error("expected error from cancelled context")
 	}
 }
+
+func TestFormatProjectDigest(t *testing.T) {
+	end := time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC)
+	text := formatProjectDigest(ProjectDigestEmail{
+		ProjectName: "Storefront",
+		Frequency:   persistence.DigestWeekly,
+		PeriodStart: end.Add(-7 * 24 * time.Hour),
+		PeriodEnd:   end,
+		Stats: persistence.ProjectDigestStats{
+			PassRates:     []persistence.DigestPassRate{{Environment: "staging", Runs: 20, Passed: 18}},
+			NewFlakyTests: []persistence.DigestFlakyTest{{SuiteName: "checkout", TestName: "pay with card", Runs: 14, Failures: 3}},
+			SlowestSuites: []persistence.DigestSlowSuite{{SuiteName: "search", Runs: 7, AvgDurationMs: 92400}},
+		},
+		ProjectURL: "https://app.rocketship.test/projects/p1",
+	})
+
+	for _, want := range []string{
+		"Scheduled suite health for Storefront, Mar 2 08:00 to Mar 9 08:00 UTC.",
+		"  - staging: 90% (18 of 20 runs passed)",
+		"  - checkout / pay with card: failed 3 of 14 runs",
+		"  - search: 1m32s on average over 7 runs",
+		"View the project: https://app.rocketship.test/projects/p1",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in digest:\n%s", want, text)
+		}
+	}
+
+	empty := formatProjectDigest(ProjectDigestEmail{ProjectName: "Storefront", PeriodStart: end, PeriodEnd: end})
+	if !strings.Contains(empty, "No scheduled runs finished in this period.") || strings.Contains(empty, "Slowest suites") {
+		t.Errorf("unexpected empty digest:\n%s", empty)
+	}
+}
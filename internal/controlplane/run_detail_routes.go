@@ -262,6 +262,90 @@ func (s *Server) handleRunLogs(w http.ResponseWriter, r *http.Request, principal
 	writeJSON(w, http.StatusOK, payload)
 }
 
+// handleRunArtifacts handles GET /api/runs/{runId}/artifacts
+func (s *Server) handleRunArtifacts(w http.ResponseWriter, r *http.Request, principal brokerPrincipal, runID string) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	if principal.RequiresOrgMembership() {
+		writeError(w, http.StatusForbidden, "organization membership required")
+		return
+	}
+
+	// Verify run belongs to org
+	run, err := s.store.GetRun(r.Context(), principal.OrgID, runID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "run not found")
+			return
+		}
+		log.Printf("failed to verify run: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to verify run")
+		return
+	}
+
+	// Check project access
+	if run.ProjectID.Valid {
+		canAccess, err := s.store.UserCanAccessProject(r.Context(), principal.OrgID, principal.UserID, run.ProjectID.UUID)
+		if err != nil {
+			log.Printf("failed to check project access: %v", err)
+			writeError(w, http.StatusInternalServerError, "failed to check access")
+			return
+		}
+		if !canAccess {
+			writeError(w, http.StatusNotFound, "run not found")
+			return
+		}
+	} else {
+		isOwner, err := s.store.IsOrganizationOwner(r.Context(), principal.OrgID, principal.UserID)
+		if err != nil {
+			log.Printf("failed to check org ownership: %v", err)
+			writeError(w, http.StatusInternalServerError, "failed to check access")
+			return
+		}
+		if !isOwner {
+			writeError(w, http.StatusNotFound, "run not found")
+			return
+		}
+	}
+
+	artifacts, err := s.store.ListRunArtifacts(r.Context(), runID)
+	if err != nil {
+		log.Printf("failed to list run artifacts: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to list artifacts")
+		return
+	}
+
+	payload := make([]map[string]interface{}, 0, len(artifacts))
+	for _, artifact := range artifacts {
+		item := map[string]interface{}{
+			"id":           artifact.ID.String(),
+			"run_id":       artifact.RunID,
+			"name":         artifact.Name,
+			"type":         artifact.ArtifactType,
+			"storage_path": artifact.StoragePath,
+			"created_at":   artifact.CreatedAt.Format(time.RFC3339),
+		}
+		if artifact.RunTestID.Valid {
+			item["run_test_id"] = artifact.RunTestID.UUID.String()
+		}
+		if artifact.RunStepID.Valid {
+			item["run_step_id"] = artifact.RunStepID.UUID.String()
+		}
+		if artifact.MimeType.Valid {
+			item["mime_type"] = artifact.MimeType.String
+		}
+		if artifact.SizeBytes.Valid {
+			item["size_bytes"] = artifact.SizeBytes.Int64
+		}
+		payload = append(payload, item)
+	}
+
+	writeJSON(w, http.StatusOK, payload)
+}
+
 // handleTestRunDetail handles GET /api/test-runs/{runTestId}
 func (s *Server) handleTestRunDetail(w http.ResponseWriter, r *http.Request, principal brokerPrincipal, runTestID uuid.UUID) {
 	if r.Method != http.MethodGet {
@@ -462,6 +546,8 @@ func (s *Server) handleRunRoutesDispatch(w http.ResponseWriter, r *http.Request,
 		s.handleRunTests(w, r, principal, runID)
 	case "logs":
 		s.handleRunLogs(w, r, principal, runID)
+	case "artifacts":
+		s.handleRunArtifacts(w, r, principal, runID)
 	default:
 		writeError(w, http.StatusNotFound, "resource not found")
 	}
@@ -549,6 +635,9 @@ func (s *Server) handleTestRunSteps(w http.ResponseWriter, r *http.Request, prin
 		if step.DurationMs.Valid {
 			item["duration_ms"] = step.DurationMs.Int64
 		}
+		if step.WorkerVersion.Valid {
+			item["worker_version"] = step.WorkerVersion.String
+		}
 		// Include request/response data for HTTP plugin steps (UI display)
 		if len(step.RequestData) > 0 {
 			item["request_data"] = step.RequestData
@@ -627,10 +716,18 @@ func buildRunPayload(run persistence.RunRecord) map[string]interface{} {
 		"failed_tests":  run.FailedTests,
 		"timeout_tests": run.TimeoutTests,
 		"skipped_tests": run.SkippedTests,
+		"xfail_tests":   run.XFailTests,
 		"created_at":    run.CreatedAt.Format(time.RFC3339),
 		"updated_at":    run.UpdatedAt.Format(time.RFC3339),
 	}
 
+	if run.CancelReason.Valid {
+		payload["cancel_reason"] = run.CancelReason.String
+		payload["interrupted_tests"] = run.InterruptedTests
+		if run.CancelMessage.Valid {
+			payload["cancel_message"] = run.CancelMessage.String
+		}
+	}
 	if run.ProjectID.Valid {
 		payload["project_id"] = run.ProjectID.UUID.String()
 	}
@@ -646,6 +743,12 @@ func buildRunPayload(run persistence.RunRecord) map[string]interface{} {
 	if run.EnvironmentID.Valid {
 		payload["environment_id"] = run.EnvironmentID.UUID.String()
 	}
+	if len(run.EnvironmentChain) > 1 {
+		payload["environment_chain"] = []string(run.EnvironmentChain)
+	}
+	if len(run.Tags) > 0 {
+		payload["tags"] = []string(run.Tags)
+	}
 	if run.ScheduleID.Valid {
 		payload["schedule_id"] = run.ScheduleID.UUID.String()
 	}
@@ -236,8 +236,7 @@ func (s *Scanner) findSuiteFiles(tree *GitHubTree, rocketshipDir string) []strin
 			continue
 		}
 
-		// Check if it's a YAML file
-		if strings.HasSuffix(entry.Path, ".yaml") || strings.HasSuffix(entry.Path, ".yml") {
+		if isSuiteFile(rocketshipDir, entry.Path) {
 			files = append(files, entry.Path)
 		}
 	}
@@ -245,6 +244,15 @@ func (s *Scanner) findSuiteFiles(tree *GitHubTree, rocketshipDir string) []strin
 	return files
 }
 
+// isSuiteFile reports whether a file in a .rocketship directory is a suite: a YAML
+// file outside lib/, which holds the files suites include
+func isSuiteFile(rocketshipDir, filePath string) bool {
+	if strings.HasPrefix(filePath, rocketshipDir+"/lib/") {
+		return false
+	}
+	return strings.HasSuffix(filePath, ".yaml") || strings.HasSuffix(filePath, ".yml")
+}
+
 // upsertProject creates or updates a project for a .rocketship directory
 func (s *Scanner) upsertProject(ctx context.Context, input ScanInput, repoInfo *GitHubRepoInfo, rocketshipDir string) (persistence.Project, error) {
 	// Generate a stable project name
@@ -340,6 +348,14 @@ func (s *Scanner) processSuiteFile(ctx context.Context, input ScanInput, project
 		return 0, 0, fmt.Errorf("failed to fetch file: %w", err)
 	}
 
+	// Inline the files the suite includes and its data files, so scheduled runs don't need the repo
+	content, _, err = dsl.ResolveIncludes(content, filePath, func(path string) ([]byte, error) {
+		return s.github.GetFileContent(ctx, input.InstallationID, owner, repo, path, fetchRef)
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to resolve includes: %w", err)
+	}
+
 	// Parse YAML
 	config, err := dsl.ParseYAML(content)
 	if err != nil {
@@ -401,6 +417,16 @@ func (s *Scanner) processSuiteFile(ctx context.Context, input ScanInput, project
 					MaximumAttempts:    step.Retry.MaximumAttempts,
 					BackoffCoefficient: step.Retry.BackoffCoefficient,
 					NonRetryableErrors: step.Retry.NonRetryableErrors,
+					Attempts:           step.Retry.Attempts,
+					On:                 step.Retry.On,
+				}
+				if backoff := step.Retry.Backoff; backoff != nil {
+					summary.Retry.Backoff = &persistence.StepRetryBackoff{
+						Delay:      backoff.Delay,
+						MaxDelay:   backoff.MaxDelay,
+						Multiplier: backoff.Multiplier,
+						Jitter:     backoff.Jitter,
+					}
 				}
 			}
 			stepSummaries = append(stepSummaries, summary)
@@ -592,13 +618,13 @@ func (s *Scanner) ScanPullRequestDelta(ctx context.Context, input ScanInput, prN
 
 		// Process each file based on its status and type
 		for _, file := range files {
-			// Only process YAML files for suite operations
-			isYAML := strings.HasSuffix(file.Filename, ".yaml") || strings.HasSuffix(file.Filename, ".yml")
+			// Only process suite files for suite operations
+			isSuite := isSuiteFile(rocketshipDir, file.Filename)
 
 			switch file.Status {
 			case "removed":
 				// File was deleted - deactivate the suite for this branch
-				if isYAML {
+				if isSuite {
 					slog.Info("scanner: deactivating removed suite",
 						"file", file.Filename,
 						"ref", input.SourceRef.Ref,
@@ -615,7 +641,7 @@ func (s *Scanner) ScanPullRequestDelta(ctx context.Context, input ScanInput, prN
 
 			case "renamed":
 				// File was renamed - deactivate old path, process new path
-				if isYAML {
+				if isSuite {
 					// Deactivate the old file path
 					if file.PreviousFilename != "" {
 						slog.Info("scanner: deactivating renamed suite (old path)",
@@ -647,7 +673,7 @@ func (s *Scanner) ScanPullRequestDelta(ctx context.Context, input ScanInput, prN
 
 			case "added", "modified":
 				// Process added/modified YAML files
-				if isYAML {
+				if isSuite {
 					suitesCreated, testsCreated, err := s.processSuiteFile(ctx, input, project, file.Filename, fetchRef, owner, repo)
 					if err != nil {
 						errMsg := fmt.Sprintf("failed to process suite file %s: %v", file.Filename, err)
@@ -661,7 +687,7 @@ func (s *Scanner) ScanPullRequestDelta(ctx context.Context, input ScanInput, prN
 
 			default:
 				// Handle other statuses (copied, changed) as modified
-				if isYAML {
+				if isSuite {
 					suitesCreated, testsCreated, err := s.processSuiteFile(ctx, input, project, file.Filename, fetchRef, owner, repo)
 					if err != nil {
 						errMsg := fmt.Sprintf("failed to process suite file %s: %v", file.Filename, err)
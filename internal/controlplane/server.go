@@ -24,6 +24,7 @@ type Server struct {
 	authSessions map[string]authSession
 	mu           sync.Mutex
 	now          func() time.Time
+	ciVerifier   *ciTokenVerifier
 }
 
 func (s *Server) Close() error {
@@ -113,6 +114,11 @@ func newServerWithComponents(cfg Config, signer *Signer, github githubProvider,
 		authSessions: make(map[string]authSession),
 		now:          time.Now,
 	}
+	ciAudience := cfg.CIOIDC.Audience
+	if ciAudience == "" {
+		ciAudience = cfg.Audience
+	}
+	srv.ciVerifier = newCITokenVerifier(ciProvidersFromConfig(cfg.CIOIDC), ciAudience, nil)
 	srv.routes()
 	return srv, nil
 }
@@ -125,7 +131,7 @@ func (s *Server) routes() {
 	s.mux.HandleFunc("/authorize", s.handleAuthorize)
 	s.mux.HandleFunc("/callback", s.handleCallback)
 
-	// Token endpoints (support both device_code and authorization_code grants)
+	// Token endpoints (device_code, authorization_code, refresh_token and CI token exchange grants)
 	s.mux.HandleFunc("/token", s.handleToken)
 	s.mux.HandleFunc("/refresh", s.handleRefreshEndpoint)
 	s.mux.HandleFunc("/logout", s.handleLogout)
@@ -24,6 +24,10 @@ import (
 type stubMailer struct {
 	verifications []struct{ email, org, code string }
 	invites       []struct{ email, org, code string }
+	digests       []struct {
+		email  string
+		digest ProjectDigestEmail
+	}
 }
 
 func (m *stubMailer) SendOrgVerification(_ context.Context, email, org, code string, _ time.Time) error {
@@ -41,6 +45,14 @@ func (m *stubMailer) SendProjectInvite(_ context.Context, email, org string, _ [
 	return nil
 }
 
+func (m *stubMailer) SendProjectDigest(_ context.Context, email string, digest ProjectDigestEmail) error {
+	m.digests = append(m.digests, struct {
+		email  string
+		digest ProjectDigestEmail
+	}{email: email, digest: digest})
+	return nil
+}
+
 type fakeGitHub struct {
 	deviceResp DeviceCodeResponse
 	tokenResp  TokenResponse
@@ -89,6 +101,9 @@ type fakeStore struct {
 	registrations  map[uuid.UUID]persistence.OrganizationRegistration
 	invites        map[uuid.UUID]persistence.OrganizationInvite
 	slugMap        map[string]uuid.UUID
+	digests        map[uuid.UUID]persistence.ProjectDigest
+	digestStats    persistence.ProjectDigestStats
+	repoProjects   []persistence.Project
 }
 
 func newFakeStore() *fakeStore {
@@ -454,6 +469,16 @@ func (f *fakeStore) UpdateProjectsDefaultBranchHeadForRepo(_ context.Context, _
 	return 0, nil
 }
 
+func (f *fakeStore) ListActiveProjectsByRepoURL(_ context.Context, repoURL string) ([]persistence.Project, error) {
+	var projects []persistence.Project
+	for _, p := range f.repoProjects {
+		if strings.EqualFold(p.RepoURL, repoURL) {
+			projects = append(projects, p)
+		}
+	}
+	return projects, nil
+}
+
 // Environment methods
 func (f *fakeStore) CreateEnvironment(_ context.Context, env persistence.ProjectEnvironment) (persistence.ProjectEnvironment, error) {
 	return env, nil
@@ -471,6 +496,10 @@ func (f *fakeStore) ListEnvironments(_ context.Context, _ uuid.UUID) ([]persiste
 	return nil, nil
 }
 
+func (f *fakeStore) ListEnvironmentsPage(_ context.Context, _ uuid.UUID, _ persistence.ListQuery) (persistence.ListPage[persistence.ProjectEnvironment], error) {
+	return persistence.ListPage[persistence.ProjectEnvironment]{}, nil
+}
+
 func (f *fakeStore) UpdateEnvironment(_ context.Context, env persistence.ProjectEnvironment) (persistence.ProjectEnvironment, error) {
 	return env, nil
 }
@@ -479,6 +508,18 @@ func (f *fakeStore) DeleteEnvironment(_ context.Context, _, _ uuid.UUID) error {
 	return nil
 }
 
+func (f *fakeStore) GetOrgDefaultEnvironment(_ context.Context, _ uuid.UUID) (persistence.OrgDefaultEnvironment, error) {
+	return persistence.OrgDefaultEnvironment{}, sql.ErrNoRows
+}
+
+func (f *fakeStore) SetOrgDefaultEnvironment(_ context.Context, env persistence.OrgDefaultEnvironment) (persistence.OrgDefaultEnvironment, error) {
+	return env, nil
+}
+
+func (f *fakeStore) DeleteOrgDefaultEnvironment(_ context.Context, _ uuid.UUID) error {
+	return sql.ErrNoRows
+}
+
 // Suite methods
 func (f *fakeStore) UpsertSuite(_ context.Context, suite persistence.Suite) (persistence.Suite, error) {
 	return suite, nil
@@ -496,6 +537,10 @@ func (f *fakeStore) ListSuitesForProjectCanonical(_ context.Context, _ uuid.UUID
 	return nil, nil
 }
 
+func (f *fakeStore) ListSuitesForProjectCanonicalPage(_ context.Context, _ uuid.UUID, _ persistence.ListQuery) (persistence.ListPage[persistence.CanonicalSuiteRow], error) {
+	return persistence.ListPage[persistence.CanonicalSuiteRow]{}, nil
+}
+
 func (f *fakeStore) UpsertTest(_ context.Context, test persistence.Test) (persistence.Test, error) {
 	return test, nil
 }
@@ -708,8 +753,8 @@ func (f *fakeStore) UserCanAccessProject(_ context.Context, orgID, userID, proje
 	return false, nil
 }
 
-func (f *fakeStore) ListProjectSummariesForUser(_ context.Context, _, _ uuid.UUID) ([]persistence.ProjectSummary, error) {
-	return nil, nil
+func (f *fakeStore) ListProjectSummariesForUser(_ context.Context, _, _ uuid.UUID, _ persistence.ListQuery) (persistence.ListPage[persistence.ProjectSummary], error) {
+	return persistence.ListPage[persistence.ProjectSummary]{}, nil
 }
 
 // Profile hydration methods
@@ -773,6 +818,10 @@ func (f *fakeStore) DeactivateSuitesForRepoAndSourceRef(_ context.Context, _ uui
 	return 0, nil
 }
 
+func (f *fakeStore) DeletePreviewEnvironmentsForRepoAndPR(_ context.Context, _ uuid.UUID, _ string, _ int) (int, int, error) {
+	return 0, 0, nil
+}
+
 func (f *fakeStore) FindDefaultBranchProject(_ context.Context, _ uuid.UUID, _ string, _ []string) (persistence.Project, bool, error) {
 	return persistence.Project{}, false, nil
 }
@@ -802,6 +851,10 @@ func (f *fakeStore) ListRunLogs(_ context.Context, _ string, _ int) ([]persisten
 	return nil, nil
 }
 
+func (f *fakeStore) ListRunArtifacts(_ context.Context, _ string) ([]persistence.RunArtifact, error) {
+	return nil, nil
+}
+
 func (f *fakeStore) GetRunTestWithRun(_ context.Context, _ uuid.UUID, _ uuid.UUID) (persistence.RunTestWithRun, error) {
 	return persistence.RunTestWithRun{}, sql.ErrNoRows
 }
@@ -900,6 +953,61 @@ func (f *fakeStore) DeleteProjectSchedule(_ context.Context, _ uuid.UUID) error
 	return nil
 }
 
+// Project digests
+func (f *fakeStore) GetProjectDigest(_ context.Context, projectID uuid.UUID) (persistence.ProjectDigest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	digest, ok := f.digests[projectID]
+	if !ok {
+		return persistence.ProjectDigest{}, sql.ErrNoRows
+	}
+	return digest, nil
+}
+
+func (f *fakeStore) UpsertProjectDigest(_ context.Context, digest persistence.ProjectDigest) (persistence.ProjectDigest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.digests == nil {
+		f.digests = make(map[uuid.UUID]persistence.ProjectDigest)
+	}
+	now := time.Now().UTC()
+	digest.NextSendAt = now.Add(persistence.DigestPeriod(digest.Frequency))
+	digest.CreatedAt = now
+	digest.UpdatedAt = now
+	f.digests[digest.ProjectID] = digest
+	return digest, nil
+}
+
+func (f *fakeStore) DeleteProjectDigest(_ context.Context, projectID uuid.UUID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.digests[projectID]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(f.digests, projectID)
+	return nil
+}
+
+func (f *fakeStore) ClaimDueProjectDigests(_ context.Context, now time.Time, limit int) ([]persistence.ProjectDigest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var claimed []persistence.ProjectDigest
+	for id, digest := range f.digests {
+		if len(claimed) == limit || digest.NextSendAt.After(now) {
+			continue
+		}
+		digest.LastSentAt = sql.NullTime{Time: now, Valid: true}
+		digest.NextSendAt = now.Add(persistence.DigestPeriod(digest.Frequency))
+		f.digests[id] = digest
+		claimed = append(claimed, digest)
+	}
+	return claimed, nil
+}
+
+func (f *fakeStore) GetProjectDigestStats(_ context.Context, _ uuid.UUID, _, _ time.Time) (persistence.ProjectDigestStats, error) {
+	return f.digestStats, nil
+}
+
 // Suite schedule management (overrides)
 func (f *fakeStore) GetSuiteByID(_ context.Context, _ uuid.UUID) (persistence.Suite, error) {
 	return persistence.Suite{}, nil
@@ -71,20 +71,26 @@ type dataStore interface {
 	ProjectNameExists(ctx context.Context, orgID uuid.UUID, name, sourceRef string) (bool, error)
 	UpdateProjectDefaultBranchHead(ctx context.Context, projectID uuid.UUID, sha, message string, at time.Time) error
 	UpdateProjectsDefaultBranchHeadForRepo(ctx context.Context, orgID uuid.UUID, repoURL, defaultBranch, sha, message string, at time.Time) (int64, error)
+	ListActiveProjectsByRepoURL(ctx context.Context, repoURL string) ([]persistence.Project, error)
 
 	// Environment management
 	CreateEnvironment(ctx context.Context, env persistence.ProjectEnvironment) (persistence.ProjectEnvironment, error)
 	GetEnvironment(ctx context.Context, projectID, envID uuid.UUID) (persistence.ProjectEnvironment, error)
 	GetEnvironmentBySlug(ctx context.Context, projectID uuid.UUID, slug string) (persistence.ProjectEnvironment, error)
 	ListEnvironments(ctx context.Context, projectID uuid.UUID) ([]persistence.ProjectEnvironment, error)
+	ListEnvironmentsPage(ctx context.Context, projectID uuid.UUID, q persistence.ListQuery) (persistence.ListPage[persistence.ProjectEnvironment], error)
 	UpdateEnvironment(ctx context.Context, env persistence.ProjectEnvironment) (persistence.ProjectEnvironment, error)
 	DeleteEnvironment(ctx context.Context, projectID, envID uuid.UUID) error
+	GetOrgDefaultEnvironment(ctx context.Context, orgID uuid.UUID) (persistence.OrgDefaultEnvironment, error)
+	SetOrgDefaultEnvironment(ctx context.Context, env persistence.OrgDefaultEnvironment) (persistence.OrgDefaultEnvironment, error)
+	DeleteOrgDefaultEnvironment(ctx context.Context, orgID uuid.UUID) error
 
 	// Suite and test management
 	UpsertSuite(ctx context.Context, suite persistence.Suite) (persistence.Suite, error)
 	GetSuiteByName(ctx context.Context, projectID uuid.UUID, name, sourceRef string) (persistence.Suite, bool, error)
 	ListSuites(ctx context.Context, projectID uuid.UUID) ([]persistence.Suite, error)
 	ListSuitesForProjectCanonical(ctx context.Context, projectID uuid.UUID) ([]persistence.CanonicalSuiteRow, error)
+	ListSuitesForProjectCanonicalPage(ctx context.Context, projectID uuid.UUID, q persistence.ListQuery) (persistence.ListPage[persistence.CanonicalSuiteRow], error)
 	UpsertTest(ctx context.Context, test persistence.Test) (persistence.Test, error)
 
 	// Schedule management
@@ -97,6 +103,13 @@ type dataStore interface {
 	UpdateProjectSchedule(ctx context.Context, scheduleID uuid.UUID, input persistence.UpdateProjectScheduleInput) (persistence.ProjectSchedule, error)
 	DeleteProjectSchedule(ctx context.Context, scheduleID uuid.UUID) error
 
+	// Project digests
+	GetProjectDigest(ctx context.Context, projectID uuid.UUID) (persistence.ProjectDigest, error)
+	UpsertProjectDigest(ctx context.Context, digest persistence.ProjectDigest) (persistence.ProjectDigest, error)
+	DeleteProjectDigest(ctx context.Context, projectID uuid.UUID) error
+	ClaimDueProjectDigests(ctx context.Context, now time.Time, limit int) ([]persistence.ProjectDigest, error)
+	GetProjectDigestStats(ctx context.Context, projectID uuid.UUID, since, until time.Time) (persistence.ProjectDigestStats, error)
+
 	// Suite schedule management (overrides)
 	GetSuiteByID(ctx context.Context, suiteID uuid.UUID) (persistence.Suite, error)
 	ListSuiteSchedulesBySuiteWithEnv(ctx context.Context, suiteID uuid.UUID) ([]persistence.SuiteScheduleWithEnv, error)
@@ -127,7 +140,7 @@ type dataStore interface {
 
 	// Console hydration queries
 	ListProjectSummariesForOrg(ctx context.Context, orgID uuid.UUID) ([]persistence.ProjectSummary, error)
-	ListProjectSummariesForUser(ctx context.Context, orgID, userID uuid.UUID) ([]persistence.ProjectSummary, error)
+	ListProjectSummariesForUser(ctx context.Context, orgID, userID uuid.UUID, q persistence.ListQuery) (persistence.ListPage[persistence.ProjectSummary], error)
 	GetProjectWithOrgCheck(ctx context.Context, orgID, projectID uuid.UUID) (persistence.Project, error)
 	GetLatestScanForProject(ctx context.Context, orgID uuid.UUID, repoURL, sourceRef string) (*persistence.ScanSummary, error)
 	ListSuitesForOrg(ctx context.Context, orgID uuid.UUID, limit int) ([]persistence.SuiteActivityRow, error)
@@ -153,6 +166,7 @@ type dataStore interface {
 	GetRun(ctx context.Context, orgID uuid.UUID, runID string) (persistence.RunRecord, error)
 	ListRunTests(ctx context.Context, runID string) ([]persistence.RunTest, error)
 	ListRunLogs(ctx context.Context, runID string, limit int) ([]persistence.RunLog, error)
+	ListRunArtifacts(ctx context.Context, runID string) ([]persistence.RunArtifact, error)
 	GetRunTestWithRun(ctx context.Context, orgID uuid.UUID, runTestID uuid.UUID) (persistence.RunTestWithRun, error)
 	ListRunLogsByTest(ctx context.Context, runTestID uuid.UUID, limit int) ([]persistence.RunLog, error)
 	ListRunSteps(ctx context.Context, runTestID uuid.UUID) ([]persistence.RunStep, error)
@@ -163,6 +177,7 @@ type dataStore interface {
 
 	// Suite lifecycle management (PR close)
 	DeactivateSuitesForRepoAndSourceRef(ctx context.Context, orgID uuid.UUID, repoURL, sourceRef, reason string) (int, error)
+	DeletePreviewEnvironmentsForRepoAndPR(ctx context.Context, orgID uuid.UUID, repoURL string, prNumber int) (int, int, error)
 
 	// Project lookup for PR delta scanning
 	FindDefaultBranchProject(ctx context.Context, orgID uuid.UUID, repoURL string, pathScope []string) (persistence.Project, bool, error)
@@ -254,4 +269,6 @@ type oauthTokenResponse struct {
 	RefreshToken string `json:"refresh_token,omitempty"`
 	Scope        string `json:"scope,omitempty"`
 	IDToken      string `json:"id_token,omitempty"`
+	// IssuedTokenType is set on RFC 8693 token exchange responses
+	IssuedTokenType string `json:"issued_token_type,omitempty"`
 }
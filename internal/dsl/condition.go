@@ -0,0 +1,287 @@
+package dsl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// EvaluateCondition evaluates a step's when or unless expression once its
+// templates are rendered, e.g. `eu == "eu"`. It supports the comparisons ==,
+// !=, <, <=, > and >=, combined with &&, || and !, and grouped in parentheses.
+// Operands are quoted strings or bare words; numbers compare as numbers. A
+// lone operand is true unless it's empty, false, 0, null or <no value>.
+func EvaluateCondition(expr string) (bool, error) {
+	tokens, err := tokenizeCondition(expr)
+	if err != nil {
+		return false, err
+	}
+	if len(tokens) == 0 {
+		return false, fmt.Errorf("empty condition")
+	}
+	p := &conditionParser{tokens: tokens}
+	value, err := p.or()
+	if err != nil {
+		return false, err
+	}
+	if p.pos < len(p.tokens) {
+		return false, fmt.Errorf("unexpected %q in condition %q", p.tokens[p.pos].text, expr)
+	}
+	return value.truthy(), nil
+}
+
+type conditionTokenKind int
+
+const (
+	tokenOperand conditionTokenKind = iota
+	tokenOperator
+	tokenOpen
+	tokenClose
+)
+
+type conditionToken struct {
+	kind   conditionTokenKind
+	text   string
+	quoted bool
+}
+
+// noValue is how a template renders a missing value
+const noValue = "<no value>"
+
+// conditionOperators are matched longest first
+var conditionOperators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!"}
+
+// tokenizeCondition splits an expression into operators, parentheses and
+// operands. Bare operands run up to the next operator, so rendered values with
+// spaces in them needn't be quoted.
+func tokenizeCondition(expr string) ([]conditionToken, error) {
+	var tokens []conditionToken
+	var bare strings.Builder
+	flush := func() {
+		if text := strings.TrimSpace(bare.String()); text != "" {
+			tokens = append(tokens, conditionToken{kind: tokenOperand, text: text})
+		}
+		bare.Reset()
+	}
+
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == '"' || c == '\'':
+			flush()
+			end := i + 1
+			for end < len(expr) && expr[end] != c {
+				if expr[end] == '\\' && c == '"' {
+					end++
+				}
+				end++
+			}
+			if end >= len(expr) {
+				return nil, fmt.Errorf("unterminated string in condition %q", expr)
+			}
+			text := expr[i+1 : end]
+			if c == '"' {
+				unquoted, err := strconv.Unquote(expr[i : end+1])
+				if err != nil {
+					return nil, fmt.Errorf("invalid string %s in condition %q", expr[i:end+1], expr)
+				}
+				text = unquoted
+			}
+			tokens = append(tokens, conditionToken{kind: tokenOperand, text: text, quoted: true})
+			i = end + 1
+			continue
+		case c == '(':
+			flush()
+			tokens = append(tokens, conditionToken{kind: tokenOpen, text: "("})
+			i++
+			continue
+		case c == ')':
+			flush()
+			tokens = append(tokens, conditionToken{kind: tokenClose, text: ")"})
+			i++
+			continue
+		}
+
+		// Templates render missing values as <no value>, which isn't a comparison
+		if strings.HasPrefix(expr[i:], noValue) {
+			bare.WriteString(noValue)
+			i += len(noValue)
+			continue
+		}
+
+		matched := ""
+		for _, op := range conditionOperators {
+			if strings.HasPrefix(expr[i:], op) {
+				matched = op
+				break
+			}
+		}
+		if matched == "" {
+			bare.WriteByte(c)
+			i++
+			continue
+		}
+		flush()
+		tokens = append(tokens, conditionToken{kind: tokenOperator, text: matched})
+		i += len(matched)
+	}
+	flush()
+	return tokens, nil
+}
+
+// conditionValue is an operand, or the result of a comparison
+type conditionValue struct {
+	text   string
+	quoted bool
+}
+
+func boolValue(b bool) conditionValue {
+	return conditionValue{text: strconv.FormatBool(b)}
+}
+
+func (v conditionValue) truthy() bool {
+	if v.quoted {
+		return v.text != ""
+	}
+	switch strings.ToLower(v.text) {
+	case "", "false", "0", "null", "nil", noValue:
+		return false
+	}
+	return true
+}
+
+type conditionParser struct {
+	tokens []conditionToken
+	pos    int
+}
+
+func (p *conditionParser) peek(kind conditionTokenKind, text string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == kind && p.tokens[p.pos].text == text
+}
+
+func (p *conditionParser) or() (conditionValue, error) {
+	left, err := p.and()
+	if err != nil {
+		return left, err
+	}
+	for p.peek(tokenOperator, "||") {
+		p.pos++
+		right, err := p.and()
+		if err != nil {
+			return right, err
+		}
+		left = boolValue(left.truthy() || right.truthy())
+	}
+	return left, nil
+}
+
+func (p *conditionParser) and() (conditionValue, error) {
+	left, err := p.not()
+	if err != nil {
+		return left, err
+	}
+	for p.peek(tokenOperator, "&&") {
+		p.pos++
+		right, err := p.not()
+		if err != nil {
+			return right, err
+		}
+		left = boolValue(left.truthy() && right.truthy())
+	}
+	return left, nil
+}
+
+func (p *conditionParser) not() (conditionValue, error) {
+	if p.peek(tokenOperator, "!") {
+		p.pos++
+		value, err := p.not()
+		if err != nil {
+			return value, err
+		}
+		return boolValue(!value.truthy()), nil
+	}
+	return p.comparison()
+}
+
+func (p *conditionParser) comparison() (conditionValue, error) {
+	left, err := p.operand()
+	if err != nil {
+		return left, err
+	}
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokenOperator {
+		return left, nil
+	}
+	op := p.tokens[p.pos].text
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=":
+	default:
+		return left, nil
+	}
+	p.pos++
+	right, err := p.operand()
+	if err != nil {
+		return right, err
+	}
+	result, err := compareCondition(left, op, right)
+	if err != nil {
+		return conditionValue{}, err
+	}
+	return boolValue(result), nil
+}
+
+func (p *conditionParser) operand() (conditionValue, error) {
+	if p.pos >= len(p.tokens) {
+		return conditionValue{}, fmt.Errorf("condition ends where a value was expected")
+	}
+	token := p.tokens[p.pos]
+	switch token.kind {
+	case tokenOperand:
+		p.pos++
+		return conditionValue{text: token.text, quoted: token.quoted}, nil
+	case tokenOpen:
+		p.pos++
+		value, err := p.or()
+		if err != nil {
+			return value, err
+		}
+		if !p.peek(tokenClose, ")") {
+			return value, fmt.Errorf("missing ) in condition")
+		}
+		p.pos++
+		return value, nil
+	}
+	return conditionValue{}, fmt.Errorf("unexpected %q where a value was expected", token.text)
+}
+
+// compareCondition compares two operands, as numbers when both are numbers
+func compareCondition(left conditionValue, op string, right conditionValue) (bool, error) {
+	l, lErr := strconv.ParseFloat(left.text, 64)
+	r, rErr := strconv.ParseFloat(right.text, 64)
+	numeric := lErr == nil && rErr == nil
+
+	switch op {
+	case "==":
+		if numeric {
+			return l == r, nil
+		}
+		return left.text == right.text, nil
+	case "!=":
+		if numeric {
+			return l != r, nil
+		}
+		return left.text != right.text, nil
+	}
+	if !numeric {
+		return false, fmt.Errorf("cannot compare %q %s %q: %s needs numbers", left.text, op, right.text, op)
+	}
+	switch op {
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	default:
+		return l >= r, nil
+	}
+}
@@ -0,0 +1,60 @@
+package dsl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateCondition(t *testing.T) {
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`eu == "eu"`, true},
+		{`us == "eu"`, false},
+		{`"us" != "eu"`, true},
+		{`'eu' == eu`, true},
+		{`10 > 9`, true},
+		{`10 > 9.5 && 1e3 == 1000`, true},
+		{`010 == 10`, true},
+		{`3 <= 2 || 2 >= 2`, true},
+		{`staging == prod || staging == staging`, true},
+		{`!(a == a)`, false},
+		{`!(a == b) && (c == c || d == e)`, true},
+		{`us west == "us west"`, true},
+		{`"a && b" == "a && b"`, true},
+		{`true`, true},
+		{`yes`, true},
+		{`false`, false},
+		{`0`, false},
+		{`<no value>`, false},
+		{`<no value> == eu`, false},
+		{`""`, false},
+		{`"false"`, true},
+		{`!false`, true},
+	}
+	for _, tt := range tests {
+		got, err := EvaluateCondition(tt.expr)
+		if assert.NoError(t, err, tt.expr) {
+			assert.Equal(t, tt.want, got, tt.expr)
+		}
+	}
+
+	for expr, errMsg := range map[string]string{
+		"":            "empty condition",
+		"   ":         "empty condition",
+		`a == `:       "value was expected",
+		`(a == a`:     "missing )",
+		`a == a)`:     `unexpected ")"`,
+		`"eu == eu`:   "unterminated string",
+		`us > 3`:      "needs numbers",
+		`a == b == c`: `unexpected "=="`,
+		`&& a`:        "value was expected",
+	} {
+		_, err := EvaluateCondition(expr)
+		if assert.Error(t, err, expr) {
+			assert.Contains(t, err.Error(), errMsg, expr)
+		}
+	}
+}
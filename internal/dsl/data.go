@@ -0,0 +1,128 @@
+package dsl
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// maxDataRows caps how many tests a single data source may expand into
+const maxDataRows = 1000
+
+// TestData runs a test once per row of a CSV or JSON file, or of rows written
+// inline. Templates read the row's fields as {{ row.* }}.
+type TestData struct {
+	// File is relative to the suite. It's read into Rows by ResolveIncludes
+	// before the suite is parsed.
+	File string                   `json:"file" yaml:"file,omitempty"`
+	Rows []map[string]interface{} `json:"rows" yaml:"rows,omitempty"`
+	// Name is the field whose value names each row's test; rows are numbered when empty
+	Name string `json:"name" yaml:"name,omitempty"`
+}
+
+// expandDataRows replaces every test that has data with one test per row, in
+// place of the original. Each test is named after its row, e.g. "Sign up
+// [alice]" when the data names rows by a field, or "Sign up [row 2]".
+func expandDataRows(config *RocketshipConfig) error {
+	expanded := make([]Test, 0, len(config.Tests))
+	for _, test := range config.Tests {
+		if test.Data == nil {
+			expanded = append(expanded, test)
+			continue
+		}
+		if test.Data.File != "" {
+			return fmt.Errorf("test %q: data file %s was not read; suites with data files must be run with the CLI or scanned from their repository", test.Name, test.Data.File)
+		}
+		rows := test.Data.Rows
+		if len(rows) == 0 {
+			return fmt.Errorf("test %q: data has no rows", test.Name)
+		}
+		if len(rows) > maxDataRows {
+			return fmt.Errorf("test %q: data has more than %d rows", test.Name, maxDataRows)
+		}
+
+		named := map[string]int{}
+		for i, row := range rows {
+			label := fmt.Sprintf("row %d", i+1)
+			if test.Data.Name != "" {
+				value, ok := row[test.Data.Name]
+				if !ok || value == nil || fmt.Sprintf("%v", value) == "" {
+					return fmt.Errorf("test %q: data row %d has no %s", test.Name, i+1, test.Data.Name)
+				}
+				label = fmt.Sprintf("%v", value)
+				if other, ok := named[label]; ok {
+					return fmt.Errorf("test %q: data rows %d and %d are both named %q", test.Name, other+1, i+1, label)
+				}
+				named[label] = i
+			}
+
+			rowTest := copyTest(test)
+			rowTest.Name = fmt.Sprintf("%s [%s]", test.Name, label)
+			rowTest.Data = nil
+			rowTest.DataRow = deepCopyMap(row)
+			expanded = append(expanded, rowTest)
+		}
+	}
+	config.Tests = expanded
+	return nil
+}
+
+// parseDataFile reads the rows of a CSV or JSON data file, by its extension. A
+// CSV file's first line names the fields, and its values are strings; a JSON
+// file holds an array of objects.
+func parseDataFile(file string, data []byte) ([]interface{}, error) {
+	switch strings.ToLower(path.Ext(file)) {
+	case ".csv":
+		return parseCSVRows(data)
+	case ".json":
+		var rows []interface{}
+		if err := json.Unmarshal(data, &rows); err != nil {
+			return nil, fmt.Errorf("must be a JSON array of objects: %w", err)
+		}
+		for i, row := range rows {
+			if _, ok := row.(map[string]interface{}); !ok {
+				return nil, fmt.Errorf("row %d is not an object", i+1)
+			}
+		}
+		return rows, nil
+	}
+	return nil, fmt.Errorf("data files must be .csv or .json")
+}
+
+func parseCSVRows(data []byte) ([]interface{}, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("no header line")
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i, field := range header {
+		header[i] = strings.TrimSpace(field)
+		if header[i] == "" {
+			return nil, fmt.Errorf("column %d has no name", i+1)
+		}
+	}
+
+	var rows []interface{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := make(map[string]interface{}, len(header))
+		for i, field := range header {
+			row[field] = record[i]
+		}
+		rows = append(rows, row)
+	}
+}
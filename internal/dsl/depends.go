@@ -0,0 +1,84 @@
+package dsl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// resolveDependencies replaces the names in each test's depends_on with the
+// tests they stand for once matrices and data are expanded: the test of that
+// name, or else every test expanded from it, e.g. "Orders [region=eu]". It
+// rejects names that match no test and tests that end up waiting on themselves.
+func resolveDependencies(config *RocketshipConfig) error {
+	for i := range config.Tests {
+		test := &config.Tests[i]
+		if len(test.DependsOn) == 0 {
+			continue
+		}
+		resolved := make([]string, 0, len(test.DependsOn))
+		seen := map[string]bool{}
+		for _, name := range test.DependsOn {
+			matches := dependencyMatches(config.Tests, name)
+			if len(matches) == 0 {
+				return fmt.Errorf("test %q depends on %q, which is not a test in this suite", test.Name, name)
+			}
+			for _, match := range matches {
+				if match == test.Name {
+					return fmt.Errorf("test %q depends on itself", test.Name)
+				}
+				if !seen[match] {
+					seen[match] = true
+					resolved = append(resolved, match)
+				}
+			}
+		}
+		test.DependsOn = resolved
+	}
+
+	dependsOn := make(map[string][]string, len(config.Tests))
+	for _, test := range config.Tests {
+		dependsOn[test.Name] = append(dependsOn[test.Name], test.DependsOn...)
+	}
+	visited := map[string]bool{}
+	for _, test := range config.Tests {
+		if err := findDependencyCycle(dependsOn, test.Name, nil, visited); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dependencyMatches returns the names of the tests a depends_on name stands for
+func dependencyMatches(tests []Test, name string) []string {
+	var expansions []string
+	for _, test := range tests {
+		if test.Name == name {
+			return []string{name}
+		}
+		if strings.HasPrefix(test.Name, name+" [") {
+			expansions = append(expansions, test.Name)
+		}
+	}
+	return expansions
+}
+
+// findDependencyCycle walks the tests name depends on. stack holds the chain of
+// tests that led to it, and visited the tests already known to be free of cycles.
+func findDependencyCycle(dependsOn map[string][]string, name string, stack []string, visited map[string]bool) error {
+	for i, seen := range stack {
+		if seen == name {
+			return fmt.Errorf("depends_on cycle: %s -> %s", strings.Join(stack[i:], " -> "), name)
+		}
+	}
+	if visited[name] {
+		return nil
+	}
+	stack = append(stack, name)
+	for _, dependency := range dependsOn[name] {
+		if err := findDependencyCycle(dependsOn, dependency, stack, visited); err != nil {
+			return err
+		}
+	}
+	visited[name] = true
+	return nil
+}
@@ -0,0 +1,172 @@
+package dsl
+
+import (
+	"regexp"
+	"sort"
+)
+
+// DSL features that run inside worker workflows. Workers advertise the ones they
+// understand, so a suite that needs a newer worker is rejected before it starts.
+// Add a feature here when its workflow support ships.
+const (
+	FeatureTestInit          = "test_init"
+	FeatureTestCleanup       = "test_cleanup"
+	FeatureStepRetry         = "step_retry"
+	FeatureStepRetryAttempts = "step_retry_attempts"
+	FeatureContinueOnFailure = "continue_on_failure"
+	FeatureStepConditions    = "step_conditions"
+	FeatureForEach           = "for_each"
+	FeatureTestMatrix        = "test_matrix"
+	FeatureTestData          = "test_data"
+	FeatureParallelSteps     = "parallel_steps"
+	FeatureSoftAssertions    = "soft_assertions"
+	FeatureTemplateDefault   = "template_default"
+	FeatureRequiredEnv       = "required_env"
+)
+
+// defaultFuncRegex matches a template calling default, e.g. {{ .env.REGION | default "eu" }}
+var defaultFuncRegex = regexp.MustCompile(`(\||\{\{-?)\s*default\s`)
+
+// SupportedFeatures are the DSL features this build's workflows understand
+var SupportedFeatures = []string{
+	FeatureTestInit,
+	FeatureTestCleanup,
+	FeatureStepRetry,
+	FeatureStepRetryAttempts,
+	FeatureContinueOnFailure,
+	FeatureStepConditions,
+	FeatureForEach,
+	FeatureTestMatrix,
+	FeatureTestData,
+	FeatureParallelSteps,
+	FeatureSoftAssertions,
+	FeatureTemplateDefault,
+	FeatureRequiredEnv,
+}
+
+// RequiredCapabilities returns the plugins and DSL features a suite uses, sorted
+func RequiredCapabilities(config RocketshipConfig) (plugins []string, features []string) {
+	pluginSet := map[string]bool{}
+	featureSet := map[string]bool{}
+
+	addSteps := func(steps []Step) {
+		for _, step := range steps {
+			if step.Plugin != "" {
+				pluginSet[step.Plugin] = true
+			}
+			if step.Retry != nil {
+				featureSet[FeatureStepRetry] = true
+				if step.Retry.Attempts > 0 {
+					featureSet[FeatureStepRetryAttempts] = true
+				}
+			}
+			if step.ContinueOnFailure {
+				featureSet[FeatureContinueOnFailure] = true
+			}
+			if step.When != "" || step.Unless != "" {
+				featureSet[FeatureStepConditions] = true
+			}
+			if step.ForEach != nil {
+				featureSet[FeatureForEach] = true
+			}
+			if step.Group != nil {
+				featureSet[FeatureParallelSteps] = true
+			}
+			if step.Soft {
+				featureSet[FeatureSoftAssertions] = true
+			}
+			for _, assertion := range step.Assertions {
+				if soft, _ := assertion["soft"].(bool); soft {
+					featureSet[FeatureSoftAssertions] = true
+				}
+			}
+			if usesDefaultFunc(step) {
+				featureSet[FeatureTemplateDefault] = true
+			}
+		}
+	}
+	addCleanup := func(cleanup *CleanupSpec) {
+		if cleanup != nil {
+			addSteps(cleanup.Always)
+			addSteps(cleanup.OnFailure)
+		}
+	}
+
+	addSteps(config.Init)
+	addCleanup(config.Cleanup)
+	for _, test := range config.Tests {
+		if len(test.Init) > 0 {
+			featureSet[FeatureTestInit] = true
+		}
+		if test.Cleanup != nil && (len(test.Cleanup.Always) > 0 || len(test.Cleanup.OnFailure) > 0) {
+			featureSet[FeatureTestCleanup] = true
+		}
+		if test.ForEach != nil {
+			featureSet[FeatureForEach] = true
+		}
+		if len(test.MatrixParams) > 0 {
+			featureSet[FeatureTestMatrix] = true
+		}
+		if len(test.DataRow) > 0 {
+			featureSet[FeatureTestData] = true
+		}
+		if len(test.RequiredEnv) > 0 {
+			featureSet[FeatureRequiredEnv] = true
+		}
+		addSteps(test.Init)
+		addSteps(test.Steps)
+		addCleanup(test.Cleanup)
+	}
+
+	return sortedKeys(pluginSet), sortedKeys(featureSet)
+}
+
+// usesDefaultFunc reports whether any of the step's templates call default
+func usesDefaultFunc(step Step) bool {
+	values := []interface{}{step.When, step.Unless, step.Config}
+	if step.ForEach != nil {
+		values = append(values, step.ForEach.Items)
+	}
+	for _, assertion := range step.Assertions {
+		values = append(values, assertion)
+	}
+	for _, save := range step.Save {
+		values = append(values, save)
+	}
+	for _, value := range values {
+		if matchesTemplate(value, defaultFuncRegex) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesTemplate reports whether any string in value matches re
+func matchesTemplate(value interface{}, re *regexp.Regexp) bool {
+	switch val := value.(type) {
+	case string:
+		return re.MatchString(val)
+	case map[string]interface{}:
+		for _, item := range val {
+			if matchesTemplate(item, re) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range val {
+			if matchesTemplate(item, re) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
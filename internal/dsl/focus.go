@@ -0,0 +1,48 @@
+package dsl
+
+// HasFocusedTests reports whether any test sets only
+func HasFocusedTests(tests []Test) bool {
+	for _, test := range tests {
+		if test.Only {
+			return true
+		}
+	}
+	return false
+}
+
+// FocusTests drops every test that doesn't set only, keeping the tests the
+// focused ones depend on, since those would never start otherwise. A suite
+// without focused tests is left as it is.
+func FocusTests(config *RocketshipConfig) {
+	if !HasFocusedTests(config.Tests) {
+		return
+	}
+	dependsOn := make(map[string][]string, len(config.Tests))
+	for _, test := range config.Tests {
+		dependsOn[test.Name] = append(dependsOn[test.Name], test.DependsOn...)
+	}
+	focused := map[string]bool{}
+	var focus func(name string)
+	focus = func(name string) {
+		if focused[name] {
+			return
+		}
+		focused[name] = true
+		for _, dependency := range dependsOn[name] {
+			focus(dependency)
+		}
+	}
+	for _, test := range config.Tests {
+		if test.Only {
+			focus(test.Name)
+		}
+	}
+
+	kept := make([]Test, 0, len(focused))
+	for _, test := range config.Tests {
+		if focused[test.Name] {
+			kept = append(kept, test)
+		}
+	}
+	config.Tests = kept
+}
@@ -0,0 +1,321 @@
+package dsl
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// includableKeys are the top-level keys an included file may set
+var includableKeys = map[string]bool{
+	"include":        true,
+	"vars":           true,
+	"step_templates": true,
+}
+
+// ResolveIncludes inlines the files a suite includes, the data files its tests
+// read, the fixtures its sql steps load and the output schemas of its agent
+// steps, so the suite no longer depends on them. Paths are relative to the
+// file that names them, and load reads a file by its slash-separated path.
+// Included files may set vars and step_templates and include files of their
+// own. Vars of later files win over earlier ones and the suite's own win over
+// all; a step template may only be defined once. It returns the suite with
+// everything inlined, and the files it read. A suite that reads no other files
+// is returned as it is.
+func ResolveIncludes(data []byte, file string, load func(path string) ([]byte, error)) ([]byte, []string, error) {
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	_, includes := doc["include"]
+	if !includes && !readsDataFiles(doc) && !readsStepFiles(doc) {
+		return data, nil, nil
+	}
+
+	r := &includeResolver{load: load, loaded: map[string]bool{}, defined: map[string]string{}}
+	if err := r.resolve(doc, file, []string{file}); err != nil {
+		return nil, nil, err
+	}
+	if err := r.readData(doc, file); err != nil {
+		return nil, nil, err
+	}
+	resolved, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal YAML: %w", err)
+	}
+	return resolved, r.files, nil
+}
+
+type includeResolver struct {
+	load    func(path string) ([]byte, error)
+	files   []string
+	loaded  map[string]bool
+	defined map[string]string // Step template name → file that defines it
+}
+
+// resolve inlines the files doc includes into doc. stack is the chain of files
+// that led to it, to catch cycles.
+func (r *includeResolver) resolve(doc map[string]interface{}, file string, stack []string) error {
+	paths, err := includePaths(doc["include"], file)
+	if err != nil {
+		return err
+	}
+	delete(doc, "include")
+	// Before included step templates are merged in, so the files each file's
+	// steps read are found relative to that file
+	if err := r.readStepFiles(doc, file); err != nil {
+		return err
+	}
+
+	vars := map[string]interface{}{}
+	templates := map[string]interface{}{}
+	for _, included := range paths {
+		for _, seen := range stack {
+			if seen == included {
+				return fmt.Errorf("include cycle: %s -> %s", strings.Join(stack, " -> "), included)
+			}
+		}
+		// A file reached along two paths is only inlined once
+		if r.loaded[included] {
+			continue
+		}
+		r.loaded[included] = true
+		r.files = append(r.files, included)
+
+		data, err := r.load(included)
+		if err != nil {
+			return fmt.Errorf("%s: failed to read include %s: %w", file, included, err)
+		}
+		var includedDoc map[string]interface{}
+		if err := yaml.Unmarshal(data, &includedDoc); err != nil {
+			return fmt.Errorf("%s: failed to parse YAML: %w", included, err)
+		}
+		for key := range includedDoc {
+			if !includableKeys[key] {
+				return fmt.Errorf("%s: included files may only set include, vars and step_templates, not %s", included, key)
+			}
+		}
+		if err := r.resolve(includedDoc, included, append(stack[:len(stack):len(stack)], included)); err != nil {
+			return err
+		}
+
+		if includedVars, ok := includedDoc["vars"].(map[string]interface{}); ok {
+			vars = MergeInterfaceMaps(vars, includedVars)
+		}
+		if includedTemplates, ok := includedDoc["step_templates"].(map[string]interface{}); ok {
+			for name, template := range includedTemplates {
+				templates[name] = template
+			}
+		}
+	}
+
+	if own, ok := doc["step_templates"].(map[string]interface{}); ok {
+		if err := r.define(own, file); err != nil {
+			return err
+		}
+		for name, template := range own {
+			templates[name] = template
+		}
+	}
+	if len(templates) > 0 {
+		doc["step_templates"] = templates
+	}
+	if own, ok := doc["vars"].(map[string]interface{}); ok {
+		vars = MergeInterfaceMaps(vars, own)
+	}
+	if len(vars) > 0 {
+		doc["vars"] = vars
+	}
+	return nil
+}
+
+// define records where each of a file's step templates is defined
+func (r *includeResolver) define(templates map[string]interface{}, file string) error {
+	for name := range templates {
+		if other, ok := r.defined[name]; ok {
+			return fmt.Errorf("step template %q is defined in both %s and %s", name, other, file)
+		}
+		r.defined[name] = file
+	}
+	return nil
+}
+
+// readsDataFiles reports whether any of a suite's tests reads its data from a file
+func readsDataFiles(doc map[string]interface{}) bool {
+	tests, _ := doc["tests"].([]interface{})
+	for _, test := range tests {
+		test, _ := test.(map[string]interface{})
+		data, _ := test["data"].(map[string]interface{})
+		if _, ok := data["file"]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// readData replaces the data file of each test that has one with the file's rows
+func (r *includeResolver) readData(doc map[string]interface{}, file string) error {
+	tests, _ := doc["tests"].([]interface{})
+	for _, test := range tests {
+		test, _ := test.(map[string]interface{})
+		data, _ := test["data"].(map[string]interface{})
+		name, ok := data["file"].(string)
+		if !ok {
+			continue
+		}
+		if _, ok := data["rows"]; ok {
+			return fmt.Errorf("%s: test %q: data sets both file and rows", file, test["name"])
+		}
+		if path.IsAbs(name) {
+			return fmt.Errorf("%s: data file %s must be relative to the file", file, name)
+		}
+		dataFile := path.Join(path.Dir(file), name)
+		content, err := r.load(dataFile)
+		if err != nil {
+			return fmt.Errorf("%s: failed to read data file %s: %w", file, dataFile, err)
+		}
+		rows, err := parseDataFile(dataFile, content)
+		if err != nil {
+			return fmt.Errorf("%s: data file %s: %w", file, dataFile, err)
+		}
+		if !r.loaded[dataFile] {
+			r.loaded[dataFile] = true
+			r.files = append(r.files, dataFile)
+		}
+		delete(data, "file")
+		data["rows"] = rows
+	}
+	return nil
+}
+
+// stepFile is a file a step reads that is inlined when the suite is loaded, so
+// workers don't have to share the suite's filesystem
+type stepFile struct {
+	kind   string // What the file is, for errors
+	name   string // Its path, relative to the file that names it
+	inline func(content []byte) error
+}
+
+// stepFileOf returns the file step reads that hasn't been inlined yet: a sql
+// fixture or an agent's output_schema. It returns nil if there's none.
+func stepFileOf(step map[string]interface{}) *stepFile {
+	plugin, _ := step["plugin"].(string)
+	config, _ := step["config"].(map[string]interface{})
+	switch plugin {
+	case "sql":
+		// The file stays as the fixture's name
+		fixture, _ := config["load_fixture"].(map[string]interface{})
+		name, ok := fixture["file"].(string)
+		if _, inlined := fixture["content"]; !ok || inlined {
+			return nil
+		}
+		return &stepFile{kind: "fixture", name: name, inline: func(content []byte) error {
+			fixture["content"] = string(content)
+			return nil
+		}}
+	case "agent":
+		name, ok := config["output_schema"].(string)
+		if !ok {
+			return nil
+		}
+		return &stepFile{kind: "output_schema", name: name, inline: func(content []byte) error {
+			var schema map[string]interface{}
+			if err := json.Unmarshal(content, &schema); err != nil {
+				return fmt.Errorf("not a JSON object: %w", err)
+			}
+			config["output_schema"] = schema
+			return nil
+		}}
+	}
+	return nil
+}
+
+// readsStepFiles reports whether any step in value reads a file that hasn't
+// been inlined yet
+func readsStepFiles(value interface{}) bool {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if readsStepFiles(item) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		if stepFileOf(v) != nil {
+			return true
+		}
+		for _, item := range v {
+			if readsStepFiles(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readStepFiles inlines the files the steps in value read
+func (r *includeResolver) readStepFiles(value interface{}, file string) error {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if err := r.readStepFiles(item, file); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		if ref := stepFileOf(v); ref != nil {
+			if path.IsAbs(ref.name) {
+				return fmt.Errorf("%s: %s %s must be relative to the file", file, ref.kind, ref.name)
+			}
+			if strings.Contains(ref.name, "{{") {
+				return fmt.Errorf("%s: %s %s can't use templates, since it's read when the suite is loaded", file, ref.kind, ref.name)
+			}
+			stepFile := path.Join(path.Dir(file), ref.name)
+			content, err := r.load(stepFile)
+			if err != nil {
+				return fmt.Errorf("%s: failed to read %s %s: %w", file, ref.kind, stepFile, err)
+			}
+			if err := ref.inline(content); err != nil {
+				return fmt.Errorf("%s: %s %s is invalid: %w", file, ref.kind, stepFile, err)
+			}
+			if !r.loaded[stepFile] {
+				r.loaded[stepFile] = true
+				r.files = append(r.files, stepFile)
+			}
+			return nil
+		}
+		for _, item := range v {
+			if err := r.readStepFiles(item, file); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// includePaths returns the paths a file includes, relative to the directory the
+// file is in
+func includePaths(include interface{}, file string) ([]string, error) {
+	if include == nil {
+		return nil, nil
+	}
+	list, ok := include.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: include must be a list of file paths", file)
+	}
+	paths := make([]string, len(list))
+	for i, item := range list {
+		p, ok := item.(string)
+		if !ok || strings.TrimSpace(p) == "" {
+			return nil, fmt.Errorf("%s: include must be a list of file paths", file)
+		}
+		if path.IsAbs(p) {
+			return nil, fmt.Errorf("%s: include %s must be relative to the file", file, p)
+		}
+		paths[i] = path.Join(path.Dir(file), p)
+	}
+	return paths, nil
+}
@@ -0,0 +1,296 @@
+package dsl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveIncludes(t *testing.T) {
+	files := map[string]string{
+		".rocketship/lib/auth.yaml": `
+include: [common.yaml]
+vars:
+  base_url: "https://auth.example.com"
+step_templates:
+  login:
+    params:
+      username: null
+    steps:
+      - name: "Request token"
+        plugin: http
+        config:
+          method: POST
+          url: "{{ .vars.base_url }}/login"
+          body: '{"user": "{{ .params.username }}"}'
+`,
+		".rocketship/lib/common.yaml": `
+vars:
+  base_url: "https://example.com"
+  timeout: 30
+step_templates:
+  ping:
+    steps:
+      - name: "Ping"
+        plugin: http
+        config:
+          method: GET
+          url: "{{ .vars.base_url }}/health"
+`,
+	}
+	load := func(path string) ([]byte, error) {
+		data, ok := files[path]
+		if !ok {
+			return nil, fmt.Errorf("file not found")
+		}
+		return []byte(data), nil
+	}
+
+	suite := []byte(`
+name: "Orders"
+include:
+  - lib/auth.yaml
+  - lib/common.yaml
+vars:
+  base_url: "https://orders.example.com"
+tests:
+  - name: "Orders"
+    steps:
+      - name: "Log in"
+        use: login
+        with:
+          username: alice
+      - name: "Health"
+        use: ping
+`)
+	resolved, read, err := ResolveIncludes(suite, ".rocketship/orders.yaml", load)
+	require.NoError(t, err)
+	assert.Equal(t, []string{".rocketship/lib/auth.yaml", ".rocketship/lib/common.yaml"}, read)
+
+	config, err := ParseYAML(resolved)
+	require.NoError(t, err)
+	assert.Empty(t, config.Include)
+	assert.Equal(t, map[string]interface{}{"base_url": "https://orders.example.com", "timeout": 30}, config.Vars)
+	require.Len(t, config.Tests[0].Steps, 2)
+	assert.Equal(t, "Log in: Request token", config.Tests[0].Steps[0].Name)
+	assert.Equal(t, "Health: Ping", config.Tests[0].Steps[1].Name)
+
+	// Suites that include nothing come back untouched
+	plain := []byte("name: \"Plain\"\ntests: []\n")
+	resolved, read, err = ResolveIncludes(plain, ".rocketship/plain.yaml", load)
+	require.NoError(t, err)
+	assert.Equal(t, plain, resolved)
+	assert.Empty(t, read)
+
+	for name, tc := range map[string]struct {
+		files map[string]string
+		err   string
+	}{
+		"missing file": {
+			files: map[string]string{},
+			err:   "failed to read include .rocketship/lib/auth.yaml",
+		},
+		"cycle": {
+			files: map[string]string{
+				".rocketship/lib/auth.yaml": "include: [../orders.yaml]\n",
+			},
+			err: "include cycle: .rocketship/orders.yaml -> .rocketship/lib/auth.yaml -> .rocketship/orders.yaml",
+		},
+		"suite keys": {
+			files: map[string]string{
+				".rocketship/lib/auth.yaml": "tests: []\n",
+			},
+			err: "included files may only set include, vars and step_templates, not tests",
+		},
+		"duplicate template": {
+			files: map[string]string{
+				".rocketship/lib/auth.yaml": "step_templates:\n  login:\n    steps: []\n",
+			},
+			err: `step template "login" is defined in both .rocketship/lib/auth.yaml and .rocketship/orders.yaml`,
+		},
+	} {
+		load := func(path string) ([]byte, error) {
+			data, ok := tc.files[path]
+			if !ok {
+				return nil, fmt.Errorf("file not found")
+			}
+			return []byte(data), nil
+		}
+		_, _, err := ResolveIncludes([]byte(`
+name: "Orders"
+include: [lib/auth.yaml]
+step_templates:
+  login:
+    steps: []
+tests: []
+`), ".rocketship/orders.yaml", load)
+		assert.ErrorContains(t, err, tc.err, name)
+	}
+}
+
+func TestResolveIncludes_DataFiles(t *testing.T) {
+	files := map[string]string{
+		".rocketship/data/users.csv":  "username, plan\nalice, free\n\"bob, jr\", pro\n",
+		".rocketship/data/users.json": `[{"username": "carol", "age": 41, "address": {"country": "NL"}}]`,
+		".rocketship/data/ragged.csv": "username,plan\nalice\n",
+		".rocketship/data/users.txt":  "alice\n",
+		".rocketship/data/list.json":  `["alice"]`,
+	}
+	load := func(path string) ([]byte, error) {
+		data, ok := files[path]
+		if !ok {
+			return nil, fmt.Errorf("file not found")
+		}
+		return []byte(data), nil
+	}
+	suite := func(file string) []byte {
+		return []byte(`
+name: "Sign up"
+tests:
+  - name: "Sign up"
+    data:
+      file: ` + file + `
+    steps:
+      - name: "Log"
+        plugin: log
+        config:
+          message: "{{ row.username }}"
+`)
+	}
+
+	resolved, read, err := ResolveIncludes(suite("data/users.csv"), ".rocketship/signup.yaml", load)
+	require.NoError(t, err)
+	assert.Equal(t, []string{".rocketship/data/users.csv"}, read)
+	config, err := ParseYAML(resolved)
+	require.NoError(t, err)
+	require.Len(t, config.Tests, 2)
+	assert.Equal(t, "Sign up [row 1]", config.Tests[0].Name)
+	assert.Equal(t, map[string]interface{}{"username": "alice", "plan": "free"}, config.Tests[0].DataRow)
+	assert.Equal(t, map[string]interface{}{"username": "bob, jr", "plan": "pro"}, config.Tests[1].DataRow)
+
+	resolved, _, err = ResolveIncludes(suite("data/users.json"), ".rocketship/signup.yaml", load)
+	require.NoError(t, err)
+	config, err = ParseYAML(resolved)
+	require.NoError(t, err)
+	require.Len(t, config.Tests, 1)
+	assert.Equal(t, map[string]interface{}{"username": "carol", "age": 41, "address": map[string]interface{}{"country": "NL"}}, config.Tests[0].DataRow)
+
+	for file, message := range map[string]string{
+		"data/missing.csv": "failed to read data file .rocketship/data/missing.csv",
+		"data/ragged.csv":  "wrong number of fields",
+		"data/users.txt":   "data files must be .csv or .json",
+		"data/list.json":   "row 1 is not an object",
+	} {
+		_, _, err := ResolveIncludes(suite(file), ".rocketship/signup.yaml", load)
+		assert.ErrorContains(t, err, message, file)
+	}
+}
+
+func TestResolveIncludes_Fixtures(t *testing.T) {
+	files := map[string]string{
+		".rocketship/fixtures/users.csv":      "id,name\n1,alice\n",
+		".rocketship/lib/fixtures/plans.json": `[{"id": 1}]`,
+		".rocketship/lib/db.yaml": `
+step_templates:
+  seed_plans:
+    steps:
+      - name: "Seed plans"
+        plugin: sql
+        config:
+          driver: postgres
+          dsn: "{{ .vars.dsn }}"
+          load_fixture:
+            file: fixtures/plans.json
+            table: plans
+`,
+	}
+	load := func(path string) ([]byte, error) {
+		data, ok := files[path]
+		if !ok {
+			return nil, fmt.Errorf("file not found")
+		}
+		return []byte(data), nil
+	}
+	suite := func(file string) []byte {
+		return []byte(`
+name: "Seed"
+include: [lib/db.yaml]
+tests:
+  - name: "Seed"
+    steps:
+      - name: "Seed users"
+        plugin: sql
+        config:
+          driver: postgres
+          dsn: "{{ .vars.dsn }}"
+          load_fixture:
+            file: ` + file + `
+            table: users
+`)
+	}
+
+	resolved, read, err := ResolveIncludes(suite("fixtures/users.csv"), ".rocketship/seed.yaml", load)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{".rocketship/lib/db.yaml", ".rocketship/lib/fixtures/plans.json", ".rocketship/fixtures/users.csv"}, read)
+	config, err := ParseYAML(resolved)
+	require.NoError(t, err)
+	fixture := config.Tests[0].Steps[0].Config["load_fixture"].(map[string]interface{})
+	assert.Equal(t, "fixtures/users.csv", fixture["file"])
+	assert.Equal(t, "id,name\n1,alice\n", fixture["content"])
+	template := config.StepTemplates["seed_plans"].Steps[0].Config["load_fixture"].(map[string]interface{})
+	assert.Equal(t, `[{"id": 1}]`, template["content"])
+
+	for file, message := range map[string]string{
+		"fixtures/missing.csv":  "failed to read fixture .rocketship/fixtures/missing.csv",
+		"/srv/users.csv":        "must be relative to the file",
+		`"{{ .vars.fixture }}"`: "can't use templates",
+	} {
+		_, _, err := ResolveIncludes(suite(file), ".rocketship/seed.yaml", load)
+		assert.ErrorContains(t, err, message, file)
+	}
+}
+
+func TestResolveIncludes_OutputSchemas(t *testing.T) {
+	files := map[string]string{
+		".rocketship/schemas/ticket.json": `{"type": "object", "required": ["id"]}`,
+		".rocketship/schemas/list.json":   `["id"]`,
+	}
+	load := func(path string) ([]byte, error) {
+		data, ok := files[path]
+		if !ok {
+			return nil, fmt.Errorf("file not found")
+		}
+		return []byte(data), nil
+	}
+	suite := func(file string) []byte {
+		return []byte(`
+name: "Triage"
+tests:
+  - name: "Triage"
+    steps:
+      - name: "Triage the newest ticket"
+        plugin: agent
+        config:
+          prompt: "Triage the newest ticket"
+          output_schema: ` + file + `
+`)
+	}
+
+	resolved, read, err := ResolveIncludes(suite("schemas/ticket.json"), ".rocketship/triage.yaml", load)
+	require.NoError(t, err)
+	assert.Equal(t, []string{".rocketship/schemas/ticket.json"}, read)
+	config, err := ParseYAML(resolved)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"type": "object", "required": []interface{}{"id"}}, config.Tests[0].Steps[0].Config["output_schema"])
+
+	for file, message := range map[string]string{
+		"schemas/missing.json": "failed to read output_schema .rocketship/schemas/missing.json",
+		"schemas/list.json":    "not a JSON object",
+		"/srv/ticket.json":     "must be relative to the file",
+	} {
+		_, _, err := ResolveIncludes(suite(file), ".rocketship/triage.yaml", load)
+		assert.ErrorContains(t, err, message, file)
+	}
+}
@@ -0,0 +1,106 @@
+package dsl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxMatrixCombinations caps how many tests a single matrix may expand into
+const maxMatrixCombinations = 256
+
+// expandMatrices replaces every test that has a matrix with one test per
+// combination of its parameters, in place of the original. Parameters vary in
+// the order of their names, the last fastest, and each test is named after its
+// combination, e.g. "Orders [api_version=v1, region=eu]".
+func expandMatrices(config *RocketshipConfig) error {
+	expanded := make([]Test, 0, len(config.Tests))
+	for _, test := range config.Tests {
+		if len(test.Matrix) == 0 {
+			expanded = append(expanded, test)
+			continue
+		}
+
+		names := make([]string, 0, len(test.Matrix))
+		total := 1
+		for name, values := range test.Matrix {
+			if len(values) == 0 {
+				return fmt.Errorf("test %q: matrix parameter %q has no values", test.Name, name)
+			}
+			names = append(names, name)
+			total *= len(values)
+			if total > maxMatrixCombinations {
+				return fmt.Errorf("test %q: matrix expands into more than %d tests", test.Name, maxMatrixCombinations)
+			}
+		}
+		sort.Strings(names)
+
+		for i := 0; i < total; i++ {
+			params := make(map[string]interface{}, len(names))
+			labels := make([]string, len(names))
+			rest := i
+			for n := len(names) - 1; n >= 0; n-- {
+				values := test.Matrix[names[n]]
+				value := values[rest%len(values)]
+				rest /= len(values)
+				params[names[n]] = value
+				labels[n] = fmt.Sprintf("%s=%v", names[n], value)
+			}
+
+			combination := copyTest(test)
+			combination.Name = fmt.Sprintf("%s [%s]", test.Name, strings.Join(labels, ", "))
+			combination.Matrix = nil
+			combination.MatrixParams = params
+			expanded = append(expanded, combination)
+		}
+	}
+	config.Tests = expanded
+	return nil
+}
+
+// copyTest copies a test deeply enough that later processing, such as the
+// browser session IDs injected into step config, doesn't leak between copies
+func copyTest(test Test) Test {
+	copied := test
+	copied.Init = copySteps(test.Init)
+	copied.Steps = copySteps(test.Steps)
+	if test.Cleanup != nil {
+		copied.Cleanup = &CleanupSpec{
+			Always:    copySteps(test.Cleanup.Always),
+			OnFailure: copySteps(test.Cleanup.OnFailure),
+		}
+	}
+	return copied
+}
+
+func copySteps(steps []Step) []Step {
+	if steps == nil {
+		return nil
+	}
+	copied := make([]Step, len(steps))
+	for i, step := range steps {
+		if step.Config != nil {
+			step.Config = deepCopyMap(step.Config)
+		}
+		step.Assertions = copyMaps(step.Assertions)
+		step.Save = copyMaps(step.Save)
+		if step.Parallel != nil {
+			parallel := *step.Parallel
+			parallel.Steps = copySteps(step.Parallel.Steps)
+			step.Parallel = &parallel
+		}
+		copied[i] = step
+	}
+	return copied
+}
+
+func copyMaps(maps []map[string]interface{}) []map[string]interface{} {
+	if maps == nil {
+		return nil
+	}
+	copied := make([]map[string]interface{}, len(maps))
+	for i, m := range maps {
+		copied[i] = deepCopyMap(m)
+	}
+	return copied
+}
@@ -0,0 +1,53 @@
+package dsl
+
+import "fmt"
+
+// expandParallelSteps replaces every parallel step with the steps of its group,
+// each marked with the group so the workflow runs them together. The steps are
+// named after the parallel step, e.g. "Check services: Orders API", and inherit
+// its when, unless, continue_on_failure and soft.
+func expandParallelSteps(config *RocketshipConfig) error {
+	return expandStepLists(config, expandParallelGroups)
+}
+
+func expandParallelGroups(steps []Step) ([]Step, error) {
+	found := false
+	for _, step := range steps {
+		found = found || step.Parallel != nil
+	}
+	if !found {
+		return steps, nil
+	}
+
+	expanded := make([]Step, 0, len(steps))
+	id := 0
+	for _, step := range steps {
+		if step.Parallel == nil {
+			expanded = append(expanded, step)
+			continue
+		}
+		if step.Plugin != "" || step.Config != nil || len(step.Assertions) > 0 || len(step.Save) > 0 ||
+			step.Retry != nil || step.ForEach != nil || step.Use != "" {
+			return nil, fmt.Errorf("step %q: a parallel step may only set name, parallel, when, unless, continue_on_failure and soft", step.Name)
+		}
+		if len(step.Parallel.Steps) == 0 {
+			return nil, fmt.Errorf("step %q: parallel has no steps", step.Name)
+		}
+
+		id++
+		group := &StepGroup{ID: id, Name: step.Name, MaxParallel: step.Parallel.MaxParallel}
+		for _, inner := range step.Parallel.Steps {
+			if inner.Parallel != nil {
+				return nil, fmt.Errorf("step %q: parallel steps can't be nested", step.Name)
+			}
+			inner.Name = step.Name + ": " + inner.Name
+			inner.When = joinConditions(step.When, inner.When, "&&")
+			inner.Unless = joinConditions(step.Unless, inner.Unless, "||")
+			inner.ContinueOnFailure = inner.ContinueOnFailure || step.ContinueOnFailure
+			inner.Soft = inner.Soft || step.Soft
+			inner.Group = group
+			expanded = append(expanded, inner)
+		}
+	}
+	return expanded, nil
+}
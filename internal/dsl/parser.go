@@ -15,11 +15,25 @@ var schemaFS embed.FS
 type RocketshipConfig struct {
 	Name        string                 `json:"name" yaml:"name"`
 	Description string                 `json:"description" yaml:"description"`
+	Tags        []string               `json:"tags" yaml:"tags,omitempty"`
 	Vars        map[string]interface{} `json:"vars" yaml:"vars,omitempty"`
 	OpenAPI     *OpenAPISuiteConfig    `json:"openapi" yaml:"openapi,omitempty"`
+	HTTP        *HTTPSuiteConfig       `json:"http" yaml:"http,omitempty"`
+	Worker      *WorkerRequirements    `json:"worker" yaml:"worker,omitempty"`
+	Env         *EnvRequirements       `json:"env" yaml:"env,omitempty"`
+	Budget      *SuiteBudget           `json:"budget" yaml:"budget,omitempty"`
 	Init        []Step                 `json:"init" yaml:"init,omitempty"`
 	Tests       []Test                 `json:"tests" yaml:"tests"`
 	Cleanup     *CleanupSpec           `json:"cleanup" yaml:"cleanup,omitempty"`
+	// EachInit and EachCleanup run in every test's workflow: EachInit before the
+	// test's init steps, EachCleanup after its cleanup. They're merged into the
+	// tests when the suite is parsed.
+	EachInit    []Step `json:"each_init" yaml:"each_init,omitempty"`
+	EachCleanup []Step `json:"each_cleanup" yaml:"each_cleanup,omitempty"`
+	// Include names files whose vars and step templates the suite uses. They're
+	// inlined by ResolveIncludes before the suite is parsed.
+	Include       []string                `json:"include" yaml:"include,omitempty"`
+	StepTemplates map[string]StepTemplate `json:"step_templates" yaml:"step_templates,omitempty"`
 }
 
 // OpenAPISuiteConfig represents OpenAPI settings applied to all HTTP steps unless overridden per step
@@ -31,11 +45,100 @@ type OpenAPISuiteConfig struct {
 	CacheTTL         string `json:"cache_ttl" yaml:"cache_ttl,omitempty"`
 }
 
+// HTTPSuiteConfig holds defaults for every http step in the suite. They're merged
+// into the steps when the suite is parsed, so the workers see them as step config.
+type HTTPSuiteConfig struct {
+	Transport map[string]interface{} `json:"transport" yaml:"transport,omitempty"`
+}
+
+// WorkerRequirements pins the worker build a suite needs. The engine checks every
+// connected worker before starting the run.
+type WorkerRequirements struct {
+	MinVersion string `json:"min_version" yaml:"min_version,omitempty"`
+	Image      string `json:"image" yaml:"image,omitempty"`
+}
+
+// EnvRequirements lists the env vars a suite can't run without. They're copied
+// into every test when the suite is parsed, and the worker checks them before
+// the test's first step, against what its templates see: the worker's
+// environment and the run's project environment.
+type EnvRequirements struct {
+	Required []string `json:"required" yaml:"required,omitempty"`
+}
+
+// Names returns the required env vars; a suite without env requires none
+func (r *EnvRequirements) Names() []string {
+	if r == nil {
+		return nil
+	}
+	return r.Required
+}
+
+// SuiteBudget caps how long a run may take and how many tests it may contain. The
+// engine stops runs that go over and marks them BUDGET_EXCEEDED.
+type SuiteBudget struct {
+	MaxDuration string `json:"max_duration" yaml:"max_duration,omitempty"`
+	MaxTests    int    `json:"max_tests" yaml:"max_tests,omitempty"`
+}
+
 type Test struct {
 	Name    string       `json:"name" yaml:"name"`
 	Init    []Step       `json:"init" yaml:"init,omitempty"`
 	Steps   []Step       `json:"steps" yaml:"steps"`
 	Cleanup *CleanupSpec `json:"cleanup" yaml:"cleanup,omitempty"`
+	// Tags select the test with --tags. Once the suite is parsed they include
+	// the suite's tags.
+	Tags []string `json:"tags" yaml:"tags,omitempty"`
+	// CookieJar shares cookies set by http responses with the test's later http steps
+	CookieJar bool `json:"cookie_jar" yaml:"cookie_jar,omitempty"`
+	// HAR records the test's http requests and responses as a HAR artifact
+	HAR bool `json:"har" yaml:"har,omitempty"`
+	// ForEach runs the test's steps once per item, between init and cleanup
+	ForEach *ForEach `json:"for_each" yaml:"for_each,omitempty"`
+	// Matrix expands the test into one test per combination of its parameters
+	// when the suite is parsed
+	Matrix map[string][]interface{} `json:"matrix" yaml:"matrix,omitempty"`
+	// MatrixParams is the combination an expanded test runs with, {{ matrix.* }} in templates
+	MatrixParams map[string]interface{} `json:"matrix_params" yaml:"-"`
+	// Data expands the test into one test per row when the suite is parsed
+	Data *TestData `json:"data" yaml:"data,omitempty"`
+	// DataRow is the row an expanded test runs with, {{ row.* }} in templates
+	DataRow map[string]interface{} `json:"data_row" yaml:"-"`
+	// DependsOn names the tests that must pass before this one starts. Once the
+	// suite is parsed it holds the names of the tests they expanded into.
+	DependsOn []string `json:"depends_on" yaml:"depends_on,omitempty"`
+	// Skip records the test as skipped, for this reason, without running it
+	Skip string `json:"skip" yaml:"skip,omitempty"`
+	// Only narrows local runs to the suite's tests that set it
+	Only bool `json:"only" yaml:"only,omitempty"`
+	// XFail marks a test that is expected to fail. Failing doesn't fail the run;
+	// passing does.
+	XFail bool `json:"xfail" yaml:"xfail,omitempty"`
+	// RequiredEnv is the suite's env.required, checked by the worker before the
+	// test's first step
+	RequiredEnv []string `json:"required_env" yaml:"-"`
+}
+
+// ForEachDefaultAs is the name of the current item when for_each doesn't set one
+const ForEachDefaultAs = "item"
+
+// ForEach runs a step, or a test's steps, once per item of a list. Templates see
+// the item under As and its 0-based position as index.
+type ForEach struct {
+	// Items is a list, or a template that renders one: a {{ .vars.* }} list or a
+	// JSON array, e.g. a saved value
+	Items       interface{} `json:"items" yaml:"items"`
+	As          string      `json:"as" yaml:"as,omitempty"`
+	Parallel    bool        `json:"parallel" yaml:"parallel,omitempty"`
+	MaxParallel int         `json:"max_parallel" yaml:"max_parallel,omitempty"`
+}
+
+// ItemName is the name templates use for the current item
+func (f *ForEach) ItemName() string {
+	if f.As == "" {
+		return ForEachDefaultAs
+	}
+	return f.As
 }
 
 type Step struct {
@@ -45,6 +148,46 @@ type Step struct {
 	Assertions []map[string]interface{} `json:"assertions" yaml:"assertions"`
 	Save       []map[string]interface{} `json:"save" yaml:"save,omitempty"`
 	Retry      *RetryPolicy             `json:"retry" yaml:"retry,omitempty"`
+	// ContinueOnFailure lets later steps run after this step fails; the test still fails
+	ContinueOnFailure bool `json:"continue_on_failure" yaml:"continue_on_failure,omitempty"`
+	// Soft makes every assertion of the step soft, as if each set soft: true
+	Soft bool `json:"soft" yaml:"soft,omitempty"`
+	// When and Unless are conditions that skip the step, rendered against vars, env and saved state
+	When   string `json:"when" yaml:"when,omitempty"`
+	Unless string `json:"unless" yaml:"unless,omitempty"`
+	// ForEach runs the step once per item
+	ForEach *ForEach `json:"for_each" yaml:"for_each,omitempty"`
+	// Use replaces the step with the steps of the named step template, given With as its params
+	Use  string                 `json:"use" yaml:"use,omitempty"`
+	With map[string]interface{} `json:"with" yaml:"with,omitempty"`
+	// Parallel replaces the step with a group of steps that run at once
+	Parallel *ParallelSteps `json:"parallel" yaml:"parallel,omitempty"`
+	// Group is the parallel group the step was expanded from
+	Group *StepGroup `json:"group" yaml:"-"`
+}
+
+// ParallelSteps are steps that run at the same time, at most MaxParallel at once
+// when it's set. The step after them runs once they've all finished.
+type ParallelSteps struct {
+	MaxParallel int    `json:"max_parallel" yaml:"max_parallel,omitempty"`
+	Steps       []Step `json:"steps" yaml:"steps"`
+}
+
+// StepGroup identifies the parallel group of an expanded step. Consecutive steps
+// with the same ID run together.
+type StepGroup struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	MaxParallel int    `json:"max_parallel,omitempty"`
+}
+
+// StepTemplate is a named sequence of steps that steps run with use. Params maps
+// each parameter to its default; a parameter without one must be given. The
+// steps reach them as {{ .params.* }}.
+type StepTemplate struct {
+	Description string                 `json:"description" yaml:"description,omitempty"`
+	Params      map[string]interface{} `json:"params" yaml:"params,omitempty"`
+	Steps       []Step                 `json:"steps" yaml:"steps"`
 }
 
 type CleanupSpec struct {
@@ -58,6 +201,29 @@ type RetryPolicy struct {
 	MaximumAttempts    int      `json:"maximum_attempts" yaml:"maximum_attempts,omitempty"`
 	BackoffCoefficient float64  `json:"backoff_coefficient" yaml:"backoff_coefficient,omitempty"`
 	NonRetryableErrors []string `json:"non_retryable_errors" yaml:"non_retryable_errors,omitempty"`
+	// Attempts has the workflow rerun the whole step, assertions included, rather
+	// than Temporal retrying its activity. Each attempt is logged.
+	Attempts int           `json:"attempts" yaml:"attempts,omitempty"`
+	Backoff  *RetryBackoff `json:"backoff" yaml:"backoff,omitempty"`
+	// On lists the failures worth another attempt; every failure is retried when empty
+	On []string `json:"on" yaml:"on,omitempty"`
+}
+
+// Failures a step's retry can be limited to
+const (
+	RetryOnError     = "error"     // The plugin failed, e.g. a refused connection
+	RetryOnAssertion = "assertion" // The step ran but an assertion failed
+	RetryOnTimeout   = "timeout"   // The step timed out
+)
+
+// RetryBackoff spaces out the attempts of a retried step. Each wait is the
+// previous one times the multiplier, up to the max delay, and is randomly
+// spread by the jitter fraction so retries from parallel tests don't line up.
+type RetryBackoff struct {
+	Delay      string   `json:"delay" yaml:"delay,omitempty"`
+	MaxDelay   string   `json:"max_delay" yaml:"max_delay,omitempty"`
+	Multiplier float64  `json:"multiplier" yaml:"multiplier,omitempty"`
+	Jitter     *float64 `json:"jitter" yaml:"jitter,omitempty"`
 }
 
 // validateWithSchema validates the YAML data against the embedded JSON schema
@@ -108,6 +274,38 @@ func ParseYAML(yamlPayload []byte) (RocketshipConfig, error) {
 		return RocketshipConfig{}, fmt.Errorf("failed to unmarshal YAML: %w", err)
 	}
 
+	if len(config.Include) > 0 {
+		return RocketshipConfig{}, fmt.Errorf("include %s was not resolved; suites that include files must be run with the CLI or scanned from their repository", config.Include[0])
+	}
+
+	applyEachHooks(&config)
+
+	if err := expandStepTemplates(&config); err != nil {
+		return RocketshipConfig{}, err
+	}
+
+	if err := expandParallelSteps(&config); err != nil {
+		return RocketshipConfig{}, err
+	}
+
+	if err := expandDataRows(&config); err != nil {
+		return RocketshipConfig{}, err
+	}
+
+	if err := expandMatrices(&config); err != nil {
+		return RocketshipConfig{}, err
+	}
+
+	if err := resolveDependencies(&config); err != nil {
+		return RocketshipConfig{}, err
+	}
+
+	applySuiteTags(&config)
+
+	applyRequiredEnv(&config)
+
+	applyHTTPDefaults(&config)
+
 	// Process browser sessions (auto-inject start/stop steps)
 	if err := processBrowserSessions(&config); err != nil {
 		return RocketshipConfig{}, fmt.Errorf("failed to process browser sessions: %w", err)
@@ -164,7 +362,7 @@ func processBrowserSessions(config *RocketshipConfig) error {
 		// Inject session_id into all browser-using steps
 		for j := range test.Steps {
 			step := &test.Steps[j]
-			if usesBrowser(*step) {
+			if usesBrowser(*step) || joinsBrowserSession(*step) {
 				// Skip the auto-injected start step
 				if step.Name == "__auto_browser_start__" {
 					continue
@@ -180,9 +378,120 @@ func processBrowserSessions(config *RocketshipConfig) error {
 	return nil
 }
 
+// expandStepLists replaces each of the suite's and its tests' step lists with
+// what expand returns for it, naming the list in errors
+func expandStepLists(config *RocketshipConfig, expand func(steps []Step) ([]Step, error)) error {
+	var err error
+	apply := func(steps *[]Step, where string) {
+		if err != nil {
+			return
+		}
+		var expanded []Step
+		if expanded, err = expand(*steps); err != nil {
+			err = fmt.Errorf("%s: %w", where, err)
+			return
+		}
+		*steps = expanded
+	}
+	applyCleanup := func(cleanup *CleanupSpec, where string) {
+		if cleanup != nil {
+			apply(&cleanup.Always, where)
+			apply(&cleanup.OnFailure, where)
+		}
+	}
+
+	apply(&config.Init, "suite init")
+	applyCleanup(config.Cleanup, "suite cleanup")
+	for i := range config.Tests {
+		test := &config.Tests[i]
+		where := fmt.Sprintf("test %q", test.Name)
+		apply(&test.Init, where)
+		apply(&test.Steps, where)
+		applyCleanup(test.Cleanup, where)
+	}
+	return err
+}
+
+// applyRequiredEnv gives every test the suite's required env vars
+func applyRequiredEnv(config *RocketshipConfig) {
+	required := config.Env.Names()
+	if len(required) == 0 {
+		return
+	}
+	for i := range config.Tests {
+		config.Tests[i].RequiredEnv = append([]string(nil), required...)
+	}
+}
+
+// applyEachHooks gives every test a copy of the suite's each_init steps ahead of
+// its init steps, and of its each_cleanup steps after its cleanup steps that
+// always run
+func applyEachHooks(config *RocketshipConfig) {
+	if len(config.EachInit) == 0 && len(config.EachCleanup) == 0 {
+		return
+	}
+	for i := range config.Tests {
+		test := &config.Tests[i]
+		if len(config.EachInit) > 0 {
+			test.Init = append(copySteps(config.EachInit), test.Init...)
+		}
+		if len(config.EachCleanup) > 0 {
+			if test.Cleanup == nil {
+				test.Cleanup = &CleanupSpec{}
+			}
+			test.Cleanup.Always = append(test.Cleanup.Always, copySteps(config.EachCleanup)...)
+		}
+	}
+}
+
+// applyHTTPDefaults merges the suite's http transport settings into every http
+// step. Settings a step gives itself win, one key at a time, so a step can
+// change the timeout and keep the suite's proxy.
+func applyHTTPDefaults(config *RocketshipConfig) {
+	if config.HTTP == nil || len(config.HTTP.Transport) == 0 {
+		return
+	}
+	apply := func(steps []Step) {
+		for i := range steps {
+			step := &steps[i]
+			if step.Plugin != "http" {
+				continue
+			}
+			if step.Config == nil {
+				step.Config = map[string]interface{}{}
+			}
+			transport := make(map[string]interface{}, len(config.HTTP.Transport))
+			for key, value := range config.HTTP.Transport {
+				transport[key] = value
+			}
+			if own, ok := step.Config["transport"].(map[string]interface{}); ok {
+				for key, value := range own {
+					transport[key] = value
+				}
+			}
+			step.Config["transport"] = transport
+		}
+	}
+
+	apply(config.Init)
+	if config.Cleanup != nil {
+		apply(config.Cleanup.Always)
+		apply(config.Cleanup.OnFailure)
+	}
+	for i := range config.Tests {
+		test := &config.Tests[i]
+		apply(test.Init)
+		apply(test.Steps)
+		if test.Cleanup != nil {
+			apply(test.Cleanup.Always)
+			apply(test.Cleanup.OnFailure)
+		}
+	}
+}
+
 // scanForBrowserUsage scans steps to determine if browser is needed and headless setting
 func scanForBrowserUsage(steps []Step) (needsBrowser bool, headless bool, err error) {
-	headless = false        // Default to non-headless (show browser for local testing)
+	headless = false // Default to non-headless (show browser for local testing)
 	seenExplicitTrue := false
 	seenExplicitFalse := false
 
@@ -233,6 +542,23 @@ func scanForBrowserUsage(steps []Step) (needsBrowser bool, headless bool, err er
 	return needsBrowser, headless, nil
 }
 
+// joinsBrowserSession returns true for browser, visual and a11y steps that share
+// the test's session when it has one. They don't start one themselves: on their own
+// they launch a browser per step, so tests without Python plugins don't need Python.
+func joinsBrowserSession(step Step) bool {
+	switch step.Plugin {
+	case "browser", "visual", "a11y":
+	default:
+		return false
+	}
+	for _, key := range []string{"executable", "headless", "width", "height"} {
+		if _, ok := step.Config[key]; ok {
+			return false
+		}
+	}
+	return true
+}
+
 // usesBrowser returns true if the step uses browser sessions
 // This includes always-browser plugins (playwright, browser_use) and
 // agent steps configured with browser capability
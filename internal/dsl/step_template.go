@@ -0,0 +1,261 @@
+package dsl
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// paramReferenceRegex matches {{ .params.* }} in a step template's steps
+var paramReferenceRegex = regexp.MustCompile(`\{\{\s*\.params\.([A-Za-z0-9_-]+(?:\.[A-Za-z0-9_-]+)*)\s*\}\}`)
+
+// expandStepTemplates replaces every step that uses a step template with the
+// template's steps, their params filled in. The steps are named after the step
+// that uses them, e.g. "Log in: Request token", and inherit its when and unless.
+func expandStepTemplates(config *RocketshipConfig) error {
+	return expandStepLists(config, func(steps []Step) ([]Step, error) {
+		return expandSteps(config.StepTemplates, steps, nil)
+	})
+}
+
+// expandSteps expands the steps that use a template. stack holds the templates
+// being expanded, to catch templates that use themselves.
+func expandSteps(templates map[string]StepTemplate, steps []Step, stack []string) ([]Step, error) {
+	expands := false
+	for _, step := range steps {
+		expands = expands || step.Use != "" || step.Parallel != nil
+	}
+	if !expands {
+		return steps, nil
+	}
+
+	expanded := make([]Step, 0, len(steps))
+	for _, step := range steps {
+		if step.Use == "" {
+			// Steps in a parallel group can use templates too
+			if step.Parallel != nil {
+				inner, err := expandSteps(templates, step.Parallel.Steps, stack)
+				if err != nil {
+					return nil, fmt.Errorf("step %q: %w", step.Name, err)
+				}
+				parallel := *step.Parallel
+				parallel.Steps = inner
+				step.Parallel = &parallel
+			}
+			expanded = append(expanded, step)
+			continue
+		}
+		inner, err := useStepTemplate(templates, step, stack)
+		if err != nil {
+			return nil, fmt.Errorf("step %q: %w", step.Name, err)
+		}
+		expanded = append(expanded, inner...)
+	}
+	return expanded, nil
+}
+
+// useStepTemplate returns the steps a step that uses a template stands for
+func useStepTemplate(templates map[string]StepTemplate, step Step, stack []string) ([]Step, error) {
+	if step.Plugin != "" || step.Config != nil || len(step.Assertions) > 0 || len(step.Save) > 0 ||
+		step.Retry != nil || step.ForEach != nil || step.ContinueOnFailure || step.Soft || step.Parallel != nil {
+		return nil, fmt.Errorf("a step that uses a template may only set name, with, when and unless")
+	}
+	template, ok := templates[step.Use]
+	if !ok {
+		return nil, fmt.Errorf("no step template named %q", step.Use)
+	}
+	for _, name := range stack {
+		if name == step.Use {
+			return nil, fmt.Errorf("step template %q uses itself: %s -> %s", step.Use, strings.Join(stack, " -> "), step.Use)
+		}
+	}
+	params, err := stepTemplateParams(step.Use, template, step.With)
+	if err != nil {
+		return nil, err
+	}
+
+	steps := copySteps(template.Steps)
+	for i := range steps {
+		inner := &steps[i]
+		if err := substituteStepParams(inner, params); err != nil {
+			return nil, fmt.Errorf("step template %q, step %q: %w", step.Use, inner.Name, err)
+		}
+		inner.Name = step.Name + ": " + inner.Name
+		inner.When = joinConditions(step.When, inner.When, "&&")
+		inner.Unless = joinConditions(step.Unless, inner.Unless, "||")
+	}
+	return expandSteps(templates, steps, append(stack[:len(stack):len(stack)], step.Use))
+}
+
+// stepTemplateParams fills in a template's params from with and their defaults
+func stepTemplateParams(name string, template StepTemplate, with map[string]interface{}) (map[string]interface{}, error) {
+	given := make([]string, 0, len(with))
+	for param := range with {
+		given = append(given, param)
+	}
+	sort.Strings(given)
+	for _, param := range given {
+		if _, ok := template.Params[param]; !ok {
+			return nil, fmt.Errorf("step template %q has no param %q", name, param)
+		}
+	}
+
+	declared := make([]string, 0, len(template.Params))
+	for param := range template.Params {
+		declared = append(declared, param)
+	}
+	sort.Strings(declared)
+	params := make(map[string]interface{}, len(declared))
+	for _, param := range declared {
+		value, ok := with[param]
+		if !ok {
+			value = template.Params[param]
+			if value == nil {
+				return nil, fmt.Errorf("step template %q needs param %q", name, param)
+			}
+		}
+		params[param] = value
+	}
+	return params, nil
+}
+
+// substituteStepParams fills {{ .params.* }} in everything a step sets
+func substituteStepParams(step *Step, params map[string]interface{}) error {
+	var err error
+	text := func(s string) string {
+		if err != nil {
+			return s
+		}
+		var out string
+		out, err = substituteParamText(s, params)
+		return out
+	}
+	value := func(v interface{}) interface{} {
+		if err != nil {
+			return v
+		}
+		var out interface{}
+		out, err = substituteParams(v, params)
+		return out
+	}
+	maps := func(ms []map[string]interface{}) []map[string]interface{} {
+		for i, m := range ms {
+			if out, ok := value(m).(map[string]interface{}); ok {
+				ms[i] = out
+			}
+		}
+		return ms
+	}
+
+	step.Name = text(step.Name)
+	step.When = text(step.When)
+	step.Unless = text(step.Unless)
+	if step.Config != nil {
+		step.Config, _ = value(step.Config).(map[string]interface{})
+	}
+	step.Assertions = maps(step.Assertions)
+	step.Save = maps(step.Save)
+	if step.With != nil {
+		step.With, _ = value(step.With).(map[string]interface{})
+	}
+	if step.ForEach != nil {
+		forEach := *step.ForEach
+		forEach.Items = value(forEach.Items)
+		step.ForEach = &forEach
+	}
+	if step.Parallel != nil && err == nil {
+		for i := range step.Parallel.Steps {
+			if err = substituteStepParams(&step.Parallel.Steps[i], params); err != nil {
+				break
+			}
+		}
+	}
+	return err
+}
+
+// substituteParams fills {{ .params.* }} in a value. A string that is a single
+// reference becomes the param itself, so lists, objects and numbers stay intact.
+func substituteParams(value interface{}, params map[string]interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		if match := paramReferenceRegex.FindStringSubmatch(v); match != nil && strings.TrimSpace(v) == match[0] {
+			return lookupParam(match[1], params)
+		}
+		return substituteParamText(v, params)
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			substituted, err := substituteParams(item, params)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = substituted
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			substituted, err := substituteParams(item, params)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = substituted
+		}
+		return result, nil
+	}
+	return value, nil
+}
+
+// substituteParamText fills {{ .params.* }} in a string
+func substituteParamText(s string, params map[string]interface{}) (string, error) {
+	if !strings.Contains(s, ".params.") {
+		return s, nil
+	}
+	var err error
+	result := paramReferenceRegex.ReplaceAllStringFunc(s, func(reference string) string {
+		value, lookupErr := lookupParam(paramReferenceRegex.FindStringSubmatch(reference)[1], params)
+		if lookupErr != nil {
+			if err == nil {
+				err = lookupErr
+			}
+			return reference
+		}
+		if str, ok := value.(string); ok {
+			return str
+		}
+		return fmt.Sprintf("%v", value)
+	})
+	return result, err
+}
+
+// lookupParam returns the value of a dotted param path, e.g. user.name
+func lookupParam(path string, params map[string]interface{}) (interface{}, error) {
+	parts := strings.Split(path, ".")
+	value, ok := params[parts[0]]
+	if !ok {
+		return nil, fmt.Errorf("undefined param %q", parts[0])
+	}
+	for _, part := range parts[1:] {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("param %q has no field %q", path, part)
+		}
+		if value, ok = fields[part]; !ok {
+			return nil, fmt.Errorf("param %q has no field %q", path, part)
+		}
+	}
+	return value, nil
+}
+
+// joinConditions combines the condition of a step that uses a template with one
+// of the template's steps
+func joinConditions(outer, inner, operator string) string {
+	switch {
+	case outer == "":
+		return inner
+	case inner == "":
+		return outer
+	}
+	return fmt.Sprintf("(%s) %s (%s)", outer, operator, inner)
+}
@@ -0,0 +1,144 @@
+package dsl
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// tagRegex matches a valid tag, the same pattern the schema enforces
+var tagRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.:-]*$`)
+
+// TagFilter selects tests by their tags, written as a comma-separated list such
+// as "smoke,!slow". A test matches when it has at least one of the included tags,
+// or there are none, and none of the excluded ones. Tags match regardless of case.
+type TagFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// ParseTagFilter parses a tag filter. An empty string is an empty filter, which
+// every test matches.
+func ParseTagFilter(expr string) (TagFilter, error) {
+	var filter TagFilter
+	if strings.TrimSpace(expr) == "" {
+		return filter, nil
+	}
+	for _, part := range strings.Split(expr, ",") {
+		tag := strings.TrimSpace(part)
+		exclude := strings.HasPrefix(tag, "!")
+		if exclude {
+			tag = strings.TrimSpace(tag[1:])
+		}
+		if !tagRegex.MatchString(tag) {
+			return TagFilter{}, fmt.Errorf("invalid tag %q in %q: tags are letters, digits, '_', '.', ':' and '-'", strings.TrimSpace(part), expr)
+		}
+		if exclude {
+			filter.Exclude = append(filter.Exclude, tag)
+		} else {
+			filter.Include = append(filter.Include, tag)
+		}
+	}
+	return filter, nil
+}
+
+// IsEmpty reports whether the filter selects every test
+func (f TagFilter) IsEmpty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+// String writes the filter the way ParseTagFilter reads it
+func (f TagFilter) String() string {
+	parts := make([]string, 0, len(f.Include)+len(f.Exclude))
+	parts = append(parts, f.Include...)
+	for _, tag := range f.Exclude {
+		parts = append(parts, "!"+tag)
+	}
+	return strings.Join(parts, ",")
+}
+
+// Matches reports whether a test with these tags is selected
+func (f TagFilter) Matches(tags []string) bool {
+	for _, tag := range f.Exclude {
+		if hasTag(tags, tag) {
+			return false
+		}
+	}
+	if len(f.Include) == 0 {
+		return true
+	}
+	for _, tag := range f.Include {
+		if hasTag(tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterTests drops the tests the filter doesn't select. A selected test can't
+// depend on one that was dropped, since it would never start.
+func FilterTests(config *RocketshipConfig, filter TagFilter) error {
+	if filter.IsEmpty() {
+		return nil
+	}
+	kept := make([]Test, 0, len(config.Tests))
+	selected := map[string]bool{}
+	for _, test := range config.Tests {
+		if filter.Matches(test.Tags) {
+			kept = append(kept, test)
+			selected[test.Name] = true
+		}
+	}
+	for _, test := range kept {
+		for _, dependency := range test.DependsOn {
+			if !selected[dependency] {
+				return fmt.Errorf("test %q depends on %q, which tags %q leave out", test.Name, dependency, filter.String())
+			}
+		}
+	}
+	config.Tests = kept
+	return nil
+}
+
+// TestTags returns every tag the tests have, sorted
+func TestTags(tests []Test) []string {
+	seen := map[string]bool{}
+	var tags []string
+	for _, test := range tests {
+		for _, tag := range test.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// applySuiteTags gives every test the suite's tags
+func applySuiteTags(config *RocketshipConfig) {
+	if len(config.Tags) == 0 {
+		return
+	}
+	for i := range config.Tests {
+		test := &config.Tests[i]
+		tags := append([]string(nil), config.Tags...)
+		for _, tag := range test.Tags {
+			if !hasTag(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		test.Tags = tags
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"sort"
 	"strings"
 	"text/template"
 )
@@ -15,6 +16,7 @@ var (
 	placeholderRegex       = regexp.MustCompile(`__ROCKETSHIP_ESCAPED_HANDLEBARS_\d+__`)
 	runtimeVariableRegex   = regexp.MustCompile(`\{_\{([^}]*?)\}_\}`)
 	templateVariableRegex  = regexp.MustCompile(`\{\{\s*([^.\s}][^}]*)\s*\}\}`)
+	varsReferenceRegex     = regexp.MustCompile(`^\s*\{\{\s*\.vars\.([A-Za-z0-9_-]+(?:\.[A-Za-z0-9_-]+)*)\s*\}\}\s*$`)
 )
 
 // TemplateContext holds runtime variables for template processing
@@ -25,6 +27,36 @@ type TemplateContext struct {
 	// Precedence for .env.*: OS env (highest) > context.Env (lowest/from DB)
 }
 
+// templateFuncs are the functions templates can call
+var templateFuncs = template.FuncMap{
+	"default": defaultValue,
+}
+
+// defaultValue returns fallback when value is missing or empty, so
+// {{ .env.REGION | default "us-east-1" }} works without REGION set
+func defaultValue(fallback, value interface{}) interface{} {
+	if value == nil {
+		return fallback
+	}
+	if s, ok := value.(string); ok && s == "" {
+		return fallback
+	}
+	return value
+}
+
+// MissingEnv returns the required env vars that are neither set in the OS
+// environment nor in secrets, sorted. Empty values count as missing.
+func MissingEnv(required []string, secrets map[string]string) []string {
+	var missing []string
+	for _, name := range required {
+		if os.Getenv(name) == "" && secrets[name] == "" {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
 // getEnvironmentVariables returns all environment variables as a map
 func getEnvironmentVariables() map[string]interface{} {
 	envVars := make(map[string]interface{})
@@ -53,7 +85,7 @@ func ProcessTemplate(input string, context TemplateContext) (string, error) {
 	processed = convertRuntimeVariables(processed, context.Runtime)
 
 	// Create template with custom delimiters to match our syntax
-	tmpl, err := template.New("rocketship").Parse(processed)
+	tmpl, err := template.New("rocketship").Funcs(templateFuncs).Parse(processed)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse template: %w", err)
 	}
@@ -274,6 +306,26 @@ func processNestedVars(input string, vars map[string]interface{}, prefix string)
 	return result
 }
 
+// LookupVarsReference returns the value of input when it is a single {{ .vars.* }}
+// reference, keeping lists and objects intact
+func LookupVarsReference(input string, vars map[string]interface{}) (interface{}, bool) {
+	match := varsReferenceRegex.FindStringSubmatch(input)
+	if match == nil {
+		return nil, false
+	}
+	var current interface{} = vars
+	for _, part := range strings.Split(match[1], ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
 // ProcessVarsOnlyRecursive processes only {{ .vars.* }} patterns in any nested data structure
 // This is used server-side to substitute vars after merging with environment config vars
 // It leaves {{ .env.* }} patterns untouched for plugin-time resolution
@@ -288,6 +340,10 @@ func ProcessVarsOnlyRecursive(data interface{}, vars map[string]interface{}) (in
 			if err != nil {
 				return nil, fmt.Errorf("failed to process template in key '%s': %w", key, err)
 			}
+			// for_each items naming a vars list become the list itself
+			if forEach, ok := value.(map[string]interface{}); ok && key == "for_each" {
+				value = withVarsItems(forEach, vars)
+			}
 			processedValue, err := ProcessVarsOnlyRecursive(value, vars)
 			if err != nil {
 				return nil, fmt.Errorf("failed to process template in value for key '%s': %w", key, err)
@@ -324,6 +380,27 @@ func ProcessVarsOnlyRecursive(data interface{}, vars map[string]interface{}) (in
 	}
 }
 
+// withVarsItems replaces a for_each's items with the vars list they reference
+func withVarsItems(forEach map[string]interface{}, vars map[string]interface{}) map[string]interface{} {
+	items, ok := forEach["items"].(string)
+	if !ok {
+		return forEach
+	}
+	value, ok := LookupVarsReference(items, vars)
+	if !ok {
+		return forEach
+	}
+	if _, isList := value.([]interface{}); !isList {
+		return forEach
+	}
+	replaced := make(map[string]interface{}, len(forEach))
+	for k, v := range forEach {
+		replaced[k] = v
+	}
+	replaced["items"] = value
+	return replaced
+}
+
 // ProcessConfigVariablesRecursive processes only config variables in any nested data structure
 // This function preserves escaped handlebars across processing phases
 func ProcessConfigVariablesRecursive(data interface{}, vars map[string]interface{}) (interface{}, error) {
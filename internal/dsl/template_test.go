@@ -683,6 +683,55 @@ func TestMergeInterfaceMaps_NoMutation(t *testing.T) {
 	}
 }
 
+func TestProcessVarsOnlyRecursive_ForEachItems(t *testing.T) {
+	vars := map[string]interface{}{
+		"regions": []interface{}{"us", "eu"},
+		"api": map[string]interface{}{
+			"versions": []interface{}{"v1", "v2"},
+		},
+		"region": "us",
+	}
+	doc := map[string]interface{}{
+		"for_each": map[string]interface{}{"items": "{{ .vars.regions }}", "as": "region"},
+		"steps": []interface{}{
+			map[string]interface{}{
+				"for_each": map[string]interface{}{"items": "{{ .vars.api.versions }}"},
+				"config":   map[string]interface{}{"url": "https://{{ .vars.region }}.example.com"},
+			},
+			map[string]interface{}{
+				"for_each": map[string]interface{}{"items": "{{ .vars.region }}"},
+			},
+		},
+	}
+
+	processed, err := ProcessVarsOnlyRecursive(doc, vars)
+	if err != nil {
+		t.Fatalf("ProcessVarsOnlyRecursive failed: %v", err)
+	}
+	result := processed.(map[string]interface{})
+	if items := result["for_each"].(map[string]interface{})["items"]; !valuesEqual(items, vars["regions"]) {
+		t.Errorf("Expected the regions list, got %v", items)
+	}
+	steps := result["steps"].([]interface{})
+	first := steps[0].(map[string]interface{})
+	if items := first["for_each"].(map[string]interface{})["items"]; !valuesEqual(items, []interface{}{"v1", "v2"}) {
+		t.Errorf("Expected the nested versions list, got %v", items)
+	}
+	if url := first["config"].(map[string]interface{})["url"]; url != "https://us.example.com" {
+		t.Errorf("Expected other vars to be substituted as text, got %v", url)
+	}
+	// Items that aren't a list are substituted as text, for the workflow to reject
+	if items := steps[1].(map[string]interface{})["for_each"].(map[string]interface{})["items"]; items != "us" {
+		t.Errorf("Expected a text substitution, got %v", items)
+	}
+	if _, ok := LookupVarsReference("{{ .vars.missing }}", vars); ok {
+		t.Errorf("Expected no value for a missing var")
+	}
+	if _, ok := LookupVarsReference("{{ .vars.regions }} and more", vars); ok {
+		t.Errorf("Expected no value for text around a reference")
+	}
+}
+
 // Helper function to compare maps for equality
 func mapsEqual(a, b map[string]interface{}) bool {
 	if len(a) != len(b) {
@@ -726,3 +775,62 @@ func valuesEqual(a, b interface{}) bool {
 		return a == b
 	}
 }
+
+func TestEnvironmentVariableDefaults(t *testing.T) {
+	t.Setenv("TEST_REGION", "us-east-1")
+	t.Setenv("TEST_EMPTY_REGION", "")
+
+	tests := []struct {
+		name     string
+		input    string
+		env      map[string]string
+		expected string
+	}{
+		{
+			name:     "set variable ignores the default",
+			input:    `{{ .env.TEST_REGION | default "eu-west-1" }}`,
+			expected: "us-east-1",
+		},
+		{
+			name:     "unset variable uses the default",
+			input:    `{{ .env.TEST_UNSET_REGION | default "eu-west-1" }}`,
+			expected: "eu-west-1",
+		},
+		{
+			name:     "empty variable uses the default",
+			input:    `{{ .env.TEST_EMPTY_REGION | default "eu-west-1" }}`,
+			expected: "eu-west-1",
+		},
+		{
+			name:     "project environment secret ignores the default",
+			input:    `{{ .env.TEST_SECRET_REGION | default "eu-west-1" }}`,
+			env:      map[string]string{"TEST_SECRET_REGION": "ap-south-1"},
+			expected: "ap-south-1",
+		},
+		{
+			name:     "function call form",
+			input:    `{{ default "eu-west-1" .env.TEST_UNSET_REGION }}`,
+			expected: "eu-west-1",
+		},
+		{
+			name:     "next to runtime variables",
+			input:    `https://{{ .env.TEST_UNSET_HOST | default "localhost" }}/users/{{ user_id }}`,
+			expected: "https://localhost/users/42",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ProcessTemplate(tt.input, TemplateContext{
+				Runtime: map[string]interface{}{"user_id": "42"},
+				Env:     tt.env,
+			})
+			if err != nil {
+				t.Fatalf("ProcessTemplate failed: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("ProcessTemplate result mismatch:\nexpected: %q\ngot:      %q", tt.expected, result)
+			}
+		})
+	}
+}
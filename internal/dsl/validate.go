@@ -0,0 +1,327 @@
+package dsl
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/itchyny/gojq"
+)
+
+var (
+	templateActionRegex   = regexp.MustCompile(`\{\{-?\s*(.*?)\s*-?\}\}`)
+	runtimeReferenceRegex = regexp.MustCompile(`^\.?([A-Za-z_][A-Za-z0-9_-]*)(\.[A-Za-z0-9_-]+)*$`)
+)
+
+// templateKeywords are Go template actions that look like bare variable names
+var templateKeywords = map[string]bool{
+	"end": true, "else": true, "break": true, "continue": true,
+	"nil": true, "true": true, "false": true,
+}
+
+// dynamicSavePlugins can save values that are not declared in the step's save
+// list (e.g. script steps calling save()), so references after them can't be checked
+var dynamicSavePlugins = map[string]bool{
+	"script": true,
+}
+
+// literalAssertionPlugins compare assertion values as written, without rendering templates
+var literalAssertionPlugins = map[string]bool{
+	"sql": true,
+}
+
+// builtinRuntimeRoots are always available to templates, e.g. {{ .run.id }}
+var builtinRuntimeRoots = map[string]bool{
+	"run": true,
+}
+
+// pluginRuntimeRoots are provided by a plugin to templates in its own config,
+// e.g. {{ webhook.url }} in a webhook_wait trigger
+var pluginRuntimeRoots = map[string][]string{
+	"webhook_wait": {"webhook"},
+}
+
+// ReferenceIssue is a problem found by ValidateReferences, located by test and step
+type ReferenceIssue struct {
+	Test     string // Empty for suite-level init and cleanup steps
+	Phase    string // init, steps or cleanup
+	Step     int    // 1-based index within the phase
+	StepName string
+	Message  string
+}
+
+func (i ReferenceIssue) String() string {
+	location := fmt.Sprintf("%s step %d (%q)", i.Phase, i.Step, i.StepName)
+	if i.Test != "" {
+		location = fmt.Sprintf("test %q %s", i.Test, location)
+	} else {
+		location = "suite " + location
+	}
+	return fmt.Sprintf("%s: %s", location, i.Message)
+}
+
+// ReferenceError lists every issue found by ValidateReferences
+type ReferenceError struct {
+	Issues []ReferenceIssue
+}
+
+func (e *ReferenceError) Error() string {
+	lines := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		lines[i] = issue.String()
+	}
+	if len(lines) == 1 {
+		return lines[0]
+	}
+	return fmt.Sprintf("%d problems found:\n  %s", len(lines), strings.Join(lines, "\n  "))
+}
+
+// ValidateReferences checks a parsed suite before anything runs: jq expressions in
+// saves and assertions must parse, templates must be well formed, and runtime
+// variables ({{ name }}) must be saved by an earlier step. Config variables
+// ({{ .vars.* }}) and secrets ({{ .env.* }}) are resolved from the environment
+// at run time and are not checked here. Returns a *ReferenceError or nil.
+func ValidateReferences(config RocketshipConfig) error {
+	v := &referenceValidator{}
+
+	suiteScope := newReferenceScope()
+	v.checkSteps(suiteScope, "", "init", config.Init)
+	if config.Cleanup != nil {
+		v.checkSteps(suiteScope.clone(), "", "cleanup", config.Cleanup.Always)
+		v.checkSteps(suiteScope.clone(), "", "cleanup", config.Cleanup.OnFailure)
+	}
+
+	for _, test := range config.Tests {
+		scope := suiteScope.clone()
+		// A matrix test's combination is available to all of its steps
+		for name := range test.MatrixParams {
+			scope.saved["matrix."+name] = true
+		}
+		// So is a data test's row
+		addRowFields(scope, "row", test.DataRow)
+		v.checkSteps(scope, test.Name, "init", test.Init)
+		if test.ForEach != nil {
+			// The item and index are available to the steps, not to cleanup
+			stepsScope := withForEach(scope, test.ForEach)
+			v.checkSteps(stepsScope, test.Name, "steps", test.Steps)
+			for name := range stepsScope.saved {
+				if name != test.ForEach.ItemName() && name != "index" {
+					scope.saved[name] = true
+				}
+			}
+			scope.dynamic = stepsScope.dynamic
+		} else {
+			v.checkSteps(scope, test.Name, "steps", test.Steps)
+		}
+		if test.Cleanup != nil {
+			// Cleanup runs after the steps, whether or not they all completed
+			v.checkSteps(scope.clone(), test.Name, "cleanup", test.Cleanup.Always)
+			v.checkSteps(scope.clone(), test.Name, "cleanup", test.Cleanup.OnFailure)
+		}
+	}
+
+	if len(v.issues) == 0 {
+		return nil
+	}
+	return &ReferenceError{Issues: v.issues}
+}
+
+type referenceScope struct {
+	saved   map[string]bool
+	dynamic bool // an earlier step may have saved undeclared values
+}
+
+func newReferenceScope() *referenceScope {
+	return &referenceScope{saved: make(map[string]bool)}
+}
+
+func (s *referenceScope) clone() *referenceScope {
+	cloned := &referenceScope{saved: make(map[string]bool, len(s.saved)), dynamic: s.dynamic}
+	for k := range s.saved {
+		cloned.saved[k] = true
+	}
+	return cloned
+}
+
+func (s *referenceScope) has(name string) bool {
+	if s.dynamic || s.saved[name] {
+		return true
+	}
+	// Dotted references like {{ user.id }} resolve through their first segment
+	head, _, _ := strings.Cut(name, ".")
+	return s.saved[head] || builtinRuntimeRoots[head]
+}
+
+type referenceValidator struct {
+	issues []ReferenceIssue
+}
+
+func (v *referenceValidator) checkSteps(scope *referenceScope, testName, phase string, steps []Step) {
+	// The steps of a parallel group only see what was saved before the group
+	var groupScope *referenceScope
+	groupID := 0
+	for idx, step := range steps {
+		readScope := scope
+		if step.Group == nil {
+			groupID = 0
+		} else {
+			if step.Group.ID != groupID {
+				groupID = step.Group.ID
+				groupScope = scope.clone()
+			}
+			readScope = groupScope
+		}
+
+		report := func(format string, args ...interface{}) {
+			v.issues = append(v.issues, ReferenceIssue{
+				Test:     testName,
+				Phase:    phase,
+				Step:     idx + 1,
+				StepName: step.Name,
+				Message:  fmt.Sprintf(format, args...),
+			})
+		}
+
+		checkTemplateString(readScope, step.When, "when", report)
+		checkTemplateString(readScope, step.Unless, "unless", report)
+
+		// A for_each step's item and index are only available to the step itself
+		stepScope := readScope
+		if step.ForEach != nil {
+			checkTemplates(readScope, step.ForEach.Items, "for_each.items", report)
+			stepScope = withForEach(readScope, step.ForEach)
+		}
+		configScope := stepScope
+		if roots := pluginRuntimeRoots[step.Plugin]; len(roots) > 0 {
+			configScope = stepScope.clone()
+			for _, root := range roots {
+				configScope.saved[root] = true
+			}
+		}
+		checkTemplates(configScope, step.Config, "config", report)
+		plugin := resultPlugin(step)
+		for i, assertion := range step.Assertions {
+			field := fmt.Sprintf("assertions[%d]", i)
+			if !literalAssertionPlugins[plugin] {
+				checkTemplates(stepScope, assertion, field, report)
+			}
+			if path, ok := assertion["path"].(string); ok {
+				checkJQ(path, field+".path", report)
+			}
+		}
+		for i, save := range step.Save {
+			field := fmt.Sprintf("save[%d]", i)
+			checkTemplates(stepScope, save, field, report)
+			if path, ok := save["json_path"].(string); ok {
+				checkJQ(path, field+".json_path", report)
+			}
+		}
+
+		// Values saved by this step are available to the steps after it
+		for _, save := range step.Save {
+			if as, ok := save["as"].(string); ok && as != "" {
+				scope.saved[as] = true
+			}
+		}
+		if dynamicSavePlugins[plugin] {
+			scope.dynamic = true
+		}
+	}
+}
+
+// withForEach returns a copy of scope with a for_each's item and index
+func withForEach(scope *referenceScope, forEach *ForEach) *referenceScope {
+	scoped := scope.clone()
+	scoped.saved[forEach.ItemName()] = true
+	scoped.saved["index"] = true
+	return scoped
+}
+
+// addRowFields adds a data row's fields to scope, nested objects included
+func addRowFields(scope *referenceScope, prefix string, fields map[string]interface{}) {
+	for name, value := range fields {
+		scope.saved[prefix+"."+name] = true
+		if nested, ok := value.(map[string]interface{}); ok {
+			addRowFields(scope, prefix+"."+name, nested)
+		}
+	}
+}
+
+// resultPlugin is the plugin whose results a step's assertions and saves read:
+// the polled plugin for poll steps
+func resultPlugin(step Step) string {
+	if step.Plugin == "poll" {
+		if plugin, ok := step.Config["plugin"].(string); ok {
+			return plugin
+		}
+	}
+	return step.Plugin
+}
+
+// checkJQ reports jq expressions that fail to parse. Templated expressions are
+// only known at run time and are skipped.
+func checkJQ(expr, field string, report func(string, ...interface{})) {
+	if expr == "" || strings.Contains(expr, "{{") {
+		return
+	}
+	if _, err := gojq.Parse(expr); err != nil {
+		report("%s: invalid jq expression %q: %v", field, expr, err)
+	}
+}
+
+// checkTemplates walks value and checks every string containing {{ }}
+func checkTemplates(scope *referenceScope, value interface{}, field string, report func(string, ...interface{})) {
+	switch val := value.(type) {
+	case string:
+		checkTemplateString(scope, val, field, report)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			checkTemplates(scope, val[k], field+"."+k, report)
+		}
+	case []interface{}:
+		for i, item := range val {
+			checkTemplates(scope, item, fmt.Sprintf("%s[%d]", field, i), report)
+		}
+	}
+}
+
+func checkTemplateString(scope *referenceScope, input, field string, report func(string, ...interface{})) {
+	if !strings.Contains(input, "{{") {
+		return
+	}
+
+	// Escaped handlebars are unescaped once per templating pass, so any escaped
+	// expression may still be literal text by the time the plugin sees it
+	processed := escapedHandlebarsRegex.ReplaceAllString(input, "")
+
+	var unknown []string
+	converted := templateActionRegex.ReplaceAllStringFunc(processed, func(action string) string {
+		content := strings.TrimSpace(templateActionRegex.FindStringSubmatch(action)[1])
+		if strings.HasPrefix(content, ".vars.") || strings.HasPrefix(content, ".env.") {
+			return action
+		}
+		if templateKeywords[content] || !runtimeReferenceRegex.MatchString(content) {
+			// Control structures and pipelines are only syntax checked below
+			return action
+		}
+		name := strings.TrimPrefix(content, ".")
+		if !scope.has(name) {
+			unknown = append(unknown, name)
+		}
+		return fmt.Sprintf("{{ .%s }}", name)
+	})
+
+	for _, name := range unknown {
+		report("%s: variable %q is not saved by an earlier step", field, name)
+	}
+	if _, err := template.New("rocketship").Funcs(templateFuncs).Parse(converted); err != nil {
+		report("%s: invalid template: %v", field, err)
+	}
+}
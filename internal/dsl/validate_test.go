@@ -0,0 +1,204 @@
+package dsl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateReferences_Valid(t *testing.T) {
+	config, err := ParseYAML([]byte(`
+name: "Chained requests"
+init:
+  - name: "Login"
+    plugin: "http"
+    config:
+      method: "POST"
+      url: "{{ .vars.base_url }}/login"
+    save:
+      - json_path: ".token"
+        as: "token"
+tests:
+  - name: "Create and fetch"
+    steps:
+      - name: "Create"
+        plugin: "http"
+        config:
+          method: "POST"
+          url: "{{ .vars.base_url }}/users"
+          headers:
+            Authorization: "Bearer {{ token }}"
+            X-Api-Key: "{{ .env.API_KEY }}"
+            X-Region: '{{ .env.REGION | default "eu" }}'
+        save:
+          - json_path: ".user.id"
+            as: "user_id"
+      - name: "Fetch"
+        plugin: "http"
+        config:
+          method: "GET"
+          url: "{{ .vars.base_url }}/users/{{ .user_id }}?q=\\{{ literal }}"
+        assertions:
+          - type: "json_path"
+            path: ".items | map(.id) | length"
+            expected: "{{ user_id }}"
+    cleanup:
+      always:
+        - name: "Delete"
+          plugin: "http"
+          config:
+            method: "DELETE"
+            url: "{{ .vars.base_url }}/users/{{ user_id }}"
+`))
+	require.NoError(t, err)
+	assert.NoError(t, ValidateReferences(config))
+}
+
+func TestValidateReferences_ReportsStepContext(t *testing.T) {
+	config, err := ParseYAML([]byte(`
+name: "Broken suite"
+tests:
+  - name: "Typos"
+    steps:
+      - name: "Create"
+        plugin: "http"
+        config:
+          method: "POST"
+          url: "https://example.com/users"
+        save:
+          - json_path: ".user.id"
+            as: "user_id"
+      - name: "Fetch"
+        plugin: "http"
+        config:
+          method: "GET"
+          url: "https://example.com/users/{{ user_idd }}"
+        assertions:
+          - type: "json_path"
+            path: ".items["
+            expected: 1
+  - name: "Uses another test's value"
+    steps:
+      - name: "Fetch"
+        plugin: "log"
+        config:
+          message: "{{ if .user_id }}{{ user_id }}"
+`))
+	require.NoError(t, err)
+
+	err = ValidateReferences(config)
+	require.Error(t, err)
+	var refErr *ReferenceError
+	require.ErrorAs(t, err, &refErr)
+	require.Len(t, refErr.Issues, 4)
+
+	assert.Equal(t, "Typos", refErr.Issues[0].Test)
+	assert.Equal(t, 2, refErr.Issues[0].Step)
+	assert.Contains(t, refErr.Issues[0].String(), `test "Typos" steps step 2 ("Fetch")`)
+	assert.Contains(t, refErr.Issues[0].Message, `variable "user_idd" is not saved by an earlier step`)
+	assert.Contains(t, refErr.Issues[1].Message, "assertions[0].path: invalid jq expression")
+
+	// Values saved in one test are not visible to another
+	assert.Equal(t, "Uses another test's value", refErr.Issues[2].Test)
+	assert.Contains(t, refErr.Issues[2].Message, `variable "user_id"`)
+	assert.Contains(t, refErr.Issues[3].Message, "invalid template")
+	assert.True(t, strings.HasPrefix(err.Error(), "4 problems found"))
+}
+
+func TestValidateReferences_ScriptStepsSaveDynamically(t *testing.T) {
+	config, err := ParseYAML([]byte(`
+name: "Script saves"
+tests:
+  - name: "Script then HTTP"
+    steps:
+      - name: "Compute"
+        plugin: "script"
+        config:
+          language: "javascript"
+          script: "save('computed', '1')"
+      - name: "Use"
+        plugin: "http"
+        config:
+          method: "GET"
+          url: "https://example.com/{{ computed }}"
+`))
+	require.NoError(t, err)
+	assert.NoError(t, ValidateReferences(config))
+}
+
+func TestValidateReferences_PluginProvidedVariables(t *testing.T) {
+	config, err := ParseYAML([]byte(`
+name: "Webhook"
+tests:
+  - name: "Callback"
+    steps:
+      - name: "Wait for callback"
+        plugin: "webhook_wait"
+        config:
+          trigger:
+            url: "https://api.example.com/orders"
+            body: '{"callback_url": "{{ webhook.url }}"}'
+      - name: "Use"
+        plugin: "http"
+        config:
+          method: "GET"
+          url: "{{ webhook.url }}"
+`))
+	require.NoError(t, err)
+	err = ValidateReferences(config)
+	var refErr *ReferenceError
+	require.ErrorAs(t, err, &refErr)
+	// Only the webhook_wait step's own config can use {{ webhook.* }}
+	require.Len(t, refErr.Issues, 1)
+	assert.Equal(t, 2, refErr.Issues[0].Step)
+	assert.Contains(t, refErr.Issues[0].Message, `variable "webhook.url" is not saved by an earlier step`)
+}
+
+func TestValidateReferences_PollStepsUseThePolledPlugin(t *testing.T) {
+	config, err := ParseYAML([]byte(`
+name: "Poll"
+tests:
+  - name: "Eventually consistent"
+    steps:
+      - name: "Wait for the row"
+        plugin: "poll"
+        config:
+          timeout: "30s"
+          plugin: "sql"
+          config:
+            dsn: "sqlite://./tmp/test.db"
+            commands:
+              - "SELECT '{{ literal }}' AS value"
+        assertions:
+          - type: "column_value"
+            query_index: 0
+            row_index: 0
+            column: "value"
+            expected: "{{ literal }}"
+`))
+	require.NoError(t, err)
+	err = ValidateReferences(config)
+	var refErr *ReferenceError
+	require.ErrorAs(t, err, &refErr)
+	// sql compares assertion values as written, so only the config is checked
+	require.Len(t, refErr.Issues, 1)
+	assert.Contains(t, refErr.Issues[0].Message, `config.config.commands[0]: variable "literal" is not saved by an earlier step`)
+}
+
+func TestValidateReferences_RepoExamples(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "..", ".rocketship", "*.yaml"))
+	require.NoError(t, err)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		config, err := ParseYAML(data)
+		if err != nil {
+			continue // schema problems are covered by the parser tests
+		}
+		assert.NoError(t, ValidateReferences(config), file)
+	}
+}
@@ -0,0 +1,340 @@
+// Package egress enforces per-project network allow-lists for plugin clients.
+//
+// Workers running tests for several tenants load a policy file at startup
+// (ROCKETSHIP_EGRESS_POLICY). The engine tags every workflow with the project
+// and environment it runs for, the interpreter forwards that scope to plugin
+// activities, and plugins dial through a Policy resolved from the scope.
+package egress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PolicyFileEnv names the environment variable pointing at the worker policy file
+const PolicyFileEnv = "ROCKETSHIP_EGRESS_POLICY"
+
+// ParamsKey is the plugin parameter carrying the run's project/environment scope
+const ParamsKey = "egress_scope"
+
+// Rules lists allowed destinations: hostnames, "*.suffix" wildcards, IPs or CIDRs
+type Rules struct {
+	Allow []string `yaml:"allow" json:"allow"`
+}
+
+// ProjectRules applies to every run of a project; environment rules are added on top
+type ProjectRules struct {
+	Allow        []string         `yaml:"allow" json:"allow"`
+	Environments map[string]Rules `yaml:"environments,omitempty" json:"environments,omitempty"`
+}
+
+// Config is the worker-wide policy file
+type Config struct {
+	// Default applies to runs whose project has no entry. Leaving it unset
+	// allows all destinations for those runs.
+	Default  *Rules                  `yaml:"default,omitempty" json:"default,omitempty"`
+	Projects map[string]ProjectRules `yaml:"projects" json:"projects"`
+}
+
+// Scope identifies the tenant a plugin activity runs for
+type Scope struct {
+	ProjectID   string
+	Environment string
+}
+
+var (
+	mu     sync.RWMutex
+	active *Config
+)
+
+// LoadFile reads and validates a policy file
+func LoadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read egress policy: %w", err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse egress policy: %w", err)
+	}
+
+	// Compile every rule set once so typos surface at startup instead of mid-run
+	if cfg.Default != nil {
+		if _, err := compile(cfg.Default.Allow, "default"); err != nil {
+			return nil, err
+		}
+	}
+	for projectID, project := range cfg.Projects {
+		if _, err := compile(project.Allow, projectID); err != nil {
+			return nil, err
+		}
+		for env, rules := range project.Environments {
+			if _, err := compile(rules.Allow, projectID+"/"+env); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &cfg, nil
+}
+
+// LoadFromEnv configures the worker from PolicyFileEnv. It returns false when no policy is set.
+func LoadFromEnv() (bool, error) {
+	path := strings.TrimSpace(os.Getenv(PolicyFileEnv))
+	if path == "" {
+		return false, nil
+	}
+	cfg, err := LoadFile(path)
+	if err != nil {
+		return false, err
+	}
+	Configure(cfg)
+	return true, nil
+}
+
+// Configure installs cfg as the active worker policy; nil disables enforcement
+func Configure(cfg *Config) {
+	mu.Lock()
+	defer mu.Unlock()
+	active = cfg
+}
+
+// Resolve returns the policy for scope, or nil when the destination is unrestricted
+func Resolve(scope Scope) (*Policy, error) {
+	mu.RLock()
+	cfg := active
+	mu.RUnlock()
+
+	if cfg == nil {
+		return nil, nil
+	}
+
+	project, ok := cfg.Projects[scope.ProjectID]
+	if !ok || scope.ProjectID == "" {
+		if cfg.Default == nil {
+			return nil, nil
+		}
+		return compile(cfg.Default.Allow, "default")
+	}
+
+	allow := append([]string{}, project.Allow...)
+	if rules, ok := project.Environments[scope.Environment]; ok && scope.Environment != "" {
+		allow = append(allow, rules.Allow...)
+	}
+
+	name := scope.ProjectID
+	if scope.Environment != "" {
+		name += "/" + scope.Environment
+	}
+	return compile(allow, name)
+}
+
+// ScopeFromParams extracts the scope the interpreter attached to plugin parameters
+func ScopeFromParams(p map[string]interface{}) Scope {
+	var scope Scope
+	switch raw := p[ParamsKey].(type) {
+	case map[string]interface{}:
+		scope.ProjectID, _ = raw["project_id"].(string)
+		scope.Environment, _ = raw["environment"].(string)
+	case map[string]string:
+		scope.ProjectID = raw["project_id"]
+		scope.Environment = raw["environment"]
+	}
+	return scope
+}
+
+// FromParams resolves the policy for a plugin activity's parameters
+func FromParams(p map[string]interface{}) (*Policy, error) {
+	return Resolve(ScopeFromParams(p))
+}
+
+// Policy is a compiled allow-list. A nil *Policy allows everything.
+type Policy struct {
+	name  string
+	hosts []string
+	nets  []*net.IPNet
+}
+
+func compile(allow []string, name string) (*Policy, error) {
+	policy := &Policy{name: name}
+	for _, entry := range allow {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			_, ipNet, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, fmt.Errorf("egress policy %s: invalid CIDR %q: %w", name, entry, err)
+			}
+			policy.nets = append(policy.nets, ipNet)
+			continue
+		}
+		if ip := net.ParseIP(entry); ip != nil {
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			policy.nets = append(policy.nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		if strings.Contains(entry, "*") && (!strings.HasPrefix(entry, "*.") || strings.Count(entry, "*") > 1) {
+			return nil, fmt.Errorf("egress policy %s: wildcards are only supported as a leading \"*.\" in %q", name, entry)
+		}
+		policy.hosts = append(policy.hosts, entry)
+	}
+	return policy, nil
+}
+
+func (p *Policy) allowsHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	for _, pattern := range p.hosts {
+		if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+			if strings.HasSuffix(host, suffix) {
+				return true
+			}
+			continue
+		}
+		if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Policy) allowsIP(ip net.IP) bool {
+	for _, ipNet := range p.nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the addresses to dial for host, rejecting destinations outside the policy.
+// A host matched by name may resolve anywhere; otherwise every resolved address must be allowed.
+func (p *Policy) resolve(ctx context.Context, host string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		if !p.allowsIP(ip) {
+			return nil, p.denied(host)
+		}
+		return []net.IP{ip}, nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no addresses found for %s", host)
+	}
+
+	byName := p.allowsHost(host)
+	ips := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		if !byName && !p.allowsIP(addr.IP) {
+			return nil, p.denied(fmt.Sprintf("%s (%s)", host, addr.IP))
+		}
+		ips = append(ips, addr.IP)
+	}
+	return ips, nil
+}
+
+// errDenied is wrapped by the errors for destinations a policy rejects
+var errDenied = errors.New("not allowed by the network policy")
+
+func (p *Policy) denied(dest string) error {
+	return fmt.Errorf("egress to %s is %w for %s", dest, errDenied, p.name)
+}
+
+// Check verifies that a host or host:port may be reached
+func (p *Policy) Check(ctx context.Context, address string) error {
+	if p == nil {
+		return nil
+	}
+	host := address
+	if h, _, err := net.SplitHostPort(address); err == nil {
+		host = h
+	}
+	_, err := p.resolve(ctx, strings.Trim(host, "[]"))
+	return err
+}
+
+// DialContext wraps dialer so connections are only opened to allowed addresses.
+// The checked IP is dialled directly, so a second DNS answer cannot bypass the policy.
+func (p *Policy) DialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+	if p == nil {
+		return dialer.DialContext
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		ips, err := p.resolve(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		var lastErr error
+		for _, ip := range ips {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+		}
+		return nil, lastErr
+	}
+}
+
+// Dialer adapts a policy to the dialer interfaces database drivers accept
+type Dialer struct {
+	dial func(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+// Dialer returns a policy-enforcing dialer; a nil policy dials directly
+func (p *Policy) Dialer() *Dialer {
+	return &Dialer{dial: p.DialContext(&net.Dialer{})}
+}
+
+// DialContext connects to addr if the policy allows it
+func (d *Dialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	return d.dial(ctx, network, addr)
+}
+
+// Dial connects to addr if the policy allows it
+func (d *Dialer) Dial(network, addr string) (net.Conn, error) {
+	return d.dial(context.Background(), network, addr)
+}
+
+// DialTimeout connects to addr within timeout if the policy allows it
+func (d *Dialer) DialTimeout(network, addr string, timeout time.Duration) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return d.dial(ctx, network, addr)
+}
+
+// Transport returns an HTTP transport that enforces the policy, or the default transport for a nil policy
+func (p *Policy) Transport() http.RoundTripper {
+	if p == nil {
+		return http.DefaultTransport
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Proxies would hide the real destination from the dialer
+	transport.Proxy = nil
+	transport.DialContext = p.DialContext(&net.Dialer{})
+	return transport
+}
@@ -0,0 +1,191 @@
+package egress
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writePolicy(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "egress.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write policy: %v", err)
+	}
+	return path
+}
+
+func TestResolveScopes(t *testing.T) {
+	cfg, err := LoadFile(writePolicy(t, `
+default:
+  allow: ["public.example.com"]
+projects:
+  proj-a:
+    allow: ["*.tenant-a.internal", "10.1.0.0/16"]
+    environments:
+      staging:
+        allow: ["127.0.0.1"]
+`))
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	Configure(cfg)
+	t.Cleanup(func() { Configure(nil) })
+
+	ctx := context.Background()
+
+	policy, err := Resolve(Scope{ProjectID: "proj-a"})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if !policy.allowsHost("api.tenant-a.internal") {
+		t.Error("expected wildcard host to be allowed")
+	}
+	if policy.allowsHost("api.tenant-b.internal") {
+		t.Error("expected other tenant host to be denied")
+	}
+	if err := policy.Check(ctx, "10.1.2.3:5432"); err != nil {
+		t.Errorf("expected CIDR address to be allowed: %v", err)
+	}
+	if err := policy.Check(ctx, "127.0.0.1:80"); err == nil {
+		t.Error("expected loopback to be denied without the staging rules")
+	}
+
+	staging, err := Resolve(Scope{ProjectID: "proj-a", Environment: "staging"})
+	if err != nil {
+		t.Fatalf("Resolve staging: %v", err)
+	}
+	if err := staging.Check(ctx, "127.0.0.1:80"); err != nil {
+		t.Errorf("expected staging rules to allow loopback: %v", err)
+	}
+
+	fallback, err := Resolve(Scope{ProjectID: "unknown"})
+	if err != nil {
+		t.Fatalf("Resolve unknown: %v", err)
+	}
+	if fallback == nil || !fallback.allowsHost("public.example.com") {
+		t.Error("expected unknown projects to fall back to the default rules")
+	}
+}
+
+func TestTransportBlocksDisallowedDestinations(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	allowed, err := compile([]string{"127.0.0.1"}, "allowed")
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	resp, err := (&http.Client{Transport: allowed.Transport()}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected request to be allowed: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	denied, err := compile([]string{"10.0.0.0/8"}, "denied")
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	_, err = (&http.Client{Transport: denied.Transport()}).Get(srv.URL)
+	if err == nil || !strings.Contains(err.Error(), "not allowed by the network policy") {
+		t.Fatalf("expected policy error, got %v", err)
+	}
+
+	var unrestricted *Policy
+	if unrestricted.Transport() != http.DefaultTransport {
+		t.Error("expected nil policy to use the default transport")
+	}
+}
+
+func TestLoadFileRejectsInvalidRules(t *testing.T) {
+	if _, err := LoadFile(writePolicy(t, "projects:\n  p:\n    allow: [\"10.0.0.0/99\"]\n")); err == nil {
+		t.Error("expected invalid CIDR to be rejected")
+	}
+	if _, err := LoadFile(writePolicy(t, "projects:\n  p:\n    allow: [\"api.*.com\"]\n")); err == nil {
+		t.Error("expected mid-label wildcard to be rejected")
+	}
+}
+
+func TestScopeFromParams(t *testing.T) {
+	scope := ScopeFromParams(map[string]interface{}{
+		ParamsKey: map[string]interface{}{"project_id": "p1", "environment": "prod"},
+	})
+	if scope.ProjectID != "p1" || scope.Environment != "prod" {
+		t.Errorf("unexpected scope: %+v", scope)
+	}
+}
+
+func TestProxyEnforcesPolicy(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("plain"))
+	}))
+	defer srv.Close()
+	tlsSrv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("tunnelled"))
+	}))
+	defer tlsSrv.Close()
+
+	get := func(proxy *Proxy, target string) (string, error) {
+		proxyURL, _ := url.Parse(proxy.URL)
+		transport := tlsSrv.Client().Transport.(*http.Transport).Clone()
+		transport.Proxy = http.ProxyURL(proxyURL)
+		resp, err := (&http.Client{Transport: transport}).Get(target)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("%d: %s", resp.StatusCode, body)
+		}
+		return string(body), nil
+	}
+
+	allowed, _ := compile([]string{"127.0.0.1"}, "allowed")
+	proxy, err := allowed.StartProxy()
+	if err != nil {
+		t.Fatalf("StartProxy: %v", err)
+	}
+	if body, err := get(proxy, srv.URL); err != nil || body != "plain" {
+		t.Errorf("expected plain HTTP to be forwarded, got %q (%v)", body, err)
+	}
+	if body, err := get(proxy, tlsSrv.URL); err != nil || body != "tunnelled" {
+		t.Errorf("expected HTTPS to be tunnelled, got %q (%v)", body, err)
+	}
+	_ = proxy.Close()
+
+	denied, _ := compile([]string{"10.0.0.0/8"}, "denied")
+	proxy, err = denied.StartProxy()
+	if err != nil {
+		t.Fatalf("StartProxy: %v", err)
+	}
+	defer proxy.Close()
+	if _, err := get(proxy, srv.URL); err == nil || !strings.Contains(err.Error(), "403: egress to 127.0.0.1 is not allowed by the network policy for denied") {
+		t.Errorf("expected plain HTTP to be refused, got %v", err)
+	}
+	if _, err := get(proxy, tlsSrv.URL); err == nil || !strings.Contains(err.Error(), "Forbidden") {
+		t.Errorf("expected the tunnel to be refused, got %v", err)
+	}
+}
+
+func TestProxyEnv(t *testing.T) {
+	proxy := &Proxy{URL: "http://127.0.0.1:41234"}
+	env := proxy.Env([]string{"PATH=/usr/bin", "https_proxy=http://corp:3128", "NO_PROXY=internal.example.com", "All_Proxy=socks5://x"})
+	want := []string{
+		"PATH=/usr/bin",
+		"HTTP_PROXY=http://127.0.0.1:41234", "HTTPS_PROXY=http://127.0.0.1:41234",
+		"http_proxy=http://127.0.0.1:41234", "https_proxy=http://127.0.0.1:41234",
+	}
+	if strings.Join(env, " ") != strings.Join(want, " ") {
+		t.Errorf("Env = %v, want %v", env, want)
+	}
+}
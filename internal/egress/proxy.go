@@ -0,0 +1,216 @@
+package egress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// proxyDialTimeout bounds connecting to a destination through a Proxy
+const proxyDialTimeout = 30 * time.Second
+
+// hopHeaders are the headers that only apply to one connection, so a proxy
+// doesn't forward them
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// Proxy is an HTTP proxy on the loopback interface that only forwards
+// connections the policy allows. It is for clients that can't dial through a
+// Policy themselves, such as browsers.
+type Proxy struct {
+	URL string // e.g. http://127.0.0.1:41234
+
+	dial      func(ctx context.Context, network, addr string) (net.Conn, error)
+	transport http.RoundTripper
+	server    *http.Server
+
+	mu      sync.Mutex
+	tunnels map[net.Conn]struct{} // Hijacked connections, which the server no longer closes
+}
+
+// StartProxy starts a proxy for the policy. A nil policy gets a proxy that
+// forwards everything. Close stops it.
+func (p *Policy) StartProxy() (*Proxy, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start egress proxy: %w", err)
+	}
+	proxy := &Proxy{
+		URL:       "http://" + listener.Addr().String(),
+		dial:      p.DialContext(&net.Dialer{Timeout: proxyDialTimeout}),
+		transport: p.Transport(),
+		tunnels:   make(map[net.Conn]struct{}),
+	}
+	proxy.server = &http.Server{Handler: proxy, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		_ = proxy.server.Serve(listener)
+	}()
+	return proxy, nil
+}
+
+// proxyEnvNames are the variables HTTP clients read their proxy settings from
+var proxyEnvNames = map[string]bool{
+	"HTTP_PROXY": true, "HTTPS_PROXY": true, "ALL_PROXY": true, "NO_PROXY": true,
+}
+
+// Env returns environ with its proxy settings replaced by the proxy, for child
+// processes whose HTTP clients honor HTTP_PROXY and HTTPS_PROXY. NO_PROXY is
+// dropped, so no host is reached around the proxy.
+func (x *Proxy) Env(environ []string) []string {
+	env := make([]string, 0, len(environ)+4)
+	for _, entry := range environ {
+		name, _, _ := strings.Cut(entry, "=")
+		if proxyEnvNames[strings.ToUpper(name)] {
+			continue
+		}
+		env = append(env, entry)
+	}
+	return append(env,
+		"HTTP_PROXY="+x.URL, "HTTPS_PROXY="+x.URL,
+		"http_proxy="+x.URL, "https_proxy="+x.URL,
+	)
+}
+
+// Close stops the proxy and drops the connections going through it
+func (x *Proxy) Close() error {
+	err := x.server.Close()
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for conn := range x.tunnels {
+		_ = conn.Close()
+	}
+	x.tunnels = nil
+	return err
+}
+
+// ServeHTTP tunnels CONNECT requests, which carry HTTPS and WebSockets, and
+// forwards plain HTTP requests
+func (x *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		x.tunnel(w, r)
+		return
+	}
+	if r.URL.Host == "" {
+		http.Error(w, "only proxy requests are accepted", http.StatusBadRequest)
+		return
+	}
+
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	removeHopHeaders(out.Header)
+	resp, err := x.transport.RoundTrip(out)
+	if err != nil {
+		x.fail(w, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	removeHopHeaders(resp.Header)
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+}
+
+// tunnel connects to the requested host and copies bytes both ways until
+// either side closes
+func (x *Proxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	upstream, err := x.dial(r.Context(), "tcp", r.Host)
+	if err != nil {
+		x.fail(w, err)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		_ = upstream.Close()
+		http.Error(w, "tunnels are not supported", http.StatusInternalServerError)
+		return
+	}
+	client, buffered, err := hijacker.Hijack()
+	if err != nil {
+		_ = upstream.Close()
+		return
+	}
+	if !x.track(client, upstream) {
+		return
+	}
+	defer x.untrack(client, upstream)
+
+	if _, err := client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		return
+	}
+	done := make(chan struct{}, 2)
+	go func() {
+		// The client may have sent the start of the tunnel with the request
+		_, _ = io.Copy(upstream, buffered)
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(client, upstream)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+// track remembers a tunnel's connections so Close can drop them. It closes
+// them and returns false when the proxy is already closed.
+func (x *Proxy) track(conns ...net.Conn) bool {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.tunnels == nil {
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+		return false
+	}
+	for _, conn := range conns {
+		x.tunnels[conn] = struct{}{}
+	}
+	return true
+}
+
+func (x *Proxy) untrack(conns ...net.Conn) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for _, conn := range conns {
+		_ = conn.Close()
+		delete(x.tunnels, conn)
+	}
+}
+
+// fail answers a request the proxy couldn't forward: 403 when the policy
+// denied it, 502 otherwise
+func (x *Proxy) fail(w http.ResponseWriter, err error) {
+	status := http.StatusBadGateway
+	if errors.Is(err, errDenied) {
+		status = http.StatusForbidden
+	}
+	http.Error(w, err.Error(), status)
+}
+
+func removeHopHeaders(header http.Header) {
+	for _, name := range strings.Split(header.Get("Connection"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			header.Del(name)
+		}
+	}
+	for _, name := range hopHeaders {
+		header.Del(name)
+	}
+}
@@ -0,0 +1,25 @@
+package interpreter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+)
+
+type EnvCheckInput struct {
+	Required []string          `json:"required"`
+	Env      map[string]string `json:"env"`
+}
+
+// EnvCheckActivity fails when env vars the suite requires are set neither on the
+// worker nor in the run's project environment, which is where {{ .env.* }}
+// templates read them from. Every missing variable is named, not just the first.
+func EnvCheckActivity(ctx context.Context, input EnvCheckInput) error {
+	missing := dsl.MissingEnv(input.Required, input.Env)
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("suite requires environment variables that are not set: %s; set them where the worker runs or add them to the project environment (--env)", strings.Join(missing, ", "))
+}
@@ -0,0 +1,60 @@
+package interpreter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/rocketship-ai/rocketship/internal/artifacts"
+	"github.com/rocketship-ai/rocketship/internal/buildinfo"
+	"go.temporal.io/sdk/activity"
+)
+
+// harLog is the root of a HAR 1.2 file
+type harLog struct {
+	Log struct {
+		Version string `json:"version"`
+		Creator struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"creator"`
+		Entries []interface{} `json:"entries"`
+		Comment string        `json:"comment,omitempty"`
+	} `json:"log"`
+}
+
+// HARWriterActivity writes the HAR entries a test's http steps recorded as a
+// HAR artifact of the run
+func HARWriterActivity(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	logger := activity.GetLogger(ctx)
+
+	runID, _ := params["run_id"].(string)
+	testName, _ := params["test_name"].(string)
+	entries, ok := params["entries"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("entries must be a list of HAR entries")
+	}
+
+	var har harLog
+	har.Log.Version = "1.2"
+	har.Log.Creator.Name = "rocketship"
+	har.Log.Creator.Version = buildinfo.CurrentWorker().Version
+	har.Log.Entries = entries
+	har.Log.Comment = testName
+
+	data, err := json.MarshalIndent(har, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode HAR: %w", err)
+	}
+	name := testName
+	if name == "" {
+		name = "test"
+	}
+	artifact, err := artifacts.Save(runID, name+".har", "har", "application/json", data)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Saved HAR artifact", "test", testName, "entries", len(entries), "path", artifact.Path)
+	return artifact, nil
+}
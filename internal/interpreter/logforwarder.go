@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"github.com/rocketship-ai/rocketship/internal/cli"
+	"github.com/rocketship-ai/rocketship/internal/vault"
 	"go.temporal.io/sdk/activity"
 )
 
@@ -36,10 +37,22 @@ func LogForwarderActivity(ctx context.Context, params map[string]interface{}) (i
 		return nil, fmt.Errorf("message is required")
 	}
 
+	// Plugins may echo env secrets resolved from Vault on this worker
+	message = vault.MaskString(message)
+
 	color, _ := params["color"].(string)
 	bold, _ := params["bold"].(bool)
 	testName, _ := params["test_name"].(string)
 	stepName, _ := params["step_name"].(string)
+	level, _ := params["level"].(string)
+
+	var fields map[string]string
+	if raw, ok := params["fields"].(map[string]interface{}); ok && len(raw) > 0 {
+		fields = make(map[string]string, len(raw))
+		for key, value := range raw {
+			fields[key] = vault.MaskString(fmt.Sprint(value))
+		}
+	}
 
 	// Get engine address from environment or use default
 	engineAddr := os.Getenv(EnvEngineGRPCAddr)
@@ -56,7 +69,7 @@ func LogForwarderActivity(ctx context.Context, params map[string]interface{}) (i
 	defer func() { _ = client.Close() }()
 
 	// Send log message to engine
-	if err := client.AddLogWithContext(ctx, runID, workflowID, message, color, bold, testName, stepName); err != nil {
+	if err := client.AddLogWithContext(ctx, runID, workflowID, message, color, bold, testName, stepName, level, fields); err != nil {
 		logger.Error("Failed to send log to engine", "error", err)
 		return nil, fmt.Errorf("failed to send log to engine: %w", err)
 	}
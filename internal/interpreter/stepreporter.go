@@ -26,6 +26,7 @@ type StepReportParams struct {
 	AssertionsFailed int                    `json:"assertions_failed"`
 	RequestData      map[string]interface{} `json:"request_data,omitempty"`
 	ResponseData     map[string]interface{} `json:"response_data,omitempty"`
+	WorkerVersion    string                 `json:"worker_version,omitempty"`
 }
 
 // StepReporterActivity forwards step execution reports to the engine for persistence
@@ -65,6 +66,7 @@ func StepReporterActivity(ctx context.Context, params map[string]interface{}) (i
 	}
 
 	errorMessage, _ := params["error_message"].(string)
+	workerVersion, _ := params["worker_version"].(string)
 	startedAt, _ := params["started_at"].(string)
 	endedAt, _ := params["ended_at"].(string)
 
@@ -94,8 +96,8 @@ func StepReporterActivity(ctx context.Context, params map[string]interface{}) (i
 		}
 	}
 
-	// Extract assertions_data, variables_data, step_config_data for rich step details
-	var assertionsJSON, variablesJSON, stepConfigJSON []byte
+	// Extract assertions_data, variables_data, step_config_data and artifacts_data for rich step details
+	var assertionsJSON, variablesJSON, stepConfigJSON, artifactsJSON []byte
 	if assertionsData, ok := params["assertions_data"].([]interface{}); ok && len(assertionsData) > 0 {
 		if data, err := json.Marshal(assertionsData); err == nil {
 			assertionsJSON = data
@@ -111,6 +113,11 @@ func StepReporterActivity(ctx context.Context, params map[string]interface{}) (i
 			stepConfigJSON = data
 		}
 	}
+	if artifactsData, ok := params["artifacts_data"].([]interface{}); ok && len(artifactsData) > 0 {
+		if data, err := json.Marshal(artifactsData); err == nil {
+			artifactsJSON = data
+		}
+	}
 
 	// Get engine address from environment or use default
 	engineAddr := os.Getenv(EnvEngineGRPCAddr)
@@ -145,6 +152,8 @@ func StepReporterActivity(ctx context.Context, params map[string]interface{}) (i
 		AssertionsJSON:   assertionsJSON,
 		VariablesJSON:    variablesJSON,
 		StepConfigJSON:   stepConfigJSON,
+		ArtifactsJSON:    artifactsJSON,
+		WorkerVersion:    workerVersion,
 	})
 	if err != nil {
 		logger.Error("Failed to send step report to engine", "error", err)
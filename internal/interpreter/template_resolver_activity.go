@@ -5,12 +5,16 @@ import (
 	"fmt"
 
 	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+	"github.com/rocketship-ai/rocketship/internal/vault"
 )
 
 type TemplateResolveInput struct {
 	Template string            `json:"template"`
 	Runtime  map[string]string `json:"runtime"`
 	Env      map[string]string `json:"env"`
+	// Scope is the run's project and environment, for Vault secrets paths
+	Scope map[string]interface{} `json:"scope,omitempty"`
 }
 
 func TemplateResolverActivity(ctx context.Context, input TemplateResolveInput) (string, error) {
@@ -19,12 +23,17 @@ func TemplateResolverActivity(ctx context.Context, input TemplateResolveInput) (
 		runtime[key] = value
 	}
 
+	env, err := vault.ResolveEnv(ctx, input.Env, egress.ScopeFromParams(map[string]interface{}{egress.ParamsKey: input.Scope}))
+	if err != nil {
+		return "", err
+	}
+
 	out, err := dsl.ProcessTemplate(input.Template, dsl.TemplateContext{
 		Runtime: runtime,
-		Env:     input.Env,
+		Env:     env,
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to resolve template: %w", err)
+		return "", vault.MaskError(fmt.Errorf("failed to resolve template: %w", err))
 	}
 	return out, nil
 }
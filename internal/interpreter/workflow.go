@@ -26,6 +26,13 @@ func TestWorkflow(
 		},
 	}
 	ctx = workflow.WithActivityOptions(ctx, baseAO)
+	if test.CookieJar {
+		ctx = withCookieJar(ctx)
+	}
+	if test.HAR {
+		ctx = withHARRecorder(ctx)
+	}
+	ctx = withWebhookHub(ctx)
 
 	state := make(map[string]string)
 	logger.Info("Initialized workflow state", "state", state)
@@ -33,6 +40,12 @@ func TestWorkflow(
 	// Clone vars to avoid mutating shared maps and inject suite-wide saved values
 	runtimeVars := cloneVars(vars)
 	injectSuiteGlobals(state, suiteGlobals)
+	injectMatrixParams(state, test.MatrixParams)
+	injectDataRow(state, test.DataRow)
+
+	if err := checkRequiredEnv(ctx, runID, test, envSecrets); err != nil {
+		return nil, err
+	}
 
 	var primaryErr error
 
@@ -41,7 +54,9 @@ func TestWorkflow(
 	}
 
 	if primaryErr == nil {
-		if err := runStepSequence(ctx, runID, test.Name, phaseMain, test.Steps, state, runtimeVars, suiteOpenAPI, nil, true, envSecrets); err != nil {
+		if test.ForEach != nil {
+			primaryErr = runTestIterations(ctx, runID, test, state, runtimeVars, suiteOpenAPI, envSecrets)
+		} else if err := runStepSequence(ctx, runID, test.Name, phaseMain, test.Steps, state, runtimeVars, suiteOpenAPI, nil, true, envSecrets); err != nil {
 			primaryErr = err
 		}
 	}
@@ -52,6 +67,9 @@ func TestWorkflow(
 		logger.Warn("Cleanup sequence reported errors", "error", err)
 	}
 
+	// Written last so cleanup requests are in the HAR too
+	writeHAR(ctx, runID, test.Name)
+
 	if primaryErr != nil {
 		return state, primaryErr
 	}
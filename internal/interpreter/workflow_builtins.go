@@ -6,21 +6,25 @@ import (
 	"github.com/rocketship-ai/rocketship/internal/dsl"
 )
 
-type workflowBuiltinExecutor func(ctx workflow.Context, step dsl.Step, testName, runID string, state map[string]string, envSecrets map[string]string) (interface{}, error)
+type workflowBuiltinExecutor func(ctx workflow.Context, step dsl.Step, testName, runID string, state map[string]string, vars map[string]interface{}, suiteOpenAPI *dsl.OpenAPISuiteConfig, opts *executionOptions, envSecrets map[string]string) (interface{}, error)
 
 var workflowBuiltinExecutors = map[string]workflowBuiltinExecutor{
 	// workflow-native steps should be implemented here instead of Activities
 	// to avoid tying up worker capacity (e.g. delay).
-	"delay": func(ctx workflow.Context, step dsl.Step, testName, runID string, state map[string]string, envSecrets map[string]string) (interface{}, error) {
+	"delay": func(ctx workflow.Context, step dsl.Step, testName, runID string, state map[string]string, vars map[string]interface{}, suiteOpenAPI *dsl.OpenAPISuiteConfig, opts *executionOptions, envSecrets map[string]string) (interface{}, error) {
 		return nil, handleDelayStep(ctx, step, testName, runID, state, envSecrets)
 	},
+	// poll sleeps between attempts in the workflow, so waiting holds no worker slot
+	"poll": handlePollStep,
+	// webhook_wait waits in the workflow, where the engine signals the callback
+	"webhook_wait": handleWebhookWaitStep,
 }
 
-func executeWorkflowBuiltinStep(ctx workflow.Context, step dsl.Step, testName, runID string, state map[string]string, envSecrets map[string]string) (interface{}, bool, error) {
+func executeWorkflowBuiltinStep(ctx workflow.Context, step dsl.Step, testName, runID string, state map[string]string, vars map[string]interface{}, suiteOpenAPI *dsl.OpenAPISuiteConfig, opts *executionOptions, envSecrets map[string]string) (interface{}, bool, error) {
 	executor, ok := workflowBuiltinExecutors[step.Plugin]
 	if !ok {
 		return nil, false, nil
 	}
-	resp, err := executor(ctx, step, testName, runID, state, envSecrets)
+	resp, err := executor(ctx, step, testName, runID, state, vars, suiteOpenAPI, opts, envSecrets)
 	return resp, true, err
 }
@@ -0,0 +1,49 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/vault"
+)
+
+// stepSkipReason evaluates a step's when and unless conditions. It returns why
+// the step is skipped, or "" when it should run. The reason quotes the
+// conditions as written, so rendered secrets don't reach the logs.
+func stepSkipReason(ctx workflow.Context, step dsl.Step, state map[string]string, envSecrets map[string]string) (string, error) {
+	if step.When != "" {
+		holds, err := evaluateStepCondition(ctx, step.When, state, envSecrets)
+		if err != nil {
+			return "", fmt.Errorf("invalid when condition: %w", err)
+		}
+		if !holds {
+			return fmt.Sprintf("when %q is false", step.When), nil
+		}
+	}
+	if step.Unless != "" {
+		holds, err := evaluateStepCondition(ctx, step.Unless, state, envSecrets)
+		if err != nil {
+			return "", fmt.Errorf("invalid unless condition: %w", err)
+		}
+		if holds {
+			return fmt.Sprintf("unless %q is true", step.Unless), nil
+		}
+	}
+	return "", nil
+}
+
+// evaluateStepCondition renders a condition's templates and evaluates it
+func evaluateStepCondition(ctx workflow.Context, condition string, state map[string]string, envSecrets map[string]string) (bool, error) {
+	rendered, err := resolveStepTemplate(ctx, condition, state, envSecrets)
+	if err != nil {
+		return false, err
+	}
+	holds, err := dsl.EvaluateCondition(rendered)
+	if err != nil {
+		// The error can quote rendered values
+		return false, vault.MaskError(err)
+	}
+	return holds, nil
+}
@@ -0,0 +1,219 @@
+package interpreter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+	"github.com/rocketship-ai/rocketship/internal/vault"
+)
+
+// maxItemLabel caps how much of an item the iteration logs quote
+const maxItemLabel = 80
+
+// forEachItems resolves a for_each's items: a list as written, a {{ .vars.* }}
+// list, or a template that renders a JSON array, such as a saved value
+func forEachItems(ctx workflow.Context, forEach *dsl.ForEach, state map[string]string, vars map[string]interface{}, envSecrets map[string]string) ([]interface{}, error) {
+	switch items := forEach.Items.(type) {
+	case []interface{}:
+		return items, nil
+	case string:
+		if value, ok := dsl.LookupVarsReference(items, vars); ok {
+			list, ok := value.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("for_each items %s is not a list", strings.TrimSpace(items))
+			}
+			return list, nil
+		}
+		rendered, err := resolveStepTemplate(ctx, items, state, envSecrets)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve for_each items: %w", err)
+		}
+		var list []interface{}
+		if err := json.Unmarshal([]byte(rendered), &list); err != nil {
+			return nil, fmt.Errorf("for_each items %s must be a JSON array, got %s", strings.TrimSpace(items), itemLabel(rendered))
+		}
+		return list, nil
+	case nil:
+		return nil, fmt.Errorf("for_each items are required")
+	}
+	return nil, fmt.Errorf("for_each items must be a list or a template, got %T", forEach.Items)
+}
+
+// iterationState copies state and adds the item and its index. Object items are
+// flattened, so templates reach their fields as {{ item.id }}.
+func iterationState(state map[string]string, forEach *dsl.ForEach, index int, item interface{}) map[string]string {
+	scoped := make(map[string]string, len(state)+2)
+	for _, k := range workflow.DeterministicKeys(state) {
+		scoped[k] = state[k]
+	}
+	scoped["index"] = strconv.Itoa(index)
+	setStateValue(scoped, forEach.ItemName(), item)
+	return scoped
+}
+
+// setStateValue stores a value in state under key, flattening objects into
+// dotted keys, e.g. user.id
+func setStateValue(state map[string]string, key string, value interface{}) {
+	if fields, ok := value.(map[string]interface{}); ok && len(fields) > 0 {
+		for _, k := range workflow.DeterministicKeys(fields) {
+			setStateValue(state, key+"."+k, fields[k])
+		}
+		return
+	}
+	formatted, err := saves.Format(value, "")
+	if err != nil {
+		formatted = fmt.Sprintf("%v", value)
+	}
+	state[key] = formatted
+}
+
+// isIterationKey reports whether a state key holds the current item or its index
+func isIterationKey(forEach *dsl.ForEach, key string) bool {
+	name := forEach.ItemName()
+	return key == "index" || key == name || strings.HasPrefix(key, name+".")
+}
+
+// mergeIterationSaves copies the values an iteration saved back into state.
+// Only values that differ from before, the state the iteration started from,
+// are merged, so an iteration that saved nothing doesn't undo what the ones
+// before it saved.
+func mergeIterationSaves(state, before, iteration map[string]string, forEach *dsl.ForEach) {
+	for _, k := range workflow.DeterministicKeys(iteration) {
+		if isIterationKey(forEach, k) {
+			continue
+		}
+		if value, ok := before[k]; !ok || value != iteration[k] {
+			state[k] = iteration[k]
+		}
+	}
+}
+
+// itemLabel is how iteration logs and errors quote an item
+func itemLabel(item interface{}) string {
+	label, ok := item.(string)
+	if !ok {
+		encoded, err := json.Marshal(item)
+		if err != nil {
+			label = fmt.Sprintf("%v", item)
+		} else {
+			label = string(encoded)
+		}
+	}
+	label = vault.MaskString(label)
+	if len(label) > maxItemLabel {
+		label = label[:maxItemLabel] + "..."
+	}
+	return strconv.Quote(label)
+}
+
+// iterationResult is the outcome of one iteration
+type iterationResult struct {
+	resp  interface{}
+	state map[string]string
+	err   error
+}
+
+// runIterations runs body once per item, one after another or in parallel.
+// Sequential iterations see the values saved by the ones before them and stop
+// at the first failure; parallel iterations all run, and their saves are merged
+// in item order once they finish. It returns the last iteration's response, or
+// the first failed one's.
+func runIterations(ctx workflow.Context, runID, testName, logName string, forEach *dsl.ForEach, items []interface{}, state map[string]string, body func(ctx workflow.Context, index int, state map[string]string) (interface{}, error)) (interface{}, error) {
+	total := len(items)
+	start := func(ctx workflow.Context, index int) iterationResult {
+		scoped := iterationState(state, forEach, index, items[index])
+		sendStepLog(ctx, runID, testName, logName, fmt.Sprintf("Iteration %d/%d: %s = %s", index+1, total, forEach.ItemName(), itemLabel(items[index])), "n/a", false)
+		resp, err := body(ctx, index, scoped)
+		return iterationResult{resp: resp, state: scoped, err: err}
+	}
+
+	if !forEach.Parallel || total == 1 {
+		var resp interface{}
+		for i := range items {
+			result := start(ctx, i)
+			mergeIterationSaves(state, state, result.state, forEach)
+			resp = result.resp
+			if result.err != nil {
+				return resp, iterationError(forEach, i, items, result.err)
+			}
+		}
+		return resp, nil
+	}
+
+	before := make(map[string]string, len(state))
+	for _, k := range workflow.DeterministicKeys(state) {
+		before[k] = state[k]
+	}
+	limit := total
+	if forEach.MaxParallel > 0 && forEach.MaxParallel < limit {
+		limit = forEach.MaxParallel
+	}
+	slots := workflow.NewBufferedChannel(ctx, limit)
+	results := make([]iterationResult, total)
+	wg := workflow.NewWaitGroup(ctx)
+	for i := range items {
+		slots.Send(ctx, struct{}{})
+		wg.Add(1)
+		workflow.Go(ctx, func(ctx workflow.Context) {
+			defer wg.Done()
+			defer slots.Receive(ctx, nil)
+			results[i] = start(ctx, i)
+		})
+	}
+	wg.Wait(ctx)
+
+	var failed []int
+	for i, result := range results {
+		mergeIterationSaves(state, before, result.state, forEach)
+		if result.err != nil {
+			failed = append(failed, i)
+		}
+	}
+	switch len(failed) {
+	case 0:
+		return results[total-1].resp, nil
+	case 1:
+		i := failed[0]
+		return results[i].resp, iterationError(forEach, i, items, results[i].err)
+	}
+	messages := make([]string, len(failed))
+	for n, i := range failed {
+		messages[n] = fmt.Sprintf("iteration %d (%s %s): %s", i+1, forEach.ItemName(), itemLabel(items[i]), ExtractCleanError(results[i].err))
+	}
+	return results[failed[0]].resp, fmt.Errorf("%d of %d iterations failed: %s", len(failed), total, strings.Join(messages, "; "))
+}
+
+// iterationError names the iteration that failed and its item
+func iterationError(forEach *dsl.ForEach, index int, items []interface{}, err error) error {
+	return fmt.Errorf("iteration %d/%d (%s %s) failed: %w", index+1, len(items), forEach.ItemName(), itemLabel(items[index]), err)
+}
+
+// runTestIterations runs a for_each test's steps once per item. Each iteration's
+// steps are reported after the ones before it, with the iteration's index in
+// their names.
+func runTestIterations(ctx workflow.Context, runID string, test dsl.Test, state map[string]string, vars map[string]interface{}, suiteOpenAPI *dsl.OpenAPISuiteConfig, envSecrets map[string]string) error {
+	items, err := forEachItems(ctx, test.ForEach, state, vars, envSecrets)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		sendStepLog(ctx, runID, test.Name, "", "for_each has no items, skipping the test's steps", "n/a", false)
+		return nil
+	}
+
+	_, err = runIterations(ctx, runID, test.Name, "", test.ForEach, items, state, func(ctx workflow.Context, index int, state map[string]string) (interface{}, error) {
+		steps := make([]dsl.Step, len(test.Steps))
+		for i, step := range test.Steps {
+			step.Name = fmt.Sprintf("%s [%d]", step.Name, index)
+			steps[i] = step
+		}
+		return nil, runStepSequenceFrom(ctx, runID, test.Name, phaseMain, index*len(test.Steps), steps, state, vars, suiteOpenAPI, nil, true, envSecrets)
+	})
+	return err
+}
@@ -7,8 +7,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/rocketship-ai/rocketship/internal/artifacts"
 	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
 	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"go.temporal.io/sdk/converter"
 	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 )
@@ -32,6 +35,127 @@ func ExtractCleanError(err error) string {
 	return errMsg
 }
 
+// Memo keys the engine sets on workflows to identify the tenant a run belongs to
+const (
+	MemoProjectID   = "project_id"
+	MemoEnvironment = "environment"
+)
+
+// cookieJarKey is the workflow context key holding a test's cookie jar
+type cookieJarKey struct{}
+
+// cookieJar holds the Set-Cookie headers http steps received during a test with
+// cookie_jar enabled. Activities are stateless, so every http step is sent the
+// records so far and returns the ones it added; the plugin rebuilds the jar from them.
+type cookieJar struct {
+	records []interface{}
+}
+
+// withCookieJar attaches an empty cookie jar to the test's context
+func withCookieJar(ctx workflow.Context) workflow.Context {
+	return workflow.WithValue(ctx, cookieJarKey{}, &cookieJar{records: []interface{}{}})
+}
+
+// testCookieJar returns the test's cookie jar, or nil when it's not enabled
+func testCookieJar(ctx workflow.Context) *cookieJar {
+	jar, _ := ctx.Value(cookieJarKey{}).(*cookieJar)
+	return jar
+}
+
+// add appends the records an http step returned under cookie_jar
+func (j *cookieJar) add(activityResp interface{}) {
+	resp, ok := activityResp.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if records, ok := resp["cookie_jar"].([]interface{}); ok {
+		j.records = append(j.records, records...)
+	}
+}
+
+// harRecorderKey is the workflow context key holding a test's HAR entries
+type harRecorderKey struct{}
+
+// harRecorder holds the HAR entries http steps returned during a test with har
+// enabled, until the test ends and they are written as an artifact
+type harRecorder struct {
+	entries []interface{}
+}
+
+// withHARRecorder attaches an empty HAR recorder to the test's context
+func withHARRecorder(ctx workflow.Context) workflow.Context {
+	return workflow.WithValue(ctx, harRecorderKey{}, &harRecorder{entries: []interface{}{}})
+}
+
+// testHARRecorder returns the test's HAR recorder, or nil when it's not enabled
+func testHARRecorder(ctx workflow.Context) *harRecorder {
+	recorder, _ := ctx.Value(harRecorderKey{}).(*harRecorder)
+	return recorder
+}
+
+// add appends the entry an http step returned under har
+func (r *harRecorder) add(activityResp interface{}) {
+	resp, ok := activityResp.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if entry, ok := resp["har_entry"].(map[string]interface{}); ok {
+		r.entries = append(r.entries, entry)
+	}
+}
+
+// writeHAR saves the recorded entries as the test's HAR artifact and notes
+// where it is in the run's logs. Failing to write it doesn't fail the test.
+func writeHAR(ctx workflow.Context, runID, testName string) {
+	recorder := testHARRecorder(ctx)
+	if recorder == nil || len(recorder.entries) == 0 {
+		return
+	}
+	logger := workflow.GetLogger(ctx)
+
+	var artifact map[string]interface{}
+	err := workflow.ExecuteActivity(ctx, "HARWriterActivity", map[string]interface{}{
+		"run_id":    runID,
+		"test_name": testName,
+		"entries":   recorder.entries,
+	}).Get(ctx, &artifact)
+	if err != nil {
+		logger.Warn("Failed to write HAR artifact", "error", err)
+		return
+	}
+
+	path, _ := artifact["path"].(string)
+	message := fmt.Sprintf("HAR with %d requests saved to %s", len(recorder.entries), path)
+	if err := forwardLogMessage(ctx, map[string]interface{}{"log_message": message}, runID, testName, ""); err != nil {
+		logger.Warn("Failed to forward HAR log message", "error", err)
+	}
+}
+
+// egressScope reads the tenant memo so plugins can apply the worker's network policy.
+// Suite YAML cannot influence it; only the engine sets workflow memos.
+func egressScope(ctx workflow.Context) map[string]interface{} {
+	memo := workflow.GetInfo(ctx).Memo
+	if memo == nil || len(memo.Fields) == 0 {
+		return nil
+	}
+
+	scope := make(map[string]interface{})
+	for _, key := range []string{MemoProjectID, MemoEnvironment} {
+		payload, ok := memo.Fields[key]
+		if !ok {
+			continue
+		}
+		var value string
+		if err := converter.GetDefaultDataConverter().FromPayload(payload, &value); err == nil && value != "" {
+			scope[key] = value
+		}
+	}
+	if len(scope) == 0 {
+		return nil
+	}
+	return scope
+}
+
 type stepPhase string
 
 const (
@@ -102,6 +226,9 @@ func (p stepPhase) wrapError(idx int, name string, err error) error {
 type executionOptions struct {
 	ActivityTimeout time.Duration
 	RetryPolicy     *temporal.RetryPolicy
+	// Params are added to the plugin's parameters, e.g. the phase of a step the
+	// workflow runs in parts
+	Params map[string]interface{}
 }
 
 func cloneVars(source map[string]interface{}) map[string]interface{} {
@@ -128,6 +255,22 @@ func injectSuiteGlobals(state map[string]string, suiteGlobals map[string]string)
 	}
 }
 
+// injectMatrixParams adds a matrix test's combination to state, as {{ matrix.* }}
+func injectMatrixParams(state map[string]string, params map[string]interface{}) {
+	if len(params) == 0 {
+		return
+	}
+	setStateValue(state, "matrix", params)
+}
+
+// injectDataRow adds a data test's row to state, as {{ row.* }}
+func injectDataRow(state map[string]string, row map[string]interface{}) {
+	if len(row) == 0 {
+		return
+	}
+	setStateValue(state, "row", row)
+}
+
 func runStepSequence(
 	ctx workflow.Context,
 	runID string,
@@ -140,24 +283,100 @@ func runStepSequence(
 	opts *executionOptions,
 	stopOnError bool,
 	envSecrets map[string]string,
+) error {
+	return runStepSequenceFrom(ctx, runID, testName, phase, 0, steps, state, vars, suiteOpenAPI, opts, stopOnError, envSecrets)
+}
+
+// runStepSequenceFrom runs steps reporting them from firstIndex on, so a for_each
+// test's iterations don't overwrite each other's step reports
+func runStepSequenceFrom(
+	ctx workflow.Context,
+	runID string,
+	testName string,
+	phase stepPhase,
+	firstIndex int,
+	steps []dsl.Step,
+	state map[string]string,
+	vars map[string]interface{},
+	suiteOpenAPI *dsl.OpenAPISuiteConfig,
+	opts *executionOptions,
+	stopOnError bool,
+	envSecrets map[string]string,
 ) error {
 	if len(steps) == 0 {
 		return nil
 	}
 
+	// Cleanup phases run every step and report the first failure, as they did
+	// before continue_on_failure. Failures are only aggregated for steps that
+	// set it.
 	var firstErr error
-	for idx, step := range steps {
-		if err := executeStep(ctx, runID, testName, phase, idx, step, state, vars, suiteOpenAPI, opts, envSecrets); err != nil {
-			if firstErr == nil {
-				firstErr = err
+	var failures []error
+	for idx := 0; idx < len(steps); idx++ {
+		// A parallel group's steps run together and count as one step here:
+		// the sequence stops after the group unless every failed step in it
+		// continues on failure
+		end := idx + 1
+		var errs []error
+		if steps[idx].Group != nil {
+			end = parallelGroupEnd(steps, idx)
+			errs = runParallelGroup(ctx, runID, testName, phase, firstIndex+idx, steps[idx:end], state, vars, suiteOpenAPI, opts, envSecrets)
+		} else {
+			errs = []error{executeStep(ctx, runID, testName, phase, firstIndex+idx, steps[idx], state, vars, suiteOpenAPI, opts, envSecrets)}
+		}
+
+		stop := false
+		logName, logMessage := "", ""
+		for i, err := range errs {
+			if err == nil {
+				continue
+			}
+			step := steps[idx+i]
+			if !stopOnError {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
 			}
-			if stopOnError {
-				return firstErr
+			failures = append(failures, err)
+			logName = step.Name
+			var softErr *softAssertionError
+			if errors.As(err, &softErr) {
+				logMessage = "soft assertions failed, running remaining steps"
+				continue
 			}
+			stop = stop || !step.ContinueOnFailure
+			logMessage = "continue_on_failure is set, running remaining steps"
+		}
+		if stop {
+			break
+		}
+		if logName != "" && end < len(steps) {
+			sendStepLog(ctx, runID, testName, logName, logMessage, "n/a", false)
 		}
+		idx = end - 1
 	}
 
-	return firstErr
+	if !stopOnError {
+		return firstErr
+	}
+	return aggregateStepErrors(failures)
+}
+
+// aggregateStepErrors returns the only failure unchanged, or a single error listing every failed step
+func aggregateStepErrors(failures []error) error {
+	switch len(failures) {
+	case 0:
+		return nil
+	case 1:
+		return failures[0]
+	}
+
+	messages := make([]string, len(failures))
+	for i, err := range failures {
+		messages[i] = ExtractCleanError(err)
+	}
+	return fmt.Errorf("%d steps failed: %s", len(failures), strings.Join(messages, "; "))
 }
 
 func executeStep(
@@ -190,18 +409,70 @@ func executeStep(
 	// These are YAML-defined variables accessed via {{ .vars.* }}
 	availableConfigVars := cloneVars(vars)
 
+	// Steps whose when or unless condition rules them out are skipped, not run,
+	// as are for_each steps without items
+	skipReason, err := stepSkipReason(ctx, step, state, envSecrets)
+	var items []interface{}
+	if err == nil && skipReason == "" && step.ForEach != nil {
+		items, err = forEachItems(ctx, step.ForEach, state, vars, envSecrets)
+		if err == nil && len(items) == 0 {
+			skipReason = "for_each has no items"
+		}
+	}
+	if err == nil && skipReason != "" {
+		sendStepLog(ctx, runID, testName, step.Name, fmt.Sprintf("Skipping step %q: %s", step.Name, skipReason), "n/a", false)
+		logger.Info("Step skipped", "phase", string(phase), "step", step.Name, "reason", skipReason)
+		endTime := workflow.Now(ctx)
+		sendStepReportWithDetails(ctx, runID, index, step, "SKIPPED", "", startTime, endTime, endTime.Sub(startTime).Milliseconds(), 0, 0, nil, availableRuntimeState, availableConfigVars)
+		return nil
+	}
+
 	sendStepLog(ctx, runID, testName, step.Name, phase.startMessage(step.Name), "n/a", false)
 
 	// Report step as RUNNING
 	sendStepReport(ctx, runID, index, step, "RUNNING", "", startTime, time.Time{}, nil)
 
-	var err error
+	var retry *stepRetry
+	if err == nil {
+		retry, err = parseStepRetry(step.Retry)
+	}
+	// run runs the step once against state; for_each steps run it per item, and
+	// retries apply to each run. The soft assertions that fail in any run are
+	// collected, so they fail the step once it has finished.
+	var softFailures []string
+	run := func(ctx workflow.Context, state map[string]string) (interface{}, error) {
+		once := func() (interface{}, error) {
+			if resp, handled, err := executeWorkflowBuiltinStep(ctx, step, testName, runID, state, vars, suiteOpenAPI, opts, envSecrets); handled {
+				return resp, err
+			}
+			return executePluginWithResponse(ctx, step, state, vars, runID, testName, suiteOpenAPI, opts, envSecrets)
+		}
+		var resp interface{}
+		var err error
+		if retry != nil {
+			resp, err = runWithRetry(ctx, retry, runID, testName, step, once)
+		} else {
+			resp, err = once()
+		}
+		if err == nil {
+			softFailures = append(softFailures, softAssertionFailures(resp)...)
+		}
+		return resp, err
+	}
+
 	var activityResp interface{}
-	if resp, handled, stepErr := executeWorkflowBuiltinStep(ctx, step, testName, runID, state, envSecrets); handled {
-		activityResp = resp
-		err = stepErr
-	} else {
-		activityResp, err = executePluginWithResponse(ctx, step, state, vars, runID, testName, suiteOpenAPI, opts, envSecrets)
+	if err == nil {
+		if step.ForEach != nil {
+			activityResp, err = runIterations(ctx, runID, testName, step.Name, step.ForEach, items, state, func(ctx workflow.Context, _ int, state map[string]string) (interface{}, error) {
+				return run(ctx, state)
+			})
+		} else {
+			activityResp, err = run(ctx, state)
+		}
+	}
+
+	if err == nil && len(softFailures) > 0 {
+		err = &softAssertionError{failures: softFailures}
 	}
 
 	// Capture end time and compute duration
@@ -321,34 +592,9 @@ func handleDelayStep(ctx workflow.Context, step dsl.Step, testName, runID string
 		return fmt.Errorf("step %q: duration is required and must be a string", step.Name)
 	}
 
-	resolvedDurationStr := durationStr
-	if strings.Contains(durationStr, "{{") {
-		ao := workflow.ActivityOptions{
-			StartToCloseTimeout: 10 * time.Second,
-			RetryPolicy: &temporal.RetryPolicy{
-				MaximumAttempts: 1,
-			},
-		}
-		actCtx := workflow.WithActivityOptions(ctx, ao)
-
-		runtime := state
-		if runtime == nil {
-			runtime = map[string]string{}
-		}
-		env := envSecrets
-		if env == nil {
-			env = map[string]string{}
-		}
-
-		var resolved string
-		if err := workflow.ExecuteActivity(actCtx, TemplateResolverActivity, TemplateResolveInput{
-			Template: durationStr,
-			Runtime:  runtime,
-			Env:      env,
-		}).Get(actCtx, &resolved); err != nil {
-			return fmt.Errorf("step %q: failed to resolve duration template: %w", step.Name, err)
-		}
-		resolvedDurationStr = resolved
+	resolvedDurationStr, err := resolveStepTemplate(ctx, durationStr, state, envSecrets)
+	if err != nil {
+		return fmt.Errorf("step %q: failed to resolve duration template: %w", step.Name, err)
 	}
 
 	duration, err := time.ParseDuration(resolvedDurationStr)
@@ -362,6 +608,73 @@ func handleDelayStep(ctx workflow.Context, step dsl.Step, testName, runID string
 	return workflow.Sleep(ctx, duration)
 }
 
+// checkRequiredEnv checks, on the worker, that the env vars the test's suite
+// requires are set, so a missing one fails the test before its first step
+func checkRequiredEnv(ctx workflow.Context, runID string, test dsl.Test, envSecrets map[string]string) error {
+	if len(test.RequiredEnv) == 0 {
+		return nil
+	}
+	ao := workflow.ActivityOptions{
+		StartToCloseTimeout: 10 * time.Second,
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts: 1,
+		},
+	}
+	actCtx := workflow.WithActivityOptions(ctx, ao)
+
+	err := workflow.ExecuteActivity(actCtx, EnvCheckActivity, EnvCheckInput{
+		Required: test.RequiredEnv,
+		Env:      envSecrets,
+	}).Get(actCtx, nil)
+	if err != nil {
+		// Report the activity's own message, not Temporal's wrapping of it
+		message := ExtractCleanError(err)
+		var appErr *temporal.ApplicationError
+		if errors.As(err, &appErr) {
+			message = appErr.Error()
+		}
+		sendStepLog(ctx, runID, test.Name, "", message, "red", true)
+		return errors.New(message)
+	}
+	return nil
+}
+
+// resolveStepTemplate renders runtime variables and secrets in a value the
+// workflow reads from a builtin step's config, e.g. a delay's duration
+func resolveStepTemplate(ctx workflow.Context, value string, state map[string]string, envSecrets map[string]string) (string, error) {
+	if !strings.Contains(value, "{{") {
+		return value, nil
+	}
+
+	ao := workflow.ActivityOptions{
+		StartToCloseTimeout: 10 * time.Second,
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts: 1,
+		},
+	}
+	actCtx := workflow.WithActivityOptions(ctx, ao)
+
+	runtime := state
+	if runtime == nil {
+		runtime = map[string]string{}
+	}
+	env := envSecrets
+	if env == nil {
+		env = map[string]string{}
+	}
+
+	var resolved string
+	if err := workflow.ExecuteActivity(actCtx, TemplateResolverActivity, TemplateResolveInput{
+		Template: value,
+		Runtime:  runtime,
+		Env:      env,
+		Scope:    egressScope(ctx),
+	}).Get(actCtx, &resolved); err != nil {
+		return "", err
+	}
+	return resolved, nil
+}
+
 // executePluginWithResponse executes any registered plugin and returns the response
 func executePluginWithResponse(ctx workflow.Context, step dsl.Step, state map[string]string, vars map[string]interface{}, runID, testName string, suiteOpenAPI *dsl.OpenAPISuiteConfig, opts *executionOptions, envSecrets map[string]string) (interface{}, error) {
 	logger := workflow.GetLogger(ctx)
@@ -389,6 +702,9 @@ func executePluginWithResponse(ctx workflow.Context, step dsl.Step, state map[st
 	// Add additional parameters based on plugin type
 	if step.Assertions != nil {
 		pluginParams["assertions"] = step.Assertions
+		if step.Soft {
+			pluginParams["assertions"] = softAssertions(step.Assertions)
+		}
 	}
 	if step.Save != nil {
 		logger.Info(fmt.Sprintf("Adding save to pluginParams: %v", step.Save))
@@ -402,6 +718,16 @@ func executePluginWithResponse(ctx workflow.Context, step dsl.Step, state map[st
 	if envSecrets != nil {
 		pluginParams["env"] = envSecrets
 	}
+	// Pass the run's tenant so plugins dial through the matching egress policy
+	if scope := egressScope(ctx); scope != nil {
+		pluginParams[egress.ParamsKey] = scope
+	}
+
+	if opts != nil {
+		for key, value := range opts.Params {
+			pluginParams[key] = value
+		}
+	}
 
 	if step.Plugin == "http" && suiteOpenAPI != nil {
 		suiteMap := map[string]interface{}{}
@@ -423,6 +749,15 @@ func executePluginWithResponse(ctx workflow.Context, step dsl.Step, state map[st
 		pluginParams["suite_openapi"] = suiteMap
 	}
 
+	jar := testCookieJar(ctx)
+	if step.Plugin == "http" && jar != nil {
+		pluginParams["cookie_jar"] = jar.records
+	}
+	har := testHARRecorder(ctx)
+	if step.Plugin == "http" && har != nil {
+		pluginParams["har"] = true
+	}
+
 	// Create step-specific activity options with retry policy
 	retryPolicy := buildRetryPolicy(step.Retry)
 	if opts != nil && opts.RetryPolicy != nil {
@@ -442,20 +777,35 @@ func executePluginWithResponse(ctx workflow.Context, step dsl.Step, state map[st
 	var activityResp interface{}
 	err := workflow.ExecuteActivity(stepCtx, step.Plugin, pluginParams).Get(stepCtx, &activityResp)
 	if err != nil {
-		// If an activity fails with rich details (e.g. HTTP assertion failures), attempt to
-		// extract the details so we can persist request/response/assertion info even on failure.
+		// If an activity fails with rich details (e.g. HTTP assertion failures or the
+		// artifacts a browser step saved), attempt to extract the details so we can
+		// persist request/response/assertion info and artifacts even on failure.
 		var appErr *temporal.ApplicationError
-		if errors.As(err, &appErr) && appErr.Type() == "http_assertion_failed" {
+		if errors.As(err, &appErr) && (appErr.Type() == "http_assertion_failed" || appErr.Type() == artifacts.ErrorType) {
 			var detail map[string]interface{}
 			if derr := appErr.Details(&detail); derr == nil && len(detail) > 0 {
 				activityResp = detail
 			}
 		}
+		// Cookies set before a failed assertion still count for later steps
+		if step.Plugin == "http" && jar != nil {
+			jar.add(activityResp)
+		}
+		if step.Plugin == "http" && har != nil {
+			har.add(activityResp)
+		}
 
 		logger.Error("Plugin activity failed", "plugin", step.Plugin, "error", err)
 		return activityResp, fmt.Errorf("%s activity error: %w", step.Plugin, err)
 	}
 
+	if step.Plugin == "http" && jar != nil {
+		jar.add(activityResp)
+	}
+	if step.Plugin == "http" && har != nil {
+		har.add(activityResp)
+	}
+
 	// Update workflow state with saved values (if any)
 	if activityResp != nil {
 		savedValues := extractSavedValues(ctx, activityResp)
@@ -484,8 +834,9 @@ func executePluginWithResponse(ctx workflow.Context, step dsl.Step, state map[st
 
 // buildRetryPolicy converts DSL retry configuration to Temporal RetryPolicy
 func buildRetryPolicy(retryConfig *dsl.RetryPolicy) *temporal.RetryPolicy {
-	// If no retry config is provided, disable retries entirely
-	if retryConfig == nil {
+	// If no retry config is provided, disable retries entirely. Steps with
+	// attempts are retried by the workflow instead.
+	if retryConfig == nil || retryConfig.Attempts > 0 {
 		return &temporal.RetryPolicy{
 			MaximumAttempts: 1,
 		}
@@ -615,6 +966,12 @@ func forwardLogMessage(ctx workflow.Context, activityResp interface{}, runID, te
 				"test_name":   testName,
 				"step_name":   stepName,
 			}
+			if logLevel, ok := respMap["log_level"].(string); ok && logLevel != "" {
+				forwarderParams["level"] = logLevel
+			}
+			if logFields, ok := respMap["log_fields"].(map[string]interface{}); ok && len(logFields) > 0 {
+				forwarderParams["fields"] = logFields
+			}
 
 			// Execute log forwarder activity
 			var forwarderResp interface{}
@@ -807,12 +1164,36 @@ func buildStepConfig(step dsl.Step) map[string]interface{} {
 
 	// Include retry config
 	if step.Retry != nil {
-		config["retry"] = map[string]interface{}{
+		retry := map[string]interface{}{
 			"maximum_attempts":    step.Retry.MaximumAttempts,
 			"initial_interval":    step.Retry.InitialInterval,
 			"maximum_interval":    step.Retry.MaximumInterval,
 			"backoff_coefficient": step.Retry.BackoffCoefficient,
 		}
+		if step.Retry.Attempts > 0 {
+			retry = map[string]interface{}{"attempts": step.Retry.Attempts}
+			if step.Retry.Backoff != nil {
+				retry["backoff"] = step.Retry.Backoff
+			}
+			if len(step.Retry.On) > 0 {
+				retry["on"] = step.Retry.On
+			}
+		}
+		config["retry"] = retry
+	}
+
+	// Include conditions
+	if step.When != "" {
+		config["when"] = step.When
+	}
+	if step.Unless != "" {
+		config["unless"] = step.Unless
+	}
+	if step.ForEach != nil {
+		config["for_each"] = step.ForEach
+	}
+	if step.Group != nil {
+		config["parallel_group"] = step.Group
 	}
 
 	return config
@@ -871,11 +1252,20 @@ func sendStepReportWithDetails(ctx workflow.Context, runID string, stepIndex int
 				}
 			}
 
+			if workerVersion, ok := respMap[plugins.WorkerVersionKey].(string); ok && workerVersion != "" {
+				reportParams["worker_version"] = workerVersion
+			}
+
 			// Extract assertion results for HTTP plugin
 			if assertionResults, ok := respMap["assertion_results"].([]interface{}); ok && len(assertionResults) > 0 {
 				reportParams["assertions_data"] = assertionResults
 			}
 
+			// Extract screenshots and recordings the step saved
+			if saved, ok := respMap["artifacts"].([]interface{}); ok && len(saved) > 0 {
+				reportParams["artifacts_data"] = saved
+			}
+
 			// Extract saved values from response
 			if savedMap, ok := respMap["saved"].(map[string]interface{}); ok && len(savedMap) > 0 {
 				savedValues = make(map[string]string)
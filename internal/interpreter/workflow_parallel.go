@@ -0,0 +1,79 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+)
+
+// parallelGroupEnd returns the index after the last step of the parallel group
+// that starts at start
+func parallelGroupEnd(steps []dsl.Step, start int) int {
+	end := start + 1
+	for end < len(steps) && steps[end].Group != nil && steps[end].Group.ID == steps[start].Group.ID {
+		end++
+	}
+	return end
+}
+
+// runParallelGroup runs the steps of a parallel group at once, at most
+// MaxParallel at a time, and waits for all of them. Each step sees state as it
+// was before the group; the values they save are merged into state in step
+// order once they've finished. It returns each step's error.
+func runParallelGroup(
+	ctx workflow.Context,
+	runID string,
+	testName string,
+	phase stepPhase,
+	firstIndex int,
+	steps []dsl.Step,
+	state map[string]string,
+	vars map[string]interface{},
+	suiteOpenAPI *dsl.OpenAPISuiteConfig,
+	opts *executionOptions,
+	envSecrets map[string]string,
+) []error {
+	group := steps[0].Group
+	limit := len(steps)
+	if group.MaxParallel > 0 && group.MaxParallel < limit {
+		limit = group.MaxParallel
+	}
+	sendStepLog(ctx, runID, testName, "", fmt.Sprintf("Running %d steps of %q in parallel", len(steps), group.Name), "n/a", false)
+
+	before := make(map[string]string, len(state))
+	for _, k := range workflow.DeterministicKeys(state) {
+		before[k] = state[k]
+	}
+
+	states := make([]map[string]string, len(steps))
+	errs := make([]error, len(steps))
+	slots := workflow.NewBufferedChannel(ctx, limit)
+	wg := workflow.NewWaitGroup(ctx)
+	for i, step := range steps {
+		states[i] = make(map[string]string, len(before))
+		for k, v := range before {
+			states[i][k] = v
+		}
+		slots.Send(ctx, struct{}{})
+		wg.Add(1)
+		workflow.Go(ctx, func(ctx workflow.Context) {
+			defer wg.Done()
+			defer slots.Receive(ctx, nil)
+			errs[i] = executeStep(ctx, runID, testName, phase, firstIndex+i, step, states[i], vars, suiteOpenAPI, opts, envSecrets)
+		})
+	}
+	wg.Wait(ctx)
+
+	// Only values a step changed are merged, so a step that saved nothing
+	// doesn't undo what the steps before it saved
+	for _, stepState := range states {
+		for _, k := range workflow.DeterministicKeys(stepState) {
+			if value, ok := before[k]; !ok || value != stepState[k] {
+				state[k] = stepState[k]
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,150 @@
+package interpreter
+
+import (
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+)
+
+// Poll defaults
+const (
+	defaultPollTimeout  = time.Minute
+	defaultPollInterval = time.Second
+	minPollAttemptTime  = time.Second
+)
+
+// pollConfig is a poll step's config. The polled step is the poll step itself
+// with the inner plugin and config, so it keeps the step's assertions and saves.
+type pollConfig struct {
+	plugin      string
+	config      map[string]interface{}
+	timeout     time.Duration
+	interval    time.Duration
+	backoff     float64
+	maxInterval time.Duration // 0 leaves the interval uncapped
+}
+
+// parsePollConfig reads a poll step's config, rendering templates in its durations
+func parsePollConfig(ctx workflow.Context, step dsl.Step, state map[string]string, envSecrets map[string]string) (*pollConfig, error) {
+	cfg := &pollConfig{timeout: defaultPollTimeout, interval: defaultPollInterval, backoff: 1}
+
+	plugin, ok := step.Config["plugin"].(string)
+	if !ok || plugin == "" {
+		return nil, fmt.Errorf("plugin is required and must be a string")
+	}
+	if plugin == "delay" || plugin == "poll" || plugin == "webhook_wait" {
+		return nil, fmt.Errorf("poll can't run %s steps", plugin)
+	}
+	cfg.plugin = plugin
+
+	config, ok := step.Config["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("config is required and must be an object")
+	}
+	cfg.config = config
+
+	durations := []struct {
+		key    string
+		target *time.Duration
+	}{
+		{"timeout", &cfg.timeout},
+		{"interval", &cfg.interval},
+		{"max_interval", &cfg.maxInterval},
+	}
+	for _, d := range durations {
+		raw, ok := step.Config[d.key]
+		if !ok {
+			continue
+		}
+		value, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s must be a duration string", d.key)
+		}
+		resolved, err := resolveStepTemplate(ctx, value, state, envSecrets)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s template: %w", d.key, err)
+		}
+		duration, err := time.ParseDuration(resolved)
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("invalid %s %q: expected a positive duration", d.key, resolved)
+		}
+		*d.target = duration
+	}
+
+	if raw, ok := step.Config["backoff"]; ok {
+		switch backoff := raw.(type) {
+		case float64:
+			cfg.backoff = backoff
+		case int:
+			cfg.backoff = float64(backoff)
+		default:
+			return nil, fmt.Errorf("backoff must be a number")
+		}
+		if cfg.backoff < 1 {
+			return nil, fmt.Errorf("backoff must be at least 1, got %v", cfg.backoff)
+		}
+	}
+	return cfg, nil
+}
+
+// nextInterval grows the interval by the backoff, up to the max interval
+func (c *pollConfig) nextInterval(interval time.Duration) time.Duration {
+	next := time.Duration(float64(interval) * c.backoff)
+	if c.maxInterval > 0 && next > c.maxInterval {
+		return c.maxInterval
+	}
+	return next
+}
+
+// handlePollStep runs the inner plugin until it succeeds, assertions included,
+// or the timeout elapses. Attempts that fail are retried after the interval,
+// which grows by the backoff; the last attempt's error fails the step.
+func handlePollStep(ctx workflow.Context, step dsl.Step, testName, runID string, state map[string]string, vars map[string]interface{}, suiteOpenAPI *dsl.OpenAPISuiteConfig, opts *executionOptions, envSecrets map[string]string) (interface{}, error) {
+	cfg, err := parsePollConfig(ctx, step, state, envSecrets)
+	if err != nil {
+		return nil, fmt.Errorf("step %q: %w", step.Name, err)
+	}
+
+	inner := step
+	inner.Plugin = cfg.plugin
+	inner.Config = cfg.config
+	inner.Retry = nil // Poll does the retrying
+
+	deadline := workflow.Now(ctx).Add(cfg.timeout)
+	interval := cfg.interval
+	for attempt := 1; ; attempt++ {
+		// An attempt can't outlast the poll
+		attemptOpts := &executionOptions{ActivityTimeout: max(deadline.Sub(workflow.Now(ctx)), minPollAttemptTime)}
+		if opts != nil {
+			attemptOpts.RetryPolicy = opts.RetryPolicy
+			if opts.ActivityTimeout > 0 {
+				attemptOpts.ActivityTimeout = min(attemptOpts.ActivityTimeout, opts.ActivityTimeout)
+			}
+		}
+
+		resp, err := executePluginWithResponse(ctx, inner, state, vars, runID, testName, suiteOpenAPI, attemptOpts, envSecrets)
+		if err == nil {
+			if attempt > 1 {
+				sendStepLog(ctx, runID, testName, step.Name, fmt.Sprintf("Condition met on attempt %d", attempt), "n/a", false)
+			}
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			return resp, err
+		}
+
+		remaining := deadline.Sub(workflow.Now(ctx))
+		if remaining <= 0 {
+			return resp, fmt.Errorf("condition not met after %d attempts in %s: %w", attempt, cfg.timeout, err)
+		}
+		wait := min(interval, remaining)
+		sendStepLog(ctx, runID, testName, step.Name, fmt.Sprintf("Attempt %d failed, retrying in %s: %s", attempt, wait, ExtractCleanError(err)), "n/a", false)
+		if err := workflow.Sleep(ctx, wait); err != nil {
+			return resp, err
+		}
+		interval = cfg.nextInterval(interval)
+	}
+}
@@ -0,0 +1,194 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+)
+
+// Retry defaults
+const (
+	defaultRetryDelay      = time.Second
+	defaultRetryMultiplier = 2.0
+	defaultRetryJitter     = 0.2
+)
+
+// stepRetry is a step's workflow retry: the whole step, assertions included,
+// runs again after failures it's set to retry
+type stepRetry struct {
+	attempts   int
+	delay      time.Duration
+	maxDelay   time.Duration // 0 leaves the delay uncapped
+	multiplier float64
+	jitter     float64
+	on         map[string]bool // nil retries every failure
+}
+
+// parseStepRetry reads a step's retry policy. It returns nil when the step
+// doesn't set attempts, so its retries, if any, are left to Temporal.
+func parseStepRetry(policy *dsl.RetryPolicy) (*stepRetry, error) {
+	if policy == nil || policy.Attempts == 0 {
+		return nil, nil
+	}
+	if policy.Attempts < 1 {
+		return nil, fmt.Errorf("retry attempts must be at least 1, got %d", policy.Attempts)
+	}
+	retry := &stepRetry{
+		attempts:   policy.Attempts,
+		delay:      defaultRetryDelay,
+		multiplier: defaultRetryMultiplier,
+		jitter:     defaultRetryJitter,
+	}
+
+	if backoff := policy.Backoff; backoff != nil {
+		if backoff.Delay != "" {
+			delay, err := time.ParseDuration(backoff.Delay)
+			if err != nil || delay < 0 {
+				return nil, fmt.Errorf("invalid retry delay %q", backoff.Delay)
+			}
+			retry.delay = delay
+		}
+		if backoff.MaxDelay != "" {
+			maxDelay, err := time.ParseDuration(backoff.MaxDelay)
+			if err != nil || maxDelay <= 0 {
+				return nil, fmt.Errorf("invalid retry max_delay %q", backoff.MaxDelay)
+			}
+			retry.maxDelay = maxDelay
+		}
+		if backoff.Multiplier != 0 {
+			if backoff.Multiplier < 1 {
+				return nil, fmt.Errorf("retry multiplier must be at least 1, got %v", backoff.Multiplier)
+			}
+			retry.multiplier = backoff.Multiplier
+		}
+		if backoff.Jitter != nil {
+			if *backoff.Jitter < 0 || *backoff.Jitter > 1 {
+				return nil, fmt.Errorf("retry jitter must be between 0 and 1, got %v", *backoff.Jitter)
+			}
+			retry.jitter = *backoff.Jitter
+		}
+	}
+
+	for _, failure := range policy.On {
+		switch failure {
+		case dsl.RetryOnError, dsl.RetryOnAssertion, dsl.RetryOnTimeout:
+		default:
+			return nil, fmt.Errorf("invalid retry on %q: expected error, assertion or timeout", failure)
+		}
+		if retry.on == nil {
+			retry.on = make(map[string]bool)
+		}
+		retry.on[failure] = true
+	}
+	return retry, nil
+}
+
+// failureKind tells whether a step failed on a timeout, an assertion, or an
+// error of the plugin
+func failureKind(err error) string {
+	var timeoutErr *temporal.TimeoutError
+	if errors.As(err, &timeoutErr) {
+		return dsl.RetryOnTimeout
+	}
+	var appErr *temporal.ApplicationError
+	if errors.As(err, &appErr) && appErr.Type() == "http_assertion_failed" {
+		return dsl.RetryOnAssertion
+	}
+	message := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(message, "assertion failed") || strings.Contains(message, "assertions failed"):
+		return dsl.RetryOnAssertion
+	case strings.Contains(message, "deadline exceeded") || strings.Contains(message, "timed out") || strings.Contains(message, "timeout"):
+		return dsl.RetryOnTimeout
+	}
+	return dsl.RetryOnError
+}
+
+// attemptFailure is the reason an attempt failed: the plugin's own message
+// when it reported one, without the activity's wrapping
+func attemptFailure(err error) string {
+	var appErr *temporal.ApplicationError
+	if errors.As(err, &appErr) && appErr.Message() != "" {
+		return appErr.Message()
+	}
+	return ExtractCleanError(err)
+}
+
+// retries reports whether a failure of this kind gets another attempt
+func (r *stepRetry) retries(kind string) bool {
+	return r.on == nil || r.on[kind]
+}
+
+// nextDelay grows the delay by the multiplier, up to the max delay
+func (r *stepRetry) nextDelay(delay time.Duration) time.Duration {
+	next := time.Duration(float64(delay) * r.multiplier)
+	if r.maxDelay > 0 && next > r.maxDelay {
+		return r.maxDelay
+	}
+	return next
+}
+
+// jittered spreads the delay by up to the jitter fraction either way. The
+// random factor is recorded as a side effect, so replays wait the same.
+func (r *stepRetry) jittered(ctx workflow.Context, delay time.Duration) time.Duration {
+	if r.jitter == 0 || delay == 0 {
+		return delay
+	}
+	var factor float64
+	encoded := workflow.SideEffect(ctx, func(workflow.Context) interface{} {
+		return 1 + r.jitter*(2*rand.Float64()-1)
+	})
+	if err := encoded.Get(&factor); err != nil {
+		return delay
+	}
+	return time.Duration(float64(delay) * factor)
+}
+
+// runWithRetry runs a step until it succeeds, it fails in a way it isn't set to
+// retry, or its attempts run out. Every failed attempt is logged with the wait
+// before the next one.
+func runWithRetry(ctx workflow.Context, retry *stepRetry, runID, testName string, step dsl.Step, run func() (interface{}, error)) (interface{}, error) {
+	delay := retry.delay
+	for attempt := 1; ; attempt++ {
+		resp, err := run()
+		if err == nil {
+			if attempt > 1 {
+				sendStepLog(ctx, runID, testName, step.Name, fmt.Sprintf("Attempt %d/%d passed", attempt, retry.attempts), "n/a", false)
+			}
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			return resp, err
+		}
+
+		kind := failureKind(err)
+		if !retry.retries(kind) {
+			if attempt > 1 {
+				return resp, fmt.Errorf("attempt %d/%d failed and %s failures aren't retried: %w", attempt, retry.attempts, kind, err)
+			}
+			return resp, err
+		}
+		if attempt >= retry.attempts {
+			if retry.attempts == 1 {
+				return resp, err
+			}
+			return resp, fmt.Errorf("failed after %d attempts: %w", attempt, err)
+		}
+
+		wait := retry.jittered(ctx, delay)
+		sendStepLog(ctx, runID, testName, step.Name, fmt.Sprintf("Attempt %d/%d failed (%s), retrying in %s: %s", attempt, retry.attempts, kind, wait.Round(time.Millisecond), attemptFailure(err)), "n/a", false)
+		if wait > 0 {
+			if err := workflow.Sleep(ctx, wait); err != nil {
+				return resp, err
+			}
+		}
+		delay = retry.nextDelay(delay)
+	}
+}
@@ -0,0 +1,55 @@
+package interpreter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// softAssertionError fails a step whose soft assertions failed. Unlike other
+// step errors it doesn't stop the test: the remaining steps run, and the test
+// fails at the end with every failure.
+type softAssertionError struct {
+	failures []string
+}
+
+func (e *softAssertionError) Error() string {
+	if len(e.failures) == 1 {
+		return "Soft assertion failed: " + e.failures[0]
+	}
+	return "Soft assertions failed: " + strings.Join(e.failures, "; ")
+}
+
+// softAssertions returns a soft step's assertions, each marked soft: true
+func softAssertions(assertions []map[string]interface{}) []map[string]interface{} {
+	marked := make([]map[string]interface{}, len(assertions))
+	for i, assertion := range assertions {
+		copied := make(map[string]interface{}, len(assertion)+1)
+		for k, v := range assertion {
+			copied[k] = v
+		}
+		copied["soft"] = true
+		marked[i] = copied
+	}
+	return marked
+}
+
+// softAssertionFailures lists the soft assertions a step's response reports as failed
+func softAssertionFailures(activityResp interface{}) []string {
+	results, _ := toMap(activityResp)["assertion_results"].([]interface{})
+	var failures []string
+	for _, r := range results {
+		result, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		soft, _ := result["soft"].(bool)
+		passed, _ := result["passed"].(bool)
+		if !soft || passed {
+			continue
+		}
+		assertionType, _ := result["type"].(string)
+		message, _ := result["message"].(string)
+		failures = append(failures, fmt.Sprintf("%s: %s", assertionType, message))
+	}
+	return failures
+}
@@ -6,9 +6,15 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/rocketship-ai/rocketship/internal/artifacts"
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/callbacks"
 	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/plugins/browser"
 	"github.com/rocketship-ai/rocketship/internal/plugins/http"
+	"github.com/rocketship-ai/rocketship/internal/plugins/webhook_wait"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.temporal.io/sdk/activity"
@@ -409,6 +415,173 @@ func TestWorkflowStatePropagation(t *testing.T) {
 	}
 }
 
+func TestWorkflowCookieJar(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		testSuite := &testsuite.WorkflowTestSuite{}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.RegisterActivityWithOptions((&http.HTTPPlugin{}).Activity, activity.RegisterOptions{Name: "http"})
+
+		var calls []map[string]interface{}
+		env.OnActivity("http", mock.Anything, mock.Anything).Return(
+			func(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+				calls = append(calls, params)
+				resp := &http.ActivityResponse{Response: &http.HTTPResponse{StatusCode: 200}}
+				if len(calls) == 1 && params["cookie_jar"] != nil {
+					resp.CookieJar = []http.CookieRecord{{URL: "http://example.com/login", Cookies: []string{"session=abc; Path=/"}}}
+				}
+				return resp, nil
+			})
+
+		test := dsl.Test{
+			Name:      "cookie-jar-test",
+			CookieJar: enabled,
+			Steps: []dsl.Step{
+				{Name: "login", Plugin: "http", Config: map[string]interface{}{"method": "POST", "url": "http://example.com/login"}},
+				{Name: "profile", Plugin: "http", Config: map[string]interface{}{"method": "GET", "url": "http://example.com/profile"}},
+			},
+		}
+		env.ExecuteWorkflow(TestWorkflow, test, make(map[string]interface{}), "test-run-id", (*dsl.OpenAPISuiteConfig)(nil), map[string]string(nil), map[string]string(nil))
+		if err := env.GetWorkflowError(); err != nil {
+			t.Fatalf("cookie_jar %v: unexpected error: %v", enabled, err)
+		}
+		if len(calls) != 2 {
+			t.Fatalf("cookie_jar %v: expected 2 activity calls, got %d", enabled, len(calls))
+		}
+
+		if !enabled {
+			for _, call := range calls {
+				if _, ok := call["cookie_jar"]; ok {
+					t.Errorf("expected no cookie_jar param without cookie_jar, got %v", call["cookie_jar"])
+				}
+			}
+			continue
+		}
+		if records, ok := calls[0]["cookie_jar"].([]interface{}); !ok || len(records) != 0 {
+			t.Errorf("expected an empty jar for the first step, got %v", calls[0]["cookie_jar"])
+		}
+		records, ok := calls[1]["cookie_jar"].([]interface{})
+		if !ok || len(records) != 1 {
+			t.Fatalf("expected the login cookies for the second step, got %v", calls[1]["cookie_jar"])
+		}
+		record := records[0].(map[string]interface{})
+		if record["url"] != "http://example.com/login" {
+			t.Errorf("unexpected cookie record: %v", record)
+		}
+	}
+}
+
+func TestWorkflowHAR(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		testSuite := &testsuite.WorkflowTestSuite{}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.RegisterActivityWithOptions((&http.HTTPPlugin{}).Activity, activity.RegisterOptions{Name: "http"})
+		env.RegisterActivityWithOptions(HARWriterActivity, activity.RegisterOptions{Name: "HARWriterActivity"})
+		env.RegisterActivityWithOptions(LogForwarderActivity, activity.RegisterOptions{Name: "LogForwarderActivity"})
+
+		env.OnActivity("http", mock.Anything, mock.Anything).Return(
+			func(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+				resp := &http.ActivityResponse{Response: &http.HTTPResponse{StatusCode: 200}}
+				if params["har"] == true {
+					resp.HAREntry = &http.HAREntry{Comment: params["name"].(string)}
+				}
+				return resp, nil
+			})
+		var written []map[string]interface{}
+		env.OnActivity("HARWriterActivity", mock.Anything, mock.Anything).Return(
+			func(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+				written = append(written, params)
+				return map[string]interface{}{"path": "/tmp/har-test.har"}, nil
+			})
+		var logged []string
+		env.OnActivity("LogForwarderActivity", mock.Anything, mock.Anything).Return(
+			func(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+				logged = append(logged, params["message"].(string))
+				return map[string]interface{}{"forwarded": true}, nil
+			})
+
+		test := dsl.Test{
+			Name: "har-test",
+			HAR:  enabled,
+			Steps: []dsl.Step{
+				{Name: "create", Plugin: "http", Config: map[string]interface{}{"method": "POST", "url": "http://example.com/items"}},
+			},
+			Cleanup: &dsl.CleanupSpec{
+				Always: []dsl.Step{
+					{Name: "delete", Plugin: "http", Config: map[string]interface{}{"method": "DELETE", "url": "http://example.com/items/1"}},
+				},
+			},
+		}
+		env.ExecuteWorkflow(TestWorkflow, test, make(map[string]interface{}), "test-run-id", (*dsl.OpenAPISuiteConfig)(nil), map[string]string(nil), map[string]string(nil))
+		if err := env.GetWorkflowError(); err != nil {
+			t.Fatalf("har %v: unexpected error: %v", enabled, err)
+		}
+
+		if !enabled {
+			if len(written) != 0 {
+				t.Errorf("expected no HAR without har, got %v", written)
+			}
+			continue
+		}
+		if len(written) != 1 {
+			t.Fatalf("expected the HAR to be written once, got %d", len(written))
+		}
+		entries, _ := written[0]["entries"].([]interface{})
+		if len(entries) != 2 || written[0]["test_name"] != "har-test" || written[0]["run_id"] != "test-run-id" {
+			t.Fatalf("expected both steps' entries for the test, got %v", written[0])
+		}
+		if last := entries[1].(map[string]interface{}); last["comment"] != "delete" {
+			t.Errorf("expected the cleanup request last, got %v", last)
+		}
+		if len(logged) == 0 || !strings.Contains(logged[len(logged)-1], "/tmp/har-test.har") {
+			t.Errorf("expected the HAR path in the run logs, got %v", logged)
+		}
+	}
+}
+
+func TestWorkflowReportsStepArtifacts(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterActivityWithOptions((&browser.BrowserPlugin{}).Activity, activity.RegisterOptions{Name: "browser"})
+	env.RegisterActivityWithOptions(StepReporterActivity, activity.RegisterOptions{Name: "StepReporterActivity"})
+	env.RegisterActivityWithOptions(LogForwarderActivity, activity.RegisterOptions{Name: "LogForwarderActivity"})
+
+	screenshot := artifacts.Artifact{Name: "checkout.png", Type: "screenshot", MimeType: "image/png", Size: 42, Path: "/tmp/run/checkout.png"}
+	env.OnActivity("browser", mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+			return nil, artifacts.Failure(fmt.Errorf("#pay: no element matches"), []artifacts.Artifact{screenshot})
+		})
+	var reports []map[string]interface{}
+	env.OnActivity("StepReporterActivity", mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+			reports = append(reports, params)
+			return map[string]interface{}{"step_id": ""}, nil
+		})
+	env.OnActivity("LogForwarderActivity", mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+			return map[string]interface{}{"forwarded": true}, nil
+		})
+
+	test := dsl.Test{
+		Name: "artifacts-test",
+		Steps: []dsl.Step{
+			{Name: "checkout", Plugin: "browser", Config: map[string]interface{}{"url": "https://app.test/checkout"}},
+		},
+	}
+	env.ExecuteWorkflow(TestWorkflow, test, make(map[string]interface{}), "test-run-id", (*dsl.OpenAPISuiteConfig)(nil), map[string]string(nil), map[string]string(nil))
+	if err := env.GetWorkflowError(); err == nil || !strings.Contains(err.Error(), "no element matches") {
+		t.Fatalf("expected the step failure, got %v", err)
+	}
+
+	final := reports[len(reports)-1]
+	if final["status"] != "FAILED" {
+		t.Fatalf("expected the failed step to be reported last, got %v", final)
+	}
+	saved, ok := final["artifacts_data"].([]interface{})
+	if !ok || len(saved) != 1 || saved[0].(map[string]interface{})["path"] != screenshot.Path {
+		t.Errorf("expected the screenshot in the step report, got %v", final["artifacts_data"])
+	}
+}
+
 func TestWorkflowConcurrency(t *testing.T) {
 	// Test that workflows can run concurrently without interference
 	numWorkflows := 10
@@ -731,6 +904,110 @@ func TestTestWorkflow_SuccessRunsCleanupAlwaysOnly(t *testing.T) {
 	assert.Zero(t, startCounts["cleanup-on-failure"], "on_failure cleanup should not run on success")
 }
 
+func TestTestWorkflow_ContinueOnFailureAggregatesErrors(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	var mu sync.Mutex
+	startCounts := make(map[string]int)
+
+	env.RegisterActivityWithOptions(LogForwarderActivity, activity.RegisterOptions{Name: "LogForwarderActivity"})
+	env.OnActivity("LogForwarderActivity", mock.Anything, mock.Anything).
+		Return(map[string]interface{}{"forwarded": true}, nil).
+		Run(func(args mock.Arguments) {
+			params, _ := args.Get(1).(map[string]interface{})
+			stepName, _ := params["step_name"].(string)
+			message, _ := params["message"].(string)
+			if stepName != "" && strings.Contains(message, "Starting") {
+				mu.Lock()
+				startCounts[stepName]++
+				mu.Unlock()
+			}
+		})
+
+	test := dsl.Test{
+		Name: "continue on failure test",
+		Steps: []dsl.Step{
+			{
+				Name:              "soft-failure",
+				Plugin:            "delay",
+				Config:            map[string]interface{}{},
+				ContinueOnFailure: true,
+			},
+			{
+				Name:   "collect-diagnostics",
+				Plugin: "delay",
+				Config: map[string]interface{}{"duration": "1s"},
+			},
+			{
+				Name:   "hard-failure",
+				Plugin: "delay",
+				Config: map[string]interface{}{"duration": "invalid"},
+			},
+			{
+				Name:   "never-runs",
+				Plugin: "delay",
+				Config: map[string]interface{}{"duration": "1s"},
+			},
+		},
+	}
+
+	env.ExecuteWorkflow(TestWorkflow, test, make(map[string]interface{}), "test-run-id", (*dsl.OpenAPISuiteConfig)(nil), map[string]string(nil), map[string]string(nil))
+	err := env.GetWorkflowError()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "2 steps failed")
+	assert.Contains(t, err.Error(), "step 0:")
+	assert.Contains(t, err.Error(), "step 2:")
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, startCounts["collect-diagnostics"], "steps after a continue_on_failure step should run")
+	assert.Zero(t, startCounts["never-runs"], "a failing step without continue_on_failure should stop the test")
+}
+
+func TestRunStepSequence_CleanupReportsFirstError(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	var mu sync.Mutex
+	startCounts := make(map[string]int)
+
+	env.RegisterActivityWithOptions(LogForwarderActivity, activity.RegisterOptions{Name: "LogForwarderActivity"})
+	env.OnActivity("LogForwarderActivity", mock.Anything, mock.Anything).
+		Return(map[string]interface{}{"forwarded": true}, nil).
+		Run(func(args mock.Arguments) {
+			params, _ := args.Get(1).(map[string]interface{})
+			stepName, _ := params["step_name"].(string)
+			message, _ := params["message"].(string)
+			if stepName != "" && strings.Contains(message, "Starting") {
+				mu.Lock()
+				startCounts[stepName]++
+				mu.Unlock()
+			}
+		})
+
+	steps := []dsl.Step{
+		{Name: "drop-database", Plugin: "delay", Config: map[string]interface{}{}},
+		{Name: "delete-bucket", Plugin: "delay", Config: map[string]interface{}{"duration": "invalid"}},
+		{Name: "revoke-token", Plugin: "delay", Config: map[string]interface{}{"duration": "1s"}},
+	}
+	cleanup := func(ctx workflow.Context) error {
+		ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{StartToCloseTimeout: time.Minute})
+		return runStepSequence(ctx, "test-run-id", "cleanup test", phaseCleanupAlways, steps, map[string]string{}, map[string]interface{}{}, nil, nil, false, nil)
+	}
+
+	env.ExecuteWorkflow(cleanup)
+	err := env.GetWorkflowError()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `"drop-database"`)
+	assert.NotContains(t, err.Error(), "delete-bucket", "cleanup should report its first failure only")
+	assert.NotContains(t, err.Error(), "steps failed", "cleanup failures should not be aggregated")
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, startCounts["revoke-token"], "cleanup should run every step")
+}
+
 func TestTestWorkflowInjectsSuiteGlobalsIntoState(t *testing.T) {
 	testSuite := &testsuite.WorkflowTestSuite{}
 	env := testSuite.NewTestWorkflowEnvironment()
@@ -763,6 +1040,69 @@ func TestTestWorkflowInjectsSuiteGlobalsIntoState(t *testing.T) {
 	assert.False(t, hasLegacyKey)
 }
 
+func TestTestWorkflowInjectsMatrixParams(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	env.RegisterActivityWithOptions(LogForwarderActivity, activity.RegisterOptions{Name: "LogForwarderActivity"})
+	env.OnActivity("LogForwarderActivity", mock.Anything, mock.Anything).
+		Return(map[string]interface{}{"forwarded": true}, nil)
+
+	test := dsl.Test{
+		Name:         "Orders [api_version=2, region=eu]",
+		MatrixParams: map[string]interface{}{"api_version": 2, "region": "eu"},
+		Steps: []dsl.Step{
+			{
+				Name:   "noop",
+				Plugin: "delay",
+				Config: map[string]interface{}{"duration": "0s"},
+			},
+		},
+	}
+
+	env.ExecuteWorkflow(TestWorkflow, test, make(map[string]interface{}), "run-id", (*dsl.OpenAPISuiteConfig)(nil), map[string]string(nil), map[string]string(nil))
+	assert.NoError(t, env.GetWorkflowError())
+
+	var state map[string]string
+	err := env.GetWorkflowResult(&state)
+	assert.NoError(t, err)
+	assert.Equal(t, "2", state["matrix.api_version"])
+	assert.Equal(t, "eu", state["matrix.region"])
+}
+
+func TestTestWorkflowInjectsDataRow(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	env.RegisterActivityWithOptions(LogForwarderActivity, activity.RegisterOptions{Name: "LogForwarderActivity"})
+	env.OnActivity("LogForwarderActivity", mock.Anything, mock.Anything).
+		Return(map[string]interface{}{"forwarded": true}, nil)
+
+	test := dsl.Test{
+		Name: "Sign up [alice]",
+		DataRow: map[string]interface{}{
+			"username": "alice",
+			"address":  map[string]interface{}{"country": "NL"},
+		},
+		Steps: []dsl.Step{
+			{
+				Name:   "noop",
+				Plugin: "delay",
+				Config: map[string]interface{}{"duration": "0s"},
+			},
+		},
+	}
+
+	env.ExecuteWorkflow(TestWorkflow, test, make(map[string]interface{}), "run-id", (*dsl.OpenAPISuiteConfig)(nil), map[string]string(nil), map[string]string(nil))
+	assert.NoError(t, env.GetWorkflowError())
+
+	var state map[string]string
+	err := env.GetWorkflowResult(&state)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", state["row.username"])
+	assert.Equal(t, "NL", state["row.address.country"])
+}
+
 func TestSuiteCleanupWorkflowHonorsFailureFlag(t *testing.T) {
 	testSuite := &testsuite.WorkflowTestSuite{}
 	env := testSuite.NewTestWorkflowEnvironment()
@@ -999,6 +1339,217 @@ func TestSuiteInitSavesAvailableInCleanup(t *testing.T) {
 }
 
 // Helper function for string containment
+func TestTestWorkflow_PollStep(t *testing.T) {
+	newEnv := func(attempts *int, succeedOn int) *testsuite.TestWorkflowEnvironment {
+		testSuite := &testsuite.WorkflowTestSuite{}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.RegisterActivityWithOptions((&http.HTTPPlugin{}).Activity, activity.RegisterOptions{Name: "http"})
+		env.RegisterActivityWithOptions(LogForwarderActivity, activity.RegisterOptions{Name: "LogForwarderActivity"})
+		env.OnActivity("LogForwarderActivity", mock.Anything, mock.Anything).Return(
+			map[string]interface{}{"forwarded": true}, nil)
+		env.OnActivity("http", mock.Anything, mock.MatchedBy(func(params map[string]interface{}) bool {
+			return params["name"] == "wait-for-order"
+		})).Return(func(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+			*attempts++
+			if succeedOn == 0 || *attempts < succeedOn {
+				return nil, fmt.Errorf("assertion failed: expected status shipped, got pending")
+			}
+			return &http.ActivityResponse{
+				Response: &http.HTTPResponse{StatusCode: 200},
+				Saved:    map[string]string{"tracking": "TRK-1"},
+			}, nil
+		})
+		return env
+	}
+	pollStep := func(config map[string]interface{}) dsl.Step {
+		config["plugin"] = "http"
+		config["config"] = map[string]interface{}{"method": "GET", "url": "http://example.com/orders/1"}
+		return dsl.Step{
+			Name:   "wait-for-order",
+			Plugin: "poll",
+			Config: config,
+			Save:   []map[string]interface{}{{"json_path": ".tracking", "as": "tracking"}},
+		}
+	}
+
+	t.Run("passes once the plugin succeeds", func(t *testing.T) {
+		attempts := 0
+		env := newEnv(&attempts, 3)
+		var followUp map[string]interface{}
+		env.OnActivity("http", mock.Anything, mock.MatchedBy(func(params map[string]interface{}) bool {
+			return params["name"] == "use-tracking"
+		})).Return(func(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+			followUp = params
+			return &http.ActivityResponse{Response: &http.HTTPResponse{StatusCode: 200}}, nil
+		})
+
+		test := dsl.Test{
+			Name: "poll test",
+			Steps: []dsl.Step{
+				pollStep(map[string]interface{}{"timeout": "1m", "interval": "1s", "backoff": 2.0}),
+				{Name: "use-tracking", Plugin: "http", Config: map[string]interface{}{"method": "GET", "url": "http://example.com/track/{{ tracking }}"}},
+			},
+		}
+		env.ExecuteWorkflow(TestWorkflow, test, make(map[string]interface{}), "test-run-id", (*dsl.OpenAPISuiteConfig)(nil), map[string]string(nil), map[string]string(nil))
+
+		assert.NoError(t, env.GetWorkflowError())
+		assert.Equal(t, 3, attempts)
+		if assert.NotNil(t, followUp) {
+			assert.Equal(t, "TRK-1", followUp["state"].(map[string]interface{})["tracking"])
+		}
+	})
+
+	t.Run("fails with the last error at the timeout", func(t *testing.T) {
+		attempts := 0
+		env := newEnv(&attempts, 0)
+		test := dsl.Test{
+			Name:  "poll timeout test",
+			Steps: []dsl.Step{pollStep(map[string]interface{}{"timeout": "5s", "interval": "2s"})},
+		}
+		env.ExecuteWorkflow(TestWorkflow, test, make(map[string]interface{}), "test-run-id", (*dsl.OpenAPISuiteConfig)(nil), map[string]string(nil), map[string]string(nil))
+
+		err := env.GetWorkflowError()
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "condition not met after 4 attempts in 5s")
+			assert.Contains(t, err.Error(), "expected status shipped, got pending")
+		}
+		// Attempts at 0s, 2s and 4s, then a last one at the timeout
+		assert.Equal(t, 4, attempts)
+	})
+
+	t.Run("rejects invalid config", func(t *testing.T) {
+		for _, tc := range []struct {
+			config map[string]interface{}
+			errMsg string
+		}{
+			{map[string]interface{}{"plugin": "delay", "config": map[string]interface{}{}}, "poll can't run delay steps"},
+			{map[string]interface{}{"config": map[string]interface{}{}}, "plugin is required"},
+			{map[string]interface{}{"plugin": "http"}, "config is required"},
+			{map[string]interface{}{"plugin": "http", "config": map[string]interface{}{}, "interval": "soon"}, `invalid interval "soon"`},
+			{map[string]interface{}{"plugin": "http", "config": map[string]interface{}{}, "backoff": 0.5}, "backoff must be at least 1"},
+		} {
+			testSuite := &testsuite.WorkflowTestSuite{}
+			env := testSuite.NewTestWorkflowEnvironment()
+			env.ExecuteWorkflow(func(ctx workflow.Context) error {
+				_, err := handlePollStep(ctx, dsl.Step{Name: "poll", Plugin: "poll", Config: tc.config}, "test-name", "test-run-id", map[string]string{}, nil, nil, nil, nil)
+				return err
+			})
+			err := env.GetWorkflowError()
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.errMsg)
+			}
+		}
+	})
+}
+
+func TestTestWorkflow_StepRetryAttempts(t *testing.T) {
+	newEnv := func(attempts *int, failures []error, logs *[]string) *testsuite.TestWorkflowEnvironment {
+		testSuite := &testsuite.WorkflowTestSuite{}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.RegisterActivityWithOptions((&http.HTTPPlugin{}).Activity, activity.RegisterOptions{Name: "http"})
+		env.RegisterActivityWithOptions(LogForwarderActivity, activity.RegisterOptions{Name: "LogForwarderActivity"})
+		var mu sync.Mutex
+		env.OnActivity("LogForwarderActivity", mock.Anything, mock.Anything).Return(func(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			*logs = append(*logs, params["message"].(string))
+			return map[string]interface{}{"forwarded": true}, nil
+		})
+		env.OnActivity("http", mock.Anything, mock.Anything).Return(func(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+			*attempts++
+			if *attempts <= len(failures) {
+				return nil, failures[*attempts-1]
+			}
+			return &http.ActivityResponse{Response: &http.HTTPResponse{StatusCode: 200}}, nil
+		})
+		return env
+	}
+	retryTest := func(retry *dsl.RetryPolicy) dsl.Test {
+		return dsl.Test{
+			Name: "retry test",
+			Steps: []dsl.Step{{
+				Name:   "flaky",
+				Plugin: "http",
+				Config: map[string]interface{}{"method": "GET", "url": "http://example.com/health"},
+				Retry:  retry,
+			}},
+		}
+	}
+	assertionFailure := fmt.Errorf("assertion failed: expected status 200, got 503")
+	connectionFailure := fmt.Errorf("dial tcp 10.0.0.1:443: connection refused")
+
+	t.Run("reruns the step until it passes", func(t *testing.T) {
+		attempts := 0
+		var logs []string
+		env := newEnv(&attempts, []error{assertionFailure, connectionFailure}, &logs)
+		noJitter := 0.0
+		env.ExecuteWorkflow(TestWorkflow, retryTest(&dsl.RetryPolicy{
+			Attempts: 3,
+			Backoff:  &dsl.RetryBackoff{Delay: "1s", Multiplier: 3, Jitter: &noJitter},
+		}), make(map[string]interface{}), "test-run-id", (*dsl.OpenAPISuiteConfig)(nil), map[string]string(nil), map[string]string(nil))
+
+		assert.NoError(t, env.GetWorkflowError())
+		assert.Equal(t, 3, attempts)
+		assert.Contains(t, logs, "Attempt 1/3 failed (assertion), retrying in 1s: assertion failed: expected status 200, got 503")
+		assert.Contains(t, logs, "Attempt 2/3 failed (error), retrying in 3s: dial tcp 10.0.0.1:443: connection refused")
+		assert.Contains(t, logs, "Attempt 3/3 passed")
+	})
+
+	t.Run("fails with the last error once the attempts run out", func(t *testing.T) {
+		attempts := 0
+		var logs []string
+		env := newEnv(&attempts, []error{assertionFailure, assertionFailure, assertionFailure}, &logs)
+		env.ExecuteWorkflow(TestWorkflow, retryTest(&dsl.RetryPolicy{Attempts: 2}), make(map[string]interface{}), "test-run-id", (*dsl.OpenAPISuiteConfig)(nil), map[string]string(nil), map[string]string(nil))
+
+		err := env.GetWorkflowError()
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "failed after 2 attempts")
+			assert.Contains(t, err.Error(), "expected status 200, got 503")
+		}
+		assert.Equal(t, 2, attempts)
+	})
+
+	t.Run("stops at failures it isn't set to retry", func(t *testing.T) {
+		attempts := 0
+		var logs []string
+		env := newEnv(&attempts, []error{connectionFailure, assertionFailure}, &logs)
+		env.ExecuteWorkflow(TestWorkflow, retryTest(&dsl.RetryPolicy{Attempts: 3, On: []string{dsl.RetryOnError}}), make(map[string]interface{}), "test-run-id", (*dsl.OpenAPISuiteConfig)(nil), map[string]string(nil), map[string]string(nil))
+
+		err := env.GetWorkflowError()
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "attempt 2/3 failed and assertion failures aren't retried")
+		}
+		assert.Equal(t, 2, attempts)
+	})
+}
+
+func TestStepRetryBackoff(t *testing.T) {
+	jitter := 0.5
+	retry, err := parseStepRetry(&dsl.RetryPolicy{
+		Attempts: 5,
+		Backoff:  &dsl.RetryBackoff{Delay: "500ms", MaxDelay: "3s", Multiplier: 2, Jitter: &jitter},
+	})
+	if assert.NoError(t, err) {
+		delays := []time.Duration{retry.delay}
+		for i := 0; i < 3; i++ {
+			delays = append(delays, retry.nextDelay(delays[len(delays)-1]))
+		}
+		assert.Equal(t, []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second, 3 * time.Second}, delays)
+		assert.Equal(t, 0.5, retry.jitter)
+	}
+
+	retry, err = parseStepRetry(&dsl.RetryPolicy{MaximumAttempts: 3})
+	assert.NoError(t, err)
+	assert.Nil(t, retry, "activity retry fields are left to Temporal")
+
+	_, err = parseStepRetry(&dsl.RetryPolicy{Attempts: 2, On: []string{"flake"}})
+	assert.ErrorContains(t, err, `invalid retry on "flake"`)
+
+	assert.Equal(t, dsl.RetryOnAssertion, failureKind(fmt.Errorf("http activity error: Assertions failed: status: expected 200, got 500")))
+	assert.Equal(t, dsl.RetryOnTimeout, failureKind(fmt.Errorf("sql activity error: context deadline exceeded")))
+	assert.Equal(t, dsl.RetryOnError, failureKind(fmt.Errorf("http activity error: connection refused")))
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(substr) == 0 ||
 		(len(s) > len(substr) && findSubstring(s, substr)))
@@ -1012,3 +1563,498 @@ func findSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestTestWorkflow_StepConditions(t *testing.T) {
+	newEnv := func(reports map[string]string) *testsuite.TestWorkflowEnvironment {
+		testSuite := &testsuite.WorkflowTestSuite{}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.RegisterActivityWithOptions(TemplateResolverActivity, activity.RegisterOptions{Name: "TemplateResolverActivity"})
+		env.RegisterActivityWithOptions(StepReporterActivity, activity.RegisterOptions{Name: "StepReporterActivity"})
+		env.RegisterActivityWithOptions(LogForwarderActivity, activity.RegisterOptions{Name: "LogForwarderActivity"})
+		env.OnActivity("StepReporterActivity", mock.Anything, mock.Anything).Return(
+			func(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+				reports[params["step_name"].(string)] = params["status"].(string)
+				return map[string]interface{}{"step_id": ""}, nil
+			})
+		env.OnActivity("LogForwarderActivity", mock.Anything, mock.Anything).Return(
+			map[string]interface{}{"forwarded": true}, nil)
+		return env
+	}
+	delayStep := func(name, when, unless string) dsl.Step {
+		return dsl.Step{Name: name, Plugin: "delay", Config: map[string]interface{}{"duration": "1s"}, When: when, Unless: unless}
+	}
+
+	t.Run("skips steps whose conditions rule them out", func(t *testing.T) {
+		reports := map[string]string{}
+		env := newEnv(reports)
+		test := dsl.Test{
+			Name: "conditions test",
+			Steps: []dsl.Step{
+				delayStep("eu-only", `{{ region }} == "eu"`, ""),
+				delayStep("us-only", `{{ region }} == "us"`, ""),
+				delayStep("not-in-us", "", `{{ region }} == "us"`),
+				delayStep("large-orders", `{{ order_total }} >= 100 && {{ region }} != "eu"`, ""),
+			},
+		}
+		suiteGlobals := map[string]string{"region": "us", "order_total": "250"}
+		env.ExecuteWorkflow(TestWorkflow, test, make(map[string]interface{}), "test-run-id", (*dsl.OpenAPISuiteConfig)(nil), suiteGlobals, map[string]string(nil))
+
+		assert.NoError(t, env.GetWorkflowError())
+		assert.Equal(t, map[string]string{
+			"eu-only":      "SKIPPED",
+			"us-only":      "PASSED",
+			"not-in-us":    "SKIPPED",
+			"large-orders": "PASSED",
+		}, reports)
+	})
+
+	t.Run("fails steps with invalid conditions", func(t *testing.T) {
+		reports := map[string]string{}
+		env := newEnv(reports)
+		test := dsl.Test{
+			Name:  "invalid condition test",
+			Steps: []dsl.Step{delayStep("compare", `{{ region }} > 3`, "")},
+		}
+		env.ExecuteWorkflow(TestWorkflow, test, make(map[string]interface{}), "test-run-id", (*dsl.OpenAPISuiteConfig)(nil), map[string]string{"region": "us"}, map[string]string(nil))
+
+		err := env.GetWorkflowError()
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "invalid when condition")
+		}
+		assert.Equal(t, "FAILED", reports["compare"])
+	})
+}
+
+func TestTestWorkflow_ParallelSteps(t *testing.T) {
+	run := func(continueOnFailure bool) (map[string]map[string]interface{}, map[string]string, *testsuite.TestWorkflowEnvironment) {
+		testSuite := &testsuite.WorkflowTestSuite{}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.RegisterActivityWithOptions((&http.HTTPPlugin{}).Activity, activity.RegisterOptions{Name: "http"})
+		env.RegisterActivityWithOptions(TemplateResolverActivity, activity.RegisterOptions{Name: "TemplateResolverActivity"})
+		env.RegisterActivityWithOptions(StepReporterActivity, activity.RegisterOptions{Name: "StepReporterActivity"})
+		env.RegisterActivityWithOptions(LogForwarderActivity, activity.RegisterOptions{Name: "LogForwarderActivity"})
+		env.OnActivity("LogForwarderActivity", mock.Anything, mock.Anything).Return(
+			map[string]interface{}{"forwarded": true}, nil)
+		reports := map[string]string{}
+		env.OnActivity("StepReporterActivity", mock.Anything, mock.Anything).Return(
+			func(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+				reports[fmt.Sprint(params["step_index"])] = fmt.Sprintf("%s %s", params["step_name"], params["status"])
+				return map[string]interface{}{"step_id": ""}, nil
+			})
+		calls := map[string]map[string]interface{}{}
+		env.OnActivity("http", mock.Anything, mock.Anything).Return(
+			func(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+				config, _ := params["config"].(map[string]interface{})
+				url, _ := config["url"].(string)
+				state, _ := params["state"].(map[string]interface{})
+				calls[url] = state
+				if url == "http://payments" {
+					return nil, fmt.Errorf("assertion failed: expected status 200, got 503")
+				}
+				return &http.ActivityResponse{Response: &http.HTTPResponse{StatusCode: 200}, Saved: map[string]string{url: "up"}}, nil
+			})
+
+		group := &dsl.StepGroup{ID: 1, Name: "check services", MaxParallel: 2}
+		check := func(name string) dsl.Step {
+			return dsl.Step{
+				Name:              "check services: " + name,
+				Plugin:            "http",
+				Config:            map[string]interface{}{"method": "GET", "url": "http://" + name},
+				ContinueOnFailure: continueOnFailure,
+				Group:             group,
+			}
+		}
+		test := dsl.Test{
+			Name: "parallel test",
+			Steps: []dsl.Step{
+				check("orders"),
+				check("payments"),
+				check("users"),
+				{Name: "summary", Plugin: "http", Config: map[string]interface{}{"method": "GET", "url": "http://summary"}},
+			},
+		}
+		env.ExecuteWorkflow(TestWorkflow, test, make(map[string]interface{}), "test-run-id", (*dsl.OpenAPISuiteConfig)(nil), map[string]string(nil), map[string]string(nil))
+		return calls, reports, env
+	}
+
+	t.Run("runs every step of the group and stops after it", func(t *testing.T) {
+		calls, reports, env := run(false)
+		err := env.GetWorkflowError()
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "expected status 200, got 503")
+		}
+		assert.Len(t, calls, 3)
+		assert.NotContains(t, calls, "http://summary")
+		assert.NotContains(t, calls["http://users"], "http://orders", "steps in a group don't see each other's saves")
+		assert.Equal(t, map[string]string{
+			"0": "check services: orders PASSED",
+			"1": "check services: payments FAILED",
+			"2": "check services: users PASSED",
+		}, reports)
+	})
+
+	t.Run("continues after the group when its failed steps continue on failure", func(t *testing.T) {
+		calls, reports, env := run(true)
+		assert.Error(t, env.GetWorkflowError())
+		if assert.Contains(t, calls, "http://summary") {
+			assert.Equal(t, "up", calls["http://summary"]["http://orders"])
+			assert.Equal(t, "up", calls["http://summary"]["http://users"])
+		}
+		assert.Equal(t, "summary PASSED", reports["3"])
+	})
+}
+
+func TestTestWorkflow_SoftAssertions(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterActivityWithOptions((&http.HTTPPlugin{}).Activity, activity.RegisterOptions{Name: "http"})
+	env.RegisterActivityWithOptions(StepReporterActivity, activity.RegisterOptions{Name: "StepReporterActivity"})
+	env.RegisterActivityWithOptions(LogForwarderActivity, activity.RegisterOptions{Name: "LogForwarderActivity"})
+	env.OnActivity("LogForwarderActivity", mock.Anything, mock.Anything).Return(
+		map[string]interface{}{"forwarded": true}, nil)
+	reports := map[string]string{}
+	env.OnActivity("StepReporterActivity", mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+			reports[fmt.Sprint(params["step_index"])] = fmt.Sprintf("%s %s", params["step_name"], params["status"])
+			return map[string]interface{}{"step_id": ""}, nil
+		})
+	calls := map[string]map[string]interface{}{}
+	env.OnActivity("http", mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+			config, _ := params["config"].(map[string]interface{})
+			url, _ := config["url"].(string)
+			state, _ := params["state"].(map[string]interface{})
+			calls[url] = state
+			// Like the plugins, fail the step only on assertions that aren't soft
+			var results []http.HTTPAssertionResult
+			assertionList, _ := params["assertions"].([]interface{})
+			for _, assertion := range assertionList {
+				assertionMap, _ := assertion.(map[string]interface{})
+				result := http.HTTPAssertionResult{Type: "equals", Message: url + " mismatch", Soft: assertions.IsSoft(assertionMap)}
+				if !result.Soft {
+					return nil, fmt.Errorf("assertion failed: equals: %s", result.Message)
+				}
+				results = append(results, result)
+			}
+			return &http.ActivityResponse{
+				Response:         &http.HTTPResponse{StatusCode: 200},
+				Saved:            map[string]string{url: "saved"},
+				AssertionResults: results,
+			}, nil
+		})
+
+	check := []map[string]interface{}{{"type": "equals", "path": ".status", "expected": "paid"}}
+	test := dsl.Test{
+		Name: "soft test",
+		Steps: []dsl.Step{
+			{Name: "orders", Plugin: "http", Config: map[string]interface{}{"method": "GET", "url": "http://orders"},
+				Assertions: []map[string]interface{}{{"type": "equals", "path": ".status", "expected": "paid", "soft": true}}},
+			{Name: "payments", Plugin: "http", Config: map[string]interface{}{"method": "GET", "url": "http://payments"},
+				Assertions: check, Soft: true},
+			{Name: "summary", Plugin: "http", Config: map[string]interface{}{"method": "GET", "url": "http://summary"}},
+		},
+	}
+	env.ExecuteWorkflow(TestWorkflow, test, make(map[string]interface{}), "test-run-id", (*dsl.OpenAPISuiteConfig)(nil), map[string]string(nil), map[string]string(nil))
+
+	err := env.GetWorkflowError()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "2 steps failed")
+		assert.Contains(t, err.Error(), "Soft assertion failed: equals: http://orders mismatch")
+		assert.Contains(t, err.Error(), "Soft assertion failed: equals: http://payments mismatch")
+	}
+	if assert.Contains(t, calls, "http://summary") {
+		assert.Equal(t, "saved", calls["http://summary"]["http://orders"], "a step whose soft assertions fail still saves")
+	}
+	assert.Equal(t, map[string]string{
+		"0": "orders FAILED",
+		"1": "payments FAILED",
+		"2": "summary PASSED",
+	}, reports)
+	assert.NotContains(t, check[0], "soft", "a soft step's assertions are marked on a copy")
+}
+
+func TestTestWorkflow_RequiredEnv(t *testing.T) {
+	t.Setenv("TEST_API_URL", "https://api.example.com")
+	run := func(envSecrets map[string]string) (int, *testsuite.TestWorkflowEnvironment) {
+		testSuite := &testsuite.WorkflowTestSuite{}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.RegisterActivityWithOptions((&http.HTTPPlugin{}).Activity, activity.RegisterOptions{Name: "http"})
+		env.RegisterActivity(EnvCheckActivity)
+		env.RegisterActivityWithOptions(StepReporterActivity, activity.RegisterOptions{Name: "StepReporterActivity"})
+		env.RegisterActivityWithOptions(LogForwarderActivity, activity.RegisterOptions{Name: "LogForwarderActivity"})
+		env.OnActivity("LogForwarderActivity", mock.Anything, mock.Anything).Return(
+			map[string]interface{}{"forwarded": true}, nil)
+		env.OnActivity("StepReporterActivity", mock.Anything, mock.Anything).Return(
+			map[string]interface{}{"step_id": ""}, nil)
+		calls := 0
+		env.OnActivity("http", mock.Anything, mock.Anything).Return(
+			func(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+				calls++
+				return &http.ActivityResponse{Response: &http.HTTPResponse{StatusCode: 200}}, nil
+			})
+
+		test := dsl.Test{
+			Name:        "required env test",
+			RequiredEnv: []string{"TEST_API_URL", "TEST_API_TOKEN", "TEST_DB_URL"},
+			Steps: []dsl.Step{
+				{Name: "ping", Plugin: "http", Config: map[string]interface{}{"method": "GET", "url": "http://example.com"}},
+			},
+		}
+		env.ExecuteWorkflow(TestWorkflow, test, make(map[string]interface{}), "test-run-id", (*dsl.OpenAPISuiteConfig)(nil), map[string]string(nil), envSecrets)
+		return calls, env
+	}
+
+	t.Run("fails before the first step, naming every missing variable", func(t *testing.T) {
+		calls, env := run(map[string]string{"TEST_API_TOKEN": "token"})
+		err := env.GetWorkflowError()
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "not set: TEST_DB_URL;")
+		}
+		assert.Zero(t, calls)
+	})
+
+	t.Run("runs when the worker and project environment set them all", func(t *testing.T) {
+		calls, env := run(map[string]string{"TEST_API_TOKEN": "token", "TEST_DB_URL": "postgres://db"})
+		assert.NoError(t, env.GetWorkflowError())
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestTestWorkflow_ForEach(t *testing.T) {
+	newEnv := func(calls *[]map[string]interface{}, reports map[string]string) *testsuite.TestWorkflowEnvironment {
+		testSuite := &testsuite.WorkflowTestSuite{}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.RegisterActivityWithOptions((&http.HTTPPlugin{}).Activity, activity.RegisterOptions{Name: "http"})
+		env.RegisterActivityWithOptions(TemplateResolverActivity, activity.RegisterOptions{Name: "TemplateResolverActivity"})
+		env.RegisterActivityWithOptions(StepReporterActivity, activity.RegisterOptions{Name: "StepReporterActivity"})
+		env.RegisterActivityWithOptions(LogForwarderActivity, activity.RegisterOptions{Name: "LogForwarderActivity"})
+		env.OnActivity("LogForwarderActivity", mock.Anything, mock.Anything).Return(
+			map[string]interface{}{"forwarded": true}, nil)
+		env.OnActivity("StepReporterActivity", mock.Anything, mock.Anything).Return(
+			func(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+				reports[fmt.Sprint(params["step_index"])] = fmt.Sprintf("%s %s", params["step_name"], params["status"])
+				return map[string]interface{}{"step_id": ""}, nil
+			})
+		env.OnActivity("http", mock.Anything, mock.Anything).Return(
+			func(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+				state, _ := params["state"].(map[string]interface{})
+				*calls = append(*calls, state)
+				if state["user.id"] == "u2" {
+					return nil, fmt.Errorf("assertion failed: expected status 200, got 404")
+				}
+				saved := map[string]string{}
+				if id, ok := state["user.id"].(string); ok && state["user.readonly"] != "true" {
+					saved["last_user"] = id
+				}
+				return &http.ActivityResponse{Response: &http.HTTPResponse{StatusCode: 200}, Saved: saved}, nil
+			})
+		return env
+	}
+	fetchStep := func(forEach *dsl.ForEach) dsl.Step {
+		return dsl.Step{
+			Name:    "fetch-user",
+			Plugin:  "http",
+			Config:  map[string]interface{}{"method": "GET", "url": "http://example.com/users/{{ user.id }}"},
+			ForEach: forEach,
+		}
+	}
+	users := `[{"id": "u1", "team": {"name": "core"}}, {"id": "u3"}]`
+
+	t.Run("runs the step once per saved item", func(t *testing.T) {
+		var calls []map[string]interface{}
+		reports := map[string]string{}
+		env := newEnv(&calls, reports)
+		test := dsl.Test{
+			Name:  "for_each step test",
+			Steps: []dsl.Step{fetchStep(&dsl.ForEach{Items: "{{ users }}", As: "user"})},
+		}
+		env.ExecuteWorkflow(TestWorkflow, test, make(map[string]interface{}), "test-run-id", (*dsl.OpenAPISuiteConfig)(nil), map[string]string{"users": users}, map[string]string(nil))
+
+		assert.NoError(t, env.GetWorkflowError())
+		if assert.Len(t, calls, 2) {
+			assert.Equal(t, "u1", calls[0]["user.id"])
+			assert.Equal(t, "core", calls[0]["user.team.name"])
+			assert.Equal(t, "0", calls[0]["index"])
+			assert.Equal(t, "u3", calls[1]["user.id"])
+			assert.Equal(t, "1", calls[1]["index"])
+			assert.Equal(t, "u1", calls[1]["last_user"], "later iterations see earlier saves")
+		}
+		assert.Equal(t, map[string]string{"0": "fetch-user PASSED"}, reports)
+
+		var state map[string]string
+		assert.NoError(t, env.GetWorkflowResult(&state))
+		assert.Equal(t, "u3", state["last_user"])
+		assert.NotContains(t, state, "user.id")
+		assert.NotContains(t, state, "index")
+	})
+
+	t.Run("runs parallel iterations and reports every failure", func(t *testing.T) {
+		var calls []map[string]interface{}
+		reports := map[string]string{}
+		env := newEnv(&calls, reports)
+		items := []interface{}{
+			map[string]interface{}{"id": "u1"},
+			map[string]interface{}{"id": "u2"},
+			map[string]interface{}{"id": "u3"},
+		}
+		test := dsl.Test{
+			Name:  "parallel for_each test",
+			Steps: []dsl.Step{fetchStep(&dsl.ForEach{Items: items, As: "user", Parallel: true, MaxParallel: 2})},
+		}
+		env.ExecuteWorkflow(TestWorkflow, test, make(map[string]interface{}), "test-run-id", (*dsl.OpenAPISuiteConfig)(nil), map[string]string(nil), map[string]string(nil))
+
+		err := env.GetWorkflowError()
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), `iteration 2/3 (user "{\"id\":\"u2\"}") failed`)
+		}
+		assert.Len(t, calls, 3, "parallel iterations all run")
+		assert.Equal(t, map[string]string{"0": "fetch-user FAILED"}, reports)
+	})
+
+	t.Run("keeps a parallel iteration's save when a later one saves nothing", func(t *testing.T) {
+		var calls []map[string]interface{}
+		reports := map[string]string{}
+		env := newEnv(&calls, reports)
+		items := []interface{}{
+			map[string]interface{}{"id": "u1"},
+			map[string]interface{}{"id": "u3", "readonly": true},
+		}
+		test := dsl.Test{
+			Name:  "parallel for_each test",
+			Steps: []dsl.Step{fetchStep(&dsl.ForEach{Items: items, As: "user", Parallel: true})},
+		}
+		env.ExecuteWorkflow(TestWorkflow, test, make(map[string]interface{}), "test-run-id", (*dsl.OpenAPISuiteConfig)(nil), map[string]string{"last_user": "u0"}, map[string]string(nil))
+
+		assert.NoError(t, env.GetWorkflowError())
+		var state map[string]string
+		assert.NoError(t, env.GetWorkflowResult(&state))
+		assert.Equal(t, "u1", state["last_user"])
+	})
+
+	t.Run("skips steps without items", func(t *testing.T) {
+		var calls []map[string]interface{}
+		reports := map[string]string{}
+		env := newEnv(&calls, reports)
+		test := dsl.Test{
+			Name:  "empty for_each test",
+			Steps: []dsl.Step{fetchStep(&dsl.ForEach{Items: "{{ users }}", As: "user"})},
+		}
+		env.ExecuteWorkflow(TestWorkflow, test, make(map[string]interface{}), "test-run-id", (*dsl.OpenAPISuiteConfig)(nil), map[string]string{"users": "[]"}, map[string]string(nil))
+
+		assert.NoError(t, env.GetWorkflowError())
+		assert.Empty(t, calls)
+		assert.Equal(t, map[string]string{"0": "fetch-user SKIPPED"}, reports)
+	})
+
+	t.Run("runs a test's steps once per vars item", func(t *testing.T) {
+		var calls []map[string]interface{}
+		reports := map[string]string{}
+		env := newEnv(&calls, reports)
+		test := dsl.Test{
+			Name:    "for_each test",
+			ForEach: &dsl.ForEach{Items: "{{ .vars.regions }}", As: "region"},
+			Steps: []dsl.Step{
+				{Name: "ping", Plugin: "http", Config: map[string]interface{}{"method": "GET", "url": "http://{{ region }}.example.com"}},
+				{Name: "wait", Plugin: "delay", Config: map[string]interface{}{"duration": "1s"}},
+			},
+		}
+		vars := map[string]interface{}{"regions": []interface{}{"us", "eu"}}
+		env.ExecuteWorkflow(TestWorkflow, test, vars, "test-run-id", (*dsl.OpenAPISuiteConfig)(nil), map[string]string(nil), map[string]string(nil))
+
+		assert.NoError(t, env.GetWorkflowError())
+		if assert.Len(t, calls, 2) {
+			assert.Equal(t, "us", calls[0]["region"])
+			assert.Equal(t, "eu", calls[1]["region"])
+		}
+		assert.Equal(t, map[string]string{"0": "ping [0] PASSED", "1": "wait [0] PASSED", "2": "ping [1] PASSED", "3": "wait [1] PASSED"}, reports)
+	})
+}
+
+func TestTestWorkflow_WebhookWaitStep(t *testing.T) {
+	t.Setenv(webhook_wait.PublicURLEnv, "https://hooks.example.com")
+	call := func(id, body string) callbacks.Call {
+		return callbacks.Call{ID: id, Request: &callbacks.Request{
+			Method:     "POST",
+			Path:       "/",
+			RawBody:    body,
+			Body:       body,
+			ReceivedAt: time.Now().UTC().Format(time.RFC3339Nano),
+		}}
+	}
+	run := func(setup func(env *testsuite.TestWorkflowEnvironment), steps ...dsl.Step) (*testsuite.TestWorkflowEnvironment, map[string]string) {
+		testSuite := &testsuite.WorkflowTestSuite{}
+		env := testSuite.NewTestWorkflowEnvironment()
+		env.RegisterActivityWithOptions((&webhook_wait.WebhookWaitPlugin{}).Activity, activity.RegisterOptions{Name: "webhook_wait"})
+		env.RegisterActivityWithOptions(LogForwarderActivity, activity.RegisterOptions{Name: "LogForwarderActivity"})
+		env.OnActivity("LogForwarderActivity", mock.Anything, mock.Anything).Return(
+			map[string]interface{}{"forwarded": true}, nil)
+		setup(env)
+
+		test := dsl.Test{Name: "webhook test", Steps: steps}
+		env.ExecuteWorkflow(TestWorkflow, test, make(map[string]interface{}), "test-run-id", (*dsl.OpenAPISuiteConfig)(nil), map[string]string(nil), map[string]string(nil))
+		var state map[string]string
+		if env.GetWorkflowError() == nil {
+			assert.NoError(t, env.GetWorkflowResult(&state))
+		}
+		return env, state
+	}
+	waitStep := dsl.Step{
+		Name:   "order shipped",
+		Plugin: "webhook_wait",
+		Config: map[string]interface{}{
+			"id":      "order-1",
+			"timeout": "1m",
+			"match":   map[string]interface{}{"contains": "shipped"},
+			"respond": map[string]interface{}{"status": 202},
+		},
+		Save: []map[string]interface{}{{"json_path": ".raw_body", "as": "callback"}},
+	}
+	delayStep := func(duration string) dsl.Step {
+		return dsl.Step{Name: "wait", Plugin: "delay", Config: map[string]interface{}{"duration": duration}}
+	}
+
+	t.Run("captures the matching call the engine signals", func(t *testing.T) {
+		env, state := run(func(env *testsuite.TestWorkflowEnvironment) {
+			env.RegisterDelayedCallback(func() {
+				// The engine asks which step waits on the callback to answer the caller
+				value, err := env.QueryWorkflow(callbacks.HookQuery, "order-1")
+				if assert.NoError(t, err) {
+					var hook callbacks.Hook
+					assert.NoError(t, value.Get(&hook))
+					assert.True(t, hook.Waiting)
+					assert.Equal(t, 202, hook.Respond.Status)
+				}
+				env.SignalWorkflow(callbacks.CallSignal, call("order-2", "shipped elsewhere"))
+				env.SignalWorkflow(callbacks.CallSignal, call("order-1", "pending"))
+				env.SignalWorkflow(callbacks.CallSignal, call("order-1", "shipped"))
+			}, 10*time.Second)
+		}, waitStep)
+
+		assert.NoError(t, env.GetWorkflowError())
+		assert.Equal(t, "shipped", state["callback"])
+	})
+
+	t.Run("keeps a call that arrives before the step starts", func(t *testing.T) {
+		env, state := run(func(env *testsuite.TestWorkflowEnvironment) {
+			env.RegisterDelayedCallback(func() {
+				env.SignalWorkflow(callbacks.CallSignal, call("order-1", "shipped early"))
+			}, time.Second)
+		}, delayStep("30s"), waitStep)
+
+		assert.NoError(t, env.GetWorkflowError())
+		assert.Equal(t, "shipped early", state["callback"])
+	})
+
+	t.Run("fails when no matching call arrives in time", func(t *testing.T) {
+		env, _ := run(func(env *testsuite.TestWorkflowEnvironment) {
+			env.RegisterDelayedCallback(func() {
+				env.SignalWorkflow(callbacks.CallSignal, call("order-1", "pending"))
+			}, 10*time.Second)
+		}, waitStep)
+
+		err := env.GetWorkflowError()
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), `no call to https://hooks.example.com/hooks/test-run-id/order-1 with body containing "shipped" arrived within 1m0s`)
+		}
+	})
+}
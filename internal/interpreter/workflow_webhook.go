@@ -0,0 +1,225 @@
+package interpreter
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/rocketship-ai/rocketship/internal/callbacks"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+)
+
+// webhookPhaseTimeout bounds the start and trigger activities of a webhook_wait step
+const webhookPhaseTimeout = time.Minute
+
+// webhookHubKey is the workflow context key holding a test's callback hub
+type webhookHubKey struct{}
+
+// webhookHub hands the calls the engine signals to the test's webhook_wait steps.
+// Calls for a callback no step is waiting on yet are kept for
+// callbacks.EarlyCallTTL, so a call made by an earlier step isn't lost.
+type webhookHub struct {
+	started bool
+	hooks   map[string]*webhookHook
+	early   map[string][]*callbacks.Request
+}
+
+// webhookHook is a callback a step is waiting on
+type webhookHook struct {
+	match    *callbacks.Match
+	respond  callbacks.Respond
+	captured *callbacks.Request
+	ignored  int
+}
+
+// withWebhookHub attaches an empty callback hub to the test's context
+func withWebhookHub(ctx workflow.Context) workflow.Context {
+	return workflow.WithValue(ctx, webhookHubKey{}, &webhookHub{
+		hooks: make(map[string]*webhookHook),
+		early: make(map[string][]*callbacks.Request),
+	})
+}
+
+// testWebhookHub returns the test's callback hub, or nil outside a test workflow
+func testWebhookHub(ctx workflow.Context) *webhookHub {
+	hub, _ := ctx.Value(webhookHubKey{}).(*webhookHub)
+	return hub
+}
+
+// start answers the engine's hook queries and receives its calls. It runs with
+// the first webhook_wait step; calls signaled before then wait in the channel.
+func (h *webhookHub) start(ctx workflow.Context) error {
+	if h.started {
+		return nil
+	}
+	err := workflow.SetQueryHandler(ctx, callbacks.HookQuery, func(id string) (callbacks.Hook, error) {
+		hook, ok := h.hooks[id]
+		if !ok {
+			return callbacks.Hook{}, nil
+		}
+		return callbacks.Hook{Waiting: true, Respond: hook.respond}, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to answer callback queries: %w", err)
+	}
+
+	calls := workflow.GetSignalChannel(ctx, callbacks.CallSignal)
+	// Receiving outlives the step that started it
+	receiveCtx, _ := workflow.NewDisconnectedContext(ctx)
+	workflow.Go(receiveCtx, func(ctx workflow.Context) {
+		for {
+			var call callbacks.Call
+			if !calls.Receive(ctx, &call) {
+				return
+			}
+			h.deliver(ctx, call)
+		}
+	})
+	h.started = true
+	return nil
+}
+
+// deliver hands a call to the step waiting on its id, or keeps it for a step
+// that starts later
+func (h *webhookHub) deliver(ctx workflow.Context, call callbacks.Call) {
+	if call.Request == nil {
+		return
+	}
+	if hook, ok := h.hooks[call.ID]; ok {
+		if hook.captured == nil && hook.match.Matches(call.Request) {
+			hook.captured = call.Request
+		} else {
+			hook.ignored++
+		}
+		return
+	}
+
+	var kept []*callbacks.Request
+	for _, request := range h.early[call.ID] {
+		if !earlyCallExpired(ctx, request) {
+			kept = append(kept, request)
+		}
+	}
+	if len(kept) < callbacks.MaxEarlyCalls {
+		kept = append(kept, call.Request)
+	}
+	h.early[call.ID] = kept
+}
+
+// register starts capturing calls for id. A call that arrived before the step
+// started is captured right away when it matches.
+func (h *webhookHub) register(ctx workflow.Context, wait *callbacks.Wait) (*webhookHook, error) {
+	if _, exists := h.hooks[wait.ID]; exists {
+		return nil, fmt.Errorf("another step is already waiting on callback %q", wait.ID)
+	}
+	hook := &webhookHook{match: wait.Match, respond: wait.Respond}
+	h.hooks[wait.ID] = hook
+
+	var kept []*callbacks.Request
+	for _, request := range h.early[wait.ID] {
+		if earlyCallExpired(ctx, request) {
+			continue
+		}
+		if hook.captured == nil && hook.match.Matches(request) {
+			hook.captured = request
+			continue
+		}
+		kept = append(kept, request)
+	}
+	if len(kept) == 0 {
+		delete(h.early, wait.ID)
+	} else {
+		h.early[wait.ID] = kept
+	}
+	return hook, nil
+}
+
+func (h *webhookHub) unregister(id string) {
+	delete(h.hooks, id)
+}
+
+func earlyCallExpired(ctx workflow.Context, request *callbacks.Request) bool {
+	at, err := time.Parse(time.RFC3339Nano, request.ReceivedAt)
+	return err == nil && workflow.Now(ctx).Sub(at) > callbacks.EarlyCallTTL
+}
+
+// handleWebhookWaitStep waits for the callback in the workflow, where the engine
+// signals it, so the call reaches the step whichever worker runs the test. The
+// plugin renders the config and sends the trigger before, and checks the
+// captured call after.
+func handleWebhookWaitStep(ctx workflow.Context, step dsl.Step, testName, runID string, state map[string]string, vars map[string]interface{}, suiteOpenAPI *dsl.OpenAPISuiteConfig, opts *executionOptions, envSecrets map[string]string) (interface{}, error) {
+	hub := testWebhookHub(ctx)
+	if hub == nil {
+		return nil, fmt.Errorf("step %q: webhook_wait steps can't run in suite cleanup", step.Name)
+	}
+	if err := hub.start(ctx); err != nil {
+		return nil, fmt.Errorf("step %q: %w", step.Name, err)
+	}
+
+	phaseOpts := func(phase string, params map[string]interface{}) *executionOptions {
+		params["phase"] = phase
+		return &executionOptions{
+			ActivityTimeout: webhookPhaseTimeout,
+			RetryPolicy:     &temporal.RetryPolicy{MaximumAttempts: 1},
+			Params:          params,
+		}
+	}
+
+	started := workflow.Now(ctx)
+	resp, err := executePluginWithResponse(ctx, step, state, vars, runID, testName, suiteOpenAPI, phaseOpts(callbacks.PhaseStart, map[string]interface{}{}), envSecrets)
+	if err != nil {
+		return resp, err
+	}
+	wait := &callbacks.Wait{}
+	if err := decodeActivityResult(resp, wait); err != nil {
+		return nil, fmt.Errorf("step %q: invalid webhook_wait callback: %w", step.Name, err)
+	}
+
+	hook, err := hub.register(ctx, wait)
+	if err != nil {
+		return nil, fmt.Errorf("step %q: %w", step.Name, err)
+	}
+	defer hub.unregister(wait.ID)
+
+	response := map[string]interface{}{"id": wait.ID, "url": wait.URL}
+	if wait.Trigger != nil {
+		result, err := executePluginWithResponse(ctx, step, state, vars, runID, testName, suiteOpenAPI, phaseOpts(callbacks.PhaseTrigger, map[string]interface{}{"trigger": wait.Trigger}), envSecrets)
+		if err != nil {
+			return result, err
+		}
+		response["trigger"] = result
+	}
+
+	remaining := wait.Timeout - workflow.Now(ctx).Sub(started)
+	if hook.captured == nil && remaining > 0 {
+		if _, err := workflow.AwaitWithTimeout(ctx, remaining, func() bool { return hook.captured != nil }); err != nil {
+			return nil, err
+		}
+	}
+	if hook.captured == nil {
+		return nil, callbacks.NoCallError(wait.URL, wait.Match, wait.Timeout)
+	}
+	response["request"] = hook.captured
+	response["ignored"] = hook.ignored
+	response["duration"] = workflow.Now(ctx).Sub(started).String()
+
+	finishOpts := &executionOptions{Params: map[string]interface{}{"phase": callbacks.PhaseFinish, "response": response}}
+	if opts != nil {
+		finishOpts.ActivityTimeout = opts.ActivityTimeout
+		finishOpts.RetryPolicy = opts.RetryPolicy
+	}
+	return executePluginWithResponse(ctx, step, state, vars, runID, testName, suiteOpenAPI, finishOpts, envSecrets)
+}
+
+// decodeActivityResult decodes an activity result, which the workflow receives as
+// generic JSON values, into out
+func decodeActivityResult(resp interface{}, out interface{}) error {
+	encoded, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, out)
+}
@@ -44,13 +44,16 @@ func (p permission) String() string {
 }
 
 var methodPermissions = map[string]permission{
-	"/rocketship.v1.Engine/CreateRun":     permWrite,
-	"/rocketship.v1.Engine/AddLog":        permWrite,
-	"/rocketship.v1.Engine/CancelRun":     permWrite,
-	"/rocketship.v1.Engine/UpsertRunStep": permWrite,
-	"/rocketship.v1.Engine/ListRuns":      permRead,
-	"/rocketship.v1.Engine/GetRun":        permRead,
-	"/rocketship.v1.Engine/StreamLogs":    permRead,
+	"/rocketship.v1.Engine/CreateRun":                permWrite,
+	"/rocketship.v1.Engine/AddLog":                   permWrite,
+	"/rocketship.v1.Engine/CancelRun":                permWrite,
+	"/rocketship.v1.Engine/PruneRuns":                permWrite,
+	"/rocketship.v1.Engine/CreatePreviewEnvironment": permWrite,
+	"/rocketship.v1.Engine/DeletePreviewEnvironment": permWrite,
+	"/rocketship.v1.Engine/UpsertRunStep":            permWrite,
+	"/rocketship.v1.Engine/ListRuns":                 permRead,
+	"/rocketship.v1.Engine/GetRun":                   permRead,
+	"/rocketship.v1.Engine/StreamLogs":               permRead,
 }
 
 type principalContextKey struct{}
@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -408,7 +409,7 @@ func principalFromClaims(claims jwt.MapClaims) (*Principal, error) {
 	}
 	scopes := parseScopeClaim(claims["scope"])
 	orgID := stringClaim(claims["org_id"])
-	return &Principal{
+	principal := &Principal{
 		Subject:  subject,
 		Email:    stringClaim(claims["email"]),
 		Name:     stringClaim(claims["name"]),
@@ -417,7 +418,39 @@ func principalFromClaims(claims jwt.MapClaims) (*Principal, error) {
 		Scopes:   scopes,
 		TokenID:  stringClaim(claims["jti"]),
 		OrgID:    orgID,
-	}, nil
+	}
+
+	// Tokens exchanged for a CI provider's ID token are limited to the projects
+	// connected to the job's repository, like CI tokens
+	if raw, ok := claims["ci_projects"]; ok {
+		projects, err := ciProjectsClaim(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ci_projects claim: %w", err)
+		}
+		principal.IsCIToken = true
+		principal.AllowedProjects = projects
+	}
+	return principal, nil
+}
+
+func ciProjectsClaim(value interface{}) ([]CITokenProjectScope, error) {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected type %T", value)
+	}
+	projects := make([]CITokenProjectScope, 0, len(items))
+	for _, item := range items {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unexpected entry type %T", item)
+		}
+		projectID, err := uuid.Parse(stringClaim(entry["project_id"]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid project_id: %w", err)
+		}
+		projects = append(projects, CITokenProjectScope{ProjectID: projectID, Scope: stringClaim(entry["scope"])})
+	}
+	return projects, nil
 }
 
 func stringClaim(value interface{}) string {
@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 	"github.com/rocketship-ai/rocketship/internal/api/generated"
 	"go.temporal.io/sdk/client"
 	"google.golang.org/grpc"
@@ -717,3 +718,31 @@ func TestResolvePrincipalAndOrgForInternalCallbacks(t *testing.T) {
 		}
 	})
 }
+
+func TestPrincipalFromExchangedCIClaims(t *testing.T) {
+	allowed, other := uuid.New(), uuid.New()
+	principal, err := principalFromClaims(jwt.MapClaims{
+		"sub":         "ci:github:repo:acme/shop:ref:refs/heads/main",
+		"roles":       []interface{}{"editor"},
+		"org_id":      "org-1",
+		"ci_projects": []interface{}{map[string]interface{}{"project_id": allowed.String(), "scope": "write"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !principal.IsCIToken || !principal.HasProjectAccess(allowed, permWrite) || principal.HasProjectAccess(other, permRead) {
+		t.Errorf("expected access limited to the exchanged project, got %+v", principal)
+	}
+	if got := determineInitiator(principal); got != "ci:github:repo:acme/shop:ref:refs/heads/main" {
+		t.Errorf("unexpected initiator %q", got)
+	}
+
+	_, err = principalFromClaims(jwt.MapClaims{
+		"sub":         "ci:github:repo:acme/shop:ref:refs/heads/main",
+		"roles":       []interface{}{"editor"},
+		"ci_projects": []interface{}{map[string]interface{}{"project_id": "shop"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid ci_projects claim") {
+		t.Errorf("expected an invalid claim error, got %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rocketship-ai/rocketship/internal/controlplane/persistence"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+)
+
+// runStatusBudgetExceeded is the final status of runs stopped by the suite budget
+const runStatusBudgetExceeded = "BUDGET_EXCEEDED"
+
+// testBudgetViolation returns why a run with this many tests is over budget, or ""
+// when it is within it
+func testBudgetViolation(budget *dsl.SuiteBudget, tests int) string {
+	if budget == nil || budget.MaxTests <= 0 || tests <= budget.MaxTests {
+		return ""
+	}
+	return fmt.Sprintf("suite has %d tests, more than budget.max_tests (%d)", tests, budget.MaxTests)
+}
+
+// rejectOverBudget ends a run before suite init or any test starts. Nothing ran, so
+// there is nothing for suite cleanup to undo.
+func (e *Engine) rejectOverBudget(runID string, runInfo *RunInfo, tests int, reason string) {
+	ended := time.Now().UTC()
+
+	e.mu.Lock()
+	runInfo.Status = runStatusBudgetExceeded
+	runInfo.BudgetExceeded = reason
+	runInfo.CancelReason = CancelReasonQuota
+	runInfo.CancelMessage = reason
+	runInfo.SuiteCleanupRan = true
+	runInfo.EndedAt = ended
+	e.mu.Unlock()
+
+	e.addLog(runID, fmt.Sprintf("Budget exceeded: %s", reason), "red", true)
+	e.addLog(runID, fmt.Sprintf("Test run: \"%s\" ended without executing any tests.", runInfo.Name), "n/a", true)
+
+	if runInfo.OrganizationID != uuid.Nil && e.runStore != nil {
+		if _, err := e.runStore.UpdateRun(context.Background(), persistence.RunUpdate{
+			RunID:          runID,
+			OrganizationID: runInfo.OrganizationID,
+			Status:         stringPtr(runStatusBudgetExceeded),
+			EndedAt:        timePtr(ended),
+			Totals:         &persistence.RunTotals{Total: tests, Skipped: tests},
+			CancelReason:   stringPtr(CancelReasonQuota),
+			CancelMessage:  reason,
+		}); err != nil {
+			slog.Error("rejectOverBudget: failed to persist budget state", "run_id", runID, "error", err)
+		}
+	}
+}
+
+// startDurationBudget arms budget.max_duration for a run that was just registered
+func (e *Engine) startDurationBudget(runID string, budget *dsl.SuiteBudget) {
+	if budget == nil || budget.MaxDuration == "" {
+		return
+	}
+	limit, err := time.ParseDuration(budget.MaxDuration)
+	if err != nil || limit <= 0 {
+		slog.Warn("startDurationBudget: ignoring invalid budget.max_duration", "run_id", runID, "max_duration", budget.MaxDuration)
+		return
+	}
+
+	timer := time.AfterFunc(limit, func() {
+		e.enforceDurationBudget(runID, budget.MaxDuration)
+	})
+
+	e.mu.Lock()
+	if runInfo, ok := e.runs[runID]; ok {
+		runInfo.BudgetTimer = timer
+	}
+	e.mu.Unlock()
+}
+
+// enforceDurationBudget cancels whatever the run still has in flight. The test
+// monitors then see the cancellations and checkIfRunFinished ends the run as
+// BUDGET_EXCEEDED with cancel reason "timeout", running suite cleanup on the way.
+func (e *Engine) enforceDurationBudget(runID, maxDuration string) {
+	reason := fmt.Sprintf("run took longer than budget.max_duration (%s)", maxDuration)
+
+	e.mu.Lock()
+	runInfo, ok := e.runs[runID]
+	if !ok || runInfo.Status != "RUNNING" || runInfo.BudgetExceeded != "" {
+		e.mu.Unlock()
+		return
+	}
+	runInfo.BudgetExceeded = reason
+	runInfo.CancelReason = CancelReasonTimeout
+	runInfo.CancelMessage = reason
+	workflows := runningWorkflows(runID, runInfo)
+	e.mu.Unlock()
+
+	slog.Info("enforceDurationBudget: cancelling run", "run_id", runID, "max_duration", maxDuration, "workflow_count", len(workflows))
+	e.addLog(runID, fmt.Sprintf("Budget exceeded: %s; cancelling %d running workflows", reason, len(workflows)), "red", true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for _, workflowID := range workflows {
+		if err := e.temporal.CancelWorkflow(ctx, workflowID, ""); err != nil {
+			slog.Warn("enforceDurationBudget: failed to cancel workflow", "run_id", runID, "workflow_id", workflowID, "error", err)
+		}
+	}
+}
+
+// budgetExceeded reports whether the run has been stopped by its budget
+func (e *Engine) budgetExceeded(runID string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	runInfo, ok := e.runs[runID]
+	return ok && runInfo.BudgetExceeded != ""
+}
+
+// stopBudgetTimer disarms max_duration once a run has ended. The caller holds e.mu.
+func stopBudgetTimer(runInfo *RunInfo) {
+	if runInfo.BudgetTimer != nil {
+		runInfo.BudgetTimer.Stop()
+		runInfo.BudgetTimer = nil
+	}
+}
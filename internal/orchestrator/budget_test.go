@@ -0,0 +1,121 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/client"
+)
+
+type cancelRecordingClient struct {
+	client.Client
+	mu        sync.Mutex
+	cancelled []string
+}
+
+func (c *cancelRecordingClient) CancelWorkflow(_ context.Context, workflowID, _ string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cancelled = append(c.cancelled, workflowID)
+	return nil
+}
+
+func TestTestBudgetViolation(t *testing.T) {
+	require.Empty(t, testBudgetViolation(nil, 500))
+	require.Empty(t, testBudgetViolation(&dsl.SuiteBudget{MaxDuration: "15m"}, 500))
+	require.Empty(t, testBudgetViolation(&dsl.SuiteBudget{MaxTests: 200}, 200))
+	require.Equal(t, "suite has 201 tests, more than budget.max_tests (200)", testBudgetViolation(&dsl.SuiteBudget{MaxTests: 200}, 201))
+}
+
+func TestRejectOverBudget(t *testing.T) {
+	engine := newTestEngineWithClient(&MockTemporalClient{})
+	runInfo := &RunInfo{
+		ID:           "run-1",
+		Name:         "Matrix",
+		Status:       "RUNNING",
+		Tests:        map[string]*TestInfo{},
+		SuiteCleanup: &dsl.CleanupSpec{Always: []dsl.Step{{Name: "teardown", Plugin: "log"}}},
+	}
+	engine.runs["run-1"] = runInfo
+
+	engine.rejectOverBudget("run-1", runInfo, 300, testBudgetViolation(&dsl.SuiteBudget{MaxTests: 200}, 300))
+
+	require.Equal(t, runStatusBudgetExceeded, runInfo.Status)
+	require.False(t, runInfo.EndedAt.IsZero())
+	// Nothing ran, so suite cleanup is skipped
+	require.True(t, runInfo.SuiteCleanupRan)
+	require.Contains(t, runInfo.Logs[0].Msg, "more than budget.max_tests (200)")
+}
+
+func TestEnforceDurationBudget(t *testing.T) {
+	temporal := &cancelRecordingClient{}
+	engine := newTestEngineWithClient(temporal)
+	runInfo := &RunInfo{
+		ID:                 "run-1",
+		Name:               "Slow suite",
+		Status:             "RUNNING",
+		SuiteInitCompleted: true,
+		Tests: map[string]*TestInfo{
+			"wf-done":  {Name: "done", Status: "PASSED"},
+			"wf-slow1": {Name: "slow1", Status: "PENDING"},
+			"wf-slow2": {Name: "slow2", Status: "PENDING"},
+		},
+	}
+	engine.runs["run-1"] = runInfo
+
+	engine.enforceDurationBudget("run-1", "15m")
+	sort.Strings(temporal.cancelled)
+	require.Equal(t, []string{"wf-slow1", "wf-slow2"}, temporal.cancelled)
+	require.True(t, engine.budgetExceeded("run-1"))
+
+	// Firing again does nothing
+	engine.enforceDurationBudget("run-1", "15m")
+	require.Len(t, temporal.cancelled, 2)
+
+	engine.updateTestStatus("run-1", "wf-slow1", errors.New("canceled"))
+	require.Equal(t, "RUNNING", runInfo.Status)
+	engine.updateTestStatus("run-1", "wf-slow2", errors.New("canceled"))
+	require.Equal(t, runStatusBudgetExceeded, runInfo.Status)
+	require.False(t, runInfo.EndedAt.IsZero())
+
+	var messages []string
+	for _, line := range runInfo.Logs {
+		messages = append(messages, line.Msg)
+	}
+	require.Contains(t, messages, "Budget exceeded: run took longer than budget.max_duration (15m)")
+	require.Contains(t, messages, "Test run: \"Slow suite\" finished. 1/3 tests passed, 2/3 tests failed.")
+}
+
+func TestEnforceDurationBudgetDuringSuiteInit(t *testing.T) {
+	temporal := &cancelRecordingClient{}
+	engine := newTestEngineWithClient(temporal)
+	runInfo := &RunInfo{ID: "run-1", Name: "Slow init", Status: "RUNNING", Tests: map[string]*TestInfo{}}
+	engine.runs["run-1"] = runInfo
+
+	engine.enforceDurationBudget("run-1", "1m")
+	require.Equal(t, []string{"run-1_suite_init"}, temporal.cancelled)
+
+	engine.handleSuiteInitFailure("run-1", runInfo, errors.New("canceled"))
+	require.Equal(t, runStatusBudgetExceeded, runInfo.Status)
+}
+
+func TestEnforceDurationBudgetAfterRunEnded(t *testing.T) {
+	temporal := &cancelRecordingClient{}
+	engine := newTestEngineWithClient(temporal)
+	runInfo := &RunInfo{
+		ID:     "run-1",
+		Status: "PASSED",
+		Tests:  map[string]*TestInfo{"wf-1": {Status: "PASSED"}},
+	}
+	engine.runs["run-1"] = runInfo
+
+	engine.enforceDurationBudget("run-1", "1m")
+	require.Empty(t, temporal.cancelled)
+	require.Equal(t, "PASSED", runInfo.Status)
+	require.Empty(t, runInfo.BudgetExceeded)
+}
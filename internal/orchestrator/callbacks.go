@@ -0,0 +1,111 @@
+package orchestrator
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/callbacks"
+)
+
+// callbackTimeout bounds the queries and signals that hand a call to a workflow
+const callbackTimeout = 10 * time.Second
+
+// CallbackHandler serves the callback URLs of webhook_wait steps,
+// <base>/hooks/<run id>/<id>. The engine hands each call to the run's
+// workflows through Temporal, so the step gets it whichever worker runs it.
+func (e *Engine) CallbackHandler() http.Handler {
+	return http.HandlerFunc(e.serveCallback)
+}
+
+func (e *Engine) serveCallback(w http.ResponseWriter, r *http.Request) {
+	rest, ok := strings.CutPrefix(r.URL.EscapedPath(), callbacks.PathPrefix)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	runID, rest, _ := strings.Cut(rest, "/")
+	id, suffix, _ := strings.Cut(rest, "/")
+	runID, runErr := url.PathUnescape(runID)
+	id, idErr := url.PathUnescape(id)
+	suffix, suffixErr := url.PathUnescape(suffix)
+	if runID == "" || id == "" || runErr != nil || idErr != nil || suffixErr != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(r.Body, callbacks.MaxBodyBytes+1))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if len(raw) > callbacks.MaxBodyBytes {
+		http.Error(w, "body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	call := callbacks.Call{ID: id, Request: callbacks.NewRequest(r, "/"+suffix, raw, time.Now())}
+
+	respond, delivered := e.deliverCallback(r.Context(), runID, call)
+	if !delivered {
+		http.Error(w, "no running test is waiting on this callback", http.StatusNotFound)
+		return
+	}
+
+	for name, value := range respond.Headers {
+		w.Header().Set(name, value)
+	}
+	status := respond.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, respond.Body)
+}
+
+// deliverCallback signals the call to the workflow of the run waiting on its
+// id, and returns that step's reply. When no step waits on it yet, every
+// workflow still running in the run keeps the call for a step that starts later.
+func (e *Engine) deliverCallback(ctx context.Context, runID string, call callbacks.Call) (callbacks.Respond, bool) {
+	e.mu.RLock()
+	var workflows []string
+	if runInfo, ok := e.runs[runID]; ok && runInfo.Status == "RUNNING" {
+		workflows = runningWorkflows(runID, runInfo)
+	}
+	e.mu.RUnlock()
+	if len(workflows) == 0 {
+		return callbacks.Respond{}, false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, callbackTimeout)
+	defer cancel()
+
+	respond := callbacks.Respond{}
+	targets := workflows
+	for _, workflowID := range workflows {
+		value, err := e.temporal.QueryWorkflow(ctx, workflowID, "", callbacks.HookQuery, call.ID)
+		if err != nil {
+			// Workflows that haven't run a webhook_wait step don't answer yet
+			continue
+		}
+		var hook callbacks.Hook
+		if err := value.Get(&hook); err == nil && hook.Waiting {
+			respond = hook.Respond
+			targets = []string{workflowID}
+			break
+		}
+	}
+
+	delivered := false
+	for _, workflowID := range targets {
+		if err := e.temporal.SignalWorkflow(ctx, workflowID, "", callbacks.CallSignal, call); err != nil {
+			slog.Debug("deliverCallback: failed to signal workflow", "run_id", runID, "workflow_id", workflowID, "error", err)
+			continue
+		}
+		delivered = true
+	}
+	return respond, delivered
+}
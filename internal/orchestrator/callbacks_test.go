@@ -0,0 +1,142 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rocketship-ai/rocketship/internal/callbacks"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+)
+
+// callbackClient answers hook queries for the workflows in waiting and records signals
+type callbackClient struct {
+	client.Client
+	waiting map[string]callbacks.Hook // Workflow ID -> the hook it waits on
+	hookID  string
+
+	mu       sync.Mutex
+	signaled map[string][]callbacks.Call
+}
+
+type hookValue struct {
+	hook callbacks.Hook
+}
+
+func (v hookValue) HasValue() bool { return true }
+
+func (v hookValue) Get(valuePtr interface{}) error {
+	*valuePtr.(*callbacks.Hook) = v.hook
+	return nil
+}
+
+func (c *callbackClient) QueryWorkflow(_ context.Context, workflowID, _, queryType string, args ...interface{}) (converter.EncodedValue, error) {
+	if queryType != callbacks.HookQuery {
+		return nil, errors.New("unknown query")
+	}
+	hook, ok := c.waiting[workflowID]
+	if !ok {
+		return nil, errors.New("unknown queryType webhook_wait_hook")
+	}
+	if args[0] != c.hookID {
+		return hookValue{}, nil
+	}
+	return hookValue{hook: hook}, nil
+}
+
+func (c *callbackClient) SignalWorkflow(_ context.Context, workflowID, _, signalName string, arg interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if signalName == callbacks.CallSignal {
+		c.signaled[workflowID] = append(c.signaled[workflowID], arg.(callbacks.Call))
+	}
+	return nil
+}
+
+func (c *callbackClient) signaledWorkflows() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var workflows []string
+	for workflowID := range c.signaled {
+		workflows = append(workflows, workflowID)
+	}
+	sort.Strings(workflows)
+	return workflows
+}
+
+func TestCallbackHandler(t *testing.T) {
+	newServer := func(temporal *callbackClient) *httptest.Server {
+		engine := newTestEngineWithClient(temporal)
+		engine.runs["run-1"] = &RunInfo{
+			ID:                 "run-1",
+			Status:             "RUNNING",
+			SuiteInitCompleted: true,
+			Tests: map[string]*TestInfo{
+				"wf-done":    {Name: "done", Status: "PASSED"},
+				"wf-orders":  {Name: "orders", Status: "PENDING"},
+				"wf-refunds": {Name: "refunds", Status: "PENDING"},
+			},
+		}
+		server := httptest.NewServer(engine.CallbackHandler())
+		t.Cleanup(server.Close)
+		return server
+	}
+	post := func(url, body string) (*http.Response, string) {
+		resp, err := http.Post(url, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		raw, _ := io.ReadAll(resp.Body)
+		return resp, string(raw)
+	}
+
+	t.Run("signals the waiting workflow and replies with its respond", func(t *testing.T) {
+		temporal := &callbackClient{
+			waiting: map[string]callbacks.Hook{
+				"wf-orders": {Waiting: true, Respond: callbacks.Respond{Status: http.StatusAccepted, Body: "ok", Headers: map[string]string{"X-Handled": "yes"}}},
+			},
+			hookID:   "order-1",
+			signaled: map[string][]callbacks.Call{},
+		}
+		server := newServer(temporal)
+
+		resp, body := post(server.URL+"/hooks/run-1/order-1/events?attempt=1", `{"status":"shipped"}`)
+		require.Equal(t, http.StatusAccepted, resp.StatusCode)
+		require.Equal(t, "ok", body)
+		require.Equal(t, "yes", resp.Header.Get("X-Handled"))
+
+		require.Equal(t, []string{"wf-orders"}, temporal.signaledWorkflows())
+		call := temporal.signaled["wf-orders"][0]
+		require.Equal(t, "order-1", call.ID)
+		require.Equal(t, "/events", call.Request.Path)
+		require.Equal(t, "1", call.Request.Query["attempt"])
+		require.Equal(t, map[string]interface{}{"status": "shipped"}, call.Request.Body)
+	})
+
+	t.Run("hands an early call to every running workflow", func(t *testing.T) {
+		temporal := &callbackClient{signaled: map[string][]callbacks.Call{}}
+		server := newServer(temporal)
+
+		resp, _ := post(server.URL+"/hooks/run-1/order-2", "shipped")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, []string{"wf-orders", "wf-refunds"}, temporal.signaledWorkflows())
+	})
+
+	t.Run("rejects calls for runs that aren't running", func(t *testing.T) {
+		temporal := &callbackClient{signaled: map[string][]callbacks.Call{}}
+		server := newServer(temporal)
+
+		for _, path := range []string{"/hooks/run-2/order-1", "/hooks/run-1", "/other/run-1/order-1"} {
+			resp, _ := post(server.URL+path, "shipped")
+			require.Equal(t, http.StatusNotFound, resp.StatusCode, path)
+		}
+		require.Empty(t, temporal.signaledWorkflows())
+	})
+}
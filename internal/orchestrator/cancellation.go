@@ -0,0 +1,122 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/rocketship-ai/rocketship/internal/api/generated"
+	"github.com/rocketship-ai/rocketship/internal/controlplane/persistence"
+	"go.temporal.io/sdk/temporal"
+)
+
+// Why a run stopped before all of its tests finished
+const (
+	CancelReasonUser     = "user"     // Cancelled through CancelRun
+	CancelReasonTimeout  = "timeout"  // Ran past budget.max_duration
+	CancelReasonQuota    = "quota"    // Rejected by budget.max_tests
+	CancelReasonShutdown = "shutdown" // The engine stopped while the run was in flight
+)
+
+// Statuses of tests a cancellation stopped. An interrupted test had started a
+// step; a skipped one never got that far, so it says nothing about the system
+// under test.
+const (
+	testStatusInterrupted = "INTERRUPTED"
+	testStatusSkipped     = "SKIPPED"
+)
+
+// cancelledTestStatus is the status of a test whose workflow was cancelled
+func cancelledTestStatus(testInfo *TestInfo) string {
+	if testInfo != nil && testInfo.StepStarted {
+		return testStatusInterrupted
+	}
+	return testStatusSkipped
+}
+
+// isCancellation reports whether a test workflow ended because it was cancelled
+func isCancellation(workflowErr error) bool {
+	return workflowErr != nil && temporal.IsCanceledError(workflowErr)
+}
+
+// runCancellation describes why the run stopped early, or nil when it wasn't
+// cancelled. The caller holds e.mu.
+func runCancellation(runInfo *RunInfo) *generated.RunCancellation {
+	if runInfo.CancelReason == "" {
+		return nil
+	}
+	cancellation := &generated.RunCancellation{
+		Reason:  runInfo.CancelReason,
+		Message: runInfo.CancelMessage,
+	}
+	for _, testInfo := range runInfo.Tests {
+		switch testInfo.Status {
+		case testStatusInterrupted:
+			cancellation.InterruptedTests++
+		case testStatusSkipped:
+			cancellation.SkippedTests++
+		case "PASSED", "FAILED", "TIMEOUT", testStatusXFail:
+			cancellation.CompletedTests++
+		}
+	}
+	return cancellation
+}
+
+// runRecordCancellation is runCancellation for a run read back from the database
+func runRecordCancellation(rec persistence.RunRecord) *generated.RunCancellation {
+	if !rec.CancelReason.Valid {
+		return nil
+	}
+	return &generated.RunCancellation{
+		Reason:           rec.CancelReason.String,
+		Message:          rec.CancelMessage.String,
+		CompletedTests:   int32(rec.PassedTests + rec.FailedTests + rec.TimeoutTests + rec.XFailTests),
+		InterruptedTests: int32(rec.InterruptedTests),
+		SkippedTests:     int32(rec.SkippedTests),
+	}
+}
+
+// Shutdown cancels the runs still in flight so they end with reason "shutdown"
+// rather than being force-failed by the reconciler once the engine is gone.
+func (e *Engine) Shutdown(ctx context.Context) {
+	e.mu.RLock()
+	running := make(map[string]*RunInfo)
+	for runID, runInfo := range e.runs {
+		if runInfo.Status == "RUNNING" {
+			running[runID] = runInfo
+		}
+	}
+	e.mu.RUnlock()
+
+	if len(running) == 0 {
+		return
+	}
+	slog.Info("Shutdown: cancelling in-flight runs", "count", len(running))
+
+	var wg sync.WaitGroup
+	for runID, runInfo := range running {
+		wg.Add(1)
+		go func(runID string, runInfo *RunInfo) {
+			defer wg.Done()
+			e.cancelRun(ctx, runID, runInfo.OrganizationID, CancelReasonShutdown, "engine shut down while the run was in flight")
+		}(runID, runInfo)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		slog.Warn("Shutdown: gave up waiting for runs to cancel", "error", ctx.Err())
+	}
+}
+
+// cancellationSummary describes the tests a cancellation stopped, for the run log
+func cancellationSummary(counts TestStatusCounts) string {
+	return fmt.Sprintf("%d/%d tests completed, %d interrupted, %d skipped",
+		counts.Passed+counts.Failed+counts.TimedOut+counts.XFailed, counts.Total, counts.Interrupted, counts.Skipped)
+}
@@ -0,0 +1,144 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rocketship-ai/rocketship/internal/controlplane/persistence"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+)
+
+type cancelledWorkflowRun struct {
+	client.WorkflowRun
+}
+
+func (cancelledWorkflowRun) Get(context.Context, interface{}) error {
+	return temporal.NewCanceledError()
+}
+
+// cancellingClient cancels every workflow it is asked to and reports them as cancelled
+type cancellingClient struct {
+	cancelRecordingClient
+}
+
+func (c *cancellingClient) GetWorkflow(context.Context, string, string) client.WorkflowRun {
+	return cancelledWorkflowRun{}
+}
+
+func TestCancelledTestStatus(t *testing.T) {
+	require.Equal(t, testStatusSkipped, cancelledTestStatus(nil))
+	require.Equal(t, testStatusSkipped, cancelledTestStatus(&TestInfo{}))
+	require.Equal(t, testStatusInterrupted, cancelledTestStatus(&TestInfo{StepStarted: true}))
+}
+
+func TestRecordStepProgressMarksStepStarted(t *testing.T) {
+	engine := newTestEngineWithClient(&MockTemporalClient{})
+	testInfo := &TestInfo{Name: "login", Status: "PENDING"}
+	engine.runs["run-1"] = &RunInfo{ID: "run-1", Status: "RUNNING", Tests: map[string]*TestInfo{"wf-1": testInfo}}
+
+	engine.recordStepProgress("run-1", "wf-1", "RUNNING", 0)
+	require.True(t, testInfo.StepStarted)
+	require.Zero(t, testInfo.CompletedSteps)
+}
+
+func TestCancelRunRecordsReasonAndPartialResults(t *testing.T) {
+	engine := newTestEngineWithClient(&cancellingClient{})
+	runInfo := &RunInfo{
+		ID:      "run-1",
+		Name:    "Checkout",
+		Status:  "RUNNING",
+		Context: &RunContext{},
+		Tests: map[string]*TestInfo{
+			"wf-done":    {WorkflowID: "wf-done", Name: "done", Status: "PASSED"},
+			"wf-started": {WorkflowID: "wf-started", Name: "started", Status: "PENDING", StepStarted: true},
+			"wf-queued":  {WorkflowID: "wf-queued", Name: "queued", Status: "PENDING"},
+		},
+	}
+	engine.runs["run-1"] = runInfo
+
+	resp := engine.cancelRun(context.Background(), "run-1", runInfo.OrganizationID, CancelReasonUser, "cancelled by user")
+	require.True(t, resp.Success)
+
+	require.Equal(t, "CANCELLED", runInfo.Status)
+	require.Equal(t, "PASSED", runInfo.Tests["wf-done"].Status)
+	require.Equal(t, testStatusInterrupted, runInfo.Tests["wf-started"].Status)
+	require.Equal(t, testStatusSkipped, runInfo.Tests["wf-queued"].Status)
+
+	details := mapRunInfoToRunDetails(runInfo).Run
+	require.NotNil(t, details.Cancellation)
+	require.Equal(t, CancelReasonUser, details.Cancellation.Reason)
+	require.EqualValues(t, 1, details.Cancellation.CompletedTests)
+	require.EqualValues(t, 1, details.Cancellation.InterruptedTests)
+	require.EqualValues(t, 1, details.Cancellation.SkippedTests)
+
+	// A cancelled workflow reporting in late keeps the run cancelled
+	engine.updateTestStatus("run-1", "wf-started", temporal.NewCanceledError())
+	require.Equal(t, "CANCELLED", runInfo.Status)
+	require.Equal(t, testStatusInterrupted, runInfo.Tests["wf-started"].Status)
+}
+
+func TestDurationBudgetInterruptsTests(t *testing.T) {
+	engine := newTestEngineWithClient(&cancelRecordingClient{})
+	runInfo := &RunInfo{
+		ID:                 "run-1",
+		Name:               "Slow suite",
+		Status:             "RUNNING",
+		Context:            &RunContext{},
+		SuiteInitCompleted: true,
+		Tests: map[string]*TestInfo{
+			"wf-done":  {Name: "done", Status: "PASSED"},
+			"wf-slow":  {Name: "slow", Status: "PENDING", StepStarted: true},
+			"wf-never": {Name: "never", Status: "PENDING"},
+		},
+	}
+	engine.runs["run-1"] = runInfo
+
+	engine.enforceDurationBudget("run-1", "15m")
+	engine.updateTestStatus("run-1", "wf-slow", temporal.NewCanceledError())
+	engine.updateTestStatus("run-1", "wf-never", temporal.NewCanceledError())
+
+	require.Equal(t, runStatusBudgetExceeded, runInfo.Status)
+	details := mapRunInfoToRunDetails(runInfo).Run
+	require.NotNil(t, details.Cancellation)
+	require.Equal(t, CancelReasonTimeout, details.Cancellation.Reason)
+	require.EqualValues(t, 1, details.Cancellation.CompletedTests)
+	require.EqualValues(t, 1, details.Cancellation.InterruptedTests)
+	require.EqualValues(t, 1, details.Cancellation.SkippedTests)
+}
+
+func TestShutdownCancelsRunningRuns(t *testing.T) {
+	engine := newTestEngineWithClient(&cancellingClient{})
+	running := &RunInfo{ID: "run-1", Status: "RUNNING", Tests: map[string]*TestInfo{
+		"wf-1": {WorkflowID: "wf-1", Status: "PENDING", StepStarted: true},
+	}}
+	finished := &RunInfo{ID: "run-2", Status: "PASSED", Tests: map[string]*TestInfo{}}
+	engine.runs["run-1"] = running
+	engine.runs["run-2"] = finished
+
+	engine.Shutdown(context.Background())
+
+	require.Equal(t, "CANCELLED", running.Status)
+	require.Equal(t, CancelReasonShutdown, running.CancelReason)
+	require.Equal(t, testStatusInterrupted, running.Tests["wf-1"].Status)
+	require.Equal(t, "PASSED", finished.Status)
+	require.Empty(t, finished.CancelReason)
+}
+
+func TestRunRecordCancellation(t *testing.T) {
+	require.Nil(t, runRecordCancellation(persistence.RunRecord{}))
+
+	rec := persistence.RunRecord{
+		PassedTests:      3,
+		FailedTests:      1,
+		SkippedTests:     4,
+		InterruptedTests: 2,
+	}
+	rec.CancelReason.String, rec.CancelReason.Valid = CancelReasonQuota, true
+	cancellation := runRecordCancellation(rec)
+	require.Equal(t, CancelReasonQuota, cancellation.Reason)
+	require.EqualValues(t, 4, cancellation.CompletedTests)
+	require.EqualValues(t, 2, cancellation.InterruptedTests)
+	require.EqualValues(t, 4, cancellation.SkippedTests)
+}
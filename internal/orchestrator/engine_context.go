@@ -6,7 +6,9 @@ import (
 	"os/exec"
 	"strings"
 
+	"github.com/google/uuid"
 	"github.com/rocketship-ai/rocketship/internal/api/generated"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
 )
 
 func extractRunContext(reqContext *generated.RunContext) *RunContext {
@@ -119,7 +121,11 @@ func determineInitiator(principal *Principal) string {
 	}
 
 	// CI token principals: ci_token:<uuid>
+	// Exchanged CI ID tokens have no token ID: ci:<provider>:<ID token subject>
 	if principal.IsCIToken {
+		if principal.CITokenID == uuid.Nil {
+			return principal.Subject
+		}
 		return "ci_token:" + principal.CITokenID.String()
 	}
 
@@ -176,3 +182,24 @@ func detectEnvironment(ctx *RunContext) string {
 	}
 	return ""
 }
+
+// detectTagFilter returns the tags that select the tests to run, which the CLI
+// sends from --tags
+func detectTagFilter(reqContext *generated.RunContext) (dsl.TagFilter, error) {
+	filter, err := dsl.ParseTagFilter(reqContext.GetMetadata()["rs_tags"])
+	if err != nil {
+		return dsl.TagFilter{}, fmt.Errorf("invalid tags: %w", err)
+	}
+	return filter, nil
+}
+
+// isLocalRun reports whether the run comes from a developer's machine rather
+// than CI or a schedule. Only local runs honor only: a test left marked only
+// must not shrink CI runs.
+func isLocalRun(reqContext *generated.RunContext) bool {
+	source := reqContext.GetSource()
+	if source == "" {
+		source = detectSource()
+	}
+	return source == "cli-local"
+}
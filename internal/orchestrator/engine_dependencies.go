@@ -0,0 +1,165 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"go.temporal.io/sdk/client"
+)
+
+// waitForDependencies registers a test that has depends_on without starting it.
+// It stays PENDING, so the run isn't finished, until startReadyTests starts or
+// skips it.
+func (e *Engine) waitForDependencies(runID string, testInfo *TestInfo, test dsl.Test) {
+	waiting := test
+	testInfo.Waiting = &waiting
+
+	e.mu.Lock()
+	if runInfo, ok := e.runs[runID]; ok {
+		runInfo.Tests[testInfo.WorkflowID] = testInfo
+	}
+	e.mu.Unlock()
+
+	e.addLog(runID, fmt.Sprintf("Test: \"%s\" waits for: %s", test.Name, strings.Join(test.DependsOn, ", ")), "n/a", false)
+}
+
+// startReadyTests starts the waiting tests whose dependencies have all passed,
+// and skips those with a dependency that didn't pass, until no waiting test can
+// move on. Once the run has been stopped, every waiting test is skipped. It
+// reports whether it ended any test, so the caller knows to check whether the
+// run has finished.
+func (e *Engine) startReadyTests(runID string) bool {
+	ended := false
+	for {
+		testInfo, test, skipReason := e.nextReadyTest(runID)
+		if testInfo == nil {
+			return ended
+		}
+		if skipReason != "" {
+			e.skipTest(runID, testInfo, skipReason)
+			ended = true
+			continue
+		}
+		if err := e.startWaitingTest(runID, testInfo, test); err != nil {
+			e.failWaitingTest(runID, testInfo, err)
+			ended = true
+		}
+	}
+}
+
+// nextReadyTest takes a waiting test whose dependencies have all finished off
+// the waiting list. The reason is empty when the test should start, and says
+// why not when it should be skipped.
+func (e *Engine) nextReadyTest(runID string) (*TestInfo, dsl.Test, string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	runInfo, ok := e.runs[runID]
+	if !ok {
+		return nil, dsl.Test{}, ""
+	}
+	statuses := make(map[string][]string, len(runInfo.Tests))
+	for _, testInfo := range runInfo.Tests {
+		statuses[testInfo.Name] = append(statuses[testInfo.Name], testInfo.Status)
+	}
+
+	for _, testInfo := range runInfo.Tests {
+		test := testInfo.Waiting
+		if test == nil {
+			continue
+		}
+		if runInfo.BudgetExceeded != "" || runInfo.CancelReason != "" {
+			testInfo.Waiting = nil
+			return testInfo, *test, "the run was stopped"
+		}
+
+		ready := true
+		var failed []string
+		for _, dependency := range test.DependsOn {
+			for _, status := range statuses[dependency] {
+				switch status {
+				case "PENDING":
+					ready = false
+				case "PASSED":
+				default:
+					failed = append(failed, fmt.Sprintf("%q %s", dependency, strings.ToLower(status)))
+				}
+			}
+		}
+		if len(failed) > 0 {
+			testInfo.Waiting = nil
+			return testInfo, *test, "depends on " + strings.Join(failed, ", ")
+		}
+		if ready {
+			testInfo.Waiting = nil
+			testInfo.StartedAt = time.Now().UTC()
+			return testInfo, *test, ""
+		}
+	}
+	return nil, dsl.Test{}, ""
+}
+
+// startWaitingTest starts the workflow of a test whose dependencies have passed
+func (e *Engine) startWaitingTest(runID string, testInfo *TestInfo, test dsl.Test) error {
+	e.mu.Lock()
+	runInfo, ok := e.runs[runID]
+	if !ok {
+		e.mu.Unlock()
+		return fmt.Errorf("run not found: %s", runID)
+	}
+	vars := runInfo.Vars
+	openAPI := runInfo.SuiteOpenAPI
+	suiteGlobals := cloneStringMap(runInfo.SuiteGlobals)
+	envSecrets := cloneStringMap(runInfo.EnvSecrets)
+	workflowOptions := client.StartWorkflowOptions{
+		ID:                    testInfo.WorkflowID,
+		TaskQueue:             "test-workflows",
+		Memo:                  workflowMemo(runInfo),
+		TypedSearchAttributes: e.workflowAttributes(runID, runInfo),
+	}
+	e.mu.Unlock()
+
+	execution, err := e.temporal.ExecuteWorkflow(context.Background(), workflowOptions, "TestWorkflow", test, vars, runID, openAPI, suiteGlobals, envSecrets)
+	if err != nil {
+		return err
+	}
+
+	e.addLog(runID, fmt.Sprintf("Running test: \"%s\"...", test.Name), "n/a", false)
+	go e.monitorWorkflow(runID, execution.GetID(), execution.GetRunID())
+	return nil
+}
+
+// skipTest ends a waiting test without running it
+func (e *Engine) skipTest(runID string, testInfo *TestInfo, reason string) {
+	e.endWaitingTest(runID, testInfo, testStatusSkipped, "skipped: "+reason)
+	e.addLog(runID, fmt.Sprintf("Test: \"%s\" skipped: %s", testInfo.Name, reason), "yellow", true)
+}
+
+// failWaitingTest ends a test whose workflow couldn't be started
+func (e *Engine) failWaitingTest(runID string, testInfo *TestInfo, err error) {
+	log.Printf("[ERROR] Failed to start workflow for run %s: %v", runID, err)
+	e.endWaitingTest(runID, testInfo, "FAILED", fmt.Sprintf("failed to start test: %v", err))
+	e.addLog(runID, fmt.Sprintf("Failed to start test \"%s\": %v", testInfo.Name, err), "red", true)
+}
+
+func (e *Engine) endWaitingTest(runID string, testInfo *TestInfo, status, message string) {
+	endedAt := time.Now().UTC()
+
+	e.mu.Lock()
+	testInfo.Status = status
+	testInfo.Error = message
+	testInfo.EndedAt = endedAt
+	e.mu.Unlock()
+
+	if e.runStore != nil {
+		if err := e.runStore.UpdateRunTestByWorkflowID(context.Background(), testInfo.WorkflowID, status, &message, endedAt, 0); err != nil {
+			slog.Debug("endWaitingTest: failed to persist run_test status", "workflow_id", testInfo.WorkflowID, "status", status, "error", err)
+		}
+	}
+	e.publishTestResult(runID, testInfo.WorkflowID, status, message, endedAt)
+}
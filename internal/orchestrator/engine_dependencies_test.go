@@ -0,0 +1,124 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/client"
+)
+
+// pendingWorkflowRun is a started workflow that hasn't finished
+type pendingWorkflowRun struct {
+	client.WorkflowRun
+	id   string
+	done chan struct{}
+}
+
+func (r pendingWorkflowRun) GetID() string    { return r.id }
+func (r pendingWorkflowRun) GetRunID() string { return r.id }
+
+func (r pendingWorkflowRun) Get(ctx context.Context, _ interface{}) error {
+	select {
+	case <-r.done:
+	case <-ctx.Done():
+	}
+	return ctx.Err()
+}
+
+// startRecordingClient records the workflows it starts; they never finish by themselves
+type startRecordingClient struct {
+	client.Client
+	mu      sync.Mutex
+	started []string
+	done    chan struct{}
+}
+
+func (c *startRecordingClient) ExecuteWorkflow(_ context.Context, options client.StartWorkflowOptions, _ interface{}, _ ...interface{}) (client.WorkflowRun, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.started = append(c.started, options.ID)
+	return pendingWorkflowRun{id: options.ID, done: c.done}, nil
+}
+
+func (c *startRecordingClient) GetWorkflow(_ context.Context, workflowID, _ string) client.WorkflowRun {
+	return pendingWorkflowRun{id: workflowID, done: c.done}
+}
+
+func waitingTest(workflowID, name string, dependsOn ...string) *TestInfo {
+	return &TestInfo{
+		WorkflowID: workflowID,
+		Name:       name,
+		Status:     "PENDING",
+		Waiting:    &dsl.Test{Name: name, DependsOn: dependsOn},
+	}
+}
+
+func TestStartReadyTests(t *testing.T) {
+	temporalClient := &startRecordingClient{done: make(chan struct{})}
+	t.Cleanup(func() { close(temporalClient.done) })
+	engine := newTestEngineWithClient(temporalClient)
+	runInfo := &RunInfo{
+		ID:                 "run-1",
+		Name:               "Orders",
+		Status:             "RUNNING",
+		Context:            &RunContext{},
+		SuiteInitCompleted: true,
+		Tests: map[string]*TestInfo{
+			"wf-create": {WorkflowID: "wf-create", Name: "Create order", Status: "PENDING"},
+			"wf-refund": {WorkflowID: "wf-refund", Name: "Refund order", Status: "PENDING"},
+			"wf-pay":    waitingTest("wf-pay", "Pay order", "Create order"),
+			"wf-ship":   waitingTest("wf-ship", "Ship order", "Pay order", "Create order"),
+			"wf-audit":  waitingTest("wf-audit", "Audit refunds", "Refund order"),
+		},
+	}
+	engine.runs["run-1"] = runInfo
+
+	// Nothing has finished, so nothing starts
+	require.False(t, engine.startReadyTests("run-1"))
+	require.Empty(t, temporalClient.started)
+
+	engine.updateTestStatus("run-1", "wf-create", nil)
+	require.Equal(t, []string{"wf-pay"}, temporalClient.started)
+	require.Nil(t, runInfo.Tests["wf-pay"].Waiting)
+	require.NotNil(t, runInfo.Tests["wf-ship"].Waiting)
+
+	engine.updateTestStatus("run-1", "wf-refund", errors.New("refund failed"))
+	require.Equal(t, testStatusSkipped, runInfo.Tests["wf-audit"].Status)
+	require.Contains(t, runInfo.Tests["wf-audit"].Error, `depends on "Refund order" failed`)
+	require.Equal(t, "RUNNING", runInfo.Status)
+
+	engine.updateTestStatus("run-1", "wf-pay", nil)
+	require.Equal(t, []string{"wf-pay", "wf-ship"}, temporalClient.started)
+
+	engine.updateTestStatus("run-1", "wf-ship", nil)
+	require.Equal(t, "FAILED", runInfo.Status)
+	require.Equal(t, "PASSED", runInfo.Tests["wf-ship"].Status)
+}
+
+func TestStartReadyTestsSkipsStoppedRuns(t *testing.T) {
+	temporalClient := &cancellingClient{}
+	engine := newTestEngineWithClient(temporalClient)
+	runInfo := &RunInfo{
+		ID:      "run-1",
+		Name:    "Orders",
+		Status:  "RUNNING",
+		Context: &RunContext{},
+		Tests: map[string]*TestInfo{
+			"wf-create": {WorkflowID: "wf-create", Name: "Create order", Status: "PENDING", StepStarted: true},
+			"wf-pay":    waitingTest("wf-pay", "Pay order", "Create order"),
+		},
+	}
+	engine.runs["run-1"] = runInfo
+
+	resp := engine.cancelRun(context.Background(), "run-1", runInfo.OrganizationID, CancelReasonUser, "cancelled by user")
+	require.True(t, resp.Success)
+	// The waiting test has no workflow to cancel
+	require.Equal(t, []string{"wf-create"}, temporalClient.cancelled)
+	require.Equal(t, testStatusInterrupted, runInfo.Tests["wf-create"].Status)
+	require.Equal(t, testStatusSkipped, runInfo.Tests["wf-pay"].Status)
+	require.Nil(t, runInfo.Tests["wf-pay"].Waiting)
+}
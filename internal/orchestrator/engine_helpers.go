@@ -2,13 +2,16 @@ package orchestrator
 
 import (
 	"crypto/rand"
+	"fmt"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/oklog/ulid/v2"
 	"github.com/rocketship-ai/rocketship/internal/api/generated"
+	"github.com/rocketship-ai/rocketship/internal/interpreter"
 )
 
 var (
@@ -72,12 +75,13 @@ func mapRunInfoToRunDetails(runInfo *RunInfo) *generated.GetRunResponse {
 		}
 
 		tests = append(tests, &generated.TestDetails{
-			TestId:     testInfo.WorkflowID,
-			Name:       testInfo.Name,
-			Status:     testInfo.Status,
-			StartedAt:  testInfo.StartedAt.Format(time.RFC3339),
-			EndedAt:    testInfo.EndedAt.Format(time.RFC3339),
-			DurationMs: duration,
+			TestId:       testInfo.WorkflowID,
+			Name:         testInfo.Name,
+			Status:       testInfo.Status,
+			StartedAt:    testInfo.StartedAt.Format(time.RFC3339),
+			EndedAt:      testInfo.EndedAt.Format(time.RFC3339),
+			DurationMs:   duration,
+			ErrorMessage: testInfo.Error,
 		})
 	}
 
@@ -86,6 +90,11 @@ func mapRunInfoToRunDetails(runInfo *RunInfo) *generated.GetRunResponse {
 		duration = runInfo.EndedAt.Sub(runInfo.StartedAt).Milliseconds()
 	}
 
+	var progress *generated.RunProgress
+	if runInfo.Status == "RUNNING" {
+		progress = computeProgress(runInfo)
+	}
+
 	return &generated.GetRunResponse{
 		Run: &generated.RunDetails{
 			RunId:      runInfo.ID,
@@ -103,7 +112,9 @@ func mapRunInfoToRunDetails(runInfo *RunInfo) *generated.GetRunResponse {
 				ScheduleName: runInfo.Context.ScheduleName,
 				Metadata:     runInfo.Context.Metadata,
 			},
-			Tests: tests,
+			Tests:        tests,
+			Progress:     progress,
+			Cancellation: runCancellation(runInfo),
 		},
 	}
 }
@@ -119,6 +130,48 @@ func cloneStringMap(source map[string]string) map[string]string {
 	return result
 }
 
+// workflowMemo tags workflows with the project and environment they run for so
+// workers can apply per-tenant policies such as egress allow-lists.
+func workflowMemo(runInfo *RunInfo) map[string]interface{} {
+	if runInfo == nil {
+		return nil
+	}
+	projectID := runProjectID(runInfo)
+	if projectID == "" && runInfo.Environment == "" {
+		return nil
+	}
+	return map[string]interface{}{
+		interpreter.MemoProjectID:   projectID,
+		interpreter.MemoEnvironment: runInfo.Environment,
+	}
+}
+
+// runningWorkflows returns the run's suite init and test workflows that are still
+// running. The caller holds e.mu.
+func runningWorkflows(runID string, runInfo *RunInfo) []string {
+	var workflows []string
+	if !runInfo.SuiteInitCompleted && !runInfo.SuiteInitFailed {
+		workflows = append(workflows, fmt.Sprintf("%s_suite_init", runID))
+	}
+	for workflowID, testInfo := range runInfo.Tests {
+		if testInfo.Status == "PENDING" && testInfo.Waiting == nil {
+			workflows = append(workflows, workflowID)
+		}
+	}
+	return workflows
+}
+
+// runProjectID returns the run's resolved project, falling back to the one the client sent
+func runProjectID(runInfo *RunInfo) string {
+	if runInfo.ProjectID != uuid.Nil {
+		return runInfo.ProjectID.String()
+	}
+	if runInfo.Context != nil {
+		return runInfo.Context.ProjectID
+	}
+	return ""
+}
+
 func cloneInterfaceMap(source map[string]interface{}) map[string]interface{} {
 	if len(source) == 0 {
 		return make(map[string]interface{})
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -53,6 +54,15 @@ func (e *Engine) updateTestStatus(runID, workflowID string, workflowErr error) {
 	if workflowErr != nil {
 		if workflowErr.Error() == "workflow monitoring timeout" {
 			status = "TIMEOUT"
+		} else if isCancellation(workflowErr) {
+			// Cancelled tests didn't fail; record how far they got instead
+			e.mu.RLock()
+			var testInfo *TestInfo
+			if runInfo, ok := e.runs[runID]; ok {
+				testInfo = runInfo.Tests[workflowID]
+			}
+			status = cancelledTestStatus(testInfo)
+			e.mu.RUnlock()
 		} else {
 			status = "FAILED"
 			cleanErr = interpreter.ExtractCleanError(workflowErr)
@@ -62,6 +72,13 @@ func (e *Engine) updateTestStatus(runID, workflowID string, workflowErr error) {
 		status = "PASSED"
 	}
 
+	if e.isXFail(runID, workflowID) {
+		status, cleanErr = xfailOutcome(status, cleanErr)
+		if cleanErr != "" {
+			errMsg = &cleanErr
+		}
+	}
+
 	// CRITICAL: Always persist DB update first, regardless of in-memory state.
 	// This ensures scheduled runs (and any runs where engine may have restarted)
 	// get their terminal status persisted correctly.
@@ -98,22 +115,30 @@ func (e *Engine) updateTestStatus(runID, workflowID string, workflowErr error) {
 	// Update in-memory state
 	testInfo.EndedAt = endedAt
 	testInfo.Status = status
+	testInfo.Error = cleanErr
 	durationMs := endedAt.Sub(testInfo.StartedAt).Milliseconds()
 	orgID := runInfo.OrganizationID
 	testID := testInfo.TestID
 	testName := testInfo.Name
 	e.mu.Unlock()
 
+	e.publishTestResult(runID, workflowID, status, cleanErr, endedAt)
+
 	// Log based on status
-	if workflowErr != nil {
-		if status == "TIMEOUT" {
-			log.Printf("[WARN] Test timed out: %s", testName)
-			e.addLog(runID, fmt.Sprintf("Test: \"%s\" timed out", testName), "red", true)
-		} else {
-			log.Printf("[ERROR] Test failed: %s - %s", testName, cleanErr)
-			e.addLog(runID, fmt.Sprintf("Test: \"%s\" failed: %s", testName, cleanErr), "red", true)
-		}
-	} else {
+	switch status {
+	case testStatusInterrupted, testStatusSkipped:
+		log.Printf("[INFO] Test cancelled: %s", testName)
+		e.addLog(runID, fmt.Sprintf("Test: \"%s\" %s by cancellation", testName, strings.ToLower(status)), "yellow", true)
+	case "TIMEOUT":
+		log.Printf("[WARN] Test timed out: %s", testName)
+		e.addLog(runID, fmt.Sprintf("Test: \"%s\" timed out", testName), "red", true)
+	case testStatusXFail:
+		log.Printf("[INFO] Test failed as expected: %s - %s", testName, cleanErr)
+		e.addLog(runID, fmt.Sprintf("Test: \"%s\" failed as expected (xfail): %s", testName, cleanErr), "yellow", true)
+	case "FAILED":
+		log.Printf("[ERROR] Test failed: %s - %s", testName, cleanErr)
+		e.addLog(runID, fmt.Sprintf("Test: \"%s\" failed: %s", testName, cleanErr), "red", true)
+	default:
 		log.Printf("[INFO] Test passed: %s", testName)
 		e.addLog(runID, fmt.Sprintf("Test: \"%s\" passed", testName), "green", true)
 	}
@@ -133,6 +158,7 @@ func (e *Engine) updateTestStatus(runID, workflowID string, workflowErr error) {
 		}
 	}
 
+	e.startReadyTests(runID)
 	e.checkIfRunFinished(runID)
 }
 
@@ -141,10 +167,10 @@ func (e *Engine) addLog(runID, message, color string, bold bool) {
 }
 
 func (e *Engine) addLogWithContext(runID, message, color string, bold bool, testName, stepName string) {
-	e.addLogWithWorkflowContext(runID, "", message, color, bold, testName, stepName)
+	e.addLogWithWorkflowContext(runID, "", message, color, bold, testName, stepName, "", nil)
 }
 
-func (e *Engine) addLogWithWorkflowContext(runID, workflowID, message, color string, bold bool, testName, stepName string) {
+func (e *Engine) addLogWithWorkflowContext(runID, workflowID, message, color string, bold bool, testName, stepName, level string, fields map[string]string) {
 	var orgID uuid.UUID
 
 	e.mu.Lock()
@@ -161,6 +187,8 @@ func (e *Engine) addLogWithWorkflowContext(runID, workflowID, message, color str
 		Bold:     bold,
 		TestName: testName,
 		StepName: stepName,
+		Level:    level,
+		Fields:   fields,
 	})
 	orgID = runInfo.OrganizationID
 	e.mu.Unlock()
@@ -182,6 +210,13 @@ func (e *Engine) addLogWithWorkflowContext(runID, workflowID, message, color str
 		},
 		LoggedAt: time.Now().UTC(),
 	}
+	if level != "" {
+		logEntry.Level = strings.ToUpper(level)
+		logEntry.Metadata["level"] = level
+	}
+	if len(fields) > 0 {
+		logEntry.Metadata["fields"] = fields
+	}
 
 	// Resolve run_test_id from workflow_id if provided
 	if workflowID != "" {
@@ -206,13 +241,25 @@ func (e *Engine) checkIfRunFinished(runID string) {
 		return
 	}
 
-	hasFailure := counts.Failed > 0 || counts.TimedOut > 0
+	// Skipped and xfail tests don't fail the run by themselves. Tests are skipped
+	// when a dependency failed or the run was stopped, which fail it already.
+	hasFailure := counts.Failed > 0 || counts.TimedOut > 0 || counts.Interrupted > 0
+	var budgetReason, cancelReason string
 	e.mu.RLock()
-	if runInfo, exists := e.runs[runID]; exists && runInfo.SuiteInitFailed {
-		hasFailure = true
+	if runInfo, exists := e.runs[runID]; exists {
+		budgetReason = runInfo.BudgetExceeded
+		cancelReason = runInfo.CancelReason
+		if runInfo.SuiteInitFailed || budgetReason != "" {
+			hasFailure = true
+		}
 	}
 	e.mu.RUnlock()
 
+	// cancelRun finalizes runs cancelled by a user or a shutdown
+	if cancelReason == CancelReasonUser || cancelReason == CancelReasonShutdown {
+		return
+	}
+
 	e.triggerSuiteCleanup(runID, hasFailure)
 
 	e.mu.Lock()
@@ -224,8 +271,9 @@ func (e *Engine) checkIfRunFinished(runID string) {
 	}
 
 	runName := runInfo.Name
+	stopBudgetTimer(runInfo)
 
-	if counts.Failed == 0 && counts.TimedOut == 0 {
+	if counts.Failed == 0 && counts.TimedOut == 0 && counts.Interrupted == 0 && budgetReason == "" {
 		runInfo.Status = "PASSED"
 		runInfo.EndedAt = time.Now().UTC()
 		orgID := runInfo.OrganizationID
@@ -234,7 +282,11 @@ func (e *Engine) checkIfRunFinished(runID string) {
 		scheduleType := runInfo.ScheduleType
 		endTime := runInfo.EndedAt
 		e.mu.Unlock()
-		e.addLog(runID, fmt.Sprintf("Test run: \"%s\" finished. All %d tests passed.", runName, counts.Total), "n/a", true)
+		if counts.Passed == counts.Total {
+			e.addLog(runID, fmt.Sprintf("Test run: \"%s\" finished. All %d tests passed.", runName, counts.Total), "n/a", true)
+		} else {
+			e.addLog(runID, fmt.Sprintf("Test run: \"%s\" finished. %s.", runName, testCountsSummary(counts)), "n/a", true)
+		}
 		if orgID != uuid.Nil && e.runStore != nil {
 			if _, err := e.runStore.UpdateRun(context.Background(), persistence.RunUpdate{
 				RunID:          runID,
@@ -268,8 +320,14 @@ func (e *Engine) checkIfRunFinished(runID string) {
 		return
 	}
 
-	runInfo.Status = "FAILED"
+	finalStatus := "FAILED"
+	if budgetReason != "" {
+		finalStatus = runStatusBudgetExceeded
+	}
+	runInfo.Status = finalStatus
 	runInfo.EndedAt = time.Now().UTC()
+	cancelReason = runInfo.CancelReason
+	cancelMessage := runInfo.CancelMessage
 	orgID := runInfo.OrganizationID
 	suiteID := runInfo.SuiteID
 	scheduleID := runInfo.ScheduleID
@@ -277,25 +335,32 @@ func (e *Engine) checkIfRunFinished(runID string) {
 	endTime := runInfo.EndedAt
 	e.mu.Unlock()
 
-	if counts.TimedOut == 0 {
-		e.addLog(runID, fmt.Sprintf("Test run: \"%s\" finished. %d/%d tests passed, %d/%d tests failed.", runName, counts.Passed, counts.Total, counts.Failed, counts.Total), "n/a", true)
-	} else {
-		e.addLog(runID, fmt.Sprintf("Test run: \"%s\" finished. %d/%d tests passed, %d/%d tests failed, %d/%d tests timed out.", runName, counts.Passed, counts.Total, counts.Failed, counts.Total, counts.TimedOut, counts.Total), "n/a", true)
+	if budgetReason != "" {
+		e.addLog(runID, fmt.Sprintf("Budget exceeded: %s", budgetReason), "red", true)
+	}
+	e.addLog(runID, fmt.Sprintf("Test run: \"%s\" finished. %s.", runName, testCountsSummary(counts)), "n/a", true)
+	if counts.Interrupted > 0 {
+		e.addLog(runID, fmt.Sprintf("%d tests interrupted before they finished.", counts.Interrupted), "n/a", true)
 	}
 
 	if orgID != uuid.Nil && e.runStore != nil {
-		if _, err := e.runStore.UpdateRun(context.Background(), persistence.RunUpdate{
+		update := persistence.RunUpdate{
 			RunID:          runID,
 			OrganizationID: orgID,
-			Status:         stringPtr("FAILED"),
+			Status:         stringPtr(finalStatus),
 			EndedAt:        timePtr(endTime),
 			Totals:         makeRunTotals(counts),
-		}); err != nil {
-			slog.Error("checkIfRunFinished: failed to persist FAILED state", "run_id", runID, "error", err)
+		}
+		if cancelReason != "" {
+			update.CancelReason = stringPtr(cancelReason)
+			update.CancelMessage = cancelMessage
+		}
+		if _, err := e.runStore.UpdateRun(context.Background(), update); err != nil {
+			slog.Error("checkIfRunFinished: failed to persist final state", "run_id", runID, "error", err)
 		}
 		// Update suite last_run
 		if suiteID != uuid.Nil {
-			if err := e.runStore.UpdateSuiteLastRun(context.Background(), suiteID, runID, "FAILED", endTime); err != nil {
+			if err := e.runStore.UpdateSuiteLastRun(context.Background(), suiteID, runID, finalStatus, endTime); err != nil {
 				slog.Debug("checkIfRunFinished: failed to update suite last_run", "suite_id", suiteID, "error", err)
 			}
 		}
@@ -303,11 +368,11 @@ func (e *Engine) checkIfRunFinished(runID string) {
 		if scheduleID != uuid.Nil {
 			switch scheduleType {
 			case "project":
-				if err := e.runStore.UpdateProjectScheduleLastRun(context.Background(), scheduleID, runID, "FAILED", endTime); err != nil {
+				if err := e.runStore.UpdateProjectScheduleLastRun(context.Background(), scheduleID, runID, finalStatus, endTime); err != nil {
 					slog.Debug("checkIfRunFinished: failed to update project schedule last_run", "schedule_id", scheduleID, "error", err)
 				}
 			case "suite":
-				if err := e.runStore.UpdateSuiteScheduleLastRun(context.Background(), scheduleID, runID, "FAILED", endTime); err != nil {
+				if err := e.runStore.UpdateSuiteScheduleLastRun(context.Background(), scheduleID, runID, finalStatus, endTime); err != nil {
 					slog.Debug("checkIfRunFinished: failed to update suite schedule last_run", "schedule_id", scheduleID, "error", err)
 				}
 			}
@@ -334,6 +399,12 @@ func (e *Engine) getTestStatusCounts(runID string) (TestStatusCounts, error) {
 			counts.Failed++
 		case "TIMEOUT":
 			counts.TimedOut++
+		case testStatusInterrupted:
+			counts.Interrupted++
+		case testStatusSkipped:
+			counts.Skipped++
+		case testStatusXFail:
+			counts.XFailed++
 		case "PENDING":
 			counts.Pending++
 		}
@@ -342,6 +413,23 @@ func (e *Engine) getTestStatusCounts(runID string) (TestStatusCounts, error) {
 	return counts, nil
 }
 
+// testCountsSummary says how many of the run's tests ended each way, as the
+// final run log reports it. Timed out, skipped and xfail tests are only
+// mentioned when there are some.
+func testCountsSummary(counts TestStatusCounts) string {
+	summary := fmt.Sprintf("%d/%d tests passed, %d/%d tests failed", counts.Passed, counts.Total, counts.Failed, counts.Total)
+	if counts.TimedOut > 0 {
+		summary += fmt.Sprintf(", %d/%d tests timed out", counts.TimedOut, counts.Total)
+	}
+	if counts.Skipped > 0 {
+		summary += fmt.Sprintf(", %d/%d tests skipped", counts.Skipped, counts.Total)
+	}
+	if counts.XFailed > 0 {
+		summary += fmt.Sprintf(", %d/%d tests xfailed", counts.XFailed, counts.Total)
+	}
+	return summary
+}
+
 // checkIfRunFinishedFromDB checks run completion status directly from the database.
 // This is used when in-memory state is not available (e.g., after engine restart).
 func (e *Engine) checkIfRunFinishedFromDB(runID string) {
@@ -373,6 +461,12 @@ func (e *Engine) checkIfRunFinishedFromDB(runID string) {
 			counts.Failed++
 		case "TIMEOUT":
 			counts.TimedOut++
+		case testStatusInterrupted:
+			counts.Interrupted++
+		case testStatusSkipped:
+			counts.Skipped++
+		case testStatusXFail:
+			counts.XFailed++
 		case "PENDING", "RUNNING":
 			counts.Pending++
 		}
@@ -387,7 +481,7 @@ func (e *Engine) checkIfRunFinishedFromDB(runID string) {
 	// All tests complete - determine final status
 	endTime := time.Now().UTC()
 	var finalStatus string
-	if counts.Failed == 0 && counts.TimedOut == 0 {
+	if counts.Failed == 0 && counts.TimedOut == 0 && counts.Interrupted == 0 {
 		finalStatus = "PASSED"
 	} else {
 		finalStatus = "FAILED"
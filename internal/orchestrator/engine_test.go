@@ -1035,3 +1035,58 @@ func TestRunAPIsAreOrgScoped(t *testing.T) {
 		t.Fatalf("GetRun for org A returned error: %v", err)
 	}
 }
+
+func TestListRunsFiltersByTags(t *testing.T) {
+	store := NewMemoryRunStore()
+	engine := NewEngine(&MockTemporalClient{}, store, true)
+	engine.authConfig.mode = authModeOIDC
+
+	orgID := uuid.New()
+	for id, tags := range map[string][]string{
+		"run-smoke":   {"checkout", "smoke"},
+		"run-slow":    {"slow", "smoke"},
+		"run-nightly": {"nightly"},
+	} {
+		if _, err := store.InsertRun(context.Background(), persistence.RunRecord{
+			ID:             id,
+			OrganizationID: orgID,
+			Status:         "PASSED",
+			SuiteName:      "Checkout",
+			Tags:           tags,
+		}); err != nil {
+			t.Fatalf("failed to insert run: %v", err)
+		}
+	}
+	ctx := contextWithPrincipal(context.Background(), &Principal{
+		Subject: "user-a",
+		OrgID:   orgID.String(),
+		Roles:   []string{"owner"},
+	})
+
+	resp, err := engine.ListRuns(ctx, &generated.ListRunsRequest{Tags: "smoke,!slow"})
+	if err != nil {
+		t.Fatalf("ListRuns returned error: %v", err)
+	}
+	if len(resp.Runs) != 1 || resp.Runs[0].RunId != "run-smoke" {
+		t.Fatalf("expected only run-smoke, got %v", resp.Runs)
+	}
+	if got := strings.Join(resp.Runs[0].Tags, ","); got != "checkout,smoke" {
+		t.Errorf("expected tags checkout,smoke, got %s", got)
+	}
+
+	if _, err := engine.ListRuns(ctx, &generated.ListRunsRequest{Tags: "smoke slow"}); err == nil {
+		t.Error("expected an error for an invalid tag filter")
+	}
+
+	// Runs without an organization are listed from memory
+	local := newTestEngineWithClient(&MockTemporalClient{})
+	local.runs["run-1"] = &RunInfo{ID: "run-1", Status: "PASSED", Context: &RunContext{}, Tags: []string{"nightly"}}
+	local.runs["run-2"] = &RunInfo{ID: "run-2", Status: "PASSED", Context: &RunContext{}, Tags: []string{"smoke"}}
+	resp, err = local.ListRuns(context.Background(), &generated.ListRunsRequest{Tags: "nightly"})
+	if err != nil {
+		t.Fatalf("ListRuns returned error: %v", err)
+	}
+	if len(resp.Runs) != 1 || resp.Runs[0].RunId != "run-1" {
+		t.Fatalf("expected only run-1, got %v", resp.Runs)
+	}
+}
@@ -0,0 +1,139 @@
+package orchestrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/rocketship-ai/rocketship/internal/controlplane/persistence"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+)
+
+// maxEnvironmentChain bounds how many fallbacks a run follows
+const maxEnvironmentChain = 10
+
+// orgDefaultChainEntry stands for the organization's default environment, which
+// has no slug, at the end of a run's chain
+const orgDefaultChainEntry = "org:default"
+
+// resolvedEnvironment is the environment a run asked for, with the secrets and
+// config vars it inherits from its fallbacks filled in
+type resolvedEnvironment struct {
+	ID         uuid.UUID
+	Slug       string
+	EnvSecrets map[string]string
+	ConfigVars map[string]interface{}
+	Chain      pq.StringArray // Slugs from the requested environment to its last fallback, then orgDefaultChainEntry
+}
+
+// environmentChainError is a broken fallback chain. It fails the run: quietly
+// running without the inherited secrets would only fail later and less clearly.
+type environmentChainError struct {
+	msg string
+}
+
+func (e *environmentChainError) Error() string {
+	return e.msg
+}
+
+// resolveEnvironment looks up slug and follows its fallbacks, then the
+// organization's default environment. Closer environments win: a secret set on
+// the requested environment overrides the same secret on its fallback, and config
+// vars are deep-merged the same way. Without a slug, the organization's default
+// environment is used on its own. It returns sql.ErrNoRows when the requested
+// environment doesn't exist, or when no slug is given and the organization has no
+// default environment.
+func (e *Engine) resolveEnvironment(ctx context.Context, projectID uuid.UUID, slug string) (resolvedEnvironment, error) {
+	orgDefault, hasOrgDefault, err := e.orgDefaultEnvironment(ctx, projectID)
+	if err != nil {
+		return resolvedEnvironment{}, err
+	}
+	if slug == "" {
+		if !hasOrgDefault {
+			return resolvedEnvironment{}, sql.ErrNoRows
+		}
+		return resolvedEnvironment{
+			EnvSecrets: copySecrets(orgDefault.EnvSecrets),
+			ConfigVars: dsl.MergeInterfaceMaps(nil, orgDefault.ConfigVars),
+			Chain:      pq.StringArray{orgDefaultChainEntry},
+		}, nil
+	}
+
+	env, err := e.runStore.GetEnvironmentBySlug(ctx, projectID, slug)
+	if err != nil {
+		return resolvedEnvironment{}, err
+	}
+
+	chain := []persistence.ProjectEnvironment{env}
+	seen := map[string]bool{env.Slug: true}
+	for next := env.Fallback; next != ""; next = chain[len(chain)-1].Fallback {
+		slugs := environmentSlugs(chain)
+		if seen[next] {
+			return resolvedEnvironment{}, &environmentChainError{msg: fmt.Sprintf("environment fallbacks form a cycle: %s → %s", strings.Join(slugs, " → "), next)}
+		}
+		if len(chain) >= maxEnvironmentChain {
+			return resolvedEnvironment{}, &environmentChainError{msg: fmt.Sprintf("environment %q has more than %d fallbacks", env.Slug, maxEnvironmentChain-1)}
+		}
+		parent, err := e.runStore.GetEnvironmentBySlug(ctx, projectID, next)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return resolvedEnvironment{}, &environmentChainError{msg: fmt.Sprintf("environment %q falls back to %q, which does not exist in this project", slugs[len(slugs)-1], next)}
+			}
+			return resolvedEnvironment{}, fmt.Errorf("failed to look up fallback environment %q: %w", next, err)
+		}
+		seen[parent.Slug] = true
+		chain = append(chain, parent)
+	}
+
+	resolved := resolvedEnvironment{
+		ID:         env.ID,
+		Slug:       env.Slug,
+		EnvSecrets: make(map[string]string),
+		Chain:      environmentSlugs(chain),
+	}
+	if hasOrgDefault {
+		resolved.EnvSecrets = copySecrets(orgDefault.EnvSecrets)
+		resolved.ConfigVars = dsl.MergeInterfaceMaps(nil, orgDefault.ConfigVars)
+		resolved.Chain = append(resolved.Chain, orgDefaultChainEntry)
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		for k, v := range chain[i].EnvSecrets {
+			resolved.EnvSecrets[k] = v
+		}
+		resolved.ConfigVars = dsl.MergeInterfaceMaps(resolved.ConfigVars, chain[i].ConfigVars)
+	}
+	return resolved, nil
+}
+
+// orgDefaultEnvironment returns the default environment of the project's
+// organization, and whether it has one
+func (e *Engine) orgDefaultEnvironment(ctx context.Context, projectID uuid.UUID) (persistence.OrgDefaultEnvironment, bool, error) {
+	env, err := e.runStore.GetProjectOrgDefaultEnvironment(ctx, projectID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return persistence.OrgDefaultEnvironment{}, false, nil
+	}
+	if err != nil {
+		return persistence.OrgDefaultEnvironment{}, false, fmt.Errorf("failed to look up the organization's default environment: %w", err)
+	}
+	return env, true, nil
+}
+
+func copySecrets(secrets map[string]string) map[string]string {
+	copied := make(map[string]string, len(secrets))
+	for k, v := range secrets {
+		copied[k] = v
+	}
+	return copied
+}
+
+func environmentSlugs(envs []persistence.ProjectEnvironment) []string {
+	slugs := make([]string, 0, len(envs))
+	for _, env := range envs {
+		slugs = append(slugs, env.Slug)
+	}
+	return slugs
+}
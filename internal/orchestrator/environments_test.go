@@ -0,0 +1,171 @@
+package orchestrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rocketship-ai/rocketship/internal/controlplane/persistence"
+	"github.com/stretchr/testify/require"
+)
+
+type environmentStore struct {
+	RunStore
+	envs       map[string]persistence.ProjectEnvironment
+	orgDefault *persistence.OrgDefaultEnvironment
+}
+
+func (s *environmentStore) GetProjectOrgDefaultEnvironment(_ context.Context, _ uuid.UUID) (persistence.OrgDefaultEnvironment, error) {
+	if s.orgDefault == nil {
+		return persistence.OrgDefaultEnvironment{}, sql.ErrNoRows
+	}
+	return *s.orgDefault, nil
+}
+
+func (s *environmentStore) GetEnvironmentBySlug(_ context.Context, _ uuid.UUID, slug string) (persistence.ProjectEnvironment, error) {
+	env, ok := s.envs[slug]
+	if !ok {
+		return persistence.ProjectEnvironment{}, sql.ErrNoRows
+	}
+	return env, nil
+}
+
+func newEnvironmentEngine(envs ...persistence.ProjectEnvironment) *Engine {
+	engine := newTestEngineWithClient(&MockTemporalClient{})
+	store := &environmentStore{RunStore: engine.runStore, envs: map[string]persistence.ProjectEnvironment{}}
+	for _, env := range envs {
+		if env.ID == uuid.Nil {
+			env.ID = uuid.New()
+		}
+		store.envs[env.Slug] = env
+	}
+	engine.runStore = store
+	return engine
+}
+
+func TestResolveEnvironmentFallbacks(t *testing.T) {
+	preview := persistence.ProjectEnvironment{
+		Slug:       "pr-123",
+		Fallback:   "staging",
+		EnvSecrets: map[string]string{"API_URL": "https://pr-123.preview.example.com"},
+		ConfigVars: map[string]interface{}{"db": map[string]interface{}{"name": "pr_123"}},
+	}
+	engine := newEnvironmentEngine(
+		preview,
+		persistence.ProjectEnvironment{
+			Slug:       "staging",
+			Fallback:   "default",
+			EnvSecrets: map[string]string{"API_URL": "https://staging.example.com", "API_KEY": "staging-key"},
+			ConfigVars: map[string]interface{}{"db": map[string]interface{}{"name": "staging", "host": "db.staging"}},
+		},
+		persistence.ProjectEnvironment{
+			Slug:       "default",
+			EnvSecrets: map[string]string{"API_KEY": "default-key", "SMTP_PASSWORD": "smtp"},
+			ConfigVars: map[string]interface{}{"region": "us-east-1"},
+		},
+	)
+
+	env, err := engine.resolveEnvironment(context.Background(), uuid.New(), "pr-123")
+	require.NoError(t, err)
+	require.Equal(t, "pr-123", env.Slug)
+	require.Equal(t, []string{"pr-123", "staging", "default"}, []string(env.Chain))
+	require.Equal(t, map[string]string{
+		"API_URL":       "https://pr-123.preview.example.com",
+		"API_KEY":       "staging-key",
+		"SMTP_PASSWORD": "smtp",
+	}, env.EnvSecrets)
+	require.Equal(t, map[string]interface{}{
+		"db":     map[string]interface{}{"name": "pr_123", "host": "db.staging"},
+		"region": "us-east-1",
+	}, env.ConfigVars)
+
+	// Resolving doesn't touch the stored environments
+	require.Equal(t, "https://pr-123.preview.example.com", preview.EnvSecrets["API_URL"])
+	require.Len(t, preview.EnvSecrets, 1)
+}
+
+func TestResolveEnvironmentWithoutFallback(t *testing.T) {
+	engine := newEnvironmentEngine(persistence.ProjectEnvironment{Slug: "staging", EnvSecrets: map[string]string{"K": "v"}})
+
+	env, err := engine.resolveEnvironment(context.Background(), uuid.New(), "staging")
+	require.NoError(t, err)
+	require.Equal(t, []string{"staging"}, []string(env.Chain))
+	require.Equal(t, map[string]string{"K": "v"}, env.EnvSecrets)
+
+	_, err = engine.resolveEnvironment(context.Background(), uuid.New(), "missing")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestResolveEnvironmentBrokenChains(t *testing.T) {
+	var chainErr *environmentChainError
+
+	engine := newEnvironmentEngine(persistence.ProjectEnvironment{Slug: "pr-1", Fallback: "staging"})
+	_, err := engine.resolveEnvironment(context.Background(), uuid.New(), "pr-1")
+	require.True(t, errors.As(err, &chainErr))
+	require.False(t, errors.Is(err, sql.ErrNoRows))
+	require.EqualError(t, err, `environment "pr-1" falls back to "staging", which does not exist in this project`)
+
+	engine = newEnvironmentEngine(
+		persistence.ProjectEnvironment{Slug: "a", Fallback: "b"},
+		persistence.ProjectEnvironment{Slug: "b", Fallback: "a"},
+	)
+	_, err = engine.resolveEnvironment(context.Background(), uuid.New(), "a")
+	require.True(t, errors.As(err, &chainErr))
+	require.EqualError(t, err, "environment fallbacks form a cycle: a → b → a")
+}
+
+func TestResolveEnvironmentOrgDefault(t *testing.T) {
+	engine := newEnvironmentEngine(
+		persistence.ProjectEnvironment{
+			Slug:       "staging",
+			Fallback:   "default",
+			EnvSecrets: map[string]string{"API_URL": "https://staging.example.com"},
+		},
+		persistence.ProjectEnvironment{
+			Slug:       "default",
+			EnvSecrets: map[string]string{"API_KEY": "project-key"},
+			ConfigVars: map[string]interface{}{"db": map[string]interface{}{"host": "db.project"}},
+		},
+	)
+	engine.runStore.(*environmentStore).orgDefault = &persistence.OrgDefaultEnvironment{
+		EnvSecrets: map[string]string{"API_KEY": "org-key", "SENTRY_DSN": "org-dsn"},
+		ConfigVars: map[string]interface{}{"db": map[string]interface{}{"host": "db.org", "port": 5432}},
+	}
+
+	// The org default is the last layer, below the project's default
+	env, err := engine.resolveEnvironment(context.Background(), uuid.New(), "staging")
+	require.NoError(t, err)
+	require.Equal(t, []string{"staging", "default", orgDefaultChainEntry}, []string(env.Chain))
+	require.Equal(t, map[string]string{
+		"API_URL":    "https://staging.example.com",
+		"API_KEY":    "project-key",
+		"SENTRY_DSN": "org-dsn",
+	}, env.EnvSecrets)
+	require.Equal(t, map[string]interface{}{
+		"db": map[string]interface{}{"host": "db.project", "port": 5432},
+	}, env.ConfigVars)
+
+	// Without an environment, the org default is used on its own
+	env, err = engine.resolveEnvironment(context.Background(), uuid.New(), "")
+	require.NoError(t, err)
+	require.Equal(t, uuid.Nil, env.ID)
+	require.Equal(t, []string{orgDefaultChainEntry}, []string(env.Chain))
+	require.Equal(t, map[string]string{"API_KEY": "org-key", "SENTRY_DSN": "org-dsn"}, env.EnvSecrets)
+
+	// Resolving doesn't touch the stored org default
+	env.EnvSecrets["API_KEY"] = "changed"
+	require.Equal(t, "org-key", engine.runStore.(*environmentStore).orgDefault.EnvSecrets["API_KEY"])
+
+	// A missing environment is still an error, even with an org default
+	_, err = engine.resolveEnvironment(context.Background(), uuid.New(), "missing")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestResolveEnvironmentNoOrgDefault(t *testing.T) {
+	engine := newEnvironmentEngine()
+
+	_, err := engine.resolveEnvironment(context.Background(), uuid.New(), "")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
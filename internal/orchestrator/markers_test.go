@@ -0,0 +1,57 @@
+package orchestrator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSkippedAndXFailTestsDontFailTheRun(t *testing.T) {
+	engine := newTestEngineWithClient(&startRecordingClient{done: make(chan struct{})})
+	runInfo := &RunInfo{
+		ID:                 "run-1",
+		Name:               "Orders",
+		Status:             "RUNNING",
+		Context:            &RunContext{},
+		SuiteInitCompleted: true,
+		Tests: map[string]*TestInfo{
+			"wf-create": {WorkflowID: "wf-create", Name: "Create order", Status: "PENDING"},
+			"wf-bug":    {WorkflowID: "wf-bug", Name: "Known bug", Status: "PENDING", XFail: true},
+		},
+	}
+	engine.runs["run-1"] = runInfo
+
+	engine.registerSkippedTest("run-1", &TestInfo{WorkflowID: "wf-refund", Name: "Refund order", Status: "PENDING"}, "refunds are down")
+	require.Equal(t, testStatusSkipped, runInfo.Tests["wf-refund"].Status)
+	require.Equal(t, "skipped: refunds are down", runInfo.Tests["wf-refund"].Error)
+
+	engine.updateTestStatus("run-1", "wf-bug", errors.New("status 500"))
+	require.Equal(t, testStatusXFail, runInfo.Tests["wf-bug"].Status)
+	require.Equal(t, "RUNNING", runInfo.Status)
+
+	engine.updateTestStatus("run-1", "wf-create", nil)
+	require.Equal(t, "PASSED", runInfo.Status)
+	finished := runInfo.Logs[len(runInfo.Logs)-1].Msg
+	require.Equal(t, `Test run: "Orders" finished. 1/3 tests passed, 0/3 tests failed, 1/3 tests skipped, 1/3 tests xfailed.`, finished)
+}
+
+func TestXFailTestThatPassesFailsTheRun(t *testing.T) {
+	engine := newTestEngineWithClient(&startRecordingClient{done: make(chan struct{})})
+	runInfo := &RunInfo{
+		ID:                 "run-1",
+		Name:               "Orders",
+		Status:             "RUNNING",
+		Context:            &RunContext{},
+		SuiteInitCompleted: true,
+		Tests: map[string]*TestInfo{
+			"wf-bug": {WorkflowID: "wf-bug", Name: "Fixed bug", Status: "PENDING", XFail: true},
+		},
+	}
+	engine.runs["run-1"] = runInfo
+
+	engine.updateTestStatus("run-1", "wf-bug", nil)
+	require.Equal(t, "FAILED", runInfo.Tests["wf-bug"].Status)
+	require.Equal(t, "passed, but is marked xfail", runInfo.Tests["wf-bug"].Error)
+	require.Equal(t, "FAILED", runInfo.Status)
+}
@@ -0,0 +1,187 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rocketship-ai/rocketship/internal/api/generated"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+)
+
+// progressLogInterval is how often a running run logs its progress
+var progressLogInterval = time.Minute
+
+// planProgress records how many steps the run will execute: suite init steps plus
+// each test's init steps and steps. Cleanup steps aren't counted since they run
+// after the work the run is measured by.
+func planProgress(runInfo *RunInfo, run dsl.RocketshipConfig) {
+	runInfo.SuiteInitSteps = len(run.Init)
+	runInfo.PlannedTestSteps = make(map[string]int, len(run.Tests))
+	for _, test := range run.Tests {
+		runInfo.PlannedTestSteps[strings.ToLower(test.Name)] = len(test.Init) + len(test.Steps)
+	}
+}
+
+// loadStepHistory fetches the median step duration of each discovered test from
+// recent runs, which the ETA is based on. Runs of suites that haven't been
+// discovered rely on the durations observed during the run instead.
+func (e *Engine) loadStepHistory(ctx context.Context, runInfo *RunInfo) {
+	if e.runStore == nil || len(runInfo.TestIDs) == 0 {
+		return
+	}
+	testIDs := make([]uuid.UUID, 0, len(runInfo.TestIDs))
+	for _, id := range runInfo.TestIDs {
+		testIDs = append(testIDs, id)
+	}
+	history, err := e.runStore.MedianStepDurations(ctx, testIDs)
+	if err != nil {
+		slog.Debug("loadStepHistory: failed to load step durations", "run_id", runInfo.ID, "error", err)
+		return
+	}
+	runInfo.StepHistoryMs = history
+}
+
+// recordStepProgress counts a finished step towards its run's progress and notes
+// that the test has started executing, which a cancellation reports as
+// interrupted rather than skipped
+func (e *Engine) recordStepProgress(runID, workflowID, status string, durationMs int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	runInfo, ok := e.runs[runID]
+	if !ok {
+		return
+	}
+	if testInfo, ok := runInfo.Tests[workflowID]; ok {
+		testInfo.StepStarted = true
+	}
+	if status != "PASSED" && status != "FAILED" && status != "SKIPPED" {
+		return
+	}
+	if workflowID == fmt.Sprintf("%s_suite_init", runID) {
+		runInfo.SuiteInitStepsDone++
+	} else if testInfo, ok := runInfo.Tests[workflowID]; ok {
+		testInfo.CompletedSteps++
+	} else {
+		return
+	}
+	// A skipped step's duration says nothing about how long steps take
+	if durationMs > 0 && status != "SKIPPED" {
+		runInfo.ObservedStepMs = append(runInfo.ObservedStepMs, durationMs)
+	}
+}
+
+// computeProgress returns how far the run has got. A finished test counts all of
+// its planned steps, including any its failure skipped. The ETA assumes tests run
+// in parallel after suite init: the time suite init still needs plus the longest
+// remaining test. The caller holds e.mu.
+func computeProgress(runInfo *RunInfo) *generated.RunProgress {
+	started := make(map[string]*TestInfo, len(runInfo.Tests))
+	for _, testInfo := range runInfo.Tests {
+		started[strings.ToLower(testInfo.Name)] = testInfo
+	}
+
+	fallbackMs := medianMs(runInfo.ObservedStepMs)
+	if fallbackMs == 0 && len(runInfo.StepHistoryMs) > 0 {
+		history := make([]int64, 0, len(runInfo.StepHistoryMs))
+		for _, ms := range runInfo.StepHistoryMs {
+			history = append(history, ms)
+		}
+		fallbackMs = medianMs(history)
+	}
+
+	planned := runInfo.SuiteInitSteps
+	completed := min(runInfo.SuiteInitStepsDone, runInfo.SuiteInitSteps)
+	if runInfo.SuiteInitCompleted || runInfo.SuiteInitFailed {
+		completed = runInfo.SuiteInitSteps
+	}
+	initEta := int64(runInfo.SuiteInitSteps-completed) * fallbackMs
+
+	var testsEta int64
+	for name, steps := range runInfo.PlannedTestSteps {
+		planned += steps
+
+		done := 0
+		if testInfo, ok := started[name]; ok {
+			done = min(testInfo.CompletedSteps, steps)
+			if testInfo.Status != "PENDING" && testInfo.Status != "RUNNING" {
+				done = steps
+			}
+		}
+		completed += done
+
+		stepMs := fallbackMs
+		if testID, ok := runInfo.TestIDs[name]; ok && runInfo.StepHistoryMs[testID] > 0 {
+			stepMs = runInfo.StepHistoryMs[testID]
+		}
+		testsEta = max(testsEta, int64(steps-done)*stepMs)
+	}
+
+	progress := &generated.RunProgress{
+		CompletedSteps: int32(completed),
+		PlannedSteps:   int32(planned),
+		Percent:        100,
+	}
+	if planned > 0 {
+		progress.Percent = int32(completed * 100 / planned)
+	}
+	if completed < planned {
+		progress.EtaMs = initEta + testsEta
+	}
+	return progress
+}
+
+// medianMs returns the median of durations, or 0 when there are none
+func medianMs(durations []int64) int64 {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]int64(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[len(sorted)/2]
+}
+
+// reportProgress logs the run's progress every progressLogInterval until it ends,
+// so long runs show how far along they are in the streamed logs
+func (e *Engine) reportProgress(runID string) {
+	ticker := time.NewTicker(progressLogInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		e.mu.RLock()
+		runInfo, ok := e.runs[runID]
+		if !ok || runInfo.Status != "RUNNING" {
+			e.mu.RUnlock()
+			return
+		}
+		progress := computeProgress(runInfo)
+		e.mu.RUnlock()
+
+		if progress.PlannedSteps == 0 {
+			continue
+		}
+		msg := fmt.Sprintf("Progress: %d/%d steps (%d%%)", progress.CompletedSteps, progress.PlannedSteps, progress.Percent)
+		if progress.EtaMs > 0 {
+			msg += fmt.Sprintf(", about %s left", formatEta(progress.EtaMs))
+		}
+		e.addLog(runID, msg, "n/a", false)
+	}
+}
+
+// formatEta rounds an ETA to a readable precision, e.g. "12m0s" rather than "11m48.312s"
+func formatEta(etaMs int64) string {
+	eta := time.Duration(etaMs) * time.Millisecond
+	switch {
+	case eta >= 10*time.Minute:
+		eta = eta.Round(time.Minute)
+	case eta >= time.Minute:
+		eta = eta.Round(10 * time.Second)
+	default:
+		eta = eta.Round(time.Second)
+	}
+	return eta.String()
+}
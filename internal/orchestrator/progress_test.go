@@ -0,0 +1,81 @@
+package orchestrator
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeProgress(t *testing.T) {
+	checkoutID := uuid.New()
+	run := dsl.RocketshipConfig{
+		Init: []dsl.Step{{Name: "seed"}},
+		Tests: []dsl.Test{
+			{Name: "Checkout", Init: []dsl.Step{{Name: "login"}}, Steps: make([]dsl.Step, 3)},
+			{Name: "Search", Steps: make([]dsl.Step, 2)},
+			{Name: "Refunds", Steps: make([]dsl.Step, 4)},
+		},
+	}
+	runInfo := &RunInfo{
+		ID:                 "run-1",
+		Status:             "RUNNING",
+		SuiteInitCompleted: true,
+		TestIDs:            map[string]uuid.UUID{"checkout": checkoutID},
+		StepHistoryMs:      map[uuid.UUID]int64{checkoutID: 2000},
+		Tests: map[string]*TestInfo{
+			"wf-1": {Name: "Checkout", Status: "PENDING"},
+			"wf-2": {Name: "Search", Status: "FAILED", CompletedSteps: 1},
+		},
+	}
+	planProgress(runInfo, run)
+
+	engine := newTestEngineWithClient(&MockTemporalClient{})
+	engine.runs["run-1"] = runInfo
+	engine.recordStepProgress("run-1", "wf-1", "RUNNING", 0)
+	engine.recordStepProgress("run-1", "wf-1", "PASSED", 500)
+	engine.recordStepProgress("run-1", "wf-1", "PASSED", 700)
+	engine.recordStepProgress("run-1", "wf-unknown", "PASSED", 100)
+
+	progress := computeProgress(runInfo)
+	// Suite init (1) + Checkout (2 of 4) + Search, failed so finished (2) + Refunds (0 of 4)
+	require.EqualValues(t, 11, progress.PlannedSteps)
+	require.EqualValues(t, 5, progress.CompletedSteps)
+	require.EqualValues(t, 45, progress.Percent)
+	// Refunds hasn't started: 4 steps at the observed median (700ms) is less than
+	// Checkout's 2 remaining steps at its 2s historical median
+	require.EqualValues(t, 4000, progress.EtaMs)
+
+	runInfo.Tests["wf-1"].Status = "PASSED"
+	runInfo.Tests["wf-3"] = &TestInfo{Name: "Refunds", Status: "PASSED"}
+	progress = computeProgress(runInfo)
+	require.EqualValues(t, 100, progress.Percent)
+	require.Zero(t, progress.EtaMs)
+}
+
+func TestComputeProgressWithoutHistory(t *testing.T) {
+	runInfo := &RunInfo{Status: "RUNNING", Tests: map[string]*TestInfo{}}
+	planProgress(runInfo, dsl.RocketshipConfig{
+		Init:  make([]dsl.Step, 2),
+		Tests: []dsl.Test{{Name: "Smoke", Steps: make([]dsl.Step, 3)}},
+	})
+
+	progress := computeProgress(runInfo)
+	require.EqualValues(t, 5, progress.PlannedSteps)
+	require.Zero(t, progress.CompletedSteps)
+	require.Zero(t, progress.EtaMs)
+
+	// Suite init runs before the tests, so its remaining time adds to theirs
+	runInfo.SuiteInitStepsDone = 1
+	runInfo.ObservedStepMs = []int64{1000}
+	progress = computeProgress(runInfo)
+	require.EqualValues(t, 20, progress.Percent)
+	require.EqualValues(t, 4000, progress.EtaMs)
+}
+
+func TestFormatEta(t *testing.T) {
+	require.Equal(t, "42s", formatEta(41_600))
+	require.Equal(t, "3m10s", formatEta(188_000))
+	require.Equal(t, "1h12m0s", formatEta(4_310_000))
+}
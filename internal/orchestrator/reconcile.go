@@ -247,7 +247,7 @@ func mapTemporalStatusToDBStatus(status enumspb.WorkflowExecutionStatus) string
 	case enumspb.WORKFLOW_EXECUTION_STATUS_TIMED_OUT:
 		return "TIMEOUT"
 	case enumspb.WORKFLOW_EXECUTION_STATUS_CANCELED, enumspb.WORKFLOW_EXECUTION_STATUS_TERMINATED:
-		return "FAILED" // Treat cancelled/terminated as failed
+		return testStatusInterrupted // Stopped before it finished, not a failure of the test
 	case enumspb.WORKFLOW_EXECUTION_STATUS_CONTINUED_AS_NEW:
 		return "" // Still running (continued)
 	case enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING:
@@ -269,7 +269,7 @@ func (r *Reconciler) recalculateRunStatus(ctx context.Context, runID string) {
 	}
 
 	// Count statuses
-	var passed, failed, timeout, pending int
+	var passed, failed, timeout, interrupted, skipped, xfailed, pending int
 	for _, rt := range runTests {
 		switch rt.Status {
 		case "PASSED":
@@ -278,6 +278,12 @@ func (r *Reconciler) recalculateRunStatus(ctx context.Context, runID string) {
 			failed++
 		case "TIMEOUT":
 			timeout++
+		case testStatusInterrupted:
+			interrupted++
+		case testStatusSkipped:
+			skipped++
+		case testStatusXFail:
+			xfailed++
 		case "PENDING", "RUNNING":
 			pending++
 		}
@@ -295,7 +301,7 @@ func (r *Reconciler) recalculateRunStatus(ctx context.Context, runID string) {
 	}
 
 	var finalStatus string
-	if failed == 0 && timeout == 0 {
+	if failed == 0 && timeout == 0 && interrupted == 0 {
 		finalStatus = "PASSED"
 	} else {
 		finalStatus = "FAILED"
@@ -303,10 +309,13 @@ func (r *Reconciler) recalculateRunStatus(ctx context.Context, runID string) {
 
 	endTime := time.Now().UTC()
 	totals := TestStatusCounts{
-		Total:    total,
-		Passed:   passed,
-		Failed:   failed,
-		TimedOut: timeout,
+		Total:       total,
+		Passed:      passed,
+		Failed:      failed,
+		TimedOut:    timeout,
+		Interrupted: interrupted,
+		Skipped:     skipped,
+		XFailed:     xfailed,
 	}
 
 	r.logger.Info("reconcile: all tests complete, marking run as terminal",
@@ -345,7 +354,7 @@ func (r *Reconciler) forceCompleteStaleRun(ctx context.Context, runID string) {
 	}
 
 	// Count statuses
-	var passed, failed, timeout, pending int
+	var passed, failed, timeout, interrupted, skipped, xfailed, pending int
 	for _, rt := range runTests {
 		switch rt.Status {
 		case "PASSED":
@@ -354,6 +363,12 @@ func (r *Reconciler) forceCompleteStaleRun(ctx context.Context, runID string) {
 			failed++
 		case "TIMEOUT":
 			timeout++
+		case testStatusInterrupted:
+			interrupted++
+		case testStatusSkipped:
+			skipped++
+		case testStatusXFail:
+			xfailed++
 		case "PENDING", "RUNNING":
 			pending++
 		}
@@ -362,9 +377,10 @@ func (r *Reconciler) forceCompleteStaleRun(ctx context.Context, runID string) {
 	total := len(runTests)
 	endTime := time.Now().UTC()
 
-	// Force-complete the stale run_tests
+	// Force-complete the stale run_tests. The engine lost track of them, so they
+	// were interrupted rather than failed.
 	if pending > 0 {
-		if err := r.engine.runStore.ForceCompleteStaleRunTests(ctx, runID, "FAILED"); err != nil {
+		if err := r.engine.runStore.ForceCompleteStaleRunTests(ctx, runID, testStatusInterrupted); err != nil {
 			r.logger.Error("reconcile: failed to force-complete stale run_tests",
 				"run_id", runID,
 				"error", err,
@@ -375,10 +391,13 @@ func (r *Reconciler) forceCompleteStaleRun(ctx context.Context, runID string) {
 
 	// Update run status
 	totals := TestStatusCounts{
-		Total:    total,
-		Passed:   passed,
-		Failed:   failed + pending, // Pending tests are now failed
-		TimedOut: timeout,
+		Total:       total,
+		Passed:      passed,
+		Failed:      failed,
+		TimedOut:    timeout,
+		Interrupted: interrupted + pending, // Pending tests are now interrupted
+		Skipped:     skipped,
+		XFailed:     xfailed,
 	}
 
 	if err := r.engine.runStore.UpdateRunStatusByID(ctx, runID, "FAILED", endTime, makeRunTotals(totals)); err != nil {
@@ -386,6 +405,13 @@ func (r *Reconciler) forceCompleteStaleRun(ctx context.Context, runID string) {
 			"run_id", runID,
 			"error", err,
 		)
+		return
+	}
+	if err := r.engine.runStore.SetRunCancelReason(ctx, runID, CancelReasonShutdown, "engine stopped tracking the run before it finished"); err != nil {
+		r.logger.Error("reconcile: failed to record cancel reason",
+			"run_id", runID,
+			"error", err,
+		)
 	}
 }
 
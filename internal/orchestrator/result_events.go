@@ -0,0 +1,303 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Test result events are emitted once per completed test for warehouse ingestion.
+// Bump TestResultSchemaVersion's major component on any breaking field change;
+// additive fields only bump the minor component.
+const (
+	TestResultEventType     = "test.completed"
+	TestResultSchemaVersion = "1.1"
+)
+
+// TestResultEvent is the versioned payload delivered to result sinks
+type TestResultEvent struct {
+	SchemaVersion string              `json:"schema_version"`
+	EventType     string              `json:"event_type"`
+	EventID       string              `json:"event_id"`
+	EmittedAt     time.Time           `json:"emitted_at"`
+	Run           TestResultRun       `json:"run"`
+	Test          TestResultTest      `json:"test"`
+	Steps         []TestResultStep    `json:"steps"`
+	Assertions    TestResultAssertion `json:"assertions"`
+}
+
+// TestResultRun describes the run the test belongs to
+type TestResultRun struct {
+	ID             string            `json:"id"`
+	SuiteName      string            `json:"suite_name"`
+	OrganizationID string            `json:"organization_id,omitempty"`
+	ProjectID      string            `json:"project_id,omitempty"`
+	SuiteID        string            `json:"suite_id,omitempty"`
+	Environment    string            `json:"environment,omitempty"`
+	Source         string            `json:"source,omitempty"`
+	Trigger        string            `json:"trigger,omitempty"`
+	Branch         string            `json:"branch,omitempty"`
+	CommitSHA      string            `json:"commit_sha,omitempty"`
+	ScheduleName   string            `json:"schedule_name,omitempty"`
+	StartedAt      time.Time         `json:"started_at"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+}
+
+// TestResultTest holds the outcome and timing of the completed test
+type TestResultTest struct {
+	Name       string    `json:"name"`
+	TestID     string    `json:"test_id,omitempty"`
+	WorkflowID string    `json:"workflow_id"`
+	Status     string    `json:"status"` // PASSED, FAILED or TIMEOUT
+	Error      string    `json:"error,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	EndedAt    time.Time `json:"ended_at"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+// TestResultStep summarises one step of the test
+type TestResultStep struct {
+	Index            int    `json:"index"`
+	Name             string `json:"name"`
+	Plugin           string `json:"plugin"`
+	Status           string `json:"status"`
+	Error            string `json:"error,omitempty"`
+	DurationMs       int64  `json:"duration_ms"`
+	AssertionsPassed int    `json:"assertions_passed"`
+	AssertionsFailed int    `json:"assertions_failed"`
+	WorkerVersion    string `json:"worker_version,omitempty"` // Added in 1.1
+}
+
+// TestResultAssertion totals assertions across all steps
+type TestResultAssertion struct {
+	Passed int `json:"passed"`
+	Failed int `json:"failed"`
+}
+
+// ResultSink receives test result events. Implementations must be safe for concurrent use.
+type ResultSink interface {
+	Name() string
+	Publish(ctx context.Context, event *TestResultEvent) error
+}
+
+// WebhookSink POSTs events as JSON to an HTTP endpoint
+type WebhookSink struct {
+	URL    string
+	Secret string // Optional HMAC-SHA256 signing secret
+	Client *http.Client
+}
+
+// Name identifies the sink in logs
+func (s *WebhookSink) Name() string {
+	return s.URL
+}
+
+// Publish delivers one event. Non-2xx responses are treated as failures.
+func (s *WebhookSink) Publish(ctx context.Context, event *TestResultEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Rocketship-Event", event.EventType)
+	req.Header.Set("X-Rocketship-Schema-Version", event.SchemaVersion)
+	req.Header.Set("X-Rocketship-Event-Id", event.EventID)
+	if s.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(s.Secret))
+		mac.Write(body)
+		req.Header.Set("X-Rocketship-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// AddResultSink registers a destination for per-test result events
+func (e *Engine) AddResultSink(sink ResultSink) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.resultSinks = append(e.resultSinks, sink)
+}
+
+// ConfigureResultWebhooksFromEnv registers a webhook sink for every URL in
+// ROCKETSHIP_RESULT_WEBHOOK_URLS (comma separated) and returns how many were added.
+func (e *Engine) ConfigureResultWebhooksFromEnv() int {
+	secret := strings.TrimSpace(os.Getenv("ROCKETSHIP_RESULT_WEBHOOK_SECRET"))
+	count := 0
+	for _, url := range strings.Split(os.Getenv("ROCKETSHIP_RESULT_WEBHOOK_URLS"), ",") {
+		url = strings.TrimSpace(url)
+		if url == "" {
+			continue
+		}
+		e.AddResultSink(&WebhookSink{URL: url, Secret: secret})
+		count++
+	}
+	return count
+}
+
+// buildTestResultEvent assembles the event for a finished test. Step details are read from
+// the run store when available; in-memory runs without persisted steps send an empty list.
+func (e *Engine) buildTestResultEvent(ctx context.Context, runID, workflowID, status, errMsg string, endedAt time.Time) (*TestResultEvent, bool) {
+	e.mu.RLock()
+	runInfo, ok := e.runs[runID]
+	if !ok {
+		e.mu.RUnlock()
+		return nil, false
+	}
+	testInfo, ok := runInfo.Tests[workflowID]
+	if !ok {
+		e.mu.RUnlock()
+		return nil, false
+	}
+
+	event := &TestResultEvent{
+		SchemaVersion: TestResultSchemaVersion,
+		EventType:     TestResultEventType,
+		EventID:       uuid.NewString(),
+		EmittedAt:     time.Now().UTC(),
+		Run: TestResultRun{
+			ID:          runID,
+			SuiteName:   runInfo.Name,
+			Environment: runInfo.Environment,
+			StartedAt:   runInfo.StartedAt.UTC(),
+		},
+		Test: TestResultTest{
+			Name:       testInfo.Name,
+			WorkflowID: workflowID,
+			Status:     status,
+			Error:      errMsg,
+			StartedAt:  testInfo.StartedAt.UTC(),
+			EndedAt:    endedAt.UTC(),
+			DurationMs: endedAt.Sub(testInfo.StartedAt).Milliseconds(),
+		},
+		Steps: []TestResultStep{},
+	}
+	if runInfo.OrganizationID != uuid.Nil {
+		event.Run.OrganizationID = runInfo.OrganizationID.String()
+	}
+	if runInfo.SuiteID != uuid.Nil {
+		event.Run.SuiteID = runInfo.SuiteID.String()
+	}
+	if testInfo.TestID != uuid.Nil {
+		event.Test.TestID = testInfo.TestID.String()
+	}
+	if runInfo.ProjectID != uuid.Nil {
+		event.Run.ProjectID = runInfo.ProjectID.String()
+	}
+	if rc := runInfo.Context; rc != nil {
+		if event.Run.ProjectID == "" {
+			event.Run.ProjectID = rc.ProjectID
+		}
+		event.Run.Source = rc.Source
+		event.Run.Trigger = rc.Trigger
+		event.Run.Branch = rc.Branch
+		event.Run.CommitSHA = rc.CommitSHA
+		event.Run.ScheduleName = rc.ScheduleName
+		if len(rc.Metadata) > 0 {
+			event.Run.Metadata = cloneStringMap(rc.Metadata)
+		}
+	}
+	e.mu.RUnlock()
+
+	if runTest, err := e.runStore.GetRunTestByWorkflowID(ctx, workflowID); err == nil {
+		if steps, err := e.runStore.ListRunSteps(ctx, runTest.ID); err == nil {
+			for _, step := range steps {
+				item := TestResultStep{
+					Index:            step.StepIndex,
+					Name:             step.Name,
+					Plugin:           step.Plugin,
+					Status:           step.Status,
+					AssertionsPassed: step.AssertionsPassed,
+					AssertionsFailed: step.AssertionsFailed,
+				}
+				if step.ErrorMessage.Valid {
+					item.Error = step.ErrorMessage.String
+				}
+				if step.DurationMs.Valid {
+					item.DurationMs = step.DurationMs.Int64
+				}
+				if step.WorkerVersion.Valid {
+					item.WorkerVersion = step.WorkerVersion.String
+				}
+				event.Steps = append(event.Steps, item)
+				event.Assertions.Passed += step.AssertionsPassed
+				event.Assertions.Failed += step.AssertionsFailed
+			}
+		}
+	}
+
+	return event, true
+}
+
+// publishTestResult sends the event to every sink in the background with a few retries
+func (e *Engine) publishTestResult(runID, workflowID, status, errMsg string, endedAt time.Time) {
+	e.mu.RLock()
+	sinks := append([]ResultSink(nil), e.resultSinks...)
+	e.mu.RUnlock()
+	if len(sinks) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	event, ok := e.buildTestResultEvent(ctx, runID, workflowID, status, errMsg, endedAt)
+	cancel()
+	if !ok {
+		slog.Debug("publishTestResult: run or test not in memory, skipping event", "run_id", runID, "workflow_id", workflowID)
+		return
+	}
+
+	for _, sink := range sinks {
+		go deliverTestResult(sink, event)
+	}
+}
+
+func deliverTestResult(sink ResultSink, event *TestResultEvent) {
+	const attempts = 3
+	backoff := time.Second
+	for attempt := 1; attempt <= attempts; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		err := sink.Publish(ctx, event)
+		cancel()
+		if err == nil {
+			slog.Debug("deliverTestResult: event delivered", "sink", sink.Name(), "event_id", event.EventID)
+			return
+		}
+		slog.Warn("deliverTestResult: delivery failed",
+			"sink", sink.Name(),
+			"event_id", event.EventID,
+			"attempt", attempt,
+			"error", err)
+		if attempt < attempts {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package orchestrator
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildTestResultEvent(t *testing.T) {
+	engine := newTestEngineWithClient(nil)
+	started := time.Now().Add(-2 * time.Second)
+	engine.runs["run-1"] = &RunInfo{
+		ID:          "run-1",
+		Name:        "checkout suite",
+		StartedAt:   started,
+		Environment: "staging",
+		Context:     &RunContext{ProjectID: "proj-1", Source: "ci-branch", Branch: "main", CommitSHA: "abc123"},
+		Tests: map[string]*TestInfo{
+			"wf-1": {WorkflowID: "wf-1", Name: "places order", StartedAt: started},
+		},
+	}
+
+	ended := started.Add(1500 * time.Millisecond)
+	event, ok := engine.buildTestResultEvent(context.Background(), "run-1", "wf-1", "FAILED", "boom", ended)
+	require.True(t, ok)
+	require.Equal(t, TestResultSchemaVersion, event.SchemaVersion)
+	require.Equal(t, TestResultEventType, event.EventType)
+	require.NotEmpty(t, event.EventID)
+	require.Equal(t, "checkout suite", event.Run.SuiteName)
+	require.Equal(t, "proj-1", event.Run.ProjectID)
+	require.Equal(t, "staging", event.Run.Environment)
+	require.Equal(t, "main", event.Run.Branch)
+	require.Equal(t, "places order", event.Test.Name)
+	require.Equal(t, "FAILED", event.Test.Status)
+	require.Equal(t, "boom", event.Test.Error)
+	require.Equal(t, int64(1500), event.Test.DurationMs)
+	require.NotNil(t, event.Steps)
+
+	_, ok = engine.buildTestResultEvent(context.Background(), "missing", "wf-1", "PASSED", "", ended)
+	require.False(t, ok)
+}
+
+func TestWebhookSinkSignsPayload(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		received <- r
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	sink := &WebhookSink{URL: srv.URL, Secret: "s3cret"}
+	event := &TestResultEvent{
+		SchemaVersion: TestResultSchemaVersion,
+		EventType:     TestResultEventType,
+		EventID:       "evt-1",
+		Test:          TestResultTest{Name: "t", Status: "PASSED"},
+	}
+	require.NoError(t, sink.Publish(context.Background(), event))
+
+	req := <-received
+	require.Equal(t, TestResultEventType, req.Header.Get("X-Rocketship-Event"))
+	require.Equal(t, TestResultSchemaVersion, req.Header.Get("X-Rocketship-Schema-Version"))
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	require.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), req.Header.Get("X-Rocketship-Signature"))
+
+	var decoded TestResultEvent
+	require.NoError(t, json.Unmarshal(body, &decoded))
+	require.Equal(t, "evt-1", decoded.EventID)
+}
+
+func TestWebhookSinkRejectsErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	sink := &WebhookSink{URL: srv.URL}
+	require.Error(t, sink.Publish(context.Background(), &TestResultEvent{}))
+}
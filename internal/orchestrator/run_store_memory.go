@@ -62,6 +62,13 @@ func (s *memoryRunStore) UpdateRun(ctx context.Context, update persistence.RunUp
 		rec.PassedTests = update.Totals.Passed
 		rec.FailedTests = update.Totals.Failed
 		rec.TimeoutTests = update.Totals.Timeout
+		rec.SkippedTests = update.Totals.Skipped
+		rec.InterruptedTests = update.Totals.Interrupted
+		rec.XFailTests = update.Totals.XFail
+	}
+	if update.CancelReason != nil {
+		rec.CancelReason = sql.NullString{String: *update.CancelReason, Valid: true}
+		rec.CancelMessage = sql.NullString{String: update.CancelMessage, Valid: update.CancelMessage != ""}
 	}
 	if update.CommitSHA != nil {
 		trimmed := strings.TrimSpace(*update.CommitSHA)
@@ -114,6 +121,37 @@ func (s *memoryRunStore) ListRuns(ctx context.Context, orgID uuid.UUID, limit in
 	return runs, nil
 }
 
+func (s *memoryRunStore) PruneRuns(ctx context.Context, filter persistence.RunPruneFilter) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs := make([]persistence.RunRecord, 0)
+	for _, rec := range s.runs {
+		if filter.OrganizationID != uuid.Nil && rec.OrganizationID != filter.OrganizationID {
+			continue
+		}
+		if filter.ProjectID.Valid && (!rec.ProjectID.Valid || rec.ProjectID.UUID != filter.ProjectID.UUID) {
+			continue
+		}
+		runs = append(runs, rec)
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].CreatedAt.After(runs[j].CreatedAt)
+	})
+
+	ids := make([]string, 0)
+	for idx, rec := range runs {
+		if !isPrunable(rec.Status, rec.CreatedAt, idx, filter) {
+			continue
+		}
+		ids = append(ids, rec.ID)
+		if !filter.DryRun {
+			delete(s.runs, rec.ID)
+		}
+	}
+	return ids, nil
+}
+
 // Run details methods - no-op for memory store (tests are tracked in-memory via Engine.runs)
 
 func (s *memoryRunStore) InsertRunTest(_ context.Context, rt persistence.RunTest) (persistence.RunTest, error) {
@@ -143,6 +181,12 @@ func (s *memoryRunStore) ListRunLogs(_ context.Context, _ string, _ int) ([]pers
 	return []persistence.RunLog{}, nil
 }
 
+func (s *memoryRunStore) InsertRunArtifact(_ context.Context, artifact persistence.RunArtifact) (persistence.RunArtifact, error) {
+	// No-op for memory store - artifacts stay on the worker and are named in step errors
+	artifact.CreatedAt = time.Now().UTC()
+	return artifact, nil
+}
+
 // Project/suite/test lookup methods - no-op for memory store (no project discovery in local mode)
 
 func (s *memoryRunStore) FindProjectByRepoAndPathScope(_ context.Context, _ uuid.UUID, _ string, _ []string) (persistence.Project, bool, error) {
@@ -209,6 +253,11 @@ func (s *memoryRunStore) ListRunSteps(_ context.Context, _ uuid.UUID) ([]persist
 	return []persistence.RunStep{}, nil
 }
 
+func (s *memoryRunStore) MedianStepDurations(_ context.Context, _ []uuid.UUID) (map[uuid.UUID]int64, error) {
+	// No step history in memory store
+	return map[uuid.UUID]int64{}, nil
+}
+
 // Environment lookup methods - no-op for memory store (no project environments in local mode)
 
 func (s *memoryRunStore) GetEnvironmentBySlug(_ context.Context, _ uuid.UUID, _ string) (persistence.ProjectEnvironment, error) {
@@ -216,6 +265,21 @@ func (s *memoryRunStore) GetEnvironmentBySlug(_ context.Context, _ uuid.UUID, _
 	return persistence.ProjectEnvironment{}, sql.ErrNoRows
 }
 
+func (s *memoryRunStore) GetProjectOrgDefaultEnvironment(_ context.Context, _ uuid.UUID) (persistence.OrgDefaultEnvironment, error) {
+	// No organizations in memory store
+	return persistence.OrgDefaultEnvironment{}, sql.ErrNoRows
+}
+
+func (s *memoryRunStore) UpsertPreviewEnvironment(_ context.Context, _ uuid.UUID, _ int, _ string) (persistence.ProjectEnvironment, bool, error) {
+	// Preview environments require database
+	return persistence.ProjectEnvironment{}, false, sql.ErrNoRows
+}
+
+func (s *memoryRunStore) DeletePreviewEnvironment(_ context.Context, _ uuid.UUID, _ int) (int, error) {
+	// Preview environments require database
+	return 0, sql.ErrNoRows
+}
+
 // CI Token methods - no-op for memory store (CI tokens require database)
 
 func (s *memoryRunStore) FindCITokenByPlaintext(_ context.Context, _ string) (*persistence.CITokenLookupResult, error) {
@@ -257,7 +321,25 @@ func (s *memoryRunStore) UpdateRunStatusByID(ctx context.Context, runID string,
 		rec.PassedTests = totals.Passed
 		rec.FailedTests = totals.Failed
 		rec.TimeoutTests = totals.Timeout
+		rec.SkippedTests = totals.Skipped
+		rec.InterruptedTests = totals.Interrupted
+		rec.XFailTests = totals.XFail
+	}
+	rec.UpdatedAt = time.Now().UTC()
+	s.runs[runID] = rec
+	return nil
+}
+
+func (s *memoryRunStore) SetRunCancelReason(ctx context.Context, runID, reason, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.runs[runID]
+	if !ok {
+		return sql.ErrNoRows
 	}
+	rec.CancelReason = sql.NullString{String: reason, Valid: true}
+	rec.CancelMessage = sql.NullString{String: message, Valid: message != ""}
 	rec.UpdatedAt = time.Now().UTC()
 	s.runs[runID] = rec
 	return nil
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"log/slog"
@@ -50,6 +51,20 @@ func (e *Engine) createRunInternal(ctx context.Context, orgID uuid.UUID, initiat
 		return nil, fmt.Errorf("test run must contain at least one test")
 	}
 
+	// Catch bad jq expressions and undefined variables before any test starts
+	if err := dsl.ValidateReferences(run); err != nil {
+		slog.Debug("createRunInternal: reference validation failed", "error", err)
+		return nil, fmt.Errorf("invalid test references: %w", err)
+	}
+
+	if err := e.checkWorkerRequirements(ctx, run.Worker); err != nil {
+		return nil, err
+	}
+	capabilityWarnings, err := e.checkWorkerCapabilities(ctx, run)
+	if err != nil {
+		return nil, err
+	}
+
 	runContext := extractRunContext(req.Context)
 	startTime := time.Now().UTC()
 	configSource := detectConfigSource(runContext)
@@ -122,6 +137,7 @@ func (e *Engine) createRunInternal(ctx context.Context, orgID uuid.UUID, initiat
 			ScheduleID:     scheduleID,
 			EnvironmentID:  environmentID,
 			Environment:    envSlug,
+			Tags:           dsl.TestTags(run.Tests),
 		}
 
 		// Parse project_id as UUID if it's a valid UUID
@@ -130,22 +146,30 @@ func (e *Engine) createRunInternal(ctx context.Context, orgID uuid.UUID, initiat
 		}
 
 		// Resolve environment after project_id is set
-		if record.ProjectID.Valid && envSlug != "" {
-			env, err := e.runStore.GetEnvironmentBySlug(ctx, record.ProjectID.UUID, envSlug)
+		// Without an environment, the organization's default environment applies if it has one
+		if record.ProjectID.Valid {
+			env, err := e.resolveEnvironment(ctx, record.ProjectID.UUID, envSlug)
+			var chainErr *environmentChainError
 			if err != nil {
-				if err == sql.ErrNoRows {
+				if err == sql.ErrNoRows && envSlug == "" {
+					// No environment and no organization default: run without one
+				} else if err == sql.ErrNoRows {
 					slog.Warn("createRunInternal: environment not found", "slug", envSlug, "project_id", record.ProjectID.UUID)
+				} else if errors.As(err, &chainErr) {
+					return nil, fmt.Errorf("cannot resolve environment %q: %w", envSlug, err)
 				} else {
 					slog.Debug("createRunInternal: failed to lookup environment", "slug", envSlug, "error", err)
 				}
 			} else {
 				envSecrets = env.EnvSecrets
 				envConfigVars = env.ConfigVars
-				record.EnvironmentID = uuid.NullUUID{UUID: env.ID, Valid: true}
+				record.EnvironmentID = uuid.NullUUID{UUID: env.ID, Valid: env.ID != uuid.Nil}
 				record.Environment = env.Slug
+				record.EnvironmentChain = env.Chain
 				slog.Debug("createRunInternal: resolved environment",
 					"env_id", env.ID,
 					"env_slug", env.Slug,
+					"chain", env.Chain,
 					"secrets_count", len(envSecrets),
 					"config_vars_count", len(envConfigVars))
 			}
@@ -314,6 +338,8 @@ func (e *Engine) createRunInternal(ctx context.Context, orgID uuid.UUID, initiat
 		SuiteID:        resolvedSuiteID,
 		TestIDs:        testIDMap,
 		EnvSecrets:     envSecrets,
+		Environment:    envSlug,
+		Tags:           dsl.TestTags(run.Tests),
 		ScheduleID:     scheduleIDForRunInfo,
 		ScheduleType:   scheduleTypeForRunInfo,
 		Logs: []LogLine{
@@ -325,14 +351,31 @@ func (e *Engine) createRunInternal(ctx context.Context, orgID uuid.UUID, initiat
 		},
 	}
 
+	planProgress(runInfo, run)
+	e.loadStepHistory(ctx, runInfo)
+
 	e.mu.Lock()
 	e.runs[runID] = runInfo
 	e.mu.Unlock()
 
+	for _, warning := range capabilityWarnings {
+		e.addLog(runID, "Warning: "+warning, "yellow", false)
+	}
+	if dsl.HasFocusedTests(run.Tests) {
+		e.addLog(runID, "Warning: only is ignored outside local runs, so every test runs", "yellow", false)
+	}
+
+	if reason := testBudgetViolation(run.Budget, len(run.Tests)); reason != "" {
+		e.rejectOverBudget(runID, runInfo, len(run.Tests), reason)
+		return &generated.CreateRunResponse{RunId: runID}, nil
+	}
+	e.startDurationBudget(runID, run.Budget)
+	go e.reportProgress(runID)
+
 	var suiteGlobals map[string]string
 	if len(run.Init) > 0 {
 		e.addLog(runID, "Running suite init...", "n/a", false)
-		initGlobals, initErr := e.runSuiteInitWorkflow(ctx, runID, run.Name, run.Init, runInfo.Vars, run.OpenAPI, runInfo.EnvSecrets)
+		initGlobals, initErr := e.runSuiteInitWorkflow(ctx, runID, run.Name, run.Init, run.Env.Names(), runInfo.Vars, run.OpenAPI, runInfo.EnvSecrets)
 		if initErr != nil {
 			e.handleSuiteInitFailure(runID, runInfo, initErr)
 			return &generated.CreateRunResponse{RunId: runID}, nil
@@ -356,7 +399,16 @@ func (e *Engine) createRunInternal(ctx context.Context, orgID uuid.UUID, initiat
 		suiteGlobals = make(map[string]string)
 	}
 
+	skipped := false
 	for _, test := range run.Tests {
+		// Stop starting tests once max_duration has passed; the ones already
+		// started are being cancelled
+		if e.budgetExceeded(runID) {
+			e.startReadyTests(runID)
+			e.checkIfRunFinished(runID)
+			break
+		}
+
 		testID, err := generateID()
 		if err != nil {
 			log.Printf("[ERROR] Failed to generate test ID: %v", err)
@@ -379,6 +431,7 @@ func (e *Engine) createRunInternal(ctx context.Context, orgID uuid.UUID, initiat
 			StartedAt:  testStartTime,
 			RunID:      runID,
 			TestID:     discoveredTestID,
+			XFail:      test.XFail,
 		}
 
 		// Persist run_test record and pre-create placeholder steps
@@ -424,9 +477,24 @@ func (e *Engine) createRunInternal(ctx context.Context, orgID uuid.UUID, initiat
 			}
 		}
 
+		// Tests marked skip end right away, without a workflow
+		if test.Skip != "" {
+			e.registerSkippedTest(runID, testInfo, test.Skip)
+			skipped = true
+			continue
+		}
+
+		// Tests with depends_on start once the tests they depend on have passed
+		if len(test.DependsOn) > 0 {
+			e.waitForDependencies(runID, testInfo, test)
+			continue
+		}
+
 		workflowOptions := client.StartWorkflowOptions{
-			ID:        testID,
-			TaskQueue: "test-workflows",
+			ID:                    testID,
+			TaskQueue:             "test-workflows",
+			Memo:                  workflowMemo(runInfo),
+			TypedSearchAttributes: e.workflowAttributes(runID, runInfo),
 		}
 
 		suiteGlobalsCopy := cloneStringMap(suiteGlobals)
@@ -467,5 +535,11 @@ func (e *Engine) createRunInternal(ctx context.Context, orgID uuid.UUID, initiat
 		go e.monitorWorkflow(runID, execution.GetID(), execution.GetRunID())
 	}
 
+	// Dependencies may have finished before the tests waiting on them were
+	// registered, and tests marked skip ended without a workflow to report them
+	if e.startReadyTests(runID) || skipped {
+		e.checkIfRunFinished(runID)
+	}
+
 	return &generated.CreateRunResponse{RunId: runID}, nil
 }
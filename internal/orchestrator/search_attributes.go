@@ -0,0 +1,90 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/operatorservice/v1"
+	"go.temporal.io/sdk/temporal"
+)
+
+// workflowSearchAttributes are set on every workflow the engine starts so operators can
+// find a run's workflows in the Temporal UI or CLI, e.g.
+//
+//	temporal workflow terminate --query 'RunId = "abc123"' --reason "incident"
+var workflowSearchAttributes = []string{
+	SearchAttrProjectID,
+	SearchAttrSuiteName,
+	SearchAttrRunID,
+	SearchAttrBranch,
+	SearchAttrEnvironment,
+}
+
+// RegisterSearchAttributes adds any missing Rocketship search attributes to the namespace
+// and turns on populating them. Temporal rejects workflows that set unregistered
+// attributes, so they stay off when registration fails (e.g. the engine's credentials
+// can't manage the namespace).
+func (e *Engine) RegisterSearchAttributes(ctx context.Context, namespace string) error {
+	svc := e.temporal.OperatorService()
+	resp, err := svc.ListSearchAttributes(ctx, &operatorservice.ListSearchAttributesRequest{Namespace: namespace})
+	if err != nil {
+		return fmt.Errorf("failed to list search attributes: %w", err)
+	}
+
+	missing := make(map[string]enumspb.IndexedValueType)
+	for _, name := range workflowSearchAttributes {
+		existing, ok := resp.GetCustomAttributes()[name]
+		if !ok {
+			missing[name] = enumspb.INDEXED_VALUE_TYPE_KEYWORD
+			continue
+		}
+		if existing != enumspb.INDEXED_VALUE_TYPE_KEYWORD {
+			return fmt.Errorf("search attribute %s is registered as %s, expected Keyword", name, existing)
+		}
+	}
+
+	if len(missing) > 0 {
+		if _, err := svc.AddSearchAttributes(ctx, &operatorservice.AddSearchAttributesRequest{
+			Namespace:        namespace,
+			SearchAttributes: missing,
+		}); err != nil {
+			names := make([]string, 0, len(missing))
+			for name := range missing {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return fmt.Errorf("failed to register search attributes %v: %w", names, err)
+		}
+	}
+
+	e.searchAttributes.Store(true)
+	return nil
+}
+
+// workflowAttributes returns the search attributes for a workflow of the given run.
+// Empty values are left unset.
+func (e *Engine) workflowAttributes(runID string, runInfo *RunInfo) temporal.SearchAttributes {
+	if !e.searchAttributes.Load() || runInfo == nil {
+		return temporal.SearchAttributes{}
+	}
+
+	values := map[string]string{
+		SearchAttrProjectID:   runProjectID(runInfo),
+		SearchAttrSuiteName:   runInfo.Name,
+		SearchAttrRunID:       runID,
+		SearchAttrEnvironment: runInfo.Environment,
+	}
+	if runInfo.Context != nil {
+		values[SearchAttrBranch] = runInfo.Context.Branch
+	}
+
+	var updates []temporal.SearchAttributeUpdate
+	for _, name := range workflowSearchAttributes {
+		if value := values[name]; value != "" {
+			updates = append(updates, temporal.NewSearchAttributeKeyKeyword(name).ValueSet(value))
+		}
+	}
+	return temporal.NewSearchAttributes(updates...)
+}
@@ -0,0 +1,82 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/operatorservice/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+	"google.golang.org/grpc"
+)
+
+type fakeOperatorService struct {
+	operatorservice.OperatorServiceClient
+	existing map[string]enumspb.IndexedValueType
+	added    map[string]enumspb.IndexedValueType
+}
+
+func (f *fakeOperatorService) ListSearchAttributes(_ context.Context, _ *operatorservice.ListSearchAttributesRequest, _ ...grpc.CallOption) (*operatorservice.ListSearchAttributesResponse, error) {
+	return &operatorservice.ListSearchAttributesResponse{CustomAttributes: f.existing}, nil
+}
+
+func (f *fakeOperatorService) AddSearchAttributes(_ context.Context, req *operatorservice.AddSearchAttributesRequest, _ ...grpc.CallOption) (*operatorservice.AddSearchAttributesResponse, error) {
+	f.added = req.GetSearchAttributes()
+	return &operatorservice.AddSearchAttributesResponse{}, nil
+}
+
+type operatorClient struct {
+	client.Client
+	operator *fakeOperatorService
+}
+
+func (c *operatorClient) OperatorService() operatorservice.OperatorServiceClient {
+	return c.operator
+}
+
+func TestRegisterSearchAttributes(t *testing.T) {
+	operator := &fakeOperatorService{existing: map[string]enumspb.IndexedValueType{
+		SearchAttrProjectID: enumspb.INDEXED_VALUE_TYPE_KEYWORD,
+	}}
+	engine := newTestEngineWithClient(&operatorClient{operator: operator})
+
+	runInfo := &RunInfo{Name: "Checkout", Environment: "staging", Context: &RunContext{Branch: "main"}}
+	require.Equal(t, 0, engine.workflowAttributes("run-1", runInfo).Size(), "attributes stay off until registered")
+
+	require.NoError(t, engine.RegisterSearchAttributes(context.Background(), "default"))
+	require.Len(t, operator.added, 4)
+	require.NotContains(t, operator.added, SearchAttrProjectID)
+
+	projectID := uuid.New()
+	runInfo.ProjectID = projectID
+	attrs := engine.workflowAttributes("run-1", runInfo)
+	require.Equal(t, 5, attrs.Size())
+	for name, want := range map[string]string{
+		SearchAttrProjectID:   projectID.String(),
+		SearchAttrSuiteName:   "Checkout",
+		SearchAttrRunID:       "run-1",
+		SearchAttrBranch:      "main",
+		SearchAttrEnvironment: "staging",
+	} {
+		got, ok := attrs.GetKeyword(temporal.NewSearchAttributeKeyKeyword(name))
+		require.True(t, ok, name)
+		require.Equal(t, want, got, name)
+	}
+
+	require.Equal(t, 1, engine.workflowAttributes("run-2", &RunInfo{}).Size(), "empty values are left unset")
+}
+
+func TestRegisterSearchAttributesTypeConflict(t *testing.T) {
+	operator := &fakeOperatorService{existing: map[string]enumspb.IndexedValueType{
+		SearchAttrBranch: enumspb.INDEXED_VALUE_TYPE_TEXT,
+	}}
+	engine := newTestEngineWithClient(&operatorClient{operator: operator})
+
+	err := engine.RegisterSearchAttributes(context.Background(), "default")
+	require.ErrorContains(t, err, "Branch is registered as Text")
+	require.Nil(t, operator.added)
+	require.Equal(t, 0, engine.workflowAttributes("run-1", &RunInfo{Name: "Checkout"}).Size())
+}
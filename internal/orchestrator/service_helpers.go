@@ -90,6 +90,7 @@ func mapRunRecordToSummary(rec persistence.RunRecord) *generated.RunSummary {
 		FailedTests:  int32(rec.FailedTests),
 		TimeoutTests: int32(rec.TimeoutTests),
 		Context:      context,
+		Tags:         rec.Tags,
 	}
 }
 
@@ -137,7 +138,8 @@ func mapRunRecordToRunDetails(rec persistence.RunRecord) *generated.GetRunRespon
 			ScheduleName: rec.ScheduleName,
 			Metadata:     map[string]string{},
 		},
-		Tests: []*generated.TestDetails{},
+		Tests:        []*generated.TestDetails{},
+		Cancellation: runRecordCancellation(rec),
 	}
 	if rec.ProjectID.Valid {
 		details.Context.ProjectId = rec.ProjectID.UUID.String()
@@ -149,10 +151,13 @@ func mapRunRecordToRunDetails(rec persistence.RunRecord) *generated.GetRunRespon
 // makeRunTotals converts TestStatusCounts to persistence RunTotals
 func makeRunTotals(counts TestStatusCounts) *persistence.RunTotals {
 	return &persistence.RunTotals{
-		Total:   counts.Total,
-		Passed:  counts.Passed,
-		Failed:  counts.Failed,
-		Timeout: counts.TimedOut,
+		Total:       counts.Total,
+		Passed:      counts.Passed,
+		Failed:      counts.Failed,
+		Timeout:     counts.TimedOut,
+		Skipped:     counts.Skipped,
+		Interrupted: counts.Interrupted,
+		XFail:       counts.XFailed,
 	}
 }
 
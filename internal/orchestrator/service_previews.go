@@ -0,0 +1,102 @@
+package orchestrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rocketship-ai/rocketship/internal/api/generated"
+)
+
+// CreatePreviewEnvironment returns the preview environment for a pull request,
+// creating it on first use. Runs pass its slug to --env; the GitHub webhook deletes
+// it and archives those runs when the pull request closes.
+func (e *Engine) CreatePreviewEnvironment(ctx context.Context, req *generated.CreatePreviewEnvironmentRequest) (*generated.CreatePreviewEnvironmentResponse, error) {
+	projectID, err := e.previewProject(ctx, req.ProjectId, req.PrNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	fallback := strings.ToLower(strings.TrimSpace(req.Fallback))
+	if fallback != "" {
+		if _, err := e.runStore.GetEnvironmentBySlug(ctx, projectID, fallback); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, fmt.Errorf("fallback environment %q does not exist in this project", fallback)
+			}
+			return nil, fmt.Errorf("failed to look up fallback environment %q: %w", fallback, err)
+		}
+	}
+
+	env, created, err := e.runStore.UpsertPreviewEnvironment(ctx, projectID, int(req.PrNumber), fallback)
+	if err != nil {
+		slog.Error("CreatePreviewEnvironment: failed to create preview environment", "project_id", projectID, "pr_number", req.PrNumber, "error", err)
+		return nil, fmt.Errorf("failed to create preview environment: %w", err)
+	}
+	if created {
+		slog.Info("CreatePreviewEnvironment: created preview environment", "project_id", projectID, "slug", env.Slug, "fallback", env.Fallback)
+	}
+
+	return &generated.CreatePreviewEnvironmentResponse{
+		EnvironmentId: env.ID.String(),
+		Slug:          env.Slug,
+		Created:       created,
+		Fallback:      env.Fallback,
+	}, nil
+}
+
+// DeletePreviewEnvironment deletes a pull request's preview environment ahead of
+// the PR closing, archiving its runs
+func (e *Engine) DeletePreviewEnvironment(ctx context.Context, req *generated.DeletePreviewEnvironmentRequest) (*generated.DeletePreviewEnvironmentResponse, error) {
+	projectID, err := e.previewProject(ctx, req.ProjectId, req.PrNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	archived, err := e.runStore.DeletePreviewEnvironment(ctx, projectID, int(req.PrNumber))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("no preview environment for PR #%d in this project", req.PrNumber)
+		}
+		slog.Error("DeletePreviewEnvironment: failed to delete preview environment", "project_id", projectID, "pr_number", req.PrNumber, "error", err)
+		return nil, fmt.Errorf("failed to delete preview environment: %w", err)
+	}
+	slog.Info("DeletePreviewEnvironment: deleted preview environment", "project_id", projectID, "pr_number", req.PrNumber, "archived_runs", archived)
+
+	return &generated.DeletePreviewEnvironmentResponse{ArchivedRuns: int32(archived)}, nil
+}
+
+// previewProject validates a preview environment request and checks the caller
+// may write to the project
+func (e *Engine) previewProject(ctx context.Context, rawProjectID string, prNumber int32) (uuid.UUID, error) {
+	if strings.TrimSpace(rawProjectID) == "" {
+		return uuid.Nil, fmt.Errorf("project_id is required")
+	}
+	projectID, err := uuid.Parse(strings.TrimSpace(rawProjectID))
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid project_id: %w", err)
+	}
+	if prNumber <= 0 {
+		return uuid.Nil, fmt.Errorf("pr_number must be positive")
+	}
+
+	principal, orgID, err := e.resolvePrincipalAndOrg(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if orgID == uuid.Nil || e.runStore == nil {
+		return uuid.Nil, fmt.Errorf("preview environments require an engine connected to the Rocketship database")
+	}
+
+	project, err := e.runStore.GetProject(ctx, projectID)
+	if err != nil || project.OrganizationID != orgID {
+		return uuid.Nil, fmt.Errorf("project %s not found", projectID)
+	}
+	if principal != nil && principal.IsCIToken && !principal.HasProjectAccess(projectID, permWrite) {
+		return uuid.Nil, fmt.Errorf("CI token does not have write access to project %s", projectID)
+	}
+	return projectID, nil
+}
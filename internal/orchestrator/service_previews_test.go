@@ -0,0 +1,98 @@
+package orchestrator
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rocketship-ai/rocketship/internal/api/generated"
+	"github.com/rocketship-ai/rocketship/internal/controlplane/persistence"
+	"github.com/stretchr/testify/require"
+)
+
+type previewStore struct {
+	environmentStore
+	projects map[uuid.UUID]persistence.Project
+	previews map[int]persistence.ProjectEnvironment
+}
+
+func (s *previewStore) GetProject(_ context.Context, projectID uuid.UUID) (persistence.Project, error) {
+	project, ok := s.projects[projectID]
+	if !ok {
+		return persistence.Project{}, sql.ErrNoRows
+	}
+	return project, nil
+}
+
+func (s *previewStore) UpsertPreviewEnvironment(_ context.Context, projectID uuid.UUID, prNumber int, fallback string) (persistence.ProjectEnvironment, bool, error) {
+	if env, ok := s.previews[prNumber]; ok {
+		return env, false, nil
+	}
+	env := persistence.ProjectEnvironment{ID: uuid.New(), ProjectID: projectID, Slug: persistence.PreviewEnvironmentSlug(prNumber), Fallback: fallback}
+	s.previews[prNumber] = env
+	return env, true, nil
+}
+
+func (s *previewStore) DeletePreviewEnvironment(_ context.Context, _ uuid.UUID, prNumber int) (int, error) {
+	if _, ok := s.previews[prNumber]; !ok {
+		return 0, sql.ErrNoRows
+	}
+	delete(s.previews, prNumber)
+	return 3, nil
+}
+
+func TestPreviewEnvironments(t *testing.T) {
+	orgID, projectID, otherProject := uuid.New(), uuid.New(), uuid.New()
+	engine := newTestEngineWithClient(&MockTemporalClient{})
+	engine.ConfigureToken("secret-token")
+	engine.runStore = &previewStore{
+		environmentStore: environmentStore{
+			RunStore: engine.runStore,
+			envs:     map[string]persistence.ProjectEnvironment{"staging": {Slug: "staging"}},
+		},
+		projects: map[uuid.UUID]persistence.Project{
+			projectID:    {ID: projectID, OrganizationID: orgID},
+			otherProject: {ID: otherProject, OrganizationID: uuid.New()},
+		},
+		previews: map[int]persistence.ProjectEnvironment{},
+	}
+	ctx := contextWithPrincipal(context.Background(), &Principal{Subject: "user", Roles: []string{"owner"}, OrgID: orgID.String()})
+
+	resp, err := engine.CreatePreviewEnvironment(ctx, &generated.CreatePreviewEnvironmentRequest{ProjectId: projectID.String(), PrNumber: 42, Fallback: "Staging"})
+	require.NoError(t, err)
+	require.True(t, resp.Created)
+	require.Equal(t, "pr-42", resp.Slug)
+	require.Equal(t, "staging", resp.Fallback)
+
+	again, err := engine.CreatePreviewEnvironment(ctx, &generated.CreatePreviewEnvironmentRequest{ProjectId: projectID.String(), PrNumber: 42})
+	require.NoError(t, err)
+	require.False(t, again.Created)
+	require.Equal(t, resp.EnvironmentId, again.EnvironmentId)
+
+	_, err = engine.CreatePreviewEnvironment(ctx, &generated.CreatePreviewEnvironmentRequest{ProjectId: projectID.String(), PrNumber: 43, Fallback: "qa"})
+	require.ErrorContains(t, err, `fallback environment "qa" does not exist`)
+
+	_, err = engine.CreatePreviewEnvironment(ctx, &generated.CreatePreviewEnvironmentRequest{ProjectId: projectID.String()})
+	require.ErrorContains(t, err, "pr_number must be positive")
+
+	_, err = engine.CreatePreviewEnvironment(ctx, &generated.CreatePreviewEnvironmentRequest{ProjectId: otherProject.String(), PrNumber: 42})
+	require.ErrorContains(t, err, "not found")
+
+	ciCtx := contextWithPrincipal(context.Background(), &Principal{
+		Subject:         "ci:github:repo:acme/shop",
+		Roles:           []string{"editor"},
+		OrgID:           orgID.String(),
+		IsCIToken:       true,
+		AllowedProjects: []CITokenProjectScope{{ProjectID: projectID, Scope: "read"}},
+	})
+	_, err = engine.DeletePreviewEnvironment(ciCtx, &generated.DeletePreviewEnvironmentRequest{ProjectId: projectID.String(), PrNumber: 42})
+	require.ErrorContains(t, err, "does not have write access")
+
+	deleted, err := engine.DeletePreviewEnvironment(ctx, &generated.DeletePreviewEnvironmentRequest{ProjectId: projectID.String(), PrNumber: 42})
+	require.NoError(t, err)
+	require.EqualValues(t, 3, deleted.ArchivedRuns)
+
+	_, err = engine.DeletePreviewEnvironment(ctx, &generated.DeletePreviewEnvironmentRequest{ProjectId: projectID.String(), PrNumber: 42})
+	require.ErrorContains(t, err, "no preview environment for PR #42")
+}
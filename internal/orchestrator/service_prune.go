@@ -0,0 +1,134 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rocketship-ai/rocketship/internal/api/generated"
+	"github.com/rocketship-ai/rocketship/internal/controlplane/persistence"
+)
+
+// PruneRuns deletes finished runs older than a duration and/or beyond the newest N.
+// When both limits are set a run must exceed both to be pruned.
+func (e *Engine) PruneRuns(ctx context.Context, req *generated.PruneRunsRequest) (*generated.PruneRunsResponse, error) {
+	if strings.TrimSpace(req.OlderThan) == "" && req.KeepLast <= 0 {
+		return nil, fmt.Errorf("older_than or keep_last is required")
+	}
+	if req.KeepLast < 0 {
+		return nil, fmt.Errorf("keep_last must not be negative")
+	}
+
+	filter := persistence.RunPruneFilter{
+		KeepLast: int(req.KeepLast),
+		DryRun:   req.DryRun,
+	}
+	if olderThan := strings.TrimSpace(req.OlderThan); olderThan != "" {
+		d, err := time.ParseDuration(olderThan)
+		if err != nil {
+			return nil, fmt.Errorf("invalid older_than %q: %w", olderThan, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("older_than must be positive")
+		}
+		filter.CreatedBefore = time.Now().UTC().Add(-d)
+	}
+
+	principal, orgID, err := e.resolvePrincipalAndOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	if orgID == uuid.Nil || e.runStore == nil {
+		ids = e.pruneRunsInMemory(req.ProjectId, filter)
+	} else {
+		filter.OrganizationID = orgID
+		if req.ProjectId != "" {
+			projectID, err := uuid.Parse(req.ProjectId)
+			if err != nil {
+				return nil, fmt.Errorf("invalid project_id: %w", err)
+			}
+			filter.ProjectID = uuid.NullUUID{UUID: projectID, Valid: true}
+		}
+
+		// CI tokens may only prune runs of projects they can write to
+		if principal != nil && principal.IsCIToken {
+			if !filter.ProjectID.Valid {
+				return nil, fmt.Errorf("CI tokens must specify project_id to prune runs")
+			}
+			if !principal.HasProjectAccess(filter.ProjectID.UUID, permWrite) {
+				return nil, fmt.Errorf("CI token does not have write access to project %s", filter.ProjectID.UUID)
+			}
+		}
+
+		ids, err = e.runStore.PruneRuns(ctx, filter)
+		if err != nil {
+			slog.Error("PruneRuns: failed to prune runs", "error", err)
+			return nil, fmt.Errorf("failed to prune runs: %w", err)
+		}
+
+		if !req.DryRun {
+			e.mu.Lock()
+			for _, id := range ids {
+				delete(e.runs, id)
+			}
+			e.mu.Unlock()
+		}
+	}
+
+	slog.Info("PruneRuns completed", "count", len(ids), "dry_run", req.DryRun)
+
+	return &generated.PruneRunsResponse{
+		RunIds:      ids,
+		PrunedCount: int32(len(ids)),
+		DryRun:      req.DryRun,
+	}, nil
+}
+
+// pruneRunsInMemory applies the filter to runs tracked by the engine when no org-scoped store is in use
+func (e *Engine) pruneRunsInMemory(projectID string, filter persistence.RunPruneFilter) []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	runs := make([]*RunInfo, 0, len(e.runs))
+	for _, runInfo := range e.runs {
+		if projectID != "" && (runInfo.Context == nil || runInfo.Context.ProjectID != projectID) {
+			continue
+		}
+		runs = append(runs, runInfo)
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].StartedAt.After(runs[j].StartedAt)
+	})
+
+	ids := make([]string, 0)
+	for idx, runInfo := range runs {
+		if !isPrunable(runInfo.Status, runInfo.StartedAt, idx, filter) {
+			continue
+		}
+		ids = append(ids, runInfo.ID)
+		if !filter.DryRun {
+			delete(e.runs, runInfo.ID)
+		}
+	}
+	return ids
+}
+
+// isPrunable reports whether a run at position rank (0 = newest) matches the filter
+func isPrunable(status string, createdAt time.Time, rank int, filter persistence.RunPruneFilter) bool {
+	if status == "RUNNING" || status == "PENDING" {
+		return false
+	}
+	if !filter.CreatedBefore.IsZero() && !createdAt.Before(filter.CreatedBefore) {
+		return false
+	}
+	if filter.KeepLast > 0 && rank < filter.KeepLast {
+		return false
+	}
+	return true
+}
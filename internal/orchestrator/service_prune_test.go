@@ -0,0 +1,56 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rocketship-ai/rocketship/internal/api/generated"
+	"github.com/rocketship-ai/rocketship/internal/controlplane/persistence"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneRunsInMemory(t *testing.T) {
+	engine := newTestEngineWithClient(nil)
+	now := time.Now()
+	engine.runs["old-passed"] = &RunInfo{ID: "old-passed", Status: "PASSED", StartedAt: now.Add(-48 * time.Hour)}
+	engine.runs["old-running"] = &RunInfo{ID: "old-running", Status: "RUNNING", StartedAt: now.Add(-47 * time.Hour)}
+	engine.runs["recent"] = &RunInfo{ID: "recent", Status: "FAILED", StartedAt: now.Add(-time.Hour)}
+
+	resp, err := engine.PruneRuns(context.Background(), &generated.PruneRunsRequest{OlderThan: "24h", DryRun: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{"old-passed"}, resp.RunIds)
+	require.Len(t, engine.runs, 3)
+
+	resp, err = engine.PruneRuns(context.Background(), &generated.PruneRunsRequest{KeepLast: 1})
+	require.NoError(t, err)
+	require.Equal(t, []string{"old-passed"}, resp.RunIds)
+	require.Contains(t, engine.runs, "old-running")
+	require.Contains(t, engine.runs, "recent")
+
+	_, err = engine.PruneRuns(context.Background(), &generated.PruneRunsRequest{})
+	require.Error(t, err)
+}
+
+func TestMemoryRunStorePruneKeepsNewest(t *testing.T) {
+	store := NewMemoryRunStore().(*memoryRunStore)
+	orgID := uuid.New()
+	now := time.Now()
+	for i, id := range []string{"r1", "r2", "r3"} {
+		store.runs[id] = persistence.RunRecord{
+			ID:             id,
+			OrganizationID: orgID,
+			Status:         "PASSED",
+			CreatedAt:      now.Add(-time.Duration(i) * time.Hour),
+		}
+	}
+
+	ids, err := store.PruneRuns(context.Background(), persistence.RunPruneFilter{
+		OrganizationID: orgID,
+		KeepLast:       1,
+		CreatedBefore:  now.Add(-90 * time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"r3"}, ids)
+}
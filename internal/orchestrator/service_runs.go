@@ -52,15 +52,45 @@ func (e *Engine) CreateRun(ctx context.Context, req *generated.CreateRunRequest)
 		return nil, fmt.Errorf("test run must contain at least one test")
 	}
 
+	tagFilter, err := detectTagFilter(req.Context)
+	if err != nil {
+		return nil, err
+	}
+	focused := dsl.HasFocusedTests(run.Tests)
+	focus := focused && isLocalRun(req.Context)
+	if err := selectTests(&run, focus, tagFilter); err != nil {
+		return nil, err
+	}
+	if len(run.Tests) == 0 {
+		return nil, fmt.Errorf("no tests match tags %q", tagFilter.String())
+	}
+
+	// Catch bad jq expressions and undefined variables before any test starts
+	if err := dsl.ValidateReferences(run); err != nil {
+		slog.Debug("CreateRun: reference validation failed", "error", err)
+		return nil, fmt.Errorf("invalid test references: %w", err)
+	}
+
+	if err := e.checkWorkerRequirements(ctx, run.Worker); err != nil {
+		return nil, err
+	}
+	capabilityWarnings, err := e.checkWorkerCapabilities(ctx, run)
+	if err != nil {
+		return nil, err
+	}
+
 	runContext := extractRunContext(req.Context)
 	startTime := time.Now().UTC()
 	configSource := detectConfigSource(runContext)
 	envSlug := detectEnvironment(runContext)
 	initiator := determineInitiator(principal)
 
-	// For CI token principals, override source and trigger
+	// For CI token principals, override source and trigger. Exchanged CI ID tokens
+	// keep the source the CLI detected (e.g. github-actions).
 	if principal != nil && principal.IsCIToken {
-		runContext.Source = "ci-token"
+		if principal.CITokenID != uuid.Nil {
+			runContext.Source = "ci-token"
+		}
 		runContext.Trigger = "ci"
 	}
 
@@ -109,6 +139,7 @@ func (e *Engine) CreateRun(ctx context.Context, req *generated.CreateRunRequest)
 			FailedTests:    0,
 			TimeoutTests:   0,
 			StartedAt:      sql.NullTime{Time: startTime, Valid: true},
+			Tags:           dsl.TestTags(run.Tests),
 		}
 
 		// Parse project_id as UUID if it's a valid UUID
@@ -151,29 +182,37 @@ func (e *Engine) CreateRun(ctx context.Context, req *generated.CreateRunRequest)
 				return nil, fmt.Errorf("CI token does not have write access to project %s", record.ProjectID.UUID)
 			}
 			slog.Debug("CreateRun: CI token project access verified",
-				"token_id", principal.CITokenID,
+				"principal", initiator,
 				"project_id", record.ProjectID.UUID)
 		}
 
 		// Resolve environment after project_id is set
-		// Only resolve if an explicit envSlug was provided via --env flag
-		// CLI never implicitly applies an environment - only when --env is explicitly provided
-		if record.ProjectID.Valid && envSlug != "" {
-			env, err := e.runStore.GetEnvironmentBySlug(ctx, record.ProjectID.UUID, envSlug)
+		// Without --env, the organization's default environment applies if it has one;
+		// the CLI never implicitly picks one of the project's environments
+		if record.ProjectID.Valid {
+			env, err := e.resolveEnvironment(ctx, record.ProjectID.UUID, envSlug)
+			var chainErr *environmentChainError
 			if err != nil {
-				if errors.Is(err, sql.ErrNoRows) {
+				if errors.Is(err, sql.ErrNoRows) && envSlug == "" {
+					// No environment requested and no organization default: run without one
+				} else if errors.Is(err, sql.ErrNoRows) {
 					slog.Error("CreateRun: environment not found", "slug", envSlug, "project_id", record.ProjectID.UUID)
 					return nil, fmt.Errorf("unknown environment %q for this project; hint: create it in the console /environments page", envSlug)
 				}
+				if errors.As(err, &chainErr) {
+					return nil, fmt.Errorf("cannot resolve environment %q: %w", envSlug, err)
+				}
 				slog.Debug("CreateRun: failed to lookup environment by slug", "slug", envSlug, "error", err)
 			} else {
 				envSecrets = env.EnvSecrets
 				envConfigVars = env.ConfigVars
-				record.EnvironmentID = uuid.NullUUID{UUID: env.ID, Valid: true}
+				record.EnvironmentID = uuid.NullUUID{UUID: env.ID, Valid: env.ID != uuid.Nil}
 				record.Environment = env.Slug
+				record.EnvironmentChain = env.Chain
 				slog.Debug("CreateRun: resolved environment",
 					"env_id", env.ID,
 					"env_slug", env.Slug,
+					"chain", env.Chain,
 					"secrets_count", len(envSecrets),
 					"config_vars_count", len(envConfigVars))
 			}
@@ -338,6 +377,10 @@ func (e *Engine) CreateRun(ctx context.Context, req *generated.CreateRunRequest)
 				}
 			}
 		}
+		// The re-parsed run has every test again
+		if err := selectTests(&run, focus, tagFilter); err != nil {
+			return nil, err
+		}
 	}
 
 	runInfo := &RunInfo{
@@ -355,6 +398,8 @@ func (e *Engine) CreateRun(ctx context.Context, req *generated.CreateRunRequest)
 		SuiteID:        resolvedSuiteID,
 		TestIDs:        testIDMap,
 		EnvSecrets:     envSecrets,
+		Environment:    envSlug,
+		Tags:           dsl.TestTags(run.Tests),
 		Logs: []LogLine{
 			{
 				Msg:   fmt.Sprintf("Starting test run \"%s\"... 🚀 [%s/%s]", run.Name, runContext.ProjectID, runContext.Source),
@@ -364,14 +409,33 @@ func (e *Engine) CreateRun(ctx context.Context, req *generated.CreateRunRequest)
 		},
 	}
 
+	planProgress(runInfo, run)
+	e.loadStepHistory(ctx, runInfo)
+
 	e.mu.Lock()
 	e.runs[runID] = runInfo
 	e.mu.Unlock()
 
+	for _, warning := range capabilityWarnings {
+		e.addLog(runID, "Warning: "+warning, "yellow", false)
+	}
+	if focus {
+		e.addLog(runID, "Focus run: only the tests marked only, and the tests they depend on, run", "yellow", false)
+	} else if focused {
+		e.addLog(runID, "Warning: only is ignored outside local runs, so every test runs", "yellow", false)
+	}
+
+	if reason := testBudgetViolation(run.Budget, len(run.Tests)); reason != "" {
+		e.rejectOverBudget(runID, runInfo, len(run.Tests), reason)
+		return &generated.CreateRunResponse{RunId: runID}, nil
+	}
+	e.startDurationBudget(runID, run.Budget)
+	go e.reportProgress(runID)
+
 	var suiteGlobals map[string]string
 	if len(run.Init) > 0 {
 		e.addLog(runID, "Running suite init...", "n/a", false)
-		initGlobals, initErr := e.runSuiteInitWorkflow(ctx, runID, run.Name, run.Init, runInfo.Vars, run.OpenAPI, runInfo.EnvSecrets)
+		initGlobals, initErr := e.runSuiteInitWorkflow(ctx, runID, run.Name, run.Init, run.Env.Names(), runInfo.Vars, run.OpenAPI, runInfo.EnvSecrets)
 		if initErr != nil {
 			e.handleSuiteInitFailure(runID, runInfo, initErr)
 			return &generated.CreateRunResponse{RunId: runID}, nil
@@ -395,7 +459,16 @@ func (e *Engine) CreateRun(ctx context.Context, req *generated.CreateRunRequest)
 		suiteGlobals = make(map[string]string)
 	}
 
+	skipped := false
 	for _, test := range run.Tests {
+		// Stop starting tests once max_duration has passed; the ones already
+		// started are being cancelled
+		if e.budgetExceeded(runID) {
+			e.startReadyTests(runID)
+			e.checkIfRunFinished(runID)
+			break
+		}
+
 		testID, err := generateID()
 		if err != nil {
 			log.Printf("[ERROR] Failed to generate test ID: %v", err)
@@ -419,6 +492,7 @@ func (e *Engine) CreateRun(ctx context.Context, req *generated.CreateRunRequest)
 			StartedAt:  testStartTime,
 			RunID:      runID,
 			TestID:     discoveredTestID,
+			XFail:      test.XFail,
 		}
 
 		// Persist run_test record and pre-create placeholder steps
@@ -466,9 +540,24 @@ func (e *Engine) CreateRun(ctx context.Context, req *generated.CreateRunRequest)
 			}
 		}
 
+		// Tests marked skip end right away, without a workflow
+		if test.Skip != "" {
+			e.registerSkippedTest(runID, testInfo, test.Skip)
+			skipped = true
+			continue
+		}
+
+		// Tests with depends_on start once the tests they depend on have passed
+		if len(test.DependsOn) > 0 {
+			e.waitForDependencies(runID, testInfo, test)
+			continue
+		}
+
 		workflowOptions := client.StartWorkflowOptions{
-			ID:        testID,
-			TaskQueue: "test-workflows",
+			ID:                    testID,
+			TaskQueue:             "test-workflows",
+			Memo:                  workflowMemo(runInfo),
+			TypedSearchAttributes: e.workflowAttributes(runID, runInfo),
 		}
 
 		slog.Debug("Starting workflow with search attributes",
@@ -515,23 +604,46 @@ func (e *Engine) CreateRun(ctx context.Context, req *generated.CreateRunRequest)
 		go e.monitorWorkflow(runID, execution.GetID(), execution.GetRunID())
 	}
 
+	// Dependencies may have finished before the tests waiting on them were
+	// registered, and tests marked skip ended without a workflow to report them
+	if e.startReadyTests(runID) || skipped {
+		e.checkIfRunFinished(runID)
+	}
+
 	return &generated.CreateRunResponse{RunId: runID}, nil
 }
 
-func (e *Engine) runSuiteInitWorkflow(ctx context.Context, runID, runName string, initSteps []dsl.Step, vars map[string]interface{}, suiteOpenAPI *dsl.OpenAPISuiteConfig, envSecrets map[string]string) (map[string]string, error) {
+// selectTests narrows the parsed suite to the tests the run runs: its focused
+// tests, when focus applies, then the ones the tags select
+func selectTests(run *dsl.RocketshipConfig, focus bool, tags dsl.TagFilter) error {
+	if focus {
+		dsl.FocusTests(run)
+	}
+	return dsl.FilterTests(run, tags)
+}
+
+func (e *Engine) runSuiteInitWorkflow(ctx context.Context, runID, runName string, initSteps []dsl.Step, requiredEnv []string, vars map[string]interface{}, suiteOpenAPI *dsl.OpenAPISuiteConfig, envSecrets map[string]string) (map[string]string, error) {
 	if len(initSteps) == 0 {
 		return make(map[string]string), nil
 	}
 
 	suiteTest := dsl.Test{
-		Name:  fmt.Sprintf("%s::suite-init", runName),
-		Init:  initSteps,
-		Steps: []dsl.Step{},
+		Name:        fmt.Sprintf("%s::suite-init", runName),
+		Init:        initSteps,
+		Steps:       []dsl.Step{},
+		RequiredEnv: requiredEnv,
 	}
 
+	e.mu.RLock()
+	memo := workflowMemo(e.runs[runID])
+	searchAttributes := e.workflowAttributes(runID, e.runs[runID])
+	e.mu.RUnlock()
+
 	workflowOptions := client.StartWorkflowOptions{
-		ID:        fmt.Sprintf("%s_suite_init", runID),
-		TaskQueue: "test-workflows",
+		ID:                    fmt.Sprintf("%s_suite_init", runID),
+		TaskQueue:             "test-workflows",
+		Memo:                  memo,
+		TypedSearchAttributes: searchAttributes,
 	}
 
 	execution, err := e.temporal.ExecuteWorkflow(ctx, workflowOptions, "TestWorkflow", suiteTest, vars, runID, suiteOpenAPI, map[string]string(nil), envSecrets)
@@ -553,9 +665,15 @@ func (e *Engine) handleSuiteInitFailure(runID string, runInfo *RunInfo, initErr
 	ended := time.Now().UTC()
 
 	e.mu.Lock()
-	runInfo.Status = "FAILED"
+	// Init fails with a cancellation when max_duration runs out during it
+	status := "FAILED"
+	if runInfo.BudgetExceeded != "" {
+		status = runStatusBudgetExceeded
+	}
+	runInfo.Status = status
 	runInfo.SuiteInitFailed = true
 	runInfo.EndedAt = ended
+	stopBudgetTimer(runInfo)
 	e.mu.Unlock()
 
 	e.addLog(runID, fmt.Sprintf("Suite init failed: %v", initErr), "red", true)
@@ -566,7 +684,7 @@ func (e *Engine) handleSuiteInitFailure(runID string, runInfo *RunInfo, initErr
 		if _, err := e.runStore.UpdateRun(context.Background(), persistence.RunUpdate{
 			RunID:          runID,
 			OrganizationID: runInfo.OrganizationID,
-			Status:         stringPtr("FAILED"),
+			Status:         stringPtr(status),
 			EndedAt:        timePtr(ended),
 			Totals:         &persistence.RunTotals{Total: len(runInfo.Tests)},
 		}); err != nil {
@@ -606,6 +724,8 @@ func (e *Engine) triggerSuiteCleanup(runID string, hasFailure bool) {
 	suiteGlobalsCopy := cloneStringMap(runInfo.SuiteGlobals)
 	suiteOpenAPI := runInfo.SuiteOpenAPI
 	envSecretsCopy := cloneStringMap(runInfo.EnvSecrets)
+	memo := workflowMemo(runInfo)
+	searchAttributes := e.workflowAttributes(runID, runInfo)
 	e.mu.Unlock()
 
 	slog.Info("triggerSuiteCleanup: Starting suite cleanup workflow", "run_id", runID)
@@ -623,8 +743,10 @@ func (e *Engine) triggerSuiteCleanup(runID string, hasFailure bool) {
 		defer cancel()
 
 		options := client.StartWorkflowOptions{
-			ID:        fmt.Sprintf("%s_suite_cleanup", runID),
-			TaskQueue: "test-workflows",
+			ID:                    fmt.Sprintf("%s_suite_cleanup", runID),
+			TaskQueue:             "test-workflows",
+			Memo:                  memo,
+			TypedSearchAttributes: searchAttributes,
 		}
 
 		params := interpreter.SuiteCleanupParams{
@@ -666,6 +788,7 @@ func (e *Engine) ListRuns(ctx context.Context, req *generated.ListRunsRequest) (
 		"source", req.Source,
 		"branch", req.Branch,
 		"status", req.Status,
+		"suite_name", req.SuiteName,
 		"limit", req.Limit)
 
 	_, orgID, err := e.resolvePrincipalAndOrg(ctx)
@@ -673,8 +796,13 @@ func (e *Engine) ListRuns(ctx context.Context, req *generated.ListRunsRequest) (
 		return nil, err
 	}
 
+	tagFilter, err := dsl.ParseTagFilter(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+
 	if orgID == uuid.Nil || e.runStore == nil {
-		return e.listRunsInMemory(req)
+		return e.listRunsInMemory(req, tagFilter)
 	}
 
 	limit := int(req.Limit)
@@ -706,6 +834,12 @@ func (e *Engine) ListRuns(ctx context.Context, req *generated.ListRunsRequest) (
 		if req.ScheduleName != "" && !strings.EqualFold(rec.ScheduleName, req.ScheduleName) {
 			continue
 		}
+		if req.SuiteName != "" && !strings.EqualFold(rec.SuiteName, req.SuiteName) {
+			continue
+		}
+		if !tagFilter.Matches(rec.Tags) {
+			continue
+		}
 
 		filtered = append(filtered, mapRunRecordToSummary(rec))
 	}
@@ -736,9 +870,10 @@ func (e *Engine) GetRun(ctx context.Context, req *generated.GetRunRequest) (*gen
 	e.mu.RLock()
 	if runInfo, exists := e.runs[req.RunId]; exists {
 		if orgID == uuid.Nil || runInfo.OrganizationID == orgID {
+			details := mapRunInfoToRunDetails(runInfo)
 			e.mu.RUnlock()
 			slog.Debug("Found active run in memory", "run_id", req.RunId)
-			return mapRunInfoToRunDetails(runInfo), nil
+			return details, nil
 		}
 	}
 	e.mu.RUnlock()
@@ -782,36 +917,51 @@ func (e *Engine) CancelRun(ctx context.Context, req *generated.CancelRunRequest)
 		return nil, err
 	}
 
+	return e.cancelRun(ctx, req.RunId, orgID, CancelReasonUser, "cancelled by user"), nil
+}
+
+// cancelRun cancels a run's test workflows, runs suite cleanup and records why the
+// run stopped. Tests a cancellation stops are INTERRUPTED if a step had started
+// and SKIPPED otherwise.
+func (e *Engine) cancelRun(ctx context.Context, runID string, orgID uuid.UUID, reason, message string) *generated.CancelRunResponse {
 	e.mu.Lock()
-	runInfo, exists := e.runs[req.RunId]
+	runInfo, exists := e.runs[runID]
 	if !exists {
 		e.mu.Unlock()
-		slog.Warn("CancelRun: Run not found", "run_id", req.RunId)
+		slog.Warn("CancelRun: Run not found", "run_id", runID)
 		return &generated.CancelRunResponse{
 			Success: false,
-			Message: fmt.Sprintf("run not found: %s", req.RunId),
-		}, nil
+			Message: fmt.Sprintf("run not found: %s", runID),
+		}
 	}
 	if orgID != uuid.Nil && runInfo.OrganizationID != orgID {
 		e.mu.Unlock()
-		slog.Warn("CancelRun: Run not accessible for caller", "run_id", req.RunId)
+		slog.Warn("CancelRun: Run not accessible for caller", "run_id", runID)
 		return &generated.CancelRunResponse{
 			Success: false,
-			Message: fmt.Sprintf("run not found: %s", req.RunId),
-		}, nil
+			Message: fmt.Sprintf("run not found: %s", runID),
+		}
 	}
 
 	runInfo.Status = "CANCELLED"
-	slog.Debug("CancelRun: Marked run as CANCELLED", "run_id", req.RunId)
+	runInfo.CancelReason = reason
+	runInfo.CancelMessage = message
+	stopBudgetTimer(runInfo)
+	slog.Debug("CancelRun: Marked run as CANCELLED", "run_id", runID, "reason", reason)
 
 	testWorkflows := make([]string, 0, len(runInfo.Tests))
 	for workflowID, testInfo := range runInfo.Tests {
+		// Tests still waiting on their dependencies have no workflow yet
+		if testInfo.Waiting != nil {
+			testInfo.Waiting = nil
+			continue
+		}
 		testWorkflows = append(testWorkflows, workflowID)
 		slog.Debug("CancelRun: Found test workflow to cancel", "workflow_id", workflowID, "test_name", testInfo.Name, "status", testInfo.Status)
 	}
 	e.mu.Unlock()
 
-	slog.Info("CancelRun: Cancelling workflows", "run_id", req.RunId, "workflow_count", len(testWorkflows))
+	slog.Info("CancelRun: Cancelling workflows", "run_id", runID, "workflow_count", len(testWorkflows))
 
 	var cancelErrors []string
 	for _, workflowID := range testWorkflows {
@@ -833,51 +983,76 @@ func (e *Engine) CancelRun(ctx context.Context, req *generated.CancelRunRequest)
 		}
 	}
 
-	e.addLog(req.RunId, "Run cancelled by user (Ctrl+C)", "yellow", true)
-	slog.Debug("CancelRun: Triggering suite cleanup", "run_id", req.RunId)
-	e.triggerSuiteCleanup(req.RunId, true)
+	if reason == CancelReasonUser {
+		e.addLog(runID, "Run cancelled by user (Ctrl+C)", "yellow", true)
+	} else {
+		e.addLog(runID, fmt.Sprintf("Run cancelled (%s): %s", reason, message), "yellow", true)
+	}
+	slog.Debug("CancelRun: Triggering suite cleanup", "run_id", runID)
+	e.triggerSuiteCleanup(runID, true)
 
+	// Tests whose monitors haven't reported yet still count as stopped by the
+	// cancellation, not as pending
 	ended := time.Now().UTC()
+	var stopped []*TestInfo
 	e.mu.Lock()
-	if runInfo, ok := e.runs[req.RunId]; ok {
+	if runInfo, ok := e.runs[runID]; ok {
 		runInfo.EndedAt = ended
+		for _, testInfo := range runInfo.Tests {
+			if testInfo.Status == "PENDING" {
+				testInfo.Status = cancelledTestStatus(testInfo)
+				testInfo.EndedAt = ended
+				stopped = append(stopped, testInfo)
+			}
+		}
 	}
 	e.mu.Unlock()
 
-	counts, err := e.getTestStatusCounts(req.RunId)
+	if e.runStore != nil {
+		for _, testInfo := range stopped {
+			if err := e.runStore.UpdateRunTestByWorkflowID(ctx, testInfo.WorkflowID, testInfo.Status, nil, ended, ended.Sub(testInfo.StartedAt).Milliseconds()); err != nil {
+				slog.Debug("CancelRun: failed to persist stopped test", "workflow_id", testInfo.WorkflowID, "error", err)
+			}
+		}
+	}
+
+	counts, err := e.getTestStatusCounts(runID)
 	if err != nil {
-		slog.Warn("CancelRun: unable to compute test counts", "run_id", req.RunId, "error", err)
+		slog.Warn("CancelRun: unable to compute test counts", "run_id", runID, "error", err)
 		counts = TestStatusCounts{}
 	}
+	e.addLog(runID, fmt.Sprintf("Test run cancelled: %s.", cancellationSummary(counts)), "n/a", true)
 
 	if orgID != uuid.Nil && e.runStore != nil {
 		if _, updErr := e.runStore.UpdateRun(ctx, persistence.RunUpdate{
-			RunID:          req.RunId,
+			RunID:          runID,
 			OrganizationID: orgID,
 			Status:         stringPtr("CANCELLED"),
 			EndedAt:        timePtr(ended),
 			Totals:         makeRunTotals(counts),
+			CancelReason:   stringPtr(reason),
+			CancelMessage:  message,
 		}); updErr != nil {
-			slog.Error("CancelRun: failed to persist cancellation", "run_id", req.RunId, "error", updErr)
+			slog.Error("CancelRun: failed to persist cancellation", "run_id", runID, "error", updErr)
 		}
 	}
 
 	if len(cancelErrors) > 0 {
-		slog.Warn("CancelRun: Completed with errors", "run_id", req.RunId, "errors", cancelErrors)
+		slog.Warn("CancelRun: Completed with errors", "run_id", runID, "errors", cancelErrors)
 		return &generated.CancelRunResponse{
 			Success: false,
 			Message: fmt.Sprintf("cancelled with errors: %s", strings.Join(cancelErrors, "; ")),
-		}, nil
+		}
 	}
 
-	slog.Info("CancelRun: Completed successfully", "run_id", req.RunId)
+	slog.Info("CancelRun: Completed successfully", "run_id", runID)
 	return &generated.CancelRunResponse{
 		Success: true,
 		Message: "run cancelled successfully",
-	}, nil
+	}
 }
 
-func (e *Engine) listRunsInMemory(req *generated.ListRunsRequest) (*generated.ListRunsResponse, error) {
+func (e *Engine) listRunsInMemory(req *generated.ListRunsRequest, tagFilter dsl.TagFilter) (*generated.ListRunsResponse, error) {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
 
@@ -899,6 +1074,12 @@ func (e *Engine) listRunsInMemory(req *generated.ListRunsRequest) (*generated.Li
 		if req.ScheduleName != "" && runInfo.Context.ScheduleName != req.ScheduleName {
 			continue
 		}
+		if req.SuiteName != "" && !strings.EqualFold(runInfo.Name, req.SuiteName) {
+			continue
+		}
+		if !tagFilter.Matches(runInfo.Tags) {
+			continue
+		}
 
 		var passed, failed, timeout int32
 		for _, test := range runInfo.Tests {
@@ -937,6 +1118,7 @@ func (e *Engine) listRunsInMemory(req *generated.ListRunsRequest) (*generated.Li
 				ScheduleName: runInfo.Context.ScheduleName,
 				Metadata:     runInfo.Context.Metadata,
 			},
+			Tags: runInfo.Tags,
 		})
 	}
 
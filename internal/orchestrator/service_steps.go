@@ -11,6 +11,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/rocketship-ai/rocketship/internal/api/generated"
+	"github.com/rocketship-ai/rocketship/internal/artifacts"
 	"github.com/rocketship-ai/rocketship/internal/controlplane/persistence"
 )
 
@@ -48,6 +49,8 @@ func (e *Engine) UpsertRunStep(ctx context.Context, req *generated.UpsertRunStep
 	}
 	e.mu.RUnlock()
 
+	e.recordStepProgress(req.RunId, req.WorkflowId, req.Status, req.DurationMs)
+
 	// Check if we have a run store (only when running with controlplane)
 	if e.runStore == nil {
 		slog.Debug("UpsertRunStep: no run store available, skipping persistence")
@@ -112,6 +115,12 @@ func (e *Engine) UpsertRunStep(ctx context.Context, req *generated.UpsertRunStep
 			slog.Warn("UpsertRunStep: failed to parse step_config_json", "error", err)
 		}
 	}
+	var savedArtifacts []artifacts.Artifact
+	if len(req.ArtifactsJson) > 0 {
+		if err := json.Unmarshal(req.ArtifactsJson, &savedArtifacts); err != nil {
+			slog.Warn("UpsertRunStep: failed to parse artifacts_json", "error", err)
+		}
+	}
 
 	// Build step record
 	step := persistence.RunStep{
@@ -137,6 +146,9 @@ func (e *Engine) UpsertRunStep(ctx context.Context, req *generated.UpsertRunStep
 	if req.DurationMs > 0 {
 		step.DurationMs = sql.NullInt64{Int64: req.DurationMs, Valid: true}
 	}
+	if req.WorkerVersion != "" {
+		step.WorkerVersion = sql.NullString{String: req.WorkerVersion, Valid: true}
+	}
 
 	// Upsert the step
 	upsertedStep, err := e.runStore.UpsertRunStep(ctx, step)
@@ -145,6 +157,23 @@ func (e *Engine) UpsertRunStep(ctx context.Context, req *generated.UpsertRunStep
 		return nil, fmt.Errorf("failed to upsert run step: %w", err)
 	}
 
+	// Record the step's artifacts against the run, test and step
+	for _, saved := range savedArtifacts {
+		artifact := persistence.RunArtifact{
+			RunID:        req.RunId,
+			RunTestID:    uuid.NullUUID{UUID: runTest.ID, Valid: true},
+			RunStepID:    uuid.NullUUID{UUID: upsertedStep.ID, Valid: true},
+			Name:         saved.Name,
+			ArtifactType: saved.Type,
+			MimeType:     sql.NullString{String: saved.MimeType, Valid: saved.MimeType != ""},
+			SizeBytes:    sql.NullInt64{Int64: saved.Size, Valid: true},
+			StoragePath:  saved.Path,
+		}
+		if _, err := e.runStore.InsertRunArtifact(ctx, artifact); err != nil {
+			slog.Warn("UpsertRunStep: failed to record artifact", "name", saved.Name, "error", err)
+		}
+	}
+
 	// If step is RUNNING, update the run_test status to RUNNING (from PENDING)
 	// This ensures the UI shows the test as actively running
 	if req.Status == "RUNNING" {
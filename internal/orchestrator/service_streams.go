@@ -41,6 +41,8 @@ func (e *Engine) StreamLogs(req *generated.LogStreamRequest, stream generated.En
 			bold := false
 			testName := ""
 			stepName := ""
+			level := ""
+			var fields map[string]string
 			if logMsg.Metadata != nil {
 				if v, ok := logMsg.Metadata["color"].(string); ok {
 					color = v
@@ -54,6 +56,15 @@ func (e *Engine) StreamLogs(req *generated.LogStreamRequest, stream generated.En
 				if v, ok := logMsg.Metadata["step_name"].(string); ok {
 					stepName = v
 				}
+				if v, ok := logMsg.Metadata["level"].(string); ok {
+					level = v
+				}
+				if v, ok := logMsg.Metadata["fields"].(map[string]interface{}); ok {
+					fields = make(map[string]string, len(v))
+					for key, value := range v {
+						fields[key] = fmt.Sprint(value)
+					}
+				}
 			}
 
 			if err := stream.Send(&generated.LogLine{
@@ -63,6 +74,8 @@ func (e *Engine) StreamLogs(req *generated.LogStreamRequest, stream generated.En
 				Bold:     bold,
 				TestName: testName,
 				StepName: stepName,
+				Level:    level,
+				Fields:   fields,
 			}); err != nil {
 				return err
 			}
@@ -87,6 +100,8 @@ func (e *Engine) StreamLogs(req *generated.LogStreamRequest, stream generated.En
 			Bold:     logMsg.Bold,
 			TestName: logMsg.TestName,
 			StepName: logMsg.StepName,
+			Level:    logMsg.Level,
+			Fields:   logMsg.Fields,
 		}); err != nil {
 			return err
 		}
@@ -129,6 +144,8 @@ func (e *Engine) StreamLogs(req *generated.LogStreamRequest, stream generated.En
 					Bold:     logMsg.Bold,
 					TestName: logMsg.TestName,
 					StepName: logMsg.StepName,
+					Level:    logMsg.Level,
+					Fields:   logMsg.Fields,
 				}); err != nil {
 					return err
 				}
@@ -170,6 +187,6 @@ func (e *Engine) AddLog(ctx context.Context, req *generated.AddLogRequest) (*gen
 	}
 	e.mu.RUnlock()
 
-	e.addLogWithWorkflowContext(req.RunId, req.WorkflowId, req.Message, req.Color, req.Bold, req.TestName, req.StepName)
+	e.addLogWithWorkflowContext(req.RunId, req.WorkflowId, req.Message, req.Color, req.Bold, req.TestName, req.StepName, req.Level, req.Fields)
 	return &generated.AddLogResponse{}, nil
 }
@@ -42,10 +42,32 @@ func BuildStepConfig(step dsl.Step) map[string]interface{} {
 		if len(step.Retry.NonRetryableErrors) > 0 {
 			retry["non_retryable_errors"] = step.Retry.NonRetryableErrors
 		}
+		if step.Retry.Attempts > 0 {
+			retry["attempts"] = step.Retry.Attempts
+		}
+		if step.Retry.Backoff != nil {
+			retry["backoff"] = step.Retry.Backoff
+		}
+		if len(step.Retry.On) > 0 {
+			retry["on"] = step.Retry.On
+		}
 		if len(retry) > 0 {
 			config["retry"] = retry
 		}
 	}
 
+	if step.When != "" {
+		config["when"] = step.When
+	}
+	if step.Unless != "" {
+		config["unless"] = step.Unless
+	}
+	if step.ForEach != nil {
+		config["for_each"] = step.ForEach
+	}
+	if step.Group != nil {
+		config["parallel_group"] = step.Group
+	}
+
 	return config
 }
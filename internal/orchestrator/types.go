@@ -3,6 +3,7 @@ package orchestrator
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,6 +22,9 @@ type Engine struct {
 	cleanupWg       sync.WaitGroup // Tracks active suite cleanup workflows
 	runStore        RunStore
 	requireOrgScope bool
+	resultSinks     []ResultSink // Destinations for per-test result events
+	// Set once the Rocketship search attributes are registered in the namespace
+	searchAttributes atomic.Bool
 }
 
 type RunStore interface {
@@ -28,6 +32,7 @@ type RunStore interface {
 	UpdateRun(ctx context.Context, update persistence.RunUpdate) (persistence.RunRecord, error)
 	GetRun(ctx context.Context, orgID uuid.UUID, runID string) (persistence.RunRecord, error)
 	ListRuns(ctx context.Context, orgID uuid.UUID, limit int) ([]persistence.RunRecord, error)
+	PruneRuns(ctx context.Context, filter persistence.RunPruneFilter) ([]string, error)
 	// Run details
 	InsertRunTest(ctx context.Context, rt persistence.RunTest) (persistence.RunTest, error)
 	UpdateRunTestByWorkflowID(ctx context.Context, workflowID, status string, errorMsg *string, endedAt time.Time, durationMs int64) error
@@ -39,6 +44,9 @@ type RunStore interface {
 	UpsertRunStep(ctx context.Context, step persistence.RunStep) (persistence.RunStep, error)
 	UpdateRunTestStepCounts(ctx context.Context, runTestID uuid.UUID) error
 	ListRunSteps(ctx context.Context, runTestID uuid.UUID) ([]persistence.RunStep, error)
+	MedianStepDurations(ctx context.Context, testIDs []uuid.UUID) (map[uuid.UUID]int64, error)
+	// Files steps saved on the worker, such as screenshots and recordings
+	InsertRunArtifact(ctx context.Context, artifact persistence.RunArtifact) (persistence.RunArtifact, error)
 	// Set run_test status to RUNNING when first step starts
 	SetRunTestRunning(ctx context.Context, runTestID uuid.UUID) error
 	// Project lookup for run association
@@ -52,6 +60,10 @@ type RunStore interface {
 	UpdateTestLastRun(ctx context.Context, testID uuid.UUID, runID, status string, runAt time.Time, durationMs int64) error
 	// Environment lookup for run execution
 	GetEnvironmentBySlug(ctx context.Context, projectID uuid.UUID, slug string) (persistence.ProjectEnvironment, error)
+	GetProjectOrgDefaultEnvironment(ctx context.Context, projectID uuid.UUID) (persistence.OrgDefaultEnvironment, error)
+	// Per-PR preview environments
+	UpsertPreviewEnvironment(ctx context.Context, projectID uuid.UUID, prNumber int, fallback string) (persistence.ProjectEnvironment, bool, error)
+	DeletePreviewEnvironment(ctx context.Context, projectID uuid.UUID, prNumber int) (int, error)
 	// CI Token authentication
 	FindCITokenByPlaintext(ctx context.Context, tokenPlaintext string) (*persistence.CITokenLookupResult, error)
 	UpdateCITokenLastUsed(ctx context.Context, tokenID uuid.UUID) error
@@ -60,6 +72,7 @@ type RunStore interface {
 	UpdateSuiteScheduleLastRun(ctx context.Context, scheduleID uuid.UUID, runID, status string, runAt time.Time) error
 	// Direct run status update (for DB-only completion checks)
 	UpdateRunStatusByID(ctx context.Context, runID string, status string, endedAt time.Time, totals *persistence.RunTotals) error
+	SetRunCancelReason(ctx context.Context, runID, reason, message string) error
 	// Stale run reconciliation
 	ListStaleRunningRuns(ctx context.Context, olderThan time.Time, limit int) ([]persistence.RunRecord, error)
 	ForceCompleteStaleRunTests(ctx context.Context, runID string, status string) error
@@ -91,9 +104,25 @@ type RunInfo struct {
 	TestIDs   map[string]uuid.UUID // Test name (lowercase) -> discovered test ID
 	// Environment secrets from project environment (for template resolution)
 	EnvSecrets map[string]string
+	// Environment slug the run targets (empty when no --env was given)
+	Environment string
+	// Tags of the tests the run runs
+	Tags []string
 	// Schedule linking for updating last_run_status on completion
 	ScheduleID   uuid.UUID // Schedule that triggered this run (if any)
 	ScheduleType string    // "project" or "suite" (if scheduled)
+	// Suite budget enforcement
+	BudgetTimer    *time.Timer // Fires at budget.max_duration; nil when there is none
+	BudgetExceeded string      // Why the budget stopped the run; empty while within budget
+	// Cancellation
+	CancelReason  string // CancelReason* constant; empty unless the run was stopped early
+	CancelMessage string // Human-readable detail for CancelReason
+	// Progress tracking
+	SuiteInitSteps     int                 // Steps in the suite init phase
+	SuiteInitStepsDone int                 // Suite init steps that have finished
+	PlannedTestSteps   map[string]int      // Test name (lowercase) -> init steps plus steps
+	StepHistoryMs      map[uuid.UUID]int64 // Test ID -> median step duration from recent runs
+	ObservedStepMs     []int64             // Durations of steps finished in this run
 }
 
 type LogLine struct {
@@ -102,6 +131,8 @@ type LogLine struct {
 	Bold     bool
 	TestName string
 	StepName string
+	Level    string            // Set by log steps: info, warn or error
+	Fields   map[string]string // Structured fields of log steps
 }
 
 type TestInfo struct {
@@ -112,15 +143,28 @@ type TestInfo struct {
 	EndedAt    time.Time
 	RunID      string
 	TestID     uuid.UUID // Resolved discovered test ID (for last_run updates)
+	Error      string    // Failure message for failed tests
+	// Steps that have finished, for run progress
+	CompletedSteps int
+	// A step has started, so cancelling the test interrupts it rather than skipping it
+	StepStarted bool
+	// The test is marked xfail: failing is expected and passing fails it
+	XFail bool
+	// Waiting holds a test that has depends_on until its dependencies finish, and
+	// it is started or skipped
+	Waiting *dsl.Test
 }
 
 // TestStatusCounts represents the count of tests in different states
 type TestStatusCounts struct {
-	Total    int
-	Passed   int
-	Failed   int
-	TimedOut int
-	Pending  int
+	Total       int
+	Passed      int
+	Failed      int
+	TimedOut    int
+	Pending     int
+	Skipped     int
+	Interrupted int
+	XFailed     int
 }
 
 // Enhanced data structures for persistence
@@ -157,4 +201,6 @@ const (
 	SearchAttrPassedTests  = "PassedTests"
 	SearchAttrFailedTests  = "FailedTests"
 	SearchAttrTimeoutTests = "TimeoutTests"
+	SearchAttrRunID        = "RunId"
+	SearchAttrEnvironment  = "Environment"
 )
@@ -0,0 +1,144 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/rocketship-ai/rocketship/internal/buildinfo"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	enumspb "go.temporal.io/api/enums/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// checkWorkerRequirements fails the run before any workflow starts when a connected
+// worker doesn't satisfy the suite's worker.min_version or worker.image. Temporal
+// hands tasks to any poller on the queue, so every worker has to qualify.
+func (e *Engine) checkWorkerRequirements(ctx context.Context, req *dsl.WorkerRequirements) error {
+	if req == nil || (req.MinVersion == "" && req.Image == "") {
+		return nil
+	}
+
+	resp, err := e.temporal.DescribeTaskQueue(ctx, "test-workflows", enumspb.TASK_QUEUE_TYPE_WORKFLOW)
+	if err != nil {
+		return fmt.Errorf("failed to check worker versions: %w", err)
+	}
+
+	pollers := resp.GetPollers()
+	if len(pollers) == 0 {
+		return status.Errorf(codes.FailedPrecondition, "suite requires %s, but no workers are connected", describeWorkerRequirements(req))
+	}
+
+	var mismatched []string
+	for _, poller := range pollers {
+		build, ok := buildinfo.ParseIdentity(poller.GetIdentity())
+		if !ok {
+			mismatched = append(mismatched, fmt.Sprintf("%s (unknown version)", poller.GetIdentity()))
+			continue
+		}
+		if reason := workerBuildMismatch(build, req); reason != "" {
+			mismatched = append(mismatched, reason)
+		}
+	}
+	if len(mismatched) > 0 {
+		slog.Debug("checkWorkerRequirements: workers do not satisfy suite requirements", "mismatched", mismatched)
+		return status.Errorf(codes.FailedPrecondition,
+			"suite requires %s, but %d of %d connected workers do not match: %s; upgrade the workers or relax the suite's worker settings",
+			describeWorkerRequirements(req), len(mismatched), len(pollers), strings.Join(mismatched, ", "))
+	}
+	return nil
+}
+
+// workerBuildMismatch returns why build doesn't satisfy req, or "" when it does
+func workerBuildMismatch(build buildinfo.WorkerBuild, req *dsl.WorkerRequirements) string {
+	if req.MinVersion != "" {
+		cmp, err := buildinfo.CompareVersions(build.Version, req.MinVersion)
+		if err != nil {
+			return fmt.Sprintf("%s (unparseable version)", build.Version)
+		}
+		if cmp < 0 {
+			return build.Version
+		}
+	}
+	if req.Image != "" && build.Image != req.Image {
+		if build.Image == "" {
+			return fmt.Sprintf("%s (no image reported)", build.Version)
+		}
+		return build.Image
+	}
+	return ""
+}
+
+func describeWorkerRequirements(req *dsl.WorkerRequirements) string {
+	var parts []string
+	if req.MinVersion != "" {
+		parts = append(parts, fmt.Sprintf("worker version >= %s", req.MinVersion))
+	}
+	if req.Image != "" {
+		parts = append(parts, fmt.Sprintf("worker image %s", req.Image))
+	}
+	return strings.Join(parts, " and ")
+}
+
+// checkWorkerCapabilities compares the plugins and DSL features a suite uses with
+// what connected workers advertise. A capability that no reporting worker has
+// fails the run up front; one that only some workers have is returned as a
+// warning, since steps that land on the other workers will fail. Workers that
+// predate capability reporting are given the benefit of the doubt.
+func (e *Engine) checkWorkerCapabilities(ctx context.Context, run dsl.RocketshipConfig) ([]string, error) {
+	requiredPlugins, requiredFeatures := dsl.RequiredCapabilities(run)
+
+	resp, err := e.temporal.DescribeTaskQueue(ctx, "test-workflows", enumspb.TASK_QUEUE_TYPE_WORKFLOW)
+	if err != nil {
+		slog.Warn("checkWorkerCapabilities: failed to describe task queue", "error", err)
+		return nil, nil
+	}
+
+	var reported []buildinfo.Capabilities
+	unknown := 0
+	for _, poller := range resp.GetPollers() {
+		caps, ok := buildinfo.ParseCapabilities(poller.GetIdentity())
+		if !ok {
+			unknown++
+			continue
+		}
+		reported = append(reported, caps)
+	}
+	if len(reported) == 0 {
+		return nil, nil
+	}
+	total := len(reported) + unknown
+
+	var missing, warnings []string
+	check := func(kind, name string, has func(buildinfo.Capabilities) []string) {
+		count := 0
+		for _, caps := range reported {
+			if containsString(has(caps), name) {
+				count++
+			}
+		}
+		switch {
+		case count == 0 && unknown == 0:
+			missing = append(missing, fmt.Sprintf("%s %q", kind, name))
+		case count == 0:
+			warnings = append(warnings, fmt.Sprintf("%s %q is not advertised by any connected worker; %d of %d workers don't report their capabilities and may still support it", kind, name, unknown, total))
+		case count < len(reported):
+			warnings = append(warnings, fmt.Sprintf("%s %q is missing on %d of %d connected workers; steps that run there will fail", kind, name, len(reported)-count, total))
+		}
+	}
+	for _, name := range requiredPlugins {
+		check("plugin", name, func(c buildinfo.Capabilities) []string { return c.Plugins })
+	}
+	for _, name := range requiredFeatures {
+		check("DSL feature", name, func(c buildinfo.Capabilities) []string { return c.Features })
+	}
+
+	if len(missing) > 0 {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"suite uses %s, which no connected worker supports; deploy workers that include them",
+			strings.Join(missing, ", "))
+	}
+	return warnings, nil
+}
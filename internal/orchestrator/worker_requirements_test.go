@@ -0,0 +1,103 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rocketship-ai/rocketship/internal/buildinfo"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/stretchr/testify/require"
+	enumspb "go.temporal.io/api/enums/v1"
+	taskqueuepb "go.temporal.io/api/taskqueue/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type taskQueueClient struct {
+	client.Client
+	identities []string
+}
+
+func (c *taskQueueClient) DescribeTaskQueue(_ context.Context, _ string, _ enumspb.TaskQueueType) (*workflowservice.DescribeTaskQueueResponse, error) {
+	resp := &workflowservice.DescribeTaskQueueResponse{}
+	for _, identity := range c.identities {
+		resp.Pollers = append(resp.Pollers, &taskqueuepb.PollerInfo{Identity: identity})
+	}
+	return resp, nil
+}
+
+func TestCheckWorkerRequirements(t *testing.T) {
+	current := buildinfo.WorkerBuild{Version: "v0.6.1", Image: "rocketshipai/rocketship-worker:v0.6.1"}.Identity("1@a")
+	old := buildinfo.WorkerBuild{Version: "v0.5.42"}.Identity("2@b")
+	ctx := context.Background()
+
+	engine := newTestEngineWithClient(&taskQueueClient{identities: []string{current}})
+	require.NoError(t, engine.checkWorkerRequirements(ctx, nil))
+	require.NoError(t, engine.checkWorkerRequirements(ctx, &dsl.WorkerRequirements{MinVersion: "v0.6.0"}))
+	require.NoError(t, engine.checkWorkerRequirements(ctx, &dsl.WorkerRequirements{Image: "rocketshipai/rocketship-worker:v0.6.1"}))
+
+	err := engine.checkWorkerRequirements(ctx, &dsl.WorkerRequirements{MinVersion: "v0.7.0"})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	require.Contains(t, err.Error(), "worker version >= v0.7.0")
+	require.Contains(t, err.Error(), "v0.6.1")
+
+	mixed := newTestEngineWithClient(&taskQueueClient{identities: []string{current, old, "3@c"}})
+	err = mixed.checkWorkerRequirements(ctx, &dsl.WorkerRequirements{MinVersion: "v0.6.0"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "2 of 3 connected workers")
+	require.Contains(t, err.Error(), "3@c (unknown version)")
+
+	empty := newTestEngineWithClient(&taskQueueClient{})
+	err = empty.checkWorkerRequirements(ctx, &dsl.WorkerRequirements{MinVersion: "v0.6.0"})
+	require.Contains(t, err.Error(), "no workers are connected")
+}
+
+func TestCheckWorkerCapabilities(t *testing.T) {
+	build := buildinfo.WorkerBuild{Version: "v0.6.1"}
+	full := buildinfo.Capabilities{Plugins: []string{"http", "sql"}, Features: dsl.SupportedFeatures}.AppendTo(build.Identity("1@a"))
+	httpOnly := buildinfo.Capabilities{Plugins: []string{"http"}, Features: dsl.SupportedFeatures}.AppendTo(build.Identity("2@b"))
+	legacy := build.Identity("3@c")
+	ctx := context.Background()
+
+	run := dsl.RocketshipConfig{Tests: []dsl.Test{{
+		Name: "orders",
+		Steps: []dsl.Step{
+			{Name: "create", Plugin: "http", Retry: &dsl.RetryPolicy{MaximumAttempts: 3}},
+			{Name: "verify", Plugin: "sql"},
+		},
+	}}}
+
+	engine := newTestEngineWithClient(&taskQueueClient{identities: []string{full}})
+	warnings, err := engine.checkWorkerCapabilities(ctx, run)
+	require.NoError(t, err)
+	require.Empty(t, warnings)
+
+	engine = newTestEngineWithClient(&taskQueueClient{identities: []string{httpOnly}})
+	_, err = engine.checkWorkerCapabilities(ctx, run)
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	require.Contains(t, err.Error(), `suite uses plugin "sql", which no connected worker supports`)
+
+	engine = newTestEngineWithClient(&taskQueueClient{identities: []string{full, httpOnly}})
+	warnings, err = engine.checkWorkerCapabilities(ctx, run)
+	require.NoError(t, err)
+	require.Equal(t, []string{`plugin "sql" is missing on 1 of 2 connected workers; steps that run there will fail`}, warnings)
+
+	engine = newTestEngineWithClient(&taskQueueClient{identities: []string{httpOnly, legacy}})
+	warnings, err = engine.checkWorkerCapabilities(ctx, run)
+	require.NoError(t, err)
+	require.Len(t, warnings, 1)
+	require.Contains(t, warnings[0], `plugin "sql" is not advertised by any connected worker`)
+
+	// Without any worker reporting capabilities there is nothing to check
+	engine = newTestEngineWithClient(&taskQueueClient{identities: []string{legacy}})
+	warnings, err = engine.checkWorkerCapabilities(ctx, run)
+	require.NoError(t, err)
+	require.Empty(t, warnings)
+
+	old := buildinfo.Capabilities{Plugins: []string{"http", "sql"}}.AppendTo(build.Identity("4@d"))
+	engine = newTestEngineWithClient(&taskQueueClient{identities: []string{old}})
+	_, err = engine.checkWorkerCapabilities(ctx, run)
+	require.Contains(t, err.Error(), `DSL feature "step_retry"`)
+}
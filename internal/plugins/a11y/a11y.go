@@ -0,0 +1,521 @@
+package a11y
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/artifacts"
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/browser/pw"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+	"go.temporal.io/sdk/activity"
+)
+
+const (
+	defaultTimeout     = 2 * time.Minute
+	defaultWaitTimeout = 10 * time.Second
+
+	// pollInterval is how often the plugin re-checks the page while it waits
+	pollInterval = 100 * time.Millisecond
+)
+
+// page is the part of pw.Tab the plugin uses
+type page interface {
+	Navigate(ctx context.Context, url string) error
+	Evaluate(ctx context.Context, expression string, out interface{}) error
+}
+
+// loadedScript reports whether axe-core is already in the page
+const loadedScript = `typeof axe !== 'undefined' && typeof axe.run === 'function'`
+
+// runScript audits the page with axe-core and returns its full results
+const runScript = `(async function(context, options) {
+  return await axe.run(context || document, options);
+})`
+
+// locationScript reads the page's address and load state
+const locationScript = `({url: location.href, ready: document.readyState})`
+
+// location is what locationScript reports
+type location struct {
+	URL   string `json:"url"`
+	Ready string `json:"ready"`
+}
+
+// axeResults is the part of axe.run's results the plugin summarises
+type axeResults struct {
+	URL        string            `json:"url"`
+	Passes     []json.RawMessage `json:"passes"`
+	Incomplete []json.RawMessage `json:"incomplete"`
+	Violations []struct {
+		ID          string   `json:"id"`
+		Impact      string   `json:"impact"`
+		Description string   `json:"description"`
+		Help        string   `json:"help"`
+		HelpURL     string   `json:"helpUrl"`
+		Tags        []string `json:"tags"`
+		Nodes       []struct {
+			Target         []interface{} `json:"target"`
+			HTML           string        `json:"html"`
+			FailureSummary string        `json:"failureSummary"`
+		} `json:"nodes"`
+	} `json:"violations"`
+}
+
+// Auto-register the plugin when the package is imported
+func init() {
+	plugins.RegisterPlugin(&A11yPlugin{})
+}
+
+// GetType returns the plugin type identifier
+func (ap *A11yPlugin) GetType() string {
+	return "a11y"
+}
+
+// Activity audits a page with axe-core and fails on violations at or above fail_on
+func (ap *A11yPlugin) Activity(ctx context.Context, p map[string]interface{}) (interface{}, error) {
+	logger := activity.GetLogger(ctx)
+
+	// Parse configuration from parameters
+	configData, ok := p["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid config format")
+	}
+
+	config := &A11yConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		return nil, fmt.Errorf("failed to parse a11y config: %w", err)
+	}
+
+	timeout := defaultTimeout
+	if config.Timeout != "" {
+		parsed, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", config.Timeout, err)
+		}
+		timeout = parsed
+	}
+
+	// Convert state to map[string]interface{} for template processing
+	state := make(map[string]interface{})
+	if stateStr, ok := p["state"].(map[string]string); ok {
+		for k, v := range stateStr {
+			state[k] = v
+		}
+	} else if stateInt, ok := p["state"].(map[string]interface{}); ok {
+		state = stateInt
+	}
+
+	// Add run metadata to state for template processing
+	runID := ""
+	if runData, ok := p["run"].(map[string]interface{}); ok {
+		state["run"] = runData
+		runID, _ = runData["id"].(string)
+	}
+
+	// Extract env secrets from params (for {{ .env.* }} template resolution)
+	env := make(map[string]string)
+	if envData, ok := p["env"].(map[string]interface{}); ok {
+		for k, v := range envData {
+			if strVal, ok := v.(string); ok {
+				env[k] = strVal
+			}
+		}
+	} else if envData, ok := p["env"].(map[string]string); ok {
+		env = envData
+	}
+
+	if err := applyVariableReplacement(config, state, env); err != nil {
+		return nil, fmt.Errorf("variable replacement failed: %w", err)
+	}
+
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	policy, err := egress.FromParams(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
+
+	stepName, _ := p["name"].(string)
+	logger.Info("Executing a11y plugin", "url", config.URL, "session_id", config.SessionID, "fail_on", config.FailOn)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	axe, err := loadAxe(ctx, axeSource(config.AxeScript), policy)
+	if err != nil {
+		return nil, err
+	}
+
+	headless := config.Headless == nil || *config.Headless
+	tab, err := pw.Open(ctx, pw.OpenOptions{
+		SessionID:  config.SessionID,
+		Executable: config.Executable,
+		Headless:   headless,
+		Policy:     policy,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer tab.Close()
+
+	response, err := execute(ctx, tab, config, axe, runID, stepName)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := assertions.Normalize(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare a11y result: %w", err)
+	}
+
+	assertionResults, err := assertions.Process(p, subject, dsl.TemplateContext{Runtime: state, Env: env}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if failure := assertions.Summary(assertionResults); failure != "" {
+		return nil, fmt.Errorf("%s", failure)
+	}
+
+	saved := make(map[string]string)
+	if err := saves.Process(p, subject, saved); err != nil {
+		return nil, err
+	}
+
+	logger.Info("A11y step completed", "violations", len(response.Violations), "failing", response.Failing, "report", response.Report, "duration", response.Duration)
+
+	return &ActivityResponse{
+		Response:         response,
+		Saved:            saved,
+		AssertionResults: assertionResults,
+	}, nil
+}
+
+// execute audits the page and saves the full report as a run artifact. The
+// error lists the violations at or above fail_on.
+func execute(ctx context.Context, pg page, config *A11yConfig, axe, runID, stepName string) (*A11yResponse, error) {
+	start := time.Now()
+
+	if config.URL != "" {
+		waitTimeout := defaultWaitTimeout
+		if config.WaitTimeout != "" {
+			waitTimeout, _ = time.ParseDuration(config.WaitTimeout)
+		}
+		if err := navigate(ctx, pg, config.URL, waitTimeout); err != nil {
+			return nil, err
+		}
+	}
+
+	var loaded bool
+	if err := pg.Evaluate(ctx, loadedScript, &loaded); err != nil {
+		return nil, fmt.Errorf("failed to check for axe-core: %w", err)
+	}
+	if !loaded {
+		// The trailing expression keeps the script's own result out of the reply
+		if err := pg.Evaluate(ctx, axe+"\n;void 0", nil); err != nil {
+			return nil, fmt.Errorf("failed to load axe-core: %w", err)
+		}
+	}
+
+	var report json.RawMessage
+	if err := pg.Evaluate(ctx, runScript+"("+axeContext(config)+", "+axeOptions(config)+")", &report); err != nil {
+		return nil, fmt.Errorf("accessibility audit failed: %w", err)
+	}
+	var results axeResults
+	if err := json.Unmarshal(report, &results); err != nil {
+		return nil, fmt.Errorf("failed to read axe-core results: %w", err)
+	}
+
+	name := stepName
+	if name == "" {
+		name = "a11y"
+	}
+	artifact, err := artifacts.Save(runID, name+"-a11y.json", "a11y-report", "application/json", report)
+	if err != nil {
+		return nil, err
+	}
+
+	failOn := config.FailOn
+	if failOn == "" {
+		failOn = ImpactMinor
+	}
+	response := &A11yResponse{
+		URL:        results.URL,
+		Violations: []Violation{},
+		Counts:     map[string]int{ImpactMinor: 0, ImpactModerate: 0, ImpactSerious: 0, ImpactCritical: 0},
+		Passes:     len(results.Passes),
+		Incomplete: len(results.Incomplete),
+		Report:     artifact.Path,
+	}
+	var failing []string
+	for _, v := range results.Violations {
+		impact := v.Impact
+		if _, ok := impactRank[impact]; !ok {
+			impact = ImpactMinor
+		}
+		violation := Violation{
+			ID:          v.ID,
+			Impact:      impact,
+			Description: v.Description,
+			Help:        v.Help,
+			HelpURL:     v.HelpURL,
+			Tags:        v.Tags,
+			Nodes:       make([]Node, 0, len(v.Nodes)),
+		}
+		for _, n := range v.Nodes {
+			violation.Nodes = append(violation.Nodes, Node{
+				Target:         formatTarget(n.Target),
+				HTML:           n.HTML,
+				FailureSummary: n.FailureSummary,
+			})
+		}
+		response.Violations = append(response.Violations, violation)
+		response.Counts[impact]++
+
+		if failOn != FailOnNone && impactRank[impact] >= impactRank[failOn] {
+			response.Failing++
+			failing = append(failing, fmt.Sprintf("%s (%s, %s)", v.ID, impact, plural(len(v.Nodes), "element")))
+		}
+	}
+	response.Duration = time.Since(start).String()
+
+	if response.Failing > 0 {
+		return nil, fmt.Errorf("%s at or above %s: %s; report saved to %s",
+			plural(response.Failing, "accessibility violation"), failOn, strings.Join(failing, ", "), artifact.Path)
+	}
+	return response, nil
+}
+
+// axeContext renders the include and exclude selectors as an axe-core context,
+// or null for the whole page
+func axeContext(config *A11yConfig) string {
+	if len(config.Include) == 0 && len(config.Exclude) == 0 {
+		return "null"
+	}
+	context := map[string]interface{}{}
+	if len(config.Include) > 0 {
+		context["include"] = config.Include
+	}
+	if len(config.Exclude) > 0 {
+		context["exclude"] = config.Exclude
+	}
+	data, _ := json.Marshal(context)
+	return string(data)
+}
+
+// axeOptions renders the rule selection as axe-core run options
+func axeOptions(config *A11yConfig) string {
+	options := map[string]interface{}{}
+	if len(config.Tags) > 0 {
+		options["runOnly"] = map[string]interface{}{"type": "tag", "values": config.Tags}
+	}
+	if len(config.DisableRules) > 0 {
+		rules := map[string]interface{}{}
+		for _, id := range config.DisableRules {
+			rules[id] = map[string]bool{"enabled": false}
+		}
+		options["rules"] = rules
+	}
+	data, _ := json.Marshal(options)
+	return string(data)
+}
+
+// formatTarget turns an axe-core target into one selector. Entries step into
+// frames, and nested lists step into shadow roots.
+func formatTarget(target []interface{}) string {
+	parts := make([]string, 0, len(target))
+	for _, entry := range target {
+		switch v := entry.(type) {
+		case string:
+			parts = append(parts, v)
+		case []interface{}:
+			shadow := make([]string, 0, len(v))
+			for _, s := range v {
+				shadow = append(shadow, fmt.Sprint(s))
+			}
+			parts = append(parts, strings.Join(shadow, " >>> "))
+		}
+	}
+	return strings.Join(parts, " >> ")
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// navigate loads url and waits for it to finish loading
+func navigate(ctx context.Context, pg page, url string, timeout time.Duration) error {
+	if err := pg.Navigate(ctx, url); err != nil {
+		return err
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	var lastErr error
+	for {
+		var loc location
+		err := pg.Evaluate(waitCtx, locationScript, &loc)
+		if err == nil && loc.Ready == "complete" {
+			return nil
+		}
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			lastErr = err
+		}
+		select {
+		case <-ticker.C:
+		case <-waitCtx.Done():
+			if lastErr != nil {
+				return fmt.Errorf("%s did not finish loading within %s (last error: %v)", url, timeout, lastErr)
+			}
+			return fmt.Errorf("%s did not finish loading within %s", url, timeout)
+		}
+	}
+}
+
+// validateConfig checks the settings once templates are rendered
+func validateConfig(config *A11yConfig) error {
+	if config.URL == "" && config.SessionID == "" {
+		return fmt.Errorf("url is required unless session_id is set")
+	}
+	if config.SessionID != "" && (config.Executable != "" || config.Headless != nil) {
+		return fmt.Errorf("executable and headless can't be used with session_id: the session's browser is already running")
+	}
+	switch config.FailOn {
+	case "", FailOnNone, ImpactMinor, ImpactModerate, ImpactSerious, ImpactCritical:
+	default:
+		return fmt.Errorf("fail_on must be minor, moderate, serious, critical or none, got %q", config.FailOn)
+	}
+	if config.WaitTimeout != "" {
+		if d, err := time.ParseDuration(config.WaitTimeout); err != nil || d <= 0 {
+			return fmt.Errorf("invalid wait_timeout %q: must be a positive duration", config.WaitTimeout)
+		}
+	}
+	lists := map[string][]string{
+		"include":       config.Include,
+		"exclude":       config.Exclude,
+		"tags":          config.Tags,
+		"disable_rules": config.DisableRules,
+	}
+	for name, list := range lists {
+		for i, entry := range list {
+			if strings.TrimSpace(entry) == "" {
+				return fmt.Errorf("%s[%d] is empty", name, i)
+			}
+		}
+	}
+	return nil
+}
+
+// applyVariableReplacement processes templates in the page, browser and
+// selector settings
+func applyVariableReplacement(config *A11yConfig, state map[string]interface{}, env map[string]string) error {
+	context := dsl.TemplateContext{
+		Runtime: state,
+		Env:     env,
+	}
+
+	render := func(name string, value *string) error {
+		if *value == "" {
+			return nil
+		}
+		processed, err := dsl.ProcessTemplate(*value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process %s template: %w", name, err)
+		}
+		*value = processed
+		return nil
+	}
+
+	fields := map[string]*string{
+		"url":        &config.URL,
+		"session_id": &config.SessionID,
+		"executable": &config.Executable,
+		"axe_script": &config.AxeScript,
+	}
+	for name, value := range fields {
+		if err := render(name, value); err != nil {
+			return err
+		}
+	}
+	lists := map[string][]string{
+		"include": config.Include,
+		"exclude": config.Exclude,
+	}
+	for name, list := range lists {
+		for i := range list {
+			if err := render(fmt.Sprintf("%s[%d]", name, i), &list[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseConfig converts map[string]interface{} to A11yConfig
+func parseConfig(configData map[string]interface{}, config *A11yConfig) error {
+	stringFields := map[string]*string{
+		"url":          &config.URL,
+		"session_id":   &config.SessionID,
+		"executable":   &config.Executable,
+		"fail_on":      &config.FailOn,
+		"axe_script":   &config.AxeScript,
+		"wait_timeout": &config.WaitTimeout,
+		"timeout":      &config.Timeout,
+	}
+	for key, target := range stringFields {
+		if v, ok := configData[key].(string); ok {
+			*target = v
+		}
+	}
+
+	switch v := configData["headless"].(type) {
+	case nil:
+	case bool:
+		config.Headless = &v
+	default:
+		return fmt.Errorf("headless must be a boolean, got %T", v)
+	}
+
+	listFields := map[string]*[]string{
+		"include":       &config.Include,
+		"exclude":       &config.Exclude,
+		"tags":          &config.Tags,
+		"disable_rules": &config.DisableRules,
+	}
+	for key, target := range listFields {
+		switch v := configData[key].(type) {
+		case nil:
+		case string:
+			// A single selector, tag or rule
+			*target = []string{v}
+		case []interface{}:
+			for i, item := range v {
+				s, ok := item.(string)
+				if !ok {
+					return fmt.Errorf("%s[%d] must be a string, got %T", key, i, item)
+				}
+				*target = append(*target, s)
+			}
+		case []string:
+			*target = append(*target, v...)
+		default:
+			return fmt.Errorf("%s must be a list of strings, got %T", key, v)
+		}
+	}
+
+	return nil
+}
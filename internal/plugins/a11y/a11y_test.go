@@ -0,0 +1,239 @@
+package a11y
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const fakeAxe = "window.axe = {run: function() {}};"
+
+// fakePage answers the plugin's scripts and returns results for every audit
+type fakePage struct {
+	url     string
+	loaded  bool
+	results map[string]interface{}
+	audits  []string
+}
+
+func (f *fakePage) Navigate(_ context.Context, url string) error {
+	f.url = url
+	return nil
+}
+
+func (f *fakePage) Evaluate(_ context.Context, expression string, out interface{}) error {
+	var result interface{}
+	switch {
+	case expression == locationScript:
+		result = map[string]interface{}{"url": f.url, "ready": "complete"}
+	case expression == loadedScript:
+		result = f.loaded
+	case expression == fakeAxe+"\n;void 0":
+		f.loaded = true
+		return nil
+	case strings.HasPrefix(expression, runScript+"("):
+		if !f.loaded {
+			return fmt.Errorf("script error: ReferenceError: axe is not defined")
+		}
+		f.audits = append(f.audits, strings.TrimPrefix(expression, runScript))
+		result = f.results
+	default:
+		return fmt.Errorf("unexpected expression %q", expression)
+	}
+	data, _ := json.Marshal(result)
+	return json.Unmarshal(data, out)
+}
+
+func violation(id, impact string, targets ...interface{}) map[string]interface{} {
+	nodes := []interface{}{}
+	for _, target := range targets {
+		nodes = append(nodes, map[string]interface{}{
+			"target":         target,
+			"html":           "<img src=\"logo.png\">",
+			"failureSummary": "Fix any of the following",
+		})
+	}
+	return map[string]interface{}{
+		"id":          id,
+		"impact":      impact,
+		"description": id + " description",
+		"help":        id + " help",
+		"helpUrl":     "https://dequeuniversity.com/rules/axe/4.10/" + id,
+		"tags":        []string{"wcag2a"},
+		"nodes":       nodes,
+	}
+}
+
+func newPage() *fakePage {
+	return &fakePage{results: map[string]interface{}{
+		"url":        "https://app.test/",
+		"passes":     []interface{}{map[string]interface{}{"id": "document-title"}},
+		"incomplete": []interface{}{},
+		"violations": []interface{}{
+			violation("image-alt", "critical", []interface{}{"img.logo"}),
+			violation("color-contrast", "serious", []interface{}{".muted"}, []interface{}{"iframe", []interface{}{"my-app", "button"}}),
+			violation("region", "moderate", []interface{}{"footer"}),
+		},
+	}}
+}
+
+func TestExecuteFailsOnImpact(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("ROCKETSHIP_ARTIFACTS_DIR", dir)
+	pg := newPage()
+
+	_, err := execute(context.Background(), pg, &A11yConfig{URL: "https://app.test/", FailOn: ImpactSerious}, fakeAxe, "run-1", "Home")
+	want := "2 accessibility violations at or above serious: image-alt (critical, 1 element), color-contrast (serious, 2 elements); report saved to " + filepath.Join(dir, "run-1", "Home-a11y.json")
+	if err == nil || err.Error() != want {
+		t.Fatalf("execute() error = %v\nwant %s", err, want)
+	}
+
+	report, err := os.ReadFile(filepath.Join(dir, "run-1", "Home-a11y.json"))
+	if err != nil || !strings.Contains(string(report), `"color-contrast"`) {
+		t.Fatalf("full report not saved: %v", err)
+	}
+}
+
+func TestExecuteReportsBelowFailOn(t *testing.T) {
+	t.Setenv("ROCKETSHIP_ARTIFACTS_DIR", t.TempDir())
+	pg := newPage()
+	pg.loaded = true
+	config := &A11yConfig{URL: "https://app.test/", FailOn: FailOnNone, Include: []string{"main"}, Tags: []string{"wcag2aa"}, DisableRules: []string{"region"}}
+
+	response, err := execute(context.Background(), pg, config, fakeAxe, "run-2", "Home")
+	if err != nil {
+		t.Fatalf("execute() error = %v", err)
+	}
+	if response.Failing != 0 || len(response.Violations) != 3 || response.Passes != 1 {
+		t.Fatalf("unexpected response: %+v", response)
+	}
+	if response.Counts[ImpactCritical] != 1 || response.Counts[ImpactSerious] != 1 || response.Counts[ImpactModerate] != 1 || response.Counts[ImpactMinor] != 0 {
+		t.Fatalf("unexpected counts: %v", response.Counts)
+	}
+	if got := response.Violations[1].Nodes[1].Target; got != "iframe >> my-app >>> button" {
+		t.Fatalf("target = %q", got)
+	}
+	wantArgs := `({"include":["main"]}, {"rules":{"region":{"enabled":false}},"runOnly":{"type":"tag","values":["wcag2aa"]}})`
+	if len(pg.audits) != 1 || pg.audits[0] != wantArgs {
+		t.Fatalf("audit args = %v, want %s", pg.audits, wantArgs)
+	}
+}
+
+func TestExecuteDefaultFailsOnAnyViolation(t *testing.T) {
+	t.Setenv("ROCKETSHIP_ARTIFACTS_DIR", t.TempDir())
+	pg := newPage()
+	pg.results["violations"] = []interface{}{violation("landmark-one-main", "minor", []interface{}{"html"})}
+
+	_, err := execute(context.Background(), pg, &A11yConfig{SessionID: "s"}, fakeAxe, "run-3", "Checkout")
+	if err == nil || !strings.HasPrefix(err.Error(), "1 accessibility violation at or above minor: landmark-one-main (minor, 1 element)") {
+		t.Fatalf("execute() error = %v", err)
+	}
+	if pg.url != "" {
+		t.Fatalf("session page was navigated to %q", pg.url)
+	}
+}
+
+func TestLoadAxe(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path != "/axe.min.js" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(fakeAxe))
+	}))
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		script, err := loadAxe(context.Background(), server.URL+"/axe.min.js", nil)
+		if err != nil || script != fakeAxe {
+			t.Fatalf("loadAxe() = %q, %v", script, err)
+		}
+	}
+	if requests.Load() != 1 {
+		t.Fatalf("axe-core downloaded %d times, want once", requests.Load())
+	}
+
+	if _, err := loadAxe(context.Background(), server.URL+"/missing.js", nil); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("missing script error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "axe.js")
+	if err := os.WriteFile(path, []byte(fakeAxe), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if script, err := loadAxe(context.Background(), path, nil); err != nil || script != fakeAxe {
+		t.Fatalf("loadAxe(file) = %q, %v", script, err)
+	}
+}
+
+func TestAxeSource(t *testing.T) {
+	t.Setenv(AxeScriptEnv, "")
+	if got := axeSource(""); got != DefaultAxeScript {
+		t.Fatalf("axeSource() = %q", got)
+	}
+	t.Setenv(AxeScriptEnv, "/opt/axe.min.js")
+	if got := axeSource(""); got != "/opt/axe.min.js" {
+		t.Fatalf("axeSource() = %q", got)
+	}
+	if got := axeSource("./axe.js"); got != "./axe.js" {
+		t.Fatalf("axeSource(configured) = %q", got)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	headless := true
+	cases := []struct {
+		name   string
+		config A11yConfig
+		want   string
+	}{
+		{"no page", A11yConfig{}, "url is required unless session_id is set"},
+		{"session and launch", A11yConfig{SessionID: "s", Headless: &headless}, "can't be used with session_id"},
+		{"fail_on", A11yConfig{URL: "https://app.test", FailOn: "high"}, "fail_on must be minor, moderate, serious, critical or none"},
+		{"empty include", A11yConfig{URL: "https://app.test", Include: []string{" "}}, "include[0] is empty"},
+		{"valid", A11yConfig{URL: "https://app.test", FailOn: "serious", Tags: []string{"wcag2aa"}}, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateConfig(&tc.config)
+			if tc.want == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	config := &A11yConfig{}
+	err := parseConfig(map[string]interface{}{
+		"url":     "https://app.test",
+		"include": "main",
+		"tags":    []interface{}{"wcag2a", "wcag2aa"},
+		"fail_on": "serious",
+	}, config)
+	if err != nil {
+		t.Fatalf("parseConfig() error = %v", err)
+	}
+	if len(config.Include) != 1 || config.Include[0] != "main" || len(config.Tags) != 2 || config.FailOn != "serious" {
+		t.Fatalf("unexpected config: %+v", config)
+	}
+
+	if err := parseConfig(map[string]interface{}{"tags": []interface{}{1}}, &A11yConfig{}); err == nil {
+		t.Fatal("expected an error for a non-string tag")
+	}
+}
@@ -0,0 +1,79 @@
+package a11y
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/rocketship-ai/rocketship/internal/egress"
+)
+
+const (
+	// AxeScriptEnv overrides the axe-core build the worker injects, as a file or URL
+	AxeScriptEnv = "ROCKETSHIP_AXE_SCRIPT"
+
+	// DefaultAxeScript is the pinned axe-core build used when nothing is configured
+	DefaultAxeScript = "https://cdn.jsdelivr.net/npm/axe-core@4.10.2/axe.min.js"
+
+	maxAxeScriptBytes = 10 << 20
+)
+
+// axeScripts caches loaded axe-core sources by file or URL, so the worker
+// downloads each build once
+var axeScripts sync.Map
+
+// axeSource resolves the configured axe-core file or URL
+func axeSource(configured string) string {
+	if configured != "" {
+		return configured
+	}
+	if env := os.Getenv(AxeScriptEnv); env != "" {
+		return env
+	}
+	return DefaultAxeScript
+}
+
+// loadAxe reads axe-core from a file on the worker, or downloads it through
+// the egress policy
+func loadAxe(ctx context.Context, source string, policy *egress.Policy) (string, error) {
+	if cached, ok := axeScripts.Load(source); ok {
+		return cached.(string), nil
+	}
+
+	var script []byte
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return "", fmt.Errorf("invalid axe_script URL %q: %w", source, err)
+		}
+		client := &http.Client{Transport: policy.Transport()}
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("failed to download axe-core from %s: %w", source, err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("failed to download axe-core from %s: %s", source, resp.Status)
+		}
+		script, err = io.ReadAll(io.LimitReader(resp.Body, maxAxeScriptBytes+1))
+		if err != nil {
+			return "", fmt.Errorf("failed to download axe-core from %s: %w", source, err)
+		}
+	} else {
+		var err error
+		script, err = os.ReadFile(source)
+		if err != nil {
+			return "", fmt.Errorf("failed to read axe-core: %w", err)
+		}
+	}
+	if len(script) > maxAxeScriptBytes {
+		return "", fmt.Errorf("axe-core script %s is larger than %d MB", source, maxAxeScriptBytes>>20)
+	}
+
+	axeScripts.Store(source, string(script))
+	return string(script), nil
+}
@@ -0,0 +1,84 @@
+package a11y
+
+import "github.com/rocketship-ai/rocketship/internal/assertions"
+
+// A11yPlugin represents an accessibility audit test step
+type A11yPlugin struct {
+	Name   string     `json:"name" yaml:"name"`
+	Plugin string     `json:"plugin" yaml:"plugin"`
+	Config A11yConfig `json:"config" yaml:"config"`
+}
+
+// A11yConfig selects the page to audit and which violations fail the step
+type A11yConfig struct {
+	URL          string   `json:"url,omitempty" yaml:"url,omitempty"`                     // Page to audit; the current page is used when empty
+	SessionID    string   `json:"session_id,omitempty" yaml:"session_id,omitempty"`       // Browser started by playwright role start; a new one is launched when empty
+	Executable   string   `json:"executable,omitempty" yaml:"executable,omitempty"`       // Chromium to launch (defaults to ROCKETSHIP_CHROME_PATH, then PATH)
+	Headless     *bool    `json:"headless,omitempty" yaml:"headless,omitempty"`           // Defaults to true
+	Include      []string `json:"include,omitempty" yaml:"include,omitempty"`             // Selectors to audit; the whole page when empty
+	Exclude      []string `json:"exclude,omitempty" yaml:"exclude,omitempty"`             // Selectors left out of the audit
+	Tags         []string `json:"tags,omitempty" yaml:"tags,omitempty"`                   // Run only rules with these tags, e.g. wcag2aa
+	DisableRules []string `json:"disable_rules,omitempty" yaml:"disable_rules,omitempty"` // Rule IDs to skip
+	FailOn       string   `json:"fail_on,omitempty" yaml:"fail_on,omitempty"`             // Lowest impact that fails the step (defaults to minor)
+	AxeScript    string   `json:"axe_script,omitempty" yaml:"axe_script,omitempty"`       // axe-core file or URL (defaults to ROCKETSHIP_AXE_SCRIPT, then the pinned CDN build)
+	WaitTimeout  string   `json:"wait_timeout,omitempty" yaml:"wait_timeout,omitempty"`   // How long to wait for the page to load (defaults to 10s)
+	Timeout      string   `json:"timeout,omitempty" yaml:"timeout,omitempty"`             // Overall step timeout (defaults to 2m)
+}
+
+// Impacts axe-core assigns to violations, lowest first
+const (
+	ImpactMinor    = "minor"
+	ImpactModerate = "moderate"
+	ImpactSerious  = "serious"
+	ImpactCritical = "critical"
+	// FailOnNone reports violations without failing the step
+	FailOnNone = "none"
+)
+
+// impactRank orders impacts so fail_on can compare them
+var impactRank = map[string]int{
+	ImpactMinor:    1,
+	ImpactModerate: 2,
+	ImpactSerious:  3,
+	ImpactCritical: 4,
+}
+
+// Violation is a rule the page breaks, with the elements that break it
+type Violation struct {
+	ID          string   `json:"id"`
+	Impact      string   `json:"impact"`
+	Description string   `json:"description"`
+	Help        string   `json:"help"`
+	HelpURL     string   `json:"help_url"`
+	Tags        []string `json:"tags"`
+	Nodes       []Node   `json:"nodes"`
+}
+
+// Node is an element that breaks a rule
+type Node struct {
+	Target         string `json:"target"` // Selector of the element
+	HTML           string `json:"html"`
+	FailureSummary string `json:"failure_summary"`
+}
+
+// A11yResponse summarises the audit
+type A11yResponse struct {
+	URL        string         `json:"url"`
+	Violations []Violation    `json:"violations"`
+	Counts     map[string]int `json:"counts"`     // Violations by impact
+	Failing    int            `json:"failing"`    // Violations at or above fail_on
+	Passes     int            `json:"passes"`     // Rules the page passes
+	Incomplete int            `json:"incomplete"` // Rules axe-core couldn't decide and that need a manual check
+	Report     string         `json:"report"`     // Path of the full axe-core report on the worker
+	Duration   string         `json:"duration"`
+}
+
+// AssertionResult represents a single assertion result for UI display
+type AssertionResult = assertions.Result
+
+// ActivityResponse represents the complete activity response
+type ActivityResponse struct {
+	Response         *A11yResponse     `json:"response"`
+	Saved            map[string]string `json:"saved"`
+	AssertionResults []AssertionResult `json:"assertion_results,omitempty"`
+}
@@ -5,15 +5,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"os/exec"
 	"strings"
 	"time"
 
-	"github.com/itchyny/gojq"
 	"github.com/rocketship-ai/rocketship/internal/browser/sessionfile"
 	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
 	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
 	"go.temporal.io/sdk/activity"
 )
 
@@ -72,6 +74,8 @@ func (ap *AgentPlugin) Activity(ctx context.Context, p map[string]interface{}) (
 	}
 
 	logger.Debug("Agent config resolved",
+		"provider", config.Provider,
+		"model", config.Model,
 		"mode", config.Mode,
 		"session_id", config.SessionID,
 		"capabilities", config.Capabilities,
@@ -102,6 +106,9 @@ Example: "save the heading as 'page_heading'" → {"ok": true, "result": "Found
 
 No code changing. No awaiting user input. If you need file writing as a scratchpad, write to .rocketship/tmp/agent-scratch/ directory.
 Clean up ONLY your own scratch files after you are done with the task.`
+	if config.OutputSchema != nil {
+		config.SystemPrompt += outputInstructions(config.OutputSchema)
+	}
 
 	// Configure default allowed tools
 	if len(config.AllowedTools) == 0 {
@@ -124,6 +131,14 @@ Clean up ONLY your own scratch files after you are done with the task.`
 		return nil, fmt.Errorf("session_id is required when mode is 'resume'")
 	}
 
+	policy, err := egress.FromParams(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
+	if err := checkBaseURL(ctx, policy, config.BaseURL); err != nil {
+		return nil, err
+	}
+
 	// Create context with timeout if specified, otherwise use unlimited
 	var timeoutCtx context.Context
 	var cancel context.CancelFunc
@@ -140,7 +155,7 @@ Clean up ONLY your own scratch files after you are done with the task.`
 	defer cancel()
 
 	// Execute agent
-	logMsg := fmt.Sprintf("Executing Claude agent (mode=%s", config.Mode)
+	logMsg := fmt.Sprintf("Executing agent (provider=%s, mode=%s", config.Provider, config.Mode)
 	if config.MaxTurns > 0 {
 		logMsg += fmt.Sprintf(", max_turns=%d", config.MaxTurns)
 	}
@@ -150,7 +165,7 @@ Clean up ONLY your own scratch files after you are done with the task.`
 	logMsg += ")"
 	logger.Info(logMsg)
 
-	result, err := ap.execute(timeoutCtx, config, stateInterface)
+	result, err := ap.execute(timeoutCtx, config, policy)
 	if err != nil {
 		return nil, fmt.Errorf("agent execution failed: %w", err)
 	}
@@ -160,12 +175,23 @@ Clean up ONLY your own scratch files after you are done with the task.`
 		if result.Response.Traceback != "" {
 			logger.Debug("Agent traceback", "traceback", result.Response.Traceback)
 		}
+		if len(result.Response.Metadata) > 0 {
+			logger.Info("Agent usage", "metadata", result.Response.Metadata)
+		}
 		if result.Response.Error != "" {
 			return nil, fmt.Errorf("agent execution failed: %s", result.Response.Error)
 		}
 		return nil, fmt.Errorf("agent execution failed with no error message")
 	}
 
+	// Check the structured output before anything is saved from it
+	if config.OutputSchema != nil {
+		if err := validateOutput(config.OutputSchema, result.Response.Output); err != nil {
+			logger.Debug("Agent output", "output", result.Response.Output)
+			return nil, err
+		}
+	}
+
 	// Process saves
 	saved := make(map[string]string)
 	if err := processSaves(p, result, saved); err != nil {
@@ -190,6 +216,10 @@ Clean up ONLY your own scratch files after you are done with the task.`
 		finalResult["error"] = result.Response.Error
 	}
 
+	if result.Response.Output != nil {
+		finalResult["output"] = result.Response.Output
+	}
+
 	if len(saved) > 0 {
 		finalResult["saved"] = saved
 	}
@@ -199,13 +229,34 @@ Clean up ONLY your own scratch files after you are done with the task.`
 		finalResult[key] = value
 	}
 
+	if err := processAssertions(p, finalResult, stateInterface, envSecrets); err != nil {
+		return nil, err
+	}
+
 	logger.Info(fmt.Sprintf("Agent execution completed successfully (duration=%s)", result.Duration))
 
 	return finalResult, nil
 }
 
-// execute runs the Python executor with the agent configuration
-func (ap *AgentPlugin) execute(ctx context.Context, cfg *Config, state map[string]interface{}) (*ExecutorResult, error) {
+// checkBaseURL fails the step before the executor starts when the egress policy
+// doesn't allow the provider's base_url
+func checkBaseURL(ctx context.Context, policy *egress.Policy, baseURL string) error {
+	if baseURL == "" {
+		return nil
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid base_url %q", baseURL)
+	}
+	if err := policy.Check(ctx, u.Host); err != nil {
+		return fmt.Errorf("base_url: %w", err)
+	}
+	return nil
+}
+
+// execute runs the Python executor with the agent configuration. With an egress
+// policy, the executor's traffic goes through a proxy enforcing it.
+func (ap *AgentPlugin) execute(ctx context.Context, cfg *Config, policy *egress.Policy) (*ExecutorResult, error) {
 	startTime := time.Now()
 
 	log.Printf("[DEBUG] Agent execute: starting Python executor (mode=%s, session_id=%s, capabilities=%v, timeout=%s)",
@@ -282,6 +333,14 @@ func (ap *AgentPlugin) execute(ctx context.Context, cfg *Config, state map[strin
 
 	cmd := exec.CommandContext(ctx, "python3", args...)
 	cmd.Env = append(os.Environ(), "PYTHONUNBUFFERED=1")
+	if policy != nil {
+		proxy, err := policy.StartProxy()
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = proxy.Close() }()
+		cmd.Env = proxy.Env(cmd.Env)
+	}
 
 	// Capture stdout and stderr separately
 	// Python executor logs to stderr, JSON response to stdout
@@ -497,7 +556,52 @@ func parseConfig(configData map[string]interface{}, templateContext dsl.Template
 	}
 
 
-	// Parse api_key with template processing, auto-detect from ANTHROPIC_API_KEY if not provided
+	// Parse provider settings with template processing, so they can come from env secrets
+	config.Provider = ProviderAnthropic
+	for field, target := range map[string]*string{
+		"provider": &config.Provider,
+		"model":    &config.Model,
+		"base_url": &config.BaseURL,
+		"region":   &config.Region,
+	} {
+		if value, ok := configData[field].(string); ok {
+			processed, err := dsl.ProcessTemplate(value, templateContext)
+			if err != nil {
+				return nil, fmt.Errorf("failed to process %s template: %w", field, err)
+			}
+			*target = processed
+		}
+	}
+
+	// Parse token and cost caps
+	if raw, ok := configData["max_tokens"]; ok {
+		switch val := raw.(type) {
+		case float64:
+			config.MaxTokens = int(val)
+		case int:
+			config.MaxTokens = val
+		default:
+			return nil, fmt.Errorf("invalid max_tokens: %v", raw)
+		}
+		if config.MaxTokens <= 0 {
+			return nil, fmt.Errorf("max_tokens must be positive, got %d", config.MaxTokens)
+		}
+	}
+	if raw, ok := configData["max_cost"]; ok {
+		switch val := raw.(type) {
+		case float64:
+			config.MaxCost = val
+		case int:
+			config.MaxCost = float64(val)
+		default:
+			return nil, fmt.Errorf("invalid max_cost: %v", raw)
+		}
+		if config.MaxCost <= 0 {
+			return nil, fmt.Errorf("max_cost must be positive, got %v", config.MaxCost)
+		}
+	}
+
+	// Parse api_key with template processing, auto-detect from the provider's env var if not provided
 	if apiKey, ok := configData["api_key"].(string); ok {
 		processed, err := dsl.ProcessTemplate(apiKey, templateContext)
 		if err != nil {
@@ -506,12 +610,59 @@ func parseConfig(configData map[string]interface{}, templateContext dsl.Template
 		config.APIKey = processed
 	} else {
 		// Auto-detect from environment if not provided
-		config.APIKey = os.Getenv("ANTHROPIC_API_KEY")
+		switch config.Provider {
+		case ProviderAnthropic:
+			config.APIKey = os.Getenv("ANTHROPIC_API_KEY")
+		case ProviderOpenAI, ProviderOpenAICompatible:
+			config.APIKey = os.Getenv("OPENAI_API_KEY")
+		}
+	}
+
+	// Parse output_schema and check it compiles
+	if raw, ok := configData["output_schema"]; ok {
+		schema, err := loadOutputSchema(raw)
+		if err != nil {
+			return nil, err
+		}
+		config.OutputSchema = schema
+	}
+
+	if err := validateProvider(config); err != nil {
+		return nil, err
 	}
 
 	return config, nil
 }
 
+// validateProvider checks the provider and the fields it needs. Bedrock
+// credentials are checked by the Claude CLI, since they come from the AWS
+// credential chain.
+func validateProvider(config *Config) error {
+	switch config.Provider {
+	case ProviderAnthropic, ProviderBedrock:
+	case ProviderOpenAI, ProviderOpenAICompatible:
+		// The executor runs its own tool loop for these, which keeps no session
+		if config.Mode == ModeContinue || config.Mode == ModeResume {
+			return fmt.Errorf("mode %q needs the anthropic or bedrock provider, %s runs single prompts only", config.Mode, config.Provider)
+		}
+		if config.MaxCost > 0 {
+			return fmt.Errorf("max_cost needs the anthropic or bedrock provider, which report costs; use max_tokens with %s", config.Provider)
+		}
+		if config.Provider == ProviderOpenAICompatible && (config.BaseURL == "" || config.Model == "") {
+			return fmt.Errorf("base_url and model are required with openai_compatible")
+		}
+	default:
+		return fmt.Errorf("unsupported provider %q: must be anthropic, bedrock, openai or openai_compatible", config.Provider)
+	}
+	if config.BaseURL != "" && config.Provider != ProviderAnthropic && config.Provider != ProviderOpenAICompatible {
+		return fmt.Errorf("base_url can only be used with anthropic and openai_compatible")
+	}
+	if config.Region != "" && config.Provider != ProviderBedrock {
+		return fmt.Errorf("region can only be used with bedrock")
+	}
+	return nil
+}
+
 // parseMCPServerConfig parses an MCP server configuration
 func parseMCPServerConfig(serverData map[string]interface{}, templateContext dsl.TemplateContext) (*MCPServerConfig, error) {
 	config := &MCPServerConfig{}
@@ -609,13 +760,13 @@ func processSaves(p map[string]interface{}, execResult *ExecutorResult, saved ma
 	}
 
 	// Second: Process explicit save configurations (can override auto-saved variables)
-	saves, ok := p["save"].([]interface{})
+	saveConfigs, ok := p["save"].([]interface{})
 	if !ok {
 		return nil
 	}
 
-	log.Printf("[DEBUG] Processing %d explicit save configs", len(saves))
-	for _, save := range saves {
+	log.Printf("[DEBUG] Processing %d explicit save configs", len(saveConfigs))
+	for _, save := range saveConfigs {
 		saveMap, ok := save.(map[string]interface{})
 		if !ok {
 			return fmt.Errorf("invalid save format: got type %T", save)
@@ -644,6 +795,11 @@ func processSaves(p map[string]interface{}, execResult *ExecutorResult, saved ma
 				"success":    execResult.Response.Success,
 			}
 
+			// Add structured output if the agent returned one
+			if execResult.Response.Output != nil {
+				agentResult["output"] = execResult.Response.Output
+			}
+
 			// Add error if present
 			if execResult.Response.Error != "" {
 				agentResult["error"] = execResult.Response.Error
@@ -654,45 +810,26 @@ func processSaves(p map[string]interface{}, execResult *ExecutorResult, saved ma
 				agentResult[key] = value
 			}
 
-			query, err := gojq.Parse(jsonPath)
+			v, found, err := saves.Extract(jsonPath, agentResult)
 			if err != nil {
-				return fmt.Errorf("failed to parse jq expression %q: %w", jsonPath, err)
+				return fmt.Errorf("error evaluating jq expression %q: %w", jsonPath, err)
 			}
-
-			iter := query.Run(agentResult)
-			v, ok := iter.Next()
-			if !ok {
+			if !found {
 				if required {
 					return fmt.Errorf("no results from required jq expression %q", jsonPath)
 				}
 				log.Printf("[WARN] No results from optional jq expression %q, skipping save", jsonPath)
 				continue
 			}
-			if err, ok := v.(error); ok {
-				return fmt.Errorf("error evaluating jq expression %q: %w", jsonPath, err)
+			if v == nil && required {
+				return fmt.Errorf("required value for %q is null", as)
 			}
 
-			// Handle different value types
-			switch val := v.(type) {
-			case string:
-				saved[as] = val
-			case float64:
-				saved[as] = fmt.Sprintf("%.0f", val)
-			case bool:
-				saved[as] = fmt.Sprintf("%t", val)
-			case nil:
-				if required {
-					return fmt.Errorf("required value for %q is null", as)
-				}
-				saved[as] = ""
-			default:
-				// For complex types, use JSON marshaling
-				bytes, err := json.Marshal(val)
-				if err != nil {
-					return fmt.Errorf("failed to marshal value for %q: %w", as, err)
-				}
-				saved[as] = string(bytes)
+			value, err := saves.Format(v, saves.Type(saveMap))
+			if err != nil {
+				return fmt.Errorf("failed to save %q: %w", as, err)
 			}
+			saved[as] = value
 
 			log.Printf("[DEBUG] Saved value for %s: %s (type: %T)", as, saved[as], v)
 		}
@@ -1,14 +1,21 @@
 #!/usr/bin/env python3
 """
-Claude Agent SDK executor for Rocketship.
+Agent executor for Rocketship.
 Supports MCP servers, sessions, and structured output.
 
+The anthropic and bedrock providers run the Claude Agent SDK. The openai and
+openai_compatible providers run a tool loop over the chat completions API,
+with the same MCP servers.
+
 Requirements:
-- claude-agent-sdk >= 0.1.0
+- claude-agent-sdk >= 0.1.0 (anthropic, bedrock)
 - Claude Code >= v2.0.0 (recommended)
+- openai and mcp (openai, openai_compatible)
 """
 import argparse
 import asyncio
+import contextlib
+import dataclasses
 import json
 import logging
 import os
@@ -26,11 +33,14 @@ logging.basicConfig(
 
 logger = logging.getLogger(__name__)
 
+CLAUDE_PROVIDERS = ("anthropic", "bedrock")
+
 try:
-    from claude_agent_sdk import ClaudeSDKClient, ClaudeAgentOptions, AssistantMessage, TextBlock
+    from claude_agent_sdk import ClaudeSDKClient, ClaudeAgentOptions, AssistantMessage, ResultMessage, TextBlock
+    _claude_import_error = None
 except ImportError as exc:
-    sys.stdout.write(json.dumps({"ok": False, "error": f"claude-agent-sdk not available: {exc}. Install with: pip install claude-agent-sdk"}) + "\n")
-    sys.exit(1)
+    # Only the anthropic and bedrock providers need the SDK
+    _claude_import_error = exc
 
 
 def _write(payload: dict) -> None:
@@ -39,7 +49,7 @@ def _write(payload: dict) -> None:
     sys.stdout.flush()
 
 
-def _check_versions() -> Optional[dict]:
+def _check_versions(provider: str) -> Optional[dict]:
     """
     Check required package versions. Returns error dict if versions insufficient, None if OK.
 
@@ -47,9 +57,17 @@ def _check_versions() -> Optional[dict]:
     """
     from importlib.metadata import version, PackageNotFoundError
 
-    required = {
-        "claude-agent-sdk": "0.1.0",  # Minimum version for MCP support
-    }
+    if provider in CLAUDE_PROVIDERS:
+        if _claude_import_error is not None:
+            return {"ok": False, "error": f"claude-agent-sdk not available: {_claude_import_error}. Install with: pip install claude-agent-sdk"}
+        required = {
+            "claude-agent-sdk": "0.1.0",  # Minimum version for MCP support
+        }
+    else:
+        required = {
+            "openai": "1.0.0",  # Minimum version for the async client
+            "mcp": "1.0.0",
+        }
 
     for package, min_version in required.items():
         try:
@@ -66,7 +84,7 @@ def _check_versions() -> Optional[dict]:
         except PackageNotFoundError:
             return {
                 "ok": False,
-                "error": f"{package} not installed (version {min_version}+ required). Install with: pip install claude-agent-sdk"
+                "error": f"{package} not installed (version {min_version}+ required). Install with: pip install {package}"
             }
         except (ValueError, AttributeError):
             # Can't parse version, allow it through
@@ -77,7 +95,7 @@ def _check_versions() -> Optional[dict]:
 
 def _extract_text_content(message: Any) -> str:
     """Extract text content from a Claude message"""
-    if isinstance(message, AssistantMessage):
+    if _claude_import_error is None and isinstance(message, AssistantMessage):
         text_parts = []
         for block in message.content:
             if isinstance(block, TextBlock):
@@ -157,7 +175,8 @@ def _parse_agent_result(response_text: str) -> dict:
                 result = {
                     "ok": parsed.get("ok", parsed.get("success", True)),
                     "result": parsed.get("result", parsed.get("message", response_text)),
-                    "error": parsed.get("error", "")
+                    "error": parsed.get("error", ""),
+                    "output": parsed.get("output")
                 }
                 return result
     except (json.JSONDecodeError, ValueError):
@@ -172,6 +191,61 @@ def _parse_agent_result(response_text: str) -> dict:
     }
 
 
+def _new_usage(config: Dict[str, Any]) -> Dict[str, Any]:
+    """Usage of one step, returned as step metadata"""
+    return {
+        "provider": config.get("provider", "anthropic"),
+        "model": config.get("model", ""),
+        "input_tokens": 0,
+        "output_tokens": 0,
+        "total_tokens": 0,
+        "cost_usd": None,
+        "turns": 0,
+    }
+
+
+def _add_claude_usage(usage: Dict[str, Any], message: Any) -> None:
+    """Record the usage the Claude SDK reports in its final message"""
+    tokens = getattr(message, "usage", None) or {}
+    usage["input_tokens"] = sum(int(tokens.get(key) or 0) for key in ("input_tokens", "cache_creation_input_tokens", "cache_read_input_tokens"))
+    usage["output_tokens"] = int(tokens.get("output_tokens") or 0)
+    usage["total_tokens"] = usage["input_tokens"] + usage["output_tokens"]
+    usage["turns"] = int(getattr(message, "num_turns", 0) or 0)
+    cost = getattr(message, "total_cost_usd", None)
+    if cost is not None:
+        usage["cost_usd"] = float(cost)
+
+
+def _over_budget(usage: Dict[str, Any], config: Dict[str, Any]) -> Optional[str]:
+    """The cap the usage exceeds, described for the step's error"""
+    max_tokens = config.get("max_tokens") or 0
+    max_cost = config.get("max_cost") or 0
+    if max_tokens and usage["total_tokens"] > max_tokens:
+        return f"token budget exceeded: used {usage['total_tokens']} tokens, max_tokens is {max_tokens}"
+    if max_cost and usage["cost_usd"] is not None and usage["cost_usd"] > max_cost:
+        return f"cost budget exceeded: used ${usage['cost_usd']:.4f}, max_cost is ${max_cost:.4f}"
+    return None
+
+
+def _metadata(usage: Dict[str, Any]) -> Dict[str, str]:
+    """Step metadata values are strings"""
+    metadata = {}
+    for key, value in usage.items():
+        if value is None or value == "":
+            continue
+        metadata[key] = f"{value:.6f}".rstrip("0").rstrip(".") if isinstance(value, float) else str(value)
+    return metadata
+
+
+def _finish(payload: Dict[str, Any], usage: Dict[str, Any], config: Dict[str, Any]) -> None:
+    """Write the result with its usage, failing it when the usage is over a cap"""
+    budget_error = _over_budget(usage, config)
+    if budget_error:
+        payload = {"ok": False, "error": budget_error, "mode": payload.get("mode")}
+    payload["metadata"] = _metadata(usage)
+    _write(payload)
+
+
 async def _execute_agent_impl(config: Dict[str, Any]) -> None:
     """
     Execute Claude agent with the given configuration.
@@ -241,12 +315,24 @@ async def _execute_agent_impl(config: Dict[str, Any]) -> None:
     if config.get("cwd"):
         options_kwargs["cwd"] = config["cwd"]
 
+    if config.get("model"):
+        options_kwargs["model"] = config["model"]
+
+    if config.get("max_turns"):
+        options_kwargs["max_turns"] = config["max_turns"]
+
+    # Newer SDKs stop the agent once it spends the budget; older ones are
+    # checked against the cost they report when the agent is done
+    if config.get("max_cost") and "max_budget_usd" in {f.name for f in dataclasses.fields(ClaudeAgentOptions)}:
+        options_kwargs["max_budget_usd"] = config["max_cost"]
+
     # Create options
     options = ClaudeAgentOptions(**options_kwargs) if options_kwargs else None
 
     # Execute based on mode
     mode = config.get("mode", "single")
     prompt = config["prompt"]
+    usage = _new_usage(config)
 
     try:
         if mode == "single":
@@ -257,6 +343,8 @@ async def _execute_agent_impl(config: Dict[str, Any]) -> None:
             response_texts = []
 
             async for message in query(prompt=prompt, options=options):
+                if isinstance(message, ResultMessage):
+                    _add_claude_usage(usage, message)
                 text = _extract_text_content(message)
                 if text:
                     response_texts.append(text)
@@ -268,12 +356,13 @@ async def _execute_agent_impl(config: Dict[str, Any]) -> None:
             # If agent returns {"ok": false, ...}, respect that to fail the test
             agent_result = _parse_agent_result(final_response)
 
-            _write({
+            _finish({
                 "ok": agent_result.get("ok", True),
                 "result": agent_result.get("result", final_response),
                 "error": agent_result.get("error", ""),
+                "output": agent_result.get("output"),
                 "mode": "single"
-            })
+            }, usage, config)
 
         elif mode in ["continue", "resume"]:
             # Session-based execution using ClaudeSDKClient
@@ -296,6 +385,8 @@ async def _execute_agent_impl(config: Dict[str, Any]) -> None:
                 # Receive and collect responses
                 response_texts = []
                 async for message in client.receive_response():
+                    if isinstance(message, ResultMessage):
+                        _add_claude_usage(usage, message)
                     text = _extract_text_content(message)
                     if text:
                         response_texts.append(text)
@@ -306,13 +397,14 @@ async def _execute_agent_impl(config: Dict[str, Any]) -> None:
                 # Try to parse agent response as JSON to check for test assertions
                 agent_result = _parse_agent_result(final_response)
 
-                _write({
+                _finish({
                     "ok": agent_result.get("ok", True),
                     "result": agent_result.get("result", final_response),
                     "error": agent_result.get("error", ""),
+                    "output": agent_result.get("output"),
                     "session_id": client.session_id,
                     "mode": mode
-                })
+                }, usage, config)
 
         else:
             _write({"ok": False, "error": f"Unsupported mode: {mode}"})
@@ -328,6 +420,146 @@ async def _execute_agent_impl(config: Dict[str, Any]) -> None:
         })
 
 
+def _tool_text(result: Any) -> str:
+    """The text of an MCP tool result, for the model to read"""
+    parts = []
+    for block in getattr(result, "content", None) or []:
+        if getattr(block, "type", "") == "text":
+            parts.append(block.text)
+        else:
+            parts.append(f"[{getattr(block, 'type', 'content')} omitted]")
+    text = "\n".join(parts) or "(no output)"
+    if getattr(result, "isError", False):
+        text = "Error: " + text
+    return text
+
+
+async def _execute_openai_agent_impl(config: Dict[str, Any]) -> None:
+    """
+    Run the agent with an OpenAI chat model, calling the MCP servers' tools
+    until the model answers without calling any.
+    """
+    from openai import AsyncOpenAI
+    from mcp import ClientSession, StdioServerParameters
+    from mcp.client.sse import sse_client
+    from mcp.client.stdio import stdio_client
+
+    provider = config.get("provider")
+    model = config.get("model") or "gpt-4o"
+    usage = _new_usage({**config, "model": model})
+    # Local servers such as Ollama, vLLM and LM Studio usually ignore the key
+    client = AsyncOpenAI(api_key=config.get("api_key") or "not-needed", base_url=config.get("base_url") or None)
+    logger.info(f"Executing agent with provider {provider}, model {model}")
+
+    allowed_tools = config.get("allowed_tools") or []
+    wildcard = allowed_tools in (["*"], "*")
+
+    try:
+        async with contextlib.AsyncExitStack() as stack:
+            # Tools are named like Claude's, mcp__<server>__<tool>, so
+            # allowed_tools means the same for every provider
+            tools, sessions = [], {}
+            for name, server in (config.get("mcp_servers") or {}).items():
+                if server.get("type", "stdio") == "stdio":
+                    params = StdioServerParameters(
+                        command=server["command"],
+                        args=server.get("args", []),
+                        env={**os.environ, **(server.get("env") or {})},
+                    )
+                    read, write = await stack.enter_async_context(stdio_client(params))
+                else:
+                    read, write = await stack.enter_async_context(sse_client(server["url"], headers=server.get("headers") or {}))
+                session = await stack.enter_async_context(ClientSession(read, write))
+                await session.initialize()
+                for tool in (await session.list_tools()).tools:
+                    tool_name = f"mcp__{name}__{tool.name}"[:64]
+                    if not wildcard and tool_name not in allowed_tools:
+                        continue
+                    tools.append({
+                        "type": "function",
+                        "function": {
+                            "name": tool_name,
+                            "description": tool.description or "",
+                            "parameters": tool.inputSchema or {"type": "object", "properties": {}},
+                        },
+                    })
+                    sessions[tool_name] = (session, tool.name)
+            logger.info(f"Agent has {len(tools)} tool(s)")
+
+            messages = [{"role": "user", "content": config["prompt"]}]
+            if config.get("system_prompt"):
+                messages.insert(0, {"role": "system", "content": config["system_prompt"]})
+
+            max_turns = config.get("max_turns") or 0
+            while True:
+                if max_turns and usage["turns"] >= max_turns:
+                    _finish({"ok": False, "error": f"agent did not finish within max_turns ({max_turns})", "mode": "single"}, usage, config)
+                    return
+                usage["turns"] += 1
+
+                kwargs = {"model": model, "messages": messages}
+                if tools:
+                    kwargs["tools"] = tools
+                completion = await client.chat.completions.create(**kwargs)
+                if completion.usage is not None:
+                    usage["input_tokens"] += completion.usage.prompt_tokens or 0
+                    usage["output_tokens"] += completion.usage.completion_tokens or 0
+                    usage["total_tokens"] = usage["input_tokens"] + usage["output_tokens"]
+
+                # The cap is checked every turn, so a wandering agent stops early
+                budget_error = _over_budget(usage, config)
+                if budget_error:
+                    logger.warning(f"Stopping agent: {budget_error}")
+                    _finish({"ok": False, "mode": "single"}, usage, config)
+                    return
+
+                message = completion.choices[0].message
+                if not message.tool_calls:
+                    break
+                messages.append({
+                    "role": "assistant",
+                    "content": message.content or "",
+                    "tool_calls": [
+                        {"id": call.id, "type": "function", "function": {"name": call.function.name, "arguments": call.function.arguments}}
+                        for call in message.tool_calls
+                    ],
+                })
+
+                for call in message.tool_calls:
+                    target = sessions.get(call.function.name)
+                    if target is None:
+                        text = f"Error: unknown tool {call.function.name}"
+                    else:
+                        try:
+                            arguments = json.loads(call.function.arguments or "{}")
+                            text = _tool_text(await target[0].call_tool(target[1], arguments))
+                        except Exception as exc:
+                            text = f"Error: {exc}"
+                    logger.debug(f"Tool {call.function.name}: {text[:100]}...")
+                    messages.append({"role": "tool", "tool_call_id": call.id, "content": text})
+
+            final_response = message.content or ""
+            agent_result = _parse_agent_result(final_response)
+            _finish({
+                "ok": agent_result.get("ok", True),
+                "result": agent_result.get("result", final_response),
+                "error": agent_result.get("error", ""),
+                "output": agent_result.get("output"),
+                "mode": "single"
+            }, usage, config)
+
+    except Exception as exc:
+        logger.error(f"Agent execution failed: {exc}")
+        tb = traceback.format_exc()
+        logger.error(f"Traceback:\n{tb}")
+        _write({
+            "ok": False,
+            "error": str(exc),
+            "traceback": tb,
+            "metadata": _metadata(usage)
+        })
+
+
 async def _execute_agent(config: Dict[str, Any]) -> None:
     """
     Execute Claude agent with timeout handling.
@@ -348,13 +580,15 @@ async def _execute_agent(config: Dict[str, Any]) -> None:
             })
             return
 
+    impl = _execute_agent_impl if config.get("provider", "anthropic") in CLAUDE_PROVIDERS else _execute_openai_agent_impl
+
     # Execute with timeout if specified
     try:
         if timeout_seconds is not None:
-            await asyncio.wait_for(_execute_agent_impl(config), timeout=timeout_seconds)
+            await asyncio.wait_for(impl(config), timeout=timeout_seconds)
         else:
             # No timeout - run without limit
-            await _execute_agent_impl(config)
+            await impl(config)
 
     except asyncio.TimeoutError:
         error_msg = f"Agent execution timed out after {timeout_seconds}s"
@@ -383,12 +617,6 @@ async def main():
     parser.add_argument("--config-json", required=True, help="JSON configuration for agent execution")
     args = parser.parse_args()
 
-    # Check versions first
-    version_error = _check_versions()
-    if version_error:
-        _write(version_error)
-        return
-
     # Parse configuration first
     try:
         config = json.loads(args.config_json)
@@ -396,14 +624,40 @@ async def main():
         _write({"ok": False, "error": f"Invalid JSON configuration: {exc}"})
         return
 
-    # Check for ANTHROPIC_API_KEY - use config value if provided, otherwise fall back to environment
-    api_key = config.get("api_key") or os.environ.get("ANTHROPIC_API_KEY")
-    if not api_key:
-        _write({"ok": False, "error": "api_key is required (either in config or ANTHROPIC_API_KEY environment variable)"})
+    provider = config.get("provider", "anthropic")
+
+    # Check versions for the provider's packages
+    version_error = _check_versions(provider)
+    if version_error:
+        _write(version_error)
         return
 
-    # Set the API key in environment for the Anthropic SDK
-    os.environ["ANTHROPIC_API_KEY"] = api_key
+    if provider == "anthropic":
+        # Check for ANTHROPIC_API_KEY - use config value if provided, otherwise fall back to environment
+        api_key = config.get("api_key") or os.environ.get("ANTHROPIC_API_KEY")
+        if not api_key:
+            _write({"ok": False, "error": "api_key is required (either in config or ANTHROPIC_API_KEY environment variable)"})
+            return
+
+        # Set the API key in environment for the Anthropic SDK
+        os.environ["ANTHROPIC_API_KEY"] = api_key
+        if config.get("base_url"):
+            os.environ["ANTHROPIC_BASE_URL"] = config["base_url"]
+
+    elif provider == "bedrock":
+        # The Claude CLI calls Bedrock with the worker's AWS credential chain
+        os.environ["CLAUDE_CODE_USE_BEDROCK"] = "1"
+        if config.get("region"):
+            os.environ["AWS_REGION"] = config["region"]
+        if not os.environ.get("AWS_REGION"):
+            _write({"ok": False, "error": "region is required for bedrock (either in config or AWS_REGION environment variable)"})
+            return
+
+    elif provider == "openai":
+        config["api_key"] = config.get("api_key") or os.environ.get("OPENAI_API_KEY")
+        if not config["api_key"]:
+            _write({"ok": False, "error": "api_key is required (either in config or OPENAI_API_KEY environment variable)"})
+            return
 
     # Validate required fields
     if "prompt" not in config:
@@ -1,10 +1,15 @@
 package agent
 
 import (
+	"context"
 	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
 )
 
 func TestParseConfig(t *testing.T) {
@@ -124,6 +129,50 @@ func TestParseConfig(t *testing.T) {
 			expectError: true,
 			expected:    nil,
 		},
+		{
+			name: "bedrock with a model override and caps",
+			configData: map[string]interface{}{
+				"prompt":     "Test prompt",
+				"provider":   "bedrock",
+				"model":      "{{ .env.BEDROCK_MODEL }}",
+				"region":     "eu-west-1",
+				"max_tokens": 50000.0,
+				"max_cost":   1.5,
+			},
+			expectError: false,
+			expected: &Config{
+				Prompt:    "Test prompt",
+				Provider:  ProviderBedrock,
+				Model:     "eu.anthropic.claude-sonnet-4-5-v1:0",
+				Region:    "eu-west-1",
+				MaxTokens: 50000,
+				MaxCost:   1.5,
+			},
+		},
+		{
+			name: "local model",
+			configData: map[string]interface{}{
+				"prompt":   "Test prompt",
+				"provider": "openai_compatible",
+				"model":    "qwen2.5:14b",
+				"base_url": "http://localhost:11434/v1",
+			},
+			expectError: false,
+			expected: &Config{
+				Prompt:   "Test prompt",
+				Provider: ProviderOpenAICompatible,
+				Model:    "qwen2.5:14b",
+				BaseURL:  "http://localhost:11434/v1",
+			},
+		},
+		{
+			name: "max_tokens must be positive",
+			configData: map[string]interface{}{
+				"prompt":     "Test prompt",
+				"max_tokens": 0,
+			},
+			expectError: true,
+		},
 		{
 			name: "max_turns as float64",
 			configData: map[string]interface{}{
@@ -142,6 +191,7 @@ func TestParseConfig(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			templateContext := dsl.TemplateContext{
 				Runtime: make(map[string]interface{}),
+				Env:     map[string]string{"BEDROCK_MODEL": "eu.anthropic.claude-sonnet-4-5-v1:0"},
 			}
 
 			config, err := parseConfig(tt.configData, templateContext)
@@ -181,6 +231,22 @@ func TestParseConfig(t *testing.T) {
 
 				// SystemPrompt is always set by the framework, not user-configurable
 
+				expectedProvider := tt.expected.Provider
+				if expectedProvider == "" {
+					expectedProvider = ProviderAnthropic
+				}
+				if config.Provider != expectedProvider {
+					t.Errorf("Expected provider %q, got %q", expectedProvider, config.Provider)
+				}
+				if config.Model != tt.expected.Model || config.BaseURL != tt.expected.BaseURL || config.Region != tt.expected.Region {
+					t.Errorf("Expected model %q, base_url %q and region %q, got %q, %q and %q",
+						tt.expected.Model, tt.expected.BaseURL, tt.expected.Region, config.Model, config.BaseURL, config.Region)
+				}
+				if config.MaxTokens != tt.expected.MaxTokens || config.MaxCost != tt.expected.MaxCost {
+					t.Errorf("Expected max_tokens %d and max_cost %v, got %d and %v",
+						tt.expected.MaxTokens, tt.expected.MaxCost, config.MaxTokens, config.MaxCost)
+				}
+
 				if tt.expected.Cwd != "" && config.Cwd != tt.expected.Cwd {
 					t.Errorf("Expected cwd %q, got %q", tt.expected.Cwd, config.Cwd)
 				}
@@ -223,6 +289,70 @@ func TestParseConfig(t *testing.T) {
 	}
 }
 
+func TestValidateProvider(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		error  string
+	}{
+		{"anthropic gateway", Config{Provider: ProviderAnthropic, BaseURL: "https://gateway.internal"}, ""},
+		{"bedrock resume", Config{Provider: ProviderBedrock, Mode: ModeResume, SessionID: "s1"}, ""},
+		{"openai", Config{Provider: ProviderOpenAI, Model: "gpt-4o", MaxTokens: 1000}, ""},
+		{"unknown provider", Config{Provider: "gemini"}, `unsupported provider "gemini"`},
+		{"openai sessions", Config{Provider: ProviderOpenAI, Mode: ModeContinue}, `mode "continue" needs the anthropic or bedrock provider`},
+		{"openai cost", Config{Provider: ProviderOpenAI, MaxCost: 1}, "max_cost needs the anthropic or bedrock provider"},
+		{"local model without endpoint", Config{Provider: ProviderOpenAICompatible, Model: "llama3"}, "base_url and model are required with openai_compatible"},
+		{"base_url with bedrock", Config{Provider: ProviderBedrock, BaseURL: "https://x"}, "base_url can only be used with anthropic and openai_compatible"},
+		{"region with anthropic", Config{Provider: ProviderAnthropic, Region: "us-east-1"}, "region can only be used with bedrock"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateProvider(&tt.config)
+			if tt.error == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Errorf("Expected error containing %q, got %v", tt.error, err)
+			}
+		})
+	}
+}
+
+func TestCheckBaseURL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "egress.yaml")
+	if err := os.WriteFile(path, []byte("default:\n  allow: [\"localhost\", \"10.1.0.0/16\"]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := egress.LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	egress.Configure(cfg)
+	t.Cleanup(func() { egress.Configure(nil) })
+	policy, err := egress.Resolve(egress.Scope{ProjectID: "p1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for _, baseURL := range []string{"", "http://localhost:11434/v1", "http://10.1.2.3:11434/v1"} {
+		if err := checkBaseURL(ctx, policy, baseURL); err != nil {
+			t.Errorf("checkBaseURL(%q) = %v, want allowed", baseURL, err)
+		}
+	}
+	for _, baseURL := range []string{"http://127.0.0.1:8080/v1", "http://169.254.169.254/latest"} {
+		if err := checkBaseURL(ctx, policy, baseURL); err == nil || !strings.Contains(err.Error(), "not allowed by the network policy") {
+			t.Errorf("checkBaseURL(%q) = %v, want the policy to refuse it", baseURL, err)
+		}
+	}
+	if err := checkBaseURL(ctx, nil, "http://127.0.0.1:8080/v1"); err != nil {
+		t.Errorf("expected no policy to allow everything, got %v", err)
+	}
+}
+
 func TestAgentPlugin_GetType(t *testing.T) {
 	plugin := &AgentPlugin{}
 	if plugin.GetType() != "agent" {
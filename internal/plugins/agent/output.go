@@ -0,0 +1,162 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+)
+
+// maxOutputViolations caps how many violations are listed in the failure message
+const maxOutputViolations = 10
+
+// outputSchemaName is the resource name output schemas are compiled under
+const outputSchemaName = "output-schema.json"
+
+// loadOutputSchema reads output_schema and checks it compiles. A schema given
+// as a file path is inlined when the suite is loaded, so the worker never
+// reads it. It returns the schema as a plain object, which is shown to the
+// agent and passed to the executor.
+func loadOutputSchema(raw interface{}) (map[string]interface{}, error) {
+	var schema map[string]interface{}
+	switch s := raw.(type) {
+	case map[string]interface{}:
+		schema = s
+	case string:
+		return nil, fmt.Errorf("output_schema %s wasn't read when the suite was loaded; run the suite through the rocketship CLI or a connected repository", s)
+	default:
+		return nil, fmt.Errorf("output_schema must be a schema object or the path of a JSON file: got type %T", raw)
+	}
+	if _, err := compileOutputSchema(schema); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// compileOutputSchema compiles a schema. Schemas without $schema are read as
+// draft 2020-12, like the http plugin's json_schema assertion.
+func compileOutputSchema(schema map[string]interface{}) (*jsonschema.Schema, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve working directory: %w", err)
+	}
+	location := filepath.Join(wd, outputSchemaName)
+
+	compiler := jsonschema.NewCompiler()
+	compiler.DefaultDraft(jsonschema.Draft2020)
+	compiler.AssertFormat()
+	if err := compiler.AddResource(location, schema); err != nil {
+		return nil, fmt.Errorf("invalid output_schema: %w", err)
+	}
+	compiled, err := compiler.Compile(location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output_schema: %w", err)
+	}
+	return compiled, nil
+}
+
+// outputInstructions tells the agent how to return its output
+func outputInstructions(schema map[string]interface{}) string {
+	data, _ := json.MarshalIndent(schema, "", "  ")
+	return fmt.Sprintf(`
+
+STRUCTURED OUTPUT: When the task succeeds, your JSON result MUST also include an "output" field whose value matches this JSON schema exactly. Use the types the schema asks for (numbers as numbers, not strings) and no extra fields unless the schema allows them:
+%s
+Example: {"ok": true, "result": "<summary>", "output": <value matching the schema>}`, data)
+}
+
+// validateOutput checks the agent's output against the schema and describes
+// every violation
+func validateOutput(schema map[string]interface{}, output interface{}) error {
+	if output == nil {
+		return errors.New(`agent returned no output: output_schema needs an "output" field in the agent's result`)
+	}
+	compiled, err := compileOutputSchema(schema)
+	if err != nil {
+		return err
+	}
+
+	err = compiled.Validate(output)
+	if err == nil {
+		return nil
+	}
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return fmt.Errorf("agent output does not match output_schema: %w", err)
+	}
+	var violations []string
+	for _, unit := range validationErr.BasicOutput().Errors {
+		if unit.Error == nil {
+			continue
+		}
+		location := unit.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+		violations = append(violations, fmt.Sprintf("%s: %s", location, unit.Error))
+	}
+	if len(violations) == 0 {
+		violations = append(violations, validationErr.Error())
+	}
+	if len(violations) > maxOutputViolations {
+		violations = append(violations[:maxOutputViolations:maxOutputViolations], fmt.Sprintf("and %d more", len(violations)-maxOutputViolations))
+	}
+	return fmt.Errorf("agent output does not match output_schema: %s", strings.Join(violations, "; "))
+}
+
+// processAssertions evaluates the step's assertions against the agent's result,
+// so checks on output fields fail the step like any other plugin's
+func processAssertions(p map[string]interface{}, result map[string]interface{}, state map[string]interface{}, envSecrets map[string]string) error {
+	assertionList, ok := p["assertions"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	subject, err := assertions.Normalize(result)
+	if err != nil {
+		return fmt.Errorf("failed to read result: %w", err)
+	}
+
+	for _, rawAssertion := range assertionList {
+		assertion, ok := rawAssertion.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("invalid assertion %T", rawAssertion)
+		}
+
+		typ, ok := assertion["type"].(string)
+		if !ok {
+			return errors.New("assertion type is required")
+		}
+
+		if !assertions.IsShared(typ) {
+			return fmt.Errorf("unsupported assertion type %q", typ)
+		}
+		if typ == assertions.TypeJSONPath {
+			if _, ok := assertion["path"].(string); !ok {
+				return errors.New("json_path assertion requires path")
+			}
+		}
+
+		expected := assertion["expected"]
+		if expectedStr, ok := expected.(string); ok {
+			rendered, err := dsl.ProcessTemplate(expectedStr, dsl.TemplateContext{Runtime: state, Env: envSecrets})
+			if err != nil {
+				return fmt.Errorf("failed to process expected template: %w", err)
+			}
+			expected = rendered
+		}
+
+		if err := assertions.Evaluate(assertion, subject, expected).Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
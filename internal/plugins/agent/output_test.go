@@ -0,0 +1,122 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+)
+
+var ticketSchema = map[string]interface{}{
+	"type":     "object",
+	"required": []interface{}{"id", "priority"},
+	"properties": map[string]interface{}{
+		"id":       map[string]interface{}{"type": "string"},
+		"priority": map[string]interface{}{"type": "integer", "minimum": 1.0, "maximum": 5.0},
+	},
+	"additionalProperties": false,
+}
+
+func TestLoadOutputSchema(t *testing.T) {
+	tests := []struct {
+		name  string
+		raw   interface{}
+		error string
+	}{
+		{"inline", ticketSchema, ""},
+		{"file not inlined", "./schemas/ticket.json", "wasn't read when the suite was loaded"},
+		{"not a schema", map[string]interface{}{"type": 5.0}, "invalid output_schema"},
+		{"wrong type", 5.0, "output_schema must be a schema object or the path of a JSON file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := loadOutputSchema(tt.raw)
+			if tt.error == "" {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if schema["type"] != "object" {
+					t.Errorf("Expected the schema object, got %v", schema)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Errorf("Expected error containing %q, got %v", tt.error, err)
+			}
+		})
+	}
+}
+
+func TestParseConfig_OutputSchema(t *testing.T) {
+	config, err := parseConfig(map[string]interface{}{
+		"prompt":        "Triage the newest ticket",
+		"output_schema": ticketSchema,
+	}, dsl.TemplateContext{Runtime: make(map[string]interface{})})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.OutputSchema == nil {
+		t.Fatal("Expected output_schema to be set")
+	}
+	if !strings.Contains(outputInstructions(config.OutputSchema), `"priority"`) {
+		t.Error("Expected the instructions to include the schema")
+	}
+}
+
+func TestValidateOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		output interface{}
+		error  string
+	}{
+		{"matches", map[string]interface{}{"id": "T-1", "priority": 2.0}, ""},
+		{"missing", nil, `agent returned no output`},
+		{"wrong type", map[string]interface{}{"id": "T-1", "priority": "high"}, "/priority: got string, want integer"},
+		{"missing field", map[string]interface{}{"id": "T-1"}, "missing property 'priority'"},
+		{"extra field", map[string]interface{}{"id": "T-1", "priority": 1.0, "note": "x"}, "additional properties 'note' not allowed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateOutput(ticketSchema, tt.output)
+			if tt.error == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Errorf("Expected error containing %q, got %v", tt.error, err)
+			}
+		})
+	}
+}
+
+func TestProcessAssertions_Output(t *testing.T) {
+	result := map[string]interface{}{
+		"success": true,
+		"result":  "Triaged T-1",
+		"output":  map[string]interface{}{"id": "T-1", "priority": 2.0},
+	}
+	assertion := func(path string, expected interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"assertions": []interface{}{
+				map[string]interface{}{"type": "json_path", "path": path, "expected": expected},
+			},
+		}
+	}
+
+	if err := processAssertions(assertion(".output.priority", 2), result, nil, nil); err != nil {
+		t.Errorf("Expected the assertion to pass, got %v", err)
+	}
+	if err := processAssertions(assertion(".output.id", "{{ .ticket }}"), result, map[string]interface{}{"ticket": "T-1"}, nil); err != nil {
+		t.Errorf("Expected the templated assertion to pass, got %v", err)
+	}
+	if err := processAssertions(assertion(".output.priority", 3), result, nil, nil); err == nil {
+		t.Error("Expected the assertion to fail")
+	}
+	if err := processAssertions(map[string]interface{}{
+		"assertions": []interface{}{map[string]interface{}{"type": "status_code", "expected": 200}},
+	}, result, nil, nil); err == nil || !strings.Contains(err.Error(), `unsupported assertion type "status_code"`) {
+		t.Errorf("Expected an unsupported assertion error, got %v", err)
+	}
+}
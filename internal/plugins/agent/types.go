@@ -35,7 +35,30 @@ type Config struct {
 	// Tool permissions: list of allowed tool names, or ["*"] for all tools (default: ["*"])
 	AllowedTools []string `json:"allowed_tools,omitempty" yaml:"allowed_tools,omitempty"`
 
-	// Anthropic API key (optional - auto-detected from ANTHROPIC_API_KEY env var if not provided)
+	// Model provider: anthropic, bedrock, openai or openai_compatible (default: anthropic)
+	Provider string `json:"provider,omitempty" yaml:"provider,omitempty"`
+
+	// Model to use for this step (default: the provider's default)
+	Model string `json:"model,omitempty" yaml:"model,omitempty"`
+
+	// Endpoint for openai_compatible, or an Anthropic-compatible gateway for anthropic
+	BaseURL string `json:"base_url,omitempty" yaml:"base_url,omitempty"`
+
+	// AWS region for bedrock (default: AWS_REGION on the worker)
+	Region string `json:"region,omitempty" yaml:"region,omitempty"`
+
+	// Tokens the agent may use; the step fails once it uses more (default: unlimited)
+	MaxTokens int `json:"max_tokens,omitempty" yaml:"max_tokens,omitempty"`
+
+	// USD the agent may spend; the step fails once it spends more (default: unlimited)
+	MaxCost float64 `json:"max_cost,omitempty" yaml:"max_cost,omitempty"`
+
+	// JSON schema the agent's output must match, inline or the path of a JSON file.
+	// Loaded and checked in parseConfig, so the executor gets the schema object.
+	OutputSchema map[string]interface{} `json:"output_schema,omitempty" yaml:"output_schema,omitempty"`
+
+	// API key for the provider (optional - auto-detected from ANTHROPIC_API_KEY or
+	// OPENAI_API_KEY env var if not provided)
 	APIKey string `json:"api_key,omitempty" yaml:"api_key,omitempty"`
 
 	// Internal: MCP servers resolved from capabilities (populated internally, not from YAML)
@@ -68,6 +91,7 @@ type Response struct {
 	Success   bool              `json:"ok"`
 	Error     string            `json:"error,omitempty"`
 	Result    string            `json:"result,omitempty"`
+	Output    interface{}       `json:"output,omitempty"`    // Structured output, checked against output_schema
 	Variables map[string]string `json:"variables,omitempty"` // Auto-saved to workflow state
 	SessionID string            `json:"session_id,omitempty"`
 	Mode      string            `json:"mode,omitempty"`
@@ -75,6 +99,14 @@ type Response struct {
 	Traceback string            `json:"traceback,omitempty"`
 }
 
+// Model providers the executor can run the agent with
+const (
+	ProviderAnthropic        = "anthropic"
+	ProviderBedrock          = "bedrock"
+	ProviderOpenAI           = "openai"
+	ProviderOpenAICompatible = "openai_compatible"
+)
+
 // ExecutionMode represents the different ways an agent can be executed
 type ExecutionMode string
 
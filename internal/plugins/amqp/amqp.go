@@ -0,0 +1,720 @@
+package amqp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/itchyny/gojq"
+	amqp091 "github.com/rabbitmq/amqp091-go"
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+	"go.temporal.io/sdk/activity"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// Auto-register the plugin when the package is imported
+func init() {
+	plugins.RegisterPlugin(&AMQPPlugin{})
+}
+
+// GetType returns the plugin type identifier
+func (ap *AMQPPlugin) GetType() string {
+	return "amqp"
+}
+
+// Activity declares topology, publishes messages and consumes matching messages from a broker
+func (ap *AMQPPlugin) Activity(ctx context.Context, p map[string]interface{}) (interface{}, error) {
+	logger := activity.GetLogger(ctx)
+
+	// Parse configuration from parameters
+	configData, ok := p["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid config format")
+	}
+
+	config := &AMQPConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		return nil, fmt.Errorf("failed to parse amqp config: %w", err)
+	}
+
+	// Validate required fields
+	if config.URL == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+	if config.Declare == nil && config.Publish == nil && config.Consume == nil {
+		return nil, fmt.Errorf("at least one of declare, publish or consume is required")
+	}
+
+	timeout := defaultTimeout
+	if config.Timeout != "" {
+		parsed, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", config.Timeout, err)
+		}
+		timeout = parsed
+	}
+
+	// Convert state to map[string]interface{} for template processing
+	state := make(map[string]interface{})
+	if stateStr, ok := p["state"].(map[string]string); ok {
+		for k, v := range stateStr {
+			state[k] = v
+		}
+	} else if stateInt, ok := p["state"].(map[string]interface{}); ok {
+		state = stateInt
+	}
+
+	// Extract env secrets from params (for {{ .env.* }} template resolution)
+	env := make(map[string]string)
+	if envData, ok := p["env"].(map[string]interface{}); ok {
+		for k, v := range envData {
+			if strVal, ok := v.(string); ok {
+				env[k] = strVal
+			}
+		}
+	} else if envData, ok := p["env"].(map[string]string); ok {
+		env = envData
+	}
+
+	if err := applyVariableReplacement(config, state, env); err != nil {
+		return nil, fmt.Errorf("variable replacement failed: %w", err)
+	}
+
+	policy, err := egress.FromParams(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
+
+	logger.Info("Executing amqp plugin",
+		"declare", config.Declare != nil,
+		"publish", config.Publish != nil,
+		"consume", config.Consume != nil)
+
+	response, err := execute(ctx, config, timeout, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	// Assertions and saves operate on the consumed messages with JSON bodies decoded
+	decoded := decodeMessages(response.Messages)
+
+	assertionResults, err := processAssertions(p, response.Messages, decoded, state, env)
+	if err != nil {
+		return nil, err
+	}
+	if failure := assertions.Summary(assertionResults); failure != "" {
+		return nil, fmt.Errorf("%s", failure)
+	}
+
+	saved := make(map[string]string)
+	if err := saves.Process(p, decoded, saved); err != nil {
+		return nil, err
+	}
+
+	logger.Info("AMQP step completed",
+		"published", response.Published,
+		"messages", len(response.Messages),
+		"skipped", response.Skipped)
+
+	return &ActivityResponse{
+		Response:         response,
+		Saved:            saved,
+		AssertionResults: assertionResults,
+	}, nil
+}
+
+// execute runs the declare, publish and consume phases over a single connection
+func execute(ctx context.Context, config *AMQPConfig, timeout time.Duration, policy *egress.Policy) (*AMQPResponse, error) {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	dialer := policy.Dialer()
+	conn, err := amqp091.DialConfig(config.URL, amqp091.Config{
+		Dial: func(network, addr string) (net.Conn, error) {
+			c, err := dialer.DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			// Bound the handshake; the client clears the deadline once connected
+			if err := c.SetDeadline(time.Now().Add(timeout)); err != nil {
+				_ = c.Close()
+				return nil, err
+			}
+			return c, nil
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to broker: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open channel: %w", err)
+	}
+	defer func() { _ = ch.Close() }()
+
+	response := &AMQPResponse{Messages: []Message{}}
+
+	// Server-named queues are remembered so later phases can refer to them
+	lastQueue := ""
+	if config.Declare != nil {
+		lastQueue, err = declare(ch, config.Declare)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if config.Publish != nil {
+		if err := publish(ctx, ch, config.Publish); err != nil {
+			return nil, err
+		}
+		response.Published = len(config.Publish.Messages)
+	}
+
+	if config.Consume != nil {
+		queue := config.Consume.Queue
+		if queue == "" {
+			queue = lastQueue
+		}
+		if queue == "" {
+			return nil, fmt.Errorf("consume.queue is required when no queue is declared")
+		}
+		if err := consume(ctx, ch, queue, config.Consume, response); err != nil {
+			return nil, err
+		}
+	}
+
+	response.Duration = time.Since(start).String()
+	return response, nil
+}
+
+// declare creates exchanges, then queues and their bindings, returning the last queue name
+func declare(ch *amqp091.Channel, config *DeclareConfig) (string, error) {
+	for _, ex := range config.Exchanges {
+		kind := ex.Type
+		if kind == "" {
+			kind = amqp091.ExchangeDirect
+		}
+		if err := ch.ExchangeDeclare(ex.Name, kind, ex.Durable, ex.AutoDelete, false, false, nil); err != nil {
+			return "", fmt.Errorf("failed to declare exchange %q: %w", ex.Name, err)
+		}
+	}
+
+	lastQueue := ""
+	for _, q := range config.Queues {
+		declared, err := ch.QueueDeclare(q.Name, q.Durable, q.AutoDelete, q.Exclusive, false, nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to declare queue %q: %w", q.Name, err)
+		}
+		if q.Purge {
+			if _, err := ch.QueuePurge(declared.Name, false); err != nil {
+				return "", fmt.Errorf("failed to purge queue %q: %w", declared.Name, err)
+			}
+		}
+		for _, b := range q.Bindings {
+			if err := ch.QueueBind(declared.Name, b.RoutingKey, b.Exchange, false, nil); err != nil {
+				return "", fmt.Errorf("failed to bind queue %q to exchange %q: %w", declared.Name, b.Exchange, err)
+			}
+		}
+		lastQueue = declared.Name
+	}
+
+	return lastQueue, nil
+}
+
+// publish sends every message with publisher confirms so consumers see them afterwards
+func publish(ctx context.Context, ch *amqp091.Channel, config *PublishConfig) error {
+	if err := ch.Confirm(false); err != nil {
+		return fmt.Errorf("failed to enable publisher confirms: %w", err)
+	}
+
+	for i, msg := range config.Messages {
+		routingKey := msg.RoutingKey
+		if routingKey == "" {
+			routingKey = config.RoutingKey
+		}
+
+		publishing := amqp091.Publishing{
+			ContentType:   msg.ContentType,
+			CorrelationId: msg.CorrelationID,
+			MessageId:     msg.MessageID,
+			Timestamp:     time.Now(),
+			Body:          []byte(msg.Body),
+		}
+		if msg.Persistent {
+			publishing.DeliveryMode = amqp091.Persistent
+		}
+		if len(msg.Headers) > 0 {
+			publishing.Headers = amqp091.Table{}
+			for k, v := range msg.Headers {
+				publishing.Headers[k] = v
+			}
+		}
+
+		confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, config.Exchange, routingKey, false, false, publishing)
+		if err != nil {
+			return fmt.Errorf("failed to publish message %d: %w", i, err)
+		}
+		acked, err := confirm.WaitContext(ctx)
+		if err != nil {
+			return fmt.Errorf("timed out waiting for broker to confirm message %d: %w", i, err)
+		}
+		if !acked {
+			return fmt.Errorf("broker rejected message %d", i)
+		}
+	}
+	return nil
+}
+
+// consume waits for config.Count matching deliveries. Every delivery is acknowledged,
+// so messages skipped by the filter are removed from the queue.
+func consume(ctx context.Context, ch *amqp091.Channel, queue string, config *ConsumeConfig, response *AMQPResponse) error {
+	if config.Timeout != "" {
+		wait, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid consume timeout %q: %w", config.Timeout, err)
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, wait)
+		defer cancel()
+	}
+
+	var filter *gojq.Query
+	if config.Filter != nil && config.Filter.JSONPath != "" {
+		var err error
+		filter, err = gojq.Parse(config.Filter.JSONPath)
+		if err != nil {
+			return fmt.Errorf("failed to parse filter jq expression %q: %w", config.Filter.JSONPath, err)
+		}
+	}
+
+	deliveries, err := ch.ConsumeWithContext(ctx, queue, "", false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to consume from queue %q: %w", queue, err)
+	}
+
+	for len(response.Messages) < config.Count {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("received %d of %d expected messages from queue %q: %w", len(response.Messages), config.Count, queue, ctx.Err())
+		case d, ok := <-deliveries:
+			if !ok {
+				return fmt.Errorf("delivery channel for queue %q closed after %d of %d messages", queue, len(response.Messages), config.Count)
+			}
+			if err := d.Ack(false); err != nil {
+				return fmt.Errorf("failed to acknowledge message: %w", err)
+			}
+			msg := toMessage(d)
+			if !matchesFilter(msg, config.Filter, filter) {
+				response.Skipped++
+				continue
+			}
+			response.Messages = append(response.Messages, msg)
+		}
+	}
+
+	return nil
+}
+
+// toMessage converts a delivery into its JSON-friendly representation
+func toMessage(d amqp091.Delivery) Message {
+	headers := make(map[string]interface{}, len(d.Headers))
+	for k, v := range d.Headers {
+		headers[k] = normalizeHeaderValue(v)
+	}
+	return Message{
+		Exchange:      d.Exchange,
+		RoutingKey:    d.RoutingKey,
+		Headers:       headers,
+		ContentType:   d.ContentType,
+		CorrelationID: d.CorrelationId,
+		MessageID:     d.MessageId,
+		Redelivered:   d.Redelivered,
+		Body:          string(d.Body),
+	}
+}
+
+// normalizeHeaderValue turns AMQP table values into plain strings, maps and slices
+func normalizeHeaderValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case []byte:
+		return string(val)
+	case amqp091.Table:
+		out := make(map[string]interface{}, len(val))
+		for k, inner := range val {
+			out[k] = normalizeHeaderValue(inner)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, inner := range val {
+			out[i] = normalizeHeaderValue(inner)
+		}
+		return out
+	default:
+		return val
+	}
+}
+
+// matchesFilter reports whether a consumed message satisfies the filter
+func matchesFilter(msg Message, filter *FilterConfig, query *gojq.Query) bool {
+	if filter == nil {
+		return true
+	}
+	for k, want := range filter.Headers {
+		got, ok := msg.Headers[k]
+		if !ok || fmt.Sprint(got) != want {
+			return false
+		}
+	}
+	if filter.Contains != "" && !strings.Contains(msg.Body, filter.Contains) {
+		return false
+	}
+	if query == nil {
+		return true
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(msg.Body), &data); err != nil {
+		return false
+	}
+	iter := query.Run(data)
+	v, ok := iter.Next()
+	if !ok {
+		return false
+	}
+	if _, isErr := v.(error); isErr {
+		return false
+	}
+	return v != nil && v != false
+}
+
+// decodeMessages converts messages to generic values for jq, parsing JSON bodies
+func decodeMessages(messages []Message) []interface{} {
+	decoded := make([]interface{}, 0, len(messages))
+	for _, msg := range messages {
+		var envelope map[string]interface{}
+		b, _ := json.Marshal(msg)
+		_ = json.Unmarshal(b, &envelope)
+
+		var body interface{}
+		if err := json.Unmarshal([]byte(msg.Body), &body); err == nil {
+			envelope["body"] = body
+		}
+		decoded = append(decoded, envelope)
+	}
+	return decoded
+}
+
+// processAssertions evaluates all assertions and returns their results
+func processAssertions(p map[string]interface{}, messages []Message, decoded []interface{}, state map[string]interface{}, env map[string]string) ([]AssertionResult, error) {
+	context := dsl.TemplateContext{Runtime: state, Env: env}
+	// json_path, equals, regex, numeric comparisons and exists run against the messages array
+	return assertions.Process(p, decoded, context, func(assertionMap map[string]interface{}, result *AssertionResult) bool {
+		return evaluateAssertion(messages, decoded, assertionMap, result)
+	})
+}
+
+// evaluateAssertion evaluates the amqp plugin's own assertion types
+func evaluateAssertion(messages []Message, decoded []interface{}, assertionMap map[string]interface{}, result *AssertionResult) bool {
+	expected := result.Expected
+	switch result.Type {
+	case AssertionTypeMessageCount:
+		result.Actual = len(messages)
+		if exp, ok := expected.(float64); !ok {
+			result.Message = fmt.Sprintf("message_count expected value must be a number: got type %T", expected)
+		} else if int(exp) != len(messages) {
+			result.Message = fmt.Sprintf("expected %d messages, got %d", int(exp), len(messages))
+		} else {
+			result.Passed = true
+		}
+
+	case AssertionTypeContains:
+		if _, hasPath := assertionMap["path"]; hasPath {
+			*result = assertions.Evaluate(assertionMap, decoded, expected)
+			break
+		}
+		exp, ok := expected.(string)
+		if !ok {
+			result.Message = "contains expected value must be a string"
+			break
+		}
+		for _, msg := range messages {
+			if strings.Contains(msg.Body, exp) {
+				result.Passed = true
+				break
+			}
+		}
+		if !result.Passed {
+			result.Message = fmt.Sprintf("no message body contains %q", exp)
+		}
+
+	case AssertionTypeHeader:
+		// Checks the first consumed message; use json_path on .[n].headers for others
+		name, _ := assertionMap["name"].(string)
+		if name == "" {
+			result.Message = "name is required for header assertion"
+			break
+		}
+		result.Name = name
+		if len(messages) == 0 {
+			result.Message = "no messages consumed"
+			break
+		}
+		actual, found := messages[0].Headers[name]
+		if !found {
+			result.Message = fmt.Sprintf("header %q not present", name)
+			break
+		}
+		result.Actual = actual
+		if !assertions.Equal(actual, expected) {
+			result.Message = fmt.Sprintf("expected header %s=%v, got %v", name, expected, actual)
+		} else {
+			result.Passed = true
+		}
+
+	default:
+		return false
+	}
+	return true
+}
+
+// applyVariableReplacement processes templates in names, routing keys, message bodies and filters
+func applyVariableReplacement(config *AMQPConfig, state map[string]interface{}, env map[string]string) error {
+	context := dsl.TemplateContext{
+		Runtime: state,
+		Env:     env,
+	}
+	process := func(field string, s *string) error {
+		processed, err := dsl.ProcessTemplate(*s, context)
+		if err != nil {
+			return fmt.Errorf("failed to process %s template: %w", field, err)
+		}
+		*s = processed
+		return nil
+	}
+
+	if err := process("url", &config.URL); err != nil {
+		return err
+	}
+
+	if config.Declare != nil {
+		for i := range config.Declare.Exchanges {
+			if err := process("exchange name", &config.Declare.Exchanges[i].Name); err != nil {
+				return err
+			}
+		}
+		for i := range config.Declare.Queues {
+			q := &config.Declare.Queues[i]
+			if err := process("queue name", &q.Name); err != nil {
+				return err
+			}
+			for j := range q.Bindings {
+				if err := process("binding exchange", &q.Bindings[j].Exchange); err != nil {
+					return err
+				}
+				if err := process("binding routing_key", &q.Bindings[j].RoutingKey); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if config.Publish != nil {
+		if err := process("publish exchange", &config.Publish.Exchange); err != nil {
+			return err
+		}
+		if err := process("publish routing_key", &config.Publish.RoutingKey); err != nil {
+			return err
+		}
+		for i := range config.Publish.Messages {
+			msg := &config.Publish.Messages[i]
+			if err := process(fmt.Sprintf("message %d body", i), &msg.Body); err != nil {
+				return err
+			}
+			for _, field := range []*string{&msg.RoutingKey, &msg.CorrelationID, &msg.MessageID} {
+				if err := process(fmt.Sprintf("message %d property", i), field); err != nil {
+					return err
+				}
+			}
+			for k, v := range msg.Headers {
+				if err := process(fmt.Sprintf("message %d header %s", i, k), &v); err != nil {
+					return err
+				}
+				msg.Headers[k] = v
+			}
+		}
+	}
+
+	if config.Consume != nil {
+		if err := process("consume queue", &config.Consume.Queue); err != nil {
+			return err
+		}
+		if f := config.Consume.Filter; f != nil {
+			if err := process("filter contains", &f.Contains); err != nil {
+				return err
+			}
+			for k, v := range f.Headers {
+				if err := process(fmt.Sprintf("filter header %s", k), &v); err != nil {
+					return err
+				}
+				f.Headers[k] = v
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseConfig converts map[string]interface{} to AMQPConfig
+func parseConfig(configData map[string]interface{}, config *AMQPConfig) error {
+	if url, ok := configData["url"].(string); ok {
+		config.URL = url
+	}
+	if timeout, ok := configData["timeout"].(string); ok {
+		config.Timeout = timeout
+	}
+
+	if declareData, ok := configData["declare"].(map[string]interface{}); ok {
+		config.Declare = &DeclareConfig{}
+		if exchanges, ok := declareData["exchanges"].([]interface{}); ok {
+			for i, item := range exchanges {
+				ex, ok := item.(map[string]interface{})
+				if !ok {
+					return fmt.Errorf("declare.exchanges[%d] must be an object", i)
+				}
+				exchange := ExchangeConfig{
+					Name:       stringValue(ex["name"]),
+					Type:       stringValue(ex["type"]),
+					Durable:    boolValue(ex["durable"]),
+					AutoDelete: boolValue(ex["auto_delete"]),
+				}
+				if exchange.Name == "" {
+					return fmt.Errorf("declare.exchanges[%d].name is required", i)
+				}
+				config.Declare.Exchanges = append(config.Declare.Exchanges, exchange)
+			}
+		}
+		if queues, ok := declareData["queues"].([]interface{}); ok {
+			for i, item := range queues {
+				q, ok := item.(map[string]interface{})
+				if !ok {
+					return fmt.Errorf("declare.queues[%d] must be an object", i)
+				}
+				queue := QueueConfig{
+					Name:       stringValue(q["name"]),
+					Durable:    boolValue(q["durable"]),
+					AutoDelete: boolValue(q["auto_delete"]),
+					Exclusive:  boolValue(q["exclusive"]),
+					Purge:      boolValue(q["purge"]),
+				}
+				if bindings, ok := q["bindings"].([]interface{}); ok {
+					for j, b := range bindings {
+						bm, ok := b.(map[string]interface{})
+						if !ok {
+							return fmt.Errorf("declare.queues[%d].bindings[%d] must be an object", i, j)
+						}
+						queue.Bindings = append(queue.Bindings, BindingConfig{
+							Exchange:   stringValue(bm["exchange"]),
+							RoutingKey: stringValue(bm["routing_key"]),
+						})
+					}
+				}
+				config.Declare.Queues = append(config.Declare.Queues, queue)
+			}
+		}
+	}
+
+	if publishData, ok := configData["publish"].(map[string]interface{}); ok {
+		config.Publish = &PublishConfig{
+			Exchange:   stringValue(publishData["exchange"]),
+			RoutingKey: stringValue(publishData["routing_key"]),
+		}
+		messages, _ := publishData["messages"].([]interface{})
+		if len(messages) == 0 {
+			return fmt.Errorf("publish.messages must contain at least one message")
+		}
+		for i, item := range messages {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("publish.messages[%d] must be an object", i)
+			}
+			msg := MessageConfig{
+				RoutingKey:    stringValue(m["routing_key"]),
+				ContentType:   stringValue(m["content_type"]),
+				CorrelationID: stringValue(m["correlation_id"]),
+				MessageID:     stringValue(m["message_id"]),
+				Persistent:    boolValue(m["persistent"]),
+			}
+			// Bodies may be plain strings or structured values that are sent as JSON
+			switch body := m["body"].(type) {
+			case nil:
+			case string:
+				msg.Body = body
+			default:
+				b, err := json.Marshal(body)
+				if err != nil {
+					return fmt.Errorf("failed to encode publish.messages[%d].body: %w", i, err)
+				}
+				msg.Body = string(b)
+				if msg.ContentType == "" {
+					msg.ContentType = "application/json"
+				}
+			}
+			if headers, ok := m["headers"].(map[string]interface{}); ok {
+				msg.Headers = make(map[string]string, len(headers))
+				for k, v := range headers {
+					msg.Headers[k] = fmt.Sprint(v)
+				}
+			}
+			config.Publish.Messages = append(config.Publish.Messages, msg)
+		}
+	}
+
+	if consumeData, ok := configData["consume"].(map[string]interface{}); ok {
+		config.Consume = &ConsumeConfig{
+			Queue:   stringValue(consumeData["queue"]),
+			Timeout: stringValue(consumeData["timeout"]),
+			Count:   1,
+		}
+		if count, ok := consumeData["count"].(float64); ok {
+			config.Consume.Count = int(count)
+		} else if count, ok := consumeData["count"].(int); ok {
+			config.Consume.Count = count
+		}
+		if filter, ok := consumeData["filter"].(map[string]interface{}); ok {
+			config.Consume.Filter = &FilterConfig{
+				JSONPath: stringValue(filter["json_path"]),
+				Contains: stringValue(filter["contains"]),
+			}
+			if headers, ok := filter["headers"].(map[string]interface{}); ok {
+				config.Consume.Filter.Headers = make(map[string]string, len(headers))
+				for k, v := range headers {
+					config.Consume.Filter.Headers[k] = fmt.Sprint(v)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func stringValue(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+func boolValue(v interface{}) bool {
+	b, _ := v.(bool)
+	return b
+}
@@ -0,0 +1,121 @@
+package amqp
+
+import "github.com/rocketship-ai/rocketship/internal/assertions"
+
+// AMQPPlugin represents an AMQP 0-9-1 (RabbitMQ) test step
+type AMQPPlugin struct {
+	Name   string     `json:"name" yaml:"name"`
+	Plugin string     `json:"plugin" yaml:"plugin"`
+	Config AMQPConfig `json:"config" yaml:"config"`
+}
+
+// AMQPConfig defines the broker connection and the declare, publish and consume phases.
+// Phases run in that order; any of them may be omitted.
+type AMQPConfig struct {
+	URL     string         `json:"url" yaml:"url"` // amqp:// or amqps:// connection URL
+	Declare *DeclareConfig `json:"declare,omitempty" yaml:"declare,omitempty"`
+	Publish *PublishConfig `json:"publish,omitempty" yaml:"publish,omitempty"`
+	Consume *ConsumeConfig `json:"consume,omitempty" yaml:"consume,omitempty"`
+	Timeout string         `json:"timeout,omitempty" yaml:"timeout,omitempty"` // Overall step timeout (e.g., "30s")
+}
+
+// DeclareConfig lists exchanges and queues to declare before publishing
+type DeclareConfig struct {
+	Exchanges []ExchangeConfig `json:"exchanges,omitempty" yaml:"exchanges,omitempty"`
+	Queues    []QueueConfig    `json:"queues,omitempty" yaml:"queues,omitempty"`
+}
+
+// ExchangeConfig declares an exchange
+type ExchangeConfig struct {
+	Name       string `json:"name" yaml:"name"`
+	Type       string `json:"type,omitempty" yaml:"type,omitempty"` // direct, fanout, topic or headers (defaults to direct)
+	Durable    bool   `json:"durable,omitempty" yaml:"durable,omitempty"`
+	AutoDelete bool   `json:"auto_delete,omitempty" yaml:"auto_delete,omitempty"`
+}
+
+// QueueConfig declares a queue and binds it to exchanges
+type QueueConfig struct {
+	Name       string          `json:"name" yaml:"name"`
+	Durable    bool            `json:"durable,omitempty" yaml:"durable,omitempty"`
+	AutoDelete bool            `json:"auto_delete,omitempty" yaml:"auto_delete,omitempty"`
+	Exclusive  bool            `json:"exclusive,omitempty" yaml:"exclusive,omitempty"`
+	Purge      bool            `json:"purge,omitempty" yaml:"purge,omitempty"` // Drop existing messages after declaring
+	Bindings   []BindingConfig `json:"bindings,omitempty" yaml:"bindings,omitempty"`
+}
+
+// BindingConfig binds a queue to an exchange
+type BindingConfig struct {
+	Exchange   string `json:"exchange" yaml:"exchange"`
+	RoutingKey string `json:"routing_key,omitempty" yaml:"routing_key,omitempty"`
+}
+
+// PublishConfig sends messages to an exchange (empty for the default exchange)
+type PublishConfig struct {
+	Exchange   string          `json:"exchange,omitempty" yaml:"exchange,omitempty"`
+	RoutingKey string          `json:"routing_key,omitempty" yaml:"routing_key,omitempty"`
+	Messages   []MessageConfig `json:"messages" yaml:"messages"`
+}
+
+// MessageConfig describes one published message
+type MessageConfig struct {
+	Body          string            `json:"body" yaml:"body"` // Objects in the YAML are sent as JSON
+	RoutingKey    string            `json:"routing_key,omitempty" yaml:"routing_key,omitempty"`
+	Headers       map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	ContentType   string            `json:"content_type,omitempty" yaml:"content_type,omitempty"`
+	CorrelationID string            `json:"correlation_id,omitempty" yaml:"correlation_id,omitempty"`
+	MessageID     string            `json:"message_id,omitempty" yaml:"message_id,omitempty"`
+	Persistent    bool              `json:"persistent,omitempty" yaml:"persistent,omitempty"`
+}
+
+// ConsumeConfig waits for messages on a queue
+type ConsumeConfig struct {
+	Queue   string        `json:"queue" yaml:"queue"`
+	Count   int           `json:"count" yaml:"count"`                         // Number of matching messages to wait for (defaults to 1)
+	Timeout string        `json:"timeout,omitempty" yaml:"timeout,omitempty"` // How long to wait for messages (defaults to the step timeout)
+	Filter  *FilterConfig `json:"filter,omitempty" yaml:"filter,omitempty"`   // Messages not matching the filter are acked and skipped
+}
+
+// FilterConfig selects consumed messages by body or headers
+type FilterConfig struct {
+	JSONPath string            `json:"json_path,omitempty" yaml:"json_path,omitempty"` // jq expression over the body that must produce a truthy value
+	Contains string            `json:"contains,omitempty" yaml:"contains,omitempty"`   // Substring the body must contain
+	Headers  map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`     // Headers that must be present with these values
+}
+
+// Assertion types supported by the amqp plugin
+const (
+	AssertionTypeMessageCount = "message_count"
+	AssertionTypeJSONPath     = "json_path"
+	AssertionTypeContains     = "contains"
+	AssertionTypeHeader       = "header"
+)
+
+// Message is a consumed delivery as exposed to assertions and saves
+type Message struct {
+	Exchange      string                 `json:"exchange"`
+	RoutingKey    string                 `json:"routing_key"`
+	Headers       map[string]interface{} `json:"headers"`
+	ContentType   string                 `json:"content_type,omitempty"`
+	CorrelationID string                 `json:"correlation_id,omitempty"`
+	MessageID     string                 `json:"message_id,omitempty"`
+	Redelivered   bool                   `json:"redelivered"`
+	Body          string                 `json:"body"`
+}
+
+// AMQPResponse summarises the step
+type AMQPResponse struct {
+	Published int       `json:"published"`
+	Messages  []Message `json:"messages"` // Matching messages, in delivery order
+	Skipped   int       `json:"skipped"`  // Deliveries that did not match the filter
+	Duration  string    `json:"duration"`
+}
+
+// AssertionResult represents a single assertion result for UI display
+type AssertionResult = assertions.Result
+
+// ActivityResponse represents the complete activity response
+type ActivityResponse struct {
+	Response         *AMQPResponse     `json:"response"`
+	Saved            map[string]string `json:"saved"`
+	AssertionResults []AssertionResult `json:"assertion_results,omitempty"`
+}
@@ -0,0 +1,353 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+const (
+	// pollInterval is how often actions re-check the page while they wait
+	pollInterval = 100 * time.Millisecond
+	// inspectTimeout bounds reading why an element wasn't ready once an action
+	// ran out of time
+	inspectTimeout = 2 * time.Second
+)
+
+// page is what the actions, routes and recordings need from a browser page.
+// playwrightPage implements it over a Playwright page.
+type page interface {
+	Navigate(ctx context.Context, url string) error
+	Location(ctx context.Context) (location, error)
+	// State reports whether selector matches an element, and whether the
+	// first one is visible and enabled, without waiting
+	State(ctx context.Context, selector string) (element, error)
+	// Read returns the text or, when attribute is set, the attribute of the
+	// first element selector matches, without waiting
+	Read(ctx context.Context, selector, attribute string) (element, error)
+
+	// Click, Fill, Press, Select and WaitFor wait for the element to be ready
+	// until ctx ends
+	Click(ctx context.Context, selector string) error
+	Fill(ctx context.Context, selector, value string) error
+	Press(ctx context.Context, selector, key string) error
+	Select(ctx context.Context, selector, value string) (bool, error)
+	WaitFor(ctx context.Context, selector, state string) error
+
+	Route(handler func(intercepted)) (func(), error)
+	Listen(events requestEvents) func()
+
+	Screenshot(ctx context.Context) ([]byte, error)
+	StartTrace() error
+	StopTrace(ctx context.Context) ([]byte, error)
+	Video(ctx context.Context) ([]byte, error)
+}
+
+// element is an element's state, and its text or attribute when read
+type element struct {
+	Found   bool
+	Visible bool
+	Enabled bool
+	Value   *string
+}
+
+type location struct {
+	URL   string
+	Title string
+}
+
+// keys are the key names press accepts besides single characters
+var keys = map[string]bool{
+	"Enter":      true,
+	"Tab":        true,
+	"Escape":     true,
+	"Backspace":  true,
+	"Delete":     true,
+	"Space":      true,
+	"ArrowUp":    true,
+	"ArrowDown":  true,
+	"ArrowLeft":  true,
+	"ArrowRight": true,
+	"Home":       true,
+	"End":        true,
+	"PageUp":     true,
+	"PageDown":   true,
+}
+
+// runner runs actions against a page
+type runner struct {
+	page    page
+	timeout time.Duration // Default wait per action
+	values  map[string]string
+	network *network
+}
+
+// run performs one action, waiting up to its timeout for the page to get there
+func (r *runner) run(ctx context.Context, action Action) (ActionResult, error) {
+	timeout := r.timeout
+	if action.Timeout != "" {
+		timeout, _ = time.ParseDuration(action.Timeout)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	result := ActionResult{Action: action.Action, Selector: action.Selector}
+	var err error
+	switch action.Action {
+	case ActionNavigate:
+		err = r.navigate(ctx, action.URL)
+	case ActionClick:
+		err = r.click(ctx, action.Selector)
+	case ActionFill:
+		err = r.fill(ctx, action.Selector, action.Value)
+	case ActionPress:
+		err = r.press(ctx, action.Selector, action.Key)
+	case ActionSelect:
+		err = r.selectOption(ctx, action.Selector, action.Value)
+	case ActionWaitFor:
+		if action.URL != "" {
+			err = r.waitForURL(ctx, action.URL)
+		} else {
+			err = r.waitFor(ctx, action.Selector, action.State)
+		}
+	case ActionAssertText, ActionAssertAttribute:
+		result.Value, err = r.assertValue(ctx, action)
+	case ActionExtract:
+		result.Value, err = r.extract(ctx, action.Selector, action.Attribute)
+		if err == nil {
+			r.values[action.As] = result.Value
+		}
+	case ActionWaitForRequest:
+		result.Value, err = r.waitForRequest(ctx, action)
+		if err == nil && action.As != "" {
+			r.values[action.As] = result.Value
+		}
+	}
+	result.Duration = time.Since(start).String()
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil {
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+	return result, err
+}
+
+func (r *runner) navigate(ctx context.Context, url string) error {
+	err := r.page.Navigate(ctx, url)
+	if err != nil && timedOut(ctx, err) {
+		return fmt.Errorf("%s did not finish loading", url)
+	}
+	return err
+}
+
+func (r *runner) click(ctx context.Context, selector string) error {
+	return r.explain(ctx, selector, r.page.Click(ctx, selector))
+}
+
+func (r *runner) fill(ctx context.Context, selector, value string) error {
+	return r.explain(ctx, selector, r.page.Fill(ctx, selector, value))
+}
+
+// press presses a key in the element, or in whatever has focus when there is
+// no selector
+func (r *runner) press(ctx context.Context, selector, key string) error {
+	return r.explain(ctx, selector, r.page.Press(ctx, selector, key))
+}
+
+func (r *runner) selectOption(ctx context.Context, selector, value string) error {
+	found, err := r.page.Select(ctx, selector, value)
+	if err != nil {
+		return r.explain(ctx, selector, err)
+	}
+	if !found {
+		return fmt.Errorf("%s has no option %q", selector, value)
+	}
+	return nil
+}
+
+func (r *runner) waitFor(ctx context.Context, selector, state string) error {
+	if state == "" {
+		state = StateVisible
+	}
+	err := r.page.WaitFor(ctx, selector, state)
+	if err != nil && timedOut(ctx, err) {
+		return fmt.Errorf("%s did not become %s: %s", selector, state, describe(r.inspect(ctx, selector)))
+	}
+	return err
+}
+
+func (r *runner) waitForURL(ctx context.Context, fragment string) error {
+	var last location
+	err := poll(ctx, func() (bool, error) {
+		var err error
+		last, err = r.page.Location(ctx)
+		return err == nil && strings.Contains(last.URL, fragment), err
+	})
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("URL did not contain %q: it is %s", fragment, last.URL)
+	}
+	return err
+}
+
+// waitForRequest waits until the page has made a request the action matches,
+// counting the requests made earlier in the step, and returns its body
+func (r *runner) waitForRequest(ctx context.Context, action Action) (string, error) {
+	var found Request
+	err := poll(ctx, func() (bool, error) {
+		var ok bool
+		found, ok = r.network.find(action)
+		return ok, nil
+	})
+	if err == nil {
+		return found.Body, nil
+	}
+	if ctx.Err() == nil {
+		return "", err
+	}
+
+	want := "request"
+	if action.Method != "" {
+		want = strings.ToUpper(action.Method) + " request"
+	}
+	want += fmt.Sprintf(" to a URL containing %q", action.URL)
+	if action.Contains != "" {
+		want += fmt.Sprintf(" with a body containing %q", action.Contains)
+	}
+	requests := r.network.recorded()
+	if len(requests) == 0 {
+		return "", fmt.Errorf("no %s was made, and the page made no XHR or fetch requests", want)
+	}
+	made := make([]string, 0, 5)
+	for _, request := range requests[max(0, len(requests)-5):] {
+		made = append(made, request.Method+" "+request.URL)
+	}
+	return "", fmt.Errorf("no %s was made; the last requests were %s", want, strings.Join(made, ", "))
+}
+
+// assertValue waits for the element's text or attribute to match, so checks
+// pass as soon as the page catches up
+func (r *runner) assertValue(ctx context.Context, action Action) (string, error) {
+	attribute, what := "", "text of "+action.Selector
+	if action.Action == ActionAssertAttribute {
+		attribute, what = action.Attribute, fmt.Sprintf("%s of %s", action.Attribute, action.Selector)
+	}
+
+	var last element
+	err := poll(ctx, func() (bool, error) {
+		var err error
+		last, err = r.page.Read(ctx, action.Selector, attribute)
+		if err != nil || !last.Found || last.Value == nil {
+			return false, err
+		}
+		return matches(*last.Value, action), nil
+	})
+	if err == nil {
+		return *last.Value, nil
+	}
+	if ctx.Err() == nil {
+		return "", err
+	}
+
+	want := fmt.Sprintf("to be %q", action.Expected)
+	if action.Contains != "" {
+		want = fmt.Sprintf("to contain %q", action.Contains)
+	}
+	switch {
+	case !last.Found:
+		return "", fmt.Errorf("expected %s %s, but %s was not found", what, want, action.Selector)
+	case last.Value == nil:
+		return "", fmt.Errorf("expected %s %s, but it has no %s attribute", what, want, action.Attribute)
+	}
+	return *last.Value, fmt.Errorf("expected %s %s, got %q", what, want, *last.Value)
+}
+
+// matches compares text with surrounding and repeated whitespace collapsed, the
+// way it reads on the page
+func matches(value string, action Action) bool {
+	value = strings.Join(strings.Fields(value), " ")
+	if action.Contains != "" {
+		return strings.Contains(value, action.Contains)
+	}
+	return value == strings.Join(strings.Fields(action.Expected), " ")
+}
+
+func (r *runner) extract(ctx context.Context, selector, attribute string) (string, error) {
+	if err := r.page.WaitFor(ctx, selector, StateAttached); err != nil {
+		return "", r.explain(ctx, selector, err)
+	}
+	state, err := r.page.Read(ctx, selector, attribute)
+	if err != nil {
+		return "", err
+	}
+	if !state.Found {
+		return "", fmt.Errorf("%s: %s", selector, describe(state))
+	}
+	if state.Value == nil {
+		return "", fmt.Errorf("%s has no %s attribute", selector, attribute)
+	}
+	return strings.TrimSpace(*state.Value), nil
+}
+
+// explain replaces the error of an action that timed out waiting for its
+// element with what kept the element from being ready
+func (r *runner) explain(ctx context.Context, selector string, err error) error {
+	if err == nil || selector == "" || !timedOut(ctx, err) {
+		return err
+	}
+	return fmt.Errorf("%s: %s", selector, describe(r.inspect(ctx, selector)))
+}
+
+// inspect reads an element's state after ctx may have ended
+func (r *runner) inspect(ctx context.Context, selector string) element {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), inspectTimeout)
+	defer cancel()
+	state, _ := r.page.State(ctx, selector)
+	return state
+}
+
+// timedOut tells whether err means the action ran out of time. Playwright's
+// timeouts end at the same deadline as ctx, and may fire just before it.
+func timedOut(ctx context.Context, err error) bool {
+	return errors.Is(err, playwright.ErrTimeout) || errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil
+}
+
+func describe(state element) string {
+	switch {
+	case !state.Found:
+		return "no element matches"
+	case !state.Visible:
+		return "element is not visible"
+	case !state.Enabled:
+		return "element is disabled"
+	}
+	return "element is visible"
+}
+
+// poll calls check until it reports done or ctx ends. Errors from check are
+// retried, since the page may be between documents; the last one is returned
+// if time runs out.
+func poll(ctx context.Context, check func() (bool, error)) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	var lastErr error
+	for {
+		done, err := check()
+		if err == nil && done {
+			return nil
+		}
+		if err != nil {
+			lastErr = err
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			if lastErr != nil && !errors.Is(lastErr, context.DeadlineExceeded) && !errors.Is(lastErr, context.Canceled) {
+				return fmt.Errorf("%w (last error: %v)", ctx.Err(), lastErr)
+			}
+			return ctx.Err()
+		}
+	}
+}
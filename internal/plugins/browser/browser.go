@@ -0,0 +1,676 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/rocketship-ai/rocketship/internal/artifacts"
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/browser/pw"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+	"go.temporal.io/sdk/activity"
+)
+
+const (
+	defaultTimeout       = 2 * time.Minute
+	defaultActionTimeout = 10 * time.Second
+)
+
+// Auto-register the plugin when the package is imported
+func init() {
+	plugins.RegisterPlugin(&BrowserPlugin{})
+}
+
+// GetType returns the plugin type identifier
+func (bp *BrowserPlugin) GetType() string {
+	return "browser"
+}
+
+// Activity drives a Chromium page through the configured actions and checks the result
+func (bp *BrowserPlugin) Activity(ctx context.Context, p map[string]interface{}) (interface{}, error) {
+	logger := activity.GetLogger(ctx)
+
+	// Parse configuration from parameters
+	configData, ok := p["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid config format")
+	}
+
+	config := &BrowserConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		return nil, fmt.Errorf("failed to parse browser config: %w", err)
+	}
+
+	timeout := defaultTimeout
+	if config.Timeout != "" {
+		parsed, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", config.Timeout, err)
+		}
+		timeout = parsed
+	}
+
+	// Convert state to map[string]interface{} for template processing
+	state := make(map[string]interface{})
+	if stateStr, ok := p["state"].(map[string]string); ok {
+		for k, v := range stateStr {
+			state[k] = v
+		}
+	} else if stateInt, ok := p["state"].(map[string]interface{}); ok {
+		state = stateInt
+	}
+
+	// Add run metadata to state for template processing
+	if runData, ok := p["run"].(map[string]interface{}); ok {
+		state["run"] = runData
+	}
+
+	// Extract env secrets from params (for {{ .env.* }} template resolution)
+	env := make(map[string]string)
+	if envData, ok := p["env"].(map[string]interface{}); ok {
+		for k, v := range envData {
+			if strVal, ok := v.(string); ok {
+				env[k] = strVal
+			}
+		}
+	} else if envData, ok := p["env"].(map[string]string); ok {
+		env = envData
+	}
+
+	if err := applyVariableReplacement(config, state, env); err != nil {
+		return nil, fmt.Errorf("variable replacement failed: %w", err)
+	}
+
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	runID := ""
+	if runData, ok := p["run"].(map[string]interface{}); ok {
+		runID, _ = runData["id"].(string)
+	}
+	name, _ := p["name"].(string)
+	if name == "" {
+		name = "browser"
+	}
+
+	logger.Info("Executing browser plugin", "url", config.URL, "session_id", config.SessionID, "actions", len(config.Actions))
+
+	stepCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	policy, err := egress.FromParams(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
+
+	headless := config.Headless == nil || *config.Headless
+	options := contextOptions(config)
+	if config.Video {
+		videoDir, err := os.MkdirTemp("", "rocketship-video-*")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create video directory: %w", err)
+		}
+		defer os.RemoveAll(videoDir)
+		options.RecordVideo = &playwright.RecordVideo{Dir: videoDir}
+	}
+	tab, err := pw.Open(stepCtx, pw.OpenOptions{
+		SessionID:  config.SessionID,
+		Executable: config.Executable,
+		Headless:   headless,
+		Policy:     policy,
+		Context:    options,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer tab.Close()
+	pg := &playwrightPage{tab: tab}
+	defer pg.close()
+
+	// A launched browser was created with the emulation; a session's page gets
+	// it for the step
+	if tab.Attached {
+		resetEmulation, err := emulate(stepCtx, pg, config)
+		if err != nil {
+			return nil, err
+		}
+		defer resetEmulation()
+	}
+
+	rec, err := startRecording(pg, config, runID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := perform(stepCtx, pg, config, p, state, env)
+
+	// Artifacts are saved even when the step ran out of time
+	captureCtx, cancelCapture := context.WithTimeout(context.WithoutCancel(ctx), captureTimeout)
+	defer cancelCapture()
+	saved, captureErr := rec.finish(captureCtx, err != nil)
+	if captureErr != nil {
+		logger.Warn("Failed to save browser artifacts", "error", captureErr)
+	}
+	if err != nil {
+		return nil, artifacts.Failure(withScreenshot(err, saved), saved)
+	}
+	result.Artifacts = saved
+
+	logger.Info("Browser step completed", "url", result.Response.URL, "actions", len(result.Response.Actions), "duration", result.Response.Duration)
+
+	return result, nil
+}
+
+// perform runs the actions, then the step's assertions and saves
+func perform(ctx context.Context, pg page, config *BrowserConfig, p map[string]interface{}, state map[string]interface{}, env map[string]string) (*ActivityResponse, error) {
+	response, err := execute(ctx, pg, config)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := assertions.Normalize(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare browser result: %w", err)
+	}
+
+	assertionResults, err := assertions.Process(p, subject, dsl.TemplateContext{Runtime: state, Env: env}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if failure := assertions.Summary(assertionResults); failure != "" {
+		return nil, fmt.Errorf("%s", failure)
+	}
+
+	saved := make(map[string]string)
+	if err := saves.Process(p, subject, saved); err != nil {
+		return nil, err
+	}
+
+	return &ActivityResponse{
+		Response:         response,
+		Saved:            saved,
+		AssertionResults: assertionResults,
+	}, nil
+}
+
+// withScreenshot adds where the screenshot of a failure was saved to its error
+func withScreenshot(err error, saved []artifacts.Artifact) error {
+	for _, artifact := range saved {
+		if artifact.Type == "screenshot" {
+			return fmt.Errorf("%w; screenshot saved to %s", err, artifact.Path)
+		}
+	}
+	return err
+}
+
+// execute opens the URL and runs the actions in order, stopping at the first failure
+func execute(ctx context.Context, pg page, config *BrowserConfig) (*BrowserResponse, error) {
+	start := time.Now()
+
+	actionTimeout := defaultActionTimeout
+	if config.ActionTimeout != "" {
+		actionTimeout, _ = time.ParseDuration(config.ActionTimeout)
+	}
+	net, err := interceptNetwork(pg, config.Routes)
+	if err != nil {
+		return nil, err
+	}
+	defer net.close()
+	r := &runner{page: pg, timeout: actionTimeout, values: make(map[string]string), network: net}
+
+	actions := config.Actions
+	if config.URL != "" {
+		actions = append([]Action{{Action: ActionNavigate, URL: config.URL}}, actions...)
+	}
+
+	response := &BrowserResponse{Values: r.values, Actions: []ActionResult{}}
+	for i, action := range actions {
+		result, err := r.run(ctx, action)
+		if err != nil {
+			return nil, fmt.Errorf("action %d (%s): %w", i+1, describeAction(action), err)
+		}
+		response.Actions = append(response.Actions, result)
+	}
+
+	loc, err := pg.Location(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read page location: %w", err)
+	}
+	response.URL = loc.URL
+	response.Title = loc.Title
+	response.Requests = net.recorded()
+	response.Duration = time.Since(start).String()
+	return response, nil
+}
+
+// describeAction names an action for errors, e.g. "click #submit"
+func describeAction(action Action) string {
+	switch {
+	case action.Selector != "":
+		return action.Action + " " + action.Selector
+	case action.URL != "":
+		return action.Action + " " + action.URL
+	case action.Key != "":
+		return action.Action + " " + action.Key
+	}
+	return action.Action
+}
+
+// validateConfig checks each action has what it needs once templates are rendered
+func validateConfig(config *BrowserConfig) error {
+	if config.URL == "" && len(config.Actions) == 0 {
+		return fmt.Errorf("url or actions is required")
+	}
+	if config.SessionID != "" && (config.Executable != "" || config.Headless != nil) {
+		return fmt.Errorf("executable and headless can't be used with session_id: the session's browser is already running")
+	}
+	if config.SessionID != "" && config.Video {
+		return fmt.Errorf("video can't be used with session_id: videos are recorded by a browser the step launches")
+	}
+	if config.ActionTimeout != "" {
+		if d, err := time.ParseDuration(config.ActionTimeout); err != nil || d <= 0 {
+			return fmt.Errorf("invalid action_timeout %q: must be a positive duration", config.ActionTimeout)
+		}
+	}
+	switch config.Screenshot {
+	case "", ScreenshotOnFailure, ScreenshotAlways, ScreenshotNever:
+	default:
+		return fmt.Errorf("screenshot must be on_failure, always or never, got %q", config.Screenshot)
+	}
+
+	if err := validateEmulation(config); err != nil {
+		return err
+	}
+
+	for i, action := range config.Actions {
+		if err := validateAction(action); err != nil {
+			return fmt.Errorf("actions[%d]: %w", i, err)
+		}
+	}
+	for i, route := range config.Routes {
+		if err := validateRoute(route); err != nil {
+			return fmt.Errorf("routes[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func validateRoute(route Route) error {
+	if route.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+	if route.Abort && (route.Status != 0 || len(route.Headers) > 0 || route.Body != nil) {
+		return fmt.Errorf("abort can't be used with status, headers or body")
+	}
+	if route.Status != 0 && (route.Status < 100 || route.Status > 599) {
+		return fmt.Errorf("status must be between 100 and 599, got %d", route.Status)
+	}
+	return nil
+}
+
+func validateAction(action Action) error {
+	if action.Timeout != "" {
+		if d, err := time.ParseDuration(action.Timeout); err != nil || d <= 0 {
+			return fmt.Errorf("invalid timeout %q: must be a positive duration", action.Timeout)
+		}
+	}
+
+	needsSelector := true
+	switch action.Action {
+	case ActionNavigate:
+		needsSelector = false
+		if action.URL == "" {
+			return fmt.Errorf("url is required with action navigate")
+		}
+	case ActionClick, ActionFill, ActionSelect:
+	case ActionPress:
+		needsSelector = false
+		if action.Key == "" {
+			return fmt.Errorf("key is required with action press")
+		}
+		if _, ok := keys[action.Key]; !ok && len([]rune(action.Key)) != 1 {
+			return fmt.Errorf("unknown key %q: use a single character or one of %s", action.Key, keyNames())
+		}
+	case ActionWaitFor:
+		needsSelector = action.URL == ""
+		if action.URL != "" && action.Selector != "" {
+			return fmt.Errorf("use either selector or url with action wait_for, not both")
+		}
+		switch action.State {
+		case "", StateVisible, StateHidden, StateAttached, StateDetached:
+		default:
+			return fmt.Errorf("state must be visible, hidden, attached or detached, got %q", action.State)
+		}
+	case ActionAssertText, ActionAssertAttribute:
+		if action.Action == ActionAssertAttribute && action.Attribute == "" {
+			return fmt.Errorf("attribute is required with action assert_attribute")
+		}
+		if (action.Expected == "") == (action.Contains == "") {
+			return fmt.Errorf("set one of expected or contains with action %s", action.Action)
+		}
+	case ActionExtract:
+		if action.As == "" {
+			return fmt.Errorf("as is required with action extract")
+		}
+	case ActionWaitForRequest:
+		needsSelector = false
+		if action.URL == "" {
+			return fmt.Errorf("url is required with action wait_for_request")
+		}
+	case "":
+		return fmt.Errorf("action is required")
+	default:
+		return fmt.Errorf("action must be navigate, click, fill, press, select, wait_for, assert_text, assert_attribute, extract or wait_for_request, got %q", action.Action)
+	}
+
+	if needsSelector && action.Selector == "" {
+		return fmt.Errorf("selector is required with action %s", action.Action)
+	}
+	return nil
+}
+
+func keyNames() string {
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// applyVariableReplacement processes templates in the URL, browser settings and
+// every action field
+func applyVariableReplacement(config *BrowserConfig, state map[string]interface{}, env map[string]string) error {
+	context := dsl.TemplateContext{
+		Runtime: state,
+		Env:     env,
+	}
+
+	render := func(name string, value *string) error {
+		if *value == "" {
+			return nil
+		}
+		processed, err := dsl.ProcessTemplate(*value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process %s template: %w", name, err)
+		}
+		*value = processed
+		return nil
+	}
+
+	fields := map[string]*string{
+		"url":        &config.URL,
+		"session_id": &config.SessionID,
+		"executable": &config.Executable,
+		"device":     &config.Device,
+		"user_agent": &config.UserAgent,
+		"timezone":   &config.Timezone,
+		"locale":     &config.Locale,
+	}
+	for name, value := range fields {
+		if err := render(name, value); err != nil {
+			return err
+		}
+	}
+
+	for i := range config.Actions {
+		action := &config.Actions[i]
+		actionFields := map[string]*string{
+			"selector":  &action.Selector,
+			"url":       &action.URL,
+			"value":     &action.Value,
+			"key":       &action.Key,
+			"attribute": &action.Attribute,
+			"method":    &action.Method,
+			"expected":  &action.Expected,
+			"contains":  &action.Contains,
+		}
+		for name, value := range actionFields {
+			if err := render(fmt.Sprintf("actions[%d].%s", i, name), value); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i := range config.Routes {
+		route := &config.Routes[i]
+		if err := render(fmt.Sprintf("routes[%d].url", i), &route.URL); err != nil {
+			return err
+		}
+		for name, value := range route.Headers {
+			if err := render(fmt.Sprintf("routes[%d].headers.%s", i, name), &value); err != nil {
+				return err
+			}
+			route.Headers[name] = value
+		}
+		body, err := renderBody(route.Body, func(value *string) error {
+			return render(fmt.Sprintf("routes[%d].body", i), value)
+		})
+		if err != nil {
+			return err
+		}
+		route.Body = body
+	}
+
+	return nil
+}
+
+// renderBody renders the templates in every string of a route body
+func renderBody(value interface{}, render func(*string) error) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		err := render(&v)
+		return v, err
+	case map[string]interface{}:
+		for key, item := range v {
+			rendered, err := renderBody(item, render)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = rendered
+		}
+	case []interface{}:
+		for i, item := range v {
+			rendered, err := renderBody(item, render)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = rendered
+		}
+	}
+	return value, nil
+}
+
+// parseConfig converts map[string]interface{} to BrowserConfig
+func parseConfig(configData map[string]interface{}, config *BrowserConfig) error {
+	stringFields := map[string]*string{
+		"url":            &config.URL,
+		"session_id":     &config.SessionID,
+		"executable":     &config.Executable,
+		"action_timeout": &config.ActionTimeout,
+		"timeout":        &config.Timeout,
+		"screenshot":     &config.Screenshot,
+		"device":         &config.Device,
+		"user_agent":     &config.UserAgent,
+		"timezone":       &config.Timezone,
+		"locale":         &config.Locale,
+	}
+	for key, target := range stringFields {
+		if v, ok := configData[key].(string); ok {
+			*target = v
+		}
+	}
+
+	switch v := configData["headless"].(type) {
+	case nil:
+	case bool:
+		config.Headless = &v
+	default:
+		return fmt.Errorf("headless must be a boolean, got %T", v)
+	}
+
+	recordings := map[string]*bool{"video": &config.Video, "trace": &config.Trace}
+	for key, target := range recordings {
+		switch v := configData[key].(type) {
+		case nil:
+		case bool:
+			*target = v
+		default:
+			return fmt.Errorf("%s must be a boolean, got %T", key, v)
+		}
+	}
+
+	if err := parseViewport(configData["viewport"], config); err != nil {
+		return err
+	}
+	if err := parseRoutes(configData["routes"], config); err != nil {
+		return err
+	}
+
+	raw, ok := configData["actions"]
+	if !ok || raw == nil {
+		return nil
+	}
+	list, ok := raw.([]interface{})
+	if !ok {
+		return fmt.Errorf("actions must be a list, got %T", raw)
+	}
+	for i, item := range list {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("actions[%d] must be a map, got %T", i, item)
+		}
+		action := Action{}
+		actionFields := map[string]*string{
+			"action":    &action.Action,
+			"selector":  &action.Selector,
+			"url":       &action.URL,
+			"value":     &action.Value,
+			"key":       &action.Key,
+			"state":     &action.State,
+			"attribute": &action.Attribute,
+			"method":    &action.Method,
+			"expected":  &action.Expected,
+			"contains":  &action.Contains,
+			"as":        &action.As,
+			"timeout":   &action.Timeout,
+		}
+		for key, target := range actionFields {
+			switch v := entry[key].(type) {
+			case nil:
+			case string:
+				*target = v
+			case float64:
+				// Values and expected text are often numbers in YAML
+				*target = strconv.FormatFloat(v, 'f', -1, 64)
+			case int, bool:
+				*target = fmt.Sprint(v)
+			default:
+				return fmt.Errorf("actions[%d].%s must be a string, got %T", i, key, v)
+			}
+		}
+		config.Actions = append(config.Actions, action)
+	}
+
+	return nil
+}
+
+// parseViewport converts the viewport map
+func parseViewport(raw interface{}, config *BrowserConfig) error {
+	if raw == nil {
+		return nil
+	}
+	entry, ok := raw.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("viewport must be a map, got %T", raw)
+	}
+	viewport := &Viewport{}
+	sizes := map[string]*int{"width": &viewport.Width, "height": &viewport.Height}
+	for key, target := range sizes {
+		switch v := entry[key].(type) {
+		case nil:
+		case float64:
+			*target = int(v)
+		case int:
+			*target = v
+		default:
+			return fmt.Errorf("viewport.%s must be a number, got %T", key, v)
+		}
+	}
+	switch v := entry["scale_factor"].(type) {
+	case nil:
+	case float64:
+		viewport.ScaleFactor = v
+	case int:
+		viewport.ScaleFactor = float64(v)
+	default:
+		return fmt.Errorf("viewport.scale_factor must be a number, got %T", v)
+	}
+	flags := map[string]**bool{"mobile": &viewport.Mobile, "touch": &viewport.Touch}
+	for key, target := range flags {
+		switch v := entry[key].(type) {
+		case nil:
+		case bool:
+			*target = &v
+		default:
+			return fmt.Errorf("viewport.%s must be a boolean, got %T", key, v)
+		}
+	}
+	config.Viewport = viewport
+	return nil
+}
+
+// parseRoutes converts the routes list
+func parseRoutes(raw interface{}, config *BrowserConfig) error {
+	if raw == nil {
+		return nil
+	}
+	list, ok := raw.([]interface{})
+	if !ok {
+		return fmt.Errorf("routes must be a list, got %T", raw)
+	}
+	for i, item := range list {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("routes[%d] must be a map, got %T", i, item)
+		}
+		route := Route{Body: entry["body"]}
+		route.URL, _ = entry["url"].(string)
+		route.Method, _ = entry["method"].(string)
+
+		switch v := entry["status"].(type) {
+		case nil:
+		case float64:
+			route.Status = int(v)
+		case int:
+			route.Status = v
+		default:
+			return fmt.Errorf("routes[%d].status must be a number, got %T", i, v)
+		}
+		switch v := entry["abort"].(type) {
+		case nil:
+		case bool:
+			route.Abort = v
+		default:
+			return fmt.Errorf("routes[%d].abort must be a boolean, got %T", i, v)
+		}
+		if headers, ok := entry["headers"].(map[string]interface{}); ok {
+			route.Headers = make(map[string]string, len(headers))
+			for name, value := range headers {
+				route.Headers[name] = fmt.Sprint(value)
+			}
+		}
+		config.Routes = append(config.Routes, route)
+	}
+	return nil
+}
@@ -0,0 +1,749 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/rocketship-ai/rocketship/internal/artifacts"
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+	"go.temporal.io/sdk/temporal"
+)
+
+type fakeElement struct {
+	text    string
+	value   string
+	attrs   map[string]string
+	hidden  bool
+	options []string
+}
+
+// fakeRequest is a request the fake page makes
+type fakeRequest struct {
+	method       string
+	url          string
+	resourceType string
+	headers      map[string]string
+	body         string
+}
+
+func (r *fakeRequest) Method() string             { return r.method }
+func (r *fakeRequest) URL() string                { return r.url }
+func (r *fakeRequest) ResourceType() string       { return r.resourceType }
+func (r *fakeRequest) Headers() map[string]string { return r.headers }
+func (r *fakeRequest) PostData() (string, error)  { return r.body, nil }
+
+// fakeAnswer is how a route answered a request
+type fakeAnswer struct {
+	how     string // fulfill, abort or fallback
+	status  int
+	headers map[string]string
+	body    []byte
+}
+
+// fakeRoute records the answer to an intercepted request
+type fakeRoute struct {
+	page    *fakePage
+	request *fakeRequest
+}
+
+func (r fakeRoute) Request() request { return r.request }
+
+func (r fakeRoute) Fulfill(status int, headers map[string]string, body []byte) error {
+	r.page.answers[r.request.method+" "+r.request.url] = fakeAnswer{how: "fulfill", status: status, headers: headers, body: body}
+	return nil
+}
+
+func (r fakeRoute) Abort() error {
+	r.page.answers[r.request.method+" "+r.request.url] = fakeAnswer{how: "abort"}
+	return nil
+}
+
+func (r fakeRoute) Fallback() error {
+	r.page.answers[r.request.method+" "+r.request.url] = fakeAnswer{how: "fallback"}
+	return nil
+}
+
+// fakePage keeps a map of selectors and waits for elements the way Playwright
+// does, failing with a Playwright timeout when ctx ends
+type fakePage struct {
+	mu       sync.Mutex
+	url      string
+	title    string
+	elements map[string]*fakeElement
+	onClick  map[string]func(f *fakePage)
+	pressed  []string
+	route    func(intercepted)
+	events   *requestEvents
+	answers  map[string]fakeAnswer // By the request's method and URL
+	tracing  bool
+	closed   bool
+}
+
+func (f *fakePage) Navigate(_ context.Context, url string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if strings.Contains(url, "unreachable") {
+		return fmt.Errorf("failed to load %s: net::ERR_NAME_NOT_RESOLVED", url)
+	}
+	f.url = url
+	return nil
+}
+
+func (f *fakePage) Location(context.Context) (location, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return location{URL: f.url, Title: f.title}, nil
+}
+
+func (f *fakePage) State(_ context.Context, selector string) (element, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	el, ok := f.elements[selector]
+	if !ok {
+		return element{}, nil
+	}
+	return element{Found: true, Visible: !el.hidden, Enabled: true}, nil
+}
+
+func (f *fakePage) Read(_ context.Context, selector, attribute string) (element, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	el, ok := f.elements[selector]
+	if !ok {
+		return element{}, nil
+	}
+	state := element{Found: true, Visible: !el.hidden, Enabled: true}
+	if attribute == "" {
+		text := el.text + el.value
+		state.Value = &text
+	} else if value, ok := el.attrs[attribute]; ok {
+		state.Value = &value
+	}
+	return state, nil
+}
+
+// wait calls do with the element once it is visible, until ctx ends
+func (f *fakePage) wait(ctx context.Context, selector string, do func(el *fakeElement)) error {
+	for {
+		f.mu.Lock()
+		el, ok := f.elements[selector]
+		if ok && !el.hidden {
+			do(el)
+			f.mu.Unlock()
+			return nil
+		}
+		f.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: Timeout exceeded.", playwright.ErrTimeout)
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
+
+func (f *fakePage) Click(ctx context.Context, selector string) error {
+	if err := f.wait(ctx, selector, func(*fakeElement) {}); err != nil {
+		return err
+	}
+	if click := f.onClick[selector]; click != nil {
+		click(f)
+	}
+	return nil
+}
+
+func (f *fakePage) Fill(ctx context.Context, selector, value string) error {
+	return f.wait(ctx, selector, func(el *fakeElement) { el.value = value })
+}
+
+func (f *fakePage) Press(ctx context.Context, selector, key string) error {
+	if selector != "" {
+		if err := f.wait(ctx, selector, func(*fakeElement) {}); err != nil {
+			return err
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pressed = append(f.pressed, key)
+	return nil
+}
+
+func (f *fakePage) Select(ctx context.Context, selector, value string) (bool, error) {
+	found := false
+	err := f.wait(ctx, selector, func(el *fakeElement) {
+		for _, option := range el.options {
+			if option == value {
+				el.value, found = option, true
+			}
+		}
+	})
+	return found, err
+}
+
+func (f *fakePage) WaitFor(ctx context.Context, selector, state string) error {
+	for {
+		f.mu.Lock()
+		el, ok := f.elements[selector]
+		var done bool
+		switch state {
+		case StateVisible:
+			done = ok && !el.hidden
+		case StateHidden:
+			done = !ok || el.hidden
+		case StateAttached:
+			done = ok
+		default:
+			done = !ok
+		}
+		f.mu.Unlock()
+		if done {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: Timeout exceeded.", playwright.ErrTimeout)
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
+
+func (f *fakePage) Route(handler func(intercepted)) (func(), error) {
+	f.route = handler
+	return func() { f.route = nil }, nil
+}
+
+func (f *fakePage) Listen(events requestEvents) func() {
+	f.events = &events
+	return func() { f.events = nil }
+}
+
+func (f *fakePage) Screenshot(context.Context) ([]byte, error) {
+	return []byte("png:" + f.title), nil
+}
+
+func (f *fakePage) StartTrace() error {
+	f.tracing = true
+	return nil
+}
+
+func (f *fakePage) StopTrace(context.Context) ([]byte, error) {
+	if !f.tracing {
+		return nil, errors.New("tracing was not started")
+	}
+	f.tracing = false
+	return []byte("zip:" + f.title), nil
+}
+
+func (f *fakePage) Video(context.Context) ([]byte, error) {
+	f.closed = true
+	return []byte("webm:" + f.title), nil
+}
+
+// send makes a request the way Playwright reports it: the request event, then
+// the route, then the response or the failure
+func (f *fakePage) send(r *fakeRequest) {
+	if f.events != nil {
+		f.events.sent(r)
+	}
+	if f.route == nil {
+		return
+	}
+	f.route(fakeRoute{page: f, request: r})
+	answer := f.answers[r.method+" "+r.url]
+	switch {
+	case f.events == nil:
+	case answer.how == "abort":
+		f.events.failed(r, "net::ERR_FAILED")
+	case answer.how == "fulfill":
+		f.events.answered(r, answer.status)
+	default:
+		f.events.answered(r, http.StatusOK)
+	}
+}
+
+// fakeDevtools records the DevTools calls emulation makes
+type fakeDevtools struct {
+	calls []string
+}
+
+func (d *fakeDevtools) Call(_ context.Context, method string, params map[string]interface{}) error {
+	if params["timezoneId"] == "Mars/Olympus_Mons" {
+		return fmt.Errorf("Invalid timezone ID")
+	}
+	data, _ := json.Marshal(params)
+	d.calls = append(d.calls, strings.TrimPrefix(method, "Emulation.")+" "+string(data))
+	return nil
+}
+
+func (d *fakeDevtools) UserAgent(context.Context) (string, error) {
+	return "Mozilla/5.0 HeadlessChrome/126.0.0.0", nil
+}
+
+func newLoginPage() *fakePage {
+	return &fakePage{
+		title: "Sign in",
+		elements: map[string]*fakeElement{
+			"#email":    {value: "prefilled"},
+			"#password": {},
+			"#plan":     {options: []string{"free", "pro"}},
+			"#submit":   {text: "Sign in"},
+		},
+		onClick: map[string]func(f *fakePage){
+			// The dashboard renders a moment after the click, the way a real app would
+			"#submit": func(f *fakePage) {
+				go func() {
+					time.Sleep(250 * time.Millisecond)
+					f.mu.Lock()
+					defer f.mu.Unlock()
+					f.url = "https://app.test/dashboard"
+					f.title = "Dashboard"
+					f.elements["h1.welcome"] = &fakeElement{text: "  Welcome back,\n alice@example.com "}
+					f.elements["a.profile"] = &fakeElement{text: "Profile", attrs: map[string]string{"href": "/users/42"}}
+					delete(f.elements, "#submit")
+				}()
+			},
+		},
+	}
+}
+
+// newCheckoutPage is a page whose pay button charges a payments API on another
+// origin, and reports the charge to an analytics endpoint
+func newCheckoutPage() *fakePage {
+	pg := &fakePage{url: "https://shop.test/checkout", title: "Checkout", elements: map[string]*fakeElement{"#pay": {text: "Pay"}}, answers: map[string]fakeAnswer{}}
+	pg.onClick = map[string]func(f *fakePage){
+		"#pay": func(f *fakePage) {
+			headers := map[string]string{"origin": "https://shop.test", "content-type": "application/json"}
+			preflight := map[string]string{"origin": "https://shop.test", "access-control-request-method": "POST", "access-control-request-headers": "content-type"}
+
+			if f.route != nil {
+				// Only routes see preflights
+				f.route(fakeRoute{page: f, request: &fakeRequest{method: "OPTIONS", url: "https://api.payments.test/v1/charges", resourceType: "fetch", headers: preflight}})
+			}
+			f.send(&fakeRequest{method: "POST", url: "https://api.payments.test/v1/charges", resourceType: "fetch", headers: headers, body: `{"amount":4200,"currency":"EUR"}`})
+			f.send(&fakeRequest{method: "GET", url: "https://shop.test/api/cart", resourceType: "xhr"})
+			f.send(&fakeRequest{method: "POST", url: "https://metrics.test/collect", resourceType: "xhr", body: "event=pay"})
+			f.send(&fakeRequest{method: "GET", url: "https://shop.test/logo.png", resourceType: "image"})
+		},
+	}
+	return pg
+}
+
+func runStep(t *testing.T, pg page, configData map[string]interface{}, assertionList []interface{}, saveList ...interface{}) (*ActivityResponse, error) {
+	t.Helper()
+	state := map[string]interface{}{"email": "alice@example.com"}
+	env := map[string]string{"PASSWORD": "hunter2"}
+
+	config := &BrowserConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		return nil, err
+	}
+	if err := applyVariableReplacement(config, state, env); err != nil {
+		return nil, err
+	}
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+	response, err := execute(context.Background(), pg, config)
+	if err != nil {
+		return nil, err
+	}
+	subject, err := assertions.Normalize(response)
+	if err != nil {
+		return nil, err
+	}
+	results, err := assertions.Process(map[string]interface{}{"assertions": assertionList}, subject, dsl.TemplateContext{Runtime: state, Env: env}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if failure := assertions.Summary(results); failure != "" {
+		return nil, fmt.Errorf("%s", failure)
+	}
+	saved := make(map[string]string)
+	if err := saves.Process(map[string]interface{}{"save": saveList}, subject, saved); err != nil {
+		return nil, err
+	}
+	return &ActivityResponse{Response: response, Saved: saved, AssertionResults: results}, nil
+}
+
+func TestLoginFlow(t *testing.T) {
+	pg := newLoginPage()
+
+	resp, err := runStep(t, pg, map[string]interface{}{
+		"url": "https://app.test/login",
+		"actions": []interface{}{
+			map[string]interface{}{"action": "fill", "selector": "#email", "value": "{{ email }}"},
+			map[string]interface{}{"action": "fill", "selector": "#password", "value": "{{ .env.PASSWORD }}"},
+			map[string]interface{}{"action": "select", "selector": "#plan", "value": "pro"},
+			map[string]interface{}{"action": "click", "selector": "#submit"},
+			map[string]interface{}{"action": "wait_for", "url": "/dashboard"},
+			map[string]interface{}{"action": "wait_for", "selector": "#submit", "state": "detached"},
+			map[string]interface{}{"action": "assert_text", "selector": "h1.welcome", "expected": "Welcome back, {{ email }}"},
+			map[string]interface{}{"action": "assert_attribute", "selector": "a.profile", "attribute": "href", "contains": "/users/"},
+			map[string]interface{}{"action": "extract", "selector": "a.profile", "attribute": "href", "as": "profile_path"},
+			map[string]interface{}{"action": "press", "key": "Escape"},
+		},
+	}, []interface{}{
+		map[string]interface{}{"type": "json_path", "path": ".title", "expected": "Dashboard"},
+		map[string]interface{}{"type": "equals", "path": ".url", "expected": "https://app.test/dashboard"},
+	}, map[string]interface{}{"json_path": ".values.profile_path", "as": "profile"})
+	if err != nil {
+		t.Fatalf("login flow failed: %v", err)
+	}
+
+	if resp.Saved["profile"] != "/users/42" {
+		t.Errorf("expected the extracted href to be saved, got %q", resp.Saved["profile"])
+	}
+	if pg.elements["#email"].value != "alice@example.com" || pg.elements["#password"].value != "hunter2" {
+		t.Errorf("expected fill to replace the content, got %q and %q", pg.elements["#email"].value, pg.elements["#password"].value)
+	}
+	if pg.elements["#plan"].value != "pro" {
+		t.Errorf("expected the option to be selected, got %q", pg.elements["#plan"].value)
+	}
+	if got := strings.Join(pg.pressed, ","); got != "Escape" {
+		t.Errorf("unexpected keyboard input %s", got)
+	}
+	if len(resp.Response.Actions) != 11 || resp.Response.Actions[0].Action != "navigate" {
+		t.Errorf("expected url to run as a first navigate action, got %+v", resp.Response.Actions)
+	}
+}
+
+func TestFailedActions(t *testing.T) {
+	tests := []struct {
+		name   string
+		action map[string]interface{}
+		error  string
+	}{
+		{
+			name:   "missing element",
+			action: map[string]interface{}{"action": "click", "selector": "#nope", "timeout": "300ms"},
+			error:  "action 1 (click #nope): #nope: no element matches",
+		},
+		{
+			name:   "hidden element",
+			action: map[string]interface{}{"action": "wait_for", "selector": "#banner", "timeout": "300ms"},
+			error:  "#banner did not become visible: element is not visible",
+		},
+		{
+			name:   "wrong text",
+			action: map[string]interface{}{"action": "assert_text", "selector": "#submit", "expected": "Log in", "timeout": "300ms"},
+			error:  `expected text of #submit to be "Log in", got "Sign in"`,
+		},
+		{
+			name:   "missing attribute",
+			action: map[string]interface{}{"action": "assert_attribute", "selector": "#submit", "attribute": "disabled", "expected": "true", "timeout": "300ms"},
+			error:  "it has no disabled attribute",
+		},
+		{
+			name:   "missing option",
+			action: map[string]interface{}{"action": "select", "selector": "#plan", "value": "enterprise"},
+			error:  `#plan has no option "enterprise"`,
+		},
+		{
+			name:   "navigation error",
+			action: map[string]interface{}{"action": "navigate", "url": "http://unreachable.test/"},
+			error:  "net::ERR_NAME_NOT_RESOLVED",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pg := newLoginPage()
+			pg.elements["#banner"] = &fakeElement{hidden: true}
+			_, err := runStep(t, pg, map[string]interface{}{"actions": []interface{}{tt.action}}, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Fatalf("expected error containing %q, got %v", tt.error, err)
+			}
+		})
+	}
+}
+
+func TestRecordingFailedStep(t *testing.T) {
+	t.Setenv(artifacts.DirEnv, t.TempDir())
+	pg := newLoginPage()
+	config := &BrowserConfig{Video: true, Trace: true}
+	ctx := context.Background()
+
+	rec, err := startRecording(pg, config, "run-3", "Checkout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, stepErr := runStep(t, pg, map[string]interface{}{
+		"actions": []interface{}{map[string]interface{}{"action": "click", "selector": "#pay", "timeout": "300ms"}},
+	}, nil)
+	if stepErr == nil {
+		t.Fatal("expected the click to fail")
+	}
+	saved, err := rec.finish(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, artifact := range saved {
+		names = append(names, artifact.Name+":"+artifact.Type+":"+artifact.MimeType)
+	}
+	want := "Checkout.png:screenshot:image/png,Checkout-trace.zip:trace:application/zip,Checkout.webm:video:video/webm"
+	if got := strings.Join(names, ","); got != want {
+		t.Fatalf("unexpected artifacts %s", got)
+	}
+	for i, content := range []string{"png:Sign in", "zip:Sign in", "webm:Sign in"} {
+		if data, _ := os.ReadFile(saved[i].Path); string(data) != content {
+			t.Errorf("expected %s to hold %q, got %q", saved[i].Name, content, data)
+		}
+	}
+	if pg.tracing || !pg.closed {
+		t.Errorf("expected the trace to be stopped and the page closed for its video")
+	}
+
+	err = artifacts.Failure(withScreenshot(stepErr, saved), saved)
+	var appErr *temporal.ApplicationError
+	if !errors.As(err, &appErr) || appErr.Type() != artifacts.ErrorType {
+		t.Fatalf("expected an application error carrying the artifacts, got %T", err)
+	}
+	if !strings.HasSuffix(appErr.Message(), "no element matches; screenshot saved to "+saved[0].Path) {
+		t.Errorf("expected the error to name the screenshot, got %v", appErr.Message())
+	}
+	var details map[string]interface{}
+	if err := appErr.Details(&details); err != nil || len(details["artifacts"].([]artifacts.Artifact)) != 3 {
+		t.Errorf("expected the artifacts in the details, got %v (%v)", details, err)
+	}
+}
+
+func TestScreenshotModes(t *testing.T) {
+	tests := []struct {
+		mode   string
+		failed bool
+		saved  int
+	}{
+		{"", false, 0},
+		{"", true, 1},
+		{ScreenshotAlways, false, 1},
+		{ScreenshotNever, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s failed=%v", tt.mode, tt.failed), func(t *testing.T) {
+			t.Setenv(artifacts.DirEnv, t.TempDir())
+			rec, err := startRecording(newLoginPage(), &BrowserConfig{Screenshot: tt.mode}, "run-4", "Login")
+			if err != nil {
+				t.Fatal(err)
+			}
+			saved, err := rec.finish(context.Background(), tt.failed)
+			if err != nil || len(saved) != tt.saved {
+				t.Errorf("expected %d screenshots, got %+v (%v)", tt.saved, saved, err)
+			}
+		})
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		error  string
+	}{
+		{"nothing to do", map[string]interface{}{}, "url or actions is required"},
+		{"unknown action", map[string]interface{}{"actions": []interface{}{map[string]interface{}{"action": "hover", "selector": "a"}}}, "actions[0]: action must be"},
+		{"click without selector", map[string]interface{}{"actions": []interface{}{map[string]interface{}{"action": "click"}}}, "selector is required with action click"},
+		{"unknown key", map[string]interface{}{"actions": []interface{}{map[string]interface{}{"action": "press", "key": "Return"}}}, `unknown key "Return"`},
+		{"assert without expectation", map[string]interface{}{"actions": []interface{}{map[string]interface{}{"action": "assert_text", "selector": "h1"}}}, "set one of expected or contains"},
+		{"session with headless", map[string]interface{}{"url": "https://app.test", "session_id": "s1", "headless": false}, "can't be used with session_id"},
+		{"session with video", map[string]interface{}{"url": "https://app.test", "session_id": "s1", "video": true}, "video can't be used with session_id"},
+		{"wait for request without url", map[string]interface{}{"actions": []interface{}{map[string]interface{}{"action": "wait_for_request", "method": "POST"}}}, "url is required with action wait_for_request"},
+		{"route without url", map[string]interface{}{"url": "https://app.test", "routes": []interface{}{map[string]interface{}{"status": 404}}}, "routes[0]: url is required"},
+		{"route status", map[string]interface{}{"url": "https://app.test", "routes": []interface{}{map[string]interface{}{"url": "*/api/*", "status": 42}}}, "status must be between 100 and 599"},
+		{"abort with body", map[string]interface{}{"url": "https://app.test", "routes": []interface{}{map[string]interface{}{"url": "*/api/*", "abort": true, "body": "x"}}}, "abort can't be used with status, headers or body"},
+		{"unknown device", map[string]interface{}{"url": "https://app.test", "device": "nokia-3310"}, `unknown device "nokia-3310": must be one of desktop, desktop-hd, galaxy-s23`},
+		{"viewport without height", map[string]interface{}{"url": "https://app.test", "viewport": map[string]interface{}{"width": 800}}, "viewport needs width and height"},
+		{"invalid locale", map[string]interface{}{"url": "https://app.test", "locale": "German"}, `invalid locale "German"`},
+		{"unknown screenshot mode", map[string]interface{}{"url": "https://app.test", "screenshot": "each_action"}, "screenshot must be on_failure, always or never"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &BrowserConfig{}
+			if err := parseConfig(tt.config, config); err != nil {
+				t.Fatal(err)
+			}
+			err := validateConfig(config)
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Fatalf("expected error containing %q, got %v", tt.error, err)
+			}
+		})
+	}
+}
+
+func TestNetworkRoutes(t *testing.T) {
+	pg := newCheckoutPage()
+
+	resp, err := runStep(t, pg, map[string]interface{}{
+		"routes": []interface{}{
+			map[string]interface{}{"url": "https://api.payments.test/*/charges", "method": "POST", "status": float64(201),
+				"headers": map[string]interface{}{"X-Request-Id": "req-1"},
+				"body":    map[string]interface{}{"id": "ch_1", "email": "{{ email }}"}},
+			map[string]interface{}{"url": "*://metrics.test/*", "abort": true},
+		},
+		"actions": []interface{}{
+			map[string]interface{}{"action": "click", "selector": "#pay"},
+			map[string]interface{}{"action": "wait_for_request", "url": "/v1/charges", "method": "post", "contains": `"currency":"EUR"`, "as": "charge"},
+		},
+	}, []interface{}{
+		map[string]interface{}{"type": "equals", "path": ".requests | length", "expected": 3},
+		map[string]interface{}{"type": "equals", "path": ".requests[0].json.amount", "expected": 4200},
+		map[string]interface{}{"type": "equals", "path": ".requests[0].status", "expected": 201},
+		map[string]interface{}{"type": "equals", "path": ".requests[1].status", "expected": 200},
+		map[string]interface{}{"type": "json_path", "path": ".requests[2].error", "expected": "net::ERR_FAILED"},
+	}, map[string]interface{}{"json_path": ".values.charge", "as": "charge"})
+	if err != nil {
+		t.Fatalf("checkout failed: %v", err)
+	}
+
+	if resp.Saved["charge"] != `{"amount":4200,"currency":"EUR"}` {
+		t.Errorf("expected the request body to be saved, got %q", resp.Saved["charge"])
+	}
+	if !resp.Response.Requests[0].Mocked || resp.Response.Requests[1].Mocked {
+		t.Errorf("expected only the routed requests to be mocked, got %+v", resp.Response.Requests)
+	}
+
+	preflight := pg.answers["OPTIONS https://api.payments.test/v1/charges"]
+	if preflight.how != "fulfill" || preflight.status != 204 || preflight.headers["Access-Control-Allow-Methods"] != "POST" {
+		t.Errorf("expected the preflight to be allowed, got %+v", preflight)
+	}
+	charge := pg.answers["POST https://api.payments.test/v1/charges"]
+	if charge.status != 201 || string(charge.body) != `{"email":"alice@example.com","id":"ch_1"}` {
+		t.Errorf("expected the charge to be answered from the route, got %+v (%s)", charge, charge.body)
+	}
+	for name, value := range map[string]string{"X-Request-Id": "req-1", "Content-Type": "application/json", "Access-Control-Allow-Origin": "https://shop.test"} {
+		if charge.headers[name] != value {
+			t.Errorf("expected header %s: %s, got %v", name, value, charge.headers)
+		}
+	}
+	if pg.answers["POST https://metrics.test/collect"].how != "abort" {
+		t.Errorf("expected the metrics call to be aborted, got %+v", pg.answers["POST https://metrics.test/collect"])
+	}
+	if pg.answers["GET https://shop.test/api/cart"].how != "fallback" {
+		t.Errorf("expected the cart call to go to the network, got %+v", pg.answers["GET https://shop.test/api/cart"])
+	}
+	if pg.route != nil || pg.events != nil {
+		t.Error("expected the route and the request listeners to be removed")
+	}
+}
+
+func TestWaitForRequestTimeout(t *testing.T) {
+	_, err := runStep(t, newCheckoutPage(), map[string]interface{}{
+		"actions": []interface{}{
+			map[string]interface{}{"action": "click", "selector": "#pay"},
+			map[string]interface{}{"action": "wait_for_request", "url": "/refunds", "method": "POST", "timeout": "300ms"},
+		},
+	}, nil)
+	want := `no POST request to a URL containing "/refunds" was made; the last requests were POST https://api.payments.test/v1/charges, GET https://shop.test/api/cart`
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Fatalf("expected error containing %q, got %v", want, err)
+	}
+}
+
+func TestEmulation(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   map[string]interface{}
+		emulated []string
+	}{
+		{
+			name:   "device with overrides",
+			config: map[string]interface{}{"device": "iPhone-15", "viewport": map[string]interface{}{"height": float64(700)}, "timezone": "Europe/Berlin", "locale": "de-DE"},
+			emulated: []string{
+				`setDeviceMetricsOverride {"deviceScaleFactor":3,"height":700,"mobile":true,"width":393}`,
+				`setTouchEmulationEnabled {"enabled":true,"maxTouchPoints":5}`,
+				`setUserAgentOverride {"acceptLanguage":"de-DE","userAgent":"` + iOSUserAgent + `"}`,
+				`setLocaleOverride {"locale":"de-DE"}`,
+				`setTimezoneOverride {"timezoneId":"Europe/Berlin"}`,
+				`setTimezoneOverride {"timezoneId":""}`,
+				`setLocaleOverride {}`,
+				`setUserAgentOverride {"userAgent":""}`,
+				`setTouchEmulationEnabled {"enabled":false}`,
+				`clearDeviceMetricsOverride {}`,
+			},
+		},
+		{
+			name:   "locale keeps the browser's user agent",
+			config: map[string]interface{}{"viewport": map[string]interface{}{"width": 1024, "height": 768}, "locale": "fr"},
+			emulated: []string{
+				`setDeviceMetricsOverride {"deviceScaleFactor":0,"height":768,"mobile":false,"width":1024}`,
+				`setUserAgentOverride {"acceptLanguage":"fr","userAgent":"Mozilla/5.0 HeadlessChrome/126.0.0.0"}`,
+				`setLocaleOverride {"locale":"fr"}`,
+				`setLocaleOverride {}`,
+				`setUserAgentOverride {"userAgent":""}`,
+				`clearDeviceMetricsOverride {}`,
+			},
+		},
+		{
+			name:   "user agent alone",
+			config: map[string]interface{}{"user_agent": "RocketshipBot/1.0"},
+			emulated: []string{
+				`setUserAgentOverride {"userAgent":"RocketshipBot/1.0"}`,
+				`setUserAgentOverride {"userAgent":""}`,
+			},
+		},
+		{
+			name:   "nothing to emulate",
+			config: map[string]interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &BrowserConfig{}
+			if err := parseConfig(tt.config, config); err != nil {
+				t.Fatal(err)
+			}
+			devtools := &fakeDevtools{}
+			reset, err := emulate(context.Background(), devtools, config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			reset()
+			if got, want := strings.Join(devtools.calls, "\n"), strings.Join(tt.emulated, "\n"); got != want {
+				t.Errorf("unexpected emulation calls\ngot:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestEmulationError(t *testing.T) {
+	devtools := &fakeDevtools{}
+	_, err := emulate(context.Background(), devtools, &BrowserConfig{Locale: "en-GB", Timezone: "Mars/Olympus_Mons"})
+	if err == nil || !strings.Contains(err.Error(), "failed to emulate timezone: Invalid timezone ID") {
+		t.Fatalf("expected the timezone to be rejected, got %v", err)
+	}
+	if got := strings.Join(devtools.calls[len(devtools.calls)-2:], ","); got != `setLocaleOverride {},setUserAgentOverride {"userAgent":""}` {
+		t.Errorf("expected the applied overrides to be undone, got %s", got)
+	}
+}
+
+func TestContextOptions(t *testing.T) {
+	config := &BrowserConfig{}
+	err := parseConfig(map[string]interface{}{"device": "pixel-7", "viewport": map[string]interface{}{"width": float64(400)}, "timezone": "Europe/Berlin", "locale": "de-DE"}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := contextOptions(config)
+	if opts.Viewport == nil || opts.Viewport.Width != 400 || opts.Viewport.Height != 915 || *opts.DeviceScaleFactor != 2.625 {
+		t.Errorf("expected the device's viewport with the width overridden, got %+v (scale %v)", opts.Viewport, opts.DeviceScaleFactor)
+	}
+	if !*opts.IsMobile || !*opts.HasTouch || !strings.Contains(*opts.UserAgent, "Pixel 7") {
+		t.Errorf("expected a mobile touchscreen with the device's user agent, got %+v", opts)
+	}
+	if *opts.Locale != "de-DE" || *opts.TimezoneId != "Europe/Berlin" {
+		t.Errorf("expected the locale and timezone, got %v and %v", *opts.Locale, *opts.TimezoneId)
+	}
+
+	if opts := contextOptions(&BrowserConfig{}); opts.Viewport != nil || opts.UserAgent != nil || opts.Locale != nil || opts.TimezoneId != nil {
+		t.Errorf("expected the browser's defaults without emulation, got %+v", opts)
+	}
+}
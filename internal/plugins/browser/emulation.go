@@ -0,0 +1,229 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// device is the screen and browser of a device preset
+type device struct {
+	viewport  Viewport
+	userAgent string // The browser's own when empty
+}
+
+const (
+	iOSUserAgent     = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1"
+	iPadUserAgent    = "Mozilla/5.0 (iPad; CPU OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1"
+	androidUserAgent = "Mozilla/5.0 (Linux; Android 14; %s) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36"
+)
+
+// devices are the presets device can name
+var devices = map[string]device{
+	"iphone-se":         {touchscreen(375, 667, 2), iOSUserAgent},
+	"iphone-15":         {touchscreen(393, 852, 3), iOSUserAgent},
+	"iphone-15-pro-max": {touchscreen(430, 932, 3), iOSUserAgent},
+	"ipad-mini":         {touchscreen(744, 1133, 2), iPadUserAgent},
+	"ipad-pro-11":       {touchscreen(834, 1194, 2), iPadUserAgent},
+	"pixel-7":           {touchscreen(412, 915, 2.625), fmt.Sprintf(androidUserAgent, "Pixel 7")},
+	"galaxy-s23":        {touchscreen(360, 780, 3), fmt.Sprintf(androidUserAgent, "SM-S911B")},
+	"desktop":           {Viewport{Width: 1280, Height: 800, ScaleFactor: 1}, ""},
+	"desktop-hd":        {Viewport{Width: 1920, Height: 1080, ScaleFactor: 1}, ""},
+}
+
+func touchscreen(width, height int, scale float64) Viewport {
+	yes := true
+	return Viewport{Width: width, Height: height, ScaleFactor: scale, Mobile: &yes, Touch: &yes}
+}
+
+// deviceNames lists the presets for errors
+func deviceNames() string {
+	names := make([]string, 0, len(devices))
+	for name := range devices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// localePattern matches BCP 47 tags such as de, de-DE and zh-Hant-TW
+var localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// emulatedViewport returns the device's viewport with the step's viewport
+// fields over it, or nil when the step keeps the browser's own
+func emulatedViewport(config *BrowserConfig) *Viewport {
+	if config.Device == "" && config.Viewport == nil {
+		return nil
+	}
+	viewport := devices[strings.ToLower(config.Device)].viewport
+	if v := config.Viewport; v != nil {
+		if v.Width != 0 {
+			viewport.Width = v.Width
+		}
+		if v.Height != 0 {
+			viewport.Height = v.Height
+		}
+		if v.ScaleFactor != 0 {
+			viewport.ScaleFactor = v.ScaleFactor
+		}
+		if v.Mobile != nil {
+			viewport.Mobile = v.Mobile
+		}
+		if v.Touch != nil {
+			viewport.Touch = v.Touch
+		}
+	}
+	return &viewport
+}
+
+// contextOptions returns the browser context of a launched browser with the
+// step's device, viewport, user agent, timezone and locale
+func contextOptions(config *BrowserConfig) playwright.BrowserNewContextOptions {
+	opts := playwright.BrowserNewContextOptions{}
+	if viewport := emulatedViewport(config); viewport != nil {
+		opts.Viewport = &playwright.Size{Width: viewport.Width, Height: viewport.Height}
+		if viewport.ScaleFactor != 0 {
+			opts.DeviceScaleFactor = playwright.Float(viewport.ScaleFactor)
+		}
+		opts.IsMobile = viewport.Mobile
+		opts.HasTouch = viewport.Touch
+	}
+
+	userAgent := config.UserAgent
+	if userAgent == "" {
+		userAgent = devices[strings.ToLower(config.Device)].userAgent
+	}
+	if userAgent != "" {
+		opts.UserAgent = playwright.String(userAgent)
+	}
+	if config.Locale != "" {
+		opts.Locale = playwright.String(config.Locale)
+	}
+	if config.Timezone != "" {
+		opts.TimezoneId = playwright.String(config.Timezone)
+	}
+	return opts
+}
+
+// devtools sends DevTools commands to a page
+type devtools interface {
+	Call(ctx context.Context, method string, params map[string]interface{}) error
+	UserAgent(ctx context.Context) (string, error)
+}
+
+// emulate applies the step's device, viewport, user agent, timezone and locale
+// to a session's page, whose browser context Playwright didn't create. The
+// returned function undoes them, so the next step in the session sees the
+// browser as it was.
+func emulate(ctx context.Context, pg devtools, config *BrowserConfig) (func(), error) {
+	var undo []func(ctx context.Context)
+	reset := func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i](ctx)
+		}
+	}
+	// apply calls method, and remembers the call that undoes it
+	apply := func(what, method string, params map[string]interface{}, resetMethod string, resetParams map[string]interface{}) error {
+		if err := pg.Call(ctx, method, params); err != nil {
+			return fmt.Errorf("failed to emulate %s: %w", what, err)
+		}
+		undo = append(undo, func(ctx context.Context) {
+			_ = pg.Call(ctx, resetMethod, resetParams)
+		})
+		return nil
+	}
+	fail := func(err error) (func(), error) {
+		reset()
+		return nil, err
+	}
+
+	if viewport := emulatedViewport(config); viewport != nil {
+		mobile := viewport.Mobile != nil && *viewport.Mobile
+		err := apply("viewport", "Emulation.setDeviceMetricsOverride", map[string]interface{}{
+			"width":             viewport.Width,
+			"height":            viewport.Height,
+			"deviceScaleFactor": viewport.ScaleFactor,
+			"mobile":            mobile,
+		}, "Emulation.clearDeviceMetricsOverride", map[string]interface{}{})
+		if err != nil {
+			return fail(err)
+		}
+		if viewport.Touch != nil && *viewport.Touch {
+			err := apply("touch", "Emulation.setTouchEmulationEnabled", map[string]interface{}{"enabled": true, "maxTouchPoints": 5},
+				"Emulation.setTouchEmulationEnabled", map[string]interface{}{"enabled": false})
+			if err != nil {
+				return fail(err)
+			}
+		}
+	}
+
+	userAgent := config.UserAgent
+	if userAgent == "" {
+		userAgent = devices[strings.ToLower(config.Device)].userAgent
+	}
+	if userAgent != "" || config.Locale != "" {
+		// Accept-Language can only be set along with a user agent
+		if userAgent == "" {
+			var err error
+			if userAgent, err = pg.UserAgent(ctx); err != nil {
+				return fail(fmt.Errorf("failed to read the user agent: %w", err))
+			}
+		}
+		params := map[string]interface{}{"userAgent": userAgent}
+		if config.Locale != "" {
+			params["acceptLanguage"] = config.Locale
+		}
+		err := apply("user agent", "Emulation.setUserAgentOverride", params, "Emulation.setUserAgentOverride", map[string]interface{}{"userAgent": ""})
+		if err != nil {
+			return fail(err)
+		}
+	}
+
+	if config.Locale != "" {
+		err := apply("locale", "Emulation.setLocaleOverride", map[string]interface{}{"locale": config.Locale},
+			"Emulation.setLocaleOverride", map[string]interface{}{})
+		if err != nil {
+			return fail(err)
+		}
+	}
+	if config.Timezone != "" {
+		err := apply("timezone", "Emulation.setTimezoneOverride", map[string]interface{}{"timezoneId": config.Timezone},
+			"Emulation.setTimezoneOverride", map[string]interface{}{"timezoneId": ""})
+		if err != nil {
+			return fail(err)
+		}
+	}
+
+	return reset, nil
+}
+
+// validateEmulation checks the device, viewport and locale
+func validateEmulation(config *BrowserConfig) error {
+	if config.Device != "" {
+		if _, ok := devices[strings.ToLower(config.Device)]; !ok {
+			return fmt.Errorf("unknown device %q: must be one of %s", config.Device, deviceNames())
+		}
+	}
+	if v := config.Viewport; v != nil {
+		if v.Width < 0 || v.Height < 0 || v.ScaleFactor < 0 {
+			return fmt.Errorf("viewport width, height and scale_factor must be positive")
+		}
+		if config.Device == "" && (v.Width == 0 || v.Height == 0) {
+			return fmt.Errorf("viewport needs width and height, unless device sets them")
+		}
+		if v.Width > 10000 || v.Height > 10000 {
+			return fmt.Errorf("viewport can be at most 10000x10000, got %dx%d", v.Width, v.Height)
+		}
+	}
+	if config.Locale != "" && !localePattern.MatchString(config.Locale) {
+		return fmt.Errorf("invalid locale %q: use a language tag such as en-US", config.Locale)
+	}
+	return nil
+}
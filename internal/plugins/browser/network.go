@@ -0,0 +1,280 @@
+package browser
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// maxRequests caps the requests a step records
+const maxRequests = 1000
+
+// network records the page's XHR and fetch requests and answers the ones that
+// match a route
+type network struct {
+	routes []route
+
+	mu        sync.Mutex
+	requests  []Request
+	byRequest map[request]int // Index in requests
+	off       []func()
+}
+
+// route is a Route with its URL pattern compiled
+type route struct {
+	Route
+	pattern *regexp.Regexp
+}
+
+// request is a request the page made. Each one is the same value in every
+// event about it.
+type request interface {
+	Method() string
+	URL() string
+	ResourceType() string // e.g. xhr, fetch or document
+	Headers() map[string]string
+	PostData() (string, error)
+}
+
+// intercepted is a request held by a route until it is answered
+type intercepted interface {
+	Request() request
+	Fulfill(status int, headers map[string]string, body []byte) error
+	Abort() error
+	// Fallback lets the request go on to the network
+	Fallback() error
+}
+
+// requestEvents are called as the page's requests progress. They run on the
+// browser connection's event loop, so they must not call the page.
+type requestEvents struct {
+	sent     func(request)
+	answered func(request, int)
+	failed   func(request, string)
+}
+
+// header returns a request header, ignoring the case of its name
+func header(r request, name string) string {
+	for key, value := range r.Headers() {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// interceptNetwork starts recording requests and, when there are routes,
+// holds the requests that match one so it can answer them
+func interceptNetwork(pg page, routes []Route) (*network, error) {
+	n := &network{byRequest: make(map[request]int)}
+	for _, r := range routes {
+		n.routes = append(n.routes, route{Route: r, pattern: globPattern(r.URL)})
+	}
+
+	n.off = append(n.off, pg.Listen(requestEvents{sent: n.sent, answered: n.answered, failed: n.failed}))
+	if len(n.routes) == 0 {
+		return n, nil
+	}
+	unroute, err := pg.Route(n.answer)
+	if err != nil {
+		n.close()
+		return nil, fmt.Errorf("failed to intercept requests: %w", err)
+	}
+	n.off = append(n.off, unroute)
+	return n, nil
+}
+
+// globPattern compiles a URL pattern where * matches any characters, slashes
+// included, and ? one
+func globPattern(glob string) *regexp.Regexp {
+	quoted := regexp.QuoteMeta(glob)
+	quoted = strings.ReplaceAll(quoted, `\*`, ".*")
+	quoted = strings.ReplaceAll(quoted, `\?`, ".")
+	return regexp.MustCompile("^" + quoted + "$")
+}
+
+// record adds a request, or replaces the one recorded for it. A route and the
+// page's events may see a request in either order.
+func (n *network) record(r request, recorded Request) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if i, ok := n.byRequest[r]; ok {
+		n.requests[i] = recorded
+		return
+	}
+	if len(n.requests) >= maxRequests {
+		return
+	}
+	n.byRequest[r] = len(n.requests)
+	n.requests = append(n.requests, recorded)
+}
+
+// update changes a recorded request
+func (n *network) update(r request, change func(*Request)) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if i, ok := n.byRequest[r]; ok {
+		change(&n.requests[i])
+	}
+}
+
+func (n *network) sent(r request) {
+	if t := r.ResourceType(); t != "xhr" && t != "fetch" {
+		return
+	}
+	n.mu.Lock()
+	_, seen := n.byRequest[r]
+	n.mu.Unlock()
+	if seen {
+		// Already recorded by a route, which knows how it was answered
+		return
+	}
+	n.record(r, newRequest(r))
+}
+
+func (n *network) answered(r request, status int) {
+	n.update(r, func(recorded *Request) { recorded.Status = status })
+}
+
+func (n *network) failed(r request, reason string) {
+	n.update(r, func(recorded *Request) {
+		if recorded.Error == "" {
+			recorded.Error = reason
+		}
+	})
+}
+
+// answer answers a request that matches a route, and lets the others through.
+// CORS preflights for a route are allowed, so pages can call stubbed APIs on
+// other origins.
+func (n *network) answer(in intercepted) {
+	r := in.Request()
+	preflight := r.Method() == http.MethodOptions && header(r, "Access-Control-Request-Method") != ""
+	for _, rt := range n.routes {
+		if !rt.pattern.MatchString(r.URL()) {
+			continue
+		}
+		if preflight {
+			_ = in.Fulfill(http.StatusNoContent, corsHeaders(r, nil, true), nil)
+			return
+		}
+		if rt.Method != "" && !strings.EqualFold(rt.Method, r.Method()) {
+			continue
+		}
+
+		recorded := newRequest(r)
+		recorded.Mocked = true
+		if rt.Abort {
+			recorded.Error = "net::ERR_FAILED"
+			n.record(r, recorded)
+			_ = in.Abort()
+			return
+		}
+
+		status := rt.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		body, contentType := routeBody(rt.Body)
+		headers := map[string]string{}
+		if contentType != "" {
+			headers["Content-Type"] = contentType
+		}
+		for name, value := range rt.Headers {
+			headers[name] = value
+		}
+		recorded.Status = status
+		n.record(r, recorded)
+		_ = in.Fulfill(status, corsHeaders(r, headers, false), body)
+		return
+	}
+	_ = in.Fallback()
+}
+
+// routeBody encodes a route's body: text as it is, anything else as JSON
+func routeBody(body interface{}) ([]byte, string) {
+	switch b := body.(type) {
+	case nil:
+		return nil, ""
+	case string:
+		return []byte(b), "text/plain; charset=utf-8"
+	default:
+		data, _ := json.Marshal(b)
+		return data, "application/json"
+	}
+}
+
+// corsHeaders adds the headers that let the requesting page read a stubbed
+// response, unless the route sets them
+func corsHeaders(r request, headers map[string]string, preflight bool) map[string]string {
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	set := func(name, value string) {
+		for existing := range headers {
+			if strings.EqualFold(existing, name) {
+				return
+			}
+		}
+		if value != "" {
+			headers[name] = value
+		}
+	}
+	if origin := header(r, "Origin"); origin != "" {
+		set("Access-Control-Allow-Origin", origin)
+		set("Access-Control-Allow-Credentials", "true")
+	}
+	if preflight {
+		set("Access-Control-Allow-Methods", header(r, "Access-Control-Request-Method"))
+		set("Access-Control-Allow-Headers", header(r, "Access-Control-Request-Headers"))
+	}
+	return headers
+}
+
+// newRequest turns a request into a recorded one, decoding a JSON body
+func newRequest(r request) Request {
+	body, _ := r.PostData()
+	recorded := Request{Method: r.Method(), URL: r.URL(), Type: r.ResourceType(), Body: body}
+	var decoded interface{}
+	if recorded.Body != "" && json.Unmarshal([]byte(recorded.Body), &decoded) == nil {
+		recorded.JSON = decoded
+	}
+	return recorded
+}
+
+// recorded returns the requests made so far
+func (n *network) recorded() []Request {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]Request{}, n.requests...)
+}
+
+// find returns the first recorded request the action matches
+func (n *network) find(action Action) (Request, bool) {
+	for _, request := range n.recorded() {
+		if !strings.Contains(request.URL, action.URL) {
+			continue
+		}
+		if action.Method != "" && !strings.EqualFold(action.Method, request.Method) {
+			continue
+		}
+		if action.Contains != "" && !strings.Contains(request.Body, action.Contains) {
+			continue
+		}
+		return request, true
+	}
+	return Request{}, false
+}
+
+// close stops recording and intercepting. Routes are removed even when the
+// step ran out of time, or the page's requests would stay held in a shared
+// session.
+func (n *network) close() {
+	for i := len(n.off) - 1; i >= 0; i-- {
+		n.off[i]()
+	}
+	n.off = nil
+}
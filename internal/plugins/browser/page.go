@@ -0,0 +1,239 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/rocketship-ai/rocketship/internal/browser/pw"
+)
+
+// quickTimeout bounds reads of the page that shouldn't wait for anything
+const quickTimeout = 1000.0 // ms
+
+// readScript reads an element's text, the value of a form field, or an attribute
+const readScript = `(el, attribute) => attribute ? el.getAttribute(attribute)
+  : ['INPUT', 'TEXTAREA', 'SELECT'].includes(el.tagName) ? el.value : (el.innerText ?? el.textContent ?? '')`
+
+// hasOptionScript tells whether a select element has an option with the value or label
+const hasOptionScript = `(el, value) => Array.from(el.options || []).some(o => o.value === value || o.label === value)`
+
+// waitStates maps wait_for states to Playwright's
+var waitStates = map[string]*playwright.WaitForSelectorState{
+	StateVisible:  playwright.WaitForSelectorStateVisible,
+	StateHidden:   playwright.WaitForSelectorStateHidden,
+	StateAttached: playwright.WaitForSelectorStateAttached,
+	StateDetached: playwright.WaitForSelectorStateDetached,
+}
+
+// playwrightPage is a page for the actions, backed by a Playwright tab
+type playwrightPage struct {
+	tab     *pw.Tab
+	session playwright.CDPSession // Opened on the first DevTools call
+}
+
+func (p *playwrightPage) Navigate(ctx context.Context, url string) error {
+	_, err := p.tab.Page.Goto(url, playwright.PageGotoOptions{Timeout: pw.Timeout(ctx), WaitUntil: playwright.WaitUntilStateLoad})
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", url, pw.Reason(err))
+	}
+	return nil
+}
+
+func (p *playwrightPage) Location(ctx context.Context) (location, error) {
+	title, err := pw.Await(ctx, p.tab.Page.Title)
+	if err != nil {
+		return location{}, pw.Reason(err)
+	}
+	return location{URL: p.tab.Page.URL(), Title: title}, nil
+}
+
+func (p *playwrightPage) locator(selector string) playwright.Locator {
+	return p.tab.Page.Locator(selector).First()
+}
+
+func (p *playwrightPage) State(ctx context.Context, selector string) (element, error) {
+	loc := p.locator(selector)
+	if count, err := loc.Count(); err != nil || count == 0 {
+		return element{}, pw.Reason(err)
+	}
+	visible, err := loc.IsVisible()
+	if err != nil {
+		return element{}, pw.Reason(err)
+	}
+	enabled, err := loc.IsEnabled(playwright.LocatorIsEnabledOptions{Timeout: playwright.Float(quickTimeout)})
+	if err != nil {
+		return element{}, pw.Reason(err)
+	}
+	return element{Found: true, Visible: visible, Enabled: enabled}, nil
+}
+
+func (p *playwrightPage) Read(ctx context.Context, selector, attribute string) (element, error) {
+	loc := p.locator(selector)
+	if count, err := loc.Count(); err != nil || count == 0 {
+		return element{}, pw.Reason(err)
+	}
+	value, err := loc.Evaluate(readScript, attribute, playwright.LocatorEvaluateOptions{Timeout: playwright.Float(quickTimeout)})
+	if err != nil {
+		return element{}, pw.Reason(err)
+	}
+	state := element{Found: true}
+	if text, ok := value.(string); ok {
+		state.Value = &text
+	}
+	return state, nil
+}
+
+func (p *playwrightPage) Click(ctx context.Context, selector string) error {
+	return pw.Reason(p.locator(selector).Click(playwright.LocatorClickOptions{Timeout: pw.Timeout(ctx)}))
+}
+
+func (p *playwrightPage) Fill(ctx context.Context, selector, value string) error {
+	return pw.Reason(p.locator(selector).Fill(value, playwright.LocatorFillOptions{Timeout: pw.Timeout(ctx)}))
+}
+
+func (p *playwrightPage) Press(ctx context.Context, selector, key string) error {
+	if selector == "" {
+		_, err := pw.Await(ctx, func() (struct{}, error) {
+			return struct{}{}, p.tab.Page.Keyboard().Press(key)
+		})
+		return pw.Reason(err)
+	}
+	return pw.Reason(p.locator(selector).Press(key, playwright.LocatorPressOptions{Timeout: pw.Timeout(ctx)}))
+}
+
+func (p *playwrightPage) Select(ctx context.Context, selector, value string) (bool, error) {
+	loc := p.locator(selector)
+	// Waits for the element, then checks the option exists, since selecting
+	// waits for a missing option until the timeout
+	found, err := loc.Evaluate(hasOptionScript, value, playwright.LocatorEvaluateOptions{Timeout: pw.Timeout(ctx)})
+	if err != nil {
+		return false, pw.Reason(err)
+	}
+	if ok, _ := found.(bool); !ok {
+		return false, nil
+	}
+	_, err = loc.SelectOption(playwright.SelectOptionValues{ValuesOrLabels: &[]string{value}}, playwright.LocatorSelectOptionOptions{Timeout: pw.Timeout(ctx)})
+	return err == nil, pw.Reason(err)
+}
+
+func (p *playwrightPage) WaitFor(ctx context.Context, selector, state string) error {
+	return pw.Reason(p.locator(selector).WaitFor(playwright.LocatorWaitForOptions{State: waitStates[state], Timeout: pw.Timeout(ctx)}))
+}
+
+func (p *playwrightPage) Route(handler func(intercepted)) (func(), error) {
+	err := p.tab.Page.Route(func(string) bool { return true }, func(route playwright.Route) {
+		handler(playwrightRoute{route})
+	})
+	if err != nil {
+		return nil, pw.Reason(err)
+	}
+	return func() {
+		_ = p.tab.Page.UnrouteAll(playwright.PageUnrouteAllOptions{Behavior: playwright.UnrouteBehaviorIgnoreErrors})
+	}, nil
+}
+
+func (p *playwrightPage) Listen(events requestEvents) func() {
+	onRequest := func(r playwright.Request) { events.sent(r) }
+	onResponse := func(r playwright.Response) { events.answered(r.Request(), r.Status()) }
+	onFailed := func(r playwright.Request) {
+		reason := "failed"
+		if err := r.Failure(); err != nil {
+			reason = err.Error()
+		}
+		events.failed(r, reason)
+	}
+	p.tab.Page.OnRequest(onRequest)
+	p.tab.Page.OnResponse(onResponse)
+	p.tab.Page.OnRequestFailed(onFailed)
+	return func() {
+		p.tab.Page.RemoveListener("request", onRequest)
+		p.tab.Page.RemoveListener("response", onResponse)
+		p.tab.Page.RemoveListener("requestfailed", onFailed)
+	}
+}
+
+func (p *playwrightPage) Screenshot(ctx context.Context) ([]byte, error) {
+	return p.tab.Screenshot(ctx, nil)
+}
+
+func (p *playwrightPage) StartTrace() error {
+	err := p.tab.Context.Tracing().Start(playwright.TracingStartOptions{
+		Screenshots: playwright.Bool(true),
+		Snapshots:   playwright.Bool(true),
+	})
+	return pw.Reason(err)
+}
+
+// StopTrace ends the trace and reads the zip Playwright writes it to
+func (p *playwrightPage) StopTrace(ctx context.Context) ([]byte, error) {
+	return saved(ctx, "trace.zip", func(path string) error {
+		return p.tab.Context.Tracing().Stop(path)
+	})
+}
+
+// Video closes the page, which finishes its video, and reads the video
+func (p *playwrightPage) Video(ctx context.Context) ([]byte, error) {
+	video := p.tab.Page.Video()
+	if err := p.tab.Page.Close(); err != nil {
+		return nil, pw.Reason(err)
+	}
+	defer func() { _ = video.Delete() }()
+	return saved(ctx, "video.webm", video.SaveAs)
+}
+
+// saved has Playwright save a file into a temporary directory and reads it
+func saved(ctx context.Context, name string, save func(path string) error) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "rocketship-browser-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, name)
+	if _, err := pw.Await(ctx, func() (struct{}, error) { return struct{}{}, save(path) }); err != nil {
+		return nil, pw.Reason(err)
+	}
+	return os.ReadFile(path)
+}
+
+// Call sends a DevTools command to the page
+func (p *playwrightPage) Call(ctx context.Context, method string, params map[string]interface{}) error {
+	if p.session == nil {
+		session, err := p.tab.Context.NewCDPSession(p.tab.Page)
+		if err != nil {
+			return fmt.Errorf("failed to open a DevTools session: %w", pw.Reason(err))
+		}
+		p.session = session
+	}
+	_, err := pw.Await(ctx, func() (interface{}, error) { return p.session.Send(method, params) })
+	return pw.Reason(err)
+}
+
+func (p *playwrightPage) UserAgent(ctx context.Context) (string, error) {
+	var userAgent string
+	err := p.tab.Evaluate(ctx, "navigator.userAgent", &userAgent)
+	return userAgent, err
+}
+
+// close detaches the DevTools session, if one was opened
+func (p *playwrightPage) close() {
+	if p.session != nil {
+		_ = p.session.Detach()
+	}
+}
+
+// playwrightRoute is a request held by a Playwright route
+type playwrightRoute struct {
+	route playwright.Route
+}
+
+func (r playwrightRoute) Request() request { return r.route.Request() }
+
+func (r playwrightRoute) Fulfill(status int, headers map[string]string, body []byte) error {
+	return r.route.Fulfill(playwright.RouteFulfillOptions{Status: playwright.Int(status), Headers: headers, Body: body})
+}
+
+func (r playwrightRoute) Abort() error    { return r.route.Abort("failed") }
+func (r playwrightRoute) Fallback() error { return r.route.Fallback() }
@@ -0,0 +1,83 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/artifacts"
+)
+
+// Screenshot modes
+const (
+	ScreenshotOnFailure = "on_failure"
+	ScreenshotAlways    = "always"
+	ScreenshotNever     = "never"
+)
+
+// captureTimeout bounds saving the artifacts once the step is over
+const captureTimeout = 30 * time.Second
+
+// recording captures the artifacts a step asks for: a video and a trace of
+// its actions, and a screenshot of the page they leave behind. The video is
+// recorded by the browser context the step launched, from its first frame.
+type recording struct {
+	page   page
+	config *BrowserConfig
+	runID  string
+	name   string // Prefix of the artifact names
+}
+
+// startRecording starts the trace when the step asks for one
+func startRecording(pg page, config *BrowserConfig, runID, name string) (*recording, error) {
+	if config.Trace {
+		if err := pg.StartTrace(); err != nil {
+			return nil, fmt.Errorf("failed to start trace: %w", err)
+		}
+	}
+	return &recording{page: pg, config: config, runID: runID, name: name}, nil
+}
+
+// finish stops the recordings and saves the step's artifacts. The screenshot
+// is taken first, so it shows the page as the step left it, and the video
+// last, since it is only complete once the page is closed.
+func (r *recording) finish(ctx context.Context, failed bool) ([]artifacts.Artifact, error) {
+	var saved []artifacts.Artifact
+	var errs []error
+	save := func(name, artifactType, mimeType string, data []byte) {
+		artifact, err := artifacts.Save(r.runID, r.name+name, artifactType, mimeType, data)
+		if err != nil {
+			errs = append(errs, err)
+			return
+		}
+		saved = append(saved, artifact)
+	}
+
+	mode := r.config.Screenshot
+	if mode == ScreenshotAlways || (failed && mode != ScreenshotNever) {
+		if shot, err := r.page.Screenshot(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to capture screenshot: %w", err))
+		} else {
+			save(".png", "screenshot", "image/png", shot)
+		}
+	}
+
+	if r.config.Trace {
+		if trace, err := r.page.StopTrace(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to record trace: %w", err))
+		} else {
+			save("-trace.zip", "trace", "application/zip", trace)
+		}
+	}
+
+	if r.config.Video {
+		if video, err := r.page.Video(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to record video: %w", err))
+		} else {
+			save(".webm", "video", "video/webm", video)
+		}
+	}
+
+	return saved, errors.Join(errs...)
+}
@@ -0,0 +1,132 @@
+package browser
+
+import (
+	"github.com/rocketship-ai/rocketship/internal/artifacts"
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+)
+
+// BrowserPlugin represents a scripted browser test step
+type BrowserPlugin struct {
+	Name   string        `json:"name" yaml:"name"`
+	Plugin string        `json:"plugin" yaml:"plugin"`
+	Config BrowserConfig `json:"config" yaml:"config"`
+}
+
+// BrowserConfig selects the browser and the actions to run in it
+type BrowserConfig struct {
+	URL           string    `json:"url,omitempty" yaml:"url,omitempty"`                       // Page to open before the actions
+	SessionID     string    `json:"session_id,omitempty" yaml:"session_id,omitempty"`         // Browser started by playwright role start; a new one is launched when empty
+	Executable    string    `json:"executable,omitempty" yaml:"executable,omitempty"`         // Chromium to launch (defaults to ROCKETSHIP_CHROME_PATH, then Playwright's)
+	Headless      *bool     `json:"headless,omitempty" yaml:"headless,omitempty"`             // Defaults to true
+	Actions       []Action  `json:"actions,omitempty" yaml:"actions,omitempty"`               // Run in order; the first failure fails the step
+	Routes        []Route   `json:"routes,omitempty" yaml:"routes,omitempty"`                 // Requests to answer instead of the network
+	ActionTimeout string    `json:"action_timeout,omitempty" yaml:"action_timeout,omitempty"` // How long each action waits (defaults to 10s)
+	Timeout       string    `json:"timeout,omitempty" yaml:"timeout,omitempty"`               // Overall step timeout (defaults to 2m)
+	Screenshot    string    `json:"screenshot,omitempty" yaml:"screenshot,omitempty"`         // on_failure (default), always or never
+	Video         bool      `json:"video,omitempty" yaml:"video,omitempty"`                   // Record the actions as a WebM video artifact (not with session_id)
+	Trace         bool      `json:"trace,omitempty" yaml:"trace,omitempty"`                   // Record a Playwright trace artifact
+	Device        string    `json:"device,omitempty" yaml:"device,omitempty"`                 // Preset viewport and user agent, e.g. iphone-15
+	Viewport      *Viewport `json:"viewport,omitempty" yaml:"viewport,omitempty"`             // Overrides the device's viewport fields
+	UserAgent     string    `json:"user_agent,omitempty" yaml:"user_agent,omitempty"`         // Overrides the device's user agent
+	Timezone      string    `json:"timezone,omitempty" yaml:"timezone,omitempty"`             // IANA timezone, e.g. Europe/Berlin
+	Locale        string    `json:"locale,omitempty" yaml:"locale,omitempty"`                 // Language tag for Intl and Accept-Language, e.g. de-DE
+}
+
+// Viewport is the screen a step emulates
+type Viewport struct {
+	Width       int     `json:"width,omitempty" yaml:"width,omitempty"`               // CSS pixels
+	Height      int     `json:"height,omitempty" yaml:"height,omitempty"`             // CSS pixels
+	ScaleFactor float64 `json:"scale_factor,omitempty" yaml:"scale_factor,omitempty"` // Device pixels per CSS pixel
+	Mobile      *bool   `json:"mobile,omitempty" yaml:"mobile,omitempty"`             // Honour the page's meta viewport, as mobile browsers do
+	Touch       *bool   `json:"touch,omitempty" yaml:"touch,omitempty"`               // Report a touchscreen
+}
+
+// Action is one browser interaction or check
+type Action struct {
+	Action    string `json:"action" yaml:"action"`
+	Selector  string `json:"selector,omitempty" yaml:"selector,omitempty"`   // CSS selector of the element
+	URL       string `json:"url,omitempty" yaml:"url,omitempty"`             // navigate: page to load; wait_for, wait_for_request: text the URL must contain
+	Value     string `json:"value,omitempty" yaml:"value,omitempty"`         // fill: text to type; select: option value or label
+	Key       string `json:"key,omitempty" yaml:"key,omitempty"`             // press: key name, e.g. Enter
+	State     string `json:"state,omitempty" yaml:"state,omitempty"`         // wait_for: visible (default), hidden, attached or detached
+	Attribute string `json:"attribute,omitempty" yaml:"attribute,omitempty"` // assert_attribute, extract: attribute to read
+	Method    string `json:"method,omitempty" yaml:"method,omitempty"`       // wait_for_request: HTTP method the request must have
+	Expected  string `json:"expected,omitempty" yaml:"expected,omitempty"`   // assert_text, assert_attribute: exact value
+	Contains  string `json:"contains,omitempty" yaml:"contains,omitempty"`   // assert_text, assert_attribute: substring; wait_for_request: text the body must contain
+	As        string `json:"as,omitempty" yaml:"as,omitempty"`               // extract, wait_for_request: name in values
+	Timeout   string `json:"timeout,omitempty" yaml:"timeout,omitempty"`     // Overrides action_timeout
+}
+
+// Actions supported by the browser plugin
+const (
+	ActionNavigate        = "navigate"
+	ActionClick           = "click"
+	ActionFill            = "fill"
+	ActionPress           = "press"
+	ActionSelect          = "select"
+	ActionWaitFor         = "wait_for"
+	ActionAssertText      = "assert_text"
+	ActionAssertAttribute = "assert_attribute"
+	ActionExtract         = "extract"
+	ActionWaitForRequest  = "wait_for_request"
+)
+
+// Route answers the page's requests to matching URLs instead of the network,
+// e.g. to stub analytics or a payment provider
+type Route struct {
+	URL     string            `json:"url" yaml:"url"`                             // Pattern where * matches any characters and ? one
+	Method  string            `json:"method,omitempty" yaml:"method,omitempty"`   // Only requests with this method; any when empty
+	Status  int               `json:"status,omitempty" yaml:"status,omitempty"`   // Defaults to 200
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"` // Response headers
+	Body    interface{}       `json:"body,omitempty" yaml:"body,omitempty"`       // Text as it is; other values are sent as JSON
+	Abort   bool              `json:"abort,omitempty" yaml:"abort,omitempty"`     // Fail the request with a network error instead
+}
+
+// Element states wait_for can wait for
+const (
+	StateVisible  = "visible"
+	StateHidden   = "hidden"
+	StateAttached = "attached"
+	StateDetached = "detached"
+)
+
+// ActionResult records an action that ran
+type ActionResult struct {
+	Action   string `json:"action"`
+	Selector string `json:"selector,omitempty"`
+	Value    string `json:"value,omitempty"` // Text or attribute read by assertions and extract, or the body wait_for_request found
+	Duration string `json:"duration"`
+}
+
+// Request is an XHR or fetch request the page made, or any request a route answered
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Type   string      `json:"type"`           // xhr, fetch, or the resource type of a routed request
+	Body   string      `json:"body,omitempty"` // Request body
+	JSON   interface{} `json:"json,omitempty"` // Body decoded, when it is JSON
+	Status int         `json:"status,omitempty"`
+	Error  string      `json:"error,omitempty"`  // Why the request failed, e.g. net::ERR_FAILED
+	Mocked bool        `json:"mocked,omitempty"` // Answered by a route
+}
+
+// BrowserResponse describes the page after the actions ran
+type BrowserResponse struct {
+	URL      string            `json:"url"`
+	Title    string            `json:"title"`
+	Values   map[string]string `json:"values"`   // Extracted values, by as
+	Actions  []ActionResult    `json:"actions"`  // Actions that ran
+	Requests []Request         `json:"requests"` // XHR and fetch requests, and the requests routes answered
+	Duration string            `json:"duration"`
+}
+
+// AssertionResult represents a single assertion result for UI display
+type AssertionResult = assertions.Result
+
+// ActivityResponse represents the complete activity response
+type ActivityResponse struct {
+	Response         *BrowserResponse     `json:"response"`
+	Saved            map[string]string    `json:"saved"`
+	AssertionResults []AssertionResult    `json:"assertion_results,omitempty"`
+	Artifacts        []artifacts.Artifact `json:"artifacts,omitempty"` // Screenshots and recordings saved for the run
+}
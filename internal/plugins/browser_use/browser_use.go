@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/rocketship-ai/rocketship/internal/browser/pw"
 	"github.com/rocketship-ai/rocketship/internal/browser/sessionfile"
 	"github.com/rocketship-ai/rocketship/internal/dsl"
 	"github.com/rocketship-ai/rocketship/internal/plugins"
@@ -36,20 +37,34 @@ type Config struct {
 	Temperature    *float64
 	Timeout        string
 	LLM            LLMConfig
+	MaxTokens      int              // Tokens the agent may use across its steps; unlimited when 0
+	MaxCost        float64          // USD the agent may spend across its steps; unlimited when 0
+	Storage        *pw.StorageState // Cookies and local storage to put in the browser before the agent starts
 }
 
 type LLMConfig struct {
 	Provider string
 	Model    string
+	BaseURL  string // Endpoint for azure_openai and openai_compatible
 	Config   map[string]string
 }
 
+// LLM providers the runner can create a model for
+const (
+	ProviderOpenAI           = "openai"
+	ProviderAnthropic        = "anthropic"
+	ProviderAzureOpenAI      = "azure_openai"
+	ProviderGemini           = "gemini"
+	ProviderOpenAICompatible = "openai_compatible"
+)
+
 type runnerResponse struct {
 	Success   bool                   `json:"ok"`
 	Error     string                 `json:"error,omitempty"`
 	Result    interface{}            `json:"result,omitempty"`
 	FinalURL  string                 `json:"finalUrl,omitempty"`
 	Artifacts map[string]interface{} `json:"artifacts,omitempty"`
+	Usage     map[string]interface{} `json:"usage,omitempty"`
 	Traceback string                 `json:"traceback,omitempty"`
 }
 
@@ -102,6 +117,13 @@ func (p *Plugin) Activity(ctx context.Context, params map[string]interface{}) (i
 		return nil, fmt.Errorf("session %q is not active: %w", cfg.SessionID, err)
 	}
 
+	if cfg.Storage != nil {
+		if err := applyStorage(timeoutCtx, cfg); err != nil {
+			return nil, err
+		}
+		logger.Info("Applied browser storage state", "cookies", len(cfg.Storage.Cookies), "origins", len(cfg.Storage.Origins))
+	}
+
 	logger.Info("Executing browser-use task", "session_id", cfg.SessionID, "timeout", cfg.Timeout)
 
 	runnerPath, cleanup, err := prepareRunnerScript()
@@ -123,6 +145,15 @@ func (p *Plugin) Activity(ctx context.Context, params map[string]interface{}) (i
 	if cfg.LLM.Model != "" {
 		args = append(args, "--llm-model", cfg.LLM.Model)
 	}
+	if cfg.LLM.BaseURL != "" {
+		args = append(args, "--llm-base-url", cfg.LLM.BaseURL)
+	}
+	if cfg.MaxTokens > 0 {
+		args = append(args, "--max-tokens", strconv.Itoa(cfg.MaxTokens))
+	}
+	if cfg.MaxCost > 0 {
+		args = append(args, "--max-cost", strconv.FormatFloat(cfg.MaxCost, 'f', -1, 64))
+	}
 
 	for _, domain := range cfg.AllowedDomains {
 		args = append(args, "--allowed-domain", domain)
@@ -204,6 +235,9 @@ func (p *Plugin) Activity(ctx context.Context, params map[string]interface{}) (i
 			logger.Debug("browser_use traceback", "traceback", response.Traceback)
 		}
 		if response.Error != "" {
+			if response.Usage != nil {
+				logger.Info("browser-use token usage", "usage", response.Usage)
+			}
 			return nil, fmt.Errorf("browser-use execution failed: %s", response.Error)
 		}
 		if waitErr != nil {
@@ -226,6 +260,7 @@ func (p *Plugin) Activity(ctx context.Context, params map[string]interface{}) (i
 		"max_steps":  cfg.MaxSteps,
 		"use_vision": cfg.UseVision,
 		"domains":    cfg.AllowedDomains,
+		"usage":      response.Usage,
 	}
 
 	saved := make(map[string]string)
@@ -339,6 +374,13 @@ func parseConfig(config map[string]interface{}, ctx dsl.TemplateContext) (*Confi
 		if model, ok := llmRaw["model"].(string); ok {
 			llmCfg.Model = model
 		}
+		if baseURL, ok := llmRaw["base_url"].(string); ok {
+			processed, err := dsl.ProcessTemplate(baseURL, ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to process template in llm.base_url: %w", err)
+			}
+			llmCfg.BaseURL = processed
+		}
 		if cfgMap, ok := llmRaw["config"].(map[string]interface{}); ok {
 			llmCfg.Config = make(map[string]string)
 			for k, v := range cfgMap {
@@ -354,6 +396,45 @@ func parseConfig(config map[string]interface{}, ctx dsl.TemplateContext) (*Confi
 		}
 	}
 
+	if err := validateLLM(llmCfg); err != nil {
+		return nil, err
+	}
+
+	maxTokens := 0
+	if raw, ok := config["max_tokens"]; ok {
+		switch val := raw.(type) {
+		case float64:
+			maxTokens = int(val)
+		case int:
+			maxTokens = val
+		default:
+			return nil, fmt.Errorf("invalid max_tokens: %v", raw)
+		}
+		if maxTokens <= 0 {
+			return nil, fmt.Errorf("max_tokens must be positive, got %d", maxTokens)
+		}
+	}
+
+	maxCost := 0.0
+	if raw, ok := config["max_cost"]; ok {
+		switch val := raw.(type) {
+		case float64:
+			maxCost = val
+		case int:
+			maxCost = float64(val)
+		default:
+			return nil, fmt.Errorf("invalid max_cost: %v", raw)
+		}
+		if maxCost <= 0 {
+			return nil, fmt.Errorf("max_cost must be positive, got %v", maxCost)
+		}
+	}
+
+	storage, err := parseStorage(config, ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// Parse timeout
 	timeout := "5m" // default to 5 minutes (matching legacy browser plugin)
 	if raw, ok := config["timeout"]; ok {
@@ -373,5 +454,44 @@ func parseConfig(config map[string]interface{}, ctx dsl.TemplateContext) (*Confi
 		Temperature:    temperature,
 		Timeout:        timeout,
 		LLM:            llmCfg,
+		MaxTokens:      maxTokens,
+		MaxCost:        maxCost,
+		Storage:        storage,
 	}, nil
 }
+
+// applyStorage puts the step's cookies and local storage in the session's
+// browser, so the agent starts signed in
+func applyStorage(ctx context.Context, cfg *Config) error {
+	tab, err := pw.Open(ctx, pw.OpenOptions{SessionID: cfg.SessionID})
+	if err != nil {
+		return err
+	}
+	defer tab.Close()
+	if err := tab.SetStorageState(ctx, cfg.Storage); err != nil {
+		return fmt.Errorf("failed to apply storage state: %w", err)
+	}
+	return nil
+}
+
+// validateLLM checks the provider and the fields it needs. API keys are checked
+// by the runner, since they may come from the worker's environment.
+func validateLLM(llm LLMConfig) error {
+	switch llm.Provider {
+	case "", ProviderOpenAI, ProviderAnthropic, ProviderGemini:
+	case ProviderAzureOpenAI:
+		if llm.Model == "" {
+			return errors.New("llm.model is required with azure_openai: set it to the deployment name")
+		}
+	case ProviderOpenAICompatible:
+		if llm.BaseURL == "" || llm.Model == "" {
+			return errors.New("llm.base_url and llm.model are required with openai_compatible")
+		}
+	default:
+		return fmt.Errorf("unsupported llm.provider %q: must be openai, anthropic, azure_openai, gemini or openai_compatible", llm.Provider)
+	}
+	if llm.BaseURL != "" && llm.Provider != ProviderAzureOpenAI && llm.Provider != ProviderOpenAICompatible {
+		return fmt.Errorf("llm.base_url can only be used with azure_openai and openai_compatible")
+	}
+	return nil
+}
@@ -126,45 +126,100 @@ def _serialize(value):
     return str(value)
 
 
-async def _run(args: argparse.Namespace) -> None:
-    # Check versions first
-    version_error = _check_versions()
-    if version_error:
-        _write(version_error)
-        return
-
-    # Initialize LLM using browser-use's Chat classes (imported from browser_use directly)
-    llm = None
+def _make_llm(args: argparse.Namespace):
+    """Create the provider's chat model. Returns (llm, error)."""
     import os
 
-    if args.llm_provider == "openai":
+    provider = args.llm_provider
+    if provider == "openai":
         from browser_use import ChatOpenAI
 
         api_key = os.environ.get("OPENAI_API_KEY")
         if not api_key:
-            _write({"ok": False, "error": "OPENAI_API_KEY environment variable required"})
-            return
+            return None, "OPENAI_API_KEY environment variable required"
+        return ChatOpenAI(model=args.llm_model or "gpt-4o", api_key=api_key, timeout=30, max_retries=2), None
 
-        llm = ChatOpenAI(
-            model=args.llm_model or "gpt-4o",
-            api_key=api_key,
-            timeout=30,
-            max_retries=2
-        )
-    elif args.llm_provider == "anthropic":
+    if provider == "anthropic":
         from browser_use import ChatAnthropic
 
         api_key = os.environ.get("ANTHROPIC_API_KEY")
         if not api_key:
-            _write({"ok": False, "error": "ANTHROPIC_API_KEY environment variable required"})
-            return
+            return None, "ANTHROPIC_API_KEY environment variable required"
+        return ChatAnthropic(model=args.llm_model or "claude-3-5-sonnet-20241022", api_key=api_key), None
+
+    if provider == "azure_openai":
+        from browser_use import ChatAzureOpenAI
+
+        api_key = os.environ.get("AZURE_OPENAI_API_KEY")
+        endpoint = args.llm_base_url or os.environ.get("AZURE_OPENAI_ENDPOINT")
+        if not api_key:
+            return None, "AZURE_OPENAI_API_KEY environment variable required"
+        if not endpoint:
+            return None, "llm.base_url or the AZURE_OPENAI_ENDPOINT environment variable required"
+        kwargs = {"model": args.llm_model, "api_key": api_key, "azure_endpoint": endpoint}
+        if os.environ.get("AZURE_OPENAI_API_VERSION"):
+            kwargs["api_version"] = os.environ["AZURE_OPENAI_API_VERSION"]
+        return ChatAzureOpenAI(**kwargs), None
+
+    if provider == "gemini":
+        from browser_use import ChatGoogle
+
+        api_key = os.environ.get("GOOGLE_API_KEY") or os.environ.get("GEMINI_API_KEY")
+        if not api_key:
+            return None, "GOOGLE_API_KEY or GEMINI_API_KEY environment variable required"
+        return ChatGoogle(model=args.llm_model or "gemini-2.0-flash", api_key=api_key), None
+
+    if provider == "openai_compatible":
+        from browser_use import ChatOpenAI
+
+        # Local servers such as Ollama, vLLM and LM Studio usually ignore the key
+        api_key = os.environ.get("OPENAI_API_KEY") or "not-needed"
+        return ChatOpenAI(model=args.llm_model, api_key=api_key, base_url=args.llm_base_url, timeout=120, max_retries=2), None
+
+    return None, f"Unsupported LLM provider: {provider}"
+
+
+async def _usage(agent) -> dict | None:
+    """Tokens and cost the agent has used so far, when browser-use tracks them."""
+    service = getattr(agent, "token_cost_service", None)
+    if service is None:
+        return None
+    try:
+        summary = service.get_usage_summary()
+        if inspect.isawaitable(summary):
+            summary = await summary
+    except Exception as exc:
+        logging.warning("Failed to read token usage: %s", exc)
+        return None
+    return {
+        "prompt_tokens": int(getattr(summary, "total_prompt_tokens", 0) or 0),
+        "completion_tokens": int(getattr(summary, "total_completion_tokens", 0) or 0),
+        "total_tokens": int(getattr(summary, "total_tokens", 0) or 0),
+        "cost": float(getattr(summary, "total_cost", 0) or 0),
+    }
+
+
+def _over_budget(usage: dict | None, args: argparse.Namespace) -> str | None:
+    """The budget the usage exceeds, described for the step's error."""
+    if usage is None:
+        return None
+    if args.max_tokens > 0 and usage["total_tokens"] > args.max_tokens:
+        return f"token budget exceeded: used {usage['total_tokens']} tokens, max_tokens is {args.max_tokens}"
+    if args.max_cost > 0 and usage["cost"] > args.max_cost:
+        return f"cost budget exceeded: used ${usage['cost']:.4f}, max_cost is ${args.max_cost:.4f}"
+    return None
+
+
+async def _run(args: argparse.Namespace) -> None:
+    # Check versions first
+    version_error = _check_versions()
+    if version_error:
+        _write(version_error)
+        return
 
-        llm = ChatAnthropic(
-            model=args.llm_model or "claude-3-5-sonnet-20241022",
-            api_key=api_key
-        )
-    else:
-        _write({"ok": False, "error": f"Unsupported LLM provider: {args.llm_provider}"})
+    llm, llm_error = _make_llm(args)
+    if llm_error:
+        _write({"ok": False, "error": llm_error})
         return
 
     session = None
@@ -270,10 +325,36 @@ async def _run(args: argparse.Namespace) -> None:
     if args.temperature is not None:
         agent_kwargs["temperature"] = args.temperature
 
+    if args.max_cost > 0:
+        # Prices come from browser-use's pricing table; models it doesn't know cost 0
+        agent_kwargs["calculate_cost"] = True
+
     agent = Agent(**agent_kwargs)
 
+    # The budget is checked after every agent step, so a runaway task stops at
+    # the step that crossed it rather than at max_steps
+    budget_error = None
+
+    async def check_budget(agent_instance) -> None:
+        nonlocal budget_error
+        if budget_error is None:
+            budget_error = _over_budget(await _usage(agent_instance), args)
+            if budget_error:
+                logging.warning("Stopping agent: %s", budget_error)
+                agent_instance.stop()
+
     try:
-        result = await agent.run(max_steps=args.max_steps if args.max_steps > 0 else None)
+        run_kwargs = {"max_steps": args.max_steps if args.max_steps > 0 else None}
+        if args.max_tokens > 0 or args.max_cost > 0:
+            run_kwargs["on_step_end"] = check_budget
+        result = await agent.run(**run_kwargs)
+
+        usage = await _usage(agent)
+        if budget_error is None:
+            budget_error = _over_budget(usage, args)
+        if budget_error:
+            _write({"ok": False, "error": budget_error, "usage": usage})
+            return
 
         # Parse structured output (qa-use pattern)
         from pydantic import ValidationError
@@ -305,6 +386,7 @@ async def _run(args: argparse.Namespace) -> None:
                 "result": _serialize(result),
                 "finalUrl": final_url,
                 "message": test_result.message,
+                "usage": usage,
             }
 
             # Add error details if test failed
@@ -342,6 +424,7 @@ async def _run(args: argparse.Namespace) -> None:
                 "error": f"Agent returned invalid response format: {str(e)[:200]}",
                 "result": _serialize(result),
                 "finalUrl": final_url,
+                "usage": usage,
             }
             _write(payload)
     except Exception:
@@ -358,10 +441,13 @@ def main() -> None:
     parser.add_argument("--task", required=True)
     parser.add_argument("--llm-provider", required=True)
     parser.add_argument("--llm-model")
+    parser.add_argument("--llm-base-url")
     parser.add_argument("--allowed-domain", action="append")
     parser.add_argument("--max-steps", type=int, default=0)
     parser.add_argument("--use-vision", action="store_true")
     parser.add_argument("--temperature", type=float)
+    parser.add_argument("--max-tokens", type=int, default=0)
+    parser.add_argument("--max-cost", type=float, default=0)
 
     args = parser.parse_args()
 
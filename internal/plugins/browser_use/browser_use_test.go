@@ -0,0 +1,103 @@
+package browser_use
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+)
+
+func TestParseConfig_ProvidersAndBudget(t *testing.T) {
+	ctx := dsl.TemplateContext{Runtime: map[string]interface{}{"llm_host": "localhost:11434"}}
+
+	cfg, err := parseConfig(map[string]interface{}{
+		"session_id": "s1",
+		"task":       "Open the pricing page",
+		"max_tokens": float64(50000),
+		"max_cost":   0.25,
+		"llm": map[string]interface{}{
+			"provider": "openai_compatible",
+			"model":    "qwen2.5:14b",
+			"base_url": "http://{{ llm_host }}/v1",
+		},
+	}, ctx)
+	if err != nil {
+		t.Fatalf("parseConfig failed: %v", err)
+	}
+	if cfg.LLM.BaseURL != "http://localhost:11434/v1" || cfg.MaxTokens != 50000 || cfg.MaxCost != 0.25 {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		error  string
+	}{
+		{"unknown provider", map[string]interface{}{"llm": map[string]interface{}{"provider": "cohere"}}, `unsupported llm.provider "cohere"`},
+		{"azure without deployment", map[string]interface{}{"llm": map[string]interface{}{"provider": "azure_openai"}}, "set it to the deployment name"},
+		{"compatible without url", map[string]interface{}{"llm": map[string]interface{}{"provider": "openai_compatible", "model": "llama3"}}, "llm.base_url and llm.model are required"},
+		{"base url for gemini", map[string]interface{}{"llm": map[string]interface{}{"provider": "gemini", "base_url": "http://x"}}, "llm.base_url can only be used"},
+		{"negative budget", map[string]interface{}{"llm": map[string]interface{}{"provider": "anthropic"}, "max_cost": -1.0}, "max_cost must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config["session_id"] = "s1"
+			tt.config["task"] = "Open the pricing page"
+			_, err := parseConfig(tt.config, ctx)
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Fatalf("expected error containing %q, got %v", tt.error, err)
+			}
+		})
+	}
+}
+
+func TestParseConfig_Storage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	saved := `{"cookies":[{"name":"sid","value":"abc","domain":".app.test","path":"/","expires":-1,"httpOnly":true,"secure":true,"sameSite":"Lax"}],
+		"origins":[{"origin":"https://app.test","localStorage":[{"name":"theme","value":"dark"}]}]}`
+	if err := os.WriteFile(path, []byte(saved), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ctx := dsl.TemplateContext{Runtime: map[string]interface{}{"token": "tok-1", "state_file": path}}
+
+	cfg, err := parseConfig(map[string]interface{}{
+		"session_id":    "s1",
+		"task":          "Open the dashboard",
+		"storage_state": "{{ state_file }}",
+		"cookies": []interface{}{
+			map[string]interface{}{"name": "csrf", "value": "{{ token }}", "url": "https://app.test"},
+		},
+		"local_storage": map[string]interface{}{
+			"https://app.test": map[string]interface{}{"auth": `{"token":"{{ token }}"}`, "locale": "de"},
+		},
+	}, ctx)
+	if err != nil {
+		t.Fatalf("parseConfig failed: %v", err)
+	}
+
+	got, _ := json.Marshal(cfg.Storage)
+	want := `{"cookies":[{"name":"sid","value":"abc","domain":".app.test","path":"/","expires":-1,"httpOnly":true,"secure":true,"sameSite":"Lax"},` +
+		`{"name":"csrf","value":"tok-1","url":"https://app.test"}],` +
+		`"origins":[{"origin":"https://app.test","localStorage":[{"name":"theme","value":"dark"}]},` +
+		`{"origin":"https://app.test","localStorage":[{"name":"auth","value":"{\"token\":\"tok-1\"}"},{"name":"locale","value":"de"}]}]}`
+	if string(got) != want {
+		t.Errorf("unexpected storage state\ngot:  %s\nwant: %s", got, want)
+	}
+
+	cfg, err = parseConfig(map[string]interface{}{"session_id": "s1", "task": "Open the dashboard"}, ctx)
+	if err != nil || cfg.Storage != nil {
+		t.Errorf("expected no storage state, got %+v (%v)", cfg.Storage, err)
+	}
+
+	_, err = parseConfig(map[string]interface{}{
+		"session_id": "s1",
+		"task":       "Open the dashboard",
+		"cookies":    []interface{}{map[string]interface{}{"name": "sid", "value": "abc"}},
+	}, ctx)
+	if err == nil || !strings.Contains(err.Error(), "cookies[0]: set url, or domain and path") {
+		t.Errorf("expected the cookie without a target to be rejected, got %v", err)
+	}
+}
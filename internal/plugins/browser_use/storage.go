@@ -0,0 +1,127 @@
+package browser_use
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/rocketship-ai/rocketship/internal/browser/pw"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+)
+
+// parseStorage merges storage_state, cookies and local_storage into the state
+// to put in the browser before the agent starts. It returns nil when the step
+// sets none of them.
+func parseStorage(config map[string]interface{}, ctx dsl.TemplateContext) (*pw.StorageState, error) {
+	_, hasState := config["storage_state"]
+	_, hasCookies := config["cookies"]
+	_, hasLocal := config["local_storage"]
+	if !hasState && !hasCookies && !hasLocal {
+		return nil, nil
+	}
+
+	state := &pw.StorageState{}
+	switch raw := config["storage_state"].(type) {
+	case nil:
+	case string:
+		path, err := dsl.ProcessTemplate(raw, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to process template for storage_state: %w", err)
+		}
+		loaded, err := pw.LoadStorageState(path)
+		if err != nil {
+			return nil, err
+		}
+		state = loaded
+	case map[string]interface{}:
+		if err := decodeStorage(raw, state, ctx); err != nil {
+			return nil, fmt.Errorf("invalid storage_state: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("storage_state must be a file path or an object, got %T", raw)
+	}
+
+	if raw, ok := config["cookies"]; ok {
+		var cookies []pw.Cookie
+		if err := decodeStorage(raw, &cookies, ctx); err != nil {
+			return nil, fmt.Errorf("invalid cookies: %w", err)
+		}
+		for i, cookie := range cookies {
+			if cookie.Name == "" {
+				return nil, fmt.Errorf("cookies[%d]: name is required", i)
+			}
+			if cookie.URL == "" && cookie.Domain == "" {
+				return nil, fmt.Errorf("cookies[%d]: set url, or domain and path", i)
+			}
+		}
+		state.Cookies = append(state.Cookies, cookies...)
+	}
+
+	if raw, ok := config["local_storage"]; ok {
+		var local map[string]map[string]string
+		if err := decodeStorage(raw, &local, ctx); err != nil {
+			return nil, fmt.Errorf("invalid local_storage: expected values by name under each origin: %w", err)
+		}
+		origins := make([]string, 0, len(local))
+		for origin := range local {
+			origins = append(origins, origin)
+		}
+		sort.Strings(origins)
+		for _, origin := range origins {
+			names := make([]string, 0, len(local[origin]))
+			for name := range local[origin] {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			storage := pw.OriginStorage{Origin: origin}
+			for _, name := range names {
+				storage.LocalStorage = append(storage.LocalStorage, pw.StorageItem{Name: name, Value: local[origin][name]})
+			}
+			state.Origins = append(state.Origins, storage)
+		}
+	}
+
+	return state, nil
+}
+
+// decodeStorage renders the templates in raw's strings and decodes it into out
+func decodeStorage(raw interface{}, out interface{}, ctx dsl.TemplateContext) error {
+	rendered, err := renderStrings(raw, ctx)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(rendered)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// renderStrings renders the templates in every string of a value
+func renderStrings(value interface{}, ctx dsl.TemplateContext) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return dsl.ProcessTemplate(v, ctx)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			rendered, err := renderStrings(item, ctx)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = rendered
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			rendered, err := renderStrings(item, ctx)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = rendered
+		}
+		return out, nil
+	}
+	return value, nil
+}
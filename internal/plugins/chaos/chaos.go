@@ -0,0 +1,446 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+	"go.temporal.io/sdk/activity"
+)
+
+// ToxiproxyURLEnv configures the Toxiproxy API when a step doesn't
+const ToxiproxyURLEnv = "ROCKETSHIP_TOXIPROXY_URL"
+
+const (
+	defaultToxiproxyURL = "http://localhost:8474"
+	defaultTimeout      = 30 * time.Second
+)
+
+// Auto-register the plugin when the package is imported
+func init() {
+	plugins.RegisterPlugin(&ChaosPlugin{})
+}
+
+// GetType returns the plugin type identifier
+func (cp *ChaosPlugin) GetType() string {
+	return "chaos"
+}
+
+// Activity changes Toxiproxy proxies and toxics
+func (cp *ChaosPlugin) Activity(ctx context.Context, p map[string]interface{}) (interface{}, error) {
+	logger := activity.GetLogger(ctx)
+
+	// Parse configuration from parameters
+	configData, ok := p["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid config format")
+	}
+
+	config := &ChaosConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		return nil, fmt.Errorf("failed to parse chaos config: %w", err)
+	}
+
+	timeout := defaultTimeout
+	if config.Timeout != "" {
+		parsed, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", config.Timeout, err)
+		}
+		timeout = parsed
+	}
+
+	// Convert state to map[string]interface{} for template processing
+	state := make(map[string]interface{})
+	if stateStr, ok := p["state"].(map[string]string); ok {
+		for k, v := range stateStr {
+			state[k] = v
+		}
+	} else if stateInt, ok := p["state"].(map[string]interface{}); ok {
+		state = stateInt
+	}
+
+	// Extract env secrets from params (for {{ .env.* }} template resolution)
+	env := make(map[string]string)
+	if envData, ok := p["env"].(map[string]interface{}); ok {
+		for k, v := range envData {
+			if strVal, ok := v.(string); ok {
+				env[k] = strVal
+			}
+		}
+	} else if envData, ok := p["env"].(map[string]string); ok {
+		env = envData
+	}
+
+	if err := applyVariableReplacement(config, state, env); err != nil {
+		return nil, fmt.Errorf("variable replacement failed: %w", err)
+	}
+
+	if config.URL == "" {
+		config.URL = os.Getenv(ToxiproxyURLEnv)
+	}
+	if config.URL == "" {
+		config.URL = defaultToxiproxyURL
+	}
+
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	policy, err := egress.FromParams(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
+
+	logger.Info("Executing chaos plugin", "action", config.Action, "proxy", config.Proxy, "url", config.URL)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	c := &client{baseURL: config.URL, http: &http.Client{Transport: policy.Transport()}}
+	response, err := execute(ctx, c, config)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := assertions.Normalize(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare chaos result: %w", err)
+	}
+
+	assertionResults, err := assertions.Process(p, subject, dsl.TemplateContext{Runtime: state, Env: env}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if failure := assertions.Summary(assertionResults); failure != "" {
+		return nil, fmt.Errorf("%s", failure)
+	}
+
+	saved := make(map[string]string)
+	if err := saves.Process(p, subject, saved); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Chaos step completed", "action", config.Action, "proxy", config.Proxy, "duration", response.Duration)
+
+	return &ActivityResponse{
+		Response:         response,
+		Saved:            saved,
+		AssertionResults: assertionResults,
+	}, nil
+}
+
+// execute runs the configured action and reads the proxy back
+func execute(ctx context.Context, c *client, config *ChaosConfig) (*ChaosResponse, error) {
+	start := time.Now()
+	response := &ChaosResponse{Action: config.Action}
+
+	var err error
+	switch config.Action {
+	case ActionCreate:
+		err = c.createProxy(ctx, config.Proxy, config.Listen, config.Upstream)
+	case ActionDelete:
+		err = c.deleteProxy(ctx, config.Proxy)
+		// Already gone is fine, so cleanup can run after a failed create
+		var apiErr *apiError
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+			err = nil
+		}
+	case ActionAdd:
+		for _, tc := range config.Toxics {
+			toxic, buildErr := buildToxic(tc)
+			if buildErr != nil {
+				return nil, buildErr
+			}
+			if err = c.addToxic(ctx, config.Proxy, toxic); err != nil {
+				break
+			}
+		}
+	case ActionRemove:
+		response.Removed, err = removeToxics(ctx, c, config.Proxy, config.Toxic)
+	case ActionDisable, ActionEnable:
+		err = c.setEnabled(ctx, config.Proxy, config.Action == ActionEnable)
+	case ActionReset:
+		response.Removed, err = reset(ctx, c, config.Proxy)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Proxy != "" && config.Action != ActionDelete {
+		if response.Proxy, err = c.getProxy(ctx, config.Proxy); err != nil {
+			return nil, err
+		}
+	}
+	response.Duration = time.Since(start).String()
+	return response, nil
+}
+
+// removeToxics removes the named toxic, or every toxic on the proxy
+func removeToxics(ctx context.Context, c *client, proxy, name string) ([]string, error) {
+	names := []string{name}
+	if name == "" {
+		current, err := c.getProxy(ctx, proxy)
+		if err != nil {
+			return nil, err
+		}
+		names = names[:0]
+		for _, toxic := range current.Toxics {
+			names = append(names, toxic.Name)
+		}
+	}
+	for _, toxic := range names {
+		if err := c.removeToxic(ctx, proxy, toxic); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+// reset removes the proxy's toxics and enables it, or does so for every proxy
+// when proxy is empty. It returns the removed toxics.
+func reset(ctx context.Context, c *client, proxy string) ([]string, error) {
+	if proxy != "" {
+		removed, err := removeToxics(ctx, c, proxy, "")
+		if err != nil {
+			return nil, err
+		}
+		return removed, c.setEnabled(ctx, proxy, true)
+	}
+
+	proxies, err := c.listProxies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for name, p := range proxies {
+		for _, toxic := range p.Toxics {
+			removed = append(removed, name+"/"+toxic.Name)
+		}
+	}
+	sort.Strings(removed)
+	return removed, c.reset(ctx)
+}
+
+// buildToxic fills in defaults and converts attributes to the integers
+// Toxiproxy expects. Duration attributes also accept strings like "500ms".
+func buildToxic(tc ToxicConfig) (Toxic, error) {
+	toxic := Toxic{
+		Name:       tc.Name,
+		Type:       tc.Type,
+		Stream:     tc.Stream,
+		Toxicity:   1,
+		Attributes: make(map[string]interface{}, len(tc.Attributes)),
+	}
+	if toxic.Stream == "" {
+		toxic.Stream = StreamDownstream
+	}
+	if toxic.Name == "" {
+		toxic.Name = toxic.Type + "_" + toxic.Stream
+	}
+	if tc.Toxicity != nil {
+		toxic.Toxicity = *tc.Toxicity
+	}
+
+	units := toxicTypes[tc.Type]
+	for key, value := range tc.Attributes {
+		n, err := attributeValue(value, units[key])
+		if err != nil {
+			return Toxic{}, fmt.Errorf("toxic %s attribute %s: %w", toxic.Name, key, err)
+		}
+		toxic.Attributes[key] = n
+	}
+	return toxic, nil
+}
+
+// attributeValue converts a number, numeric string or, when unit is set, a
+// duration string to an integer
+func attributeValue(value interface{}, unit time.Duration) (int64, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("must be a whole number, got %v", v)
+		}
+		return int64(v), nil
+	case string:
+		if n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+			return n, nil
+		}
+		if unit != 0 {
+			d, err := time.ParseDuration(strings.TrimSpace(v))
+			if err != nil {
+				return 0, fmt.Errorf("must be a number or a duration, got %q", v)
+			}
+			return int64(d / unit), nil
+		}
+		return 0, fmt.Errorf("must be a number, got %q", v)
+	default:
+		return 0, fmt.Errorf("must be a number, got %T", value)
+	}
+}
+
+// validateConfig checks the config once templates are rendered
+func validateConfig(config *ChaosConfig) error {
+	switch config.Action {
+	case "":
+		return fmt.Errorf("action is required")
+	case ActionCreate, ActionDelete, ActionAdd, ActionRemove, ActionDisable, ActionEnable, ActionReset:
+	default:
+		return fmt.Errorf("action must be create, delete, add, remove, disable, enable or reset, got %q", config.Action)
+	}
+
+	if config.Proxy == "" && config.Action != ActionReset {
+		return fmt.Errorf("proxy is required for %s", config.Action)
+	}
+
+	switch config.Action {
+	case ActionCreate:
+		if config.Listen == "" || config.Upstream == "" {
+			return fmt.Errorf("listen and upstream are required for create")
+		}
+	case ActionAdd:
+		if len(config.Toxics) == 0 {
+			return fmt.Errorf("toxics is required for add")
+		}
+		for i, toxic := range config.Toxics {
+			if _, ok := toxicTypes[toxic.Type]; !ok {
+				return fmt.Errorf("toxics[%d].type must be latency, bandwidth, slow_close, timeout, reset_peer, slicer or limit_data, got %q", i, toxic.Type)
+			}
+			if toxic.Stream != "" && toxic.Stream != StreamDownstream && toxic.Stream != StreamUpstream {
+				return fmt.Errorf("toxics[%d].stream must be downstream or upstream, got %q", i, toxic.Stream)
+			}
+			if toxic.Toxicity != nil && (*toxic.Toxicity < 0 || *toxic.Toxicity > 1) {
+				return fmt.Errorf("toxics[%d].toxicity must be between 0 and 1, got %v", i, *toxic.Toxicity)
+			}
+		}
+	}
+
+	return nil
+}
+
+// applyVariableReplacement processes templates in the API URL, names, addresses
+// and string toxic attributes
+func applyVariableReplacement(config *ChaosConfig, state map[string]interface{}, env map[string]string) error {
+	context := dsl.TemplateContext{
+		Runtime: state,
+		Env:     env,
+	}
+
+	process := func(name string, value *string) error {
+		if *value == "" {
+			return nil
+		}
+		processed, err := dsl.ProcessTemplate(*value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process %s template: %w", name, err)
+		}
+		*value = processed
+		return nil
+	}
+
+	fields := map[string]*string{
+		"url":      &config.URL,
+		"proxy":    &config.Proxy,
+		"listen":   &config.Listen,
+		"upstream": &config.Upstream,
+		"toxic":    &config.Toxic,
+	}
+	for name, value := range fields {
+		if err := process(name, value); err != nil {
+			return err
+		}
+	}
+
+	for i := range config.Toxics {
+		toxic := &config.Toxics[i]
+		if err := process(fmt.Sprintf("toxics[%d].name", i), &toxic.Name); err != nil {
+			return err
+		}
+		for key, value := range toxic.Attributes {
+			s, ok := value.(string)
+			if !ok {
+				continue
+			}
+			if err := process(fmt.Sprintf("toxics[%d].attributes.%s", i, key), &s); err != nil {
+				return err
+			}
+			toxic.Attributes[key] = s
+		}
+	}
+
+	return nil
+}
+
+// parseConfig converts map[string]interface{} to ChaosConfig
+func parseConfig(configData map[string]interface{}, config *ChaosConfig) error {
+	stringFields := map[string]*string{
+		"action":   &config.Action,
+		"url":      &config.URL,
+		"proxy":    &config.Proxy,
+		"listen":   &config.Listen,
+		"upstream": &config.Upstream,
+		"toxic":    &config.Toxic,
+		"timeout":  &config.Timeout,
+	}
+	for key, target := range stringFields {
+		if v, ok := configData[key].(string); ok {
+			*target = v
+		}
+	}
+
+	switch v := configData["toxics"].(type) {
+	case nil:
+	case []interface{}:
+		for i, item := range v {
+			toxicData, ok := item.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("toxics[%d] must be an object, got %T", i, item)
+			}
+			toxic := ToxicConfig{}
+			toxic.Type, _ = toxicData["type"].(string)
+			toxic.Name, _ = toxicData["name"].(string)
+			toxic.Stream, _ = toxicData["stream"].(string)
+			switch t := toxicData["toxicity"].(type) {
+			case nil:
+			case float64:
+				toxic.Toxicity = &t
+			case int:
+				f := float64(t)
+				toxic.Toxicity = &f
+			default:
+				return fmt.Errorf("toxics[%d].toxicity must be a number, got %T", i, t)
+			}
+			switch attributes := toxicData["attributes"].(type) {
+			case nil:
+			case map[string]interface{}:
+				toxic.Attributes = make(map[string]interface{}, len(attributes))
+				for key, value := range attributes {
+					toxic.Attributes[key] = value
+				}
+			default:
+				return fmt.Errorf("toxics[%d].attributes must be an object, got %T", i, attributes)
+			}
+			config.Toxics = append(config.Toxics, toxic)
+		}
+	default:
+		return fmt.Errorf("toxics must be a list, got %T", v)
+	}
+
+	return nil
+}
@@ -0,0 +1,298 @@
+package chaos
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeToxiproxy keeps proxies in memory and serves the parts of the
+// Toxiproxy API the plugin calls
+type fakeToxiproxy struct {
+	mu      sync.Mutex
+	proxies map[string]*Proxy
+}
+
+func (f *fakeToxiproxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fail := func(status int, message string) {
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"error": message, "status": status})
+	}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case r.URL.Path == "/reset" && r.Method == http.MethodPost:
+		for _, p := range f.proxies {
+			p.Enabled = true
+			p.Toxics = []Toxic{}
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.URL.Path == "/proxies" && r.Method == http.MethodGet:
+		_ = json.NewEncoder(w).Encode(f.proxies)
+
+	case r.URL.Path == "/proxies" && r.Method == http.MethodPost:
+		var p Proxy
+		_ = json.NewDecoder(r.Body).Decode(&p)
+		if _, exists := f.proxies[p.Name]; exists {
+			fail(http.StatusConflict, "proxy already exists")
+			return
+		}
+		p.Toxics = []Toxic{}
+		f.proxies[p.Name] = &p
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(p)
+
+	case len(parts) >= 2 && parts[0] == "proxies":
+		p, ok := f.proxies[parts[1]]
+		if !ok {
+			fail(http.StatusNotFound, "proxy not found")
+			return
+		}
+		switch {
+		case len(parts) == 2 && r.Method == http.MethodGet:
+			_ = json.NewEncoder(w).Encode(p)
+		case len(parts) == 2 && r.Method == http.MethodPost:
+			var update map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&update)
+			if enabled, ok := update["enabled"].(bool); ok {
+				p.Enabled = enabled
+			}
+			if upstream, ok := update["upstream"].(string); ok {
+				p.Upstream = upstream
+			}
+			_ = json.NewEncoder(w).Encode(p)
+		case len(parts) == 2 && r.Method == http.MethodDelete:
+			delete(f.proxies, parts[1])
+			w.WriteHeader(http.StatusNoContent)
+		case len(parts) == 3 && r.Method == http.MethodPost:
+			var toxic Toxic
+			_ = json.NewDecoder(r.Body).Decode(&toxic)
+			for _, existing := range p.Toxics {
+				if existing.Name == toxic.Name {
+					fail(http.StatusConflict, "toxic already exists")
+					return
+				}
+			}
+			p.Toxics = append(p.Toxics, toxic)
+			_ = json.NewEncoder(w).Encode(toxic)
+		case len(parts) == 4:
+			for i, existing := range p.Toxics {
+				if existing.Name != parts[3] {
+					continue
+				}
+				if r.Method == http.MethodDelete {
+					p.Toxics = append(p.Toxics[:i], p.Toxics[i+1:]...)
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				var update Toxic
+				_ = json.NewDecoder(r.Body).Decode(&update)
+				p.Toxics[i].Toxicity = update.Toxicity
+				p.Toxics[i].Attributes = update.Attributes
+				_ = json.NewEncoder(w).Encode(p.Toxics[i])
+				return
+			}
+			fail(http.StatusNotFound, "toxic not found")
+		default:
+			fail(http.StatusMethodNotAllowed, "method not allowed")
+		}
+
+	default:
+		fail(http.StatusNotFound, "not found")
+	}
+}
+
+func newFakeToxiproxy(t *testing.T) (*fakeToxiproxy, *client) {
+	t.Helper()
+	fake := &fakeToxiproxy{proxies: map[string]*Proxy{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, &client{baseURL: server.URL, http: server.Client()}
+}
+
+func run(t *testing.T, c *client, configData map[string]interface{}) (*ChaosResponse, error) {
+	t.Helper()
+	config := &ChaosConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		t.Fatalf("parseConfig() error = %v", err)
+	}
+	if err := validateConfig(config); err != nil {
+		t.Fatalf("validateConfig() error = %v", err)
+	}
+	return execute(context.Background(), c, config)
+}
+
+func TestProxyLifecycle(t *testing.T) {
+	fake, c := newFakeToxiproxy(t)
+
+	response, err := run(t, c, map[string]interface{}{"action": "create", "proxy": "postgres", "listen": "0.0.0.0:15432", "upstream": "postgres:5432"})
+	if err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+	if response.Proxy == nil || response.Proxy.Listen != "0.0.0.0:15432" || !response.Proxy.Enabled {
+		t.Fatalf("unexpected create response: %+v", response.Proxy)
+	}
+
+	// Creating again points the existing proxy at the new upstream
+	if _, err := run(t, c, map[string]interface{}{"action": "create", "proxy": "postgres", "listen": "0.0.0.0:15432", "upstream": "replica:5432"}); err != nil {
+		t.Fatalf("second create error = %v", err)
+	}
+	if fake.proxies["postgres"].Upstream != "replica:5432" {
+		t.Fatalf("proxy not updated: %+v", fake.proxies["postgres"])
+	}
+
+	response, err = run(t, c, map[string]interface{}{
+		"action": "add",
+		"proxy":  "postgres",
+		"toxics": []interface{}{
+			map[string]interface{}{"type": "latency", "attributes": map[string]interface{}{"latency": "1.5s", "jitter": float64(100)}},
+			map[string]interface{}{"type": "bandwidth", "stream": "upstream", "toxicity": 0.5, "attributes": map[string]interface{}{"rate": "64"}},
+			map[string]interface{}{"type": "slicer", "name": "slow-packets", "attributes": map[string]interface{}{"average_size": float64(64), "delay": "2ms"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("add error = %v", err)
+	}
+	want := []Toxic{
+		{Name: "latency_downstream", Type: "latency", Stream: "downstream", Toxicity: 1, Attributes: map[string]interface{}{"latency": float64(1500), "jitter": float64(100)}},
+		{Name: "bandwidth_upstream", Type: "bandwidth", Stream: "upstream", Toxicity: 0.5, Attributes: map[string]interface{}{"rate": float64(64)}},
+		{Name: "slow-packets", Type: "slicer", Stream: "downstream", Toxicity: 1, Attributes: map[string]interface{}{"average_size": float64(64), "delay": float64(2000)}},
+	}
+	if !reflect.DeepEqual(response.Proxy.Toxics, want) {
+		t.Fatalf("toxics = %+v\nwant %+v", response.Proxy.Toxics, want)
+	}
+
+	// Adding a toxic that exists updates it
+	if _, err := run(t, c, map[string]interface{}{
+		"action": "add",
+		"proxy":  "postgres",
+		"toxics": []interface{}{map[string]interface{}{"type": "latency", "attributes": map[string]interface{}{"latency": float64(200)}}},
+	}); err != nil {
+		t.Fatalf("second add error = %v", err)
+	}
+	if got := fake.proxies["postgres"].Toxics[0].Attributes["latency"]; got != float64(200) {
+		t.Fatalf("latency = %v, want 200", got)
+	}
+
+	response, err = run(t, c, map[string]interface{}{"action": "remove", "proxy": "postgres", "toxic": "slow-packets"})
+	if err != nil || len(response.Proxy.Toxics) != 2 {
+		t.Fatalf("remove error = %v, toxics = %+v", err, response)
+	}
+
+	if response, err = run(t, c, map[string]interface{}{"action": "disable", "proxy": "postgres"}); err != nil || response.Proxy.Enabled {
+		t.Fatalf("disable error = %v, proxy = %+v", err, response)
+	}
+
+	response, err = run(t, c, map[string]interface{}{"action": "reset", "proxy": "postgres"})
+	if err != nil {
+		t.Fatalf("reset error = %v", err)
+	}
+	if !reflect.DeepEqual(response.Removed, []string{"latency_downstream", "bandwidth_upstream"}) || len(response.Proxy.Toxics) != 0 || !response.Proxy.Enabled {
+		t.Fatalf("unexpected reset response: %+v %+v", response, response.Proxy)
+	}
+
+	if _, err := run(t, c, map[string]interface{}{"action": "delete", "proxy": "postgres"}); err != nil {
+		t.Fatalf("delete error = %v", err)
+	}
+	// Deleting a proxy that's gone succeeds, so cleanup is safe to repeat
+	if _, err := run(t, c, map[string]interface{}{"action": "delete", "proxy": "postgres"}); err != nil {
+		t.Fatalf("second delete error = %v", err)
+	}
+}
+
+func TestResetAll(t *testing.T) {
+	fake, c := newFakeToxiproxy(t)
+	fake.proxies["redis"] = &Proxy{Name: "redis", Enabled: false, Toxics: []Toxic{{Name: "timeout_downstream"}}}
+	fake.proxies["api"] = &Proxy{Name: "api", Enabled: true, Toxics: []Toxic{{Name: "latency_downstream"}, {Name: "reset_peer_upstream"}}}
+
+	response, err := run(t, c, map[string]interface{}{"action": "reset"})
+	if err != nil {
+		t.Fatalf("reset error = %v", err)
+	}
+	if !reflect.DeepEqual(response.Removed, []string{"api/latency_downstream", "api/reset_peer_upstream", "redis/timeout_downstream"}) {
+		t.Fatalf("removed = %v", response.Removed)
+	}
+	if !fake.proxies["redis"].Enabled || len(fake.proxies["api"].Toxics) != 0 {
+		t.Fatalf("proxies not reset: %+v", fake.proxies)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	_, c := newFakeToxiproxy(t)
+	_, err := run(t, c, map[string]interface{}{"action": "disable", "proxy": "missing"})
+	if err == nil || !strings.Contains(err.Error(), "404 Not Found: proxy not found") {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestAttributeValue(t *testing.T) {
+	cases := []struct {
+		value interface{}
+		want  int64
+		err   string
+	}{
+		{float64(500), 500, ""},
+		{"750", 750, ""},
+		{"2s", 2000, ""},
+		{1.5, 0, "must be a whole number"},
+		{"fast", 0, "must be a number or a duration"},
+		{true, 0, "must be a number"},
+	}
+	for _, tc := range cases {
+		got, err := attributeValue(tc.value, 1e6)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("attributeValue(%v) error = %v, want %q", tc.value, err, tc.err)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("attributeValue(%v) = %d, %v; want %d", tc.value, got, err, tc.want)
+		}
+	}
+	if _, err := attributeValue("1KB", 0); err == nil || !strings.Contains(err.Error(), "must be a number") {
+		t.Errorf("non-duration attribute accepted a string: %v", err)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	toxicity := 1.5
+	cases := []struct {
+		name   string
+		config ChaosConfig
+		want   string
+	}{
+		{"no action", ChaosConfig{}, "action is required"},
+		{"unknown action", ChaosConfig{Action: "break"}, "action must be create, delete"},
+		{"no proxy", ChaosConfig{Action: ActionAdd}, "proxy is required for add"},
+		{"create without upstream", ChaosConfig{Action: ActionCreate, Proxy: "db", Listen: ":1"}, "listen and upstream are required"},
+		{"add without toxics", ChaosConfig{Action: ActionAdd, Proxy: "db"}, "toxics is required"},
+		{"unknown toxic", ChaosConfig{Action: ActionAdd, Proxy: "db", Toxics: []ToxicConfig{{Type: "packet_loss"}}}, "toxics[0].type must be"},
+		{"bad stream", ChaosConfig{Action: ActionAdd, Proxy: "db", Toxics: []ToxicConfig{{Type: "latency", Stream: "both"}}}, "stream must be downstream or upstream"},
+		{"bad toxicity", ChaosConfig{Action: ActionAdd, Proxy: "db", Toxics: []ToxicConfig{{Type: "latency", Toxicity: &toxicity}}}, "toxicity must be between 0 and 1"},
+		{"reset all", ChaosConfig{Action: ActionReset}, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateConfig(&tc.config)
+			if tc.want == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
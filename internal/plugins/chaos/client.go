@@ -0,0 +1,137 @@
+package chaos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// client calls the Toxiproxy HTTP API
+type client struct {
+	baseURL string
+	http    *http.Client
+}
+
+// apiError is a failed call, with the reason Toxiproxy gave
+type apiError struct {
+	Status  int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+// call sends a request to path and decodes the reply into out
+func (c *client) call(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode Toxiproxy request: %w", err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(c.baseURL, "/")+path, reader)
+	if err != nil {
+		return fmt.Errorf("invalid Toxiproxy URL %q: %w", c.baseURL, err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("Toxiproxy %s %s failed: %w", method, path, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read Toxiproxy reply: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var reply struct {
+			Error string `json:"error"`
+		}
+		message := strings.TrimSpace(string(raw))
+		if json.Unmarshal(raw, &reply) == nil && reply.Error != "" {
+			message = reply.Error
+		}
+		return &apiError{Status: resp.StatusCode, Message: fmt.Sprintf("Toxiproxy %s %s returned %s: %s", method, path, resp.Status, message)}
+	}
+	if out == nil || len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("failed to decode Toxiproxy reply: %w", err)
+	}
+	return nil
+}
+
+func proxyPath(name string) string {
+	return "/proxies/" + url.PathEscape(name)
+}
+
+func (c *client) getProxy(ctx context.Context, name string) (*Proxy, error) {
+	proxy := &Proxy{}
+	if err := c.call(ctx, http.MethodGet, proxyPath(name), nil, proxy); err != nil {
+		return nil, err
+	}
+	return proxy, nil
+}
+
+func (c *client) listProxies(ctx context.Context) (map[string]*Proxy, error) {
+	proxies := map[string]*Proxy{}
+	if err := c.call(ctx, http.MethodGet, "/proxies", nil, &proxies); err != nil {
+		return nil, err
+	}
+	return proxies, nil
+}
+
+// createProxy creates the proxy, or points an existing one with the same name
+// at the new addresses, and enables it
+func (c *client) createProxy(ctx context.Context, name, listen, upstream string) error {
+	body := map[string]interface{}{"name": name, "listen": listen, "upstream": upstream, "enabled": true}
+	err := c.call(ctx, http.MethodPost, "/proxies", body, nil)
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.Status == http.StatusConflict {
+		delete(body, "name")
+		return c.call(ctx, http.MethodPost, proxyPath(name), body, nil)
+	}
+	return err
+}
+
+func (c *client) deleteProxy(ctx context.Context, name string) error {
+	return c.call(ctx, http.MethodDelete, proxyPath(name), nil, nil)
+}
+
+func (c *client) setEnabled(ctx context.Context, name string, enabled bool) error {
+	return c.call(ctx, http.MethodPost, proxyPath(name), map[string]interface{}{"enabled": enabled}, nil)
+}
+
+// addToxic adds the toxic, or updates an existing one with the same name
+func (c *client) addToxic(ctx context.Context, proxy string, toxic Toxic) error {
+	err := c.call(ctx, http.MethodPost, proxyPath(proxy)+"/toxics", toxic, nil)
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.Status == http.StatusConflict {
+		update := map[string]interface{}{"toxicity": toxic.Toxicity, "attributes": toxic.Attributes}
+		return c.call(ctx, http.MethodPost, proxyPath(proxy)+"/toxics/"+url.PathEscape(toxic.Name), update, nil)
+	}
+	return err
+}
+
+func (c *client) removeToxic(ctx context.Context, proxy, toxic string) error {
+	return c.call(ctx, http.MethodDelete, proxyPath(proxy)+"/toxics/"+url.PathEscape(toxic), nil, nil)
+}
+
+// reset enables every proxy and removes every toxic
+func (c *client) reset(ctx context.Context) error {
+	return c.call(ctx, http.MethodPost, "/reset", nil, nil)
+}
@@ -0,0 +1,107 @@
+package chaos
+
+import (
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+)
+
+// ChaosPlugin represents a fault injection step against Toxiproxy
+type ChaosPlugin struct {
+	Name   string      `json:"name" yaml:"name"`
+	Plugin string      `json:"plugin" yaml:"plugin"`
+	Config ChaosConfig `json:"config" yaml:"config"`
+}
+
+// ChaosConfig selects the Toxiproxy server, the action and the proxy it applies to
+type ChaosConfig struct {
+	Action string `json:"action" yaml:"action"`               // create, delete, add, remove, disable, enable or reset
+	URL    string `json:"url,omitempty" yaml:"url,omitempty"` // Toxiproxy API (defaults to ROCKETSHIP_TOXIPROXY_URL, then http://localhost:8474)
+	Proxy  string `json:"proxy,omitempty" yaml:"proxy,omitempty"`
+
+	// create
+	Listen   string `json:"listen,omitempty" yaml:"listen,omitempty"`     // Address the proxy listens on, e.g. 0.0.0.0:15432
+	Upstream string `json:"upstream,omitempty" yaml:"upstream,omitempty"` // Address it forwards to, e.g. postgres:5432
+
+	// add
+	Toxics []ToxicConfig `json:"toxics,omitempty" yaml:"toxics,omitempty"`
+
+	// remove
+	Toxic string `json:"toxic,omitempty" yaml:"toxic,omitempty"` // Toxic name; every toxic on the proxy when empty
+
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"` // Overall step timeout (defaults to 30s)
+}
+
+// ToxicConfig is a fault added to a proxy
+type ToxicConfig struct {
+	Type       string                 `json:"type" yaml:"type"`                                 // latency, bandwidth, slow_close, timeout, reset_peer, slicer or limit_data
+	Name       string                 `json:"name,omitempty" yaml:"name,omitempty"`             // Defaults to <type>_<stream>
+	Stream     string                 `json:"stream,omitempty" yaml:"stream,omitempty"`         // downstream (default) or upstream
+	Toxicity   *float64               `json:"toxicity,omitempty" yaml:"toxicity,omitempty"`     // Share of connections affected (defaults to 1)
+	Attributes map[string]interface{} `json:"attributes,omitempty" yaml:"attributes,omitempty"` // Type-specific settings, e.g. latency: 500
+}
+
+// Actions supported by the chaos plugin
+const (
+	ActionCreate  = "create"
+	ActionDelete  = "delete"
+	ActionAdd     = "add"
+	ActionRemove  = "remove"
+	ActionDisable = "disable"
+	ActionEnable  = "enable"
+	ActionReset   = "reset"
+)
+
+// Streams a toxic can apply to
+const (
+	StreamDownstream = "downstream" // Data from the upstream to the client
+	StreamUpstream   = "upstream"   // Data from the client to the upstream
+)
+
+// toxicTypes lists Toxiproxy's built-in toxics with the unit of each attribute
+// that is a duration, so steps can write durations like "500ms"
+var toxicTypes = map[string]map[string]time.Duration{
+	"latency":    {"latency": time.Millisecond, "jitter": time.Millisecond},
+	"bandwidth":  nil,
+	"slow_close": {"delay": time.Millisecond},
+	"timeout":    {"timeout": time.Millisecond},
+	"reset_peer": {"timeout": time.Millisecond},
+	"slicer":     {"delay": time.Microsecond},
+	"limit_data": nil,
+}
+
+// Toxic is a fault on a proxy as Toxiproxy reports it
+type Toxic struct {
+	Name       string                 `json:"name"`
+	Type       string                 `json:"type"`
+	Stream     string                 `json:"stream"`
+	Toxicity   float64                `json:"toxicity"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// Proxy is a Toxiproxy proxy with its toxics
+type Proxy struct {
+	Name     string  `json:"name"`
+	Listen   string  `json:"listen"`
+	Upstream string  `json:"upstream"`
+	Enabled  bool    `json:"enabled"`
+	Toxics   []Toxic `json:"toxics"`
+}
+
+// ChaosResponse contains the proxy after the action
+type ChaosResponse struct {
+	Action   string   `json:"action"`
+	Proxy    *Proxy   `json:"proxy,omitempty"`   // State of the proxy; empty after delete and reset without a proxy
+	Removed  []string `json:"removed,omitempty"` // remove and reset: names of removed toxics
+	Duration string   `json:"duration"`
+}
+
+// AssertionResult represents a single assertion result for UI display
+type AssertionResult = assertions.Result
+
+// ActivityResponse represents the complete activity response
+type ActivityResponse struct {
+	Response         *ChaosResponse    `json:"response"`
+	Saved            map[string]string `json:"saved"`
+	AssertionResults []AssertionResult `json:"assertion_results,omitempty"`
+}
@@ -0,0 +1,516 @@
+package clickhouse
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+	"go.temporal.io/sdk/activity"
+)
+
+const (
+	defaultTimeout = 60 * time.Second
+	defaultMaxRows = 1000
+	maxRowsLimit   = 100000
+)
+
+// tableNamePattern accepts table and database.table, optionally backquoted
+var tableNamePattern = regexp.MustCompile("^(`[^`]+`|[A-Za-z_][A-Za-z0-9_]*)(\\.(`[^`]+`|[A-Za-z_][A-Za-z0-9_]*))?$")
+
+// Auto-register the plugin when the package is imported
+func init() {
+	plugins.RegisterPlugin(&ClickHousePlugin{})
+}
+
+// GetType returns the plugin type identifier
+func (cp *ClickHousePlugin) GetType() string {
+	return "clickhouse"
+}
+
+// Activity runs a query or an insert against ClickHouse and checks the result
+func (cp *ClickHousePlugin) Activity(ctx context.Context, p map[string]interface{}) (interface{}, error) {
+	logger := activity.GetLogger(ctx)
+
+	// Parse configuration from parameters
+	configData, ok := p["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid config format")
+	}
+
+	config := &ClickHouseConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		return nil, fmt.Errorf("failed to parse clickhouse config: %w", err)
+	}
+
+	timeout := defaultTimeout
+	if config.Timeout != "" {
+		parsed, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", config.Timeout, err)
+		}
+		timeout = parsed
+	}
+
+	// Convert state to map[string]interface{} for template processing
+	state := make(map[string]interface{})
+	if stateStr, ok := p["state"].(map[string]string); ok {
+		for k, v := range stateStr {
+			state[k] = v
+		}
+	} else if stateInt, ok := p["state"].(map[string]interface{}); ok {
+		state = stateInt
+	}
+
+	// Extract env secrets from params (for {{ .env.* }} template resolution)
+	env := make(map[string]string)
+	if envData, ok := p["env"].(map[string]interface{}); ok {
+		for k, v := range envData {
+			if strVal, ok := v.(string); ok {
+				env[k] = strVal
+			}
+		}
+	} else if envData, ok := p["env"].(map[string]string); ok {
+		env = envData
+	}
+
+	if err := applyVariableReplacement(config, state, env); err != nil {
+		return nil, fmt.Errorf("variable replacement failed: %w", err)
+	}
+
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	policy, err := egress.FromParams(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
+
+	logger.Info("Executing clickhouse plugin", "action", config.Action)
+
+	c, err := newClient(config, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	response, err := execute(ctx, c, config)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := assertions.Normalize(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare clickhouse result: %w", err)
+	}
+
+	assertionResults, err := processAssertions(p, response, subject, state, env)
+	if err != nil {
+		return nil, err
+	}
+	if failure := assertions.Summary(assertionResults); failure != "" {
+		return nil, fmt.Errorf("%s", failure)
+	}
+
+	saved := make(map[string]string)
+	if err := saves.Process(p, subject, saved); err != nil {
+		return nil, err
+	}
+
+	logger.Info("ClickHouse step completed", "action", config.Action, "rows", response.RowCount, "sampled", response.Sampled, "duration", response.Duration)
+
+	return &ActivityResponse{
+		Response:         response,
+		Saved:            saved,
+		AssertionResults: assertionResults,
+	}, nil
+}
+
+// execute runs the configured action
+func execute(ctx context.Context, c *client, config *ClickHouseConfig) (*ClickHouseResponse, error) {
+	start := time.Now()
+
+	var response *ClickHouseResponse
+	var err error
+	switch config.Action {
+	case ActionInsert:
+		response, err = insert(ctx, c, config)
+	default:
+		response, err = query(ctx, c, config)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response.Duration = time.Since(start).String()
+	return response, nil
+}
+
+// query runs the statement and keeps up to max_rows of its rows. Statements that
+// return nothing, such as DDL, succeed with no rows.
+func query(ctx context.Context, c *client, config *ClickHouseConfig) (*ClickHouseResponse, error) {
+	settings := map[string]string{"default_format": "JSONEachRow"}
+	for key, value := range config.Settings {
+		settings[key] = value
+	}
+
+	res, err := c.exec(ctx, config.Query, settings, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.body.Close() }()
+
+	sampler := &rowSampler{max: config.MaxRows}
+	if sampler.max == 0 {
+		sampler.max = defaultMaxRows
+	}
+	if config.Sample == SampleRandom {
+		seed := time.Now().UnixNano()
+		if config.SampleSeed != nil {
+			seed = *config.SampleSeed
+		}
+		sampler.rng = rand.New(rand.NewSource(seed))
+	}
+	if err := readRows(res.body, sampler); err != nil {
+		return nil, err
+	}
+
+	rows := sampler.rows
+	if rows == nil {
+		rows = []map[string]interface{}{}
+	}
+	return &ClickHouseResponse{
+		Action:   ActionQuery,
+		QueryID:  res.queryID,
+		Rows:     rows,
+		RowCount: sampler.seen,
+		Sampled:  sampler.seen > int64(len(rows)),
+		Summary:  res.summary,
+	}, nil
+}
+
+// insert writes rows to the table as JSONEachRow. With async, ClickHouse buffers
+// the rows and, unless wait_for_async_insert is false, answers once they are
+// written. flush forces buffered rows out so later steps can read them.
+func insert(ctx context.Context, c *client, config *ClickHouseConfig) (*ClickHouseResponse, error) {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for i, row := range config.Rows {
+		if err := encoder.Encode(row); err != nil {
+			return nil, fmt.Errorf("rows[%d]: failed to encode as JSON: %w", i, err)
+		}
+	}
+
+	settings := make(map[string]string, len(config.Settings)+2)
+	if config.Async {
+		settings["async_insert"] = "1"
+		settings["wait_for_async_insert"] = "1"
+		if config.WaitForAsyncInsert != nil && !*config.WaitForAsyncInsert {
+			settings["wait_for_async_insert"] = "0"
+		}
+	}
+	for key, value := range config.Settings {
+		settings[key] = value
+	}
+
+	res, err := c.exec(ctx, fmt.Sprintf("INSERT INTO %s FORMAT JSONEachRow", config.Table), settings, &body)
+	if err != nil {
+		return nil, err
+	}
+	_ = res.body.Close()
+
+	if config.Flush {
+		flushed, err := c.exec(ctx, "SYSTEM FLUSH ASYNC INSERT QUEUE", nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to flush async inserts: %w", err)
+		}
+		_ = flushed.body.Close()
+	}
+
+	return &ClickHouseResponse{
+		Action:   ActionInsert,
+		QueryID:  res.queryID,
+		Rows:     []map[string]interface{}{},
+		RowCount: int64(len(config.Rows)),
+		Summary:  res.summary,
+	}, nil
+}
+
+// processAssertions evaluates all assertions and returns their results
+func processAssertions(p map[string]interface{}, response *ClickHouseResponse, subject interface{}, state map[string]interface{}, env map[string]string) ([]AssertionResult, error) {
+	context := dsl.TemplateContext{Runtime: state, Env: env}
+	return assertions.Process(p, subject, context, func(assertionMap map[string]interface{}, result *AssertionResult) bool {
+		return evaluateAssertion(response, subject, assertionMap, result)
+	})
+}
+
+// evaluateAssertion evaluates the clickhouse plugin's own assertion types
+func evaluateAssertion(response *ClickHouseResponse, subject interface{}, assertionMap map[string]interface{}, result *AssertionResult) bool {
+	expected := result.Expected
+	switch result.Type {
+	case AssertionTypeRowCount:
+		result.Actual = response.RowCount
+		if !assertions.Equal(float64(response.RowCount), expected) {
+			result.Message = fmt.Sprintf("expected %v rows, got %d", expected, response.RowCount)
+		} else {
+			result.Passed = true
+		}
+
+	case AssertionTypeEveryRow:
+		*result = everyRow(assertionMap, subject, response)
+
+	default:
+		return false
+	}
+	return true
+}
+
+// everyRow checks that a jq condition holds for every row kept. On a sampled
+// result that is every row of the sample.
+func everyRow(assertionMap map[string]interface{}, subject interface{}, response *ClickHouseResponse) AssertionResult {
+	condition, _ := assertionMap["path"].(string)
+	result := AssertionResult{Type: AssertionTypeEveryRow, Path: condition}
+	if condition == "" {
+		result.Message = "every_row requires a path: a jq condition each row must satisfy, e.g. .amount > 0"
+		return result
+	}
+
+	rows, _ := subject.(map[string]interface{})["rows"].([]interface{})
+	for i, row := range rows {
+		value, found, err := assertions.Query(condition, row)
+		if err != nil {
+			result.Message = fmt.Sprintf("invalid condition %q: %v", condition, err)
+			return result
+		}
+		if !found || value != true {
+			encoded, _ := json.Marshal(row)
+			result.Actual = row
+			result.Message = fmt.Sprintf("row %d does not satisfy %s: %s", i, condition, encoded)
+			return result
+		}
+	}
+
+	result.Passed = true
+	result.Actual = len(rows)
+	if response.Sampled {
+		result.Message = fmt.Sprintf("checked a sample of %d of %d rows", len(rows), response.RowCount)
+	}
+	return result
+}
+
+// validateConfig checks the action has what it needs once templates are rendered
+func validateConfig(config *ClickHouseConfig) error {
+	if config.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+
+	switch config.Action {
+	case "", ActionQuery:
+		config.Action = ActionQuery
+		if config.Query == "" {
+			return fmt.Errorf("query is required")
+		}
+		if config.MaxRows < 0 || config.MaxRows > maxRowsLimit {
+			return fmt.Errorf("max_rows must be between 1 and %d", maxRowsLimit)
+		}
+		switch config.Sample {
+		case "", SampleFirst, SampleRandom:
+		default:
+			return fmt.Errorf("sample must be first or random, got %q", config.Sample)
+		}
+	case ActionInsert:
+		if config.Table == "" {
+			return fmt.Errorf("table is required with action insert")
+		}
+		if !tableNamePattern.MatchString(config.Table) {
+			return fmt.Errorf("invalid table %q: use table or database.table", config.Table)
+		}
+		if len(config.Rows) == 0 {
+			return fmt.Errorf("rows is required with action insert")
+		}
+	default:
+		return fmt.Errorf("action must be query or insert, got %q", config.Action)
+	}
+
+	return nil
+}
+
+// applyVariableReplacement processes templates in the connection settings, the
+// query and the inserted rows
+func applyVariableReplacement(config *ClickHouseConfig, state map[string]interface{}, env map[string]string) error {
+	context := dsl.TemplateContext{
+		Runtime: state,
+		Env:     env,
+	}
+
+	fields := map[string]*string{
+		"url":      &config.URL,
+		"database": &config.Database,
+		"username": &config.Username,
+		"password": &config.Password,
+		"query":    &config.Query,
+		"table":    &config.Table,
+	}
+	for name, value := range fields {
+		if *value == "" {
+			continue
+		}
+		processed, err := dsl.ProcessTemplate(*value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process %s template: %w", name, err)
+		}
+		*value = processed
+	}
+
+	for key, value := range config.Settings {
+		processed, err := dsl.ProcessTemplate(value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process settings.%s template: %w", key, err)
+		}
+		config.Settings[key] = processed
+	}
+
+	for i, row := range config.Rows {
+		for column, value := range row {
+			processed, err := processValue(value, context)
+			if err != nil {
+				return fmt.Errorf("failed to process rows[%d].%s template: %w", i, column, err)
+			}
+			row[column] = processed
+		}
+	}
+
+	return nil
+}
+
+// processValue renders templates in the strings of a row value, leaving numbers
+// and other scalars as they are
+func processValue(value interface{}, context dsl.TemplateContext) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return dsl.ProcessTemplate(v, context)
+	case map[string]interface{}:
+		for key, nested := range v {
+			processed, err := processValue(nested, context)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = processed
+		}
+	case []interface{}:
+		for i, nested := range v {
+			processed, err := processValue(nested, context)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = processed
+		}
+	}
+	return value, nil
+}
+
+// parseConfig converts map[string]interface{} to ClickHouseConfig
+func parseConfig(configData map[string]interface{}, config *ClickHouseConfig) error {
+	stringFields := map[string]*string{
+		"action":   &config.Action,
+		"url":      &config.URL,
+		"database": &config.Database,
+		"username": &config.Username,
+		"password": &config.Password,
+		"query":    &config.Query,
+		"sample":   &config.Sample,
+		"table":    &config.Table,
+		"timeout":  &config.Timeout,
+	}
+	for key, target := range stringFields {
+		if v, ok := configData[key].(string); ok {
+			*target = v
+		}
+	}
+
+	if v, ok := configData["async"].(bool); ok {
+		config.Async = v
+	}
+	if v, ok := configData["wait_for_async_insert"].(bool); ok {
+		config.WaitForAsyncInsert = &v
+	}
+	if v, ok := configData["flush"].(bool); ok {
+		config.Flush = v
+	}
+
+	switch settings := configData["settings"].(type) {
+	case nil:
+	case map[string]interface{}:
+		config.Settings = make(map[string]string, len(settings))
+		for key, value := range settings {
+			switch v := value.(type) {
+			case string:
+				config.Settings[key] = v
+			case bool:
+				config.Settings[key] = "0"
+				if v {
+					config.Settings[key] = "1"
+				}
+			default:
+				config.Settings[key] = fmt.Sprintf("%v", v)
+			}
+		}
+	default:
+		return fmt.Errorf("settings must be a map, got %T", settings)
+	}
+
+	switch rows := configData["rows"].(type) {
+	case nil:
+	case []interface{}:
+		for i, entry := range rows {
+			row, ok := entry.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("rows[%d] must be an object of column values", i)
+			}
+			config.Rows = append(config.Rows, row)
+		}
+	default:
+		return fmt.Errorf("rows must be a list, got %T", rows)
+	}
+
+	maxRows, _, err := intField(configData, "max_rows")
+	if err != nil {
+		return err
+	}
+	config.MaxRows = maxRows
+
+	seed, set, err := intField(configData, "sample_seed")
+	if err != nil {
+		return err
+	}
+	if set {
+		seed64 := int64(seed)
+		config.SampleSeed = &seed64
+	}
+
+	return nil
+}
+
+func intField(configData map[string]interface{}, key string) (int, bool, error) {
+	switch v := configData[key].(type) {
+	case nil:
+		return 0, false, nil
+	case float64:
+		return int(v), true, nil
+	case int:
+		return v, true, nil
+	default:
+		return 0, false, fmt.Errorf("%s must be a number, got %T", key, v)
+	}
+}
@@ -0,0 +1,248 @@
+package clickhouse
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rocketship-ai/rocketship/internal/plugins/plugintest"
+)
+
+// fakeClickHouse answers queries with a fixed JSONEachRow result and records
+// what it was sent
+type fakeClickHouse struct {
+	mu       sync.Mutex
+	rows     int
+	requests []*http.Request
+	bodies   []string
+}
+
+func (f *fakeClickHouse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	body, _ := io.ReadAll(r.Body)
+	f.requests = append(f.requests, r)
+	f.bodies = append(f.bodies, string(body))
+
+	if r.Header.Get("X-ClickHouse-User") != "tester" || r.Header.Get("X-ClickHouse-Key") != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte("Code: 516. DB::Exception: tester: Authentication failed. (AUTHENTICATION_FAILED)"))
+		return
+	}
+
+	query := r.URL.Query().Get("query")
+	if query == "" {
+		query = string(body)
+	}
+	w.Header().Set("X-ClickHouse-Query-Id", fmt.Sprintf("q-%d", len(f.requests)))
+	switch {
+	case strings.HasPrefix(query, "SELECT"):
+		w.Header().Set("X-ClickHouse-Summary", fmt.Sprintf(`{"read_rows":"%d","read_bytes":"800","result_rows":"%d","elapsed_ns":"1200"}`, f.rows, f.rows))
+		for i := 0; i < f.rows; i++ {
+			_, _ = fmt.Fprintf(w, `{"id":%d,"status":"ok"}`+"\n", i)
+		}
+	case strings.HasPrefix(query, "INSERT"), strings.HasPrefix(query, "SYSTEM"):
+		w.Header().Set("X-ClickHouse-Summary", `{"written_rows":"2","written_bytes":"64"}`)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("Code: 62. DB::Exception: Syntax error. (SYNTAX_ERROR)"))
+	}
+}
+
+// runStep runs the step through the plugin's Activity
+func runStep(t *testing.T, server *httptest.Server, configData map[string]interface{}, assertionList []interface{}) (*ActivityResponse, error) {
+	t.Helper()
+	configData["url"] = server.URL
+	configData["username"] = "tester"
+	configData["password"] = "{{ .env.CH_PASSWORD }}"
+
+	resp := &ActivityResponse{}
+	err := plugintest.Run(t, &ClickHousePlugin{}, map[string]interface{}{
+		"config":     configData,
+		"state":      map[string]interface{}{"order_id": "a"},
+		"env":        map[string]interface{}{"CH_PASSWORD": "secret"},
+		"assertions": assertionList,
+	}, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func TestQueryKeepsFirstRows(t *testing.T) {
+	fake := &fakeClickHouse{rows: 5}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	resp, err := runStep(t, server, map[string]interface{}{
+		"query":    "SELECT id, status FROM events",
+		"database": "analytics",
+		"max_rows": float64(3),
+	}, []interface{}{
+		map[string]interface{}{"type": "row_count", "expected": float64(5)},
+		map[string]interface{}{"type": "equals", "path": ".summary.read_rows", "expected": float64(5)},
+	})
+	if err != nil {
+		t.Fatalf("Activity failed: %v", err)
+	}
+
+	if resp.Response.RowCount != 5 || len(resp.Response.Rows) != 3 || !resp.Response.Sampled {
+		t.Fatalf("expected 3 of 5 rows, got %d of %d (sampled=%v)", len(resp.Response.Rows), resp.Response.RowCount, resp.Response.Sampled)
+	}
+	if resp.Response.Rows[2]["id"] != float64(2) {
+		t.Errorf("expected the first rows, got %v", resp.Response.Rows)
+	}
+	if resp.Response.QueryID != "q-1" {
+		t.Errorf("expected query id q-1, got %q", resp.Response.QueryID)
+	}
+
+	params := fake.requests[0].URL.Query()
+	if params.Get("database") != "analytics" || params.Get("default_format") != "JSONEachRow" || params.Get("wait_end_of_query") != "1" {
+		t.Errorf("unexpected query parameters: %v", params)
+	}
+}
+
+func TestQueryRandomSample(t *testing.T) {
+	fake := &fakeClickHouse{rows: 1000}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	config := func() map[string]interface{} {
+		return map[string]interface{}{
+			"query":       "SELECT id, status FROM events",
+			"max_rows":    float64(10),
+			"sample":      "random",
+			"sample_seed": float64(7),
+		}
+	}
+	first, err := runStep(t, server, config(), []interface{}{
+		map[string]interface{}{"type": "every_row", "path": `.status == "ok"`},
+	})
+	if err != nil {
+		t.Fatalf("Activity failed: %v", err)
+	}
+	second, err := runStep(t, server, config(), nil)
+	if err != nil {
+		t.Fatalf("Activity failed: %v", err)
+	}
+
+	if len(first.Response.Rows) != 10 || first.Response.RowCount != 1000 {
+		t.Fatalf("expected a sample of 10 of 1000 rows, got %d of %d", len(first.Response.Rows), first.Response.RowCount)
+	}
+	// The same seed samples the same rows, and the sample reaches past the first rows
+	beyondFirst := false
+	for i, row := range first.Response.Rows {
+		if row["id"] != second.Response.Rows[i]["id"] {
+			t.Fatalf("samples with the same seed differ at %d: %v vs %v", i, row["id"], second.Response.Rows[i]["id"])
+		}
+		if row["id"].(float64) >= 10 {
+			beyondFirst = true
+		}
+	}
+	if !beyondFirst {
+		t.Errorf("expected the random sample to include rows past the first 10, got %v", first.Response.Rows)
+	}
+	if !strings.Contains(first.AssertionResults[0].Message, "sample of 10 of 1000 rows") {
+		t.Errorf("expected every_row to say it checked a sample, got %q", first.AssertionResults[0].Message)
+	}
+}
+
+func TestEveryRowFailure(t *testing.T) {
+	server := httptest.NewServer(&fakeClickHouse{rows: 4})
+	defer server.Close()
+
+	_, err := runStep(t, server, map[string]interface{}{"query": "SELECT id, status FROM events"}, []interface{}{
+		map[string]interface{}{"type": "every_row", "path": ".id < 3"},
+	})
+	if err == nil || !strings.Contains(err.Error(), `row 3 does not satisfy .id < 3: {"id":3,"status":"ok"}`) {
+		t.Fatalf("expected the offending row in the error, got %v", err)
+	}
+}
+
+func TestInsertAsync(t *testing.T) {
+	fake := &fakeClickHouse{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	resp, err := runStep(t, server, map[string]interface{}{
+		"action": "insert",
+		"table":  "analytics.events",
+		"rows": []interface{}{
+			map[string]interface{}{"id": float64(1), "order": "{{ order_id }}"},
+			map[string]interface{}{"id": float64(2), "order": "b"},
+		},
+		"async":                 true,
+		"wait_for_async_insert": false,
+		"flush":                 true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Activity failed: %v", err)
+	}
+
+	if resp.Response.RowCount != 2 || resp.Response.Summary.WrittenRows != 2 {
+		t.Errorf("expected 2 rows written, got %+v", resp.Response)
+	}
+	if len(fake.requests) != 2 {
+		t.Fatalf("expected the insert and a flush, got %d requests", len(fake.requests))
+	}
+	params := fake.requests[0].URL.Query()
+	if params.Get("query") != "INSERT INTO analytics.events FORMAT JSONEachRow" {
+		t.Errorf("unexpected insert query %q", params.Get("query"))
+	}
+	if params.Get("async_insert") != "1" || params.Get("wait_for_async_insert") != "0" {
+		t.Errorf("expected async insert settings, got %v", params)
+	}
+	if fake.bodies[0] != "{\"id\":1,\"order\":\"a\"}\n{\"id\":2,\"order\":\"b\"}\n" {
+		t.Errorf("unexpected insert body %q", fake.bodies[0])
+	}
+	if fake.bodies[1] != "SYSTEM FLUSH ASYNC INSERT QUEUE" {
+		t.Errorf("expected a flush, got %q", fake.bodies[1])
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	server := httptest.NewServer(&fakeClickHouse{})
+	defer server.Close()
+
+	_, err := runStep(t, server, map[string]interface{}{"query": "SELEC 1"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "SYNTAX_ERROR") {
+		t.Fatalf("expected the ClickHouse exception, got %v", err)
+	}
+}
+
+func TestReadRowsMidStreamException(t *testing.T) {
+	sampler := &rowSampler{max: 10}
+	body := strings.NewReader("{\"id\":1}\nCode: 241. DB::Exception: Memory limit exceeded. (MEMORY_LIMIT_EXCEEDED)\n")
+	err := readRows(body, sampler)
+	if err == nil || !strings.Contains(err.Error(), "MEMORY_LIMIT_EXCEEDED") {
+		t.Fatalf("expected the exception, got %v", err)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  ClickHouseConfig
+		wantErr string
+	}{
+		{"missing url", ClickHouseConfig{Query: "SELECT 1"}, "url is required"},
+		{"missing query", ClickHouseConfig{URL: "http://ch:8123"}, "query is required"},
+		{"bad sample", ClickHouseConfig{URL: "http://ch:8123", Query: "SELECT 1", Sample: "last"}, "sample must be first or random"},
+		{"insert without rows", ClickHouseConfig{URL: "http://ch:8123", Action: "insert", Table: "events"}, "rows is required"},
+		{"injected table", ClickHouseConfig{URL: "http://ch:8123", Action: "insert", Table: "events; DROP TABLE x", Rows: []map[string]interface{}{{}}}, "invalid table"},
+		{"bad action", ClickHouseConfig{URL: "http://ch:8123", Action: "delete"}, "action must be query or insert"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateConfig(&tt.config)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
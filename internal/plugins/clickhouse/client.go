@@ -0,0 +1,179 @@
+package clickhouse
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/rocketship-ai/rocketship/internal/egress"
+)
+
+// maxRowBytes is the largest JSONEachRow line the plugin reads
+const maxRowBytes = 16 << 20
+
+// client runs statements over ClickHouse's HTTP interface, one request per statement
+type client struct {
+	endpoint *url.URL
+	database string
+	username string
+	password string
+	http     *http.Client
+}
+
+// newClient connects to the configured server. Connections go through the egress policy.
+func newClient(config *ClickHouseConfig, policy *egress.Policy) (*client, error) {
+	endpoint, err := url.Parse(config.URL)
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("invalid url %q: must be an http or https URL, e.g. http://clickhouse:8123", config.URL)
+	}
+	if endpoint.Path == "" {
+		endpoint.Path = "/"
+	}
+
+	return &client{
+		endpoint: endpoint,
+		database: config.Database,
+		username: config.Username,
+		password: config.Password,
+		http:     &http.Client{Transport: policy.Transport()},
+	}, nil
+}
+
+// result is a statement's response before its rows are read
+type result struct {
+	body    io.ReadCloser
+	queryID string
+	summary QuerySummary
+}
+
+// exec sends query with settings. For inserts, body holds the data that follows
+// the query. The caller closes the result's body.
+func (c *client) exec(ctx context.Context, query string, settings map[string]string, body io.Reader) (*result, error) {
+	params := url.Values{}
+	// Buffer the whole result on the server so errors arrive as an HTTP status
+	// rather than halfway through the rows, and the summary header is complete
+	params.Set("wait_end_of_query", "1")
+	params.Set("output_format_json_quote_64bit_integers", "0")
+	for key, value := range settings {
+		params.Set(key, value)
+	}
+	if c.database != "" {
+		params.Set("database", c.database)
+	}
+
+	endpoint := *c.endpoint
+	method := http.MethodPost
+	if body == nil {
+		body = strings.NewReader(query)
+	} else {
+		params.Set("query", query)
+	}
+	endpoint.RawQuery = params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if c.username != "" {
+		req.Header.Set("X-ClickHouse-User", c.username)
+	}
+	if c.password != "" {
+		req.Header.Set("X-ClickHouse-Key", c.password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("clickhouse request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer func() { _ = resp.Body.Close() }()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		message := strings.TrimSpace(string(data))
+		if message == "" {
+			message = resp.Status
+		}
+		return nil, fmt.Errorf("clickhouse error: %s", message)
+	}
+
+	return &result{
+		body:    resp.Body,
+		queryID: resp.Header.Get("X-ClickHouse-Query-Id"),
+		summary: parseSummary(resp.Header.Get("X-ClickHouse-Summary")),
+	}, nil
+}
+
+// parseSummary decodes the X-ClickHouse-Summary header, whose counters are strings
+func parseSummary(header string) QuerySummary {
+	var raw map[string]string
+	if header == "" || json.Unmarshal([]byte(header), &raw) != nil {
+		return QuerySummary{}
+	}
+	number := func(key string) int64 {
+		n, _ := strconv.ParseInt(raw[key], 10, 64)
+		return n
+	}
+	return QuerySummary{
+		ReadRows:     number("read_rows"),
+		ReadBytes:    number("read_bytes"),
+		WrittenRows:  number("written_rows"),
+		WrittenBytes: number("written_bytes"),
+		ResultRows:   number("result_rows"),
+		ElapsedNs:    number("elapsed_ns"),
+	}
+}
+
+// rowSampler keeps at most max rows of a result: the first ones, or a uniform
+// random sample of all of them (reservoir sampling) so assertions over a large
+// result aren't biased towards whatever ClickHouse returned first
+type rowSampler struct {
+	max  int
+	rng  *rand.Rand // nil keeps the first rows
+	seen int64
+	rows []map[string]interface{}
+}
+
+func (s *rowSampler) add(row map[string]interface{}) {
+	s.seen++
+	if len(s.rows) < s.max {
+		s.rows = append(s.rows, row)
+		return
+	}
+	if s.rng == nil {
+		return
+	}
+	if j := s.rng.Int63n(s.seen); j < int64(s.max) {
+		s.rows[j] = row
+	}
+}
+
+// readRows reads a JSONEachRow result into the sampler
+func readRows(body io.Reader, sampler *rowSampler) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64<<10), maxRowBytes)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		var row map[string]interface{}
+		if err := json.Unmarshal(line, &row); err != nil {
+			// An exception raised after the rows started streaming
+			if strings.HasPrefix(string(line), "Code:") {
+				return fmt.Errorf("clickhouse error: %s", strings.TrimSpace(string(line)))
+			}
+			return fmt.Errorf("failed to decode row %d: %w (add no FORMAT clause; the plugin reads JSONEachRow)", sampler.seen+1, err)
+		}
+		sampler.add(row)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read clickhouse result: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package clickhouse
+
+import "github.com/rocketship-ai/rocketship/internal/assertions"
+
+// ClickHousePlugin represents a ClickHouse test step
+type ClickHousePlugin struct {
+	Name   string           `json:"name" yaml:"name"`
+	Plugin string           `json:"plugin" yaml:"plugin"`
+	Config ClickHouseConfig `json:"config" yaml:"config"`
+}
+
+// ClickHouseConfig selects the server, the action and how results are collected
+type ClickHouseConfig struct {
+	Action string `json:"action,omitempty" yaml:"action,omitempty"` // query (default) or insert
+
+	// Connection, over the HTTP interface
+	URL      string            `json:"url" yaml:"url"` // e.g. http://clickhouse:8123
+	Database string            `json:"database,omitempty" yaml:"database,omitempty"`
+	Username string            `json:"username,omitempty" yaml:"username,omitempty"`
+	Password string            `json:"password,omitempty" yaml:"password,omitempty"`
+	Settings map[string]string `json:"settings,omitempty" yaml:"settings,omitempty"` // ClickHouse settings for the statement
+
+	// query
+	Query      string `json:"query,omitempty" yaml:"query,omitempty"`
+	MaxRows    int    `json:"max_rows,omitempty" yaml:"max_rows,omitempty"` // Rows kept for assertions (defaults to 1000)
+	Sample     string `json:"sample,omitempty" yaml:"sample,omitempty"`     // first (default) or random
+	SampleSeed *int64 `json:"sample_seed,omitempty" yaml:"sample_seed,omitempty"`
+
+	// insert
+	Table              string                   `json:"table,omitempty" yaml:"table,omitempty"`
+	Rows               []map[string]interface{} `json:"rows,omitempty" yaml:"rows,omitempty"`
+	Async              bool                     `json:"async,omitempty" yaml:"async,omitempty"`                                 // Use async_insert
+	WaitForAsyncInsert *bool                    `json:"wait_for_async_insert,omitempty" yaml:"wait_for_async_insert,omitempty"` // Defaults to true
+	Flush              bool                     `json:"flush,omitempty" yaml:"flush,omitempty"`                                 // Flush the async insert queue afterwards
+
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"` // Overall step timeout (defaults to 60s)
+}
+
+// Actions supported by the clickhouse plugin
+const (
+	ActionQuery  = "query"
+	ActionInsert = "insert"
+)
+
+// Row sampling modes for results larger than max_rows
+const (
+	SampleFirst  = "first"
+	SampleRandom = "random"
+)
+
+// Assertion types supported by the clickhouse plugin in addition to the shared ones
+const (
+	AssertionTypeRowCount = "row_count"
+	AssertionTypeEveryRow = "every_row"
+)
+
+// QuerySummary is ClickHouse's own account of the work a statement did
+type QuerySummary struct {
+	ReadRows     int64 `json:"read_rows"`
+	ReadBytes    int64 `json:"read_bytes"`
+	WrittenRows  int64 `json:"written_rows"`
+	WrittenBytes int64 `json:"written_bytes"`
+	ResultRows   int64 `json:"result_rows"`
+	ElapsedNs    int64 `json:"elapsed_ns"`
+}
+
+// ClickHouseResponse contains the rows a query returned, or what an insert wrote
+type ClickHouseResponse struct {
+	Action   string                   `json:"action"`
+	QueryID  string                   `json:"query_id,omitempty"`
+	Rows     []map[string]interface{} `json:"rows"`
+	RowCount int64                    `json:"row_count"` // Every row the query returned, or the rows inserted
+	Sampled  bool                     `json:"sampled"`   // Rows holds a sample of RowCount rows
+	Summary  QuerySummary             `json:"summary"`
+	Duration string                   `json:"duration"`
+}
+
+// AssertionResult represents a single assertion result for UI display
+type AssertionResult = assertions.Result
+
+// ActivityResponse represents the complete activity response
+type ActivityResponse struct {
+	Response         *ClickHouseResponse `json:"response"`
+	Saved            map[string]string   `json:"saved"`
+	AssertionResults []AssertionResult   `json:"assertion_results,omitempty"`
+}
@@ -0,0 +1,652 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+	"go.temporal.io/sdk/activity"
+)
+
+const (
+	defaultTimeout     = 5 * time.Minute
+	defaultWaitTimeout = 60 * time.Second
+	removeTimeout      = 30 * time.Second
+	// maxLogBytes caps how much container output is read
+	maxLogBytes = 1 << 20
+)
+
+// Auto-register the plugin when the package is imported
+func init() {
+	plugins.RegisterPlugin(&DockerPlugin{})
+}
+
+// GetType returns the plugin type identifier
+func (dp *DockerPlugin) GetType() string {
+	return "docker"
+}
+
+// Activity starts, stops or reads the output of a container
+func (dp *DockerPlugin) Activity(ctx context.Context, p map[string]interface{}) (interface{}, error) {
+	logger := activity.GetLogger(ctx)
+
+	// Parse configuration from parameters
+	configData, ok := p["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid config format")
+	}
+
+	config := &DockerConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		return nil, fmt.Errorf("failed to parse docker config: %w", err)
+	}
+
+	timeout := defaultTimeout
+	if config.Timeout != "" {
+		parsed, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", config.Timeout, err)
+		}
+		timeout = parsed
+	}
+
+	// Convert state to map[string]interface{} for template processing
+	state := make(map[string]interface{})
+	if stateStr, ok := p["state"].(map[string]string); ok {
+		for k, v := range stateStr {
+			state[k] = v
+		}
+	} else if stateInt, ok := p["state"].(map[string]interface{}); ok {
+		state = stateInt
+	}
+
+	// Extract env secrets from params (for {{ .env.* }} template resolution)
+	env := make(map[string]string)
+	if envData, ok := p["env"].(map[string]interface{}); ok {
+		for k, v := range envData {
+			if strVal, ok := v.(string); ok {
+				env[k] = strVal
+			}
+		}
+	} else if envData, ok := p["env"].(map[string]string); ok {
+		env = envData
+	}
+
+	if err := applyVariableReplacement(config, state, env); err != nil {
+		return nil, fmt.Errorf("variable replacement failed: %w", err)
+	}
+
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	policy, err := egress.FromParams(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
+
+	e, err := newEngine(config.Host, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	runID := ""
+	if run, ok := p["run"].(map[string]interface{}); ok {
+		runID, _ = run["id"].(string)
+	}
+	stepName, _ := p["name"].(string)
+
+	logger.Info("Executing docker plugin", "action", config.Action, "image", config.Image, "container", config.Container)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	var response *DockerResponse
+	switch config.Action {
+	case ActionStart:
+		response, err = startContainer(ctx, e, config, runID, stepName, policy)
+	case ActionStop:
+		response, err = stopContainers(ctx, e, config, runID)
+	case ActionLogs:
+		response, err = containerLogs(ctx, e, config)
+	}
+	if err != nil {
+		return nil, err
+	}
+	response.Action = config.Action
+	response.Duration = time.Since(start).String()
+
+	subject, err := assertions.Normalize(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare docker result: %w", err)
+	}
+
+	assertionResults, err := processAssertions(p, response, subject, state, env)
+	if err != nil {
+		return nil, err
+	}
+	if failure := assertions.Summary(assertionResults); failure != "" {
+		return nil, fmt.Errorf("%s", failure)
+	}
+
+	saved := make(map[string]string)
+	if err := saves.Process(p, subject, saved); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Docker step completed", "action", config.Action, "container", shortID(response.ID), "duration", response.Duration)
+
+	return &ActivityResponse{
+		Response:         response,
+		Saved:            saved,
+		AssertionResults: assertionResults,
+	}, nil
+}
+
+// startContainer pulls the image if needed, starts the container and waits until it
+// is ready. A container that fails to start or become ready is removed again.
+func startContainer(ctx context.Context, e *engine, config *DockerConfig, runID, stepName string, policy *egress.Policy) (*DockerResponse, error) {
+	image := normalizeImage(config.Image)
+	pull := config.Pull
+	if pull == "" {
+		pull = PullMissing
+	}
+	switch pull {
+	case PullAlways:
+		if err := e.pull(ctx, image); err != nil {
+			return nil, err
+		}
+	case PullMissing, PullNever:
+		exists, err := e.imageExists(ctx, image)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect image %s: %w", image, err)
+		}
+		if !exists && pull == PullNever {
+			return nil, fmt.Errorf("image %s is not present and pull is never", image)
+		}
+		if !exists {
+			if err := e.pull(ctx, image); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	spec, err := buildSpec(config, image, runID, stepName)
+	if err != nil {
+		return nil, err
+	}
+	id, err := e.create(ctx, config.Name, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	// From here on a failure must not leave the container behind
+	started := false
+	defer func() {
+		if started {
+			return
+		}
+		removeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), removeTimeout)
+		defer cancel()
+		_, _ = e.remove(removeCtx, id)
+	}()
+
+	if err := e.start(ctx, id); err != nil {
+		return nil, err
+	}
+
+	connectHost := config.ConnectHost
+	if connectHost == "" {
+		connectHost = e.host
+	}
+	if config.WaitFor != nil {
+		if err := waitReady(ctx, e, id, connectHost, config.WaitFor, policy); err != nil {
+			return nil, err
+		}
+	}
+
+	info, err := e.inspect(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container %s: %w", shortID(id), err)
+	}
+	started = true
+	return describe(info, connectHost, config.Network), nil
+}
+
+// buildSpec turns the step config into a create request. Containers are labelled with
+// the run ID so a cleanup step can find everything the run started.
+func buildSpec(config *DockerConfig, image, runID, stepName string) (*containerSpec, error) {
+	spec := &containerSpec{
+		Image:      image,
+		Cmd:        config.Command,
+		Entrypoint: config.Entrypoint,
+		Labels:     make(map[string]string, len(config.Labels)+2),
+		HostConfig: hostConfig{NetworkMode: config.Network},
+	}
+	for k, v := range config.Labels {
+		spec.Labels[k] = v
+	}
+	if runID != "" {
+		spec.Labels[LabelRunID] = runID
+	}
+	if stepName != "" {
+		spec.Labels[LabelStep] = stepName
+	}
+
+	keys := make([]string, 0, len(config.Env))
+	for k := range config.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		spec.Env = append(spec.Env, k+"="+config.Env[k])
+	}
+
+	for _, port := range config.Ports {
+		containerPort, hostPort, err := parsePort(port)
+		if err != nil {
+			return nil, err
+		}
+		if spec.ExposedPorts == nil {
+			spec.ExposedPorts = make(map[string]struct{})
+			spec.HostConfig.PortBindings = make(map[string][]portBinding)
+		}
+		spec.ExposedPorts[containerPort] = struct{}{}
+		spec.HostConfig.PortBindings[containerPort] = []portBinding{{HostPort: hostPort}}
+	}
+	return spec, nil
+}
+
+// parsePort parses "5432", "53/udp" or "15432:5432" into a container port key like
+// "5432/tcp" and a host port, empty for a random one
+func parsePort(port string) (string, string, error) {
+	hostPort := ""
+	if i := strings.LastIndex(port, ":"); i >= 0 {
+		hostPort, port = port[:i], port[i+1:]
+		if n, err := strconv.Atoi(hostPort); err != nil || n < 1 || n > 65535 {
+			return "", "", fmt.Errorf("invalid host port in %q", hostPort+":"+port)
+		}
+	}
+	number, proto, found := strings.Cut(port, "/")
+	if !found {
+		proto = "tcp"
+	}
+	if proto != "tcp" && proto != "udp" && proto != "sctp" {
+		return "", "", fmt.Errorf("invalid protocol %q in port %q", proto, port)
+	}
+	if n, err := strconv.Atoi(number); err != nil || n < 1 || n > 65535 {
+		return "", "", fmt.Errorf("invalid container port %q", port)
+	}
+	return number + "/" + proto, hostPort, nil
+}
+
+// normalizeImage adds the latest tag to an untagged image. Without a tag the pull API
+// would fetch every tag of the repository.
+func normalizeImage(image string) string {
+	if strings.Contains(image, "@") {
+		return image
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	if strings.Contains(name, ":") {
+		return image
+	}
+	return image + ":latest"
+}
+
+// describe builds the connection details of a started container
+func describe(info *containerInfo, connectHost, network string) *DockerResponse {
+	response := &DockerResponse{
+		ID:        info.ID,
+		Name:      strings.TrimPrefix(info.Name, "/"),
+		Image:     info.Config.Image,
+		Host:      connectHost,
+		Ports:     make(map[string]int),
+		Endpoints: make(map[string]string),
+		Networks:  make(map[string]string),
+	}
+
+	for key, bindings := range info.NetworkSettings.Ports {
+		for _, binding := range bindings {
+			hostPort, err := strconv.Atoi(binding.HostPort)
+			if err != nil || hostPort == 0 {
+				continue
+			}
+			// TCP ports are keyed by number alone, others keep their protocol
+			name := strings.TrimSuffix(key, "/tcp")
+			response.Ports[name] = hostPort
+			response.Endpoints[name] = fmt.Sprintf("%s:%d", hostPortAddress(connectHost), hostPort)
+			break
+		}
+	}
+
+	names := make([]string, 0, len(info.NetworkSettings.Networks))
+	for name, settings := range info.NetworkSettings.Networks {
+		response.Networks[name] = settings.IPAddress
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if ip, ok := response.Networks[network]; ok {
+		response.IP = ip
+	} else if len(names) > 0 {
+		response.IP = response.Networks[names[0]]
+	}
+	return response
+}
+
+// hostPortAddress brackets IPv6 literals for use in host:port
+func hostPortAddress(host string) string {
+	if strings.Contains(host, ":") {
+		return "[" + host + "]"
+	}
+	return host
+}
+
+// stopContainers removes the configured container, or every container the run started
+func stopContainers(ctx context.Context, e *engine, config *DockerConfig, runID string) (*DockerResponse, error) {
+	response := &DockerResponse{ID: config.Container}
+
+	ids := []string{config.Container}
+	if config.Container == "" {
+		if runID == "" {
+			return nil, fmt.Errorf("container is required when the run ID is unknown")
+		}
+		var err error
+		if ids, err = e.listByLabel(ctx, LabelRunID+"="+runID); err != nil {
+			return nil, err
+		}
+	}
+
+	var errs []error
+	for _, id := range ids {
+		removed, err := e.remove(ctx, id)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if removed {
+			response.Removed = append(response.Removed, id)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return response, nil
+}
+
+// containerLogs reads the output of a container
+func containerLogs(ctx context.Context, e *engine, config *DockerConfig) (*DockerResponse, error) {
+	logs, err := e.logs(ctx, config.Container, config.Tail)
+	if err != nil {
+		return nil, err
+	}
+	return &DockerResponse{ID: config.Container, Logs: logs}, nil
+}
+
+// processAssertions evaluates all assertions and returns their results.
+// Shared assertions without a path check the output for logs and the whole result otherwise.
+func processAssertions(p map[string]interface{}, response *DockerResponse, subject interface{}, state map[string]interface{}, env map[string]string) ([]AssertionResult, error) {
+	context := dsl.TemplateContext{Runtime: state, Env: env}
+	return assertions.Process(p, subject, context, func(assertionMap map[string]interface{}, result *AssertionResult) bool {
+		if _, hasPath := assertionMap["path"]; hasPath || response.Action != ActionLogs {
+			return false
+		}
+		*result = assertions.Evaluate(assertionMap, response.Logs, result.Expected)
+		return true
+	})
+}
+
+// validateConfig checks the action has what it needs once templates are rendered
+func validateConfig(config *DockerConfig) error {
+	switch config.Action {
+	case ActionStart:
+		if config.Image == "" {
+			return fmt.Errorf("image is required with action start")
+		}
+		switch config.Pull {
+		case "", PullMissing, PullAlways, PullNever:
+		default:
+			return fmt.Errorf("pull must be missing, always or never, got %q", config.Pull)
+		}
+		published := make(map[string]bool)
+		for _, port := range config.Ports {
+			containerPort, _, err := parsePort(port)
+			if err != nil {
+				return err
+			}
+			published[containerPort] = true
+		}
+		if wait := config.WaitFor; wait != nil {
+			if wait.Port == "" && wait.Log == "" && !wait.Healthy && wait.HTTP == nil {
+				return fmt.Errorf("wait_for needs at least one of port, log, healthy or http")
+			}
+			for field, port := range map[string]string{"wait_for.port": wait.Port, "wait_for.http.port": httpPort(wait.HTTP)} {
+				if port == "" {
+					continue
+				}
+				containerPort, _, err := parsePort(port)
+				if err != nil {
+					return fmt.Errorf("invalid %s: %w", field, err)
+				}
+				if !published[containerPort] {
+					return fmt.Errorf("%s %s must also be listed in ports", field, port)
+				}
+			}
+			if wait.HTTP != nil && wait.HTTP.Port == "" {
+				return fmt.Errorf("wait_for.http.port is required")
+			}
+			if wait.Log != "" {
+				if _, err := regexp.Compile(wait.Log); err != nil {
+					return fmt.Errorf("invalid wait_for.log pattern: %w", err)
+				}
+			}
+			if wait.Timeout != "" {
+				if _, err := time.ParseDuration(wait.Timeout); err != nil {
+					return fmt.Errorf("invalid wait_for.timeout %q: %w", wait.Timeout, err)
+				}
+			}
+		}
+	case ActionStop:
+	case ActionLogs:
+		if config.Container == "" {
+			return fmt.Errorf("container is required with action logs")
+		}
+	case "":
+		return fmt.Errorf("action is required")
+	default:
+		return fmt.Errorf("action must be start, stop or logs, got %q", config.Action)
+	}
+
+	return nil
+}
+
+func httpPort(config *HTTPWaitConfig) string {
+	if config == nil {
+		return ""
+	}
+	return config.Port
+}
+
+// applyVariableReplacement processes templates in the daemon settings, the container
+// definition and the readiness checks
+func applyVariableReplacement(config *DockerConfig, state map[string]interface{}, env map[string]string) error {
+	context := dsl.TemplateContext{
+		Runtime: state,
+		Env:     env,
+	}
+
+	fields := []*string{&config.Host, &config.ConnectHost, &config.Image, &config.Name, &config.Network, &config.Container}
+	for _, list := range [][]string{config.Ports, config.Command, config.Entrypoint} {
+		for i := range list {
+			fields = append(fields, &list[i])
+		}
+	}
+	if wait := config.WaitFor; wait != nil {
+		fields = append(fields, &wait.Port, &wait.Log)
+		if wait.HTTP != nil {
+			fields = append(fields, &wait.HTTP.Port, &wait.HTTP.Path)
+		}
+	}
+
+	for _, field := range fields {
+		if *field == "" {
+			continue
+		}
+		processed, err := dsl.ProcessTemplate(*field, context)
+		if err != nil {
+			return fmt.Errorf("failed to process template %q: %w", *field, err)
+		}
+		*field = processed
+	}
+
+	for _, values := range []map[string]string{config.Env, config.Labels} {
+		for name, value := range values {
+			processed, err := dsl.ProcessTemplate(value, context)
+			if err != nil {
+				return fmt.Errorf("failed to process %s template: %w", name, err)
+			}
+			values[name] = processed
+		}
+	}
+
+	return nil
+}
+
+// parseConfig converts map[string]interface{} to DockerConfig
+func parseConfig(configData map[string]interface{}, config *DockerConfig) error {
+	stringFields := map[string]*string{
+		"action":       &config.Action,
+		"host":         &config.Host,
+		"connect_host": &config.ConnectHost,
+		"image":        &config.Image,
+		"name":         &config.Name,
+		"network":      &config.Network,
+		"pull":         &config.Pull,
+		"container":    &config.Container,
+		"timeout":      &config.Timeout,
+	}
+	for key, target := range stringFields {
+		if v, ok := configData[key].(string); ok {
+			*target = v
+		}
+	}
+
+	var err error
+	if config.Ports, err = stringList(configData["ports"], "ports"); err != nil {
+		return err
+	}
+	if config.Command, err = stringList(configData["command"], "command"); err != nil {
+		return err
+	}
+	if config.Entrypoint, err = stringList(configData["entrypoint"], "entrypoint"); err != nil {
+		return err
+	}
+	if config.Env, err = stringMap(configData["env"], "env"); err != nil {
+		return err
+	}
+	if config.Labels, err = stringMap(configData["labels"], "labels"); err != nil {
+		return err
+	}
+	if config.Tail, err = intValue(configData["tail"], "tail"); err != nil {
+		return err
+	}
+
+	if waitData, ok := configData["wait_for"].(map[string]interface{}); ok {
+		config.WaitFor = &WaitConfig{
+			Port:    scalarString(waitData["port"]),
+			Log:     scalarString(waitData["log"]),
+			Timeout: scalarString(waitData["timeout"]),
+		}
+		config.WaitFor.Healthy, _ = waitData["healthy"].(bool)
+		if config.WaitFor.LogOccurrences, err = intValue(waitData["log_occurrences"], "wait_for.log_occurrences"); err != nil {
+			return err
+		}
+		if httpData, ok := waitData["http"].(map[string]interface{}); ok {
+			config.WaitFor.HTTP = &HTTPWaitConfig{
+				Port: scalarString(httpData["port"]),
+				Path: scalarString(httpData["path"]),
+			}
+			if config.WaitFor.HTTP.Status, err = intValue(httpData["status"], "wait_for.http.status"); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// stringList accepts a single value or a list; numbers are allowed so ports can be
+// written unquoted
+func stringList(v interface{}, field string) ([]string, error) {
+	switch list := v.(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		out := make([]string, 0, len(list))
+		for i, item := range list {
+			s := scalarString(item)
+			if s == "" {
+				return nil, fmt.Errorf("%s[%d] must be a string or number", field, i)
+			}
+			out = append(out, s)
+		}
+		return out, nil
+	default:
+		if s := scalarString(v); s != "" {
+			return []string{s}, nil
+		}
+		return nil, fmt.Errorf("%s must be a string or a list of strings", field)
+	}
+}
+
+func stringMap(v interface{}, field string) (map[string]string, error) {
+	switch m := v.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		out := make(map[string]string, len(m))
+		for k, value := range m {
+			out[k] = fmt.Sprint(value)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("%s must be a map", field)
+	}
+}
+
+func intValue(v interface{}, field string) (int, error) {
+	switch n := v.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		return int(n), nil
+	case int:
+		return n, nil
+	default:
+		return 0, fmt.Errorf("%s must be a number, got %T", field, v)
+	}
+}
+
+// scalarString renders strings and numbers; anything else is empty
+func scalarString(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(s)
+	default:
+		return ""
+	}
+}
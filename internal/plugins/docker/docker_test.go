@@ -0,0 +1,415 @@
+package docker
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/saves"
+)
+
+type fakeContainer struct {
+	id      string
+	name    string
+	spec    containerSpec
+	running bool
+	status  string
+	exit    int
+	output  string
+}
+
+// fakeDaemon implements the Engine API endpoints the plugin uses
+type fakeDaemon struct {
+	mu         sync.Mutex
+	images     map[string]bool
+	pulled     []string
+	containers map[string]*fakeContainer
+	hostPorts  map[string]string // Host port assigned to each container port on start
+	onStart    func(c *fakeContainer)
+	nextID     int
+}
+
+func newFakeDaemon(t *testing.T) (*fakeDaemon, *engine) {
+	d := &fakeDaemon{
+		images:     map[string]bool{},
+		containers: map[string]*fakeContainer{},
+		hostPorts:  map[string]string{},
+	}
+	server := httptest.NewServer(http.StripPrefix("/"+apiVersion, http.HandlerFunc(d.serve)))
+	t.Cleanup(server.Close)
+
+	e, err := newEngine("tcp://"+server.Listener.Addr().String(), nil)
+	if err != nil {
+		t.Fatalf("newEngine: %v", err)
+	}
+	return d, e
+}
+
+func (d *fakeDaemon) serve(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	notFound := func(what string) {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]string{"message": "No such " + what})
+	}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case r.Method == http.MethodGet && parts[0] == "images":
+		if !d.images[strings.Join(parts[1:len(parts)-1], "/")] {
+			notFound("image")
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+
+	case r.Method == http.MethodPost && r.URL.Path == "/images/create":
+		image := r.URL.Query().Get("fromImage")
+		d.pulled = append(d.pulled, image)
+		_, _ = fmt.Fprintln(w, `{"status":"Pulling from library"}`)
+		if strings.HasPrefix(image, "missing") {
+			_, _ = fmt.Fprintln(w, `{"error":"manifest unknown"}`)
+			return
+		}
+		d.images[image] = true
+
+	case r.Method == http.MethodPost && r.URL.Path == "/containers/create":
+		c := &fakeContainer{name: r.URL.Query().Get("name"), status: "created"}
+		if err := json.NewDecoder(r.Body).Decode(&c.spec); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		d.nextID++
+		c.id = fmt.Sprintf("%064d", d.nextID)
+		if c.name == "" {
+			c.name = "fake_" + strconv.Itoa(d.nextID)
+		}
+		d.containers[c.id] = c
+		_ = json.NewEncoder(w).Encode(map[string]string{"Id": c.id})
+
+	case r.Method == http.MethodGet && r.URL.Path == "/containers/json":
+		var filters map[string][]string
+		_ = json.Unmarshal([]byte(r.URL.Query().Get("filters")), &filters)
+		var list []map[string]string
+		for _, c := range d.containers {
+			for _, label := range filters["label"] {
+				key, value, _ := strings.Cut(label, "=")
+				if c.spec.Labels[key] == value {
+					list = append(list, map[string]string{"Id": c.id})
+				}
+			}
+		}
+		_ = json.NewEncoder(w).Encode(list)
+
+	case parts[0] == "containers" && len(parts) >= 2:
+		c := d.lookup(parts[1])
+		if c == nil {
+			notFound("container")
+			return
+		}
+		action := ""
+		if len(parts) > 2 {
+			action = parts[2]
+		}
+		switch {
+		case r.Method == http.MethodPost && action == "start":
+			c.running, c.status = true, "running"
+			if d.onStart != nil {
+				d.onStart(c)
+			}
+		case r.Method == http.MethodGet && action == "json":
+			_ = json.NewEncoder(w).Encode(d.inspect(c))
+		case r.Method == http.MethodGet && action == "logs":
+			for _, line := range strings.SplitAfter(c.output, "\n") {
+				if line == "" {
+					continue
+				}
+				header := make([]byte, 8)
+				header[0] = 1
+				binary.BigEndian.PutUint32(header[4:], uint32(len(line)))
+				_, _ = w.Write(append(header, line...))
+			}
+		case r.Method == http.MethodDelete && action == "":
+			delete(d.containers, c.id)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (d *fakeDaemon) lookup(ref string) *fakeContainer {
+	for _, c := range d.containers {
+		if c.id == ref || c.name == ref {
+			return c
+		}
+	}
+	return nil
+}
+
+func (d *fakeDaemon) inspect(c *fakeContainer) map[string]interface{} {
+	ports := map[string]interface{}{}
+	if c.running {
+		for port := range c.spec.ExposedPorts {
+			ports[port] = []map[string]string{{"HostIp": "0.0.0.0", "HostPort": d.hostPorts[port]}}
+		}
+	}
+	return map[string]interface{}{
+		"Id":     c.id,
+		"Name":   "/" + c.name,
+		"Config": map[string]interface{}{"Image": c.spec.Image},
+		"State":  map[string]interface{}{"Status": c.status, "Running": c.running, "ExitCode": c.exit},
+		"NetworkSettings": map[string]interface{}{
+			"Ports":    ports,
+			"Networks": map[string]interface{}{"bridge": map[string]string{"IPAddress": "172.17.0.2"}},
+		},
+	}
+}
+
+func TestStartContainer(t *testing.T) {
+	d, e := newFakeDaemon(t)
+
+	// The readiness check dials the published port, so back it with a real listener
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+	_, hostPort, _ := net.SplitHostPort(listener.Addr().String())
+	d.hostPorts["5432/tcp"] = hostPort
+	d.onStart = func(c *fakeContainer) {
+		c.output = "database system is ready to accept connections\nrestarting\ndatabase system is ready to accept connections\n"
+	}
+
+	config := &DockerConfig{
+		Action:  ActionStart,
+		Image:   "postgres:16",
+		Name:    "pg-run-1",
+		Env:     map[string]string{"POSTGRES_PASSWORD": "secret", "POSTGRES_DB": "app"},
+		Ports:   []string{"5432"},
+		WaitFor: &WaitConfig{Port: "5432", Log: "ready to accept connections", LogOccurrences: 2, Timeout: "5s"},
+	}
+	if err := validateConfig(config); err != nil {
+		t.Fatalf("validateConfig: %v", err)
+	}
+
+	response, err := startContainer(context.Background(), e, config, "run-1", "Start postgres", nil)
+	if err != nil {
+		t.Fatalf("startContainer: %v", err)
+	}
+
+	if len(d.pulled) != 1 || d.pulled[0] != "postgres:16" {
+		t.Errorf("expected postgres:16 to be pulled once, got %v", d.pulled)
+	}
+	c := d.lookup("pg-run-1")
+	if c == nil {
+		t.Fatal("container was not created")
+	}
+	if strings.Join(c.spec.Env, ",") != "POSTGRES_DB=app,POSTGRES_PASSWORD=secret" {
+		t.Errorf("unexpected env: %v", c.spec.Env)
+	}
+	if c.spec.Labels[LabelRunID] != "run-1" || c.spec.Labels[LabelStep] != "Start postgres" {
+		t.Errorf("unexpected labels: %v", c.spec.Labels)
+	}
+
+	wantPort, _ := strconv.Atoi(hostPort)
+	if response.Name != "pg-run-1" || response.Ports["5432"] != wantPort || response.IP != "172.17.0.2" {
+		t.Errorf("unexpected response: %+v", response)
+	}
+	if response.Endpoints["5432"] != "127.0.0.1:"+hostPort {
+		t.Errorf("unexpected endpoint: %v", response.Endpoints)
+	}
+
+	subject := map[string]interface{}{}
+	data, _ := json.Marshal(response)
+	_ = json.Unmarshal(data, &subject)
+	saved := map[string]string{}
+	p := map[string]interface{}{"save": []interface{}{
+		map[string]interface{}{"json_path": `.ports["5432"]`, "as": "pg_port"},
+		map[string]interface{}{"json_path": ".id", "as": "pg_id"},
+	}}
+	if err := saves.Process(p, subject, saved); err != nil {
+		t.Fatalf("saves: %v", err)
+	}
+	if saved["pg_port"] != hostPort || saved["pg_id"] != c.id {
+		t.Errorf("unexpected saves: %v", saved)
+	}
+}
+
+func TestStartRemovesFailedContainer(t *testing.T) {
+	d, e := newFakeDaemon(t)
+	d.images["app:latest"] = true
+	d.onStart = func(c *fakeContainer) {
+		c.running, c.status, c.exit = false, "exited", 1
+		c.output = "loading config\nFATAL: DATABASE_URL is not set\n"
+	}
+
+	config := &DockerConfig{Action: ActionStart, Image: "app", Ports: []string{"8080"}, WaitFor: &WaitConfig{Port: "8080"}}
+	_, err := startContainer(context.Background(), e, config, "run-1", "", nil)
+	if err == nil || !strings.Contains(err.Error(), "exited with code 1 before it was ready: loading config | FATAL: DATABASE_URL is not set") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(d.pulled) != 0 {
+		t.Errorf("expected the present image not to be pulled, got %v", d.pulled)
+	}
+	if len(d.containers) != 0 {
+		t.Errorf("expected the failed container to be removed, %d left", len(d.containers))
+	}
+
+	_, err = startContainer(context.Background(), e, &DockerConfig{Action: ActionStart, Image: "missing/image"}, "run-1", "", nil)
+	if err == nil || !strings.Contains(err.Error(), "failed to pull missing/image:latest: manifest unknown") {
+		t.Errorf("unexpected pull error: %v", err)
+	}
+
+	_, err = startContainer(context.Background(), e, &DockerConfig{Action: ActionStart, Image: "redis:7", Pull: PullNever}, "run-1", "", nil)
+	if err == nil || !strings.Contains(err.Error(), "pull is never") {
+		t.Errorf("unexpected error with pull never: %v", err)
+	}
+}
+
+func TestWaitTimeout(t *testing.T) {
+	d, e := newFakeDaemon(t)
+	d.images["app:latest"] = true
+	d.onStart = func(c *fakeContainer) { c.output = "starting\n" }
+
+	previous := waitInterval
+	waitInterval = 10 * time.Millisecond
+	defer func() { waitInterval = previous }()
+
+	config := &DockerConfig{Action: ActionStart, Image: "app", WaitFor: &WaitConfig{Log: "listening", Timeout: "100ms"}}
+	_, err := startContainer(context.Background(), e, config, "run-1", "", nil)
+	if err == nil || !strings.Contains(err.Error(), `was not ready after 100ms: log matched "listening" 0 of 1 times`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(d.containers) != 0 {
+		t.Errorf("expected the container to be removed after the timeout")
+	}
+}
+
+func TestStopAndLogs(t *testing.T) {
+	d, e := newFakeDaemon(t)
+	for i, run := range []string{"run-1", "run-1", "run-2"} {
+		id := fmt.Sprintf("c%d", i)
+		d.containers[id] = &fakeContainer{id: id, name: "name-" + id, output: "hello\n", spec: containerSpec{Labels: map[string]string{LabelRunID: run}}}
+	}
+
+	response, err := containerLogs(context.Background(), e, &DockerConfig{Container: "name-c0"})
+	if err != nil {
+		t.Fatalf("containerLogs: %v", err)
+	}
+	if response.Logs != "hello\n" {
+		t.Errorf("unexpected logs: %q", response.Logs)
+	}
+
+	response, err = stopContainers(context.Background(), e, &DockerConfig{}, "run-1")
+	if err != nil {
+		t.Fatalf("stopContainers: %v", err)
+	}
+	if len(response.Removed) != 2 || len(d.containers) != 1 || d.containers["c2"] == nil {
+		t.Errorf("expected only run-1 containers to be removed, removed %v", response.Removed)
+	}
+
+	response, err = stopContainers(context.Background(), e, &DockerConfig{Container: "gone"}, "run-1")
+	if err != nil {
+		t.Fatalf("stopping a missing container should succeed, got %v", err)
+	}
+	if len(response.Removed) != 0 {
+		t.Errorf("expected nothing removed, got %v", response.Removed)
+	}
+}
+
+func TestParsePortAndImage(t *testing.T) {
+	ports := map[string][2]string{
+		"5432":       {"5432/tcp", ""},
+		"53/udp":     {"53/udp", ""},
+		"15432:5432": {"5432/tcp", "15432"},
+	}
+	for in, want := range ports {
+		containerPort, hostPort, err := parsePort(in)
+		if err != nil || containerPort != want[0] || hostPort != want[1] {
+			t.Errorf("parsePort(%q) = %q, %q, %v", in, containerPort, hostPort, err)
+		}
+	}
+	for _, bad := range []string{"http", "0", "5432/icmp", "x:5432"} {
+		if _, _, err := parsePort(bad); err == nil {
+			t.Errorf("expected parsePort(%q) to fail", bad)
+		}
+	}
+
+	images := map[string]string{
+		"postgres":                       "postgres:latest",
+		"postgres:16":                    "postgres:16",
+		"localhost:5000/app":             "localhost:5000/app:latest",
+		"ghcr.io/org/app@sha256:abcdef0": "ghcr.io/org/app@sha256:abcdef0",
+	}
+	for in, want := range images {
+		if got := normalizeImage(in); got != want {
+			t.Errorf("normalizeImage(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	framed := []byte{1, 0, 0, 0, 0, 0, 0, 3, 'o', 'u', 't', 2, 0, 0, 0, 0, 0, 0, 4, 'e', 'r', 'r', '\n'}
+	if got := demux(framed); got != "outerr\n" {
+		t.Errorf("demux = %q", got)
+	}
+	if got := demux([]byte("plain tty output\n")); got != "plain tty output\n" {
+		t.Errorf("demux of unframed output = %q", got)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	invalid := map[string]DockerConfig{
+		"missing action":        {Image: "redis"},
+		"start without image":   {Action: ActionStart},
+		"bad pull policy":       {Action: ActionStart, Image: "redis", Pull: "sometimes"},
+		"empty wait":            {Action: ActionStart, Image: "redis", WaitFor: &WaitConfig{}},
+		"unpublished wait port": {Action: ActionStart, Image: "redis", WaitFor: &WaitConfig{Port: "6379"}},
+		"bad log pattern":       {Action: ActionStart, Image: "redis", WaitFor: &WaitConfig{Log: "ready("}},
+		"logs without name":     {Action: ActionLogs},
+	}
+	for name, config := range invalid {
+		if err := validateConfig(&config); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	config := &DockerConfig{}
+	err := parseConfig(map[string]interface{}{
+		"action":   "start",
+		"image":    "redis:7",
+		"ports":    []interface{}{float64(6379), "16379:6379"},
+		"env":      map[string]interface{}{"REDIS_ARGS": "--save ''", "MAXMEMORY": float64(64)},
+		"command":  "redis-server",
+		"wait_for": map[string]interface{}{"port": float64(6379), "http": map[string]interface{}{"port": "8001", "status": float64(204)}},
+	}, config)
+	if err != nil {
+		t.Fatalf("parseConfig: %v", err)
+	}
+	if strings.Join(config.Ports, ",") != "6379,16379:6379" || config.Env["MAXMEMORY"] != "64" || config.Command[0] != "redis-server" {
+		t.Errorf("unexpected config: %+v", config)
+	}
+	if config.WaitFor.Port != "6379" || config.WaitFor.HTTP.Status != 204 {
+		t.Errorf("unexpected wait_for: %+v", config.WaitFor)
+	}
+}
@@ -0,0 +1,372 @@
+package docker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rocketship-ai/rocketship/internal/egress"
+)
+
+const (
+	defaultDockerHost = "unix:///var/run/docker.sock"
+	// apiVersion is the oldest Engine API with everything used here (Docker 20.10)
+	apiVersion = "v1.41"
+)
+
+// engine is a small Docker Engine API client covering what the plugin needs: image
+// inspect and pull, container create, start, inspect, logs, list and remove
+type engine struct {
+	client  *http.Client
+	baseURL string
+	host    string // Host published ports are reachable on
+}
+
+// apiError is an error response from the daemon
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("docker daemon returned %d: %s", e.status, e.message)
+}
+
+func isNotFound(err error) bool {
+	var apiErr *apiError
+	return errors.As(err, &apiErr) && apiErr.status == http.StatusNotFound
+}
+
+// newEngine connects to host, or $DOCKER_HOST, or the local socket. TCP daemons use
+// TLS with the certificates in $DOCKER_CERT_PATH when $DOCKER_TLS_VERIFY is set, and
+// go through the egress policy.
+func newEngine(host string, policy *egress.Policy) (*engine, error) {
+	if host == "" {
+		host = os.Getenv("DOCKER_HOST")
+	}
+	if host == "" {
+		host = defaultDockerHost
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker host %q: %w", host, err)
+	}
+
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		}
+		return &engine{client: &http.Client{Transport: transport}, baseURL: "http://docker", host: "localhost"}, nil
+
+	case "tcp", "http", "https":
+		transport := &http.Transport{DialContext: policy.DialContext(&net.Dialer{})}
+		scheme := "http"
+		if u.Scheme == "https" || os.Getenv("DOCKER_TLS_VERIFY") != "" {
+			tlsConfig, err := dockerTLSConfig()
+			if err != nil {
+				return nil, err
+			}
+			transport.TLSClientConfig = tlsConfig
+			scheme = "https"
+		}
+		return &engine{client: &http.Client{Transport: transport}, baseURL: scheme + "://" + u.Host, host: u.Hostname()}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported docker host %q: use unix://, tcp:// or https://", host)
+	}
+}
+
+// dockerTLSConfig loads the client certificate and CA the way the docker CLI does
+func dockerTLSConfig() (*tls.Config, error) {
+	dir := os.Getenv("DOCKER_CERT_PATH")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("DOCKER_CERT_PATH is not set: %w", err)
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+
+	cert, err := tls.LoadX509KeyPair(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load docker client certificate: %w", err)
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+
+	ca, err := os.ReadFile(filepath.Join(dir, "ca.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load docker CA certificate: %w", err)
+	}
+	config.RootCAs = x509.NewCertPool()
+	if !config.RootCAs.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificates found in %s", filepath.Join(dir, "ca.pem"))
+	}
+	return config, nil
+}
+
+// do sends a request and decodes a JSON response into out when it is non-nil
+func (e *engine) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	resp, err := e.send(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode docker response to %s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+// send returns the response of a successful request; the caller closes its body
+func (e *engine) send(ctx context.Context, method, path string, query url.Values, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	target := e.baseURL + "/" + apiVersion + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach docker daemon: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		defer func() { _ = resp.Body.Close() }()
+		var payload struct {
+			Message string `json:"message"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		if json.Unmarshal(data, &payload) != nil || payload.Message == "" {
+			payload.Message = strings.TrimSpace(string(data))
+		}
+		return nil, &apiError{status: resp.StatusCode, message: payload.Message}
+	}
+	return resp, nil
+}
+
+// imageExists reports whether the image is present on the daemon
+func (e *engine) imageExists(ctx context.Context, image string) (bool, error) {
+	err := e.do(ctx, http.MethodGet, "/images/"+image+"/json", nil, nil, nil)
+	if isNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// pull pulls the image, reading the progress stream to the end so errors reported
+// mid-stream are not missed
+func (e *engine) pull(ctx context.Context, image string) error {
+	resp, err := e.send(ctx, http.MethodPost, "/images/create", url.Values{"fromImage": {image}}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to pull %s: %w", image, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		var progress struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &progress) == nil && progress.Error != "" {
+			return fmt.Errorf("failed to pull %s: %s", image, progress.Error)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to pull %s: %w", image, err)
+	}
+	return nil
+}
+
+// containerSpec is the body of POST /containers/create
+type containerSpec struct {
+	Image        string              `json:"Image"`
+	Env          []string            `json:"Env,omitempty"`
+	Cmd          []string            `json:"Cmd,omitempty"`
+	Entrypoint   []string            `json:"Entrypoint,omitempty"`
+	Labels       map[string]string   `json:"Labels,omitempty"`
+	ExposedPorts map[string]struct{} `json:"ExposedPorts,omitempty"`
+	HostConfig   hostConfig          `json:"HostConfig"`
+}
+
+type hostConfig struct {
+	PortBindings map[string][]portBinding `json:"PortBindings,omitempty"`
+	NetworkMode  string                   `json:"NetworkMode,omitempty"`
+}
+
+type portBinding struct {
+	HostIP   string `json:"HostIp"`
+	HostPort string `json:"HostPort"`
+}
+
+// containerInfo is the subset of GET /containers/{id}/json the plugin reads
+type containerInfo struct {
+	ID     string `json:"Id"`
+	Name   string `json:"Name"`
+	Config struct {
+		Image string `json:"Image"`
+	} `json:"Config"`
+	State struct {
+		Status   string `json:"Status"`
+		Running  bool   `json:"Running"`
+		ExitCode int    `json:"ExitCode"`
+		Health   *struct {
+			Status string `json:"Status"`
+		} `json:"Health"`
+	} `json:"State"`
+	NetworkSettings struct {
+		Ports    map[string][]portBinding `json:"Ports"`
+		Networks map[string]struct {
+			IPAddress string `json:"IPAddress"`
+		} `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+func (e *engine) create(ctx context.Context, name string, spec *containerSpec) (string, error) {
+	query := url.Values{}
+	if name != "" {
+		query.Set("name", name)
+	}
+	var created struct {
+		ID string `json:"Id"`
+	}
+	if err := e.do(ctx, http.MethodPost, "/containers/create", query, spec, &created); err != nil {
+		return "", fmt.Errorf("failed to create container from %s: %w", spec.Image, err)
+	}
+	return created.ID, nil
+}
+
+func (e *engine) start(ctx context.Context, id string) error {
+	if err := e.do(ctx, http.MethodPost, "/containers/"+id+"/start", nil, nil, nil); err != nil {
+		return fmt.Errorf("failed to start container %s: %w", shortID(id), err)
+	}
+	return nil
+}
+
+func (e *engine) inspect(ctx context.Context, id string) (*containerInfo, error) {
+	info := &containerInfo{}
+	if err := e.do(ctx, http.MethodGet, "/containers/"+id+"/json", nil, nil, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// logs returns stdout and stderr interleaved. tail limits the output to the last
+// lines when positive.
+func (e *engine) logs(ctx context.Context, id string, tail int) (string, error) {
+	query := url.Values{"stdout": {"1"}, "stderr": {"1"}}
+	if tail > 0 {
+		query.Set("tail", fmt.Sprint(tail))
+	}
+	resp, err := e.send(ctx, http.MethodGet, "/containers/"+id+"/logs", query, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to read logs of container %s: %w", shortID(id), err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxLogBytes))
+	if err != nil {
+		return "", fmt.Errorf("failed to read logs of container %s: %w", shortID(id), err)
+	}
+	return demux(data), nil
+}
+
+// demux strips the 8-byte frame headers Docker puts on the output of containers
+// without a TTY. Output that isn't framed is returned as is.
+func demux(data []byte) string {
+	var out strings.Builder
+	rest := data
+	for len(rest) > 0 {
+		if len(rest) < 8 || rest[0] > 2 || rest[1] != 0 || rest[2] != 0 || rest[3] != 0 {
+			if out.Len() == 0 {
+				return string(data)
+			}
+			out.Write(rest)
+			break
+		}
+		size := int(binary.BigEndian.Uint32(rest[4:8]))
+		rest = rest[8:]
+		if size > len(rest) {
+			size = len(rest)
+		}
+		out.Write(rest[:size])
+		rest = rest[size:]
+	}
+	return out.String()
+}
+
+// listByLabel returns the IDs of all containers, running or not, with the label
+func (e *engine) listByLabel(ctx context.Context, label string) ([]string, error) {
+	filters, err := json.Marshal(map[string][]string{"label": {label}})
+	if err != nil {
+		return nil, err
+	}
+	var containers []struct {
+		ID string `json:"Id"`
+	}
+	if err := e.do(ctx, http.MethodGet, "/containers/json", url.Values{"all": {"1"}, "filters": {string(filters)}}, nil, &containers); err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	ids := make([]string, 0, len(containers))
+	for _, c := range containers {
+		ids = append(ids, c.ID)
+	}
+	return ids, nil
+}
+
+// remove force-removes a container and its anonymous volumes. It reports false
+// without an error when the container is already gone.
+func (e *engine) remove(ctx context.Context, id string) (bool, error) {
+	err := e.do(ctx, http.MethodDelete, "/containers/"+id, url.Values{"force": {"1"}, "v": {"1"}}, nil, nil)
+	switch {
+	case isNotFound(err):
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("failed to remove container %s: %w", shortID(id), err)
+	}
+	return true, nil
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
@@ -0,0 +1,98 @@
+package docker
+
+import "github.com/rocketship-ai/rocketship/internal/assertions"
+
+// DockerPlugin represents a Docker test step
+type DockerPlugin struct {
+	Name   string       `json:"name" yaml:"name"`
+	Plugin string       `json:"plugin" yaml:"plugin"`
+	Config DockerConfig `json:"config" yaml:"config"`
+}
+
+// DockerConfig defines the action and the container it applies to
+type DockerConfig struct {
+	Action      string `json:"action" yaml:"action"`                                 // start, stop or logs
+	Host        string `json:"host,omitempty" yaml:"host,omitempty"`                 // Docker daemon; defaults to $DOCKER_HOST, then the local socket
+	ConnectHost string `json:"connect_host,omitempty" yaml:"connect_host,omitempty"` // Host in connection details; localhost for a local daemon, the daemon's host otherwise
+
+	// start
+	Image      string            `json:"image,omitempty" yaml:"image,omitempty"`
+	Name       string            `json:"name,omitempty" yaml:"name,omitempty"` // Container name; Docker picks one by default
+	Env        map[string]string `json:"env,omitempty" yaml:"env,omitempty"`
+	Ports      []string          `json:"ports,omitempty" yaml:"ports,omitempty"` // Container ports, e.g. "5432", "53/udp" or "15432:5432" to pin the host port
+	Command    []string          `json:"command,omitempty" yaml:"command,omitempty"`
+	Entrypoint []string          `json:"entrypoint,omitempty" yaml:"entrypoint,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Network    string            `json:"network,omitempty" yaml:"network,omitempty"`
+	Pull       string            `json:"pull,omitempty" yaml:"pull,omitempty"` // missing (default), always or never
+	WaitFor    *WaitConfig       `json:"wait_for,omitempty" yaml:"wait_for,omitempty"`
+
+	// stop and logs
+	Container string `json:"container,omitempty" yaml:"container,omitempty"` // ID or name; stop without it removes every container the run started
+	Tail      int    `json:"tail,omitempty" yaml:"tail,omitempty"`           // logs: only the last N lines
+
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"` // Overall step timeout (defaults to 5m)
+}
+
+// WaitConfig defines when a started container counts as ready. Every set check must pass.
+type WaitConfig struct {
+	Port           string          `json:"port,omitempty" yaml:"port,omitempty"`                       // Container port that must accept TCP connections
+	Log            string          `json:"log,omitempty" yaml:"log,omitempty"`                         // Regular expression the container output must match
+	LogOccurrences int             `json:"log_occurrences,omitempty" yaml:"log_occurrences,omitempty"` // How many times log must match (defaults to 1)
+	Healthy        bool            `json:"healthy,omitempty" yaml:"healthy,omitempty"`                 // The image's HEALTHCHECK must report healthy
+	HTTP           *HTTPWaitConfig `json:"http,omitempty" yaml:"http,omitempty"`
+	Timeout        string          `json:"timeout,omitempty" yaml:"timeout,omitempty"` // Defaults to 60s
+}
+
+// HTTPWaitConfig waits for an HTTP endpoint on a published port
+type HTTPWaitConfig struct {
+	Port   string `json:"port" yaml:"port"`                         // Container port
+	Path   string `json:"path,omitempty" yaml:"path,omitempty"`     // Defaults to /
+	Status int    `json:"status,omitempty" yaml:"status,omitempty"` // Defaults to 200
+}
+
+// Actions supported by the docker plugin
+const (
+	ActionStart = "start"
+	ActionStop  = "stop"
+	ActionLogs  = "logs"
+)
+
+// Pull policies
+const (
+	PullMissing = "missing"
+	PullAlways  = "always"
+	PullNever   = "never"
+)
+
+// Labels set on every started container
+const (
+	LabelRunID = "sh.rocketship.run-id"
+	LabelStep  = "sh.rocketship.step"
+)
+
+// DockerResponse contains the result of the action
+type DockerResponse struct {
+	Action    string            `json:"action"`
+	ID        string            `json:"id,omitempty"`
+	Name      string            `json:"name,omitempty"`
+	Image     string            `json:"image,omitempty"`
+	Host      string            `json:"host,omitempty"`      // Host to reach published ports on
+	Ports     map[string]int    `json:"ports,omitempty"`     // Host port by container port, e.g. "5432" or "53/udp"
+	Endpoints map[string]string `json:"endpoints,omitempty"` // host:port by container port
+	IP        string            `json:"ip,omitempty"`        // Container address on its first network
+	Networks  map[string]string `json:"networks,omitempty"`  // Container address by network
+	Logs      string            `json:"logs,omitempty"`
+	Removed   []string          `json:"removed,omitempty"` // stop: IDs of removed containers
+	Duration  string            `json:"duration"`
+}
+
+// AssertionResult represents a single assertion result for UI display
+type AssertionResult = assertions.Result
+
+// ActivityResponse represents the complete activity response
+type ActivityResponse struct {
+	Response         *DockerResponse   `json:"response"`
+	Saved            map[string]string `json:"saved"`
+	AssertionResults []AssertionResult `json:"assertion_results,omitempty"`
+}
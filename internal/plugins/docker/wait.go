@@ -0,0 +1,168 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/egress"
+)
+
+// waitInterval is how often readiness checks are retried
+var waitInterval = 500 * time.Millisecond
+
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// waitReady polls until every configured check passes. It gives up early when the
+// container stops, with the end of its output in the error.
+func waitReady(ctx context.Context, e *engine, id, connectHost string, config *WaitConfig, policy *egress.Policy) error {
+	timeout := defaultWaitTimeout
+	if config.Timeout != "" {
+		timeout, _ = time.ParseDuration(config.Timeout)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var logPattern *regexp.Regexp
+	if config.Log != "" {
+		logPattern = regexp.MustCompile(config.Log)
+	}
+	occurrences := config.LogOccurrences
+	if occurrences == 0 {
+		occurrences = 1
+	}
+	dial := policy.DialContext(&net.Dialer{})
+	httpClient := &http.Client{
+		Transport: &http.Transport{DialContext: dial},
+		Timeout:   2 * time.Second,
+	}
+
+	pending := "container not inspected yet"
+	for {
+		info, err := e.inspect(ctx, id)
+		if err != nil && ctx.Err() == nil {
+			return err
+		}
+		if err == nil {
+			if !info.State.Running {
+				output, _ := e.logs(context.WithoutCancel(ctx), id, 20)
+				return fmt.Errorf("container %s %s with code %d before it was ready: %s", shortID(id), info.State.Status, info.State.ExitCode, lastLines(output))
+			}
+			pending, err = checkReady(ctx, e, info, connectHost, config, logPattern, occurrences, dial, httpClient)
+			if err != nil {
+				return err
+			}
+			if pending == "" {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("container %s was not ready after %s: %s", shortID(id), timeout, pending)
+		case <-time.After(waitInterval):
+		}
+	}
+}
+
+// checkReady runs the checks once and returns what is still pending, or "" when all pass
+func checkReady(ctx context.Context, e *engine, info *containerInfo, connectHost string, config *WaitConfig, logPattern *regexp.Regexp, occurrences int, dial dialFunc, httpClient *http.Client) (string, error) {
+	if config.Healthy {
+		if info.State.Health == nil {
+			return "", fmt.Errorf("wait_for.healthy is set but the image has no HEALTHCHECK")
+		}
+		switch info.State.Health.Status {
+		case "healthy":
+		case "unhealthy":
+			return "", fmt.Errorf("container %s is unhealthy", shortID(info.ID))
+		default:
+			return "health is " + info.State.Health.Status, nil
+		}
+	}
+
+	if config.Port != "" {
+		hostPort, err := publishedPort(info, config.Port)
+		if err != nil {
+			return "", err
+		}
+		address := net.JoinHostPort(connectHost, strconv.Itoa(hostPort))
+		dialCtx, cancel := context.WithTimeout(ctx, time.Second)
+		conn, err := dial(dialCtx, "tcp", address)
+		cancel()
+		if err != nil {
+			return fmt.Sprintf("port %s (%s) is not accepting connections", config.Port, address), nil
+		}
+		_ = conn.Close()
+	}
+
+	if logPattern != nil {
+		output, err := e.logs(ctx, info.ID, 0)
+		if err != nil {
+			return "", err
+		}
+		if found := len(logPattern.FindAllStringIndex(output, -1)); found < occurrences {
+			return fmt.Sprintf("log matched %q %d of %d times", config.Log, found, occurrences), nil
+		}
+	}
+
+	if config.HTTP != nil {
+		hostPort, err := publishedPort(info, config.HTTP.Port)
+		if err != nil {
+			return "", err
+		}
+		path := config.HTTP.Path
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		status := config.HTTP.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		target := "http://" + net.JoinHostPort(connectHost, strconv.Itoa(hostPort)) + path
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return "", err
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return fmt.Sprintf("GET %s failed: %v", target, err), nil
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != status {
+			return fmt.Sprintf("GET %s returned %d, want %d", target, resp.StatusCode, status), nil
+		}
+	}
+
+	return "", nil
+}
+
+// publishedPort returns the host port a container port is published on
+func publishedPort(info *containerInfo, port string) (int, error) {
+	key := port
+	if !strings.Contains(key, "/") {
+		key += "/tcp"
+	}
+	for _, binding := range info.NetworkSettings.Ports[key] {
+		if hostPort, err := strconv.Atoi(binding.HostPort); err == nil && hostPort > 0 {
+			return hostPort, nil
+		}
+	}
+	return 0, fmt.Errorf("container port %s is not published; add it to ports", port)
+}
+
+func lastLines(output string) string {
+	output = strings.TrimRight(output, "\n")
+	if output == "" {
+		return "(no output)"
+	}
+	lines := strings.Split(output, "\n")
+	if len(lines) > 5 {
+		lines = lines[len(lines)-5:]
+	}
+	return strings.Join(lines, " | ")
+}
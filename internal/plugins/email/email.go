@@ -0,0 +1,589 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+	"go.temporal.io/sdk/activity"
+)
+
+const (
+	defaultTimeout  = 60 * time.Second
+	defaultInterval = time.Second
+)
+
+// Auto-register the plugin when the package is imported
+func init() {
+	plugins.RegisterPlugin(&EmailPlugin{})
+}
+
+// GetType returns the plugin type identifier
+func (ep *EmailPlugin) GetType() string {
+	return "email"
+}
+
+// Activity sends a message over SMTP and/or waits for a matching message in a mailbox
+func (ep *EmailPlugin) Activity(ctx context.Context, p map[string]interface{}) (interface{}, error) {
+	logger := activity.GetLogger(ctx)
+
+	// Parse configuration from parameters
+	configData, ok := p["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid config format")
+	}
+
+	config := &EmailConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		return nil, fmt.Errorf("failed to parse email config: %w", err)
+	}
+
+	timeout := defaultTimeout
+	if config.Timeout != "" {
+		parsed, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", config.Timeout, err)
+		}
+		timeout = parsed
+	}
+
+	// Convert state to map[string]interface{} for template processing
+	state := make(map[string]interface{})
+	if stateStr, ok := p["state"].(map[string]string); ok {
+		for k, v := range stateStr {
+			state[k] = v
+		}
+	} else if stateInt, ok := p["state"].(map[string]interface{}); ok {
+		state = stateInt
+	}
+
+	// Extract env secrets from params (for {{ .env.* }} template resolution)
+	env := make(map[string]string)
+	if envData, ok := p["env"].(map[string]interface{}); ok {
+		for k, v := range envData {
+			if strVal, ok := v.(string); ok {
+				env[k] = strVal
+			}
+		}
+	} else if envData, ok := p["env"].(map[string]string); ok {
+		env = envData
+	}
+
+	if err := applyVariableReplacement(config, state, env); err != nil {
+		return nil, fmt.Errorf("variable replacement failed: %w", err)
+	}
+
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	policy, err := egress.FromParams(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
+
+	logger.Info("Executing email plugin", "send", config.Send != nil, "receive", config.Receive != nil)
+
+	response, err := execute(ctx, config, timeout, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := resultSubject(response)
+	if err != nil {
+		return nil, err
+	}
+
+	assertionResults, err := processAssertions(p, response, subject, state, env)
+	if err != nil {
+		return nil, err
+	}
+	if failure := assertions.Summary(assertionResults); failure != "" {
+		return nil, fmt.Errorf("%s", failure)
+	}
+
+	saved := make(map[string]string)
+	if err := saves.Process(p, subject, saved); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Email step completed", "duration", response.Duration)
+
+	return &ActivityResponse{
+		Response:         response,
+		Saved:            saved,
+		AssertionResults: assertionResults,
+	}, nil
+}
+
+// execute runs the send and receive phases
+func execute(ctx context.Context, config *EmailConfig, timeout time.Duration, policy *egress.Policy) (*EmailResponse, error) {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	response := &EmailResponse{}
+
+	var box inbox
+	skip := make(map[string]bool)
+	if config.Receive != nil {
+		var err error
+		box, err = openInbox(ctx, config.Receive, policy)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = box.close() }()
+
+		// Snapshot before sending so the message we send counts as new
+		if config.Receive.NewOnly {
+			ids, err := box.list(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range ids {
+				skip[id] = true
+			}
+		}
+	}
+
+	if config.Send != nil {
+		sent, err := send(ctx, config.SMTP, config.Send, policy)
+		if err != nil {
+			return nil, err
+		}
+		response.Sent = sent
+	}
+
+	if box != nil {
+		message, err := receive(ctx, config.Receive, box, skip)
+		if err != nil {
+			return nil, err
+		}
+		response.Message = message
+	}
+
+	response.Duration = time.Since(start).String()
+	return response, nil
+}
+
+// receive polls the inbox until a message matching the filter arrives
+func receive(ctx context.Context, config *ReceiveConfig, box inbox, skip map[string]bool) (*Message, error) {
+	wait := time.Duration(0)
+	if config.Timeout != "" {
+		parsed, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid receive.timeout %q: %w", config.Timeout, err)
+		}
+		wait = parsed
+	}
+	interval := defaultInterval
+	if config.Interval != "" {
+		parsed, err := time.ParseDuration(config.Interval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid receive.interval %q: %w", config.Interval, err)
+		}
+		interval = parsed
+	}
+
+	if wait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, wait)
+		defer cancel()
+	}
+
+	// Messages don't change, so each one is fetched and parsed once
+	parsed := make(map[string]*Message)
+	for {
+		ids, err := box.list(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, noMatchError(config.Match)
+			}
+			return nil, err
+		}
+
+		for _, id := range ids {
+			if skip[id] {
+				continue
+			}
+			msg, ok := parsed[id]
+			if !ok {
+				raw, err := box.fetch(ctx, id)
+				if err != nil {
+					if ctx.Err() != nil {
+						return nil, noMatchError(config.Match)
+					}
+					return nil, err
+				}
+				if msg, err = parseMessage(id, raw); err != nil {
+					return nil, err
+				}
+				parsed[id] = msg
+			}
+			if matches(msg, config.Match) {
+				return msg, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, noMatchError(config.Match)
+		case <-time.After(interval):
+		}
+	}
+}
+
+// matches reports whether msg satisfies every field of the filter
+func matches(msg *Message, match *MatchConfig) bool {
+	if match == nil {
+		return true
+	}
+	containsFold := func(s, substr string) bool {
+		return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+	}
+
+	if match.To != "" {
+		found := false
+		for _, addr := range append(append([]string(nil), msg.To...), msg.Cc...) {
+			if containsFold(addr, match.To) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if match.From != "" && !containsFold(msg.From, match.From) {
+		return false
+	}
+	if match.Subject != "" && !containsFold(msg.Subject, match.Subject) {
+		return false
+	}
+	if match.Contains != "" && !containsFold(msg.Text, match.Contains) && !containsFold(msg.HTML, match.Contains) {
+		return false
+	}
+	return true
+}
+
+func noMatchError(match *MatchConfig) error {
+	var parts []string
+	if match != nil {
+		for _, field := range []struct{ name, value string }{
+			{"to", match.To}, {"from", match.From}, {"subject", match.Subject}, {"contains", match.Contains},
+		} {
+			if field.value != "" {
+				parts = append(parts, fmt.Sprintf("%s %q", field.name, field.value))
+			}
+		}
+	}
+	if len(parts) == 0 {
+		return fmt.Errorf("no message arrived before the receive timeout")
+	}
+	return fmt.Errorf("no message matching %s arrived before the receive timeout", strings.Join(parts, ", "))
+}
+
+// resultSubject is what assertions with a path and saves run against: the received
+// message, or the sent message details when the step only sends
+func resultSubject(response *EmailResponse) (interface{}, error) {
+	var subject interface{} = response.Sent
+	if response.Message != nil {
+		subject = response.Message
+	}
+	normalized, err := assertions.Normalize(subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare email result: %w", err)
+	}
+	return normalized, nil
+}
+
+// processAssertions evaluates all assertions and returns their results.
+// Shared assertions without a path check the message body.
+func processAssertions(p map[string]interface{}, response *EmailResponse, subject interface{}, state map[string]interface{}, env map[string]string) ([]AssertionResult, error) {
+	context := dsl.TemplateContext{Runtime: state, Env: env}
+	return assertions.Process(p, subject, context, func(assertionMap map[string]interface{}, result *AssertionResult) bool {
+		return evaluateAssertion(response, subject, assertionMap, result)
+	})
+}
+
+// evaluateAssertion evaluates the email plugin's own assertion types
+func evaluateAssertion(response *EmailResponse, subject interface{}, assertionMap map[string]interface{}, result *AssertionResult) bool {
+	expected := result.Expected
+	switch result.Type {
+	case AssertionTypeHeader:
+		name, _ := assertionMap["name"].(string)
+		if name == "" {
+			result.Message = "name is required for header assertion"
+			break
+		}
+		result.Name = name
+		if response.Message == nil {
+			result.Message = "no message received"
+			break
+		}
+		actual, found := lookupHeader(response.Message.Headers, name)
+		if !found {
+			result.Message = fmt.Sprintf("header %q not present", name)
+			break
+		}
+		result.Actual = actual
+		if !assertions.Equal(actual, expected) {
+			result.Message = fmt.Sprintf("expected header %s=%v, got %v", name, expected, actual)
+		} else {
+			result.Passed = true
+		}
+
+	default:
+		_, hasPath := assertionMap["path"]
+		if !hasPath && response.Message != nil {
+			*result = assertions.Evaluate(assertionMap, response.Message.body(), expected)
+		} else {
+			*result = assertions.Evaluate(assertionMap, subject, expected)
+		}
+	}
+	return true
+}
+
+func lookupHeader(headers map[string]string, name string) (string, bool) {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// validateConfig checks the phases once templates are rendered
+func validateConfig(config *EmailConfig) error {
+	if config.Send == nil && config.Receive == nil {
+		return fmt.Errorf("at least one of send or receive is required")
+	}
+
+	if config.Send != nil {
+		switch {
+		case config.SMTP == nil || config.SMTP.Host == "":
+			return fmt.Errorf("smtp.host is required to send")
+		case config.Send.From == "":
+			return fmt.Errorf("send.from is required")
+		case len(config.Send.To) == 0:
+			return fmt.Errorf("send.to must contain at least one recipient")
+		case config.Send.Text == "" && config.Send.HTML == "":
+			return fmt.Errorf("one of send.text or send.html is required")
+		}
+	}
+	if config.SMTP != nil {
+		switch config.SMTP.Security {
+		case "", SecurityStartTLS, SecurityTLS, SecurityNone:
+		default:
+			return fmt.Errorf("smtp.security must be starttls, tls or none, got %q", config.SMTP.Security)
+		}
+	}
+
+	if config.Receive != nil {
+		sources := 0
+		for _, set := range []bool{config.Receive.Mailpit != "", config.Receive.Mailhog != "", config.Receive.IMAP != nil} {
+			if set {
+				sources++
+			}
+		}
+		if sources != 1 {
+			return fmt.Errorf("receive requires exactly one of mailpit, mailhog or imap")
+		}
+		if imap := config.Receive.IMAP; imap != nil {
+			switch {
+			case imap.Host == "":
+				return fmt.Errorf("receive.imap.host is required")
+			case imap.Username == "":
+				return fmt.Errorf("receive.imap.username is required")
+			case imap.Security != "" && imap.Security != SecurityTLS && imap.Security != SecurityNone:
+				return fmt.Errorf("receive.imap.security must be tls or none, got %q", imap.Security)
+			}
+		}
+	}
+
+	return nil
+}
+
+// applyVariableReplacement processes templates in server settings, the sent message and the filter
+func applyVariableReplacement(config *EmailConfig, state map[string]interface{}, env map[string]string) error {
+	context := dsl.TemplateContext{
+		Runtime: state,
+		Env:     env,
+	}
+
+	var fields []*string
+	if config.SMTP != nil {
+		fields = append(fields, &config.SMTP.Host, &config.SMTP.Username, &config.SMTP.Password)
+	}
+	if send := config.Send; send != nil {
+		fields = append(fields, &send.From, &send.Subject, &send.Text, &send.HTML)
+		for _, list := range [][]string{send.To, send.Cc, send.Bcc} {
+			for i := range list {
+				fields = append(fields, &list[i])
+			}
+		}
+	}
+	if receive := config.Receive; receive != nil {
+		fields = append(fields, &receive.Mailpit, &receive.Mailhog)
+		if receive.IMAP != nil {
+			fields = append(fields, &receive.IMAP.Host, &receive.IMAP.Username, &receive.IMAP.Password, &receive.IMAP.Mailbox)
+		}
+		if receive.Match != nil {
+			fields = append(fields, &receive.Match.To, &receive.Match.From, &receive.Match.Subject, &receive.Match.Contains)
+		}
+	}
+
+	for _, field := range fields {
+		if *field == "" {
+			continue
+		}
+		processed, err := dsl.ProcessTemplate(*field, context)
+		if err != nil {
+			return fmt.Errorf("failed to process template %q: %w", *field, err)
+		}
+		*field = processed
+	}
+
+	if config.Send != nil {
+		for name, value := range config.Send.Headers {
+			processed, err := dsl.ProcessTemplate(value, context)
+			if err != nil {
+				return fmt.Errorf("failed to process header %s template: %w", name, err)
+			}
+			config.Send.Headers[name] = processed
+		}
+	}
+
+	return nil
+}
+
+// parseConfig converts map[string]interface{} to EmailConfig
+func parseConfig(configData map[string]interface{}, config *EmailConfig) error {
+	config.Timeout = stringValue(configData["timeout"])
+
+	if smtpData, ok := configData["smtp"].(map[string]interface{}); ok {
+		port, err := intValue(smtpData["port"], "smtp.port")
+		if err != nil {
+			return err
+		}
+		config.SMTP = &SMTPConfig{
+			Host:               stringValue(smtpData["host"]),
+			Port:               port,
+			Username:           stringValue(smtpData["username"]),
+			Password:           stringValue(smtpData["password"]),
+			Security:           stringValue(smtpData["security"]),
+			InsecureSkipVerify: boolValue(smtpData["insecure_skip_verify"]),
+		}
+	}
+
+	if sendData, ok := configData["send"].(map[string]interface{}); ok {
+		config.Send = &SendConfig{
+			From:    stringValue(sendData["from"]),
+			Subject: stringValue(sendData["subject"]),
+			Text:    stringValue(sendData["text"]),
+			HTML:    stringValue(sendData["html"]),
+		}
+		var err error
+		if config.Send.To, err = stringList(sendData["to"], "send.to"); err != nil {
+			return err
+		}
+		if config.Send.Cc, err = stringList(sendData["cc"], "send.cc"); err != nil {
+			return err
+		}
+		if config.Send.Bcc, err = stringList(sendData["bcc"], "send.bcc"); err != nil {
+			return err
+		}
+		if headers, ok := sendData["headers"].(map[string]interface{}); ok {
+			config.Send.Headers = make(map[string]string, len(headers))
+			for k, v := range headers {
+				config.Send.Headers[k] = fmt.Sprint(v)
+			}
+		}
+	}
+
+	if receiveData, ok := configData["receive"].(map[string]interface{}); ok {
+		config.Receive = &ReceiveConfig{
+			Mailpit:  stringValue(receiveData["mailpit"]),
+			Mailhog:  stringValue(receiveData["mailhog"]),
+			NewOnly:  boolValue(receiveData["new_only"]),
+			Timeout:  stringValue(receiveData["timeout"]),
+			Interval: stringValue(receiveData["interval"]),
+		}
+		if imapData, ok := receiveData["imap"].(map[string]interface{}); ok {
+			port, err := intValue(imapData["port"], "receive.imap.port")
+			if err != nil {
+				return err
+			}
+			config.Receive.IMAP = &IMAPConfig{
+				Host:               stringValue(imapData["host"]),
+				Port:               port,
+				Username:           stringValue(imapData["username"]),
+				Password:           stringValue(imapData["password"]),
+				Mailbox:            stringValue(imapData["mailbox"]),
+				Security:           stringValue(imapData["security"]),
+				InsecureSkipVerify: boolValue(imapData["insecure_skip_verify"]),
+			}
+		}
+		if matchData, ok := receiveData["match"].(map[string]interface{}); ok {
+			config.Receive.Match = &MatchConfig{
+				To:       stringValue(matchData["to"]),
+				From:     stringValue(matchData["from"]),
+				Subject:  stringValue(matchData["subject"]),
+				Contains: stringValue(matchData["contains"]),
+			}
+		}
+	}
+
+	return nil
+}
+
+// stringList accepts a single string or a list of strings
+func stringList(v interface{}, field string) ([]string, error) {
+	switch list := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{list}, nil
+	case []interface{}:
+		out := make([]string, 0, len(list))
+		for i, item := range list {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s[%d] must be a string", field, i)
+			}
+			out = append(out, s)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("%s must be a string or a list of strings", field)
+	}
+}
+
+func intValue(v interface{}, field string) (int, error) {
+	switch n := v.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		return int(n), nil
+	case int:
+		return n, nil
+	default:
+		return 0, fmt.Errorf("%s must be a number, got %T", field, v)
+	}
+}
+
+func stringValue(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+func boolValue(v interface{}) bool {
+	b, _ := v.(bool)
+	return b
+}
@@ -0,0 +1,344 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+)
+
+// fakeSMTP accepts every message and keeps its raw source
+type fakeSMTP struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (f *fakeSMTP) start(t *testing.T) (string, int) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port
+}
+
+func (f *fakeSMTP) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = fmt.Fprintf(conn, "%s\r\n", line) }
+
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 fake")
+		case strings.HasPrefix(cmd, "DATA"):
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			f.mu.Lock()
+			f.messages = append(f.messages, data.String())
+			f.mu.Unlock()
+			reply("250 queued")
+		case strings.HasPrefix(cmd, "QUIT"):
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func (f *fakeSMTP) raw() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.messages...)
+}
+
+// mailpitServer serves the messages caught by smtp, newest first, plus any preloaded ones
+func mailpitServer(t *testing.T, smtp *fakeSMTP, preloaded ...string) *httptest.Server {
+	t.Helper()
+	all := func() []string {
+		return append(append([]string(nil), preloaded...), smtp.raw()...)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		messages := all()
+		switch {
+		case r.URL.Path == "/api/v1/messages":
+			var ids []string
+			for i := len(messages) - 1; i >= 0; i-- {
+				ids = append(ids, fmt.Sprintf(`{"ID":"msg-%d"}`, i))
+			}
+			_, _ = fmt.Fprintf(w, `{"messages":[%s]}`, strings.Join(ids, ","))
+		case strings.HasPrefix(r.URL.Path, "/api/v1/message/msg-") && strings.HasSuffix(r.URL.Path, "/raw"):
+			i, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/message/msg-"), "/raw"))
+			if i >= len(messages) {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write([]byte(messages[i]))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+const oldSignup = "From: noreply@example.com\r\nTo: new-user@example.com\r\nSubject: Verify your account\r\n\r\nOld link: https://app.example.com/verify?token=stale\r\n"
+
+func TestSendAndReceiveViaMailpit(t *testing.T) {
+	smtp := &fakeSMTP{}
+	host, port := smtp.start(t)
+	mailpit := mailpitServer(t, smtp, oldSignup)
+
+	config := &EmailConfig{
+		SMTP: &SMTPConfig{Host: host, Port: port, Security: SecurityNone},
+		Send: &SendConfig{
+			From:    "Rocketship <noreply@example.com>",
+			To:      []string{"new-user@example.com"},
+			Subject: "Verify your account ✔",
+			Text:    "Confirm here: https://app.example.com/verify?token=abc&step=1.",
+			HTML:    `<p><a href="https://app.example.com/verify?token=abc&amp;step=1">Confirm</a></p>`,
+			Headers: map[string]string{"x-campaign": "signup"},
+		},
+		Receive: &ReceiveConfig{
+			Mailpit:  mailpit.URL,
+			Match:    &MatchConfig{To: "NEW-USER@", Subject: "verify"},
+			NewOnly:  true,
+			Interval: "10ms",
+		},
+	}
+	if err := validateConfig(config); err != nil {
+		t.Fatalf("validateConfig: %v", err)
+	}
+
+	response, err := execute(context.Background(), config, 5*time.Second, nil)
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	msg := response.Message
+	if msg == nil || response.Sent == nil {
+		t.Fatalf("expected a sent and a received message, got %+v", response)
+	}
+	if msg.MessageID != response.Sent.MessageID {
+		t.Errorf("received message %q, sent %q", msg.MessageID, response.Sent.MessageID)
+	}
+	if msg.Subject != "Verify your account ✔" || msg.From != "noreply@example.com" {
+		t.Errorf("unexpected message: %+v", msg)
+	}
+	if len(msg.Links) != 1 || msg.Links[0] != "https://app.example.com/verify?token=abc&step=1" {
+		t.Errorf("unexpected links: %v", msg.Links)
+	}
+
+	subject, err := resultSubject(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := map[string]interface{}{
+		"assertions": []interface{}{
+			map[string]interface{}{"type": "contains", "expected": "Confirm here"},
+			map[string]interface{}{"type": "header", "name": "X-Campaign", "expected": "signup"},
+			map[string]interface{}{"type": "equals", "path": ".to[0]", "expected": "new-user@example.com"},
+		},
+		"save": []interface{}{
+			map[string]interface{}{"json_path": `.links[0] | capture("token=(?<t>[^&]+)").t`, "as": "token"},
+		},
+	}
+	if results, err := processAssertions(p, response, subject, map[string]interface{}{}, map[string]string{}); err != nil || assertions.Summary(results) != "" {
+		t.Fatalf("unexpected assertion failure: %v (%+v)", err, results)
+	}
+	saved := map[string]string{}
+	if err := saves.Process(p, subject, saved); err != nil {
+		t.Fatalf("saves: %v", err)
+	}
+	if saved["token"] != "abc" {
+		t.Errorf("unexpected saves: %v", saved)
+	}
+}
+
+func TestReceiveTimesOut(t *testing.T) {
+	mailpit := mailpitServer(t, &fakeSMTP{}, oldSignup)
+	config := &EmailConfig{Receive: &ReceiveConfig{
+		Mailpit:  mailpit.URL,
+		Match:    &MatchConfig{Subject: "password reset"},
+		Timeout:  "50ms",
+		Interval: "10ms",
+	}}
+
+	_, err := execute(context.Background(), config, 5*time.Second, nil)
+	if err == nil || !strings.Contains(err.Error(), `no message matching subject "password reset"`) {
+		t.Errorf("expected a no-match error, got %v", err)
+	}
+}
+
+// startIMAP serves a single mailbox holding messages, in the subset of IMAP the client uses
+func startIMAP(t *testing.T, messages ...string) (string, int) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		r := bufio.NewReader(conn)
+		_, _ = fmt.Fprint(conn, "* OK IMAP4rev1 ready\r\n")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			tag, cmd, _ := strings.Cut(strings.TrimSpace(line), " ")
+			switch {
+			case strings.HasPrefix(cmd, "LOGIN "):
+				if cmd != `LOGIN "qa@example.com" "p\"ss"` {
+					_, _ = fmt.Fprintf(conn, "%s NO invalid credentials\r\n", tag)
+					continue
+				}
+			case strings.HasPrefix(cmd, "EXAMINE "):
+				_, _ = fmt.Fprintf(conn, "* %d EXISTS\r\n", len(messages))
+			case cmd == "UID SEARCH ALL":
+				uids := make([]string, len(messages))
+				for i := range messages {
+					uids[i] = strconv.Itoa(i + 1)
+				}
+				_, _ = fmt.Fprintf(conn, "* SEARCH %s\r\n", strings.Join(uids, " "))
+			case strings.HasPrefix(cmd, "UID FETCH "):
+				uid, _ := strconv.Atoi(strings.Fields(cmd)[2])
+				body := messages[uid-1]
+				_, _ = fmt.Fprintf(conn, "* %d FETCH (UID %d BODY[] {%d}\r\n%s)\r\n", uid, uid, len(body), body)
+			case cmd == "LOGOUT":
+				_, _ = fmt.Fprintf(conn, "* BYE\r\n%s OK done\r\n", tag)
+				return
+			}
+			_, _ = fmt.Fprintf(conn, "%s OK done\r\n", tag)
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port
+}
+
+func TestReceiveViaIMAP(t *testing.T) {
+	reset := "From: Support <support@example.com>\r\n" +
+		"To: qa@example.com\r\n" +
+		"Subject: =?utf-8?q?Reset_your_password?=\r\n" +
+		"Content-Type: multipart/alternative; boundary=b1\r\n" +
+		"\r\n" +
+		"--b1\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"Content-Transfer-Encoding: quoted-printable\r\n" +
+		"\r\n" +
+		"Reset: https://app.example.com/reset?code=3D42&user=3Dqa=\r\n" +
+		"@example.com\r\n" +
+		"--b1\r\n" +
+		"Content-Type: text/html; charset=utf-8\r\n" +
+		"Content-Transfer-Encoding: base64\r\n" +
+		"\r\n" +
+		"PGEgaHJlZj0iaHR0cHM6Ly9hcHAuZXhhbXBsZS5jb20vcmVzZXQ/Y29kZT00MiI+UmVzZXQ8L2E+\r\n" +
+		"--b1--\r\n"
+	host, port := startIMAP(t, oldSignup, reset)
+
+	config := &EmailConfig{Receive: &ReceiveConfig{
+		IMAP:     &IMAPConfig{Host: host, Port: port, Username: "qa@example.com", Password: `p"ss`, Security: SecurityNone},
+		Match:    &MatchConfig{From: "support@", Contains: "reset"},
+		Interval: "10ms",
+	}}
+	if err := validateConfig(config); err != nil {
+		t.Fatalf("validateConfig: %v", err)
+	}
+
+	response, err := execute(context.Background(), config, 5*time.Second, nil)
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	msg := response.Message
+	if msg.ID != "2" || msg.Subject != "Reset your password" {
+		t.Errorf("unexpected message: %+v", msg)
+	}
+	if msg.Text != "Reset: https://app.example.com/reset?code=42&user=qa@example.com" {
+		t.Errorf("unexpected text body: %q", msg.Text)
+	}
+	want := []string{"https://app.example.com/reset?code=42", "https://app.example.com/reset?code=42&user=qa@example.com"}
+	if strings.Join(msg.Links, " ") != strings.Join(want, " ") {
+		t.Errorf("unexpected links: %v", msg.Links)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  EmailConfig
+		wantErr string
+	}{
+		{"no phases", EmailConfig{}, "at least one of send or receive"},
+		{"send without smtp", EmailConfig{Send: &SendConfig{From: "a@example.com", To: []string{"b@example.com"}, Text: "hi"}}, "smtp.host is required"},
+		{"send without body", EmailConfig{SMTP: &SMTPConfig{Host: "smtp"}, Send: &SendConfig{From: "a@example.com", To: []string{"b@example.com"}}}, "send.text or send.html"},
+		{"two mailboxes", EmailConfig{Receive: &ReceiveConfig{Mailpit: "http://mailpit:8025", Mailhog: "http://mailhog:8025"}}, "exactly one of mailpit, mailhog or imap"},
+		{"bad security", EmailConfig{SMTP: &SMTPConfig{Host: "smtp", Security: "ssl"}, Receive: &ReceiveConfig{Mailpit: "http://mailpit:8025"}}, "smtp.security"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateConfig(&tt.config)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	config := &EmailConfig{}
+	err := parseConfig(map[string]interface{}{
+		"send": map[string]interface{}{"to": "solo@example.com"},
+		"smtp": map[string]interface{}{"port": float64(1025)},
+	}, config)
+	if err != nil {
+		t.Fatalf("parseConfig: %v", err)
+	}
+	if len(config.Send.To) != 1 || config.SMTP.Port != 1025 {
+		t.Errorf("unexpected config: %+v %+v", config.Send, config.SMTP)
+	}
+}
@@ -0,0 +1,197 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/rocketship-ai/rocketship/internal/egress"
+)
+
+// imapInbox is a read-only IMAP4rev1 client covering what the receive phase needs:
+// LOGIN, EXAMINE, UID SEARCH and UID FETCH. The mailbox is opened with EXAMINE and
+// bodies are fetched with BODY.PEEK, so no flags are changed.
+type imapInbox struct {
+	conn net.Conn
+	r    *bufio.Reader
+	tag  int
+	stop func() bool
+}
+
+// imapResponse is one server response line with any literals it carried
+type imapResponse struct {
+	text     string
+	literals [][]byte
+}
+
+func dialIMAP(ctx context.Context, config *IMAPConfig, policy *egress.Policy) (*imapInbox, error) {
+	port := config.Port
+	if port == 0 {
+		port = 993
+		if config.Security == SecurityNone {
+			port = 143
+		}
+	}
+	addr := net.JoinHostPort(config.Host, strconv.Itoa(port))
+
+	conn, err := policy.DialContext(&net.Dialer{})(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to imap server %s: %w", addr, err)
+	}
+	if config.Security != SecurityNone {
+		conn = tls.Client(conn, &tls.Config{ServerName: config.Host, InsecureSkipVerify: config.InsecureSkipVerify})
+	}
+
+	c := &imapInbox{
+		conn: conn,
+		r:    bufio.NewReader(conn),
+		// Closing the connection unblocks reads when the step times out
+		stop: context.AfterFunc(ctx, func() { _ = conn.Close() }),
+	}
+
+	greeting, err := c.readResponse()
+	if err != nil {
+		_ = c.close()
+		return nil, fmt.Errorf("imap handshake with %s failed: %w", addr, err)
+	}
+	if !strings.HasPrefix(greeting.text, "* OK") {
+		_ = c.close()
+		return nil, fmt.Errorf("imap server %s refused the connection: %s", addr, greeting.text)
+	}
+
+	if _, err := c.command("LOGIN " + imapQuote(config.Username) + " " + imapQuote(config.Password)); err != nil {
+		_ = c.close()
+		return nil, fmt.Errorf("imap login failed: %w", err)
+	}
+
+	mailbox := config.Mailbox
+	if mailbox == "" {
+		mailbox = "INBOX"
+	}
+	if _, err := c.command("EXAMINE " + imapQuote(mailbox)); err != nil {
+		_ = c.close()
+		return nil, fmt.Errorf("failed to open mailbox %s: %w", mailbox, err)
+	}
+
+	return c, nil
+}
+
+func (c *imapInbox) list(_ context.Context) ([]string, error) {
+	responses, err := c.command("UID SEARCH ALL")
+	if err != nil {
+		return nil, fmt.Errorf("imap search failed: %w", err)
+	}
+
+	var uids []string
+	for _, resp := range responses {
+		if rest, ok := strings.CutPrefix(resp.text, "* SEARCH"); ok {
+			uids = append(uids, strings.Fields(rest)...)
+		}
+	}
+
+	// UIDs ascend with arrival, so the newest messages are at the end
+	ids := make([]string, 0, min(len(uids), recentLimit))
+	for i := len(uids) - 1; i >= 0 && len(ids) < recentLimit; i-- {
+		ids = append(ids, uids[i])
+	}
+	return ids, nil
+}
+
+func (c *imapInbox) fetch(_ context.Context, id string) ([]byte, error) {
+	if _, err := strconv.ParseUint(id, 10, 32); err != nil {
+		return nil, fmt.Errorf("invalid imap uid %q", id)
+	}
+	responses, err := c.command("UID FETCH " + id + " BODY.PEEK[]")
+	if err != nil {
+		return nil, fmt.Errorf("imap fetch of uid %s failed: %w", id, err)
+	}
+	for _, resp := range responses {
+		if strings.Contains(resp.text, " FETCH ") && len(resp.literals) > 0 {
+			return resp.literals[0], nil
+		}
+	}
+	return nil, fmt.Errorf("imap server returned no body for uid %s", id)
+}
+
+func (c *imapInbox) close() error {
+	c.stop()
+	// Best effort; the connection is closed either way
+	_, _ = fmt.Fprintf(c.conn, "a%d LOGOUT\r\n", c.tag+1)
+	return c.conn.Close()
+}
+
+// command sends a tagged command and returns the untagged responses once the server
+// completes it. A NO or BAD completion is returned as an error.
+func (c *imapInbox) command(cmd string) ([]imapResponse, error) {
+	c.tag++
+	tag := fmt.Sprintf("a%d", c.tag)
+	if _, err := fmt.Fprintf(c.conn, "%s %s\r\n", tag, cmd); err != nil {
+		return nil, err
+	}
+
+	var responses []imapResponse
+	for {
+		resp, err := c.readResponse()
+		if err != nil {
+			return nil, err
+		}
+		if status, ok := strings.CutPrefix(resp.text, tag+" "); ok {
+			if !strings.HasPrefix(status, "OK") {
+				return nil, fmt.Errorf("%s", status)
+			}
+			return responses, nil
+		}
+		responses = append(responses, resp)
+	}
+}
+
+// readResponse reads one response line, following {n} literals onto the next line
+func (c *imapInbox) readResponse() (imapResponse, error) {
+	var resp imapResponse
+	var text strings.Builder
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return resp, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		text.WriteString(line)
+
+		size, ok := literalSize(line)
+		if !ok {
+			resp.text = text.String()
+			return resp, nil
+		}
+		literal := make([]byte, size)
+		if _, err := io.ReadFull(c.r, literal); err != nil {
+			return resp, err
+		}
+		resp.literals = append(resp.literals, literal)
+	}
+}
+
+// literalSize parses a trailing {n} literal marker
+func literalSize(line string) (int, bool) {
+	if !strings.HasSuffix(line, "}") {
+		return 0, false
+	}
+	open := strings.LastIndex(line, "{")
+	if open < 0 {
+		return 0, false
+	}
+	size, err := strconv.Atoi(line[open+1 : len(line)-1])
+	if err != nil || size < 0 {
+		return 0, false
+	}
+	return size, true
+}
+
+// imapQuote renders s as an IMAP quoted string
+func imapQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
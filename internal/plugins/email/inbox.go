@@ -0,0 +1,148 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/egress"
+)
+
+// recentLimit is how many of the newest messages are checked on each poll
+const recentLimit = 50
+
+// inbox is a mailbox the receive phase polls
+type inbox interface {
+	// list returns the IDs of the most recent messages, newest first
+	list(ctx context.Context) ([]string, error)
+	// fetch returns the raw RFC 5322 source of a message
+	fetch(ctx context.Context, id string) ([]byte, error)
+	close() error
+}
+
+// openInbox connects to the mailbox named in the receive configuration
+func openInbox(ctx context.Context, config *ReceiveConfig, policy *egress.Policy) (inbox, error) {
+	switch {
+	case config.Mailpit != "":
+		return newAPIInbox(config.Mailpit, mailpitAPI, policy)
+	case config.Mailhog != "":
+		return newAPIInbox(config.Mailhog, mailhogAPI, policy)
+	default:
+		return dialIMAP(ctx, config.IMAP, policy)
+	}
+}
+
+// apiFlavor describes the HTTP API of a development mail catcher
+type apiFlavor struct {
+	name     string
+	listPath string
+	rawPath  string // %s is the message ID
+	ids      func(body []byte) ([]string, error)
+}
+
+var mailpitAPI = apiFlavor{
+	name:     "mailpit",
+	listPath: fmt.Sprintf("/api/v1/messages?limit=%d", recentLimit),
+	rawPath:  "/api/v1/message/%s/raw",
+	ids: func(body []byte) ([]string, error) {
+		var resp struct {
+			Messages []struct {
+				ID string `json:"ID"`
+			} `json:"messages"`
+		}
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, err
+		}
+		ids := make([]string, 0, len(resp.Messages))
+		for _, m := range resp.Messages {
+			ids = append(ids, m.ID)
+		}
+		return ids, nil
+	},
+}
+
+var mailhogAPI = apiFlavor{
+	name:     "mailhog",
+	listPath: fmt.Sprintf("/api/v2/messages?limit=%d", recentLimit),
+	rawPath:  "/api/v1/messages/%s/download",
+	ids: func(body []byte) ([]string, error) {
+		var resp struct {
+			Items []struct {
+				ID string `json:"ID"`
+			} `json:"items"`
+		}
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, err
+		}
+		ids := make([]string, 0, len(resp.Items))
+		for _, m := range resp.Items {
+			ids = append(ids, m.ID)
+		}
+		return ids, nil
+	},
+}
+
+// apiInbox reads messages from Mailpit or MailHog over HTTP
+type apiInbox struct {
+	baseURL string
+	flavor  apiFlavor
+	client  *http.Client
+}
+
+func newAPIInbox(baseURL string, flavor apiFlavor, policy *egress.Policy) (*apiInbox, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid %s url %q", flavor.name, baseURL)
+	}
+	return &apiInbox{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		flavor:  flavor,
+		client:  &http.Client{Timeout: 10 * time.Second, Transport: policy.Transport()},
+	}, nil
+}
+
+func (a *apiInbox) get(ctx context.Context, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s request failed: %w", a.flavor.name, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s response: %w", a.flavor.name, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s for %s", a.flavor.name, resp.Status, path)
+	}
+	return body, nil
+}
+
+func (a *apiInbox) list(ctx context.Context) ([]string, error) {
+	body, err := a.get(ctx, a.flavor.listPath)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := a.flavor.ids(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s message list: %w", a.flavor.name, err)
+	}
+	return ids, nil
+}
+
+func (a *apiInbox) fetch(ctx context.Context, id string) ([]byte, error) {
+	return a.get(ctx, fmt.Sprintf(a.flavor.rawPath, url.PathEscape(id)))
+}
+
+func (a *apiInbox) close() error {
+	return nil
+}
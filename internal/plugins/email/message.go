@@ -0,0 +1,156 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// linkPattern finds http(s) URLs in text and in HTML attributes
+var linkPattern = regexp.MustCompile(`https?://[^\s"'<>]+`)
+
+// parseMessage decodes a raw RFC 5322 message into its headers, bodies and links
+func parseMessage(id string, raw []byte) (*Message, error) {
+	m, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse message %s: %w", id, err)
+	}
+
+	decoder := new(mime.WordDecoder)
+	decode := func(value string) string {
+		if decoded, err := decoder.DecodeHeader(value); err == nil {
+			return decoded
+		}
+		return value
+	}
+
+	msg := &Message{
+		ID:        id,
+		MessageID: strings.Trim(m.Header.Get("Message-Id"), "<> "),
+		From:      decode(m.Header.Get("From")),
+		To:        addressList(m.Header, "To"),
+		Cc:        addressList(m.Header, "Cc"),
+		Subject:   decode(m.Header.Get("Subject")),
+		Headers:   make(map[string]string, len(m.Header)),
+	}
+	if from, err := mail.ParseAddress(m.Header.Get("From")); err == nil {
+		msg.From = from.Address
+	}
+	if date, err := m.Header.Date(); err == nil {
+		msg.Date = date.UTC().Format(time.RFC3339)
+	}
+	for name, values := range m.Header {
+		if len(values) > 0 {
+			msg.Headers[name] = decode(values[0])
+		}
+	}
+
+	if err := readPart(m.Header.Get("Content-Type"), m.Header.Get("Content-Transfer-Encoding"), m.Body, msg); err != nil {
+		return nil, fmt.Errorf("failed to read body of message %s: %w", id, err)
+	}
+	msg.Links = extractLinks(msg.HTML, msg.Text)
+
+	return msg, nil
+}
+
+// addressList returns the bare addresses in a header, or the raw value when it doesn't parse
+func addressList(header mail.Header, name string) []string {
+	value := header.Get(name)
+	if value == "" {
+		return nil
+	}
+	list, err := header.AddressList(name)
+	if err != nil {
+		return []string{value}
+	}
+	addresses := make([]string, 0, len(list))
+	for _, addr := range list {
+		addresses = append(addresses, addr.Address)
+	}
+	return addresses
+}
+
+// readPart walks a MIME part, keeping the first text/plain and text/html bodies.
+// Attachments are skipped.
+func readPart(contentType, encoding string, body io.Reader, msg *Message) error {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if disposition, _, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition")); disposition == "attachment" {
+				continue
+			}
+			// multipart.Part already decodes quoted-printable and drops the header
+			if err := readPart(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part, msg); err != nil {
+				return err
+			}
+		}
+	}
+
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		return nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if mediaType == "text/html" && msg.HTML == "" {
+		msg.HTML = text
+	} else if mediaType == "text/plain" && msg.Text == "" {
+		msg.Text = text
+	}
+	return nil
+}
+
+// extractLinks returns the distinct URLs in the given bodies, in order of appearance
+func extractLinks(bodies ...string) []string {
+	links := []string{}
+	seen := make(map[string]bool)
+	for _, body := range bodies {
+		for _, match := range linkPattern.FindAllString(body, -1) {
+			link := strings.TrimRight(html.UnescapeString(match), ".,;:!?)]}")
+			if !seen[link] {
+				seen[link] = true
+				links = append(links, link)
+			}
+		}
+	}
+	return links
+}
+
+// body returns the text body, falling back to the HTML body
+func (m *Message) body() string {
+	if m.Text != "" {
+		return m.Text
+	}
+	return m.HTML
+}
@@ -0,0 +1,196 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/egress"
+)
+
+// send delivers the message through the SMTP server
+func send(ctx context.Context, server *SMTPConfig, message *SendConfig, policy *egress.Policy) (*SentMessage, error) {
+	port := server.Port
+	if port == 0 {
+		port = 587
+		if server.Security == SecurityTLS {
+			port = 465
+		}
+	}
+	addr := net.JoinHostPort(server.Host, strconv.Itoa(port))
+
+	conn, err := policy.DialContext(&net.Dialer{})(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to smtp server %s: %w", addr, err)
+	}
+	// Closing the connection unblocks any SMTP exchange when the step times out
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	tlsConfig := &tls.Config{ServerName: server.Host, InsecureSkipVerify: server.InsecureSkipVerify}
+	if server.Security == SecurityTLS {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	client, err := smtp.NewClient(conn, server.Host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("smtp handshake with %s failed: %w", addr, err)
+	}
+	defer func() { _ = client.Close() }()
+
+	if server.Security != SecurityTLS && server.Security != SecurityNone {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return nil, fmt.Errorf("smtp STARTTLS failed: %w", err)
+			}
+		} else if server.Security == SecurityStartTLS {
+			return nil, fmt.Errorf("smtp server %s does not support STARTTLS", addr)
+		}
+	}
+
+	if server.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", server.Username, server.Password, server.Host)); err != nil {
+			return nil, fmt.Errorf("smtp authentication failed: %w", err)
+		}
+	}
+
+	data, messageID, err := buildMessage(message, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := mail.ParseAddress(message.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address %q: %w", message.From, err)
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return nil, fmt.Errorf("smtp server rejected sender %s: %w", from.Address, err)
+	}
+
+	var recipients []string
+	for _, list := range [][]string{message.To, message.Cc, message.Bcc} {
+		for _, rcpt := range list {
+			addr, err := mail.ParseAddress(rcpt)
+			if err != nil {
+				return nil, fmt.Errorf("invalid recipient %q: %w", rcpt, err)
+			}
+			if err := client.Rcpt(addr.Address); err != nil {
+				return nil, fmt.Errorf("smtp server rejected recipient %s: %w", addr.Address, err)
+			}
+			recipients = append(recipients, addr.Address)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return nil, fmt.Errorf("smtp DATA failed: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("smtp server rejected message: %w", err)
+	}
+	_ = client.Quit()
+
+	return &SentMessage{MessageID: messageID, Recipients: recipients}, nil
+}
+
+// buildMessage renders the RFC 5322 message and returns it with its Message-ID
+func buildMessage(message *SendConfig, now time.Time) ([]byte, string, error) {
+	random := make([]byte, 12)
+	if _, err := rand.Read(random); err != nil {
+		return nil, "", err
+	}
+	domain := "rocketship.local"
+	if from, err := mail.ParseAddress(message.From); err == nil {
+		if at := strings.LastIndex(from.Address, "@"); at >= 0 {
+			domain = from.Address[at+1:]
+		}
+	}
+	messageID := hex.EncodeToString(random) + "@" + domain
+
+	var buf bytes.Buffer
+	writeHeader := func(name, value string) {
+		buf.WriteString(name + ": " + value + "\r\n")
+	}
+	writeHeader("From", message.From)
+	writeHeader("To", strings.Join(message.To, ", "))
+	if len(message.Cc) > 0 {
+		writeHeader("Cc", strings.Join(message.Cc, ", "))
+	}
+	writeHeader("Subject", mime.QEncoding.Encode("utf-8", message.Subject))
+	writeHeader("Date", now.Format(time.RFC1123Z))
+	writeHeader("Message-ID", "<"+messageID+">")
+	writeHeader("MIME-Version", "1.0")
+
+	names := make([]string, 0, len(message.Headers))
+	for name := range message.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writeHeader(textproto.CanonicalMIMEHeaderKey(name), message.Headers[name])
+	}
+
+	if message.Text != "" && message.HTML != "" {
+		mw := multipart.NewWriter(&buf)
+		writeHeader("Content-Type", "multipart/alternative; boundary="+mw.Boundary())
+		buf.WriteString("\r\n")
+		for _, part := range []struct{ contentType, body string }{
+			{"text/plain; charset=utf-8", message.Text},
+			{"text/html; charset=utf-8", message.HTML},
+		} {
+			w, err := mw.CreatePart(textproto.MIMEHeader{
+				"Content-Type":              {part.contentType},
+				"Content-Transfer-Encoding": {"quoted-printable"},
+			})
+			if err != nil {
+				return nil, "", err
+			}
+			if err := writeQuotedPrintable(w, part.body); err != nil {
+				return nil, "", err
+			}
+		}
+		if err := mw.Close(); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), messageID, nil
+	}
+
+	contentType, body := "text/plain; charset=utf-8", message.Text
+	if message.HTML != "" {
+		contentType, body = "text/html; charset=utf-8", message.HTML
+	}
+	writeHeader("Content-Type", contentType)
+	writeHeader("Content-Transfer-Encoding", "quoted-printable")
+	buf.WriteString("\r\n")
+	if err := writeQuotedPrintable(&buf, body); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), messageID, nil
+}
+
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
@@ -0,0 +1,122 @@
+package email
+
+import "github.com/rocketship-ai/rocketship/internal/assertions"
+
+// EmailPlugin represents an email test step
+type EmailPlugin struct {
+	Name   string      `json:"name" yaml:"name"`
+	Plugin string      `json:"plugin" yaml:"plugin"`
+	Config EmailConfig `json:"config" yaml:"config"`
+}
+
+// EmailConfig defines the send and receive phases. Send runs first; either may be omitted.
+type EmailConfig struct {
+	SMTP    *SMTPConfig    `json:"smtp,omitempty" yaml:"smtp,omitempty"` // Required with send
+	Send    *SendConfig    `json:"send,omitempty" yaml:"send,omitempty"`
+	Receive *ReceiveConfig `json:"receive,omitempty" yaml:"receive,omitempty"`
+	Timeout string         `json:"timeout,omitempty" yaml:"timeout,omitempty"` // Overall step timeout (e.g., "1m")
+}
+
+// SMTPConfig defines the server messages are sent through
+type SMTPConfig struct {
+	Host               string `json:"host" yaml:"host"`
+	Port               int    `json:"port,omitempty" yaml:"port,omitempty"`         // Defaults to 465 with security "tls", 587 otherwise
+	Username           string `json:"username,omitempty" yaml:"username,omitempty"` // PLAIN auth when set
+	Password           string `json:"password,omitempty" yaml:"password,omitempty"`
+	Security           string `json:"security,omitempty" yaml:"security,omitempty"` // "starttls", "tls" or "none"; STARTTLS when offered by default
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty" yaml:"insecure_skip_verify,omitempty"`
+}
+
+// SendConfig describes the message to send
+type SendConfig struct {
+	From    string            `json:"from" yaml:"from"`
+	To      []string          `json:"to" yaml:"to"`
+	Cc      []string          `json:"cc,omitempty" yaml:"cc,omitempty"`
+	Bcc     []string          `json:"bcc,omitempty" yaml:"bcc,omitempty"`
+	Subject string            `json:"subject" yaml:"subject"`
+	Text    string            `json:"text,omitempty" yaml:"text,omitempty"`
+	HTML    string            `json:"html,omitempty" yaml:"html,omitempty"` // Sent as multipart/alternative together with text
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+}
+
+// ReceiveConfig polls a mailbox until a matching message arrives. Exactly one of
+// Mailpit, Mailhog or IMAP is required.
+type ReceiveConfig struct {
+	Mailpit  string       `json:"mailpit,omitempty" yaml:"mailpit,omitempty"` // Mailpit base URL, e.g. http://localhost:8025
+	Mailhog  string       `json:"mailhog,omitempty" yaml:"mailhog,omitempty"` // MailHog base URL, e.g. http://localhost:8025
+	IMAP     *IMAPConfig  `json:"imap,omitempty" yaml:"imap,omitempty"`
+	Match    *MatchConfig `json:"match,omitempty" yaml:"match,omitempty"`
+	NewOnly  bool         `json:"new_only,omitempty" yaml:"new_only,omitempty"` // Ignore messages already in the mailbox when the step starts
+	Timeout  string       `json:"timeout,omitempty" yaml:"timeout,omitempty"`   // How long to wait (defaults to the step timeout)
+	Interval string       `json:"interval,omitempty" yaml:"interval,omitempty"` // Poll interval (defaults to 1s)
+}
+
+// IMAPConfig defines a mailbox read over IMAP. Messages are never marked as read.
+type IMAPConfig struct {
+	Host               string `json:"host" yaml:"host"`
+	Port               int    `json:"port,omitempty" yaml:"port,omitempty"` // Defaults to 993 with TLS, 143 without
+	Username           string `json:"username" yaml:"username"`
+	Password           string `json:"password" yaml:"password"`
+	Mailbox            string `json:"mailbox,omitempty" yaml:"mailbox,omitempty"`   // Defaults to INBOX
+	Security           string `json:"security,omitempty" yaml:"security,omitempty"` // "tls" (default) or "none"
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty" yaml:"insecure_skip_verify,omitempty"`
+}
+
+// MatchConfig selects the received message. Every set field must match; comparisons
+// are case-insensitive substring matches.
+type MatchConfig struct {
+	To       string `json:"to,omitempty" yaml:"to,omitempty"` // Any To or Cc address
+	From     string `json:"from,omitempty" yaml:"from,omitempty"`
+	Subject  string `json:"subject,omitempty" yaml:"subject,omitempty"`
+	Contains string `json:"contains,omitempty" yaml:"contains,omitempty"` // Text or HTML body
+}
+
+// Security modes for SMTP and IMAP connections
+const (
+	SecurityStartTLS = "starttls"
+	SecurityTLS      = "tls"
+	SecurityNone     = "none"
+)
+
+// Assertion types supported by the email plugin in addition to the shared ones
+const (
+	AssertionTypeHeader = "header"
+)
+
+// Message is a received email
+type Message struct {
+	ID        string            `json:"id"` // Mailbox-specific ID (Mailpit/MailHog ID or IMAP UID)
+	MessageID string            `json:"message_id,omitempty"`
+	From      string            `json:"from"`
+	To        []string          `json:"to"`
+	Cc        []string          `json:"cc,omitempty"`
+	Subject   string            `json:"subject"`
+	Date      string            `json:"date,omitempty"`
+	Text      string            `json:"text,omitempty"`
+	HTML      string            `json:"html,omitempty"`
+	Headers   map[string]string `json:"headers"`
+	Links     []string          `json:"links"` // http(s) URLs found in the HTML and text bodies, in order
+}
+
+// SentMessage describes a message accepted by the SMTP server
+type SentMessage struct {
+	MessageID  string   `json:"message_id"`
+	Recipients []string `json:"recipients"`
+}
+
+// EmailResponse contains the results of the send and receive phases
+type EmailResponse struct {
+	Sent     *SentMessage `json:"sent,omitempty"`
+	Message  *Message     `json:"message,omitempty"`
+	Duration string       `json:"duration"`
+}
+
+// AssertionResult represents a single assertion result for UI display
+type AssertionResult = assertions.Result
+
+// ActivityResponse represents the complete activity response
+type ActivityResponse struct {
+	Response         *EmailResponse    `json:"response"`
+	Saved            map[string]string `json:"saved"`
+	AssertionResults []AssertionResult `json:"assertion_results,omitempty"`
+}
@@ -0,0 +1,402 @@
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/egress"
+)
+
+// client calls etcd's v3 HTTP/JSON gateway. The gateway encodes keys and values
+// as base64 and 64-bit integers as strings.
+type client struct {
+	endpoint string
+	username string
+	password string
+	token    string
+	http     *http.Client
+}
+
+// newClient connects to the configured endpoint. Connections go through the egress policy.
+func newClient(config *EtcdConfig, policy *egress.Policy) (*client, error) {
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("invalid endpoint %q: must be an http or https URL, e.g. http://etcd:2379", config.Endpoint)
+	}
+
+	return &client{
+		endpoint: strings.TrimRight(endpoint.String(), "/"),
+		username: config.Username,
+		password: config.Password,
+		http:     &http.Client{Transport: policy.Transport()},
+	}, nil
+}
+
+// int64String decodes the gateway's 64-bit integers, which arrive as strings
+type int64String int64
+
+func (n *int64String) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		*n = 0
+		return nil
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return err
+	}
+	*n = int64String(v)
+	return nil
+}
+
+type responseHeader struct {
+	Revision int64String `json:"revision"`
+}
+
+type gatewayKV struct {
+	Key            string      `json:"key"`
+	Value          string      `json:"value"`
+	CreateRevision int64String `json:"create_revision"`
+	ModRevision    int64String `json:"mod_revision"`
+	Version        int64String `json:"version"`
+	Lease          int64String `json:"lease"`
+}
+
+// keyValue decodes a gateway key-value
+func (kv gatewayKV) keyValue() (KeyValue, error) {
+	key, err := base64.StdEncoding.DecodeString(kv.Key)
+	if err != nil {
+		return KeyValue{}, fmt.Errorf("failed to decode key: %w", err)
+	}
+	value, err := base64.StdEncoding.DecodeString(kv.Value)
+	if err != nil {
+		return KeyValue{}, fmt.Errorf("failed to decode value of %s: %w", key, err)
+	}
+	decoded := KeyValue{
+		Key:            string(key),
+		Value:          string(value),
+		CreateRevision: int64(kv.CreateRevision),
+		ModRevision:    int64(kv.ModRevision),
+		Version:        int64(kv.Version),
+	}
+	if kv.Lease != 0 {
+		decoded.Lease = strconv.FormatInt(int64(kv.Lease), 10)
+	}
+	return decoded, nil
+}
+
+func decodeKVs(kvs []gatewayKV) ([]KeyValue, error) {
+	decoded := make([]KeyValue, 0, len(kvs))
+	for _, kv := range kvs {
+		keyValue, err := kv.keyValue()
+		if err != nil {
+			return nil, err
+		}
+		decoded = append(decoded, keyValue)
+	}
+	return decoded, nil
+}
+
+// encode base64-encodes a key or value for the gateway
+func encode(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+// prefixEnd is the range end that selects every key starting with prefix
+func prefixEnd(prefix string) string {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return string(end[:i+1])
+		}
+	}
+	// The prefix is all 0xff or empty: select every key from it onwards
+	return "\x00"
+}
+
+// call posts a request to a gateway path and decodes the response into out
+func (c *client) call(ctx context.Context, path string, request, out interface{}) error {
+	resp, err := c.post(ctx, path, request)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode etcd response: %w", err)
+	}
+	return nil
+}
+
+// post sends a request, authenticating first when a user is configured. The
+// caller closes the response body.
+func (c *client) post(ctx context.Context, path string, request interface{}) (*http.Response, error) {
+	if c.username != "" && c.token == "" {
+		if err := c.authenticate(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("etcd request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer func() { _ = resp.Body.Close() }()
+		return nil, gatewayError(resp)
+	}
+	return resp, nil
+}
+
+// gatewayError reads the gateway's error body
+func gatewayError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var body struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(data, &body) == nil {
+		if body.Message != "" {
+			return fmt.Errorf("etcd error: %s", body.Message)
+		}
+		if body.Error != "" {
+			return fmt.Errorf("etcd error: %s", body.Error)
+		}
+	}
+	message := strings.TrimSpace(string(data))
+	if message == "" {
+		message = resp.Status
+	}
+	return fmt.Errorf("etcd error: %s", message)
+}
+
+// authenticate exchanges the username and password for a token
+func (c *client) authenticate(ctx context.Context) error {
+	payload, _ := json.Marshal(map[string]string{"name": c.username, "password": c.password})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+"/v3/auth/authenticate", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("etcd request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to authenticate: %w", gatewayError(resp))
+	}
+
+	var body struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Token == "" {
+		return fmt.Errorf("failed to authenticate: no token in response")
+	}
+	c.token = body.Token
+	return nil
+}
+
+// get reads a key, or every key in [key, rangeEnd)
+func (c *client) get(ctx context.Context, key, rangeEnd string, limit int) (*EtcdResponse, error) {
+	request := map[string]interface{}{"key": encode(key)}
+	if rangeEnd != "" {
+		request["range_end"] = encode(rangeEnd)
+	}
+	if limit > 0 {
+		request["limit"] = strconv.Itoa(limit)
+	}
+
+	var body struct {
+		Header responseHeader `json:"header"`
+		KVs    []gatewayKV    `json:"kvs"`
+		Count  int64String    `json:"count"`
+	}
+	if err := c.call(ctx, "/v3/kv/range", request, &body); err != nil {
+		return nil, err
+	}
+	kvs, err := decodeKVs(body.KVs)
+	if err != nil {
+		return nil, err
+	}
+	return &EtcdResponse{Revision: int64(body.Header.Revision), KVs: kvs, Count: int64(body.Count)}, nil
+}
+
+// put writes a key, attached to lease when it isn't empty, and returns the
+// previous value if there was one
+func (c *client) put(ctx context.Context, key, value, lease string) (*EtcdResponse, error) {
+	request := map[string]interface{}{"key": encode(key), "value": encode(value), "prev_kv": true}
+	if lease != "" {
+		request["lease"] = lease
+	}
+
+	var body struct {
+		Header responseHeader `json:"header"`
+		PrevKV *gatewayKV     `json:"prev_kv"`
+	}
+	if err := c.call(ctx, "/v3/kv/put", request, &body); err != nil {
+		return nil, err
+	}
+	response := &EtcdResponse{Revision: int64(body.Header.Revision), KVs: []KeyValue{}}
+	if body.PrevKV != nil {
+		prev, err := body.PrevKV.keyValue()
+		if err != nil {
+			return nil, err
+		}
+		response.KVs = append(response.KVs, prev)
+	}
+	return response, nil
+}
+
+// delete removes a key, or every key in [key, rangeEnd), returning what was deleted
+func (c *client) delete(ctx context.Context, key, rangeEnd string) (*EtcdResponse, error) {
+	request := map[string]interface{}{"key": encode(key), "prev_kv": true}
+	if rangeEnd != "" {
+		request["range_end"] = encode(rangeEnd)
+	}
+
+	var body struct {
+		Header  responseHeader `json:"header"`
+		Deleted int64String    `json:"deleted"`
+		PrevKVs []gatewayKV    `json:"prev_kvs"`
+	}
+	if err := c.call(ctx, "/v3/kv/deleterange", request, &body); err != nil {
+		return nil, err
+	}
+	kvs, err := decodeKVs(body.PrevKVs)
+	if err != nil {
+		return nil, err
+	}
+	return &EtcdResponse{Revision: int64(body.Header.Revision), KVs: kvs, Count: int64(body.Deleted)}, nil
+}
+
+// grantLease creates a lease that expires after ttl seconds without keep-alives
+func (c *client) grantLease(ctx context.Context, ttl int64) (*EtcdResponse, error) {
+	var body struct {
+		Header responseHeader `json:"header"`
+		ID     int64String    `json:"ID"`
+		TTL    int64String    `json:"TTL"`
+		Error  string         `json:"error"`
+	}
+	if err := c.call(ctx, "/v3/lease/grant", map[string]interface{}{"TTL": strconv.FormatInt(ttl, 10)}, &body); err != nil {
+		return nil, err
+	}
+	if body.Error != "" {
+		return nil, fmt.Errorf("etcd error: %s", body.Error)
+	}
+	return &EtcdResponse{
+		Revision: int64(body.Header.Revision),
+		KVs:      []KeyValue{},
+		LeaseID:  strconv.FormatInt(int64(body.ID), 10),
+		LeaseTTL: int64(body.TTL),
+	}, nil
+}
+
+// revokeLease revokes a lease, deleting every key attached to it
+func (c *client) revokeLease(ctx context.Context, lease string) (*EtcdResponse, error) {
+	var body struct {
+		Header responseHeader `json:"header"`
+	}
+	if err := c.call(ctx, "/v3/lease/revoke", map[string]interface{}{"ID": lease}, &body); err != nil {
+		return nil, err
+	}
+	return &EtcdResponse{Revision: int64(body.Header.Revision), KVs: []KeyValue{}, LeaseID: lease}, nil
+}
+
+// watch waits up to wait for count events on a key or range. Running out of time
+// isn't an error: the events seen so far are returned for assertions to judge.
+func (c *client) watch(ctx context.Context, key, rangeEnd string, startRevision int64, count int, wait time.Duration) (*EtcdResponse, error) {
+	create := map[string]interface{}{"key": encode(key)}
+	if rangeEnd != "" {
+		create["range_end"] = encode(rangeEnd)
+	}
+	if startRevision > 0 {
+		create["start_revision"] = strconv.FormatInt(startRevision, 10)
+	}
+
+	watchCtx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+
+	resp, err := c.post(watchCtx, "/v3/watch", map[string]interface{}{"create_request": create})
+	if err != nil {
+		if ctx.Err() == nil && errors.Is(watchCtx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("etcd watch did not start within %s", wait)
+		}
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	response := &EtcdResponse{KVs: []KeyValue{}, Events: []Event{}}
+	decoder := json.NewDecoder(resp.Body)
+	for len(response.Events) < count {
+		var message struct {
+			Result struct {
+				Header       responseHeader `json:"header"`
+				Canceled     bool           `json:"canceled"`
+				CancelReason string         `json:"cancel_reason"`
+				Events       []struct {
+					Type string    `json:"type"`
+					KV   gatewayKV `json:"kv"`
+				} `json:"events"`
+			} `json:"result"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := decoder.Decode(&message); err != nil {
+			if ctx.Err() == nil && watchCtx.Err() != nil {
+				break // Waited long enough
+			}
+			return nil, fmt.Errorf("etcd watch failed: %w", err)
+		}
+		if message.Error != nil {
+			return nil, fmt.Errorf("etcd error: %s", message.Error.Message)
+		}
+		result := message.Result
+		if result.Canceled {
+			return nil, fmt.Errorf("etcd watch cancelled: %s", result.CancelReason)
+		}
+		if revision := int64(result.Header.Revision); revision > response.Revision {
+			response.Revision = revision
+		}
+		for _, event := range result.Events {
+			kv, err := event.KV.keyValue()
+			if err != nil {
+				return nil, err
+			}
+			eventType := event.Type
+			if eventType == "" {
+				eventType = "PUT" // The gateway leaves out the zero value
+			}
+			response.Events = append(response.Events, Event{Type: eventType, KV: kv})
+		}
+	}
+
+	response.Count = int64(len(response.Events))
+	return response, nil
+}
@@ -0,0 +1,448 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+	"go.temporal.io/sdk/activity"
+)
+
+const (
+	defaultTimeout     = 30 * time.Second
+	defaultWatchWait   = 10 * time.Second
+	defaultWatchEvents = 1
+)
+
+// Auto-register the plugin when the package is imported
+func init() {
+	plugins.RegisterPlugin(&EtcdPlugin{})
+}
+
+// GetType returns the plugin type identifier
+func (ep *EtcdPlugin) GetType() string {
+	return "etcd"
+}
+
+// Activity runs a key-value, watch or lease action against etcd and checks the result
+func (ep *EtcdPlugin) Activity(ctx context.Context, p map[string]interface{}) (interface{}, error) {
+	logger := activity.GetLogger(ctx)
+
+	// Parse configuration from parameters
+	configData, ok := p["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid config format")
+	}
+
+	config := &EtcdConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		return nil, fmt.Errorf("failed to parse etcd config: %w", err)
+	}
+
+	timeout := defaultTimeout
+	if config.Timeout != "" {
+		parsed, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", config.Timeout, err)
+		}
+		timeout = parsed
+	}
+
+	// Convert state to map[string]interface{} for template processing
+	state := make(map[string]interface{})
+	if stateStr, ok := p["state"].(map[string]string); ok {
+		for k, v := range stateStr {
+			state[k] = v
+		}
+	} else if stateInt, ok := p["state"].(map[string]interface{}); ok {
+		state = stateInt
+	}
+
+	// Extract env secrets from params (for {{ .env.* }} template resolution)
+	env := make(map[string]string)
+	if envData, ok := p["env"].(map[string]interface{}); ok {
+		for k, v := range envData {
+			if strVal, ok := v.(string); ok {
+				env[k] = strVal
+			}
+		}
+	} else if envData, ok := p["env"].(map[string]string); ok {
+		env = envData
+	}
+
+	if err := applyVariableReplacement(config, state, env); err != nil {
+		return nil, fmt.Errorf("variable replacement failed: %w", err)
+	}
+
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	policy, err := egress.FromParams(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
+
+	logger.Info("Executing etcd plugin", "action", config.Action, "key", config.Key)
+
+	c, err := newClient(config, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	response, err := execute(ctx, c, config)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := assertions.Normalize(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare etcd result: %w", err)
+	}
+
+	assertionResults, err := processAssertions(p, response, subject, state, env)
+	if err != nil {
+		return nil, err
+	}
+	if failure := assertions.Summary(assertionResults); failure != "" {
+		return nil, fmt.Errorf("%s", failure)
+	}
+
+	saved := make(map[string]string)
+	if err := saves.Process(p, subject, saved); err != nil {
+		return nil, err
+	}
+
+	logger.Info("etcd step completed", "action", config.Action, "revision", response.Revision, "duration", response.Duration)
+
+	return &ActivityResponse{
+		Response:         response,
+		Saved:            saved,
+		AssertionResults: assertionResults,
+	}, nil
+}
+
+// execute runs the configured action
+func execute(ctx context.Context, c *client, config *EtcdConfig) (*EtcdResponse, error) {
+	start := time.Now()
+
+	rangeEnd := ""
+	if config.Prefix {
+		rangeEnd = prefixEnd(config.Key)
+	}
+
+	var response *EtcdResponse
+	var err error
+	switch config.Action {
+	case ActionGet:
+		response, err = c.get(ctx, config.Key, rangeEnd, config.Limit)
+	case ActionPut:
+		response, err = put(ctx, c, config)
+	case ActionDelete:
+		response, err = c.delete(ctx, config.Key, rangeEnd)
+	case ActionWatch:
+		wait := defaultWatchWait
+		if config.Wait != "" {
+			wait, _ = time.ParseDuration(config.Wait)
+		}
+		events := config.Events
+		if events == 0 {
+			events = defaultWatchEvents
+		}
+		response, err = c.watch(ctx, config.Key, rangeEnd, config.StartRevision, events, wait)
+	case ActionGrantLease:
+		response, err = c.grantLease(ctx, config.LeaseTTL)
+	case ActionRevokeLease:
+		response, err = c.revokeLease(ctx, config.LeaseID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response.Action = config.Action
+	if response.Events == nil {
+		response.Events = []Event{}
+	}
+	response.Duration = time.Since(start).String()
+	return response, nil
+}
+
+// put writes the key. With lease_ttl it grants a lease first and attaches the
+// key to it, so the key disappears once the lease expires.
+func put(ctx context.Context, c *client, config *EtcdConfig) (*EtcdResponse, error) {
+	lease := config.LeaseID
+	var ttl int64
+	if config.LeaseTTL > 0 {
+		granted, err := c.grantLease(ctx, config.LeaseTTL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to grant lease: %w", err)
+		}
+		lease, ttl = granted.LeaseID, granted.LeaseTTL
+	}
+
+	response, err := c.put(ctx, config.Key, config.Value, lease)
+	if err != nil {
+		return nil, err
+	}
+	response.LeaseID = lease
+	response.LeaseTTL = ttl
+	return response, nil
+}
+
+// processAssertions evaluates all assertions and returns their results
+func processAssertions(p map[string]interface{}, response *EtcdResponse, subject interface{}, state map[string]interface{}, env map[string]string) ([]AssertionResult, error) {
+	context := dsl.TemplateContext{Runtime: state, Env: env}
+	return assertions.Process(p, subject, context, func(assertionMap map[string]interface{}, result *AssertionResult) bool {
+		return evaluateAssertion(response, context, assertionMap, result)
+	})
+}
+
+// evaluateAssertion evaluates the etcd plugin's own assertion types
+func evaluateAssertion(response *EtcdResponse, context dsl.TemplateContext, assertionMap map[string]interface{}, result *AssertionResult) bool {
+	expected := result.Expected
+	switch result.Type {
+	case AssertionTypeKeyCount:
+		result.Actual = response.Count
+		if !assertions.Equal(float64(response.Count), expected) {
+			result.Message = fmt.Sprintf("expected %v keys, got %d", expected, response.Count)
+		} else {
+			result.Passed = true
+		}
+
+	case AssertionTypeEventCount:
+		result.Actual = len(response.Events)
+		if !assertions.Equal(float64(len(response.Events)), expected) {
+			result.Message = fmt.Sprintf("expected %v events, got %d", expected, len(response.Events))
+		} else {
+			result.Passed = true
+		}
+
+	case AssertionTypeValue:
+		key, _ := assertionMap["key"].(string)
+		processed, err := dsl.ProcessTemplate(key, context)
+		if err != nil {
+			result.Message = fmt.Sprintf("failed to render key: %v", err)
+			break
+		}
+		key = processed
+		result.Name = key
+		kv, found := findKey(response, key)
+		if !found {
+			if key == "" {
+				result.Message = "no keys in the result"
+			} else {
+				result.Message = fmt.Sprintf("key %s not in the result", key)
+			}
+			break
+		}
+		result.Actual = kv.Value
+		if !assertions.Equal(kv.Value, expected) {
+			result.Message = fmt.Sprintf("expected %s to be %v, got %q", kv.Key, expected, kv.Value)
+		} else {
+			result.Passed = true
+		}
+
+	default:
+		return false
+	}
+	return true
+}
+
+// findKey returns the named key from the result, or the first one when key is
+// empty. For watch, the latest event's key-value counts.
+func findKey(response *EtcdResponse, key string) (KeyValue, bool) {
+	kvs := response.KVs
+	if response.Action == ActionWatch {
+		kvs = make([]KeyValue, 0, len(response.Events))
+		for i := len(response.Events) - 1; i >= 0; i-- {
+			kvs = append(kvs, response.Events[i].KV)
+		}
+	}
+	for _, kv := range kvs {
+		if key == "" || kv.Key == key {
+			return kv, true
+		}
+	}
+	return KeyValue{}, false
+}
+
+// validateConfig checks the action has what it needs once templates are rendered
+func validateConfig(config *EtcdConfig) error {
+	if config.Endpoint == "" {
+		return fmt.Errorf("endpoint is required")
+	}
+	if config.LeaseID != "" {
+		if _, err := strconv.ParseInt(config.LeaseID, 10, 64); err != nil {
+			return fmt.Errorf("lease_id must be a decimal lease ID, got %q", config.LeaseID)
+		}
+	}
+
+	switch config.Action {
+	case ActionGet, ActionDelete, ActionWatch:
+		if config.Key == "" && !config.Prefix {
+			return fmt.Errorf("key is required with action %s (use prefix: true with an empty key for every key)", config.Action)
+		}
+		if config.Limit < 0 {
+			return fmt.Errorf("limit must not be negative")
+		}
+		if config.Action == ActionWatch {
+			if config.Events < 0 {
+				return fmt.Errorf("events must not be negative")
+			}
+			if config.Wait != "" {
+				if wait, err := time.ParseDuration(config.Wait); err != nil || wait <= 0 {
+					return fmt.Errorf("invalid wait %q: must be a positive duration", config.Wait)
+				}
+			}
+		}
+	case ActionPut:
+		if config.Key == "" {
+			return fmt.Errorf("key is required with action put")
+		}
+		if config.Prefix {
+			return fmt.Errorf("prefix can't be used with action put")
+		}
+		if config.LeaseID != "" && config.LeaseTTL > 0 {
+			return fmt.Errorf("use either lease_id or lease_ttl, not both")
+		}
+	case ActionGrantLease:
+		if config.LeaseTTL <= 0 {
+			return fmt.Errorf("lease_ttl is required with action grant_lease")
+		}
+	case ActionRevokeLease:
+		if config.LeaseID == "" {
+			return fmt.Errorf("lease_id is required with action revoke_lease")
+		}
+	case "":
+		return fmt.Errorf("action is required")
+	default:
+		return fmt.Errorf("action must be get, put, delete, watch, grant_lease or revoke_lease, got %q", config.Action)
+	}
+
+	return nil
+}
+
+// applyVariableReplacement processes templates in the connection settings, keys,
+// values and lease ID
+func applyVariableReplacement(config *EtcdConfig, state map[string]interface{}, env map[string]string) error {
+	context := dsl.TemplateContext{
+		Runtime: state,
+		Env:     env,
+	}
+
+	fields := map[string]*string{
+		"endpoint": &config.Endpoint,
+		"username": &config.Username,
+		"password": &config.Password,
+		"key":      &config.Key,
+		"value":    &config.Value,
+		"lease_id": &config.LeaseID,
+	}
+	for name, value := range fields {
+		if *value == "" {
+			continue
+		}
+		processed, err := dsl.ProcessTemplate(*value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process %s template: %w", name, err)
+		}
+		*value = processed
+	}
+
+	return nil
+}
+
+// parseConfig converts map[string]interface{} to EtcdConfig
+func parseConfig(configData map[string]interface{}, config *EtcdConfig) error {
+	stringFields := map[string]*string{
+		"action":   &config.Action,
+		"endpoint": &config.Endpoint,
+		"username": &config.Username,
+		"password": &config.Password,
+		"key":      &config.Key,
+		"wait":     &config.Wait,
+		"timeout":  &config.Timeout,
+	}
+	for key, target := range stringFields {
+		if v, ok := configData[key].(string); ok {
+			*target = v
+		}
+	}
+
+	if v, ok := configData["prefix"].(bool); ok {
+		config.Prefix = v
+	}
+
+	// Values and lease IDs are strings to etcd; accept numbers in YAML too.
+	// Lease IDs that don't fit a float64 must be quoted or templated.
+	var err error
+	if config.Value, err = scalarField(configData, "value"); err != nil {
+		return err
+	}
+	if config.LeaseID, err = scalarField(configData, "lease_id"); err != nil {
+		return err
+	}
+
+	intFields := map[string]*int64{
+		"lease_ttl":      &config.LeaseTTL,
+		"start_revision": &config.StartRevision,
+	}
+	for key, target := range intFields {
+		v, err := intField(configData, key)
+		if err != nil {
+			return err
+		}
+		*target = v
+	}
+	limit, err := intField(configData, "limit")
+	if err != nil {
+		return err
+	}
+	config.Limit = int(limit)
+	events, err := intField(configData, "events")
+	if err != nil {
+		return err
+	}
+	config.Events = int(events)
+
+	return nil
+}
+
+func scalarField(configData map[string]interface{}, key string) (string, error) {
+	switch v := configData[key].(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		return "", fmt.Errorf("%s must be a string, got %T", key, v)
+	}
+}
+
+func intField(configData map[string]interface{}, key string) (int64, error) {
+	switch v := configData[key].(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		return int64(v), nil
+	case int:
+		return int64(v), nil
+	default:
+		return 0, fmt.Errorf("%s must be a number, got %T", key, v)
+	}
+}
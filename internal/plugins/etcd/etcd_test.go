@@ -0,0 +1,429 @@
+package etcd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/plugins/plugintest"
+)
+
+// fakeGateway is an in-memory etcd v3 JSON gateway. It requires a token from
+// root/secret and replays every put since start_revision to watchers.
+type fakeGateway struct {
+	revision int64
+	kvs      map[string]map[string]interface{}
+	history  []map[string]interface{}
+	leases   map[string]bool
+	requests map[string]map[string]interface{}
+}
+
+func newFakeGateway() *fakeGateway {
+	return &fakeGateway{
+		revision: 1,
+		kvs:      make(map[string]map[string]interface{}),
+		leases:   make(map[string]bool),
+		requests: make(map[string]map[string]interface{}),
+	}
+}
+
+func decodeString(s interface{}) string {
+	str, _ := s.(string)
+	b, _ := base64.StdEncoding.DecodeString(str)
+	return string(b)
+}
+
+// inRange reports whether key is in [start, end), or equals start without an end
+func inRange(key, start, end string) bool {
+	if end == "" {
+		return key == start
+	}
+	return key >= start && (end == "\x00" || key < end)
+}
+
+func (f *fakeGateway) header() map[string]interface{} {
+	return map[string]interface{}{"revision": strconv.FormatInt(f.revision, 10)}
+}
+
+func (f *fakeGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request map[string]interface{}
+	_ = json.NewDecoder(r.Body).Decode(&request)
+	f.requests[r.URL.Path] = request
+
+	if r.URL.Path == "/v3/auth/authenticate" {
+		if request["name"] != "root" || request["password"] != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"etcdserver: authentication failed, invalid user ID or password","code":3,"message":"etcdserver: authentication failed, invalid user ID or password"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"header": f.header(), "token": "tok123"})
+		return
+	}
+	if r.Header.Get("Authorization") != "tok123" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":"etcdserver: user name is empty","code":3,"message":"etcdserver: user name is empty"}`))
+		return
+	}
+
+	start, end := decodeString(request["key"]), decodeString(request["range_end"])
+	matching := func() []map[string]interface{} {
+		var keys []string
+		for key := range f.kvs {
+			if inRange(key, start, end) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		kvs := make([]map[string]interface{}, 0, len(keys))
+		for _, key := range keys {
+			kvs = append(kvs, f.kvs[key])
+		}
+		return kvs
+	}
+
+	switch r.URL.Path {
+	case "/v3/kv/range":
+		kvs := matching()
+		count := len(kvs)
+		if limit, _ := strconv.Atoi(fmt.Sprint(request["limit"])); limit > 0 && limit < len(kvs) {
+			kvs = kvs[:limit]
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"header": f.header(), "kvs": kvs, "count": strconv.Itoa(count)})
+
+	case "/v3/kv/put":
+		key := decodeString(request["key"])
+		if lease, ok := request["lease"].(string); ok && !f.leases[lease] {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"etcdserver: requested lease not found","code":5,"message":"etcdserver: requested lease not found"}`))
+			return
+		}
+		f.revision++
+		prev := f.kvs[key]
+		kv := map[string]interface{}{
+			"key":             request["key"],
+			"value":           request["value"],
+			"create_revision": strconv.FormatInt(f.revision, 10),
+			"mod_revision":    strconv.FormatInt(f.revision, 10),
+			"version":         "1",
+		}
+		if prev != nil {
+			kv["create_revision"] = prev["create_revision"]
+			version, _ := strconv.Atoi(prev["version"].(string))
+			kv["version"] = strconv.Itoa(version + 1)
+		}
+		if lease, ok := request["lease"].(string); ok {
+			kv["lease"] = lease
+		}
+		f.kvs[key] = kv
+		f.history = append(f.history, kv)
+		body := map[string]interface{}{"header": f.header()}
+		if prev != nil {
+			body["prev_kv"] = prev
+		}
+		_ = json.NewEncoder(w).Encode(body)
+
+	case "/v3/kv/deleterange":
+		kvs := matching()
+		f.revision++
+		for _, kv := range kvs {
+			delete(f.kvs, decodeString(kv["key"]))
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"header": f.header(), "deleted": strconv.Itoa(len(kvs)), "prev_kvs": kvs})
+
+	case "/v3/lease/grant":
+		id := "7587869187612345678" // More than a float64 holds exactly
+		f.leases[id] = true
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"header": f.header(), "ID": id, "TTL": request["TTL"]})
+
+	case "/v3/lease/revoke":
+		id := fmt.Sprint(request["ID"])
+		if !f.leases[id] {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"etcdserver: requested lease not found","code":5,"message":"etcdserver: requested lease not found"}`))
+			return
+		}
+		for key, kv := range f.kvs {
+			if kv["lease"] == id {
+				delete(f.kvs, key)
+			}
+		}
+		delete(f.leases, id)
+		f.revision++
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"header": f.header()})
+
+	case "/v3/watch":
+		create, _ := request["create_request"].(map[string]interface{})
+		start, end = decodeString(create["key"]), decodeString(create["range_end"])
+		from, _ := strconv.ParseInt(fmt.Sprint(create["start_revision"]), 10, 64)
+		flusher := w.(http.Flusher)
+		encoder := json.NewEncoder(w)
+		_ = encoder.Encode(map[string]interface{}{"result": map[string]interface{}{"header": f.header(), "created": true}})
+		flusher.Flush()
+		for _, kv := range f.history {
+			modRevision, _ := strconv.ParseInt(kv["mod_revision"].(string), 10, 64)
+			if from > 0 && modRevision >= from && inRange(decodeString(kv["key"]), start, end) {
+				// PUT is the zero value, so the gateway leaves the type out
+				_ = encoder.Encode(map[string]interface{}{"result": map[string]interface{}{"header": f.header(), "events": []interface{}{map[string]interface{}{"kv": kv}}}})
+				flusher.Flush()
+			}
+		}
+		<-r.Context().Done()
+	}
+}
+
+// runStep runs the step through the plugin's Activity
+func runStep(t *testing.T, server *httptest.Server, configData map[string]interface{}, assertionList []interface{}, saveList ...interface{}) (*ActivityResponse, error) {
+	t.Helper()
+	configData["endpoint"] = server.URL
+	if _, ok := configData["username"]; !ok {
+		configData["username"] = "root"
+		configData["password"] = "{{ .env.ETCD_PASSWORD }}"
+	}
+
+	resp := &ActivityResponse{}
+	err := plugintest.Run(t, &EtcdPlugin{}, map[string]interface{}{
+		"config":     configData,
+		"state":      map[string]interface{}{"service": "orders"},
+		"env":        map[string]interface{}{"ETCD_PASSWORD": "secret"},
+		"assertions": assertionList,
+		"save":       saveList,
+	}, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func TestPutGetDelete(t *testing.T) {
+	fake := newFakeGateway()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	for _, put := range []map[string]interface{}{
+		{"key": "/services/{{ service }}/leader", "value": "node-1"},
+		{"key": "/services/{{ service }}/leader", "value": "node-2"},
+		{"key": "/services/{{ service }}/replicas", "value": float64(3)},
+		{"key": "/services/payments/leader", "value": "node-9"},
+	} {
+		put["action"] = "put"
+		if _, err := runStep(t, server, put, nil); err != nil {
+			t.Fatalf("put failed: %v", err)
+		}
+	}
+	if fake.requests["/v3/kv/put"]["value"] != base64.StdEncoding.EncodeToString([]byte("node-9")) {
+		t.Errorf("expected base64 values, got %v", fake.requests["/v3/kv/put"])
+	}
+
+	resp, err := runStep(t, server, map[string]interface{}{
+		"action": "get",
+		"key":    "/services/orders/",
+		"prefix": true,
+	}, []interface{}{
+		map[string]interface{}{"type": "key_count", "expected": float64(2)},
+		map[string]interface{}{"type": "value", "key": "/services/{{ service }}/leader", "expected": "node-2"},
+		map[string]interface{}{"type": "value", "key": "/services/orders/replicas", "expected": float64(3)},
+		map[string]interface{}{"type": "equals", "path": ".kvs[0].version", "expected": float64(2)},
+	}, map[string]interface{}{"json_path": ".revision", "as": "revision"})
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	if resp.Saved["revision"] != "5" {
+		t.Errorf("expected revision 5 saved, got %v", resp.Saved)
+	}
+	if got := decodeString(fake.requests["/v3/kv/range"]["range_end"]); got != "/services/orders0" {
+		t.Errorf("expected the prefix range end, got %q", got)
+	}
+
+	resp, err = runStep(t, server, map[string]interface{}{
+		"action": "get",
+		"key":    "/services/",
+		"prefix": true,
+		"limit":  float64(1),
+	}, []interface{}{
+		map[string]interface{}{"type": "key_count", "expected": float64(3)},
+	})
+	if err != nil {
+		t.Fatalf("limited get failed: %v", err)
+	}
+	if len(resp.Response.KVs) != 1 {
+		t.Errorf("expected the limit to cap returned keys, got %d", len(resp.Response.KVs))
+	}
+
+	resp, err = runStep(t, server, map[string]interface{}{
+		"action": "delete",
+		"key":    "/services/orders/",
+		"prefix": true,
+	}, []interface{}{
+		map[string]interface{}{"type": "key_count", "expected": float64(2)},
+	})
+	if err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if resp.Response.KVs[0].Value != "node-2" {
+		t.Errorf("expected deleted values back, got %+v", resp.Response.KVs)
+	}
+
+	_, err = runStep(t, server, map[string]interface{}{
+		"action": "get",
+		"key":    "/services/orders/leader",
+	}, []interface{}{
+		map[string]interface{}{"type": "value", "expected": "node-2"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "no keys in the result") {
+		t.Fatalf("expected the deleted key to be missing, got %v", err)
+	}
+}
+
+func TestLeases(t *testing.T) {
+	fake := newFakeGateway()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	resp, err := runStep(t, server, map[string]interface{}{
+		"action":    "put",
+		"key":       "/locks/orders",
+		"value":     "worker-1",
+		"lease_ttl": float64(15),
+	}, nil, map[string]interface{}{"json_path": ".lease_id", "as": "lease"})
+	if err != nil {
+		t.Fatalf("put with lease failed: %v", err)
+	}
+	if resp.Saved["lease"] != "7587869187612345678" || resp.Response.LeaseTTL != 15 {
+		t.Fatalf("expected the granted lease to survive intact, got %q (ttl %d)", resp.Saved["lease"], resp.Response.LeaseTTL)
+	}
+	if fake.requests["/v3/kv/put"]["lease"] != "7587869187612345678" {
+		t.Errorf("expected the key to be attached to the lease, got %v", fake.requests["/v3/kv/put"])
+	}
+
+	if _, err := runStep(t, server, map[string]interface{}{
+		"action": "get",
+		"key":    "/locks/orders",
+	}, []interface{}{
+		map[string]interface{}{"type": "equals", "path": ".kvs[0].lease", "expected": resp.Saved["lease"]},
+	}); err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+
+	if _, err := runStep(t, server, map[string]interface{}{
+		"action":   "revoke_lease",
+		"lease_id": resp.Saved["lease"],
+	}, nil); err != nil {
+		t.Fatalf("revoke failed: %v", err)
+	}
+	if _, err := runStep(t, server, map[string]interface{}{
+		"action": "get",
+		"key":    "/locks/orders",
+	}, []interface{}{
+		map[string]interface{}{"type": "key_count", "expected": float64(0)},
+	}); err != nil {
+		t.Fatalf("expected the leased key to be gone: %v", err)
+	}
+
+	_, err = runStep(t, server, map[string]interface{}{
+		"action":   "revoke_lease",
+		"lease_id": resp.Saved["lease"],
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "requested lease not found") {
+		t.Fatalf("expected the gateway error, got %v", err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	fake := newFakeGateway()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	for _, value := range []string{"pending", "paid"} {
+		if _, err := runStep(t, server, map[string]interface{}{"action": "put", "key": "/orders/42/status", "value": value}, nil); err != nil {
+			t.Fatalf("put failed: %v", err)
+		}
+	}
+
+	resp, err := runStep(t, server, map[string]interface{}{
+		"action":         "watch",
+		"key":            "/orders/",
+		"prefix":         true,
+		"start_revision": float64(2),
+		"events":         float64(2),
+	}, []interface{}{
+		map[string]interface{}{"type": "event_count", "expected": float64(2)},
+		map[string]interface{}{"type": "value", "key": "/orders/42/status", "expected": "paid"},
+		map[string]interface{}{"type": "equals", "path": ".events[0].type", "expected": "PUT"},
+	})
+	if err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+	if resp.Response.Events[0].KV.Value != "pending" {
+		t.Errorf("expected events in order, got %+v", resp.Response.Events)
+	}
+
+	// Fewer events than asked for within wait is left to the assertions
+	start := time.Now()
+	_, err = runStep(t, server, map[string]interface{}{
+		"action":         "watch",
+		"key":            "/orders/42/status",
+		"start_revision": float64(2),
+		"events":         float64(3),
+		"wait":           "200ms",
+	}, []interface{}{
+		map[string]interface{}{"type": "event_count", "expected": float64(3)},
+	})
+	if err == nil || !strings.Contains(err.Error(), "expected 3 events, got 2") {
+		t.Fatalf("expected an event count failure, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("expected the watch to stop after wait")
+	}
+}
+
+func TestAuthenticationFailure(t *testing.T) {
+	server := httptest.NewServer(newFakeGateway())
+	defer server.Close()
+
+	_, err := runStep(t, server, map[string]interface{}{
+		"action":   "get",
+		"key":      "/a",
+		"username": "root",
+		"password": "wrong",
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "failed to authenticate: etcd error: etcdserver: authentication failed") {
+		t.Fatalf("expected an authentication error, got %v", err)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  EtcdConfig
+		wantErr string
+	}{
+		{"missing endpoint", EtcdConfig{Action: "get", Key: "/a"}, "endpoint is required"},
+		{"missing action", EtcdConfig{Endpoint: "http://etcd:2379"}, "action is required"},
+		{"unknown action", EtcdConfig{Endpoint: "http://etcd:2379", Action: "compact"}, "action must be"},
+		{"missing key", EtcdConfig{Endpoint: "http://etcd:2379", Action: "get"}, "key is required"},
+		{"lease id and ttl", EtcdConfig{Endpoint: "http://etcd:2379", Action: "put", Key: "/a", LeaseID: "1", LeaseTTL: 5}, "either lease_id or lease_ttl"},
+		{"grant without ttl", EtcdConfig{Endpoint: "http://etcd:2379", Action: "grant_lease"}, "lease_ttl is required"},
+		{"revoke without id", EtcdConfig{Endpoint: "http://etcd:2379", Action: "revoke_lease"}, "lease_id is required"},
+		{"bad lease id", EtcdConfig{Endpoint: "http://etcd:2379", Action: "revoke_lease", LeaseID: "7.5e18"}, "decimal lease ID"},
+		{"bad wait", EtcdConfig{Endpoint: "http://etcd:2379", Action: "watch", Key: "/a", Wait: "soon"}, "invalid wait"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateConfig(&tt.config)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if end := prefixEnd("a\xff"); end != "b" {
+		t.Errorf("expected prefixEnd to carry past 0xff, got %q", end)
+	}
+}
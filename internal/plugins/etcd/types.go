@@ -0,0 +1,93 @@
+package etcd
+
+import "github.com/rocketship-ai/rocketship/internal/assertions"
+
+// EtcdPlugin represents an etcd test step
+type EtcdPlugin struct {
+	Name   string     `json:"name" yaml:"name"`
+	Plugin string     `json:"plugin" yaml:"plugin"`
+	Config EtcdConfig `json:"config" yaml:"config"`
+}
+
+// EtcdConfig selects the cluster, the action and its arguments
+type EtcdConfig struct {
+	Action string `json:"action" yaml:"action"` // get, put, delete, watch, grant_lease or revoke_lease
+
+	// Connection, over the v3 HTTP/JSON gateway
+	Endpoint string `json:"endpoint" yaml:"endpoint"` // e.g. http://etcd:2379
+	Username string `json:"username,omitempty" yaml:"username,omitempty"`
+	Password string `json:"password,omitempty" yaml:"password,omitempty"`
+
+	// Keys
+	Key    string `json:"key,omitempty" yaml:"key,omitempty"`
+	Prefix bool   `json:"prefix,omitempty" yaml:"prefix,omitempty"` // Treat key as a prefix
+	Limit  int    `json:"limit,omitempty" yaml:"limit,omitempty"`   // get: most keys returned
+	Value  string `json:"value,omitempty" yaml:"value,omitempty"`   // put
+
+	// Leases
+	LeaseID  string `json:"lease_id,omitempty" yaml:"lease_id,omitempty"`   // put: attach to this lease; revoke_lease: lease to revoke
+	LeaseTTL int64  `json:"lease_ttl,omitempty" yaml:"lease_ttl,omitempty"` // put: grant a lease of this many seconds; grant_lease: its TTL
+
+	// watch
+	StartRevision int64  `json:"start_revision,omitempty" yaml:"start_revision,omitempty"` // Replay events from this revision
+	Events        int    `json:"events,omitempty" yaml:"events,omitempty"`                 // Events to wait for (defaults to 1)
+	Wait          string `json:"wait,omitempty" yaml:"wait,omitempty"`                     // How long to wait for them (defaults to 10s)
+
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"` // Overall step timeout (defaults to 30s)
+}
+
+// Actions supported by the etcd plugin
+const (
+	ActionGet         = "get"
+	ActionPut         = "put"
+	ActionDelete      = "delete"
+	ActionWatch       = "watch"
+	ActionGrantLease  = "grant_lease"
+	ActionRevokeLease = "revoke_lease"
+)
+
+// Assertion types supported by the etcd plugin in addition to the shared ones
+const (
+	AssertionTypeKeyCount   = "key_count"
+	AssertionTypeValue      = "value"
+	AssertionTypeEventCount = "event_count"
+)
+
+// KeyValue is a key as etcd stores it. Lease IDs are strings because they don't
+// fit in a JSON number.
+type KeyValue struct {
+	Key            string `json:"key"`
+	Value          string `json:"value"`
+	CreateRevision int64  `json:"create_revision"`
+	ModRevision    int64  `json:"mod_revision"`
+	Version        int64  `json:"version"`
+	Lease          string `json:"lease,omitempty"`
+}
+
+// Event is a change seen by watch
+type Event struct {
+	Type string   `json:"type"` // PUT or DELETE
+	KV   KeyValue `json:"kv"`
+}
+
+// EtcdResponse contains what the action read or changed
+type EtcdResponse struct {
+	Action   string     `json:"action"`
+	Revision int64      `json:"revision"` // Cluster revision after the action
+	KVs      []KeyValue `json:"kvs"`      // get: keys read; put and delete: previous values
+	Count    int64      `json:"count"`    // get: keys matching; delete: keys deleted
+	LeaseID  string     `json:"lease_id,omitempty"`
+	LeaseTTL int64      `json:"lease_ttl,omitempty"`
+	Events   []Event    `json:"events"`
+	Duration string     `json:"duration"`
+}
+
+// AssertionResult represents a single assertion result for UI display
+type AssertionResult = assertions.Result
+
+// ActivityResponse represents the complete activity response
+type ActivityResponse struct {
+	Response         *EtcdResponse     `json:"response"`
+	Saved            map[string]string `json:"saved"`
+	AssertionResults []AssertionResult `json:"assertion_results,omitempty"`
+}
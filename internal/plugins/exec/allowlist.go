@@ -0,0 +1,75 @@
+package exec
+
+import (
+	"fmt"
+	"os"
+	osexec "os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// AllowEnv lists the commands exec steps may run on this worker, separated by
+// commas or whitespace. Bare names (kubectl) allow that command from the worker's
+// PATH; entries with a slash are paths or globs (/opt/tools/*) matched against the
+// resolved program. * allows every command. exec steps fail while it is unset.
+const AllowEnv = "ROCKETSHIP_EXEC_ALLOW"
+
+// allowlist is the parsed value of AllowEnv
+type allowlist struct {
+	all   bool
+	names map[string]bool
+	paths []string
+}
+
+func loadAllowlist() allowlist {
+	list := allowlist{names: make(map[string]bool)}
+	fields := strings.FieldsFunc(os.Getenv(AllowEnv), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\t'
+	})
+	for _, entry := range fields {
+		switch {
+		case entry == "*":
+			list.all = true
+		case strings.Contains(entry, "/"):
+			list.paths = append(list.paths, filepath.Clean(entry))
+		default:
+			list.names[entry] = true
+		}
+	}
+	return list
+}
+
+func (l allowlist) empty() bool {
+	return !l.all && len(l.names) == 0 && len(l.paths) == 0
+}
+
+// resolve finds the program for command and checks it against the allowlist
+func (l allowlist) resolve(command string) (string, error) {
+	if l.empty() {
+		return "", fmt.Errorf("exec steps are disabled on this worker; set %s to the commands they may run", AllowEnv)
+	}
+
+	bare := !strings.Contains(command, "/")
+	program := command
+	if bare {
+		found, err := osexec.LookPath(command)
+		if err != nil {
+			return "", fmt.Errorf("command %q not found in the worker's PATH", command)
+		}
+		program = found
+	}
+	program, err := filepath.Abs(program)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve command %q: %w", command, err)
+	}
+
+	if l.all || (bare && l.names[command]) {
+		return program, nil
+	}
+	for _, pattern := range l.paths {
+		if matched, _ := filepath.Match(pattern, program); matched {
+			return program, nil
+		}
+	}
+	return "", fmt.Errorf("command %q is not allowed on this worker (see %s)", command, AllowEnv)
+}
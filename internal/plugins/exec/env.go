@@ -0,0 +1,95 @@
+package exec
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// InheritEnv lists the worker's environment variables passed on to commands,
+// besides the minimal ones, separated by commas or whitespace. Entries ending
+// in * match a prefix (AWS_*). Tests can't widen it, so the worker's own
+// credentials only reach commands the operator chose to give them to.
+const InheritEnv = "ROCKETSHIP_EXEC_INHERIT_ENV"
+
+// minimalEnv is the part of the worker's environment every command gets
+var minimalEnv = []string{"PATH", "HOME", "USER", "LANG", "LC_ALL", "TMPDIR", "TZ", "SYSTEMROOT"}
+
+// deniedEnv are variables a step's env can't set, since they change which
+// program runs or load code into it: the search path, the dynamic loader's
+// variables, and files shells source on start
+var (
+	deniedEnv       = []string{"PATH", "BASH_ENV", "ENV", "GCONV_PATH", "IFS"}
+	deniedEnvPrefix = []string{"LD_", "DYLD_"}
+)
+
+// checkEnv rejects a step env that sets a denied variable
+func checkEnv(env map[string]string) error {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		upper := strings.ToUpper(name)
+		denied := false
+		for _, deniedName := range deniedEnv {
+			denied = denied || upper == deniedName
+		}
+		for _, prefix := range deniedEnvPrefix {
+			denied = denied || strings.HasPrefix(upper, prefix)
+		}
+		if denied {
+			return fmt.Errorf("env can't set %s: it changes which program runs or what it loads", name)
+		}
+	}
+	return nil
+}
+
+// inheritedEnv returns the worker's variables listed in InheritEnv
+func inheritedEnv() []string {
+	patterns := strings.FieldsFunc(os.Getenv(InheritEnv), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\t'
+	})
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	var inherited []string
+	for _, variable := range os.Environ() {
+		name, _, _ := strings.Cut(variable, "=")
+		for _, pattern := range patterns {
+			prefix, isPrefix := strings.CutSuffix(pattern, "*")
+			if name == pattern || (isPrefix && strings.HasPrefix(name, prefix)) {
+				inherited = append(inherited, variable)
+				break
+			}
+		}
+	}
+	sort.Strings(inherited)
+	return inherited
+}
+
+// buildEnvironment returns the command's environment: the minimal part of the
+// worker's, what the worker allows commands to inherit, and the step's env on
+// top
+func buildEnvironment(config *ExecConfig) []string {
+	var base []string
+	for _, name := range minimalEnv {
+		if value, ok := os.LookupEnv(name); ok {
+			base = append(base, name+"="+value)
+		}
+	}
+	base = append(base, inheritedEnv()...)
+
+	names := make([]string, 0, len(config.Env))
+	for name := range config.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		base = append(base, name+"="+config.Env[name])
+	}
+	return base
+}
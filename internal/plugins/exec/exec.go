@@ -0,0 +1,358 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	osexec "os/exec"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+	"go.temporal.io/sdk/activity"
+)
+
+const (
+	defaultTimeout = 30 * time.Second
+	// Output beyond this many bytes per stream is dropped
+	maxOutputBytes = 1 << 20
+	// How long to wait for output after the command exits or is killed, in case a
+	// child process keeps the pipes open
+	waitDelay = 2 * time.Second
+)
+
+// Auto-register the plugin when the package is imported
+func init() {
+	plugins.RegisterPlugin(&ExecPlugin{})
+}
+
+// GetType returns the plugin type identifier
+func (ep *ExecPlugin) GetType() string {
+	return "exec"
+}
+
+// Activity runs the command on the worker and checks its output and exit status
+func (ep *ExecPlugin) Activity(ctx context.Context, p map[string]interface{}) (interface{}, error) {
+	logger := activity.GetLogger(ctx)
+
+	// Parse configuration from parameters
+	configData, ok := p["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid config format")
+	}
+
+	config := &ExecConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		return nil, fmt.Errorf("failed to parse exec config: %w", err)
+	}
+
+	timeout := defaultTimeout
+	if config.Timeout != "" {
+		parsed, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", config.Timeout, err)
+		}
+		timeout = parsed
+	}
+
+	// Convert state to map[string]interface{} for template processing
+	state := make(map[string]interface{})
+	if stateStr, ok := p["state"].(map[string]string); ok {
+		for k, v := range stateStr {
+			state[k] = v
+		}
+	} else if stateInt, ok := p["state"].(map[string]interface{}); ok {
+		state = stateInt
+	}
+
+	// Extract env secrets from params (for {{ .env.* }} template resolution)
+	env := make(map[string]string)
+	if envData, ok := p["env"].(map[string]interface{}); ok {
+		for k, v := range envData {
+			if strVal, ok := v.(string); ok {
+				env[k] = strVal
+			}
+		}
+	} else if envData, ok := p["env"].(map[string]string); ok {
+		env = envData
+	}
+
+	if err := applyVariableReplacement(config, state, env); err != nil {
+		return nil, fmt.Errorf("variable replacement failed: %w", err)
+	}
+
+	if config.Command == "" {
+		return nil, fmt.Errorf("command is required")
+	}
+	if err := checkEnv(config.Env); err != nil {
+		return nil, err
+	}
+	program, err := loadAllowlist().resolve(config.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Executing exec plugin", "command", program, "args", len(config.Args))
+
+	response, err := runCommand(ctx, program, config, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	// A failing command fails the step unless the test asserts on the exit code itself
+	if response.ExitCode != 0 && !hasExitCodeAssertion(p) {
+		return nil, fmt.Errorf("command exited with status %d: %s", response.ExitCode, lastLine(response.Stderr))
+	}
+
+	subject := resultSubject(response)
+
+	assertionResults, err := processAssertions(p, response, subject, state, env)
+	if err != nil {
+		return nil, err
+	}
+	if failure := assertions.Summary(assertionResults); failure != "" {
+		return nil, fmt.Errorf("%s", failure)
+	}
+
+	saved := make(map[string]string)
+	if err := saves.Process(p, subject, saved); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Command completed", "exit_code", response.ExitCode, "duration", response.Duration)
+
+	return &ActivityResponse{
+		Response:         response,
+		Saved:            saved,
+		AssertionResults: assertionResults,
+	}, nil
+}
+
+// runCommand starts the program and waits for it to exit
+func runCommand(ctx context.Context, program string, config *ExecConfig, timeout time.Duration) (*ExecResponse, error) {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if config.Dir != "" {
+		info, err := os.Stat(config.Dir)
+		if err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("dir %q is not a directory on the worker", config.Dir)
+		}
+	}
+
+	cmd := osexec.CommandContext(ctx, program, config.Args...)
+	cmd.Dir = config.Dir
+	cmd.Env = buildEnvironment(config)
+	cmd.WaitDelay = waitDelay
+	if config.Stdin != "" {
+		cmd.Stdin = strings.NewReader(config.Stdin)
+	}
+	stdout := &limitedBuffer{limit: maxOutputBytes}
+	stderr := &limitedBuffer{limit: maxOutputBytes}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	exitCode := 0
+	if err := cmd.Run(); err != nil {
+		var exitErr *osexec.ExitError
+		switch {
+		case ctx.Err() != nil:
+			return nil, fmt.Errorf("command timed out after %s", timeout)
+		case errors.As(err, &exitErr):
+			exitCode = exitErr.ExitCode()
+		default:
+			return nil, fmt.Errorf("failed to run %s: %w", program, err)
+		}
+	}
+
+	return &ExecResponse{
+		Command:  program,
+		Args:     config.Args,
+		Dir:      config.Dir,
+		Stdout:   strings.TrimRight(stdout.String(), "\r\n"),
+		Stderr:   strings.TrimRight(stderr.String(), "\r\n"),
+		ExitCode: exitCode,
+		Duration: time.Since(start).String(),
+	}, nil
+}
+
+// limitedBuffer keeps the first limit bytes written to it and drops the rest
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); room > 0 {
+		if len(p) > room {
+			b.Buffer.Write(p[:room])
+		} else {
+			b.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// resultSubject is what assertions with a path and saves run against. stdout is also
+// decoded into json when it holds a JSON document.
+func resultSubject(response *ExecResponse) map[string]interface{} {
+	subject := map[string]interface{}{
+		"stdout":    response.Stdout,
+		"stderr":    response.Stderr,
+		"exit_code": float64(response.ExitCode),
+	}
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(response.Stdout), &decoded); err == nil {
+		subject["json"] = decoded
+	}
+	return subject
+}
+
+func hasExitCodeAssertion(p map[string]interface{}) bool {
+	assertionList, _ := p["assertions"].([]interface{})
+	for _, assertion := range assertionList {
+		if assertionMap, ok := assertion.(map[string]interface{}); ok && assertionMap["type"] == AssertionTypeExitCode {
+			return true
+		}
+	}
+	return false
+}
+
+func lastLine(s string) string {
+	if s == "" {
+		return "(no stderr output)"
+	}
+	if i := strings.LastIndex(s, "\n"); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
+
+// processAssertions evaluates all assertions and returns their results.
+// Shared assertions without a path check stdout.
+func processAssertions(p map[string]interface{}, response *ExecResponse, subject map[string]interface{}, state map[string]interface{}, env map[string]string) ([]AssertionResult, error) {
+	context := dsl.TemplateContext{Runtime: state, Env: env}
+	return assertions.Process(p, subject, context, func(assertionMap map[string]interface{}, result *AssertionResult) bool {
+		return evaluateAssertion(response, subject, assertionMap, result)
+	})
+}
+
+// evaluateAssertion evaluates the exec plugin's own assertion types
+func evaluateAssertion(response *ExecResponse, subject map[string]interface{}, assertionMap map[string]interface{}, result *AssertionResult) bool {
+	expected := result.Expected
+	switch result.Type {
+	case AssertionTypeExitCode:
+		result.Actual = response.ExitCode
+		if !assertions.Equal(float64(response.ExitCode), expected) {
+			result.Message = fmt.Sprintf("expected exit code %v, got %d", expected, response.ExitCode)
+			if response.Stderr != "" {
+				result.Message += ": " + lastLine(response.Stderr)
+			}
+		} else {
+			result.Passed = true
+		}
+
+	default:
+		if _, hasPath := assertionMap["path"]; hasPath {
+			*result = assertions.Evaluate(assertionMap, subject, expected)
+		} else {
+			*result = assertions.Evaluate(assertionMap, response.Stdout, expected)
+		}
+	}
+	return true
+}
+
+// applyVariableReplacement processes templates in the command, its arguments and environment
+func applyVariableReplacement(config *ExecConfig, state map[string]interface{}, env map[string]string) error {
+	context := dsl.TemplateContext{
+		Runtime: state,
+		Env:     env,
+	}
+
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"command", &config.Command},
+		{"dir", &config.Dir},
+		{"stdin", &config.Stdin},
+	}
+	for i := range config.Args {
+		fields = append(fields, struct {
+			name  string
+			value *string
+		}{fmt.Sprintf("args[%d]", i), &config.Args[i]})
+	}
+	for _, field := range fields {
+		if *field.value == "" {
+			continue
+		}
+		processed, err := dsl.ProcessTemplate(*field.value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process %s template: %w", field.name, err)
+		}
+		*field.value = processed
+	}
+
+	for name, value := range config.Env {
+		processed, err := dsl.ProcessTemplate(value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process env %s template: %w", name, err)
+		}
+		config.Env[name] = processed
+	}
+
+	return nil
+}
+
+// parseConfig converts map[string]interface{} to ExecConfig
+func parseConfig(configData map[string]interface{}, config *ExecConfig) error {
+	stringFields := map[string]*string{
+		"command": &config.Command,
+		"dir":     &config.Dir,
+		"stdin":   &config.Stdin,
+		"timeout": &config.Timeout,
+	}
+	for key, target := range stringFields {
+		if v, ok := configData[key].(string); ok {
+			*target = v
+		}
+	}
+
+	switch args := configData["args"].(type) {
+	case nil:
+	case []interface{}:
+		for _, arg := range args {
+			switch v := arg.(type) {
+			case string:
+				config.Args = append(config.Args, v)
+			case float64, int, bool:
+				config.Args = append(config.Args, fmt.Sprint(v))
+			default:
+				return fmt.Errorf("args must be strings, numbers or booleans, got %T", arg)
+			}
+		}
+	default:
+		return fmt.Errorf("args must be a list, got %T", args)
+	}
+
+	if envData, ok := configData["env"].(map[string]interface{}); ok {
+		config.Env = make(map[string]string, len(envData))
+		for name, value := range envData {
+			config.Env[name] = fmt.Sprint(value)
+		}
+	}
+
+	if _, ok := configData["inherit_env"]; ok {
+		return fmt.Errorf("inherit_env is no longer supported; the worker passes the variables listed in %s", InheritEnv)
+	}
+
+	return nil
+}
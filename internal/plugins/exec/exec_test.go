@@ -0,0 +1,198 @@
+package exec
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+)
+
+func TestAllowlist(t *testing.T) {
+	t.Setenv(AllowEnv, "")
+	if _, err := loadAllowlist().resolve("sh"); err == nil || !strings.Contains(err.Error(), "exec steps are disabled") {
+		t.Errorf("expected exec to be disabled, got %v", err)
+	}
+
+	t.Setenv(AllowEnv, "sh, echo")
+	list := loadAllowlist()
+	program, err := list.resolve("sh")
+	if err != nil || !filepath.IsAbs(program) {
+		t.Errorf("expected sh to resolve to an absolute path, got %q %v", program, err)
+	}
+	if _, err := list.resolve("cat"); err == nil || !strings.Contains(err.Error(), `command "cat" is not allowed`) {
+		t.Errorf("expected cat to be rejected, got %v", err)
+	}
+	// A bare name doesn't allow an explicit path to another program of that name
+	if _, err := list.resolve("/tmp/sh"); err == nil {
+		t.Error("expected a path outside the allowlist to be rejected")
+	}
+
+	dir := t.TempDir()
+	t.Setenv(AllowEnv, dir+"/*")
+	script := filepath.Join(dir, "tool")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho ok\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if program, err := loadAllowlist().resolve(script); err != nil || program != script {
+		t.Errorf("expected %s to match the glob, got %q %v", script, program, err)
+	}
+
+	t.Setenv(AllowEnv, "*")
+	if _, err := loadAllowlist().resolve("cat"); err != nil {
+		t.Errorf("expected * to allow everything, got %v", err)
+	}
+	if _, err := loadAllowlist().resolve("no-such-command-rocketship"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected a missing command error, got %v", err)
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	t.Setenv("ROCKETSHIP_EXEC_TEST_SECRET", "worker-only")
+	dir := t.TempDir()
+
+	config := &ExecConfig{
+		Args:  []string{"-c", `read line; echo "$line $GREETING $ROCKETSHIP_EXEC_TEST_SECRET"; pwd; echo oops >&2; exit 3`},
+		Env:   map[string]string{"GREETING": "hello"},
+		Dir:   dir,
+		Stdin: "input\n",
+	}
+	response, err := runCommand(context.Background(), "/bin/sh", config, 5*time.Second)
+	if err != nil {
+		t.Fatalf("runCommand failed: %v", err)
+	}
+	resolvedDir, _ := filepath.EvalSymlinks(dir)
+	if response.ExitCode != 3 || response.Stderr != "oops" {
+		t.Errorf("unexpected exit status %d, stderr %q", response.ExitCode, response.Stderr)
+	}
+	lines := strings.Split(response.Stdout, "\n")
+	if len(lines) != 2 || lines[0] != "input hello " || (lines[1] != dir && lines[1] != resolvedDir) {
+		t.Errorf("unexpected stdout %q", response.Stdout)
+	}
+
+	// The worker chooses which of its variables commands inherit
+	t.Setenv(InheritEnv, "ROCKETSHIP_EXEC_TEST_*")
+	config = &ExecConfig{Args: []string{"-c", "echo $ROCKETSHIP_EXEC_TEST_SECRET"}}
+	response, err = runCommand(context.Background(), "/bin/sh", config, 5*time.Second)
+	if err != nil || response.Stdout != "worker-only" {
+		t.Errorf("expected the inherited variable, got %q %v", response.Stdout, err)
+	}
+
+	_, err = runCommand(context.Background(), "/bin/sh", &ExecConfig{Args: []string{"-c", "sleep 5"}}, 100*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "command timed out after 100ms") {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+
+	_, err = runCommand(context.Background(), "/bin/sh", &ExecConfig{Dir: filepath.Join(dir, "missing")}, time.Second)
+	if err == nil || !strings.Contains(err.Error(), "is not a directory") {
+		t.Errorf("expected a missing dir error, got %v", err)
+	}
+}
+
+func TestAssertionsAndSaves(t *testing.T) {
+	response := &ExecResponse{Stdout: `{"version":"1.2.3","ready":true}`, Stderr: "warning: slow\nfatal: nope", ExitCode: 2}
+	subject := resultSubject(response)
+
+	p := map[string]interface{}{
+		"assertions": []interface{}{
+			map[string]interface{}{"type": "exit_code", "expected": 2.0},
+			map[string]interface{}{"type": "contains", "expected": "1.2.3"},
+			map[string]interface{}{"type": "equals", "path": ".json.ready", "expected": true},
+			map[string]interface{}{"type": "regex", "path": ".stderr", "expected": "^warning"},
+		},
+		"save": []interface{}{
+			map[string]interface{}{"json_path": ".json.version", "as": "version"},
+			map[string]interface{}{"json_path": ".exit_code", "as": "code"},
+		},
+	}
+	if !hasExitCodeAssertion(p) {
+		t.Error("expected the exit_code assertion to be found")
+	}
+	results, err := processAssertions(p, response, subject, map[string]interface{}{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failure := assertions.Summary(results); failure != "" {
+		t.Fatalf("assertions failed: %s %+v", failure, results)
+	}
+
+	saved := make(map[string]string)
+	if err := saves.Process(p, subject, saved); err != nil {
+		t.Fatalf("saves failed: %v", err)
+	}
+	if saved["version"] != "1.2.3" || saved["code"] != "2" {
+		t.Errorf("unexpected saves %v", saved)
+	}
+
+	results, err = processAssertions(map[string]interface{}{
+		"assertions": []interface{}{map[string]interface{}{"type": "exit_code", "expected": 0.0}},
+	}, response, subject, map[string]interface{}{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failure := assertions.Summary(results)
+	if !strings.Contains(failure, "expected exit code 0, got 2: fatal: nope") {
+		t.Errorf("unexpected failure %q", failure)
+	}
+}
+
+func TestParseConfigAndTemplates(t *testing.T) {
+	config := &ExecConfig{}
+	err := parseConfig(map[string]interface{}{
+		"command": "kubectl",
+		"args":    []interface{}{"get", "pods", "-n", "{{ namespace }}", 3.0},
+		"env":     map[string]interface{}{"KUBECONFIG": "{{ .env.KUBECONFIG }}", "RETRIES": 2.0},
+		"timeout": "1m",
+	}, config)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(config.Args) != 5 || config.Args[4] != "3" || config.Env["RETRIES"] != "2" {
+		t.Errorf("unexpected config %+v", config)
+	}
+
+	if err := applyVariableReplacement(config, map[string]interface{}{"namespace": "staging"}, map[string]string{"KUBECONFIG": "/etc/kube"}); err != nil {
+		t.Fatalf("replacement failed: %v", err)
+	}
+	if config.Args[3] != "staging" || config.Env["KUBECONFIG"] != "/etc/kube" {
+		t.Errorf("templates not applied: %+v", config)
+	}
+
+	if err := parseConfig(map[string]interface{}{"args": "get pods"}, &ExecConfig{}); err == nil || !strings.Contains(err.Error(), "args must be a list") {
+		t.Errorf("expected an args error, got %v", err)
+	}
+	if err := parseConfig(map[string]interface{}{"inherit_env": true}, &ExecConfig{}); err == nil || !strings.Contains(err.Error(), InheritEnv) {
+		t.Errorf("expected inherit_env to be rejected, got %v", err)
+	}
+}
+
+func TestCheckEnv(t *testing.T) {
+	for _, name := range []string{"PATH", "Path", "LD_PRELOAD", "LD_LIBRARY_PATH", "LD_AUDIT", "DYLD_INSERT_LIBRARIES", "BASH_ENV"} {
+		if err := checkEnv(map[string]string{"GREETING": "hello", name: "/tmp/evil"}); err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("expected %s to be denied, got %v", name, err)
+		}
+	}
+	if err := checkEnv(map[string]string{"KUBECONFIG": "/etc/kube", "OLD_PATH": "/bin"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestBuildEnvironment(t *testing.T) {
+	t.Setenv("ROCKETSHIP_EXEC_TEST_TOKEN", "t")
+	t.Setenv("ROCKETSHIP_EXEC_OTHER", "o")
+	t.Setenv(InheritEnv, "")
+	env := strings.Join(buildEnvironment(&ExecConfig{}), "\n")
+	if strings.Contains(env, "ROCKETSHIP_EXEC_TEST_TOKEN") || strings.Contains(env, "ROCKETSHIP_EXEC_OTHER") {
+		t.Errorf("worker variables passed without %s: %s", InheritEnv, env)
+	}
+
+	t.Setenv(InheritEnv, "ROCKETSHIP_EXEC_TEST_*, HOSTNAME")
+	env = strings.Join(buildEnvironment(&ExecConfig{Env: map[string]string{"GREETING": "hi"}}), "\n")
+	if !strings.Contains(env, "ROCKETSHIP_EXEC_TEST_TOKEN=t") || strings.Contains(env, "ROCKETSHIP_EXEC_OTHER") || !strings.Contains(env, "GREETING=hi") {
+		t.Errorf("unexpected environment: %s", env)
+	}
+}
@@ -0,0 +1,47 @@
+package exec
+
+import "github.com/rocketship-ai/rocketship/internal/assertions"
+
+// ExecPlugin represents an exec test step
+type ExecPlugin struct {
+	Name   string     `json:"name" yaml:"name"`
+	Plugin string     `json:"plugin" yaml:"plugin"`
+	Config ExecConfig `json:"config" yaml:"config"`
+}
+
+// ExecConfig defines the command to run on the worker. The command is started
+// directly, without a shell, and must be allowed by the worker's allowlist.
+type ExecConfig struct {
+	Command string            `json:"command" yaml:"command"`                     // Name looked up in the worker's PATH, or a path
+	Args    []string          `json:"args,omitempty" yaml:"args,omitempty"`       // Passed as-is, without shell expansion
+	Env     map[string]string `json:"env,omitempty" yaml:"env,omitempty"`         // Added to the command's environment
+	Dir     string            `json:"dir,omitempty" yaml:"dir,omitempty"`         // Working directory (defaults to the worker's)
+	Stdin   string            `json:"stdin,omitempty" yaml:"stdin,omitempty"`     // Written to the command's standard input
+	Timeout string            `json:"timeout,omitempty" yaml:"timeout,omitempty"` // Overall step timeout (e.g., "30s")
+}
+
+// Assertion types supported by the exec plugin in addition to the shared ones
+const (
+	AssertionTypeExitCode = "exit_code"
+)
+
+// ExecResponse contains the result of the command
+type ExecResponse struct {
+	Command  string   `json:"command"` // Resolved path of the program
+	Args     []string `json:"args"`
+	Dir      string   `json:"dir,omitempty"`
+	Stdout   string   `json:"stdout"` // Trailing newlines are trimmed
+	Stderr   string   `json:"stderr"` // Trailing newlines are trimmed
+	ExitCode int      `json:"exit_code"`
+	Duration string   `json:"duration"`
+}
+
+// AssertionResult represents a single assertion result for UI display
+type AssertionResult = assertions.Result
+
+// ActivityResponse represents the complete activity response
+type ActivityResponse struct {
+	Response         *ExecResponse     `json:"response"`
+	Saved            map[string]string `json:"saved"`
+	AssertionResults []AssertionResult `json:"assertion_results,omitempty"`
+}
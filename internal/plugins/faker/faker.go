@@ -0,0 +1,263 @@
+package faker
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+	"go.temporal.io/sdk/activity"
+)
+
+// maxLength caps length so a typo can't generate megabytes of lorem ipsum
+const maxLength = 10000
+
+// Auto-register the plugin when the package is imported
+func init() {
+	plugins.RegisterPlugin(&FakerPlugin{})
+}
+
+// GetType returns the plugin type identifier
+func (fp *FakerPlugin) GetType() string {
+	return "faker"
+}
+
+// Activity generates the configured values so later steps can use them through saves
+func (fp *FakerPlugin) Activity(ctx context.Context, p map[string]interface{}) (interface{}, error) {
+	logger := activity.GetLogger(ctx)
+
+	// Parse configuration from parameters
+	configData, ok := p["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid config format")
+	}
+
+	config := &FakerConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		return nil, fmt.Errorf("failed to parse faker config: %w", err)
+	}
+
+	// Convert state to map[string]interface{} for template processing
+	state := make(map[string]interface{})
+	if stateStr, ok := p["state"].(map[string]string); ok {
+		for k, v := range stateStr {
+			state[k] = v
+		}
+	} else if stateInt, ok := p["state"].(map[string]interface{}); ok {
+		state = stateInt
+	}
+
+	// Extract env secrets from params (for {{ .env.* }} template resolution)
+	env := make(map[string]string)
+	if envData, ok := p["env"].(map[string]interface{}); ok {
+		for k, v := range envData {
+			if strVal, ok := v.(string); ok {
+				env[k] = strVal
+			}
+		}
+	} else if envData, ok := p["env"].(map[string]string); ok {
+		env = envData
+	}
+
+	if err := applyVariableReplacement(config, state, env); err != nil {
+		return nil, fmt.Errorf("variable replacement failed: %w", err)
+	}
+
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	response, err := generate(config, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := assertions.Normalize(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare faker result: %w", err)
+	}
+
+	assertionResults, err := assertions.Process(p, subject, dsl.TemplateContext{Runtime: state, Env: env}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if failure := assertions.Summary(assertionResults); failure != "" {
+		return nil, fmt.Errorf("%s", failure)
+	}
+
+	saved := make(map[string]string)
+	if err := saves.Process(p, subject, saved); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Generated synthetic data", "fields", len(response.Values), "seed", response.Seed)
+
+	return &ActivityResponse{
+		Response:         response,
+		Saved:            saved,
+		AssertionResults: assertionResults,
+	}, nil
+}
+
+// generate draws every field from one random source. Fields are drawn in name
+// order, so a seed reproduces the same values however the YAML map is ordered.
+func generate(config *FakerConfig, now time.Time) (*FakerResponse, error) {
+	seed := rand.Int64()
+	if config.Seed != nil {
+		seed = *config.Seed
+	}
+	g := &generation{
+		rng: rand.New(rand.NewPCG(uint64(seed), 0)),
+		now: now,
+	}
+
+	names := make([]string, 0, len(config.Fields))
+	for name := range config.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	values := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		field := config.Fields[name]
+		value, err := generators[field.Type](g, field)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", name, err)
+		}
+		values[name] = value
+	}
+
+	return &FakerResponse{Seed: seed, Values: values}, nil
+}
+
+// validateConfig checks every field names a known generator with usable options
+func validateConfig(config *FakerConfig) error {
+	if len(config.Fields) == 0 {
+		return fmt.Errorf("fields is required")
+	}
+
+	for name, field := range config.Fields {
+		if _, ok := generators[field.Type]; !ok {
+			return fmt.Errorf("field %s: unknown type %q (available: %s)", name, field.Type, generatorNames())
+		}
+		if field.Length < 0 || field.Length > maxLength {
+			return fmt.Errorf("field %s: length must be between 0 and %d", name, maxLength)
+		}
+		switch field.Type {
+		case "pick":
+			if len(field.Values) == 0 {
+				return fmt.Errorf("field %s: pick needs values", name)
+			}
+		case "credit_card":
+			switch field.Brand {
+			case "", "visa", "mastercard", "amex":
+			default:
+				return fmt.Errorf("field %s: brand must be visa, mastercard or amex, got %q", name, field.Brand)
+			}
+		case "number":
+			if field.Min != nil && field.Max != nil && *field.Min > *field.Max {
+				return fmt.Errorf("field %s: min %v is greater than max %v", name, *field.Min, *field.Max)
+			}
+		}
+	}
+
+	return nil
+}
+
+// applyVariableReplacement processes templates in email domains and pick values
+func applyVariableReplacement(config *FakerConfig, state map[string]interface{}, env map[string]string) error {
+	context := dsl.TemplateContext{
+		Runtime: state,
+		Env:     env,
+	}
+
+	for name, field := range config.Fields {
+		if field.Domain != "" {
+			processed, err := dsl.ProcessTemplate(field.Domain, context)
+			if err != nil {
+				return fmt.Errorf("failed to process field %s domain template: %w", name, err)
+			}
+			field.Domain = processed
+		}
+		for i, value := range field.Values {
+			if s, ok := value.(string); ok {
+				processed, err := dsl.ProcessTemplate(s, context)
+				if err != nil {
+					return fmt.Errorf("failed to process field %s values template: %w", name, err)
+				}
+				field.Values[i] = processed
+			}
+		}
+		config.Fields[name] = field
+	}
+
+	return nil
+}
+
+// parseConfig converts map[string]interface{} to FakerConfig
+func parseConfig(configData map[string]interface{}, config *FakerConfig) error {
+	switch seed := configData["seed"].(type) {
+	case nil:
+	case float64:
+		s := int64(seed)
+		config.Seed = &s
+	case int:
+		s := int64(seed)
+		config.Seed = &s
+	case int64:
+		config.Seed = &seed
+	default:
+		return fmt.Errorf("seed must be a number, got %T", seed)
+	}
+
+	fieldsData, ok := configData["fields"].(map[string]interface{})
+	if !ok {
+		if configData["fields"] != nil {
+			return fmt.Errorf("fields must be a map, got %T", configData["fields"])
+		}
+		return nil
+	}
+
+	config.Fields = make(map[string]FieldConfig, len(fieldsData))
+	for name, raw := range fieldsData {
+		field := FieldConfig{}
+		switch v := raw.(type) {
+		case string:
+			field.Type = v
+		case map[string]interface{}:
+			field.Type, _ = v["type"].(string)
+			field.Domain, _ = v["domain"].(string)
+			field.Brand, _ = v["brand"].(string)
+			if values, ok := v["values"].([]interface{}); ok {
+				field.Values = values
+			}
+			if length, ok := v["length"].(float64); ok {
+				field.Length = int(length)
+			} else if length, ok := v["length"].(int); ok {
+				field.Length = length
+			}
+			for key, target := range map[string]**float64{"min": &field.Min, "max": &field.Max} {
+				switch n := v[key].(type) {
+				case nil:
+				case float64:
+					*target = &n
+				case int:
+					f := float64(n)
+					*target = &f
+				default:
+					return fmt.Errorf("field %s: %s must be a number, got %T", name, key, n)
+				}
+			}
+		default:
+			return fmt.Errorf("field %s must be a type name or a map, got %T", name, raw)
+		}
+		config.Fields[name] = field
+	}
+
+	return nil
+}
@@ -0,0 +1,208 @@
+package faker
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+)
+
+var now = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func fields(types ...string) map[string]FieldConfig {
+	f := make(map[string]FieldConfig, len(types))
+	for _, t := range types {
+		f[t] = FieldConfig{Type: t}
+	}
+	return f
+}
+
+func TestGenerateFormats(t *testing.T) {
+	config := &FakerConfig{Fields: fields(
+		"uuid", "first_name", "last_name", "name", "email", "username", "phone", "company",
+		"street_address", "city", "country", "zip", "credit_card", "credit_card_cvv", "credit_card_expiry",
+		"word", "words", "sentence", "sentences", "paragraphs", "alphanumeric", "digits", "number",
+		"boolean", "ipv4", "url", "date",
+	)}
+	config.Fields["pick"] = FieldConfig{Type: "pick", Values: []interface{}{"free", "pro"}}
+	if err := validateConfig(config); err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+	if len(config.Fields) != len(generators) {
+		t.Errorf("test covers %d of %d generators", len(config.Fields), len(generators))
+	}
+
+	response, err := generate(config, now)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	values := response.Values
+
+	patterns := map[string]string{
+		"uuid":               `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`,
+		"email":              `^[a-z]+\.[a-z]+\d+@example\.com$`,
+		"phone":              `^\+1-\d{3}-555-01\d{2}$`,
+		"zip":                `^\d{5}$`,
+		"credit_card":        `^4\d{15}$`,
+		"credit_card_cvv":    `^\d{3}$`,
+		"credit_card_expiry": `^(0[1-9]|1[0-2])/\d{2}$`,
+		"sentence":           `^[A-Z][a-z ]+\.$`,
+		"alphanumeric":       `^[A-Za-z0-9]{12}$`,
+		"digits":             `^\d{6}$`,
+		"ipv4":               `^198\.1[89]\.\d+\.\d+$`,
+		"date":               `^\d{4}-\d{2}-\d{2}$`,
+	}
+	for field, pattern := range patterns {
+		if s, _ := values[field].(string); !regexp.MustCompile(pattern).MatchString(s) {
+			t.Errorf("%s: %q does not match %s", field, values[field], pattern)
+		}
+	}
+
+	// The name fields describe one person
+	first, last := values["first_name"].(string), values["last_name"].(string)
+	if values["name"] != first+" "+last || !strings.HasPrefix(values["email"].(string), values["username"].(string)+"@") {
+		t.Errorf("person fields disagree: %v", values)
+	}
+	if n := values["number"].(int64); n < 0 || n > 1000 {
+		t.Errorf("number %d out of the default range", n)
+	}
+	if values["pick"] != "free" && values["pick"] != "pro" {
+		t.Errorf("unexpected pick %v", values["pick"])
+	}
+	if !luhnValid(values["credit_card"].(string)) {
+		t.Errorf("card %s fails the Luhn check", values["credit_card"])
+	}
+}
+
+func luhnValid(number string) bool {
+	return luhnCheckDigit(number[:len(number)-1]) == int(number[len(number)-1]-'0')
+}
+
+func TestSeedReproducesValues(t *testing.T) {
+	seed := int64(42)
+	config := &FakerConfig{Seed: &seed, Fields: fields("uuid", "email", "credit_card", "sentence")}
+
+	first, err := generate(config, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := generate(config, now)
+	for name, value := range first.Values {
+		if second.Values[name] != value {
+			t.Errorf("%s differs with the same seed: %v vs %v", name, value, second.Values[name])
+		}
+	}
+
+	// Unseeded runs report their seed so they can be replayed
+	random, _ := generate(&FakerConfig{Fields: fields("uuid")}, now)
+	replayed, _ := generate(&FakerConfig{Seed: &random.Seed, Fields: fields("uuid")}, now)
+	if random.Values["uuid"] != replayed.Values["uuid"] {
+		t.Errorf("replaying seed %d gave %v, want %v", random.Seed, replayed.Values["uuid"], random.Values["uuid"])
+	}
+	other, _ := generate(&FakerConfig{Fields: fields("uuid")}, now)
+	if other.Values["uuid"] == random.Values["uuid"] {
+		t.Error("expected unseeded runs to differ")
+	}
+}
+
+func TestFieldOptions(t *testing.T) {
+	min, max := 10.0, 12.0
+	priceMin, priceMax := 1.5, 2.5
+	config := &FakerConfig{Fields: map[string]FieldConfig{
+		"quantity": {Type: "number", Min: &min, Max: &max},
+		"price":    {Type: "number", Min: &priceMin, Max: &priceMax},
+		"amex":     {Type: "credit_card", Brand: "amex"},
+		"mc":       {Type: "credit_card", Brand: "mastercard"},
+		"email":    {Type: "email", Domain: "test.acme.io"},
+		"code":     {Type: "alphanumeric", Length: 4},
+		"bio":      {Type: "words", Length: 3},
+	}}
+	response, err := generate(config, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := response.Values
+	if q := v["quantity"].(int64); q < 10 || q > 12 {
+		t.Errorf("quantity %d out of range", q)
+	}
+	if p := v["price"].(float64); p < 1.5 || p > 2.5 {
+		t.Errorf("price %v out of range", p)
+	}
+	if amex := v["amex"].(string); len(amex) != 15 || !(strings.HasPrefix(amex, "34") || strings.HasPrefix(amex, "37")) || !luhnValid(amex) {
+		t.Errorf("unexpected amex %s", amex)
+	}
+	if mc := v["mc"].(string); len(mc) != 16 || mc[0] != '5' || !luhnValid(mc) {
+		t.Errorf("unexpected mastercard %s", mc)
+	}
+	if !strings.HasSuffix(v["email"].(string), "@test.acme.io") || len(v["code"].(string)) != 4 || len(strings.Fields(v["bio"].(string))) != 3 {
+		t.Errorf("options not applied: %v", v)
+	}
+}
+
+func TestParseConfigAndSaves(t *testing.T) {
+	config := &FakerConfig{}
+	err := parseConfig(map[string]interface{}{
+		"seed": 7.0,
+		"fields": map[string]interface{}{
+			"email": map[string]interface{}{"type": "email", "domain": "{{ tenant }}.example.com"},
+			"plan":  map[string]interface{}{"type": "pick", "values": []interface{}{"{{ default_plan }}"}},
+			"age":   map[string]interface{}{"type": "number", "min": 18.0, "max": 99.0},
+			"id":    "uuid",
+		},
+	}, config)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if err := applyVariableReplacement(config, map[string]interface{}{"tenant": "acme", "default_plan": "pro"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateConfig(config); err != nil {
+		t.Fatal(err)
+	}
+	response, err := generate(config, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Seed != 7 || response.Values["plan"] != "pro" || !strings.HasSuffix(response.Values["email"].(string), "@acme.example.com") {
+		t.Errorf("unexpected response %+v", response)
+	}
+
+	subject, err := assertions.Normalize(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := map[string]interface{}{
+		"assertions": []interface{}{map[string]interface{}{"type": "regex", "path": ".values.email", "expected": "@acme"}},
+		"save": []interface{}{
+			map[string]interface{}{"json_path": ".values.email", "as": "email"},
+			map[string]interface{}{"json_path": ".values.age", "as": "age"},
+		},
+	}
+	if results, err := assertions.Process(p, subject, dsl.TemplateContext{}, nil); err != nil || assertions.Summary(results) != "" {
+		t.Errorf("assertions failed: %v %+v", err, results)
+	}
+	saved := make(map[string]string)
+	if err := saves.Process(p, subject, saved); err != nil || saved["email"] != response.Values["email"] || saved["age"] == "" {
+		t.Errorf("unexpected saves %v: %v", saved, err)
+	}
+
+	invalid := []struct {
+		config  FakerConfig
+		message string
+	}{
+		{FakerConfig{}, "fields is required"},
+		{FakerConfig{Fields: map[string]FieldConfig{"x": {Type: "ssn"}}}, `unknown type "ssn"`},
+		{FakerConfig{Fields: map[string]FieldConfig{"x": {Type: "pick"}}}, "pick needs values"},
+		{FakerConfig{Fields: map[string]FieldConfig{"x": {Type: "credit_card", Brand: "discover"}}}, "brand must be"},
+		{FakerConfig{Fields: map[string]FieldConfig{"x": {Type: "words", Length: 1 << 20}}}, "length must be"},
+	}
+	for _, tc := range invalid {
+		if err := validateConfig(&tc.config); err == nil || !strings.Contains(err.Error(), tc.message) {
+			t.Errorf("expected %q, got %v", tc.message, err)
+		}
+	}
+}
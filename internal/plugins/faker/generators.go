@@ -0,0 +1,282 @@
+package faker
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	firstNames = []string{
+		"Ada", "Alan", "Amara", "Anika", "Arjun", "Beatriz", "Carlos", "Chen", "Chloe", "Dmitri",
+		"Elena", "Emeka", "Fatima", "Grace", "Hana", "Hugo", "Ines", "Ivan", "Jamal", "Jia",
+		"Kai", "Kenji", "Lars", "Leila", "Liam", "Lucia", "Maya", "Mateo", "Nadia", "Noah",
+		"Olga", "Omar", "Priya", "Rafael", "Rosa", "Sami", "Sofia", "Tariq", "Yara", "Zoe",
+	}
+	lastNames = []string{
+		"Abara", "Andersen", "Bianchi", "Costa", "Dubois", "Eriksson", "Fernandes", "Garcia", "Haddad", "Hoffmann",
+		"Ibrahim", "Ivanova", "Jensen", "Kim", "Kowalski", "Lovelace", "Martin", "Mendes", "Müller", "Nakamura",
+		"Nguyen", "Novak", "Okafor", "Oliveira", "Patel", "Petrov", "Quinn", "Rossi", "Sato", "Schmidt",
+		"Silva", "Singh", "Tanaka", "Turner", "Urban", "Varga", "Wang", "Weber", "Yilmaz", "Zhang",
+	}
+	companySuffixes = []string{"Labs", "Systems", "Industries", "Group", "Partners", "Analytics", "Logistics", "Works"}
+	streetNames     = []string{"Maple", "Oak", "Cedar", "Harbor", "Mill", "River", "Station", "Park", "Lake", "Hill"}
+	streetTypes     = []string{"Street", "Avenue", "Road", "Lane", "Way", "Drive"}
+	cities          = []string{"Springfield", "Riverton", "Lakeside", "Fairview", "Georgetown", "Clinton", "Madison", "Ashford", "Bristol", "Kingston"}
+	countries       = []string{"United States", "Canada", "United Kingdom", "Germany", "France", "Brazil", "Japan", "India", "Nigeria", "Australia"}
+	loremWords      = strings.Fields("lorem ipsum dolor sit amet consectetur adipiscing elit sed do eiusmod tempor incididunt ut labore et dolore magna aliqua enim ad minim veniam quis nostrud exercitation ullamco laboris nisi aliquip ex ea commodo consequat duis aute irure in reprehenderit voluptate velit esse cillum fugiat nulla pariatur excepteur sint occaecat cupidatat non proident sunt culpa qui officia deserunt mollit anim id est laborum")
+)
+
+const alphanumeric = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// generator is a field type: it draws a value from rng for a field's options
+type generator func(g *generation, field FieldConfig) (interface{}, error)
+
+// generators maps field types to their generators
+var generators = map[string]generator{
+	"uuid": func(g *generation, _ FieldConfig) (interface{}, error) {
+		var b [16]byte
+		for i := range b {
+			b[i] = byte(g.rng.IntN(256))
+		}
+		b[6] = (b[6] & 0x0f) | 0x40 // version 4
+		b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+	},
+	"first_name": func(g *generation, _ FieldConfig) (interface{}, error) {
+		return g.person().first, nil
+	},
+	"last_name": func(g *generation, _ FieldConfig) (interface{}, error) {
+		return g.person().last, nil
+	},
+	"name": func(g *generation, _ FieldConfig) (interface{}, error) {
+		p := g.person()
+		return p.first + " " + p.last, nil
+	},
+	"username": func(g *generation, _ FieldConfig) (interface{}, error) {
+		return g.person().username(), nil
+	},
+	"email": func(g *generation, field FieldConfig) (interface{}, error) {
+		domain := field.Domain
+		if domain == "" {
+			domain = "example.com"
+		}
+		return g.person().username() + "@" + domain, nil
+	},
+	"phone": func(g *generation, _ FieldConfig) (interface{}, error) {
+		// 555-0100 through 555-0199 are reserved for fictional use in North America
+		return fmt.Sprintf("+1-%d-555-01%02d", 200+g.rng.IntN(800), g.rng.IntN(100)), nil
+	},
+	"company": func(g *generation, _ FieldConfig) (interface{}, error) {
+		return pick(g.rng, lastNames) + " " + pick(g.rng, companySuffixes), nil
+	},
+	"street_address": func(g *generation, _ FieldConfig) (interface{}, error) {
+		return fmt.Sprintf("%d %s %s", 1+g.rng.IntN(9999), pick(g.rng, streetNames), pick(g.rng, streetTypes)), nil
+	},
+	"city": func(g *generation, _ FieldConfig) (interface{}, error) {
+		return pick(g.rng, cities), nil
+	},
+	"country": func(g *generation, _ FieldConfig) (interface{}, error) {
+		return pick(g.rng, countries), nil
+	},
+	"zip": func(g *generation, _ FieldConfig) (interface{}, error) {
+		return fmt.Sprintf("%05d", g.rng.IntN(100000)), nil
+	},
+	"credit_card":        creditCard,
+	"credit_card_cvv":    func(g *generation, _ FieldConfig) (interface{}, error) { return digits(g.rng, 3), nil },
+	"credit_card_expiry": creditCardExpiry,
+	"word": func(g *generation, _ FieldConfig) (interface{}, error) {
+		return pick(g.rng, loremWords), nil
+	},
+	"words": func(g *generation, field FieldConfig) (interface{}, error) {
+		return words(g.rng, lengthOr(field, 5)), nil
+	},
+	"sentence": func(g *generation, _ FieldConfig) (interface{}, error) {
+		return sentence(g.rng), nil
+	},
+	"sentences": func(g *generation, field FieldConfig) (interface{}, error) {
+		return sentences(g.rng, lengthOr(field, 3)), nil
+	},
+	"paragraphs": func(g *generation, field FieldConfig) (interface{}, error) {
+		count := lengthOr(field, 2)
+		paragraphs := make([]string, count)
+		for i := range paragraphs {
+			paragraphs[i] = sentences(g.rng, 3+g.rng.IntN(3))
+		}
+		return strings.Join(paragraphs, "\n\n"), nil
+	},
+	"alphanumeric": func(g *generation, field FieldConfig) (interface{}, error) {
+		b := make([]byte, lengthOr(field, 12))
+		for i := range b {
+			b[i] = alphanumeric[g.rng.IntN(len(alphanumeric))]
+		}
+		return string(b), nil
+	},
+	"digits": func(g *generation, field FieldConfig) (interface{}, error) {
+		return digits(g.rng, lengthOr(field, 6)), nil
+	},
+	"number": number,
+	"boolean": func(g *generation, _ FieldConfig) (interface{}, error) {
+		return g.rng.IntN(2) == 1, nil
+	},
+	"pick": func(g *generation, field FieldConfig) (interface{}, error) {
+		return field.Values[g.rng.IntN(len(field.Values))], nil
+	},
+	"ipv4": func(g *generation, _ FieldConfig) (interface{}, error) {
+		// 198.18.0.0/15 is reserved for benchmarking, so it never routes anywhere real
+		return fmt.Sprintf("198.%d.%d.%d", 18+g.rng.IntN(2), g.rng.IntN(256), 1+g.rng.IntN(254)), nil
+	},
+	"url": func(g *generation, _ FieldConfig) (interface{}, error) {
+		return fmt.Sprintf("https://%s.example.com/%s", pick(g.rng, loremWords), pick(g.rng, loremWords)), nil
+	},
+	"date": func(g *generation, _ FieldConfig) (interface{}, error) {
+		// Within a year either side of now
+		offset := time.Duration(g.rng.Int64N(int64(2*365*24*time.Hour))) - 365*24*time.Hour
+		return g.now.Add(offset).Format("2006-01-02"), nil
+	},
+}
+
+// generatorNames lists the field types for error messages
+func generatorNames() string {
+	names := make([]string, 0, len(generators))
+	for name := range generators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// generation is the state of one step's values
+type generation struct {
+	rng      *rand.Rand
+	now      time.Time
+	somebody *person
+}
+
+type person struct {
+	first, last string
+	suffix      int
+}
+
+// person returns the step's person, drawing it on first use
+func (g *generation) person() *person {
+	if g.somebody == nil {
+		g.somebody = &person{
+			first:  pick(g.rng, firstNames),
+			last:   pick(g.rng, lastNames),
+			suffix: g.rng.IntN(10000),
+		}
+	}
+	return g.somebody
+}
+
+// username is ASCII so it also works as the local part of an email address
+func (p *person) username() string {
+	name := strings.ToLower(p.first + "." + p.last)
+	name = strings.NewReplacer("ü", "u", "ö", "o", "ä", "a").Replace(name)
+	return fmt.Sprintf("%s%d", name, p.suffix)
+}
+
+// creditCard returns a Luhn-valid number for the brand. The numbers pass format
+// checks but belong to no real account.
+func creditCard(g *generation, field FieldConfig) (interface{}, error) {
+	var prefix string
+	length := 16
+	switch field.Brand {
+	case "", "visa":
+		prefix = "4"
+	case "mastercard":
+		prefix = fmt.Sprintf("5%d", 1+g.rng.IntN(5))
+	case "amex":
+		prefix = pick(g.rng, []string{"34", "37"})
+		length = 15
+	}
+
+	number := prefix + digits(g.rng, length-len(prefix)-1)
+	return number + string(rune('0'+luhnCheckDigit(number))), nil
+}
+
+// luhnCheckDigit returns the digit that makes number+digit pass the Luhn check
+func luhnCheckDigit(number string) int {
+	sum := 0
+	double := true
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return (10 - sum%10) % 10
+}
+
+// creditCardExpiry returns an MM/YY date one to five years ahead
+func creditCardExpiry(g *generation, _ FieldConfig) (interface{}, error) {
+	expiry := g.now.AddDate(1+g.rng.IntN(5), g.rng.IntN(12), 0)
+	return expiry.Format("01/06"), nil
+}
+
+// number returns an integer between min and max, or a decimal when either bound has a fraction
+func number(g *generation, field FieldConfig) (interface{}, error) {
+	lo, hi := 0.0, 1000.0
+	if field.Min != nil {
+		lo = *field.Min
+	}
+	if field.Max != nil {
+		hi = *field.Max
+	}
+	if lo > hi {
+		return nil, fmt.Errorf("min %v is greater than max %v", lo, hi)
+	}
+	if lo == math.Trunc(lo) && hi == math.Trunc(hi) {
+		return int64(lo) + g.rng.Int64N(int64(hi-lo)+1), nil
+	}
+	return math.Round((lo+g.rng.Float64()*(hi-lo))*100) / 100, nil
+}
+
+func pick(rng *rand.Rand, values []string) string {
+	return values[rng.IntN(len(values))]
+}
+
+func digits(rng *rand.Rand, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte('0' + rng.IntN(10))
+	}
+	return string(b)
+}
+
+func words(rng *rand.Rand, n int) string {
+	w := make([]string, n)
+	for i := range w {
+		w[i] = pick(rng, loremWords)
+	}
+	return strings.Join(w, " ")
+}
+
+func sentence(rng *rand.Rand) string {
+	s := words(rng, 6+rng.IntN(7))
+	return strings.ToUpper(s[:1]) + s[1:] + "."
+}
+
+func sentences(rng *rand.Rand, n int) string {
+	s := make([]string, n)
+	for i := range s {
+		s[i] = sentence(rng)
+	}
+	return strings.Join(s, " ")
+}
+
+func lengthOr(field FieldConfig, fallback int) int {
+	if field.Length > 0 {
+		return field.Length
+	}
+	return fallback
+}
@@ -0,0 +1,44 @@
+package faker
+
+import "github.com/rocketship-ai/rocketship/internal/assertions"
+
+// FakerPlugin represents a synthetic data test step
+type FakerPlugin struct {
+	Name   string      `json:"name" yaml:"name"`
+	Plugin string      `json:"plugin" yaml:"plugin"`
+	Config FakerConfig `json:"config" yaml:"config"`
+}
+
+// FakerConfig lists the values to generate
+type FakerConfig struct {
+	Seed   *int64                 `json:"seed,omitempty" yaml:"seed,omitempty"` // Same seed, same values
+	Fields map[string]FieldConfig `json:"fields" yaml:"fields"`
+}
+
+// FieldConfig selects a generator and its options. In YAML a field can also be
+// just the generator name.
+type FieldConfig struct {
+	Type   string        `json:"type" yaml:"type"`
+	Min    *float64      `json:"min,omitempty" yaml:"min,omitempty"`       // number: lowest value (default 0)
+	Max    *float64      `json:"max,omitempty" yaml:"max,omitempty"`       // number: highest value (default 1000)
+	Length int           `json:"length,omitempty" yaml:"length,omitempty"` // alphanumeric, digits: characters; words, sentences, paragraphs: how many
+	Domain string        `json:"domain,omitempty" yaml:"domain,omitempty"` // email: domain (default example.com)
+	Brand  string        `json:"brand,omitempty" yaml:"brand,omitempty"`   // credit_card: visa (default), mastercard or amex
+	Values []interface{} `json:"values,omitempty" yaml:"values,omitempty"` // pick: values to choose from
+}
+
+// FakerResponse contains the generated values
+type FakerResponse struct {
+	Seed   int64                  `json:"seed"` // Pass back as seed to reproduce the values
+	Values map[string]interface{} `json:"values"`
+}
+
+// AssertionResult represents a single assertion result for UI display
+type AssertionResult = assertions.Result
+
+// ActivityResponse represents the complete activity response
+type ActivityResponse struct {
+	Response         *FakerResponse    `json:"response"`
+	Saved            map[string]string `json:"saved"`
+	AssertionResults []AssertionResult `json:"assertion_results,omitempty"`
+}
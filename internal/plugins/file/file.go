@@ -0,0 +1,645 @@
+package file
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+	"go.temporal.io/sdk/activity"
+)
+
+const (
+	defaultTimeout   = 30 * time.Second
+	defaultAlgorithm = "sha256"
+	defaultFileMode  = 0o644
+	// read keeps at most this many bytes of content; the checksum still covers the whole file
+	maxContentBytes = 10 << 20
+	// list stops after this many entries
+	maxEntries = 10000
+	// How often read, list and stat look for the path while waiting
+	pollInterval = 250 * time.Millisecond
+)
+
+// Auto-register the plugin when the package is imported
+func init() {
+	plugins.RegisterPlugin(&FilePlugin{})
+}
+
+// GetType returns the plugin type identifier
+func (fp *FilePlugin) GetType() string {
+	return "file"
+}
+
+// Activity runs a filesystem action on the worker and checks the result
+func (fp *FilePlugin) Activity(ctx context.Context, p map[string]interface{}) (interface{}, error) {
+	logger := activity.GetLogger(ctx)
+
+	// Parse configuration from parameters
+	configData, ok := p["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid config format")
+	}
+
+	config := &FileConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		return nil, fmt.Errorf("failed to parse file config: %w", err)
+	}
+
+	timeout := defaultTimeout
+	if config.Timeout != "" {
+		parsed, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", config.Timeout, err)
+		}
+		timeout = parsed
+	}
+
+	// Convert state to map[string]interface{} for template processing
+	state := make(map[string]interface{})
+	if stateStr, ok := p["state"].(map[string]string); ok {
+		for k, v := range stateStr {
+			state[k] = v
+		}
+	} else if stateInt, ok := p["state"].(map[string]interface{}); ok {
+		state = stateInt
+	}
+
+	// Extract env secrets from params (for {{ .env.* }} template resolution)
+	env := make(map[string]string)
+	if envData, ok := p["env"].(map[string]interface{}); ok {
+		for k, v := range envData {
+			if strVal, ok := v.(string); ok {
+				env[k] = strVal
+			}
+		}
+	} else if envData, ok := p["env"].(map[string]string); ok {
+		env = envData
+	}
+
+	if err := applyVariableReplacement(config, state, env); err != nil {
+		return nil, fmt.Errorf("variable replacement failed: %w", err)
+	}
+
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	runID := ""
+	if runData, ok := p["run"].(map[string]interface{}); ok {
+		runID, _ = runData["id"].(string)
+	}
+	roots, err := loadRoots(runID)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Executing file plugin", "action", config.Action, "path", config.Path)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	response, err := execute(ctx, roots, config)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := resultSubject(response)
+	if err != nil {
+		return nil, err
+	}
+
+	assertionResults, err := processAssertions(p, response, subject, state, env)
+	if err != nil {
+		return nil, err
+	}
+	if failure := assertions.Summary(assertionResults); failure != "" {
+		return nil, fmt.Errorf("%s", failure)
+	}
+
+	saved := make(map[string]string)
+	if err := saves.Process(p, subject, saved); err != nil {
+		return nil, err
+	}
+
+	logger.Info("File step completed", "action", config.Action, "path", response.Path, "duration", response.Duration)
+
+	return &ActivityResponse{
+		Response:         response,
+		Saved:            saved,
+		AssertionResults: assertionResults,
+	}, nil
+}
+
+// execute runs the configured action
+func execute(ctx context.Context, roots []string, config *FileConfig) (*FileResponse, error) {
+	start := time.Now()
+
+	path, err := resolvePath(roots, config.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var response *FileResponse
+	switch config.Action {
+	case ActionRead:
+		if err := waitFor(ctx, config.Wait, func() bool { return exists(path) }); err != nil {
+			return nil, fmt.Errorf("%s did not appear within %s", path, config.Wait)
+		}
+		response, err = readFile(path, config.Algorithm)
+	case ActionWrite:
+		response, err = writeFile(path, config)
+	case ActionDelete:
+		response, err = deletePath(roots, path, config.Recursive)
+	case ActionMkdir:
+		if err := os.MkdirAll(path, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", path, err)
+		}
+		response, err = stat(path)
+	case ActionList:
+		var entries []Entry
+		found := func() bool {
+			entries, err = listDir(path, config.Pattern, config.Recursive)
+			return err == nil && len(entries) > 0
+		}
+		if waitErr := waitFor(ctx, config.Wait, found); waitErr != nil && config.Wait != "" {
+			return nil, fmt.Errorf("no entries in %s matched within %s", path, config.Wait)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if response, err = stat(path); err == nil {
+			response.Entries = entries
+		}
+	case ActionStat:
+		if err := waitFor(ctx, config.Wait, func() bool { return exists(path) }); err != nil {
+			return nil, fmt.Errorf("%s did not appear within %s", path, config.Wait)
+		}
+		response, err = stat(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response.Action = config.Action
+	response.Duration = time.Since(start).String()
+	return response, nil
+}
+
+// waitFor polls ready until it returns true or wait runs out. Without a wait it
+// checks once and never fails, leaving the action to report a missing path.
+func waitFor(ctx context.Context, wait string, ready func() bool) error {
+	if ready() || wait == "" {
+		return nil
+	}
+	duration, _ := time.ParseDuration(wait)
+	deadline := time.NewTimer(duration)
+	defer deadline.Stop()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline.C:
+			return fmt.Errorf("timed out")
+		case <-ticker.C:
+			if ready() {
+				return nil
+			}
+		}
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// stat describes path, with Exists false when it is missing
+func stat(path string) (*FileResponse, error) {
+	response := &FileResponse{Path: path}
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return response, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	response.Exists = true
+	response.Type = "file"
+	if info.IsDir() {
+		response.Type = "dir"
+	} else {
+		response.Size = info.Size()
+	}
+	response.Mode = fmt.Sprintf("%04o", info.Mode().Perm())
+	response.ModTime = info.ModTime().UTC().Format(time.RFC3339)
+	return response, nil
+}
+
+// readFile reads the start of the file and checksums all of it in one pass
+func readFile(path, algorithm string) (*FileResponse, error) {
+	response, err := stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !response.Exists {
+		return nil, fmt.Errorf("%s does not exist", path)
+	}
+	if response.Type == "dir" {
+		return nil, fmt.Errorf("%s is a directory; use action list", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	h := newHash(algorithm)
+	content := &prefixWriter{limit: maxContentBytes}
+	size, err := io.Copy(io.MultiWriter(h, content), f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	response.Size = size
+	response.Truncated = size > int64(len(content.data))
+	if utf8.Valid(content.data) {
+		response.Content = string(content.data)
+	} else {
+		response.Content = base64.StdEncoding.EncodeToString(content.data)
+		response.Encoding = "base64"
+	}
+	response.Algorithm = algorithmName(algorithm)
+	response.Checksum = hex.EncodeToString(h.Sum(nil))
+	return response, nil
+}
+
+// writeFile replaces the file's content, creating missing parent directories
+func writeFile(path string, config *FileConfig) (*FileResponse, error) {
+	data := []byte(config.Content)
+	if config.Encoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(config.Content))
+		if err != nil {
+			return nil, fmt.Errorf("content is not valid base64: %w", err)
+		}
+		data = decoded
+	}
+
+	mode := os.FileMode(defaultFileMode)
+	if config.Mode != "" {
+		parsed, _ := strconv.ParseUint(config.Mode, 8, 32)
+		mode = os.FileMode(parsed)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, data, mode); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	// WriteFile leaves the mode of an existing file alone
+	if config.Mode != "" {
+		if err := os.Chmod(path, mode); err != nil {
+			return nil, fmt.Errorf("failed to set mode on %s: %w", path, err)
+		}
+	}
+
+	response, err := stat(path)
+	if err != nil {
+		return nil, err
+	}
+	h := newHash(config.Algorithm)
+	h.Write(data)
+	response.Algorithm = algorithmName(config.Algorithm)
+	response.Checksum = hex.EncodeToString(h.Sum(nil))
+	return response, nil
+}
+
+// deletePath removes path. Missing paths aren't an error, so cleanup steps can
+// run more than once.
+func deletePath(roots []string, path string, recursive bool) (*FileResponse, error) {
+	for _, root := range roots {
+		if path == root {
+			return nil, fmt.Errorf("%s is an allowed root and can't be deleted", path)
+		}
+	}
+
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &FileResponse{Path: path}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	if info.IsDir() && recursive {
+		err = os.RemoveAll(path)
+	} else {
+		err = os.Remove(path)
+	}
+	if err != nil {
+		if info.IsDir() && !recursive {
+			return nil, fmt.Errorf("failed to delete %s: %w (set recursive: true to delete a directory with its contents)", path, err)
+		}
+		return nil, fmt.Errorf("failed to delete %s: %w", path, err)
+	}
+	return &FileResponse{Path: path, Deleted: true}, nil
+}
+
+// listDir returns the entries of dir whose names match pattern, descending into
+// subdirectories when recursive is set
+func listDir(dir, pattern string, recursive bool) ([]Entry, error) {
+	if pattern == "" {
+		pattern = "*"
+	}
+
+	entries := []Entry{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		if matched, _ := filepath.Match(pattern, d.Name()); matched {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			rel, _ := filepath.Rel(dir, path)
+			entry := Entry{
+				Name:    d.Name(),
+				Path:    filepath.ToSlash(rel),
+				Type:    "file",
+				ModTime: info.ModTime().UTC().Format(time.RFC3339),
+			}
+			switch {
+			case d.IsDir():
+				entry.Type = "dir"
+			case d.Type()&fs.ModeSymlink != 0:
+				entry.Type = "symlink"
+			default:
+				entry.Size = info.Size()
+			}
+			entries = append(entries, entry)
+			if len(entries) >= maxEntries {
+				return fs.SkipAll
+			}
+		}
+
+		if d.IsDir() && !recursive {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s does not exist", dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries, nil
+}
+
+// prefixWriter keeps the first limit bytes written to it
+type prefixWriter struct {
+	data  []byte
+	limit int
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	if room := w.limit - len(w.data); room > 0 {
+		if len(p) > room {
+			w.data = append(w.data, p[:room]...)
+		} else {
+			w.data = append(w.data, p...)
+		}
+	}
+	return len(p), nil
+}
+
+func newHash(algorithm string) hash.Hash {
+	switch algorithmName(algorithm) {
+	case "md5":
+		return md5.New()
+	case "sha1":
+		return sha1.New()
+	case "sha512":
+		return sha512.New()
+	default:
+		return sha256.New()
+	}
+}
+
+func algorithmName(algorithm string) string {
+	if algorithm == "" {
+		return defaultAlgorithm
+	}
+	return strings.ToLower(algorithm)
+}
+
+// resultSubject is what assertions with a path and saves run against. Content
+// holding a JSON document is also decoded into json.
+func resultSubject(response *FileResponse) (map[string]interface{}, error) {
+	normalized, err := assertions.Normalize(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare file result: %w", err)
+	}
+	subject, _ := normalized.(map[string]interface{})
+	if response.Content != "" && response.Encoding == "" && !response.Truncated {
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(response.Content), &decoded); err == nil {
+			subject["json"] = decoded
+		}
+	}
+	return subject, nil
+}
+
+// processAssertions evaluates all assertions and returns their results.
+// Shared assertions without a path check the content.
+func processAssertions(p map[string]interface{}, response *FileResponse, subject map[string]interface{}, state map[string]interface{}, env map[string]string) ([]AssertionResult, error) {
+	context := dsl.TemplateContext{Runtime: state, Env: env}
+	return assertions.Process(p, subject, context, func(assertionMap map[string]interface{}, result *AssertionResult) bool {
+		return evaluateAssertion(response, subject, assertionMap, result)
+	})
+}
+
+// evaluateAssertion evaluates the file plugin's own assertion types
+func evaluateAssertion(response *FileResponse, subject map[string]interface{}, assertionMap map[string]interface{}, result *AssertionResult) bool {
+	expected := result.Expected
+	switch result.Type {
+	case AssertionTypeChecksum:
+		result.Actual = response.Checksum
+		expectedStr, _ := expected.(string)
+		switch {
+		case response.Checksum == "":
+			result.Message = fmt.Sprintf("no checksum for action %s (use read or write)", response.Action)
+		case !strings.EqualFold(strings.TrimSpace(expectedStr), response.Checksum):
+			result.Message = fmt.Sprintf("expected %s checksum %v, got %s", response.Algorithm, expected, response.Checksum)
+		default:
+			result.Passed = true
+		}
+
+	case AssertionTypeEntryCount:
+		result.Actual = len(response.Entries)
+		if !assertions.Equal(float64(len(response.Entries)), expected) {
+			result.Message = fmt.Sprintf("expected %v entries, got %d", expected, len(response.Entries))
+		} else {
+			result.Passed = true
+		}
+
+	default:
+		if _, hasPath := assertionMap["path"]; hasPath {
+			*result = assertions.Evaluate(assertionMap, subject, expected)
+		} else {
+			*result = assertions.Evaluate(assertionMap, response.Content, expected)
+		}
+	}
+	return true
+}
+
+// validateConfig checks the action has what it needs once templates are rendered
+func validateConfig(config *FileConfig) error {
+	if config.Path == "" {
+		return fmt.Errorf("path is required")
+	}
+
+	switch algorithmName(config.Algorithm) {
+	case "md5", "sha1", "sha256", "sha512":
+	default:
+		return fmt.Errorf("algorithm must be md5, sha1, sha256 or sha512, got %q", config.Algorithm)
+	}
+	switch config.Encoding {
+	case "", "text", "base64":
+	default:
+		return fmt.Errorf("encoding must be text or base64, got %q", config.Encoding)
+	}
+	if config.Mode != "" {
+		if mode, err := strconv.ParseUint(config.Mode, 8, 32); err != nil || mode > 0o777 {
+			return fmt.Errorf("mode must be octal permissions like \"0644\", got %q", config.Mode)
+		}
+	}
+	if config.Pattern != "" {
+		if _, err := filepath.Match(config.Pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", config.Pattern, err)
+		}
+	}
+	if config.Wait != "" {
+		if wait, err := time.ParseDuration(config.Wait); err != nil || wait <= 0 {
+			return fmt.Errorf("invalid wait %q: must be a positive duration", config.Wait)
+		}
+	}
+
+	switch config.Action {
+	case ActionRead, ActionList, ActionStat:
+	case ActionWrite, ActionDelete, ActionMkdir:
+		if config.Wait != "" {
+			return fmt.Errorf("wait can't be used with action %s", config.Action)
+		}
+	case "":
+		return fmt.Errorf("action is required")
+	default:
+		return fmt.Errorf("action must be read, write, delete, mkdir, list or stat, got %q", config.Action)
+	}
+
+	return nil
+}
+
+// applyVariableReplacement processes templates in the path, content and pattern
+func applyVariableReplacement(config *FileConfig, state map[string]interface{}, env map[string]string) error {
+	context := dsl.TemplateContext{
+		Runtime: state,
+		Env:     env,
+	}
+
+	fields := map[string]*string{
+		"path":    &config.Path,
+		"content": &config.Content,
+		"pattern": &config.Pattern,
+	}
+	for name, value := range fields {
+		if *value == "" {
+			continue
+		}
+		processed, err := dsl.ProcessTemplate(*value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process %s template: %w", name, err)
+		}
+		*value = processed
+	}
+
+	return nil
+}
+
+// parseConfig converts map[string]interface{} to FileConfig
+func parseConfig(configData map[string]interface{}, config *FileConfig) error {
+	stringFields := map[string]*string{
+		"action":    &config.Action,
+		"path":      &config.Path,
+		"encoding":  &config.Encoding,
+		"algorithm": &config.Algorithm,
+		"pattern":   &config.Pattern,
+		"wait":      &config.Wait,
+		"timeout":   &config.Timeout,
+	}
+	for key, target := range stringFields {
+		if v, ok := configData[key].(string); ok {
+			*target = v
+		}
+	}
+
+	// Objects and arrays are written as JSON
+	switch content := configData["content"].(type) {
+	case nil:
+	case string:
+		config.Content = content
+	case map[string]interface{}, []interface{}:
+		encoded, err := json.Marshal(content)
+		if err != nil {
+			return fmt.Errorf("failed to encode content: %w", err)
+		}
+		config.Content = string(encoded)
+	default:
+		config.Content = fmt.Sprint(content)
+	}
+
+	// An unquoted 0644 may reach the plugin as a number in either base, so only strings are accepted
+	switch mode := configData["mode"].(type) {
+	case nil:
+	case string:
+		config.Mode = mode
+	default:
+		return fmt.Errorf("mode must be a quoted octal string like \"0644\", got %T", mode)
+	}
+
+	if v, ok := configData["recursive"].(bool); ok {
+		config.Recursive = v
+	}
+
+	return nil
+}
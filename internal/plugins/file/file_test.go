@@ -0,0 +1,230 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+)
+
+func run(t *testing.T, roots []string, config *FileConfig) *FileResponse {
+	t.Helper()
+	if err := validateConfig(config); err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+	response, err := execute(context.Background(), roots, config)
+	if err != nil {
+		t.Fatalf("%s failed: %v", config.Action, err)
+	}
+	return response
+}
+
+func TestWriteReadDelete(t *testing.T) {
+	root := t.TempDir()
+	roots := []string{root}
+
+	written := run(t, roots, &FileConfig{Action: ActionWrite, Path: "exports/report.json", Content: `{"rows":3,"status":"done"}`, Mode: "0600"})
+	if written.Path != filepath.Join(root, "exports", "report.json") || written.Mode != "0600" || written.Size != 26 ||
+		written.Checksum != "99b155404b19358b636ccfe91aca018b0e40a65470d3f16a34bc46d8fdcf1887" {
+		t.Errorf("unexpected write response %+v", written)
+	}
+	read := run(t, roots, &FileConfig{Action: ActionRead, Path: "exports/report.json"})
+	if read.Content != `{"rows":3,"status":"done"}` || read.Checksum != written.Checksum || read.Algorithm != "sha256" {
+		t.Errorf("unexpected read response %+v", read)
+	}
+
+	subject, err := resultSubject(read)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := map[string]interface{}{
+		"assertions": []interface{}{
+			map[string]interface{}{"type": "checksum", "expected": strings.ToUpper(written.Checksum)},
+			map[string]interface{}{"type": "regex", "expected": `"status":"done"`},
+			map[string]interface{}{"type": "equals", "path": ".json.rows", "expected": 3.0},
+			map[string]interface{}{"type": "equals", "path": ".size", "expected": 26.0},
+		},
+		"save": []interface{}{
+			map[string]interface{}{"json_path": ".json.status", "as": "status"},
+			map[string]interface{}{"json_path": ".checksum", "as": "checksum"},
+		},
+	}
+	if results, err := processAssertions(p, read, subject, map[string]interface{}{}, nil); err != nil || assertions.Summary(results) != "" {
+		t.Fatalf("assertions failed: %v %+v", err, results)
+	}
+	saved := make(map[string]string)
+	if err := saves.Process(p, subject, saved); err != nil || saved["status"] != "done" || saved["checksum"] != read.Checksum {
+		t.Errorf("unexpected saves %v: %v", saved, err)
+	}
+
+	md5 := run(t, roots, &FileConfig{Action: ActionRead, Path: "exports/report.json", Algorithm: "md5"})
+	if md5.Algorithm != "md5" || len(md5.Checksum) != 32 {
+		t.Errorf("unexpected md5 checksum %+v", md5)
+	}
+
+	// Deleting a directory needs recursive, and deleting twice is fine
+	dir := filepath.Join(root, "exports")
+	if _, err := execute(context.Background(), roots, &FileConfig{Action: ActionDelete, Path: dir}); err == nil || !strings.Contains(err.Error(), "recursive: true") {
+		t.Errorf("expected a recursive hint, got %v", err)
+	}
+	if deleted := run(t, roots, &FileConfig{Action: ActionDelete, Path: dir, Recursive: true}); !deleted.Deleted {
+		t.Errorf("expected the directory to be deleted: %+v", deleted)
+	}
+	if deleted := run(t, roots, &FileConfig{Action: ActionDelete, Path: dir, Recursive: true}); deleted.Deleted {
+		t.Errorf("expected nothing to delete: %+v", deleted)
+	}
+	if stat := run(t, roots, &FileConfig{Action: ActionStat, Path: dir}); stat.Exists {
+		t.Errorf("expected %s to be gone", dir)
+	}
+	if _, err := execute(context.Background(), roots, &FileConfig{Action: ActionDelete, Path: root, Recursive: true}); err == nil {
+		t.Error("expected deleting a root to fail")
+	}
+}
+
+func TestBinaryContent(t *testing.T) {
+	roots := []string{t.TempDir()}
+	run(t, roots, &FileConfig{Action: ActionWrite, Path: "logo.bin", Content: "iVBORw0KGgo=", Encoding: "base64"})
+
+	read := run(t, roots, &FileConfig{Action: ActionRead, Path: "logo.bin"})
+	if read.Encoding != "base64" || read.Content != "iVBORw0KGgo=" || read.Size != 8 {
+		t.Errorf("unexpected binary read %+v", read)
+	}
+}
+
+func TestListAndWait(t *testing.T) {
+	root := t.TempDir()
+	roots := []string{root}
+	for _, name := range []string{"a.csv", "b.txt", "nested/c.csv"} {
+		run(t, roots, &FileConfig{Action: ActionWrite, Path: name, Content: "x"})
+	}
+
+	list := run(t, roots, &FileConfig{Action: ActionList, Path: ".", Pattern: "*.csv"})
+	if len(list.Entries) != 1 || list.Entries[0].Path != "a.csv" {
+		t.Errorf("unexpected entries %+v", list.Entries)
+	}
+	list = run(t, roots, &FileConfig{Action: ActionList, Path: root, Pattern: "*.csv", Recursive: true})
+	if len(list.Entries) != 2 || list.Entries[1].Path != "nested/c.csv" {
+		t.Errorf("unexpected recursive entries %+v", list.Entries)
+	}
+	subject, _ := resultSubject(list)
+	if results, err := processAssertions(map[string]interface{}{
+		"assertions": []interface{}{map[string]interface{}{"type": "entry_count", "expected": 2.0}},
+	}, list, subject, map[string]interface{}{}, nil); err != nil || assertions.Summary(results) != "" {
+		t.Errorf("entry_count failed: %v %+v", err, results)
+	}
+
+	// An export job that finishes while the step waits
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_ = os.WriteFile(filepath.Join(root, "late.json"), []byte(`{}`), 0o644)
+	}()
+	read := run(t, roots, &FileConfig{Action: ActionRead, Path: "late.json", Wait: "5s"})
+	if read.Content != `{}` {
+		t.Errorf("unexpected late read %+v", read)
+	}
+
+	_, err := execute(context.Background(), roots, &FileConfig{Action: ActionList, Path: ".", Pattern: "*.xml", Wait: "300ms"})
+	if err == nil || !strings.Contains(err.Error(), "matched within 300ms") {
+		t.Errorf("expected a wait timeout, got %v", err)
+	}
+	_, err = execute(context.Background(), roots, &FileConfig{Action: ActionRead, Path: "missing.txt"})
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("expected a missing file error, got %v", err)
+	}
+}
+
+func TestRoots(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+
+	t.Setenv(RootsEnv, root+string(os.PathListSeparator)+"relative")
+	if _, err := loadRoots("run-1"); err == nil || !strings.Contains(err.Error(), "absolute") {
+		t.Errorf("expected relative roots to be rejected, got %v", err)
+	}
+
+	// Without roots, each run gets its own directory, not the whole temp dir
+	t.Setenv("TMPDIR", t.TempDir())
+	t.Setenv(RootsEnv, "")
+	runRoots, err := loadRoots("run-1")
+	if err != nil || len(runRoots) != 1 || filepath.Base(runRoots[0]) != "run-1" {
+		t.Fatalf("expected the run's own directory as the default root, got %v %v", runRoots, err)
+	}
+	if info, err := os.Stat(runRoots[0]); err != nil || !info.IsDir() {
+		t.Fatalf("run directory not created: %v", err)
+	}
+	if _, err := resolvePath(runRoots, "../run-2/secret.txt"); err == nil {
+		t.Error("expected another run's directory to be rejected")
+	}
+	if escaped, err := loadRoots("../.."); err != nil || filepath.Dir(filepath.Dir(escaped[0])) != filepath.Dir(filepath.Dir(runRoots[0])) {
+		t.Errorf("expected run IDs to stay inside the runs directory, got %v %v", escaped, err)
+	}
+
+	roots := []string{root}
+	for _, path := range []string{"../escape.txt", filepath.Join(outside, "x.txt"), "/etc/passwd"} {
+		if _, err := resolvePath(roots, path); err == nil || !strings.Contains(err.Error(), "outside the directories") {
+			t.Errorf("expected %s to be rejected, got %v", path, err)
+		}
+	}
+
+	// Symlinks can't be used to leave the roots
+	if err := os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	if _, err := resolvePath(roots, "link/new/file.txt"); err == nil {
+		t.Error("expected a path through a symlink out of the root to be rejected")
+	}
+
+	// The path returned has its symlinks followed, so it is the one checked
+	if err := os.Mkdir(filepath.Join(root, "real"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(root, "real"), filepath.Join(root, "alias")); err != nil {
+		t.Fatal(err)
+	}
+	resolved, err := resolvePath([]string{root}, "alias/report.json")
+	if err != nil || resolved != filepath.Join(root, "real", "report.json") {
+		t.Errorf("resolvePath = %q, %v", resolved, err)
+	}
+}
+
+func TestValidateAndParseConfig(t *testing.T) {
+	config := &FileConfig{}
+	if err := parseConfig(map[string]interface{}{
+		"action":  "write",
+		"path":    "out/{{ run_id }}.json",
+		"content": map[string]interface{}{"id": "{{ run_id }}"},
+	}, config); err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if err := applyVariableReplacement(config, map[string]interface{}{"run_id": "r1"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if config.Path != "out/r1.json" || config.Content != `{"id":"r1"}` {
+		t.Errorf("unexpected config %+v", config)
+	}
+	if err := parseConfig(map[string]interface{}{"mode": 644.0}, &FileConfig{}); err == nil || !strings.Contains(err.Error(), "quoted octal") {
+		t.Errorf("expected a mode error, got %v", err)
+	}
+
+	invalid := []struct {
+		config  FileConfig
+		message string
+	}{
+		{FileConfig{Action: ActionRead}, "path is required"},
+		{FileConfig{Action: "copy", Path: "a"}, "action must be"},
+		{FileConfig{Action: ActionRead, Path: "a", Algorithm: "crc32"}, "algorithm must be"},
+		{FileConfig{Action: ActionWrite, Path: "a", Mode: "0999"}, "mode must be octal"},
+		{FileConfig{Action: ActionWrite, Path: "a", Wait: "1s"}, "wait can't be used"},
+		{FileConfig{Action: ActionList, Path: "a", Pattern: "["}, "invalid pattern"},
+	}
+	for _, tc := range invalid {
+		if err := validateConfig(&tc.config); err == nil || !strings.Contains(err.Error(), tc.message) {
+			t.Errorf("expected %q, got %v", tc.message, err)
+		}
+	}
+}
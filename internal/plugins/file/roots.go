@@ -0,0 +1,29 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rocketship-ai/rocketship/internal/confine"
+)
+
+// RootsEnv lists the directories file steps may touch on this worker, see
+// confine.RootsEnv
+const RootsEnv = confine.RootsEnv
+
+// loadRoots returns the allowed directories with symlinks resolved, in the order
+// they were configured, or the run's own directory when none are
+func loadRoots(runID string) ([]string, error) {
+	return confine.WorkerRoots(runID)
+}
+
+// resolvePath makes path absolute against the first root and checks it stays
+// inside one of the roots once symlinks are followed. It returns the path with
+// symlinks followed, so the path used is the one that was checked.
+func resolvePath(roots []string, path string) (string, error) {
+	resolved, err := confine.Resolve(roots, path)
+	if errors.Is(err, confine.ErrOutside) {
+		return "", fmt.Errorf("path %s is outside the directories file steps may use on this worker (see %s)", path, RootsEnv)
+	}
+	return resolved, err
+}
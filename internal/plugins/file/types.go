@@ -0,0 +1,87 @@
+package file
+
+import "github.com/rocketship-ai/rocketship/internal/assertions"
+
+// FilePlugin represents a filesystem test step
+type FilePlugin struct {
+	Name   string     `json:"name" yaml:"name"`
+	Plugin string     `json:"plugin" yaml:"plugin"`
+	Config FileConfig `json:"config" yaml:"config"`
+}
+
+// FileConfig selects the action and the path on the worker it runs against
+type FileConfig struct {
+	Action string `json:"action" yaml:"action"` // read, write, delete, mkdir, list or stat
+	Path   string `json:"path" yaml:"path"`     // Relative paths are resolved against the first allowed root
+
+	// write
+	Content  string `json:"content,omitempty" yaml:"content,omitempty"`
+	Encoding string `json:"encoding,omitempty" yaml:"encoding,omitempty"` // text (default) or base64
+	Mode     string `json:"mode,omitempty" yaml:"mode,omitempty"`         // Octal permissions, e.g. "0600"
+
+	// read and write
+	Algorithm string `json:"algorithm,omitempty" yaml:"algorithm,omitempty"` // Checksum algorithm: md5, sha1, sha256 (default) or sha512
+
+	// list and delete
+	Pattern   string `json:"pattern,omitempty" yaml:"pattern,omitempty"` // list: only names matching this glob
+	Recursive bool   `json:"recursive,omitempty" yaml:"recursive,omitempty"`
+
+	// read, list and stat
+	Wait string `json:"wait,omitempty" yaml:"wait,omitempty"` // How long to wait for the path (or a matching entry) to appear
+
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"` // Overall step timeout (defaults to 30s)
+}
+
+// Actions supported by the file plugin
+const (
+	ActionRead   = "read"
+	ActionWrite  = "write"
+	ActionDelete = "delete"
+	ActionMkdir  = "mkdir"
+	ActionList   = "list"
+	ActionStat   = "stat"
+)
+
+// Assertion types supported by the file plugin in addition to the shared ones
+const (
+	AssertionTypeChecksum   = "checksum"
+	AssertionTypeEntryCount = "entry_count"
+)
+
+// Entry is a file or directory found by list
+type Entry struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"` // Relative to the listed directory
+	Type    string `json:"type"` // file, dir or symlink
+	Size    int64  `json:"size"`
+	ModTime string `json:"mod_time"`
+}
+
+// FileResponse describes the path after the action
+type FileResponse struct {
+	Action    string  `json:"action"`
+	Path      string  `json:"path"` // Absolute path on the worker
+	Exists    bool    `json:"exists"`
+	Type      string  `json:"type,omitempty"` // file or dir
+	Size      int64   `json:"size"`
+	Mode      string  `json:"mode,omitempty"` // Octal permissions
+	ModTime   string  `json:"mod_time,omitempty"`
+	Content   string  `json:"content,omitempty"`   // read: the file's content
+	Encoding  string  `json:"encoding,omitempty"`  // read: base64 when the content isn't valid UTF-8
+	Truncated bool    `json:"truncated,omitempty"` // read: content holds only the start of the file
+	Algorithm string  `json:"algorithm,omitempty"` // read and write: checksum algorithm
+	Checksum  string  `json:"checksum,omitempty"`  // read and write: hex digest of the whole file
+	Entries   []Entry `json:"entries"`             // list: entries in path order
+	Deleted   bool    `json:"deleted,omitempty"`   // delete: whether the path existed
+	Duration  string  `json:"duration"`
+}
+
+// AssertionResult represents a single assertion result for UI display
+type AssertionResult = assertions.Result
+
+// ActivityResponse represents the complete activity response
+type ActivityResponse struct {
+	Response         *FileResponse     `json:"response"`
+	Saved            map[string]string `json:"saved"`
+	AssertionResults []AssertionResult `json:"assertion_results,omitempty"`
+}
@@ -0,0 +1,405 @@
+package firestore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v4"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+)
+
+const (
+	productionHost  = "https://firestore.googleapis.com"
+	datastoreScope  = "https://www.googleapis.com/auth/datastore"
+	defaultTokenURI = "https://oauth2.googleapis.com/token"
+
+	// emulatorEnv is read when emulator_host isn't set, as the Firebase SDKs do
+	emulatorEnv = "FIRESTORE_EMULATOR_HOST"
+	// credentialsEnv is read when neither access_token nor credentials are set
+	credentialsEnv = "GOOGLE_APPLICATION_CREDENTIALS"
+)
+
+// client calls the Firestore v1 REST API, or the emulator's copy of it
+type client struct {
+	base     string // .../v1/projects/{project}/databases/{database}/documents
+	name     string // projects/{project}/databases/{database}/documents
+	emulator bool
+	token    string
+	account  *serviceAccount
+	http     *http.Client
+}
+
+// serviceAccount is the part of a service account key needed to get access tokens
+type serviceAccount struct {
+	ClientEmail  string `json:"client_email"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// cachedToken is an access token minted for a service account
+type cachedToken struct {
+	token   string
+	expires time.Time
+}
+
+// tokens caches access tokens by service account, so steps don't sign in each time
+var tokens = struct {
+	sync.Mutex
+	byAccount map[string]cachedToken
+}{byAccount: make(map[string]cachedToken)}
+
+// newClient builds a client for the configured database. Requests go through the egress policy.
+func newClient(config *FirestoreConfig, policy *egress.Policy) (*client, error) {
+	database := config.Database
+	if database == "" {
+		database = "(default)"
+	}
+	name := fmt.Sprintf("projects/%s/databases/%s/documents", config.Project, database)
+
+	c := &client{
+		name: name,
+		http: &http.Client{Transport: policy.Transport()},
+	}
+
+	emulatorHost := config.EmulatorHost
+	if emulatorHost == "" {
+		emulatorHost = os.Getenv(emulatorEnv)
+	}
+	if emulatorHost != "" {
+		host := emulatorHost
+		if !strings.Contains(host, "://") {
+			host = "http://" + host
+		}
+		parsed, err := url.Parse(host)
+		if err != nil || parsed.Host == "" {
+			return nil, fmt.Errorf("invalid emulator_host %q: must be host:port, e.g. localhost:8080", emulatorHost)
+		}
+		c.base = strings.TrimRight(parsed.String(), "/") + "/v1/" + name
+		c.emulator = true
+		return c, nil
+	}
+
+	c.base = productionHost + "/v1/" + name
+	switch {
+	case config.AccessToken != "":
+		c.token = config.AccessToken
+	case config.Credentials != "":
+		account, err := parseServiceAccount([]byte(config.Credentials))
+		if err != nil {
+			return nil, err
+		}
+		c.account = account
+	default:
+		path := config.CredentialsFile
+		if path == "" {
+			path = os.Getenv(credentialsEnv)
+		}
+		if path == "" {
+			return nil, fmt.Errorf("access_token, credentials or credentials_file is required unless emulator_host is set")
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read credentials file: %w", err)
+		}
+		account, err := parseServiceAccount(data)
+		if err != nil {
+			return nil, err
+		}
+		c.account = account
+	}
+	return c, nil
+}
+
+// parseServiceAccount reads a service account key file
+func parseServiceAccount(data []byte) (*serviceAccount, error) {
+	var account serviceAccount
+	if err := json.Unmarshal(data, &account); err != nil {
+		return nil, fmt.Errorf("invalid service account credentials: %w", err)
+	}
+	if account.ClientEmail == "" || account.PrivateKey == "" {
+		return nil, fmt.Errorf("invalid service account credentials: client_email and private_key are required")
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = defaultTokenURI
+	}
+	return &account, nil
+}
+
+// authorization returns the Authorization header value for a request
+func (c *client) authorization(ctx context.Context) (string, error) {
+	if c.emulator {
+		// The emulator treats "owner" as an admin that bypasses security rules
+		return "Bearer owner", nil
+	}
+	if c.account == nil {
+		return "Bearer " + c.token, nil
+	}
+
+	tokens.Lock()
+	cached, ok := tokens.byAccount[c.account.ClientEmail]
+	tokens.Unlock()
+	if ok && time.Until(cached.expires) > time.Minute {
+		return "Bearer " + cached.token, nil
+	}
+
+	token, err := c.fetchToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	tokens.Lock()
+	tokens.byAccount[c.account.ClientEmail] = token
+	tokens.Unlock()
+	return "Bearer " + token.token, nil
+}
+
+// fetchToken exchanges a signed assertion for an access token (RFC 7523)
+func (c *client) fetchToken(ctx context.Context) (cachedToken, error) {
+	key, err := gojwt.ParseRSAPrivateKeyFromPEM([]byte(c.account.PrivateKey))
+	if err != nil {
+		return cachedToken{}, fmt.Errorf("invalid service account private_key: %w", err)
+	}
+	now := time.Now()
+	assertion := gojwt.NewWithClaims(gojwt.SigningMethodRS256, gojwt.MapClaims{
+		"iss":   c.account.ClientEmail,
+		"scope": datastoreScope,
+		"aud":   c.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if c.account.PrivateKeyID != "" {
+		assertion.Header["kid"] = c.account.PrivateKeyID
+	}
+	signed, err := assertion.SignedString(key)
+	if err != nil {
+		return cachedToken{}, fmt.Errorf("failed to sign token request: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {signed},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return cachedToken{}, fmt.Errorf("invalid token_uri: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return cachedToken{}, fmt.Errorf("failed to get access token: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		return cachedToken{}, fmt.Errorf("failed to get access token: %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.AccessToken == "" {
+		return cachedToken{}, fmt.Errorf("failed to get access token: no access_token in response")
+	}
+	if body.ExpiresIn <= 0 {
+		body.ExpiresIn = 3600
+	}
+	return cachedToken{token: body.AccessToken, expires: now.Add(time.Duration(body.ExpiresIn) * time.Second)}, nil
+}
+
+// do sends a request and decodes a successful response into out. It returns the
+// status code so callers can treat 404 as an answer rather than a failure.
+func (c *client) do(ctx context.Context, method, path string, query url.Values, request, out interface{}) (int, error) {
+	var body io.Reader
+	if request != nil {
+		payload, err := json.Marshal(request)
+		if err != nil {
+			return 0, err
+		}
+		body = bytes.NewReader(payload)
+	}
+
+	target := c.base + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return 0, err
+	}
+	if request != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	authorization, err := c.authorization(ctx)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("firestore request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, apiError(resp)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to decode firestore response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// apiError reads a Google API error body
+func apiError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+			Status  string `json:"status"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(data, &body) == nil && body.Error.Message != "" {
+		if body.Error.Status != "" {
+			return fmt.Errorf("firestore error: %s: %s", body.Error.Status, body.Error.Message)
+		}
+		return fmt.Errorf("firestore error: %s", body.Error.Message)
+	}
+	message := strings.TrimSpace(string(data))
+	if message == "" {
+		message = resp.Status
+	}
+	return fmt.Errorf("firestore error: %s", message)
+}
+
+// restDocument is a document as the REST API returns it
+type restDocument struct {
+	Name       string                 `json:"name"`
+	Fields     map[string]interface{} `json:"fields"`
+	CreateTime string                 `json:"createTime"`
+	UpdateTime string                 `json:"updateTime"`
+}
+
+// decodeDocument converts a REST document to a Document
+func decodeDocument(doc restDocument) (Document, error) {
+	data, err := decodeFields(doc.Fields)
+	if err != nil {
+		return Document{}, fmt.Errorf("failed to decode %s: %w", doc.Name, err)
+	}
+	path := doc.Name
+	if i := strings.Index(path, "/documents/"); i >= 0 {
+		path = path[i+len("/documents/"):]
+	}
+	return Document{
+		ID:         path[strings.LastIndex(path, "/")+1:],
+		Path:       path,
+		Data:       data,
+		CreateTime: doc.CreateTime,
+		UpdateTime: doc.UpdateTime,
+	}, nil
+}
+
+// get reads a document. A missing document isn't an error.
+func (c *client) get(ctx context.Context, path string) (*FirestoreResponse, error) {
+	var doc restDocument
+	status, err := c.do(ctx, http.MethodGet, "/"+escapePath(path), nil, nil, &doc)
+	if status == http.StatusNotFound {
+		return &FirestoreResponse{Documents: []Document{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return single(doc)
+}
+
+// write creates or replaces a document, or with mask set, updates those fields
+// of an existing one
+func (c *client) write(ctx context.Context, path string, data map[string]interface{}, mask []string) (*FirestoreResponse, error) {
+	fields, err := encodeFields(data)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	if mask != nil {
+		query["updateMask.fieldPaths"] = mask
+		query.Set("currentDocument.exists", "true")
+	}
+
+	var doc restDocument
+	status, err := c.do(ctx, http.MethodPatch, "/"+escapePath(path), query, map[string]interface{}{"fields": fields}, &doc)
+	if status == http.StatusNotFound && mask != nil {
+		return nil, fmt.Errorf("can't update %s: the document does not exist", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return single(doc)
+}
+
+// delete removes a document. Deleting a missing document succeeds.
+func (c *client) delete(ctx context.Context, path string) (*FirestoreResponse, error) {
+	if _, err := c.do(ctx, http.MethodDelete, "/"+escapePath(path), nil, nil, nil); err != nil {
+		return nil, err
+	}
+	return &FirestoreResponse{Documents: []Document{}}, nil
+}
+
+// runQuery runs a structured query against a collection under parent, which is
+// empty for a top-level collection
+func (c *client) runQuery(ctx context.Context, parent string, query map[string]interface{}) (*FirestoreResponse, error) {
+	path := ":runQuery"
+	if parent != "" {
+		path = "/" + escapePath(parent) + path
+	}
+
+	var results []struct {
+		Document *restDocument `json:"document"`
+	}
+	if _, err := c.do(ctx, http.MethodPost, path, nil, map[string]interface{}{"structuredQuery": query}, &results); err != nil {
+		return nil, err
+	}
+
+	response := &FirestoreResponse{Documents: []Document{}}
+	for _, result := range results {
+		// Results without a document only report progress
+		if result.Document == nil {
+			continue
+		}
+		doc, err := decodeDocument(*result.Document)
+		if err != nil {
+			return nil, err
+		}
+		response.Documents = append(response.Documents, doc)
+	}
+	return response, nil
+}
+
+// single builds the response for an action on one existing document
+func single(doc restDocument) (*FirestoreResponse, error) {
+	decoded, err := decodeDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+	return &FirestoreResponse{Exists: true, Data: decoded.Data, Documents: []Document{decoded}}, nil
+}
+
+// escapePath escapes each segment of a document path
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
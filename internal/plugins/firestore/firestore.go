@@ -0,0 +1,491 @@
+package firestore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+	"go.temporal.io/sdk/activity"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// filterOperators maps where operators to the REST API's FieldFilter operators
+var filterOperators = map[string]string{
+	"==":                 "EQUAL",
+	"!=":                 "NOT_EQUAL",
+	"<":                  "LESS_THAN",
+	"<=":                 "LESS_THAN_OR_EQUAL",
+	">":                  "GREATER_THAN",
+	">=":                 "GREATER_THAN_OR_EQUAL",
+	"array-contains":     "ARRAY_CONTAINS",
+	"array-contains-any": "ARRAY_CONTAINS_ANY",
+	"in":                 "IN",
+	"not-in":             "NOT_IN",
+}
+
+// Auto-register the plugin when the package is imported
+func init() {
+	plugins.RegisterPlugin(&FirestorePlugin{})
+}
+
+// GetType returns the plugin type identifier
+func (fp *FirestorePlugin) GetType() string {
+	return "firestore"
+}
+
+// Activity reads, writes or queries Firestore documents and checks the result
+func (fp *FirestorePlugin) Activity(ctx context.Context, p map[string]interface{}) (interface{}, error) {
+	logger := activity.GetLogger(ctx)
+
+	// Parse configuration from parameters
+	configData, ok := p["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid config format")
+	}
+
+	config := &FirestoreConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		return nil, fmt.Errorf("failed to parse firestore config: %w", err)
+	}
+
+	timeout := defaultTimeout
+	if config.Timeout != "" {
+		parsed, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", config.Timeout, err)
+		}
+		timeout = parsed
+	}
+
+	// Convert state to map[string]interface{} for template processing
+	state := make(map[string]interface{})
+	if stateStr, ok := p["state"].(map[string]string); ok {
+		for k, v := range stateStr {
+			state[k] = v
+		}
+	} else if stateInt, ok := p["state"].(map[string]interface{}); ok {
+		state = stateInt
+	}
+
+	// Extract env secrets from params (for {{ .env.* }} template resolution)
+	env := make(map[string]string)
+	if envData, ok := p["env"].(map[string]interface{}); ok {
+		for k, v := range envData {
+			if strVal, ok := v.(string); ok {
+				env[k] = strVal
+			}
+		}
+	} else if envData, ok := p["env"].(map[string]string); ok {
+		env = envData
+	}
+
+	if err := applyVariableReplacement(config, state, env); err != nil {
+		return nil, fmt.Errorf("variable replacement failed: %w", err)
+	}
+
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	policy, err := egress.FromParams(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
+
+	logger.Info("Executing firestore plugin", "action", config.Action, "path", config.Path, "collection", config.Collection)
+
+	c, err := newClient(config, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	response, err := execute(ctx, c, config)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := assertions.Normalize(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare firestore result: %w", err)
+	}
+
+	assertionResults, err := processAssertions(p, response, subject, state, env)
+	if err != nil {
+		return nil, err
+	}
+	if failure := assertions.Summary(assertionResults); failure != "" {
+		return nil, fmt.Errorf("%s", failure)
+	}
+
+	saved := make(map[string]string)
+	if err := saves.Process(p, subject, saved); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Firestore step completed", "action", config.Action, "count", response.Count, "duration", response.Duration)
+
+	return &ActivityResponse{
+		Response:         response,
+		Saved:            saved,
+		AssertionResults: assertionResults,
+	}, nil
+}
+
+// execute runs the configured action
+func execute(ctx context.Context, c *client, config *FirestoreConfig) (*FirestoreResponse, error) {
+	start := time.Now()
+
+	var response *FirestoreResponse
+	var err error
+	switch config.Action {
+	case ActionGet:
+		response, err = c.get(ctx, config.Path)
+	case ActionSet:
+		response, err = c.write(ctx, config.Path, config.Data, nil)
+	case ActionUpdate:
+		response, err = c.write(ctx, config.Path, config.Data, fieldPaths(config.Data))
+	case ActionDelete:
+		response, err = c.delete(ctx, config.Path)
+	case ActionQuery:
+		var query map[string]interface{}
+		query, err = buildQuery(config)
+		if err == nil {
+			response, err = c.runQuery(ctx, config.Path, query)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response.Action = config.Action
+	response.Count = len(response.Documents)
+	response.Duration = time.Since(start).String()
+	return response, nil
+}
+
+// buildQuery converts the query settings to a StructuredQuery. Filters are
+// combined with AND.
+func buildQuery(config *FirestoreConfig) (map[string]interface{}, error) {
+	query := map[string]interface{}{
+		"from": []interface{}{map[string]interface{}{"collectionId": config.Collection}},
+	}
+
+	filters := make([]interface{}, 0, len(config.Where))
+	for _, where := range config.Where {
+		filter, err := buildFilter(where)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	switch len(filters) {
+	case 0:
+	case 1:
+		query["where"] = filters[0]
+	default:
+		query["where"] = map[string]interface{}{
+			"compositeFilter": map[string]interface{}{"op": "AND", "filters": filters},
+		}
+	}
+
+	if len(config.OrderBy) > 0 {
+		orders := make([]interface{}, 0, len(config.OrderBy))
+		for _, order := range config.OrderBy {
+			direction := "ASCENDING"
+			if strings.EqualFold(order.Direction, "desc") {
+				direction = "DESCENDING"
+			}
+			orders = append(orders, map[string]interface{}{
+				"field":     map[string]interface{}{"fieldPath": order.Field},
+				"direction": direction,
+			})
+		}
+		query["orderBy"] = orders
+	}
+
+	if config.Limit > 0 {
+		query["limit"] = config.Limit
+	}
+	return query, nil
+}
+
+// buildFilter converts one where entry. Comparisons with null become the unary
+// IS_NULL and IS_NOT_NULL filters, which is how Firestore expresses them.
+func buildFilter(where WhereFilter) (map[string]interface{}, error) {
+	field := map[string]interface{}{"fieldPath": where.Field}
+	if where.Value == nil && (where.Op == "==" || where.Op == "!=") {
+		op := "IS_NULL"
+		if where.Op == "!=" {
+			op = "IS_NOT_NULL"
+		}
+		return map[string]interface{}{"unaryFilter": map[string]interface{}{"op": op, "field": field}}, nil
+	}
+
+	value, err := encodeValue(where.Value)
+	if err != nil {
+		return nil, fmt.Errorf("where %s: %w", where.Field, err)
+	}
+	return map[string]interface{}{
+		"fieldFilter": map[string]interface{}{"field": field, "op": filterOperators[where.Op], "value": value},
+	}, nil
+}
+
+// processAssertions evaluates all assertions and returns their results
+func processAssertions(p map[string]interface{}, response *FirestoreResponse, subject interface{}, state map[string]interface{}, env map[string]string) ([]AssertionResult, error) {
+	context := dsl.TemplateContext{Runtime: state, Env: env}
+	return assertions.Process(p, subject, context, func(assertionMap map[string]interface{}, result *AssertionResult) bool {
+		return evaluateAssertion(response, assertionMap, result)
+	})
+}
+
+// evaluateAssertion evaluates the firestore plugin's own assertion types
+func evaluateAssertion(response *FirestoreResponse, assertionMap map[string]interface{}, result *AssertionResult) bool {
+	expected := result.Expected
+	switch result.Type {
+	case AssertionTypeDocumentCount:
+		result.Actual = response.Count
+		if !assertions.Equal(float64(response.Count), expected) {
+			result.Message = fmt.Sprintf("expected %v documents, got %d", expected, response.Count)
+		} else {
+			result.Passed = true
+		}
+
+	default:
+		return false
+	}
+	return true
+}
+
+// validateConfig checks the action has what it needs once templates are rendered
+func validateConfig(config *FirestoreConfig) error {
+	if config.Project == "" {
+		return fmt.Errorf("project is required")
+	}
+	if config.Path != "" {
+		for _, segment := range strings.Split(config.Path, "/") {
+			if segment == "" {
+				return fmt.Errorf("invalid path %q: segments must not be empty", config.Path)
+			}
+		}
+	}
+
+	switch config.Action {
+	case ActionGet, ActionSet, ActionUpdate, ActionDelete:
+		if config.Path == "" {
+			return fmt.Errorf("path is required with action %s", config.Action)
+		}
+		if !isDocumentPath(config.Path) {
+			return fmt.Errorf("path %q is a collection: document paths have an even number of segments, e.g. users/alice", config.Path)
+		}
+		if config.Action == ActionUpdate && len(config.Data) == 0 {
+			return fmt.Errorf("data is required with action update")
+		}
+		if config.Action != ActionSet && config.Action != ActionUpdate && config.Data != nil {
+			return fmt.Errorf("data can't be used with action %s", config.Action)
+		}
+	case ActionQuery:
+		if config.Collection == "" {
+			return fmt.Errorf("collection is required with action query")
+		}
+		if strings.Contains(config.Collection, "/") {
+			return fmt.Errorf("collection must be a collection ID; set path to the parent document for subcollections")
+		}
+		if config.Path != "" && !isDocumentPath(config.Path) {
+			return fmt.Errorf("path %q must be the parent document of collection %s", config.Path, config.Collection)
+		}
+		if config.Limit < 0 {
+			return fmt.Errorf("limit must not be negative")
+		}
+		for i, where := range config.Where {
+			if where.Field == "" {
+				return fmt.Errorf("where[%d].field is required", i)
+			}
+			if _, ok := filterOperators[where.Op]; !ok {
+				return fmt.Errorf("where[%d].op must be one of ==, !=, <, <=, >, >=, array-contains, array-contains-any, in or not-in, got %q", i, where.Op)
+			}
+		}
+		for i, order := range config.OrderBy {
+			if order.Field == "" {
+				return fmt.Errorf("order_by[%d].field is required", i)
+			}
+			if order.Direction != "" && !strings.EqualFold(order.Direction, "asc") && !strings.EqualFold(order.Direction, "desc") {
+				return fmt.Errorf("order_by[%d].direction must be asc or desc, got %q", i, order.Direction)
+			}
+		}
+	case "":
+		return fmt.Errorf("action is required")
+	default:
+		return fmt.Errorf("action must be get, set, update, delete or query, got %q", config.Action)
+	}
+
+	if config.Action != ActionQuery && (config.Collection != "" || len(config.Where) > 0 || len(config.OrderBy) > 0 || config.Limit != 0) {
+		return fmt.Errorf("collection, where, order_by and limit can only be used with action query")
+	}
+
+	return nil
+}
+
+// isDocumentPath reports whether path names a document rather than a collection
+func isDocumentPath(path string) bool {
+	return len(strings.Split(path, "/"))%2 == 0
+}
+
+// applyVariableReplacement processes templates in the connection settings,
+// paths, data and filter values
+func applyVariableReplacement(config *FirestoreConfig, state map[string]interface{}, env map[string]string) error {
+	context := dsl.TemplateContext{
+		Runtime: state,
+		Env:     env,
+	}
+
+	fields := map[string]*string{
+		"project":          &config.Project,
+		"database":         &config.Database,
+		"emulator_host":    &config.EmulatorHost,
+		"access_token":     &config.AccessToken,
+		"credentials":      &config.Credentials,
+		"credentials_file": &config.CredentialsFile,
+		"path":             &config.Path,
+		"collection":       &config.Collection,
+	}
+	for name, value := range fields {
+		if *value == "" {
+			continue
+		}
+		processed, err := dsl.ProcessTemplate(*value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process %s template: %w", name, err)
+		}
+		*value = processed
+	}
+
+	for name, value := range config.Data {
+		processed, err := replaceInValue(value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process data %s template: %w", name, err)
+		}
+		config.Data[name] = processed
+	}
+	for i := range config.Where {
+		processed, err := replaceInValue(config.Where[i].Value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process where %s template: %w", config.Where[i].Field, err)
+		}
+		config.Where[i].Value = processed
+	}
+
+	return nil
+}
+
+// replaceInValue renders templates in strings nested anywhere in a value
+func replaceInValue(value interface{}, context dsl.TemplateContext) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return dsl.ProcessTemplate(v, context)
+	case map[string]interface{}:
+		for key, item := range v {
+			processed, err := replaceInValue(item, context)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = processed
+		}
+		return v, nil
+	case []interface{}:
+		for i, item := range v {
+			processed, err := replaceInValue(item, context)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = processed
+		}
+		return v, nil
+	}
+	return value, nil
+}
+
+// parseConfig converts map[string]interface{} to FirestoreConfig
+func parseConfig(configData map[string]interface{}, config *FirestoreConfig) error {
+	stringFields := map[string]*string{
+		"action":           &config.Action,
+		"project":          &config.Project,
+		"database":         &config.Database,
+		"emulator_host":    &config.EmulatorHost,
+		"access_token":     &config.AccessToken,
+		"credentials":      &config.Credentials,
+		"credentials_file": &config.CredentialsFile,
+		"path":             &config.Path,
+		"collection":       &config.Collection,
+		"timeout":          &config.Timeout,
+	}
+	for key, target := range stringFields {
+		if v, ok := configData[key].(string); ok {
+			*target = v
+		}
+	}
+	config.Path = strings.Trim(config.Path, "/")
+
+	switch v := configData["data"].(type) {
+	case nil:
+	case map[string]interface{}:
+		config.Data = v
+	default:
+		return fmt.Errorf("data must be a map, got %T", v)
+	}
+
+	switch v := configData["limit"].(type) {
+	case nil:
+	case float64:
+		config.Limit = int(v)
+	case int:
+		config.Limit = v
+	default:
+		return fmt.Errorf("limit must be a number, got %T", v)
+	}
+
+	if raw, ok := configData["where"]; ok && raw != nil {
+		list, ok := raw.([]interface{})
+		if !ok {
+			return fmt.Errorf("where must be a list, got %T", raw)
+		}
+		for i, item := range list {
+			entry, ok := item.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("where[%d] must be a map, got %T", i, item)
+			}
+			filter := WhereFilter{Value: entry["value"]}
+			filter.Field, _ = entry["field"].(string)
+			filter.Op, _ = entry["op"].(string)
+			config.Where = append(config.Where, filter)
+		}
+	}
+
+	if raw, ok := configData["order_by"]; ok && raw != nil {
+		list, ok := raw.([]interface{})
+		if !ok {
+			return fmt.Errorf("order_by must be a list, got %T", raw)
+		}
+		for i, item := range list {
+			entry, ok := item.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("order_by[%d] must be a map, got %T", i, item)
+			}
+			order := OrderBy{}
+			order.Field, _ = entry["field"].(string)
+			order.Direction, _ = entry["direction"].(string)
+			config.OrderBy = append(config.OrderBy, order)
+		}
+	}
+
+	return nil
+}
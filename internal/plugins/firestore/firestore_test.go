@@ -0,0 +1,474 @@
+package firestore
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	gojwt "github.com/golang-jwt/jwt/v4"
+	"github.com/rocketship-ai/rocketship/internal/plugins/plugintest"
+)
+
+const documentsPrefix = "/v1/projects/demo/databases/(default)/documents"
+
+// fakeEmulator is an in-memory Firestore emulator. Queries support equality and
+// integer range filters, ordering by one field and limits.
+type fakeEmulator struct {
+	docs     map[string]map[string]interface{}
+	requests []*http.Request
+	queries  []map[string]interface{}
+}
+
+func newFakeEmulator() *fakeEmulator {
+	return &fakeEmulator{docs: make(map[string]map[string]interface{})}
+}
+
+func (f *fakeEmulator) document(path string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":       "projects/demo/databases/(default)/documents/" + path,
+		"fields":     f.docs[path],
+		"createTime": "2026-10-16T10:00:00Z",
+		"updateTime": "2026-10-16T10:00:01Z",
+	}
+}
+
+func notFound(w http.ResponseWriter, path string) {
+	w.WriteHeader(http.StatusNotFound)
+	_, _ = fmt.Fprintf(w, `{"error":{"code":404,"message":"no entity to update: %s","status":"NOT_FOUND"}}`, path)
+}
+
+func (f *fakeEmulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.requests = append(f.requests, r)
+	if r.Header.Get("Authorization") != "Bearer owner" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, documentsPrefix) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	rest := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, documentsPrefix), "/")
+
+	if strings.HasSuffix(rest, ":runQuery") {
+		var body struct {
+			StructuredQuery map[string]interface{} `json:"structuredQuery"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		f.queries = append(f.queries, body.StructuredQuery)
+		parent := strings.TrimSuffix(rest, ":runQuery")
+		results := []interface{}{map[string]interface{}{"readTime": "2026-10-16T10:00:02Z"}}
+		for _, path := range f.query(parent, body.StructuredQuery) {
+			results = append(results, map[string]interface{}{"document": f.document(path)})
+		}
+		_ = json.NewEncoder(w).Encode(results)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if _, ok := f.docs[rest]; !ok {
+			notFound(w, rest)
+			return
+		}
+	case http.MethodPatch:
+		var body struct {
+			Fields map[string]interface{} `json:"fields"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mask := r.URL.Query()["updateMask.fieldPaths"]
+		if mask == nil {
+			f.docs[rest] = body.Fields
+			break
+		}
+		existing, ok := f.docs[rest]
+		if !ok && r.URL.Query().Get("currentDocument.exists") == "true" {
+			notFound(w, rest)
+			return
+		}
+		for _, field := range mask {
+			existing[strings.Trim(field, "`")] = body.Fields[strings.Trim(field, "`")]
+		}
+	case http.MethodDelete:
+		delete(f.docs, rest)
+		_, _ = w.Write([]byte("{}"))
+		return
+	}
+	_ = json.NewEncoder(w).Encode(f.document(rest))
+}
+
+// query returns the paths of documents in the queried collection that match
+func (f *fakeEmulator) query(parent string, query map[string]interface{}) []string {
+	from := query["from"].([]interface{})[0].(map[string]interface{})["collectionId"].(string)
+	prefix := from + "/"
+	if parent != "" {
+		prefix = parent + "/" + prefix
+	}
+
+	var filters []interface{}
+	if where, ok := query["where"].(map[string]interface{}); ok {
+		if composite, ok := where["compositeFilter"].(map[string]interface{}); ok {
+			filters = composite["filters"].([]interface{})
+		} else {
+			filters = []interface{}{where}
+		}
+	}
+
+	var paths []string
+	for path, fields := range f.docs {
+		if !strings.HasPrefix(path, prefix) || strings.Contains(strings.TrimPrefix(path, prefix), "/") {
+			continue
+		}
+		if matchesAll(fields, filters) {
+			paths = append(paths, path)
+		}
+	}
+
+	sort.Strings(paths)
+	if orders, ok := query["orderBy"].([]interface{}); ok {
+		order := orders[0].(map[string]interface{})
+		field := order["field"].(map[string]interface{})["fieldPath"].(string)
+		sort.SliceStable(paths, func(i, j int) bool {
+			less := integer(f.docs[paths[i]][field]) < integer(f.docs[paths[j]][field])
+			if order["direction"] == "DESCENDING" {
+				return integer(f.docs[paths[i]][field]) > integer(f.docs[paths[j]][field])
+			}
+			return less
+		})
+	}
+	if limit, ok := query["limit"].(float64); ok && int(limit) < len(paths) {
+		paths = paths[:int(limit)]
+	}
+	return paths
+}
+
+func matchesAll(fields map[string]interface{}, filters []interface{}) bool {
+	for _, filter := range filters {
+		fieldFilter := filter.(map[string]interface{})["fieldFilter"].(map[string]interface{})
+		name := fieldFilter["field"].(map[string]interface{})["fieldPath"].(string)
+		actual, _ := json.Marshal(fields[name])
+		expected, _ := json.Marshal(fieldFilter["value"])
+		switch fieldFilter["op"] {
+		case "EQUAL":
+			if string(actual) != string(expected) {
+				return false
+			}
+		case "GREATER_THAN_OR_EQUAL":
+			if integer(fields[name]) < integer(fieldFilter["value"]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func integer(value interface{}) int64 {
+	typed, _ := value.(map[string]interface{})
+	s, _ := typed["integerValue"].(string)
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
+
+// runStep runs the step through the plugin's Activity
+func runStep(t *testing.T, server *httptest.Server, configData map[string]interface{}, assertionList []interface{}, saveList ...interface{}) (*ActivityResponse, error) {
+	t.Helper()
+	configData["project"] = "{{ .env.FIREBASE_PROJECT }}"
+	configData["emulator_host"] = strings.TrimPrefix(server.URL, "http://")
+
+	resp := &ActivityResponse{}
+	err := plugintest.Run(t, &FirestorePlugin{}, map[string]interface{}{
+		"config":     configData,
+		"state":      map[string]interface{}{"user_id": "alice"},
+		"env":        map[string]interface{}{"FIREBASE_PROJECT": "demo"},
+		"assertions": assertionList,
+		"save":       saveList,
+	}, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func TestSetGetUpdateDelete(t *testing.T) {
+	fake := newFakeEmulator()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	resp, err := runStep(t, server, map[string]interface{}{
+		"action": "set",
+		"path":   "users/{{ user_id }}",
+		"data": map[string]interface{}{
+			"name":    "Alice",
+			"age":     float64(31),
+			"score":   9.5,
+			"active":  true,
+			"tags":    []interface{}{"admin", "{{ user_id }}"},
+			"address": map[string]interface{}{"city": "Lisbon"},
+			"deleted": nil,
+		},
+	}, []interface{}{
+		map[string]interface{}{"type": "json_path", "path": ".exists", "expected": true},
+	}, map[string]interface{}{"json_path": ".documents[0].id", "as": "doc_id"})
+	if err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	if resp.Saved["doc_id"] != "alice" {
+		t.Errorf("expected the document ID to be saved, got %q", resp.Saved["doc_id"])
+	}
+	stored := fake.docs["users/alice"]
+	if age, _ := json.Marshal(stored["age"]); string(age) != `{"integerValue":"31"}` {
+		t.Errorf("expected whole numbers to be stored as integers, got %s", age)
+	}
+	if score, _ := json.Marshal(stored["score"]); string(score) != `{"doubleValue":9.5}` {
+		t.Errorf("expected fractions to be stored as doubles, got %s", score)
+	}
+	if tags, _ := json.Marshal(stored["tags"]); string(tags) != `{"arrayValue":{"values":[{"stringValue":"admin"},{"stringValue":"alice"}]}}` {
+		t.Errorf("expected templates inside lists to be rendered, got %s", tags)
+	}
+
+	if _, err := runStep(t, server, map[string]interface{}{
+		"action": "get",
+		"path":   "users/alice",
+	}, []interface{}{
+		map[string]interface{}{"type": "json_path", "path": ".data.address.city", "expected": "Lisbon"},
+		map[string]interface{}{"type": "json_path", "path": ".data.age", "expected": float64(31)},
+		map[string]interface{}{"type": "json_path", "path": ".data.deleted", "expected": nil},
+		map[string]interface{}{"type": "json_path", "path": ".documents[0].update_time", "expected": "2026-10-16T10:00:01Z"},
+		map[string]interface{}{"type": "document_count", "expected": float64(1)},
+	}); err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+
+	if _, err := runStep(t, server, map[string]interface{}{
+		"action": "update",
+		"path":   "users/alice",
+		"data":   map[string]interface{}{"age": float64(32), "last-login": "today"},
+	}, []interface{}{
+		map[string]interface{}{"type": "json_path", "path": ".data.age", "expected": float64(32)},
+		map[string]interface{}{"type": "json_path", "path": ".data.name", "expected": "Alice"},
+	}); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	mask := fake.requests[len(fake.requests)-1].URL.Query()["updateMask.fieldPaths"]
+	if strings.Join(mask, ",") != "`last-login`,age" {
+		t.Errorf("expected a quoted update mask, got %v", mask)
+	}
+
+	_, err = runStep(t, server, map[string]interface{}{
+		"action": "update",
+		"path":   "users/bob",
+		"data":   map[string]interface{}{"age": float64(40)},
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Fatalf("expected updating a missing document to fail, got %v", err)
+	}
+
+	if _, err := runStep(t, server, map[string]interface{}{
+		"action": "delete",
+		"path":   "users/alice",
+	}, nil); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if _, err := runStep(t, server, map[string]interface{}{
+		"action": "get",
+		"path":   "users/alice",
+	}, []interface{}{
+		map[string]interface{}{"type": "json_path", "path": ".exists", "expected": false},
+		map[string]interface{}{"type": "document_count", "expected": float64(0)},
+	}); err != nil {
+		t.Fatalf("expected a missing document to be reported, not fail: %v", err)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	fake := newFakeEmulator()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	for i, status := range []string{"paid", "paid", "open", "paid"} {
+		if _, err := runStep(t, server, map[string]interface{}{
+			"action": "set",
+			"path":   fmt.Sprintf("users/alice/orders/o%d", i+1),
+			"data":   map[string]interface{}{"status": status, "total": float64(10 * (i + 1))},
+		}, nil); err != nil {
+			t.Fatalf("set failed: %v", err)
+		}
+	}
+	if _, err := runStep(t, server, map[string]interface{}{
+		"action": "set",
+		"path":   "users/bob/orders/o9",
+		"data":   map[string]interface{}{"status": "paid", "total": float64(90)},
+	}, nil); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+
+	resp, err := runStep(t, server, map[string]interface{}{
+		"action":     "query",
+		"path":       "users/{{ user_id }}",
+		"collection": "orders",
+		"where": []interface{}{
+			map[string]interface{}{"field": "status", "op": "==", "value": "paid"},
+			map[string]interface{}{"field": "total", "op": ">=", "value": float64(20)},
+		},
+		"order_by": []interface{}{map[string]interface{}{"field": "total", "direction": "desc"}},
+		"limit":    float64(5),
+	}, []interface{}{
+		map[string]interface{}{"type": "document_count", "expected": float64(2)},
+		map[string]interface{}{"type": "json_path", "path": ".documents[0].data.total", "expected": float64(40)},
+		map[string]interface{}{"type": "json_path", "path": ".documents[1].path", "expected": "users/alice/orders/o2"},
+	})
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if resp.Response.Count != 2 {
+		t.Errorf("expected progress-only results to be skipped, got %d documents", resp.Response.Count)
+	}
+
+	query := fake.queries[len(fake.queries)-1]
+	where, _ := json.Marshal(query["where"])
+	if !strings.Contains(string(where), `"compositeFilter":{"filters":[`) || !strings.Contains(string(where), `"op":"AND"`) {
+		t.Errorf("expected filters to be combined with AND, got %s", where)
+	}
+
+	_, err = runStep(t, server, map[string]interface{}{
+		"action":     "query",
+		"collection": "users",
+		"where":      []interface{}{map[string]interface{}{"field": "status", "op": "like", "value": "p%"}},
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "where[0].op") {
+		t.Fatalf("expected an invalid operator to be rejected, got %v", err)
+	}
+}
+
+func TestBuildFilterNull(t *testing.T) {
+	filter, err := buildFilter(WhereFilter{Field: "deleted_at", Op: "==", Value: nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(filter)
+	if string(data) != `{"unaryFilter":{"field":{"fieldPath":"deleted_at"},"op":"IS_NULL"}}` {
+		t.Errorf("expected an IS_NULL filter, got %s", data)
+	}
+}
+
+func TestDecodeValue(t *testing.T) {
+	fields := map[string]interface{}{}
+	_ = json.Unmarshal([]byte(`{
+		"count": {"integerValue": "9007199254740993"},
+		"when": {"timestampValue": "2026-10-16T10:00:00Z"},
+		"where": {"geoPointValue": {"latitude": 38.7}},
+		"owner": {"referenceValue": "projects/demo/databases/(default)/documents/users/alice"},
+		"empty": {"arrayValue": {}}
+	}`), &fields)
+
+	data, err := decodeFields(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data["count"] != int64(9007199254740993) {
+		t.Errorf("expected integers to keep full precision, got %v", data["count"])
+	}
+	if data["when"] != "2026-10-16T10:00:00Z" {
+		t.Errorf("expected timestamps as strings, got %v", data["when"])
+	}
+	point := data["where"].(map[string]interface{})
+	if point["latitude"] != 38.7 || point["longitude"] != 0.0 {
+		t.Errorf("expected a missing coordinate to be zero, got %v", point)
+	}
+	if list, ok := data["empty"].([]interface{}); !ok || len(list) != 0 {
+		t.Errorf("expected an empty list, got %#v", data["empty"])
+	}
+
+	if _, err := decodeValue(map[string]interface{}{"vectorValue": map[string]interface{}{}}); err == nil {
+		t.Error("expected an unsupported value type to fail")
+	}
+}
+
+func TestServiceAccountToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	exchanges := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.Form.Get("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		claims := gojwt.MapClaims{}
+		if _, err := gojwt.ParseWithClaims(r.Form.Get("assertion"), claims, func(*gojwt.Token) (interface{}, error) {
+			return &key.PublicKey, nil
+		}); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if claims["scope"] != datastoreScope || claims["iss"] != "tests@demo.iam.gserviceaccount.com" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		exchanges++
+		_, _ = w.Write([]byte(`{"access_token":"ya29.test","expires_in":3600,"token_type":"Bearer"}`))
+	}))
+	defer server.Close()
+
+	credentials, _ := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "tests@demo.iam.gserviceaccount.com",
+		"private_key":  string(keyPEM),
+		"token_uri":    server.URL,
+	})
+	c, err := newClient(&FirestoreConfig{Project: "demo", Credentials: string(credentials)}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(c.base, "https://firestore.googleapis.com/v1/projects/demo/databases/(default)/documents") {
+		t.Errorf("expected the production endpoint, got %s", c.base)
+	}
+
+	for i := 0; i < 2; i++ {
+		authorization, err := c.authorization(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if authorization != "Bearer ya29.test" {
+			t.Errorf("expected the exchanged token, got %q", authorization)
+		}
+	}
+	if exchanges != 1 {
+		t.Errorf("expected the token to be cached, got %d exchanges", exchanges)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config FirestoreConfig
+		error  string
+	}{
+		{"collection path", FirestoreConfig{Action: "get", Project: "demo", Path: "users"}, "is a collection"},
+		{"update without data", FirestoreConfig{Action: "update", Project: "demo", Path: "users/alice"}, "data is required"},
+		{"query without collection", FirestoreConfig{Action: "query", Project: "demo"}, "collection is required"},
+		{"limit outside query", FirestoreConfig{Action: "get", Project: "demo", Path: "users/alice", Limit: 1}, "only be used with action query"},
+		{"missing project", FirestoreConfig{Action: "get", Path: "users/alice"}, "project is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateConfig(&tt.config)
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Fatalf("expected error containing %q, got %v", tt.error, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package firestore
+
+import "github.com/rocketship-ai/rocketship/internal/assertions"
+
+// FirestorePlugin represents a Firestore test step
+type FirestorePlugin struct {
+	Name   string          `json:"name" yaml:"name"`
+	Plugin string          `json:"plugin" yaml:"plugin"`
+	Config FirestoreConfig `json:"config" yaml:"config"`
+}
+
+// FirestoreConfig selects the database, the action and its arguments
+type FirestoreConfig struct {
+	Action string `json:"action" yaml:"action"` // get, set, update, delete or query
+
+	// Database
+	Project      string `json:"project" yaml:"project"`
+	Database     string `json:"database,omitempty" yaml:"database,omitempty"`           // Defaults to (default)
+	EmulatorHost string `json:"emulator_host,omitempty" yaml:"emulator_host,omitempty"` // host:port of the emulator (defaults to FIRESTORE_EMULATOR_HOST)
+
+	// Credentials for Firestore itself; the emulator needs none
+	AccessToken     string `json:"access_token,omitempty" yaml:"access_token,omitempty"`         // OAuth access token
+	Credentials     string `json:"credentials,omitempty" yaml:"credentials,omitempty"`           // Service account key JSON
+	CredentialsFile string `json:"credentials_file,omitempty" yaml:"credentials_file,omitempty"` // Path on the worker to a service account key
+
+	// Documents
+	Path string                 `json:"path,omitempty" yaml:"path,omitempty"` // get, set, update, delete: document path, e.g. users/alice
+	Data map[string]interface{} `json:"data,omitempty" yaml:"data,omitempty"` // set, update: fields to write
+
+	// query
+	Collection string        `json:"collection,omitempty" yaml:"collection,omitempty"` // Collection ID, under path when it is set
+	Where      []WhereFilter `json:"where,omitempty" yaml:"where,omitempty"`
+	OrderBy    []OrderBy     `json:"order_by,omitempty" yaml:"order_by,omitempty"`
+	Limit      int           `json:"limit,omitempty" yaml:"limit,omitempty"`
+
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"` // Overall step timeout (defaults to 30s)
+}
+
+// WhereFilter is a field filter of a query. Filters are combined with AND.
+type WhereFilter struct {
+	Field string      `json:"field" yaml:"field"`
+	Op    string      `json:"op" yaml:"op"` // ==, !=, <, <=, >, >=, array-contains, array-contains-any, in or not-in
+	Value interface{} `json:"value" yaml:"value"`
+}
+
+// OrderBy sorts query results
+type OrderBy struct {
+	Field     string `json:"field" yaml:"field"`
+	Direction string `json:"direction,omitempty" yaml:"direction,omitempty"` // asc (default) or desc
+}
+
+// Actions supported by the Firestore plugin
+const (
+	ActionGet    = "get"
+	ActionSet    = "set"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+	ActionQuery  = "query"
+)
+
+// Assertion types supported by the Firestore plugin in addition to the shared ones
+const (
+	AssertionTypeDocumentCount = "document_count"
+)
+
+// Document is a Firestore document with its fields decoded to plain JSON values
+type Document struct {
+	ID         string                 `json:"id"`
+	Path       string                 `json:"path"` // Relative to the database, e.g. users/alice
+	Data       map[string]interface{} `json:"data"`
+	CreateTime string                 `json:"create_time,omitempty"`
+	UpdateTime string                 `json:"update_time,omitempty"`
+}
+
+// FirestoreResponse contains what the action read or wrote
+type FirestoreResponse struct {
+	Action    string                 `json:"action"`
+	Exists    bool                   `json:"exists"`         // get, set, update: whether the document exists afterwards
+	Data      map[string]interface{} `json:"data,omitempty"` // get, set, update: the document's fields
+	Documents []Document             `json:"documents"`      // Documents read or written
+	Count     int                    `json:"count"`          // Number of documents
+	Duration  string                 `json:"duration"`
+}
+
+// AssertionResult represents a single assertion result for UI display
+type AssertionResult = assertions.Result
+
+// ActivityResponse represents the complete activity response
+type ActivityResponse struct {
+	Response         *FirestoreResponse `json:"response"`
+	Saved            map[string]string  `json:"saved"`
+	AssertionResults []AssertionResult  `json:"assertion_results,omitempty"`
+}
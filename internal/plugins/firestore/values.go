@@ -0,0 +1,156 @@
+package firestore
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// encodeFields converts plain values to Firestore's typed field map
+func encodeFields(data map[string]interface{}) (map[string]interface{}, error) {
+	fields := make(map[string]interface{}, len(data))
+	for name, value := range data {
+		encoded, err := encodeValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", name, err)
+		}
+		fields[name] = encoded
+	}
+	return fields, nil
+}
+
+// encodeValue converts a YAML or JSON value to a Firestore Value. Whole numbers
+// become integers, the way they would when written from JavaScript or Python.
+func encodeValue(value interface{}) (map[string]interface{}, error) {
+	switch v := value.(type) {
+	case nil:
+		return map[string]interface{}{"nullValue": nil}, nil
+	case bool:
+		return map[string]interface{}{"booleanValue": v}, nil
+	case string:
+		return map[string]interface{}{"stringValue": v}, nil
+	case int:
+		return map[string]interface{}{"integerValue": strconv.Itoa(v)}, nil
+	case int64:
+		return map[string]interface{}{"integerValue": strconv.FormatInt(v, 10)}, nil
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return map[string]interface{}{"integerValue": strconv.FormatInt(int64(v), 10)}, nil
+		}
+		return map[string]interface{}{"doubleValue": v}, nil
+	case map[string]interface{}:
+		fields, err := encodeFields(v)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"mapValue": map[string]interface{}{"fields": fields}}, nil
+	case []interface{}:
+		values := make([]interface{}, 0, len(v))
+		for i, item := range v {
+			encoded, err := encodeValue(item)
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %w", i, err)
+			}
+			values = append(values, encoded)
+		}
+		return map[string]interface{}{"arrayValue": map[string]interface{}{"values": values}}, nil
+	}
+	return nil, fmt.Errorf("unsupported value type %T", value)
+}
+
+// decodeFields converts a document's typed field map to plain values
+func decodeFields(fields map[string]interface{}) (map[string]interface{}, error) {
+	data := make(map[string]interface{}, len(fields))
+	for name, value := range fields {
+		decoded, err := decodeValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", name, err)
+		}
+		data[name] = decoded
+	}
+	return data, nil
+}
+
+// decodeValue converts a Firestore Value to a plain value. Timestamps, references
+// and bytes stay strings, as the REST API returns them; geo points become
+// {latitude, longitude}.
+func decodeValue(value interface{}) (interface{}, error) {
+	typed, ok := value.(map[string]interface{})
+	if !ok || len(typed) != 1 {
+		return nil, fmt.Errorf("invalid Firestore value %v", value)
+	}
+	for kind, v := range typed {
+		switch kind {
+		case "nullValue":
+			return nil, nil
+		case "booleanValue", "doubleValue", "stringValue", "timestampValue", "referenceValue", "bytesValue":
+			if s, ok := v.(string); ok && kind == "doubleValue" {
+				// NaN and the infinities arrive as strings
+				return s, nil
+			}
+			return v, nil
+		case "integerValue":
+			s, _ := v.(string)
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid integer %v", v)
+			}
+			return n, nil
+		case "geoPointValue":
+			point, _ := v.(map[string]interface{})
+			return map[string]interface{}{"latitude": numberOrZero(point["latitude"]), "longitude": numberOrZero(point["longitude"])}, nil
+		case "mapValue":
+			m, _ := v.(map[string]interface{})
+			fields, _ := m["fields"].(map[string]interface{})
+			return decodeFields(fields)
+		case "arrayValue":
+			a, _ := v.(map[string]interface{})
+			values, _ := a["values"].([]interface{})
+			decoded := make([]interface{}, 0, len(values))
+			for i, item := range values {
+				d, err := decodeValue(item)
+				if err != nil {
+					return nil, fmt.Errorf("item %d: %w", i, err)
+				}
+				decoded = append(decoded, d)
+			}
+			return decoded, nil
+		}
+		return nil, fmt.Errorf("unsupported Firestore value type %s", kind)
+	}
+	return nil, nil
+}
+
+// numberOrZero returns a geo point coordinate, which the API leaves out when it is zero
+func numberOrZero(v interface{}) float64 {
+	f, _ := v.(float64)
+	return f
+}
+
+// fieldPaths returns the top-level field paths of data for an update mask,
+// quoting names that aren't simple identifiers
+func fieldPaths(data map[string]interface{}) []string {
+	paths := make([]string, 0, len(data))
+	for name := range data {
+		paths = append(paths, quoteFieldPath(name))
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// quoteFieldPath backquotes a field name unless it is a simple identifier
+func quoteFieldPath(name string) string {
+	simple := name != ""
+	for i, r := range name {
+		if !(r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (i > 0 && r >= '0' && r <= '9')) {
+			simple = false
+			break
+		}
+	}
+	if simple {
+		return name
+	}
+	return "`" + strings.NewReplacer(`\`, `\\`, "`", "\\`").Replace(name) + "`"
+}
@@ -0,0 +1,523 @@
+package http
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// Auth types
+const (
+	AuthTypeSigV4                   = "sigv4"
+	AuthTypeDigest                  = "digest"
+	AuthTypeOAuth2ClientCredentials = "oauth2_client_credentials"
+)
+
+// authFields lists the fields each auth type requires
+var authFields = map[string][]string{
+	AuthTypeSigV4:                   {"region", "service", "access_key_id", "secret_access_key"},
+	AuthTypeDigest:                  {"username", "password"},
+	AuthTypeOAuth2ClientCredentials: {"token_url", "client_id", "client_secret"},
+}
+
+// authConfig is a parsed auth block, with templates rendered
+type authConfig struct {
+	authType string
+	fields   map[string]string
+	scopes   []string
+	params   url.Values
+}
+
+// parseAuth reads the auth block of the config. It returns nil when the step
+// has no auth block.
+func parseAuth(configData map[string]interface{}, state map[string]string, env map[string]string) (*authConfig, error) {
+	raw, exists := configData["auth"]
+	if !exists || raw == nil {
+		return nil, nil
+	}
+	authData, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("auth must be an object, got %T", raw)
+	}
+	authType, _ := authData["type"].(string)
+	required, known := authFields[authType]
+	if !known {
+		return nil, fmt.Errorf("auth.type must be %q, %q or %q, got %v", AuthTypeSigV4, AuthTypeDigest, AuthTypeOAuth2ClientCredentials, authData["type"])
+	}
+
+	cfg := &authConfig{authType: authType, fields: make(map[string]string), params: url.Values{}}
+	for key, value := range authData {
+		switch key {
+		case "type":
+			continue
+		case "scopes":
+			list, ok := value.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("auth.scopes must be a list, got %T", value)
+			}
+			for _, scope := range list {
+				rendered, err := replaceVariables(fmt.Sprint(scope), state, env)
+				if err != nil {
+					return nil, fmt.Errorf("failed to replace variables in auth.scopes: %w", err)
+				}
+				cfg.scopes = append(cfg.scopes, rendered)
+			}
+		case "params":
+			params, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("auth.params must be a map, got %T", value)
+			}
+			for name, param := range params {
+				rendered, err := replaceVariables(fmt.Sprint(param), state, env)
+				if err != nil {
+					return nil, fmt.Errorf("failed to replace variables in auth.params.%s: %w", name, err)
+				}
+				cfg.params.Set(name, rendered)
+			}
+		default:
+			s, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("auth.%s must be a string, got %T", key, value)
+			}
+			rendered, err := replaceVariables(s, state, env)
+			if err != nil {
+				return nil, fmt.Errorf("failed to replace variables in auth.%s: %w", key, err)
+			}
+			cfg.fields[key] = rendered
+		}
+	}
+	for _, field := range required {
+		if cfg.fields[field] == "" {
+			return nil, fmt.Errorf("auth.%s is required for %s auth", field, authType)
+		}
+	}
+	if audience := cfg.fields["audience"]; audience != "" {
+		cfg.params.Set("audience", audience)
+	}
+	return cfg, nil
+}
+
+// transport wraps base so requests are signed or carry credentials. Token
+// requests for oauth2 go through base too, so they follow the egress policy.
+func (ac *authConfig) transport(base http.RoundTripper) http.RoundTripper {
+	switch ac.authType {
+	case AuthTypeSigV4:
+		return &sigV4Transport{base: base, auth: ac, now: time.Now}
+	case AuthTypeDigest:
+		return &digestTransport{base: base, username: ac.fields["username"], password: ac.fields["password"]}
+	default:
+		return &oauth2Transport{base: base, auth: ac}
+	}
+}
+
+// cloneWithBody clones req with a fresh copy of its body, so a transport can
+// read or resend it
+func cloneWithBody(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to rewind request body: %w", err)
+		}
+		clone.Body = body
+	}
+	return clone, nil
+}
+
+// sigV4Transport signs requests with AWS Signature Version 4
+type sigV4Transport struct {
+	base http.RoundTripper
+	auth *authConfig
+	now  func() time.Time
+}
+
+func (t *sigV4Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	signed, err := cloneWithBody(req)
+	if err != nil {
+		return nil, err
+	}
+	var payload []byte
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to rewind request body: %w", err)
+		}
+		payload, err = io.ReadAll(body)
+		_ = body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body for signing: %w", err)
+		}
+	}
+	signSigV4(signed, payload, t.auth.fields, t.now().UTC())
+	return t.base.RoundTrip(signed)
+}
+
+// signSigV4 adds the X-Amz-Date and Authorization headers for the request.
+// It signs host, content-type and the x-amz-* headers.
+func signSigV4(req *http.Request, payload []byte, fields map[string]string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	service := fields["service"]
+	payloadHash := hexSHA256(payload)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if token := fields["session_token"]; token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+	if service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			trimmed := make([]string, len(values))
+			for i, v := range values {
+				trimmed[i] = strings.Join(strings.Fields(v), " ")
+			}
+			headers[lower] = strings.Join(trimmed, ",")
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		sigV4Path(req.URL, service),
+		sigV4Query(req.URL),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, fields["region"], service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hexSHA256([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+fields["secret_access_key"]), date)
+	key = hmacSHA256(key, fields["region"])
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		fields["access_key_id"], scope, signedHeaders, signature))
+}
+
+// sigV4Path is the canonical URI. S3 encodes each segment once; other
+// services encode the already-escaped path again.
+func sigV4Path(u *url.URL, service string) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		decoded, err := url.PathUnescape(segment)
+		if err != nil {
+			decoded = segment
+		}
+		segments[i] = awsURIEncode(decoded)
+		if service != "s3" {
+			segments[i] = awsURIEncode(segments[i])
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// sigV4Query is the canonical query string, sorted by name then value
+func sigV4Query(u *url.URL) string {
+	query := u.Query()
+	pairs := make([]string, 0, len(query))
+	for name, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, awsURIEncode(name)+"="+awsURIEncode(value))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// awsURIEncode percent-encodes everything but the RFC 3986 unreserved characters
+func awsURIEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// digestTransport answers HTTP Digest challenges (RFC 7616). The request is
+// sent once without credentials, and again with them if the server replies
+// 401 with a Digest challenge.
+type digestTransport struct {
+	base     http.RoundTripper
+	username string
+	password string
+}
+
+func (t *digestTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	first, err := cloneWithBody(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(first)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	challenge, ok := parseDigestChallenge(resp.Header.Values("WWW-Authenticate"))
+	if !ok {
+		return resp, nil
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	cnonce, err := digestCnonce()
+	if err != nil {
+		return nil, err
+	}
+	authorized, err := cloneWithBody(req)
+	if err != nil {
+		return nil, err
+	}
+	authorized.Header.Set("Authorization", challenge.authorization(req.Method, req.URL.RequestURI(), t.username, t.password, cnonce))
+	return t.base.RoundTrip(authorized)
+}
+
+// digestChallenge is the parameters of a Digest WWW-Authenticate challenge
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string // "auth" when the server offers it, else empty
+}
+
+// parseDigestChallenge picks the strongest Digest challenge the server
+// offered: SHA-256 over MD5
+func parseDigestChallenge(headers []string) (*digestChallenge, bool) {
+	var best *digestChallenge
+	for _, header := range headers {
+		scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+		if !strings.EqualFold(scheme, "Digest") {
+			continue
+		}
+		params := parseAuthParams(rest)
+		c := &digestChallenge{
+			realm:     params["realm"],
+			nonce:     params["nonce"],
+			opaque:    params["opaque"],
+			algorithm: strings.ToUpper(params["algorithm"]),
+		}
+		if c.algorithm == "" {
+			c.algorithm = "MD5"
+		}
+		if _, ok := digestHashes[strings.TrimSuffix(c.algorithm, "-SESS")]; !ok || c.nonce == "" {
+			continue
+		}
+		for _, qop := range strings.Split(params["qop"], ",") {
+			if strings.TrimSpace(qop) == "auth" {
+				c.qop = "auth"
+			}
+		}
+		if best == nil || strings.HasPrefix(c.algorithm, "SHA-256") {
+			best = c
+		}
+	}
+	return best, best != nil
+}
+
+// parseAuthParams splits name=value and name="quoted, value" pairs
+func parseAuthParams(s string) map[string]string {
+	params := make(map[string]string)
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimLeft(strings.TrimSpace(s), ",") {
+		name, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		rest = strings.TrimSpace(rest)
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(rest) && rest[i] != '"'; i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+				}
+				b.WriteByte(rest[i])
+			}
+			value, s = b.String(), rest[min(i+1, len(rest)):]
+		} else {
+			value, s, _ = strings.Cut(rest, ",")
+			value = strings.TrimSpace(value)
+		}
+		params[name] = value
+	}
+	return params
+}
+
+// digestHashes are the algorithms digest auth supports
+var digestHashes = map[string]func() hash.Hash{
+	"MD5":     md5.New,
+	"SHA-256": sha256.New,
+}
+
+// authorization builds the Authorization header answering the challenge
+func (c *digestChallenge) authorization(method, uri, username, password, cnonce string) string {
+	newHash := digestHashes[strings.TrimSuffix(c.algorithm, "-SESS")]
+	h := func(s string) string {
+		hh := newHash()
+		hh.Write([]byte(s))
+		return hex.EncodeToString(hh.Sum(nil))
+	}
+
+	const nc = "00000001"
+	ha1 := h(username + ":" + c.realm + ":" + password)
+	if strings.HasSuffix(c.algorithm, "-SESS") {
+		ha1 = h(ha1 + ":" + c.nonce + ":" + cnonce)
+	}
+	ha2 := h(method + ":" + uri)
+
+	var response string
+	if c.qop == "auth" {
+		response = h(strings.Join([]string{ha1, c.nonce, nc, cnonce, c.qop, ha2}, ":"))
+	} else {
+		response = h(ha1 + ":" + c.nonce + ":" + ha2)
+	}
+
+	parts := []string{
+		fmt.Sprintf("username=%q", username),
+		fmt.Sprintf("realm=%q", c.realm),
+		fmt.Sprintf("nonce=%q", c.nonce),
+		fmt.Sprintf("uri=%q", uri),
+		"algorithm=" + c.algorithm,
+		fmt.Sprintf("response=%q", response),
+	}
+	if c.qop != "" {
+		parts = append(parts, "qop="+c.qop, "nc="+nc, fmt.Sprintf("cnonce=%q", cnonce))
+	}
+	if c.opaque != "" {
+		parts = append(parts, fmt.Sprintf("opaque=%q", c.opaque))
+	}
+	return "Digest " + strings.Join(parts, ", ")
+}
+
+func digestCnonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate digest cnonce: %w", err)
+	}
+	return base64.RawStdEncoding.EncodeToString(b), nil
+}
+
+// oauth2Transport adds a bearer token from the client credentials grant
+type oauth2Transport struct {
+	base http.RoundTripper
+	auth *authConfig
+}
+
+func (t *oauth2Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := clientCredentialsToken(req.Context(), t.base, t.auth)
+	if err != nil {
+		return nil, err
+	}
+	authorized, err := cloneWithBody(req)
+	if err != nil {
+		return nil, err
+	}
+	token.SetAuthHeader(authorized)
+	return t.base.RoundTrip(authorized)
+}
+
+// tokenExpiryMargin renews cached tokens this long before they expire
+const tokenExpiryMargin = 30 * time.Second
+
+// defaultTokenLifetime is how long a token without expires_in is reused
+const defaultTokenLifetime = 5 * time.Minute
+
+// tokenCache holds client credentials tokens on the worker, keyed by the
+// token request, so steps and runs with the same client share a token
+var tokenCache = struct {
+	sync.Mutex
+	tokens map[string]*oauth2.Token
+}{tokens: make(map[string]*oauth2.Token)}
+
+// clientCredentialsToken returns a cached token for the client, fetching a
+// new one when there's none or it is about to expire
+func clientCredentialsToken(ctx context.Context, base http.RoundTripper, ac *authConfig) (*oauth2.Token, error) {
+	keyParts := []string{ac.fields["token_url"], ac.fields["client_id"], hexSHA256([]byte(ac.fields["client_secret"])), strings.Join(ac.scopes, " "), ac.params.Encode()}
+	key := strings.Join(keyParts, "\x00")
+
+	tokenCache.Lock()
+	cached := tokenCache.tokens[key]
+	tokenCache.Unlock()
+	if cached != nil && time.Until(cached.Expiry) > tokenExpiryMargin {
+		return cached, nil
+	}
+
+	cfg := clientcredentials.Config{
+		ClientID:       ac.fields["client_id"],
+		ClientSecret:   ac.fields["client_secret"],
+		TokenURL:       ac.fields["token_url"],
+		Scopes:         ac.scopes,
+		EndpointParams: ac.params,
+	}
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: base, Timeout: 30 * time.Second})
+	token, err := cfg.Token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch oauth2 token from %s: %w", ac.fields["token_url"], err)
+	}
+	if token.Expiry.IsZero() {
+		token.Expiry = time.Now().Add(defaultTokenLifetime)
+	}
+
+	tokenCache.Lock()
+	tokenCache.tokens[key] = token
+	tokenCache.Unlock()
+	return token, nil
+}
@@ -0,0 +1,100 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// CookieRecord is a Set-Cookie response kept in a test's cookie jar. The
+// workflow holds the records between steps and sends them back with each
+// http step, which replays them into a fresh jar.
+type CookieRecord struct {
+	URL     string   `json:"url"`
+	Cookies []string `json:"cookies"`
+}
+
+// recordingJar is a cookie jar that remembers every cookie it is given, so
+// the cookies a step received can be returned to the workflow
+type recordingJar struct {
+	*cookiejar.Jar
+	mu      sync.Mutex
+	records []CookieRecord
+}
+
+// SetCookies stores the cookies and records them. Max-Age becomes an absolute
+// Expires so a replayed cookie still expires when the server meant it to.
+func (j *recordingJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.Jar.SetCookies(u, cookies)
+
+	now := time.Now()
+	record := CookieRecord{
+		URL:     (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}).String(),
+		Cookies: make([]string, 0, len(cookies)),
+	}
+	for _, c := range cookies {
+		stored := *c
+		if stored.MaxAge > 0 {
+			stored.Expires = now.Add(time.Duration(stored.MaxAge) * time.Second)
+			stored.MaxAge = 0
+		}
+		record.Cookies = append(record.Cookies, stored.String())
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.records = append(j.records, record)
+}
+
+// newCookies returns the records added since the jar was built. A nil jar,
+// for a test without cookie_jar, has none.
+func (j *recordingJar) newCookies() []CookieRecord {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]CookieRecord(nil), j.records...)
+}
+
+// buildCookieJar rebuilds the test's cookie jar from the records earlier steps
+// returned. It returns nil when the test doesn't have cookie_jar enabled.
+func buildCookieJar(p map[string]interface{}) (*recordingJar, error) {
+	raw, exists := p["cookie_jar"]
+	if !exists || raw == nil {
+		return nil, nil
+	}
+	records, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cookie_jar must be a list of cookie records, got %T", raw)
+	}
+
+	inner, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cookie jar: %w", err)
+	}
+	for _, r := range records {
+		record, ok := r.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid cookie record: got type %T", r)
+		}
+		rawURL, _ := record["url"].(string)
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cookie record URL %q: %w", rawURL, err)
+		}
+		setCookies, _ := record["cookies"].([]interface{})
+		cookies := make([]*http.Cookie, 0, len(setCookies))
+		for _, sc := range setCookies {
+			line, _ := sc.(string)
+			if c, err := http.ParseSetCookie(line); err == nil {
+				cookies = append(cookies, c)
+			}
+		}
+		inner.SetCookies(u, cookies)
+	}
+	return &recordingJar{Jar: inner}, nil
+}
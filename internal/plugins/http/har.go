@@ -0,0 +1,229 @@
+package http
+
+import (
+	"encoding/base64"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxHARBodyBytes caps each body kept in a HAR entry. Entries travel through
+// workflow history until the test writes its HAR file, so they stay small.
+const maxHARBodyBytes = 64 * 1024
+
+// HAREntry is one request and its response in HTTP Archive (HAR) 1.2 format
+type HAREntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"` // Total milliseconds
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"` // The step that sent the request
+}
+
+// HARNameValue is a header or query parameter
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARRequest is the request of a HAR entry
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARPostData is a request body
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// HARResponse is the response of a HAR entry
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARContent is a response body
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"` // base64 for binary bodies
+	Comment  string `json:"comment,omitempty"`
+}
+
+// HARTimings is the timing breakdown of an entry in milliseconds, -1 for
+// phases that didn't happen
+type HARTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"` // Includes ssl, as HAR defines it
+	SSL     float64 `json:"ssl"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// harEnabled reports whether the test records a HAR file
+func harEnabled(p map[string]interface{}) bool {
+	enabled, _ := p["har"].(bool)
+	return enabled
+}
+
+// buildHAREntry records the exchange of a step. reqHeader is the request's
+// headers including any cookie jar cookies. Sensitive headers are redacted as
+// they are in the run details, and bodies are capped.
+func buildHAREntry(stepName string, started time.Time, req *http.Request, reqHeader http.Header, reqBody []byte, resp *http.Response, respBody []byte, timings *Timings) HAREntry {
+	entry := HAREntry{
+		StartedDateTime: started.UTC().Format(time.RFC3339Nano),
+		Request: HARRequest{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: req.Proto,
+			Cookies:     []HARNameValue{},
+			Headers:     harHeaders(reqHeader),
+			QueryString: []HARNameValue{},
+			HeadersSize: -1,
+			BodySize:    len(reqBody),
+		},
+		Response: HARResponse{
+			Status:      resp.StatusCode,
+			StatusText:  http.StatusText(resp.StatusCode),
+			HTTPVersion: resp.Proto,
+			Cookies:     []HARNameValue{},
+			Headers:     harHeaders(resp.Header),
+			RedirectURL: resp.Header.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    len(respBody),
+		},
+		Comment: stepName,
+	}
+	if entry.Request.HTTPVersion == "" {
+		entry.Request.HTTPVersion = "HTTP/1.1"
+	}
+
+	query := req.URL.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range query[k] {
+			entry.Request.QueryString = append(entry.Request.QueryString, HARNameValue{Name: k, Value: v})
+		}
+	}
+
+	if len(reqBody) > 0 {
+		text, _, truncated := harBody(reqBody)
+		entry.Request.PostData = &HARPostData{MimeType: reqHeader.Get("Content-Type"), Text: text}
+		if truncated {
+			entry.Request.PostData.Comment = "body truncated"
+		}
+	}
+
+	text, encoding, truncated := harBody(respBody)
+	entry.Response.Content = HARContent{
+		Size:     len(respBody),
+		MimeType: resp.Header.Get("Content-Type"),
+		Text:     text,
+		Encoding: encoding,
+	}
+	if truncated {
+		entry.Response.Content.Comment = "body truncated"
+	}
+
+	entry.Timings = harTimings(timings)
+	entry.Time = entry.Timings.Send + entry.Timings.Wait + entry.Timings.Receive
+	for _, phase := range []float64{entry.Timings.DNS, entry.Timings.Connect} {
+		if phase > 0 {
+			entry.Time += phase
+		}
+	}
+	return entry
+}
+
+// harHeaders lists headers in name order, redacting sensitive values
+func harHeaders(header http.Header) []HARNameValue {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	headers := make([]HARNameValue, 0, len(names))
+	for _, name := range names {
+		for _, value := range header[name] {
+			if sensitiveHeaders[strings.ToLower(name)] {
+				value = "[REDACTED]"
+			}
+			headers = append(headers, HARNameValue{Name: name, Value: value})
+		}
+	}
+	return headers
+}
+
+// harBody returns a body as HAR text: as is when it is UTF-8, otherwise
+// base64. It reports whether the body was cut at maxHARBodyBytes.
+func harBody(body []byte) (text, encoding string, truncated bool) {
+	if len(body) > maxHARBodyBytes {
+		body = body[:maxHARBodyBytes]
+		truncated = true
+	}
+	if utf8.Valid(body) {
+		return string(body), "", truncated
+	}
+	if truncated {
+		// The cut may have split a character of a text body
+		for cut := 1; cut < utf8.UTFMax && cut < len(body); cut++ {
+			if utf8.Valid(body[:len(body)-cut]) {
+				return string(body[:len(body)-cut]), "", truncated
+			}
+		}
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64", truncated
+}
+
+// harTimings maps the request's timings onto HAR phases. Sending the
+// request isn't timed separately, so it is counted in wait.
+func harTimings(t *Timings) HARTimings {
+	timings := HARTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1}
+	if t == nil {
+		return timings
+	}
+	if !t.ConnectionReuse {
+		timings.DNS = t.DNS
+		timings.Connect = t.Connect + t.TLS
+		if t.TLS > 0 {
+			timings.SSL = t.TLS
+		}
+	}
+	timings.Wait = roundMilliseconds(math.Max(t.TTFB-t.DNS-t.Connect-t.TLS, 0))
+	timings.Receive = roundMilliseconds(math.Max(t.Total-t.TTFB, 0))
+	return timings
+}
+
+// roundMilliseconds keeps microsecond precision after subtracting phases
+func roundMilliseconds(ms float64) float64 {
+	return math.Round(ms*1000) / 1000
+}
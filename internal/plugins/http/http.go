@@ -10,14 +10,17 @@ import (
 	"net/http"
 	"net/url"
 	"sort"
+	"strconv"
 	"strings"
 
-	"github.com/itchyny/gojq"
 	"go.temporal.io/sdk/activity"
 	"go.temporal.io/sdk/temporal"
 
+	"github.com/rocketship-ai/rocketship/internal/assertions"
 	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
 	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
 )
 
 // Auto-register the plugin when the package is imported
@@ -27,12 +30,18 @@ func init() {
 
 // ActivityResponse represents the response from the HTTP activity
 type ActivityResponse struct {
-	Response          *HTTPResponse         `json:"response"`
-	Saved             map[string]string     `json:"saved"`
-	UIPayload         *UIPayload            `json:"ui_payload,omitempty"`
-	AssertionResults  []HTTPAssertionResult `json:"assertion_results,omitempty"`
-	AssertionFailed   bool                  `json:"assertion_failed,omitempty"`
-	AssertionError    string                `json:"assertion_error,omitempty"`
+	Response         *HTTPResponse         `json:"response"`
+	Saved            map[string]string     `json:"saved"`
+	UIPayload        *UIPayload            `json:"ui_payload,omitempty"`
+	AssertionResults []HTTPAssertionResult `json:"assertion_results,omitempty"`
+	AssertionFailed  bool                  `json:"assertion_failed,omitempty"`
+	AssertionError   string                `json:"assertion_error,omitempty"`
+	Attempts         int                   `json:"attempts,omitempty"`
+	Timings          *Timings              `json:"timings,omitempty"`
+	// CookieJar holds the cookies this step added to the test's cookie jar
+	CookieJar []CookieRecord `json:"cookie_jar,omitempty"`
+	// HAREntry records the exchange for the test's HAR file, when it keeps one
+	HAREntry *HAREntry `json:"har_entry,omitempty"`
 }
 
 // replaceVariables replaces {{ variable }} patterns in the input string with values from the state
@@ -172,12 +181,33 @@ func (hp *HTTPPlugin) Activity(ctx context.Context, p map[string]interface{}) (i
 		}
 	}
 
+	retryPolicy, err := parseRetryPolicy(configData)
+	if err != nil {
+		return nil, err
+	}
+	bodyFormat, err := parseBodyFormat(configData)
+	if err != nil {
+		return nil, err
+	}
+	streamCfg, err := parseStreamConfig(configData)
+	if err != nil {
+		return nil, err
+	}
+	authCfg, err := parseAuth(configData, state, env)
+	if err != nil {
+		return nil, err
+	}
+	transportCfg, err := parseTransportConfig(configData, state, env)
+	if err != nil {
+		return nil, err
+	}
+
 	// Replace variables in URL
 	urlStr, ok := configData["url"].(string)
 	if !ok {
 		return nil, fmt.Errorf("url is required")
 	}
-	urlStr, err := replaceVariables(urlStr, state, env)
+	urlStr, err = replaceVariables(urlStr, state, env)
 	if err != nil {
 		return nil, fmt.Errorf("failed to replace variables in URL: %w", err)
 	}
@@ -252,6 +282,13 @@ func (hp *HTTPPlugin) Activity(ctx context.Context, p map[string]interface{}) (i
 	if isForm && req.Header.Get("Content-Type") == "" {
 		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	}
+	// Likewise for bodies with a declared format
+	if !isForm && body != nil && bodyFormat != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", bodyFormatContentTypes[bodyFormat])
+	}
+	if streamCfg != nil && streamCfg.format != StreamFormatLines && req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "text/event-stream")
+	}
 
 	// Debug: Print the final HTTP request details
 	logger.Info("=== HTTP REQUEST DEBUG ===")
@@ -286,30 +323,81 @@ func (hp *HTTPPlugin) Activity(ctx context.Context, p map[string]interface{}) (i
 		openapiValidator = validator
 	}
 
+	// Contract results lead the step's assertion results
+	var contractResults []HTTPAssertionResult
 	if openapiValidator != nil {
 		if err := openapiValidator.prepareRequestValidation(ctx, req, reqBodyBytes); err != nil {
 			return nil, err
 		}
 		if openapiValidator.shouldValidateRequest() {
-			if err := openapiValidator.validateRequest(ctx); err != nil {
-				return nil, err
+			result := openapiValidator.openAPIResult("request", openapiValidator.validateRequest(ctx))
+			if !result.Passed {
+				// The request isn't sent, so there is no response to show
+				return nil, temporal.NewApplicationError(result.Message, "http_assertion_failed", map[string]interface{}{
+					"assertion_results": []HTTPAssertionResult{result},
+				})
 			}
+			contractResults = append(contractResults, result)
 		}
 	}
 
-	// Send request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	// Send request through the tenant's egress policy (unrestricted when none applies)
+	policy, err := egress.FromParams(p)
 	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
+	client := &http.Client{Transport: policy.Transport()}
+	if transportCfg != nil {
+		if err := transportCfg.apply(client, policy); err != nil {
+			return nil, err
+		}
+	}
+	timer := &timingTransport{base: client.Transport}
+	client.Transport = timer
+	if authCfg != nil {
+		client.Transport = authCfg.transport(client.Transport)
+	}
+	jar, err := buildCookieJar(p)
+	if err != nil {
+		return nil, err
+	}
+	// The client adds jar cookies as it sends, so note them for the UI payload
+	var jarCookies []string
+	if jar != nil {
+		client.Jar = jar
+		for _, c := range jar.Cookies(req.URL) {
+			jarCookies = append(jarCookies, c.String())
+		}
+	}
+	resp, attempts, err := sendWithRetry(ctx, logger, client, req, retryPolicy)
+	if err != nil {
+		if attempts > 1 {
+			return nil, fmt.Errorf("failed to send request after %d attempts: %w", attempts, err)
+		}
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
-	// Read response body
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+	// Read response body. A stream is read into events, and assertions and
+	// saves see those instead of the raw body.
+	var respBody, rawBody []byte
+	if streamCfg != nil {
+		result, raw, err := readStream(resp, streamCfg)
+		if err != nil {
+			return nil, err
+		}
+		if respBody, err = json.Marshal(result); err != nil {
+			return nil, fmt.Errorf("failed to encode stream events: %w", err)
+		}
+		rawBody = raw
+	} else {
+		if respBody, err = io.ReadAll(resp.Body); err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+		rawBody = respBody
 	}
+	timings := timer.finish()
+	logger.Info("HTTP request timings", "dns_ms", timings.DNS, "connect_ms", timings.Connect, "tls_ms", timings.TLS, "ttfb_ms", timings.TTFB, "total_ms", timings.Total)
 
 	// Create response object
 	response := &HTTPResponse{
@@ -326,17 +414,27 @@ func (hp *HTTPPlugin) Activity(ctx context.Context, p map[string]interface{}) (i
 	}
 
 	if openapiValidator != nil && openapiValidator.shouldValidateResponse() {
-		if err := openapiValidator.validateResponse(ctx, resp, respBody); err != nil {
-			return nil, err
-		}
+		contractResults = append(contractResults, openapiValidator.openAPIResult("response", openapiValidator.validateResponse(ctx, resp, rawBody)))
 	}
 
 	// Process assertions - collect results without failing the activity
-	assertionResults, assertionFailed, assertionError := hp.processAssertionsWithResults(p, resp, respBody)
+	assertionResults, assertionFailed, assertionError := hp.processAssertionsWithResults(p, resp, respBody, timings)
+	if len(contractResults) > 0 {
+		assertionResults = append(contractResults, assertionResults...)
+		if last := contractResults[len(contractResults)-1]; !last.Passed {
+			// A broken contract is the headline failure
+			assertionFailed = true
+			if assertionError == "" {
+				assertionError = last.Message
+			} else {
+				assertionError = last.Message + "; " + assertionError
+			}
+		}
+	}
 
 	// Process saves - we still do this even if assertions failed so we capture all data
 	saved := make(map[string]string)
-	if err := hp.processSaves(p, resp, respBody, saved); err != nil {
+	if err := hp.processSaves(p, resp, respBody, timings, saved); err != nil {
 		return nil, err
 	}
 
@@ -350,11 +448,17 @@ func (hp *HTTPPlugin) Activity(ctx context.Context, p map[string]interface{}) (i
 			reqHeaders[key] = values[0]
 		}
 	}
+	if len(jarCookies) > 0 {
+		if explicit := reqHeaders["Cookie"]; explicit != "" {
+			jarCookies = append([]string{explicit}, jarCookies...)
+		}
+		reqHeaders["Cookie"] = strings.Join(jarCookies, "; ")
+	}
 
 	// Truncate request body if needed
 	reqBodyStr, reqTruncated, reqOrigBytes := truncateBody(string(reqBodyBytes))
 	// Truncate response body if needed
-	respBodyStr, respTruncated, respOrigBytes := truncateBody(string(respBody))
+	respBodyStr, respTruncated, respOrigBytes := truncateBody(string(rawBody))
 
 	uiPayload := &UIPayload{
 		Request: &UIRequestData{
@@ -371,9 +475,21 @@ func (hp *HTTPPlugin) Activity(ctx context.Context, p map[string]interface{}) (i
 			Body:          respBodyStr,
 			BodyTruncated: respTruncated,
 			BodyBytes:     respOrigBytes,
+			Timings:       timings,
 		},
 	}
 
+	var harEntry *HAREntry
+	if harEnabled(p) {
+		harHeader := req.Header.Clone()
+		if len(jarCookies) > 0 {
+			harHeader.Add("Cookie", strings.Join(jarCookies, "; "))
+		}
+		stepName, _ := p["name"].(string)
+		entry := buildHAREntry(stepName, timer.startedAt(), req, harHeader, reqBodyBytes, resp, rawBody, timings)
+		harEntry = &entry
+	}
+
 	// Preserve Temporal retry semantics for assertion failures:
 	// return a retryable activity error so Temporal can perform retries according to the step retry policy.
 	// Include rich UI payload + assertion results + saved values as error details so the workflow can persist them.
@@ -382,6 +498,14 @@ func (hp *HTTPPlugin) Activity(ctx context.Context, p map[string]interface{}) (i
 			"ui_payload":        uiPayload,
 			"assertion_results": assertionResults,
 			"saved":             saved,
+			"attempts":          attempts,
+			"timings":           timings,
+		}
+		if jar != nil {
+			details["cookie_jar"] = jar.newCookies()
+		}
+		if harEntry != nil {
+			details["har_entry"] = harEntry
 		}
 		return nil, temporal.NewApplicationError(assertionError, "http_assertion_failed", details)
 	}
@@ -393,18 +517,22 @@ func (hp *HTTPPlugin) Activity(ctx context.Context, p map[string]interface{}) (i
 		AssertionResults: assertionResults,
 		AssertionFailed:  false,
 		AssertionError:   "",
+		Attempts:         attempts,
+		Timings:          timings,
+		CookieJar:        jar.newCookies(),
+		HAREntry:         harEntry,
 	}, nil
 }
 
-func (hp *HTTPPlugin) processSaves(p map[string]interface{}, resp *http.Response, respBody []byte, saved map[string]string) error {
-	saves, ok := p["save"].([]interface{})
+func (hp *HTTPPlugin) processSaves(p map[string]interface{}, resp *http.Response, respBody []byte, timings *Timings, saved map[string]string) error {
+	saveConfigs, ok := p["save"].([]interface{})
 	if !ok {
 		log.Printf("[DEBUG] No saves configured")
 		return nil
 	}
 
-	log.Printf("[DEBUG] Processing %d saves", len(saves))
-	for _, save := range saves {
+	log.Printf("[DEBUG] Processing %d saves", len(saveConfigs))
+	for _, save := range saveConfigs {
 		saveMap, ok := save.(map[string]interface{})
 		if !ok {
 			return fmt.Errorf("invalid save format: got type %T", save)
@@ -430,15 +558,12 @@ func (hp *HTTPPlugin) processSaves(p map[string]interface{}, resp *http.Response
 				return fmt.Errorf("failed to parse response body as JSON for save: %w", err)
 			}
 
-			query, err := gojq.Parse(jsonPath)
+			v, found, err := saves.Extract(jsonPath, jsonData)
 			if err != nil {
-				log.Printf("[ERROR] Failed to parse jq expression: %v", err)
-				return fmt.Errorf("failed to parse jq expression %q: %w", jsonPath, err)
+				log.Printf("[ERROR] Error evaluating jq expression: %v", err)
+				return fmt.Errorf("error evaluating jq expression %q: %w", jsonPath, err)
 			}
-
-			iter := query.Run(jsonData)
-			v, ok := iter.Next()
-			if !ok {
+			if !found {
 				if required {
 					log.Printf("[ERROR] No results from required jq expression %q. Response body: %s", jsonPath, string(respBody))
 					return fmt.Errorf("no results from required jq expression %q", jsonPath)
@@ -446,34 +571,43 @@ func (hp *HTTPPlugin) processSaves(p map[string]interface{}, resp *http.Response
 				log.Printf("[WARN] No results from optional jq expression %q, skipping save", jsonPath)
 				continue
 			}
-			if err, ok := v.(error); ok {
-				log.Printf("[ERROR] Error evaluating jq expression: %v", err)
-				return fmt.Errorf("error evaluating jq expression %q: %w", jsonPath, err)
+			if v == nil && required {
+				return fmt.Errorf("required value for %q is null", as)
 			}
 
-			// Improved type handling for different value types
-			switch val := v.(type) {
-			case string:
-				saved[as] = val
-			case float64:
-				saved[as] = fmt.Sprintf("%.0f", val) // Remove decimal for whole numbers
-			case bool:
-				saved[as] = fmt.Sprintf("%t", val)
-			case nil:
+			value, err := saves.Format(v, saves.Type(saveMap))
+			if err != nil {
+				return fmt.Errorf("failed to save %s: %w", as, err)
+			}
+			saved[as] = value
+			log.Printf("[DEBUG] Saved value for %s: %s (type: %T)", as, saved[as], v)
+			continue
+		}
+
+		// Handle XPath save
+		if xpathExpr, ok := saveMap["xpath"].(string); ok && xpathExpr != "" {
+			log.Printf("[DEBUG] Processing XPath save: '%s' as %s", xpathExpr, as)
+			namespaces, err := xmlNamespaces(p)
+			if err != nil {
+				return err
+			}
+			v, found, err := queryXPath(respBody, xpathExpr, namespaces)
+			if err != nil {
+				return err
+			}
+			if !found {
 				if required {
-					return fmt.Errorf("required value for %q is null", as)
+					return fmt.Errorf("no results from required xpath expression %q", xpathExpr)
 				}
-				saved[as] = ""
-			default:
-				// For complex types, use JSON marshaling
-				bytes, err := json.Marshal(val)
-				if err != nil {
-					log.Printf("[ERROR] Failed to marshal value: %v", err)
-					return fmt.Errorf("failed to marshal value for %s: %w", as, err)
-				}
-				saved[as] = string(bytes)
+				log.Printf("[WARN] No results from optional xpath expression %q, skipping save", xpathExpr)
+				continue
 			}
-			log.Printf("[DEBUG] Saved value for %s: %s (type: %T)", as, saved[as], v)
+			value, err := saves.Format(v, saves.Type(saveMap))
+			if err != nil {
+				return fmt.Errorf("failed to save %s: %w", as, err)
+			}
+			saved[as] = value
+			log.Printf("[DEBUG] Saved value for %s: %s", as, saved[as])
 			continue
 		}
 
@@ -485,12 +619,32 @@ func (hp *HTTPPlugin) processSaves(p map[string]interface{}, resp *http.Response
 				log.Printf("[ERROR] Required header %s not found in response", headerName)
 				return fmt.Errorf("required header %s not found in response", headerName)
 			}
+			if typ := saves.Type(saveMap); typ != "" && headerValue != "" {
+				value, err := saves.Format(headerValue, typ)
+				if err != nil {
+					return fmt.Errorf("failed to save %s: %w", as, err)
+				}
+				headerValue = value
+			}
 			saved[as] = headerValue
 			log.Printf("[DEBUG] Saved value for %s: %s", as, saved[as])
 			continue
 		}
 
-		return fmt.Errorf("save configuration must specify either json_path or header")
+		// Handle timing save
+		if phase, ok := saveMap["timing"].(string); ok && phase != "" {
+			if timings == nil {
+				return fmt.Errorf("no timings recorded for timing save %s", as)
+			}
+			ms, ok := timings.phase(phase)
+			if !ok {
+				return fmt.Errorf("unknown timing %q for save %s: use dns, connect, tls, ttfb or total", phase, as)
+			}
+			saved[as] = strconv.FormatFloat(ms, 'f', -1, 64)
+			continue
+		}
+
+		return fmt.Errorf("save configuration must specify json_path, xpath, header or timing")
 	}
 
 	log.Printf("[DEBUG] Final saved values: %v", saved)
@@ -499,9 +653,9 @@ func (hp *HTTPPlugin) processSaves(p map[string]interface{}, resp *http.Response
 
 // processAssertionsWithResults evaluates all assertions and returns structured results
 // Returns (results, hasFailed, errorSummary) - never returns an error so the activity can complete
-func (hp *HTTPPlugin) processAssertionsWithResults(p map[string]interface{}, resp *http.Response, respBody []byte) ([]HTTPAssertionResult, bool, string) {
-	assertions, ok := p["assertions"].([]interface{})
-	if !ok || len(assertions) == 0 {
+func (hp *HTTPPlugin) processAssertionsWithResults(p map[string]interface{}, resp *http.Response, respBody []byte, timings *Timings) ([]HTTPAssertionResult, bool, string) {
+	assertionList, ok := p["assertions"].([]interface{})
+	if !ok || len(assertionList) == 0 {
 		return nil, false, ""
 	}
 
@@ -545,7 +699,7 @@ func (hp *HTTPPlugin) processAssertionsWithResults(p map[string]interface{}, res
 	var hasFailed bool
 	var failedMessages []string
 
-	for _, assertion := range assertions {
+	for _, assertion := range assertionList {
 		assertionMap, ok := assertion.(map[string]interface{})
 		if !ok {
 			results = append(results, HTTPAssertionResult{
@@ -570,12 +724,23 @@ func (hp *HTTPPlugin) processAssertionsWithResults(p map[string]interface{}, res
 			continue
 		}
 
-		// Replace variables in expected value if it's a string
+		// Replace variables in expected value if it's a string, or in the strings of a list
 		expected := assertionMap["expected"]
 		if expectedStr, ok := expected.(string); ok {
 			if replaced, err := replaceVariables(expectedStr, state, env); err == nil {
 				expected = replaced
 			}
+		} else if expectedList, ok := expected.([]interface{}); ok {
+			replacedList := make([]interface{}, len(expectedList))
+			for i, item := range expectedList {
+				replacedList[i] = item
+				if itemStr, ok := item.(string); ok {
+					if replaced, err := replaceVariables(itemStr, state, env); err == nil {
+						replacedList[i] = replaced
+					}
+				}
+			}
+			expected = replacedList
 		}
 
 		result := HTTPAssertionResult{
@@ -623,106 +788,53 @@ func (hp *HTTPPlugin) processAssertionsWithResults(p map[string]interface{}, res
 				}
 			}
 
-		case AssertionTypeJSONPath:
-			path, ok := assertionMap["path"].(string)
-			if !ok {
+		case AssertionTypeJSONSchema:
+			result = evaluateJSONSchema(p, assertionMap, respBody, expected)
+
+		case AssertionTypeResponseTime:
+			result = evaluateResponseTime(assertionMap, timings, expected)
+
+		case AssertionTypeXPath:
+			// Replace variables in path
+			if path, ok := assertionMap["path"].(string); ok {
+				if replaced, err := replaceVariables(path, state, env); err == nil {
+					assertionMap = withPath(assertionMap, replaced)
+				}
+			}
+			result = evaluateXPathAssertion(p, assertionMap, respBody, expected)
+
+		default:
+			if !assertions.IsShared(assertionType) {
 				result.Passed = false
-				result.Message = "path is required for JSONPath assertion"
-			} else {
-				// Replace variables in path
+				result.Message = fmt.Sprintf("unknown assertion type: %s", assertionType)
+				break
+			}
+
+			// Replace variables in path
+			if path, ok := assertionMap["path"].(string); ok {
 				if replaced, err := replaceVariables(path, state, env); err == nil {
-					path = replaced
+					assertionMap = withPath(assertionMap, replaced)
 				}
-				result.Path = path
+			}
 
-				var jsonData interface{}
-				if err := json.Unmarshal(respBody, &jsonData); err != nil {
+			var subject interface{}
+			if err := json.Unmarshal(respBody, &subject); err != nil {
+				if assertionType == AssertionTypeJSONPath {
+					result.Path, _ = assertionMap["path"].(string)
 					result.Passed = false
 					result.Message = fmt.Sprintf("failed to parse response body as JSON: %v", err)
-				} else {
-					query, err := gojq.Parse(path)
-					if err != nil {
-						result.Passed = false
-						result.Message = fmt.Sprintf("failed to parse jq expression: %v", err)
-					} else {
-						iter := query.Run(jsonData)
-						var queryResult interface{}
-						var found bool
-
-						for {
-							v, ok := iter.Next()
-							if !ok {
-								break
-							}
-							if err, ok := v.(error); ok {
-								result.Passed = false
-								result.Message = fmt.Sprintf("error evaluating jq expression: %v", err)
-								break
-							}
-							if !found {
-								queryResult = v
-								found = true
-							}
-						}
-
-						result.Actual = queryResult
-
-						// If we're just checking existence
-						if exists, ok := assertionMap["exists"].(bool); ok && exists {
-							if found {
-								result.Passed = true
-							} else {
-								result.Passed = false
-								result.Message = fmt.Sprintf("path %q does not exist", path)
-							}
-						} else if !found {
-							result.Passed = false
-							result.Message = fmt.Sprintf("no results from jq expression %q", path)
-						} else if expected != nil {
-							// Compare values
-							equal := false
-							switch v := queryResult.(type) {
-							case int:
-								if exp, ok := expected.(float64); ok {
-									equal = float64(v) == exp
-								} else if exp, ok := expected.(int); ok {
-									equal = v == exp
-								}
-							case float64:
-								if exp, ok := expected.(float64); ok {
-									equal = v == exp
-								} else if exp, ok := expected.(int); ok {
-									equal = v == float64(exp)
-								}
-							case string:
-								if exp, ok := expected.(string); ok {
-									equal = v == exp
-								}
-							default:
-								equal = queryResult == expected
-							}
-
-							if equal {
-								result.Passed = true
-							} else {
-								result.Passed = false
-								result.Message = fmt.Sprintf("expected %v, got %v", expected, queryResult)
-							}
-						} else {
-							// No expected value and we found a result
-							result.Passed = true
-						}
-					}
+					break
 				}
+				// Other shared assertions can run against a plain-text body
+				subject = string(respBody)
 			}
-
-		default:
-			result.Passed = false
-			result.Message = fmt.Sprintf("unknown assertion type: %s", assertionType)
+			result = assertions.Evaluate(assertionMap, subject, expected)
 		}
 
+		// Soft failures are reported by the workflow at the end of the test
+		result.Soft = assertions.IsSoft(assertionMap)
 		results = append(results, result)
-		if !result.Passed {
+		if !result.Passed && !result.Soft {
 			hasFailed = true
 			if result.Message != "" {
 				failedMessages = append(failedMessages, fmt.Sprintf("%s: %s", result.Type, result.Message))
@@ -742,9 +854,19 @@ func (hp *HTTPPlugin) processAssertionsWithResults(p map[string]interface{}, res
 	return results, hasFailed, errorSummary
 }
 
+// withPath returns a copy of the assertion with its path replaced
+func withPath(assertion map[string]interface{}, path string) map[string]interface{} {
+	copied := make(map[string]interface{}, len(assertion))
+	for k, v := range assertion {
+		copied[k] = v
+	}
+	copied["path"] = path
+	return copied
+}
+
 // processAssertions is kept for backward compatibility but now uses the new implementation
 func (hp *HTTPPlugin) processAssertions(p map[string]interface{}, resp *http.Response, respBody []byte) error {
-	results, hasFailed, errorSummary := hp.processAssertionsWithResults(p, resp, respBody)
+	results, hasFailed, errorSummary := hp.processAssertionsWithResults(p, resp, respBody, nil)
 	if hasFailed {
 		// Find first failure for detailed error message
 		for _, r := range results {
@@ -6,15 +6,21 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+	"unicode/utf8"
+
+	tlog "go.temporal.io/sdk/log"
 )
 
 func TestHTTPPlugin_GetType(t *testing.T) {
@@ -398,7 +404,7 @@ func TestHTTPPlugin_ProcessSaves(t *testing.T) {
 				Header: tt.headers,
 			}
 			saved := make(map[string]string)
-			err := plugin.processSaves(tt.params, resp, tt.body, saved)
+			err := plugin.processSaves(tt.params, resp, tt.body, nil, saved)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("processSaves() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -1344,3 +1350,953 @@ func TestHTTPPlugin_ErrorHandling(t *testing.T) {
 		})
 	}
 }
+
+func testLogger() tlog.Logger {
+	return tlog.NewStructuredLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestSendWithRetry(t *testing.T) {
+	var calls atomic.Int32
+	var bodies sync.Map
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		bodies.Store(n, string(body))
+		switch {
+		case r.URL.Path == "/flaky" && n < 3:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case r.URL.Path == "/limited" && n == 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case r.URL.Path == "/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	newRequest := func(path string) *http.Request {
+		req, err := http.NewRequest(http.MethodPost, server.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		setRequestBody(req, []byte(`{"order":1}`))
+		return req
+	}
+	fast := func(retry map[string]interface{}) *retryPolicy {
+		retry["initial_interval"] = "1ms"
+		policy, err := parseRetryPolicy(map[string]interface{}{"retry": retry})
+		if err != nil {
+			t.Fatalf("parseRetryPolicy() error = %v", err)
+		}
+		return policy
+	}
+
+	tests := []struct {
+		name         string
+		path         string
+		policy       *retryPolicy
+		wantStatus   int
+		wantAttempts int
+	}{
+		{"no retry block sends once", "/flaky", nil, 503, 1},
+		{"retries until success", "/flaky", fast(map[string]interface{}{}), 200, 3},
+		{"gives up after maximum_attempts", "/flaky", fast(map[string]interface{}{"maximum_attempts": float64(2)}), 503, 2},
+		{"honours Retry-After", "/limited", fast(map[string]interface{}{}), 200, 2},
+		{"500 is not retried by default", "/broken", fast(map[string]interface{}{}), 500, 1},
+		{"status classes", "/broken", fast(map[string]interface{}{"on_status": []interface{}{"5xx"}}), 500, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls.Store(0)
+			bodies = sync.Map{}
+			resp, attempts, err := sendWithRetry(context.Background(), testLogger(), server.Client(), newRequest(tt.path), tt.policy)
+			if err != nil {
+				t.Fatalf("sendWithRetry() error = %v", err)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus || attempts != tt.wantAttempts || int(calls.Load()) != tt.wantAttempts {
+				t.Fatalf("status = %d, attempts = %d, calls = %d; want %d after %d attempts", resp.StatusCode, attempts, calls.Load(), tt.wantStatus, tt.wantAttempts)
+			}
+			bodies.Range(func(key, value interface{}) bool {
+				if value != `{"order":1}` {
+					t.Errorf("attempt %v sent body %q", key, value)
+				}
+				return true
+			})
+		})
+	}
+}
+
+func TestSendWithRetryNetworkErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serverURL := server.URL
+	server.Close()
+
+	for _, tt := range []struct {
+		onNetworkError bool
+		wantAttempts   int
+	}{{true, 3}, {false, 1}} {
+		policy, err := parseRetryPolicy(map[string]interface{}{"retry": map[string]interface{}{
+			"initial_interval": "1ms",
+			"on_network_error": tt.onNetworkError,
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, _ := http.NewRequest(http.MethodGet, serverURL, nil)
+		_, attempts, err := sendWithRetry(context.Background(), testLogger(), http.DefaultClient, req, policy)
+		if err == nil || attempts != tt.wantAttempts {
+			t.Errorf("on_network_error=%v: attempts = %d, err = %v; want %d attempts and an error", tt.onNetworkError, attempts, err, tt.wantAttempts)
+		}
+	}
+
+	// Cancelling the step stops the retries
+	policy, _ := parseRetryPolicy(map[string]interface{}{"retry": map[string]interface{}{"initial_interval": "1h", "maximum_interval": "1h"}})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, serverURL, nil)
+	_, attempts, err := sendWithRetry(ctx, testLogger(), http.DefaultClient, req, policy)
+	if err == nil || attempts != 1 || !strings.Contains(err.Error(), "cancelled") {
+		t.Errorf("attempts = %d, err = %v; want a cancelled error after 1 attempt", attempts, err)
+	}
+}
+
+func TestParseRetryPolicy(t *testing.T) {
+	policy, err := parseRetryPolicy(map[string]interface{}{"retry": map[string]interface{}{}})
+	if err != nil {
+		t.Fatalf("parseRetryPolicy() error = %v", err)
+	}
+	if policy.maxAttempts != 3 || !policy.onNetworkError || !policy.retriesStatus(429) || policy.retriesStatus(500) {
+		t.Errorf("unexpected defaults: %+v", policy)
+	}
+	delays := []time.Duration{policy.delay(1, nil), policy.delay(2, nil), policy.delay(3, nil), policy.delay(10, nil)}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 30 * time.Second}
+	for i := range want {
+		if delays[i] != want[i] {
+			t.Errorf("delay(%d) = %s, want %s", i+1, delays[i], want[i])
+		}
+	}
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"120"}}}
+	if got := policy.delay(1, resp); got != 30*time.Second {
+		t.Errorf("Retry-After delay = %s, want it capped at 30s", got)
+	}
+
+	if policy, err := parseRetryPolicy(map[string]interface{}{}); policy != nil || err != nil {
+		t.Errorf("no retry block: policy = %+v, err = %v", policy, err)
+	}
+
+	invalid := []map[string]interface{}{
+		{"maximum_attempts": float64(0)},
+		{"maximum_attempts": 2.5},
+		{"initial_interval": "soon"},
+		{"initial_interval": "10s", "maximum_interval": "1s"},
+		{"backoff_coefficient": 0.5},
+		{"on_status": []interface{}{float64(42)}},
+		{"on_status": []interface{}{"6xx"}},
+		{"on_network_error": "yes"},
+	}
+	for _, retry := range invalid {
+		if _, err := parseRetryPolicy(map[string]interface{}{"retry": retry}); err == nil {
+			t.Errorf("retry %v accepted", retry)
+		}
+	}
+}
+
+func TestJSONSchemaAssertion(t *testing.T) {
+	userSchema := map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"id", "email", "roles"},
+		"properties": map[string]interface{}{
+			"id":    map[string]interface{}{"type": "integer"},
+			"email": map[string]interface{}{"type": "string", "format": "email"},
+			"roles": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"enum": []interface{}{"admin", "member"}},
+			},
+		},
+		"additionalProperties": false,
+	}
+
+	dir := t.TempDir()
+	schemaFile := filepath.Join(dir, "user.schema.json")
+	schemaJSON, _ := json.Marshal(userSchema)
+	if err := os.WriteFile(schemaFile, schemaJSON, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(schemaJSON)
+	}))
+	defer server.Close()
+
+	valid := []byte(`{"id": 7, "email": "ada@example.com", "roles": ["admin"]}`)
+	invalid := []byte(`{"id": "7", "email": "not-an-email", "roles": ["owner"], "extra": true}`)
+
+	tests := []struct {
+		name           string
+		body           []byte
+		assertion      map[string]interface{}
+		wantPassed     bool
+		wantViolations []string
+		wantMessage    string
+	}{
+		{name: "inline schema passes", body: valid, assertion: map[string]interface{}{"expected": userSchema}, wantPassed: true},
+		{name: "file schema passes", body: valid, assertion: map[string]interface{}{"expected": schemaFile}, wantPassed: true},
+		{name: "url schema passes", body: valid, assertion: map[string]interface{}{"expected": server.URL + "/user.schema.json"}, wantPassed: true},
+		{
+			name:      "reports every violation",
+			body:      invalid,
+			assertion: map[string]interface{}{"expected": userSchema},
+			wantViolations: []string{
+				"/id: got string, want integer",
+				"/email: 'not-an-email' is not valid email",
+				"/roles/0: value must be one of 'admin', 'member'",
+				"additional properties 'extra' not allowed",
+			},
+			wantMessage: "4 schema violations",
+		},
+		{
+			name:       "path selects the value to validate",
+			body:       []byte(`{"data": {"id": 7, "email": "ada@example.com", "roles": []}}`),
+			assertion:  map[string]interface{}{"expected": userSchema, "path": ".data"},
+			wantPassed: true,
+		},
+		{
+			name:        "schema declares its own draft",
+			body:        []byte(`{"id": 7}`),
+			assertion:   map[string]interface{}{"expected": map[string]interface{}{"$schema": "http://json-schema.org/draft-07/schema#", "required": []interface{}{"name"}}},
+			wantMessage: "1 schema violation: /: missing property 'name'",
+		},
+		{name: "body is not JSON", body: []byte("<html>"), assertion: map[string]interface{}{"expected": userSchema}, wantMessage: "failed to parse response body as JSON"},
+		{name: "missing schema file", body: valid, assertion: map[string]interface{}{"expected": filepath.Join(dir, "missing.json")}, wantMessage: "failed to compile JSON schema"},
+		{name: "invalid schema", body: valid, assertion: map[string]interface{}{"expected": map[string]interface{}{"type": 5}}, wantMessage: "failed to compile JSON schema"},
+		{name: "expected is not a schema", body: valid, assertion: map[string]interface{}{"expected": float64(5)}, wantMessage: "must be a schema object, a file path or a URL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.assertion["type"] = AssertionTypeJSONSchema
+			result := evaluateJSONSchema(map[string]interface{}{}, tt.assertion, tt.body, tt.assertion["expected"])
+			if result.Passed != tt.wantPassed {
+				t.Fatalf("passed = %v, want %v (message: %s)", result.Passed, tt.wantPassed, result.Message)
+			}
+			if !strings.Contains(result.Message, tt.wantMessage) {
+				t.Errorf("message = %q, want it to contain %q", result.Message, tt.wantMessage)
+			}
+			if tt.wantViolations != nil {
+				violations, _ := result.Actual.([]string)
+				joined := strings.Join(violations, "\n")
+				for _, want := range tt.wantViolations {
+					if !strings.Contains(joined, want) {
+						t.Errorf("violations missing %q:\n%s", want, joined)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestXPathAssertions(t *testing.T) {
+	body := []byte(`<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:o="urn:orders">
+  <soap:Body>
+    <o:Order id="42" status="paid">
+      <o:Item sku="A1"><o:Price>9.99</o:Price></o:Item>
+      <o:Item sku="B2"><o:Price>5</o:Price></o:Item>
+    </o:Order>
+  </soap:Body>
+</soap:Envelope>`)
+	config := map[string]interface{}{
+		"namespaces": map[string]interface{}{"soap": "http://schemas.xmlsoap.org/soap/envelope/", "o": "urn:orders"},
+	}
+	p := map[string]interface{}{"config": config}
+
+	tests := []struct {
+		name        string
+		assertion   map[string]interface{}
+		wantPassed  bool
+		wantActual  interface{}
+		wantMessage string
+	}{
+		{name: "attribute", assertion: map[string]interface{}{"path": "//o:Order/@id", "expected": float64(42)}, wantPassed: true, wantActual: "42"},
+		{name: "element text", assertion: map[string]interface{}{"path": "//o:Item[@sku='A1']/o:Price", "expected": "9.99"}, wantPassed: true, wantActual: "9.99"},
+		{name: "count function", assertion: map[string]interface{}{"path": "count(//o:Item)", "expected": float64(2)}, wantPassed: true, wantActual: float64(2)},
+		{name: "sum function", assertion: map[string]interface{}{"path": "sum(//o:Price)", "expected": "14.99"}, wantPassed: true},
+		{name: "local-name without prefixes", assertion: map[string]interface{}{"path": "//*[local-name()='Order']/@status", "expected": "paid"}, wantPassed: true},
+		{name: "no expected value checks the match", assertion: map[string]interface{}{"path": "//o:Order"}, wantPassed: true},
+		{name: "mismatch", assertion: map[string]interface{}{"path": "//o:Order/@status", "expected": "refunded"}, wantMessage: "expected refunded, got paid"},
+		{name: "exists", assertion: map[string]interface{}{"path": "//o:Refund", "exists": true}, wantMessage: `path "//o:Refund" does not exist`},
+		{name: "no match", assertion: map[string]interface{}{"path": "//o:Refund/@id", "expected": "1"}, wantMessage: `no results from xpath expression "//o:Refund/@id"`},
+		{name: "unknown prefix", assertion: map[string]interface{}{"path": "//x:Order", "expected": "1"}, wantMessage: "failed to parse xpath expression"},
+		{name: "missing path", assertion: map[string]interface{}{"expected": "1"}, wantMessage: "path is required for xpath assertion"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.assertion["type"] = AssertionTypeXPath
+			result := evaluateXPathAssertion(p, tt.assertion, body, tt.assertion["expected"])
+			if result.Passed != tt.wantPassed || !strings.Contains(result.Message, tt.wantMessage) {
+				t.Fatalf("passed = %v, message = %q; want passed = %v, message containing %q", result.Passed, result.Message, tt.wantPassed, tt.wantMessage)
+			}
+			if tt.wantActual != nil && result.Actual != tt.wantActual {
+				t.Errorf("actual = %#v, want %#v", result.Actual, tt.wantActual)
+			}
+		})
+	}
+
+	result := evaluateXPathAssertion(p, map[string]interface{}{"type": AssertionTypeXPath, "path": "//id"}, []byte(`{"id": 1}`), nil)
+	if result.Passed || !strings.Contains(result.Message, "failed to parse response body as XML") {
+		t.Errorf("JSON body: passed = %v, message = %q", result.Passed, result.Message)
+	}
+}
+
+func TestXPathSaves(t *testing.T) {
+	plugin := &HTTPPlugin{}
+	body := []byte(`<order xmlns="urn:orders" id="42"><total currency="EUR">14.99</total></order>`)
+	p := map[string]interface{}{
+		"config": map[string]interface{}{"namespaces": map[string]interface{}{"o": "urn:orders"}},
+		"save": []interface{}{
+			map[string]interface{}{"xpath": "/o:order/@id", "as": "order_id"},
+			map[string]interface{}{"xpath": "/o:order/o:total", "as": "total", "type": "float"},
+			map[string]interface{}{"xpath": "/o:order/o:refund", "as": "refund", "required": false},
+		},
+	}
+	saved := make(map[string]string)
+	if err := plugin.processSaves(p, &http.Response{Header: http.Header{}}, body, nil, saved); err != nil {
+		t.Fatalf("processSaves() error = %v", err)
+	}
+	if saved["order_id"] != "42" || saved["total"] != "14.99" {
+		t.Errorf("saved = %v", saved)
+	}
+	if _, ok := saved["refund"]; ok {
+		t.Errorf("optional save with no match was saved: %v", saved)
+	}
+
+	p["save"] = []interface{}{map[string]interface{}{"xpath": "/o:order/o:refund", "as": "refund"}}
+	err := plugin.processSaves(p, &http.Response{Header: http.Header{}}, body, nil, map[string]string{})
+	if err == nil || !strings.Contains(err.Error(), "no results from required xpath expression") {
+		t.Errorf("required save error = %v", err)
+	}
+}
+
+func TestParseBodyFormat(t *testing.T) {
+	for _, tt := range []struct {
+		config  map[string]interface{}
+		want    string
+		wantErr bool
+	}{
+		{config: map[string]interface{}{}, want: ""},
+		{config: map[string]interface{}{"body_format": "xml"}, want: BodyFormatXML},
+		{config: map[string]interface{}{"body_format": "json"}, want: BodyFormatJSON},
+		{config: map[string]interface{}{"body_format": "yaml"}, wantErr: true},
+		{config: map[string]interface{}{"body_format": true}, wantErr: true},
+	} {
+		got, err := parseBodyFormat(tt.config)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("parseBodyFormat(%v) = %q, %v; want %q, error %v", tt.config, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestCookieJar(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/", MaxAge: 3600})
+			http.SetCookie(w, &http.Cookie{Name: "stale", Value: "x", Path: "/", MaxAge: -1})
+		case "/logout":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "", Path: "/", MaxAge: -1})
+		case "/me":
+			c, err := r.Cookie("session")
+			if err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = fmt.Fprint(w, c.Value)
+		}
+	}))
+	defer server.Close()
+
+	// step sends one request with the jar rebuilt from records, and returns
+	// the records it added after a JSON round trip, as the workflow would
+	step := func(records []interface{}, path string) ([]interface{}, string) {
+		t.Helper()
+		jar, err := buildCookieJar(map[string]interface{}{"cookie_jar": records})
+		if err != nil {
+			t.Fatalf("buildCookieJar() error = %v", err)
+		}
+		client := &http.Client{Jar: jar}
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+
+		encoded, err := json.Marshal(jar.newCookies())
+		if err != nil {
+			t.Fatalf("failed to encode records: %v", err)
+		}
+		var added []interface{}
+		if err := json.Unmarshal(encoded, &added); err != nil {
+			t.Fatalf("failed to decode records: %v", err)
+		}
+		return append(records, added...), string(body)
+	}
+
+	records, _ := step([]interface{}{}, "/login")
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record from login, got %v", records)
+	}
+	cookies := records[0].(map[string]interface{})["cookies"].([]interface{})
+	if !strings.Contains(cookies[0].(string), "Expires=") || strings.Contains(cookies[0].(string), "Max-Age") {
+		t.Errorf("expected Max-Age to be stored as Expires, got %q", cookies[0])
+	}
+
+	records, body := step(records, "/me")
+	if body != "abc" {
+		t.Fatalf("expected the session cookie to be sent, got %q", body)
+	}
+	records, _ = step(records, "/logout")
+	if _, body = step(records, "/me"); body != "" {
+		t.Fatalf("expected the session cookie to be cleared, got %q", body)
+	}
+
+	jar, err := buildCookieJar(map[string]interface{}{})
+	if err != nil || jar != nil {
+		t.Fatalf("expected no jar without cookie_jar, got %v, %v", jar, err)
+	}
+	if _, err := buildCookieJar(map[string]interface{}{"cookie_jar": "session=abc"}); err == nil {
+		t.Fatal("expected an error for a malformed cookie_jar")
+	}
+}
+
+func TestReadStream(t *testing.T) {
+	// The server sends its events then holds the stream open, like a
+	// notification feed
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher := w.(http.Flusher)
+		if r.URL.Path == "/ndjson" {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			_, _ = fmt.Fprint(w, "{\"n\":1}\n\n{\"n\":2}\n")
+		} else {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = fmt.Fprint(w, ": keep-alive\n\nevent: token\nid: 1\ndata: {\"text\":\"Hel\"}\n\n")
+			flusher.Flush()
+			_, _ = fmt.Fprint(w, "data: line one\ndata: line two\n\nevent: done\ndata: [DONE]\n\n")
+		}
+		flusher.Flush()
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	read := func(path string, stream map[string]interface{}) (*StreamResult, error) {
+		t.Helper()
+		sc, err := parseStreamConfig(map[string]interface{}{"stream": stream})
+		if err != nil {
+			t.Fatalf("parseStreamConfig() error = %v", err)
+		}
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		result, _, err := readStream(resp, sc)
+		return result, err
+	}
+
+	result, err := read("/sse", map[string]interface{}{"until": `^\[DONE\]$`, "duration": "2s"})
+	if err != nil {
+		t.Fatalf("readStream() error = %v", err)
+	}
+	if result.Format != StreamFormatSSE || result.EventCount != 3 || !result.Matched || result.StopReason != streamStopUntil {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	first := result.Events[0]
+	if first.Event != "token" || first.ID != "1" || first.JSON.(map[string]interface{})["text"] != "Hel" {
+		t.Errorf("unexpected first event: %+v", first)
+	}
+	if result.Events[1].Data != "line one\nline two" || result.Events[2].Event != "done" {
+		t.Errorf("unexpected events: %+v", result.Events)
+	}
+
+	result, err = read("/sse", map[string]interface{}{"max_events": float64(2)})
+	if err != nil || result.EventCount != 2 || result.StopReason != streamStopMaxEvents {
+		t.Fatalf("max_events: result = %+v, err = %v", result, err)
+	}
+
+	start := time.Now()
+	result, err = read("/ndjson", map[string]interface{}{"duration": "200ms"})
+	if err != nil {
+		t.Fatalf("readStream() error = %v", err)
+	}
+	if result.Format != StreamFormatLines || result.EventCount != 2 || result.StopReason != streamStopDuration {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("duration was not enforced, read took %s", elapsed)
+	}
+
+	_, err = read("/sse", map[string]interface{}{"until": "never", "duration": "200ms"})
+	if err == nil || !strings.Contains(err.Error(), `no stream event matched "never"`) {
+		t.Fatalf("expected an until error, got %v", err)
+	}
+}
+
+func TestParseStreamConfig(t *testing.T) {
+	sc, err := parseStreamConfig(map[string]interface{}{})
+	if err != nil || sc != nil {
+		t.Fatalf("expected no stream config, got %v, %v", sc, err)
+	}
+	sc, err = parseStreamConfig(map[string]interface{}{"stream": map[string]interface{}{}})
+	if err != nil || sc.duration != defaultStreamDuration {
+		t.Fatalf("expected the default duration, got %v, %v", sc, err)
+	}
+	for _, bad := range []map[string]interface{}{
+		{"format": "websocket"},
+		{"duration": "soon"},
+		{"until": "("},
+		{"max_events": float64(0)},
+	} {
+		if _, err := parseStreamConfig(map[string]interface{}{"stream": bad}); err == nil {
+			t.Errorf("stream %v accepted", bad)
+		}
+	}
+}
+
+func TestSignSigV4(t *testing.T) {
+	// Cases from the AWS Signature Version 4 test suite
+	fields := map[string]string{
+		"region":            "us-east-1",
+		"service":           "service",
+		"access_key_id":     "AKIDEXAMPLE",
+		"secret_access_key": "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	cases := map[string]string{
+		"https://example.amazonaws.com/":                             "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		"https://example.amazonaws.com/?Param2=value2&Param1=value1": "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+	}
+	for target, signature := range cases {
+		req, err := http.NewRequest(http.MethodGet, target, nil)
+		if err != nil {
+			t.Fatalf("failed to build request: %v", err)
+		}
+		signSigV4(req, nil, fields, now)
+		want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=" + signature
+		if got := req.Header.Get("Authorization"); got != want {
+			t.Errorf("%s: Authorization = %q\nwant %q", target, got, want)
+		}
+		if req.Header.Get("X-Amz-Date") != "20150830T123600Z" {
+			t.Errorf("%s: X-Amz-Date = %q", target, req.Header.Get("X-Amz-Date"))
+		}
+	}
+}
+
+func TestDigestAuthorization(t *testing.T) {
+	// The examples from RFC 7616 section 3.9.1
+	challenge, ok := parseDigestChallenge([]string{
+		`Digest realm="http-auth@example.org", qop="auth, auth-int", algorithm=MD5, nonce="7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"`,
+		`Digest realm="http-auth@example.org", qop="auth, auth-int", algorithm=SHA-256, nonce="7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"`,
+	})
+	if !ok || challenge.algorithm != "SHA-256" || challenge.qop != "auth" {
+		t.Fatalf("unexpected challenge: %+v", challenge)
+	}
+	const cnonce = "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ"
+	header := challenge.authorization(http.MethodGet, "/dir/index.html", "Mufasa", "Circle of Life", cnonce)
+	if !strings.Contains(header, `response="753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1"`) {
+		t.Errorf("unexpected SHA-256 header: %s", header)
+	}
+	challenge.algorithm = "MD5"
+	header = challenge.authorization(http.MethodGet, "/dir/index.html", "Mufasa", "Circle of Life", cnonce)
+	if !strings.Contains(header, `response="8ca523f5e9506fed4657c9700eebdbec"`) || !strings.Contains(header, `opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"`) {
+		t.Errorf("unexpected MD5 header: %s", header)
+	}
+}
+
+func TestDigestTransport(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		params := parseAuthParams(strings.TrimPrefix(r.Header.Get("Authorization"), "Digest "))
+		if params["username"] != "alice" || string(body) != `{"a":1}` {
+			w.Header().Set("WWW-Authenticate", `Digest realm="test", nonce="abc", qop="auth"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		challenge := &digestChallenge{realm: "test", nonce: "abc", algorithm: "MD5", qop: "auth"}
+		want := parseAuthParams(strings.TrimPrefix(challenge.authorization(r.Method, r.URL.RequestURI(), "alice", "secret", params["cnonce"]), "Digest "))
+		if params["response"] != want["response"] {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: (&authConfig{authType: AuthTypeDigest, fields: map[string]string{"username": "alice", "password": "secret"}}).transport(http.DefaultTransport)}
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/items?x=1", nil)
+	setRequestBody(req, []byte(`{"a":1}`))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || requests.Load() != 2 {
+		t.Fatalf("status = %d after %d requests", resp.StatusCode, requests.Load())
+	}
+}
+
+func TestOAuth2ClientCredentials(t *testing.T) {
+	var tokenRequests atomic.Int64
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests.Add(1)
+		_ = r.ParseForm()
+		id, secret, _ := r.BasicAuth()
+		if r.Form.Get("grant_type") != "client_credentials" || id != "svc" || secret != "shh" || r.Form.Get("audience") != "orders" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"access_token":"tok-1","token_type":"Bearer","expires_in":3600}`)
+	}))
+	defer tokenServer.Close()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer api.Close()
+
+	auth, err := parseAuth(map[string]interface{}{"auth": map[string]interface{}{
+		"type":          "oauth2_client_credentials",
+		"token_url":     tokenServer.URL,
+		"client_id":     "svc",
+		"client_secret": "{{ .env.CLIENT_SECRET }}",
+		"audience":      "orders",
+		"scopes":        []interface{}{"orders:read"},
+	}}, map[string]string{}, map[string]string{"CLIENT_SECRET": "shh"})
+	if err != nil {
+		t.Fatalf("parseAuth() error = %v", err)
+	}
+	client := &http.Client{Transport: auth.transport(http.DefaultTransport)}
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(api.URL)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d", resp.StatusCode)
+		}
+	}
+	if tokenRequests.Load() != 1 {
+		t.Fatalf("expected the token to be cached, got %d token requests", tokenRequests.Load())
+	}
+
+	for _, bad := range []map[string]interface{}{
+		{"type": "kerberos"},
+		{"type": "digest", "username": "alice"},
+		{"type": "sigv4", "region": "us-east-1", "service": "s3", "access_key_id": "AKID", "secret_access_key": 42},
+	} {
+		if _, err := parseAuth(map[string]interface{}{"auth": bad}, nil, nil); err == nil {
+			t.Errorf("auth %v accepted", bad)
+		}
+	}
+}
+
+func TestTransportConfig(t *testing.T) {
+	// get sends a request with the transport block, as the Activity does
+	get := func(transport map[string]interface{}, target string) (*http.Response, error) {
+		t.Helper()
+		tc, err := parseTransportConfig(map[string]interface{}{"transport": transport}, map[string]string{}, map[string]string{"PROXY_PASSWORD": "hunter2"})
+		if err != nil {
+			t.Fatalf("parseTransportConfig() error = %v", err)
+		}
+		client := &http.Client{Transport: http.DefaultTransport}
+		if err := tc.apply(client, nil); err != nil {
+			t.Fatalf("apply() error = %v", err)
+		}
+		resp, err := client.Get(target)
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+		return resp, err
+	}
+
+	t.Run("proxy", func(t *testing.T) {
+		var proxied atomic.Int64
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !r.URL.IsAbs() || r.Header.Get("Proxy-Authorization") == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			proxied.Add(1)
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer proxy.Close()
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer upstream.Close()
+
+		settings := map[string]interface{}{"proxy": map[string]interface{}{
+			"url":      proxy.URL,
+			"username": "tester",
+			"password": "{{ .env.PROXY_PASSWORD }}",
+			"no_proxy": []interface{}{"direct.example.com"},
+		}}
+		resp, err := get(settings, "http://api.example.com/health")
+		if err != nil || resp.StatusCode != http.StatusAccepted || proxied.Load() != 1 {
+			t.Fatalf("expected the request to go through the proxy, got %v, %v", resp, err)
+		}
+
+		tc, _ := parseTransportConfig(map[string]interface{}{"transport": settings}, nil, map[string]string{"PROXY_PASSWORD": "hunter2"})
+		if password, _ := tc.proxy.User.Password(); password != "hunter2" {
+			t.Errorf("proxy password = %q", password)
+		}
+		if !tc.bypassesProxy("direct.example.com") || !tc.bypassesProxy("a.direct.example.com") || tc.bypassesProxy("api.example.com") {
+			t.Errorf("unexpected no_proxy matching for %v", tc.noProxy)
+		}
+	})
+
+	t.Run("tls and http2", func(t *testing.T) {
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Proto", r.Proto)
+		}))
+		server.EnableHTTP2 = true
+		server.StartTLS()
+		defer server.Close()
+
+		if _, err := get(map[string]interface{}{}, server.URL); err == nil {
+			t.Fatal("expected the self-signed certificate to be rejected")
+		}
+		resp, err := get(map[string]interface{}{"tls": map[string]interface{}{"insecure_skip_verify": true}}, server.URL)
+		if err != nil || resp.Header.Get("X-Proto") != "HTTP/2.0" {
+			t.Fatalf("expected HTTP/2 with skip verify, got %v, %v", resp, err)
+		}
+		resp, err = get(map[string]interface{}{"tls": map[string]interface{}{"insecure_skip_verify": true}, "http2": false}, server.URL)
+		if err != nil || resp.Header.Get("X-Proto") != "HTTP/1.1" {
+			t.Fatalf("expected HTTP/1.1 with http2 off, got %v, %v", resp, err)
+		}
+	})
+
+	t.Run("read timeout", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(300 * time.Millisecond)
+		}))
+		defer server.Close()
+		if _, err := get(map[string]interface{}{"read_timeout": "50ms"}, server.URL); err == nil || !strings.Contains(err.Error(), "timeout") {
+			t.Fatalf("expected a timeout, got %v", err)
+		}
+	})
+
+	for _, bad := range []map[string]interface{}{
+		{"proxy": "proxy.internal:3128"},
+		{"http2": "no"},
+		{"connect_timeout": "fast"},
+		{"tls": map[string]interface{}{"insecure_skip_verify": "yes"}},
+	} {
+		if _, err := parseTransportConfig(map[string]interface{}{"transport": bad}, nil, nil); err == nil {
+			t.Errorf("transport %v accepted", bad)
+		}
+	}
+}
+
+func TestTimings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.(http.Flusher).Flush()
+		time.Sleep(30 * time.Millisecond)
+		_, _ = fmt.Fprint(w, "done")
+	}))
+	defer server.Close()
+
+	timer := &timingTransport{base: http.DefaultTransport}
+	client := &http.Client{Transport: timer}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	_, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	timings := timer.finish()
+
+	if timings.TTFB < 50 || timings.Total < timings.TTFB+30 || timings.Connect <= 0 || timings.ConnectionReuse {
+		t.Fatalf("unexpected timings: %+v", timings)
+	}
+
+	plugin := &HTTPPlugin{}
+	p := map[string]interface{}{"assertions": []interface{}{
+		map[string]interface{}{"type": "response_time", "expected": "2s"},
+		map[string]interface{}{"type": "response_time", "phase": "ttfb", "expected": float64(10)},
+	}}
+	results, failed, _ := plugin.processAssertionsWithResults(p, resp, nil, timings)
+	if !failed || !results[0].Passed || results[1].Passed || !strings.HasPrefix(results[1].Message, "ttfb time ") || !strings.HasSuffix(results[1].Message, "ms exceeds 10ms") {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+
+	saved := map[string]string{}
+	p = map[string]interface{}{"save": []interface{}{map[string]interface{}{"timing": "total", "as": "total_ms"}}}
+	if err := plugin.processSaves(p, resp, nil, timings, saved); err != nil {
+		t.Fatalf("processSaves() error = %v", err)
+	}
+	if saved["total_ms"] != strconv.FormatFloat(timings.Total, 'f', -1, 64) {
+		t.Fatalf("saved = %v, timings = %+v", saved, timings)
+	}
+	p = map[string]interface{}{"save": []interface{}{map[string]interface{}{"timing": "queue", "as": "x"}}}
+	if err := plugin.processSaves(p, resp, nil, timings, map[string]string{}); err == nil {
+		t.Fatal("expected an error for an unknown timing")
+	}
+}
+
+func TestOpenAPIContractResult(t *testing.T) {
+	spec := `openapi: 3.1.0
+info:
+  title: Contract API
+  version: 1.0.0
+paths:
+  /widgets/{id}:
+    get:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: string
+      responses:
+        '200':
+          description: OK
+          headers:
+            X-Request-Id:
+              required: true
+              schema:
+                type: string
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [id, name]
+                properties:
+                  id:
+                    type: string
+                  name:
+                    type: string
+`
+	specPath := filepath.Join(t.TempDir(), "contract-openapi.yaml")
+	if err := os.WriteFile(specPath, []byte(spec), 0o600); err != nil {
+		t.Fatalf("failed to write spec: %v", err)
+	}
+
+	validate := func(t *testing.T, status int, header http.Header, body string) HTTPAssertionResult {
+		t.Helper()
+		ctx := context.Background()
+		validator, err := newOpenAPIValidator(ctx, map[string]interface{}{}, map[string]interface{}{"spec": specPath}, nil, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating validator: %v", err)
+		}
+		req := httptest.NewRequest(http.MethodGet, "http://example.com/widgets/42", nil)
+		if err := validator.prepareRequestValidation(ctx, req, nil); err != nil {
+			t.Fatalf("prepareRequestValidation error: %v", err)
+		}
+		resp := &http.Response{StatusCode: status, Header: header}
+		return validator.openAPIResult("response", validator.validateResponse(ctx, resp, []byte(body)))
+	}
+
+	jsonHeader := http.Header{"Content-Type": []string{"application/json"}, "X-Request-Id": []string{"abc"}}
+
+	t.Run("conforming response passes", func(t *testing.T) {
+		result := validate(t, http.StatusOK, jsonHeader, `{"id":"42","name":"gear"}`)
+		if !result.Passed || result.Type != AssertionTypeOpenAPI || result.Name != "response" {
+			t.Fatalf("expected a passing openapi response result, got %+v", result)
+		}
+		if result.Expected != "GET /widgets/{id}" {
+			t.Errorf("expected the matched operation, got %v", result.Expected)
+		}
+	})
+
+	t.Run("schema and header violations", func(t *testing.T) {
+		result := validate(t, http.StatusOK, http.Header{"Content-Type": []string{"application/json"}}, `{"id":42}`)
+		if result.Passed {
+			t.Fatal("expected the response to fail validation")
+		}
+		if !strings.HasPrefix(result.Message, "openapi response validation failed") {
+			t.Errorf("unexpected message %q", result.Message)
+		}
+		violations, ok := result.Actual.([]OpenAPIViolation)
+		if !ok || len(violations) == 0 {
+			t.Fatalf("expected violations, got %#v", result.Actual)
+		}
+		kinds := make(map[string]bool)
+		for _, v := range violations {
+			kinds[v.Kind] = true
+			if v.Message == "" {
+				t.Errorf("violation without a message: %+v", v)
+			}
+		}
+		if !kinds["body"] || !kinds["header"] {
+			t.Errorf("expected body and header violations, got %+v", violations)
+		}
+	})
+
+	t.Run("undeclared status code", func(t *testing.T) {
+		result := validate(t, http.StatusTeapot, jsonHeader, `{}`)
+		violations, _ := result.Actual.([]OpenAPIViolation)
+		if result.Passed || len(violations) != 1 || violations[0].Kind != "status_code" {
+			t.Fatalf("expected a status_code violation, got %+v", result)
+		}
+	})
+}
+
+func TestBuildHAREntry(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/items?b=2&a=1", nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer secret")
+	header := req.Header.Clone()
+	header.Add("Cookie", "session=abc")
+
+	resp := &http.Response{
+		StatusCode: http.StatusCreated,
+		Proto:      "HTTP/1.1",
+		Header:     http.Header{"Content-Type": []string{"application/octet-stream"}, "Location": []string{"/items/1"}},
+	}
+	timings := &Timings{DNS: 1, Connect: 2, TLS: 3, TTFB: 10, Total: 12}
+	started := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	entry := buildHAREntry("create", started, req, header, []byte(`{"name":"gear"}`), resp, []byte{0xff, 0x00}, timings)
+
+	if entry.StartedDateTime != "2026-01-02T03:04:05Z" || entry.Comment != "create" {
+		t.Errorf("unexpected entry metadata: %+v", entry)
+	}
+	for _, h := range entry.Request.Headers {
+		if (h.Name == "Authorization" || h.Name == "Cookie") && h.Value != "[REDACTED]" {
+			t.Errorf("expected %s to be redacted, got %q", h.Name, h.Value)
+		}
+	}
+	if len(entry.Request.QueryString) != 2 || entry.Request.QueryString[0].Name != "a" {
+		t.Errorf("expected sorted query parameters, got %+v", entry.Request.QueryString)
+	}
+	if entry.Request.PostData == nil || entry.Request.PostData.Text != `{"name":"gear"}` || entry.Request.PostData.MimeType != "application/json" {
+		t.Errorf("unexpected post data: %+v", entry.Request.PostData)
+	}
+	if entry.Response.Content.Encoding != "base64" || entry.Response.Content.Text != "/wA=" || entry.Response.Content.Size != 2 {
+		t.Errorf("expected a base64 binary body, got %+v", entry.Response.Content)
+	}
+	if entry.Response.RedirectURL != "/items/1" || entry.Response.StatusText != "Created" {
+		t.Errorf("unexpected response: %+v", entry.Response)
+	}
+	want := HARTimings{Blocked: -1, DNS: 1, Connect: 5, SSL: 3, Wait: 4, Receive: 2}
+	if entry.Timings != want || entry.Time != 12 {
+		t.Errorf("expected timings %+v totalling 12ms, got %+v and %v", want, entry.Timings, entry.Time)
+	}
+
+	reused := harTimings(&Timings{TTFB: 5, Total: 6, ConnectionReuse: true})
+	if reused.DNS != -1 || reused.Connect != -1 || reused.SSL != -1 || reused.Wait != 5 || reused.Receive != 1 {
+		t.Errorf("unexpected timings for a reused connection: %+v", reused)
+	}
+
+	// A three byte character doesn't fit evenly at the cap
+	body, encoding, truncated := harBody([]byte(strings.Repeat("€", maxHARBodyBytes)))
+	if !truncated || encoding != "" || len(body) != maxHARBodyBytes-1 || !utf8.ValidString(body) {
+		t.Errorf("expected a truncated text body of whole characters, got %d bytes, encoding %q", len(body), encoding)
+	}
+}
@@ -0,0 +1,181 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+)
+
+// maxSchemaViolations caps how many violations are listed in the failure message
+const maxSchemaViolations = 20
+
+// inlineSchemaName is the resource name inline schemas are compiled under.
+// It sits in the working directory, so relative $refs resolve like schema paths do.
+const inlineSchemaName = "inline-schema.json"
+
+// schemaURLLoader loads http(s) $refs and schema URLs through the egress policy
+type schemaURLLoader struct {
+	client *http.Client
+}
+
+func (l *schemaURLLoader) Load(location string) (any, error) {
+	resp, err := l.client.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", location, resp.StatusCode)
+	}
+	return jsonschema.UnmarshalJSON(resp.Body)
+}
+
+// compileJSONSchema compiles an inline schema (an object) or loads one from a
+// file path or http(s) URL. Schemas without $schema are read as draft 2020-12.
+func compileJSONSchema(p map[string]interface{}, schema interface{}) (*jsonschema.Schema, error) {
+	policy, err := egress.FromParams(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
+	urlLoader := &schemaURLLoader{client: &http.Client{Transport: policy.Transport(), Timeout: 30 * time.Second}}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.DefaultDraft(jsonschema.Draft2020)
+	compiler.AssertFormat()
+	compiler.UseLoader(jsonschema.SchemeURLLoader{
+		"file":  jsonschema.FileLoader{},
+		"http":  urlLoader,
+		"https": urlLoader,
+	})
+
+	var location string
+	switch s := schema.(type) {
+	case map[string]interface{}, bool:
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve working directory: %w", err)
+		}
+		location = filepath.Join(wd, inlineSchemaName)
+		if err := compiler.AddResource(location, s); err != nil {
+			return nil, fmt.Errorf("invalid JSON schema: %w", err)
+		}
+	case string:
+		location = strings.TrimSpace(s)
+		if location == "" {
+			return nil, fmt.Errorf("json_schema expected value must be a schema object, a file path or a URL")
+		}
+		if u, err := url.Parse(location); err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "file") {
+			// A plain path, relative to the worker's working directory
+			if location, err = filepath.Abs(location); err != nil {
+				return nil, fmt.Errorf("invalid schema path %q: %w", s, err)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("json_schema expected value must be a schema object, a file path or a URL: got type %T", schema)
+	}
+
+	compiled, err := compiler.Compile(location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile JSON schema: %w", err)
+	}
+	return compiled, nil
+}
+
+// schemaViolations validates instance and lists every violation as
+// "<instance location>: <message>"
+func schemaViolations(schema *jsonschema.Schema, instance interface{}) []string {
+	err := schema.Validate(instance)
+	if err == nil {
+		return nil
+	}
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return []string{err.Error()}
+	}
+
+	var violations []string
+	for _, unit := range validationErr.BasicOutput().Errors {
+		if unit.Error == nil {
+			continue
+		}
+		location := unit.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+		violations = append(violations, fmt.Sprintf("%s: %s", location, unit.Error))
+	}
+	if len(violations) == 0 {
+		violations = append(violations, validationErr.Error())
+	}
+	return violations
+}
+
+// evaluateJSONSchema runs a json_schema assertion against the response body,
+// or against the value selected by path
+func evaluateJSONSchema(p map[string]interface{}, assertion map[string]interface{}, respBody []byte, expected interface{}) HTTPAssertionResult {
+	result := HTTPAssertionResult{Type: AssertionTypeJSONSchema}
+	result.Path, _ = assertion["path"].(string)
+	if location, ok := expected.(string); ok {
+		// Keep the location for display; inline schemas are too long to show
+		result.Expected = location
+	}
+
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(respBody))
+	if err != nil {
+		result.Message = fmt.Sprintf("failed to parse response body as JSON: %v", err)
+		return result
+	}
+	if result.Path != "" {
+		// jq works on plain JSON values, so decode again without json.Number
+		var subject interface{}
+		if err := json.Unmarshal(respBody, &subject); err != nil {
+			result.Message = fmt.Sprintf("failed to parse response body as JSON: %v", err)
+			return result
+		}
+		selected, found, err := assertions.Query(result.Path, subject)
+		if err != nil {
+			result.Message = err.Error()
+			return result
+		}
+		if !found {
+			result.Message = fmt.Sprintf("no results from jq expression %q", result.Path)
+			return result
+		}
+		instance = selected
+	}
+
+	schema, err := compileJSONSchema(p, expected)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+
+	violations := schemaViolations(schema, instance)
+	if len(violations) == 0 {
+		result.Passed = true
+		return result
+	}
+
+	result.Actual = violations
+	listed := violations
+	if len(listed) > maxSchemaViolations {
+		listed = append(listed[:maxSchemaViolations:maxSchemaViolations], fmt.Sprintf("and %d more", len(violations)-maxSchemaViolations))
+	}
+	noun := "violations"
+	if len(violations) == 1 {
+		noun = "violation"
+	}
+	result.Message = fmt.Sprintf("%d schema %s: %s", len(violations), noun, strings.Join(listed, "; "))
+	return result
+}
@@ -3,6 +3,7 @@ package http
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"mime"
@@ -855,11 +856,26 @@ func setRequestBody(req *http.Request, body []byte) {
 	}
 }
 
+// openAPIValidationError is a failed request or response validation. Its
+// violations are the structured diff reported in the step's assertion results.
+type openAPIValidationError struct {
+	message    string
+	violations []OpenAPIViolation
+}
+
+func (e *openAPIValidationError) Error() string {
+	return e.message
+}
+
 func formatValidationErrors(prefix string, errs []*validatorErrors.ValidationError) error {
 	if len(errs) == 0 {
-		return fmt.Errorf("%s: unknown validation error", prefix)
+		return &openAPIValidationError{
+			message:    fmt.Sprintf("%s: unknown validation error", prefix),
+			violations: []OpenAPIViolation{{Kind: "unknown", Message: "unknown validation error"}},
+		}
 	}
 	parts := make([]string, 0, len(errs))
+	violations := make([]OpenAPIViolation, 0, len(errs))
 	for _, e := range errs {
 		msg := strings.TrimSpace(e.Message)
 		if msg == "" {
@@ -868,6 +884,7 @@ func formatValidationErrors(prefix string, errs []*validatorErrors.ValidationErr
 		if msg == "" {
 			msg = fmt.Sprintf("%s validation failed", e.ValidationType)
 		}
+		violations = append(violations, openAPIViolations(e, msg)...)
 
 		if len(e.SchemaValidationErrors) > 0 {
 			schemaParts := make([]string, 0, len(e.SchemaValidationErrors))
@@ -886,7 +903,86 @@ func formatValidationErrors(prefix string, errs []*validatorErrors.ValidationErr
 		}
 		parts = append(parts, msg)
 	}
-	return fmt.Errorf("%s: %s", prefix, strings.Join(parts, "; "))
+	return &openAPIValidationError{
+		message:    fmt.Sprintf("%s: %s", prefix, strings.Join(parts, "; ")),
+		violations: violations,
+	}
+}
+
+// openAPIViolations breaks a validator error down into violations, one per
+// schema failure when the error has them. The reason is used as the message
+// when there is one, since it names the header or value at fault.
+func openAPIViolations(e *validatorErrors.ValidationError, msg string) []OpenAPIViolation {
+	violation := OpenAPIViolation{
+		Kind:     openAPIViolationKind(e),
+		Location: e.ParameterName,
+		Message:  msg,
+		Fix:      strings.TrimSpace(e.HowToFix),
+	}
+	if reason := strings.TrimSpace(e.Reason); reason != "" {
+		violation.Message = reason
+	}
+	if e.SpecLine > 0 {
+		violation.SpecLine = e.SpecLine
+	}
+	if len(e.SchemaValidationErrors) == 0 {
+		return []OpenAPIViolation{violation}
+	}
+
+	violations := make([]OpenAPIViolation, 0, len(e.SchemaValidationErrors))
+	for _, se := range e.SchemaValidationErrors {
+		v := violation
+		v.Message = strings.TrimSpace(se.Reason)
+		if v.Location == "" {
+			v.Location = strings.TrimSpace(se.FieldPath)
+		}
+		if v.Location == "" {
+			v.Location = strings.TrimSpace(se.Location)
+		}
+		if se.Line > 0 {
+			v.SpecLine = se.Line
+		}
+		violations = append(violations, v)
+	}
+	return violations
+}
+
+// openAPIViolationKind names what part of the exchange a validator error is about
+func openAPIViolationKind(e *validatorErrors.ValidationError) string {
+	switch {
+	case e.ValidationSubType == "statusCode":
+		return "status_code"
+	case e.ValidationSubType == "contentType":
+		return "content_type"
+	case e.ValidationSubType == "header":
+		return "header"
+	case e.ValidationType == "parameter":
+		return "parameter"
+	case e.ValidationType == "path" || e.ValidationSubType == "missingOperation":
+		return "operation"
+	case e.ValidationSubType == "schema" || e.ValidationType == "requestBody":
+		return "body"
+	}
+	return e.ValidationType
+}
+
+// openAPIResult reports a request or response validation as an assertion
+// result, so contract violations show up with the step's assertions. The
+// expected value is the operation the exchange was matched to.
+func (v *openAPIValidator) openAPIResult(name string, err error) HTTPAssertionResult {
+	result := HTTPAssertionResult{Type: AssertionTypeOpenAPI, Name: name, Passed: err == nil}
+	if v.matchedOp != nil {
+		result.Expected = fmt.Sprintf("%s %s", v.matchedOp.method, v.matchedOp.template)
+	}
+	if err == nil {
+		return result
+	}
+	result.Message = err.Error()
+	var validationErr *openAPIValidationError
+	if errors.As(err, &validationErr) {
+		result.Actual = validationErr.violations
+	}
+	return result
 }
 
 func formatGenericErrors(errs []error) string {
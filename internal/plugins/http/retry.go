@@ -0,0 +1,242 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	tlog "go.temporal.io/sdk/log"
+)
+
+// Retry defaults, used for fields left out of the retry block
+const (
+	defaultRetryMaxAttempts        = 3
+	defaultRetryInitialInterval    = time.Second
+	defaultRetryMaximumInterval    = 30 * time.Second
+	defaultRetryBackoffCoefficient = 2.0
+)
+
+// defaultRetryStatuses are retried when on_status is not set
+var defaultRetryStatuses = []string{"429", "502", "503", "504"}
+
+// retryPolicy is a parsed retry block
+type retryPolicy struct {
+	maxAttempts        int
+	initialInterval    time.Duration
+	maximumInterval    time.Duration
+	backoffCoefficient float64
+	// statuses holds exact codes ("503") and classes ("5xx")
+	statuses       map[string]bool
+	onNetworkError bool
+}
+
+// parseRetryPolicy reads the retry block of the config. It returns nil when
+// the step has no retry block, so the request is sent once.
+func parseRetryPolicy(configData map[string]interface{}) (*retryPolicy, error) {
+	raw, exists := configData["retry"]
+	if !exists || raw == nil {
+		return nil, nil
+	}
+	retryData, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("retry must be an object, got %T", raw)
+	}
+
+	policy := &retryPolicy{
+		maxAttempts:        defaultRetryMaxAttempts,
+		initialInterval:    defaultRetryInitialInterval,
+		maximumInterval:    defaultRetryMaximumInterval,
+		backoffCoefficient: defaultRetryBackoffCoefficient,
+		statuses:           make(map[string]bool),
+		onNetworkError:     true,
+	}
+
+	if v, ok := retryData["maximum_attempts"]; ok {
+		attempts, ok := v.(float64)
+		if !ok || attempts < 1 || attempts != math.Trunc(attempts) {
+			return nil, fmt.Errorf("retry.maximum_attempts must be a whole number of at least 1, got %v", v)
+		}
+		policy.maxAttempts = int(attempts)
+	}
+
+	intervals := map[string]*time.Duration{
+		"initial_interval": &policy.initialInterval,
+		"maximum_interval": &policy.maximumInterval,
+	}
+	for key, target := range intervals {
+		v, ok := retryData[key]
+		if !ok {
+			continue
+		}
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("retry.%s must be a duration string, got %T", key, v)
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("retry.%s must be a non-negative duration, got %q", key, s)
+		}
+		*target = d
+	}
+	if policy.maximumInterval < policy.initialInterval {
+		return nil, fmt.Errorf("retry.maximum_interval (%s) must not be less than initial_interval (%s)", policy.maximumInterval, policy.initialInterval)
+	}
+
+	if v, ok := retryData["backoff_coefficient"]; ok {
+		coefficient, ok := v.(float64)
+		if !ok || coefficient < 1 {
+			return nil, fmt.Errorf("retry.backoff_coefficient must be a number of at least 1, got %v", v)
+		}
+		policy.backoffCoefficient = coefficient
+	}
+
+	statuses := defaultRetryStatuses
+	if v, ok := retryData["on_status"]; ok {
+		list, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("retry.on_status must be a list, got %T", v)
+		}
+		statuses = make([]string, 0, len(list))
+		for _, item := range list {
+			status, err := parseRetryStatus(item)
+			if err != nil {
+				return nil, err
+			}
+			statuses = append(statuses, status)
+		}
+	}
+	for _, status := range statuses {
+		policy.statuses[status] = true
+	}
+
+	if v, ok := retryData["on_network_error"]; ok {
+		onNetworkError, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("retry.on_network_error must be a boolean, got %T", v)
+		}
+		policy.onNetworkError = onNetworkError
+	}
+
+	return policy, nil
+}
+
+// parseRetryStatus accepts a status code or a class such as "5xx"
+func parseRetryStatus(v interface{}) (string, error) {
+	switch status := v.(type) {
+	case float64:
+		if status >= 100 && status <= 599 && status == math.Trunc(status) {
+			return strconv.Itoa(int(status)), nil
+		}
+	case string:
+		status = strings.ToLower(strings.TrimSpace(status))
+		if len(status) == 3 && status[0] >= '1' && status[0] <= '5' {
+			if status[1:] == "xx" {
+				return status, nil
+			}
+			if code, err := strconv.Atoi(status); err == nil {
+				return strconv.Itoa(code), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("retry.on_status entries must be status codes like 503 or classes like 5xx, got %v", v)
+}
+
+// retriesStatus reports whether a response with this status is retried
+func (rp *retryPolicy) retriesStatus(code int) bool {
+	status := strconv.Itoa(code)
+	return rp.statuses[status] || rp.statuses[status[:1]+"xx"]
+}
+
+// delay returns the wait before the given retry (1 for the first retry).
+// A Retry-After header on the response is honoured up to maximum_interval.
+func (rp *retryPolicy) delay(retry int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if wait, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return min(wait, rp.maximumInterval)
+		}
+	}
+	wait := float64(rp.initialInterval) * math.Pow(rp.backoffCoefficient, float64(retry-1))
+	if wait > float64(rp.maximumInterval) {
+		return rp.maximumInterval
+	}
+	return time.Duration(wait)
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+// sendWithRetry sends req, resending it under the retry policy until a
+// response is not retryable or the attempts run out. It returns the last
+// response or error and how many attempts were made. A nil policy sends once.
+func sendWithRetry(ctx context.Context, logger tlog.Logger, client *http.Client, req *http.Request, policy *retryPolicy) (*http.Response, int, error) {
+	if policy == nil {
+		resp, err := client.Do(req)
+		return resp, 1, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+		if attempt > 1 {
+			attemptReq = req.Clone(ctx)
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, attempt - 1, fmt.Errorf("failed to rewind request body: %w", err)
+				}
+				attemptReq.Body = body
+			}
+		}
+
+		resp, err := client.Do(attemptReq)
+
+		var reason string
+		switch {
+		case err != nil && ctx.Err() != nil:
+			// The step timed out or was cancelled; another attempt can't succeed
+			return nil, attempt, err
+		case err != nil && policy.onNetworkError:
+			reason = err.Error()
+		case err == nil && policy.retriesStatus(resp.StatusCode):
+			reason = fmt.Sprintf("status %d", resp.StatusCode)
+		}
+
+		if reason == "" || attempt >= policy.maxAttempts {
+			if reason != "" {
+				logger.Warn("HTTP request failed on final attempt", "attempt", attempt, "maximum_attempts", policy.maxAttempts, "reason", reason)
+			}
+			return resp, attempt, err
+		}
+
+		wait := policy.delay(attempt, resp)
+		logger.Info("HTTP request failed, retrying", "attempt", attempt, "maximum_attempts", policy.maxAttempts, "reason", reason, "delay", wait.String())
+		if resp != nil {
+			// Drain the body so the connection can be reused
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, attempt, fmt.Errorf("retry after attempt %d cancelled: %w", attempt, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Stream formats
+const (
+	StreamFormatSSE   = "sse"
+	StreamFormatLines = "lines"
+)
+
+// defaultStreamDuration caps how long a stream is read when duration isn't set
+const defaultStreamDuration = 30 * time.Second
+
+// maxStreamLineBytes is the longest line a stream may send
+const maxStreamLineBytes = 1024 * 1024
+
+// streamConfig is a parsed stream block
+type streamConfig struct {
+	format    string // empty picks sse or lines from the Content-Type
+	duration  time.Duration
+	until     *regexp.Regexp
+	maxEvents int
+}
+
+// StreamEvent is one server-sent event, or one line of a lines stream
+type StreamEvent struct {
+	Event string      `json:"event,omitempty"`
+	ID    string      `json:"id,omitempty"`
+	Data  string      `json:"data"`
+	JSON  interface{} `json:"json,omitempty"` // Data decoded, when it is JSON
+	At    float64     `json:"at_ms"`          // Milliseconds after the response headers arrived
+}
+
+// StreamResult is what assertions and saves see for a streaming step
+type StreamResult struct {
+	Format     string        `json:"format"`
+	Events     []StreamEvent `json:"events"`
+	EventCount int           `json:"event_count"`
+	Text       string        `json:"text"`    // Every event's data, joined
+	Matched    bool          `json:"matched"` // An event matched until
+	StopReason string        `json:"stop_reason"`
+	Duration   float64       `json:"duration_ms"`
+}
+
+// Reasons a stream stopped being read
+const (
+	streamStopClosed    = "closed"
+	streamStopDuration  = "duration"
+	streamStopUntil     = "until"
+	streamStopMaxEvents = "max_events"
+)
+
+// parseStreamConfig reads the stream block of the config. It returns nil when
+// the step has no stream block, so the whole body is read as usual.
+func parseStreamConfig(configData map[string]interface{}) (*streamConfig, error) {
+	raw, exists := configData["stream"]
+	if !exists || raw == nil {
+		return nil, nil
+	}
+	streamData, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("stream must be an object, got %T", raw)
+	}
+
+	cfg := &streamConfig{duration: defaultStreamDuration}
+	if v, ok := streamData["format"]; ok {
+		format, _ := v.(string)
+		if format != StreamFormatSSE && format != StreamFormatLines {
+			return nil, fmt.Errorf("stream.format must be %q or %q, got %v", StreamFormatSSE, StreamFormatLines, v)
+		}
+		cfg.format = format
+	}
+	if v, ok := streamData["duration"]; ok {
+		s, _ := v.(string)
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("stream.duration must be a positive duration, got %v", v)
+		}
+		cfg.duration = d
+	}
+	if v, ok := streamData["until"]; ok {
+		pattern, _ := v.(string)
+		if pattern == "" {
+			return nil, fmt.Errorf("stream.until must be a regular expression, got %v", v)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("stream.until is not a valid regular expression: %w", err)
+		}
+		cfg.until = re
+	}
+	if v, ok := streamData["max_events"]; ok {
+		n, ok := v.(float64)
+		if !ok || n < 1 || n != math.Trunc(n) {
+			return nil, fmt.Errorf("stream.max_events must be a whole number of at least 1, got %v", v)
+		}
+		cfg.maxEvents = int(n)
+	}
+	return cfg, nil
+}
+
+// streamFormat picks the format for a response: the configured one, else sse
+// for text/event-stream and lines for anything else
+func (sc *streamConfig) streamFormat(resp *http.Response) string {
+	if sc.format != "" {
+		return sc.format
+	}
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil && mediaType == "text/event-stream" {
+		return StreamFormatSSE
+	}
+	return StreamFormatLines
+}
+
+// readStream reads events from the response body until the server closes the
+// stream, an event matches until, max_events arrive or duration passes. It
+// returns the result and the raw bytes read. An until pattern that never
+// matched is an error.
+func readStream(resp *http.Response, sc *streamConfig) (*StreamResult, []byte, error) {
+	start := time.Now()
+	result := &StreamResult{Format: sc.streamFormat(resp), Events: []StreamEvent{}}
+
+	// Closing the body ends a blocked read once the duration is up
+	var timedOut atomic.Bool
+	timer := time.AfterFunc(sc.duration, func() {
+		timedOut.Store(true)
+		_ = resp.Body.Close()
+	})
+	defer timer.Stop()
+
+	var raw bytes.Buffer
+	scanner := bufio.NewScanner(io.TeeReader(resp.Body, &raw))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLineBytes)
+
+	// add records an event and reports whether reading should stop
+	add := func(event StreamEvent) bool {
+		event.At = float64(time.Since(start).Microseconds()) / 1000
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(event.Data), &decoded); err == nil {
+			event.JSON = decoded
+		}
+		result.Events = append(result.Events, event)
+		if sc.until != nil && sc.until.MatchString(event.Data) {
+			result.Matched = true
+			result.StopReason = streamStopUntil
+			return true
+		}
+		if sc.maxEvents > 0 && len(result.Events) >= sc.maxEvents {
+			result.StopReason = streamStopMaxEvents
+			return true
+		}
+		return false
+	}
+
+	if result.Format == StreamFormatSSE {
+		readSSE(scanner, add)
+	} else {
+		for scanner.Scan() {
+			line := strings.TrimRight(scanner.Text(), "\r")
+			if line == "" {
+				continue
+			}
+			if add(StreamEvent{Data: line}) {
+				break
+			}
+		}
+	}
+
+	if result.StopReason == "" {
+		switch err := scanner.Err(); {
+		case timedOut.Load():
+			result.StopReason = streamStopDuration
+		case err != nil && !errors.Is(err, io.EOF):
+			return nil, raw.Bytes(), fmt.Errorf("failed to read stream: %w", err)
+		default:
+			result.StopReason = streamStopClosed
+		}
+	}
+
+	result.EventCount = len(result.Events)
+	data := make([]string, 0, len(result.Events))
+	for _, event := range result.Events {
+		data = append(data, event.Data)
+	}
+	result.Text = strings.Join(data, "\n")
+	result.Duration = float64(time.Since(start).Microseconds()) / 1000
+
+	if sc.until != nil && !result.Matched {
+		return result, raw.Bytes(), fmt.Errorf("no stream event matched %q before the stream stopped (%s after %d events)", sc.until.String(), result.StopReason, result.EventCount)
+	}
+	return result, raw.Bytes(), nil
+}
+
+// readSSE parses text/event-stream lines into events, dispatching each one
+// at a blank line as the EventSource spec does. Comments and retry fields
+// are skipped.
+func readSSE(scanner *bufio.Scanner, add func(StreamEvent) bool) {
+	var event StreamEvent
+	var data []string
+	hasData := false
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			if hasData {
+				event.Data = strings.Join(data, "\n")
+				if add(event) {
+					return
+				}
+			}
+			event, data, hasData = StreamEvent{}, nil, false
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event.Event = value
+		case "id":
+			event.ID = value
+		case "data":
+			data = append(data, value)
+			hasData = true
+		}
+	}
+	// A final event without its blank line still counts when the stream closes
+	if hasData && scanner.Err() == nil {
+		event.Data = strings.Join(data, "\n")
+		add(event)
+	}
+}
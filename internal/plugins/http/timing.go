@@ -0,0 +1,179 @@
+package http
+
+import (
+	"crypto/tls"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Timing phases, as named in response_time assertions and timing saves
+const (
+	TimingDNS     = "dns"
+	TimingConnect = "connect"
+	TimingTLS     = "tls"
+	TimingTTFB    = "ttfb"
+	TimingTotal   = "total"
+)
+
+// Timings is the breakdown of the last request sent, in milliseconds. Phases
+// that didn't happen, such as DNS and connect on a reused connection, are 0.
+type Timings struct {
+	DNS             float64 `json:"dns_ms"`
+	Connect         float64 `json:"connect_ms"`
+	TLS             float64 `json:"tls_ms"`
+	TTFB            float64 `json:"ttfb_ms"` // From sending the request to the first response byte
+	Total           float64 `json:"total_ms"`
+	ConnectionReuse bool    `json:"connection_reused"`
+}
+
+// phase returns the duration of a named phase
+func (t *Timings) phase(name string) (float64, bool) {
+	switch name {
+	case TimingDNS:
+		return t.DNS, true
+	case TimingConnect:
+		return t.Connect, true
+	case TimingTLS:
+		return t.TLS, true
+	case TimingTTFB:
+		return t.TTFB, true
+	case TimingTotal:
+		return t.Total, true
+	}
+	return 0, false
+}
+
+// timingTransport times each round trip with httptrace. It keeps the last
+// one, so retries, redirects and digest challenges report the request that
+// produced the response.
+type timingTransport struct {
+	base http.RoundTripper
+
+	mu                                   sync.Mutex
+	start, dnsStart, connStart, tlsStart time.Time
+	timings                              Timings
+}
+
+func (tt *timingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tt.mu.Lock()
+	tt.start = time.Now()
+	tt.dnsStart, tt.connStart, tt.tlsStart = time.Time{}, time.Time{}, time.Time{}
+	tt.timings = Timings{}
+	tt.mu.Unlock()
+
+	// since records the time from a phase's start, under the lock
+	since := func(from *time.Time, into *float64) {
+		tt.mu.Lock()
+		defer tt.mu.Unlock()
+		if !from.IsZero() {
+			*into = milliseconds(time.Since(*from))
+		}
+	}
+	mark := func(at *time.Time) {
+		tt.mu.Lock()
+		defer tt.mu.Unlock()
+		if at.IsZero() {
+			*at = time.Now()
+		}
+	}
+
+	trace := &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { mark(&tt.dnsStart) },
+		DNSDone:           func(httptrace.DNSDoneInfo) { since(&tt.dnsStart, &tt.timings.DNS) },
+		ConnectStart:      func(string, string) { mark(&tt.connStart) },
+		ConnectDone:       func(string, string, error) { since(&tt.connStart, &tt.timings.Connect) },
+		TLSHandshakeStart: func() { mark(&tt.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { since(&tt.tlsStart, &tt.timings.TLS) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			tt.mu.Lock()
+			tt.timings.ConnectionReuse = info.Reused
+			tt.mu.Unlock()
+		},
+		GotFirstResponseByte: func() { since(&tt.start, &tt.timings.TTFB) },
+	}
+	return tt.base.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+}
+
+// finish returns the timings of the last round trip, with the total up to
+// now, once the body has been read
+func (tt *timingTransport) finish() *Timings {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	timings := tt.timings
+	if !tt.start.IsZero() {
+		timings.Total = milliseconds(time.Since(tt.start))
+	}
+	return &timings
+}
+
+// startedAt returns when the last round trip started
+func (tt *timingTransport) startedAt() time.Time {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	return tt.start
+}
+
+// milliseconds rounds a duration to microsecond precision, in ms
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// parseMilliseconds reads a limit given in ms or as a duration string
+func parseMilliseconds(v interface{}) (float64, error) {
+	switch limit := v.(type) {
+	case float64:
+		if limit > 0 {
+			return limit, nil
+		}
+	case string:
+		if d, err := time.ParseDuration(limit); err == nil && d > 0 {
+			return milliseconds(d), nil
+		}
+		if n, err := strconv.ParseFloat(limit, 64); err == nil && n > 0 {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("response_time expected value must be a positive number of milliseconds or a duration: got %v", v)
+}
+
+// evaluateResponseTime checks that a phase, the total by default, took no
+// longer than expected
+func evaluateResponseTime(assertion map[string]interface{}, timings *Timings, expected interface{}) HTTPAssertionResult {
+	result := HTTPAssertionResult{Type: AssertionTypeResponseTime, Expected: expected}
+	phase, _ := assertion["phase"].(string)
+	if phase == "" {
+		phase = TimingTotal
+	}
+	result.Name = phase
+
+	limit, err := parseMilliseconds(expected)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	if timings == nil {
+		result.Message = "no timings recorded for the request"
+		return result
+	}
+	actual, ok := timings.phase(phase)
+	if !ok {
+		result.Message = fmt.Sprintf("unknown response_time phase %q: use dns, connect, tls, ttfb or total", phase)
+		return result
+	}
+	result.Actual = actual
+	if actual <= limit {
+		result.Passed = true
+		return result
+	}
+	result.Message = fmt.Sprintf("%s time %sms exceeds %sms", phase, formatMilliseconds(actual), formatMilliseconds(limit))
+	return result
+}
+
+func formatMilliseconds(ms float64) string {
+	return strconv.FormatFloat(math.Round(ms*10)/10, 'f', -1, 64)
+}
@@ -0,0 +1,206 @@
+package http
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/egress"
+)
+
+// transportConfig is a parsed transport block
+type transportConfig struct {
+	proxy              *url.URL
+	noProxy            []string
+	insecureSkipVerify bool
+	serverName         string
+	http2              *bool
+	connectTimeout     time.Duration
+	readTimeout        time.Duration
+	timeout            time.Duration
+}
+
+// parseTransportConfig reads the transport block of the config. It returns nil
+// when the step has none, so the request uses the worker's default transport.
+func parseTransportConfig(configData map[string]interface{}, state map[string]string, env map[string]string) (*transportConfig, error) {
+	raw, exists := configData["transport"]
+	if !exists || raw == nil {
+		return nil, nil
+	}
+	transportData, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("transport must be an object, got %T", raw)
+	}
+
+	tc := &transportConfig{}
+	if v, ok := transportData["proxy"]; ok && v != nil {
+		if err := tc.parseProxy(v, state, env); err != nil {
+			return nil, err
+		}
+	}
+
+	if v, ok := transportData["tls"]; ok && v != nil {
+		tlsData, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("transport.tls must be an object, got %T", v)
+		}
+		if skip, ok := tlsData["insecure_skip_verify"]; ok {
+			if tc.insecureSkipVerify, ok = skip.(bool); !ok {
+				return nil, fmt.Errorf("transport.tls.insecure_skip_verify must be a boolean, got %T", skip)
+			}
+		}
+		if name, ok := tlsData["server_name"]; ok {
+			if tc.serverName, ok = name.(string); !ok {
+				return nil, fmt.Errorf("transport.tls.server_name must be a string, got %T", name)
+			}
+		}
+	}
+
+	if v, ok := transportData["http2"]; ok {
+		enabled, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("transport.http2 must be a boolean, got %T", v)
+		}
+		tc.http2 = &enabled
+	}
+
+	timeouts := map[string]*time.Duration{
+		"connect_timeout": &tc.connectTimeout,
+		"read_timeout":    &tc.readTimeout,
+		"timeout":         &tc.timeout,
+	}
+	for key, target := range timeouts {
+		v, ok := transportData[key]
+		if !ok {
+			continue
+		}
+		s, _ := v.(string)
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("transport.%s must be a positive duration, got %v", key, v)
+		}
+		*target = d
+	}
+	return tc, nil
+}
+
+// parseProxy reads transport.proxy: a URL, or an object with url, username,
+// password and no_proxy
+func (tc *transportConfig) parseProxy(v interface{}, state map[string]string, env map[string]string) error {
+	proxyData, ok := v.(map[string]interface{})
+	if !ok {
+		proxyData = map[string]interface{}{"url": v}
+	}
+	fields := make(map[string]string)
+	for _, key := range []string{"url", "username", "password"} {
+		value, exists := proxyData[key]
+		if !exists {
+			continue
+		}
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("transport.proxy.%s must be a string, got %T", key, value)
+		}
+		rendered, err := replaceVariables(s, state, env)
+		if err != nil {
+			return fmt.Errorf("failed to replace variables in transport.proxy.%s: %w", key, err)
+		}
+		fields[key] = rendered
+	}
+
+	proxyURL, err := url.Parse(fields["url"])
+	if err != nil || proxyURL.Host == "" || (proxyURL.Scheme != "http" && proxyURL.Scheme != "https" && proxyURL.Scheme != "socks5") {
+		return fmt.Errorf("transport.proxy.url must be an http, https or socks5 URL, got %q", fields["url"])
+	}
+	if fields["username"] != "" {
+		proxyURL.User = url.UserPassword(fields["username"], fields["password"])
+	}
+	tc.proxy = proxyURL
+
+	if list, ok := proxyData["no_proxy"]; ok {
+		hosts, ok := list.([]interface{})
+		if !ok {
+			return fmt.Errorf("transport.proxy.no_proxy must be a list of hosts, got %T", list)
+		}
+		for _, host := range hosts {
+			tc.noProxy = append(tc.noProxy, strings.ToLower(strings.TrimSpace(fmt.Sprint(host))))
+		}
+	}
+	return nil
+}
+
+// bypassesProxy reports whether host matches no_proxy: an exact host, a
+// domain and its subdomains (with or without a leading dot), or "*"
+func (tc *transportConfig) bypassesProxy(host string) bool {
+	host = strings.ToLower(host)
+	for _, entry := range tc.noProxy {
+		domain := strings.TrimPrefix(entry, ".")
+		if entry == "*" || host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply configures the client's transport, which must come from the egress
+// policy. Connections to the proxy go through the policy's dialer, and each
+// proxied destination is checked against the policy too, since the worker
+// doesn't dial it itself.
+func (tc *transportConfig) apply(client *http.Client, policy *egress.Policy) error {
+	base, ok := client.Transport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("transport settings need an *http.Transport, got %T", client.Transport)
+	}
+	transport := base.Clone()
+
+	if tc.connectTimeout > 0 {
+		transport.DialContext = policy.DialContext(&net.Dialer{Timeout: tc.connectTimeout, KeepAlive: 30 * time.Second})
+		transport.TLSHandshakeTimeout = tc.connectTimeout
+	}
+	if tc.readTimeout > 0 {
+		transport.ResponseHeaderTimeout = tc.readTimeout
+	}
+	if tc.timeout > 0 {
+		client.Timeout = tc.timeout
+	}
+
+	if tc.proxy != nil {
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			if tc.bypassesProxy(req.URL.Hostname()) {
+				return nil, nil
+			}
+			if err := policy.Check(req.Context(), req.URL.Host); err != nil {
+				return nil, err
+			}
+			return tc.proxy, nil
+		}
+	}
+
+	if tc.insecureSkipVerify || tc.serverName != "" {
+		tlsConfig := &tls.Config{}
+		if transport.TLSClientConfig != nil {
+			tlsConfig = transport.TLSClientConfig.Clone()
+		}
+		tlsConfig.InsecureSkipVerify = tc.insecureSkipVerify
+		tlsConfig.ServerName = tc.serverName
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	if tc.http2 != nil {
+		transport.ForceAttemptHTTP2 = *tc.http2
+		if !*tc.http2 {
+			// A non-nil empty map turns off the built-in HTTP/2 support
+			transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+			if transport.TLSClientConfig != nil {
+				transport.TLSClientConfig.NextProtos = []string{"http/1.1"}
+			}
+		}
+	}
+
+	client.Transport = transport
+	return nil
+}
@@ -1,5 +1,7 @@
 package http
 
+import "github.com/rocketship-ai/rocketship/internal/assertions"
+
 // HTTPPlugin represents a single HTTP test step
 type HTTPPlugin struct {
 	Name       string          `json:"name" yaml:"name"`
@@ -16,21 +18,89 @@ type HTTPConfig struct {
 	Body    string                   `json:"body" yaml:"body,omitempty"`
 	Headers map[string]string        `json:"headers" yaml:"headers,omitempty"`
 	OpenAPI *OpenAPIValidationConfig `json:"openapi" yaml:"openapi,omitempty"`
+
+	BodyFormat string            `json:"body_format" yaml:"body_format,omitempty"` // "json" or "xml"; sets the default Content-Type
+	Namespaces map[string]string `json:"namespaces" yaml:"namespaces,omitempty"`   // Prefixes usable in xpath assertions and saves
+
+	Stream *StreamConfig `json:"stream" yaml:"stream,omitempty"`
+	Auth   *AuthConfig   `json:"auth" yaml:"auth,omitempty"`
+
+	Transport *TransportConfig `json:"transport" yaml:"transport,omitempty"`
+}
+
+// TransportConfig sets the proxy, TLS, HTTP/2 and timeouts for a request.
+// Suite-level http.transport settings are merged in when the suite is parsed.
+type TransportConfig struct {
+	Proxy          interface{}         `json:"proxy" yaml:"proxy,omitempty"` // A URL, or a ProxyConfig
+	TLS            *TransportTLSConfig `json:"tls" yaml:"tls,omitempty"`
+	HTTP2          *bool               `json:"http2" yaml:"http2,omitempty"`                     // Negotiate HTTP/2 over TLS (default true)
+	ConnectTimeout string              `json:"connect_timeout" yaml:"connect_timeout,omitempty"` // Dial and TLS handshake
+	ReadTimeout    string              `json:"read_timeout" yaml:"read_timeout,omitempty"`       // Wait for response headers
+	Timeout        string              `json:"timeout" yaml:"timeout,omitempty"`                 // Whole request, including the body
+}
+
+// ProxyConfig is a proxy with credentials
+type ProxyConfig struct {
+	URL      string   `json:"url" yaml:"url"`
+	Username string   `json:"username" yaml:"username,omitempty"`
+	Password string   `json:"password" yaml:"password,omitempty"`
+	NoProxy  []string `json:"no_proxy" yaml:"no_proxy,omitempty"` // Hosts and domains reached directly
+}
+
+// TransportTLSConfig adjusts certificate checks
+type TransportTLSConfig struct {
+	InsecureSkipVerify bool   `json:"insecure_skip_verify" yaml:"insecure_skip_verify,omitempty"`
+	ServerName         string `json:"server_name" yaml:"server_name,omitempty"`
+}
+
+// AuthConfig signs requests or adds credentials. Which fields apply depends on Type.
+type AuthConfig struct {
+	Type string `json:"type" yaml:"type"` // "sigv4", "digest" or "oauth2_client_credentials"
+
+	// sigv4
+	Region          string `json:"region" yaml:"region,omitempty"`
+	Service         string `json:"service" yaml:"service,omitempty"`
+	AccessKeyID     string `json:"access_key_id" yaml:"access_key_id,omitempty"`
+	SecretAccessKey string `json:"secret_access_key" yaml:"secret_access_key,omitempty"`
+	SessionToken    string `json:"session_token" yaml:"session_token,omitempty"`
+
+	// digest
+	Username string `json:"username" yaml:"username,omitempty"`
+	Password string `json:"password" yaml:"password,omitempty"`
+
+	// oauth2_client_credentials
+	TokenURL     string            `json:"token_url" yaml:"token_url,omitempty"`
+	ClientID     string            `json:"client_id" yaml:"client_id,omitempty"`
+	ClientSecret string            `json:"client_secret" yaml:"client_secret,omitempty"`
+	Scopes       []string          `json:"scopes" yaml:"scopes,omitempty"`
+	Audience     string            `json:"audience" yaml:"audience,omitempty"`
+	Params       map[string]string `json:"params" yaml:"params,omitempty"`
+}
+
+// StreamConfig reads a streaming response into events
+type StreamConfig struct {
+	Format    string `json:"format" yaml:"format,omitempty"`         // "sse" or "lines"; picked from the Content-Type when empty
+	Duration  string `json:"duration" yaml:"duration,omitempty"`     // Longest time to read (defaults to 30s)
+	Until     string `json:"until" yaml:"until,omitempty"`           // Regex that ends the read when an event's data matches
+	MaxEvents int    `json:"max_events" yaml:"max_events,omitempty"` // Stop after this many events
 }
 
 // HTTPAssertion represents a test assertion
 type HTTPAssertion struct {
-	Type     string      `json:"type" yaml:"type"`           // "status_code", "json_path", or "header"
-	Path     string      `json:"path" yaml:"path,omitempty"` // Used for json_path assertions
-	Name     string      `json:"name" yaml:"name,omitempty"` // Used for header assertions
-	Expected interface{} `json:"expected" yaml:"expected"`   // Expected value to match against
-	Exists   bool        `json:"exists" yaml:"exists"`       // Used for checking if a value exists
+	Type     string      `json:"type" yaml:"type"`             // "status_code", "json_path", "xpath", "header", "json_schema" or "response_time"
+	Path     string      `json:"path" yaml:"path,omitempty"`   // Used for json_path and xpath assertions
+	Name     string      `json:"name" yaml:"name,omitempty"`   // Used for header assertions
+	Phase    string      `json:"phase" yaml:"phase,omitempty"` // Used for response_time assertions: dns, connect, tls, ttfb or total
+	Expected interface{} `json:"expected" yaml:"expected"`     // Expected value to match against
+	Exists   bool        `json:"exists" yaml:"exists"`         // Used for checking if a value exists
 }
 
 // SaveConfig represents a configuration for saving response data
 type SaveConfig struct {
 	JSONPath string `json:"json_path" yaml:"json_path,omitempty"` // JSONPath to extract from response
+	XPath    string `json:"xpath" yaml:"xpath,omitempty"`         // XPath to extract from an XML response
 	Header   string `json:"header" yaml:"header,omitempty"`       // Header name to extract
+	Timing   string `json:"timing" yaml:"timing,omitempty"`       // Timing phase to save, in ms
 	As       string `json:"as" yaml:"as"`                         // Variable name to save as
 	Required *bool  `json:"required" yaml:"required,omitempty"`   // Whether the value is required (defaults to true)
 }
@@ -49,8 +119,26 @@ const (
 	AssertionTypeStatusCode = "status_code"
 	AssertionTypeJSONPath   = "json_path"
 	AssertionTypeHeader     = "header"
+	AssertionTypeJSONSchema = "json_schema"
+	AssertionTypeXPath      = "xpath"
+
+	AssertionTypeResponseTime = "response_time"
+
+	// AssertionTypeOpenAPI reports OpenAPI contract validation. It isn't
+	// configured as an assertion; a step gets one per validated request or
+	// response.
+	AssertionTypeOpenAPI = "openapi"
 )
 
+// OpenAPIViolation is one way a request or response differs from the OpenAPI spec
+type OpenAPIViolation struct {
+	Kind     string `json:"kind"`               // status_code, content_type, header, parameter, body or operation
+	Location string `json:"location,omitempty"` // Header or parameter name, or the body field
+	Message  string `json:"message"`
+	Fix      string `json:"fix,omitempty"`
+	SpecLine int    `json:"spec_line,omitempty"` // Line in the spec the violation was found against
+}
+
 // HTTPResponse represents the response from an HTTP request
 type HTTPResponse struct {
 	StatusCode int               `json:"status_code"`
@@ -81,15 +169,8 @@ type UIResponseData struct {
 	Body          string            `json:"body,omitempty"`
 	BodyTruncated bool              `json:"body_truncated,omitempty"`
 	BodyBytes     int               `json:"body_bytes,omitempty"`
+	Timings       *Timings          `json:"timings,omitempty"`
 }
 
 // HTTPAssertionResult represents a single assertion result for UI display
-type HTTPAssertionResult struct {
-	Type     string      `json:"type"`               // status_code, json_path, header
-	Name     string      `json:"name,omitempty"`     // Header name for header assertions
-	Path     string      `json:"path,omitempty"`     // JSONPath/jq expression for json_path assertions
-	Expected interface{} `json:"expected,omitempty"` // Expected value
-	Actual   interface{} `json:"actual,omitempty"`   // Actual value received
-	Passed   bool        `json:"passed"`             // Whether the assertion passed
-	Message  string      `json:"message,omitempty"`  // Error message if failed
-}
+type HTTPAssertionResult = assertions.Result
@@ -0,0 +1,128 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+)
+
+// Request body formats
+const (
+	BodyFormatJSON = "json"
+	BodyFormatXML  = "xml"
+)
+
+// bodyFormatContentTypes is the Content-Type sent for each body format when
+// the step doesn't set one
+var bodyFormatContentTypes = map[string]string{
+	BodyFormatJSON: "application/json",
+	BodyFormatXML:  "application/xml",
+}
+
+// parseBodyFormat reads body_format from the config
+func parseBodyFormat(configData map[string]interface{}) (string, error) {
+	raw, exists := configData["body_format"]
+	if !exists || raw == nil {
+		return "", nil
+	}
+	format, _ := raw.(string)
+	if _, known := bodyFormatContentTypes[format]; !known {
+		return "", fmt.Errorf("body_format must be %q or %q, got %v", BodyFormatJSON, BodyFormatXML, raw)
+	}
+	return format, nil
+}
+
+// xmlNamespaces returns the prefixes xpath expressions may use, from the
+// namespaces map of the step config
+func xmlNamespaces(p map[string]interface{}) (map[string]string, error) {
+	configData, _ := p["config"].(map[string]interface{})
+	raw, exists := configData["namespaces"]
+	if !exists || raw == nil {
+		return nil, nil
+	}
+	namespaceData, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("namespaces must be a map of prefixes to namespace URIs, got %T", raw)
+	}
+	namespaces := make(map[string]string, len(namespaceData))
+	for prefix, uri := range namespaceData {
+		uriStr, ok := uri.(string)
+		if !ok {
+			return nil, fmt.Errorf("namespace %q must be a string URI, got %T", prefix, uri)
+		}
+		namespaces[prefix] = uriStr
+	}
+	return namespaces, nil
+}
+
+// queryXPath evaluates an XPath expression against an XML body. A node set
+// gives the text of its first node (the value, for an attribute); functions
+// such as count() or boolean() give their number, string or boolean. found is
+// false when the node set is empty.
+func queryXPath(body []byte, expr string, namespaces map[string]string) (interface{}, bool, error) {
+	compiled, err := xpath.CompileWithNS(expr, namespaces)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to parse xpath expression %q: %w", expr, err)
+	}
+	doc, err := xmlquery.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to parse response body as XML: %w", err)
+	}
+
+	switch v := compiled.Evaluate(xmlquery.CreateXPathNavigator(doc)).(type) {
+	case *xpath.NodeIterator:
+		if !v.MoveNext() {
+			return nil, false, nil
+		}
+		return v.Current().Value(), true, nil
+	default:
+		return v, true, nil
+	}
+}
+
+// evaluateXPathAssertion runs an xpath assertion. Like json_path, it checks the
+// value against expected, only that the path matches when expected is left
+// out, or whether it matches at all with exists.
+func evaluateXPathAssertion(p map[string]interface{}, assertion map[string]interface{}, respBody []byte, expected interface{}) HTTPAssertionResult {
+	result := HTTPAssertionResult{Type: AssertionTypeXPath, Expected: expected}
+	result.Path, _ = assertion["path"].(string)
+	if result.Path == "" {
+		result.Message = "path is required for xpath assertion"
+		return result
+	}
+
+	namespaces, err := xmlNamespaces(p)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	actual, found, err := queryXPath(respBody, result.Path, namespaces)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	result.Actual = actual
+
+	if exists, ok := assertion["exists"].(bool); ok && exists {
+		if found {
+			result.Passed = true
+		} else {
+			result.Message = fmt.Sprintf("path %q does not exist", result.Path)
+		}
+		return result
+	}
+	if !found {
+		result.Message = fmt.Sprintf("no results from xpath expression %q", result.Path)
+		return result
+	}
+	if expected == nil || assertions.Equal(actual, expected) {
+		result.Passed = true
+		return result
+	}
+	result.Message = fmt.Sprintf("expected %v, got %v", expected, actual)
+	return result
+}
@@ -0,0 +1,626 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v4"
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+	"go.temporal.io/sdk/activity"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// Auto-register the plugin when the package is imported
+func init() {
+	plugins.RegisterPlugin(&JWTPlugin{})
+}
+
+// GetType returns the plugin type identifier
+func (jp *JWTPlugin) GetType() string {
+	return "jwt"
+}
+
+// Activity signs, verifies or decodes a token and checks its claims
+func (jp *JWTPlugin) Activity(ctx context.Context, p map[string]interface{}) (interface{}, error) {
+	logger := activity.GetLogger(ctx)
+
+	// Parse configuration from parameters
+	configData, ok := p["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid config format")
+	}
+
+	config := &JWTConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		return nil, fmt.Errorf("failed to parse jwt config: %w", err)
+	}
+
+	timeout := defaultTimeout
+	if config.Timeout != "" {
+		parsed, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", config.Timeout, err)
+		}
+		timeout = parsed
+	}
+
+	// Convert state to map[string]interface{} for template processing
+	state := make(map[string]interface{})
+	if stateStr, ok := p["state"].(map[string]string); ok {
+		for k, v := range stateStr {
+			state[k] = v
+		}
+	} else if stateInt, ok := p["state"].(map[string]interface{}); ok {
+		state = stateInt
+	}
+
+	// Extract env secrets from params (for {{ .env.* }} template resolution)
+	env := make(map[string]string)
+	if envData, ok := p["env"].(map[string]interface{}); ok {
+		for k, v := range envData {
+			if strVal, ok := v.(string); ok {
+				env[k] = strVal
+			}
+		}
+	} else if envData, ok := p["env"].(map[string]string); ok {
+		env = envData
+	}
+
+	if err := applyVariableReplacement(config, state, env); err != nil {
+		return nil, fmt.Errorf("variable replacement failed: %w", err)
+	}
+
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	policy, err := egress.FromParams(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
+
+	logger.Info("Executing jwt plugin", "action", config.Action, "algorithm", config.Algorithm)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	response, err := execute(ctx, config, policy, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// A rejected token fails the step unless the test asserts on validity itself
+	if !response.Valid && !hasValidAssertion(p) {
+		return nil, fmt.Errorf("token rejected: %s", response.Error)
+	}
+
+	subject, err := assertions.Normalize(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare jwt result: %w", err)
+	}
+
+	assertionResults, err := processAssertions(p, response, subject, state, env)
+	if err != nil {
+		return nil, err
+	}
+	if failure := assertions.Summary(assertionResults); failure != "" {
+		return nil, fmt.Errorf("%s", failure)
+	}
+
+	saved := make(map[string]string)
+	if err := saves.Process(p, subject, saved); err != nil {
+		return nil, err
+	}
+
+	logger.Info("JWT step completed", "action", config.Action, "valid", response.Valid, "duration", response.Duration)
+
+	return &ActivityResponse{
+		Response:         response,
+		Saved:            saved,
+		AssertionResults: assertionResults,
+	}, nil
+}
+
+// execute runs the configured action
+func execute(ctx context.Context, config *JWTConfig, policy *egress.Policy, now time.Time) (*JWTResponse, error) {
+	start := time.Now()
+
+	var response *JWTResponse
+	var err error
+	switch config.Action {
+	case ActionSign:
+		response, err = sign(config, now)
+	case ActionVerify:
+		response, err = verify(ctx, config, policy, now)
+	case ActionDecode:
+		response, err = decode(config.Token, now)
+		if err == nil {
+			// decode doesn't check anything, so the token isn't rejected either
+			response.Valid = true
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response.Action = config.Action
+	response.Duration = time.Since(start).String()
+	return response, nil
+}
+
+// sign builds and signs a token from the configured claims and headers
+func sign(config *JWTConfig, now time.Time) (*JWTResponse, error) {
+	var key interface{}
+	algorithm := config.Algorithm
+	if config.Secret != "" {
+		key = []byte(config.Secret)
+		if algorithm == "" {
+			algorithm = "HS256"
+		}
+	} else {
+		privateKey, err := parsePrivateKey(config.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		key = privateKey
+		if algorithm == "" {
+			algorithm = defaultAlgorithm(privateKey)
+		}
+	}
+
+	method := gojwt.GetSigningMethod(algorithm)
+	if method == nil || method == gojwt.SigningMethodNone {
+		return nil, fmt.Errorf("unsupported algorithm %q", algorithm)
+	}
+
+	claims := gojwt.MapClaims{}
+	for name, value := range config.Claims {
+		claims[name] = value
+	}
+	if config.ExpiresIn != "" {
+		expiresIn, _ := time.ParseDuration(config.ExpiresIn)
+		claims["iat"] = now.Unix()
+		claims["exp"] = now.Add(expiresIn).Unix()
+	}
+
+	token := gojwt.NewWithClaims(method, claims)
+	for name, value := range config.Headers {
+		token.Header[name] = value
+	}
+	if config.KeyID != "" {
+		token.Header["kid"] = config.KeyID
+	}
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign token with %s: %w", algorithm, err)
+	}
+
+	response, err := decode(signed, now)
+	if err != nil {
+		return nil, err
+	}
+	response.Valid = true
+	return response, nil
+}
+
+// defaultAlgorithm picks the usual algorithm for a private key
+func defaultAlgorithm(key interface{}) string {
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		switch k.Curve.Params().BitSize {
+		case 384:
+			return "ES384"
+		case 521:
+			return "ES512"
+		}
+		return "ES256"
+	case ed25519.PrivateKey:
+		return "EdDSA"
+	}
+	return "RS256"
+}
+
+// verify checks the token's signature, time claims, issuer and audience. A token
+// that fails any check is returned with Valid false and the reason in Error.
+func verify(ctx context.Context, config *JWTConfig, policy *egress.Policy, now time.Time) (*JWTResponse, error) {
+	response, err := decode(config.Token, now)
+	if err != nil {
+		return &JWTResponse{Token: config.Token, Error: err.Error()}, nil
+	}
+
+	keys := map[string]interface{}{}
+	switch {
+	case config.Secret != "":
+		keys[""] = []byte(config.Secret)
+	case config.PublicKey != "":
+		key, err := parsePublicKey(config.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		keys[""] = key
+	default:
+		keys, err = fetchJWKS(ctx, config.JWKSURL, policy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	methods := []string{config.Algorithm}
+	if config.Algorithm == "" {
+		seen := map[string]bool{}
+		methods = nil
+		for _, key := range keys {
+			for _, method := range algorithmsForKey(key) {
+				if !seen[method] {
+					seen[method] = true
+					methods = append(methods, method)
+				}
+			}
+		}
+	}
+
+	parser := gojwt.NewParser(gojwt.WithValidMethods(methods), gojwt.WithoutClaimsValidation())
+	_, err = parser.Parse(config.Token, func(token *gojwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if key, ok := keys[kid]; ok {
+			return key, nil
+		}
+		if len(keys) == 1 {
+			for _, key := range keys {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("no key with kid %q", kid)
+	})
+	if err != nil {
+		response.Error = err.Error()
+		return response, nil
+	}
+
+	leeway, _ := time.ParseDuration(config.Leeway)
+	if reason := checkClaims(response.Claims, config, now, leeway); reason != "" {
+		response.Error = reason
+		return response, nil
+	}
+
+	response.Valid = true
+	return response, nil
+}
+
+// checkClaims validates the registered claims that verify enforces
+func checkClaims(claims map[string]interface{}, config *JWTConfig, now time.Time, leeway time.Duration) string {
+	if exp, ok := numericClaim(claims, "exp"); ok && now.After(exp.Add(leeway)) {
+		return fmt.Sprintf("token expired at %s", exp.UTC().Format(time.RFC3339))
+	}
+	if nbf, ok := numericClaim(claims, "nbf"); ok && now.Add(leeway).Before(nbf) {
+		return fmt.Sprintf("token is not valid before %s", nbf.UTC().Format(time.RFC3339))
+	}
+	if iat, ok := numericClaim(claims, "iat"); ok && now.Add(leeway).Before(iat) {
+		return fmt.Sprintf("token was issued in the future, at %s", iat.UTC().Format(time.RFC3339))
+	}
+	if config.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != config.Issuer {
+			return fmt.Sprintf("expected issuer %q, got %q", config.Issuer, iss)
+		}
+	}
+	if config.Audience != "" && !claimContains(claims["aud"], config.Audience) {
+		return fmt.Sprintf("audience %v does not include %q", claims["aud"], config.Audience)
+	}
+	return ""
+}
+
+// decode reads the token's header and claims without checking the signature
+func decode(token string, now time.Time) (*JWTResponse, error) {
+	claims := gojwt.MapClaims{}
+	parsed, _, err := gojwt.NewParser().ParseUnverified(token, claims)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode token: %w", err)
+	}
+
+	response := &JWTResponse{
+		Token:  token,
+		Header: parsed.Header,
+		Claims: claims,
+	}
+	if exp, ok := numericClaim(claims, "exp"); ok {
+		response.ExpiresAt = exp.UTC().Format(time.RFC3339)
+		expiresIn := int64(math.Floor(exp.Sub(now).Seconds()))
+		response.ExpiresIn = &expiresIn
+	}
+	if iat, ok := numericClaim(claims, "iat"); ok {
+		response.IssuedAt = iat.UTC().Format(time.RFC3339)
+	}
+	return response, nil
+}
+
+// numericClaim reads a NumericDate claim (seconds since the epoch)
+func numericClaim(claims map[string]interface{}, name string) (time.Time, bool) {
+	var seconds float64
+	switch v := claims[name].(type) {
+	case float64:
+		seconds = v
+	case int64:
+		seconds = float64(v)
+	case int:
+		seconds = float64(v)
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return time.Time{}, false
+		}
+		seconds = f
+	default:
+		return time.Time{}, false
+	}
+	whole, frac := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(frac*1e9)), true
+}
+
+// claimContains reports whether a claim equals expected or, for list claims like
+// aud, holds it
+func claimContains(claim interface{}, expected interface{}) bool {
+	if list, ok := claim.([]interface{}); ok {
+		for _, item := range list {
+			if assertions.Equal(item, expected) {
+				return true
+			}
+		}
+		return false
+	}
+	return claim != nil && assertions.Equal(claim, expected)
+}
+
+func hasValidAssertion(p map[string]interface{}) bool {
+	assertionList, _ := p["assertions"].([]interface{})
+	for _, assertion := range assertionList {
+		if assertionMap, ok := assertion.(map[string]interface{}); ok && assertionMap["type"] == AssertionTypeValid {
+			return true
+		}
+	}
+	return false
+}
+
+// processAssertions evaluates all assertions and returns their results.
+// Shared assertions without a path check the claims.
+func processAssertions(p map[string]interface{}, response *JWTResponse, subject interface{}, state map[string]interface{}, env map[string]string) ([]AssertionResult, error) {
+	context := dsl.TemplateContext{Runtime: state, Env: env}
+	claims, _ := assertions.Normalize(response.Claims)
+
+	return assertions.Process(p, subject, context, func(assertionMap map[string]interface{}, result *AssertionResult) bool {
+		return evaluateAssertion(response, subject, claims, assertionMap, result)
+	})
+}
+
+// evaluateAssertion evaluates the jwt plugin's own assertion types
+func evaluateAssertion(response *JWTResponse, subject interface{}, claims interface{}, assertionMap map[string]interface{}, result *AssertionResult) bool {
+	expected := result.Expected
+	switch result.Type {
+	case AssertionTypeClaim:
+		name, _ := assertionMap["name"].(string)
+		result.Name = name
+		value, found := response.Claims[name]
+		if !found {
+			result.Message = fmt.Sprintf("token has no %s claim", name)
+			break
+		}
+		result.Actual = value
+		if !claimContains(value, expected) {
+			result.Message = fmt.Sprintf("expected claim %s to be %v, got %v", name, expected, value)
+		} else {
+			result.Passed = true
+		}
+
+	case AssertionTypeValid:
+		want := true
+		if b, ok := expected.(bool); ok {
+			want = b
+		}
+		result.Actual = response.Valid
+		switch {
+		case response.Valid == want:
+			result.Passed = true
+		case want:
+			result.Message = fmt.Sprintf("expected a valid token: %s", response.Error)
+		default:
+			result.Message = "expected the token to be rejected, but it is valid"
+		}
+
+	default:
+		if _, hasPath := assertionMap["path"]; hasPath {
+			*result = assertions.Evaluate(assertionMap, subject, expected)
+		} else {
+			*result = assertions.Evaluate(assertionMap, claims, expected)
+		}
+	}
+	return true
+}
+
+// validateConfig checks the action has what it needs once templates are rendered
+func validateConfig(config *JWTConfig) error {
+	if config.Algorithm != "" {
+		method := gojwt.GetSigningMethod(config.Algorithm)
+		if method == nil || method == gojwt.SigningMethodNone {
+			return fmt.Errorf("unsupported algorithm %q", config.Algorithm)
+		}
+	}
+	if config.ExpiresIn != "" {
+		if d, err := time.ParseDuration(config.ExpiresIn); err != nil || d == 0 {
+			return fmt.Errorf("invalid expires_in %q: must be a non-zero duration", config.ExpiresIn)
+		}
+	}
+	if config.Leeway != "" {
+		if d, err := time.ParseDuration(config.Leeway); err != nil || d < 0 {
+			return fmt.Errorf("invalid leeway %q: must be a non-negative duration", config.Leeway)
+		}
+	}
+	hmac := strings.HasPrefix(config.Algorithm, "HS")
+
+	switch config.Action {
+	case ActionSign:
+		switch {
+		case config.Secret == "" && config.PrivateKey == "":
+			return fmt.Errorf("sign needs secret or private_key")
+		case config.Secret != "" && config.PrivateKey != "":
+			return fmt.Errorf("use either secret or private_key, not both")
+		case config.Secret != "" && config.Algorithm != "" && !hmac:
+			return fmt.Errorf("secret can only be used with HS256, HS384 or HS512, not %s", config.Algorithm)
+		case config.PrivateKey != "" && hmac:
+			return fmt.Errorf("%s needs secret, not private_key", config.Algorithm)
+		}
+		if _, ok := config.Headers["alg"]; ok {
+			return fmt.Errorf("headers can't set alg; use algorithm")
+		}
+	case ActionVerify:
+		if config.Token == "" {
+			return fmt.Errorf("token is required with action verify")
+		}
+		keys := 0
+		for _, key := range []string{config.Secret, config.PublicKey, config.JWKSURL} {
+			if key != "" {
+				keys++
+			}
+		}
+		if keys != 1 {
+			return fmt.Errorf("verify needs exactly one of secret, public_key or jwks_url")
+		}
+		if config.JWKSURL != "" && !strings.HasPrefix(config.JWKSURL, "http://") && !strings.HasPrefix(config.JWKSURL, "https://") {
+			return fmt.Errorf("jwks_url must be an http(s) URL, got %q", config.JWKSURL)
+		}
+	case ActionDecode:
+		if config.Token == "" {
+			return fmt.Errorf("token is required with action decode")
+		}
+	case "":
+		return fmt.Errorf("action is required")
+	default:
+		return fmt.Errorf("action must be sign, verify or decode, got %q", config.Action)
+	}
+
+	return nil
+}
+
+// applyVariableReplacement processes templates in the keys, token, expected
+// issuer and audience, and in claim and header values
+func applyVariableReplacement(config *JWTConfig, state map[string]interface{}, env map[string]string) error {
+	context := dsl.TemplateContext{
+		Runtime: state,
+		Env:     env,
+	}
+
+	fields := map[string]*string{
+		"secret":      &config.Secret,
+		"private_key": &config.PrivateKey,
+		"public_key":  &config.PublicKey,
+		"jwks_url":    &config.JWKSURL,
+		"key_id":      &config.KeyID,
+		"token":       &config.Token,
+		"issuer":      &config.Issuer,
+		"audience":    &config.Audience,
+	}
+	for name, value := range fields {
+		if *value == "" {
+			continue
+		}
+		processed, err := dsl.ProcessTemplate(*value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process %s template: %w", name, err)
+		}
+		*value = processed
+	}
+
+	for name, value := range config.Claims {
+		processed, err := replaceInValue(value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process claim %s template: %w", name, err)
+		}
+		config.Claims[name] = processed
+	}
+	for name, value := range config.Headers {
+		processed, err := replaceInValue(value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process header %s template: %w", name, err)
+		}
+		config.Headers[name] = processed
+	}
+
+	return nil
+}
+
+// replaceInValue renders templates in strings nested anywhere in a claim value
+func replaceInValue(value interface{}, context dsl.TemplateContext) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return dsl.ProcessTemplate(v, context)
+	case map[string]interface{}:
+		for key, item := range v {
+			processed, err := replaceInValue(item, context)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = processed
+		}
+		return v, nil
+	case []interface{}:
+		for i, item := range v {
+			processed, err := replaceInValue(item, context)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = processed
+		}
+		return v, nil
+	}
+	return value, nil
+}
+
+// parseConfig converts map[string]interface{} to JWTConfig
+func parseConfig(configData map[string]interface{}, config *JWTConfig) error {
+	stringFields := map[string]*string{
+		"action":      &config.Action,
+		"algorithm":   &config.Algorithm,
+		"secret":      &config.Secret,
+		"private_key": &config.PrivateKey,
+		"public_key":  &config.PublicKey,
+		"jwks_url":    &config.JWKSURL,
+		"key_id":      &config.KeyID,
+		"expires_in":  &config.ExpiresIn,
+		"token":       &config.Token,
+		"issuer":      &config.Issuer,
+		"audience":    &config.Audience,
+		"leeway":      &config.Leeway,
+		"timeout":     &config.Timeout,
+	}
+	for key, target := range stringFields {
+		if v, ok := configData[key].(string); ok {
+			*target = v
+		}
+	}
+
+	mapFields := map[string]*map[string]interface{}{
+		"claims":  &config.Claims,
+		"headers": &config.Headers,
+	}
+	for key, target := range mapFields {
+		switch v := configData[key].(type) {
+		case nil:
+		case map[string]interface{}:
+			*target = v
+		default:
+			return fmt.Errorf("%s must be a map, got %T", key, v)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,264 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+)
+
+var now = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func pemKeys(t *testing.T, private interface{}, public interface{}) (string, string) {
+	t.Helper()
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}))
+}
+
+func run(t *testing.T, config *JWTConfig) *JWTResponse {
+	t.Helper()
+	if err := validateConfig(config); err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+	response, err := execute(context.Background(), config, nil, now)
+	if err != nil {
+		t.Fatalf("%s failed: %v", config.Action, err)
+	}
+	return response
+}
+
+func TestSignAndVerifyHMAC(t *testing.T) {
+	signed := run(t, &JWTConfig{
+		Action:    ActionSign,
+		Secret:    "test-secret",
+		Claims:    map[string]interface{}{"sub": "user-42", "aud": []interface{}{"api", "admin"}, "iss": "tests", "roles": []interface{}{"admin"}},
+		KeyID:     "k1",
+		ExpiresIn: "1h",
+	})
+	if signed.Header["alg"] != "HS256" || signed.Header["kid"] != "k1" || signed.Claims["sub"] != "user-42" {
+		t.Errorf("unexpected token %+v", signed)
+	}
+	if signed.ExpiresIn == nil || *signed.ExpiresIn != 3600 || signed.ExpiresAt != "2026-03-01T13:00:00Z" {
+		t.Errorf("unexpected expiry %v %s", signed.ExpiresIn, signed.ExpiresAt)
+	}
+
+	verified := run(t, &JWTConfig{Action: ActionVerify, Token: signed.Token, Secret: "test-secret", Issuer: "tests", Audience: "admin"})
+	if !verified.Valid {
+		t.Fatalf("expected a valid token: %s", verified.Error)
+	}
+
+	rejected := []struct {
+		config JWTConfig
+		reason string
+	}{
+		{JWTConfig{Secret: "other-secret"}, "signature is invalid"},
+		{JWTConfig{Secret: "test-secret", Issuer: "prod"}, `expected issuer "prod", got "tests"`},
+		{JWTConfig{Secret: "test-secret", Audience: "billing"}, `does not include "billing"`},
+		{JWTConfig{Secret: "test-secret", Algorithm: "HS512"}, "signing method HS256 is invalid"},
+	}
+	for _, tc := range rejected {
+		config := tc.config
+		config.Action = ActionVerify
+		config.Token = signed.Token
+		response := run(t, &config)
+		if response.Valid || !strings.Contains(response.Error, tc.reason) {
+			t.Errorf("expected rejection %q, got valid=%v %q", tc.reason, response.Valid, response.Error)
+		}
+	}
+
+	subject, err := assertions.Normalize(verified)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := map[string]interface{}{
+		"assertions": []interface{}{
+			map[string]interface{}{"type": "claim", "name": "sub", "expected": "user-42"},
+			map[string]interface{}{"type": "claim", "name": "aud", "expected": "api"},
+			map[string]interface{}{"type": "valid", "expected": true},
+			map[string]interface{}{"type": "contains", "path": ".claims.roles", "expected": "admin"},
+			map[string]interface{}{"type": "greater_than", "path": ".expires_in", "expected": 3000.0},
+			map[string]interface{}{"type": "exists", "path": ".claims.exp"},
+		},
+		"save": []interface{}{
+			map[string]interface{}{"json_path": ".token", "as": "token"},
+			map[string]interface{}{"json_path": ".claims.sub", "as": "user_id"},
+		},
+	}
+	if results, err := processAssertions(p, verified, subject, map[string]interface{}{}, nil); err != nil || assertions.Summary(results) != "" {
+		t.Fatalf("assertions failed: %v %+v", err, results)
+	}
+	saved := make(map[string]string)
+	if err := saves.Process(p, subject, saved); err != nil || saved["token"] != signed.Token || saved["user_id"] != "user-42" {
+		t.Errorf("unexpected saves %v: %v", saved, err)
+	}
+}
+
+func TestVerifyTimeClaims(t *testing.T) {
+	expired := run(t, &JWTConfig{Action: ActionSign, Secret: "s", Claims: map[string]interface{}{"exp": float64(now.Add(-time.Minute).Unix())}})
+	if *expired.ExpiresIn != -60 {
+		t.Errorf("expected expires_in -60, got %d", *expired.ExpiresIn)
+	}
+
+	response := run(t, &JWTConfig{Action: ActionVerify, Token: expired.Token, Secret: "s"})
+	if response.Valid || !strings.Contains(response.Error, "token expired at 2026-03-01T11:59:00Z") {
+		t.Errorf("expected an expired token, got %+v", response)
+	}
+	if response := run(t, &JWTConfig{Action: ActionVerify, Token: expired.Token, Secret: "s", Leeway: "2m"}); !response.Valid {
+		t.Errorf("expected leeway to accept the token: %s", response.Error)
+	}
+
+	notYet := run(t, &JWTConfig{Action: ActionSign, Secret: "s", Claims: map[string]interface{}{"nbf": float64(now.Add(time.Hour).Unix())}})
+	if response := run(t, &JWTConfig{Action: ActionVerify, Token: notYet.Token, Secret: "s"}); response.Valid || !strings.Contains(response.Error, "not valid before") {
+		t.Errorf("expected a not-yet-valid token, got %+v", response)
+	}
+
+	// Asserting that a token is rejected
+	results, err := processAssertions(map[string]interface{}{
+		"assertions": []interface{}{map[string]interface{}{"type": "valid", "expected": false}},
+	}, response, nil, map[string]interface{}{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failure := assertions.Summary(results); failure != "" {
+		t.Errorf("valid: false failed: %s", failure)
+	}
+	if !hasValidAssertion(map[string]interface{}{"assertions": []interface{}{map[string]interface{}{"type": "valid"}}}) {
+		t.Error("expected the valid assertion to be found")
+	}
+
+	// A malformed token is rejected, not an error
+	if response := run(t, &JWTConfig{Action: ActionVerify, Token: "not-a-jwt", Secret: "s"}); response.Valid || !strings.Contains(response.Error, "failed to decode token") {
+		t.Errorf("expected a malformed token rejection, got %+v", response)
+	}
+	if _, err := execute(context.Background(), &JWTConfig{Action: ActionDecode, Token: "not-a-jwt"}, nil, now); err == nil {
+		t.Error("expected decode to fail on a malformed token")
+	}
+}
+
+func TestAsymmetricKeys(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	edPublic, edPrivate, _ := ed25519.GenerateKey(rand.Reader)
+
+	cases := []struct {
+		private, public interface{}
+		algorithm       string
+	}{
+		{rsaKey, &rsaKey.PublicKey, "RS256"},
+		{ecKey, &ecKey.PublicKey, "ES384"},
+		{edPrivate, edPublic, "EdDSA"},
+	}
+	for _, tc := range cases {
+		privatePEM, publicPEM := pemKeys(t, tc.private, tc.public)
+		signed := run(t, &JWTConfig{Action: ActionSign, PrivateKey: privatePEM, Claims: map[string]interface{}{"sub": "svc"}})
+		if signed.Header["alg"] != tc.algorithm {
+			t.Errorf("expected %s by default, got %v", tc.algorithm, signed.Header["alg"])
+		}
+		if response := run(t, &JWTConfig{Action: ActionVerify, Token: signed.Token, PublicKey: publicPEM}); !response.Valid {
+			t.Errorf("%s: expected a valid token: %s", tc.algorithm, response.Error)
+		}
+	}
+
+	// A token signed with HS256 using the public key as the secret must not verify
+	_, publicPEM := pemKeys(t, rsaKey, &rsaKey.PublicKey)
+	forged := run(t, &JWTConfig{Action: ActionSign, Secret: publicPEM, Claims: map[string]interface{}{"sub": "attacker"}})
+	if response := run(t, &JWTConfig{Action: ActionVerify, Token: forged.Token, PublicKey: publicPEM}); response.Valid {
+		t.Error("expected an algorithm confusion token to be rejected")
+	}
+
+	// PS256 needs to be asked for explicitly
+	privatePEM, _ := pemKeys(t, rsaKey, &rsaKey.PublicKey)
+	pss := run(t, &JWTConfig{Action: ActionSign, PrivateKey: privatePEM, Algorithm: "PS256"})
+	if response := run(t, &JWTConfig{Action: ActionVerify, Token: pss.Token, PublicKey: publicPEM}); !response.Valid || pss.Header["alg"] != "PS256" {
+		t.Errorf("expected a valid PS256 token: %s", response.Error)
+	}
+}
+
+func TestVerifyWithJWKS(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	jwks := map[string]interface{}{"keys": []interface{}{
+		map[string]interface{}{"kty": "RSA", "kid": "old", "use": "sig", "n": encode(otherKey.N.Bytes()), "e": encode(big.NewInt(int64(otherKey.E)).Bytes())},
+		map[string]interface{}{"kty": "RSA", "kid": "current", "n": encode(rsaKey.N.Bytes()), "e": encode(big.NewInt(int64(rsaKey.E)).Bytes())},
+		map[string]interface{}{"kty": "RSA", "kid": "enc", "use": "enc", "n": encode(rsaKey.N.Bytes()), "e": "AQAB"},
+	}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(jwks)
+	}))
+	defer server.Close()
+
+	privatePEM, _ := pemKeys(t, rsaKey, &rsaKey.PublicKey)
+	signed := run(t, &JWTConfig{Action: ActionSign, PrivateKey: privatePEM, KeyID: "current", ExpiresIn: "5m"})
+	response := run(t, &JWTConfig{Action: ActionVerify, Token: signed.Token, JWKSURL: server.URL})
+	if !response.Valid {
+		t.Fatalf("expected a valid token: %s", response.Error)
+	}
+
+	unknown := run(t, &JWTConfig{Action: ActionSign, PrivateKey: privatePEM, KeyID: "rotated"})
+	if response := run(t, &JWTConfig{Action: ActionVerify, Token: unknown.Token, JWKSURL: server.URL}); response.Valid || !strings.Contains(response.Error, `no key with kid "rotated"`) {
+		t.Errorf("expected an unknown kid rejection, got %+v", response)
+	}
+}
+
+func TestValidateAndParseConfig(t *testing.T) {
+	config := &JWTConfig{}
+	if err := parseConfig(map[string]interface{}{
+		"action": "sign",
+		"secret": "{{ .env.JWT_SECRET }}",
+		"claims": map[string]interface{}{"sub": "{{ user_id }}", "org": map[string]interface{}{"id": "{{ org_id }}"}},
+	}, config); err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if err := applyVariableReplacement(config, map[string]interface{}{"user_id": "u1", "org_id": "o1"}, map[string]string{"JWT_SECRET": "s3"}); err != nil {
+		t.Fatal(err)
+	}
+	if config.Secret != "s3" || config.Claims["sub"] != "u1" || config.Claims["org"].(map[string]interface{})["id"] != "o1" {
+		t.Errorf("templates not applied: %+v", config)
+	}
+	if err := parseConfig(map[string]interface{}{"claims": "sub=1"}, &JWTConfig{}); err == nil {
+		t.Error("expected claims to require a map")
+	}
+
+	invalid := []struct {
+		config  JWTConfig
+		message string
+	}{
+		{JWTConfig{Action: ActionSign}, "needs secret or private_key"},
+		{JWTConfig{Action: ActionSign, Secret: "s", Algorithm: "RS256"}, "secret can only be used"},
+		{JWTConfig{Action: ActionSign, Secret: "s", Algorithm: "none"}, "unsupported algorithm"},
+		{JWTConfig{Action: ActionSign, Secret: "s", Headers: map[string]interface{}{"alg": "none"}}, "headers can't set alg"},
+		{JWTConfig{Action: ActionVerify, Token: "t"}, "exactly one of"},
+		{JWTConfig{Action: ActionVerify, Token: "t", Secret: "s", PublicKey: "k"}, "exactly one of"},
+		{JWTConfig{Action: ActionDecode}, "token is required"},
+		{JWTConfig{Action: "refresh"}, "action must be"},
+	}
+	for _, tc := range invalid {
+		if err := validateConfig(&tc.config); err == nil || !strings.Contains(err.Error(), tc.message) {
+			t.Errorf("expected %q, got %v", tc.message, err)
+		}
+	}
+}
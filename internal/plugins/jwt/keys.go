@@ -0,0 +1,188 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/rocketship-ai/rocketship/internal/egress"
+)
+
+// maxJWKSBytes caps the JWKS document read from jwks_url
+const maxJWKSBytes = 1 << 20
+
+// parsePrivateKey reads a PEM encoded RSA, ECDSA or Ed25519 private key
+func parsePrivateKey(data string) (interface{}, error) {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(data)))
+	if block == nil {
+		return nil, fmt.Errorf("private_key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("private_key must be a PKCS#8, PKCS#1 or SEC 1 private key, got a %s block", block.Type)
+}
+
+// parsePublicKey reads a PEM encoded public key or certificate
+func parsePublicKey(data string) (interface{}, error) {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(data)))
+	if block == nil {
+		return nil, fmt.Errorf("public_key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+		return cert.PublicKey, nil
+	}
+	return nil, fmt.Errorf("public_key must be a PKIX or PKCS#1 public key or a certificate, got a %s block", block.Type)
+}
+
+// algorithmsForKey lists the algorithms that can verify with key, so a token
+// can't pick an algorithm its key was never meant for
+func algorithmsForKey(key interface{}) []string {
+	switch key.(type) {
+	case []byte:
+		return []string{"HS256", "HS384", "HS512"}
+	case *rsa.PublicKey:
+		return []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}
+	case *ecdsa.PublicKey:
+		return []string{"ES256", "ES384", "ES512"}
+	case ed25519.PublicKey:
+		return []string{"EdDSA"}
+	}
+	return nil
+}
+
+// jwk is a single key of a JSON Web Key Set
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// fetchJWKS downloads the key set and returns its signing keys by key ID
+func fetchJWKS(ctx context.Context, url string, policy *egress.Policy) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jwks_url: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	client := &http.Client{Transport: policy.Transport()}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: %s returned %s", url, resp.Status)
+	}
+
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxJWKSBytes)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS from %s: %w", url, err)
+	}
+
+	keys := make(map[string]interface{}, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			// Keys of types we can't use don't make the others unusable
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS at %s has no usable signing keys", url)
+	}
+	return keys, nil
+}
+
+// publicKey converts the JWK to the key type the signing methods expect
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBase64URL(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBase64URL(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBase64URL(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBase64URL(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBase64URL(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key length %d", len(x))
+		}
+		return ed25519.PublicKey(x), nil
+	case "oct":
+		return decodeBase64URL(k.K)
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
@@ -0,0 +1,75 @@
+package jwt
+
+import "github.com/rocketship-ai/rocketship/internal/assertions"
+
+// JWTPlugin represents a JWT test step
+type JWTPlugin struct {
+	Name   string    `json:"name" yaml:"name"`
+	Plugin string    `json:"plugin" yaml:"plugin"`
+	Config JWTConfig `json:"config" yaml:"config"`
+}
+
+// JWTConfig selects the action and the keys it uses
+type JWTConfig struct {
+	Action    string `json:"action" yaml:"action"`                           // sign, verify or decode
+	Algorithm string `json:"algorithm,omitempty" yaml:"algorithm,omitempty"` // e.g. HS256, RS256, ES256, EdDSA
+
+	// Keys. HMAC algorithms use secret; the others a PEM key or, for verify, a JWKS.
+	Secret     string `json:"secret,omitempty" yaml:"secret,omitempty"`
+	PrivateKey string `json:"private_key,omitempty" yaml:"private_key,omitempty"` // sign: PEM private key
+	PublicKey  string `json:"public_key,omitempty" yaml:"public_key,omitempty"`   // verify: PEM public key or certificate
+	JWKSURL    string `json:"jwks_url,omitempty" yaml:"jwks_url,omitempty"`       // verify: fetch keys from this JWKS endpoint
+
+	// sign
+	Claims    map[string]interface{} `json:"claims,omitempty" yaml:"claims,omitempty"`
+	Headers   map[string]interface{} `json:"headers,omitempty" yaml:"headers,omitempty"`       // Extra header fields
+	KeyID     string                 `json:"key_id,omitempty" yaml:"key_id,omitempty"`         // kid header
+	ExpiresIn string                 `json:"expires_in,omitempty" yaml:"expires_in,omitempty"` // Sets iat and exp, e.g. "1h"
+
+	// verify and decode
+	Token string `json:"token,omitempty" yaml:"token,omitempty"`
+
+	// verify
+	Issuer   string `json:"issuer,omitempty" yaml:"issuer,omitempty"`     // Required iss
+	Audience string `json:"audience,omitempty" yaml:"audience,omitempty"` // Required aud
+	Leeway   string `json:"leeway,omitempty" yaml:"leeway,omitempty"`     // Clock skew allowed for exp, nbf and iat
+
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"` // Overall step timeout (defaults to 30s)
+}
+
+// Actions supported by the JWT plugin
+const (
+	ActionSign   = "sign"
+	ActionVerify = "verify"
+	ActionDecode = "decode"
+)
+
+// Assertion types supported by the JWT plugin in addition to the shared ones
+const (
+	AssertionTypeClaim = "claim"
+	AssertionTypeValid = "valid"
+)
+
+// JWTResponse contains the token and what it says
+type JWTResponse struct {
+	Action    string                 `json:"action"`
+	Token     string                 `json:"token"`
+	Header    map[string]interface{} `json:"header"`
+	Claims    map[string]interface{} `json:"claims"`
+	Valid     bool                   `json:"valid"`           // verify: signature and time claims check out; sign: always true
+	Error     string                 `json:"error,omitempty"` // verify: why the token was rejected
+	ExpiresAt string                 `json:"expires_at,omitempty"`
+	ExpiresIn *int64                 `json:"expires_in,omitempty"` // Seconds until exp, negative once expired
+	IssuedAt  string                 `json:"issued_at,omitempty"`
+	Duration  string                 `json:"duration"`
+}
+
+// AssertionResult represents a single assertion result for UI display
+type AssertionResult = assertions.Result
+
+// ActivityResponse represents the complete activity response
+type ActivityResponse struct {
+	Response         *JWTResponse      `json:"response"`
+	Saved            map[string]string `json:"saved"`
+	AssertionResults []AssertionResult `json:"assertion_results,omitempty"`
+}
@@ -0,0 +1,155 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+)
+
+// administer runs create_topic, delete_topic or alter_config
+func administer(ctx context.Context, c *client, config *KafkaConfig, response *KafkaResponse) error {
+	admin, err := c.newAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	switch config.Action {
+	case ActionCreateTopic:
+		// -1 leaves the partitions and the replication factor to the broker
+		partitions, replicationFactor := int32(-1), int16(-1)
+		if config.Partitions > 0 {
+			partitions = int32(config.Partitions)
+		}
+		if config.ReplicationFactor > 0 {
+			replicationFactor = int16(config.ReplicationFactor)
+		}
+		created, err := admin.CreateTopic(ctx, partitions, replicationFactor, createConfigs(config.Configs), config.Topic)
+		if errors.Is(err, kerr.TopicAlreadyExists) && config.IfNotExists {
+			response.Skipped = true
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create topic %s: %w", config.Topic, adminError(err, created.ErrMessage))
+		}
+		response.Partitions, response.ReplicationFactor = int(created.NumPartitions), int(created.ReplicationFactor)
+
+	case ActionDeleteTopic:
+		deleted, err := admin.DeleteTopic(ctx, config.Topic)
+		if errors.Is(err, kerr.UnknownTopicOrPartition) && config.IfExists {
+			response.Skipped = true
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to delete topic %s: %w", config.Topic, adminError(err, deleted.ErrMessage))
+		}
+		return nil
+
+	case ActionAlterConfig:
+		altered, err := admin.AlterTopicConfigs(ctx, alterConfigs(config.Configs), config.Topic)
+		if err == nil {
+			for _, result := range altered {
+				if result.Err != nil {
+					err = adminError(result.Err, result.ErrMessage)
+					break
+				}
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to alter configs of topic %s: %w", config.Topic, err)
+		}
+	}
+
+	response.Configs, err = topicConfigs(ctx, admin, config.Topic)
+	return err
+}
+
+// topicConfigs returns the topic's configs. Sensitive configs have no value and
+// are left out.
+func topicConfigs(ctx context.Context, admin *kadm.Client, topic string) (map[string]string, error) {
+	described, err := admin.DescribeTopicConfigs(ctx, topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configs of topic %s: %w", topic, err)
+	}
+	resource, err := described.On(topic, nil)
+	if err == nil && resource.Err != nil {
+		err = adminError(resource.Err, resource.ErrMessage)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configs of topic %s: %w", topic, err)
+	}
+
+	configs := make(map[string]string, len(resource.Configs))
+	for _, config := range resource.Configs {
+		if config.Value != nil && !config.Sensitive {
+			configs[config.Key] = *config.Value
+		}
+	}
+	return configs, nil
+}
+
+// adminError adds the broker's explanation to an error code
+func adminError(err error, message string) error {
+	if message == "" {
+		return err
+	}
+	return fmt.Errorf("%w: %s", err, message)
+}
+
+// createConfigs converts configs to the values a new topic is created with
+func createConfigs(configs map[string]interface{}) map[string]*string {
+	if len(configs) == 0 {
+		return nil
+	}
+	values := make(map[string]*string, len(configs))
+	for name, value := range configs {
+		text, _ := configValue(value)
+		values[name] = kadm.StringPtr(text)
+	}
+	return values
+}
+
+// alterConfigs converts configs to incremental changes, in name order. A
+// config without a value is reset to its default.
+func alterConfigs(configs map[string]interface{}) []kadm.AlterConfig {
+	names := make([]string, 0, len(configs))
+	for name := range configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	changes := make([]kadm.AlterConfig, 0, len(names))
+	for _, name := range names {
+		value, set := configValue(configs[name])
+		if !set {
+			changes = append(changes, kadm.AlterConfig{Op: kadm.DeleteConfig, Name: name})
+			continue
+		}
+		changes = append(changes, kadm.AlterConfig{Op: kadm.SetConfig, Name: name, Value: kadm.StringPtr(value)})
+	}
+	return changes
+}
+
+// configValue formats a config value the way Kafka expects it, e.g. 604800000
+// rather than 6.048e+08
+func configValue(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case int:
+		return strconv.Itoa(v), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return fmt.Sprint(v), true
+	}
+}
@@ -0,0 +1,205 @@
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/egress"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+	"github.com/twmb/franz-go/pkg/sr"
+)
+
+// dialTimeout bounds connecting to one broker
+const dialTimeout = 10 * time.Second
+
+// client holds what the plugin needs to reach the cluster and the registry.
+// Broker clients are created per action, since produce and consume need
+// different options.
+type client struct {
+	opts     []kgo.Opt
+	registry *sr.Client
+	codecs   map[int]*codec // Schemas read from the registry, by ID
+}
+
+// newClient prepares the broker connection, and the registry when one is
+// configured. Connections go through the egress policy.
+func newClient(config *KafkaConfig, policy *egress.Policy) (*client, error) {
+	dial := policy.DialContext(&net.Dialer{Timeout: dialTimeout})
+	if config.TLS != nil {
+		tlsConfig, err := buildTLSConfig(config.TLS)
+		if err != nil {
+			return nil, err
+		}
+		dial = tlsDialer(dial, tlsConfig)
+	}
+
+	c := &client{
+		opts: []kgo.Opt{
+			kgo.SeedBrokers(config.Brokers...),
+			kgo.Dialer(dial),
+			kgo.ClientID("rocketship"),
+		},
+		codecs: map[int]*codec{},
+	}
+	if config.SASL != nil {
+		mechanism, err := saslMechanism(config.SASL)
+		if err != nil {
+			return nil, err
+		}
+		c.opts = append(c.opts, kgo.SASL(mechanism))
+	}
+
+	if config.SchemaRegistry != nil {
+		opts := []sr.ClientOpt{
+			sr.URLs(strings.TrimRight(config.SchemaRegistry.URL, "/")),
+			sr.HTTPClient(&http.Client{Transport: policy.Transport(), Timeout: 30 * time.Second}),
+			sr.UserAgent("rocketship"),
+		}
+		if config.SchemaRegistry.Username != "" {
+			opts = append(opts, sr.BasicAuth(config.SchemaRegistry.Username, config.SchemaRegistry.Password))
+		}
+		registry, err := sr.NewClient(opts...)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema_registry: %w", err)
+		}
+		c.registry = registry
+	}
+	return c, nil
+}
+
+// connect creates a broker client with the connection options and extra, and
+// checks that a broker answers. The client would otherwise retry unreachable
+// brokers and rejected credentials until the step times out.
+func (c *client) connect(ctx context.Context, extra ...kgo.Opt) (*kgo.Client, error) {
+	cl, err := kgo.NewClient(append(append([]kgo.Opt{}, c.opts...), extra...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka client: %w", err)
+	}
+	if err := cl.Ping(ctx); err != nil {
+		cl.Close()
+		return nil, fmt.Errorf("failed to connect to kafka: %w", err)
+	}
+	return cl, nil
+}
+
+// tlsDialer wraps dial in a TLS handshake. Without a server_name, broker
+// certificates are checked against the host being dialed.
+func tlsDialer(dial func(ctx context.Context, network, addr string) (net.Conn, error), config *tls.Config) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig := config.Clone()
+		if tlsConfig.ServerName == "" {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				_ = conn.Close()
+				return nil, err
+			}
+			tlsConfig.ServerName = host
+		}
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("tls handshake with %s: %w", addr, err)
+		}
+		return tlsConn, nil
+	}
+}
+
+// buildTLSConfig loads the trusted CAs and the client certificate
+func buildTLSConfig(config *TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         config.ServerName,
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+
+	caPEM, err := pemValue("ca", config.CA, config.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	if caPEM != nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("tls: no certificates found in the CA")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	certPEM, err := pemValue("cert", config.Cert, config.CertFile)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := pemValue("key", config.Key, config.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case certPEM != nil:
+		if keyPEM == nil {
+			// The key may follow the certificate in the same PEM
+			keyPEM = certPEM
+		}
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("tls: failed to load the client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	case keyPEM != nil:
+		return nil, fmt.Errorf("tls: key needs a client certificate in cert or cert_file")
+	}
+	return tlsConfig, nil
+}
+
+// pemValue returns the PEM given inline or read from the file, or nil
+func pemValue(name, inline, file string) ([]byte, error) {
+	switch {
+	case inline != "" && file != "":
+		return nil, fmt.Errorf("tls: use either %s or %s_file, not both", name, name)
+	case inline != "":
+		return []byte(inline), nil
+	case file != "":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("tls: failed to read %s_file: %w", name, err)
+		}
+		return data, nil
+	}
+	return nil, nil
+}
+
+// saslMechanism converts the sasl block into the client's mechanism
+func saslMechanism(config *SASLConfig) (sasl.Mechanism, error) {
+	switch strings.ToUpper(config.Mechanism) {
+	case MechanismPlain:
+		return plain.Auth{User: config.Username, Pass: config.Password}.AsMechanism(), nil
+	case MechanismSCRAMSHA256:
+		return scram.Auth{User: config.Username, Pass: config.Password}.AsSha256Mechanism(), nil
+	case MechanismSCRAMSHA512:
+		return scram.Auth{User: config.Username, Pass: config.Password}.AsSha512Mechanism(), nil
+	default:
+		return nil, fmt.Errorf("sasl.mechanism must be PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512, got %q", config.Mechanism)
+	}
+}
+
+// newAdmin creates an admin client, closed with the broker client it wraps
+func (c *client) newAdmin(ctx context.Context) (*kadm.Client, error) {
+	cl, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return kadm.NewClient(cl), nil
+}
@@ -0,0 +1,1082 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/itchyny/gojq"
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.temporal.io/sdk/activity"
+)
+
+const (
+	defaultTimeout     = 60 * time.Second
+	defaultWait        = 10 * time.Second
+	defaultMaxMessages = 100
+	maxMessagesLimit   = 10000
+)
+
+// Auto-register the plugin when the package is imported
+func init() {
+	plugins.RegisterPlugin(&KafkaPlugin{})
+}
+
+// GetType returns the plugin type identifier
+func (kp *KafkaPlugin) GetType() string {
+	return "kafka"
+}
+
+// Activity produces messages to a topic, consumes them or manages the topic,
+// and checks the result
+func (kp *KafkaPlugin) Activity(ctx context.Context, p map[string]interface{}) (interface{}, error) {
+	logger := activity.GetLogger(ctx)
+
+	// Parse configuration from parameters
+	configData, ok := p["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid config format")
+	}
+
+	config := &KafkaConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		return nil, fmt.Errorf("failed to parse kafka config: %w", err)
+	}
+
+	timeout := defaultTimeout
+	if config.Timeout != "" {
+		parsed, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", config.Timeout, err)
+		}
+		timeout = parsed
+	}
+
+	// Convert state to map[string]interface{} for template processing
+	state := make(map[string]interface{})
+	if stateStr, ok := p["state"].(map[string]string); ok {
+		for k, v := range stateStr {
+			state[k] = v
+		}
+	} else if stateInt, ok := p["state"].(map[string]interface{}); ok {
+		state = stateInt
+	}
+
+	// Extract env secrets from params (for {{ .env.* }} template resolution)
+	env := make(map[string]string)
+	if envData, ok := p["env"].(map[string]interface{}); ok {
+		for k, v := range envData {
+			if strVal, ok := v.(string); ok {
+				env[k] = strVal
+			}
+		}
+	} else if envData, ok := p["env"].(map[string]string); ok {
+		env = envData
+	}
+
+	if err := applyVariableReplacement(config, state, env); err != nil {
+		return nil, fmt.Errorf("variable replacement failed: %w", err)
+	}
+
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	policy, err := egress.FromParams(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
+
+	logger.Info("Executing kafka plugin", "action", config.Action, "topic", config.Topic, "format", formatName(config.Format))
+
+	c, err := newClient(config, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	response, err := execute(ctx, c, config)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := assertions.Normalize(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare kafka result: %w", err)
+	}
+
+	assertionResults, err := processAssertions(p, response, subject, state, env)
+	if err != nil {
+		return nil, err
+	}
+	if failure := assertions.Summary(assertionResults); failure != "" {
+		return nil, fmt.Errorf("%s", failure)
+	}
+
+	saved := make(map[string]string)
+	if err := saves.Process(p, subject, saved); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Kafka step completed", "action", config.Action, "messages", response.Count, "duration", response.Duration)
+
+	return &ActivityResponse{
+		Response:         response,
+		Saved:            saved,
+		AssertionResults: assertionResults,
+	}, nil
+}
+
+// execute runs the configured action
+func execute(ctx context.Context, c *client, config *KafkaConfig) (*KafkaResponse, error) {
+	start := time.Now()
+
+	response := &KafkaResponse{Action: config.Action, Topic: config.Topic}
+	var err error
+	switch config.Action {
+	case ActionProduce:
+		err = produce(ctx, c, config, response)
+	case ActionConsume:
+		err = consume(ctx, c, config, response)
+	case ActionCreateTopic, ActionDeleteTopic, ActionAlterConfig:
+		err = administer(ctx, c, config, response)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response.Count = len(response.Messages)
+	response.Duration = time.Since(start).String()
+	return response, nil
+}
+
+// produce sends the messages and waits for the brokers to acknowledge them.
+// Any message the brokers reject fails the step.
+func produce(ctx context.Context, c *client, config *KafkaConfig, response *KafkaResponse) error {
+	format := formatName(config.Format)
+	var keyCodec, valueCodec *codec
+	if usesSchema(format) {
+		var err error
+		if valueCodec, err = c.resolveCodec(ctx, format, config.ValueSchema, config.Topic+"-value"); err != nil {
+			return fmt.Errorf("value_schema: %w", err)
+		}
+		response.ValueSchemaID = valueCodec.id
+		if config.KeySchema != nil || hasKeys(config.Messages) {
+			if keyCodec, err = c.resolveCodec(ctx, format, config.KeySchema, config.Topic+"-key"); err != nil {
+				return fmt.Errorf("key_schema: %w", err)
+			}
+			response.KeySchemaID = keyCodec.id
+		}
+	}
+
+	records := make([]*kgo.Record, 0, len(config.Messages))
+	for i, message := range config.Messages {
+		record := &kgo.Record{Topic: config.Topic, Context: ctx}
+		var err error
+		if message.Key != nil {
+			if record.Key, err = encodePayload(format, keyCodec, message.Key); err != nil {
+				return fmt.Errorf("messages[%d].key: %w", i, err)
+			}
+		}
+		if record.Value, err = encodePayload(format, valueCodec, message.Value); err != nil {
+			return fmt.Errorf("messages[%d].value: %w", i, err)
+		}
+		if message.Partition != nil {
+			record.Partition = int32(*message.Partition)
+			record.Context = context.WithValue(ctx, explicitPartition{}, true)
+		}
+		records = append(records, record)
+	}
+
+	opts := []kgo.Opt{kgo.RecordPartitioner(partitioner{kgo.StickyKeyPartitioner(nil)})}
+	if tx := config.Transaction; tx != nil {
+		transactionalID := tx.TransactionalID
+		if transactionalID == "" {
+			transactionalID = "rocketship-" + uuid.NewString()
+		}
+		opts = append(opts, kgo.TransactionalID(transactionalID))
+	}
+	cl, err := c.connect(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	defer cl.Close()
+
+	results, outcome, err := send(ctx, cl, records, config.Transaction)
+	if err != nil {
+		return err
+	}
+	response.Transaction = outcome
+
+	var failed []string
+	for i, result := range results {
+		if result.Err != nil {
+			failed = append(failed, fmt.Sprintf("messages[%d]: %v", i, result.Err))
+			continue
+		}
+		response.Messages = append(response.Messages, Message{
+			Topic:     config.Topic,
+			Partition: int(result.Record.Partition),
+			Offset:    result.Record.Offset,
+			Key:       displayPayload(format, config.Messages[i].Key),
+			Value:     displayPayload(format, config.Messages[i].Value),
+			JSON:      jsonPayload(format, displayPayload(format, config.Messages[i].Value)),
+		})
+	}
+	if len(failed) > 0 && config.Transaction != nil {
+		return fmt.Errorf("kafka rejected %d of %d messages, so the transaction was aborted: %s", len(failed), len(config.Messages), strings.Join(failed, "; "))
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("kafka rejected %d of %d messages: %s", len(failed), len(config.Messages), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// producer is the part of the broker client send uses
+type producer interface {
+	BeginTransaction() error
+	ProduceSync(ctx context.Context, rs ...*kgo.Record) kgo.ProduceResults
+	EndTransaction(ctx context.Context, commit kgo.TransactionEndTry) error
+}
+
+// send produces the records and returns their results in the records' order.
+// With a transaction, the records are written in it, and it is committed or
+// aborted as configured; a rejected record aborts it.
+func send(ctx context.Context, p producer, records []*kgo.Record, tx *TransactionConfig) ([]kgo.ProduceResult, string, error) {
+	if tx != nil {
+		if err := p.BeginTransaction(); err != nil {
+			return nil, "", fmt.Errorf("failed to begin transaction: %w", err)
+		}
+	}
+
+	// Results arrive in the order the brokers acknowledge them
+	results := make([]kgo.ProduceResult, len(records))
+	for _, result := range p.ProduceSync(ctx, records...) {
+		for i, record := range records {
+			if record == result.Record {
+				results[i] = result
+			}
+		}
+	}
+	if tx == nil {
+		return results, "", nil
+	}
+
+	commit := tx.End != TransactionAbort
+	for _, result := range results {
+		if result.Err != nil {
+			commit = false
+		}
+	}
+	if !commit {
+		if err := p.EndTransaction(ctx, kgo.TryAbort); err != nil {
+			return nil, "", fmt.Errorf("failed to abort transaction: %w", err)
+		}
+		return results, "aborted", nil
+	}
+	if err := p.EndTransaction(ctx, kgo.TryCommit); err != nil {
+		return nil, "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return results, "committed", nil
+}
+
+// explicitPartition marks the context of records produced to the partition
+// their message names
+type explicitPartition struct{}
+
+// partitioner sends records with an explicit partition there, and leaves the
+// rest to the default partitioner
+type partitioner struct {
+	fallback kgo.Partitioner
+}
+
+func (p partitioner) ForTopic(topic string) kgo.TopicPartitioner {
+	return topicPartitioner{p.fallback.ForTopic(topic)}
+}
+
+type topicPartitioner struct {
+	kgo.TopicPartitioner
+}
+
+func (p topicPartitioner) RequiresConsistency(r *kgo.Record) bool {
+	return isExplicit(r) || p.TopicPartitioner.RequiresConsistency(r)
+}
+
+func (p topicPartitioner) Partition(r *kgo.Record, n int) int {
+	if isExplicit(r) {
+		return int(r.Partition)
+	}
+	return p.TopicPartitioner.Partition(r, n)
+}
+
+func isExplicit(r *kgo.Record) bool {
+	explicit, _ := r.Context.Value(explicitPartition{}).(bool)
+	return explicit
+}
+
+// consume reads the topic until min_messages matching messages have arrived,
+// max_messages is reached or wait runs out. Without a group it reads every
+// partition directly; in a group it joins without committing offsets, so steps
+// don't move a shared group along.
+func consume(ctx context.Context, c *client, config *KafkaConfig, response *KafkaResponse) error {
+	format := formatName(config.Format)
+	var query *gojq.Query
+	if config.Filter != nil && config.Filter.JSONPath != "" {
+		var err error
+		if query, err = gojq.Parse(config.Filter.JSONPath); err != nil {
+			return fmt.Errorf("failed to parse filter jq expression %q: %w", config.Filter.JSONPath, err)
+		}
+	}
+
+	minMessages := 1
+	if config.MinMessages != nil {
+		minMessages = *config.MinMessages
+	}
+	maxMessages := config.MaxMessages
+	if maxMessages == 0 {
+		maxMessages = defaultMaxMessages
+	}
+	if maxMessages < minMessages {
+		maxMessages = minMessages
+	}
+	wait := defaultWait
+	if config.Wait != "" {
+		wait, _ = time.ParseDuration(config.Wait)
+	}
+
+	start := kgo.NewOffset().AtStart()
+	if config.From == FromLatest {
+		start = kgo.NewOffset().AtEnd()
+	}
+	opts := []kgo.Opt{kgo.ConsumeTopics(config.Topic), kgo.ConsumeResetOffset(start)}
+	if config.Isolation == IsolationReadCommitted {
+		opts = append(opts, kgo.FetchIsolationLevel(kgo.ReadCommitted()))
+	}
+	if config.Group != "" {
+		opts = append(opts, kgo.ConsumerGroup(config.Group), kgo.DisableAutoCommit())
+	}
+	cl, err := c.connect(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	defer cl.Close()
+
+	pollCtx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+
+	var messages []Message
+	for len(messages) < maxMessages {
+		fetches := cl.PollRecords(pollCtx, maxMessages-len(messages))
+		for _, fetchErr := range fetches.Errors() {
+			if errors.Is(fetchErr.Err, context.DeadlineExceeded) || errors.Is(fetchErr.Err, context.Canceled) {
+				continue
+			}
+			return fmt.Errorf("failed to consume topic %s: %w", config.Topic, fetchErr.Err)
+		}
+		for _, record := range fetches.Records() {
+			message, err := decodeRecord(ctx, c, format, record)
+			if err != nil {
+				return err
+			}
+			if !matchesFilter(message, config.Filter, query) {
+				response.Filtered++
+				continue
+			}
+			messages = append(messages, message)
+		}
+
+		if ctx.Err() != nil {
+			return fmt.Errorf("consuming topic %s: %w", config.Topic, ctx.Err())
+		}
+		if (minMessages > 0 && len(messages) >= minMessages) || pollCtx.Err() != nil {
+			break
+		}
+	}
+
+	if len(messages) < minMessages {
+		if config.Filter != nil {
+			return fmt.Errorf("consumed %d of at least %d messages matching the filter from topic %s within %s (%d skipped)", len(messages), minMessages, config.Topic, wait, response.Filtered)
+		}
+		return fmt.Errorf("consumed %d of at least %d messages from topic %s within %s", len(messages), minMessages, config.Topic, wait)
+	}
+	response.Messages = messages
+	return nil
+}
+
+// encodePayload serializes a key or value. The binary format sends strings as
+// they are and anything else as JSON; json sends everything as JSON; schema
+// formats encode with the codec.
+func encodePayload(format string, cd *codec, value interface{}) ([]byte, error) {
+	switch format {
+	case FormatBinary:
+		text, err := payloadText(value)
+		return []byte(text), err
+	case FormatJSON:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode as JSON: %w", err)
+		}
+		return data, nil
+	default:
+		return cd.encode(value)
+	}
+}
+
+// displayPayload is a produced key or value as a consume would report it
+func displayPayload(format string, value interface{}) interface{} {
+	if value == nil || format != FormatBinary {
+		return value
+	}
+	text, _ := payloadText(value)
+	return text
+}
+
+func payloadText(value interface{}) (string, error) {
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode as JSON: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// decodeRecord converts a consumed record. Binary keys and values become
+// text; the other formats are decoded.
+func decodeRecord(ctx context.Context, c *client, format string, record *kgo.Record) (Message, error) {
+	message := Message{Topic: record.Topic, Partition: int(record.Partition), Offset: record.Offset}
+	var err error
+	if record.Key != nil {
+		if message.Key, err = decodePayload(ctx, c, format, record.Key, true); err != nil {
+			return Message{}, fmt.Errorf("failed to decode key at partition %d offset %d: %w", record.Partition, record.Offset, err)
+		}
+	}
+	if record.Value != nil {
+		if message.Value, err = decodePayload(ctx, c, format, record.Value, false); err != nil {
+			return Message{}, fmt.Errorf("failed to decode value at partition %d offset %d: %w", record.Partition, record.Offset, err)
+		}
+	}
+	message.JSON = jsonPayload(format, message.Value)
+	return message, nil
+}
+
+// decodePayload decodes a key or value in the format. With schema formats,
+// keys that aren't framed with a schema ID are read as text, since topics
+// often key schema encoded values by plain strings.
+func decodePayload(ctx context.Context, c *client, format string, data []byte, key bool) (interface{}, error) {
+	switch format {
+	case FormatBinary:
+		return string(data), nil
+	case FormatJSON:
+		var value interface{}
+		if err := json.Unmarshal(data, &value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+
+	id, payload, err := wireHeader.DecodeID(data)
+	if err != nil {
+		if key {
+			return string(data), nil
+		}
+		return nil, fmt.Errorf("not framed with a schema ID")
+	}
+	cd, err := c.codecByID(ctx, format, id)
+	if err != nil {
+		return nil, err
+	}
+	return cd.decode(payload)
+}
+
+// jsonPayload decodes a binary value that is JSON text. Other formats already
+// carry structured values, so it returns nil for them, as for text that isn't JSON.
+func jsonPayload(format string, value interface{}) interface{} {
+	text, ok := value.(string)
+	if !ok || format != FormatBinary {
+		return nil
+	}
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(text), &decoded); err != nil {
+		return nil
+	}
+	return decoded
+}
+
+// matchesFilter reports whether a consumed message satisfies the filter. The
+// jq expression runs over the decoded JSON of binary values, and over the
+// value itself otherwise.
+func matchesFilter(message Message, filter *FilterConfig, query *gojq.Query) bool {
+	if filter == nil {
+		return true
+	}
+	if filter.Key != "" {
+		if key, _ := payloadText(message.Key); message.Key == nil || key != filter.Key {
+			return false
+		}
+	}
+	if filter.Contains != "" {
+		if text, _ := payloadText(message.Value); !strings.Contains(text, filter.Contains) {
+			return false
+		}
+	}
+	if query == nil {
+		return true
+	}
+
+	data := message.Value
+	if message.JSON != nil {
+		data = message.JSON
+	}
+	iter := query.Run(data)
+	v, ok := iter.Next()
+	if !ok {
+		return false
+	}
+	if _, isErr := v.(error); isErr {
+		return false
+	}
+	return v != nil && v != false
+}
+
+func hasKeys(messages []MessageConfig) bool {
+	for _, message := range messages {
+		if message.Key != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// usesSchema reports whether the format serializes against a registered schema
+func usesSchema(format string) bool {
+	return format == FormatAvro || format == FormatProtobuf || format == FormatJSONSchema
+}
+
+func formatName(format string) string {
+	if format == "" {
+		return FormatBinary
+	}
+	return format
+}
+
+// processAssertions evaluates all assertions and returns their results
+func processAssertions(p map[string]interface{}, response *KafkaResponse, subject interface{}, state map[string]interface{}, env map[string]string) ([]AssertionResult, error) {
+	context := dsl.TemplateContext{Runtime: state, Env: env}
+	return assertions.Process(p, subject, context, func(assertionMap map[string]interface{}, result *AssertionResult) bool {
+		return evaluateAssertion(response, assertionMap, result)
+	})
+}
+
+// evaluateAssertion evaluates the kafka plugin's own assertion types
+func evaluateAssertion(response *KafkaResponse, assertionMap map[string]interface{}, result *AssertionResult) bool {
+	expected := result.Expected
+	switch result.Type {
+	case AssertionTypeMessageCount:
+		result.Actual = response.Count
+		if !assertions.Equal(float64(response.Count), expected) {
+			result.Message = fmt.Sprintf("expected %v messages, got %d", expected, response.Count)
+		} else {
+			result.Passed = true
+		}
+
+	default:
+		return false
+	}
+	return true
+}
+
+// validateConfig checks the action has what it needs once templates are rendered
+func validateConfig(config *KafkaConfig) error {
+	if len(config.Brokers) == 0 {
+		return fmt.Errorf("brokers is required")
+	}
+	for i, broker := range config.Brokers {
+		if strings.TrimSpace(broker) == "" || strings.Contains(broker, "://") {
+			return fmt.Errorf("brokers[%d] must be a host:port address, got %q", i, broker)
+		}
+	}
+	if sasl := config.SASL; sasl != nil {
+		switch strings.ToUpper(sasl.Mechanism) {
+		case MechanismPlain, MechanismSCRAMSHA256, MechanismSCRAMSHA512:
+		default:
+			return fmt.Errorf("sasl.mechanism must be PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512, got %q", sasl.Mechanism)
+		}
+		if sasl.Username == "" {
+			return fmt.Errorf("sasl.username is required")
+		}
+	}
+	if config.Topic == "" {
+		return fmt.Errorf("topic is required")
+	}
+
+	switch config.Format {
+	case "", FormatBinary, FormatJSON, FormatAvro, FormatProtobuf, FormatJSONSchema:
+	default:
+		return fmt.Errorf("format must be binary, json, avro, protobuf or jsonschema, got %q", config.Format)
+	}
+	if !usesSchema(formatName(config.Format)) && (config.KeySchema != nil || config.ValueSchema != nil) {
+		return fmt.Errorf("key_schema and value_schema need format avro, protobuf or jsonschema")
+	}
+	for name, schema := range map[string]*SchemaConfig{"key_schema": config.KeySchema, "value_schema": config.ValueSchema} {
+		if schema == nil {
+			continue
+		}
+		if schema.ID > 0 && schema.Schema != "" {
+			return fmt.Errorf("%s: use either id or schema, not both", name)
+		}
+		if schema.Message != "" && config.Format != FormatProtobuf {
+			return fmt.Errorf("%s.message only applies to format protobuf", name)
+		}
+		if schema.Version != "" && schema.Version != "latest" {
+			if v, err := strconv.Atoi(schema.Version); err != nil || v < 1 {
+				return fmt.Errorf("%s.version must be a version number or latest, got %q", name, schema.Version)
+			}
+		}
+	}
+	if config.SchemaRegistry != nil && config.SchemaRegistry.URL == "" {
+		return fmt.Errorf("schema_registry.url is required")
+	}
+	if usesSchema(formatName(config.Format)) && config.SchemaRegistry == nil && (config.Action == ActionProduce || config.Action == ActionConsume) {
+		return fmt.Errorf("schema_registry is required with format %s", config.Format)
+	}
+
+	switch config.Action {
+	case ActionProduce:
+		if len(config.Messages) == 0 {
+			return fmt.Errorf("messages is required with action produce")
+		}
+		for i, message := range config.Messages {
+			if message.Value == nil {
+				return fmt.Errorf("messages[%d].value is required", i)
+			}
+			if message.Partition != nil && *message.Partition < 0 {
+				return fmt.Errorf("messages[%d].partition must not be negative", i)
+			}
+		}
+		if tx := config.Transaction; tx != nil && tx.End != "" && tx.End != TransactionCommit && tx.End != TransactionAbort {
+			return fmt.Errorf("transaction.end must be commit or abort, got %q", tx.End)
+		}
+	case ActionConsume:
+		switch config.From {
+		case "", FromEarliest, FromLatest:
+		default:
+			return fmt.Errorf("from must be earliest or latest, got %q", config.From)
+		}
+		switch config.Isolation {
+		case "", IsolationReadUncommitted, IsolationReadCommitted:
+		default:
+			return fmt.Errorf("isolation must be read_uncommitted or read_committed, got %q", config.Isolation)
+		}
+		if config.MinMessages != nil && *config.MinMessages < 0 {
+			return fmt.Errorf("min_messages must not be negative")
+		}
+		if config.MaxMessages < 0 || config.MaxMessages > maxMessagesLimit {
+			return fmt.Errorf("max_messages must be between 1 and %d", maxMessagesLimit)
+		}
+		if config.Wait != "" {
+			if _, err := time.ParseDuration(config.Wait); err != nil {
+				return fmt.Errorf("invalid wait %q: %w", config.Wait, err)
+			}
+		}
+		if filter := config.Filter; filter != nil && filter.JSONPath == "" && filter.Contains == "" && filter.Key == "" {
+			return fmt.Errorf("filter needs json_path, contains or key")
+		}
+	case ActionCreateTopic:
+		if config.Partitions < 0 {
+			return fmt.Errorf("partitions must not be negative")
+		}
+		if config.ReplicationFactor < 0 {
+			return fmt.Errorf("replication_factor must not be negative")
+		}
+		for name, value := range config.Configs {
+			if value == nil {
+				return fmt.Errorf("configs.%s needs a value with create_topic", name)
+			}
+		}
+	case ActionDeleteTopic:
+	case ActionAlterConfig:
+		if len(config.Configs) == 0 {
+			return fmt.Errorf("configs is required with action alter_config")
+		}
+	case "":
+		return fmt.Errorf("action is required")
+	default:
+		return fmt.Errorf("action must be produce, consume, create_topic, delete_topic or alter_config, got %q", config.Action)
+	}
+
+	if config.Filter != nil && config.Action != ActionConsume {
+		return fmt.Errorf("filter only applies to consume")
+	}
+	if config.Isolation != "" && config.Action != ActionConsume {
+		return fmt.Errorf("isolation only applies to consume")
+	}
+	if config.Transaction != nil && config.Action != ActionProduce {
+		return fmt.Errorf("transaction only applies to produce")
+	}
+	if config.IfNotExists && config.Action != ActionCreateTopic {
+		return fmt.Errorf("if_not_exists only applies to create_topic")
+	}
+	if config.IfExists && config.Action != ActionDeleteTopic {
+		return fmt.Errorf("if_exists only applies to delete_topic")
+	}
+	if (config.Partitions != 0 || config.ReplicationFactor != 0) && config.Action != ActionCreateTopic {
+		return fmt.Errorf("partitions and replication_factor only apply to create_topic")
+	}
+	if len(config.Configs) > 0 && config.Action != ActionCreateTopic && config.Action != ActionAlterConfig {
+		return fmt.Errorf("configs only applies to create_topic and alter_config")
+	}
+
+	return nil
+}
+
+// applyVariableReplacement processes templates in the connection settings,
+// the schemas and the messages
+func applyVariableReplacement(config *KafkaConfig, state map[string]interface{}, env map[string]string) error {
+	context := dsl.TemplateContext{
+		Runtime: state,
+		Env:     env,
+	}
+
+	fields := map[string]*string{
+		"topic": &config.Topic,
+		"group": &config.Group,
+	}
+	for i := range config.Brokers {
+		fields[fmt.Sprintf("brokers[%d]", i)] = &config.Brokers[i]
+	}
+	if tls := config.TLS; tls != nil {
+		fields["tls.ca"] = &tls.CA
+		fields["tls.ca_file"] = &tls.CAFile
+		fields["tls.cert"] = &tls.Cert
+		fields["tls.cert_file"] = &tls.CertFile
+		fields["tls.key"] = &tls.Key
+		fields["tls.key_file"] = &tls.KeyFile
+		fields["tls.server_name"] = &tls.ServerName
+	}
+	if sasl := config.SASL; sasl != nil {
+		fields["sasl.mechanism"] = &sasl.Mechanism
+		fields["sasl.username"] = &sasl.Username
+		fields["sasl.password"] = &sasl.Password
+	}
+	if tx := config.Transaction; tx != nil {
+		fields["transaction.transactional_id"] = &tx.TransactionalID
+	}
+	if filter := config.Filter; filter != nil {
+		fields["filter.json_path"] = &filter.JSONPath
+		fields["filter.contains"] = &filter.Contains
+		fields["filter.key"] = &filter.Key
+	}
+	if registry := config.SchemaRegistry; registry != nil {
+		fields["schema_registry.url"] = &registry.URL
+		fields["schema_registry.username"] = &registry.Username
+		fields["schema_registry.password"] = &registry.Password
+	}
+	for name, schema := range map[string]*SchemaConfig{"key_schema": config.KeySchema, "value_schema": config.ValueSchema} {
+		if schema != nil {
+			fields[name+".subject"] = &schema.Subject
+			fields[name+".version"] = &schema.Version
+			fields[name+".message"] = &schema.Message
+		}
+	}
+	for name, value := range fields {
+		if *value == "" {
+			continue
+		}
+		processed, err := dsl.ProcessTemplate(*value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process %s template: %w", name, err)
+		}
+		*value = processed
+	}
+
+	for name, value := range config.Configs {
+		if text, ok := value.(string); ok {
+			processed, err := dsl.ProcessTemplate(text, context)
+			if err != nil {
+				return fmt.Errorf("failed to process configs.%s template: %w", name, err)
+			}
+			config.Configs[name] = processed
+		}
+	}
+
+	for i := range config.Messages {
+		message := &config.Messages[i]
+		var err error
+		if message.Key, err = processValue(message.Key, context); err != nil {
+			return fmt.Errorf("failed to process messages[%d].key template: %w", i, err)
+		}
+		if message.Value, err = processValue(message.Value, context); err != nil {
+			return fmt.Errorf("failed to process messages[%d].value template: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// processValue renders templates in the strings of a value, leaving numbers
+// and other scalars as they are so schemas see their types
+func processValue(value interface{}, context dsl.TemplateContext) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return dsl.ProcessTemplate(v, context)
+	case map[string]interface{}:
+		for key, nested := range v {
+			processed, err := processValue(nested, context)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = processed
+		}
+	case []interface{}:
+		for i, nested := range v {
+			processed, err := processValue(nested, context)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = processed
+		}
+	}
+	return value, nil
+}
+
+// parseConfig converts map[string]interface{} to KafkaConfig
+func parseConfig(configData map[string]interface{}, config *KafkaConfig) error {
+	stringFields := map[string]*string{
+		"action":    &config.Action,
+		"topic":     &config.Topic,
+		"format":    &config.Format,
+		"group":     &config.Group,
+		"from":      &config.From,
+		"isolation": &config.Isolation,
+		"wait":      &config.Wait,
+		"timeout":   &config.Timeout,
+	}
+	for key, target := range stringFields {
+		if v, ok := configData[key].(string); ok {
+			*target = v
+		}
+	}
+
+	// brokers is a list, or one string of comma separated addresses
+	switch brokers := configData["brokers"].(type) {
+	case nil:
+	case string:
+		for _, broker := range strings.Split(brokers, ",") {
+			if broker = strings.TrimSpace(broker); broker != "" {
+				config.Brokers = append(config.Brokers, broker)
+			}
+		}
+	case []interface{}:
+		for i, broker := range brokers {
+			address, ok := broker.(string)
+			if !ok {
+				return fmt.Errorf("brokers[%d] must be a string, got %T", i, broker)
+			}
+			config.Brokers = append(config.Brokers, address)
+		}
+	default:
+		return fmt.Errorf("brokers must be a list or a string, got %T", brokers)
+	}
+
+	switch tlsData := configData["tls"].(type) {
+	case nil:
+	case map[string]interface{}:
+		config.TLS = &TLSConfig{}
+		tlsFields := map[string]*string{
+			"ca":          &config.TLS.CA,
+			"ca_file":     &config.TLS.CAFile,
+			"cert":        &config.TLS.Cert,
+			"cert_file":   &config.TLS.CertFile,
+			"key":         &config.TLS.Key,
+			"key_file":    &config.TLS.KeyFile,
+			"server_name": &config.TLS.ServerName,
+		}
+		for key, target := range tlsFields {
+			*target, _ = tlsData[key].(string)
+		}
+		config.TLS.InsecureSkipVerify, _ = tlsData["insecure_skip_verify"].(bool)
+	case bool:
+		// tls: true turns on TLS with the system's CAs
+		if tlsData {
+			config.TLS = &TLSConfig{}
+		}
+	default:
+		return fmt.Errorf("tls must be an object or a boolean, got %T", tlsData)
+	}
+
+	switch saslData := configData["sasl"].(type) {
+	case nil:
+	case map[string]interface{}:
+		config.SASL = &SASLConfig{}
+		config.SASL.Mechanism, _ = saslData["mechanism"].(string)
+		config.SASL.Username, _ = saslData["username"].(string)
+		config.SASL.Password, _ = saslData["password"].(string)
+	default:
+		return fmt.Errorf("sasl must be an object, got %T", saslData)
+	}
+
+	var err error
+	if config.KeySchema, err = parseSchema(configData, "key_schema"); err != nil {
+		return err
+	}
+	if config.ValueSchema, err = parseSchema(configData, "value_schema"); err != nil {
+		return err
+	}
+
+	switch registry := configData["schema_registry"].(type) {
+	case nil:
+	case string:
+		config.SchemaRegistry = &RegistryConfig{URL: registry}
+	case map[string]interface{}:
+		config.SchemaRegistry = &RegistryConfig{}
+		config.SchemaRegistry.URL, _ = registry["url"].(string)
+		config.SchemaRegistry.Username, _ = registry["username"].(string)
+		config.SchemaRegistry.Password, _ = registry["password"].(string)
+	default:
+		return fmt.Errorf("schema_registry must be a URL or an object, got %T", registry)
+	}
+
+	switch messages := configData["messages"].(type) {
+	case nil:
+	case []interface{}:
+		for i, entry := range messages {
+			entryMap, ok := entry.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("messages[%d] must be an object with a value and an optional key", i)
+			}
+			message := MessageConfig{Key: entryMap["key"], Value: entryMap["value"]}
+			if _, set := entryMap["partition"]; set {
+				partition, _, err := intField(entryMap, "partition")
+				if err != nil {
+					return fmt.Errorf("messages[%d]: %w", i, err)
+				}
+				message.Partition = &partition
+			}
+			config.Messages = append(config.Messages, message)
+		}
+	default:
+		return fmt.Errorf("messages must be a list, got %T", messages)
+	}
+
+	switch tx := configData["transaction"].(type) {
+	case nil:
+	case map[string]interface{}:
+		config.Transaction = &TransactionConfig{}
+		config.Transaction.TransactionalID, _ = tx["transactional_id"].(string)
+		config.Transaction.End, _ = tx["end"].(string)
+	case bool:
+		// transaction: true commits the messages in a transaction with a new ID
+		if tx {
+			config.Transaction = &TransactionConfig{}
+		}
+	default:
+		return fmt.Errorf("transaction must be an object or a boolean, got %T", tx)
+	}
+
+	switch configs := configData["configs"].(type) {
+	case nil:
+	case map[string]interface{}:
+		config.Configs = configs
+	default:
+		return fmt.Errorf("configs must be an object, got %T", configs)
+	}
+	switch filter := configData["filter"].(type) {
+	case nil:
+	case map[string]interface{}:
+		config.Filter = &FilterConfig{}
+		config.Filter.JSONPath, _ = filter["json_path"].(string)
+		config.Filter.Contains, _ = filter["contains"].(string)
+		config.Filter.Key, _ = filter["key"].(string)
+	default:
+		return fmt.Errorf("filter must be an object, got %T", filter)
+	}
+	config.IfNotExists, _ = configData["if_not_exists"].(bool)
+	config.IfExists, _ = configData["if_exists"].(bool)
+
+	if config.Partitions, _, err = intField(configData, "partitions"); err != nil {
+		return err
+	}
+	if config.ReplicationFactor, _, err = intField(configData, "replication_factor"); err != nil {
+		return err
+	}
+
+	maxMessages, _, err := intField(configData, "max_messages")
+	if err != nil {
+		return err
+	}
+	config.MaxMessages = maxMessages
+
+	minMessages, set, err := intField(configData, "min_messages")
+	if err != nil {
+		return err
+	}
+	if set {
+		config.MinMessages = &minMessages
+	}
+
+	return nil
+}
+
+// parseSchema reads a schema block. An inline Avro or JSON Schema may be
+// written as YAML; it is sent as JSON text.
+func parseSchema(configData map[string]interface{}, key string) (*SchemaConfig, error) {
+	switch data := configData[key].(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		schema := &SchemaConfig{}
+		schema.Subject, _ = data["subject"].(string)
+		schema.Message, _ = data["message"].(string)
+		switch version := data["version"].(type) {
+		case string:
+			schema.Version = version
+		case float64:
+			schema.Version = strconv.FormatFloat(version, 'f', -1, 64)
+		}
+		id, _, err := intField(data, "id")
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		schema.ID = id
+		switch inline := data["schema"].(type) {
+		case nil:
+		case string:
+			schema.Schema = inline
+		default:
+			encoded, err := json.Marshal(inline)
+			if err != nil {
+				return nil, fmt.Errorf("%s.schema: failed to encode as JSON: %w", key, err)
+			}
+			schema.Schema = string(encoded)
+		}
+		return schema, nil
+	default:
+		return nil, fmt.Errorf("%s must be an object, got %T", key, data)
+	}
+}
+
+func intField(configData map[string]interface{}, key string) (int, bool, error) {
+	switch v := configData[key].(type) {
+	case nil:
+		return 0, false, nil
+	case float64:
+		return int(v), true, nil
+	case int:
+		return v, true, nil
+	default:
+		return 0, false, fmt.Errorf("%s must be a number, got %T", key, v)
+	}
+}
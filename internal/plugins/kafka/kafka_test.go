@@ -0,0 +1,699 @@
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/plugins/plugintest"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sr"
+	"github.com/twmb/franz-go/pkg/sr/srfake"
+)
+
+const orderSchema = `{"type":"record","name":"Order","namespace":"shop","fields":[
+	{"name":"id","type":"string"},
+	{"name":"total","type":"double"},
+	{"name":"quantity","type":"long"},
+	{"name":"note","type":["null","string"],"default":null}
+]}`
+
+// newCluster starts an in-memory cluster with an orders topic of two
+// partitions, and a registry with orders-value at version 1 as schema 42
+func newCluster(t *testing.T, opts ...kfake.Opt) (*kfake.Cluster, *srfake.Registry) {
+	t.Helper()
+	cluster, err := kfake.NewCluster(append([]kfake.Opt{kfake.NumBrokers(1), kfake.SeedTopics(2, "orders")}, opts...)...)
+	if err != nil {
+		t.Fatalf("failed to start kafka: %v", err)
+	}
+	t.Cleanup(cluster.Close)
+
+	registry := srfake.New()
+	t.Cleanup(registry.Close)
+	registry.SeedSchema("orders-value", 1, 42, sr.Schema{Schema: orderSchema, Type: sr.TypeAvro})
+	return cluster, registry
+}
+
+// runStep runs the step through the plugin's Activity
+func runStep(t *testing.T, cluster *kfake.Cluster, registry *srfake.Registry, configData map[string]interface{}, assertionList []interface{}) (*ActivityResponse, error) {
+	t.Helper()
+	if _, ok := configData["brokers"]; !ok {
+		configData["brokers"] = strings.Join(cluster.ListenAddrs(), ",")
+	}
+	if _, ok := configData["topic"]; !ok {
+		configData["topic"] = "orders"
+	}
+
+	resp := &ActivityResponse{}
+	err := plugintest.Run(t, &KafkaPlugin{}, map[string]interface{}{
+		"config":     configData,
+		"state":      map[string]interface{}{"order_id": "o-1"},
+		"env":        map[string]interface{}{"REGISTRY_URL": registry.URL()},
+		"assertions": assertionList,
+		"save": []interface{}{
+			map[string]interface{}{"json_path": ".messages[0].offset", "as": "first_offset", "required": false},
+		},
+	}, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// readRaw returns the records of a topic as the brokers store them
+func readRaw(t *testing.T, cluster *kfake.Cluster, topic string, count int) []*kgo.Record {
+	t.Helper()
+	cl, err := kgo.NewClient(kgo.SeedBrokers(cluster.ListenAddrs()...), kgo.ConsumeTopics(topic), kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer cl.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var records []*kgo.Record
+	for len(records) < count && ctx.Err() == nil {
+		records = append(records, cl.PollFetches(ctx).Records()...)
+	}
+	return records
+}
+
+func TestProduceAvroWithRegisteredSchema(t *testing.T) {
+	cluster, registry := newCluster(t)
+
+	resp, err := runStep(t, cluster, registry, map[string]interface{}{
+		"action":          "produce",
+		"format":          "avro",
+		"schema_registry": map[string]interface{}{"url": "{{ .env.REGISTRY_URL }}"},
+		"messages": []interface{}{
+			map[string]interface{}{"value": map[string]interface{}{"id": "{{ order_id }}", "total": float64(12.5), "quantity": float64(2), "note": "gift"}},
+		},
+	}, []interface{}{
+		map[string]interface{}{"type": "message_count", "expected": float64(1)},
+		map[string]interface{}{"type": "equals", "path": ".value_schema_id", "expected": float64(42)},
+	})
+	if err != nil {
+		t.Fatalf("step failed: %v", err)
+	}
+	if resp.Saved["first_offset"] != "0" {
+		t.Errorf("expected first_offset 0, got %q", resp.Saved["first_offset"])
+	}
+
+	raw := readRaw(t, cluster, "orders", 1)
+	if len(raw) != 1 || raw[0].Value[0] != 0 || binary.BigEndian.Uint32(raw[0].Value[1:5]) != 42 {
+		t.Fatalf("expected the value framed with schema 42, got %v", raw)
+	}
+
+	resp, err = runStep(t, cluster, registry, map[string]interface{}{
+		"action":          "consume",
+		"format":          "avro",
+		"schema_registry": registry.URL(),
+		"filter":          map[string]interface{}{"json_path": ".quantity > 1"},
+	}, []interface{}{
+		map[string]interface{}{"type": "equals", "path": ".messages[0].value.id", "expected": "o-1"},
+		map[string]interface{}{"type": "equals", "path": ".messages[0].value.total", "expected": float64(12.5)},
+		map[string]interface{}{"type": "equals", "path": ".messages[0].value.note", "expected": "gift"},
+	})
+	if err != nil {
+		t.Fatalf("consume failed: %v", err)
+	}
+	if resp.Response.Messages[0].JSON != nil {
+		t.Errorf("expected json only for binary values, got %v", resp.Response.Messages[0].JSON)
+	}
+}
+
+func TestProduceInlineJSONSchema(t *testing.T) {
+	cluster, registry := newCluster(t)
+	step := func(value interface{}) (*ActivityResponse, error) {
+		return runStep(t, cluster, registry, map[string]interface{}{
+			"action":          "produce",
+			"format":          "jsonschema",
+			"schema_registry": registry.URL(),
+			"value_schema": map[string]interface{}{
+				"subject": "orders-json",
+				"schema": map[string]interface{}{
+					"type":     "object",
+					"required": []interface{}{"id"},
+					"properties": map[string]interface{}{
+						"id": map[string]interface{}{"type": "string"},
+					},
+				},
+			},
+			"key_schema": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}},
+			"messages":   []interface{}{map[string]interface{}{"key": "k-1", "value": value}},
+		}, nil)
+	}
+
+	resp, err := step(map[string]interface{}{"id": "o-2"})
+	if err != nil {
+		t.Fatalf("step failed: %v", err)
+	}
+	registered, ok := registry.GetSchema("orders-json", 1)
+	if !ok || registered.Type != sr.TypeJSON || resp.Response.ValueSchemaID != registered.ID {
+		t.Fatalf("expected the inline schema registered under orders-json, got %+v", registered)
+	}
+	if !registry.SubjectExists("orders-key") {
+		t.Errorf("expected the key schema registered under orders-key")
+	}
+
+	if _, err := step(map[string]interface{}{"total": float64(3)}); err == nil || !strings.Contains(err.Error(), "messages[0].value: doesn't match JSON schema") {
+		t.Fatalf("expected the value to be validated, got %v", err)
+	}
+}
+
+func TestProtobufRoundTrip(t *testing.T) {
+	cluster, registry := newCluster(t)
+	proto := `syntax = "proto3";
+package shop;
+message Order { string id = 1; }
+message Refund {
+  string order_id = 1;
+  int32 cents = 2;
+  message Reason { string code = 1; }
+  Reason reason = 3;
+}`
+	_, err := runStep(t, cluster, registry, map[string]interface{}{
+		"action":          "produce",
+		"topic":           "refunds",
+		"format":          "protobuf",
+		"schema_registry": registry.URL(),
+		"value_schema":    map[string]interface{}{"schema": proto, "message": "Refund"},
+		"messages": []interface{}{
+			map[string]interface{}{"value": map[string]interface{}{"order_id": "{{ order_id }}", "cents": float64(250), "reason": map[string]interface{}{"code": "damaged"}}},
+		},
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "UNKNOWN_TOPIC_OR_PARTITION") {
+		t.Fatalf("expected the missing topic to fail, got %v", err)
+	}
+
+	if _, err := runStep(t, cluster, registry, map[string]interface{}{"action": "create_topic", "topic": "refunds", "partitions": float64(1)}, nil); err != nil {
+		t.Fatalf("create_topic failed: %v", err)
+	}
+	_, err = runStep(t, cluster, registry, map[string]interface{}{
+		"action":          "produce",
+		"topic":           "refunds",
+		"format":          "protobuf",
+		"schema_registry": registry.URL(),
+		"value_schema":    map[string]interface{}{"schema": proto, "message": "Refund"},
+		"messages": []interface{}{
+			map[string]interface{}{"value": map[string]interface{}{"order_id": "{{ order_id }}", "cents": float64(250), "reason": map[string]interface{}{"code": "damaged"}}},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("produce failed: %v", err)
+	}
+	raw := readRaw(t, cluster, "refunds", 1)
+	if len(raw) != 1 || raw[0].Value[5] != 2 || raw[0].Value[6] != 2 {
+		t.Fatalf("expected the message index of Refund (1) after the schema ID, got %v", raw[0].Value[:8])
+	}
+
+	_, err = runStep(t, cluster, registry, map[string]interface{}{
+		"action":          "consume",
+		"topic":           "refunds",
+		"format":          "protobuf",
+		"schema_registry": registry.URL(),
+	}, []interface{}{
+		map[string]interface{}{"type": "equals", "path": ".messages[0].value.order_id", "expected": "o-1"},
+		map[string]interface{}{"type": "equals", "path": ".messages[0].value.cents", "expected": float64(250)},
+		map[string]interface{}{"type": "equals", "path": ".messages[0].value.reason.code", "expected": "damaged"},
+	})
+	if err != nil {
+		t.Fatalf("consume failed: %v", err)
+	}
+
+	_, err = runStep(t, cluster, registry, map[string]interface{}{
+		"action":          "produce",
+		"topic":           "refunds",
+		"format":          "protobuf",
+		"schema_registry": registry.URL(),
+		"value_schema":    map[string]interface{}{"schema": proto, "message": "Shipment"},
+		"messages":        []interface{}{map[string]interface{}{"value": map[string]interface{}{}}},
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "has no message Shipment") {
+		t.Fatalf("expected an unknown message to fail, got %v", err)
+	}
+}
+
+func TestProduceThenConsumeBinary(t *testing.T) {
+	cluster, registry := newCluster(t)
+
+	resp, err := runStep(t, cluster, registry, map[string]interface{}{
+		"action": "produce",
+		"messages": []interface{}{
+			map[string]interface{}{"key": "{{ order_id }}", "value": map[string]interface{}{"status": "paid"}, "partition": float64(1)},
+			map[string]interface{}{"value": "plain text", "partition": float64(1)},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("produce failed: %v", err)
+	}
+	if got := resp.Response.Messages; got[0].Partition != 1 || got[1].Partition != 1 || got[1].Offset != 1 {
+		t.Errorf("expected both messages at partition 1, got %+v", got)
+	}
+	raw := readRaw(t, cluster, "orders", 2)
+	if string(raw[0].Key) != "o-1" || string(raw[0].Value) != `{"status":"paid"}` {
+		t.Errorf("expected text key and JSON value, got %q %q", raw[0].Key, raw[0].Value)
+	}
+
+	resp, err = runStep(t, cluster, registry, map[string]interface{}{
+		"action":       "consume",
+		"min_messages": float64(2),
+		"wait":         "5s",
+	}, []interface{}{
+		map[string]interface{}{"type": "message_count", "expected": float64(2)},
+		map[string]interface{}{"type": "equals", "path": ".messages[0].key", "expected": "o-1"},
+		map[string]interface{}{"type": "equals", "path": ".messages[0].value", "expected": `{"status":"paid"}`},
+		map[string]interface{}{"type": "equals", "path": ".messages[0].json.status", "expected": "paid"},
+		map[string]interface{}{"type": "equals", "path": ".messages[1].value", "expected": "plain text"},
+	})
+	if err != nil {
+		t.Fatalf("consume failed: %v", err)
+	}
+	if resp.Response.Messages[1].Key != nil {
+		t.Errorf("expected no key on the second message, got %v", resp.Response.Messages[1].Key)
+	}
+}
+
+func TestConsumeGroupDoesNotCommit(t *testing.T) {
+	cluster, registry := newCluster(t)
+	if _, err := runStep(t, cluster, registry, map[string]interface{}{
+		"action":   "produce",
+		"format":   "json",
+		"messages": []interface{}{map[string]interface{}{"key": "o-1", "value": map[string]interface{}{"n": float64(1)}}},
+	}, nil); err != nil {
+		t.Fatalf("produce failed: %v", err)
+	}
+
+	// Both steps read the message, since the first doesn't commit its offset
+	for i := 0; i < 2; i++ {
+		if _, err := runStep(t, cluster, registry, map[string]interface{}{
+			"action": "consume",
+			"format": "json",
+			"group":  "checkout-tests",
+			"wait":   "10s",
+		}, []interface{}{
+			map[string]interface{}{"type": "equals", "path": ".messages[0].key", "expected": "o-1"},
+			map[string]interface{}{"type": "equals", "path": ".messages[0].value.n", "expected": float64(1)},
+		}); err != nil {
+			t.Fatalf("consume %d failed: %v", i, err)
+		}
+	}
+}
+
+func TestConsumeFilterOnJSONValues(t *testing.T) {
+	cluster, registry := newCluster(t, kfake.SeedTopics(1, "events"))
+
+	_, err := runStep(t, cluster, registry, map[string]interface{}{
+		"action": "produce",
+		"topic":  "events",
+		"messages": []interface{}{
+			map[string]interface{}{"key": "o-1", "value": map[string]interface{}{"id": "o-1", "status": "paid", "total": float64(30)}},
+			map[string]interface{}{"key": "o-2", "value": `{"id":"o-2","status":"paid","total":5}`},
+			map[string]interface{}{"key": "o-1", "value": "not json"},
+			map[string]interface{}{"key": "o-1", "value": map[string]interface{}{"id": "o-1", "status": "shipped"}},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("produce failed: %v", err)
+	}
+
+	resp, err := runStep(t, cluster, registry, map[string]interface{}{
+		"action":       "consume",
+		"topic":        "events",
+		"filter":       map[string]interface{}{"json_path": `.id == "{{ order_id }}"`, "key": "o-1"},
+		"wait":         "5s",
+		"min_messages": float64(2),
+	}, []interface{}{
+		map[string]interface{}{"type": "message_count", "expected": float64(2)},
+		map[string]interface{}{"type": "equals", "path": ".messages[0].json.total", "expected": float64(30)},
+		map[string]interface{}{"type": "equals", "path": "[.messages[].json.status]", "expected": []interface{}{"paid", "shipped"}},
+		map[string]interface{}{"type": "equals", "path": ".filtered", "expected": float64(2)},
+	})
+	if err != nil {
+		t.Fatalf("consume failed: %v", err)
+	}
+	if resp.Response.Messages[0].Value != `{"id":"o-1","status":"paid","total":30}` {
+		t.Errorf("expected the value text to be kept, got %v", resp.Response.Messages[0].Value)
+	}
+
+	_, err = runStep(t, cluster, registry, map[string]interface{}{
+		"action": "consume",
+		"topic":  "events",
+		"filter": map[string]interface{}{"contains": "refunded"},
+		"wait":   "500ms",
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "consumed 0 of at least 1 messages matching the filter from topic events within 500ms (4 skipped)") {
+		t.Fatalf("expected no message to match, got %v", err)
+	}
+
+	_, err = runStep(t, cluster, registry, map[string]interface{}{
+		"action": "consume",
+		"filter": map[string]interface{}{"json_path": ".id ==="},
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "failed to parse filter jq expression") {
+		t.Fatalf("expected an invalid filter to fail, got %v", err)
+	}
+}
+
+func TestConsumeTooFewMessages(t *testing.T) {
+	cluster, registry := newCluster(t)
+
+	_, err := runStep(t, cluster, registry, map[string]interface{}{"action": "consume", "wait": "200ms"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "consumed 0 of at least 1 messages from topic orders within 200ms") {
+		t.Fatalf("expected a minimum messages failure, got %v", err)
+	}
+}
+
+func TestProduceErrors(t *testing.T) {
+	cluster, registry := newCluster(t)
+
+	_, err := runStep(t, cluster, registry, map[string]interface{}{
+		"action":   "produce",
+		"format":   "json",
+		"messages": []interface{}{map[string]interface{}{"value": "ok"}, map[string]interface{}{"value": "lost", "partition": float64(7)}},
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "kafka rejected 1 of 2 messages: messages[1]:") {
+		t.Fatalf("expected the message to a missing partition to fail, got %v", err)
+	}
+
+	_, err = runStep(t, cluster, registry, map[string]interface{}{
+		"action":          "produce",
+		"format":          "avro",
+		"schema_registry": registry.URL(),
+		"messages":        []interface{}{map[string]interface{}{"value": map[string]interface{}{"id": "o-3", "total": "a lot", "quantity": float64(1)}}},
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "messages[0].value: total: expected a number, got string") {
+		t.Fatalf("expected the value not to match the schema, got %v", err)
+	}
+
+	_, err = runStep(t, cluster, registry, map[string]interface{}{
+		"action":          "produce",
+		"format":          "protobuf",
+		"topic":           "payments",
+		"schema_registry": registry.URL(),
+		"messages":        []interface{}{map[string]interface{}{"value": map[string]interface{}{"id": "p"}}},
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "failed to look up schema payments-value version latest") {
+		t.Fatalf("expected the subject lookup failure, got %v", err)
+	}
+
+	_, err = runStep(t, cluster, registry, map[string]interface{}{
+		"action":          "produce",
+		"format":          "protobuf",
+		"schema_registry": registry.URL(),
+		"value_schema":    map[string]interface{}{"id": float64(42)},
+		"messages":        []interface{}{map[string]interface{}{"value": map[string]interface{}{"id": "p"}}},
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "schema 42 is a AVRO schema, not protobuf") {
+		t.Fatalf("expected the schema type mismatch, got %v", err)
+	}
+}
+
+func TestSASL(t *testing.T) {
+	cluster, registry := newCluster(t, kfake.EnableSASL(), kfake.Superuser("PLAIN", "admin", "secret"))
+	step := func(password string) error {
+		_, err := runStep(t, cluster, registry, map[string]interface{}{
+			"action":   "produce",
+			"sasl":     map[string]interface{}{"mechanism": "PLAIN", "username": "admin", "password": password},
+			"messages": []interface{}{map[string]interface{}{"value": "hello"}},
+		}, nil)
+		return err
+	}
+	if err := step("secret"); err != nil {
+		t.Fatalf("expected the superuser to produce, got %v", err)
+	}
+	if err := step("wrong"); err == nil || !strings.Contains(err.Error(), "failed to connect to kafka") {
+		t.Fatalf("expected a bad password to fail, got %v", err)
+	}
+}
+
+func TestTopicLifecycle(t *testing.T) {
+	cluster, registry := newCluster(t)
+
+	resp, err := runStep(t, cluster, registry, map[string]interface{}{
+		"action":             "create_topic",
+		"topic":              "orders-{{ order_id }}",
+		"partitions":         float64(3),
+		"replication_factor": float64(1),
+		"configs":            map[string]interface{}{"retention.ms": float64(3600000), "cleanup.policy": "compact"},
+	}, []interface{}{
+		map[string]interface{}{"type": "equals", "path": ".partitions", "expected": float64(3)},
+		map[string]interface{}{"type": "equals", "path": `.configs["retention.ms"]`, "expected": "3600000"},
+	})
+	if err != nil {
+		t.Fatalf("create_topic failed: %v", err)
+	}
+	if resp.Response.Topic != "orders-o-1" || resp.Response.Configs["cleanup.policy"] != "compact" {
+		t.Errorf("expected orders-o-1 with its configs, got %+v", resp.Response)
+	}
+
+	resp, err = runStep(t, cluster, registry, map[string]interface{}{
+		"action":  "alter_config",
+		"topic":   "orders-o-1",
+		"configs": map[string]interface{}{"retention.ms": nil, "max.message.bytes": float64(2097152)},
+	}, nil)
+	if err != nil {
+		t.Fatalf("alter_config failed: %v", err)
+	}
+	configs := resp.Response.Configs
+	if configs["retention.ms"] == "3600000" || configs["max.message.bytes"] != "2097152" || configs["cleanup.policy"] != "compact" {
+		t.Errorf("expected retention.ms reset and max.message.bytes set, got %v", configs)
+	}
+
+	if _, err := runStep(t, cluster, registry, map[string]interface{}{"action": "delete_topic", "topic": "orders-o-1"}, nil); err != nil {
+		t.Fatalf("delete_topic failed: %v", err)
+	}
+	_, err = runStep(t, cluster, registry, map[string]interface{}{"action": "alter_config", "topic": "orders-o-1", "configs": map[string]interface{}{"retention.ms": "1"}}, nil)
+	if err == nil || !strings.Contains(err.Error(), "failed to alter configs of topic orders-o-1") {
+		t.Errorf("expected the topic to be deleted, got %v", err)
+	}
+}
+
+func TestTopicExistence(t *testing.T) {
+	cluster, registry := newCluster(t)
+
+	_, err := runStep(t, cluster, registry, map[string]interface{}{"action": "create_topic"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "failed to create topic orders: TOPIC_ALREADY_EXISTS") {
+		t.Fatalf("expected the existing topic to fail, got %v", err)
+	}
+	resp, err := runStep(t, cluster, registry, map[string]interface{}{"action": "create_topic", "if_not_exists": true}, nil)
+	if err != nil || !resp.Response.Skipped {
+		t.Fatalf("expected if_not_exists to skip the existing topic, got %+v, %v", resp, err)
+	}
+
+	_, err = runStep(t, cluster, registry, map[string]interface{}{"action": "delete_topic", "topic": "gone"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "failed to delete topic gone: UNKNOWN_TOPIC_OR_PARTITION") {
+		t.Fatalf("expected the missing topic to fail, got %v", err)
+	}
+	resp, err = runStep(t, cluster, registry, map[string]interface{}{"action": "delete_topic", "topic": "gone", "if_exists": true}, nil)
+	if err != nil || !resp.Response.Skipped {
+		t.Fatalf("expected if_exists to skip the missing topic, got %+v, %v", resp, err)
+	}
+}
+
+func TestAvroValue(t *testing.T) {
+	cd, err := newCodec(context.Background(), 1, FormatAvro, sr.Schema{Schema: `{"type":"record","name":"Event","fields":[
+		{"name":"count","type":"int"},
+		{"name":"payload","type":["null","string",{"type":"record","name":"Detail","fields":[{"name":"code","type":"string"}]}]},
+		{"name":"tags","type":{"type":"map","values":"long"}}
+	]}`})
+	if err != nil {
+		t.Fatalf("newCodec: %v", err)
+	}
+
+	for _, value := range []map[string]interface{}{
+		{"count": float64(3), "payload": map[string]interface{}{"code": "x"}, "tags": map[string]interface{}{"a": float64(1)}},
+		{"count": float64(3), "payload": map[string]interface{}{"Detail": map[string]interface{}{"code": "x"}}, "tags": map[string]interface{}{"a": float64(1)}},
+	} {
+		encoded, err := cd.encode(value)
+		if err != nil {
+			t.Fatalf("encode %v: %v", value, err)
+		}
+		decoded, err := cd.decode(encoded[5:])
+		if err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		want := map[string]interface{}{"count": float64(3), "payload": map[string]interface{}{"Detail": map[string]interface{}{"code": "x"}}, "tags": map[string]interface{}{"a": float64(1)}}
+		if !reflect.DeepEqual(decoded, want) {
+			t.Errorf("expected %v, got %v", want, decoded)
+		}
+	}
+
+	if _, err := cd.encode(map[string]interface{}{"count": 1.5, "tags": map[string]interface{}{}}); err == nil || !strings.Contains(err.Error(), "count: expected an int") {
+		t.Errorf("expected fractions to be rejected for int, got %v", err)
+	}
+	if _, err := cd.encode(map[string]interface{}{"count": float64(1), "payload": true, "tags": map[string]interface{}{}}); err == nil || !strings.Contains(err.Error(), "matches none of the types") {
+		t.Errorf("expected a value outside the union to be rejected, got %v", err)
+	}
+}
+
+// fakeProducer records the transaction calls send makes, and fails the
+// records whose value is "reject"
+type fakeProducer struct {
+	calls    []string
+	beginErr error
+}
+
+func (p *fakeProducer) BeginTransaction() error {
+	p.calls = append(p.calls, "begin")
+	return p.beginErr
+}
+
+func (p *fakeProducer) ProduceSync(_ context.Context, rs ...*kgo.Record) kgo.ProduceResults {
+	p.calls = append(p.calls, fmt.Sprintf("produce %d", len(rs)))
+	var results kgo.ProduceResults
+	// Acknowledge in reverse, as brokers may
+	for i := len(rs) - 1; i >= 0; i-- {
+		var err error
+		if string(rs[i].Value) == "reject" {
+			err = fmt.Errorf("MESSAGE_TOO_LARGE")
+		}
+		rs[i].Offset = int64(i)
+		results = append(results, kgo.ProduceResult{Record: rs[i], Err: err})
+	}
+	return results
+}
+
+func (p *fakeProducer) EndTransaction(_ context.Context, commit kgo.TransactionEndTry) error {
+	if commit {
+		p.calls = append(p.calls, "commit")
+	} else {
+		p.calls = append(p.calls, "abort")
+	}
+	return nil
+}
+
+func TestSendInTransaction(t *testing.T) {
+	records := func(values ...string) []*kgo.Record {
+		var rs []*kgo.Record
+		for _, value := range values {
+			rs = append(rs, &kgo.Record{Topic: "orders", Value: []byte(value)})
+		}
+		return rs
+	}
+	tests := []struct {
+		name        string
+		values      []string
+		tx          *TransactionConfig
+		wantCalls   []string
+		wantOutcome string
+	}{
+		{"no transaction", []string{"a", "b"}, nil, []string{"produce 2"}, ""},
+		{"commit", []string{"a", "b"}, &TransactionConfig{}, []string{"begin", "produce 2", "commit"}, "committed"},
+		{"abort", []string{"a", "b"}, &TransactionConfig{End: TransactionAbort}, []string{"begin", "produce 2", "abort"}, "aborted"},
+		{"rejected record", []string{"a", "reject"}, &TransactionConfig{End: TransactionCommit}, []string{"begin", "produce 2", "abort"}, "aborted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &fakeProducer{}
+			results, outcome, err := send(context.Background(), p, records(tt.values...), tt.tx)
+			if err != nil {
+				t.Fatalf("send: %v", err)
+			}
+			if !reflect.DeepEqual(p.calls, tt.wantCalls) || outcome != tt.wantOutcome {
+				t.Errorf("expected %v and %q, got %v and %q", tt.wantCalls, tt.wantOutcome, p.calls, outcome)
+			}
+			for i, result := range results {
+				if string(result.Record.Value) != tt.values[i] {
+					t.Errorf("expected results in message order, got %q at %d", result.Record.Value, i)
+				}
+			}
+		})
+	}
+
+	p := &fakeProducer{beginErr: fmt.Errorf("TRANSACTIONAL_ID_AUTHORIZATION_FAILED")}
+	if _, _, err := send(context.Background(), p, records("a"), &TransactionConfig{}); err == nil || !strings.Contains(err.Error(), "failed to begin transaction") {
+		t.Fatalf("expected the transaction not to begin, got %v", err)
+	}
+	if !reflect.DeepEqual(p.calls, []string{"begin"}) {
+		t.Errorf("expected nothing produced, got %v", p.calls)
+	}
+}
+
+func TestConsumeReadCommitted(t *testing.T) {
+	cluster, registry := newCluster(t)
+	if _, err := runStep(t, cluster, registry, map[string]interface{}{
+		"action":   "produce",
+		"messages": []interface{}{map[string]interface{}{"value": "outside a transaction"}},
+	}, nil); err != nil {
+		t.Fatalf("produce failed: %v", err)
+	}
+
+	if _, err := runStep(t, cluster, registry, map[string]interface{}{
+		"action":    "consume",
+		"isolation": "read_committed",
+	}, []interface{}{
+		map[string]interface{}{"type": "equals", "path": ".messages[0].value", "expected": "outside a transaction"},
+	}); err != nil {
+		t.Fatalf("consume failed: %v", err)
+	}
+
+	_, err := runStep(t, cluster, registry, map[string]interface{}{
+		"action":      "produce",
+		"transaction": map[string]interface{}{"transactional_id": "orders-{{ order_id }}"},
+		"messages":    []interface{}{map[string]interface{}{"value": "x"}},
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "failed to begin transaction") {
+		t.Fatalf("expected the fake cluster, which has no transactions, to refuse one, got %v", err)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	one := 1
+	brokers := []string{"kafka:9092"}
+	tests := []struct {
+		name    string
+		config  KafkaConfig
+		wantErr string
+	}{
+		{"missing brokers", KafkaConfig{Topic: "t", Action: "consume"}, "brokers is required"},
+		{"url broker", KafkaConfig{Brokers: []string{"http://rest-proxy:8082"}, Topic: "t", Action: "consume"}, "brokers[0] must be a host:port address"},
+		{"missing topic", KafkaConfig{Brokers: brokers, Action: "consume"}, "topic is required"},
+		{"unknown action", KafkaConfig{Brokers: brokers, Topic: "t", Action: "seek"}, "action must be produce, consume, create_topic"},
+		{"unknown format", KafkaConfig{Brokers: brokers, Topic: "t", Action: "consume", Format: "thrift"}, "format must be"},
+		{"bad mechanism", KafkaConfig{Brokers: brokers, Topic: "t", Action: "consume", SASL: &SASLConfig{Mechanism: "GSSAPI", Username: "u"}}, "sasl.mechanism must be"},
+		{"sasl without user", KafkaConfig{Brokers: brokers, Topic: "t", Action: "consume", SASL: &SASLConfig{Mechanism: "PLAIN"}}, "sasl.username is required"},
+		{"schema without format", KafkaConfig{Brokers: brokers, Topic: "t", Action: "consume", ValueSchema: &SchemaConfig{ID: 1}}, "need format avro"},
+		{"schema without registry", KafkaConfig{Brokers: brokers, Topic: "t", Action: "consume", Format: "avro"}, "schema_registry is required with format avro"},
+		{"id and schema", KafkaConfig{Brokers: brokers, Topic: "t", Action: "consume", Format: "avro", ValueSchema: &SchemaConfig{ID: 1, Schema: "{}"}}, "either id or schema"},
+		{"message with avro", KafkaConfig{Brokers: brokers, Topic: "t", Action: "consume", Format: "avro", ValueSchema: &SchemaConfig{Message: "Order"}}, "value_schema.message only applies to format protobuf"},
+		{"bad version", KafkaConfig{Brokers: brokers, Topic: "t", Action: "consume", Format: "avro", KeySchema: &SchemaConfig{Version: "newest"}}, "key_schema.version"},
+		{"no messages", KafkaConfig{Brokers: brokers, Topic: "t", Action: "produce"}, "messages is required"},
+		{"no value", KafkaConfig{Brokers: brokers, Topic: "t", Action: "produce", Messages: []MessageConfig{{Key: "k"}}}, "messages[0].value is required"},
+		{"bad from", KafkaConfig{Brokers: brokers, Topic: "t", Action: "consume", From: "middle", MinMessages: &one}, "from must be earliest or latest"},
+		{"bad wait", KafkaConfig{Brokers: brokers, Topic: "t", Action: "consume", Wait: "soon"}, "invalid wait"},
+		{"empty filter", KafkaConfig{Brokers: brokers, Topic: "t", Action: "consume", Filter: &FilterConfig{}}, "filter needs json_path, contains or key"},
+		{"filter on produce", KafkaConfig{Brokers: brokers, Topic: "t", Action: "produce", Messages: []MessageConfig{{Value: "v"}}, Filter: &FilterConfig{Key: "k"}}, "filter only applies to consume"},
+		{"alter without configs", KafkaConfig{Brokers: brokers, Topic: "t", Action: "alter_config"}, "configs is required"},
+		{"create with reset", KafkaConfig{Brokers: brokers, Topic: "t", Action: "create_topic", Configs: map[string]interface{}{"retention.ms": nil}}, "configs.retention.ms needs a value"},
+		{"if_exists on create", KafkaConfig{Brokers: brokers, Topic: "t", Action: "create_topic", IfExists: true}, "if_exists only applies to delete_topic"},
+		{"bad transaction end", KafkaConfig{Brokers: brokers, Topic: "t", Action: "produce", Messages: []MessageConfig{{Value: "v"}}, Transaction: &TransactionConfig{End: "rollback"}}, "transaction.end must be commit or abort"},
+		{"transaction on consume", KafkaConfig{Brokers: brokers, Topic: "t", Action: "consume", Transaction: &TransactionConfig{}}, "transaction only applies to produce"},
+		{"bad isolation", KafkaConfig{Brokers: brokers, Topic: "t", Action: "consume", Isolation: "serializable"}, "isolation must be read_uncommitted or read_committed"},
+		{"isolation on produce", KafkaConfig{Brokers: brokers, Topic: "t", Action: "produce", Messages: []MessageConfig{{Value: "v"}}, Isolation: "read_committed"}, "isolation only applies to consume"},
+		{"partitions on produce", KafkaConfig{Brokers: brokers, Topic: "t", Action: "produce", Messages: []MessageConfig{{Value: "v"}}, Partitions: 3}, "only apply to create_topic"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateConfig(&tt.config)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	config := &KafkaConfig{}
+	if err := parseConfig(map[string]interface{}{"brokers": "a:9092, b:9092", "tls": true, "transaction": true}, config); err != nil {
+		t.Fatalf("parseConfig: %v", err)
+	}
+	if !reflect.DeepEqual(config.Brokers, []string{"a:9092", "b:9092"}) || config.TLS == nil || config.Transaction == nil {
+		t.Errorf("expected two brokers with TLS and a transaction, got %+v", config)
+	}
+	if _, err := newClient(&KafkaConfig{Brokers: brokers, TLS: &TLSConfig{CA: "not a certificate"}}, nil); err == nil || !strings.Contains(err.Error(), "no certificates found in the CA") {
+		t.Errorf("expected an invalid CA to be rejected, got %v", err)
+	}
+}
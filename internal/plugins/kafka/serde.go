@@ -0,0 +1,519 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/hamba/avro/v2"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/twmb/franz-go/pkg/sr"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// schemaTypes are the registry's schema types of the schema formats
+var schemaTypes = map[string]sr.SchemaType{
+	FormatAvro:       sr.TypeAvro,
+	FormatProtobuf:   sr.TypeProtobuf,
+	FormatJSONSchema: sr.TypeJSON,
+}
+
+// wireHeader frames schema encoded keys and values: a zero byte, the schema
+// ID, and for protobuf the message's index in its file
+var wireHeader sr.ConfluentHeader
+
+// protoFileName is the name .proto sources are compiled under
+const protoFileName = "schema.proto"
+
+// codec encodes and decodes records with one registered schema
+type codec struct {
+	id     int
+	format string
+
+	avro avro.Schema
+
+	protoFile    protoreflect.FileDescriptor
+	protoMessage protoreflect.MessageDescriptor // Message encoded records are
+	protoIndex   []int                          // protoMessage's path in protoFile
+
+	jsonSchema *jsonschema.Schema
+}
+
+// resolveCodec returns the codec to produce with: the configured ID's schema,
+// the inline schema once it is registered under the subject, or the
+// subject's version
+func (c *client) resolveCodec(ctx context.Context, format string, schema *SchemaConfig, defaultSubject string) (*codec, error) {
+	if c.registry == nil {
+		return nil, fmt.Errorf("schema_registry is required with format %s", format)
+	}
+	subject, version, message := defaultSubject, "latest", ""
+	if schema != nil {
+		if schema.Subject != "" {
+			subject = schema.Subject
+		}
+		if schema.Version != "" {
+			version = schema.Version
+		}
+		message = schema.Message
+	}
+
+	var id int
+	switch {
+	case schema != nil && schema.ID > 0:
+		id = schema.ID
+	case schema != nil && schema.Schema != "":
+		registered, err := c.registry.CreateSchema(ctx, subject, sr.Schema{Schema: schema.Schema, Type: schemaTypes[format]})
+		if err != nil {
+			return nil, fmt.Errorf("failed to register schema under %s: %w", subject, err)
+		}
+		id = registered.ID
+	default:
+		number := -1
+		if version != "latest" {
+			number, _ = strconv.Atoi(version)
+		}
+		found, err := c.registry.SchemaByVersion(ctx, subject, number)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up schema %s version %s: %w", subject, version, err)
+		}
+		id = found.ID
+	}
+
+	cd, err := c.codecByID(ctx, format, id)
+	if err != nil {
+		return nil, err
+	}
+	if format == FormatProtobuf {
+		return cd.withMessage(message)
+	}
+	return cd, nil
+}
+
+// codecByID returns the codec of a registered schema, reading it from the
+// registry once per step
+func (c *client) codecByID(ctx context.Context, format string, id int) (*codec, error) {
+	if cd, ok := c.codecs[id]; ok {
+		return cd, nil
+	}
+	if c.registry == nil {
+		return nil, fmt.Errorf("schema_registry is required with format %s", format)
+	}
+	schema, err := c.registry.SchemaByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema %d: %w", id, err)
+	}
+	cd, err := newCodec(ctx, id, format, schema)
+	if err != nil {
+		return nil, err
+	}
+	c.codecs[id] = cd
+	return cd, nil
+}
+
+// newCodec parses a registered schema of the format
+func newCodec(ctx context.Context, id int, format string, schema sr.Schema) (*codec, error) {
+	if schema.Type != schemaTypes[format] {
+		return nil, fmt.Errorf("schema %d is a %s schema, not %s", id, schema.Type, format)
+	}
+	if len(schema.References) > 0 {
+		return nil, fmt.Errorf("schema %d references other schemas, which the kafka plugin doesn't support", id)
+	}
+
+	cd := &codec{id: id, format: format}
+	switch format {
+	case FormatAvro:
+		// A cache per schema, so named types of other schemas don't leak in
+		parsed, err := avro.ParseWithCache(schema.Schema, "", &avro.SchemaCache{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse avro schema %d: %w", id, err)
+		}
+		cd.avro = parsed
+
+	case FormatProtobuf:
+		compiler := protocompile.Compiler{
+			Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+				Accessor: protocompile.SourceAccessorFromMap(map[string]string{protoFileName: schema.Schema}),
+			}),
+		}
+		files, err := compiler.Compile(ctx, protoFileName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse protobuf schema %d: %w", id, err)
+		}
+		cd.protoFile = files[0]
+
+	case FormatJSONSchema:
+		document, err := jsonschema.UnmarshalJSON(strings.NewReader(schema.Schema))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse JSON schema %d: %w", id, err)
+		}
+		location := fmt.Sprintf("schema-%d.json", id)
+		compiler := jsonschema.NewCompiler()
+		compiler.DefaultDraft(jsonschema.Draft7)
+		if err := compiler.AddResource(location, document); err != nil {
+			return nil, fmt.Errorf("invalid JSON schema %d: %w", id, err)
+		}
+		if cd.jsonSchema, err = compiler.Compile(location); err != nil {
+			return nil, fmt.Errorf("failed to compile JSON schema %d: %w", id, err)
+		}
+	}
+	return cd, nil
+}
+
+// withMessage returns the protobuf codec encoding the named message, or the
+// file's first message
+func (cd *codec) withMessage(name string) (*codec, error) {
+	selected := *cd
+	if name == "" {
+		if cd.protoFile.Messages().Len() == 0 {
+			return nil, fmt.Errorf("protobuf schema %d has no messages", cd.id)
+		}
+		selected.protoMessage, selected.protoIndex = cd.protoFile.Messages().Get(0), []int{0}
+		return &selected, nil
+	}
+
+	pkg := string(cd.protoFile.Package())
+	var find func(messages protoreflect.MessageDescriptors, path []int) bool
+	find = func(messages protoreflect.MessageDescriptors, path []int) bool {
+		for i := 0; i < messages.Len(); i++ {
+			md := messages.Get(i)
+			index := append(append([]int{}, path...), i)
+			fullName := string(md.FullName())
+			if fullName == name || (pkg != "" && fullName == pkg+"."+name) {
+				selected.protoMessage, selected.protoIndex = md, index
+				return true
+			}
+			if find(md.Messages(), index) {
+				return true
+			}
+		}
+		return false
+	}
+	if !find(cd.protoFile.Messages(), nil) {
+		return nil, fmt.Errorf("protobuf schema %d has no message %s", cd.id, name)
+	}
+	return &selected, nil
+}
+
+// encode serializes a key or value and frames it with the schema ID
+func (cd *codec) encode(value interface{}) ([]byte, error) {
+	var payload []byte
+	switch cd.format {
+	case FormatAvro:
+		native, err := avroValue(cd.avro, value)
+		if err != nil {
+			return nil, err
+		}
+		if payload, err = avro.Marshal(cd.avro, native); err != nil {
+			return nil, err
+		}
+
+	case FormatProtobuf:
+		text, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode as JSON: %w", err)
+		}
+		message := dynamicpb.NewMessage(cd.protoMessage)
+		if err := protojson.Unmarshal(text, message); err != nil {
+			return nil, fmt.Errorf("doesn't match %s: %w", cd.protoMessage.FullName(), err)
+		}
+		if payload, err = proto.Marshal(message); err != nil {
+			return nil, err
+		}
+
+	case FormatJSONSchema:
+		if err := cd.jsonSchema.Validate(value); err != nil {
+			return nil, fmt.Errorf("doesn't match JSON schema %d: %w", cd.id, err)
+		}
+		var err error
+		if payload, err = json.Marshal(value); err != nil {
+			return nil, fmt.Errorf("failed to encode as JSON: %w", err)
+		}
+	}
+
+	framed, _ := wireHeader.AppendEncode(nil, cd.id, cd.protoIndex)
+	return append(framed, payload...), nil
+}
+
+// decode reads a key or value whose schema ID has been stripped. The result
+// is plain JSON data, so filters and assertions see the same values for every
+// format.
+func (cd *codec) decode(payload []byte) (interface{}, error) {
+	switch cd.format {
+	case FormatAvro:
+		var value interface{}
+		if err := avro.Unmarshal(cd.avro, payload, &value); err != nil {
+			return nil, err
+		}
+		return jsonValue(value)
+
+	case FormatProtobuf:
+		index, rest, err := wireHeader.DecodeIndex(payload, 0)
+		if err != nil {
+			return nil, fmt.Errorf("invalid message index: %w", err)
+		}
+		md, err := cd.messageAt(index)
+		if err != nil {
+			return nil, err
+		}
+		message := dynamicpb.NewMessage(md)
+		if err := proto.Unmarshal(rest, message); err != nil {
+			return nil, err
+		}
+		text, err := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal(message)
+		if err != nil {
+			return nil, err
+		}
+		var value interface{}
+		err = json.Unmarshal(text, &value)
+		return value, err
+
+	default:
+		var value interface{}
+		err := json.Unmarshal(payload, &value)
+		return value, err
+	}
+}
+
+// messageAt returns the protobuf message at a path of indexes into the file
+func (cd *codec) messageAt(index []int) (protoreflect.MessageDescriptor, error) {
+	messages := cd.protoFile.Messages()
+	var md protoreflect.MessageDescriptor
+	for _, i := range index {
+		if i < 0 || i >= messages.Len() {
+			return nil, fmt.Errorf("protobuf schema %d has no message at index %v", cd.id, index)
+		}
+		md = messages.Get(i)
+		messages = md.Messages()
+	}
+	if md == nil {
+		return nil, fmt.Errorf("protobuf schema %d has no message at index %v", cd.id, index)
+	}
+	return md, nil
+}
+
+// avroValue converts YAML or JSON data to what the Avro encoder takes for the
+// schema: whole numbers for int and long, float32 for float, byte slices for
+// bytes and fixed, and union branches other than primitives wrapped in their
+// type name, as Avro's JSON encoding does. Values already wrapped that way are
+// accepted too.
+func avroValue(schema avro.Schema, value interface{}) (interface{}, error) {
+	switch s := schema.(type) {
+	case *avro.RefSchema:
+		return avroValue(s.Schema(), value)
+
+	case *avro.RecordSchema:
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: expected an object, got %T", s.FullName(), value)
+		}
+		record := make(map[string]interface{}, len(fields))
+		for _, field := range s.Fields() {
+			fieldValue, set := fields[field.Name()]
+			if !set {
+				// The encoder fills in the field's default, or rejects it
+				continue
+			}
+			converted, err := avroValue(field.Type(), fieldValue)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", field.Name(), err)
+			}
+			record[field.Name()] = converted
+		}
+		return record, nil
+
+	case *avro.ArraySchema:
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected a list, got %T", value)
+		}
+		converted := make([]interface{}, len(items))
+		for i, item := range items {
+			var err error
+			if converted[i], err = avroValue(s.Items(), item); err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+		return converted, nil
+
+	case *avro.MapSchema:
+		entries, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected an object, got %T", value)
+		}
+		converted := make(map[string]interface{}, len(entries))
+		for key, entry := range entries {
+			var err error
+			if converted[key], err = avroValue(s.Values(), entry); err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+		}
+		return converted, nil
+
+	case *avro.UnionSchema:
+		if value == nil && s.Contains(avro.Null) {
+			return nil, nil
+		}
+		if wrapped, ok := value.(map[string]interface{}); ok && len(wrapped) == 1 {
+			for name, inner := range wrapped {
+				if branch, _ := s.Types().Get(name); branch != nil {
+					converted, err := avroValue(branch, inner)
+					if err != nil {
+						return nil, err
+					}
+					return map[string]interface{}{name: converted}, nil
+				}
+			}
+		}
+		for _, branch := range s.Types() {
+			converted, err := avroValue(branch, value)
+			if err != nil {
+				continue
+			}
+			switch branch.(type) {
+			case *avro.PrimitiveSchema, *avro.NullSchema:
+				return converted, nil
+			}
+			return map[string]interface{}{unionBranchName(branch): converted}, nil
+		}
+		return nil, fmt.Errorf("%v matches none of the types in %s", value, s.String())
+
+	case *avro.EnumSchema:
+		symbol, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected a symbol, got %T", s.FullName(), value)
+		}
+		return symbol, nil
+
+	case *avro.FixedSchema:
+		text, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected text, got %T", s.FullName(), value)
+		}
+		return []byte(text), nil
+
+	case *avro.NullSchema:
+		return avroPrimitive(avro.Null, value)
+
+	case *avro.PrimitiveSchema:
+		return avroPrimitive(s.Type(), value)
+	}
+	return value, nil
+}
+
+// avroPrimitive checks and converts a value of a primitive type
+func avroPrimitive(typ avro.Type, value interface{}) (interface{}, error) {
+	switch typ {
+	case avro.Null:
+		if value != nil {
+			return nil, fmt.Errorf("expected null, got %T", value)
+		}
+		return nil, nil
+	case avro.Boolean:
+		if _, ok := value.(bool); !ok {
+			return nil, fmt.Errorf("expected a boolean, got %T", value)
+		}
+		return value, nil
+	case avro.Int:
+		n, err := wholeNumber(value)
+		if err != nil || n < math.MinInt32 || n > math.MaxInt32 {
+			return nil, fmt.Errorf("expected an int, got %v", value)
+		}
+		return int(n), nil
+	case avro.Long:
+		n, err := wholeNumber(value)
+		if err != nil {
+			return nil, err
+		}
+		return n, nil
+	case avro.Float, avro.Double:
+		var f float64
+		switch n := value.(type) {
+		case float64:
+			f = n
+		case int:
+			f = float64(n)
+		case int64:
+			f = float64(n)
+		default:
+			return nil, fmt.Errorf("expected a number, got %T", value)
+		}
+		if typ == avro.Float {
+			return float32(f), nil
+		}
+		return f, nil
+	case avro.String:
+		if _, ok := value.(string); !ok {
+			return nil, fmt.Errorf("expected text, got %T", value)
+		}
+		return value, nil
+	case avro.Bytes:
+		text, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected text, got %T", value)
+		}
+		return []byte(text), nil
+	}
+	return value, nil
+}
+
+func wholeNumber(value interface{}) (int64, error) {
+	switch n := value.(type) {
+	case float64:
+		if n != math.Trunc(n) {
+			return 0, fmt.Errorf("expected a whole number, got %v", n)
+		}
+		return int64(n), nil
+	case int:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	default:
+		return 0, fmt.Errorf("expected a whole number, got %T", value)
+	}
+}
+
+// unionBranchName is the name Avro's JSON encoding wraps a union value in
+func unionBranchName(schema avro.Schema) string {
+	if ref, ok := schema.(*avro.RefSchema); ok {
+		schema = ref.Schema()
+	}
+	if named, ok := schema.(avro.NamedSchema); ok {
+		return named.FullName()
+	}
+	return string(schema.Type())
+}
+
+// jsonValue converts decoded data, e.g. Avro's longs, into the types JSON
+// decodes to. Bytes become text, as they were produced.
+func jsonValue(value interface{}) (interface{}, error) {
+	text, err := json.Marshal(bytesAsText(value))
+	if err != nil {
+		return nil, err
+	}
+	var converted interface{}
+	err = json.Unmarshal(text, &converted)
+	return converted, err
+}
+
+func bytesAsText(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case map[string]interface{}:
+		for key, nested := range v {
+			v[key] = bytesAsText(nested)
+		}
+	case []interface{}:
+		for i, nested := range v {
+			v[i] = bytesAsText(nested)
+		}
+	}
+	return value
+}
@@ -0,0 +1,204 @@
+package kafka
+
+import "github.com/rocketship-ai/rocketship/internal/assertions"
+
+// KafkaPlugin represents a Kafka test step
+type KafkaPlugin struct {
+	Name   string      `json:"name" yaml:"name"`
+	Plugin string      `json:"plugin" yaml:"plugin"`
+	Config KafkaConfig `json:"config" yaml:"config"`
+}
+
+// KafkaConfig selects the brokers, the action and how messages are
+// serialized
+type KafkaConfig struct {
+	// Connection
+	Brokers []string    `json:"brokers" yaml:"brokers"`               // Seed brokers, e.g. kafka:9092
+	TLS     *TLSConfig  `json:"tls,omitempty" yaml:"tls,omitempty"`   // Connect with TLS
+	SASL    *SASLConfig `json:"sasl,omitempty" yaml:"sasl,omitempty"` // Authenticate with SASL
+
+	Action string `json:"action" yaml:"action"` // produce, consume, create_topic, delete_topic or alter_config
+	Topic  string `json:"topic" yaml:"topic"`
+
+	// Serialization
+	Format         string          `json:"format,omitempty" yaml:"format,omitempty"`                   // binary (default), json, avro, protobuf or jsonschema
+	KeySchema      *SchemaConfig   `json:"key_schema,omitempty" yaml:"key_schema,omitempty"`           // Schema of the keys, for schema formats
+	ValueSchema    *SchemaConfig   `json:"value_schema,omitempty" yaml:"value_schema,omitempty"`       // Schema of the values (defaults to the latest <topic>-value)
+	SchemaRegistry *RegistryConfig `json:"schema_registry,omitempty" yaml:"schema_registry,omitempty"` // Where subjects are looked up
+
+	// produce
+	Messages    []MessageConfig    `json:"messages,omitempty" yaml:"messages,omitempty"`
+	Transaction *TransactionConfig `json:"transaction,omitempty" yaml:"transaction,omitempty"` // Produce the messages in one transaction
+
+	// consume
+	Group       string        `json:"group,omitempty" yaml:"group,omitempty"`               // Consumer group to join (defaults to reading every partition directly)
+	From        string        `json:"from,omitempty" yaml:"from,omitempty"`                 // earliest (default) or latest, without committed offsets
+	Isolation   string        `json:"isolation,omitempty" yaml:"isolation,omitempty"`       // read_uncommitted (default) or read_committed
+	MinMessages *int          `json:"min_messages,omitempty" yaml:"min_messages,omitempty"` // Defaults to 1
+	MaxMessages int           `json:"max_messages,omitempty" yaml:"max_messages,omitempty"` // Defaults to 100
+	Wait        string        `json:"wait,omitempty" yaml:"wait,omitempty"`                 // How long to poll for min_messages (defaults to 10s)
+	Filter      *FilterConfig `json:"filter,omitempty" yaml:"filter,omitempty"`             // Messages not matching the filter are skipped
+
+	// Topic administration
+	Partitions        int                    `json:"partitions,omitempty" yaml:"partitions,omitempty"`                 // create_topic (defaults to the broker's num.partitions)
+	ReplicationFactor int                    `json:"replication_factor,omitempty" yaml:"replication_factor,omitempty"` // create_topic (defaults to the broker's default.replication.factor)
+	Configs           map[string]interface{} `json:"configs,omitempty" yaml:"configs,omitempty"`                       // Topic configs, e.g. retention.ms; null resets one with alter_config
+	IfNotExists       bool                   `json:"if_not_exists,omitempty" yaml:"if_not_exists,omitempty"`           // create_topic succeeds when the topic exists
+	IfExists          bool                   `json:"if_exists,omitempty" yaml:"if_exists,omitempty"`                   // delete_topic succeeds when the topic is missing
+
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"` // Overall step timeout (defaults to 60s)
+}
+
+// MessageConfig is one produced message. With the binary format, objects are
+// sent as JSON; with schema formats, key and value are the record's fields.
+type MessageConfig struct {
+	Key       interface{} `json:"key,omitempty" yaml:"key,omitempty"`
+	Value     interface{} `json:"value" yaml:"value"`
+	Partition *int        `json:"partition,omitempty" yaml:"partition,omitempty"` // Defaults to the partitioner's choice
+}
+
+// TransactionConfig produces the messages in one Kafka transaction, which is
+// committed or aborted once every message is written
+type TransactionConfig struct {
+	TransactionalID string `json:"transactional_id,omitempty" yaml:"transactional_id,omitempty"` // Defaults to a new ID per step
+	End             string `json:"end,omitempty" yaml:"end,omitempty"`                           // commit (default) or abort
+}
+
+// SchemaConfig selects a registered schema by ID or subject, or gives it
+// inline. Inline schemas are registered under the subject before producing.
+type SchemaConfig struct {
+	ID      int    `json:"id,omitempty" yaml:"id,omitempty"`
+	Subject string `json:"subject,omitempty" yaml:"subject,omitempty"` // Defaults to <topic>-key or <topic>-value
+	Version string `json:"version,omitempty" yaml:"version,omitempty"` // A version number or latest (default)
+	Schema  string `json:"schema,omitempty" yaml:"schema,omitempty"`   // Avro JSON, .proto source or JSON Schema
+	Message string `json:"message,omitempty" yaml:"message,omitempty"` // protobuf: the message type (defaults to the first in the file)
+}
+
+// FilterConfig selects consumed messages by key or value. Every field that is
+// set must match.
+type FilterConfig struct {
+	JSONPath string `json:"json_path,omitempty" yaml:"json_path,omitempty"` // jq expression over the value that must produce a truthy value
+	Contains string `json:"contains,omitempty" yaml:"contains,omitempty"`   // Substring the value's text must contain
+	Key      string `json:"key,omitempty" yaml:"key,omitempty"`             // Key the message must have
+}
+
+// TLSConfig enables TLS and sets the certificates. PEM fields take the
+// certificate itself, typically from an env secret; _file fields take a path
+// on the worker.
+type TLSConfig struct {
+	CA                 string `json:"ca,omitempty" yaml:"ca,omitempty"`                                     // PEM CA certificates to trust
+	CAFile             string `json:"ca_file,omitempty" yaml:"ca_file,omitempty"`                           // Path to PEM CA certificates
+	Cert               string `json:"cert,omitempty" yaml:"cert,omitempty"`                                 // PEM client certificate, optionally with its key
+	CertFile           string `json:"cert_file,omitempty" yaml:"cert_file,omitempty"`                       // Path to a PEM client certificate
+	Key                string `json:"key,omitempty" yaml:"key,omitempty"`                                   // PEM client key, unless it is part of cert
+	KeyFile            string `json:"key_file,omitempty" yaml:"key_file,omitempty"`                         // Path to a PEM client key
+	ServerName         string `json:"server_name,omitempty" yaml:"server_name,omitempty"`                   // Name to verify broker certificates against (defaults to the broker's host)
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty" yaml:"insecure_skip_verify,omitempty"` // Skip certificate verification
+}
+
+// SASLConfig sets the credentials brokers check
+type SASLConfig struct {
+	Mechanism string `json:"mechanism" yaml:"mechanism"` // PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512
+	Username  string `json:"username" yaml:"username"`
+	Password  string `json:"password" yaml:"password"`
+}
+
+// RegistryConfig is a Confluent Schema Registry
+type RegistryConfig struct {
+	URL      string `json:"url" yaml:"url"`
+	Username string `json:"username,omitempty" yaml:"username,omitempty"`
+	Password string `json:"password,omitempty" yaml:"password,omitempty"`
+}
+
+// Actions supported by the kafka plugin
+const (
+	ActionProduce = "produce"
+	ActionConsume = "consume"
+
+	ActionCreateTopic = "create_topic"
+	ActionDeleteTopic = "delete_topic"
+	ActionAlterConfig = "alter_config"
+)
+
+// Serialization formats. Schema formats use the registry's wire format: a
+// zero byte and the schema ID before the encoded record.
+const (
+	FormatBinary     = "binary"
+	FormatJSON       = "json"
+	FormatAvro       = "avro"
+	FormatProtobuf   = "protobuf"
+	FormatJSONSchema = "jsonschema"
+)
+
+// SASL mechanisms
+const (
+	MechanismPlain       = "PLAIN"
+	MechanismSCRAMSHA256 = "SCRAM-SHA-256"
+	MechanismSCRAMSHA512 = "SCRAM-SHA-512"
+)
+
+// Where a new consumer group starts reading
+const (
+	FromEarliest = "earliest"
+	FromLatest   = "latest"
+)
+
+// How a transaction ends
+const (
+	TransactionCommit = "commit"
+	TransactionAbort  = "abort"
+)
+
+// Which records consume reads. read_committed leaves out records of aborted
+// and still open transactions.
+const (
+	IsolationReadUncommitted = "read_uncommitted"
+	IsolationReadCommitted   = "read_committed"
+)
+
+// Assertion types supported by the kafka plugin in addition to the shared ones
+const (
+	AssertionTypeMessageCount = "message_count"
+)
+
+// Message is a message produced or consumed. Binary keys and values are text,
+// and values that are JSON are also decoded into JSON; with the other formats
+// they are the decoded records.
+type Message struct {
+	Topic     string      `json:"topic"`
+	Partition int         `json:"partition"`
+	Offset    int64       `json:"offset"`
+	Key       interface{} `json:"key,omitempty"`
+	Value     interface{} `json:"value"`
+	JSON      interface{} `json:"json,omitempty"`
+}
+
+// KafkaResponse contains the messages produced or consumed, or the topic an
+// admin action changed
+type KafkaResponse struct {
+	Action        string    `json:"action"`
+	Topic         string    `json:"topic"`
+	Messages      []Message `json:"messages"`
+	Count         int       `json:"count"`
+	Filtered      int       `json:"filtered,omitempty"`        // Messages consume skipped because they didn't match the filter
+	KeySchemaID   int       `json:"key_schema_id,omitempty"`   // produce with a schema format
+	ValueSchemaID int       `json:"value_schema_id,omitempty"` // produce with a schema format
+	Transaction   string    `json:"transaction,omitempty"`     // committed or aborted, for produce with a transaction
+
+	Partitions        int               `json:"partitions,omitempty"`         // create_topic
+	ReplicationFactor int               `json:"replication_factor,omitempty"` // create_topic
+	Configs           map[string]string `json:"configs,omitempty"`            // The topic's configs after create_topic and alter_config
+	Skipped           bool              `json:"skipped,omitempty"`            // Nothing to do with if_not_exists or if_exists
+
+	Duration string `json:"duration"`
+}
+
+// AssertionResult represents a single assertion result for UI display
+type AssertionResult = assertions.Result
+
+// ActivityResponse represents the complete activity response
+type ActivityResponse struct {
+	Response         *KafkaResponse    `json:"response"`
+	Saved            map[string]string `json:"saved"`
+	AssertionResults []AssertionResult `json:"assertion_results,omitempty"`
+}
@@ -0,0 +1,223 @@
+package kinesis
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/awsauth"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+)
+
+// client calls the Kinesis Data Streams JSON API. Only the handful of operations
+// the plugin needs are implemented.
+type client struct {
+	endpoint string
+	region   string
+	creds    awsauth.Credentials
+	http     *http.Client
+	now      func() time.Time
+}
+
+// apiError is an error response from Kinesis, e.g. ResourceNotFoundException
+type apiError struct {
+	Type    string
+	Message string
+	Status  int
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Type, e.Message)
+}
+
+// newClient connects to the stream's region, or to endpoint when it's set, e.g.
+// for LocalStack. Connections go through the egress policy.
+func newClient(config *KinesisConfig, policy *egress.Policy) (*client, error) {
+	region := config.Region
+	if region == "" {
+		region = awsauth.RegionFromEnv()
+	}
+	if region == "" {
+		return nil, fmt.Errorf("region is required; set it in the step or with AWS_REGION on the worker")
+	}
+
+	creds := awsauth.CredentialsFromEnv()
+	if config.AccessKeyID != "" || config.SecretAccessKey != "" {
+		creds = awsauth.Credentials{
+			AccessKeyID:     config.AccessKeyID,
+			SecretAccessKey: config.SecretAccessKey,
+			SessionToken:    config.SessionToken,
+		}
+	}
+	if !creds.Valid() {
+		return nil, fmt.Errorf("AWS credentials are required; set access_key_id and secret_access_key or AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY on the worker")
+	}
+
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://kinesis.%s.amazonaws.com", region)
+	}
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, fmt.Errorf("invalid endpoint %q: must be an http or https URL", endpoint)
+	}
+
+	return &client{
+		endpoint: strings.TrimRight(endpoint, "/") + "/",
+		region:   region,
+		creds:    creds,
+		http:     &http.Client{Transport: policy.Transport()},
+		now:      time.Now,
+	}, nil
+}
+
+// call runs one Kinesis operation, e.g. "PutRecord", decoding the result into out
+func (c *client) call(ctx context.Context, operation string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s request: %w", operation, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "Kinesis_20131202."+operation)
+	if err := awsauth.Sign(req, body, c.creds, c.region, "kinesis", c.now()); err != nil {
+		return err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("kinesis %s failed: %w", operation, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read kinesis %s response: %w", operation, err)
+	}
+	if resp.StatusCode >= 300 {
+		var failure struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(data, &failure)
+		apiErr := &apiError{Type: failure.Type, Message: failure.Message, Status: resp.StatusCode}
+		if i := strings.LastIndex(apiErr.Type, "#"); i >= 0 {
+			apiErr.Type = apiErr.Type[i+1:]
+		}
+		if apiErr.Type == "" {
+			apiErr.Type = resp.Status
+		}
+		if apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+		return fmt.Errorf("kinesis %s failed: %w", operation, apiErr)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode kinesis %s response: %w", operation, err)
+	}
+	return nil
+}
+
+type putRecordInput struct {
+	StreamName   string `json:"StreamName"`
+	Data         []byte `json:"Data"` // Base64 encoded by encoding/json, as Kinesis expects
+	PartitionKey string `json:"PartitionKey"`
+}
+
+type putRecordOutput struct {
+	ShardID        string `json:"ShardId"`
+	SequenceNumber string `json:"SequenceNumber"`
+}
+
+type putRecordsEntry struct {
+	Data         []byte `json:"Data"`
+	PartitionKey string `json:"PartitionKey"`
+}
+
+type putRecordsInput struct {
+	StreamName string            `json:"StreamName"`
+	Records    []putRecordsEntry `json:"Records"`
+}
+
+type putRecordsOutput struct {
+	FailedRecordCount int `json:"FailedRecordCount"`
+	Records           []struct {
+		ShardID        string `json:"ShardId"`
+		SequenceNumber string `json:"SequenceNumber"`
+		ErrorCode      string `json:"ErrorCode"`
+		ErrorMessage   string `json:"ErrorMessage"`
+	} `json:"Records"`
+}
+
+type listShardsInput struct {
+	StreamName string `json:"StreamName,omitempty"`
+	NextToken  string `json:"NextToken,omitempty"`
+}
+
+type listShardsOutput struct {
+	Shards []struct {
+		ShardID string `json:"ShardId"`
+	} `json:"Shards"`
+	NextToken string `json:"NextToken"`
+}
+
+type getShardIteratorInput struct {
+	StreamName             string   `json:"StreamName"`
+	ShardID                string   `json:"ShardId"`
+	ShardIteratorType      string   `json:"ShardIteratorType"`
+	StartingSequenceNumber string   `json:"StartingSequenceNumber,omitempty"`
+	Timestamp              *float64 `json:"Timestamp,omitempty"` // Seconds since the epoch
+}
+
+type getShardIteratorOutput struct {
+	ShardIterator string `json:"ShardIterator"`
+}
+
+type getRecordsInput struct {
+	ShardIterator string `json:"ShardIterator"`
+	Limit         int    `json:"Limit,omitempty"`
+}
+
+type getRecordsOutput struct {
+	Records []struct {
+		SequenceNumber              string  `json:"SequenceNumber"`
+		ApproximateArrivalTimestamp float64 `json:"ApproximateArrivalTimestamp"`
+		Data                        []byte  `json:"Data"`
+		PartitionKey                string  `json:"PartitionKey"`
+	} `json:"Records"`
+	NextShardIterator string `json:"NextShardIterator"`
+}
+
+// listShards returns the IDs of every shard in the stream
+func (c *client) listShards(ctx context.Context, stream string) ([]string, error) {
+	var shards []string
+	in := listShardsInput{StreamName: stream}
+	for {
+		var out listShardsOutput
+		if err := c.call(ctx, "ListShards", in, &out); err != nil {
+			return nil, err
+		}
+		for _, shard := range out.Shards {
+			shards = append(shards, shard.ShardID)
+		}
+		if out.NextToken == "" {
+			return shards, nil
+		}
+		// Kinesis rejects StreamName alongside NextToken
+		in = listShardsInput{NextToken: out.NextToken}
+	}
+}
@@ -0,0 +1,592 @@
+package kinesis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+	"go.temporal.io/sdk/activity"
+)
+
+const (
+	defaultTimeout    = 60 * time.Second
+	defaultWait       = 10 * time.Second
+	defaultMaxRecords = 100
+	maxRecordsLimit   = 10000
+)
+
+// pollInterval is how long a read waits between empty GetRecords rounds. Kinesis
+// allows five GetRecords calls per second per shard.
+var pollInterval = time.Second
+
+// Auto-register the plugin when the package is imported
+func init() {
+	plugins.RegisterPlugin(&KinesisPlugin{})
+}
+
+// GetType returns the plugin type identifier
+func (kp *KinesisPlugin) GetType() string {
+	return "kinesis"
+}
+
+// Activity puts records on a stream or reads them back and checks the result
+func (kp *KinesisPlugin) Activity(ctx context.Context, p map[string]interface{}) (interface{}, error) {
+	logger := activity.GetLogger(ctx)
+
+	// Parse configuration from parameters
+	configData, ok := p["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid config format")
+	}
+
+	config := &KinesisConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		return nil, fmt.Errorf("failed to parse kinesis config: %w", err)
+	}
+
+	timeout := defaultTimeout
+	if config.Timeout != "" {
+		parsed, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", config.Timeout, err)
+		}
+		timeout = parsed
+	}
+
+	// Convert state to map[string]interface{} for template processing
+	state := make(map[string]interface{})
+	if stateStr, ok := p["state"].(map[string]string); ok {
+		for k, v := range stateStr {
+			state[k] = v
+		}
+	} else if stateInt, ok := p["state"].(map[string]interface{}); ok {
+		state = stateInt
+	}
+
+	// Extract env secrets from params (for {{ .env.* }} template resolution)
+	env := make(map[string]string)
+	if envData, ok := p["env"].(map[string]interface{}); ok {
+		for k, v := range envData {
+			if strVal, ok := v.(string); ok {
+				env[k] = strVal
+			}
+		}
+	} else if envData, ok := p["env"].(map[string]string); ok {
+		env = envData
+	}
+
+	if err := applyVariableReplacement(config, state, env); err != nil {
+		return nil, fmt.Errorf("variable replacement failed: %w", err)
+	}
+
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	policy, err := egress.FromParams(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
+
+	logger.Info("Executing kinesis plugin", "action", config.Action, "stream", config.Stream)
+
+	c, err := newClient(config, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	response, err := execute(ctx, c, config)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := resultSubject(response)
+	if err != nil {
+		return nil, err
+	}
+
+	assertionResults, err := processAssertions(p, response, subject, state, env)
+	if err != nil {
+		return nil, err
+	}
+	if failure := assertions.Summary(assertionResults); failure != "" {
+		return nil, fmt.Errorf("%s", failure)
+	}
+
+	saved := make(map[string]string)
+	if err := saves.Process(p, subject, saved); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Kinesis step completed", "action", config.Action, "records", response.Count, "duration", response.Duration)
+
+	return &ActivityResponse{
+		Response:         response,
+		Saved:            saved,
+		AssertionResults: assertionResults,
+	}, nil
+}
+
+// execute runs the configured action
+func execute(ctx context.Context, c *client, config *KinesisConfig) (*KinesisResponse, error) {
+	start := time.Now()
+
+	var records []Record
+	var err error
+	switch config.Action {
+	case ActionPut:
+		records, err = put(ctx, c, config)
+	case ActionRead:
+		records, err = read(ctx, c, config)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &KinesisResponse{
+		Action:   config.Action,
+		Stream:   config.Stream,
+		Records:  records,
+		Count:    len(records),
+		Duration: time.Since(start).String(),
+	}, nil
+}
+
+// put writes data with PutRecord, or records with a single PutRecords call. Any
+// record Kinesis rejects fails the step.
+func put(ctx context.Context, c *client, config *KinesisConfig) ([]Record, error) {
+	if len(config.Records) == 0 {
+		key := partitionKey(config.PartitionKey)
+		var out putRecordOutput
+		if err := c.call(ctx, "PutRecord", putRecordInput{StreamName: config.Stream, Data: []byte(config.Data), PartitionKey: key}, &out); err != nil {
+			return nil, err
+		}
+		return []Record{newRecord(out.ShardID, out.SequenceNumber, key, []byte(config.Data))}, nil
+	}
+
+	in := putRecordsInput{StreamName: config.Stream}
+	for _, record := range config.Records {
+		in.Records = append(in.Records, putRecordsEntry{Data: []byte(record.Data), PartitionKey: partitionKey(record.PartitionKey)})
+	}
+	var out putRecordsOutput
+	if err := c.call(ctx, "PutRecords", in, &out); err != nil {
+		return nil, err
+	}
+	if len(out.Records) != len(in.Records) {
+		return nil, fmt.Errorf("kinesis PutRecords returned %d results for %d records", len(out.Records), len(in.Records))
+	}
+
+	records := make([]Record, 0, len(out.Records))
+	var failed []string
+	for i, result := range out.Records {
+		if result.ErrorCode != "" {
+			failed = append(failed, fmt.Sprintf("records[%d]: %s: %s", i, result.ErrorCode, result.ErrorMessage))
+			continue
+		}
+		records = append(records, newRecord(result.ShardID, result.SequenceNumber, in.Records[i].PartitionKey, in.Records[i].Data))
+	}
+	if len(failed) > 0 {
+		return nil, fmt.Errorf("kinesis rejected %d of %d records: %s", len(failed), len(in.Records), strings.Join(failed, "; "))
+	}
+	return records, nil
+}
+
+// read polls the configured shards, or every shard, until min_records records have
+// arrived, max_records is reached, every shard is closed or wait runs out. Records
+// are returned in arrival order.
+func read(ctx context.Context, c *client, config *KinesisConfig) ([]Record, error) {
+	minRecords := 1
+	if config.MinRecords != nil {
+		minRecords = *config.MinRecords
+	}
+	maxRecords := config.MaxRecords
+	if maxRecords == 0 {
+		maxRecords = defaultMaxRecords
+	}
+	if maxRecords < minRecords {
+		maxRecords = minRecords
+	}
+	wait := defaultWait
+	if config.Wait != "" {
+		wait, _ = time.ParseDuration(config.Wait)
+	}
+
+	shards := []string{config.ShardID}
+	if config.ShardID == "" {
+		var err error
+		if shards, err = c.listShards(ctx, config.Stream); err != nil {
+			return nil, err
+		}
+	}
+
+	in := getShardIteratorInput{
+		StreamName:             config.Stream,
+		ShardIteratorType:      strings.ToUpper(config.Position),
+		StartingSequenceNumber: config.SequenceNumber,
+	}
+	if in.ShardIteratorType == "" {
+		in.ShardIteratorType = strings.ToUpper(PositionTrimHorizon)
+	}
+	if config.Position == PositionAtTimestamp {
+		at, err := parseTimestamp(config.Timestamp, c.now())
+		if err != nil {
+			return nil, err
+		}
+		seconds := float64(at.UnixNano()) / float64(time.Second)
+		in.Timestamp = &seconds
+	}
+
+	iterators := make(map[string]string, len(shards))
+	for _, shard := range shards {
+		in.ShardID = shard
+		var out getShardIteratorOutput
+		if err := c.call(ctx, "GetShardIterator", in, &out); err != nil {
+			return nil, err
+		}
+		iterators[shard] = out.ShardIterator
+	}
+
+	deadline := time.Now().Add(wait)
+	var records []Record
+	for {
+		open := 0
+		for _, shard := range shards {
+			iterator := iterators[shard]
+			if iterator == "" || len(records) >= maxRecords {
+				continue
+			}
+			var out getRecordsOutput
+			if err := c.call(ctx, "GetRecords", getRecordsInput{ShardIterator: iterator, Limit: maxRecords - len(records)}, &out); err != nil {
+				return nil, err
+			}
+			for _, r := range out.Records {
+				record := newRecord(shard, r.SequenceNumber, r.PartitionKey, r.Data)
+				arrival := time.Unix(0, int64(r.ApproximateArrivalTimestamp*float64(time.Second))).UTC()
+				record.ArrivalTime = arrival.Format(time.RFC3339Nano)
+				record.arrival = r.ApproximateArrivalTimestamp
+				records = append(records, record)
+			}
+			iterators[shard] = out.NextShardIterator
+			if out.NextShardIterator != "" {
+				open++
+			}
+		}
+
+		if len(records) >= maxRecords || (minRecords > 0 && len(records) >= minRecords) || open == 0 || !time.Now().Before(deadline) {
+			break
+		}
+		sleep := pollInterval
+		if remaining := time.Until(deadline); remaining < sleep {
+			sleep = remaining
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("reading stream %s: %w", config.Stream, ctx.Err())
+		case <-time.After(sleep):
+		}
+	}
+
+	if len(records) < minRecords {
+		return nil, fmt.Errorf("read %d of at least %d records from stream %s within %s", len(records), minRecords, config.Stream, wait)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].arrival < records[j].arrival
+	})
+	return records, nil
+}
+
+// parseTimestamp accepts an RFC 3339 time or a duration meaning that long ago, e.g. "5m"
+func parseTimestamp(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q: use an RFC 3339 time or a duration like 5m", value)
+}
+
+func partitionKey(key string) string {
+	if key != "" {
+		return key
+	}
+	return uuid.NewString()
+}
+
+func newRecord(shardID, sequenceNumber, partitionKey string, data []byte) Record {
+	record := Record{
+		ShardID:        shardID,
+		SequenceNumber: sequenceNumber,
+		PartitionKey:   partitionKey,
+		Data:           string(data),
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err == nil {
+		record.JSON = decoded
+	}
+	return record
+}
+
+// resultSubject is what assertions with a path and saves run against: the records
+// and their count. A put also exposes the first record's shard_id and
+// sequence_number at the top level, since most puts write one record.
+func resultSubject(response *KinesisResponse) (interface{}, error) {
+	subject := map[string]interface{}{
+		"records": response.Records,
+		"count":   response.Count,
+	}
+	if response.Action == ActionPut && len(response.Records) > 0 {
+		subject["shard_id"] = response.Records[0].ShardID
+		subject["sequence_number"] = response.Records[0].SequenceNumber
+	}
+
+	normalized, err := assertions.Normalize(subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare kinesis result: %w", err)
+	}
+	return normalized, nil
+}
+
+// processAssertions evaluates all assertions and returns their results
+func processAssertions(p map[string]interface{}, response *KinesisResponse, subject interface{}, state map[string]interface{}, env map[string]string) ([]AssertionResult, error) {
+	context := dsl.TemplateContext{Runtime: state, Env: env}
+	return assertions.Process(p, subject, context, func(assertionMap map[string]interface{}, result *AssertionResult) bool {
+		return evaluateAssertion(response, assertionMap, result)
+	})
+}
+
+// evaluateAssertion evaluates the kinesis plugin's own assertion types
+func evaluateAssertion(response *KinesisResponse, assertionMap map[string]interface{}, result *AssertionResult) bool {
+	expected := result.Expected
+	switch result.Type {
+	case AssertionTypeRecordCount:
+		result.Actual = response.Count
+		if !assertions.Equal(float64(response.Count), expected) {
+			result.Message = fmt.Sprintf("expected %v records, got %d", expected, response.Count)
+		} else {
+			result.Passed = true
+		}
+
+	default:
+		return false
+	}
+	return true
+}
+
+// validateConfig checks the action has what it needs once templates are rendered
+func validateConfig(config *KinesisConfig) error {
+	if config.Stream == "" {
+		return fmt.Errorf("stream is required")
+	}
+	if (config.AccessKeyID == "") != (config.SecretAccessKey == "") {
+		return fmt.Errorf("access_key_id and secret_access_key must be set together")
+	}
+
+	switch config.Action {
+	case ActionPut:
+		if config.Data == "" && len(config.Records) == 0 {
+			return fmt.Errorf("data or records is required with action put")
+		}
+		if config.Data != "" && len(config.Records) > 0 {
+			return fmt.Errorf("only one of data or records may be set")
+		}
+		if len(config.Records) > 500 {
+			return fmt.Errorf("records may hold at most 500 records, got %d", len(config.Records))
+		}
+	case ActionRead:
+		switch config.Position {
+		case "", PositionTrimHorizon, PositionLatest:
+		case PositionAtTimestamp:
+			if config.Timestamp == "" {
+				return fmt.Errorf("timestamp is required with position at_timestamp")
+			}
+			if _, err := parseTimestamp(config.Timestamp, time.Now()); err != nil {
+				return err
+			}
+		case PositionAtSequenceNumber, PositionAfterSequenceNumber:
+			if config.SequenceNumber == "" || config.ShardID == "" {
+				return fmt.Errorf("sequence_number and shard_id are required with position %s", config.Position)
+			}
+		default:
+			return fmt.Errorf("position must be trim_horizon, latest, at_timestamp, at_sequence_number or after_sequence_number, got %q", config.Position)
+		}
+		if config.MinRecords != nil && *config.MinRecords < 0 {
+			return fmt.Errorf("min_records must not be negative")
+		}
+		if config.MaxRecords < 0 || config.MaxRecords > maxRecordsLimit {
+			return fmt.Errorf("max_records must be between 1 and %d", maxRecordsLimit)
+		}
+		if config.Wait != "" {
+			if _, err := time.ParseDuration(config.Wait); err != nil {
+				return fmt.Errorf("invalid wait %q: %w", config.Wait, err)
+			}
+		}
+	case "":
+		return fmt.Errorf("action is required")
+	default:
+		return fmt.Errorf("action must be put or read, got %q", config.Action)
+	}
+
+	return nil
+}
+
+// applyVariableReplacement processes templates in the connection settings, the
+// records and the read position
+func applyVariableReplacement(config *KinesisConfig, state map[string]interface{}, env map[string]string) error {
+	context := dsl.TemplateContext{
+		Runtime: state,
+		Env:     env,
+	}
+
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"stream", &config.Stream},
+		{"region", &config.Region},
+		{"endpoint", &config.Endpoint},
+		{"access_key_id", &config.AccessKeyID},
+		{"secret_access_key", &config.SecretAccessKey},
+		{"session_token", &config.SessionToken},
+		{"data", &config.Data},
+		{"partition_key", &config.PartitionKey},
+		{"shard_id", &config.ShardID},
+		{"timestamp", &config.Timestamp},
+		{"sequence_number", &config.SequenceNumber},
+	}
+	for i := range config.Records {
+		fields = append(fields,
+			struct {
+				name  string
+				value *string
+			}{fmt.Sprintf("records[%d].data", i), &config.Records[i].Data},
+			struct {
+				name  string
+				value *string
+			}{fmt.Sprintf("records[%d].partition_key", i), &config.Records[i].PartitionKey},
+		)
+	}
+	for _, field := range fields {
+		if *field.value == "" {
+			continue
+		}
+		processed, err := dsl.ProcessTemplate(*field.value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process %s template: %w", field.name, err)
+		}
+		*field.value = processed
+	}
+
+	return nil
+}
+
+// parseConfig converts map[string]interface{} to KinesisConfig
+func parseConfig(configData map[string]interface{}, config *KinesisConfig) error {
+	stringFields := map[string]*string{
+		"action":            &config.Action,
+		"stream":            &config.Stream,
+		"region":            &config.Region,
+		"endpoint":          &config.Endpoint,
+		"access_key_id":     &config.AccessKeyID,
+		"secret_access_key": &config.SecretAccessKey,
+		"session_token":     &config.SessionToken,
+		"partition_key":     &config.PartitionKey,
+		"shard_id":          &config.ShardID,
+		"position":          &config.Position,
+		"timestamp":         &config.Timestamp,
+		"sequence_number":   &config.SequenceNumber,
+		"wait":              &config.Wait,
+		"timeout":           &config.Timeout,
+	}
+	for key, target := range stringFields {
+		if v, ok := configData[key].(string); ok {
+			*target = v
+		}
+	}
+
+	if raw, ok := configData["data"]; ok {
+		data, err := payload(raw)
+		if err != nil {
+			return fmt.Errorf("data: %w", err)
+		}
+		config.Data = data
+	}
+
+	switch records := configData["records"].(type) {
+	case nil:
+	case []interface{}:
+		for i, entry := range records {
+			entryMap, ok := entry.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("records[%d] must be an object with data and an optional partition_key", i)
+			}
+			data, err := payload(entryMap["data"])
+			if err != nil {
+				return fmt.Errorf("records[%d].data: %w", i, err)
+			}
+			key, _ := entryMap["partition_key"].(string)
+			config.Records = append(config.Records, PutRecord{Data: data, PartitionKey: key})
+		}
+	default:
+		return fmt.Errorf("records must be a list, got %T", records)
+	}
+
+	maxRecords, _, err := intField(configData, "max_records")
+	if err != nil {
+		return err
+	}
+	config.MaxRecords = maxRecords
+
+	minRecords, set, err := intField(configData, "min_records")
+	if err != nil {
+		return err
+	}
+	if set {
+		config.MinRecords = &minRecords
+	}
+
+	return nil
+}
+
+// payload renders a record's data: strings as-is, anything else as JSON
+func payload(raw interface{}) (string, error) {
+	switch v := raw.(type) {
+	case nil:
+		return "", errors.New("is required")
+	case string:
+		return v, nil
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode as JSON: %w", err)
+		}
+		return string(encoded), nil
+	}
+}
+
+func intField(configData map[string]interface{}, key string) (int, bool, error) {
+	switch v := configData[key].(type) {
+	case nil:
+		return 0, false, nil
+	case float64:
+		return int(v), true, nil
+	case int:
+		return v, true, nil
+	default:
+		return 0, false, fmt.Errorf("%s must be a number, got %T", key, v)
+	}
+}
@@ -0,0 +1,312 @@
+package kinesis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+)
+
+type fakeRecord struct {
+	shard    string
+	sequence string
+	key      string
+	data     []byte
+	arrival  float64
+}
+
+// fakeKinesis serves the operations the plugin uses from an in-memory stream
+type fakeKinesis struct {
+	mu        sync.Mutex
+	stream    string
+	shards    []string
+	records   []fakeRecord
+	iterators []getShardIteratorInput
+	reject    map[string]bool // Partition keys PutRecords rejects
+	// pending records appear on the stream after the first GetRecords round
+	pending []fakeRecord
+}
+
+func (f *fakeKinesis) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"__type":"MissingAuthenticationTokenException","message":"unsigned"}`))
+		return
+	}
+
+	operation := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "Kinesis_20131202.")
+	var in map[string]interface{}
+	_ = json.NewDecoder(r.Body).Decode(&in)
+
+	if name, ok := in["StreamName"]; ok && name != f.stream {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"__type":"com.amazonaws.kinesis#ResourceNotFoundException","message":"Stream missing not found"}`))
+		return
+	}
+
+	var out interface{}
+	switch operation {
+	case "PutRecord":
+		record := f.add(in)
+		out = map[string]string{"ShardId": record.shard, "SequenceNumber": record.sequence}
+	case "PutRecords":
+		var results []map[string]string
+		failed := 0
+		for _, entry := range in["Records"].([]interface{}) {
+			entryMap := entry.(map[string]interface{})
+			if f.reject[entryMap["PartitionKey"].(string)] {
+				failed++
+				results = append(results, map[string]string{"ErrorCode": "ProvisionedThroughputExceededException", "ErrorMessage": "slow down"})
+				continue
+			}
+			record := f.add(entryMap)
+			results = append(results, map[string]string{"ShardId": record.shard, "SequenceNumber": record.sequence})
+		}
+		out = map[string]interface{}{"FailedRecordCount": failed, "Records": results}
+	case "ListShards":
+		var shards []map[string]string
+		for _, shard := range f.shards {
+			shards = append(shards, map[string]string{"ShardId": shard})
+		}
+		out = map[string]interface{}{"Shards": shards}
+	case "GetShardIterator":
+		encoded, _ := json.Marshal(in)
+		var iterator getShardIteratorInput
+		_ = json.Unmarshal(encoded, &iterator)
+		f.iterators = append(f.iterators, iterator)
+		out = map[string]string{"ShardIterator": iterator.ShardID + ":0"}
+	case "GetRecords":
+		iterator := in["ShardIterator"].(string)
+		shard, offset, _ := strings.Cut(iterator, ":")
+		var start int
+		_, _ = fmt.Sscanf(offset, "%d", &start)
+		var batch []map[string]interface{}
+		seen := 0
+		for _, record := range f.records {
+			if record.shard != shard {
+				continue
+			}
+			if seen >= start {
+				batch = append(batch, map[string]interface{}{
+					"SequenceNumber":              record.sequence,
+					"PartitionKey":                record.key,
+					"Data":                        record.data,
+					"ApproximateArrivalTimestamp": record.arrival,
+				})
+			}
+			seen++
+		}
+		if shard == f.shards[len(f.shards)-1] {
+			f.records = append(f.records, f.pending...)
+			f.pending = nil
+		}
+		out = map[string]interface{}{"Records": batch, "NextShardIterator": fmt.Sprintf("%s:%d", shard, start+len(batch))}
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+func (f *fakeKinesis) add(in map[string]interface{}) fakeRecord {
+	var data []byte
+	_ = json.Unmarshal([]byte(fmt.Sprintf("%q", in["Data"])), &data)
+	record := fakeRecord{
+		shard:    f.shards[len(f.records)%len(f.shards)],
+		sequence: fmt.Sprintf("4959%04d", len(f.records)),
+		key:      in["PartitionKey"].(string),
+		data:     data,
+		arrival:  float64(1700000000 + len(f.records)),
+	}
+	f.records = append(f.records, record)
+	return record
+}
+
+func newTestClient(t *testing.T, fake *fakeKinesis) *client {
+	t.Helper()
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	c, err := newClient(&KinesisConfig{Region: "us-east-1", Endpoint: server.URL, AccessKeyID: "AKID", SecretAccessKey: "secret"}, nil)
+	if err != nil {
+		t.Fatalf("newClient: %v", err)
+	}
+	c.now = func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) }
+	return c
+}
+
+func TestPutAndRead(t *testing.T) {
+	fake := &fakeKinesis{stream: "orders", shards: []string{"shardId-000", "shardId-001"}}
+	c := newTestClient(t, fake)
+	ctx := context.Background()
+
+	put, err := execute(ctx, c, &KinesisConfig{Action: ActionPut, Stream: "orders", Data: `{"id":"o-1"}`, PartitionKey: "o-1"})
+	if err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if put.Count != 1 || put.Records[0].ShardID != "shardId-000" || put.Records[0].PartitionKey != "o-1" {
+		t.Fatalf("unexpected put response %+v", put)
+	}
+
+	batch, err := execute(ctx, c, &KinesisConfig{Action: ActionPut, Stream: "orders", Records: []PutRecord{{Data: "plain"}, {Data: `{"id":"o-3"}`, PartitionKey: "o-3"}}})
+	if err != nil {
+		t.Fatalf("batch put: %v", err)
+	}
+	if batch.Count != 2 || batch.Records[0].PartitionKey == "" {
+		t.Fatalf("expected a random partition key, got %+v", batch.Records)
+	}
+
+	three := 3
+	read, err := execute(ctx, c, &KinesisConfig{Action: ActionRead, Stream: "orders", MinRecords: &three})
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if read.Count != 3 {
+		t.Fatalf("expected 3 records, got %d", read.Count)
+	}
+	// Records from both shards come back in arrival order, with JSON payloads decoded
+	if read.Records[0].Data != `{"id":"o-1"}` || read.Records[1].Data != "plain" || read.Records[2].PartitionKey != "o-3" {
+		t.Errorf("unexpected order %+v", read.Records)
+	}
+	if read.Records[0].JSON.(map[string]interface{})["id"] != "o-1" || read.Records[1].JSON != nil {
+		t.Errorf("unexpected decoded payloads %+v", read.Records)
+	}
+	if read.Records[0].ArrivalTime != "2023-11-14T22:13:20Z" {
+		t.Errorf("unexpected arrival time %s", read.Records[0].ArrivalTime)
+	}
+
+	subject, err := resultSubject(read)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := map[string]string{}
+	p := map[string]interface{}{
+		"save": []interface{}{map[string]interface{}{"json_path": ".records[0].json.id", "as": "order_id"}},
+		"assertions": []interface{}{
+			map[string]interface{}{"type": "record_count", "expected": 3.0},
+			map[string]interface{}{"type": "json_path", "path": ".records[2].json.id", "expected": "o-3"},
+		},
+	}
+	if err := saves.Process(p, subject, saved); err != nil || saved["order_id"] != "o-1" {
+		t.Errorf("unexpected saves %v: %v", saved, err)
+	}
+	if results, err := processAssertions(p, read, subject, nil, nil); err != nil || assertions.Summary(results) != "" {
+		t.Errorf("unexpected assertion failure: %v %+v", err, results)
+	}
+}
+
+func TestReadWaitsForRecords(t *testing.T) {
+	pollInterval = time.Millisecond
+	defer func() { pollInterval = time.Second }()
+
+	fake := &fakeKinesis{stream: "orders", shards: []string{"shardId-000"}}
+	fake.pending = []fakeRecord{{shard: "shardId-000", sequence: "1", key: "k", data: []byte("late"), arrival: 1700000000}}
+	c := newTestClient(t, fake)
+
+	read, err := execute(context.Background(), c, &KinesisConfig{Action: ActionRead, Stream: "orders", Position: PositionLatest})
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if read.Count != 1 || read.Records[0].Data != "late" {
+		t.Fatalf("expected the late record, got %+v", read.Records)
+	}
+	if fake.iterators[0].ShardIteratorType != "LATEST" {
+		t.Errorf("unexpected iterator type %s", fake.iterators[0].ShardIteratorType)
+	}
+
+	_, err = execute(context.Background(), c, &KinesisConfig{Action: ActionRead, Stream: "orders", ShardID: "shardId-000", Position: PositionAfterSequenceNumber, SequenceNumber: "1", Wait: "5ms", MaxRecords: 5, MinRecords: intPtr(6)})
+	if err == nil || !strings.Contains(err.Error(), "read 1 of at least 6 records from stream orders within 5ms") {
+		t.Errorf("expected a min_records failure, got %v", err)
+	}
+	last := fake.iterators[len(fake.iterators)-1]
+	if last.ShardIteratorType != "AFTER_SEQUENCE_NUMBER" || last.StartingSequenceNumber != "1" {
+		t.Errorf("unexpected iterator request %+v", last)
+	}
+}
+
+func TestReadAtTimestamp(t *testing.T) {
+	fake := &fakeKinesis{stream: "orders", shards: []string{"shardId-000"}}
+	c := newTestClient(t, fake)
+
+	_, err := execute(context.Background(), c, &KinesisConfig{Action: ActionRead, Stream: "orders", Position: PositionAtTimestamp, Timestamp: "5m", MinRecords: intPtr(0), Wait: "1ms"})
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	got := fake.iterators[0]
+	if got.ShardIteratorType != "AT_TIMESTAMP" || got.Timestamp == nil || *got.Timestamp != float64(time.Date(2024, 1, 1, 11, 55, 0, 0, time.UTC).Unix()) {
+		t.Errorf("unexpected iterator request %+v", got)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	fake := &fakeKinesis{stream: "orders", shards: []string{"shardId-000"}, reject: map[string]bool{"hot": true}}
+	c := newTestClient(t, fake)
+	ctx := context.Background()
+
+	_, err := execute(ctx, c, &KinesisConfig{Action: ActionPut, Stream: "missing", Data: "x"})
+	if err == nil || !strings.Contains(err.Error(), "kinesis PutRecord failed: ResourceNotFoundException: Stream missing not found") {
+		t.Errorf("expected a stream not found error, got %v", err)
+	}
+
+	_, err = execute(ctx, c, &KinesisConfig{Action: ActionPut, Stream: "orders", Records: []PutRecord{{Data: "a", PartitionKey: "ok"}, {Data: "b", PartitionKey: "hot"}}})
+	if err == nil || !strings.Contains(err.Error(), "kinesis rejected 1 of 2 records: records[1]: ProvisionedThroughputExceededException") {
+		t.Errorf("expected a partial failure, got %v", err)
+	}
+
+	c.creds.AccessKeyID = "OTHER"
+	_, err = execute(ctx, c, &KinesisConfig{Action: ActionPut, Stream: "orders", Data: "x"})
+	if err == nil || !strings.Contains(err.Error(), "MissingAuthenticationTokenException") {
+		t.Errorf("expected an authentication error, got %v", err)
+	}
+}
+
+func TestParseAndValidateConfig(t *testing.T) {
+	config := &KinesisConfig{}
+	err := parseConfig(map[string]interface{}{
+		"action":      "put",
+		"stream":      "orders",
+		"records":     []interface{}{map[string]interface{}{"data": map[string]interface{}{"id": 1.0}, "partition_key": "k"}},
+		"min_records": 0.0,
+	}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Records[0].Data != `{"id":1}` || config.MinRecords == nil || *config.MinRecords != 0 {
+		t.Errorf("unexpected config %+v", config)
+	}
+
+	tests := []struct {
+		config KinesisConfig
+		want   string
+	}{
+		{KinesisConfig{Action: ActionPut}, "stream is required"},
+		{KinesisConfig{Action: ActionPut, Stream: "s"}, "data or records is required"},
+		{KinesisConfig{Action: "scan", Stream: "s"}, "action must be put or read"},
+		{KinesisConfig{Action: ActionRead, Stream: "s", Position: "earliest"}, "position must be"},
+		{KinesisConfig{Action: ActionRead, Stream: "s", Position: PositionAtTimestamp}, "timestamp is required"},
+		{KinesisConfig{Action: ActionRead, Stream: "s", Position: PositionAtTimestamp, Timestamp: "yesterday"}, "invalid timestamp"},
+		{KinesisConfig{Action: ActionRead, Stream: "s", Position: PositionAtSequenceNumber, SequenceNumber: "1"}, "sequence_number and shard_id are required"},
+		{KinesisConfig{Action: ActionRead, Stream: "s", AccessKeyID: "AKID"}, "must be set together"},
+	}
+	for _, tt := range tests {
+		err := validateConfig(&tt.config)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("validateConfig(%+v) = %v, want %q", tt.config, err, tt.want)
+		}
+	}
+}
+
+func intPtr(n int) *int {
+	return &n
+}
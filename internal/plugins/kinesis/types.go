@@ -0,0 +1,98 @@
+package kinesis
+
+import "github.com/rocketship-ai/rocketship/internal/assertions"
+
+// KinesisPlugin represents a Kinesis test step
+type KinesisPlugin struct {
+	Name   string        `json:"name" yaml:"name"`
+	Plugin string        `json:"plugin" yaml:"plugin"`
+	Config KinesisConfig `json:"config" yaml:"config"`
+}
+
+// KinesisConfig selects the stream, the action and how records are written or read
+type KinesisConfig struct {
+	Action string `json:"action" yaml:"action"` // put or read
+	Stream string `json:"stream" yaml:"stream"`
+
+	// Connection. Credentials and region default to the standard AWS environment
+	// variables on the worker.
+	Region          string `json:"region,omitempty" yaml:"region,omitempty"`
+	Endpoint        string `json:"endpoint,omitempty" yaml:"endpoint,omitempty"` // e.g. http://localstack:4566
+	AccessKeyID     string `json:"access_key_id,omitempty" yaml:"access_key_id,omitempty"`
+	SecretAccessKey string `json:"secret_access_key,omitempty" yaml:"secret_access_key,omitempty"`
+	SessionToken    string `json:"session_token,omitempty" yaml:"session_token,omitempty"`
+
+	// put
+	Data         string      `json:"data,omitempty" yaml:"data,omitempty"`                   // Objects and arrays are sent as JSON
+	PartitionKey string      `json:"partition_key,omitempty" yaml:"partition_key,omitempty"` // Defaults to a random key
+	Records      []PutRecord `json:"records,omitempty" yaml:"records,omitempty"`             // Sent with one PutRecords call
+
+	// read
+	ShardID        string `json:"shard_id,omitempty" yaml:"shard_id,omitempty"` // Defaults to every shard
+	Position       string `json:"position,omitempty" yaml:"position,omitempty"` // Defaults to trim_horizon
+	Timestamp      string `json:"timestamp,omitempty" yaml:"timestamp,omitempty"`
+	SequenceNumber string `json:"sequence_number,omitempty" yaml:"sequence_number,omitempty"`
+	MinRecords     *int   `json:"min_records,omitempty" yaml:"min_records,omitempty"` // Defaults to 1
+	MaxRecords     int    `json:"max_records,omitempty" yaml:"max_records,omitempty"`
+	Wait           string `json:"wait,omitempty" yaml:"wait,omitempty"` // How long to poll for min_records (defaults to 10s)
+
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"` // Overall step timeout (defaults to 60s)
+}
+
+// PutRecord is one record of a batch put
+type PutRecord struct {
+	Data         string `json:"data" yaml:"data"`
+	PartitionKey string `json:"partition_key,omitempty" yaml:"partition_key,omitempty"`
+}
+
+// Actions supported by the kinesis plugin
+const (
+	ActionPut  = "put"
+	ActionRead = "read"
+)
+
+// Read positions, mapped to Kinesis shard iterator types
+const (
+	PositionTrimHorizon         = "trim_horizon"
+	PositionLatest              = "latest"
+	PositionAtTimestamp         = "at_timestamp"
+	PositionAtSequenceNumber    = "at_sequence_number"
+	PositionAfterSequenceNumber = "after_sequence_number"
+)
+
+// Assertion types supported by the kinesis plugin in addition to the shared ones
+const (
+	AssertionTypeRecordCount = "record_count"
+)
+
+// Record is a record written or read. Data holds the payload as text, and JSON
+// payloads are also decoded into JSON.
+type Record struct {
+	ShardID        string      `json:"shard_id"`
+	SequenceNumber string      `json:"sequence_number"`
+	PartitionKey   string      `json:"partition_key,omitempty"`
+	Data           string      `json:"data,omitempty"`
+	JSON           interface{} `json:"json,omitempty"`
+	ArrivalTime    string      `json:"arrival_time,omitempty"` // RFC 3339, read only
+
+	arrival float64 // Seconds since the epoch, for ordering records across shards
+}
+
+// KinesisResponse contains the records written or read
+type KinesisResponse struct {
+	Action   string   `json:"action"`
+	Stream   string   `json:"stream"`
+	Records  []Record `json:"records"`
+	Count    int      `json:"count"`
+	Duration string   `json:"duration"`
+}
+
+// AssertionResult represents a single assertion result for UI display
+type AssertionResult = assertions.Result
+
+// ActivityResponse represents the complete activity response
+type ActivityResponse struct {
+	Response         *KinesisResponse  `json:"response"`
+	Saved            map[string]string `json:"saved"`
+	AssertionResults []AssertionResult `json:"assertion_results,omitempty"`
+}
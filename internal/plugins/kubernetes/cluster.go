@@ -0,0 +1,295 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/egress"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+// maxLogBytes caps how much of a container log is kept for assertions
+const maxLogBytes = 1 << 20
+
+// cluster holds the clients for one step
+type cluster struct {
+	config    *rest.Config
+	dynamic   dynamic.Interface
+	clientset clientset.Interface
+	mapper    meta.RESTMapper
+	namespace string // Namespace from the kubeconfig context or service account
+}
+
+// connect builds clients from the configured kubeconfig. All connections go through
+// the egress policy.
+func connect(config *KubernetesConfig, policy *egress.Policy) (*cluster, error) {
+	var clientConfig clientcmd.ClientConfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: config.Context}
+	if config.KubeconfigData != "" {
+		raw, err := clientcmd.Load([]byte(config.KubeconfigData))
+		if err != nil {
+			return nil, fmt.Errorf("invalid kubeconfig_data: %w", err)
+		}
+		clientConfig = clientcmd.NewDefaultClientConfig(*raw, overrides)
+	} else {
+		// Falls back to the in-cluster service account when no kubeconfig is found
+		rules := clientcmd.NewDefaultClientConfigLoadingRules()
+		rules.ExplicitPath = config.Kubeconfig
+		clientConfig = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides)
+	}
+
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil || namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+
+	restConfig.Dial = policy.DialContext(&net.Dialer{})
+	restConfig.UserAgent = "rocketship"
+	// Waits poll; keep client-side throttling from stretching them
+	restConfig.QPS = 50
+	restConfig.Burst = 100
+
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+	typedClient, err := clientset.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+	discovery := memory.NewMemCacheClient(typedClient.Discovery())
+
+	return &cluster{
+		config:    restConfig,
+		dynamic:   dynamicClient,
+		clientset: typedClient,
+		mapper:    restmapper.NewShortcutExpander(restmapper.NewDeferredDiscoveryRESTMapper(discovery), discovery, nil),
+		namespace: namespace,
+	}, nil
+}
+
+// resolve maps a resource name as kubectl accepts it ("deploy", "deployment",
+// "deployments.apps") to its REST mapping
+func (c *cluster) resolve(resource, apiVersion string) (*meta.RESTMapping, error) {
+	groupResource := schema.ParseGroupResource(strings.ToLower(resource))
+	gvr := groupResource.WithVersion("")
+	if apiVersion != "" {
+		gv, err := schema.ParseGroupVersion(apiVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid api_version %q: %w", apiVersion, err)
+		}
+		gvr = gv.WithResource(groupResource.Resource)
+	}
+
+	gvk, err := c.mapper.KindFor(gvr)
+	if err != nil {
+		return nil, fmt.Errorf("unknown resource %q: %w", resource, err)
+	}
+	mapping, err := c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, fmt.Errorf("unknown resource %q: %w", resource, err)
+	}
+	return mapping, nil
+}
+
+func (c *cluster) resourceClient(mapping *meta.RESTMapping, namespace string) dynamic.ResourceInterface {
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		return c.dynamic.Resource(mapping.Resource).Namespace(namespace)
+	}
+	return c.dynamic.Resource(mapping.Resource)
+}
+
+// resourceAction runs get, list and wait
+func (c *cluster) resourceAction(ctx context.Context, config *KubernetesConfig, namespace string, response *KubernetesResponse) error {
+	mapping, err := c.resolve(config.Resource, config.APIVersion)
+	if err != nil {
+		return err
+	}
+	client := c.resourceClient(mapping, namespace)
+	response.Resource = mapping.Resource.GroupResource().String()
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		response.Namespace = namespace
+	}
+
+	switch config.Action {
+	case ActionGet:
+		obj, err := client.Get(ctx, config.Name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get %s/%s: %w", response.Resource, config.Name, err)
+		}
+		response.Object = obj.Object
+		response.Count = 1
+
+	case ActionList:
+		list, err := client.List(ctx, metav1.ListOptions{LabelSelector: config.LabelSelector, FieldSelector: config.FieldSelector})
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", response.Resource, err)
+		}
+		response.Items = make([]map[string]interface{}, 0, len(list.Items))
+		for _, item := range list.Items {
+			response.Items = append(response.Items, item.Object)
+		}
+		response.Count = len(response.Items)
+
+	case ActionWait:
+		interval := defaultInterval
+		if config.Interval != "" {
+			interval, _ = time.ParseDuration(config.Interval)
+		}
+		return wait(ctx, client, config, interval, response)
+	}
+	return nil
+}
+
+// logs reads a container log
+func (c *cluster) logs(ctx context.Context, config *KubernetesConfig, namespace string, response *KubernetesResponse) error {
+	pod, err := c.selectPod(ctx, config, namespace)
+	if err != nil {
+		return err
+	}
+	response.Namespace = namespace
+	response.Pod = pod
+	response.Container = config.Container
+
+	options := &corev1.PodLogOptions{
+		Container: config.Container,
+		Previous:  config.Previous,
+	}
+	if config.TailLines > 0 {
+		options.TailLines = &config.TailLines
+	}
+	if config.Since != "" {
+		since, _ := time.ParseDuration(config.Since)
+		seconds := int64(since.Seconds())
+		options.SinceSeconds = &seconds
+	}
+
+	stream, err := c.clientset.CoreV1().Pods(namespace).GetLogs(pod, options).Stream(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read logs of pod %s: %w", pod, err)
+	}
+	defer func() { _ = stream.Close() }()
+
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(io.LimitReader(stream, maxLogBytes)); err != nil {
+		return fmt.Errorf("failed to read logs of pod %s: %w", pod, err)
+	}
+	response.Logs = buf.String()
+	return nil
+}
+
+// exec runs a command in a container. A non-zero exit status is returned in the
+// response rather than as an error.
+func (c *cluster) exec(ctx context.Context, config *KubernetesConfig, namespace string, response *KubernetesResponse) error {
+	pod, err := c.selectPod(ctx, config, namespace)
+	if err != nil {
+		return err
+	}
+	response.Namespace = namespace
+	response.Pod = pod
+	response.Container = config.Container
+
+	req := c.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(pod).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: config.Container,
+			Command:   config.Command,
+			Stdin:     config.Stdin != "",
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	// Prefer the WebSocket protocol and fall back to SPDY for older API servers
+	spdyExecutor, err := remotecommand.NewSPDYExecutor(c.config, "POST", req.URL())
+	if err != nil {
+		return fmt.Errorf("failed to set up exec in pod %s: %w", pod, err)
+	}
+	wsExecutor, err := remotecommand.NewWebSocketExecutor(c.config, "GET", req.URL().String())
+	if err != nil {
+		return fmt.Errorf("failed to set up exec in pod %s: %w", pod, err)
+	}
+	executor, err := remotecommand.NewFallbackExecutor(wsExecutor, spdyExecutor, func(err error) bool {
+		return httpstream.IsUpgradeFailure(err) || httpstream.IsHTTPSProxyError(err)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set up exec in pod %s: %w", pod, err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	streamOptions := remotecommand.StreamOptions{Stdout: &stdout, Stderr: &stderr}
+	if config.Stdin != "" {
+		streamOptions.Stdin = strings.NewReader(config.Stdin)
+	}
+	err = executor.StreamWithContext(ctx, streamOptions)
+
+	var exitErr utilexec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr) && exitErr.Exited():
+		response.ExitCode = exitErr.ExitStatus()
+	default:
+		return fmt.Errorf("exec in pod %s failed: %w", pod, err)
+	}
+
+	response.Stdout = stdout.String()
+	response.Stderr = stderr.String()
+	return nil
+}
+
+// selectPod returns the named pod, or the first running pod matching the label
+// selector by name
+func (c *cluster) selectPod(ctx context.Context, config *KubernetesConfig, namespace string) (string, error) {
+	if config.Name != "" {
+		return config.Name, nil
+	}
+
+	pods, err := c.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: config.LabelSelector})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods: %w", err)
+	}
+	var running, other []string
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		if pod.Status.Phase == corev1.PodRunning {
+			running = append(running, pod.Name)
+		} else {
+			other = append(other, pod.Name)
+		}
+	}
+	for _, names := range [][]string{running, other} {
+		if len(names) > 0 {
+			sort.Strings(names)
+			return names[0], nil
+		}
+	}
+	return "", fmt.Errorf("no pods in namespace %s match label selector %q", namespace, config.LabelSelector)
+}
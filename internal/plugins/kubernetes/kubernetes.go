@@ -0,0 +1,451 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+	"go.temporal.io/sdk/activity"
+)
+
+const (
+	defaultTimeout     = 60 * time.Second
+	defaultWaitTimeout = 5 * time.Minute
+	defaultInterval    = 2 * time.Second
+)
+
+// Auto-register the plugin when the package is imported
+func init() {
+	plugins.RegisterPlugin(&KubernetesPlugin{})
+}
+
+// GetType returns the plugin type identifier
+func (kp *KubernetesPlugin) GetType() string {
+	return "kubernetes"
+}
+
+// Activity runs a read-only action against a cluster and checks the result
+func (kp *KubernetesPlugin) Activity(ctx context.Context, p map[string]interface{}) (interface{}, error) {
+	logger := activity.GetLogger(ctx)
+
+	// Parse configuration from parameters
+	configData, ok := p["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid config format")
+	}
+
+	config := &KubernetesConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		return nil, fmt.Errorf("failed to parse kubernetes config: %w", err)
+	}
+
+	timeout := defaultTimeout
+	if config.Action == ActionWait {
+		timeout = defaultWaitTimeout
+	}
+	if config.Timeout != "" {
+		parsed, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", config.Timeout, err)
+		}
+		timeout = parsed
+	}
+
+	// Convert state to map[string]interface{} for template processing
+	state := make(map[string]interface{})
+	if stateStr, ok := p["state"].(map[string]string); ok {
+		for k, v := range stateStr {
+			state[k] = v
+		}
+	} else if stateInt, ok := p["state"].(map[string]interface{}); ok {
+		state = stateInt
+	}
+
+	// Extract env secrets from params (for {{ .env.* }} template resolution)
+	env := make(map[string]string)
+	if envData, ok := p["env"].(map[string]interface{}); ok {
+		for k, v := range envData {
+			if strVal, ok := v.(string); ok {
+				env[k] = strVal
+			}
+		}
+	} else if envData, ok := p["env"].(map[string]string); ok {
+		env = envData
+	}
+
+	if err := applyVariableReplacement(config, state, env); err != nil {
+		return nil, fmt.Errorf("variable replacement failed: %w", err)
+	}
+
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	policy, err := egress.FromParams(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
+
+	logger.Info("Executing kubernetes plugin", "action", config.Action, "resource", config.Resource, "name", config.Name)
+
+	c, err := connect(config, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	response, err := execute(ctx, c, config)
+	if err != nil {
+		return nil, err
+	}
+
+	// A failing command fails the step unless the test asserts on the exit code itself
+	if response.ExitCode != 0 && !hasExitCodeAssertion(p) {
+		return nil, fmt.Errorf("command in pod %s exited with status %d: %s", response.Pod, response.ExitCode, lastLine(response.Stderr))
+	}
+
+	subject, err := resultSubject(response)
+	if err != nil {
+		return nil, err
+	}
+
+	assertionResults, err := processAssertions(p, response, subject, state, env)
+	if err != nil {
+		return nil, err
+	}
+	if failure := assertions.Summary(assertionResults); failure != "" {
+		return nil, fmt.Errorf("%s", failure)
+	}
+
+	saved := make(map[string]string)
+	if err := saves.Process(p, subject, saved); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Kubernetes step completed", "action", config.Action, "duration", response.Duration)
+
+	return &ActivityResponse{
+		Response:         response,
+		Saved:            saved,
+		AssertionResults: assertionResults,
+	}, nil
+}
+
+// execute runs the configured action
+func execute(ctx context.Context, c *cluster, config *KubernetesConfig) (*KubernetesResponse, error) {
+	start := time.Now()
+	namespace := config.Namespace
+	if namespace == "" {
+		namespace = c.namespace
+	}
+
+	response := &KubernetesResponse{Action: config.Action}
+	var err error
+	switch config.Action {
+	case ActionGet, ActionList, ActionWait:
+		err = c.resourceAction(ctx, config, namespace, response)
+	case ActionLogs:
+		err = c.logs(ctx, config, namespace, response)
+	case ActionExec:
+		err = c.exec(ctx, config, namespace, response)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response.Duration = time.Since(start).String()
+	return response, nil
+}
+
+// resultSubject is what assertions with a path and saves run against: the object for
+// get and wait on a named resource, items and count for list and selector waits, and
+// the output for logs and exec. Exec stdout is also decoded into json when it holds a
+// JSON document.
+func resultSubject(response *KubernetesResponse) (interface{}, error) {
+	var subject interface{}
+	switch {
+	case response.Action == ActionLogs:
+		subject = map[string]interface{}{
+			"pod":       response.Pod,
+			"container": response.Container,
+			"logs":      response.Logs,
+		}
+	case response.Action == ActionExec:
+		result := map[string]interface{}{
+			"pod":       response.Pod,
+			"container": response.Container,
+			"stdout":    response.Stdout,
+			"stderr":    response.Stderr,
+			"exit_code": float64(response.ExitCode),
+		}
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(response.Stdout), &decoded); err == nil {
+			result["json"] = decoded
+		}
+		subject = result
+	case response.Object != nil:
+		subject = response.Object
+	default:
+		items := make([]interface{}, len(response.Items))
+		for i, item := range response.Items {
+			items[i] = item
+		}
+		subject = map[string]interface{}{
+			"items": items,
+			"count": response.Count,
+		}
+	}
+
+	normalized, err := assertions.Normalize(subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare kubernetes result: %w", err)
+	}
+	return normalized, nil
+}
+
+func hasExitCodeAssertion(p map[string]interface{}) bool {
+	assertionList, _ := p["assertions"].([]interface{})
+	for _, assertion := range assertionList {
+		if assertionMap, ok := assertion.(map[string]interface{}); ok && assertionMap["type"] == AssertionTypeExitCode {
+			return true
+		}
+	}
+	return false
+}
+
+func lastLine(s string) string {
+	s = strings.TrimRight(s, "\n")
+	if s == "" {
+		return "(no stderr output)"
+	}
+	if i := strings.LastIndex(s, "\n"); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
+
+// processAssertions evaluates all assertions and returns their results.
+// Shared assertions without a path check the logs for logs, stdout for exec and the
+// whole result otherwise.
+func processAssertions(p map[string]interface{}, response *KubernetesResponse, subject interface{}, state map[string]interface{}, env map[string]string) ([]AssertionResult, error) {
+	context := dsl.TemplateContext{Runtime: state, Env: env}
+	return assertions.Process(p, subject, context, func(assertionMap map[string]interface{}, result *AssertionResult) bool {
+		return evaluateAssertion(response, subject, assertionMap, result)
+	})
+}
+
+// evaluateAssertion evaluates the kubernetes plugin's own assertion types
+func evaluateAssertion(response *KubernetesResponse, subject interface{}, assertionMap map[string]interface{}, result *AssertionResult) bool {
+	expected := result.Expected
+	switch result.Type {
+	case AssertionTypeExitCode:
+		if response.Action != ActionExec {
+			result.Message = "exit_code assertions are only supported with action exec"
+			break
+		}
+		result.Actual = response.ExitCode
+		if !assertions.Equal(float64(response.ExitCode), expected) {
+			result.Message = fmt.Sprintf("expected exit code %v, got %d", expected, response.ExitCode)
+			if response.Stderr != "" {
+				result.Message += ": " + lastLine(response.Stderr)
+			}
+		} else {
+			result.Passed = true
+		}
+
+	default:
+		_, hasPath := assertionMap["path"]
+		switch {
+		case hasPath:
+			*result = assertions.Evaluate(assertionMap, subject, expected)
+		case response.Action == ActionLogs:
+			*result = assertions.Evaluate(assertionMap, response.Logs, expected)
+		case response.Action == ActionExec:
+			*result = assertions.Evaluate(assertionMap, response.Stdout, expected)
+		default:
+			*result = assertions.Evaluate(assertionMap, subject, expected)
+		}
+	}
+	return true
+}
+
+// validateConfig checks the action has what it needs once templates are rendered
+func validateConfig(config *KubernetesConfig) error {
+	if config.Kubeconfig != "" && config.KubeconfigData != "" {
+		return fmt.Errorf("only one of kubeconfig or kubeconfig_data may be set")
+	}
+	if config.Name != "" && config.LabelSelector != "" {
+		return fmt.Errorf("only one of name or label_selector may be set")
+	}
+
+	switch config.Action {
+	case ActionGet:
+		if config.Resource == "" || config.Name == "" {
+			return fmt.Errorf("resource and name are required with action get")
+		}
+	case ActionList:
+		if config.Resource == "" {
+			return fmt.Errorf("resource is required with action list")
+		}
+	case ActionWait:
+		if config.Resource == "" {
+			return fmt.Errorf("resource is required with action wait")
+		}
+		if config.Name == "" && config.LabelSelector == "" {
+			return fmt.Errorf("one of name or label_selector is required with action wait")
+		}
+		switch config.For {
+		case WaitForRollout, WaitForReady, WaitForDeleted:
+		case WaitForCondition:
+			if config.Condition == "" {
+				return fmt.Errorf("condition is required with for: condition")
+			}
+		case "":
+			return fmt.Errorf("for is required with action wait")
+		default:
+			return fmt.Errorf("for must be rollout, ready, condition or deleted, got %q", config.For)
+		}
+		if config.Interval != "" {
+			if _, err := time.ParseDuration(config.Interval); err != nil {
+				return fmt.Errorf("invalid interval %q: %w", config.Interval, err)
+			}
+		}
+	case ActionLogs, ActionExec:
+		if config.Resource != "" && config.Resource != "pods" && config.Resource != "pod" && config.Resource != "po" {
+			return fmt.Errorf("action %s only supports pods, got resource %q", config.Action, config.Resource)
+		}
+		if config.Name == "" && config.LabelSelector == "" {
+			return fmt.Errorf("one of name or label_selector is required with action %s", config.Action)
+		}
+		if config.Action == ActionExec && len(config.Command) == 0 {
+			return fmt.Errorf("command is required with action exec")
+		}
+		if config.Since != "" {
+			if _, err := time.ParseDuration(config.Since); err != nil {
+				return fmt.Errorf("invalid since %q: %w", config.Since, err)
+			}
+		}
+	case "":
+		return fmt.Errorf("action is required")
+	default:
+		return fmt.Errorf("action must be get, list, wait, logs or exec, got %q", config.Action)
+	}
+
+	return nil
+}
+
+// applyVariableReplacement processes templates in the connection settings, the
+// resource selection and the command
+func applyVariableReplacement(config *KubernetesConfig, state map[string]interface{}, env map[string]string) error {
+	context := dsl.TemplateContext{
+		Runtime: state,
+		Env:     env,
+	}
+
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"kubeconfig", &config.Kubeconfig},
+		{"kubeconfig_data", &config.KubeconfigData},
+		{"context", &config.Context},
+		{"namespace", &config.Namespace},
+		{"resource", &config.Resource},
+		{"name", &config.Name},
+		{"label_selector", &config.LabelSelector},
+		{"field_selector", &config.FieldSelector},
+		{"condition", &config.Condition},
+		{"container", &config.Container},
+		{"stdin", &config.Stdin},
+	}
+	for i := range config.Command {
+		fields = append(fields, struct {
+			name  string
+			value *string
+		}{fmt.Sprintf("command[%d]", i), &config.Command[i]})
+	}
+	for _, field := range fields {
+		if *field.value == "" {
+			continue
+		}
+		processed, err := dsl.ProcessTemplate(*field.value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process %s template: %w", field.name, err)
+		}
+		*field.value = processed
+	}
+
+	return nil
+}
+
+// parseConfig converts map[string]interface{} to KubernetesConfig
+func parseConfig(configData map[string]interface{}, config *KubernetesConfig) error {
+	stringFields := map[string]*string{
+		"action":          &config.Action,
+		"kubeconfig":      &config.Kubeconfig,
+		"kubeconfig_data": &config.KubeconfigData,
+		"context":         &config.Context,
+		"namespace":       &config.Namespace,
+		"resource":        &config.Resource,
+		"api_version":     &config.APIVersion,
+		"name":            &config.Name,
+		"label_selector":  &config.LabelSelector,
+		"field_selector":  &config.FieldSelector,
+		"for":             &config.For,
+		"condition":       &config.Condition,
+		"interval":        &config.Interval,
+		"container":       &config.Container,
+		"since":           &config.Since,
+		"stdin":           &config.Stdin,
+		"timeout":         &config.Timeout,
+	}
+	for key, target := range stringFields {
+		if v, ok := configData[key].(string); ok {
+			*target = v
+		}
+	}
+
+	switch tail := configData["tail_lines"].(type) {
+	case nil:
+	case float64:
+		config.TailLines = int64(tail)
+	case int:
+		config.TailLines = int64(tail)
+	default:
+		return fmt.Errorf("tail_lines must be a number, got %T", tail)
+	}
+	if config.TailLines < 0 {
+		return fmt.Errorf("tail_lines must not be negative")
+	}
+
+	if previous, ok := configData["previous"].(bool); ok {
+		config.Previous = previous
+	}
+
+	switch command := configData["command"].(type) {
+	case nil:
+	case string:
+		config.Command = []string{command}
+	case []interface{}:
+		for i, arg := range command {
+			s, ok := arg.(string)
+			if !ok {
+				return fmt.Errorf("command[%d] must be a string", i)
+			}
+			config.Command = append(config.Command, s)
+		}
+	default:
+		return fmt.Errorf("command must be a string or a list of strings")
+	}
+
+	return nil
+}
@@ -0,0 +1,367 @@
+package kubernetes
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func deployment(name string, generation, observed, updated, available int64) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":       name,
+			"namespace":  "shop",
+			"generation": generation,
+			"labels":     map[string]interface{}{"app": name},
+		},
+		"spec": map[string]interface{}{"replicas": int64(2)},
+		"status": map[string]interface{}{
+			"observedGeneration": observed,
+			"replicas":           updated,
+			"updatedReplicas":    updated,
+			"availableReplicas":  available,
+		},
+	}}
+}
+
+func newTestCluster(objects ...runtime.Object) *cluster {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		{Group: "apps", Version: "v1", Resource: "deployments"}: "DeploymentList",
+		{Version: "v1", Resource: "namespaces"}:                 "NamespaceList",
+	}, objects...)
+
+	return &cluster{dynamic: dynamicClient, mapper: mapper, namespace: "shop"}
+}
+
+func TestResolve(t *testing.T) {
+	c := newTestCluster()
+
+	for _, resource := range []string{"deployments", "Deployment", "deployments.apps"} {
+		mapping, err := c.resolve(resource, "")
+		if err != nil {
+			t.Fatalf("resolve(%q): %v", resource, err)
+		}
+		if got := mapping.Resource.GroupResource().String(); got != "deployments.apps" {
+			t.Errorf("resolve(%q) = %s", resource, got)
+		}
+	}
+
+	mapping, err := c.resolve("namespace", "v1")
+	if err != nil {
+		t.Fatalf("resolve(namespace): %v", err)
+	}
+	if mapping.Scope.Name() != meta.RESTScopeNameRoot {
+		t.Errorf("expected namespaces to be cluster-scoped, got %s", mapping.Scope.Name())
+	}
+
+	if _, err := c.resolve("widgets", ""); err == nil || !strings.Contains(err.Error(), `unknown resource "widgets"`) {
+		t.Errorf("expected unknown resource error, got %v", err)
+	}
+}
+
+func TestResourceActions(t *testing.T) {
+	c := newTestCluster(deployment("api", 2, 2, 2, 2), deployment("worker", 3, 2, 1, 1))
+	ctx := context.Background()
+
+	response, err := execute(ctx, c, &KubernetesConfig{Action: ActionGet, Resource: "deployment", Name: "api"})
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if response.Resource != "deployments.apps" || response.Namespace != "shop" || response.Object["kind"] != "Deployment" {
+		t.Errorf("unexpected get response: %+v", response)
+	}
+
+	response, err = execute(ctx, c, &KubernetesConfig{Action: ActionList, Resource: "deployments", LabelSelector: "app=worker"})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if response.Count != 1 {
+		t.Fatalf("expected one deployment, got %d", response.Count)
+	}
+	subject, err := resultSubject(response)
+	if err != nil {
+		t.Fatalf("resultSubject: %v", err)
+	}
+	saved := map[string]string{}
+	p := map[string]interface{}{"save": []interface{}{
+		map[string]interface{}{"json_path": ".items[0].metadata.name", "as": "name"},
+		map[string]interface{}{"json_path": ".count", "as": "count"},
+	}}
+	if err := saves.Process(p, subject, saved); err != nil {
+		t.Fatalf("saves: %v", err)
+	}
+	if saved["name"] != "worker" || saved["count"] != "1" {
+		t.Errorf("unexpected saves: %v", saved)
+	}
+
+	response, err = execute(ctx, c, &KubernetesConfig{Action: ActionWait, Resource: "deployments", Name: "api", For: WaitForRollout})
+	if err != nil {
+		t.Fatalf("wait for completed rollout: %v", err)
+	}
+	if response.Object == nil {
+		t.Error("expected wait to return the object")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = execute(ctx, c, &KubernetesConfig{Action: ActionWait, Resource: "deployments", Name: "worker", For: WaitForRollout, Interval: "10ms"})
+	if err == nil || !strings.Contains(err.Error(), "timed out waiting for deployments.apps/worker to roll out: waiting for the controller to observe the latest spec") {
+		t.Errorf("unexpected wait error: %v", err)
+	}
+}
+
+func TestWaitDeleted(t *testing.T) {
+	c := newTestCluster(deployment("api", 1, 1, 2, 2))
+	mapping, err := c.resolve("deployments", "")
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	client := c.resourceClient(mapping, "shop")
+
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		_ = client.Delete(context.Background(), "api", metav1.DeleteOptions{})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	response := &KubernetesResponse{Resource: "deployments.apps"}
+	if err := wait(ctx, client, &KubernetesConfig{LabelSelector: "app=api", For: WaitForDeleted}, 10*time.Millisecond, response); err != nil {
+		t.Fatalf("wait for deletion: %v", err)
+	}
+	if response.Count != 0 {
+		t.Errorf("expected no remaining items, got %d", response.Count)
+	}
+}
+
+func TestRolloutDone(t *testing.T) {
+	tests := []struct {
+		name    string
+		obj     map[string]interface{}
+		done    bool
+		status  string
+		wantErr string
+	}{
+		{
+			name: "deployment complete",
+			obj:  deployment("api", 1, 1, 2, 2).Object,
+			done: true,
+		},
+		{
+			name:   "deployment waiting for availability",
+			obj:    deployment("api", 1, 1, 2, 1).Object,
+			status: "1 of 2 updated replicas are available",
+		},
+		{
+			name: "deployment past its progress deadline",
+			obj: func() map[string]interface{} {
+				obj := deployment("api", 1, 1, 1, 1).Object
+				obj["status"].(map[string]interface{})["conditions"] = []interface{}{
+					map[string]interface{}{"type": "Progressing", "status": "False", "reason": "ProgressDeadlineExceeded"},
+				}
+				return obj
+			}(),
+			wantErr: "exceeded its progress deadline",
+		},
+		{
+			name: "statefulset on an old revision",
+			obj: map[string]interface{}{
+				"kind":     "StatefulSet",
+				"metadata": map[string]interface{}{"name": "db"},
+				"spec":     map[string]interface{}{"replicas": int64(3)},
+				"status": map[string]interface{}{
+					"readyReplicas": int64(3), "updatedReplicas": int64(1),
+					"currentRevision": "db-1", "updateRevision": "db-2",
+				},
+			},
+			status: "1 of 3 pods are on revision db-2",
+		},
+		{
+			name: "daemonset complete",
+			obj: map[string]interface{}{
+				"kind":     "DaemonSet",
+				"metadata": map[string]interface{}{"name": "agent"},
+				"status": map[string]interface{}{
+					"desiredNumberScheduled": int64(4), "updatedNumberScheduled": int64(4), "numberAvailable": int64(4),
+				},
+			},
+			done: true,
+		},
+		{
+			name:    "unsupported kind",
+			obj:     map[string]interface{}{"kind": "Job", "metadata": map[string]interface{}{"name": "migrate"}},
+			wantErr: "got Job",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done, status, err := rolloutDone(&unstructured.Unstructured{Object: tt.obj})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if done != tt.done || status != tt.status {
+				t.Errorf("got (%v, %q), want (%v, %q)", done, status, tt.done, tt.status)
+			}
+		})
+	}
+}
+
+func TestConditionDone(t *testing.T) {
+	pod := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "Ready", "status": "False", "message": "containers with unready status: [api]"},
+			map[string]interface{}{"type": "PodScheduled", "status": "True"},
+		}},
+	}}
+
+	if done, status, _ := conditionDone(pod, "Ready"); done || status != "Ready is False: containers with unready status: [api]" {
+		t.Errorf("unexpected Ready result: %v %q", done, status)
+	}
+	if done, _, _ := conditionDone(pod, "podscheduled"); !done {
+		t.Error("expected condition types to match case-insensitively")
+	}
+	if _, status, _ := conditionDone(pod, "Initialized"); status != "no Initialized condition yet" {
+		t.Errorf("unexpected missing condition status: %q", status)
+	}
+}
+
+func TestLogs(t *testing.T) {
+	pod := func(name string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop", Labels: map[string]string{"app": "api"}},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+	c := &cluster{
+		clientset: fake.NewClientset(pod("api-a", corev1.PodPending), pod("api-c", corev1.PodRunning), pod("api-b", corev1.PodRunning)),
+		namespace: "shop",
+	}
+
+	response, err := execute(context.Background(), c, &KubernetesConfig{Action: ActionLogs, LabelSelector: "app=api", Container: "api", TailLines: 100})
+	if err != nil {
+		t.Fatalf("logs: %v", err)
+	}
+	if response.Pod != "api-b" {
+		t.Errorf("expected the first running pod, got %s", response.Pod)
+	}
+
+	p := map[string]interface{}{"assertions": []interface{}{
+		map[string]interface{}{"type": "contains", "expected": "fake logs"},
+		map[string]interface{}{"type": "equals", "path": ".container", "expected": "api"},
+	}}
+	subject, err := resultSubject(response)
+	if err != nil {
+		t.Fatalf("resultSubject: %v", err)
+	}
+	if results, err := processAssertions(p, response, subject, map[string]interface{}{}, map[string]string{}); err != nil || assertions.Summary(results) != "" {
+		t.Errorf("unexpected assertion failure: %v (%+v)", err, results)
+	}
+
+	if _, err := execute(context.Background(), c, &KubernetesConfig{Action: ActionLogs, LabelSelector: "app=web"}); err == nil || !strings.Contains(err.Error(), "no pods in namespace shop") {
+		t.Errorf("expected no matching pods error, got %v", err)
+	}
+}
+
+func TestExecAssertions(t *testing.T) {
+	response := &KubernetesResponse{Action: ActionExec, Pod: "api-0", Stdout: `{"migrations":"up to date"}`, Stderr: "warn: slow disk\n", ExitCode: 3}
+	subject, err := resultSubject(response)
+	if err != nil {
+		t.Fatalf("resultSubject: %v", err)
+	}
+
+	p := map[string]interface{}{
+		"assertions": []interface{}{
+			map[string]interface{}{"type": "exit_code", "expected": float64(3)},
+			map[string]interface{}{"type": "contains", "expected": "up to date"},
+			map[string]interface{}{"type": "equals", "path": ".json.migrations", "expected": "up to date"},
+		},
+	}
+	if !hasExitCodeAssertion(p) {
+		t.Error("expected exit_code assertion to be detected")
+	}
+	if results, err := processAssertions(p, response, subject, map[string]interface{}{}, map[string]string{}); err != nil || assertions.Summary(results) != "" {
+		t.Fatalf("unexpected assertion failure: %v (%+v)", err, results)
+	}
+
+	results, err := processAssertions(map[string]interface{}{
+		"assertions": []interface{}{map[string]interface{}{"type": "exit_code", "expected": float64(0)}},
+	}, response, subject, map[string]interface{}{}, map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	failure := assertions.Summary(results)
+	if !strings.Contains(failure, "expected exit code 0, got 3: warn: slow disk") {
+		t.Errorf("unexpected failure message: %q", failure)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	valid := []KubernetesConfig{
+		{Action: ActionGet, Resource: "deployments", Name: "api"},
+		{Action: ActionList, Resource: "pods", LabelSelector: "app=api"},
+		{Action: ActionWait, Resource: "deployments", Name: "api", For: WaitForRollout},
+		{Action: ActionWait, Resource: "jobs", Name: "migrate", For: WaitForCondition, Condition: "Complete"},
+		{Action: ActionLogs, LabelSelector: "app=api", Since: "10m"},
+		{Action: ActionExec, Name: "api-0", Command: []string{"sh", "-c", "echo ok"}},
+	}
+	for _, config := range valid {
+		if err := validateConfig(&config); err != nil {
+			t.Errorf("expected %+v to be valid, got %v", config, err)
+		}
+	}
+
+	invalid := map[string]KubernetesConfig{
+		"missing action":      {Resource: "pods"},
+		"get without name":    {Action: ActionGet, Resource: "pods"},
+		"wait without for":    {Action: ActionWait, Resource: "pods", Name: "api-0"},
+		"condition missing":   {Action: ActionWait, Resource: "jobs", Name: "migrate", For: WaitForCondition},
+		"name and selector":   {Action: ActionList, Resource: "pods", Name: "api-0", LabelSelector: "app=api"},
+		"exec without cmd":    {Action: ActionExec, Name: "api-0"},
+		"logs of deployment":  {Action: ActionLogs, Resource: "deployments", Name: "api"},
+		"two kubeconfigs":     {Action: ActionList, Resource: "pods", Kubeconfig: "/tmp/kc", KubeconfigData: "apiVersion: v1"},
+		"invalid since":       {Action: ActionLogs, Name: "api-0", Since: "yesterday"},
+		"unknown wait target": {Action: ActionWait, Resource: "pods", Name: "api-0", For: "healthy"},
+	}
+	for name, config := range invalid {
+		if err := validateConfig(&config); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	config := &KubernetesConfig{}
+	if err := parseConfig(map[string]interface{}{"action": "exec", "command": []interface{}{"cat", "/etc/hostname"}, "tail_lines": float64(20)}, config); err != nil {
+		t.Fatalf("parseConfig: %v", err)
+	}
+	if len(config.Command) != 2 || config.TailLines != 20 {
+		t.Errorf("unexpected config: %+v", config)
+	}
+	if err := parseConfig(map[string]interface{}{"command": []interface{}{"ls", float64(1)}}, &KubernetesConfig{}); err == nil {
+		t.Error("expected non-string command argument to be rejected")
+	}
+}
@@ -0,0 +1,94 @@
+package kubernetes
+
+import "github.com/rocketship-ai/rocketship/internal/assertions"
+
+// KubernetesPlugin represents a Kubernetes test step
+type KubernetesPlugin struct {
+	Name   string           `json:"name" yaml:"name"`
+	Plugin string           `json:"plugin" yaml:"plugin"`
+	Config KubernetesConfig `json:"config" yaml:"config"`
+}
+
+// KubernetesConfig selects the cluster, the action and the resources it applies to
+type KubernetesConfig struct {
+	Action string `json:"action" yaml:"action"` // get, list, wait, logs or exec
+
+	// Cluster connection. Without kubeconfig or kubeconfig_data, $KUBECONFIG and
+	// ~/.kube/config are tried, then the in-cluster service account.
+	Kubeconfig     string `json:"kubeconfig,omitempty" yaml:"kubeconfig,omitempty"`           // Path on the worker
+	KubeconfigData string `json:"kubeconfig_data,omitempty" yaml:"kubeconfig_data,omitempty"` // Kubeconfig contents
+	Context        string `json:"context,omitempty" yaml:"context,omitempty"`
+	Namespace      string `json:"namespace,omitempty" yaml:"namespace,omitempty"` // Defaults to the context's namespace, then "default"
+
+	// Resource selection
+	Resource      string `json:"resource,omitempty" yaml:"resource,omitempty"`       // Plural, singular or short name, e.g. "deployments", "deploy", "certificates.cert-manager.io"
+	APIVersion    string `json:"api_version,omitempty" yaml:"api_version,omitempty"` // e.g. "apps/v1"; the preferred version by default
+	Name          string `json:"name,omitempty" yaml:"name,omitempty"`
+	LabelSelector string `json:"label_selector,omitempty" yaml:"label_selector,omitempty"`
+	FieldSelector string `json:"field_selector,omitempty" yaml:"field_selector,omitempty"`
+
+	// wait
+	For       string `json:"for,omitempty" yaml:"for,omitempty"`             // rollout, ready, condition or deleted
+	Condition string `json:"condition,omitempty" yaml:"condition,omitempty"` // Condition type with for: condition, e.g. "Available"
+	Interval  string `json:"interval,omitempty" yaml:"interval,omitempty"`   // Poll interval (defaults to 2s)
+
+	// logs and exec
+	Container string   `json:"container,omitempty" yaml:"container,omitempty"` // Defaults to the pod's only or default container
+	TailLines int64    `json:"tail_lines,omitempty" yaml:"tail_lines,omitempty"`
+	Since     string   `json:"since,omitempty" yaml:"since,omitempty"` // Only logs newer than this, e.g. "10m"
+	Previous  bool     `json:"previous,omitempty" yaml:"previous,omitempty"`
+	Command   []string `json:"command,omitempty" yaml:"command,omitempty"`
+	Stdin     string   `json:"stdin,omitempty" yaml:"stdin,omitempty"`
+
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"` // Overall step timeout (defaults to 60s, 5m for wait)
+}
+
+// Actions supported by the kubernetes plugin
+const (
+	ActionGet  = "get"
+	ActionList = "list"
+	ActionWait = "wait"
+	ActionLogs = "logs"
+	ActionExec = "exec"
+)
+
+// Wait targets
+const (
+	WaitForRollout   = "rollout"
+	WaitForReady     = "ready"
+	WaitForCondition = "condition"
+	WaitForDeleted   = "deleted"
+)
+
+// Assertion types supported by the kubernetes plugin in addition to the shared ones
+const (
+	AssertionTypeExitCode = "exit_code"
+)
+
+// KubernetesResponse contains the result of the action. Which fields are set
+// depends on the action.
+type KubernetesResponse struct {
+	Action    string                   `json:"action"`
+	Resource  string                   `json:"resource,omitempty"` // Resolved resource, e.g. "deployments.apps"
+	Namespace string                   `json:"namespace,omitempty"`
+	Object    map[string]interface{}   `json:"object,omitempty"` // get, and wait on a named resource
+	Items     []map[string]interface{} `json:"items,omitempty"`  // list, and wait with a label selector
+	Count     int                      `json:"count"`
+	Pod       string                   `json:"pod,omitempty"` // logs and exec
+	Container string                   `json:"container,omitempty"`
+	Logs      string                   `json:"logs,omitempty"`
+	Stdout    string                   `json:"stdout,omitempty"`
+	Stderr    string                   `json:"stderr,omitempty"`
+	ExitCode  int                      `json:"exit_code"`
+	Duration  string                   `json:"duration"`
+}
+
+// AssertionResult represents a single assertion result for UI display
+type AssertionResult = assertions.Result
+
+// ActivityResponse represents the complete activity response
+type ActivityResponse struct {
+	Response         *KubernetesResponse `json:"response"`
+	Saved            map[string]string   `json:"saved"`
+	AssertionResults []AssertionResult   `json:"assertion_results,omitempty"`
+}
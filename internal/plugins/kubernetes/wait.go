@@ -0,0 +1,220 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// wait polls until the named resource, or every resource matching the label
+// selector, reaches the configured state. Resources that don't exist yet are waited
+// for too, so a step can run right after the deploy that creates them.
+func wait(ctx context.Context, client dynamic.ResourceInterface, config *KubernetesConfig, interval time.Duration, response *KubernetesResponse) error {
+	target := response.Resource + "/" + config.Name
+	if config.Name == "" {
+		target = fmt.Sprintf("%s matching %q", response.Resource, config.LabelSelector)
+	}
+
+	status := "not checked yet"
+	for {
+		done, current, err := checkWait(ctx, client, config, response)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			return fmt.Errorf("waiting for %s: %w", target, err)
+		case err == nil && done:
+			return nil
+		case err == nil:
+			status = current
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for %s %s: %s", target, waitDescription(config), status)
+		case <-time.After(interval):
+		}
+	}
+}
+
+// checkWait fetches the resources once, records them in response and reports whether
+// the wait is over, or else what it is still waiting on
+func checkWait(ctx context.Context, client dynamic.ResourceInterface, config *KubernetesConfig, response *KubernetesResponse) (bool, string, error) {
+	if config.Name != "" {
+		obj, err := client.Get(ctx, config.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			response.Object, response.Count = nil, 0
+			if config.For == WaitForDeleted {
+				return true, "", nil
+			}
+			return false, "not found", nil
+		}
+		if err != nil {
+			return false, "", err
+		}
+		response.Object, response.Count = obj.Object, 1
+		if config.For == WaitForDeleted {
+			return false, "still exists", nil
+		}
+		return objectDone(obj, config)
+	}
+
+	list, err := client.List(ctx, metav1.ListOptions{LabelSelector: config.LabelSelector, FieldSelector: config.FieldSelector})
+	if err != nil {
+		return false, "", err
+	}
+	response.Items = make([]map[string]interface{}, 0, len(list.Items))
+	for _, item := range list.Items {
+		response.Items = append(response.Items, item.Object)
+	}
+	response.Count = len(response.Items)
+
+	if config.For == WaitForDeleted {
+		if response.Count == 0 {
+			return true, "", nil
+		}
+		return false, fmt.Sprintf("%d still exist", response.Count), nil
+	}
+	if response.Count == 0 {
+		return false, "no matching resources", nil
+	}
+	for i := range list.Items {
+		done, status, err := objectDone(&list.Items[i], config)
+		if err != nil || !done {
+			if status != "" {
+				status = list.Items[i].GetName() + ": " + status
+			}
+			return false, status, err
+		}
+	}
+	return true, "", nil
+}
+
+func waitDescription(config *KubernetesConfig) string {
+	switch config.For {
+	case WaitForRollout:
+		return "to roll out"
+	case WaitForReady:
+		return "to be ready"
+	case WaitForDeleted:
+		return "to be deleted"
+	default:
+		return "to have condition " + config.Condition
+	}
+}
+
+// objectDone reports whether a single object has reached the wait target
+func objectDone(obj *unstructured.Unstructured, config *KubernetesConfig) (bool, string, error) {
+	switch config.For {
+	case WaitForRollout:
+		return rolloutDone(obj)
+	case WaitForReady:
+		return conditionDone(obj, "Ready")
+	default:
+		return conditionDone(obj, config.Condition)
+	}
+}
+
+// conditionDone reports whether status.conditions has conditionType with status True
+func conditionDone(obj *unstructured.Unstructured, conditionType string) (bool, string, error) {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if t, _ := condition["type"].(string); !strings.EqualFold(t, conditionType) {
+			continue
+		}
+		if status, _ := condition["status"].(string); status == string(metav1.ConditionTrue) {
+			return true, "", nil
+		}
+		status := fmt.Sprintf("%s is %v", conditionType, condition["status"])
+		if message, _ := condition["message"].(string); message != "" {
+			status += ": " + message
+		}
+		return false, status, nil
+	}
+	return false, fmt.Sprintf("no %s condition yet", conditionType), nil
+}
+
+// rolloutDone follows the checks kubectl rollout status makes for Deployments,
+// StatefulSets and DaemonSets
+func rolloutDone(obj *unstructured.Unstructured) (bool, string, error) {
+	kind := obj.GetKind()
+	generation := obj.GetGeneration()
+	observed, _, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if observed < generation {
+		return false, "waiting for the controller to observe the latest spec", nil
+	}
+
+	status := func(field string) int64 {
+		v, _, _ := unstructured.NestedInt64(obj.Object, "status", field)
+		return v
+	}
+	desired, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if !found {
+		desired = 1
+	}
+
+	switch kind {
+	case "Deployment":
+		conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+		for _, c := range conditions {
+			if condition, ok := c.(map[string]interface{}); ok && condition["type"] == "Progressing" && condition["reason"] == "ProgressDeadlineExceeded" {
+				return false, "", fmt.Errorf("deployment %s exceeded its progress deadline", obj.GetName())
+			}
+		}
+		updated := status("updatedReplicas")
+		switch {
+		case updated < desired:
+			return false, fmt.Sprintf("%d of %d new replicas have been updated", updated, desired), nil
+		case status("replicas") > updated:
+			return false, fmt.Sprintf("%d old replicas are pending termination", status("replicas")-updated), nil
+		case status("availableReplicas") < updated:
+			return false, fmt.Sprintf("%d of %d updated replicas are available", status("availableReplicas"), updated), nil
+		}
+		return true, "", nil
+
+	case "StatefulSet":
+		if strategy, _, _ := unstructured.NestedString(obj.Object, "spec", "updateStrategy", "type"); strategy == "OnDelete" {
+			return false, "", fmt.Errorf("rollout of statefulset %s can't be tracked with the OnDelete update strategy", obj.GetName())
+		}
+		ready := status("readyReplicas")
+		if ready < desired {
+			return false, fmt.Sprintf("%d of %d pods are ready", ready, desired), nil
+		}
+		partition, hasPartition, _ := unstructured.NestedInt64(obj.Object, "spec", "updateStrategy", "rollingUpdate", "partition")
+		if hasPartition && partition > 0 {
+			if updated := status("updatedReplicas"); updated < desired-partition {
+				return false, fmt.Sprintf("%d of %d pods updated past partition %d", updated, desired-partition, partition), nil
+			}
+			return true, "", nil
+		}
+		current, _, _ := unstructured.NestedString(obj.Object, "status", "currentRevision")
+		update, _, _ := unstructured.NestedString(obj.Object, "status", "updateRevision")
+		if current != update {
+			return false, fmt.Sprintf("%d of %d pods are on revision %s", status("updatedReplicas"), desired, update), nil
+		}
+		return true, "", nil
+
+	case "DaemonSet":
+		if strategy, _, _ := unstructured.NestedString(obj.Object, "spec", "updateStrategy", "type"); strategy == "OnDelete" {
+			return false, "", fmt.Errorf("rollout of daemonset %s can't be tracked with the OnDelete update strategy", obj.GetName())
+		}
+		scheduled := status("desiredNumberScheduled")
+		switch {
+		case status("updatedNumberScheduled") < scheduled:
+			return false, fmt.Sprintf("%d of %d updated pods have been scheduled", status("updatedNumberScheduled"), scheduled), nil
+		case status("numberAvailable") < scheduled:
+			return false, fmt.Sprintf("%d of %d updated pods are available", status("numberAvailable"), scheduled), nil
+		}
+		return true, "", nil
+	}
+
+	return false, "", fmt.Errorf("rollout can only be waited for on deployments, statefulsets and daemonsets, got %s", kind)
+}
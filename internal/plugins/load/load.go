@@ -0,0 +1,494 @@
+package load
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+	"go.temporal.io/sdk/activity"
+)
+
+const (
+	defaultMaxConcurrency = 100
+	defaultRequestTimeout = 10 * time.Second
+	// timeoutMargin is added to duration for the default step timeout
+	timeoutMargin = time.Minute
+
+	maxRPS = 10000
+	maxVUs = 10000
+)
+
+// Auto-register the plugin when the package is imported
+func init() {
+	plugins.RegisterPlugin(&LoadPlugin{})
+}
+
+// GetType returns the plugin type identifier
+func (lp *LoadPlugin) GetType() string {
+	return "load"
+}
+
+// Activity sends the request at the configured load and fails when the
+// results break a threshold
+func (lp *LoadPlugin) Activity(ctx context.Context, p map[string]interface{}) (interface{}, error) {
+	logger := activity.GetLogger(ctx)
+
+	// Parse configuration from parameters
+	configData, ok := p["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid config format")
+	}
+
+	config := &LoadConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		return nil, fmt.Errorf("failed to parse load config: %w", err)
+	}
+
+	// Convert state to map[string]interface{} for template processing
+	state := make(map[string]interface{})
+	if stateStr, ok := p["state"].(map[string]string); ok {
+		for k, v := range stateStr {
+			state[k] = v
+		}
+	} else if stateInt, ok := p["state"].(map[string]interface{}); ok {
+		state = stateInt
+	}
+
+	// Extract env secrets from params (for {{ .env.* }} template resolution)
+	env := make(map[string]string)
+	if envData, ok := p["env"].(map[string]interface{}); ok {
+		for k, v := range envData {
+			if strVal, ok := v.(string); ok {
+				env[k] = strVal
+			}
+		}
+	} else if envData, ok := p["env"].(map[string]string); ok {
+		env = envData
+	}
+
+	if err := applyVariableReplacement(config, state, env); err != nil {
+		return nil, fmt.Errorf("variable replacement failed: %w", err)
+	}
+
+	pl, err := buildPlan(config)
+	if err != nil {
+		return nil, err
+	}
+	thresholds, err := parseThresholds(config.Thresholds)
+	if err != nil {
+		return nil, err
+	}
+
+	requestTimeout := defaultRequestTimeout
+	if config.RequestTimeout != "" {
+		if requestTimeout, err = time.ParseDuration(config.RequestTimeout); err != nil {
+			return nil, fmt.Errorf("invalid request_timeout %q: %w", config.RequestTimeout, err)
+		}
+	}
+	timeout := pl.duration + requestTimeout + timeoutMargin
+	if config.Timeout != "" {
+		if timeout, err = time.ParseDuration(config.Timeout); err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", config.Timeout, err)
+		}
+	}
+
+	policy, err := egress.FromParams(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
+
+	logger.Info("Executing load plugin", "method", pl.method, "url", pl.url, "rps", pl.rps, "vus", pl.vus, "duration", pl.duration)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := &http.Client{Transport: tuneTransport(policy.Transport(), pl), Timeout: requestTimeout}
+	response := run(ctx, client, pl, func(requests, errors int) {
+		activity.RecordHeartbeat(ctx, fmt.Sprintf("%d requests, %d errors", requests, errors))
+	})
+	if response.Requests == 0 {
+		return nil, fmt.Errorf("no requests completed to %s in %s", pl.url, pl.duration)
+	}
+
+	if failed := checkThresholds(thresholds, response); len(failed) > 0 {
+		return nil, fmt.Errorf("load thresholds failed for %s %s: %s", pl.method, pl.url, strings.Join(failed, ", "))
+	}
+
+	subject, err := assertions.Normalize(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare load result: %w", err)
+	}
+
+	assertionResults, err := assertions.Process(p, subject, dsl.TemplateContext{Runtime: state, Env: env}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if failure := assertions.Summary(assertionResults); failure != "" {
+		return nil, fmt.Errorf("%s", failure)
+	}
+
+	saved := make(map[string]string)
+	if err := saves.Process(p, subject, saved); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Load step completed", "requests", response.Requests, "rps", response.RPS, "p95_ms", response.Latency.P95, "error_rate", response.ErrorRate)
+
+	return &ActivityResponse{
+		Response:         response,
+		Saved:            saved,
+		AssertionResults: assertionResults,
+	}, nil
+}
+
+// tuneTransport keeps enough idle connections for the load, so requests reuse
+// connections instead of opening new ones
+func tuneTransport(rt http.RoundTripper, p *plan) http.RoundTripper {
+	transport, ok := rt.(*http.Transport)
+	if !ok {
+		return rt
+	}
+	transport = transport.Clone()
+	connections := p.vus
+	if connections == 0 {
+		connections = p.maxConcurrency
+	}
+	transport.MaxIdleConns = connections
+	transport.MaxIdleConnsPerHost = connections
+	return transport
+}
+
+// buildPlan validates the config once templates are rendered
+func buildPlan(config *LoadConfig) (*plan, error) {
+	request := config.Request
+	u, err := url.Parse(request.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("request.url must be an http(s) URL, got %q", request.URL)
+	}
+	method := strings.ToUpper(request.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	if (config.RPS > 0) == (config.VUs > 0) {
+		return nil, fmt.Errorf("set one of rps or vus")
+	}
+	if config.RPS < 0 || config.RPS > maxRPS {
+		return nil, fmt.Errorf("rps must be between 0 and %d, got %v", maxRPS, config.RPS)
+	}
+	if config.VUs < 0 || config.VUs > maxVUs {
+		return nil, fmt.Errorf("vus must be between 0 and %d, got %d", maxVUs, config.VUs)
+	}
+	if config.Duration == "" {
+		return nil, fmt.Errorf("duration is required")
+	}
+	duration, err := time.ParseDuration(config.Duration)
+	if err != nil || duration <= 0 {
+		return nil, fmt.Errorf("duration must be a positive duration, got %q", config.Duration)
+	}
+	maxConcurrency := config.MaxConcurrency
+	if maxConcurrency == 0 {
+		maxConcurrency = defaultMaxConcurrency
+	}
+	if maxConcurrency < 0 || maxConcurrency > maxVUs {
+		return nil, fmt.Errorf("max_concurrency must be between 1 and %d, got %d", maxVUs, maxConcurrency)
+	}
+
+	success := make(map[int]bool, len(config.SuccessStatus))
+	for _, status := range config.SuccessStatus {
+		if status < 100 || status > 599 {
+			return nil, fmt.Errorf("success_status must contain HTTP statuses, got %d", status)
+		}
+		success[status] = true
+	}
+
+	return &plan{
+		method:         method,
+		url:            request.URL,
+		headers:        request.Headers,
+		body:           request.Body,
+		rps:            config.RPS,
+		vus:            config.VUs,
+		duration:       duration,
+		maxConcurrency: maxConcurrency,
+		success:        success,
+	}, nil
+}
+
+// threshold is a limit on one result
+type threshold struct {
+	name  string
+	limit float64
+	value func(*LoadResponse) float64
+	// below means the value must not go under the limit, rather than over it
+	below  bool
+	format func(float64) string
+}
+
+func formatMillis(v float64) string {
+	return time.Duration(v * float64(time.Millisecond)).Round(time.Millisecond / 10).String()
+}
+
+func formatPercent(v float64) string {
+	return strconv.FormatFloat(v*100, 'f', 2, 64) + "%"
+}
+
+func formatRate(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64) + "/s"
+}
+
+// parseThresholds converts the configured limits, in a fixed order
+func parseThresholds(config *ThresholdConfig) ([]threshold, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	var thresholds []threshold
+	latencies := []struct {
+		name  string
+		limit string
+		value func(*LoadResponse) float64
+	}{
+		{"p50", config.P50, func(r *LoadResponse) float64 { return r.Latency.P50 }},
+		{"p90", config.P90, func(r *LoadResponse) float64 { return r.Latency.P90 }},
+		{"p95", config.P95, func(r *LoadResponse) float64 { return r.Latency.P95 }},
+		{"p99", config.P99, func(r *LoadResponse) float64 { return r.Latency.P99 }},
+		{"max", config.Max, func(r *LoadResponse) float64 { return r.Latency.Max }},
+		{"mean", config.Mean, func(r *LoadResponse) float64 { return r.Latency.Mean }},
+	}
+	for _, l := range latencies {
+		if l.limit == "" {
+			continue
+		}
+		d, err := time.ParseDuration(l.limit)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("thresholds.%s must be a positive duration, got %q", l.name, l.limit)
+		}
+		thresholds = append(thresholds, threshold{name: l.name, limit: millis(d), value: l.value, format: formatMillis})
+	}
+
+	if config.ErrorRate != "" {
+		raw := strings.TrimSpace(config.ErrorRate)
+		percent := strings.HasSuffix(raw, "%")
+		rate, err := strconv.ParseFloat(strings.TrimSuffix(raw, "%"), 64)
+		if percent {
+			rate /= 100
+		}
+		if err != nil || rate < 0 || rate > 1 {
+			return nil, fmt.Errorf("thresholds.error_rate must be a ratio between 0 and 1 or a percentage, got %q", config.ErrorRate)
+		}
+		thresholds = append(thresholds, threshold{name: "error_rate", limit: rate, value: func(r *LoadResponse) float64 { return r.ErrorRate }, format: formatPercent})
+	}
+
+	if config.MinRPS != nil {
+		if *config.MinRPS <= 0 {
+			return nil, fmt.Errorf("thresholds.min_rps must be positive, got %v", *config.MinRPS)
+		}
+		thresholds = append(thresholds, threshold{name: "rps", limit: *config.MinRPS, value: func(r *LoadResponse) float64 { return r.RPS }, below: true, format: formatRate})
+	}
+
+	return thresholds, nil
+}
+
+// checkThresholds describes each threshold the response breaks
+func checkThresholds(thresholds []threshold, response *LoadResponse) []string {
+	var failed []string
+	for _, t := range thresholds {
+		value := t.value(response)
+		switch {
+		case t.below && value < t.limit:
+			failed = append(failed, fmt.Sprintf("%s %s < %s", t.name, t.format(value), t.format(t.limit)))
+		case !t.below && value > t.limit:
+			failed = append(failed, fmt.Sprintf("%s %s > %s", t.name, t.format(value), t.format(t.limit)))
+		}
+	}
+	return failed
+}
+
+// applyVariableReplacement processes templates in the request
+func applyVariableReplacement(config *LoadConfig, state map[string]interface{}, env map[string]string) error {
+	context := dsl.TemplateContext{
+		Runtime: state,
+		Env:     env,
+	}
+
+	request := &config.Request
+	fields := map[string]*string{
+		"request.method": &request.Method,
+		"request.url":    &request.URL,
+		"request.body":   &request.Body,
+	}
+	for name, value := range fields {
+		if *value == "" {
+			continue
+		}
+		processed, err := dsl.ProcessTemplate(*value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process %s template: %w", name, err)
+		}
+		*value = processed
+	}
+	for name, value := range request.Headers {
+		processed, err := dsl.ProcessTemplate(value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process header %s template: %w", name, err)
+		}
+		request.Headers[name] = processed
+	}
+
+	return nil
+}
+
+// parseConfig converts map[string]interface{} to LoadConfig
+func parseConfig(configData map[string]interface{}, config *LoadConfig) error {
+	requestData, ok := configData["request"].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("request is required")
+	}
+	config.Request.Method, _ = requestData["method"].(string)
+	config.Request.URL, _ = requestData["url"].(string)
+	if headers, ok := requestData["headers"].(map[string]interface{}); ok {
+		config.Request.Headers = make(map[string]string, len(headers))
+		for name, value := range headers {
+			config.Request.Headers[name] = fmt.Sprint(value)
+		}
+	}
+	switch body := requestData["body"].(type) {
+	case nil:
+	case string:
+		config.Request.Body = body
+	case map[string]interface{}, []interface{}:
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request.body: %w", err)
+		}
+		config.Request.Body = string(encoded)
+		if _, set := lookupHeader(config.Request.Headers, "Content-Type"); !set {
+			if config.Request.Headers == nil {
+				config.Request.Headers = map[string]string{}
+			}
+			config.Request.Headers["Content-Type"] = "application/json"
+		}
+	default:
+		return fmt.Errorf("request.body must be a string or an object, got %T", body)
+	}
+
+	var err error
+	if config.RPS, err = numberField(configData, "rps"); err != nil {
+		return err
+	}
+	vus, err := numberField(configData, "vus")
+	if err != nil {
+		return err
+	}
+	config.VUs = int(vus)
+	maxConcurrency, err := numberField(configData, "max_concurrency")
+	if err != nil {
+		return err
+	}
+	config.MaxConcurrency = int(maxConcurrency)
+
+	config.Duration, _ = configData["duration"].(string)
+	config.RequestTimeout, _ = configData["request_timeout"].(string)
+	config.Timeout, _ = configData["timeout"].(string)
+
+	switch statuses := configData["success_status"].(type) {
+	case nil:
+	case []interface{}:
+		for i, status := range statuses {
+			switch s := status.(type) {
+			case float64:
+				config.SuccessStatus = append(config.SuccessStatus, int(s))
+			case int:
+				config.SuccessStatus = append(config.SuccessStatus, s)
+			default:
+				return fmt.Errorf("success_status[%d] must be a number, got %T", i, status)
+			}
+		}
+	default:
+		return fmt.Errorf("success_status must be a list, got %T", statuses)
+	}
+
+	switch t := configData["thresholds"].(type) {
+	case nil:
+	case map[string]interface{}:
+		config.Thresholds = &ThresholdConfig{}
+		durations := map[string]*string{
+			"p50":  &config.Thresholds.P50,
+			"p90":  &config.Thresholds.P90,
+			"p95":  &config.Thresholds.P95,
+			"p99":  &config.Thresholds.P99,
+			"max":  &config.Thresholds.Max,
+			"mean": &config.Thresholds.Mean,
+		}
+		for key, target := range durations {
+			switch v := t[key].(type) {
+			case nil:
+			case string:
+				*target = v
+			case float64, int:
+				// Bare numbers are milliseconds
+				*target = fmt.Sprintf("%vms", v)
+			default:
+				return fmt.Errorf("thresholds.%s must be a duration, got %T", key, v)
+			}
+		}
+		switch v := t["error_rate"].(type) {
+		case nil:
+		case string:
+			config.Thresholds.ErrorRate = v
+		case float64, int:
+			config.Thresholds.ErrorRate = fmt.Sprint(v)
+		default:
+			return fmt.Errorf("thresholds.error_rate must be a number or a percentage, got %T", v)
+		}
+		if _, set := t["min_rps"]; set {
+			minRPS, err := numberField(t, "min_rps")
+			if err != nil {
+				return fmt.Errorf("thresholds.%w", err)
+			}
+			config.Thresholds.MinRPS = &minRPS
+		}
+		for key := range t {
+			if _, known := durations[key]; !known && key != "error_rate" && key != "min_rps" {
+				return fmt.Errorf("unknown threshold %q (expected p50, p90, p95, p99, max, mean, error_rate or min_rps)", key)
+			}
+		}
+	default:
+		return fmt.Errorf("thresholds must be an object, got %T", t)
+	}
+
+	return nil
+}
+
+func numberField(data map[string]interface{}, key string) (float64, error) {
+	switch v := data[key].(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	default:
+		return 0, fmt.Errorf("%s must be a number, got %T", key, v)
+	}
+}
+
+func lookupHeader(headers map[string]string, name string) (string, bool) {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,230 @@
+package load
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testPlan(t *testing.T, configData map[string]interface{}) *plan {
+	t.Helper()
+	config := &LoadConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		t.Fatalf("parseConfig() error = %v", err)
+	}
+	p, err := buildPlan(config)
+	if err != nil {
+		t.Fatalf("buildPlan() error = %v", err)
+	}
+	return p
+}
+
+func TestRunAtRate(t *testing.T) {
+	var count atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := count.Add(1)
+		if r.Header.Get("Content-Type") != "application/json" || r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		// Every tenth request fails
+		if n%10 == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	p := testPlan(t, map[string]interface{}{
+		"request":  map[string]interface{}{"method": "post", "url": server.URL, "body": map[string]interface{}{"sku": "A1"}},
+		"rps":      float64(100),
+		"duration": "500ms",
+	})
+
+	var heartbeats atomic.Int64
+	response := run(context.Background(), server.Client(), p, func(int, int) { heartbeats.Add(1) })
+
+	if response.Mode != ModeRPS || response.Method != http.MethodPost {
+		t.Fatalf("unexpected response: %+v", response)
+	}
+	if response.Requests < 45 || response.Requests > 55 {
+		t.Fatalf("requests = %d, want about 50", response.Requests)
+	}
+	if response.Statuses["503"] != response.Errors || response.Statuses["201"] != response.Successes {
+		t.Fatalf("statuses = %v, successes = %d, errors = %d", response.Statuses, response.Successes, response.Errors)
+	}
+	if response.ErrorRate < 0.05 || response.ErrorRate > 0.15 {
+		t.Fatalf("error rate = %v, want about 0.1", response.ErrorRate)
+	}
+	if response.Latency.Max <= 0 || response.Latency.P50 > response.Latency.P99 {
+		t.Fatalf("unexpected latency: %+v", response.Latency)
+	}
+}
+
+func TestRunVirtualUsers(t *testing.T) {
+	var inFlight, peak atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			old := peak.Load()
+			if n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	p := testPlan(t, map[string]interface{}{
+		"request":  map[string]interface{}{"url": server.URL},
+		"vus":      float64(4),
+		"duration": "300ms",
+	})
+	response := run(context.Background(), server.Client(), p, func(int, int) {})
+
+	if response.Mode != ModeVUs || peak.Load() != 4 {
+		t.Fatalf("mode = %s, peak concurrency = %d, want 4", response.Mode, peak.Load())
+	}
+	// 4 users each sending about 15 requests of 20ms
+	if response.Requests < 40 || response.Requests > 70 || response.Errors != 0 {
+		t.Fatalf("requests = %d, errors = %d", response.Requests, response.Errors)
+	}
+	if response.Latency.Min < 20 {
+		t.Fatalf("min latency = %vms, want at least 20ms", response.Latency.Min)
+	}
+}
+
+func TestRunDropsOverConcurrency(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	p := testPlan(t, map[string]interface{}{
+		"request":         map[string]interface{}{"url": server.URL},
+		"rps":             float64(100),
+		"max_concurrency": float64(2),
+		"duration":        "300ms",
+	})
+	response := run(context.Background(), server.Client(), p, func(int, int) {})
+	if response.Requests > 6 || response.Dropped < 20 {
+		t.Fatalf("requests = %d, dropped = %d", response.Requests, response.Dropped)
+	}
+}
+
+func TestRequestFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	p := testPlan(t, map[string]interface{}{
+		"request":  map[string]interface{}{"url": server.URL},
+		"vus":      float64(2),
+		"duration": "150ms",
+	})
+	client := server.Client()
+	client.Timeout = 10 * time.Millisecond
+	response := run(context.Background(), client, p, func(int, int) {})
+
+	if response.Errors != response.Requests || response.ErrorRate != 1 || len(response.Statuses) != 0 {
+		t.Fatalf("unexpected response: %+v", response)
+	}
+	if len(response.Failures) != 1 || response.Failures["request timed out"] != response.Requests {
+		t.Fatalf("failures = %v", response.Failures)
+	}
+}
+
+func TestThresholds(t *testing.T) {
+	config := &LoadConfig{}
+	err := parseConfig(map[string]interface{}{
+		"request":    map[string]interface{}{"url": "http://localhost"},
+		"thresholds": map[string]interface{}{"p95": "250ms", "p99": float64(400), "error_rate": "1%", "min_rps": float64(50)},
+	}, config)
+	if err != nil {
+		t.Fatalf("parseConfig() error = %v", err)
+	}
+	thresholds, err := parseThresholds(config.Thresholds)
+	if err != nil {
+		t.Fatalf("parseThresholds() error = %v", err)
+	}
+
+	passing := &LoadResponse{RPS: 99.5, ErrorRate: 0.005, Latency: Latency{P95: 120, P99: 400}}
+	if failed := checkThresholds(thresholds, passing); len(failed) != 0 {
+		t.Fatalf("unexpected failures: %v", failed)
+	}
+
+	failing := &LoadResponse{RPS: 42.25, ErrorRate: 0.021, Latency: Latency{P95: 812.5, P99: 390}}
+	got := strings.Join(checkThresholds(thresholds, failing), ", ")
+	want := "p95 812.5ms > 250ms, error_rate 2.10% > 1.00%, rps 42.25/s < 50.00/s"
+	if got != want {
+		t.Fatalf("failures = %q\nwant %q", got, want)
+	}
+
+	for _, bad := range []map[string]interface{}{
+		{"p95": "fast"},
+		{"error_rate": "150%"},
+		{"p42": "1s"},
+	} {
+		config := &LoadConfig{}
+		err := parseConfig(map[string]interface{}{"request": map[string]interface{}{"url": "http://localhost"}, "thresholds": bad}, config)
+		if err == nil {
+			_, err = parseThresholds(config.Thresholds)
+		}
+		if err == nil {
+			t.Errorf("thresholds %v accepted", bad)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i+1) * time.Millisecond
+	}
+	for p, want := range map[float64]time.Duration{50: 50 * time.Millisecond, 95: 95 * time.Millisecond, 99: 99 * time.Millisecond, 100: 100 * time.Millisecond} {
+		if got := percentile(sorted, p); got != want {
+			t.Errorf("percentile(%v) = %s, want %s", p, got, want)
+		}
+	}
+	if got := percentile(sorted[:1], 99); got != time.Millisecond {
+		t.Errorf("percentile of one value = %s", got)
+	}
+}
+
+func TestBuildPlan(t *testing.T) {
+	cases := []struct {
+		name   string
+		config LoadConfig
+		want   string
+	}{
+		{"bad url", LoadConfig{Request: RequestConfig{URL: "localhost:8080"}, RPS: 10, Duration: "1s"}, "request.url must be an http(s) URL"},
+		{"no load", LoadConfig{Request: RequestConfig{URL: "http://api"}, Duration: "1s"}, "set one of rps or vus"},
+		{"both", LoadConfig{Request: RequestConfig{URL: "http://api"}, RPS: 10, VUs: 2, Duration: "1s"}, "set one of rps or vus"},
+		{"too fast", LoadConfig{Request: RequestConfig{URL: "http://api"}, RPS: 50000, Duration: "1s"}, "rps must be between 0 and 10000"},
+		{"no duration", LoadConfig{Request: RequestConfig{URL: "http://api"}, VUs: 2}, "duration is required"},
+		{"bad status", LoadConfig{Request: RequestConfig{URL: "http://api"}, VUs: 2, Duration: "1s", SuccessStatus: []int{42}}, "success_status must contain HTTP statuses"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := buildPlan(&tc.config)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+
+	p, err := buildPlan(&LoadConfig{Request: RequestConfig{URL: "http://api"}, VUs: 2, Duration: "1s", SuccessStatus: []int{404}})
+	if err != nil {
+		t.Fatalf("buildPlan() error = %v", err)
+	}
+	if p.method != http.MethodGet || !p.succeeded(404) || p.succeeded(200) {
+		t.Fatalf("unexpected plan: %+v", p)
+	}
+}
@@ -0,0 +1,268 @@
+package load
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxFailureKinds is how many distinct request errors are kept; the rest are
+// counted under "other"
+const maxFailureKinds = 10
+
+// plan is a validated load configuration
+type plan struct {
+	method         string
+	url            string
+	headers        map[string]string
+	body           string
+	rps            float64
+	vus            int
+	duration       time.Duration
+	maxConcurrency int
+	success        map[int]bool
+}
+
+// collector aggregates results as requests finish
+type collector struct {
+	mu        sync.Mutex
+	latencies []time.Duration
+	requests  int
+	successes int
+	dropped   int
+	statuses  map[string]int
+	failures  map[string]int
+}
+
+func newCollector() *collector {
+	return &collector{statuses: map[string]int{}, failures: map[string]int{}}
+}
+
+func (c *collector) record(status int, latency time.Duration, ok bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests++
+	if err != nil {
+		message := failureMessage(err)
+		if _, seen := c.failures[message]; !seen && len(c.failures) >= maxFailureKinds {
+			message = "other"
+		}
+		c.failures[message]++
+		return
+	}
+	c.latencies = append(c.latencies, latency)
+	c.statuses[strconv.Itoa(status)]++
+	if ok {
+		c.successes++
+	}
+}
+
+func (c *collector) drop() {
+	c.mu.Lock()
+	c.dropped++
+	c.mu.Unlock()
+}
+
+// progress returns the requests so far and how many failed
+func (c *collector) progress() (int, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.requests, c.requests - c.successes
+}
+
+// failureMessage drops the method and URL net/http puts in front of errors,
+// so the same failure on every request counts as one kind
+func failureMessage(err error) string {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "request timed out"
+	}
+	return err.Error()
+}
+
+// run sends requests for the plan's duration and returns the aggregated
+// results. progress is called about once a second.
+func run(ctx context.Context, client *http.Client, p *plan, progress func(requests, errors int)) *LoadResponse {
+	results := newCollector()
+	start := time.Now()
+	ctx, cancel := context.WithDeadline(ctx, start.Add(p.duration))
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				progress(results.progress())
+			}
+		}
+	}()
+
+	// Requests still in flight at the deadline finish, so the duration cut-off
+	// doesn't count as a wave of errors
+	send := func() {
+		sent := time.Now()
+		status, err := do(context.WithoutCancel(ctx), client, p)
+		results.record(status, time.Since(sent), err == nil && p.succeeded(status), err)
+	}
+
+	var wg sync.WaitGroup
+	if p.vus > 0 {
+		for i := 0; i < p.vus; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for ctx.Err() == nil {
+					send()
+				}
+			}()
+		}
+	} else {
+		slots := make(chan struct{}, p.maxConcurrency)
+		interval := time.Duration(float64(time.Second) / p.rps)
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+	schedule:
+		for i := 0; ; i++ {
+			// Sends are scheduled from the start time, so slow iterations
+			// don't lower the rate
+			timer.Reset(time.Until(start.Add(time.Duration(i) * interval)))
+			select {
+			case <-ctx.Done():
+				break schedule
+			case <-timer.C:
+			}
+			select {
+			case slots <- struct{}{}:
+			default:
+				results.drop()
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-slots }()
+				send()
+			}()
+		}
+	}
+	wg.Wait()
+	close(done)
+
+	return results.summary(p, time.Since(start))
+}
+
+// do sends one request and returns its status
+func do(ctx context.Context, client *http.Client, p *plan) (int, error) {
+	var body io.Reader
+	if p.body != "" {
+		body = strings.NewReader(p.body)
+	}
+	req, err := http.NewRequestWithContext(ctx, p.method, p.url, body)
+	if err != nil {
+		return 0, err
+	}
+	for name, value := range p.headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	// Reading the body keeps the connection reusable and counts the download
+	// in the latency
+	_, err = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return 0, err
+	}
+	return resp.StatusCode, nil
+}
+
+func (p *plan) succeeded(status int) bool {
+	if len(p.success) > 0 {
+		return p.success[status]
+	}
+	return status >= 200 && status < 400
+}
+
+// summary computes rates and latency percentiles
+func (c *collector) summary(p *plan, elapsed time.Duration) *LoadResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	mode := ModeRPS
+	if p.vus > 0 {
+		mode = ModeVUs
+	}
+	response := &LoadResponse{
+		Method:    p.method,
+		URL:       p.url,
+		Mode:      mode,
+		Requests:  c.requests,
+		Successes: c.successes,
+		Errors:    c.requests - c.successes,
+		Dropped:   c.dropped,
+		Statuses:  c.statuses,
+		Failures:  c.failures,
+		Duration:  elapsed.String(),
+	}
+	if c.requests > 0 {
+		response.ErrorRate = round(float64(response.Errors)/float64(c.requests), 4)
+	}
+	if elapsed > 0 {
+		response.RPS = round(float64(c.requests)/elapsed.Seconds(), 2)
+	}
+
+	if len(c.latencies) > 0 {
+		sorted := append([]time.Duration(nil), c.latencies...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		var total time.Duration
+		for _, latency := range sorted {
+			total += latency
+		}
+		response.Latency = Latency{
+			Min:  millis(sorted[0]),
+			Mean: millis(total / time.Duration(len(sorted))),
+			P50:  millis(percentile(sorted, 50)),
+			P90:  millis(percentile(sorted, 90)),
+			P95:  millis(percentile(sorted, 95)),
+			P99:  millis(percentile(sorted, 99)),
+			Max:  millis(sorted[len(sorted)-1]),
+		}
+	}
+	return response
+}
+
+// percentile returns the nearest-rank percentile of sorted
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func millis(d time.Duration) float64 {
+	return round(float64(d)/float64(time.Millisecond), 2)
+}
+
+func round(v float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(v*scale) / scale
+}
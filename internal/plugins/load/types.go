@@ -0,0 +1,93 @@
+package load
+
+import "github.com/rocketship-ai/rocketship/internal/assertions"
+
+// LoadPlugin represents a load test step
+type LoadPlugin struct {
+	Name   string     `json:"name" yaml:"name"`
+	Plugin string     `json:"plugin" yaml:"plugin"`
+	Config LoadConfig `json:"config" yaml:"config"`
+}
+
+// LoadConfig defines the request, how hard to send it and the thresholds the
+// results must meet
+type LoadConfig struct {
+	Request RequestConfig `json:"request" yaml:"request"`
+
+	// Load, either a rate or a number of virtual users
+	RPS            float64 `json:"rps,omitempty" yaml:"rps,omitempty"`                         // Requests started per second, whatever their latency
+	VUs            int     `json:"vus,omitempty" yaml:"vus,omitempty"`                         // Virtual users, each sending requests back to back
+	Duration       string  `json:"duration" yaml:"duration"`                                   // How long to send requests
+	MaxConcurrency int     `json:"max_concurrency,omitempty" yaml:"max_concurrency,omitempty"` // rps: most requests in flight (defaults to 100)
+
+	RequestTimeout string `json:"request_timeout,omitempty" yaml:"request_timeout,omitempty"` // Per-request timeout (defaults to 10s)
+	SuccessStatus  []int  `json:"success_status,omitempty" yaml:"success_status,omitempty"`   // Statuses that count as success (defaults to 2xx and 3xx)
+
+	Thresholds *ThresholdConfig `json:"thresholds,omitempty" yaml:"thresholds,omitempty"`
+	Timeout    string           `json:"timeout,omitempty" yaml:"timeout,omitempty"` // Overall step timeout (defaults to duration plus a minute)
+}
+
+// RequestConfig is the HTTP request sent under load
+type RequestConfig struct {
+	Method  string            `json:"method,omitempty" yaml:"method,omitempty"` // Defaults to GET
+	URL     string            `json:"url" yaml:"url"`
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body    string            `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// ThresholdConfig sets limits the results must stay within. Latencies are
+// durations; error_rate is a ratio or a percentage.
+type ThresholdConfig struct {
+	P50       string   `json:"p50,omitempty" yaml:"p50,omitempty"`
+	P90       string   `json:"p90,omitempty" yaml:"p90,omitempty"`
+	P95       string   `json:"p95,omitempty" yaml:"p95,omitempty"`
+	P99       string   `json:"p99,omitempty" yaml:"p99,omitempty"`
+	Max       string   `json:"max,omitempty" yaml:"max,omitempty"`
+	Mean      string   `json:"mean,omitempty" yaml:"mean,omitempty"`
+	ErrorRate string   `json:"error_rate,omitempty" yaml:"error_rate,omitempty"` // e.g. 0.01 or 1%
+	MinRPS    *float64 `json:"min_rps,omitempty" yaml:"min_rps,omitempty"`       // Lowest achieved rate
+}
+
+// Load modes
+const (
+	ModeRPS = "rps"
+	ModeVUs = "vus"
+)
+
+// Latency summarises response times in milliseconds
+type Latency struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// LoadResponse contains the aggregated results
+type LoadResponse struct {
+	Method    string         `json:"method"`
+	URL       string         `json:"url"`
+	Mode      string         `json:"mode"`     // rps or vus
+	Requests  int            `json:"requests"` // Requests that completed or failed
+	Successes int            `json:"successes"`
+	Errors    int            `json:"errors"`     // Requests that failed or got a status outside success_status
+	ErrorRate float64        `json:"error_rate"` // errors / requests
+	RPS       float64        `json:"rps"`        // Achieved requests per second
+	Dropped   int            `json:"dropped"`    // rps: requests not sent because max_concurrency were in flight
+	Latency   Latency        `json:"latency"`    // Over requests that got a response
+	Statuses  map[string]int `json:"statuses"`   // Responses by status code
+	Failures  map[string]int `json:"failures"`   // Request errors by message, such as timeouts and refused connections
+	Duration  string         `json:"duration"`
+}
+
+// AssertionResult represents a single assertion result for UI display
+type AssertionResult = assertions.Result
+
+// ActivityResponse represents the complete activity response
+type ActivityResponse struct {
+	Response         *LoadResponse     `json:"response"`
+	Saved            map[string]string `json:"saved"`
+	AssertionResults []AssertionResult `json:"assertion_results,omitempty"`
+}
@@ -2,10 +2,23 @@ package log
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
 
+	"github.com/rocketship-ai/rocketship/internal/artifacts"
+	"github.com/rocketship-ai/rocketship/internal/confine"
 	"github.com/rocketship-ai/rocketship/internal/dsl"
 	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/vault"
 	"go.temporal.io/sdk/activity"
 )
 
@@ -38,6 +51,14 @@ func (lp *LogPlugin) Activity(ctx context.Context, p map[string]interface{}) (in
 	if config.Message == "" {
 		return nil, fmt.Errorf("message is required")
 	}
+	if config.Level == "" {
+		config.Level = LevelInfo
+	}
+	switch config.Level {
+	case LevelInfo, LevelWarn, LevelError:
+	default:
+		return nil, fmt.Errorf("invalid level %q: expected info, warn or error", config.Level)
+	}
 
 	// Get state for template processing
 	// Convert state to map[string]interface{} for template processing
@@ -52,6 +73,11 @@ func (lp *LogPlugin) Activity(ctx context.Context, p map[string]interface{}) (in
 		stateInterface = stateInt
 	}
 
+	runID := ""
+	if runData, ok := p["run"].(map[string]interface{}); ok {
+		runID, _ = runData["id"].(string)
+	}
+
 	// Extract env secrets from params (for {{ .env.* }} template resolution)
 	env := make(map[string]string)
 	if envData, ok := p["env"].(map[string]interface{}); ok {
@@ -63,6 +89,8 @@ func (lp *LogPlugin) Activity(ctx context.Context, p map[string]interface{}) (in
 	} else if envData, ok := p["env"].(map[string]string); ok {
 		env = envData
 	}
+	// Secrets must not leak into the run's logs or artifacts
+	mask := newMasker(env)
 
 	// Process templates in the message (config vars already processed by CLI)
 	context := dsl.TemplateContext{
@@ -74,17 +102,50 @@ func (lp *LogPlugin) Activity(ctx context.Context, p map[string]interface{}) (in
 	if err != nil {
 		return nil, fmt.Errorf("failed to process message template: %w", err)
 	}
+	processedMessage = mask(processedMessage)
+
+	fields := make(map[string]string, len(config.Fields))
+	for key, value := range config.Fields {
+		processed, err := dsl.ProcessTemplate(fieldString(value), context)
+		if err != nil {
+			return nil, fmt.Errorf("failed to process field %q template: %w", key, err)
+		}
+		fields[key] = mask(processed)
+	}
+
+	saved := make([]artifacts.Artifact, 0, len(config.Attachments))
+	for i, attachment := range config.Attachments {
+		artifact, err := saveAttachment(runID, attachment, context, mask)
+		if err != nil {
+			return nil, fmt.Errorf("attachment %d: %w", i, err)
+		}
+		saved = append(saved, artifact)
+	}
 
 	// Log the message for debugging purposes
-	logger.Info(processedMessage)
+	switch config.Level {
+	case LevelWarn:
+		logger.Warn(processedMessage, "fields", fields)
+	case LevelError:
+		logger.Error(processedMessage, "fields", fields)
+	default:
+		logger.Info(processedMessage, "fields", fields)
+	}
 
 	// Return result with log information for the workflow to send to engine
 	result := map[string]interface{}{
 		"message":     processedMessage,
+		"level":       config.Level,
+		"fields":      fields,
 		"logged":      true,
 		"log_message": processedMessage,
 		"log_color":   "n/a",
 		"log_bold":    false,
+		"log_level":   config.Level,
+		"log_fields":  fields,
+	}
+	if len(saved) > 0 {
+		result["artifacts"] = saved
 	}
 
 	return result, nil
@@ -96,5 +157,133 @@ func parseConfig(configData map[string]interface{}, config *LogConfig) error {
 		config.Message = message
 	}
 
+	if raw, ok := configData["level"]; ok {
+		level, ok := raw.(string)
+		if !ok {
+			return fmt.Errorf("level must be a string")
+		}
+		config.Level = strings.ToLower(level)
+	}
+
+	if raw, ok := configData["fields"]; ok {
+		fields, ok := raw.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("fields must be an object")
+		}
+		config.Fields = fields
+	}
+
+	if raw, ok := configData["attachments"]; ok {
+		list, ok := raw.([]interface{})
+		if !ok {
+			return fmt.Errorf("attachments must be a list")
+		}
+		for i, item := range list {
+			entry, ok := item.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("attachment %d must be an object", i)
+			}
+			attachment := AttachmentConfig{}
+			attachment.Name, _ = entry["name"].(string)
+			attachment.Content, _ = entry["content"].(string)
+			attachment.Path, _ = entry["path"].(string)
+			attachment.MimeType, _ = entry["mime_type"].(string)
+			if (attachment.Content == "") == (attachment.Path == "") {
+				return fmt.Errorf("attachment %d needs either content or path", i)
+			}
+			if attachment.Name == "" {
+				if attachment.Path == "" {
+					return fmt.Errorf("attachment %d needs a name", i)
+				}
+				attachment.Name = filepath.Base(attachment.Path)
+			}
+			config.Attachments = append(config.Attachments, attachment)
+		}
+	}
+
 	return nil
 }
+
+// fieldString renders a field value as text; strings are kept for templating
+// and objects and lists are written as JSON
+func fieldString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	case map[string]interface{}, []interface{}:
+		if data, err := json.Marshal(v); err == nil {
+			return string(data)
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+// saveAttachment saves an attachment as an artifact of the run. Inline content
+// is templated; text is masked either way. Paths must stay inside the
+// directories steps may use on the worker (see confine.WorkerRoots).
+func saveAttachment(runID string, attachment AttachmentConfig, templateContext dsl.TemplateContext, mask func(string) string) (artifacts.Artifact, error) {
+	var data []byte
+	if attachment.Path != "" {
+		path, err := confine.ResolveForRun(runID, attachment.Path)
+		if errors.Is(err, confine.ErrOutside) {
+			return artifacts.Artifact{}, fmt.Errorf("%s is outside the directories steps may read on this worker (see %s): %w", attachment.Path, confine.RootsEnv, confine.ErrOutside)
+		}
+		if err != nil {
+			return artifacts.Artifact{}, err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return artifacts.Artifact{}, fmt.Errorf("failed to open %s: %w", attachment.Path, err)
+		}
+		defer func() { _ = f.Close() }()
+		data, err = io.ReadAll(io.LimitReader(f, maxAttachmentSize+1))
+		if err != nil {
+			return artifacts.Artifact{}, fmt.Errorf("failed to read %s: %w", attachment.Path, err)
+		}
+	} else {
+		content, err := dsl.ProcessTemplate(attachment.Content, templateContext)
+		if err != nil {
+			return artifacts.Artifact{}, fmt.Errorf("failed to process content template: %w", err)
+		}
+		data = []byte(content)
+	}
+	if len(data) > maxAttachmentSize {
+		return artifacts.Artifact{}, fmt.Errorf("%s is larger than %d bytes", attachment.Name, maxAttachmentSize)
+	}
+	if utf8.Valid(data) {
+		data = []byte(mask(string(data)))
+	}
+
+	mimeType := attachment.MimeType
+	if mimeType == "" {
+		mimeType = mime.TypeByExtension(filepath.Ext(attachment.Name))
+	}
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	return artifacts.Save(runID, attachment.Name, "attachment", mimeType, data)
+}
+
+// newMasker returns a function replacing the env secrets and Vault-resolved
+// values in text. Values shorter than minMaskLength are left alone, so a secret
+// like "1" doesn't garble everything else.
+func newMasker(env map[string]string) func(string) string {
+	values := make([]string, 0, len(env))
+	for _, value := range env {
+		if len(value) >= minMaskLength {
+			values = append(values, value)
+		}
+	}
+	// Longest first, so a secret containing another is replaced whole
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	pairs := make([]string, 0, 2*len(values))
+	for _, value := range values {
+		pairs = append(pairs, value, vault.Mask)
+	}
+	replacer := strings.NewReplacer(pairs...)
+	return func(s string) string {
+		return vault.MaskString(replacer.Replace(s))
+	}
+}
@@ -0,0 +1,165 @@
+package log
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/rocketship-ai/rocketship/internal/artifacts"
+	"github.com/rocketship-ai/rocketship/internal/confine"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+)
+
+func TestParseConfig(t *testing.T) {
+	config := &LogConfig{}
+	err := parseConfig(map[string]interface{}{
+		"message": "done",
+		"level":   "WARN",
+		"fields":  map[string]interface{}{"user": "{{ user_id }}", "count": float64(3)},
+		"attachments": []interface{}{
+			map[string]interface{}{"name": "body.json", "content": "{}"},
+			map[string]interface{}{"path": "/tmp/out/report.html"},
+		},
+	}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Level != LevelWarn || len(config.Fields) != 2 || len(config.Attachments) != 2 {
+		t.Fatalf("unexpected config %+v", config)
+	}
+	if config.Attachments[1].Name != "report.html" {
+		t.Errorf("expected the name to default to the file name, got %q", config.Attachments[1].Name)
+	}
+
+	for name, configData := range map[string]map[string]interface{}{
+		"both content and path":     {"attachments": []interface{}{map[string]interface{}{"name": "a", "content": "x", "path": "/a"}}},
+		"neither content nor path":  {"attachments": []interface{}{map[string]interface{}{"name": "a"}}},
+		"inline content, no name":   {"attachments": []interface{}{map[string]interface{}{"content": "x"}}},
+		"fields that aren't a map":  {"fields": []interface{}{"a"}},
+		"level that isn't a string": {"level": 1},
+	} {
+		if err := parseConfig(configData, &LogConfig{}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestNewMasker(t *testing.T) {
+	mask := newMasker(map[string]string{"TOKEN": "s3cr3t", "LONG": "s3cr3t-extended", "PORT": "80"})
+	got := mask("token=s3cr3t-extended and s3cr3t on port 80")
+	if got != "token=*** and *** on port 80" {
+		t.Errorf("unexpected masked text %q", got)
+	}
+}
+
+func TestActivity(t *testing.T) {
+	t.Setenv(artifacts.DirEnv, t.TempDir())
+	root := t.TempDir()
+	t.Setenv(confine.RootsEnv, root)
+	path := filepath.Join(root, "response.txt")
+	if err := os.WriteFile(path, []byte("Authorization: Bearer s3cr3t"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	plugin := &LogPlugin{}
+	env.RegisterActivity(plugin.Activity)
+
+	value, err := env.ExecuteActivity(plugin.Activity, map[string]interface{}{
+		"config": map[string]interface{}{
+			"message": "Logged in as {{ user }} with {{ .env.TOKEN }}",
+			"level":   "error",
+			"fields":  map[string]interface{}{"user": "{{ user }}", "token": "{{ .env.TOKEN }}", "attempts": float64(2)},
+			"attachments": []interface{}{
+				map[string]interface{}{"name": "response.txt", "path": path},
+				map[string]interface{}{"name": "state.json", "content": `{"user": "{{ user }}"}`},
+			},
+		},
+		"state": map[string]interface{}{"user": "ada"},
+		"env":   map[string]interface{}{"TOKEN": "s3cr3t"},
+		"run":   map[string]interface{}{"id": "run-1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result map[string]interface{}
+	if err := value.Get(&result); err != nil {
+		t.Fatal(err)
+	}
+
+	if result["log_message"] != "Logged in as ada with ***" || result["log_level"] != LevelError {
+		t.Errorf("unexpected log %v (%v)", result["log_message"], result["log_level"])
+	}
+	fields := result["log_fields"].(map[string]interface{})
+	if fields["user"] != "ada" || fields["token"] != "***" || fields["attempts"] != "2" {
+		t.Errorf("unexpected fields %v", fields)
+	}
+
+	saved := result["artifacts"].([]interface{})
+	if len(saved) != 2 {
+		t.Fatalf("expected 2 artifacts, got %v", saved)
+	}
+	response := saved[0].(map[string]interface{})
+	data, err := os.ReadFile(response["path"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "Authorization: Bearer ***" || response["type"] != "attachment" || !strings.HasPrefix(response["mime_type"].(string), "text/plain") {
+		t.Errorf("unexpected attachment %v: %q", response, data)
+	}
+	state := saved[1].(map[string]interface{})
+	if data, _ := os.ReadFile(state["path"].(string)); string(data) != `{"user": "ada"}` || state["mime_type"] != "application/json" {
+		t.Errorf("unexpected attachment %v: %q", state, data)
+	}
+}
+
+func TestSaveAttachmentStaysInsideRoots(t *testing.T) {
+	t.Setenv(artifacts.DirEnv, t.TempDir())
+	root := t.TempDir()
+	t.Setenv(confine.RootsEnv, root)
+	secret := filepath.Join(t.TempDir(), "credentials")
+	if err := os.WriteFile(secret, []byte("aws_secret_access_key=abc"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(secret, filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	noMask := func(s string) string { return s }
+	for _, path := range []string{secret, "../credentials", "link"} {
+		_, err := saveAttachment("run-1", AttachmentConfig{Name: "leak.txt", Path: path}, dsl.TemplateContext{}, noMask)
+		if !errors.Is(err, confine.ErrOutside) {
+			t.Errorf("saveAttachment(%q) = %v, want ErrOutside", path, err)
+		}
+	}
+}
+
+func TestActivityRejectsLargeAttachments(t *testing.T) {
+	t.Setenv(artifacts.DirEnv, t.TempDir())
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	plugin := &LogPlugin{}
+	env.RegisterActivity(plugin.Activity)
+
+	_, err := env.ExecuteActivity(plugin.Activity, map[string]interface{}{
+		"config": map[string]interface{}{
+			"message":     "dump",
+			"attachments": []interface{}{map[string]interface{}{"name": "big.txt", "content": strings.Repeat("a", maxAttachmentSize+1)}},
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("expected the attachment to be rejected, got %v", err)
+	}
+
+	_, err = env.ExecuteActivity(plugin.Activity, map[string]interface{}{
+		"config": map[string]interface{}{"message": "hi", "level": "debug"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid level") {
+		t.Errorf("expected an invalid level error, got %v", err)
+	}
+}
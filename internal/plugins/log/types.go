@@ -1,5 +1,19 @@
 package log
 
+// Log levels
+const (
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+)
+
+// maxAttachmentSize caps each attached file; logs are for small files such as
+// a response body or a generated config, not for large artifacts
+const maxAttachmentSize = 1 << 20
+
+// minMaskLength matches the shortest Vault-resolved value that gets masked
+const minMaskLength = 4
+
 type LogPlugin struct {
 	Name   string    `json:"name" yaml:"name"`
 	Plugin string    `json:"plugin" yaml:"plugin"`
@@ -7,11 +21,25 @@ type LogPlugin struct {
 }
 
 type LogConfig struct {
-	Message string `json:"message" yaml:"message"`
+	Message     string                 `json:"message" yaml:"message"`
+	Level       string                 `json:"level,omitempty" yaml:"level,omitempty"`
+	Fields      map[string]interface{} `json:"fields,omitempty" yaml:"fields,omitempty"`
+	Attachments []AttachmentConfig     `json:"attachments,omitempty" yaml:"attachments,omitempty"`
+}
+
+// AttachmentConfig is a file saved as an artifact of the step, either written
+// inline or read from a path on the worker
+type AttachmentConfig struct {
+	Name     string `json:"name,omitempty" yaml:"name,omitempty"`
+	Content  string `json:"content,omitempty" yaml:"content,omitempty"`
+	Path     string `json:"path,omitempty" yaml:"path,omitempty"`
+	MimeType string `json:"mime_type,omitempty" yaml:"mime_type,omitempty"`
 }
 
 type LogActivityResponse struct {
-	LogMessage string `json:"log_message,omitempty"`
-	LogColor   string `json:"log_color,omitempty"`
-	LogBold    bool   `json:"log_bold,omitempty"`
+	LogMessage string            `json:"log_message,omitempty"`
+	LogColor   string            `json:"log_color,omitempty"`
+	LogBold    bool              `json:"log_bold,omitempty"`
+	LogLevel   string            `json:"log_level,omitempty"`
+	LogFields  map[string]string `json:"log_fields,omitempty"`
 }
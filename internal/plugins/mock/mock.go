@@ -0,0 +1,417 @@
+package mock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+	"go.temporal.io/sdk/activity"
+)
+
+// HostEnv sets the host in mock server URLs, for callers that reach the worker
+// by another name
+const HostEnv = "ROCKETSHIP_MOCK_HOST"
+
+const (
+	defaultListen  = "127.0.0.1:0"
+	defaultTTL     = time.Hour
+	defaultTimeout = 30 * time.Second
+)
+
+// Auto-register the plugin when the package is imported
+func init() {
+	plugins.RegisterPlugin(&MockPlugin{})
+}
+
+// GetType returns the plugin type identifier
+func (mp *MockPlugin) GetType() string {
+	return "mock"
+}
+
+// Activity starts or stops a stub HTTP server on the worker, or reads the
+// requests it received
+func (mp *MockPlugin) Activity(ctx context.Context, p map[string]interface{}) (interface{}, error) {
+	logger := activity.GetLogger(ctx)
+
+	// Parse configuration from parameters
+	configData, ok := p["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid config format")
+	}
+
+	config := &MockConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		return nil, fmt.Errorf("failed to parse mock config: %w", err)
+	}
+
+	timeout := defaultTimeout
+	if config.Timeout != "" {
+		parsed, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", config.Timeout, err)
+		}
+		timeout = parsed
+	}
+
+	// Convert state to map[string]interface{} for template processing
+	state := make(map[string]interface{})
+	if stateStr, ok := p["state"].(map[string]string); ok {
+		for k, v := range stateStr {
+			state[k] = v
+		}
+	} else if stateInt, ok := p["state"].(map[string]interface{}); ok {
+		state = stateInt
+	}
+	if runData, ok := p["run"].(map[string]interface{}); ok {
+		state["run"] = runData
+	}
+
+	// Extract env secrets from params (for {{ .env.* }} template resolution)
+	env := make(map[string]string)
+	if envData, ok := p["env"].(map[string]interface{}); ok {
+		for k, v := range envData {
+			if strVal, ok := v.(string); ok {
+				env[k] = strVal
+			}
+		}
+	} else if envData, ok := p["env"].(map[string]string); ok {
+		env = envData
+	}
+
+	if err := applyVariableReplacement(config, state, env); err != nil {
+		return nil, fmt.Errorf("variable replacement failed: %w", err)
+	}
+
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	// Servers belong to the run, so parallel runs can use the same IDs
+	runID := ""
+	if run, ok := p["run"].(map[string]interface{}); ok {
+		runID, _ = run["id"].(string)
+	}
+
+	logger.Info("Executing mock plugin", "action", config.Action, "id", config.ID)
+
+	policy, err := egress.FromParams(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	response, err := execute(ctx, config, runID, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := assertions.Normalize(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare mock result: %w", err)
+	}
+
+	assertionResults, err := assertions.Process(p, subject, dsl.TemplateContext{Runtime: state, Env: env}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if failure := assertions.Summary(assertionResults); failure != "" {
+		return nil, fmt.Errorf("%s", failure)
+	}
+
+	saved := make(map[string]string)
+	if err := saves.Process(p, subject, saved); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Mock step completed", "action", config.Action, "id", response.ID, "duration", response.Duration)
+
+	return &ActivityResponse{
+		Response:         response,
+		Saved:            saved,
+		AssertionResults: assertionResults,
+	}, nil
+}
+
+// execute runs the action against the run's servers. A server may only listen
+// on an address the policy allows steps to reach.
+func execute(ctx context.Context, config *MockConfig, runID string, policy *egress.Policy) (*MockResponse, error) {
+	start := time.Now()
+	response := &MockResponse{Action: config.Action, ID: config.ID}
+
+	switch config.Action {
+	case ActionStart:
+		if response.ID == "" {
+			response.ID = randomID()
+		}
+		routes, err := buildRoutes(config.Routes)
+		if err != nil {
+			return nil, err
+		}
+		listen := config.Listen
+		if listen == "" {
+			listen = defaultListen
+		}
+		host := config.Host
+		if host == "" {
+			host = strings.TrimSpace(os.Getenv(HostEnv))
+		}
+		ttl := defaultTTL
+		if config.TTL != "" {
+			if ttl, err = time.ParseDuration(config.TTL); err != nil {
+				return nil, fmt.Errorf("invalid ttl %q: %w", config.TTL, err)
+			}
+		}
+		fallback := ReplyConfig{}
+		if config.Default != nil {
+			fallback = *config.Default
+		}
+		s, err := startServer(ctx, policy, runID, response.ID, listen, host, routes, fallback, ttl)
+		if err != nil {
+			return nil, err
+		}
+		response.URL = s.url
+		response.Routes = len(routes)
+
+	case ActionRequests:
+		s, err := lookupServer(runID, config.ID)
+		if err != nil {
+			return nil, err
+		}
+		var pattern []string
+		if config.Path != "" {
+			pattern = splitPattern(config.Path)
+		}
+		response.URL = s.url
+		response.Requests = s.received(config.Method, pattern, config.Clear)
+		response.Count = len(response.Requests)
+
+	case ActionStop:
+		response.Stopped = stopServers(runID, config.ID)
+		if config.ID != "" && len(response.Stopped) == 0 {
+			return nil, fmt.Errorf("no mock server %q is running for this run on this worker", config.ID)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("mock %s did not finish: %w", config.Action, err)
+	}
+	response.Duration = time.Since(start).String()
+	return response, nil
+}
+
+// buildRoutes prepares routes for matching
+func buildRoutes(configs []RouteConfig) ([]route, error) {
+	routes := make([]route, 0, len(configs))
+	for i, rc := range configs {
+		rt := route{method: strings.ToUpper(rc.Method), pattern: rc.Path, segments: splitPattern(rc.Path), reply: rc.ReplyConfig}
+		if rc.Latency != "" {
+			latency, err := time.ParseDuration(rc.Latency)
+			if err != nil {
+				return nil, fmt.Errorf("invalid routes[%d].latency %q: %w", i, rc.Latency, err)
+			}
+			rt.latency = latency
+		}
+		routes = append(routes, rt)
+	}
+	return routes, nil
+}
+
+func randomID() string {
+	random := make([]byte, 6)
+	_, _ = rand.Read(random)
+	return hex.EncodeToString(random)
+}
+
+// validateConfig checks the config once templates are rendered
+func validateConfig(config *MockConfig) error {
+	switch config.Action {
+	case "":
+		return fmt.Errorf("action is required")
+	case ActionStart:
+		for i, rc := range config.Routes {
+			if !strings.HasPrefix(rc.Path, "/") {
+				return fmt.Errorf("routes[%d].path must start with /, got %q", i, rc.Path)
+			}
+			if rc.Status != 0 && (rc.Status < 100 || rc.Status > 599) {
+				return fmt.Errorf("routes[%d].status must be a valid HTTP status, got %d", i, rc.Status)
+			}
+		}
+		if d := config.Default; d != nil && d.Status != 0 && (d.Status < 100 || d.Status > 599) {
+			return fmt.Errorf("default.status must be a valid HTTP status, got %d", d.Status)
+		}
+	case ActionRequests:
+		if config.ID == "" {
+			return fmt.Errorf("id is required for requests")
+		}
+		if config.Path != "" && !strings.HasPrefix(config.Path, "/") {
+			return fmt.Errorf("path must start with /, got %q", config.Path)
+		}
+	case ActionStop:
+	default:
+		return fmt.Errorf("unknown action %q (expected start, requests or stop)", config.Action)
+	}
+	if strings.Contains(config.ID, "/") {
+		return fmt.Errorf("id must not contain /, got %q", config.ID)
+	}
+	return nil
+}
+
+// applyVariableReplacement processes templates in the server ID, routes and filters
+func applyVariableReplacement(config *MockConfig, state map[string]interface{}, env map[string]string) error {
+	context := dsl.TemplateContext{
+		Runtime: state,
+		Env:     env,
+	}
+
+	fields := []*string{&config.ID, &config.Listen, &config.Host, &config.Method, &config.Path}
+	var maps []map[string]string
+	replies := []*ReplyConfig{config.Default}
+	for i := range config.Routes {
+		fields = append(fields, &config.Routes[i].Method, &config.Routes[i].Path)
+		replies = append(replies, &config.Routes[i].ReplyConfig)
+	}
+	for _, reply := range replies {
+		if reply == nil {
+			continue
+		}
+		fields = append(fields, &reply.Body, &reply.Latency)
+		maps = append(maps, reply.Headers)
+	}
+
+	for _, field := range fields {
+		if *field == "" {
+			continue
+		}
+		processed, err := dsl.ProcessTemplate(*field, context)
+		if err != nil {
+			return fmt.Errorf("failed to process template %q: %w", *field, err)
+		}
+		*field = processed
+	}
+	for _, values := range maps {
+		for name, value := range values {
+			processed, err := dsl.ProcessTemplate(value, context)
+			if err != nil {
+				return fmt.Errorf("failed to process header %s template: %w", name, err)
+			}
+			values[name] = processed
+		}
+	}
+	return nil
+}
+
+// parseConfig converts map[string]interface{} to MockConfig
+func parseConfig(configData map[string]interface{}, config *MockConfig) error {
+	config.Action = stringValue(configData["action"])
+	config.ID = stringValue(configData["id"])
+	config.Listen = stringValue(configData["listen"])
+	config.Host = stringValue(configData["host"])
+	config.TTL = stringValue(configData["ttl"])
+	config.Method = stringValue(configData["method"])
+	config.Path = stringValue(configData["path"])
+	config.Timeout = stringValue(configData["timeout"])
+
+	switch v := configData["clear"].(type) {
+	case nil:
+	case bool:
+		config.Clear = v
+	default:
+		return fmt.Errorf("clear must be a boolean, got %T", v)
+	}
+
+	switch v := configData["routes"].(type) {
+	case nil:
+	case []interface{}:
+		for i, item := range v {
+			routeData, ok := item.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("routes[%d] must be an object, got %T", i, item)
+			}
+			reply, err := parseReply(routeData, fmt.Sprintf("routes[%d]", i))
+			if err != nil {
+				return err
+			}
+			config.Routes = append(config.Routes, RouteConfig{
+				Method:      stringValue(routeData["method"]),
+				Path:        stringValue(routeData["path"]),
+				ReplyConfig: reply,
+			})
+		}
+	default:
+		return fmt.Errorf("routes must be a list, got %T", v)
+	}
+
+	switch v := configData["default"].(type) {
+	case nil:
+	case map[string]interface{}:
+		reply, err := parseReply(v, "default")
+		if err != nil {
+			return err
+		}
+		config.Default = &reply
+	default:
+		return fmt.Errorf("default must be an object, got %T", v)
+	}
+
+	return nil
+}
+
+func parseReply(data map[string]interface{}, field string) (ReplyConfig, error) {
+	reply := ReplyConfig{
+		Headers: stringMap(data["headers"]),
+		Latency: stringValue(data["latency"]),
+	}
+	switch status := data["status"].(type) {
+	case nil:
+	case float64:
+		reply.Status = int(status)
+	case int:
+		reply.Status = status
+	default:
+		return reply, fmt.Errorf("%s.status must be a number, got %T", field, status)
+	}
+	switch body := data["body"].(type) {
+	case nil:
+	case string:
+		reply.Body = body
+	case map[string]interface{}, []interface{}:
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return reply, fmt.Errorf("failed to encode %s.body: %w", field, err)
+		}
+		reply.Body = string(encoded)
+		reply.JSON = true
+	default:
+		return reply, fmt.Errorf("%s.body must be a string or an object, got %T", field, body)
+	}
+	return reply, nil
+}
+
+func stringMap(v interface{}) map[string]string {
+	data, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	out := make(map[string]string, len(data))
+	for k, value := range data {
+		out[k] = fmt.Sprint(value)
+	}
+	return out
+}
+
+func stringValue(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
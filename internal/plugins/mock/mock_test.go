@@ -0,0 +1,238 @@
+package mock
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/egress"
+)
+
+func startTestServer(t *testing.T, runID string, configData map[string]interface{}) *MockResponse {
+	t.Helper()
+	config := &MockConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		t.Fatalf("parseConfig() error = %v", err)
+	}
+	if err := validateConfig(config); err != nil {
+		t.Fatalf("validateConfig() error = %v", err)
+	}
+	response, err := execute(context.Background(), config, runID, nil)
+	if err != nil {
+		t.Fatalf("execute() error = %v", err)
+	}
+	t.Cleanup(func() { stopServers(runID, "") })
+	return response
+}
+
+func send(t *testing.T, method, url, body string) (int, http.Header, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	raw, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, resp.Header, string(raw)
+}
+
+func TestRoutes(t *testing.T) {
+	started := startTestServer(t, "run-1", map[string]interface{}{
+		"action": "start",
+		"id":     "payments",
+		"routes": []interface{}{
+			map[string]interface{}{"method": "post", "path": "/charges", "status": float64(201), "body": map[string]interface{}{"id": "ch_1"}},
+			map[string]interface{}{"method": "GET", "path": "/charges/{id}", "body": "charge", "headers": map[string]interface{}{"X-Mock": "yes"}},
+			map[string]interface{}{"path": "/static/*", "status": float64(204)},
+		},
+		"default": map[string]interface{}{"status": float64(503), "body": "down"},
+	})
+	if !strings.HasPrefix(started.URL, "http://localhost:") || started.Routes != 3 {
+		t.Fatalf("unexpected start response: %+v", started)
+	}
+
+	status, header, body := send(t, http.MethodPost, started.URL+"/charges", `{"amount":1000}`)
+	if status != 201 || body != `{"id":"ch_1"}` || header.Get("Content-Type") != "application/json" {
+		t.Fatalf("POST /charges = %d %q %v", status, body, header)
+	}
+	status, header, body = send(t, http.MethodGet, started.URL+"/charges/ch_1?expand=customer", "")
+	if status != 200 || body != "charge" || header.Get("X-Mock") != "yes" {
+		t.Fatalf("GET /charges/ch_1 = %d %q %v", status, body, header)
+	}
+	if status, _, _ = send(t, http.MethodDelete, started.URL+"/static/css/site.css", ""); status != 204 {
+		t.Fatalf("DELETE /static/css/site.css = %d", status)
+	}
+	if status, _, body = send(t, http.MethodGet, started.URL+"/charges", ""); status != 503 || body != "down" {
+		t.Fatalf("GET /charges = %d %q", status, body)
+	}
+
+	config := &MockConfig{Action: ActionRequests, ID: "payments", Path: "/charges/*"}
+	response, err := execute(context.Background(), config, "run-1", nil)
+	if err != nil {
+		t.Fatalf("execute() error = %v", err)
+	}
+	if response.Count != 3 {
+		t.Fatalf("count = %d, want 3: %+v", response.Count, response.Requests)
+	}
+	created := response.Requests[0]
+	if created.Route != "/charges" || created.Status != 201 || created.Body.(map[string]interface{})["amount"] != float64(1000) {
+		t.Fatalf("unexpected first request: %+v", created)
+	}
+	fetched := response.Requests[1]
+	if fetched.Route != "/charges/{id}" || fetched.Params["id"] != "ch_1" || fetched.Query["expand"] != "customer" {
+		t.Fatalf("unexpected second request: %+v", fetched)
+	}
+	if unmatched := response.Requests[2]; unmatched.Route != "" || unmatched.Status != 503 {
+		t.Fatalf("unexpected third request: %+v", unmatched)
+	}
+
+	config = &MockConfig{Action: ActionRequests, ID: "payments", Method: "post", Clear: true}
+	if response, _ = execute(context.Background(), config, "run-1", nil); response.Count != 1 {
+		t.Fatalf("POST count = %d, want 1", response.Count)
+	}
+	if response, _ = execute(context.Background(), config, "run-1", nil); response.Count != 0 {
+		t.Fatalf("POST count after clear = %d, want 0", response.Count)
+	}
+}
+
+func TestLatency(t *testing.T) {
+	started := startTestServer(t, "run-latency", map[string]interface{}{
+		"action": "start",
+		"routes": []interface{}{map[string]interface{}{"path": "/slow", "latency": "150ms"}},
+	})
+
+	begin := time.Now()
+	if status, _, _ := send(t, http.MethodGet, started.URL+"/slow", ""); status != 200 {
+		t.Fatalf("GET /slow = %d", status)
+	}
+	if elapsed := time.Since(begin); elapsed < 150*time.Millisecond {
+		t.Fatalf("reply came after %s, want at least 150ms", elapsed)
+	}
+}
+
+func TestServersBelongToRun(t *testing.T) {
+	first := startTestServer(t, "run-a", map[string]interface{}{"action": "start", "id": "api"})
+	startTestServer(t, "run-b", map[string]interface{}{"action": "start", "id": "api"})
+	startTestServer(t, "run-a", map[string]interface{}{"action": "start", "id": "auth"})
+
+	if _, err := execute(context.Background(), &MockConfig{Action: ActionRequests, ID: "auth"}, "run-b", nil); err == nil {
+		t.Fatal("expected run-b to not see run-a's server")
+	}
+
+	response, err := execute(context.Background(), &MockConfig{Action: ActionStop}, "run-a", nil)
+	if err != nil {
+		t.Fatalf("execute() error = %v", err)
+	}
+	if len(response.Stopped) != 2 {
+		t.Fatalf("stopped = %v, want api and auth", response.Stopped)
+	}
+	if _, err := http.Get(first.URL); err == nil {
+		t.Fatal("expected stopped server to refuse connections")
+	}
+	if _, err := lookupServer("run-b", "api"); err != nil {
+		t.Fatalf("run-b's server was stopped: %v", err)
+	}
+
+	_, err = execute(context.Background(), &MockConfig{Action: ActionStop, ID: "api"}, "run-a", nil)
+	if err == nil || !strings.Contains(err.Error(), `no mock server "api"`) {
+		t.Fatalf("execute() error = %v", err)
+	}
+}
+
+func TestListenPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "egress.yaml")
+	if err := os.WriteFile(path, []byte("default:\n  allow: [\"127.0.0.1\"]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := egress.LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	egress.Configure(cfg)
+	t.Cleanup(func() { egress.Configure(nil) })
+	policy, err := egress.Resolve(egress.Scope{ProjectID: "p1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { stopServers("run-p", "") })
+
+	if _, err := execute(context.Background(), &MockConfig{Action: ActionStart, ID: "local"}, "run-p", policy); err != nil {
+		t.Fatalf("expected the default loopback address to be allowed, got %v", err)
+	}
+	_, err = execute(context.Background(), &MockConfig{Action: ActionStart, ID: "public", Listen: ":0"}, "run-p", policy)
+	if err == nil || !strings.Contains(err.Error(), "mock server can't listen on :0: egress to 0.0.0.0 is not allowed") {
+		t.Fatalf("expected all interfaces to be denied, got %v", err)
+	}
+	if _, err := lookupServer("run-p", "public"); err == nil {
+		t.Fatal("expected the denied server not to start")
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		match   bool
+		params  map[string]string
+	}{
+		{"/", "/", true, nil},
+		{"/users", "/users/", true, nil},
+		{"/users", "/users/1", false, nil},
+		{"/users/{id}", "/users/42", true, map[string]string{"id": "42"}},
+		{"/users/{id}/orders/{order}", "/users/42/orders/7", true, map[string]string{"id": "42", "order": "7"}},
+		{"/users/{id}", "/users", false, nil},
+		{"/files/*", "/files/a/b.txt", true, nil},
+		{"/files/*", "/other/a", false, nil},
+	}
+	for _, tc := range cases {
+		params, ok := matchPath(splitPattern(tc.pattern), tc.path)
+		if ok != tc.match {
+			t.Errorf("matchPath(%q, %q) = %v, want %v", tc.pattern, tc.path, ok, tc.match)
+			continue
+		}
+		for name, want := range tc.params {
+			if params[name] != want {
+				t.Errorf("matchPath(%q, %q) params = %v, want %v", tc.pattern, tc.path, params, tc.params)
+			}
+		}
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	cases := []struct {
+		name   string
+		config MockConfig
+		want   string
+	}{
+		{"no action", MockConfig{}, "action is required"},
+		{"unknown action", MockConfig{Action: "reset"}, "unknown action"},
+		{"relative path", MockConfig{Action: ActionStart, Routes: []RouteConfig{{Path: "users"}}}, "routes[0].path must start with /"},
+		{"bad status", MockConfig{Action: ActionStart, Routes: []RouteConfig{{Path: "/", ReplyConfig: ReplyConfig{Status: 42}}}}, "routes[0].status must be a valid HTTP status"},
+		{"requests without id", MockConfig{Action: ActionRequests}, "id is required for requests"},
+		{"slash in id", MockConfig{Action: ActionStop, ID: "a/b"}, "id must not contain /"},
+		{"valid", MockConfig{Action: ActionStart, Routes: []RouteConfig{{Path: "/health"}}}, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateConfig(&tc.config)
+			if tc.want == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
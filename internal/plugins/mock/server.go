@@ -0,0 +1,291 @@
+package mock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/egress"
+)
+
+const (
+	maxBodyBytes = 1 << 20
+	// maxRequests is how many requests a server keeps; older ones are dropped
+	maxRequests = 1000
+)
+
+// route is a RouteConfig ready to match
+type route struct {
+	method   string
+	pattern  string
+	segments []string
+	reply    ReplyConfig
+	latency  time.Duration
+}
+
+// server is a running stub server
+type server struct {
+	id      string
+	runID   string
+	url     string
+	routes  []route
+	reply   ReplyConfig
+	latency time.Duration
+	http    *http.Server
+	expiry  *time.Timer
+	now     func() time.Time
+
+	mu       sync.Mutex
+	requests []Request
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]*server{}
+)
+
+func registryKey(runID, id string) string {
+	return runID + "/" + id
+}
+
+// startServer binds the listener and serves routes until the server is stopped
+// or ttl passes. A server with the same ID in the same run is replaced. The
+// listen address must be one the policy allows, so a step can't open the
+// worker on interfaces its tests may not reach.
+func startServer(ctx context.Context, policy *egress.Policy, runID, id, listen, host string, routes []route, fallback ReplyConfig, ttl time.Duration) (*server, error) {
+	var fallbackLatency time.Duration
+	if fallback.Latency != "" {
+		parsed, err := time.ParseDuration(fallback.Latency)
+		if err != nil {
+			return nil, fmt.Errorf("invalid default.latency %q: %w", fallback.Latency, err)
+		}
+		fallbackLatency = parsed
+	}
+
+	listenHost, _, err := net.SplitHostPort(listen)
+	if err != nil {
+		return nil, fmt.Errorf("invalid listen address %q: %w", listen, err)
+	}
+	if listenHost == "" {
+		listenHost = "0.0.0.0"
+	}
+	if err := policy.Check(ctx, listenHost); err != nil {
+		return nil, fmt.Errorf("mock server can't listen on %s: %w", listen, err)
+	}
+
+	stopServers(runID, id)
+
+	lis, err := net.Listen("tcp", listen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start mock server on %s: %w", listen, err)
+	}
+	boundHost, port, _ := net.SplitHostPort(lis.Addr().String())
+	if host == "" {
+		host = boundHost
+		if ip := net.ParseIP(host); ip == nil || ip.IsUnspecified() || ip.IsLoopback() {
+			host = "localhost"
+		}
+	}
+
+	s := &server{
+		id:      id,
+		runID:   runID,
+		url:     "http://" + net.JoinHostPort(host, port),
+		routes:  routes,
+		reply:   fallback,
+		latency: fallbackLatency,
+		now:     time.Now,
+	}
+	s.http = &http.Server{Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = s.http.Serve(lis) }()
+	s.expiry = time.AfterFunc(ttl, func() { stopServers(runID, id) })
+
+	registryMu.Lock()
+	registry[registryKey(runID, id)] = s
+	registryMu.Unlock()
+	return s, nil
+}
+
+// lookupServer returns the run's server with id
+func lookupServer(runID, id string) (*server, error) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	s, ok := registry[registryKey(runID, id)]
+	if !ok {
+		return nil, fmt.Errorf("no mock server %q is running for this run on this worker", id)
+	}
+	return s, nil
+}
+
+// stopServers stops the run's server with id, or all of the run's servers when
+// id is empty, and returns the IDs it stopped
+func stopServers(runID, id string) []string {
+	registryMu.Lock()
+	var stopping []*server
+	for key, s := range registry {
+		if s.runID == runID && (id == "" || s.id == id) {
+			stopping = append(stopping, s)
+			delete(registry, key)
+		}
+	}
+	registryMu.Unlock()
+
+	stopped := make([]string, 0, len(stopping))
+	for _, s := range stopping {
+		s.expiry.Stop()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_ = s.http.Shutdown(ctx)
+		cancel()
+		stopped = append(stopped, s.id)
+	}
+	return stopped
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	raw, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes+1))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if len(raw) > maxBodyBytes {
+		http.Error(w, "body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	request := newRequest(r, raw, s.now())
+	reply, latency := s.reply, s.latency
+	if reply.Status == 0 {
+		reply.Status = http.StatusNotFound
+	}
+	for _, rt := range s.routes {
+		if rt.method != "" && !strings.EqualFold(rt.method, r.Method) {
+			continue
+		}
+		params, ok := matchPath(rt.segments, r.URL.Path)
+		if !ok {
+			continue
+		}
+		request.Route = rt.pattern
+		request.Params = params
+		reply, latency = rt.reply, rt.latency
+		if reply.Status == 0 {
+			reply.Status = http.StatusOK
+		}
+		break
+	}
+	request.Status = reply.Status
+	s.record(request)
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	for name, value := range reply.Headers {
+		w.Header().Set(name, value)
+	}
+	if reply.JSON && w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(reply.Status)
+	_, _ = io.WriteString(w, reply.Body)
+}
+
+func (s *server) record(request Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, request)
+	if len(s.requests) > maxRequests {
+		s.requests = s.requests[len(s.requests)-maxRequests:]
+	}
+}
+
+// received returns the requests matching method and the path pattern, oldest
+// first, and forgets them when clear is set
+func (s *server) received(method string, pattern []string, clear bool) []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matched, kept []Request
+	for _, request := range s.requests {
+		ok := method == "" || strings.EqualFold(method, request.Method)
+		if ok && pattern != nil {
+			_, ok = matchPath(pattern, request.Path)
+		}
+		if ok {
+			matched = append(matched, request)
+		} else {
+			kept = append(kept, request)
+		}
+	}
+	if clear {
+		s.requests = kept
+	}
+	return matched
+}
+
+func newRequest(r *http.Request, raw []byte, at time.Time) Request {
+	request := Request{
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Params:     map[string]string{},
+		Query:      make(map[string]string),
+		Headers:    make(map[string]string),
+		RawBody:    string(raw),
+		ReceivedAt: at.UTC().Format(time.RFC3339Nano),
+	}
+	for name, values := range r.URL.Query() {
+		request.Query[name] = strings.Join(values, ",")
+	}
+	for name, values := range r.Header {
+		request.Headers[name] = strings.Join(values, ", ")
+	}
+
+	request.Body = request.RawBody
+	var decoded interface{}
+	if len(raw) > 0 && json.Unmarshal(raw, &decoded) == nil {
+		request.Body = decoded
+	}
+	return request
+}
+
+// splitPattern breaks a path pattern into segments. {name} matches one segment
+// and * as the last segment matches the rest of the path.
+func splitPattern(pattern string) []string {
+	return strings.Split(strings.Trim(pattern, "/"), "/")
+}
+
+// matchPath reports whether path fits the pattern segments and returns the
+// values of its {name} segments
+func matchPath(segments []string, path string) (map[string]string, bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	params := map[string]string{}
+	for i, segment := range segments {
+		if segment == "*" && i == len(segments)-1 {
+			return params, true
+		}
+		if i >= len(parts) {
+			return nil, false
+		}
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") && parts[i] != "" {
+			params[segment[1:len(segment)-1]] = parts[i]
+			continue
+		}
+		if segment != parts[i] {
+			return nil, false
+		}
+	}
+	if len(parts) != len(segments) {
+		return nil, false
+	}
+	return params, true
+}
@@ -0,0 +1,89 @@
+package mock
+
+import "github.com/rocketship-ai/rocketship/internal/assertions"
+
+// MockPlugin represents a step that manages a stub HTTP server on the worker
+type MockPlugin struct {
+	Name   string     `json:"name" yaml:"name"`
+	Plugin string     `json:"plugin" yaml:"plugin"`
+	Config MockConfig `json:"config" yaml:"config"`
+}
+
+// MockConfig defines the action and the server it applies to
+type MockConfig struct {
+	Action string `json:"action" yaml:"action"`             // start, requests or stop
+	ID     string `json:"id,omitempty" yaml:"id,omitempty"` // Server name within the run; random for start when empty
+
+	// start
+	Listen  string        `json:"listen,omitempty" yaml:"listen,omitempty"`   // Address to bind (defaults to 127.0.0.1 on a random port)
+	Host    string        `json:"host,omitempty" yaml:"host,omitempty"`       // Host in the server URL (defaults to $ROCKETSHIP_MOCK_HOST, then the listen host or localhost)
+	Routes  []RouteConfig `json:"routes,omitempty" yaml:"routes,omitempty"`   // First matching route answers
+	Default *ReplyConfig  `json:"default,omitempty" yaml:"default,omitempty"` // Reply for requests no route matches (defaults to 404)
+	TTL     string        `json:"ttl,omitempty" yaml:"ttl,omitempty"`         // Stop the server after this long (defaults to 1h)
+
+	// requests
+	Method string `json:"method,omitempty" yaml:"method,omitempty"` // Only requests with this method
+	Path   string `json:"path,omitempty" yaml:"path,omitempty"`     // Only requests matching this path pattern
+	Clear  bool   `json:"clear,omitempty" yaml:"clear,omitempty"`   // Forget the returned requests
+
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"` // Overall step timeout (defaults to 30s)
+}
+
+// RouteConfig answers requests matching method and path
+type RouteConfig struct {
+	Method string `json:"method,omitempty" yaml:"method,omitempty"` // Any method when empty
+	Path   string `json:"path" yaml:"path"`                         // e.g. /users/{id} or /static/*
+	ReplyConfig
+}
+
+// ReplyConfig is what the server sends back
+type ReplyConfig struct {
+	Status  int               `json:"status,omitempty" yaml:"status,omitempty"` // Defaults to 200 for routes
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body    string            `json:"body,omitempty" yaml:"body,omitempty"`
+	JSON    bool              `json:"-" yaml:"-"`                                 // Body was given as an object or list
+	Latency string            `json:"latency,omitempty" yaml:"latency,omitempty"` // Delay before replying
+}
+
+// Actions supported by the mock plugin
+const (
+	ActionStart    = "start"
+	ActionRequests = "requests"
+	ActionStop     = "stop"
+)
+
+// Request is a call the server received
+type Request struct {
+	Method     string            `json:"method"`
+	Path       string            `json:"path"`
+	Route      string            `json:"route"`  // Path pattern of the route that answered, empty when none matched
+	Params     map[string]string `json:"params"` // Values of {name} segments in the route
+	Query      map[string]string `json:"query"`
+	Headers    map[string]string `json:"headers"`
+	Body       interface{}       `json:"body"` // Decoded when the body is JSON, the raw text otherwise
+	RawBody    string            `json:"raw_body"`
+	Status     int               `json:"status"` // Status the server replied with
+	ReceivedAt string            `json:"received_at"`
+}
+
+// MockResponse contains the result of the action
+type MockResponse struct {
+	Action   string    `json:"action"`
+	ID       string    `json:"id,omitempty"`
+	URL      string    `json:"url,omitempty"`      // Base URL of the server
+	Routes   int       `json:"routes,omitempty"`   // start: routes served
+	Requests []Request `json:"requests,omitempty"` // requests: matching requests, oldest first
+	Count    int       `json:"count"`              // requests: number of matching requests
+	Stopped  []string  `json:"stopped,omitempty"`  // stop: IDs of stopped servers
+	Duration string    `json:"duration"`
+}
+
+// AssertionResult represents a single assertion result for UI display
+type AssertionResult = assertions.Result
+
+// ActivityResponse represents the complete activity response
+type ActivityResponse struct {
+	Response         *MockResponse     `json:"response"`
+	Saved            map[string]string `json:"saved"`
+	AssertionResults []AssertionResult `json:"assertion_results,omitempty"`
+}
@@ -0,0 +1,88 @@
+package mongodb
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// buildTLSConfig loads the trusted CAs and the client certificate
+func buildTLSConfig(config *TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         config.ServerName,
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+
+	caPEM, err := pemValue("ca", config.CA, config.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	if caPEM != nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("tls: no certificates found in the CA")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	certPEM, err := pemValue("cert", config.Cert, config.CertFile)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := pemValue("key", config.Key, config.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case certPEM != nil:
+		if keyPEM == nil {
+			// The key may follow the certificate in the same PEM
+			keyPEM = certPEM
+		}
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("tls: failed to load the client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	case keyPEM != nil:
+		return nil, fmt.Errorf("tls: key needs a client certificate in cert or cert_file")
+	}
+	return tlsConfig, nil
+}
+
+// pemValue returns the PEM given inline or read from the file, or nil
+func pemValue(name, inline, file string) ([]byte, error) {
+	switch {
+	case inline != "" && file != "":
+		return nil, fmt.Errorf("tls: use either %s or %s_file, not both", name, name)
+	case inline != "":
+		return []byte(inline), nil
+	case file != "":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("tls: failed to read %s_file: %w", name, err)
+		}
+		return data, nil
+	}
+	return nil, nil
+}
+
+// buildCredential converts the auth block into driver credentials. They
+// replace any credentials in the URI.
+func buildCredential(config *AuthConfig) options.Credential {
+	credential := options.Credential{
+		AuthMechanism: config.Mechanism,
+		AuthSource:    config.Source,
+		Username:      config.Username,
+		Password:      config.Password,
+		PasswordSet:   config.Password != "",
+	}
+	if credential.AuthSource == "" && (config.Mechanism == MechanismX509 || config.Mechanism == MechanismPlain) {
+		credential.AuthSource = "$external"
+	}
+	return credential
+}
@@ -0,0 +1,122 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// bulkWrite sends the requests to the collection in one bulk write and
+// records what each of them created
+func bulkWrite(ctx context.Context, coll *mongo.Collection, op Operation, result *OperationResult) error {
+	models := make([]mongo.WriteModel, len(op.Requests))
+	result.Requests = make([]WriteResult, len(op.Requests))
+	for i, request := range op.Requests {
+		model, insertedID, err := writeModel(request)
+		if err != nil {
+			return fmt.Errorf("requests[%d]: %w", i, err)
+		}
+		models[i] = model
+		result.Requests[i] = WriteResult{Index: i, Operation: request.Operation, InsertedID: insertedID}
+	}
+
+	ordered := op.Ordered == nil || *op.Ordered
+	written, err := coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(ordered))
+	if err != nil {
+		return bulkWriteError(err, op.Requests)
+	}
+
+	for index, id := range written.UpsertedIDs {
+		result.Requests[index].UpsertedID = fromBSON(id)
+	}
+	result.InsertedCount = written.InsertedCount
+	result.MatchedCount = written.MatchedCount
+	result.ModifiedCount = written.ModifiedCount
+	result.UpsertedCount = written.UpsertedCount
+	result.DeletedCount = written.DeletedCount
+	result.Count = written.InsertedCount + written.ModifiedCount + written.UpsertedCount + written.DeletedCount
+	return nil
+}
+
+// writeModel converts a request into a write model. Inserted documents get
+// their _id here when they have none, so the result can report it.
+func writeModel(request Operation) (mongo.WriteModel, interface{}, error) {
+	var filter interface{} = bson.D{}
+	if request.Filter != nil {
+		var err error
+		if filter, err = toBSON(request.Filter); err != nil {
+			return nil, nil, fmt.Errorf("invalid filter: %w", err)
+		}
+	}
+
+	switch request.Operation {
+	case OperationInsertOne:
+		converted, err := toBSON(request.Document)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid document: %w", err)
+		}
+		doc := converted.(bson.D)
+		for _, elem := range doc {
+			if elem.Key == "_id" {
+				return mongo.NewInsertOneModel().SetDocument(doc), fromBSON(elem.Value), nil
+			}
+		}
+		id := primitive.NewObjectID()
+		doc = append(bson.D{{Key: "_id", Value: id}}, doc...)
+		return mongo.NewInsertOneModel().SetDocument(doc), id.Hex(), nil
+
+	case OperationUpdateOne, OperationUpdateMany:
+		update, err := toBSON(request.Update)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid update: %w", err)
+		}
+		if request.Operation == OperationUpdateOne {
+			return mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update).SetUpsert(request.Upsert), nil, nil
+		}
+		return mongo.NewUpdateManyModel().SetFilter(filter).SetUpdate(update).SetUpsert(request.Upsert), nil, nil
+
+	case OperationReplaceOne:
+		replacement, err := toBSON(request.Replacement)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid replacement: %w", err)
+		}
+		return mongo.NewReplaceOneModel().SetFilter(filter).SetReplacement(replacement).SetUpsert(request.Upsert), nil, nil
+
+	case OperationDeleteOne:
+		return mongo.NewDeleteOneModel().SetFilter(filter), nil, nil
+
+	case OperationDeleteMany:
+		return mongo.NewDeleteManyModel().SetFilter(filter), nil, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported bulk_write request %q", request.Operation)
+}
+
+// bulkWriteError names the requests that failed
+func bulkWriteError(err error, requests []Operation) error {
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) {
+		return err
+	}
+
+	var failures []string
+	for _, writeErr := range bulkErr.WriteErrors {
+		operation := ""
+		if writeErr.Index < len(requests) {
+			operation = requests[writeErr.Index].Operation
+		}
+		failures = append(failures, fmt.Sprintf("request %d (%s): %s", writeErr.Index, operation, writeErr.Message))
+	}
+	if bulkErr.WriteConcernError != nil {
+		failures = append(failures, fmt.Sprintf("write concern: %s", bulkErr.WriteConcernError.Message))
+	}
+	if len(failures) == 0 {
+		return err
+	}
+	return fmt.Errorf("bulk write failed: %s", strings.Join(failures, "; "))
+}
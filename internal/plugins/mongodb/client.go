@@ -0,0 +1,443 @@
+package mongodb
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/egress"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// illegalOperation is the server's error code for a transaction on a standalone server
+const illegalOperation = 20
+
+// connect opens a client for the step. Connections go through the egress policy.
+func connect(ctx context.Context, config *MongoDBConfig, policy *egress.Policy) (*mongo.Client, error) {
+	opts := options.Client().ApplyURI(config.URI).SetDialer(policy.Dialer()).SetAppName("rocketship")
+	if config.TLS != nil {
+		tlsConfig, err := buildTLSConfig(config.TLS)
+		if err != nil {
+			return nil, err
+		}
+		opts.SetTLSConfig(tlsConfig)
+	}
+	if config.Auth != nil {
+		opts.SetAuth(buildCredential(config.Auth))
+	}
+	client, err := mongo.Connect(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to mongodb: %w", err)
+	}
+	return client, nil
+}
+
+// runOperation runs one operation against its collection. ctx carries the
+// session when the operation is part of a transaction.
+func runOperation(ctx context.Context, db *mongo.Database, op Operation) (*OperationResult, error) {
+	result := &OperationResult{
+		Operation:  op.Operation,
+		Collection: op.Collection,
+		Documents:  []map[string]interface{}{},
+	}
+	coll := db.Collection(op.Collection)
+
+	filter, err := toBSON(op.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+	if op.Filter == nil {
+		filter = bson.D{}
+	}
+
+	switch op.Operation {
+	case OperationInsertOne:
+		doc, err := toBSON(op.Document)
+		if err != nil {
+			return nil, fmt.Errorf("invalid document: %w", err)
+		}
+		inserted, err := coll.InsertOne(ctx, doc)
+		if err != nil {
+			return nil, err
+		}
+		result.InsertedIDs = []interface{}{fromBSON(inserted.InsertedID)}
+		result.Count = 1
+
+	case OperationInsertMany:
+		docs := make([]interface{}, len(op.Documents))
+		for i, document := range op.Documents {
+			if docs[i], err = toBSON(document); err != nil {
+				return nil, fmt.Errorf("invalid documents[%d]: %w", i, err)
+			}
+		}
+		inserted, err := coll.InsertMany(ctx, docs)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range inserted.InsertedIDs {
+			result.InsertedIDs = append(result.InsertedIDs, fromBSON(id))
+		}
+		result.Count = int64(len(inserted.InsertedIDs))
+
+	case OperationFind, OperationFindOne:
+		findOpts := options.Find()
+		if op.Projection != nil {
+			projection, err := toBSON(op.Projection)
+			if err != nil {
+				return nil, fmt.Errorf("invalid projection: %w", err)
+			}
+			findOpts.SetProjection(projection)
+		}
+		if op.Sort != nil {
+			sort, err := sortSpec(op.Sort)
+			if err != nil {
+				return nil, err
+			}
+			findOpts.SetSort(sort)
+		}
+		if op.Skip > 0 {
+			findOpts.SetSkip(op.Skip)
+		}
+		switch {
+		case op.Operation == OperationFindOne:
+			findOpts.SetLimit(1)
+		case op.Limit > 0:
+			findOpts.SetLimit(op.Limit)
+		}
+		cursor, err := coll.Find(ctx, filter, findOpts)
+		if err != nil {
+			return nil, err
+		}
+		if err := readDocuments(ctx, cursor, result); err != nil {
+			return nil, err
+		}
+
+	case OperationUpdateOne, OperationUpdateMany:
+		update, err := toBSON(op.Update)
+		if err != nil {
+			return nil, fmt.Errorf("invalid update: %w", err)
+		}
+		updateOpts := options.Update().SetUpsert(op.Upsert)
+		var updated *mongo.UpdateResult
+		if op.Operation == OperationUpdateOne {
+			updated, err = coll.UpdateOne(ctx, filter, update, updateOpts)
+		} else {
+			updated, err = coll.UpdateMany(ctx, filter, update, updateOpts)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.MatchedCount = updated.MatchedCount
+		result.ModifiedCount = updated.ModifiedCount
+		result.UpsertedCount = updated.UpsertedCount
+		result.UpsertedID = fromBSON(updated.UpsertedID)
+		result.Count = updated.ModifiedCount + updated.UpsertedCount
+
+	case OperationReplaceOne:
+		replacement, err := toBSON(op.Replacement)
+		if err != nil {
+			return nil, fmt.Errorf("invalid replacement: %w", err)
+		}
+		replaced, err := coll.ReplaceOne(ctx, filter, replacement, options.Replace().SetUpsert(op.Upsert))
+		if err != nil {
+			return nil, err
+		}
+		result.MatchedCount = replaced.MatchedCount
+		result.ModifiedCount = replaced.ModifiedCount
+		result.UpsertedCount = replaced.UpsertedCount
+		result.UpsertedID = fromBSON(replaced.UpsertedID)
+		result.Count = replaced.ModifiedCount + replaced.UpsertedCount
+
+	case OperationFindOneAndUpdate, OperationFindOneAndReplace:
+		if err := findOneAndModify(ctx, coll, filter, op, result); err != nil {
+			return nil, err
+		}
+
+	case OperationBulkWrite:
+		if err := bulkWrite(ctx, coll, op, result); err != nil {
+			return nil, err
+		}
+
+	case OperationGridFSUpload:
+		if err := gridFSUpload(ctx, db, op, result); err != nil {
+			return nil, err
+		}
+
+	case OperationGridFSDownload:
+		if err := gridFSDownload(ctx, db, op, result); err != nil {
+			return nil, err
+		}
+
+	case OperationDeleteOne, OperationDeleteMany:
+		var deleted *mongo.DeleteResult
+		if op.Operation == OperationDeleteOne {
+			deleted, err = coll.DeleteOne(ctx, filter)
+		} else {
+			deleted, err = coll.DeleteMany(ctx, filter)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.DeletedCount = deleted.DeletedCount
+		result.Count = deleted.DeletedCount
+
+	case OperationCount:
+		count, err := coll.CountDocuments(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		result.Count = count
+
+	case OperationAggregate:
+		pipeline, err := toBSON(op.Pipeline)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pipeline: %w", err)
+		}
+		cursor, err := coll.Aggregate(ctx, pipeline)
+		if err != nil {
+			return nil, err
+		}
+		if err := readDocuments(ctx, cursor, result); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported operation %q", op.Operation)
+	}
+
+	return result, nil
+}
+
+// findOneAndModify updates or replaces the first matching document and
+// returns it as it was before the change, or after with return_document: after
+func findOneAndModify(ctx context.Context, coll *mongo.Collection, filter interface{}, op Operation, result *OperationResult) error {
+	returnDocument := options.Before
+	if op.ReturnDocument == ReturnDocumentAfter {
+		returnDocument = options.After
+	}
+	var projection interface{}
+	if op.Projection != nil {
+		var err error
+		if projection, err = toBSON(op.Projection); err != nil {
+			return fmt.Errorf("invalid projection: %w", err)
+		}
+	}
+	var sort bson.D
+	if op.Sort != nil {
+		var err error
+		if sort, err = sortSpec(op.Sort); err != nil {
+			return err
+		}
+	}
+
+	var single *mongo.SingleResult
+	if op.Operation == OperationFindOneAndUpdate {
+		update, err := toBSON(op.Update)
+		if err != nil {
+			return fmt.Errorf("invalid update: %w", err)
+		}
+		opts := options.FindOneAndUpdate().SetUpsert(op.Upsert).SetReturnDocument(returnDocument)
+		if projection != nil {
+			opts.SetProjection(projection)
+		}
+		if sort != nil {
+			opts.SetSort(sort)
+		}
+		single = coll.FindOneAndUpdate(ctx, filter, update, opts)
+	} else {
+		replacement, err := toBSON(op.Replacement)
+		if err != nil {
+			return fmt.Errorf("invalid replacement: %w", err)
+		}
+		opts := options.FindOneAndReplace().SetUpsert(op.Upsert).SetReturnDocument(returnDocument)
+		if projection != nil {
+			opts.SetProjection(projection)
+		}
+		if sort != nil {
+			opts.SetSort(sort)
+		}
+		single = coll.FindOneAndReplace(ctx, filter, replacement, opts)
+	}
+
+	var doc bson.D
+	if err := single.Decode(&doc); err != nil {
+		// Nothing matched, or an upsert inserted with return_document: before
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		return err
+	}
+	result.Documents = append(result.Documents, fromBSON(doc).(map[string]interface{}))
+	result.Count = 1
+	return nil
+}
+
+// readDocuments drains the cursor into the result
+func readDocuments(ctx context.Context, cursor *mongo.Cursor, result *OperationResult) error {
+	var docs []bson.D
+	if err := cursor.All(ctx, &docs); err != nil {
+		return fmt.Errorf("failed to read documents: %w", err)
+	}
+	for _, doc := range docs {
+		result.Documents = append(result.Documents, fromBSON(doc).(map[string]interface{}))
+	}
+	result.Count = int64(len(result.Documents))
+	return nil
+}
+
+// runTransaction runs the operations in one transaction and commits or
+// aborts it as configured. A failed operation aborts the transaction.
+func runTransaction(ctx context.Context, client *mongo.Client, db *mongo.Database, tx *TransactionConfig) ([]OperationResult, string, error) {
+	txOpts := options.Transaction()
+	if tx.ReadConcern != "" {
+		txOpts.SetReadConcern(readconcern.New(readconcern.Level(tx.ReadConcern)))
+	}
+	if tx.WriteConcern != "" {
+		wc := writeconcern.Majority()
+		if tx.WriteConcern != "majority" {
+			w, _ := strconv.Atoi(tx.WriteConcern)
+			wc = &writeconcern.WriteConcern{W: w}
+		}
+		txOpts.SetWriteConcern(wc)
+	}
+
+	session, err := client.StartSession()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(context.Background())
+
+	if err := session.StartTransaction(txOpts); err != nil {
+		return nil, "", fmt.Errorf("failed to start transaction: %w", err)
+	}
+	sessionCtx := mongo.NewSessionContext(ctx, session)
+
+	results := make([]OperationResult, 0, len(tx.Operations))
+	for i, op := range tx.Operations {
+		result, err := runOperation(sessionCtx, db, op)
+		if err != nil {
+			_ = session.AbortTransaction(context.Background())
+			return results, "aborted", transactionError(fmt.Errorf("transaction aborted: operation %d (%s on %s) failed: %w", i, op.Operation, op.Collection, err))
+		}
+		results = append(results, *result)
+	}
+
+	if tx.End == TransactionAbort {
+		if err := session.AbortTransaction(ctx); err != nil {
+			return results, "", fmt.Errorf("failed to abort transaction: %w", err)
+		}
+		return results, "aborted", nil
+	}
+	if err := session.CommitTransaction(ctx); err != nil {
+		return results, "", transactionError(fmt.Errorf("failed to commit transaction: %w", err))
+	}
+	return results, "committed", nil
+}
+
+// transactionError explains the server refusing a transaction because it
+// isn't part of a replica set
+func transactionError(err error) error {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == illegalOperation {
+		return fmt.Errorf("%w (transactions need a replica set or sharded cluster)", err)
+	}
+	return err
+}
+
+// sortSpec converts {field: 1} or a list of such objects, for sorts on
+// several keys, into an ordered sort document
+func sortSpec(v interface{}) (bson.D, error) {
+	var keys []interface{}
+	switch sort := v.(type) {
+	case map[string]interface{}:
+		keys = []interface{}{sort}
+	case []interface{}:
+		keys = sort
+	default:
+		return nil, fmt.Errorf("sort must be an object or a list of objects, got %T", v)
+	}
+
+	var spec bson.D
+	for _, key := range keys {
+		fields, ok := key.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("sort entries must be objects like {created_at: -1}, got %T", key)
+		}
+		doc, err := toBSON(fields)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sort: %w", err)
+		}
+		spec = append(spec, doc.(bson.D)...)
+	}
+	return spec, nil
+}
+
+// toBSON converts a YAML value into BSON through Extended JSON, so tests can
+// write {"$oid": ...} and {"$date": ...}, and whole numbers become integers
+func toBSON(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(map[string]interface{}{"v": v})
+	if err != nil {
+		return nil, err
+	}
+	var doc bson.D
+	if err := bson.UnmarshalExtJSON(data, false, &doc); err != nil {
+		return nil, err
+	}
+	return doc[0].Value, nil
+}
+
+// fromBSON converts a BSON value into plain JSON values for assertions and
+// saves: ObjectIDs become hex strings, dates RFC 3339 strings and decimals
+// their text
+func fromBSON(v interface{}) interface{} {
+	switch value := v.(type) {
+	case bson.D:
+		doc := make(map[string]interface{}, len(value))
+		for _, elem := range value {
+			doc[elem.Key] = fromBSON(elem.Value)
+		}
+		return doc
+	case bson.M:
+		doc := make(map[string]interface{}, len(value))
+		for key, elem := range value {
+			doc[key] = fromBSON(elem)
+		}
+		return doc
+	case bson.A:
+		list := make([]interface{}, len(value))
+		for i, elem := range value {
+			list[i] = fromBSON(elem)
+		}
+		return list
+	case []interface{}:
+		return fromBSON(bson.A(value))
+	case primitive.ObjectID:
+		return value.Hex()
+	case primitive.DateTime:
+		return value.Time().UTC().Format(time.RFC3339Nano)
+	case time.Time:
+		return value.UTC().Format(time.RFC3339Nano)
+	case primitive.Decimal128:
+		return value.String()
+	case primitive.Binary:
+		return base64.StdEncoding.EncodeToString(value.Data)
+	case primitive.Regex:
+		return "/" + value.Pattern + "/" + value.Options
+	case primitive.Timestamp:
+		return map[string]interface{}{"t": value.T, "i": value.I}
+	case primitive.Null, primitive.Undefined:
+		return nil
+	case nil, string, bool, int32, int64, float64:
+		return value
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,205 @@
+package mongodb
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultBucket    = "fs"
+	defaultAlgorithm = "sha256"
+
+	// gridfs_download keeps at most this many bytes of content; the checksum
+	// still covers the whole file
+	maxContentBytes = 10 << 20
+)
+
+// openBucket opens the operation's bucket. GridFS calls take deadlines rather
+// than contexts, so they get the step's.
+func openBucket(ctx context.Context, db *mongo.Database, op Operation) (*gridfs.Bucket, error) {
+	bucket, err := gridfs.NewBucket(db, options.GridFSBucket().SetName(bucketName(op)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bucket %s: %w", bucketName(op), err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = bucket.SetWriteDeadline(deadline)
+		_ = bucket.SetReadDeadline(deadline)
+	}
+	return bucket, nil
+}
+
+// gridFSUpload stores the content as a new file, or a new revision of the
+// filename
+func gridFSUpload(ctx context.Context, db *mongo.Database, op Operation, result *OperationResult) error {
+	data := []byte(*op.Content)
+	if op.Encoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(*op.Content))
+		if err != nil {
+			return fmt.Errorf("content is not valid base64: %w", err)
+		}
+		data = decoded
+	}
+
+	bucket, err := openBucket(ctx, db, op)
+	if err != nil {
+		return err
+	}
+	uploadOpts := options.GridFSUpload()
+	if op.Metadata != nil {
+		metadata, err := toBSON(op.Metadata)
+		if err != nil {
+			return fmt.Errorf("invalid metadata: %w", err)
+		}
+		uploadOpts.SetMetadata(metadata)
+	}
+	id, err := bucket.UploadFromStream(op.Filename, bytes.NewReader(data), uploadOpts)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", op.Filename, err)
+	}
+
+	h := newHash(op.Algorithm)
+	h.Write(data)
+	result.Collection = bucketName(op)
+	result.File = &FileResult{
+		ID:        id.Hex(),
+		Filename:  op.Filename,
+		Length:    int64(len(data)),
+		Metadata:  op.Metadata,
+		Algorithm: algorithmName(op.Algorithm),
+		Checksum:  hex.EncodeToString(h.Sum(nil)),
+	}
+	result.InsertedIDs = []interface{}{id.Hex()}
+	result.Count = 1
+	return nil
+}
+
+// gridFSDownload reads a file by ID, or the latest revision of a filename, and
+// checksums all of it
+func gridFSDownload(ctx context.Context, db *mongo.Database, op Operation, result *OperationResult) error {
+	bucket, err := openBucket(ctx, db, op)
+	if err != nil {
+		return err
+	}
+
+	var stream *gridfs.DownloadStream
+	name := op.Filename
+	if op.FileID != "" {
+		name = op.FileID
+		stream, err = bucket.OpenDownloadStream(fileID(op.FileID))
+	} else {
+		stream, err = bucket.OpenDownloadStreamByName(op.Filename)
+	}
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return fmt.Errorf("file %s not found in bucket %s", name, bucketName(op))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer func() { _ = stream.Close() }()
+
+	h := newHash(op.Algorithm)
+	content := &prefixWriter{limit: maxContentBytes}
+	length, err := io.Copy(io.MultiWriter(h, content), stream)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", name, err)
+	}
+
+	file := stream.GetFile()
+	result.Collection = bucketName(op)
+	result.File = &FileResult{
+		ID:         fromBSON(file.ID),
+		Filename:   file.Name,
+		Length:     length,
+		UploadDate: file.UploadDate.UTC().Format(time.RFC3339Nano),
+		Truncated:  length > int64(len(content.data)),
+		Algorithm:  algorithmName(op.Algorithm),
+		Checksum:   hex.EncodeToString(h.Sum(nil)),
+	}
+	if utf8.Valid(content.data) {
+		result.File.Content = string(content.data)
+	} else {
+		result.File.Content = base64.StdEncoding.EncodeToString(content.data)
+		result.File.Encoding = "base64"
+	}
+	if len(file.Metadata) > 0 {
+		var metadata bson.D
+		if err := bson.Unmarshal(file.Metadata, &metadata); err != nil {
+			return fmt.Errorf("failed to read metadata of %s: %w", name, err)
+		}
+		result.File.Metadata = fromBSON(metadata).(map[string]interface{})
+	}
+	result.Count = 1
+	return nil
+}
+
+// fileID reads a file ID as an ObjectID when it is one, since that is what
+// uploads create
+func fileID(id string) interface{} {
+	if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+		return oid
+	}
+	return id
+}
+
+func bucketName(op Operation) string {
+	if op.Bucket == "" {
+		return defaultBucket
+	}
+	return op.Bucket
+}
+
+// prefixWriter keeps the first limit bytes written to it
+type prefixWriter struct {
+	data  []byte
+	limit int
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	if room := w.limit - len(w.data); room > 0 {
+		if len(p) > room {
+			w.data = append(w.data, p[:room]...)
+		} else {
+			w.data = append(w.data, p...)
+		}
+	}
+	return len(p), nil
+}
+
+func newHash(algorithm string) hash.Hash {
+	switch algorithmName(algorithm) {
+	case "md5":
+		return md5.New()
+	case "sha1":
+		return sha1.New()
+	case "sha512":
+		return sha512.New()
+	default:
+		return sha256.New()
+	}
+}
+
+func algorithmName(algorithm string) string {
+	if algorithm == "" {
+		return defaultAlgorithm
+	}
+	return strings.ToLower(algorithm)
+}
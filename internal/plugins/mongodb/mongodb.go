@@ -0,0 +1,699 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+	"go.temporal.io/sdk/activity"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// Auto-register the plugin when the package is imported
+func init() {
+	plugins.RegisterPlugin(&MongoDBPlugin{})
+}
+
+// GetType returns the plugin type identifier
+func (mp *MongoDBPlugin) GetType() string {
+	return "mongodb"
+}
+
+// Activity runs an operation, or a transaction of several, against MongoDB
+// and checks the result
+func (mp *MongoDBPlugin) Activity(ctx context.Context, p map[string]interface{}) (interface{}, error) {
+	logger := activity.GetLogger(ctx)
+
+	// Parse configuration from parameters
+	configData, ok := p["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid config format")
+	}
+
+	config := &MongoDBConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		return nil, fmt.Errorf("failed to parse mongodb config: %w", err)
+	}
+
+	timeout := defaultTimeout
+	if config.Timeout != "" {
+		parsed, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", config.Timeout, err)
+		}
+		timeout = parsed
+	}
+
+	// Convert state to map[string]interface{} for template processing
+	state := make(map[string]interface{})
+	if stateStr, ok := p["state"].(map[string]string); ok {
+		for k, v := range stateStr {
+			state[k] = v
+		}
+	} else if stateInt, ok := p["state"].(map[string]interface{}); ok {
+		state = stateInt
+	}
+
+	// Extract env secrets from params (for {{ .env.* }} template resolution)
+	env := make(map[string]string)
+	if envData, ok := p["env"].(map[string]interface{}); ok {
+		for k, v := range envData {
+			if strVal, ok := v.(string); ok {
+				env[k] = strVal
+			}
+		}
+	} else if envData, ok := p["env"].(map[string]string); ok {
+		env = envData
+	}
+
+	if err := applyVariableReplacement(config, state, env); err != nil {
+		return nil, fmt.Errorf("variable replacement failed: %w", err)
+	}
+
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	policy, err := egress.FromParams(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
+
+	logger.Info("Executing mongodb plugin", "database", config.Database, "operation", config.Operation.Operation, "transaction", config.Transaction != nil)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	response, err := execute(ctx, config, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := assertions.Normalize(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare mongodb result: %w", err)
+	}
+
+	assertionResults, err := processAssertions(p, response, subject, state, env)
+	if err != nil {
+		return nil, err
+	}
+	if failure := assertions.Summary(assertionResults); failure != "" {
+		return nil, fmt.Errorf("%s", failure)
+	}
+
+	saved := make(map[string]string)
+	if err := saves.Process(p, subject, saved); err != nil {
+		return nil, err
+	}
+
+	logger.Info("MongoDB step completed", "operations", len(response.Operations), "count", response.Count, "transaction", response.Transaction, "duration", response.Duration)
+
+	return &ActivityResponse{
+		Response:         response,
+		Saved:            saved,
+		AssertionResults: assertionResults,
+	}, nil
+}
+
+// execute connects and runs the step's operation or transaction
+func execute(ctx context.Context, config *MongoDBConfig, policy *egress.Policy) (*MongoDBResponse, error) {
+	start := time.Now()
+
+	client, err := connect(ctx, config, policy)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = client.Disconnect(context.Background()) }()
+	db := client.Database(config.Database)
+
+	response := &MongoDBResponse{}
+	if config.Transaction != nil {
+		results, outcome, err := runTransaction(ctx, client, db, config.Transaction)
+		if err != nil {
+			return nil, err
+		}
+		response.Operations = results
+		response.Transaction = outcome
+	} else {
+		result, err := runOperation(ctx, db, config.Operation)
+		if err != nil {
+			return nil, fmt.Errorf("%s on %s failed: %w", config.Operation.Operation, config.Operation.Collection, err)
+		}
+		response.Operations = []OperationResult{*result}
+	}
+
+	last := response.Operations[len(response.Operations)-1]
+	response.Documents = last.Documents
+	response.Count = last.Count
+	response.Duration = time.Since(start).String()
+	return response, nil
+}
+
+// processAssertions evaluates all assertions and returns their results
+func processAssertions(p map[string]interface{}, response *MongoDBResponse, subject interface{}, state map[string]interface{}, env map[string]string) ([]AssertionResult, error) {
+	context := dsl.TemplateContext{Runtime: state, Env: env}
+	return assertions.Process(p, subject, context, func(assertionMap map[string]interface{}, result *AssertionResult) bool {
+		return evaluateAssertion(response, assertionMap, result)
+	})
+}
+
+// evaluateAssertion evaluates the mongodb plugin's own assertion types
+func evaluateAssertion(response *MongoDBResponse, assertionMap map[string]interface{}, result *AssertionResult) bool {
+	expected := result.Expected
+	switch result.Type {
+	case AssertionTypeDocumentCount:
+		result.Actual = response.Count
+		if !assertions.Equal(float64(response.Count), expected) {
+			result.Message = fmt.Sprintf("expected %v documents, got %d", expected, response.Count)
+		} else {
+			result.Passed = true
+		}
+
+	case AssertionTypeChecksum:
+		file := response.Operations[len(response.Operations)-1].File
+		expectedStr, _ := expected.(string)
+		switch {
+		case file == nil:
+			result.Message = "no file to checksum (use gridfs_upload or gridfs_download)"
+		case !strings.EqualFold(strings.TrimSpace(expectedStr), file.Checksum):
+			result.Actual = file.Checksum
+			result.Message = fmt.Sprintf("expected %s checksum %v, got %s", file.Algorithm, expected, file.Checksum)
+		default:
+			result.Actual = file.Checksum
+			result.Passed = true
+		}
+
+	default:
+		return false
+	}
+	return true
+}
+
+// validateConfig checks the step has what it needs once templates are rendered
+func validateConfig(config *MongoDBConfig) error {
+	if config.URI == "" {
+		return fmt.Errorf("uri is required")
+	}
+	if config.Database == "" {
+		return fmt.Errorf("database is required")
+	}
+	if err := validateAuth(config); err != nil {
+		return err
+	}
+
+	if config.Transaction == nil {
+		return validateOperation(&config.Operation, config.Collection)
+	}
+	if config.Operation.Operation != "" {
+		return fmt.Errorf("use either operation or transaction, not both")
+	}
+
+	tx := config.Transaction
+	if len(tx.Operations) == 0 {
+		return fmt.Errorf("transaction.operations must list at least one operation")
+	}
+	for i := range tx.Operations {
+		if err := validateOperation(&tx.Operations[i], config.Collection); err != nil {
+			return fmt.Errorf("transaction.operations[%d]: %w", i, err)
+		}
+		if strings.HasPrefix(tx.Operations[i].Operation, "gridfs_") {
+			return fmt.Errorf("transaction.operations[%d]: %s can't run in a transaction", i, tx.Operations[i].Operation)
+		}
+	}
+	switch tx.End {
+	case "":
+		tx.End = TransactionCommit
+	case TransactionCommit, TransactionAbort:
+	default:
+		return fmt.Errorf("transaction.end must be commit or abort, got %q", tx.End)
+	}
+	switch tx.ReadConcern {
+	case "", "local", "majority", "snapshot":
+	default:
+		return fmt.Errorf("transaction.read_concern must be local, majority or snapshot, got %q", tx.ReadConcern)
+	}
+	if tx.WriteConcern != "" && tx.WriteConcern != "majority" {
+		if w, err := strconv.Atoi(tx.WriteConcern); err != nil || w < 0 {
+			return fmt.Errorf("transaction.write_concern must be majority or a number of members, got %q", tx.WriteConcern)
+		}
+	}
+	return nil
+}
+
+// validateAuth checks the auth block has the credentials its mechanism needs
+func validateAuth(config *MongoDBConfig) error {
+	auth := config.Auth
+	if auth == nil {
+		return nil
+	}
+	switch auth.Mechanism {
+	case "", MechanismSCRAMSHA256, MechanismSCRAMSHA1, MechanismPlain:
+		if auth.Username == "" || auth.Password == "" {
+			return fmt.Errorf("auth needs username and password for %s", mechanismName(auth.Mechanism))
+		}
+	case MechanismX509:
+		if auth.Password != "" {
+			return fmt.Errorf("auth.password can't be used with MONGODB-X509, which authenticates with the client certificate")
+		}
+		if config.TLS == nil || (config.TLS.Cert == "" && config.TLS.CertFile == "") {
+			return fmt.Errorf("MONGODB-X509 needs a client certificate in tls.cert or tls.cert_file")
+		}
+	default:
+		return fmt.Errorf("auth.mechanism must be SCRAM-SHA-256, SCRAM-SHA-1, MONGODB-X509 or PLAIN, got %q", auth.Mechanism)
+	}
+	return nil
+}
+
+func mechanismName(mechanism string) string {
+	if mechanism == "" {
+		return "the default mechanism"
+	}
+	return mechanism
+}
+
+// validateOperation checks an operation has the arguments it needs, and
+// defaults its collection to the step's
+func validateOperation(op *Operation, collection string) error {
+	if op.Operation == "" {
+		return fmt.Errorf("operation is required")
+	}
+	if op.Collection == "" {
+		op.Collection = collection
+	}
+	// GridFS operations work on a bucket rather than a collection
+	if op.Collection == "" && !strings.HasPrefix(op.Operation, "gridfs_") {
+		return fmt.Errorf("collection is required for %s", op.Operation)
+	}
+
+	switch op.Operation {
+	case OperationInsertOne:
+		if op.Document == nil {
+			return fmt.Errorf("document is required for insert_one")
+		}
+	case OperationInsertMany:
+		if len(op.Documents) == 0 {
+			return fmt.Errorf("documents is required for insert_many")
+		}
+	case OperationUpdateOne, OperationUpdateMany:
+		if op.Update == nil {
+			return fmt.Errorf("update is required for %s", op.Operation)
+		}
+	case OperationDeleteOne, OperationDeleteMany:
+		if op.Filter == nil {
+			// An empty filter deletes everything, so it must be spelled out
+			return fmt.Errorf("filter is required for %s; use {} to match every document", op.Operation)
+		}
+	case OperationAggregate:
+		if op.Pipeline == nil {
+			return fmt.Errorf("pipeline is required for aggregate")
+		}
+	case OperationReplaceOne, OperationFindOneAndReplace:
+		if op.Replacement == nil {
+			return fmt.Errorf("replacement is required for %s", op.Operation)
+		}
+	case OperationFindOneAndUpdate:
+		if op.Update == nil {
+			return fmt.Errorf("update is required for find_one_and_update")
+		}
+	case OperationBulkWrite:
+		if len(op.Requests) == 0 {
+			return fmt.Errorf("requests is required for bulk_write")
+		}
+		for i := range op.Requests {
+			if err := validateWriteRequest(&op.Requests[i], op.Collection); err != nil {
+				return fmt.Errorf("requests[%d]: %w", i, err)
+			}
+		}
+	case OperationGridFSUpload:
+		if op.Filename == "" {
+			return fmt.Errorf("filename is required for gridfs_upload")
+		}
+		if op.Content == nil {
+			return fmt.Errorf("content is required for gridfs_upload")
+		}
+		switch op.Encoding {
+		case "", "utf8", "base64":
+		default:
+			return fmt.Errorf("encoding must be utf8 or base64, got %q", op.Encoding)
+		}
+	case OperationGridFSDownload:
+		if (op.FileID == "") == (op.Filename == "") {
+			return fmt.Errorf("gridfs_download needs either file_id or filename")
+		}
+	case OperationFind, OperationFindOne, OperationCount:
+	default:
+		return fmt.Errorf("unsupported operation %q: use insert_one, insert_many, find, find_one, update_one, update_many, replace_one, delete_one, delete_many, count, aggregate, find_one_and_update, find_one_and_replace, bulk_write, gridfs_upload or gridfs_download", op.Operation)
+	}
+
+	switch op.ReturnDocument {
+	case "", ReturnDocumentBefore, ReturnDocumentAfter:
+	default:
+		return fmt.Errorf("return_document must be before or after, got %q", op.ReturnDocument)
+	}
+	switch algorithmName(op.Algorithm) {
+	case "md5", "sha1", "sha256", "sha512":
+	default:
+		return fmt.Errorf("algorithm must be md5, sha1, sha256 or sha512, got %q", op.Algorithm)
+	}
+	return nil
+}
+
+// validateWriteRequest checks one request of a bulk write. Requests write to
+// the bulk write's collection.
+func validateWriteRequest(request *Operation, collection string) error {
+	switch request.Operation {
+	case OperationInsertOne, OperationUpdateOne, OperationUpdateMany, OperationReplaceOne, OperationDeleteOne, OperationDeleteMany:
+	case "":
+		return fmt.Errorf("operation is required")
+	default:
+		return fmt.Errorf("bulk_write requests must be insert_one, update_one, update_many, replace_one, delete_one or delete_many, got %q", request.Operation)
+	}
+	if request.Collection != "" && request.Collection != collection {
+		return fmt.Errorf("requests write to the bulk_write's collection, so they can't name their own")
+	}
+	return validateOperation(request, collection)
+}
+
+// applyVariableReplacement processes templates in the connection settings and
+// the operations' arguments
+func applyVariableReplacement(config *MongoDBConfig, state map[string]interface{}, env map[string]string) error {
+	context := dsl.TemplateContext{
+		Runtime: state,
+		Env:     env,
+	}
+
+	fields := map[string]*string{
+		"uri":        &config.URI,
+		"database":   &config.Database,
+		"collection": &config.Collection,
+	}
+	if tls := config.TLS; tls != nil {
+		fields["tls.ca"] = &tls.CA
+		fields["tls.ca_file"] = &tls.CAFile
+		fields["tls.cert"] = &tls.Cert
+		fields["tls.cert_file"] = &tls.CertFile
+		fields["tls.key"] = &tls.Key
+		fields["tls.key_file"] = &tls.KeyFile
+		fields["tls.server_name"] = &tls.ServerName
+	}
+	if auth := config.Auth; auth != nil {
+		fields["auth.username"] = &auth.Username
+		fields["auth.password"] = &auth.Password
+		fields["auth.source"] = &auth.Source
+	}
+	for name, value := range fields {
+		if *value == "" {
+			continue
+		}
+		processed, err := dsl.ProcessTemplate(*value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process %s template: %w", name, err)
+		}
+		*value = processed
+	}
+
+	if config.Transaction == nil {
+		return processOperation(&config.Operation, context)
+	}
+	for i := range config.Transaction.Operations {
+		if err := processOperation(&config.Transaction.Operations[i], context); err != nil {
+			return fmt.Errorf("transaction.operations[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// processOperation renders templates in an operation's arguments
+func processOperation(op *Operation, context dsl.TemplateContext) error {
+	if op.Collection != "" {
+		processed, err := dsl.ProcessTemplate(op.Collection, context)
+		if err != nil {
+			return fmt.Errorf("failed to process collection template: %w", err)
+		}
+		op.Collection = processed
+	}
+
+	text := map[string]*string{
+		"bucket":   &op.Bucket,
+		"filename": &op.Filename,
+		"file_id":  &op.FileID,
+		"content":  op.Content,
+	}
+	for name, value := range text {
+		if value == nil || *value == "" {
+			continue
+		}
+		processed, err := dsl.ProcessTemplate(*value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process %s template: %w", name, err)
+		}
+		*value = processed
+	}
+
+	values := map[string]interface{}{
+		"filter":      op.Filter,
+		"document":    op.Document,
+		"update":      op.Update,
+		"replacement": op.Replacement,
+		"projection":  op.Projection,
+		"sort":        op.Sort,
+		"pipeline":    op.Pipeline,
+		"metadata":    op.Metadata,
+	}
+	for name, value := range values {
+		if _, err := processValue(value, context); err != nil {
+			return fmt.Errorf("failed to process %s template: %w", name, err)
+		}
+	}
+	for i, document := range op.Documents {
+		if _, err := processValue(document, context); err != nil {
+			return fmt.Errorf("failed to process documents[%d] template: %w", i, err)
+		}
+	}
+	for i := range op.Requests {
+		if err := processOperation(&op.Requests[i], context); err != nil {
+			return fmt.Errorf("requests[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// processValue renders templates in the strings of a value in place, leaving
+// numbers and other scalars as they are so MongoDB sees their types
+func processValue(value interface{}, context dsl.TemplateContext) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return dsl.ProcessTemplate(v, context)
+	case map[string]interface{}:
+		for key, nested := range v {
+			processed, err := processValue(nested, context)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = processed
+		}
+	case []interface{}:
+		for i, nested := range v {
+			processed, err := processValue(nested, context)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = processed
+		}
+	}
+	return value, nil
+}
+
+// parseConfig converts map[string]interface{} to MongoDBConfig
+func parseConfig(configData map[string]interface{}, config *MongoDBConfig) error {
+	stringFields := map[string]*string{
+		"uri":        &config.URI,
+		"database":   &config.Database,
+		"collection": &config.Collection,
+		"timeout":    &config.Timeout,
+	}
+	for key, target := range stringFields {
+		if v, ok := configData[key].(string); ok {
+			*target = v
+		}
+	}
+
+	switch tlsData := configData["tls"].(type) {
+	case nil:
+	case map[string]interface{}:
+		config.TLS = &TLSConfig{}
+		tlsFields := map[string]*string{
+			"ca":          &config.TLS.CA,
+			"ca_file":     &config.TLS.CAFile,
+			"cert":        &config.TLS.Cert,
+			"cert_file":   &config.TLS.CertFile,
+			"key":         &config.TLS.Key,
+			"key_file":    &config.TLS.KeyFile,
+			"server_name": &config.TLS.ServerName,
+		}
+		for key, target := range tlsFields {
+			*target, _ = tlsData[key].(string)
+		}
+		config.TLS.InsecureSkipVerify, _ = tlsData["insecure_skip_verify"].(bool)
+	case bool:
+		// tls: true turns on TLS with the system's CAs
+		if tlsData {
+			config.TLS = &TLSConfig{}
+		}
+	default:
+		return fmt.Errorf("tls must be an object or a boolean, got %T", tlsData)
+	}
+
+	switch authData := configData["auth"].(type) {
+	case nil:
+	case map[string]interface{}:
+		config.Auth = &AuthConfig{}
+		config.Auth.Mechanism, _ = authData["mechanism"].(string)
+		config.Auth.Username, _ = authData["username"].(string)
+		config.Auth.Password, _ = authData["password"].(string)
+		config.Auth.Source, _ = authData["source"].(string)
+	default:
+		return fmt.Errorf("auth must be an object, got %T", authData)
+	}
+
+	// The operation's own collection is the step's collection
+	if err := parseOperation(configData, &config.Operation); err != nil {
+		return err
+	}
+	config.Operation.Collection = ""
+
+	switch tx := configData["transaction"].(type) {
+	case nil:
+	case map[string]interface{}:
+		config.Transaction = &TransactionConfig{}
+		config.Transaction.End, _ = tx["end"].(string)
+		config.Transaction.ReadConcern, _ = tx["read_concern"].(string)
+		switch wc := tx["write_concern"].(type) {
+		case string:
+			config.Transaction.WriteConcern = wc
+		case float64:
+			config.Transaction.WriteConcern = strconv.FormatFloat(wc, 'f', -1, 64)
+		}
+
+		operations, ok := tx["operations"].([]interface{})
+		if !ok && tx["operations"] != nil {
+			return fmt.Errorf("transaction.operations must be a list, got %T", tx["operations"])
+		}
+		for i, raw := range operations {
+			opData, ok := raw.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("transaction.operations[%d] must be an object, got %T", i, raw)
+			}
+			var op Operation
+			if err := parseOperation(opData, &op); err != nil {
+				return fmt.Errorf("transaction.operations[%d]: %w", i, err)
+			}
+			config.Transaction.Operations = append(config.Transaction.Operations, op)
+		}
+	default:
+		return fmt.Errorf("transaction must be an object, got %T", tx)
+	}
+
+	return nil
+}
+
+// parseOperation reads an operation's fields
+func parseOperation(data map[string]interface{}, op *Operation) error {
+	op.Operation, _ = data["operation"].(string)
+	op.Collection, _ = data["collection"].(string)
+	op.Upsert, _ = data["upsert"].(bool)
+	op.Update = data["update"]
+	op.Sort = data["sort"]
+	op.ReturnDocument, _ = data["return_document"].(string)
+	op.Bucket, _ = data["bucket"].(string)
+	op.Filename, _ = data["filename"].(string)
+	op.FileID, _ = data["file_id"].(string)
+	op.Encoding, _ = data["encoding"].(string)
+	op.Algorithm, _ = data["algorithm"].(string)
+	if content, ok := data["content"].(string); ok {
+		op.Content = &content
+	}
+	if ordered, ok := data["ordered"].(bool); ok {
+		op.Ordered = &ordered
+	}
+
+	objects := map[string]*map[string]interface{}{
+		"filter":      &op.Filter,
+		"document":    &op.Document,
+		"replacement": &op.Replacement,
+		"projection":  &op.Projection,
+		"metadata":    &op.Metadata,
+	}
+	for key, target := range objects {
+		switch v := data[key].(type) {
+		case nil:
+		case map[string]interface{}:
+			*target = v
+		default:
+			return fmt.Errorf("%s must be an object, got %T", key, v)
+		}
+	}
+
+	switch documents := data["documents"].(type) {
+	case nil:
+	case []interface{}:
+		for i, doc := range documents {
+			m, ok := doc.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("documents[%d] must be an object, got %T", i, doc)
+			}
+			op.Documents = append(op.Documents, m)
+		}
+	default:
+		return fmt.Errorf("documents must be a list, got %T", documents)
+	}
+
+	switch requests := data["requests"].(type) {
+	case nil:
+	case []interface{}:
+		for i, raw := range requests {
+			requestData, ok := raw.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("requests[%d] must be an object, got %T", i, raw)
+			}
+			var request Operation
+			if err := parseOperation(requestData, &request); err != nil {
+				return fmt.Errorf("requests[%d]: %w", i, err)
+			}
+			op.Requests = append(op.Requests, request)
+		}
+	default:
+		return fmt.Errorf("requests must be a list, got %T", requests)
+	}
+
+	switch pipeline := data["pipeline"].(type) {
+	case nil:
+	case []interface{}:
+		op.Pipeline = pipeline
+	default:
+		return fmt.Errorf("pipeline must be a list of stages, got %T", pipeline)
+	}
+
+	for key, target := range map[string]*int64{"limit": &op.Limit, "skip": &op.Skip} {
+		switch v := data[key].(type) {
+		case nil:
+		case float64:
+			if v < 0 || v != float64(int64(v)) {
+				return fmt.Errorf("%s must be a non-negative integer, got %v", key, v)
+			}
+			*target = int64(v)
+		default:
+			return fmt.Errorf("%s must be a number, got %T", key, v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package mongodb
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestParseTransactionConfig(t *testing.T) {
+	configData := map[string]interface{}{
+		"uri":        "{{ .env.MONGO_URI }}",
+		"database":   "shop",
+		"collection": "orders",
+		"transaction": map[string]interface{}{
+			"read_concern":  "snapshot",
+			"write_concern": float64(2),
+			"end":           "abort",
+			"operations": []interface{}{
+				map[string]interface{}{
+					"operation": "insert_one",
+					"document":  map[string]interface{}{"customer": "{{ customer }}", "total": float64(42)},
+				},
+				map[string]interface{}{
+					"operation":  "update_one",
+					"collection": "stock",
+					"filter":     map[string]interface{}{"sku": "A1"},
+					"update":     map[string]interface{}{"$inc": map[string]interface{}{"qty": float64(-1)}},
+				},
+			},
+		},
+	}
+
+	config := &MongoDBConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		t.Fatalf("parseConfig() error = %v", err)
+	}
+	if err := applyVariableReplacement(config, map[string]interface{}{"customer": "ada"}, map[string]string{"MONGO_URI": "mongodb://localhost:27017/?replicaSet=rs0"}); err != nil {
+		t.Fatalf("applyVariableReplacement() error = %v", err)
+	}
+	if err := validateConfig(config); err != nil {
+		t.Fatalf("validateConfig() error = %v", err)
+	}
+
+	if config.URI != "mongodb://localhost:27017/?replicaSet=rs0" {
+		t.Errorf("uri = %q", config.URI)
+	}
+	tx := config.Transaction
+	if tx.End != TransactionAbort || tx.ReadConcern != "snapshot" || tx.WriteConcern != "2" {
+		t.Errorf("transaction settings = %+v", tx)
+	}
+	if len(tx.Operations) != 2 {
+		t.Fatalf("expected 2 operations, got %d", len(tx.Operations))
+	}
+	if tx.Operations[0].Collection != "orders" || tx.Operations[1].Collection != "stock" {
+		t.Errorf("expected the step's collection as the default, got %q and %q", tx.Operations[0].Collection, tx.Operations[1].Collection)
+	}
+	if got := tx.Operations[0].Document["customer"]; got != "ada" {
+		t.Errorf("expected templates rendered in documents, got %v", got)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]interface{}
+		wantErr string
+	}{
+		{"find", map[string]interface{}{"operation": "find"}, ""},
+		{"missing uri", map[string]interface{}{"uri": "", "operation": "find"}, "uri is required"},
+		{"unknown operation", map[string]interface{}{"operation": "drop"}, `unsupported operation "drop"`},
+		{"delete without filter", map[string]interface{}{"operation": "delete_many"}, "filter is required for delete_many"},
+		{"insert without document", map[string]interface{}{"operation": "insert_one"}, "document is required"},
+		{"operation and transaction", map[string]interface{}{"operation": "find", "transaction": map[string]interface{}{"operations": []interface{}{map[string]interface{}{"operation": "find"}}}}, "not both"},
+		{"empty transaction", map[string]interface{}{"transaction": map[string]interface{}{}}, "at least one operation"},
+		{"bad end", map[string]interface{}{"transaction": map[string]interface{}{"end": "rollback", "operations": []interface{}{map[string]interface{}{"operation": "find"}}}}, "commit or abort"},
+		{"bad write concern", map[string]interface{}{"transaction": map[string]interface{}{"write_concern": "all", "operations": []interface{}{map[string]interface{}{"operation": "find"}}}}, "write_concern"},
+		{"bad operation in transaction", map[string]interface{}{"transaction": map[string]interface{}{"operations": []interface{}{map[string]interface{}{"operation": "update_one"}}}}, "transaction.operations[0]: update is required"},
+		{"find_one_and_update", map[string]interface{}{"operation": "find_one_and_update", "update": map[string]interface{}{"$set": map[string]interface{}{"a": 1}}, "return_document": "after"}, ""},
+		{"bad return_document", map[string]interface{}{"operation": "find_one_and_update", "update": map[string]interface{}{}, "return_document": "new"}, "before or after"},
+		{"replace without replacement", map[string]interface{}{"operation": "find_one_and_replace"}, "replacement is required"},
+		{"bulk_write", map[string]interface{}{"operation": "bulk_write", "ordered": false, "requests": []interface{}{
+			map[string]interface{}{"operation": "insert_one", "document": map[string]interface{}{"a": 1}},
+			map[string]interface{}{"operation": "delete_one", "filter": map[string]interface{}{"a": 2}},
+		}}, ""},
+		{"empty bulk_write", map[string]interface{}{"operation": "bulk_write"}, "requests is required"},
+		{"find in bulk_write", map[string]interface{}{"operation": "bulk_write", "requests": []interface{}{map[string]interface{}{"operation": "find"}}}, `requests[0]: bulk_write requests must be`},
+		{"bulk_write request on another collection", map[string]interface{}{"operation": "bulk_write", "requests": []interface{}{map[string]interface{}{"operation": "delete_one", "collection": "users", "filter": map[string]interface{}{}}}}, "can't name their own"},
+		{"gridfs_upload", map[string]interface{}{"collection": "", "operation": "gridfs_upload", "filename": "report.pdf", "content": "", "algorithm": "md5"}, ""},
+		{"gridfs_upload without content", map[string]interface{}{"operation": "gridfs_upload", "filename": "report.pdf"}, "content is required"},
+		{"gridfs_download with both", map[string]interface{}{"operation": "gridfs_download", "filename": "report.pdf", "file_id": "65e1f0a2b3c4d5e6f7a8b9c0"}, "either file_id or filename"},
+		{"bad algorithm", map[string]interface{}{"operation": "gridfs_download", "filename": "report.pdf", "algorithm": "crc32"}, "algorithm must be"},
+		{"scram auth", map[string]interface{}{"operation": "find", "auth": map[string]interface{}{"mechanism": "SCRAM-SHA-256", "username": "app", "password": "secret", "source": "admin"}}, ""},
+		{"scram auth without password", map[string]interface{}{"operation": "find", "auth": map[string]interface{}{"username": "app"}}, "username and password for the default mechanism"},
+		{"x509 without certificate", map[string]interface{}{"operation": "find", "tls": true, "auth": map[string]interface{}{"mechanism": "MONGODB-X509"}}, "needs a client certificate"},
+		{"x509", map[string]interface{}{"operation": "find", "tls": map[string]interface{}{"cert_file": "/etc/mongo/client.pem"}, "auth": map[string]interface{}{"mechanism": "MONGODB-X509"}}, ""},
+		{"unknown mechanism", map[string]interface{}{"operation": "find", "auth": map[string]interface{}{"mechanism": "GSSAPI", "username": "app"}}, "auth.mechanism must be"},
+		{"gridfs in transaction", map[string]interface{}{"transaction": map[string]interface{}{"operations": []interface{}{map[string]interface{}{"operation": "gridfs_download", "filename": "a"}}}}, "can't run in a transaction"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configData := map[string]interface{}{"uri": "mongodb://localhost", "database": "shop", "collection": "orders"}
+			for k, v := range tt.config {
+				configData[k] = v
+			}
+			config := &MongoDBConfig{}
+			err := parseConfig(configData, config)
+			if err == nil {
+				err = validateConfig(config)
+			}
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestToBSON(t *testing.T) {
+	id := primitive.NewObjectID()
+	filter := map[string]interface{}{
+		"_id":   map[string]interface{}{"$oid": id.Hex()},
+		"qty":   float64(3),
+		"price": 9.5,
+		"since": map[string]interface{}{"$gte": map[string]interface{}{"$date": "2024-03-01T00:00:00Z"}},
+	}
+
+	converted, err := toBSON(filter)
+	if err != nil {
+		t.Fatalf("toBSON() error = %v", err)
+	}
+	doc := converted.(bson.D).Map()
+	if doc["_id"] != id {
+		t.Errorf("expected $oid to become an ObjectID, got %#v", doc["_id"])
+	}
+	if doc["qty"] != int32(3) {
+		t.Errorf("expected a whole number to become an integer, got %#v", doc["qty"])
+	}
+	if doc["price"] != 9.5 {
+		t.Errorf("expected a fraction to stay a double, got %#v", doc["price"])
+	}
+	since := doc["since"].(bson.D).Map()["$gte"]
+	if since != primitive.NewDateTimeFromTime(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected $date to become a date, got %#v", since)
+	}
+
+	sort, err := sortSpec([]interface{}{map[string]interface{}{"created_at": float64(-1)}, map[string]interface{}{"_id": float64(1)}})
+	if err != nil {
+		t.Fatalf("sortSpec() error = %v", err)
+	}
+	if want := (bson.D{{Key: "created_at", Value: int32(-1)}, {Key: "_id", Value: int32(1)}}); !reflect.DeepEqual(sort, want) {
+		t.Errorf("sortSpec() = %v, want %v", sort, want)
+	}
+}
+
+func TestFromBSON(t *testing.T) {
+	id, _ := primitive.ObjectIDFromHex("65e1f0a2b3c4d5e6f7a8b9c0")
+	price, _ := primitive.ParseDecimal128("19.99")
+	doc := bson.D{
+		{Key: "_id", Value: id},
+		{Key: "placed_at", Value: primitive.NewDateTimeFromTime(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))},
+		{Key: "price", Value: price},
+		{Key: "items", Value: bson.A{bson.D{{Key: "sku", Value: "A1"}, {Key: "qty", Value: int32(2)}}}},
+		{Key: "note", Value: nil},
+	}
+
+	want := map[string]interface{}{
+		"_id":       "65e1f0a2b3c4d5e6f7a8b9c0",
+		"placed_at": "2024-03-01T12:00:00Z",
+		"price":     "19.99",
+		"items":     []interface{}{map[string]interface{}{"sku": "A1", "qty": int32(2)}},
+		"note":      nil,
+	}
+	if got := fromBSON(doc); !reflect.DeepEqual(got, want) {
+		t.Errorf("fromBSON() = %#v, want %#v", got, want)
+	}
+}
+
+func TestWriteModel(t *testing.T) {
+	model, id, err := writeModel(Operation{Operation: OperationInsertOne, Document: map[string]interface{}{"sku": "A1"}})
+	if err != nil {
+		t.Fatalf("writeModel() error = %v", err)
+	}
+	doc := model.(*mongo.InsertOneModel).Document.(bson.D)
+	if doc[0].Key != "_id" || doc[0].Value.(primitive.ObjectID).Hex() != id {
+		t.Errorf("expected a generated _id reported as %v, got %v", id, doc)
+	}
+
+	_, id, err = writeModel(Operation{Operation: OperationInsertOne, Document: map[string]interface{}{"_id": "sku-A1"}})
+	if err != nil || id != "sku-A1" {
+		t.Errorf("expected the document's own _id, got %v (%v)", id, err)
+	}
+
+	model, _, err = writeModel(Operation{Operation: OperationUpdateMany, Update: map[string]interface{}{"$set": map[string]interface{}{"qty": float64(0)}}, Upsert: true})
+	if err != nil {
+		t.Fatalf("writeModel() error = %v", err)
+	}
+	update := model.(*mongo.UpdateManyModel)
+	if !reflect.DeepEqual(update.Filter, bson.D{}) || update.Upsert == nil || !*update.Upsert {
+		t.Errorf("unexpected update model %+v", update)
+	}
+}
+
+func TestBulkWriteError(t *testing.T) {
+	requests := []Operation{{Operation: OperationInsertOne}, {Operation: OperationUpdateOne}, {Operation: OperationInsertOne}}
+	err := bulkWriteError(mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{
+		{WriteError: mongo.WriteError{Index: 2, Code: 11000, Message: "E11000 duplicate key error"}},
+	}}, requests)
+	if want := "bulk write failed: request 2 (insert_one): E11000 duplicate key error"; err == nil || err.Error() != want {
+		t.Errorf("bulkWriteError() = %v, want %q", err, want)
+	}
+}
+
+func TestBuildTLSConfig(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "app", OrganizationalUnit: []string{"tests"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	keyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+
+	// The key can follow the certificate in one PEM, as mongod's --tlsCertificateKeyFile expects
+	tlsConfig, err := buildTLSConfig(&TLSConfig{CA: certPEM, Cert: certPEM + keyPEM, ServerName: "mongo.internal"})
+	if err != nil {
+		t.Fatalf("buildTLSConfig() error = %v", err)
+	}
+	if tlsConfig.RootCAs == nil || len(tlsConfig.Certificates) != 1 || tlsConfig.ServerName != "mongo.internal" {
+		t.Errorf("unexpected tls config %+v", tlsConfig)
+	}
+
+	if _, err := buildTLSConfig(&TLSConfig{Cert: certPEM, Key: keyPEM}); err != nil {
+		t.Errorf("expected a separate key to load, got %v", err)
+	}
+	if _, err := buildTLSConfig(&TLSConfig{CA: "not a certificate"}); err == nil || !strings.Contains(err.Error(), "no certificates") {
+		t.Errorf("expected an invalid CA to fail, got %v", err)
+	}
+	if _, err := buildTLSConfig(&TLSConfig{Key: keyPEM}); err == nil || !strings.Contains(err.Error(), "needs a client certificate") {
+		t.Errorf("expected a key without a certificate to fail, got %v", err)
+	}
+	if _, err := buildTLSConfig(&TLSConfig{CA: certPEM, CAFile: "/etc/ca.pem"}); err == nil || !strings.Contains(err.Error(), "not both") {
+		t.Errorf("expected ca and ca_file together to fail, got %v", err)
+	}
+
+	if source := buildCredential(&AuthConfig{Mechanism: MechanismX509}).AuthSource; source != "$external" {
+		t.Errorf("expected $external as the X.509 auth source, got %q", source)
+	}
+	if credential := buildCredential(&AuthConfig{Username: "app", Password: "secret"}); credential.AuthSource != "" || !credential.PasswordSet {
+		t.Errorf("unexpected SCRAM credential %+v", credential)
+	}
+}
@@ -0,0 +1,202 @@
+package mongodb
+
+import "github.com/rocketship-ai/rocketship/internal/assertions"
+
+// MongoDBPlugin represents a MongoDB test step
+type MongoDBPlugin struct {
+	Name   string        `json:"name" yaml:"name"`
+	Plugin string        `json:"plugin" yaml:"plugin"`
+	Config MongoDBConfig `json:"config" yaml:"config"`
+}
+
+// MongoDBConfig selects the deployment and either one operation or a
+// transaction of several
+type MongoDBConfig struct {
+	// Connection
+	URI        string `json:"uri" yaml:"uri"`                                   // mongodb:// or mongodb+srv:// connection string
+	Database   string `json:"database" yaml:"database"`                         // Database the operations run in
+	Collection string `json:"collection,omitempty" yaml:"collection,omitempty"` // Default collection of the operations
+
+	// Credentials and certificates outside the URI, so they stay out of logs
+	TLS  *TLSConfig  `json:"tls,omitempty" yaml:"tls,omitempty"`
+	Auth *AuthConfig `json:"auth,omitempty" yaml:"auth,omitempty"`
+
+	// The step's operation, unless it runs a transaction
+	Operation
+
+	Transaction *TransactionConfig `json:"transaction,omitempty" yaml:"transaction,omitempty"`
+
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"` // Overall step timeout (defaults to 30s)
+}
+
+// TLSConfig enables TLS and sets the certificates. PEM fields take the
+// certificate itself, typically from an env secret; _file fields take a path
+// on the worker.
+type TLSConfig struct {
+	CA                 string `json:"ca,omitempty" yaml:"ca,omitempty"`                                     // PEM CA certificates to trust
+	CAFile             string `json:"ca_file,omitempty" yaml:"ca_file,omitempty"`                           // Path to PEM CA certificates
+	Cert               string `json:"cert,omitempty" yaml:"cert,omitempty"`                                 // PEM client certificate, optionally with its key
+	CertFile           string `json:"cert_file,omitempty" yaml:"cert_file,omitempty"`                       // Path to a PEM client certificate
+	Key                string `json:"key,omitempty" yaml:"key,omitempty"`                                   // PEM client key, unless it is part of cert
+	KeyFile            string `json:"key_file,omitempty" yaml:"key_file,omitempty"`                         // Path to a PEM client key
+	ServerName         string `json:"server_name,omitempty" yaml:"server_name,omitempty"`                   // Name to verify the server certificate against
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty" yaml:"insecure_skip_verify,omitempty"` // Skip certificate verification
+}
+
+// AuthConfig sets the credentials and how they are checked
+type AuthConfig struct {
+	Mechanism string `json:"mechanism,omitempty" yaml:"mechanism,omitempty"` // SCRAM-SHA-256, SCRAM-SHA-1, MONGODB-X509 or PLAIN; negotiated when unset
+	Username  string `json:"username,omitempty" yaml:"username,omitempty"`   // Optional for MONGODB-X509, which uses the certificate subject
+	Password  string `json:"password,omitempty" yaml:"password,omitempty"`
+	Source    string `json:"source,omitempty" yaml:"source,omitempty"` // authSource: admin by default, $external for MONGODB-X509 and PLAIN
+}
+
+// Operation is one operation against a collection
+type Operation struct {
+	Operation  string                   `json:"operation" yaml:"operation"`
+	Collection string                   `json:"collection,omitempty" yaml:"collection,omitempty"` // Defaults to the step's collection
+	Filter     map[string]interface{}   `json:"filter,omitempty" yaml:"filter,omitempty"`         // find, find_one, update_*, delete_*, count
+	Document   map[string]interface{}   `json:"document,omitempty" yaml:"document,omitempty"`     // insert_one
+	Documents  []map[string]interface{} `json:"documents,omitempty" yaml:"documents,omitempty"`   // insert_many
+	Update     interface{}              `json:"update,omitempty" yaml:"update,omitempty"`         // update_*: update operators or an aggregation pipeline
+	Upsert     bool                     `json:"upsert,omitempty" yaml:"upsert,omitempty"`         // update_*: insert when nothing matches
+	Projection map[string]interface{}   `json:"projection,omitempty" yaml:"projection,omitempty"` // find, find_one
+	Sort       interface{}              `json:"sort,omitempty" yaml:"sort,omitempty"`             // find, find_one: {field: 1|-1}, or a list of them for several keys
+	Limit      int64                    `json:"limit,omitempty" yaml:"limit,omitempty"`           // find
+	Skip       int64                    `json:"skip,omitempty" yaml:"skip,omitempty"`             // find
+	Pipeline   []interface{}            `json:"pipeline,omitempty" yaml:"pipeline,omitempty"`     // aggregate
+
+	// find_one_and_update and find_one_and_replace
+	Replacement    map[string]interface{} `json:"replacement,omitempty" yaml:"replacement,omitempty"`         // replace_one, find_one_and_replace
+	ReturnDocument string                 `json:"return_document,omitempty" yaml:"return_document,omitempty"` // before (default) or after the change
+
+	// bulk_write
+	Requests []Operation `json:"requests,omitempty" yaml:"requests,omitempty"` // insert_one, update_*, replace_one and delete_* writes
+	Ordered  *bool       `json:"ordered,omitempty" yaml:"ordered,omitempty"`   // Stop at the first failed write (default true)
+
+	// gridfs_upload and gridfs_download
+	Bucket    string                 `json:"bucket,omitempty" yaml:"bucket,omitempty"`       // GridFS bucket (defaults to fs)
+	Filename  string                 `json:"filename,omitempty" yaml:"filename,omitempty"`   // Name to upload as, or to download the latest revision of
+	FileID    string                 `json:"file_id,omitempty" yaml:"file_id,omitempty"`     // gridfs_download: ObjectID hex of the file
+	Content   *string                `json:"content,omitempty" yaml:"content,omitempty"`     // gridfs_upload: file content
+	Encoding  string                 `json:"encoding,omitempty" yaml:"encoding,omitempty"`   // gridfs_upload: utf8 (default) or base64 content
+	Metadata  map[string]interface{} `json:"metadata,omitempty" yaml:"metadata,omitempty"`   // gridfs_upload: metadata stored with the file
+	Algorithm string                 `json:"algorithm,omitempty" yaml:"algorithm,omitempty"` // Checksum algorithm: md5, sha1, sha256 (default) or sha512
+}
+
+// TransactionConfig runs operations in one transaction of a session
+type TransactionConfig struct {
+	Operations   []Operation `json:"operations" yaml:"operations"`
+	End          string      `json:"end,omitempty" yaml:"end,omitempty"`                     // commit (default) or abort
+	ReadConcern  string      `json:"read_concern,omitempty" yaml:"read_concern,omitempty"`   // local, majority or snapshot
+	WriteConcern string      `json:"write_concern,omitempty" yaml:"write_concern,omitempty"` // majority or a number of members
+}
+
+// Operations supported by the MongoDB plugin
+const (
+	OperationInsertOne  = "insert_one"
+	OperationInsertMany = "insert_many"
+	OperationFind       = "find"
+	OperationFindOne    = "find_one"
+	OperationUpdateOne  = "update_one"
+	OperationUpdateMany = "update_many"
+	OperationDeleteOne  = "delete_one"
+	OperationDeleteMany = "delete_many"
+	OperationCount      = "count"
+	OperationAggregate  = "aggregate"
+
+	OperationReplaceOne        = "replace_one"
+	OperationFindOneAndUpdate  = "find_one_and_update"
+	OperationFindOneAndReplace = "find_one_and_replace"
+	OperationBulkWrite         = "bulk_write"
+	OperationGridFSUpload      = "gridfs_upload"
+	OperationGridFSDownload    = "gridfs_download"
+)
+
+// Which document find_one_and_* returns
+const (
+	ReturnDocumentBefore = "before"
+	ReturnDocumentAfter  = "after"
+)
+
+// Authentication mechanisms
+const (
+	MechanismSCRAMSHA256 = "SCRAM-SHA-256"
+	MechanismSCRAMSHA1   = "SCRAM-SHA-1"
+	MechanismX509        = "MONGODB-X509"
+	MechanismPlain       = "PLAIN"
+)
+
+// How a transaction ends
+const (
+	TransactionCommit = "commit"
+	TransactionAbort  = "abort"
+)
+
+// Assertion types supported by the MongoDB plugin in addition to the shared ones
+const (
+	AssertionTypeDocumentCount = "document_count"
+	AssertionTypeChecksum      = "checksum"
+)
+
+// OperationResult is what one operation read or changed. Documents are plain
+// JSON: ObjectIDs as hex strings, dates as RFC 3339 strings.
+type OperationResult struct {
+	Operation     string                   `json:"operation"`
+	Collection    string                   `json:"collection"`
+	Documents     []map[string]interface{} `json:"documents"`              // find, find_one, aggregate
+	Count         int64                    `json:"count"`                  // Documents returned, or counted by count
+	InsertedIDs   []interface{}            `json:"inserted_ids,omitempty"` // insert_*
+	MatchedCount  int64                    `json:"matched_count"`          // update_*
+	ModifiedCount int64                    `json:"modified_count"`         // update_*
+	UpsertedCount int64                    `json:"upserted_count"`         // update_*
+	UpsertedID    interface{}              `json:"upserted_id,omitempty"`  // update_*
+	DeletedCount  int64                    `json:"deleted_count"`          // delete_*
+	InsertedCount int64                    `json:"inserted_count"`         // bulk_write
+	Requests      []WriteResult            `json:"requests,omitempty"`     // bulk_write: one result per request, in order
+	File          *FileResult              `json:"file,omitempty"`         // gridfs_*
+}
+
+// WriteResult is what one request of a bulk write did. The server only
+// reports totals, so per-request results carry the IDs each write created.
+type WriteResult struct {
+	Index      int         `json:"index"`
+	Operation  string      `json:"operation"`
+	InsertedID interface{} `json:"inserted_id,omitempty"` // insert_one
+	UpsertedID interface{} `json:"upserted_id,omitempty"` // update_* and replace_one that inserted
+}
+
+// FileResult describes a GridFS file that was uploaded or downloaded
+type FileResult struct {
+	ID         interface{}            `json:"id"`
+	Filename   string                 `json:"filename"`
+	Length     int64                  `json:"length"`
+	UploadDate string                 `json:"upload_date,omitempty"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	Content    string                 `json:"content,omitempty"`   // gridfs_download: up to 10 MiB of the content
+	Encoding   string                 `json:"encoding,omitempty"`  // base64 when the content isn't valid UTF-8
+	Truncated  bool                   `json:"truncated,omitempty"` // The file is longer than content
+	Algorithm  string                 `json:"algorithm"`
+	Checksum   string                 `json:"checksum"` // Hex digest of the whole file
+}
+
+// MongoDBResponse contains the results of the step's operations. documents
+// and count repeat the last operation's, so single-operation steps can read
+// them directly.
+type MongoDBResponse struct {
+	Operations  []OperationResult        `json:"operations"`
+	Documents   []map[string]interface{} `json:"documents"`
+	Count       int64                    `json:"count"`
+	Transaction string                   `json:"transaction,omitempty"` // committed or aborted, for transaction steps
+	Duration    string                   `json:"duration"`
+}
+
+// AssertionResult represents a single assertion result for UI display
+type AssertionResult = assertions.Result
+
+// ActivityResponse represents the complete activity response
+type ActivityResponse struct {
+	Response         *MongoDBResponse  `json:"response"`
+	Saved            map[string]string `json:"saved"`
+	AssertionResults []AssertionResult `json:"assertion_results,omitempty"`
+}
@@ -0,0 +1,171 @@
+package neo4j
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/rocketship-ai/rocketship/internal/egress"
+)
+
+// maxResponseBytes caps how much of a query result the plugin reads
+const maxResponseBytes = 64 << 20
+
+// client runs Cypher over Neo4j's HTTP API, one auto-committed transaction per query
+type client struct {
+	endpoint string
+	username string
+	password string
+	token    string
+	http     *http.Client
+}
+
+// newClient connects to the configured server. Connections go through the egress policy.
+func newClient(config *Neo4jConfig, policy *egress.Policy) (*client, error) {
+	base, err := url.Parse(config.URL)
+	if err != nil || base.Host == "" || (base.Scheme != "http" && base.Scheme != "https") {
+		return nil, fmt.Errorf("invalid url %q: must be an http or https URL, e.g. http://neo4j:7474", config.URL)
+	}
+
+	database := config.Database
+	if database == "" {
+		database = defaultDatabase
+	}
+	base.Path = strings.TrimRight(base.Path, "/") + "/db/" + url.PathEscape(database) + "/tx/commit"
+
+	return &client{
+		endpoint: base.String(),
+		username: config.Username,
+		password: config.Password,
+		token:    config.Token,
+		http:     &http.Client{Transport: policy.Transport()},
+	}, nil
+}
+
+// txStatement is one statement of a transaction request
+type txStatement struct {
+	Statement          string                 `json:"statement"`
+	Parameters         map[string]interface{} `json:"parameters,omitempty"`
+	ResultDataContents []string               `json:"resultDataContents"`
+	IncludeStats       bool                   `json:"includeStats"`
+}
+
+// txResponse is the body of a transaction response
+type txResponse struct {
+	Results []txResult `json:"results"`
+	Errors  []struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+type txResult struct {
+	Columns []string `json:"columns"`
+	Data    []struct {
+		Row   []interface{} `json:"row"`
+		Graph struct {
+			Nodes         []txNode         `json:"nodes"`
+			Relationships []txRelationship `json:"relationships"`
+		} `json:"graph"`
+	} `json:"data"`
+	Stats struct {
+		ContainsUpdates      bool `json:"contains_updates"`
+		NodesCreated         int  `json:"nodes_created"`
+		NodesDeleted         int  `json:"nodes_deleted"`
+		PropertiesSet        int  `json:"properties_set"`
+		RelationshipsCreated int  `json:"relationships_created"`
+		RelationshipsDeleted int  `json:"relationship_deleted"` // Neo4j's spelling
+		LabelsAdded          int  `json:"labels_added"`
+		LabelsRemoved        int  `json:"labels_removed"`
+		IndexesAdded         int  `json:"indexes_added"`
+		IndexesRemoved       int  `json:"indexes_removed"`
+		ConstraintsAdded     int  `json:"constraints_added"`
+		ConstraintsRemoved   int  `json:"constraints_removed"`
+	} `json:"stats"`
+}
+
+// Neo4j 5 sends element IDs alongside the deprecated numeric ones
+type txNode struct {
+	ID         string                 `json:"id"`
+	ElementID  string                 `json:"elementId"`
+	Labels     []string               `json:"labels"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type txRelationship struct {
+	ID                 string                 `json:"id"`
+	ElementID          string                 `json:"elementId"`
+	Type               string                 `json:"type"`
+	StartNode          string                 `json:"startNode"`
+	StartNodeElementID string                 `json:"startNodeElementId"`
+	EndNode            string                 `json:"endNode"`
+	EndNodeElementID   string                 `json:"endNodeElementId"`
+	Properties         map[string]interface{} `json:"properties"`
+}
+
+// run executes one statement and returns its result. Cypher errors come back in
+// the body, usually with a 200 status, and fail the call.
+func (c *client) run(ctx context.Context, statement string, parameters map[string]interface{}) (*txResult, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"statements": []txStatement{{
+			Statement:          statement,
+			Parameters:         parameters,
+			ResultDataContents: []string{"row", "graph"},
+			IncludeStats:       true,
+		}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode parameters: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json;charset=UTF-8")
+	switch {
+	case c.token != "":
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	case c.username != "":
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("neo4j request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read neo4j response: %w", err)
+	}
+
+	var body txResponse
+	if err := json.Unmarshal(data, &body); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("neo4j error: %s", strings.TrimSpace(resp.Status+" "+string(data)))
+		}
+		return nil, fmt.Errorf("failed to decode neo4j response: %w", err)
+	}
+	if len(body.Errors) > 0 {
+		messages := make([]string, 0, len(body.Errors))
+		for _, e := range body.Errors {
+			messages = append(messages, fmt.Sprintf("%s: %s", e.Code, e.Message))
+		}
+		return nil, fmt.Errorf("neo4j error: %s", strings.Join(messages, "; "))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("neo4j error: %s", resp.Status)
+	}
+	if len(body.Results) == 0 {
+		return nil, fmt.Errorf("neo4j returned no result for the query")
+	}
+	return &body.Results[0], nil
+}
@@ -0,0 +1,389 @@
+package neo4j
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+	"go.temporal.io/sdk/activity"
+)
+
+const (
+	defaultTimeout  = 30 * time.Second
+	defaultDatabase = "neo4j"
+)
+
+// Auto-register the plugin when the package is imported
+func init() {
+	plugins.RegisterPlugin(&Neo4jPlugin{})
+}
+
+// GetType returns the plugin type identifier
+func (np *Neo4jPlugin) GetType() string {
+	return "neo4j"
+}
+
+// Activity runs a Cypher query against Neo4j and checks the result
+func (np *Neo4jPlugin) Activity(ctx context.Context, p map[string]interface{}) (interface{}, error) {
+	logger := activity.GetLogger(ctx)
+
+	// Parse configuration from parameters
+	configData, ok := p["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid config format")
+	}
+
+	config := &Neo4jConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		return nil, fmt.Errorf("failed to parse neo4j config: %w", err)
+	}
+
+	timeout := defaultTimeout
+	if config.Timeout != "" {
+		parsed, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", config.Timeout, err)
+		}
+		timeout = parsed
+	}
+
+	// Convert state to map[string]interface{} for template processing
+	state := make(map[string]interface{})
+	if stateStr, ok := p["state"].(map[string]string); ok {
+		for k, v := range stateStr {
+			state[k] = v
+		}
+	} else if stateInt, ok := p["state"].(map[string]interface{}); ok {
+		state = stateInt
+	}
+
+	// Extract env secrets from params (for {{ .env.* }} template resolution)
+	env := make(map[string]string)
+	if envData, ok := p["env"].(map[string]interface{}); ok {
+		for k, v := range envData {
+			if strVal, ok := v.(string); ok {
+				env[k] = strVal
+			}
+		}
+	} else if envData, ok := p["env"].(map[string]string); ok {
+		env = envData
+	}
+
+	if err := applyVariableReplacement(config, state, env); err != nil {
+		return nil, fmt.Errorf("variable replacement failed: %w", err)
+	}
+
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	policy, err := egress.FromParams(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
+
+	logger.Info("Executing neo4j plugin", "database", config.Database)
+
+	c, err := newClient(config, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	response, err := execute(ctx, c, config)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := assertions.Normalize(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare neo4j result: %w", err)
+	}
+
+	assertionResults, err := processAssertions(p, response, subject, state, env)
+	if err != nil {
+		return nil, err
+	}
+	if failure := assertions.Summary(assertionResults); failure != "" {
+		return nil, fmt.Errorf("%s", failure)
+	}
+
+	saved := make(map[string]string)
+	if err := saves.Process(p, subject, saved); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Neo4j step completed", "rows", response.RowCount, "nodes", len(response.Nodes), "relationships", len(response.Relationships), "duration", response.Duration)
+
+	return &ActivityResponse{
+		Response:         response,
+		Saved:            saved,
+		AssertionResults: assertionResults,
+	}, nil
+}
+
+// execute runs the query and collects its rows and graph
+func execute(ctx context.Context, c *client, config *Neo4jConfig) (*Neo4jResponse, error) {
+	start := time.Now()
+
+	result, err := c.run(ctx, config.Query, config.Parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &Neo4jResponse{
+		Columns:       result.Columns,
+		Rows:          make([]map[string]interface{}, 0, len(result.Data)),
+		RowCount:      len(result.Data),
+		Nodes:         []Node{},
+		Relationships: []Relationship{},
+		Stats: QueryStats{
+			ContainsUpdates:      result.Stats.ContainsUpdates,
+			NodesCreated:         result.Stats.NodesCreated,
+			NodesDeleted:         result.Stats.NodesDeleted,
+			PropertiesSet:        result.Stats.PropertiesSet,
+			RelationshipsCreated: result.Stats.RelationshipsCreated,
+			RelationshipsDeleted: result.Stats.RelationshipsDeleted,
+			LabelsAdded:          result.Stats.LabelsAdded,
+			LabelsRemoved:        result.Stats.LabelsRemoved,
+			IndexesAdded:         result.Stats.IndexesAdded,
+			IndexesRemoved:       result.Stats.IndexesRemoved,
+			ConstraintsAdded:     result.Stats.ConstraintsAdded,
+			ConstraintsRemoved:   result.Stats.ConstraintsRemoved,
+		},
+	}
+	if response.Columns == nil {
+		response.Columns = []string{}
+	}
+
+	// The same node or relationship can appear in many rows; keep each once
+	seenNodes := make(map[string]bool)
+	seenRelationships := make(map[string]bool)
+	for _, data := range result.Data {
+		row := make(map[string]interface{}, len(result.Columns))
+		for i, column := range result.Columns {
+			if i < len(data.Row) {
+				row[column] = data.Row[i]
+			}
+		}
+		response.Rows = append(response.Rows, row)
+
+		for _, n := range data.Graph.Nodes {
+			node := Node{ID: preferElementID(n.ElementID, n.ID), Labels: n.Labels, Properties: n.Properties}
+			if seenNodes[node.ID] {
+				continue
+			}
+			seenNodes[node.ID] = true
+			if node.Labels == nil {
+				node.Labels = []string{}
+			}
+			if node.Properties == nil {
+				node.Properties = map[string]interface{}{}
+			}
+			response.Nodes = append(response.Nodes, node)
+		}
+		for _, r := range data.Graph.Relationships {
+			relationship := Relationship{
+				ID:         preferElementID(r.ElementID, r.ID),
+				Type:       r.Type,
+				StartNode:  preferElementID(r.StartNodeElementID, r.StartNode),
+				EndNode:    preferElementID(r.EndNodeElementID, r.EndNode),
+				Properties: r.Properties,
+			}
+			if seenRelationships[relationship.ID] {
+				continue
+			}
+			seenRelationships[relationship.ID] = true
+			if relationship.Properties == nil {
+				relationship.Properties = map[string]interface{}{}
+			}
+			response.Relationships = append(response.Relationships, relationship)
+		}
+	}
+
+	response.Duration = time.Since(start).String()
+	return response, nil
+}
+
+// preferElementID uses Neo4j 5's element ID, falling back to the legacy numeric ID
+func preferElementID(elementID, id string) string {
+	if elementID != "" {
+		return elementID
+	}
+	return id
+}
+
+// processAssertions evaluates all assertions and returns their results
+func processAssertions(p map[string]interface{}, response *Neo4jResponse, subject interface{}, state map[string]interface{}, env map[string]string) ([]AssertionResult, error) {
+	context := dsl.TemplateContext{Runtime: state, Env: env}
+	return assertions.Process(p, subject, context, func(assertionMap map[string]interface{}, result *AssertionResult) bool {
+		return evaluateAssertion(response, assertionMap, result)
+	})
+}
+
+// evaluateAssertion evaluates the neo4j plugin's own assertion types
+func evaluateAssertion(response *Neo4jResponse, assertionMap map[string]interface{}, result *AssertionResult) bool {
+	switch result.Type {
+	case AssertionTypeRowCount:
+		*result = countResult(*result, response.RowCount, "rows")
+
+	case AssertionTypeNodeCount:
+		label, _ := assertionMap["label"].(string)
+		count := 0
+		for _, node := range response.Nodes {
+			if label == "" || slices.Contains(node.Labels, label) {
+				count++
+			}
+		}
+		result.Name = label
+		noun := "nodes"
+		if label != "" {
+			noun = fmt.Sprintf("nodes labelled %s", label)
+		}
+		*result = countResult(*result, count, noun)
+
+	case AssertionTypeRelationshipCount:
+		relType, _ := assertionMap["relationship_type"].(string)
+		count := 0
+		for _, relationship := range response.Relationships {
+			if relType == "" || relationship.Type == relType {
+				count++
+			}
+		}
+		result.Name = relType
+		noun := "relationships"
+		if relType != "" {
+			noun = fmt.Sprintf("%s relationships", relType)
+		}
+		*result = countResult(*result, count, noun)
+
+	default:
+		return false
+	}
+	return true
+}
+
+// countResult compares a count with the assertion's expected value
+func countResult(result AssertionResult, count int, noun string) AssertionResult {
+	result.Actual = count
+	if !assertions.Equal(float64(count), result.Expected) {
+		result.Message = fmt.Sprintf("expected %v %s, got %d", result.Expected, noun, count)
+	} else {
+		result.Passed = true
+	}
+	return result
+}
+
+// validateConfig checks the step has what it needs once templates are rendered
+func validateConfig(config *Neo4jConfig) error {
+	if config.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+	if config.Query == "" {
+		return fmt.Errorf("query is required")
+	}
+	if config.Token != "" && config.Username != "" {
+		return fmt.Errorf("use either token or username and password, not both")
+	}
+	return nil
+}
+
+// applyVariableReplacement processes templates in the connection settings, the
+// query and the parameters
+func applyVariableReplacement(config *Neo4jConfig, state map[string]interface{}, env map[string]string) error {
+	context := dsl.TemplateContext{
+		Runtime: state,
+		Env:     env,
+	}
+
+	fields := map[string]*string{
+		"url":      &config.URL,
+		"database": &config.Database,
+		"username": &config.Username,
+		"password": &config.Password,
+		"token":    &config.Token,
+		"query":    &config.Query,
+	}
+	for name, value := range fields {
+		if *value == "" {
+			continue
+		}
+		processed, err := dsl.ProcessTemplate(*value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process %s template: %w", name, err)
+		}
+		*value = processed
+	}
+
+	for name, value := range config.Parameters {
+		processed, err := processValue(value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process parameters.%s template: %w", name, err)
+		}
+		config.Parameters[name] = processed
+	}
+
+	return nil
+}
+
+// processValue renders templates in the strings of a parameter value, leaving
+// numbers and other scalars as they are so Cypher sees their types
+func processValue(value interface{}, context dsl.TemplateContext) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return dsl.ProcessTemplate(v, context)
+	case map[string]interface{}:
+		for key, nested := range v {
+			processed, err := processValue(nested, context)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = processed
+		}
+	case []interface{}:
+		for i, nested := range v {
+			processed, err := processValue(nested, context)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = processed
+		}
+	}
+	return value, nil
+}
+
+// parseConfig converts map[string]interface{} to Neo4jConfig
+func parseConfig(configData map[string]interface{}, config *Neo4jConfig) error {
+	stringFields := map[string]*string{
+		"url":      &config.URL,
+		"database": &config.Database,
+		"username": &config.Username,
+		"password": &config.Password,
+		"token":    &config.Token,
+		"query":    &config.Query,
+		"timeout":  &config.Timeout,
+	}
+	for key, target := range stringFields {
+		if v, ok := configData[key].(string); ok {
+			*target = v
+		}
+	}
+
+	switch parameters := configData["parameters"].(type) {
+	case nil:
+	case map[string]interface{}:
+		config.Parameters = parameters
+	default:
+		return fmt.Errorf("parameters must be a map, got %T", parameters)
+	}
+
+	return nil
+}
@@ -0,0 +1,193 @@
+package neo4j
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rocketship-ai/rocketship/internal/plugins/plugintest"
+)
+
+// fakeNeo4j answers transactions with a fixed graph: Alice knows Bob and Carol,
+// returned one row per relationship
+type fakeNeo4j struct {
+	paths      []string
+	statements []txStatement
+}
+
+func (f *fakeNeo4j) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.paths = append(f.paths, r.URL.Path)
+
+	user, password, ok := r.BasicAuth()
+	if !ok || user != "neo4j" || password != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"errors":[{"code":"Neo.ClientError.Security.Unauthorized","message":"Invalid username or password."}]}`))
+		return
+	}
+
+	var body struct {
+		Statements []txStatement `json:"statements"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	f.statements = append(f.statements, body.Statements...)
+
+	statement := body.Statements[0].Statement
+	switch {
+	case strings.HasPrefix(statement, "MATCH"):
+		_, _ = w.Write([]byte(`{"results":[{"columns":["a","friend","r"],"data":[
+			{"row":[{"name":"Alice"},{"name":"Bob"},{"since":2019}],
+			 "graph":{"nodes":[{"id":"1","elementId":"4:x:1","labels":["Person"],"properties":{"name":"Alice"}},{"id":"2","elementId":"4:x:2","labels":["Person"],"properties":{"name":"Bob"}}],
+			          "relationships":[{"id":"7","elementId":"5:x:7","type":"KNOWS","startNode":"1","startNodeElementId":"4:x:1","endNode":"2","endNodeElementId":"4:x:2","properties":{"since":2019}}]}},
+			{"row":[{"name":"Alice"},{"name":"Carol"},{"since":2021}],
+			 "graph":{"nodes":[{"id":"1","elementId":"4:x:1","labels":["Person"],"properties":{"name":"Alice"}},{"id":"3","elementId":"4:x:3","labels":["Person","Admin"],"properties":{"name":"Carol"}}],
+			          "relationships":[{"id":"8","elementId":"5:x:8","type":"KNOWS","startNode":"1","startNodeElementId":"4:x:1","endNode":"3","endNodeElementId":"4:x:3","properties":{"since":2021}}]}}
+		],"stats":{"contains_updates":false}}],"errors":[]}`))
+	case strings.HasPrefix(statement, "CREATE"):
+		_, _ = w.Write([]byte(`{"results":[{"columns":[],"data":[],"stats":{"contains_updates":true,"nodes_created":2,"relationships_created":1,"relationship_deleted":0,"properties_set":3,"labels_added":2}}],"errors":[]}`))
+	default:
+		_, _ = w.Write([]byte(`{"results":[],"errors":[{"code":"Neo.ClientError.Statement.SyntaxError","message":"Invalid input 'MTCH'"}]}`))
+	}
+}
+
+// runStep runs the step through the plugin's Activity
+func runStep(t *testing.T, server *httptest.Server, configData map[string]interface{}, assertionList []interface{}) (*ActivityResponse, error) {
+	t.Helper()
+	configData["url"] = server.URL
+	if _, ok := configData["username"]; !ok {
+		configData["username"] = "neo4j"
+		configData["password"] = "{{ .env.NEO4J_PASSWORD }}"
+	}
+
+	resp := &ActivityResponse{}
+	err := plugintest.Run(t, &Neo4jPlugin{}, map[string]interface{}{
+		"config":     configData,
+		"state":      map[string]interface{}{"user_name": "Alice"},
+		"env":        map[string]interface{}{"NEO4J_PASSWORD": "secret"},
+		"assertions": assertionList,
+		"save": []interface{}{
+			map[string]interface{}{"json_path": ".rows[0].friend.name", "as": "first_friend", "required": false},
+		},
+	}, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func TestQueryNodesAndRelationships(t *testing.T) {
+	fake := &fakeNeo4j{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	resp, err := runStep(t, server, map[string]interface{}{
+		"database": "social",
+		"query":    "MATCH (a:Person {name: $name})-[r:KNOWS]->(friend) RETURN a, friend, r",
+		"parameters": map[string]interface{}{
+			"name":  "{{ user_name }}",
+			"limit": float64(10),
+		},
+	}, []interface{}{
+		map[string]interface{}{"type": "row_count", "expected": float64(2)},
+		map[string]interface{}{"type": "node_count", "expected": float64(3)},
+		map[string]interface{}{"type": "node_count", "label": "Admin", "expected": float64(1)},
+		map[string]interface{}{"type": "relationship_count", "relationship_type": "KNOWS", "expected": float64(2)},
+		map[string]interface{}{"type": "equals", "path": ".relationships[0].start_node", "expected": "4:x:1"},
+		map[string]interface{}{"type": "contains", "path": "[.rows[].friend.name]", "expected": "Carol"},
+	})
+	if err != nil {
+		t.Fatalf("step failed: %v", err)
+	}
+
+	if fake.paths[0] != "/db/social/tx/commit" {
+		t.Errorf("expected the social database's commit endpoint, got %s", fake.paths[0])
+	}
+	sent := fake.statements[0]
+	if sent.Parameters["name"] != "Alice" || sent.Parameters["limit"] != float64(10) {
+		t.Errorf("expected rendered parameters, got %v", sent.Parameters)
+	}
+	if !sent.IncludeStats || strings.Join(sent.ResultDataContents, ",") != "row,graph" {
+		t.Errorf("expected rows, graph and stats to be requested, got %+v", sent)
+	}
+	if len(resp.Response.Nodes) != 3 || resp.Response.Nodes[0].ID != "4:x:1" {
+		t.Errorf("expected Alice once among 3 nodes, got %+v", resp.Response.Nodes)
+	}
+	if resp.Saved["first_friend"] != "Bob" {
+		t.Errorf("expected first_friend Bob, got %q", resp.Saved["first_friend"])
+	}
+}
+
+func TestCountAssertionFailure(t *testing.T) {
+	server := httptest.NewServer(&fakeNeo4j{})
+	defer server.Close()
+
+	_, err := runStep(t, server, map[string]interface{}{
+		"query": "MATCH (a)-[r]->(b) RETURN a, b, r",
+	}, []interface{}{
+		map[string]interface{}{"type": "node_count", "label": "Admin", "expected": float64(2)},
+	})
+	if err == nil || !strings.Contains(err.Error(), "expected 2 nodes labelled Admin, got 1") {
+		t.Fatalf("expected a node count failure, got %v", err)
+	}
+}
+
+func TestWriteStats(t *testing.T) {
+	server := httptest.NewServer(&fakeNeo4j{})
+	defer server.Close()
+
+	resp, err := runStep(t, server, map[string]interface{}{
+		"query": "CREATE (:Person {name: 'Dan'})-[:KNOWS]->(:Person {name: 'Eve'})",
+	}, []interface{}{
+		map[string]interface{}{"type": "equals", "path": ".stats.nodes_created", "expected": float64(2)},
+		map[string]interface{}{"type": "row_count", "expected": float64(0)},
+	})
+	if err != nil {
+		t.Fatalf("step failed: %v", err)
+	}
+	if !resp.Response.Stats.ContainsUpdates || resp.Response.Stats.RelationshipsCreated != 1 {
+		t.Errorf("unexpected stats %+v", resp.Response.Stats)
+	}
+	if _, ok := resp.Saved["first_friend"]; ok {
+		t.Errorf("expected the optional save to be skipped, got %v", resp.Saved)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	server := httptest.NewServer(&fakeNeo4j{})
+	defer server.Close()
+
+	_, err := runStep(t, server, map[string]interface{}{"query": "MTCH (n) RETURN n"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "Neo.ClientError.Statement.SyntaxError: Invalid input 'MTCH'") {
+		t.Fatalf("expected the Cypher error, got %v", err)
+	}
+
+	_, err = runStep(t, server, map[string]interface{}{"query": "MATCH (n) RETURN n", "username": "neo4j", "password": "wrong"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "Neo.ClientError.Security.Unauthorized") {
+		t.Fatalf("expected an authentication error, got %v", err)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Neo4jConfig
+		wantErr string
+	}{
+		{"missing url", Neo4jConfig{Query: "RETURN 1"}, "url is required"},
+		{"missing query", Neo4jConfig{URL: "http://neo4j:7474"}, "query is required"},
+		{"token and username", Neo4jConfig{URL: "http://neo4j:7474", Query: "RETURN 1", Token: "t", Username: "neo4j"}, "either token or username"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateConfig(&tt.config)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if _, err := newClient(&Neo4jConfig{URL: "bolt://neo4j:7687"}, nil); err == nil || !strings.Contains(err.Error(), "must be an http or https URL") {
+		t.Errorf("expected bolt URLs to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package neo4j
+
+import "github.com/rocketship-ai/rocketship/internal/assertions"
+
+// Neo4jPlugin represents a Neo4j test step
+type Neo4jPlugin struct {
+	Name   string      `json:"name" yaml:"name"`
+	Plugin string      `json:"plugin" yaml:"plugin"`
+	Config Neo4jConfig `json:"config" yaml:"config"`
+}
+
+// Neo4jConfig selects the server and the Cypher query to run
+type Neo4jConfig struct {
+	// Connection, over the HTTP API
+	URL      string `json:"url" yaml:"url"`                               // e.g. http://neo4j:7474
+	Database string `json:"database,omitempty" yaml:"database,omitempty"` // Defaults to neo4j
+	Username string `json:"username,omitempty" yaml:"username,omitempty"` // Basic auth user
+	Password string `json:"password,omitempty" yaml:"password,omitempty"` // Basic auth password
+	Token    string `json:"token,omitempty" yaml:"token,omitempty"`       // Bearer token, instead of username and password
+
+	// Query
+	Query      string                 `json:"query" yaml:"query"`
+	Parameters map[string]interface{} `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"` // Overall step timeout (defaults to 30s)
+}
+
+// Assertion types supported by the neo4j plugin in addition to the shared ones
+const (
+	AssertionTypeRowCount          = "row_count"
+	AssertionTypeNodeCount         = "node_count"
+	AssertionTypeRelationshipCount = "relationship_count"
+)
+
+// Node is a node the query returned, anywhere in its rows
+type Node struct {
+	ID         string                 `json:"id"`
+	Labels     []string               `json:"labels"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// Relationship is a relationship the query returned, anywhere in its rows
+type Relationship struct {
+	ID         string                 `json:"id"`
+	Type       string                 `json:"type"`
+	StartNode  string                 `json:"start_node"`
+	EndNode    string                 `json:"end_node"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// QueryStats counts what a write query changed
+type QueryStats struct {
+	ContainsUpdates      bool `json:"contains_updates"`
+	NodesCreated         int  `json:"nodes_created"`
+	NodesDeleted         int  `json:"nodes_deleted"`
+	PropertiesSet        int  `json:"properties_set"`
+	RelationshipsCreated int  `json:"relationships_created"`
+	RelationshipsDeleted int  `json:"relationships_deleted"`
+	LabelsAdded          int  `json:"labels_added"`
+	LabelsRemoved        int  `json:"labels_removed"`
+	IndexesAdded         int  `json:"indexes_added"`
+	IndexesRemoved       int  `json:"indexes_removed"`
+	ConstraintsAdded     int  `json:"constraints_added"`
+	ConstraintsRemoved   int  `json:"constraints_removed"`
+}
+
+// Neo4jResponse contains the rows, and the nodes and relationships in them
+type Neo4jResponse struct {
+	Columns       []string                 `json:"columns"`
+	Rows          []map[string]interface{} `json:"rows"`
+	RowCount      int                      `json:"row_count"`
+	Nodes         []Node                   `json:"nodes"`
+	Relationships []Relationship           `json:"relationships"`
+	Stats         QueryStats               `json:"stats"`
+	Duration      string                   `json:"duration"`
+}
+
+// AssertionResult represents a single assertion result for UI display
+type AssertionResult = assertions.Result
+
+// ActivityResponse represents the complete activity response
+type ActivityResponse struct {
+	Response         *Neo4jResponse    `json:"response"`
+	Saved            map[string]string `json:"saved"`
+	AssertionResults []AssertionResult `json:"assertion_results,omitempty"`
+}
@@ -6,11 +6,12 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/itchyny/gojq"
 	"go.temporal.io/sdk/activity"
 	"go.temporal.io/sdk/log"
 
+	"github.com/rocketship-ai/rocketship/internal/assertions"
 	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/saves"
 )
 
 type noopLogger struct{}
@@ -67,12 +68,12 @@ func templateStringField(config map[string]interface{}, key string, ctx dsl.Temp
 }
 
 func processSaves(params map[string]interface{}, result map[string]interface{}, saved map[string]string) error {
-	saves, ok := params["save"].([]interface{})
+	saveConfigs, ok := params["save"].([]interface{})
 	if !ok {
 		return nil
 	}
 
-	for _, rawSave := range saves {
+	for _, rawSave := range saveConfigs {
 		saveMap, ok := rawSave.(map[string]interface{})
 		if !ok {
 			return fmt.Errorf("invalid save entry %T", rawSave)
@@ -93,27 +94,9 @@ func processSaves(params map[string]interface{}, result map[string]interface{},
 			return fmt.Errorf("save %q must define json_path", as)
 		}
 
-		query, err := gojq.Parse(jsonPath)
+		v, found, err := saves.Extract(jsonPath, result)
 		if err != nil {
-			return fmt.Errorf("failed to parse jq expression %q: %w", jsonPath, err)
-		}
-
-		iter := query.Run(result)
-		var value interface{}
-		var found bool
-
-		for {
-			v, ok := iter.Next()
-			if !ok {
-				break
-			}
-			if err, ok := v.(error); ok {
-				return fmt.Errorf("jq evaluation error for %q: %w", jsonPath, err)
-			}
-			if !found {
-				value = v
-				found = true
-			}
+			return fmt.Errorf("jq evaluation error for %q: %w", jsonPath, err)
 		}
 
 		if !found {
@@ -123,19 +106,28 @@ func processSaves(params map[string]interface{}, result map[string]interface{},
 			continue
 		}
 
-		saved[as] = fmt.Sprint(value)
+		value, err := saves.Format(v, saves.Type(saveMap))
+		if err != nil {
+			return fmt.Errorf("save %q failed: %w", as, err)
+		}
+		saved[as] = value
 	}
 
 	return nil
 }
 
 func processAssertions(params map[string]interface{}, result map[string]interface{}, state map[string]interface{}, envSecrets map[string]string) error {
-	assertions, ok := params["assertions"].([]interface{})
+	assertionList, ok := params["assertions"].([]interface{})
 	if !ok {
 		return nil
 	}
 
-	for _, rawAssertion := range assertions {
+	subject, err := assertions.Normalize(result)
+	if err != nil {
+		return fmt.Errorf("failed to read result: %w", err)
+	}
+
+	for _, rawAssertion := range assertionList {
 		assertion, ok := rawAssertion.(map[string]interface{})
 		if !ok {
 			return fmt.Errorf("invalid assertion %T", rawAssertion)
@@ -146,62 +138,26 @@ func processAssertions(params map[string]interface{}, result map[string]interfac
 			return errors.New("assertion type is required")
 		}
 
-		switch typ {
-		case "json_path":
-			path, ok := assertion["path"].(string)
-			if !ok {
+		if !assertions.IsShared(typ) {
+			return fmt.Errorf("unsupported assertion type %q", typ)
+		}
+		if typ == assertions.TypeJSONPath {
+			if _, ok := assertion["path"].(string); !ok {
 				return errors.New("json_path assertion requires path")
 			}
+		}
 
-			query, err := gojq.Parse(path)
+		expected := assertion["expected"]
+		if expectedStr, ok := expected.(string); ok {
+			rendered, err := dsl.ProcessTemplate(expectedStr, dsl.TemplateContext{Runtime: state, Env: envSecrets})
 			if err != nil {
-				return fmt.Errorf("failed to parse jq expression %q: %w", path, err)
-			}
-
-			iter := query.Run(result)
-			var actual interface{}
-			var found bool
-
-			for {
-				v, ok := iter.Next()
-				if !ok {
-					break
-				}
-				if err, ok := v.(error); ok {
-					return fmt.Errorf("jq evaluation error for %q: %w", path, err)
-				}
-				if !found {
-					actual = v
-					found = true
-				}
-			}
-
-			if existsOnly, ok := assertion["exists"].(bool); ok && existsOnly {
-				if !found {
-					return fmt.Errorf("expected path %q to exist", path)
-				}
-				continue
-			}
-
-			if !found {
-				return fmt.Errorf("jq path %q did not return a value", path)
+				return fmt.Errorf("failed to process expected template: %w", err)
 			}
+			expected = rendered
+		}
 
-			if expectedRaw, ok := assertion["expected"]; ok {
-				expected := expectedRaw
-				if expectedStr, ok := expectedRaw.(string); ok {
-					rendered, err := dsl.ProcessTemplate(expectedStr, dsl.TemplateContext{Runtime: state, Env: envSecrets})
-					if err != nil {
-						return fmt.Errorf("failed to process expected template: %w", err)
-					}
-					expected = rendered
-				}
-				if fmt.Sprint(actual) != fmt.Sprint(expected) {
-					return fmt.Errorf("assertion failed for %q: expected %v, got %v", path, expected, actual)
-				}
-			}
-		default:
-			return fmt.Errorf("unsupported assertion type %q", typ)
+		if err := assertions.Evaluate(assertion, subject, expected).Err(); err != nil {
+			return err
 		}
 	}
 
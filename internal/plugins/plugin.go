@@ -2,9 +2,14 @@ package plugins
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
 	"sync"
 
+	"github.com/rocketship-ai/rocketship/internal/buildinfo"
+	"github.com/rocketship-ai/rocketship/internal/vault"
+
 	"go.temporal.io/sdk/activity"
 	"go.temporal.io/sdk/worker"
 )
@@ -55,14 +60,60 @@ func GetRegisteredPlugins() []Plugin {
 	return plugins
 }
 
+// RegisteredTypes returns the types of all registered plugins, sorted
+func RegisteredTypes() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	types := make([]string, 0, len(registry))
+	for pluginType := range registry {
+		types = append(types, pluginType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// WorkerVersionKey is added to every plugin response with the version of the worker that ran it
+const WorkerVersionKey = "worker_version"
+
 // RegisterWithTemporal registers a plugin with Temporal worker
 func RegisterWithTemporal(w worker.Worker, c Plugin) {
+	version := buildinfo.CurrentWorker().Version
 	w.RegisterActivityWithOptions(
-		c.Activity,
+		func(ctx context.Context, p map[string]interface{}) (interface{}, error) {
+			// Env secrets stored in Vault are read here, so their values never
+			// reach workflow history; anything echoing them back is masked
+			if err := vault.ResolveParams(ctx, p); err != nil {
+				return nil, err
+			}
+			resp, err := c.Activity(ctx, p)
+			if err != nil {
+				return vault.MaskResponse(resp), vault.MaskError(err)
+			}
+			return withWorkerVersion(vault.MaskResponse(resp), version), nil
+		},
 		activity.RegisterOptions{Name: c.GetType()},
 	)
 }
 
+// withWorkerVersion adds the worker version to object responses. Temporal encodes
+// results as JSON anyway, so the round trip doesn't change what the workflow sees.
+func withWorkerVersion(resp interface{}, version string) interface{} {
+	if resp == nil {
+		return resp
+	}
+	encoded, err := json.Marshal(resp)
+	if err != nil {
+		return resp
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil || decoded == nil {
+		return resp
+	}
+	decoded[WorkerVersionKey] = version
+	return decoded
+}
+
 // RegisterAllWithTemporal registers all plugins in the registry with Temporal worker
 func RegisterAllWithTemporal(w worker.Worker) {
 	plugins := GetRegisteredPlugins()
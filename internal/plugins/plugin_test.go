@@ -2,8 +2,10 @@ package plugins
 
 import (
 	"context"
+	"testing"
 
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockPlugin is a mock implementation of the Plugin interface
@@ -20,3 +22,17 @@ func (m *MockPlugin) Activity(ctx context.Context, p map[string]interface{}) (in
 	args := m.Called(ctx, p)
 	return args.Get(0), args.Error(1)
 }
+
+func TestWithWorkerVersion(t *testing.T) {
+	type response struct {
+		Saved map[string]string `json:"saved"`
+	}
+
+	stamped, ok := withWorkerVersion(&response{Saved: map[string]string{"id": "1"}}, "v0.6.0").(map[string]interface{})
+	require.True(t, ok)
+	require.Equal(t, "v0.6.0", stamped[WorkerVersionKey])
+	require.Equal(t, map[string]interface{}{"id": "1"}, stamped["saved"])
+
+	require.Nil(t, withWorkerVersion(nil, "v0.6.0"))
+	require.Equal(t, "text", withWorkerVersion("text", "v0.6.0"))
+}
@@ -0,0 +1,31 @@
+// Package plugintest runs plugin activities in tests the way a worker does, with
+// an activity context, so tests go through the plugin's own Activity.
+package plugintest
+
+import (
+	"testing"
+
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/rocketship-ai/rocketship/internal/plugins"
+)
+
+// Run executes plugin's Activity with params and decodes the result into out,
+// which can be nil. It returns the activity's error.
+func Run(t testing.TB, plugin plugins.Plugin, params map[string]interface{}, out interface{}) error {
+	t.Helper()
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	env.RegisterActivity(plugin.Activity)
+
+	value, err := env.ExecuteActivity(plugin.Activity, params)
+	if err != nil {
+		return err
+	}
+	if out != nil {
+		if err := value.Get(out); err != nil {
+			t.Fatalf("failed to decode %s result: %v", plugin.GetType(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package poll
+
+import (
+	"context"
+
+	"github.com/rocketship-ai/rocketship/internal/plugins"
+)
+
+// Auto-register the plugin when the package is imported
+func init() {
+	plugins.RegisterPlugin(&PollPlugin{})
+}
+
+func (pp *PollPlugin) GetType() string {
+	return "poll"
+}
+
+func (pp *PollPlugin) Activity(ctx context.Context, p map[string]interface{}) (interface{}, error) {
+	// Dummy activity to satisfy the interface. Poll runs the inner plugin's
+	// activity from the workflow, sleeping between attempts.
+	return nil, nil
+}
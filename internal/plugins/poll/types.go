@@ -0,0 +1,16 @@
+package poll
+
+type PollPlugin struct {
+	Name   string     `json:"name" yaml:"name"`
+	Plugin string     `json:"plugin" yaml:"plugin"`
+	Config PollConfig `json:"config" yaml:"config"`
+}
+
+type PollConfig struct {
+	Plugin      string                 `json:"plugin" yaml:"plugin"`
+	Config      map[string]interface{} `json:"config" yaml:"config"`
+	Timeout     string                 `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	Interval    string                 `json:"interval,omitempty" yaml:"interval,omitempty"`
+	Backoff     float64                `json:"backoff,omitempty" yaml:"backoff,omitempty"`
+	MaxInterval string                 `json:"max_interval,omitempty" yaml:"max_interval,omitempty"`
+}
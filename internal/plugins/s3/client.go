@@ -0,0 +1,286 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/awsauth"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+)
+
+// client calls the S3 REST API. Only the handful of operations the plugin needs
+// are implemented.
+type client struct {
+	endpoint  *url.URL
+	pathStyle bool // Address buckets in the path instead of the host
+	region    string
+	creds     awsauth.Credentials
+	http      *http.Client
+	now       func() time.Time
+}
+
+// apiError is an error response from S3, e.g. NoSuchKey
+type apiError struct {
+	Code    string
+	Message string
+	Status  int
+}
+
+func (e *apiError) Error() string {
+	if e.Message == "" {
+		return e.Code
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// defaultCompatibleRegion signs requests to a custom endpoint when no region is
+// set. MinIO, Ceph and Cloudflare R2 all accept it.
+const defaultCompatibleRegion = "us-east-1"
+
+// newClient connects to S3 in the bucket's region, or to endpoint when it's set,
+// e.g. for MinIO, Ceph or Cloudflare R2. Connections go through the egress
+// policy.
+func newClient(config *S3Config, policy *egress.Policy) (*client, error) {
+	region := config.Region
+	if region == "" {
+		region = awsauth.RegionFromEnv()
+	}
+	if region == "" && config.Endpoint != "" {
+		region = defaultCompatibleRegion
+	}
+	if region == "" {
+		return nil, fmt.Errorf("region is required; set it in the step or with AWS_REGION on the worker")
+	}
+
+	creds := awsauth.CredentialsFromEnv()
+	if config.AccessKeyID != "" || config.SecretAccessKey != "" {
+		creds = awsauth.Credentials{
+			AccessKeyID:     config.AccessKeyID,
+			SecretAccessKey: config.SecretAccessKey,
+			SessionToken:    config.SessionToken,
+		}
+	}
+	if !creds.Valid() {
+		return nil, fmt.Errorf("AWS credentials are required; set access_key_id and secret_access_key or AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY on the worker")
+	}
+
+	// S3-compatible stores are usually reached by an address that has no
+	// wildcard DNS for bucket subdomains, so they default to path-style
+	endpoint := &url.URL{Scheme: "https", Host: fmt.Sprintf("s3.%s.amazonaws.com", region)}
+	pathStyle := false
+	if config.Endpoint != "" {
+		parsed, err := url.Parse(config.Endpoint)
+		if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.RawQuery != "" {
+			return nil, fmt.Errorf("invalid endpoint %q: must be an http or https URL", config.Endpoint)
+		}
+		endpoint = parsed
+		pathStyle = true
+	}
+	if config.PathStyle != nil {
+		pathStyle = *config.PathStyle
+	} else if endpoint.Scheme == "https" && strings.Contains(config.Bucket, ".") {
+		// A dotted bucket name doesn't match the endpoint's wildcard certificate
+		pathStyle = true
+	}
+
+	return &client{
+		endpoint:  endpoint,
+		pathStyle: pathStyle,
+		region:    region,
+		creds:     creds,
+		http:      &http.Client{Transport: policy.Transport()},
+		now:       time.Now,
+	}, nil
+}
+
+// objectURL returns the URL of key in bucket, with the key encoded the way it
+// is signed
+func (c *client) objectURL(bucket, key string, query url.Values) *url.URL {
+	u := *c.endpoint
+	path := "/" + key
+	if c.pathStyle {
+		path = "/" + bucket + path
+	} else {
+		u.Host = bucket + "." + u.Host
+	}
+	u.Path = strings.TrimRight(c.endpoint.Path, "/") + path
+	u.RawPath = strings.TrimRight(c.endpoint.EscapedPath(), "/") + awsauth.EscapePath(path)
+	u.RawQuery = query.Encode()
+	return &u
+}
+
+// send signs and sends a request for key. Error responses are returned as an
+// *apiError; otherwise the caller closes the body.
+func (c *client) send(ctx context.Context, method, bucket, key string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.objectURL(bucket, key, query).String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if err := awsauth.Sign(req, body, c.creds, c.region, "s3", c.now()); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	return nil, responseError(resp, data)
+}
+
+// responseError decodes an S3 error document. HEAD responses have no body, so
+// their status stands in for the code.
+func responseError(resp *http.Response, data []byte) error {
+	var failure struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	_ = xml.Unmarshal(data, &failure)
+	apiErr := &apiError{Code: failure.Code, Message: failure.Message, Status: resp.StatusCode}
+	if apiErr.Code == "" {
+		apiErr.Code = resp.Status
+	}
+	return apiErr
+}
+
+// call sends a request and reads the whole response
+func (c *client) call(ctx context.Context, operation, method, bucket, key string, query url.Values, header http.Header, body []byte) (http.Header, []byte, error) {
+	resp, err := c.send(ctx, method, bucket, key, query, header, body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("s3 %s failed: %w", operation, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read s3 %s response: %w", operation, err)
+	}
+	return resp.Header, data, nil
+}
+
+// objectHeader holds the headers a put or a new multipart upload sends
+func objectHeader(contentType string, metadata map[string]string) http.Header {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	for name, value := range metadata {
+		header.Set("X-Amz-Meta-"+name, value)
+	}
+	return header
+}
+
+// contentMD5 is the Content-MD5 header for data, which S3 checks the body against
+func contentMD5(data []byte) string {
+	sum := md5.Sum(data)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// putObject writes data to key in one request
+func (c *client) putObject(ctx context.Context, bucket, key string, data []byte, contentType string, metadata map[string]string) (http.Header, error) {
+	header := objectHeader(contentType, metadata)
+	header.Set("Content-MD5", contentMD5(data))
+	respHeader, _, err := c.call(ctx, "PutObject", http.MethodPut, bucket, key, nil, header, data)
+	return respHeader, err
+}
+
+// deleteObject removes key. S3 doesn't report whether it existed.
+func (c *client) deleteObject(ctx context.Context, bucket, key string) error {
+	_, _, err := c.call(ctx, "DeleteObject", http.MethodDelete, bucket, key, nil, nil, nil)
+	return err
+}
+
+type initiateMultipartUploadResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+type completeMultipartUploadResult struct {
+	ETag string `xml:"ETag"`
+}
+
+// createMultipartUpload starts a multipart upload and returns its ID
+func (c *client) createMultipartUpload(ctx context.Context, bucket, key, contentType string, metadata map[string]string) (string, error) {
+	_, data, err := c.call(ctx, "CreateMultipartUpload", http.MethodPost, bucket, key, url.Values{"uploads": {""}}, objectHeader(contentType, metadata), nil)
+	if err != nil {
+		return "", err
+	}
+	var result initiateMultipartUploadResult
+	if err := xml.Unmarshal(data, &result); err != nil || result.UploadID == "" {
+		return "", fmt.Errorf("s3 CreateMultipartUpload returned no upload ID")
+	}
+	return result.UploadID, nil
+}
+
+// uploadPart sends one part and returns the ETag S3 gave it
+func (c *client) uploadPart(ctx context.Context, bucket, key, uploadID string, number int, data []byte) (string, error) {
+	query := url.Values{"partNumber": {fmt.Sprint(number)}, "uploadId": {uploadID}}
+	header := http.Header{}
+	header.Set("Content-MD5", contentMD5(data))
+	respHeader, _, err := c.call(ctx, fmt.Sprintf("UploadPart %d", number), http.MethodPut, bucket, key, query, header, data)
+	if err != nil {
+		return "", err
+	}
+	return respHeader.Get("ETag"), nil
+}
+
+// completeMultipartUpload joins the parts into the object and returns its ETag
+// and response headers. S3 can report a failure with a 200 status once it has
+// started answering, so the body is checked for an error too.
+func (c *client) completeMultipartUpload(ctx context.Context, bucket, key, uploadID string, parts []completedPart) (string, http.Header, error) {
+	body, err := xml.Marshal(completeMultipartUpload{Parts: parts})
+	if err != nil {
+		return "", nil, err
+	}
+	respHeader, data, err := c.call(ctx, "CompleteMultipartUpload", http.MethodPost, bucket, key, url.Values{"uploadId": {uploadID}}, nil, body)
+	if err != nil {
+		return "", nil, err
+	}
+	if bytes.Contains(data, []byte("<Error>")) {
+		return "", nil, fmt.Errorf("s3 CompleteMultipartUpload failed: %w", responseError(&http.Response{Status: "200 OK", StatusCode: http.StatusOK}, data))
+	}
+	var result completeMultipartUploadResult
+	if err := xml.Unmarshal(data, &result); err != nil {
+		return "", nil, fmt.Errorf("failed to decode s3 CompleteMultipartUpload response: %w", err)
+	}
+	return result.ETag, respHeader, nil
+}
+
+// abortMultipartUpload discards the parts of an unfinished upload
+func (c *client) abortMultipartUpload(ctx context.Context, bucket, key, uploadID string) error {
+	_, _, err := c.call(ctx, "AbortMultipartUpload", http.MethodDelete, bucket, key, url.Values{"uploadId": {uploadID}}, nil, nil)
+	return err
+}
+
+// presign returns a URL that lets its holder send method to key until expires passes
+func (c *client) presign(method, bucket, key string, expires time.Duration) (string, error) {
+	return awsauth.Presign(method, c.objectURL(bucket, key, nil), c.creds, c.region, "s3", expires, c.now())
+}
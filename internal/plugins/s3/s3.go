@@ -0,0 +1,737 @@
+package s3
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/confine"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+	"go.temporal.io/sdk/activity"
+)
+
+const (
+	defaultTimeout   = 60 * time.Second
+	defaultExpires   = 15 * time.Minute
+	maxExpires       = 7 * 24 * time.Hour // The longest SigV4 allows
+	defaultPartSize  = 8 << 20
+	maxPartSize      = 5 << 30
+	maxParts         = 10000
+	defaultAlgorithm = "sha256"
+	// get keeps at most this many bytes of the body; the checksum still covers the whole object
+	maxBodyBytes = 10 << 20
+)
+
+// minPartSize is the smallest part S3 accepts, except for the last one
+var minPartSize int64 = 5 << 20
+
+// Auto-register the plugin when the package is imported
+func init() {
+	plugins.RegisterPlugin(&S3Plugin{})
+}
+
+// GetType returns the plugin type identifier
+func (sp *S3Plugin) GetType() string {
+	return "s3"
+}
+
+// Activity runs an action against an object and checks the result
+func (sp *S3Plugin) Activity(ctx context.Context, p map[string]interface{}) (interface{}, error) {
+	logger := activity.GetLogger(ctx)
+
+	// Parse configuration from parameters
+	configData, ok := p["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid config format")
+	}
+
+	config := &S3Config{}
+	if err := parseConfig(configData, config); err != nil {
+		return nil, fmt.Errorf("failed to parse s3 config: %w", err)
+	}
+
+	timeout := defaultTimeout
+	if config.Timeout != "" {
+		parsed, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", config.Timeout, err)
+		}
+		timeout = parsed
+	}
+
+	// Convert state to map[string]interface{} for template processing
+	state := make(map[string]interface{})
+	if stateStr, ok := p["state"].(map[string]string); ok {
+		for k, v := range stateStr {
+			state[k] = v
+		}
+	} else if stateInt, ok := p["state"].(map[string]interface{}); ok {
+		state = stateInt
+	}
+
+	// Extract env secrets from params (for {{ .env.* }} template resolution)
+	env := make(map[string]string)
+	if envData, ok := p["env"].(map[string]interface{}); ok {
+		for k, v := range envData {
+			if strVal, ok := v.(string); ok {
+				env[k] = strVal
+			}
+		}
+	} else if envData, ok := p["env"].(map[string]string); ok {
+		env = envData
+	}
+
+	if err := applyVariableReplacement(config, state, env); err != nil {
+		return nil, fmt.Errorf("variable replacement failed: %w", err)
+	}
+
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	if err := resolveCredentials(config, env); err != nil {
+		return nil, err
+	}
+
+	runID := ""
+	if runData, ok := p["run"].(map[string]interface{}); ok {
+		runID, _ = runData["id"].(string)
+	}
+	if err := resolveFile(runID, config); err != nil {
+		return nil, err
+	}
+
+	policy, err := egress.FromParams(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
+
+	logger.Info("Executing s3 plugin", "action", config.Action, "bucket", config.Bucket, "key", config.Key)
+
+	c, err := newClient(config, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	response, err := execute(ctx, c, config)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := assertions.Normalize(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare s3 result: %w", err)
+	}
+
+	assertionResults, err := processAssertions(p, response, subject, state, env)
+	if err != nil {
+		return nil, err
+	}
+	if failure := assertions.Summary(assertionResults); failure != "" {
+		return nil, fmt.Errorf("%s", failure)
+	}
+
+	saved := make(map[string]string)
+	if err := saves.Process(p, subject, saved); err != nil {
+		return nil, err
+	}
+
+	logger.Info("S3 step completed", "action", config.Action, "size", response.Size, "duration", response.Duration)
+
+	return &ActivityResponse{
+		Response:         response,
+		Saved:            saved,
+		AssertionResults: assertionResults,
+	}, nil
+}
+
+// execute runs the configured action
+func execute(ctx context.Context, c *client, config *S3Config) (*S3Response, error) {
+	start := time.Now()
+	response := &S3Response{Action: config.Action, Bucket: config.Bucket, Key: config.Key}
+
+	var err error
+	switch config.Action {
+	case ActionPut:
+		err = put(ctx, c, config, response)
+	case ActionGet:
+		err = get(ctx, c, config, response)
+	case ActionHead:
+		err = head(ctx, c, config, response)
+	case ActionDelete:
+		err = c.deleteObject(ctx, config.Bucket, config.Key)
+	case ActionUpload:
+		err = upload(ctx, c, config, response)
+	case ActionPresign:
+		err = presign(c, config, response)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response.Duration = time.Since(start).String()
+	return response, nil
+}
+
+// put writes body or the file in one request
+func put(ctx context.Context, c *client, config *S3Config, response *S3Response) error {
+	data := []byte(config.Body)
+	if config.File != "" {
+		var err error
+		if data, err = os.ReadFile(config.File); err != nil {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+	}
+
+	header, err := c.putObject(ctx, config.Bucket, config.Key, data, config.ContentType, config.Metadata)
+	if err != nil {
+		return err
+	}
+
+	h := newHash(config.Algorithm)
+	h.Write(data)
+	response.ETag = strings.Trim(header.Get("ETag"), `"`)
+	response.VersionID = header.Get("X-Amz-Version-Id")
+	response.Size = int64(len(data))
+	response.ContentType = config.ContentType
+	response.Algorithm = algorithmName(config.Algorithm)
+	response.Checksum = hex.EncodeToString(h.Sum(nil))
+	return nil
+}
+
+// get reads the start of the object and checksums all of it in one pass
+func get(ctx context.Context, c *client, config *S3Config, response *S3Response) error {
+	resp, err := c.send(ctx, http.MethodGet, config.Bucket, config.Key, nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("s3 GetObject failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	h := newHash(config.Algorithm)
+	body := &prefixWriter{limit: maxBodyBytes}
+	size, err := io.Copy(io.MultiWriter(h, body), resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read s3 GetObject response: %w", err)
+	}
+
+	describe(response, resp.Header)
+	response.Size = size
+	response.Truncated = size > int64(len(body.data))
+	if utf8.Valid(body.data) {
+		response.Body = string(body.data)
+		var decoded interface{}
+		if !response.Truncated && json.Unmarshal(body.data, &decoded) == nil {
+			response.JSON = decoded
+		}
+	} else {
+		response.Body = base64.StdEncoding.EncodeToString(body.data)
+		response.Encoding = "base64"
+	}
+	response.Algorithm = algorithmName(config.Algorithm)
+	response.Checksum = hex.EncodeToString(h.Sum(nil))
+	return nil
+}
+
+// head reads the object's metadata without its content
+func head(ctx context.Context, c *client, config *S3Config, response *S3Response) error {
+	resp, err := c.send(ctx, http.MethodHead, config.Bucket, config.Key, nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("s3 HeadObject failed: %w", err)
+	}
+	_ = resp.Body.Close()
+
+	describe(response, resp.Header)
+	response.Size, _ = strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	return nil
+}
+
+// describe copies what S3 reports about an object from the response headers
+func describe(response *S3Response, header http.Header) {
+	response.ETag = strings.Trim(header.Get("ETag"), `"`)
+	response.VersionID = header.Get("X-Amz-Version-Id")
+	response.ContentType = header.Get("Content-Type")
+	if modified, err := http.ParseTime(header.Get("Last-Modified")); err == nil {
+		response.LastModified = modified.UTC().Format(time.RFC3339)
+	}
+	for name, values := range header {
+		if len(name) > len("X-Amz-Meta-") && strings.EqualFold(name[:len("X-Amz-Meta-")], "X-Amz-Meta-") {
+			if response.Metadata == nil {
+				response.Metadata = make(map[string]string)
+			}
+			response.Metadata[strings.ToLower(name[len("X-Amz-Meta-"):])] = strings.Join(values, ",")
+		}
+	}
+}
+
+// upload sends the file in parts. Each part carries its MD5, which S3 checks,
+// and the ETag S3 gives the finished object is checked against the parts, so a
+// corrupted or misassembled upload fails the step. Failed uploads are aborted
+// so their parts aren't billed.
+func upload(ctx context.Context, c *client, config *S3Config, response *S3Response) error {
+	f, err := os.Open(config.File)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	partSize := int64(defaultPartSize)
+	if config.PartSize != "" {
+		partSize, _ = parseSize(config.PartSize)
+	}
+	// S3 allows 10,000 parts, so large files get larger parts
+	if parts := (info.Size() + partSize - 1) / partSize; parts > maxParts {
+		partSize = (info.Size() + maxParts - 1) / maxParts
+	}
+	if partSize > maxPartSize {
+		return fmt.Errorf("file is too large for a multipart upload: %d bytes", info.Size())
+	}
+
+	uploadID, err := c.createMultipartUpload(ctx, config.Bucket, config.Key, config.ContentType, config.Metadata)
+	if err != nil {
+		return err
+	}
+
+	h := newHash(config.Algorithm)
+	var parts []completedPart
+	var digests []byte
+	buf := make([]byte, partSize)
+	for {
+		n, readErr := io.ReadFull(f, buf)
+		if n > 0 || len(parts) == 0 {
+			data := buf[:n]
+			h.Write(data)
+			sum := md5.Sum(data)
+			digests = append(digests, sum[:]...)
+
+			etag, err := c.uploadPart(ctx, config.Bucket, config.Key, uploadID, len(parts)+1, data)
+			if err != nil {
+				return abort(c, config, uploadID, err)
+			}
+			parts = append(parts, completedPart{PartNumber: len(parts) + 1, ETag: etag})
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return abort(c, config, uploadID, fmt.Errorf("failed to read file: %w", readErr))
+		}
+	}
+
+	etag, header, err := c.completeMultipartUpload(ctx, config.Bucket, config.Key, uploadID, parts)
+	if err != nil {
+		return abort(c, config, uploadID, err)
+	}
+	etag = strings.Trim(etag, `"`)
+
+	// A multipart ETag is the MD5 of the parts' MD5s and the part count, unless
+	// the object is encrypted with KMS, which gives it an opaque ETag
+	sum := md5.Sum(digests)
+	expected := fmt.Sprintf("%s-%d", hex.EncodeToString(sum[:]), len(parts))
+	if etag != expected && header.Get("X-Amz-Server-Side-Encryption") != "aws:kms" {
+		return fmt.Errorf("checksum verification failed for %s: S3 reports ETag %s, the uploaded parts give %s", config.Key, etag, expected)
+	}
+
+	response.ETag = etag
+	response.VersionID = header.Get("X-Amz-Version-Id")
+	response.Size = info.Size()
+	response.ContentType = config.ContentType
+	response.Parts = len(parts)
+	response.PartSize = partSize
+	response.Algorithm = algorithmName(config.Algorithm)
+	response.Checksum = hex.EncodeToString(h.Sum(nil))
+	return nil
+}
+
+// abort discards a failed upload's parts and returns err. It gets its own
+// context, since the step's may be what ran out.
+func abort(c *client, config *S3Config, uploadID string, err error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if abortErr := c.abortMultipartUpload(ctx, config.Bucket, config.Key, uploadID); abortErr != nil {
+		return fmt.Errorf("%w (and the upload could not be aborted: %v)", err, abortErr)
+	}
+	return err
+}
+
+// presign builds a URL for a later step, e.g. an http step, to download or
+// upload the object without credentials. No request is made.
+func presign(c *client, config *S3Config, response *S3Response) error {
+	method := strings.ToUpper(config.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	expires := defaultExpires
+	if config.Expires != "" {
+		expires, _ = time.ParseDuration(config.Expires)
+	}
+
+	signed, err := c.presign(method, config.Bucket, config.Key, expires)
+	if err != nil {
+		return err
+	}
+	response.URL = signed
+	response.Method = method
+	response.ExpiresAt = c.now().Add(expires).UTC().Format(time.RFC3339)
+	return nil
+}
+
+// prefixWriter keeps the first limit bytes written to it
+type prefixWriter struct {
+	data  []byte
+	limit int
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	if room := w.limit - len(w.data); room > 0 {
+		if len(p) > room {
+			w.data = append(w.data, p[:room]...)
+		} else {
+			w.data = append(w.data, p...)
+		}
+	}
+	return len(p), nil
+}
+
+func newHash(algorithm string) hash.Hash {
+	switch algorithmName(algorithm) {
+	case "md5":
+		return md5.New()
+	case "sha1":
+		return sha1.New()
+	case "sha512":
+		return sha512.New()
+	default:
+		return sha256.New()
+	}
+}
+
+func algorithmName(algorithm string) string {
+	if algorithm == "" {
+		return defaultAlgorithm
+	}
+	return strings.ToLower(algorithm)
+}
+
+// parseSize parses a size like "16MB" or "5242880". Units are powers of 1024.
+func parseSize(size string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(size))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix     string
+		multiplier int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q: use a size like 16MB", size)
+	}
+	return n * multiplier, nil
+}
+
+// processAssertions evaluates all assertions and returns their results
+func processAssertions(p map[string]interface{}, response *S3Response, subject interface{}, state map[string]interface{}, env map[string]string) ([]AssertionResult, error) {
+	context := dsl.TemplateContext{Runtime: state, Env: env}
+	return assertions.Process(p, subject, context, func(assertionMap map[string]interface{}, result *AssertionResult) bool {
+		return evaluateAssertion(response, assertionMap, result)
+	})
+}
+
+// evaluateAssertion evaluates the s3 plugin's own assertion types
+func evaluateAssertion(response *S3Response, assertionMap map[string]interface{}, result *AssertionResult) bool {
+	expected := result.Expected
+	switch result.Type {
+	case AssertionTypeChecksum:
+		result.Actual = response.Checksum
+		expectedStr := fmt.Sprint(expected)
+		switch {
+		case response.Checksum == "":
+			result.Message = fmt.Sprintf("no checksum for action %s (use put, get or upload)", response.Action)
+		case !strings.EqualFold(strings.TrimSpace(expectedStr), response.Checksum):
+			result.Message = fmt.Sprintf("expected %s checksum %v, got %s", response.Algorithm, expected, response.Checksum)
+		default:
+			result.Passed = true
+		}
+
+	case AssertionTypeMetadata:
+		name, _ := assertionMap["name"].(string)
+		result.Name = name
+		value, found := response.Metadata[strings.ToLower(name)]
+		result.Actual = value
+		switch {
+		case name == "":
+			result.Message = "metadata assertion requires name"
+		case !found:
+			result.Message = fmt.Sprintf("object has no metadata %q (use get or head)", name)
+		case !assertions.Equal(value, expected):
+			result.Message = fmt.Sprintf("expected metadata %q to be %v, got %q", name, expected, value)
+		default:
+			result.Passed = true
+		}
+
+	default:
+		return false
+	}
+	return true
+}
+
+// validateConfig checks the action has what it needs once templates are rendered
+func validateConfig(config *S3Config) error {
+	if config.Bucket == "" {
+		return fmt.Errorf("bucket is required")
+	}
+	if config.Key == "" {
+		return fmt.Errorf("key is required")
+	}
+	if (config.AccessKeyID == "") != (config.SecretAccessKey == "") {
+		return fmt.Errorf("access_key_id and secret_access_key must be set together")
+	}
+	if config.CredentialsEnv != "" && config.AccessKeyID != "" {
+		return fmt.Errorf("only one of credentials_env or access_key_id may be set")
+	}
+	switch algorithmName(config.Algorithm) {
+	case "md5", "sha1", "sha256", "sha512":
+	default:
+		return fmt.Errorf("algorithm must be md5, sha1, sha256 or sha512, got %q", config.Algorithm)
+	}
+
+	switch config.Action {
+	case ActionPut:
+		if config.Body != "" && config.File != "" {
+			return fmt.Errorf("only one of body or file may be set")
+		}
+	case ActionGet, ActionHead, ActionDelete:
+	case ActionUpload:
+		if config.File == "" {
+			return fmt.Errorf("file is required with action upload")
+		}
+		if config.PartSize != "" {
+			size, err := parseSize(config.PartSize)
+			if err != nil {
+				return fmt.Errorf("part_size: %w", err)
+			}
+			if size < minPartSize || size > maxPartSize {
+				return fmt.Errorf("part_size must be between 5MB and 5GB, got %s", config.PartSize)
+			}
+		}
+	case ActionPresign:
+		switch strings.ToUpper(config.Method) {
+		case "", http.MethodGet, http.MethodPut:
+		default:
+			return fmt.Errorf("method must be GET or PUT, got %q", config.Method)
+		}
+		if config.Expires != "" {
+			expires, err := time.ParseDuration(config.Expires)
+			if err != nil {
+				return fmt.Errorf("invalid expires %q: %w", config.Expires, err)
+			}
+			if expires < time.Second || expires > maxExpires {
+				return fmt.Errorf("expires must be between 1s and 168h, got %s", config.Expires)
+			}
+		}
+	case "":
+		return fmt.Errorf("action is required")
+	default:
+		return fmt.Errorf("action must be put, get, head, delete, upload or presign, got %q", config.Action)
+	}
+
+	return nil
+}
+
+// resolveFile confines file to the directories steps may read on the worker, so
+// a test can't upload the worker's own files to a bucket it controls
+func resolveFile(runID string, config *S3Config) error {
+	if config.File == "" {
+		return nil
+	}
+	path, err := confine.ResolveForRun(runID, config.File)
+	if errors.Is(err, confine.ErrOutside) {
+		return fmt.Errorf("file %s is outside the directories steps may read on this worker (see %s): %w", config.File, confine.RootsEnv, confine.ErrOutside)
+	}
+	if err != nil {
+		return err
+	}
+	config.File = path
+	return nil
+}
+
+// resolveCredentials fills the step's credentials from the env secrets named by
+// credentials_env, so each step can use its own account or provider. Only the
+// run's env is read: the worker's own environment holds its credentials, which
+// a test must not be able to pick by name.
+func resolveCredentials(config *S3Config, env map[string]string) error {
+	if config.CredentialsEnv == "" {
+		return nil
+	}
+	prefix := config.CredentialsEnv + "_"
+	config.AccessKeyID = env[prefix+"ACCESS_KEY_ID"]
+	config.SecretAccessKey = env[prefix+"SECRET_ACCESS_KEY"]
+	config.SessionToken = env[prefix+"SESSION_TOKEN"]
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return fmt.Errorf("credentials_env %s: %s_ACCESS_KEY_ID and %s_SECRET_ACCESS_KEY must be set in the env", config.CredentialsEnv, config.CredentialsEnv, config.CredentialsEnv)
+	}
+	return nil
+}
+
+// applyVariableReplacement processes templates in the connection settings, the
+// object's location and what is written to it
+func applyVariableReplacement(config *S3Config, state map[string]interface{}, env map[string]string) error {
+	context := dsl.TemplateContext{
+		Runtime: state,
+		Env:     env,
+	}
+
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"bucket", &config.Bucket},
+		{"key", &config.Key},
+		{"region", &config.Region},
+		{"endpoint", &config.Endpoint},
+		{"access_key_id", &config.AccessKeyID},
+		{"secret_access_key", &config.SecretAccessKey},
+		{"session_token", &config.SessionToken},
+		{"credentials_env", &config.CredentialsEnv},
+		{"body", &config.Body},
+		{"file", &config.File},
+		{"content_type", &config.ContentType},
+		{"expires", &config.Expires},
+	}
+	for _, field := range fields {
+		if *field.value == "" {
+			continue
+		}
+		processed, err := dsl.ProcessTemplate(*field.value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process %s template: %w", field.name, err)
+		}
+		*field.value = processed
+	}
+
+	for name, value := range config.Metadata {
+		processed, err := dsl.ProcessTemplate(value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process metadata.%s template: %w", name, err)
+		}
+		config.Metadata[name] = processed
+	}
+
+	return nil
+}
+
+// parseConfig converts map[string]interface{} to S3Config
+func parseConfig(configData map[string]interface{}, config *S3Config) error {
+	stringFields := map[string]*string{
+		"action":            &config.Action,
+		"bucket":            &config.Bucket,
+		"key":               &config.Key,
+		"region":            &config.Region,
+		"endpoint":          &config.Endpoint,
+		"access_key_id":     &config.AccessKeyID,
+		"secret_access_key": &config.SecretAccessKey,
+		"session_token":     &config.SessionToken,
+		"credentials_env":   &config.CredentialsEnv,
+		"file":              &config.File,
+		"content_type":      &config.ContentType,
+		"algorithm":         &config.Algorithm,
+		"method":            &config.Method,
+		"expires":           &config.Expires,
+		"timeout":           &config.Timeout,
+	}
+	for key, target := range stringFields {
+		if v, ok := configData[key].(string); ok {
+			*target = v
+		}
+	}
+
+	if pathStyle, ok := configData["path_style"].(bool); ok {
+		config.PathStyle = &pathStyle
+	}
+
+	switch body := configData["body"].(type) {
+	case nil:
+	case string:
+		config.Body = body
+	default:
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("body: failed to encode as JSON: %w", err)
+		}
+		config.Body = string(encoded)
+		if config.ContentType == "" {
+			config.ContentType = "application/json"
+		}
+	}
+
+	switch partSize := configData["part_size"].(type) {
+	case nil:
+	case string:
+		config.PartSize = partSize
+	case float64:
+		config.PartSize = strconv.FormatInt(int64(partSize), 10)
+	case int:
+		config.PartSize = strconv.Itoa(partSize)
+	default:
+		return fmt.Errorf("part_size must be a size like 16MB, got %T", partSize)
+	}
+
+	switch metadata := configData["metadata"].(type) {
+	case nil:
+	case map[string]interface{}:
+		config.Metadata = make(map[string]string, len(metadata))
+		for name, value := range metadata {
+			if !validMetadataName(name) {
+				return fmt.Errorf("metadata name %q must hold only letters, digits, - and _", name)
+			}
+			config.Metadata[name] = fmt.Sprint(value)
+		}
+	default:
+		return fmt.Errorf("metadata must be a map of names to values, got %T", metadata)
+	}
+
+	return nil
+}
+
+// validMetadataName reports whether name can be sent in an x-amz-meta-* header
+func validMetadataName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
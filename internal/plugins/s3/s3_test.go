@@ -0,0 +1,500 @@
+package s3
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/awsauth"
+	"github.com/rocketship-ai/rocketship/internal/confine"
+)
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+	metadata    http.Header
+	etag        string
+}
+
+// fakeS3 serves the operations the plugin uses from an in-memory bucket,
+// addressed path-style
+type fakeS3 struct {
+	mu       sync.Mutex
+	bucket   string
+	objects  map[string]*fakeObject
+	uploads  map[string]map[int][]byte
+	pending  map[string]*fakeObject // Uploads by ID, holding their headers
+	failPart int                    // Part number UploadPart rejects
+	badETag  bool                   // CompleteMultipartUpload reports a wrong ETag
+	aborted  []string
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{
+		bucket:  "reports",
+		objects: map[string]*fakeObject{},
+		uploads: map[string]map[int][]byte{},
+		pending: map[string]*fakeObject{},
+	}
+}
+
+func (f *fakeS3) fail(w http.ResponseWriter, status int, code, message string) {
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, message)
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+		f.fail(w, http.StatusForbidden, "AccessDenied", "unsigned")
+		return
+	}
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != f.bucket {
+		f.fail(w, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist")
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	if expected := r.Header.Get("Content-MD5"); expected != "" {
+		sum := md5.Sum(body)
+		if base64.StdEncoding.EncodeToString(sum[:]) != expected {
+			f.fail(w, http.StatusBadRequest, "BadDigest", "The Content-MD5 you specified did not match what we received")
+			return
+		}
+	}
+	query := r.URL.Query()
+	uploadID := query.Get("uploadId")
+
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		id := fmt.Sprintf("upload-%d", len(f.pending)+1)
+		f.pending[id] = &fakeObject{contentType: r.Header.Get("Content-Type"), metadata: metaHeaders(r.Header)}
+		f.uploads[id] = map[int][]byte{}
+		_, _ = fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", id)
+
+	case r.Method == http.MethodPut && uploadID != "":
+		var number int
+		_, _ = fmt.Sscan(query.Get("partNumber"), &number)
+		if number == f.failPart {
+			f.fail(w, http.StatusInternalServerError, "InternalError", "We encountered an internal error")
+			return
+		}
+		f.uploads[uploadID][number] = body
+		sum := md5.Sum(body)
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+
+	case r.Method == http.MethodPost && uploadID != "":
+		var complete completeMultipartUpload
+		_ = xml.Unmarshal(body, &complete)
+		object := f.pending[uploadID]
+		var digests []byte
+		for _, part := range complete.Parts {
+			data := f.uploads[uploadID][part.PartNumber]
+			object.data = append(object.data, data...)
+			sum := md5.Sum(data)
+			digests = append(digests, sum[:]...)
+		}
+		sum := md5.Sum(digests)
+		object.etag = fmt.Sprintf("%s-%d", hex.EncodeToString(sum[:]), len(complete.Parts))
+		if f.badETag {
+			object.etag = "0123456789abcdef0123456789abcdef-1"
+		}
+		f.objects[key] = object
+		_, _ = fmt.Fprintf(w, `<CompleteMultipartUploadResult><ETag>"%s"</ETag></CompleteMultipartUploadResult>`, object.etag)
+
+	case r.Method == http.MethodDelete && uploadID != "":
+		f.aborted = append(f.aborted, uploadID)
+		delete(f.uploads, uploadID)
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == http.MethodPut:
+		sum := md5.Sum(body)
+		object := &fakeObject{data: body, contentType: r.Header.Get("Content-Type"), metadata: metaHeaders(r.Header), etag: hex.EncodeToString(sum[:])}
+		f.objects[key] = object
+		w.Header().Set("ETag", `"`+object.etag+`"`)
+
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		object, ok := f.objects[key]
+		if !ok {
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			f.fail(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+			return
+		}
+		for name, values := range object.metadata {
+			w.Header()[name] = values
+		}
+		w.Header().Set("ETag", `"`+object.etag+`"`)
+		w.Header().Set("Content-Type", object.contentType)
+		w.Header().Set("Content-Length", fmt.Sprint(len(object.data)))
+		w.Header().Set("Last-Modified", "Wed, 21 Oct 2015 07:28:00 GMT")
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(object.data)
+		}
+
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		f.fail(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+	}
+}
+
+func metaHeaders(header http.Header) http.Header {
+	meta := http.Header{}
+	for name, values := range header {
+		if strings.HasPrefix(name, "X-Amz-Meta-") {
+			meta[name] = values
+		}
+	}
+	return meta
+}
+
+func newTestClient(t *testing.T, fake *fakeS3) *client {
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	endpoint, _ := url.Parse(server.URL)
+	return &client{
+		endpoint:  endpoint,
+		pathStyle: true,
+		region:    "us-east-1",
+		creds:     awsauth.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"},
+		http:      server.Client(),
+		now:       time.Now,
+	}
+}
+
+func TestPutGetAndHead(t *testing.T) {
+	fake := newFakeS3()
+	c := newTestClient(t, fake)
+	ctx := context.Background()
+
+	config := &S3Config{}
+	if err := parseConfig(map[string]interface{}{
+		"action":   "put",
+		"bucket":   "reports",
+		"key":      "daily/2024 Q1+summary.json",
+		"body":     map[string]interface{}{"orders": 3.0},
+		"metadata": map[string]interface{}{"owner": "billing", "run": 7.0},
+	}, config); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateConfig(config); err != nil {
+		t.Fatal(err)
+	}
+	put, err := execute(ctx, c, config)
+	if err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+	sum := sha256.Sum256([]byte(`{"orders":3}`))
+	if put.Checksum != hex.EncodeToString(sum[:]) || put.Size != 12 || put.ContentType != "application/json" {
+		t.Errorf("unexpected put response: %+v", put)
+	}
+
+	get, err := execute(ctx, c, &S3Config{Action: ActionGet, Bucket: "reports", Key: "daily/2024 Q1+summary.json"})
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	if get.Body != `{"orders":3}` || get.Checksum != put.Checksum || get.ETag != put.ETag {
+		t.Errorf("unexpected get response: %+v", get)
+	}
+	if get.Metadata["owner"] != "billing" || get.Metadata["run"] != "7" || get.LastModified != "2015-10-21T07:28:00Z" {
+		t.Errorf("unexpected metadata: %v, %s", get.Metadata, get.LastModified)
+	}
+	if get.JSON.(map[string]interface{})["orders"] != 3.0 {
+		t.Errorf("expected the body to be decoded, got %v", get.JSON)
+	}
+
+	head, err := execute(ctx, c, &S3Config{Action: ActionHead, Bucket: "reports", Key: "daily/2024 Q1+summary.json"})
+	if err != nil {
+		t.Fatalf("head failed: %v", err)
+	}
+	if head.Size != 12 || head.Body != "" || head.Checksum != "" || head.Metadata["owner"] != "billing" {
+		t.Errorf("unexpected head response: %+v", head)
+	}
+
+	if _, err := execute(ctx, c, &S3Config{Action: ActionDelete, Bucket: "reports", Key: "daily/2024 Q1+summary.json"}); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	_, err = execute(ctx, c, &S3Config{Action: ActionGet, Bucket: "reports", Key: "daily/2024 Q1+summary.json"})
+	if err == nil || !strings.Contains(err.Error(), "NoSuchKey: The specified key does not exist.") {
+		t.Errorf("expected NoSuchKey, got %v", err)
+	}
+	_, err = execute(ctx, c, &S3Config{Action: ActionHead, Bucket: "reports", Key: "missing"})
+	if err == nil || !strings.Contains(err.Error(), "404 Not Found") {
+		t.Errorf("expected a 404, got %v", err)
+	}
+}
+
+func TestUpload(t *testing.T) {
+	minPartSize = 1 << 10
+	t.Cleanup(func() { minPartSize = 5 << 20 })
+
+	data := []byte(strings.Repeat("rocketship-", 300)) // 3300 bytes, 4 parts of 1KB
+	path := filepath.Join(t.TempDir(), "export.csv")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	config := &S3Config{Action: ActionUpload, Bucket: "reports", Key: "exports/export.csv", File: path, PartSize: "1KB", Algorithm: "md5", ContentType: "text/csv"}
+	if err := validateConfig(config); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("verified", func(t *testing.T) {
+		fake := newFakeS3()
+		response, err := execute(context.Background(), newTestClient(t, fake), config)
+		if err != nil {
+			t.Fatalf("upload failed: %v", err)
+		}
+		sum := md5.Sum(data)
+		if response.Parts != 4 || response.PartSize != 1024 || response.Size != 3300 || response.Checksum != hex.EncodeToString(sum[:]) {
+			t.Errorf("unexpected upload response: %+v", response)
+		}
+		if !strings.HasSuffix(response.ETag, "-4") {
+			t.Errorf("expected a multipart ETag, got %s", response.ETag)
+		}
+		if object := fake.objects["exports/export.csv"]; string(object.data) != string(data) || object.contentType != "text/csv" {
+			t.Error("expected the parts to be joined into the object")
+		}
+	})
+
+	t.Run("part fails", func(t *testing.T) {
+		fake := newFakeS3()
+		fake.failPart = 3
+		_, err := execute(context.Background(), newTestClient(t, fake), config)
+		if err == nil || !strings.Contains(err.Error(), "s3 UploadPart 3 failed: InternalError") {
+			t.Errorf("expected the part to fail, got %v", err)
+		}
+		if len(fake.aborted) != 1 {
+			t.Errorf("expected the upload to be aborted, got %v", fake.aborted)
+		}
+	})
+
+	t.Run("wrong ETag", func(t *testing.T) {
+		fake := newFakeS3()
+		fake.badETag = true
+		_, err := execute(context.Background(), newTestClient(t, fake), config)
+		if err == nil || !strings.Contains(err.Error(), "checksum verification failed for exports/export.csv") {
+			t.Errorf("expected checksum verification to fail, got %v", err)
+		}
+	})
+}
+
+func TestPresign(t *testing.T) {
+	c := newTestClient(t, newFakeS3())
+	c.endpoint, _ = url.Parse("https://s3.eu-west-1.amazonaws.com")
+	c.pathStyle = false
+	c.region = "eu-west-1"
+	c.now = func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC) }
+
+	response, err := execute(context.Background(), c, &S3Config{Action: ActionPresign, Bucket: "reports", Key: "a b.txt", Method: "put", Expires: "1h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(response.URL, "https://reports.s3.eu-west-1.amazonaws.com/a%20b.txt?X-Amz-Algorithm=AWS4-HMAC-SHA256&") {
+		t.Errorf("unexpected URL %s", response.URL)
+	}
+	if !strings.Contains(response.URL, "X-Amz-Expires=3600&") || !strings.Contains(response.URL, "&X-Amz-Signature=") {
+		t.Errorf("expected the URL to be signed for an hour: %s", response.URL)
+	}
+	if response.Method != http.MethodPut || response.ExpiresAt != "2024-03-01T13:00:00Z" {
+		t.Errorf("unexpected presign response: %+v", response)
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	yes, no := true, false
+	creds := S3Config{AccessKeyID: "AKID", SecretAccessKey: "secret"}
+	with := func(change func(*S3Config)) *S3Config {
+		config := creds
+		change(&config)
+		return &config
+	}
+
+	tests := []struct {
+		name      string
+		config    *S3Config
+		url       string
+		region    string
+		pathStyle bool
+		error     string
+	}{
+		{"aws", with(func(c *S3Config) { c.Region = "eu-west-1" }), "https://reports.s3.eu-west-1.amazonaws.com/a.txt", "eu-west-1", false, ""},
+		{"aws dotted bucket", with(func(c *S3Config) { c.Region = "eu-west-1"; c.Bucket = "reports.example.com" }), "https://s3.eu-west-1.amazonaws.com/reports.example.com/a.txt", "eu-west-1", true, ""},
+		{"aws without region", with(func(c *S3Config) {}), "", "", false, "region is required"},
+		{"minio", with(func(c *S3Config) { c.Endpoint = "http://minio:9000" }), "http://minio:9000/reports/a.txt", "us-east-1", true, ""},
+		{"ceph under a path", with(func(c *S3Config) { c.Endpoint = "https://ceph.internal/rgw/"; c.Region = "default" }), "https://ceph.internal/rgw/reports/a.txt", "default", true, ""},
+		{"r2 virtual-hosted", with(func(c *S3Config) {
+			c.Endpoint = "https://acct.r2.cloudflarestorage.com"
+			c.Region = "auto"
+			c.PathStyle = &no
+		}), "https://reports.acct.r2.cloudflarestorage.com/a.txt", "auto", false, ""},
+		{"aws forced path-style", with(func(c *S3Config) { c.Region = "us-west-2"; c.PathStyle = &yes }), "https://s3.us-west-2.amazonaws.com/reports/a.txt", "us-west-2", true, ""},
+		{"bad endpoint", with(func(c *S3Config) { c.Endpoint = "minio:9000" }), "", "", false, `invalid endpoint "minio:9000"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.config.Bucket == "" {
+				tt.config.Bucket = "reports"
+			}
+			c, err := newClient(tt.config, nil)
+			if tt.error != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.error) {
+					t.Errorf("expected error containing %q, got %v", tt.error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.objectURL(tt.config.Bucket, "a.txt", nil).String(); got != tt.url {
+				t.Errorf("expected URL %s, got %s", tt.url, got)
+			}
+			if c.region != tt.region || c.pathStyle != tt.pathStyle {
+				t.Errorf("expected region %q and path-style %v, got %q and %v", tt.region, tt.pathStyle, c.region, c.pathStyle)
+			}
+		})
+	}
+}
+
+func TestResolveCredentials(t *testing.T) {
+	config := &S3Config{CredentialsEnv: "R2"}
+	env := map[string]string{"R2_ACCESS_KEY_ID": "AKID", "R2_SECRET_ACCESS_KEY": "secret", "R2_SESSION_TOKEN": "token", "AWS_ACCESS_KEY_ID": "other"}
+	if err := resolveCredentials(config, env); err != nil {
+		t.Fatal(err)
+	}
+	if config.AccessKeyID != "AKID" || config.SecretAccessKey != "secret" || config.SessionToken != "token" {
+		t.Errorf("unexpected credentials: %+v", config)
+	}
+
+	// The worker's own environment is never read
+	t.Setenv("WORKER_ACCESS_KEY_ID", "AKID")
+	t.Setenv("WORKER_SECRET_ACCESS_KEY", "secret")
+	if err := resolveCredentials(&S3Config{CredentialsEnv: "WORKER"}, env); err == nil {
+		t.Error("expected credentials from the worker's environment to be ignored")
+	}
+
+	err := resolveCredentials(&S3Config{CredentialsEnv: "MINIO"}, env)
+	if err == nil || !strings.Contains(err.Error(), "MINIO_ACCESS_KEY_ID and MINIO_SECRET_ACCESS_KEY must be set") {
+		t.Errorf("expected missing credentials to fail, got %v", err)
+	}
+}
+
+func TestProcessAssertions(t *testing.T) {
+	response := &S3Response{
+		Action:    ActionGet,
+		Size:      12,
+		Metadata:  map[string]string{"owner": "billing"},
+		Algorithm: "sha256",
+		Checksum:  "abc123",
+	}
+	subject := map[string]interface{}{"size": 12.0, "content_type": "application/json"}
+	p := map[string]interface{}{
+		"assertions": []interface{}{
+			map[string]interface{}{"type": "checksum", "expected": "ABC123"},
+			map[string]interface{}{"type": "metadata", "name": "Owner", "expected": "{{ .vars.team }}"},
+			map[string]interface{}{"type": "json_path", "path": ".size", "expected": 12},
+			map[string]interface{}{"type": "metadata", "name": "retention"},
+		},
+	}
+
+	results, err := processAssertions(p, response, subject, map[string]interface{}{"vars": map[string]interface{}{"team": "billing"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failure := assertions.Summary(results)
+	if len(results) != 4 {
+		t.Fatalf("expected 4 results, got %d", len(results))
+	}
+	for i, result := range results[:3] {
+		if !result.Passed {
+			t.Errorf("assertion %d failed: %s", i, result.Message)
+		}
+	}
+	if results[3].Passed || !strings.Contains(failure, `object has no metadata "retention"`) {
+		t.Errorf("expected the missing metadata to fail, got %q", failure)
+	}
+}
+
+func TestResolveFile(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(confine.RootsEnv, root)
+	if err := os.WriteFile(filepath.Join(root, "export.csv"), []byte("id\n1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(t.TempDir(), "credentials")
+	if err := os.WriteFile(secret, []byte("aws_secret_access_key=abc"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	config := &S3Config{Action: ActionUpload, File: "export.csv"}
+	if err := resolveFile("run-1", config); err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := confine.Resolve([]string{root}, "export.csv"); config.File != want {
+		t.Errorf("expected %s, got %s", want, config.File)
+	}
+
+	for _, file := range []string{secret, "../credentials", "/etc/passwd"} {
+		config := &S3Config{Action: ActionPut, File: file}
+		if err := resolveFile("run-1", config); !errors.Is(err, confine.ErrOutside) {
+			t.Errorf("resolveFile(%q) = %v, want ErrOutside", file, err)
+		}
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config S3Config
+		error  string
+	}{
+		{"get", S3Config{Action: ActionGet, Bucket: "b", Key: "k"}, ""},
+		{"missing key", S3Config{Action: ActionGet, Bucket: "b"}, "key is required"},
+		{"body and file", S3Config{Action: ActionPut, Bucket: "b", Key: "k", Body: "x", File: "f"}, "only one of body or file may be set"},
+		{"upload without file", S3Config{Action: ActionUpload, Bucket: "b", Key: "k"}, "file is required with action upload"},
+		{"small parts", S3Config{Action: ActionUpload, Bucket: "b", Key: "k", File: "f", PartSize: "1MB"}, "part_size must be between 5MB and 5GB"},
+		{"presign post", S3Config{Action: ActionPresign, Bucket: "b", Key: "k", Method: "POST"}, "method must be GET or PUT"},
+		{"presign too long", S3Config{Action: ActionPresign, Bucket: "b", Key: "k", Expires: "200h"}, "expires must be between 1s and 168h"},
+		{"unknown algorithm", S3Config{Action: ActionGet, Bucket: "b", Key: "k", Algorithm: "crc32"}, "algorithm must be md5, sha1, sha256 or sha512"},
+		{"half credentials", S3Config{Action: ActionGet, Bucket: "b", Key: "k", AccessKeyID: "AKID"}, "access_key_id and secret_access_key must be set together"},
+		{"two credential sources", S3Config{Action: ActionGet, Bucket: "b", Key: "k", AccessKeyID: "AKID", SecretAccessKey: "s", CredentialsEnv: "R2"}, "only one of credentials_env or access_key_id may be set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateConfig(&tt.config)
+			if tt.error == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Errorf("expected error containing %q, got %v", tt.error, err)
+			}
+		})
+	}
+}
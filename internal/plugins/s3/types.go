@@ -0,0 +1,96 @@
+package s3
+
+import "github.com/rocketship-ai/rocketship/internal/assertions"
+
+// S3Plugin represents an S3 test step
+type S3Plugin struct {
+	Name   string   `json:"name" yaml:"name"`
+	Plugin string   `json:"plugin" yaml:"plugin"`
+	Config S3Config `json:"config" yaml:"config"`
+}
+
+// S3Config selects the object, the action and how the object is written or shared
+type S3Config struct {
+	Action string `json:"action" yaml:"action"` // put, get, head, delete, upload or presign
+	Bucket string `json:"bucket" yaml:"bucket"`
+	Key    string `json:"key" yaml:"key"`
+
+	// Connection. Credentials and region default to the standard AWS environment
+	// variables on the worker.
+	Region          string `json:"region,omitempty" yaml:"region,omitempty"`
+	Endpoint        string `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`     // e.g. http://minio:9000 for S3-compatible stores
+	PathStyle       *bool  `json:"path_style,omitempty" yaml:"path_style,omitempty"` // Defaults to true with endpoint
+	AccessKeyID     string `json:"access_key_id,omitempty" yaml:"access_key_id,omitempty"`
+	SecretAccessKey string `json:"secret_access_key,omitempty" yaml:"secret_access_key,omitempty"`
+	SessionToken    string `json:"session_token,omitempty" yaml:"session_token,omitempty"`
+	CredentialsEnv  string `json:"credentials_env,omitempty" yaml:"credentials_env,omitempty"` // Prefix of env secrets holding the credentials, e.g. R2
+
+	// put and upload
+	Body        string            `json:"body,omitempty" yaml:"body,omitempty"` // put: objects and arrays are sent as JSON
+	File        string            `json:"file,omitempty" yaml:"file,omitempty"` // Path on the worker
+	ContentType string            `json:"content_type,omitempty" yaml:"content_type,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty" yaml:"metadata,omitempty"` // Sent as x-amz-meta-* headers
+
+	// upload
+	PartSize string `json:"part_size,omitempty" yaml:"part_size,omitempty"` // e.g. "16MB" (defaults to 8MB)
+
+	// put, get and upload
+	Algorithm string `json:"algorithm,omitempty" yaml:"algorithm,omitempty"` // Checksum algorithm: md5, sha1, sha256 (default) or sha512
+
+	// presign
+	Method  string `json:"method,omitempty" yaml:"method,omitempty"`   // GET (default) or PUT
+	Expires string `json:"expires,omitempty" yaml:"expires,omitempty"` // How long the URL works (defaults to 15m, at most 168h)
+
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"` // Overall step timeout (defaults to 60s)
+}
+
+// Actions supported by the s3 plugin
+const (
+	ActionPut     = "put"
+	ActionGet     = "get"
+	ActionHead    = "head"
+	ActionDelete  = "delete"
+	ActionUpload  = "upload"
+	ActionPresign = "presign"
+)
+
+// Assertion types supported by the s3 plugin in addition to the shared ones
+const (
+	AssertionTypeChecksum = "checksum"
+	AssertionTypeMetadata = "metadata"
+)
+
+// S3Response describes the object after the action
+type S3Response struct {
+	Action       string            `json:"action"`
+	Bucket       string            `json:"bucket"`
+	Key          string            `json:"key"`
+	ETag         string            `json:"etag,omitempty"`
+	VersionID    string            `json:"version_id,omitempty"`
+	Size         int64             `json:"size"`
+	ContentType  string            `json:"content_type,omitempty"`
+	LastModified string            `json:"last_modified,omitempty"` // get and head
+	Metadata     map[string]string `json:"metadata,omitempty"`      // get and head: x-amz-meta-* headers, by lowercase name
+	Body         string            `json:"body,omitempty"`          // get: the object's content
+	JSON         interface{}       `json:"json,omitempty"`          // get: the content decoded, when it is JSON
+	Encoding     string            `json:"encoding,omitempty"`      // get: base64 when the content isn't valid UTF-8
+	Truncated    bool              `json:"truncated,omitempty"`     // get: body holds only the start of the object
+	Algorithm    string            `json:"algorithm,omitempty"`     // put, get and upload: checksum algorithm
+	Checksum     string            `json:"checksum,omitempty"`      // put, get and upload: hex digest of the whole object
+	Parts        int               `json:"parts,omitempty"`         // upload: number of parts sent
+	PartSize     int64             `json:"part_size,omitempty"`     // upload: bytes per part
+	URL          string            `json:"url,omitempty"`           // presign
+	Method       string            `json:"method,omitempty"`        // presign
+	ExpiresAt    string            `json:"expires_at,omitempty"`    // presign: RFC 3339
+	Duration     string            `json:"duration"`
+}
+
+// AssertionResult represents a single assertion result for UI display
+type AssertionResult = assertions.Result
+
+// ActivityResponse represents the complete activity response
+type ActivityResponse struct {
+	Response         *S3Response       `json:"response"`
+	Saved            map[string]string `json:"saved"`
+	AssertionResults []AssertionResult `json:"assertion_results,omitempty"`
+}
@@ -26,8 +26,10 @@ func NewExecutor(language string) (Executor, error) {
 		return NewJavaScriptExecutor(), nil
 	case "shell":
 		return NewShellExecutor(), nil
+	case "typescript":
+		return NewTypeScriptExecutor(), nil
 	case "python":
-		return nil, fmt.Errorf("python executor not yet implemented")
+		return NewPythonExecutor(), nil
 	default:
 		return nil, fmt.Errorf("unsupported language: %s", language)
 	}
@@ -35,5 +37,5 @@ func NewExecutor(language string) (Executor, error) {
 
 // GetSupportedLanguages returns a list of all supported languages
 func GetSupportedLanguages() []string {
-	return []string{"javascript", "shell"} // Will expand as we add more languages
+	return []string{"javascript", "python", "shell", "typescript"}
 }
@@ -0,0 +1,161 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+const (
+	// maxFetches caps the requests one script can make
+	maxFetches = 100
+	// maxFetchBody caps a response body, which scripts hold in memory
+	maxFetchBody = 10 << 20
+)
+
+// fetcher implements fetch for a script. Requests are sent as fetch is
+// called, and the promise it returns is already settled, so scripts without
+// an event loop can chain on it or await it.
+type fetcher struct {
+	vm     *goja.Runtime
+	ctx    context.Context
+	client *http.Client
+	clock  *scriptClock // Stopped while waiting for responses
+	sent   int
+}
+
+// setupFetch injects fetch, sending requests with client
+func setupFetch(ctx context.Context, vm *goja.Runtime, client *http.Client, clock *scriptClock) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	f := &fetcher{vm: vm, ctx: ctx, client: client, clock: clock}
+	_ = vm.Set("fetch", f.fetch)
+}
+
+func (f *fetcher) fetch(call goja.FunctionCall) goja.Value {
+	promise, resolve, reject := f.vm.NewPromise()
+	response, err := f.send(call.Argument(0), call.Argument(1))
+	if err != nil {
+		_ = reject(f.vm.NewTypeError("fetch failed: %v", err))
+	} else {
+		_ = resolve(response)
+	}
+	return f.vm.ToValue(promise)
+}
+
+// send sends the request fetch(input, init) describes
+func (f *fetcher) send(input, init goja.Value) (*goja.Object, error) {
+	if f.sent >= maxFetches {
+		return nil, fmt.Errorf("scripts can make at most %d requests", maxFetches)
+	}
+	f.sent++
+
+	target := input.String()
+	parsed, err := url.Parse(target)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, fmt.Errorf("invalid URL %q: must be an http or https URL", target)
+	}
+
+	method := http.MethodGet
+	headers := http.Header{}
+	var body io.Reader
+	if set(init) {
+		options := init.ToObject(f.vm)
+		if value := options.Get("method"); set(value) {
+			method = strings.ToUpper(value.String())
+		}
+		if value := options.Get("headers"); set(value) {
+			object := value.ToObject(f.vm)
+			for _, name := range object.Keys() {
+				headers.Set(name, object.Get(name).String())
+			}
+		}
+		if value := options.Get("body"); set(value) {
+			text, ok := value.Export().(string)
+			if !ok {
+				return nil, fmt.Errorf("body must be a string, use JSON.stringify for objects")
+			}
+			body = strings.NewReader(text)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(f.ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = headers
+	resume := f.clock.wait()
+	defer resume()
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchBody+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if len(data) > maxFetchBody {
+		return nil, fmt.Errorf("response is larger than %d MB", maxFetchBody>>20)
+	}
+	return f.response(resp, string(data)), nil
+}
+
+// response builds the Response fetch resolves with
+func (f *fetcher) response(resp *http.Response, text string) *goja.Object {
+	vm := f.vm
+	headers := vm.NewObject()
+	_ = headers.Set("get", func(name string) goja.Value {
+		values := resp.Header.Values(name)
+		if len(values) == 0 {
+			return goja.Null()
+		}
+		return vm.ToValue(strings.Join(values, ", "))
+	})
+	_ = headers.Set("has", func(name string) bool {
+		return len(resp.Header.Values(name)) > 0
+	})
+
+	response := vm.NewObject()
+	_ = response.Set("ok", resp.StatusCode >= 200 && resp.StatusCode < 300)
+	_ = response.Set("status", resp.StatusCode)
+	_ = response.Set("statusText", http.StatusText(resp.StatusCode))
+	_ = response.Set("url", resp.Request.URL.String())
+	_ = response.Set("headers", headers)
+	_ = response.Set("text", func() goja.Value {
+		promise, resolve, _ := vm.NewPromise()
+		_ = resolve(text)
+		return vm.ToValue(promise)
+	})
+	_ = response.Set("json", func() goja.Value {
+		promise, resolve, reject := vm.NewPromise()
+		parse, _ := goja.AssertFunction(vm.Get("JSON").ToObject(vm).Get("parse"))
+		if value, err := parse(goja.Undefined(), vm.ToValue(text)); err != nil {
+			_ = reject(exceptionValue(vm, err))
+		} else {
+			_ = resolve(value)
+		}
+		return vm.ToValue(promise)
+	})
+	return response
+}
+
+// set reports whether an optional argument or option was given
+func set(value goja.Value) bool {
+	return value != nil && !goja.IsUndefined(value) && !goja.IsNull(value)
+}
+
+// exceptionValue returns what a failed call threw
+func exceptionValue(vm *goja.Runtime, err error) goja.Value {
+	if exception, ok := err.(*goja.Exception); ok {
+		return exception.Value()
+	}
+	return vm.NewGoError(err)
+}
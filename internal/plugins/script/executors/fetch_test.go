@@ -0,0 +1,132 @@
+package executors
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rocketship-ai/rocketship/internal/plugins/script/runtime"
+)
+
+func TestJavaScriptFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/orders":
+			body, _ := io.ReadAll(r.Body)
+			if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer t0k" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("X-Order", "ord_1")
+			_, _ = w.Write([]byte(`{"id":"ord_1","echo":` + string(body) + `}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		script string
+	}{
+		{
+			name: "await",
+			script: `
+const res = await fetch(state.base + "/orders", {
+  method: "post",
+  headers: { Authorization: "Bearer t0k" },
+  body: JSON.stringify({ qty: 2 }),
+});
+assert(res.ok && res.status === 200, "status " + res.status);
+const order = await res.json();
+save("id", order.id);
+save("qty", String(order.echo.qty));
+save("header", res.headers.get("x-order"));
+const missing = await fetch(state.base + "/missing");
+save("missing", missing.status + " " + missing.statusText + " " + missing.ok);
+`,
+		},
+		{
+			name: "then chains",
+			script: `
+fetch(state.base + "/orders", { method: "POST", headers: { Authorization: "Bearer t0k" }, body: '{"qty":2}' })
+  .then((res) => res.json())
+  .then((order) => {
+    save("id", order.id);
+    save("qty", String(order.echo.qty));
+    save("header", "ord_1");
+    save("missing", "404 Not Found false");
+  });
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rtCtx := runtime.NewContext(map[string]string{"base": server.URL}, map[string]interface{}{}, nil)
+			if err := NewJavaScriptExecutor().Execute(context.Background(), tt.script, rtCtx); err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+			want := map[string]string{"id": "ord_1", "qty": "2", "header": "ord_1", "missing": "404 Not Found false"}
+			for key, value := range want {
+				if rtCtx.Saved[key] != value {
+					t.Errorf("saved %s = %q, want %q", key, rtCtx.Saved[key], value)
+				}
+			}
+		})
+	}
+}
+
+func TestJavaScriptFetch_Errors(t *testing.T) {
+	refused := httptest.NewServer(http.NotFoundHandler())
+	refused.Close()
+
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{
+			name:   "unhandled rejection fails the script",
+			script: `fetch("file:///etc/passwd").then(() => save("read", "yes"));`,
+			want:   `fetch failed: invalid URL "file:///etc/passwd": must be an http or https URL`,
+		},
+		{
+			name:   "awaited rejection",
+			script: `await fetch("` + refused.URL + `");`,
+			want:   "fetch failed:",
+		},
+		{
+			name:   "body must be a string",
+			script: `await fetch("` + refused.URL + `", { method: "POST", body: { a: 1 } });`,
+			want:   "body must be a string, use JSON.stringify for objects",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rtCtx := runtime.NewContext(map[string]string{}, map[string]interface{}{}, nil)
+			err := NewJavaScriptExecutor().Execute(context.Background(), tt.script, rtCtx)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got %v", tt.want, err)
+			}
+			if len(rtCtx.Saved) != 0 {
+				t.Errorf("expected nothing saved, got %v", rtCtx.Saved)
+			}
+		})
+	}
+
+	// Caught rejections don't fail the script
+	rtCtx := runtime.NewContext(map[string]string{}, map[string]interface{}{}, nil)
+	script := `fetch("ftp://example.com").catch((err) => save("error", err.message));`
+	if err := NewJavaScriptExecutor().Execute(context.Background(), script, rtCtx); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !strings.HasPrefix(rtCtx.Saved["error"], "fetch failed: invalid URL") {
+		t.Errorf("unexpected error message %q", rtCtx.Saved["error"])
+	}
+}
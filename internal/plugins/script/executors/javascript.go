@@ -2,7 +2,10 @@ package executors
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/dop251/goja"
@@ -25,22 +28,67 @@ func (e *JavaScriptExecutor) Language() string {
 // ValidateScript performs static validation of JavaScript code
 func (e *JavaScriptExecutor) ValidateScript(script string) error {
 	// Basic validation - try to parse the script
-	_, err := goja.Compile("validation", script, false)
+	_, err := compileScript("validation", script, false)
 	if err != nil {
 		return fmt.Errorf("javascript syntax error: %w", err)
 	}
 	return nil
 }
 
+// compileScript compiles a script, counting its ops when it has an ops limit.
+// Scripts that await at the top level run in an async function, opened on
+// their first line so errors keep their lines.
+func compileScript(name, script string, counted bool) (*goja.Program, error) {
+	var firstErr error
+	for _, source := range []string{script, "(async () => {" + script + "\n})()"} {
+		if counted {
+			if withOps, err := countOps(name, source); err == nil {
+				source = withOps
+			}
+		}
+		program, err := goja.Compile(name, source, false)
+		if err == nil {
+			return program, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
+
 // Execute runs the JavaScript code in the provided runtime context
 func (e *JavaScriptExecutor) Execute(ctx context.Context, script string, rtCtx *runtime.Context) error {
 	// Create timeout context
-	timeout := 30 * time.Second // Default timeout
-	execCtx, cancel := context.WithTimeout(ctx, timeout)
+	limits := withDefaults(rtCtx.Limits, defaultTimeout)
+	execCtx, cancel := context.WithTimeout(ctx, limits.Timeout)
 	defer cancel()
 
+	program, err := compileScript("script", script, limits.Ops > 0)
+	if err != nil {
+		return fmt.Errorf("javascript syntax error: %w", err)
+	}
+
 	// Create JavaScript VM
 	vm := goja.New()
+	vm.SetMaxCallStackSize(maxCallStack)
+	clock := newScriptClock()
+
+	// Promises rejected with nothing to handle them fail the script, like
+	// errors thrown outside of them
+	var unhandled []*goja.Promise
+	vm.SetPromiseRejectionTracker(func(p *goja.Promise, operation goja.PromiseRejectionOperation) {
+		if operation == goja.PromiseRejectionReject {
+			unhandled = append(unhandled, p)
+			return
+		}
+		for i, rejected := range unhandled {
+			if rejected == p {
+				unhandled = append(unhandled[:i], unhandled[i+1:]...)
+				break
+			}
+		}
+	})
 
 	// Set up built-in functions
 	if err := e.setupBuiltins(vm, rtCtx); err != nil {
@@ -51,6 +99,22 @@ func (e *JavaScriptExecutor) Execute(ctx context.Context, script string, rtCtx *
 	if err := e.setupRuntimeData(vm, rtCtx); err != nil {
 		return fmt.Errorf("failed to setup runtime data: %w", err)
 	}
+	e.setupSandbox(execCtx, vm, rtCtx, clock)
+
+	// Scripts are interrupted when they go over a limit, so they don't keep
+	// running on the worker after the step has failed. The op counter stops
+	// them from the script's goroutine, and the watch below from this one.
+	var stopped error
+	var once sync.Once
+	stop := func(err error) {
+		once.Do(func() {
+			stopped = err
+			vm.Interrupt(err)
+		})
+	}
+	if limits.Ops > 0 {
+		setupOpCounter(vm, limits.Ops, func() { stop(limits.Exceeded("ops")) })
+	}
 
 	// Execute script with timeout
 	done := make(chan error, 1)
@@ -61,21 +125,57 @@ func (e *JavaScriptExecutor) Execute(ctx context.Context, script string, rtCtx *
 			}
 		}()
 
-		_, err := vm.RunString(script)
+		_, err := vm.RunProgram(program)
+		if err == nil && len(unhandled) > 0 {
+			err = fmt.Errorf("%s", unhandled[0].Result().String())
+		}
 		done <- err
 	}()
 
-	select {
-	case err := <-done:
-		if err != nil {
-			return fmt.Errorf("javascript execution error: %w", err)
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	baseline := heapBytes()
+	deadline := execCtx.Done()
+	for {
+		select {
+		case err := <-done:
+			if stopped != nil {
+				return fmt.Errorf("javascript execution stopped: %w", stopped)
+			}
+			var overflow *goja.StackOverflowError
+			if errors.As(err, &overflow) {
+				return fmt.Errorf("javascript execution error: maximum call stack size of %d exceeded, check for unbounded recursion (%s)", maxCallStack, strings.TrimSpace(err.Error()))
+			}
+			if err != nil {
+				return fmt.Errorf("javascript execution error: %w", err)
+			}
+			return nil
+		case <-deadline:
+			deadline = nil
+			if ctx.Err() != nil {
+				stop(fmt.Errorf("javascript execution timeout: %w", ctx.Err()))
+			} else {
+				stop(limits.Exceeded("timeout"))
+			}
+		case <-ticker.C:
+			if limits.CPUTime > 0 && clock.running() > limits.CPUTime {
+				stop(limits.Exceeded("cpu_time"))
+			}
+			if limits.Memory > 0 && heapBytes()-baseline > limits.Memory {
+				stop(limits.Exceeded("memory"))
+			}
 		}
-		return nil
-	case <-execCtx.Done():
-		return fmt.Errorf("javascript execution timeout")
 	}
 }
 
+// setupSandbox injects what scripts get beyond the language: fetch, require
+// for the bundled modules, and atob and btoa
+func (e *JavaScriptExecutor) setupSandbox(ctx context.Context, vm *goja.Runtime, rtCtx *runtime.Context, clock *scriptClock) {
+	setupFetch(ctx, vm, rtCtx.HTTP, clock)
+	setupRequire(vm)
+	setupEncoding(vm)
+}
+
 // setupBuiltins injects built-in functions into the JavaScript runtime
 func (e *JavaScriptExecutor) setupBuiltins(vm *goja.Runtime, rtCtx *runtime.Context) error {
 	// Inject save function
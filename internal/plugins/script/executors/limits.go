@@ -0,0 +1,84 @@
+package executors
+
+import (
+	"runtime/metrics"
+	"sync/atomic"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/plugins/script/runtime"
+)
+
+const (
+	// defaultTimeout bounds JavaScript, TypeScript and Python scripts
+	defaultTimeout = 30 * time.Second
+	// defaultShellTimeout is longer, since shell steps build and deploy things
+	defaultShellTimeout = 10 * time.Minute
+	// maxCallStack bounds recursion in JavaScript, which would otherwise grow
+	// the worker's stack until it crashes
+	maxCallStack = 10000
+	// watchInterval is how often JavaScript's CPU time and memory are checked
+	watchInterval = 10 * time.Millisecond
+)
+
+// withDefaults fills in the timeout when a step didn't set one. The other
+// limits are only enforced when set.
+func withDefaults(limits runtime.Limits, timeout time.Duration) runtime.Limits {
+	if limits.Timeout == 0 {
+		limits.Timeout = timeout
+	}
+	return limits
+}
+
+// scriptClock measures how long a script has been running, leaving out the
+// time it waited for fetch. JavaScript runs on one goroutine, so that is the
+// CPU time it used.
+type scriptClock struct {
+	start   time.Time
+	waited  atomic.Int64 // Nanoseconds spent in finished waits
+	waiting atomic.Int64 // Unix nanoseconds the current wait started, or 0
+}
+
+func newScriptClock() *scriptClock {
+	return &scriptClock{start: time.Now()}
+}
+
+// wait stops the clock until the returned function is called
+func (c *scriptClock) wait() func() {
+	if c == nil {
+		return func() {}
+	}
+	started := time.Now()
+	c.waiting.Store(started.UnixNano())
+	return func() {
+		c.waited.Add(int64(time.Since(started)))
+		c.waiting.Store(0)
+	}
+}
+
+// running returns the time the script has spent running
+func (c *scriptClock) running() time.Duration {
+	now := time.Now()
+	elapsed := now.Sub(c.start) - time.Duration(c.waited.Load())
+	if since := c.waiting.Load(); since != 0 {
+		elapsed -= now.Sub(time.Unix(0, since))
+	}
+	return elapsed
+}
+
+// heapSample reads the bytes of the worker's heap objects, including garbage
+// that has not been collected yet
+var heapSample = []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+
+// heapBytes returns the size of the worker's heap. JavaScript allocates in
+// the worker, so a script's memory is how much the heap grew while it ran.
+// The heap is shared by everything the worker runs, so this is approximate,
+// which is why JavaScript has no memory limit unless a step sets one.
+func heapBytes() int64 {
+	sample := make([]metrics.Sample, len(heapSample))
+	copy(sample, heapSample)
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return int64(sample[0].Value.Uint64())
+}
@@ -0,0 +1,209 @@
+package executors
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/plugins/script/runtime"
+)
+
+func limitedContext(limits runtime.Limits) *runtime.Context {
+	rtCtx := runtime.NewContext(map[string]string{}, map[string]interface{}{}, nil)
+	rtCtx.Limits = limits
+	return rtCtx
+}
+
+func TestJavaScriptLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		limits runtime.Limits
+		want   string
+	}{
+		{
+			name:   "timeout",
+			script: "while (true) {}",
+			limits: runtime.Limits{Timeout: 200 * time.Millisecond},
+			want:   "javascript execution stopped: script exceeded its timeout limit of 200ms",
+		},
+		{
+			name:   "cpu time",
+			script: "while (true) {}",
+			limits: runtime.Limits{Timeout: 10 * time.Second, CPUTime: 200 * time.Millisecond},
+			want:   "javascript execution stopped: script exceeded its cpu_time limit of 200ms",
+		},
+		{
+			name:   "memory",
+			script: "const rows = []; while (true) { rows.push({ id: rows.length, name: 'row ' + rows.length }); }",
+			limits: runtime.Limits{Timeout: 10 * time.Second, Memory: 32 << 20},
+			want:   "javascript execution stopped: script exceeded its memory limit of 32MB",
+		},
+		{
+			name:   "ops",
+			script: "let n = 0;\nwhile (true) n++;",
+			limits: runtime.Limits{Timeout: 10 * time.Second, Ops: 100000},
+			want:   "javascript execution stopped: script exceeded its ops limit of 100000",
+		},
+		{
+			name:   "ops in calls",
+			script: "function tick() { try { return 1; } catch (e) { return 0; } }\nfor (;;) { try { tick(); } catch (e) {} }",
+			limits: runtime.Limits{Timeout: 10 * time.Second, Ops: 100000},
+			want:   "javascript execution stopped: script exceeded its ops limit of 100000",
+		},
+		{
+			name:   "recursion",
+			script: "function down(n) { return down(n + 1) + 1; }\ndown(0);",
+			want:   "maximum call stack size of 10000 exceeded, check for unbounded recursion (at down (script:1:",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started := time.Now()
+			err := NewJavaScriptExecutor().Execute(context.Background(), tt.script, limitedContext(tt.limits))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected an error containing %q, got %v", tt.want, err)
+			}
+			if elapsed := time.Since(started); elapsed > 5*time.Second {
+				t.Errorf("the script ran for %s after going over its limit", elapsed)
+			}
+		})
+	}
+
+	var limitErr *runtime.LimitError
+	err := NewTypeScriptExecutor().Execute(context.Background(), "let n: number = 0;\nwhile (true) { n++; }", limitedContext(runtime.Limits{Timeout: 100 * time.Millisecond}))
+	if !errors.As(err, &limitErr) || limitErr.Limit != "timeout" {
+		t.Errorf("expected a timeout LimitError from TypeScript, got %v", err)
+	}
+}
+
+func TestJavaScriptLimits_NoMemoryByDefault(t *testing.T) {
+	// The heap is shared with the rest of the worker, so other scripts
+	// allocating must not stop one that set no memory limit
+	script := "const rows = []; for (let i = 0; i < 200000; i++) { rows.push({ id: i, name: 'row ' + i }); }\nsave('rows', String(rows.length));"
+	rtCtx := limitedContext(runtime.Limits{})
+	if err := NewJavaScriptExecutor().Execute(context.Background(), script, rtCtx); err != nil {
+		t.Fatalf("script without a memory limit was stopped: %v", err)
+	}
+	if rtCtx.Saved["rows"] != "200000" {
+		t.Errorf("unexpected rows %q", rtCtx.Saved["rows"])
+	}
+}
+
+func TestCountOps(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		ops    int
+		want   string
+	}{
+		{"for", "let n = 0; for (let i = 0; i < 5; i++) n++; save('n', String(n));", 5, "5"},
+		{"while", "let n = 0; while (n < 5) { n++ } save('n', String(n));", 5, "5"},
+		{"do while", "let n = 0; do n++; while (n < 5); save('n', String(n));", 5, "5"},
+		{"for of", "let n = 0; for (const x of [1, 2, 3, 4, 5]) n += x; save('n', String(n));", 5, "15"},
+		{"for in", "let n = 0; for (const k in {a: 1, b: 2, c: 3, d: 4, e: 5}) { n++ } save('n', String(n));", 5, "5"},
+		{"nested", "let n = 0; for (let i = 0; i < 2; i++) while (n < 4) n++; save('n', String(n));", 6, "4"},
+		{"functions", "function inc(n) { 'use strict'; return n + 1; }\nconst twice = (n) => inc(inc(n));\nsave('n', String(twice(3)));", 3, "5"},
+		{"arrow in loop", "let n = 0; for (let i = 0; i < 2; i++) n = [1].map(x => x + n)[0]; save('n', String(n));", 4, "2"},
+		{"class", "class Counter { constructor() { this.n = 0 } inc() { this.n++; return this } }\nsave('n', String(new Counter().inc().inc().n));", 3, "2"},
+		{"top level await", "const one = async () => 1;\nconst n = await one(); save('n', String(n));", 2, "1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rtCtx := limitedContext(runtime.Limits{Ops: int64(tt.ops)})
+			if err := NewJavaScriptExecutor().Execute(context.Background(), tt.script, rtCtx); err != nil {
+				t.Fatalf("script failed with %d ops allowed: %v", tt.ops, err)
+			}
+			if rtCtx.Saved["n"] != tt.want {
+				t.Errorf("expected n to be %q, got %q", tt.want, rtCtx.Saved["n"])
+			}
+
+			var limitErr *runtime.LimitError
+			err := NewJavaScriptExecutor().Execute(context.Background(), tt.script, limitedContext(runtime.Limits{Ops: int64(tt.ops - 1)}))
+			if !errors.As(err, &limitErr) || limitErr.Limit != "ops" {
+				t.Errorf("expected an ops LimitError with %d ops allowed, got %v", tt.ops-1, err)
+			}
+		})
+	}
+
+	// Errors keep their lines when ops are counted
+	err := NewJavaScriptExecutor().Execute(context.Background(), "function f() {\n  return missing;\n}\nf();", limitedContext(runtime.Limits{Ops: 100}))
+	if err == nil || !strings.Contains(err.Error(), "script:2:") {
+		t.Errorf("expected the error on line 2, got %v", err)
+	}
+}
+
+func TestJavaScriptLimits_FetchIsNotCPUTime(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		_, _ = w.Write([]byte("slow"))
+	}))
+	defer server.Close()
+
+	rtCtx := limitedContext(runtime.Limits{CPUTime: 200 * time.Millisecond})
+	rtCtx.State["url"] = server.URL
+	script := `const res = await fetch(state.url); save("body", await res.text());`
+	if err := NewJavaScriptExecutor().Execute(context.Background(), script, rtCtx); err != nil {
+		t.Fatalf("waiting on fetch counted against the cpu_time limit: %v", err)
+	}
+	if rtCtx.Saved["body"] != "slow" {
+		t.Errorf("unexpected body %q", rtCtx.Saved["body"])
+	}
+}
+
+func TestPythonLimits(t *testing.T) {
+	requirePython(t)
+	tests := []struct {
+		name   string
+		script string
+		limits runtime.Limits
+		want   string
+	}{
+		{"timeout", "import time\ntime.sleep(10)", runtime.Limits{Timeout: 300 * time.Millisecond}, "python execution timeout: script exceeded its timeout limit of 300ms"},
+		{"cpu time", "while True:\n    pass", runtime.Limits{CPUTime: time.Second}, "python execution stopped: script exceeded its cpu_time limit of 1s"},
+		{"memory", "rows = [0] * (512 * 1024 * 1024)", runtime.Limits{Memory: 128 << 20}, "python execution stopped: script exceeded its memory limit of 128MB"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewPythonExecutor().Execute(context.Background(), tt.script, limitedContext(tt.limits))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestShellLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		limits runtime.Limits
+		want   string
+	}{
+		{"timeout", "sleep 10", runtime.Limits{Timeout: 300 * time.Millisecond}, "shell execution timeout: script exceeded its timeout limit of 300ms"},
+		{"cpu time", "while true; do :; done", runtime.Limits{CPUTime: time.Second}, "shell execution stopped: script exceeded its cpu_time limit of 1s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewShellExecutor().Execute(context.Background(), tt.script, limitedContext(tt.limits))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	// Scripts run as written under the limits, with their own line numbers
+	rtCtx := limitedContext(runtime.Limits{CPUTime: 5 * time.Second})
+	err := NewShellExecutor().Execute(context.Background(), "echo \"$(ulimit -t)\"\nfalse_command_xyz", rtCtx)
+	if err == nil || !strings.Contains(rtCtx.Saved["stderr"], "line 2") {
+		t.Errorf("expected the error on line 2, got %v: %q", err, rtCtx.Saved["stderr"])
+	}
+	if strings.TrimSpace(rtCtx.Saved["stdout"]) != "5" {
+		t.Errorf("expected a 5s cpu limit inside the script, got %q", rtCtx.Saved["stdout"])
+	}
+}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package executors
+
+import (
+	"os"
+	"syscall"
+)
+
+// overCPULimit reports whether a process was killed for going over its CPU
+// time limit
+func overCPULimit(state *os.ProcessState) bool {
+	if state == nil {
+		return false
+	}
+	status, ok := state.Sys().(syscall.WaitStatus)
+	return ok && status.Signaled() && status.Signal() == syscall.SIGXCPU
+}
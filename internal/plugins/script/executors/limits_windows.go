@@ -0,0 +1,11 @@
+//go:build windows
+
+package executors
+
+import "os"
+
+// overCPULimit reports whether a process was killed for going over its CPU
+// time limit, which Windows doesn't set
+func overCPULimit(state *os.ProcessState) bool {
+	return false
+}
@@ -0,0 +1,203 @@
+package executors
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	_ "embed"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"sort"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+//go:embed modules/lodash.js
+var lodashSource string
+
+//go:embed modules/dayjs.js
+var dayjsSource string
+
+// modules are the modules scripts can require. There is no filesystem or
+// package loading behind require: anything else fails.
+var modules = map[string]func(vm *goja.Runtime) (goja.Value, error){
+	"lodash": sourceModule("lodash", lodashSource),
+	"dayjs":  sourceModule("dayjs", dayjsSource),
+	"crypto": cryptoModule,
+}
+
+// ModuleNames returns the names scripts can require, sorted
+func ModuleNames() []string {
+	names := make([]string, 0, len(modules))
+	for name := range modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// setupRequire injects require, loading each module once per script
+func setupRequire(vm *goja.Runtime) {
+	loaded := make(map[string]goja.Value)
+	_ = vm.Set("require", func(name string) goja.Value {
+		if module, ok := loaded[name]; ok {
+			return module
+		}
+		load, ok := modules[name]
+		if !ok {
+			panic(vm.NewTypeError("module %q is not available, scripts can require %s", name, strings.Join(ModuleNames(), ", ")))
+		}
+		module, err := load(vm)
+		if err != nil {
+			panic(vm.NewGoError(fmt.Errorf("failed to load module %q: %w", name, err)))
+		}
+		loaded[name] = module
+		return module
+	})
+}
+
+// sourceModule loads a bundled CommonJS module from its source
+func sourceModule(name, source string) func(vm *goja.Runtime) (goja.Value, error) {
+	return func(vm *goja.Runtime) (goja.Value, error) {
+		wrapper, err := vm.RunScript(name+".js", "(function (module, exports) {\n"+source+"\n})")
+		if err != nil {
+			return nil, err
+		}
+		fn, _ := goja.AssertFunction(wrapper)
+		module := vm.NewObject()
+		exports := vm.NewObject()
+		_ = module.Set("exports", exports)
+		if _, err := fn(goja.Undefined(), module, exports); err != nil {
+			return nil, err
+		}
+		return module.Get("exports"), nil
+	}
+}
+
+// hashes are the algorithms createHash and createHmac accept
+var hashes = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha384": sha512.New384,
+	"sha512": sha512.New,
+}
+
+// cryptoModule is the part of node's crypto module that works on strings:
+// hashes, HMACs, random bytes and UUIDs
+func cryptoModule(vm *goja.Runtime) (goja.Value, error) {
+	module := vm.NewObject()
+	newHash := func(algorithm string, key *string) goja.Value {
+		newFunc, ok := hashes[strings.ToLower(algorithm)]
+		if !ok {
+			panic(vm.NewTypeError("unsupported hash algorithm %q", algorithm))
+		}
+		var h hash.Hash
+		if key != nil {
+			h = hmac.New(newFunc, []byte(*key))
+		} else {
+			h = newFunc()
+		}
+		return hashObject(vm, h)
+	}
+	_ = module.Set("createHash", func(algorithm string) goja.Value {
+		return newHash(algorithm, nil)
+	})
+	_ = module.Set("createHmac", func(algorithm, key string) goja.Value {
+		return newHash(algorithm, &key)
+	})
+	_ = module.Set("randomBytes", func(size int, encoding goja.Value) string {
+		if size < 0 || size > 1<<16 {
+			panic(vm.NewTypeError("randomBytes size must be between 0 and 65536"))
+		}
+		data := make([]byte, size)
+		_, _ = rand.Read(data)
+		return encode(vm, data, encoding)
+	})
+	_ = module.Set("randomUUID", func() string {
+		data := make([]byte, 16)
+		_, _ = rand.Read(data)
+		data[6] = data[6]&0x0f | 0x40
+		data[8] = data[8]&0x3f | 0x80
+		h := hex.EncodeToString(data)
+		return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+	})
+	_ = module.Set("randomInt", func(min, max int64) int64 {
+		if max <= min {
+			panic(vm.NewTypeError("randomInt max must be greater than min"))
+		}
+		var data [8]byte
+		_, _ = rand.Read(data[:])
+		var n uint64
+		for _, b := range data {
+			n = n<<8 | uint64(b)
+		}
+		return min + int64(n%uint64(max-min))
+	})
+	_ = module.Set("timingSafeEqual", func(a, b string) bool {
+		return hmac.Equal([]byte(a), []byte(b))
+	})
+	return module, nil
+}
+
+// hashObject is a hash with node's update and digest
+func hashObject(vm *goja.Runtime, h hash.Hash) goja.Value {
+	obj := vm.NewObject()
+	_ = obj.Set("update", func(data string) goja.Value {
+		h.Write([]byte(data))
+		return obj
+	})
+	_ = obj.Set("digest", func(encoding goja.Value) string {
+		return encode(vm, h.Sum(nil), encoding)
+	})
+	return obj
+}
+
+// encode encodes bytes as hex (the default, where node would return a
+// Buffer), base64 or base64url
+func encode(vm *goja.Runtime, data []byte, encoding goja.Value) string {
+	name := "hex"
+	if set(encoding) {
+		name = encoding.String()
+	}
+	switch name {
+	case "hex":
+		return hex.EncodeToString(data)
+	case "base64":
+		return base64.StdEncoding.EncodeToString(data)
+	case "base64url":
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	panic(vm.NewTypeError("unsupported encoding %q, use hex, base64 or base64url", name))
+}
+
+// setupEncoding injects the browser's atob and btoa
+func setupEncoding(vm *goja.Runtime) {
+	_ = vm.Set("btoa", func(text string) string {
+		data := make([]byte, 0, len(text))
+		for _, r := range text {
+			if r > 0xff {
+				panic(vm.NewTypeError("btoa: the string has characters outside of Latin1"))
+			}
+			data = append(data, byte(r))
+		}
+		return base64.StdEncoding.EncodeToString(data)
+	})
+	_ = vm.Set("atob", func(encoded string) string {
+		data, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(encoded, "="))
+		if err != nil {
+			panic(vm.NewTypeError("atob: the string is not valid base64"))
+		}
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	})
+}
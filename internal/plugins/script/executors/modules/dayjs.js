@@ -0,0 +1,213 @@
+// The core of dayjs: parsing, formatting, arithmetic and comparisons, in the
+// worker's local time. dayjs.utc() works in UTC instead.
+"use strict";
+
+const MS = { millisecond: 1, second: 1e3, minute: 6e4, hour: 36e5, day: 864e5, week: 6048e5 };
+const UNITS = {
+  ms: "millisecond", millisecond: "millisecond", milliseconds: "millisecond",
+  s: "second", second: "second", seconds: "second",
+  m: "minute", minute: "minute", minutes: "minute",
+  h: "hour", hour: "hour", hours: "hour",
+  d: "day", day: "day", days: "day", D: "date", date: "date", dates: "date",
+  w: "week", week: "week", weeks: "week",
+  M: "month", month: "month", months: "month",
+  Q: "quarter", quarter: "quarter", quarters: "quarter",
+  y: "year", year: "year", years: "year",
+};
+const MONTHS = ["January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"];
+const WEEKDAYS = ["Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"];
+const FORMAT = /\[([^\]]+)]|Y{1,4}|M{1,4}|D{1,2}|d{1,4}|H{1,2}|h{1,2}|a|A|m{1,2}|s{1,2}|Z{1,2}|SSS/g;
+const ISO_DATE = /^(\d{4})-?(\d{1,2})?-?(\d{1,2})?$/;
+
+function unit(u) {
+  const normalized = UNITS[u] || UNITS[String(u || "").toLowerCase()];
+  if (!normalized) throw new Error(`dayjs: unknown unit "${u}"`);
+  return normalized;
+}
+
+function pad(n, length = 2) {
+  return String(Math.abs(n)).padStart(length, "0");
+}
+
+function parse(input, utc) {
+  if (input === undefined) return new Date();
+  if (input === null) return new Date(NaN);
+  if (input instanceof Dayjs) return new Date(input.valueOf());
+  if (input instanceof Date) return new Date(input.getTime());
+  if (typeof input === "number") return new Date(input);
+  const date = ISO_DATE.exec(String(input));
+  if (date) {
+    // Dates without a time are midnight where the instance lives, as in dayjs
+    const [year, month, day] = [Number(date[1]), Number(date[2] || 1) - 1, Number(date[3] || 1)];
+    return utc ? new Date(Date.UTC(year, month, day)) : new Date(year, month, day);
+  }
+  return new Date(String(input));
+}
+
+class Dayjs {
+  constructor(input, utc) {
+    this.$utc = !!utc;
+    this.$d = parse(input, this.$utc);
+  }
+
+  $get(part) {
+    const d = this.$d;
+    switch (part) {
+      case "year": return this.$utc ? d.getUTCFullYear() : d.getFullYear();
+      case "month": return this.$utc ? d.getUTCMonth() : d.getMonth();
+      case "date": return this.$utc ? d.getUTCDate() : d.getDate();
+      case "day": return this.$utc ? d.getUTCDay() : d.getDay();
+      case "hour": return this.$utc ? d.getUTCHours() : d.getHours();
+      case "minute": return this.$utc ? d.getUTCMinutes() : d.getMinutes();
+      case "second": return this.$utc ? d.getUTCSeconds() : d.getSeconds();
+      case "millisecond": return this.$utc ? d.getUTCMilliseconds() : d.getMilliseconds();
+    }
+    throw new Error(`dayjs: unknown unit "${part}"`);
+  }
+
+  // $with returns a copy with the parts changed, rolling over like Date does
+  $with(parts) {
+    const p = {
+      year: this.$get("year"), month: this.$get("month"), date: this.$get("date"), hour: this.$get("hour"),
+      minute: this.$get("minute"), second: this.$get("second"), millisecond: this.$get("millisecond"),
+    };
+    Object.assign(p, parts);
+    const args = [p.year, p.month, p.date, p.hour, p.minute, p.second, p.millisecond];
+    const ms = this.$utc ? Date.UTC(...args) : new Date(...args).getTime();
+    return new Dayjs(ms, this.$utc);
+  }
+
+  isValid() { return !isNaN(this.$d.getTime()); }
+  valueOf() { return this.$d.getTime(); }
+  unix() { return Math.floor(this.valueOf() / 1000); }
+  toDate() { return new Date(this.valueOf()); }
+  toISOString() { return this.$d.toISOString(); }
+  toJSON() { return this.isValid() ? this.toISOString() : null; }
+  toString() { return this.$d.toUTCString(); }
+  clone() { return new Dayjs(this.valueOf(), this.$utc); }
+  utc() { return new Dayjs(this.valueOf(), true); }
+  local() { return new Dayjs(this.valueOf(), false); }
+  isUTC() { return this.$utc; }
+  utcOffset() { return this.$utc ? 0 : -this.$d.getTimezoneOffset(); }
+  daysInMonth() { return this.$with({ month: this.$get("month") + 1, date: 0 }).$get("date"); }
+
+  year(v) { return v === undefined ? this.$get("year") : this.$with({ year: v }); }
+  month(v) { return v === undefined ? this.$get("month") : this.$with({ month: v }); }
+  date(v) { return v === undefined ? this.$get("date") : this.$with({ date: v }); }
+  day(v) { return v === undefined ? this.$get("day") : this.add(v - this.$get("day"), "day"); }
+  hour(v) { return v === undefined ? this.$get("hour") : this.$with({ hour: v }); }
+  minute(v) { return v === undefined ? this.$get("minute") : this.$with({ minute: v }); }
+  second(v) { return v === undefined ? this.$get("second") : this.$with({ second: v }); }
+  millisecond(v) { return v === undefined ? this.$get("millisecond") : this.$with({ millisecond: v }); }
+  get(u) { return this.$get(unit(u)); }
+  set(u, v) { return unit(u) === "day" ? this.day(v) : this.$with({ [unit(u)]: v }); }
+
+  add(n, u) {
+    n = Number(n);
+    switch (unit(u)) {
+      case "year": return this.$addMonths(n * 12);
+      case "quarter": return this.$addMonths(n * 3);
+      case "month": return this.$addMonths(n);
+      case "day": case "date": return this.$with({ date: this.$get("date") + n });
+      case "week": return this.$with({ date: this.$get("date") + n * 7 });
+      default: return new Dayjs(this.valueOf() + n * MS[unit(u)], this.$utc);
+    }
+  }
+
+  // $addMonths keeps the day of the month, clamped to the new month's length
+  $addMonths(n) {
+    const first = this.$with({ date: 1, month: this.$get("month") + n });
+    return first.$with({ date: Math.min(this.$get("date"), first.daysInMonth()) });
+  }
+
+  subtract(n, u) { return this.add(-n, u); }
+
+  startOf(u, end) {
+    const zero = { month: 0, date: 1, hour: 0, minute: 0, second: 0, millisecond: 0 };
+    const order = ["year", "month", "date", "hour", "minute", "second", "millisecond"];
+    let start;
+    switch (unit(u)) {
+      case "week":
+        start = this.$with({ date: this.$get("date") - this.$get("day"), hour: 0, minute: 0, second: 0, millisecond: 0 });
+        return end ? start.add(1, "week").subtract(1, "ms") : start;
+      case "quarter":
+        start = this.$with({ month: Math.floor(this.$get("month") / 3) * 3, date: 1, hour: 0, minute: 0, second: 0, millisecond: 0 });
+        return end ? start.add(1, "quarter").subtract(1, "ms") : start;
+      default: {
+        const u2 = unit(u) === "day" ? "date" : unit(u);
+        const reset = {};
+        for (const part of order.slice(order.indexOf(u2) + 1)) reset[part] = zero[part];
+        start = this.$with(reset);
+        return end ? start.add(1, u2 === "date" ? "day" : u2).subtract(1, "ms") : start;
+      }
+    }
+  }
+
+  endOf(u) { return this.startOf(u, true); }
+
+  diff(other, u = "millisecond", float = false) {
+    const that = dayjs(other);
+    let result;
+    switch (unit(u)) {
+      case "year": result = this.$monthDiff(that) / 12; break;
+      case "quarter": result = this.$monthDiff(that) / 3; break;
+      case "month": result = this.$monthDiff(that); break;
+      case "day": case "date": case "week":
+        // Days are counted on the calendar, so a daylight saving change doesn't cut one short
+        result = (this.valueOf() - that.valueOf() - (that.utcOffset() - this.utcOffset()) * MS.minute) / MS[unit(u) === "date" ? "day" : unit(u)];
+        break;
+      default: result = (this.valueOf() - that.valueOf()) / MS[unit(u)];
+    }
+    return float ? result : Math.trunc(result);
+  }
+
+  // $monthDiff is the months from that to this, with the part month as a fraction
+  $monthDiff(that) {
+    if (this.$get("date") < that.$get("date")) return -that.$monthDiff(this);
+    const whole = (this.$get("year") - that.$get("year")) * 12 + (this.$get("month") - that.$get("month"));
+    const anchor = that.add(whole, "month");
+    const next = that.add(whole + (this.valueOf() - anchor.valueOf() < 0 ? -1 : 1), "month");
+    const part = (this.valueOf() - anchor.valueOf()) / Math.abs(next.valueOf() - anchor.valueOf());
+    return whole + part || 0;
+  }
+
+  isSame(other, u = "millisecond") {
+    const that = dayjs(other);
+    return this.startOf(u).valueOf() <= that.valueOf() && that.valueOf() <= this.endOf(u).valueOf();
+  }
+  isBefore(other, u = "millisecond") { return this.endOf(u).valueOf() < dayjs(other).valueOf(); }
+  isAfter(other, u = "millisecond") { return dayjs(other).valueOf() < this.startOf(u).valueOf(); }
+
+  format(template = "YYYY-MM-DDTHH:mm:ssZ") {
+    if (!this.isValid()) return "Invalid Date";
+    const offset = this.utcOffset();
+    const zone = (separator) => (offset < 0 ? "-" : "+") + pad(Math.floor(Math.abs(offset) / 60)) + separator + pad(Math.abs(offset) % 60);
+    const h = this.$get("hour");
+    const tokens = {
+      YY: String(this.$get("year")).slice(-2), YYYY: pad(this.$get("year"), 4),
+      M: this.$get("month") + 1, MM: pad(this.$get("month") + 1),
+      MMM: MONTHS[this.$get("month")].slice(0, 3), MMMM: MONTHS[this.$get("month")],
+      D: this.$get("date"), DD: pad(this.$get("date")),
+      d: this.$get("day"), dd: WEEKDAYS[this.$get("day")].slice(0, 2),
+      ddd: WEEKDAYS[this.$get("day")].slice(0, 3), dddd: WEEKDAYS[this.$get("day")],
+      H: h, HH: pad(h), h: h % 12 || 12, hh: pad(h % 12 || 12),
+      a: h < 12 ? "am" : "pm", A: h < 12 ? "AM" : "PM",
+      m: this.$get("minute"), mm: pad(this.$get("minute")),
+      s: this.$get("second"), ss: pad(this.$get("second")),
+      SSS: pad(this.$get("millisecond"), 3), Z: zone(":"), ZZ: zone(""),
+    };
+    return template.replace(FORMAT, (match, escaped) => escaped || String(tokens[match] !== undefined ? tokens[match] : match));
+  }
+}
+
+function dayjs(input) {
+  return new Dayjs(input, input instanceof Dayjs ? input.$utc : false);
+}
+
+dayjs.utc = (input) => new Dayjs(input, true);
+dayjs.unix = (seconds) => dayjs(seconds * 1000);
+dayjs.isDayjs = (v) => v instanceof Dayjs;
+dayjs.max = (...dates) => dates.flat().reduce((a, b) => (b.valueOf() > a.valueOf() ? b : a));
+dayjs.min = (...dates) => dates.flat().reduce((a, b) => (b.valueOf() < a.valueOf() ? b : a));
+
+module.exports = dayjs;
@@ -0,0 +1,343 @@
+// The lodash functions scripts use most, with lodash's behaviour. Iteratees
+// can be functions, property paths ("user.name"), or objects to match.
+"use strict";
+
+const _ = {};
+
+function toPath(path) {
+  if (Array.isArray(path)) return path;
+  return String(path).replace(/\[(\w+)\]/g, ".$1").split(".").filter((p) => p !== "");
+}
+
+function iteratee(fn) {
+  if (typeof fn === "function") return fn;
+  if (fn == null) return (v) => v;
+  if (typeof fn === "object") return (v) => Object.keys(fn).every((k) => _.isEqual(_.get(v, k), fn[k]));
+  return (v) => _.get(v, fn);
+}
+
+function values(collection) {
+  if (collection == null) return [];
+  return Array.isArray(collection) ? collection : Object.values(collection);
+}
+
+function compare(a, b) {
+  if (a === b) return 0;
+  if (a === undefined || a === null) return 1;
+  if (b === undefined || b === null) return -1;
+  return a < b ? -1 : 1;
+}
+
+// Objects
+
+_.get = (obj, path, defaultValue) => {
+  let value = obj;
+  for (const key of toPath(path)) {
+    if (value == null) return defaultValue;
+    value = value[key];
+  }
+  return value === undefined ? defaultValue : value;
+};
+
+_.set = (obj, path, value) => {
+  const keys = toPath(path);
+  let target = obj;
+  keys.forEach((key, i) => {
+    if (i === keys.length - 1) {
+      target[key] = value;
+    } else {
+      if (target[key] == null || typeof target[key] !== "object") target[key] = /^\d+$/.test(keys[i + 1]) ? [] : {};
+      target = target[key];
+    }
+  });
+  return obj;
+};
+
+_.has = (obj, path) => {
+  let value = obj;
+  for (const key of toPath(path)) {
+    if (value == null || !Object.prototype.hasOwnProperty.call(value, key)) return false;
+    value = value[key];
+  }
+  return true;
+};
+
+_.pick = (obj, ...paths) => {
+  const result = {};
+  for (const path of paths.flat()) {
+    if (_.has(obj, path)) _.set(result, path, _.get(obj, path));
+  }
+  return result;
+};
+
+_.omit = (obj, ...paths) => {
+  const omitted = new Set(paths.flat().map(String));
+  const result = {};
+  for (const key of Object.keys(obj || {})) {
+    if (!omitted.has(key)) result[key] = obj[key];
+  }
+  return result;
+};
+
+_.mapValues = (obj, fn) => {
+  const f = iteratee(fn);
+  const result = {};
+  for (const key of Object.keys(obj || {})) result[key] = f(obj[key], key, obj);
+  return result;
+};
+
+_.mapKeys = (obj, fn) => {
+  const f = iteratee(fn);
+  const result = {};
+  for (const key of Object.keys(obj || {})) result[f(obj[key], key, obj)] = obj[key];
+  return result;
+};
+
+_.keys = (obj) => Object.keys(obj || {});
+_.values = (obj) => values(obj).slice();
+_.entries = _.toPairs = (obj) => Object.entries(obj || {});
+_.fromPairs = (pairs) => Object.fromEntries(pairs || []);
+
+_.cloneDeep = (value) => {
+  if (Array.isArray(value)) return value.map(_.cloneDeep);
+  if (value instanceof Date) return new Date(value.getTime());
+  if (_.isPlainObject(value)) return _.mapValues(value, _.cloneDeep);
+  return value;
+};
+_.clone = (value) => {
+  if (Array.isArray(value)) return value.slice();
+  if (_.isPlainObject(value)) return Object.assign({}, value);
+  return value;
+};
+
+_.merge = (target, ...sources) => {
+  for (const source of sources) {
+    if (source == null) continue;
+    for (const key of Object.keys(source)) {
+      const value = source[key];
+      if ((_.isPlainObject(value) || Array.isArray(value)) && (_.isPlainObject(target[key]) || Array.isArray(target[key]))) {
+        _.merge(target[key], value);
+      } else if (value !== undefined) {
+        target[key] = _.cloneDeep(value);
+      }
+    }
+  }
+  return target;
+};
+
+_.defaults = (target, ...sources) => {
+  for (const source of sources) {
+    for (const key of Object.keys(source || {})) {
+      if (target[key] === undefined) target[key] = source[key];
+    }
+  }
+  return target;
+};
+
+// Collections
+
+_.map = (collection, fn) => values(collection).map(iteratee(fn));
+_.filter = (collection, fn) => values(collection).filter(iteratee(fn));
+_.reject = (collection, fn) => {
+  const f = iteratee(fn);
+  return values(collection).filter((v, i, a) => !f(v, i, a));
+};
+_.find = (collection, fn) => values(collection).find(iteratee(fn));
+_.findIndex = (array, fn) => (array || []).findIndex(iteratee(fn));
+_.some = (collection, fn) => values(collection).some(iteratee(fn));
+_.every = (collection, fn) => values(collection).every(iteratee(fn));
+_.includes = (collection, value) =>
+  typeof collection === "string" ? collection.includes(value) : values(collection).some((v) => _.isEqual(v, value));
+_.size = (collection) => (typeof collection === "string" ? collection.length : values(collection).length);
+
+_.partition = (collection, fn) => {
+  const f = iteratee(fn);
+  const result = [[], []];
+  for (const v of values(collection)) result[f(v) ? 0 : 1].push(v);
+  return result;
+};
+
+_.groupBy = (collection, fn) => {
+  const f = iteratee(fn);
+  const result = {};
+  for (const v of values(collection)) {
+    const key = f(v);
+    (result[key] = result[key] || []).push(v);
+  }
+  return result;
+};
+
+_.keyBy = (collection, fn) => {
+  const f = iteratee(fn);
+  const result = {};
+  for (const v of values(collection)) result[f(v)] = v;
+  return result;
+};
+
+_.countBy = (collection, fn) => {
+  const f = iteratee(fn);
+  const result = {};
+  for (const v of values(collection)) {
+    const key = f(v);
+    result[key] = (result[key] || 0) + 1;
+  }
+  return result;
+};
+
+_.orderBy = (collection, fns, orders) => {
+  const fs = (Array.isArray(fns) ? fns : [fns]).map(iteratee);
+  const dirs = Array.isArray(orders) ? orders : [orders];
+  return values(collection)
+    .map((v, i) => ({ v, i }))
+    .sort((a, b) => {
+      for (let k = 0; k < fs.length; k++) {
+        const c = compare(fs[k](a.v), fs[k](b.v));
+        if (c !== 0) return dirs[k] === "desc" ? -c : c;
+      }
+      return a.i - b.i;
+    })
+    .map((e) => e.v);
+};
+_.sortBy = (collection, ...fns) => _.orderBy(collection, fns.flat().length ? fns.flat() : [(v) => v]);
+
+// Arrays
+
+_.chunk = (array, size = 1) => {
+  const result = [];
+  for (let i = 0; i < (array || []).length; i += Math.max(size, 1)) result.push(array.slice(i, i + Math.max(size, 1)));
+  return result;
+};
+_.compact = (array) => (array || []).filter(Boolean);
+_.flatten = (array) => (array || []).flat();
+_.flattenDeep = (array) => (array || []).flat(Infinity);
+_.uniq = (array) => Array.from(new Set(array || []));
+_.uniqBy = (array, fn) => {
+  const f = iteratee(fn);
+  const seen = new Set();
+  return (array || []).filter((v) => {
+    const key = f(v);
+    if (seen.has(key)) return false;
+    seen.add(key);
+    return true;
+  });
+};
+_.difference = (array, ...others) => {
+  const excluded = new Set(others.flat());
+  return (array || []).filter((v) => !excluded.has(v));
+};
+_.intersection = (array, ...others) => _.uniq(array).filter((v) => others.every((o) => (o || []).includes(v)));
+_.union = (...arrays) => _.uniq(arrays.flat());
+_.without = (array, ...excluded) => _.difference(array, excluded);
+_.zip = (...arrays) => Array.from({ length: Math.max(0, ...arrays.map((a) => a.length)) }, (_unused, i) => arrays.map((a) => a[i]));
+_.head = _.first = (array) => (array || [])[0];
+_.last = (array) => (array || [])[(array || []).length - 1];
+_.take = (array, n = 1) => (array || []).slice(0, n);
+_.takeRight = (array, n = 1) => (n > 0 ? (array || []).slice(-n) : []);
+_.drop = (array, n = 1) => (array || []).slice(n);
+_.range = (start, end, step) => {
+  if (end === undefined) [start, end] = [0, start];
+  step = step === undefined ? (start < end ? 1 : -1) : step;
+  const result = [];
+  if (step === 0) return result;
+  for (let i = start; step > 0 ? i < end : i > end; i += step) result.push(i);
+  return result;
+};
+_.times = (n, fn) => Array.from({ length: Math.max(n, 0) }, (_unused, i) => iteratee(fn)(i));
+_.shuffle = (collection) => {
+  const result = values(collection).slice();
+  for (let i = result.length - 1; i > 0; i--) {
+    const j = Math.floor(Math.random() * (i + 1));
+    [result[i], result[j]] = [result[j], result[i]];
+  }
+  return result;
+};
+_.sample = (collection) => {
+  const vs = values(collection);
+  return vs[Math.floor(Math.random() * vs.length)];
+};
+
+// Math
+
+_.sum = (array) => (array || []).reduce((a, b) => a + b, 0);
+_.sumBy = (array, fn) => _.sum((array || []).map(iteratee(fn)));
+_.mean = (array) => _.sum(array) / (array || []).length;
+_.meanBy = (array, fn) => _.mean((array || []).map(iteratee(fn)));
+_.maxBy = (array, fn) => {
+  const f = iteratee(fn);
+  return (array || []).reduce((best, v) => (best === undefined || f(v) > f(best) ? v : best), undefined);
+};
+_.minBy = (array, fn) => {
+  const f = iteratee(fn);
+  return (array || []).reduce((best, v) => (best === undefined || f(v) < f(best) ? v : best), undefined);
+};
+_.max = (array) => _.maxBy(array);
+_.min = (array) => _.minBy(array);
+_.round = (n, precision = 0) => Math.round(n * 10 ** precision) / 10 ** precision;
+_.clamp = (n, lower, upper) => Math.min(Math.max(n, lower), upper);
+_.random = (lower = 0, upper = 1, floating = false) => {
+  const value = lower + Math.random() * (upper - lower);
+  return floating || !Number.isInteger(lower) || !Number.isInteger(upper) ? value : Math.floor(lower + Math.random() * (upper - lower + 1));
+};
+
+// Types
+
+_.isNil = (v) => v === null || v === undefined;
+_.isString = (v) => typeof v === "string";
+_.isNumber = (v) => typeof v === "number";
+_.isBoolean = (v) => typeof v === "boolean";
+_.isArray = Array.isArray;
+_.isFunction = (v) => typeof v === "function";
+_.isObject = (v) => v !== null && (typeof v === "object" || typeof v === "function");
+_.isPlainObject = (v) => {
+  if (v === null || typeof v !== "object") return false;
+  const proto = Object.getPrototypeOf(v);
+  return proto === null || proto === Object.prototype;
+};
+_.isEmpty = (v) => {
+  if (v == null) return true;
+  if (typeof v === "string" || Array.isArray(v)) return v.length === 0;
+  if (v instanceof Map || v instanceof Set) return v.size === 0;
+  if (typeof v === "object") return Object.keys(v).length === 0;
+  return true;
+};
+_.isEqual = (a, b) => {
+  if (a === b || (a !== a && b !== b)) return true;
+  if (a instanceof Date && b instanceof Date) return a.getTime() === b.getTime();
+  if (typeof a !== "object" || typeof b !== "object" || a === null || b === null) return false;
+  if (Array.isArray(a) !== Array.isArray(b)) return false;
+  const keys = Object.keys(a);
+  if (keys.length !== Object.keys(b).length) return false;
+  return keys.every((k) => Object.prototype.hasOwnProperty.call(b, k) && _.isEqual(a[k], b[k]));
+};
+
+// Strings
+
+function words(s) {
+  return String(s || "")
+    .replace(/([a-z\d])([A-Z])/g, "$1 $2")
+    .replace(/([A-Z]+)([A-Z][a-z])/g, "$1 $2")
+    .split(/[^A-Za-z\d]+/)
+    .filter(Boolean);
+}
+
+_.words = words;
+_.capitalize = (s) => {
+  s = String(s || "");
+  return s.charAt(0).toUpperCase() + s.slice(1).toLowerCase();
+};
+_.upperFirst = (s) => String(s || "").charAt(0).toUpperCase() + String(s || "").slice(1);
+_.camelCase = (s) => words(s).map((w, i) => (i === 0 ? w.toLowerCase() : _.capitalize(w))).join("");
+_.snakeCase = (s) => words(s).map((w) => w.toLowerCase()).join("_");
+_.kebabCase = (s) => words(s).map((w) => w.toLowerCase()).join("-");
+_.startCase = (s) => words(s).map(_.upperFirst).join(" ");
+_.trim = (s) => String(s || "").trim();
+_.padStart = (s, length, chars = " ") => String(s || "").padStart(length, chars);
+_.padEnd = (s, length, chars = " ") => String(s || "").padEnd(length, chars);
+_.truncate = (s, options = {}) => {
+  const length = options.length === undefined ? 30 : options.length;
+  const omission = options.omission === undefined ? "..." : options.omission;
+  s = String(s || "");
+  return s.length <= length ? s : s.slice(0, Math.max(length - omission.length, 0)) + omission;
+};
+
+module.exports = _;
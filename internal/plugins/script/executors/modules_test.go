@@ -0,0 +1,85 @@
+package executors
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/rocketship-ai/rocketship/internal/plugins/script/runtime"
+)
+
+func TestJavaScriptModules(t *testing.T) {
+	script := `
+const _ = require("lodash");
+const dayjs = require("dayjs");
+const crypto = require("crypto");
+
+const orders = [
+  { id: "a", status: "paid", total: 10 },
+  { id: "b", status: "refunded", total: 5 },
+  { id: "c", status: "paid", total: 7.5 },
+];
+save("paid", _.map(_.filter(orders, { status: "paid" }), "id").join(","));
+save("total", String(_.sumBy(orders, "total")));
+save("groups", JSON.stringify(_.mapValues(_.groupBy(orders, "status"), (g) => g.length)));
+save("deep", _.get({ a: { b: [{ c: "x" }] } }, "a.b[0].c"));
+save("case", _.camelCase("order line item") + " " + _.kebabCase("orderLineItem"));
+save("same", String(require("lodash") === _));
+
+const start = dayjs.utc("2024-01-31T10:30:00Z");
+save("format", start.format("YYYY-MM-DD HH:mm [UTC]"));
+save("month", start.add(1, "month").format("YYYY-MM-DD"));
+save("diff", String(dayjs.utc("2024-03-01").diff(dayjs.utc("2024-02-01"), "day")));
+save("start", start.startOf("month").toISOString());
+
+save("sha256", crypto.createHash("sha256").update("rocketship").digest("hex"));
+save("hmac", crypto.createHmac("sha256", "key").update("The quick brown fox jumps over the lazy dog").digest("hex"));
+save("uuid", String(/^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$/.test(crypto.randomUUID())));
+save("random", String(crypto.randomBytes(16, "hex").length));
+save("base64", btoa("user:pass") + " " + atob("dXNlcjpwYXNz"));
+`
+	rtCtx := runtime.NewContext(map[string]string{}, map[string]interface{}{}, nil)
+	if err := NewJavaScriptExecutor().Execute(context.Background(), script, rtCtx); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	want := map[string]string{
+		"paid":   "a,c",
+		"total":  "22.5",
+		"groups": `{"paid":2,"refunded":1}`,
+		"deep":   "x",
+		"case":   "orderLineItem order-line-item",
+		"same":   "true",
+		"format": "2024-01-31 10:30 UTC",
+		"month":  "2024-02-29",
+		"diff":   "29",
+		"start":  "2024-01-01T00:00:00.000Z",
+		"sha256": "0bcb7f32d2c9798f391abd22e402f7099e6505b332c9637de13e888a2609dbb5",
+		"hmac":   "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8",
+		"uuid":   "true",
+		"random": "32",
+		"base64": "dXNlcjpwYXNz user:pass",
+	}
+	for key, value := range want {
+		if rtCtx.Saved[key] != value {
+			t.Errorf("saved %s = %q, want %q", key, rtCtx.Saved[key], value)
+		}
+	}
+}
+
+func TestJavaScriptModules_Unavailable(t *testing.T) {
+	tests := []struct {
+		script string
+		want   string
+	}{
+		{`require("fs")`, `module "fs" is not available, scripts can require crypto, dayjs, lodash`},
+		{`require("crypto").createHash("sha3")`, `unsupported hash algorithm "sha3"`},
+	}
+	for _, tt := range tests {
+		rtCtx := runtime.NewContext(map[string]string{}, map[string]interface{}{}, nil)
+		err := NewJavaScriptExecutor().Execute(context.Background(), tt.script, rtCtx)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected an error containing %q, got %v", tt.script, tt.want, err)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package executors
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/dop251/goja"
+	"github.com/dop251/goja/ast"
+	"github.com/dop251/goja/file"
+	"github.com/dop251/goja/parser"
+)
+
+// opCounter is the function scripts call on each op when they have an ops
+// limit. It is read-only, so scripts can't replace it.
+const opCounter = "__rocketship_op"
+
+// insertion is text added to a script at a byte offset. Closing text, such
+// as the brace around a loop body, goes before what opens there.
+type insertion struct {
+	offset  int
+	text    string
+	closing bool
+	order   int // Nodes are visited outside in, so inner nodes come later
+}
+
+// countOps returns the script with a call to opCounter at the start of every
+// loop iteration and function call, which are the ops the limit counts. goja
+// has no hook for each instruction, and a script can't run for long without
+// looping or calling, so these bound its work. Insertions stay on their line,
+// so errors keep their lines.
+func countOps(name, script string) (string, error) {
+	program, err := parser.ParseFile(nil, name, script, 0)
+	if err != nil {
+		return "", err
+	}
+
+	var insertions []insertion
+	insert := func(offset int, text string, closing bool) {
+		insertions = append(insertions, insertion{offset, text, closing, len(insertions)})
+	}
+	call := opCounter + "();"
+	// offset turns a node position, which starts at 1, into a byte offset
+	offset := func(idx file.Idx) int { return int(idx) - 1 }
+	// atStart counts at the start of a block, after any directives such as
+	// "use strict", which only count before other statements
+	atStart := func(block *ast.BlockStatement) {
+		at := offset(block.LeftBrace) + 1
+		text := call
+		for _, statement := range block.List {
+			expression, ok := statement.(*ast.ExpressionStatement)
+			if !ok {
+				break
+			}
+			if _, ok := expression.Expression.(*ast.StringLiteral); !ok {
+				break
+			}
+			at = offset(expression.Idx1())
+			text = ";" + call
+		}
+		insert(at, text, false)
+	}
+	// inBody counts each run of a loop body, giving bodies without braces a
+	// block to hold the call
+	inBody := func(body ast.Statement) {
+		if block, ok := body.(*ast.BlockStatement); ok {
+			atStart(block)
+			return
+		}
+		insert(offset(body.Idx0()), "{"+call, false)
+		insert(offset(body.Idx1()), "}", true)
+	}
+	// inExpression counts each time an expression is evaluated
+	inExpression := func(expression ast.Expression) {
+		insert(offset(expression.Idx0()), "("+opCounter+"(), ", false)
+		insert(offset(expression.Idx1()), ")", true)
+	}
+
+	walkAST(reflect.ValueOf(program), map[uintptr]bool{}, func(node ast.Node) {
+		switch node := node.(type) {
+		case *ast.ForStatement:
+			inBody(node.Body)
+		case *ast.ForInStatement:
+			inBody(node.Body)
+		case *ast.ForOfStatement:
+			inBody(node.Body)
+		case *ast.WhileStatement:
+			inBody(node.Body)
+		case *ast.DoWhileStatement:
+			// do bodies can't be wrapped, since a semicolon may end them
+			// before the while, so the test is counted instead
+			inExpression(node.Test)
+		case *ast.FunctionLiteral:
+			atStart(node.Body)
+		case *ast.ArrowFunctionLiteral:
+			switch body := node.Body.(type) {
+			case *ast.BlockStatement:
+				atStart(body)
+			case *ast.ExpressionBody:
+				inExpression(body.Expression)
+			}
+		}
+	})
+
+	// At one offset, inner nodes close before outer ones and open after them
+	sort.Slice(insertions, func(i, j int) bool {
+		a, b := insertions[i], insertions[j]
+		switch {
+		case a.offset != b.offset:
+			return a.offset < b.offset
+		case a.closing != b.closing:
+			return a.closing
+		case a.closing:
+			return a.order > b.order
+		default:
+			return a.order < b.order
+		}
+	})
+	var counted strings.Builder
+	start := 0
+	for _, insert := range insertions {
+		counted.WriteString(script[start:insert.offset])
+		counted.WriteString(insert.text)
+		start = insert.offset
+	}
+	counted.WriteString(script[start:])
+	return counted.String(), nil
+}
+
+// walkAST calls visit on every node under value, once each, outside in.
+// goja's ast package has no walker, so this follows the fields of each node.
+// Nodes can be reached twice, e.g. through the declarations hoisted out of
+// a function, so seen holds the ones already visited.
+func walkAST(value reflect.Value, seen map[uintptr]bool, visit func(ast.Node)) {
+	switch value.Kind() {
+	case reflect.Interface:
+		if !value.IsNil() {
+			walkAST(value.Elem(), seen, visit)
+		}
+	case reflect.Pointer:
+		if value.IsNil() || seen[value.Pointer()] {
+			return
+		}
+		seen[value.Pointer()] = true
+		if node, ok := value.Interface().(ast.Node); ok {
+			visit(node)
+		}
+		walkAST(value.Elem(), seen, visit)
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			if value.Type().Field(i).IsExported() {
+				walkAST(value.Field(i), seen, visit)
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			walkAST(value.Index(i), seen, visit)
+		}
+	}
+}
+
+// setupOpCounter defines opCounter, which calls stop once a script has run
+// more ops than its limit
+func setupOpCounter(vm *goja.Runtime, limit int64, stop func()) {
+	var ops int64
+	counter := vm.ToValue(func() {
+		ops++
+		if ops == limit+1 {
+			stop()
+		}
+	})
+	_ = vm.GlobalObject().DefineDataProperty(opCounter, counter, goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_FALSE)
+}
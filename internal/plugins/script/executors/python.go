@@ -0,0 +1,178 @@
+package executors
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/plugins/script/runtime"
+)
+
+//go:embed python_runner.py
+var pythonRunner []byte
+
+// maxPythonOutput caps the script output kept for errors
+const maxPythonOutput = 4096
+
+// pythonEnvNames are the worker's environment variables Python scripts get.
+// The rest, such as the worker's cloud credentials, are kept from them.
+var pythonEnvNames = map[string]bool{
+	"PATH": true, "HOME": true, "USER": true, "TMPDIR": true, "TZ": true, "SYSTEMROOT": true,
+	"LANG": true, "LC_ALL": true, "LC_CTYPE": true,
+	"PYTHONPATH": true, "PYTHONHOME": true, "VIRTUAL_ENV": true, "PYENV_ROOT": true, "PYENV_VERSION": true,
+	"SSL_CERT_FILE": true, "SSL_CERT_DIR": true,
+}
+
+// PythonExecutor executes Python scripts with python3, giving them the same
+// state, vars and save as JavaScript
+type PythonExecutor struct {
+	Interpreter string // Defaults to python3
+}
+
+// NewPythonExecutor creates a new Python executor
+func NewPythonExecutor() *PythonExecutor {
+	return &PythonExecutor{Interpreter: "python3"}
+}
+
+// Language returns the language identifier
+func (e *PythonExecutor) Language() string {
+	return "python"
+}
+
+// ValidateScript performs basic validation on the Python script. Syntax errors
+// are reported by the interpreter, with their line.
+func (e *PythonExecutor) ValidateScript(script string) error {
+	if strings.TrimSpace(script) == "" {
+		return fmt.Errorf("python script cannot be empty")
+	}
+	return nil
+}
+
+// pythonResult is what the runner writes once the script is done
+type pythonResult struct {
+	Saved map[string]string `json:"saved"`
+	Error string            `json:"error"` // The exception, with the script line it was raised on
+	Limit string            `json:"limit"` // The limit the script ran out of, if it did
+}
+
+// Execute runs the Python code in the provided runtime context
+func (e *PythonExecutor) Execute(ctx context.Context, script string, rtCtx *runtime.Context) error {
+	limits := withDefaults(rtCtx.Limits, defaultTimeout)
+	execCtx, cancel := context.WithTimeout(ctx, limits.Timeout)
+	defer cancel()
+
+	dir, err := os.MkdirTemp("", "rocketship-python-")
+	if err != nil {
+		return fmt.Errorf("failed to create script directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	runnerPath := filepath.Join(dir, "runner.py")
+	scriptPath := filepath.Join(dir, "script.py")
+	resultPath := filepath.Join(dir, "result.json")
+	if err := os.WriteFile(runnerPath, pythonRunner, 0o600); err != nil {
+		return fmt.Errorf("failed to write python runner: %w", err)
+	}
+	if err := os.WriteFile(scriptPath, []byte(script), 0o600); err != nil {
+		return fmt.Errorf("failed to write python script: %w", err)
+	}
+
+	// The runner sets the CPU and memory limits on itself before running the script
+	input, err := json.Marshal(map[string]interface{}{
+		"state":  rtCtx.State,
+		"vars":   rtCtx.Vars,
+		"limits": map[string]int64{"cpu_seconds": int64(math.Ceil(limits.CPUTime.Seconds())), "memory_bytes": limits.Memory},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode script state: %w", err)
+	}
+
+	interpreter := e.Interpreter
+	if interpreter == "" {
+		interpreter = "python3"
+	}
+	cmd := exec.CommandContext(execCtx, interpreter, runnerPath, scriptPath, resultPath)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Env = append(pythonEnv(os.Environ()), "PYTHONUNBUFFERED=1", "PYTHONDONTWRITEBYTECODE=1")
+	if rtCtx.Egress != nil {
+		// urllib, requests and httpx all honor HTTPS_PROXY
+		proxy, err := rtCtx.Egress.StartProxy()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = proxy.Close() }()
+		cmd.Env = proxy.Env(cmd.Env)
+	}
+	cmd.WaitDelay = 2 * time.Second
+	var stderr bytes.Buffer
+	cmd.Stdout = &stderr // Prints are only kept to explain failures
+	cmd.Stderr = &stderr
+
+	runErr := cmd.Run()
+	if ctx.Err() != nil {
+		return fmt.Errorf("python execution timeout: %w", ctx.Err())
+	}
+	if execCtx.Err() != nil {
+		return fmt.Errorf("python execution timeout: %w", limits.Exceeded("timeout"))
+	}
+	var execErr *exec.Error
+	if errors.As(runErr, &execErr) {
+		return fmt.Errorf("python is not available on the worker: %w", runErr)
+	}
+
+	data, err := os.ReadFile(resultPath)
+	if err != nil && overCPULimit(cmd.ProcessState) {
+		// Going over the CPU limit kills the interpreter with SIGXCPU
+		return fmt.Errorf("python execution stopped: %w", limits.Exceeded("cpu_time"))
+	}
+	if err != nil {
+		// The runner didn't finish, e.g. the interpreter crashed or is too old
+		return fmt.Errorf("python execution error: %v: %s", runErr, tail(stderr.String()))
+	}
+	var result pythonResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("failed to read python result: %w", err)
+	}
+
+	// Values saved before a failure are kept, as with JavaScript
+	for key, value := range result.Saved {
+		rtCtx.Save(key, value)
+	}
+	if result.Limit != "" {
+		return fmt.Errorf("python execution stopped: %w", limits.Exceeded(result.Limit))
+	}
+	if result.Error != "" {
+		return fmt.Errorf("python execution error: %s", result.Error)
+	}
+	return nil
+}
+
+// pythonEnv keeps the entries of environ named in pythonEnvNames
+func pythonEnv(environ []string) []string {
+	var env []string
+	for _, entry := range environ {
+		name, _, _ := strings.Cut(entry, "=")
+		if pythonEnvNames[name] {
+			env = append(env, entry)
+		}
+	}
+	return env
+}
+
+// tail returns the end of the script's output
+func tail(output string) string {
+	output = strings.TrimSpace(output)
+	if len(output) > maxPythonOutput {
+		output = "..." + output[len(output)-maxPythonOutput:]
+	}
+	return output
+}
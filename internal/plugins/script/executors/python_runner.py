@@ -0,0 +1,85 @@
+"""Runs a script step's Python with the same state, vars and save as JavaScript.
+
+Usage: python3 python_runner.py <script.py> <result.json>, with
+{"state": {...}, "vars": {...}, "limits": {...}} on stdin. The script's own
+output goes to stdout and stderr; the saved values and any error go to
+result.json.
+"""
+import json
+import sys
+import traceback
+
+
+def _text(value):
+    """Saved values are strings, the way JavaScript's save converts them."""
+    if isinstance(value, str):
+        return value
+    if isinstance(value, bool):
+        return "true" if value else "false"
+    if value is None:
+        return "null"
+    if isinstance(value, (dict, list, tuple)):
+        return json.dumps(value)
+    return str(value)
+
+
+def _limit(limits):
+    """Limits the interpreter's CPU time and address space. Going over the CPU
+    limit kills it with SIGXCPU; going over the memory one raises MemoryError."""
+    for name, key, value in (("cpu_time", "RLIMIT_CPU", limits.get("cpu_seconds")), ("memory", "RLIMIT_AS", limits.get("memory_bytes"))):
+        if not value:
+            continue
+        try:
+            import resource  # Only on Unix
+
+            limit = getattr(resource, key)
+            resource.setrlimit(limit, (value, resource.getrlimit(limit)[1]))
+        except (ImportError, ValueError, OSError) as exc:
+            raise RuntimeError(f"the worker can't set a {name} limit for python: {exc}") from None
+
+
+def main():
+    script_path, result_path = sys.argv[1], sys.argv[2]
+    data = json.load(sys.stdin)
+    saved = {}
+
+    def save(key, value):
+        saved[str(key)] = _text(value)
+
+    namespace = {
+        "__name__": "__main__",
+        "__file__": script_path,
+        "state": data.get("state") or {},
+        "vars": data.get("vars") or {},
+        "save": save,
+    }
+
+    result = {"saved": saved}
+    limits = data.get("limits") or {}
+    try:
+        _limit(limits)
+        with open(script_path, encoding="utf-8") as f:
+            code = compile(f.read(), "script.py", "exec")
+        exec(code, namespace)
+    except AssertionError as exc:
+        result["error"] = "assertion failed: " + (str(exc) or "assert statement")
+    except MemoryError:
+        if limits.get("memory_bytes"):
+            result["limit"] = "memory"
+        else:
+            result["error"] = "MemoryError"
+    except SystemExit as exc:
+        if exc.code not in (None, 0):
+            result["error"] = "script exited with " + str(exc.code)
+    except BaseException as exc:
+        result["error"] = "".join(traceback.format_exception_only(type(exc), exc)).strip()
+        lines = [frame.lineno for frame in traceback.extract_tb(exc.__traceback__) if frame.filename == "script.py"]
+        if lines and not isinstance(exc, SyntaxError):
+            result["error"] += f" (script.py, line {lines[-1]})"
+
+    with open(result_path, "w", encoding="utf-8") as f:
+        json.dump(result, f)
+
+
+if __name__ == "__main__":
+    main()
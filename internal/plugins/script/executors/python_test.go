@@ -0,0 +1,151 @@
+package executors
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/egress"
+	"github.com/rocketship-ai/rocketship/internal/plugins/script/runtime"
+)
+
+func requirePython(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 is not installed")
+	}
+}
+
+func TestPythonExecutor_StateAndSave(t *testing.T) {
+	requirePython(t)
+	rtCtx := runtime.NewContext(
+		map[string]string{"user_name": "alice", "count": "41"},
+		map[string]interface{}{"api": map[string]interface{}{"url": "https://api.test"}, "tags": []interface{}{"a", "b"}},
+		nil,
+	)
+
+	err := NewPythonExecutor().Execute(context.Background(), `
+import hashlib
+
+print("not a saved value")
+save("display_name", state["user_name"].upper())
+save("next", int(state["count"]) + 1)
+save("active", True)
+save("user", {"name": state["user_name"], "tags": vars["tags"]})
+save("digest", hashlib.sha256(vars["api"]["url"].encode()).hexdigest()[:8])
+assert len(state["user_name"]) > 0, "name required"
+`, rtCtx)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	want := map[string]string{
+		"display_name": "ALICE",
+		"next":         "42",
+		"active":       "true",
+		"user":         `{"name": "alice", "tags": ["a", "b"]}`,
+		"digest":       "048a0d93",
+	}
+	for key, value := range want {
+		if rtCtx.Saved[key] != value {
+			t.Errorf("expected %s = %q, got %q", key, value, rtCtx.Saved[key])
+		}
+	}
+}
+
+func TestPythonExecutor_Errors(t *testing.T) {
+	requirePython(t)
+	tests := []struct {
+		name   string
+		script string
+		error  string
+	}{
+		{"assertion", "save('before', 1)\nassert state['count'] == '2', 'count must be 2'", "python execution error: assertion failed: count must be 2"},
+		{"exception", "x = 1\nvalue = state['missing']", "KeyError: 'missing' (script.py, line 2)"},
+		{"syntax error", "def broken(:\n  pass", "SyntaxError"},
+		{"exit code", "import sys\nsys.exit(3)", "script exited with 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rtCtx := runtime.NewContext(map[string]string{"count": "1"}, nil, nil)
+			err := NewPythonExecutor().Execute(context.Background(), tt.script, rtCtx)
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Fatalf("expected error containing %q, got %v", tt.error, err)
+			}
+		})
+	}
+}
+
+func TestPythonExecutor_Timeout(t *testing.T) {
+	requirePython(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	err := NewPythonExecutor().Execute(ctx, "import time\ntime.sleep(10)", runtime.NewContext(nil, nil, nil))
+	if err == nil || !strings.Contains(err.Error(), "python execution timeout") {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+}
+
+func TestPythonExecutor_MissingInterpreter(t *testing.T) {
+	executor := &PythonExecutor{Interpreter: "python-that-does-not-exist"}
+	err := executor.Execute(context.Background(), "pass", runtime.NewContext(nil, nil, nil))
+	if err == nil || !strings.Contains(err.Error(), "python is not available on the worker") {
+		t.Fatalf("expected the missing interpreter to be reported, got %v", err)
+	}
+}
+
+func TestPythonExecutor_WorkerEnvAndEgress(t *testing.T) {
+	requirePython(t)
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "worker-secret")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("pong"))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "egress.yaml")
+	if err := os.WriteFile(path, []byte("default:\n  allow: [\"127.0.0.0/8\"]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := egress.LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	egress.Configure(cfg)
+	t.Cleanup(func() { egress.Configure(nil) })
+	policy, err := egress.Resolve(egress.Scope{ProjectID: "p1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rtCtx := runtime.NewContext(map[string]string{"url": server.URL}, nil, nil)
+	rtCtx.Egress = policy
+	err = NewPythonExecutor().Execute(context.Background(), `
+import os
+import urllib.error
+import urllib.request
+
+save("secret", os.environ.get("AWS_SECRET_ACCESS_KEY", ""))
+save("allowed", urllib.request.urlopen(state["url"], timeout=5).read().decode())
+try:
+    urllib.request.urlopen("http://10.255.255.1/", timeout=5)
+    save("denied", "reached")
+except urllib.error.HTTPError as e:
+    save("denied", e.code)
+`, rtCtx)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	want := map[string]string{"secret": "", "allowed": "pong", "denied": "403"}
+	for key, value := range want {
+		if rtCtx.Saved[key] != value {
+			t.Errorf("expected %s = %q, got %q", key, value, rtCtx.Saved[key])
+		}
+	}
+}
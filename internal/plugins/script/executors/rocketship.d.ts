@@ -0,0 +1,41 @@
+// Globals of Rocketship script steps. Keep this file next to your .ts scripts
+// so editors and tsc check them the way the worker runs them.
+
+/** Values saved by earlier steps. They are always strings. */
+declare const state: Readonly<Record<string, string>>;
+
+/** The suite's config variables, as set in vars or with --var */
+declare const vars: Readonly<Record<string, any>>;
+
+/** Saves a value for later steps, which read it as {{ key }} or state.key */
+declare function save(key: string, value: string): void;
+
+/** Fails the step with message unless condition holds */
+declare function assert(condition: unknown, message: string): asserts condition;
+
+declare const console: {
+  log(...args: unknown[]): void;
+};
+
+/**
+ * Sends an HTTP request through the worker's egress policy. Scripts can send
+ * 100 requests of http or https URLs, with responses of up to 10 MB.
+ */
+declare function fetch(
+  url: string,
+  init?: { method?: string; headers?: Record<string, string>; body?: string },
+): Promise<{
+  readonly ok: boolean;
+  readonly status: number;
+  readonly statusText: string;
+  readonly url: string;
+  readonly headers: { get(name: string): string | null; has(name: string): boolean };
+  text(): Promise<string>;
+  json(): Promise<any>;
+}>;
+
+/** Loads a bundled module: lodash, dayjs or crypto */
+declare function require(name: "lodash" | "dayjs" | "crypto"): any;
+
+declare function btoa(text: string): string;
+declare function atob(encoded: string): string;
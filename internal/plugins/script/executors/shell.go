@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"os/exec"
 	"strings"
@@ -37,6 +38,9 @@ func (s *ShellExecutor) ValidateScript(script string) error {
 // Execute runs the shell script in the current working directory
 func (s *ShellExecutor) Execute(ctx context.Context, script string, rtCtx *runtime.Context) error {
 	startTime := time.Now()
+	limits := withDefaults(rtCtx.Limits, defaultShellTimeout)
+	execCtx, cancel := context.WithTimeout(ctx, limits.Timeout)
+	defer cancel()
 
 	// Process template variables in the script
 	processedScript, err := s.processVariables(script, rtCtx)
@@ -44,22 +48,28 @@ func (s *ShellExecutor) Execute(ctx context.Context, script string, rtCtx *runti
 		return fmt.Errorf("failed to process variables: %w", err)
 	}
 
-	// Create the command - try bash first, fallback to sh
-	var cmd *exec.Cmd
+	// Find a shell - try bash first, fallback to sh
+	var shell string
 	if s.commandExists("bash") {
-		cmd = exec.CommandContext(ctx, "bash", "-c", processedScript)
+		shell = "bash"
 	} else if s.commandExists("sh") {
-		cmd = exec.CommandContext(ctx, "sh", "-c", processedScript)
+		shell = "sh"
 	} else {
 		// Debug: Try absolute paths
 		if s.commandExistsAbsolute("/bin/bash") {
-			cmd = exec.CommandContext(ctx, "/bin/bash", "-c", processedScript)
+			shell = "/bin/bash"
 		} else if s.commandExistsAbsolute("/bin/sh") {
-			cmd = exec.CommandContext(ctx, "/bin/sh", "-c", processedScript)
+			shell = "/bin/sh"
 		} else {
 			return fmt.Errorf("neither bash nor sh is available on this system")
 		}
 	}
+	cmd := exec.CommandContext(execCtx, shell, "-c", processedScript)
+	if ulimits := s.ulimits(limits); ulimits != "" {
+		// A first shell sets the limits, which its children inherit, and
+		// replaces itself with one running the script, so its lines don't move
+		cmd = exec.CommandContext(execCtx, shell, "-c", ulimits+`exec "$0" -c "$1"`, shell, processedScript)
+	}
 
 	// Set up environment with runtime state and current environment
 	cmd.Env = s.buildEnvironment(rtCtx)
@@ -102,6 +112,17 @@ func (s *ShellExecutor) Execute(ctx context.Context, script string, rtCtx *runti
 	err = cmd.Wait()
 	duration := time.Since(startTime)
 
+	if ctx.Err() != nil {
+		return fmt.Errorf("shell execution timeout: %w", ctx.Err())
+	}
+	if execCtx.Err() != nil {
+		return fmt.Errorf("shell execution timeout: %w", limits.Exceeded("timeout"))
+	}
+	if err != nil && overCPULimit(cmd.ProcessState) {
+		// Going over the CPU limit kills the shell with SIGXCPU
+		return fmt.Errorf("shell execution stopped: %w", limits.Exceeded("cpu_time"))
+	}
+
 	// Determine exit code
 	exitCode := 0
 	if err != nil {
@@ -162,6 +183,20 @@ func (s *ShellExecutor) processVariables(script string, rtCtx *runtime.Context)
 	return result, nil
 }
 
+// ulimits returns the commands setting the script's CPU and memory limits.
+// Going over the memory limit makes allocations fail, which commands report
+// in their own way.
+func (s *ShellExecutor) ulimits(limits runtime.Limits) string {
+	var commands string
+	if limits.CPUTime > 0 {
+		commands += fmt.Sprintf("ulimit -S -t %d && ", int64(math.Ceil(limits.CPUTime.Seconds())))
+	}
+	if limits.Memory > 0 {
+		commands += fmt.Sprintf("ulimit -v %d && ", limits.Memory>>10)
+	}
+	return commands
+}
+
 // buildEnvironment creates the environment for the shell command
 func (s *ShellExecutor) buildEnvironment(rtCtx *runtime.Context) []string {
 	// Start with current environment
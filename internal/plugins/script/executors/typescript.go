@@ -0,0 +1,53 @@
+package executors
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	"github.com/rocketship-ai/rocketship/internal/plugins/script/runtime"
+)
+
+// TypeDeclarations declares the globals scripts get, for editors and tsc to
+// check TypeScript scripts against
+//
+//go:embed rocketship.d.ts
+var TypeDeclarations string
+
+// TypeScriptExecutor executes TypeScript by erasing its types and running the
+// JavaScript left behind with the JavaScript executor
+type TypeScriptExecutor struct {
+	js *JavaScriptExecutor
+}
+
+// NewTypeScriptExecutor creates a new TypeScript executor
+func NewTypeScriptExecutor() *TypeScriptExecutor {
+	return &TypeScriptExecutor{js: NewJavaScriptExecutor()}
+}
+
+// Language returns the language identifier
+func (e *TypeScriptExecutor) Language() string {
+	return "typescript"
+}
+
+// ValidateScript checks that the script's types can be erased and that the
+// JavaScript left behind parses
+func (e *TypeScriptExecutor) ValidateScript(script string) error {
+	js, err := stripTypes(script)
+	if err != nil {
+		return fmt.Errorf("typescript syntax error: %w", err)
+	}
+	if _, err := compileScript("validation", js, false); err != nil {
+		return fmt.Errorf("typescript syntax error: %w", err)
+	}
+	return nil
+}
+
+// Execute runs the TypeScript code in the provided runtime context
+func (e *TypeScriptExecutor) Execute(ctx context.Context, script string, rtCtx *runtime.Context) error {
+	js, err := stripTypes(script)
+	if err != nil {
+		return fmt.Errorf("typescript syntax error: %w", err)
+	}
+	return e.js.Execute(ctx, js, rtCtx)
+}
@@ -0,0 +1,215 @@
+package executors
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/rocketship-ai/rocketship/internal/plugins/script/runtime"
+)
+
+// squeeze collapses the spaces the stripper leaves, keeping the lines
+func squeeze(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestStripTypes(t *testing.T) {
+	tests := []struct {
+		name string
+		ts   string
+		js   string
+	}{
+		{
+			name: "variable annotations",
+			ts:   "let total: number = 0, label: string | null = null;\nconst ids!: string[];\nconst { a, b }: Pair = pair;",
+			js:   "let total = 0, label = null;\nconst ids ;\nconst { a, b } = pair;",
+		},
+		{
+			name: "functions",
+			ts:   "function sum<T extends number>(items: T[], start?: number): number {\n  return items.reduce((a: number, b) => a + b, start ?? 0);\n}",
+			js:   "function sum (items , start ) {\nreturn items.reduce((a , b) => a + b, start ?? 0);\n}",
+		},
+		{
+			name: "arrow functions",
+			ts:   "const parse = async (raw: string, { strict = true }: Options = {}): Promise<Order> => JSON.parse(raw);\nconst id = <T,>(x: T): T => x;",
+			js:   "const parse = async (raw , { strict = true } = {}) => JSON.parse(raw);\nconst id = (x ) => x;",
+		},
+		{
+			name: "type predicates and overloads",
+			ts:   "function isOrder(x: unknown): x is Order;\nfunction isOrder(x: unknown): x is Order { return x != null; }",
+			js:   ";\nfunction isOrder(x ) { return x != null; }",
+		},
+		{
+			name: "interfaces and type aliases",
+			ts:   "interface Order extends Base<{ id: string }> {\n  id: string;\n  items: Item[];\n}\ntype Status = \"paid\" | \"refunded\";\ntype Pick2<T, K extends keyof T> = { [P in K]: T[P] }\nconst s: Status = \"paid\";",
+			js:   ";\n\n\n\n;\n;\nconst s = \"paid\";",
+		},
+		{
+			name: "assertions",
+			ts:   "const order = JSON.parse(raw) as Order;\nconst total = (order as any).total as number;\nconst tags = [\"a\", \"b\"] as const;\nconst cfg = { retries: 3 } satisfies Config;\nconst name = user!.name!;\nif (a !== b) { ok(!a); }",
+			js:   "const order = JSON.parse(raw) ;\nconst total = (order ).total ;\nconst tags = [\"a\", \"b\"] ;\nconst cfg = { retries: 3 } ;\nconst name = user .name ;\nif (a !== b) { ok(!a); }",
+		},
+		{
+			name: "generic calls and comparisons",
+			ts:   "const m = new Map<string, Array<number>>();\nconst v = parse<Order>(raw);\nif (a < b && c > (d)) {}\nfor (let i: number = 0; i < n; i++) {}",
+			js:   "const m = new Map ();\nconst v = parse (raw);\nif (a < b && c > (d)) {}\nfor (let i = 0; i < n; i++) {}",
+		},
+		{
+			name: "classes",
+			ts: `abstract class Base<T> implements Named, Other {
+  private readonly id: string = "x";
+  name?: string;
+  static count: number
+  declare extra: number;
+  [key: string]: unknown;
+  abstract describe(): string;
+  constructor(id: string) { super(); }
+  get label(): string { return this.name!; }
+  render<U>(value: U, this_: T): void {}
+}`,
+			js: `class Base {
+id = "x";
+name ;
+static count
+;
+;
+;
+constructor(id ) { super(); }
+get label() { return this.name ; }
+render (value , this_ ) {}
+}`,
+		},
+		{
+			name: "declarations and type imports",
+			ts:   "declare const API_URL: string;\ndeclare global {\n  interface Window { app: App }\n}\nimport type { Order } from \"./order\";\nexport interface Item { sku: string }",
+			js:   ";\n;\n\n\n;\n;",
+		},
+		{
+			name: "strings, templates and regexes keep their text",
+			ts:   "const re = /<T>: (a|b)/g;\nconst msg = `total: ${format(total as number)}: ${`<${tag}>`}`;\nconst s = \"a: string\";",
+			js:   "const re = /<T>: (a|b)/g;\nconst msg = `total: ${format(total )}: ${`<${tag}>`}`;\nconst s = \"a: string\";",
+		},
+		{
+			name: "object literals, ternaries and labels",
+			ts:   "const o = { a: 1, b: x ? y : z, m(n: number): number { return n; }, f: (q: string) => q };\nouter: for (const k of keys) { switch (k) { case 1: break outer; } }",
+			js:   "const o = { a: 1, b: x ? y : z, m(n ) { return n; }, f: (q ) => q };\nouter: for (const k of keys) { switch (k) { case 1: break outer; } }",
+		},
+		{
+			name: "this parameters and catch clauses",
+			ts:   "function handler(this: Window, e: Event) {}\ntry { f(); } catch (err: unknown) { p.catch((e: Error) => e); }",
+			js:   "function handler( e ) {}\ntry { f(); } catch (err ) { p.catch((e ) => e); }",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			js, err := stripTypes(tt.ts)
+			if err != nil {
+				t.Fatalf("stripTypes failed: %v", err)
+			}
+			if len(js) != len(tt.ts) {
+				t.Errorf("stripTypes changed the length from %d to %d", len(tt.ts), len(js))
+			}
+			if got := squeeze(js); got != squeeze(tt.js) {
+				t.Errorf("stripTypes() =\n%s\nwant\n%s", got, squeeze(tt.js))
+			}
+		})
+	}
+}
+
+func TestStripTypes_Unsupported(t *testing.T) {
+	tests := []struct {
+		ts   string
+		want string
+	}{
+		{"let a = 1;\nenum Color { Red, Green }", "line 2: enums are not supported"},
+		{"const enum Color { Red }", "line 1: enums are not supported"},
+		{"namespace Shop {\n}", "line 1: namespaces are not supported"},
+		{"class A {\n  constructor(private id: string) {}\n}", "line 2: parameter properties are not supported"},
+		{"@sealed\nclass A {}", "line 1: decorators are not supported"},
+		{"import { v4 } from \"uuid\";", "line 1: imports are not supported"},
+		{"const a = (1;", "line 1: ( is never closed"},
+		{"const s = \"open", "line 1: string is never closed"},
+	}
+	for _, tt := range tests {
+		_, err := stripTypes(tt.ts)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("stripTypes(%q) error = %v, want %q", tt.ts, err, tt.want)
+		}
+	}
+}
+
+func TestTypeDeclarations(t *testing.T) {
+	js, err := stripTypes(TypeDeclarations)
+	if err != nil {
+		t.Fatalf("the declarations don't parse: %v", err)
+	}
+	for _, line := range strings.Split(js, "\n") {
+		if code := strings.Trim(strings.TrimSpace(line), ";"); code != "" && !strings.HasPrefix(code, "//") && !strings.HasPrefix(code, "/*") && !strings.HasPrefix(code, "*") {
+			t.Errorf("declarations left code behind: %q", line)
+		}
+	}
+}
+
+func TestTypeScriptExecutor(t *testing.T) {
+	executor := NewTypeScriptExecutor()
+	script := `
+interface LineItem {
+  sku: string;
+  price: number;
+  quantity: number;
+}
+
+const items: LineItem[] = JSON.parse(state.cart);
+const total = items.reduce((sum: number, item: LineItem): number => sum + item.price * item.quantity, 0);
+
+function currency<T extends { currency?: string }>(config: T): string {
+  return config.currency ?? "EUR";
+}
+
+assert(items.length > 0, "cart is empty");
+save("total", total.toFixed(2) as string);
+save("currency", currency(vars.shop as { currency?: string }));
+`
+	if err := executor.ValidateScript(script); err != nil {
+		t.Fatalf("ValidateScript failed: %v", err)
+	}
+
+	rtCtx := runtime.NewContext(
+		map[string]string{"cart": `[{"sku":"a","price":2.5,"quantity":2},{"sku":"b","price":10,"quantity":1}]`},
+		map[string]interface{}{"shop": map[string]interface{}{"currency": "USD"}},
+		nil,
+	)
+	if err := executor.Execute(context.Background(), script, rtCtx); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if rtCtx.Saved["total"] != "15.00" || rtCtx.Saved["currency"] != "USD" {
+		t.Errorf("unexpected saved values: %v", rtCtx.Saved)
+	}
+}
+
+func TestTypeScriptExecutor_Errors(t *testing.T) {
+	executor := NewTypeScriptExecutor()
+
+	err := executor.ValidateScript("const a: number = 1;\nenum Color { Red }")
+	if err == nil || !strings.Contains(err.Error(), "typescript syntax error: line 2: enums are not supported") {
+		t.Errorf("expected an enum error, got %v", err)
+	}
+
+	// Errors point at the line as written, since erasing types keeps the lines
+	err = executor.ValidateScript("type Id = string;\nconst id: Id = ;")
+	if err == nil || !strings.Contains(err.Error(), "validation: Line 2:") {
+		t.Errorf("expected a syntax error on line 2, got %v", err)
+	}
+
+	rtCtx := runtime.NewContext(map[string]string{}, map[string]interface{}{}, nil)
+	err = executor.Execute(context.Background(), `const n: number = 0;
+assert(n > 0, "n must be positive");`, rtCtx)
+	if err == nil || !strings.Contains(err.Error(), "n must be positive") {
+		t.Errorf("expected the assertion to fail, got %v", err)
+	}
+}
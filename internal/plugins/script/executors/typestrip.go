@@ -0,0 +1,1239 @@
+package executors
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TypeScript is run by erasing its types: annotations, interfaces, type
+// aliases, generics and the other syntax that only exists for the type
+// checker are replaced with spaces, and the JavaScript left behind runs as it
+// is. Lines and columns don't move, so errors point at the script as written.
+// Syntax that would need code generated for it (enums, namespaces, parameter
+// properties and decorators) is rejected.
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokRegex
+	tokTemplate       // A template without substitutions
+	tokTemplateHead   // `...${
+	tokTemplateMiddle // }...${
+	tokTemplateTail   // }...`
+	tokPunct
+)
+
+type token struct {
+	kind       tokenKind
+	text       string
+	start, end int // Byte offsets in the source
+	line       int
+	newline    bool // A line break comes before the token
+}
+
+// reserved are the words that can't end an expression
+var reserved = map[string]bool{
+	"await": true, "break": true, "case": true, "catch": true, "class": true, "const": true,
+	"continue": true, "debugger": true, "default": true, "delete": true, "do": true,
+	"else": true, "export": true, "extends": true, "finally": true, "for": true,
+	"function": true, "if": true, "import": true, "in": true, "instanceof": true,
+	"let": true, "new": true, "of": true, "return": true, "switch": true, "throw": true,
+	"try": true, "typeof": true, "var": true, "void": true, "while": true, "with": true,
+	"yield": true,
+}
+
+// puncts are the punctuators longer than one character. < and > are always
+// single characters, so nested type arguments can close with >>.
+var puncts = []string{
+	"...", "===", "!==", "**=", "&&=", "||=", "??=",
+	"=>", "==", "!=", "+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=",
+	"&&", "||", "??", "?.", "++", "--", "**",
+}
+
+// endsExpression reports whether an expression can end with t, which tells a
+// regular expression from a division and a type from a comparison
+func endsExpression(t token) bool {
+	switch t.kind {
+	case tokIdent:
+		return !reserved[t.text]
+	case tokNumber, tokString, tokRegex, tokTemplate, tokTemplateTail:
+		return true
+	case tokPunct:
+		return t.text == ")" || t.text == "]" || t.text == "}"
+	}
+	return false
+}
+
+// scanner splits a script into tokens
+type scanner struct {
+	src    string
+	pos    int
+	line   int
+	tokens []token
+	braces []bool // Open braces, true for a template's ${
+}
+
+func tokenize(src string) ([]token, error) {
+	s := &scanner{src: src, line: 1}
+	for {
+		newline, err := s.skipSpace()
+		if err != nil {
+			return nil, err
+		}
+		if s.pos >= len(src) {
+			return s.tokens, nil
+		}
+
+		t := token{start: s.pos, line: s.line, newline: newline}
+		if t.kind, err = s.next(); err != nil {
+			return nil, err
+		}
+		t.end = s.pos
+		t.text = src[t.start:t.end]
+		s.tokens = append(s.tokens, t)
+	}
+}
+
+// skipSpace skips whitespace and comments, and reports whether they held a line break
+func (s *scanner) skipSpace() (bool, error) {
+	newline := false
+	if s.pos == 0 && strings.HasPrefix(s.src, "#!") {
+		s.skipLine()
+	}
+	for s.pos < len(s.src) {
+		rest := s.src[s.pos:]
+		switch {
+		case rest[0] == '\n':
+			s.line++
+			newline = true
+			s.pos++
+		case rest[0] == ' ' || rest[0] == '\t' || rest[0] == '\r' || rest[0] == '\v' || rest[0] == '\f':
+			s.pos++
+		case strings.HasPrefix(rest, "//"):
+			s.skipLine()
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				return false, fmt.Errorf("line %d: comment is never closed", s.line)
+			}
+			comment := rest[:end+4]
+			if lines := strings.Count(comment, "\n"); lines > 0 {
+				s.line += lines
+				newline = true
+			}
+			s.pos += len(comment)
+		case rest[0] >= utf8.RuneSelf:
+			r, size := utf8.DecodeRuneInString(rest)
+			if !unicode.IsSpace(r) && r != '\ufeff' {
+				return newline, nil
+			}
+			if r == '\u2028' || r == '\u2029' {
+				newline = true
+			}
+			s.pos += size
+		default:
+			return newline, nil
+		}
+	}
+	return newline, nil
+}
+
+func (s *scanner) skipLine() {
+	if end := strings.IndexByte(s.src[s.pos:], '\n'); end >= 0 {
+		s.pos += end
+	} else {
+		s.pos = len(s.src)
+	}
+}
+
+// next scans the token at the current position
+func (s *scanner) next() (tokenKind, error) {
+	c := s.src[s.pos]
+	r, _ := utf8.DecodeRuneInString(s.src[s.pos:])
+	switch {
+	case isIdentStart(r) || c == '#' || c == '\\':
+		s.pos++
+		if c == '\\' {
+			s.pos++
+		}
+		s.identRest()
+		return tokIdent, nil
+	case isDigit(c) || (c == '.' && s.pos+1 < len(s.src) && isDigit(s.src[s.pos+1])):
+		s.number()
+		return tokNumber, nil
+	case c == '"' || c == '\'':
+		return tokString, s.quoted(c)
+	case c == '`':
+		s.pos++
+		return s.template(tokTemplate, tokTemplateHead)
+	case c == '}' && len(s.braces) > 0 && s.braces[len(s.braces)-1]:
+		s.braces = s.braces[:len(s.braces)-1]
+		s.pos++
+		return s.template(tokTemplateTail, tokTemplateMiddle)
+	case c == '/' && (len(s.tokens) == 0 || !endsExpression(s.tokens[len(s.tokens)-1])):
+		return tokRegex, s.regex()
+	}
+
+	switch c {
+	case '{':
+		s.braces = append(s.braces, false)
+	case '}':
+		if len(s.braces) > 0 {
+			s.braces = s.braces[:len(s.braces)-1]
+		}
+	}
+	rest := s.src[s.pos:]
+	for _, p := range puncts {
+		if strings.HasPrefix(rest, p) && (p != "?." || len(rest) < 3 || !isDigit(rest[2])) {
+			s.pos += len(p)
+			return tokPunct, nil
+		}
+	}
+	s.pos++
+	return tokPunct, nil
+}
+
+func (s *scanner) identRest() {
+	for s.pos < len(s.src) {
+		if s.src[s.pos] == '\\' {
+			s.pos += 2
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s.src[s.pos:])
+		if !isIdentStart(r) && !unicode.IsDigit(r) && r != '\u200c' && r != '\u200d' {
+			return
+		}
+		s.pos += size
+	}
+}
+
+func (s *scanner) number() {
+	hex := strings.HasPrefix(s.src[s.pos:], "0x") || strings.HasPrefix(s.src[s.pos:], "0X")
+	dot := false
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		switch {
+		case c == '.' && !dot && !hex:
+			dot = true
+		case (c == '+' || c == '-') && !hex && (s.src[s.pos-1] == 'e' || s.src[s.pos-1] == 'E'):
+		case isDigit(c) || c == '_' || unicode.IsLetter(rune(c)):
+		default:
+			return
+		}
+		s.pos++
+	}
+}
+
+func (s *scanner) quoted(quote byte) error {
+	line := s.line
+	for s.pos++; s.pos < len(s.src); s.pos++ {
+		switch s.src[s.pos] {
+		case '\\':
+			s.pos++
+			if s.pos < len(s.src) && s.src[s.pos] == '\n' {
+				s.line++
+			}
+		case '\n':
+			return fmt.Errorf("line %d: string is never closed", line)
+		case quote:
+			s.pos++
+			return nil
+		}
+	}
+	return fmt.Errorf("line %d: string is never closed", line)
+}
+
+// template scans the text of a template up to its end or its next ${
+func (s *scanner) template(end, substitution tokenKind) (tokenKind, error) {
+	line := s.line
+	for s.pos < len(s.src) {
+		switch s.src[s.pos] {
+		case '\\':
+			s.pos++
+			if s.pos < len(s.src) && s.src[s.pos] == '\n' {
+				s.line++
+			}
+		case '\n':
+			s.line++
+		case '`':
+			s.pos++
+			return end, nil
+		case '$':
+			if strings.HasPrefix(s.src[s.pos:], "${") {
+				s.pos += 2
+				s.braces = append(s.braces, true)
+				return substitution, nil
+			}
+		}
+		s.pos++
+	}
+	return end, fmt.Errorf("line %d: template is never closed", line)
+}
+
+func (s *scanner) regex() error {
+	class := false
+	for s.pos++; s.pos < len(s.src); s.pos++ {
+		switch s.src[s.pos] {
+		case '\\':
+			s.pos++
+		case '[':
+			class = true
+		case ']':
+			class = false
+		case '\n':
+			return fmt.Errorf("line %d: regular expression is never closed", s.line)
+		case '/':
+			if !class {
+				s.pos++
+				s.identRest()
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("line %d: regular expression is never closed", s.line)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r)
+}
+
+// stripper erases the types from a tokenized script
+type stripper struct {
+	out      []byte
+	tokens   []token
+	match    []int        // Index of the bracket closing or opening each bracket
+	typeArgs map[int]bool // Indexes of the > closing erased type arguments
+	headers  map[int]bool // Indexes of the ) closing an if, while, for or switch head
+}
+
+// stripTypes turns TypeScript into the JavaScript it compiles to
+func stripTypes(src string) (string, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return "", err
+	}
+	s := &stripper{
+		out:      []byte(src),
+		tokens:   tokens,
+		match:    make([]int, len(tokens)),
+		typeArgs: make(map[int]bool),
+		headers:  make(map[int]bool),
+	}
+	if err := s.pair(); err != nil {
+		return "", err
+	}
+	if err := s.walk(0, len(tokens)); err != nil {
+		return "", err
+	}
+	return string(s.out), nil
+}
+
+// pair matches the brackets and the parts of templates
+func (s *stripper) pair() error {
+	closing := map[string]string{")": "(", "]": "[", "}": "{"}
+	var open []int
+	for i, t := range s.tokens {
+		s.match[i] = -1
+		switch {
+		case t.kind == tokTemplateHead || (t.kind == tokPunct && (t.text == "(" || t.text == "[" || t.text == "{")):
+			open = append(open, i)
+		case t.kind == tokTemplateMiddle:
+			if len(open) == 0 || s.tokens[open[len(open)-1]].kind != tokTemplateHead {
+				return s.errorf(i, "unexpected }")
+			}
+		case t.kind == tokTemplateTail || (t.kind == tokPunct && closing[t.text] != ""):
+			if len(open) == 0 {
+				return s.errorf(i, "unexpected %s", t.text[:1])
+			}
+			top := open[len(open)-1]
+			if (t.kind == tokTemplateTail) != (s.tokens[top].kind == tokTemplateHead) ||
+				(t.kind == tokPunct && s.tokens[top].text != closing[t.text]) {
+				return s.errorf(i, "unexpected %s", t.text[:1])
+			}
+			s.match[top], s.match[i] = i, top
+			open = open[:len(open)-1]
+		}
+	}
+	if len(open) > 0 {
+		return s.errorf(open[len(open)-1], "%s is never closed", s.tokens[open[len(open)-1]].text[:1])
+	}
+	return nil
+}
+
+func (s *stripper) errorf(i int, format string, args ...interface{}) error {
+	line := 1
+	if i < len(s.tokens) {
+		line = s.tokens[i].line
+	} else if len(s.tokens) > 0 {
+		line = s.tokens[len(s.tokens)-1].line
+	}
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// tok returns the token at i, or an EOF token past the end
+func (s *stripper) tok(i int) token {
+	if i < 0 || i >= len(s.tokens) {
+		return token{kind: tokEOF}
+	}
+	return s.tokens[i]
+}
+
+// is reports whether the token at i is the punctuator or word text
+func (s *stripper) is(i int, text string) bool {
+	t := s.tok(i)
+	return (t.kind == tokPunct || t.kind == tokIdent) && t.text == text
+}
+
+// keyword reports whether the token at i is the word text, and not a property
+// with its name: promise.catch
+func (s *stripper) keyword(i int, text string) bool {
+	return s.is(i, text) && !s.is(i-1, ".") && !s.is(i-1, "?.")
+}
+
+func (s *stripper) isIdent(i int) bool {
+	return s.tok(i).kind == tokIdent
+}
+
+func (s *stripper) endsExpression(i int) bool {
+	return endsExpression(s.tok(i)) || s.typeArgs[i]
+}
+
+// blank replaces the tokens in [i, j) with spaces, keeping line breaks
+func (s *stripper) blank(i, j int) {
+	if i >= j {
+		return
+	}
+	for p := s.tokens[i].start; p < s.tokens[j-1].end; p++ {
+		if s.out[p] != '\n' && s.out[p] != '\r' {
+			s.out[p] = ' '
+		}
+	}
+}
+
+// erase blanks a whole statement or class member, leaving a semicolon so the
+// code around it still parses the same
+func (s *stripper) erase(i, j int) {
+	s.blank(i, j)
+	if i < j {
+		s.out[s.tokens[i].start] = ';'
+	}
+}
+
+// walk strips the types from the tokens in [i, end)
+func (s *stripper) walk(i, end int) error {
+	declaring := false // In a let, const or var, whose next declarators may have types
+	for i < end {
+		t := s.tokens[i]
+		next := i + 1
+		var err error
+		switch t.kind {
+		case tokTemplateHead:
+			err = s.walk(i+1, s.match[i])
+			next = s.match[i] + 1
+		case tokIdent:
+			if (t.text == "let" || t.text == "const" || t.text == "var") && !s.is(i-1, ".") {
+				if t.text == "const" && s.is(i+1, "enum") {
+					return s.errorf(i, "enums are not supported, use an object instead")
+				}
+				if s.isIdent(i+1) || s.is(i+1, "{") || s.is(i+1, "[") {
+					declaring = true
+					next, err = s.declarator(i + 1)
+					break
+				}
+			}
+			next, err = s.word(i)
+		case tokPunct:
+			switch t.text {
+			case "{", "[":
+				err = s.walk(i+1, s.match[i])
+				next = s.match[i] + 1
+			case "(":
+				next, err = s.paren(i)
+			case "<":
+				next, err = s.angle(i)
+			case ";":
+				declaring = false
+			case ",":
+				if declaring {
+					next, err = s.declarator(i + 1)
+				}
+			case "!":
+				// Non-null assertion: user!.name
+				if !t.newline && s.endsExpression(i-1) && !s.headers[i-1] {
+					s.blank(i, i+1)
+				}
+			case "@":
+				err = s.errorf(i, "decorators are not supported")
+			}
+		}
+		if err != nil {
+			return err
+		}
+		i = next
+	}
+	return nil
+}
+
+// declarator strips the type from the variable declared at i
+func (s *stripper) declarator(i int) (int, error) {
+	switch {
+	case s.is(i, "{") || s.is(i, "["):
+		if err := s.walk(i+1, s.match[i]); err != nil {
+			return i, err
+		}
+		i = s.match[i] + 1
+	case s.isIdent(i):
+		i++
+	default:
+		return i, nil
+	}
+	if s.is(i, "!") && s.is(i+1, ":") {
+		s.blank(i, i+1)
+		i++
+	}
+	if s.is(i, ":") {
+		return s.annotation(i)
+	}
+	return i, nil
+}
+
+// annotation blanks the : Type at i
+func (s *stripper) annotation(i int) (int, error) {
+	end, ok := s.skipType(i + 1)
+	if !ok {
+		return i, s.errorf(i, "can't read the type after :")
+	}
+	s.blank(i, end)
+	return end, nil
+}
+
+// word handles the keywords that introduce types, and the ones around them
+func (s *stripper) word(i int) (int, error) {
+	t := s.tokens[i]
+	if s.is(i-1, ".") || s.is(i-1, "?.") {
+		return i + 1, nil
+	}
+	switch t.text {
+	case "function":
+		return s.function(i)
+	case "class":
+		return s.class(i)
+	case "as", "satisfies":
+		// user as Admin, config satisfies Config, ["a", "b"] as const
+		if !t.newline && s.endsExpression(i-1) {
+			end, ok := i+2, s.is(i+1, "const")
+			if !ok {
+				end, ok = s.skipType(i + 1)
+			}
+			if !ok {
+				return i, s.errorf(i, "can't read the type after %s", t.text)
+			}
+			s.blank(i, end)
+			return end, nil
+		}
+	case "interface":
+		if s.isIdent(i + 1) {
+			body := s.body(i + 2)
+			if body < 0 {
+				return i, s.errorf(i, "interface has no body")
+			}
+			s.erase(i, s.match[body]+1)
+			return s.match[body] + 1, nil
+		}
+	case "type":
+		if s.isIdent(i+1) && !s.tok(i+1).newline && (s.is(i+2, "=") || s.is(i+2, "<")) {
+			k := i + 2
+			if s.is(k, "<") {
+				k, _ = s.skipAngles(k)
+			}
+			if !s.is(k, "=") {
+				return i, s.errorf(i, "type alias has no =")
+			}
+			end, ok := s.skipType(k + 1)
+			if !ok {
+				return i, s.errorf(k, "can't read the type after =")
+			}
+			if s.is(end, ";") {
+				end++
+			}
+			s.erase(i, end)
+			return end, nil
+		}
+	case "declare":
+		next := s.tok(i + 1)
+		if next.kind == tokIdent && !next.newline && declarable[next.text] {
+			end := s.statementEnd(i + 1)
+			if bodied[next.text] {
+				body := s.body(i + 2)
+				if body < 0 {
+					return i, s.errorf(i, "declaration has no body")
+				}
+				end = s.match[body] + 1
+			}
+			s.erase(i, end)
+			return end, nil
+		}
+	case "abstract":
+		if s.is(i+1, "class") && !s.tok(i+1).newline {
+			s.blank(i, i+1)
+		}
+	case "enum":
+		if s.isIdent(i+1) && s.is(i+2, "{") {
+			return i, s.errorf(i, "enums are not supported, use an object instead")
+		}
+	case "namespace", "module":
+		if (s.isIdent(i+1) || s.tok(i+1).kind == tokString) && !s.tok(i+1).newline {
+			return i, s.errorf(i, "namespaces are not supported")
+		}
+	case "import":
+		switch {
+		case s.is(i+1, "type") && !s.is(i+2, "("):
+			end := s.statementEnd(i)
+			s.erase(i, end)
+			return end, nil
+		case s.isIdent(i+1) || s.is(i+1, "{") || s.is(i+1, "*") || s.tok(i+1).kind == tokString:
+			return i, s.errorf(i, "imports are not supported, each script runs on its own")
+		}
+	case "export":
+		switch {
+		case s.is(i+1, "type") && s.is(i+2, "{"):
+			end := s.statementEnd(i)
+			s.erase(i, end)
+			return end, nil
+		case s.is(i+1, "interface") || s.is(i+1, "declare") || (s.is(i+1, "type") && s.isIdent(i+2)):
+			s.blank(i, i+1)
+		}
+	}
+	return i + 1, nil
+}
+
+// declarable are the words that can follow declare
+var declarable = map[string]bool{
+	"const": true, "let": true, "var": true, "function": true, "async": true, "class": true,
+	"abstract": true, "enum": true, "namespace": true, "module": true, "global": true,
+	"type": true, "interface": true,
+}
+
+// bodied are the declarations that end with a body in braces
+var bodied = map[string]bool{
+	"class": true, "abstract": true, "enum": true, "namespace": true, "module": true,
+	"global": true, "interface": true,
+}
+
+// body returns the index of the { opening the body of a declaration whose
+// header starts at i, or -1
+func (s *stripper) body(i int) int {
+	for i < len(s.tokens) {
+		switch {
+		case s.is(i, "{"):
+			return i
+		case s.is(i, "<"):
+			end, ok := s.skipAngles(i)
+			if !ok {
+				return -1
+			}
+			i = end
+		case s.is(i, "(") || s.is(i, "["):
+			i = s.match[i] + 1
+		case s.is(i, ";") || s.is(i, ")") || s.is(i, "]") || s.is(i, "}"):
+			return -1
+		default:
+			i++
+		}
+	}
+	return -1
+}
+
+// statementEnd returns the index after the statement or class member starting
+// at i: past its semicolon, or where a line break ends it
+func (s *stripper) statementEnd(i int) int {
+	for j := i; j < len(s.tokens); j++ {
+		t := s.tokens[j]
+		if j > i && t.newline && (s.endsExpression(j-1) || s.is(j-1, ">")) && startsStatement(t) {
+			return j
+		}
+		switch {
+		case t.kind == tokTemplateHead || s.is(j, "(") || s.is(j, "[") || s.is(j, "{"):
+			j = s.match[j]
+		case s.is(j, ";"):
+			return j + 1
+		case s.is(j, ")") || s.is(j, "]") || s.is(j, "}"):
+			return j
+		}
+	}
+	return len(s.tokens)
+}
+
+// endFrom returns the index after a statement or class member whose remaining
+// tokens start at i, which may be the next statement's
+func (s *stripper) endFrom(i int) int {
+	if t := s.tok(i); t.newline && startsStatement(t) {
+		return i
+	}
+	return s.statementEnd(i)
+}
+
+// startsStatement reports whether a line starting with t starts a new
+// statement rather than continuing the one before
+func startsStatement(t token) bool {
+	switch t.kind {
+	case tokIdent:
+		return t.text != "in" && t.text != "instanceof" && t.text != "as" && t.text != "satisfies"
+	case tokString, tokNumber:
+		return true
+	case tokPunct:
+		return t.text == "!" || t.text == "++" || t.text == "--" || t.text == "{" || t.text == "@"
+	}
+	return false
+}
+
+// paren handles the ( at i: the head of a control statement, the parameters
+// of an arrow function or a method, or an ordinary group or call
+func (s *stripper) paren(i int) (int, error) {
+	end := s.match[i]
+	prev := i - 1
+	switch {
+	case s.keyword(prev, "if") || s.keyword(prev, "while") || s.keyword(prev, "for") || s.keyword(prev, "switch") ||
+		s.keyword(prev, "with") || (s.is(prev, "await") && s.keyword(prev-1, "for")):
+		s.headers[end] = true
+		return end + 1, s.walk(i+1, end)
+	case s.keyword(prev, "catch"):
+		return end + 1, s.parameters(i)
+	case s.is(end+1, "=>"):
+		return end + 1, s.parameters(i)
+	}
+
+	callee := s.endsExpression(prev)
+	if s.is(end+1, ":") {
+		// A return type: (a): T => a, or m(): T { }
+		if typeEnd, ok := s.skipReturnType(end + 2); ok {
+			arrow := s.is(typeEnd, "=>") && (!callee || s.is(prev, "async") || s.typeArgs[prev])
+			method := s.is(typeEnd, "{") && !s.tok(typeEnd).newline && callee
+			if arrow || method {
+				if err := s.parameters(i); err != nil {
+					return i, err
+				}
+				s.blank(end+1, typeEnd)
+				return typeEnd, nil
+			}
+		}
+	}
+	if callee && s.is(end+1, "{") && !s.tok(end+1).newline {
+		// An object literal's method: { total(items: Item[]) { } }
+		return end + 1, s.parameters(i)
+	}
+	return end + 1, s.walk(i+1, end)
+}
+
+// angle handles the < at i when it opens the type arguments of a call, or the
+// type parameters of an arrow function
+func (s *stripper) angle(i int) (int, error) {
+	if s.endsExpression(i - 1) {
+		end, ok := s.typeArguments(i)
+		if ok && (s.is(end, "(") || s.tok(end).kind == tokTemplate || s.tok(end).kind == tokTemplateHead) {
+			s.blank(i, end)
+			s.typeArgs[end-1] = true
+			return end, nil
+		}
+		return i + 1, nil
+	}
+	end, ok := s.skipAngles(i)
+	if ok && s.is(end, "(") {
+		s.blank(i, end)
+		s.typeArgs[end-1] = true
+		return end, nil
+	}
+	return i + 1, nil
+}
+
+// parameters strips the types from the parameter list opened at i
+func (s *stripper) parameters(i int) error {
+	end := s.match[i]
+	k := i + 1
+	for k < end {
+		t := s.tokens[k]
+		if t.text == "@" {
+			return s.errorf(k, "decorators are not supported")
+		}
+		if t.kind == tokIdent && accessModifiers[t.text] && (s.isIdent(k+1) || s.is(k+1, "{") || s.is(k+1, "[")) {
+			return s.errorf(k, "parameter properties are not supported, assign the fields in the constructor")
+		}
+		if t.text == "this" && s.is(k+1, ":") {
+			// this: Window only types the function's this
+			typeEnd, ok := s.skipType(k + 2)
+			if !ok {
+				return s.errorf(k, "can't read the type after :")
+			}
+			if s.is(typeEnd, ",") {
+				typeEnd++
+			}
+			s.blank(k, typeEnd)
+			k = typeEnd
+			continue
+		}
+
+		if s.is(k, "...") {
+			k++
+		}
+		switch {
+		case s.is(k, "{") || s.is(k, "["):
+			if err := s.walk(k+1, s.match[k]); err != nil {
+				return err
+			}
+			k = s.match[k] + 1
+		case s.isIdent(k):
+			k++
+		default:
+			return s.errorf(k, "unexpected %s in parameters", s.tok(k).text)
+		}
+		if s.is(k, "?") {
+			s.blank(k, k+1)
+			k++
+		}
+		if s.is(k, ":") {
+			var err error
+			if k, err = s.annotation(k); err != nil {
+				return err
+			}
+		}
+		if s.is(k, "=") {
+			value := s.listEnd(k+1, end)
+			if err := s.walk(k+1, value); err != nil {
+				return err
+			}
+			k = value
+		}
+		if s.is(k, ",") {
+			k++
+		} else if k != end {
+			return s.errorf(k, "unexpected %s in parameters", s.tok(k).text)
+		}
+	}
+	return nil
+}
+
+// accessModifiers are the modifiers that make a constructor parameter a property
+var accessModifiers = map[string]bool{
+	"public": true, "private": true, "protected": true, "readonly": true, "override": true,
+}
+
+// listEnd returns the index of the next comma in [i, end), or end
+func (s *stripper) listEnd(i, end int) int {
+	for ; i < end; i++ {
+		switch {
+		case s.is(i, ","):
+			return i
+		case s.tokens[i].kind == tokTemplateHead || s.is(i, "(") || s.is(i, "[") || s.is(i, "{"):
+			i = s.match[i]
+		}
+	}
+	return end
+}
+
+// function handles a function declaration or expression starting at i
+func (s *stripper) function(i int) (int, error) {
+	k := i + 1
+	if s.is(k, "*") {
+		k++
+	}
+	if s.isIdent(k) {
+		k++
+	}
+	if s.is(k, "<") {
+		end, ok := s.skipAngles(k)
+		if !ok {
+			return k, s.errorf(k, "type parameters are never closed")
+		}
+		s.blank(k, end)
+		k = end
+	}
+	if !s.is(k, "(") {
+		return k, nil
+	}
+	if err := s.parameters(k); err != nil {
+		return k, err
+	}
+	k = s.match[k] + 1
+	if s.is(k, ":") {
+		end, ok := s.skipReturnType(k + 1)
+		if !ok {
+			return k, s.errorf(k, "can't read the return type")
+		}
+		s.blank(k, end)
+		k = end
+	}
+	if !s.is(k, "{") {
+		// An overload signature, which has no body
+		start := i
+		if s.is(i-1, "async") && !s.tokens[i].newline {
+			start--
+		}
+		end := s.endFrom(k)
+		s.erase(start, end)
+		return end, nil
+	}
+	return k, nil
+}
+
+// class handles a class declaration or expression starting at i
+func (s *stripper) class(i int) (int, error) {
+	k := i + 1
+	if s.isIdent(k) && !s.is(k, "extends") && !s.is(k, "implements") {
+		k++
+	}
+	if s.is(k, "<") {
+		end, ok := s.skipAngles(k)
+		if !ok {
+			return k, s.errorf(k, "type parameters are never closed")
+		}
+		s.blank(k, end)
+		k = end
+	}
+	if s.is(k, "extends") {
+		// The base class is an expression, but its type arguments are erased
+		for k++; k < len(s.tokens) && !s.is(k, "{") && !s.is(k, "implements"); {
+			switch {
+			case s.is(k, "<"):
+				end, ok := s.skipAngles(k)
+				if !ok {
+					return k, s.errorf(k, "type arguments are never closed")
+				}
+				s.blank(k, end)
+				k = end
+			case s.is(k, "(") || s.is(k, "["):
+				if err := s.walk(k+1, s.match[k]); err != nil {
+					return k, err
+				}
+				k = s.match[k] + 1
+			default:
+				k++
+			}
+		}
+	}
+	if s.is(k, "implements") {
+		body := s.body(k + 1)
+		if body < 0 {
+			return k, s.errorf(k, "class has no body")
+		}
+		s.blank(k, body)
+		k = body
+	}
+	if !s.is(k, "{") {
+		return k, s.errorf(k, "class has no body")
+	}
+	return s.match[k] + 1, s.classBody(k+1, s.match[k])
+}
+
+// memberModifiers are the words that can come before a class member's name.
+// typeModifiers are the ones erased with the types.
+var (
+	memberModifiers = map[string]bool{
+		"public": true, "private": true, "protected": true, "readonly": true, "abstract": true,
+		"override": true, "declare": true, "static": true, "async": true, "get": true, "set": true,
+	}
+	typeModifiers = map[string]bool{
+		"public": true, "private": true, "protected": true, "readonly": true, "abstract": true,
+		"override": true, "declare": true,
+	}
+)
+
+// modifier reports whether the word at i modifies the member after it, rather
+// than being the member's name
+func (s *stripper) modifier(i int) bool {
+	t := s.tok(i)
+	if t.kind != tokIdent || !memberModifiers[t.text] {
+		return false
+	}
+	next := s.tok(i + 1)
+	return next.kind == tokIdent || next.kind == tokString || next.kind == tokNumber ||
+		s.is(i+1, "[") || s.is(i+1, "*") || (t.text == "static" && s.is(i+1, "{"))
+}
+
+// classBody strips the types from the members of a class in [i, end)
+func (s *stripper) classBody(i, end int) error {
+	for i < end {
+		if s.is(i, ";") {
+			i++
+			continue
+		}
+		start := i
+		erase := false // declare fields and abstract members only exist for the type checker
+		for s.modifier(i) {
+			if typeModifiers[s.tokens[i].text] {
+				s.blank(i, i+1)
+			}
+			if s.is(i, "declare") || s.is(i, "abstract") {
+				erase = true
+			}
+			i++
+		}
+
+		switch {
+		case s.is(i, "{"):
+			// A static block
+			if err := s.walk(i+1, s.match[i]); err != nil {
+				return err
+			}
+			i = s.match[i] + 1
+			continue
+		case s.is(i, "@"):
+			return s.errorf(i, "decorators are not supported")
+		case s.is(i, "*"):
+			i++
+		}
+		switch t := s.tok(i); {
+		case s.is(i, "[") && s.isIdent(i+1) && s.is(i+2, ":"):
+			// An index signature: [key: string]: unknown
+			memberEnd := s.statementEnd(i)
+			s.erase(start, memberEnd)
+			i = memberEnd
+			continue
+		case s.is(i, "["):
+			if err := s.walk(i+1, s.match[i]); err != nil {
+				return err
+			}
+			i = s.match[i] + 1
+		case t.kind == tokIdent || t.kind == tokString || t.kind == tokNumber:
+			i++
+		default:
+			return s.errorf(i, "unexpected %s in class body", t.text)
+		}
+
+		if s.is(i, "?") || s.is(i, "!") {
+			s.blank(i, i+1)
+			i++
+		}
+		if s.is(i, "<") {
+			typeEnd, ok := s.skipAngles(i)
+			if !ok {
+				return s.errorf(i, "type parameters are never closed")
+			}
+			s.blank(i, typeEnd)
+			i = typeEnd
+		}
+
+		if s.is(i, "(") {
+			if err := s.parameters(i); err != nil {
+				return err
+			}
+			i = s.match[i] + 1
+			if s.is(i, ":") {
+				typeEnd, ok := s.skipReturnType(i + 1)
+				if !ok {
+					return s.errorf(i, "can't read the return type")
+				}
+				s.blank(i, typeEnd)
+				i = typeEnd
+			}
+			if s.is(i, "{") && !erase {
+				if err := s.walk(i+1, s.match[i]); err != nil {
+					return err
+				}
+				i = s.match[i] + 1
+				continue
+			}
+			// An overload or an abstract method, which have no body
+			memberEnd := s.endFrom(i)
+			s.erase(start, memberEnd)
+			i = memberEnd
+			continue
+		}
+
+		if s.is(i, ":") {
+			var err error
+			if i, err = s.annotation(i); err != nil {
+				return err
+			}
+		}
+		memberEnd := s.endFrom(i)
+		if erase {
+			s.erase(start, memberEnd)
+		} else if s.is(i, "=") {
+			if err := s.walk(i+1, memberEnd); err != nil {
+				return err
+			}
+		}
+		i = memberEnd
+	}
+	return nil
+}
+
+// skipType returns the index after the type starting at i, and false when
+// there isn't one
+func (s *stripper) skipType(i int) (int, bool) {
+	i, ok := s.unionType(i)
+	if !ok {
+		return i, false
+	}
+	// A conditional type: T extends U ? X : Y
+	if s.is(i, "extends") && !s.tokens[i].newline {
+		if i, ok = s.unionType(i + 1); !ok || !s.is(i, "?") {
+			return i, false
+		}
+		if i, ok = s.skipType(i + 1); !ok || !s.is(i, ":") {
+			return i, false
+		}
+		return s.skipType(i + 1)
+	}
+	return i, true
+}
+
+// skipReturnType is skipType, also allowing type predicates: x is string,
+// asserts condition
+func (s *stripper) skipReturnType(i int) (int, bool) {
+	if s.is(i, "asserts") && s.isIdent(i+1) && !s.tok(i+1).newline {
+		if s.is(i+2, "is") {
+			return s.skipType(i + 3)
+		}
+		return i + 2, true
+	}
+	if s.isIdent(i) && s.is(i+1, "is") && !s.tok(i+1).newline {
+		return s.skipType(i + 2)
+	}
+	return s.skipType(i)
+}
+
+func (s *stripper) unionType(i int) (int, bool) {
+	if s.is(i, "|") || s.is(i, "&") {
+		i++
+	}
+	for {
+		var ok bool
+		if i, ok = s.operatorType(i); !ok {
+			return i, false
+		}
+		if !s.is(i, "|") && !s.is(i, "&") {
+			return i, true
+		}
+		i++
+	}
+}
+
+func (s *stripper) operatorType(i int) (int, bool) {
+	for s.is(i, "keyof") || s.is(i, "unique") || s.is(i, "readonly") || s.is(i, "infer") {
+		i++
+	}
+	i, ok := s.primaryType(i)
+	// Array and indexed access types: string[], Order["items"]
+	for ok && s.is(i, "[") && !s.tokens[i].newline {
+		i = s.match[i] + 1
+	}
+	return i, ok
+}
+
+func (s *stripper) primaryType(i int) (int, bool) {
+	t := s.tok(i)
+	switch {
+	case s.is(i, "("):
+		// A parenthesized type, or a function type: (a: string) => void
+		end := s.match[i] + 1
+		if s.is(end, "=>") {
+			return s.skipReturnType(end + 1)
+		}
+		return end, true
+	case s.is(i, "new") || (s.is(i, "abstract") && s.is(i+1, "new")):
+		// A constructor type: new (name: string) => User
+		if s.is(i, "abstract") {
+			i++
+		}
+		return s.functionType(i + 1)
+	case s.is(i, "<"):
+		return s.functionType(i)
+	case s.is(i, "{") || s.is(i, "["):
+		return s.match[i] + 1, true
+	case t.kind == tokString || t.kind == tokNumber || t.kind == tokTemplate:
+		return i + 1, true
+	case t.kind == tokTemplateHead:
+		return s.match[i] + 1, true
+	case s.is(i, "-") && s.tok(i+1).kind == tokNumber:
+		return i + 2, true
+	case s.is(i, "typeof"):
+		i++
+		if s.is(i, "import") && s.is(i+1, "(") {
+			i = s.match[i+1] + 1
+			for s.is(i, ".") && s.isIdent(i+1) {
+				i += 2
+			}
+			return i, true
+		}
+		if !s.isIdent(i) {
+			return i, false
+		}
+		fallthrough
+	case t.kind == tokIdent && (!reserved[t.text] || t.text == "void" || t.text == "typeof"):
+		i++
+		for s.is(i, ".") && s.isIdent(i+1) {
+			i += 2
+		}
+		if s.is(i, "<") && !s.tokens[i].newline {
+			return s.skipAngles(i)
+		}
+		return i, true
+	}
+	return i, false
+}
+
+// functionType skips a function type's optional type parameters at i, its
+// parameters and its return type
+func (s *stripper) functionType(i int) (int, bool) {
+	if s.is(i, "<") {
+		end, ok := s.skipAngles(i)
+		if !ok {
+			return end, false
+		}
+		i = end
+	}
+	if !s.is(i, "(") || !s.is(s.match[i]+1, "=>") {
+		return i, false
+	}
+	return s.skipReturnType(s.match[i] + 2)
+}
+
+// typeArguments returns the index after the type arguments opened at i, and
+// false when what follows < isn't a list of types
+func (s *stripper) typeArguments(i int) (int, bool) {
+	for i++; ; i++ {
+		end, ok := s.skipType(i)
+		if !ok {
+			return end, false
+		}
+		i = end
+		if s.is(i, ">") {
+			return i + 1, true
+		}
+		if !s.is(i, ",") {
+			return i, false
+		}
+	}
+}
+
+// skipAngles returns the index after the > closing the < at i, skipping
+// anything in brackets
+func (s *stripper) skipAngles(i int) (int, bool) {
+	depth := 0
+	for j := i; j < len(s.tokens); j++ {
+		switch {
+		case s.is(j, "<"):
+			depth++
+		case s.is(j, ">"):
+			if depth--; depth == 0 {
+				return j + 1, true
+			}
+		case s.tokens[j].kind == tokTemplateHead || s.is(j, "(") || s.is(j, "[") || s.is(j, "{"):
+			j = s.match[j]
+		case s.is(j, ";") || s.is(j, ")") || s.is(j, "]") || s.is(j, "}"):
+			return j, false
+		}
+	}
+	return len(s.tokens), false
+}
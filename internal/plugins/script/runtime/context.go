@@ -2,6 +2,9 @@ package runtime
 
 import (
 	"fmt"
+	"net/http"
+
+	"github.com/rocketship-ai/rocketship/internal/egress"
 )
 
 // Context provides the runtime environment for script execution
@@ -11,6 +14,17 @@ type Context struct {
 	Vars  map[string]interface{} // Configuration variables
 	Env   map[string]string      // Environment secrets from project environment
 
+	// HTTP sends the requests of JavaScript's fetch, through the worker's
+	// egress policy. Nil uses the default client.
+	HTTP *http.Client
+
+	// Egress is the worker's egress policy, for scripts that run in their own
+	// process. Nil allows everything.
+	Egress *egress.Policy
+
+	// Limits bound the script's time and memory
+	Limits Limits
+
 	// Output data
 	Saved map[string]string // Values to save back to workflow state
 
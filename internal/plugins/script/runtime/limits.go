@@ -0,0 +1,77 @@
+package runtime
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limits bound what one script can use. Zero values leave the choice to the
+// executor, which applies its defaults.
+type Limits struct {
+	Timeout time.Duration // Wall clock time
+	CPUTime time.Duration // Time spent running, leaving out waits on requests
+	Memory  int64         // Bytes
+	Ops     int64         // Loop iterations and function calls, for JavaScript
+}
+
+// LimitError reports a script that was stopped for going over a limit
+type LimitError struct {
+	Limit string // timeout, cpu_time, memory or ops
+	Value string // The limit that was exceeded
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("script exceeded its %s limit of %s", e.Limit, e.Value)
+}
+
+// Exceeded returns the error for a script that went over the named limit
+func (l Limits) Exceeded(limit string) *LimitError {
+	var value string
+	switch limit {
+	case "timeout":
+		value = l.Timeout.String()
+	case "cpu_time":
+		value = l.CPUTime.String()
+	case "memory":
+		value = FormatSize(l.Memory)
+	case "ops":
+		value = strconv.FormatInt(l.Ops, 10)
+	}
+	return &LimitError{Limit: limit, Value: value}
+}
+
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+}
+
+// ParseSize parses a size such as 256MB. Units are KB, MB and GB, in powers
+// of 1024.
+func ParseSize(size string) (int64, error) {
+	for _, unit := range sizeUnits {
+		if number, ok := strings.CutSuffix(size, unit.suffix); ok {
+			n, err := strconv.ParseInt(number, 10, 64)
+			if err != nil || n <= 0 {
+				break
+			}
+			return n * unit.bytes, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid size %q, use a number of KB, MB or GB such as 256MB", size)
+}
+
+// FormatSize formats bytes in the largest unit that divides them
+func FormatSize(bytes int64) string {
+	for _, unit := range sizeUnits {
+		if bytes >= unit.bytes && bytes%unit.bytes == 0 {
+			return fmt.Sprintf("%d%s", bytes/unit.bytes, unit.suffix)
+		}
+	}
+	return fmt.Sprintf("%d bytes", bytes)
+}
@@ -0,0 +1,47 @@
+package runtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		size string
+		want int64
+	}{
+		{"512KB", 512 << 10},
+		{"256MB", 256 << 20},
+		{"2GB", 2 << 30},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.size)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, %v, want %d", tt.size, got, err, tt.want)
+		}
+		if formatted := FormatSize(got); formatted != tt.size {
+			t.Errorf("FormatSize(%d) = %q, want %q", got, formatted, tt.size)
+		}
+	}
+
+	for _, size := range []string{"", "256", "256mb", "0MB", "-1MB", "1.5GB"} {
+		if _, err := ParseSize(size); err == nil {
+			t.Errorf("ParseSize(%q) should fail", size)
+		}
+	}
+}
+
+func TestLimitsExceeded(t *testing.T) {
+	limits := Limits{Timeout: 30 * time.Second, CPUTime: 500 * time.Millisecond, Memory: 64 << 20, Ops: 1000000}
+	want := map[string]string{
+		"timeout":  "script exceeded its timeout limit of 30s",
+		"cpu_time": "script exceeded its cpu_time limit of 500ms",
+		"memory":   "script exceeded its memory limit of 64MB",
+		"ops":      "script exceeded its ops limit of 1000000",
+	}
+	for limit, message := range want {
+		if got := limits.Exceeded(limit).Error(); got != message {
+			t.Errorf("Exceeded(%q) = %q, want %q", limit, got, message)
+		}
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
 	"github.com/rocketship-ai/rocketship/internal/plugins"
 	"github.com/rocketship-ai/rocketship/internal/plugins/script/executors"
 	"github.com/rocketship-ai/rocketship/internal/plugins/script/runtime"
@@ -80,23 +82,26 @@ func (p *ScriptPlugin) Activity(ctx context.Context, params map[string]interface
 		return nil, fmt.Errorf("script validation failed: %w", err)
 	}
 
-	// Create runtime context
-	rtCtx := runtime.NewContext(req.State, req.Vars, req.Env)
+	// Scripts' fetch, and Python's HTTP clients, go through the worker's egress
+	// policy like HTTP steps
+	policy, err := egress.FromParams(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
 
-	// Set up timeout if specified
-	execCtx := ctx
-	if config.Timeout != "" {
-		timeout, err := time.ParseDuration(config.Timeout)
-		if err != nil {
-			return nil, fmt.Errorf("invalid timeout format: %w", err)
-		}
-		var cancel context.CancelFunc
-		execCtx, cancel = context.WithTimeout(ctx, timeout)
-		defer cancel()
+	limits, err := p.parseLimits(config)
+	if err != nil {
+		return nil, err
 	}
 
+	// Create runtime context
+	rtCtx := runtime.NewContext(req.State, req.Vars, req.Env)
+	rtCtx.HTTP = &http.Client{Transport: policy.Transport()}
+	rtCtx.Egress = policy
+	rtCtx.Limits = limits
+
 	// Execute script
-	if err := executor.Execute(execCtx, script, rtCtx); err != nil {
+	if err := executor.Execute(ctx, script, rtCtx); err != nil {
 		return nil, fmt.Errorf("script execution failed: %w", err)
 	}
 
@@ -191,6 +196,33 @@ func (p *ScriptPlugin) parseConfig(configMap map[string]interface{}) (ScriptConf
 	return config, nil
 }
 
+// parseLimits parses the step's timeout, CPU time, memory and ops limits. The
+// executors enforce them, with their defaults for the ones left out.
+func (p *ScriptPlugin) parseLimits(config ScriptConfig) (runtime.Limits, error) {
+	var limits runtime.Limits
+	var err error
+	if config.Timeout != "" {
+		if limits.Timeout, err = time.ParseDuration(config.Timeout); err != nil {
+			return limits, fmt.Errorf("invalid timeout format: %w", err)
+		}
+	}
+	if config.CPUTime != "" {
+		if limits.CPUTime, err = time.ParseDuration(config.CPUTime); err != nil {
+			return limits, fmt.Errorf("invalid cpu_time format: %w", err)
+		}
+	}
+	if config.Memory != "" {
+		if limits.Memory, err = runtime.ParseSize(config.Memory); err != nil {
+			return limits, fmt.Errorf("invalid memory format: %w", err)
+		}
+	}
+	if config.Ops < 0 {
+		return limits, fmt.Errorf("invalid ops: must be positive")
+	}
+	limits.Ops = config.Ops
+	return limits, nil
+}
+
 // getScriptContent retrieves the script content from inline or file
 func (p *ScriptPlugin) getScriptContent(config ScriptConfig) (string, error) {
 	if config.Script != "" {
@@ -5,7 +5,10 @@ type ScriptConfig struct {
 	Language string `json:"language" yaml:"language"` // Required: javascript, python, shell, etc.
 	Script   string `json:"script" yaml:"script"`     // Inline script content
 	File     string `json:"file" yaml:"file"`         // Path to external script file
-	Timeout  string `json:"timeout" yaml:"timeout"`   // Execution timeout (default: 30s)
+	Timeout  string `json:"timeout" yaml:"timeout"`   // Wall clock limit (default: 30s, 10m for shell)
+	CPUTime  string `json:"cpu_time" yaml:"cpu_time"` // CPU time limit
+	Memory   string `json:"memory" yaml:"memory"`     // Memory limit, e.g. 256MB
+	Ops      int64  `json:"ops" yaml:"ops"`           // Loop iterations and function calls, for JavaScript
 }
 
 // ActivityRequest represents the input to the script activity
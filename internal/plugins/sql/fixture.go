@@ -0,0 +1,279 @@
+package sql
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// defaultFixtureBatchSize is the number of rows per INSERT statement
+	defaultFixtureBatchSize = 100
+	// maxFixtureParams keeps each INSERT under the lowest placeholder limit of
+	// the supported drivers, SQLite's 999 in older builds
+	maxFixtureParams = 999
+)
+
+// parseFixture reads config.load_fixture
+func parseFixture(raw interface{}) (*FixtureConfig, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	data, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("load_fixture must be an object, got %T", raw)
+	}
+
+	fixture := &FixtureConfig{}
+	fixture.File, _ = data["file"].(string)
+	fixture.Content, _ = data["content"].(string)
+	fixture.Table, _ = data["table"].(string)
+	fixture.Format, _ = data["format"].(string)
+	fixture.Truncate, _ = data["truncate"].(bool)
+	if fixture.File == "" {
+		return nil, fmt.Errorf("load_fixture.file is required")
+	}
+	if fixture.Table == "" {
+		return nil, fmt.Errorf("load_fixture.table is required")
+	}
+	// Workers don't share the suite's filesystem, so fixtures are only read
+	// when the suite is loaded
+	if _, ok := data["content"]; !ok {
+		return nil, fmt.Errorf("load_fixture.file %s wasn't read when the suite was loaded; run the suite through the rocketship CLI or a connected repository", fixture.File)
+	}
+
+	if columns, ok := data["columns"]; ok {
+		mapping, ok := columns.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("load_fixture.columns must map fixture fields to table columns, got %T", columns)
+		}
+		fixture.Columns = make(map[string]string, len(mapping))
+		for field, column := range mapping {
+			name, ok := column.(string)
+			if !ok || name == "" {
+				return nil, fmt.Errorf("load_fixture.columns.%s must be a column name", field)
+			}
+			fixture.Columns[field] = name
+		}
+	}
+
+	if batchSize, ok := data["batch_size"]; ok {
+		size, ok := batchSize.(float64)
+		if !ok || size < 1 || size != float64(int(size)) {
+			return nil, fmt.Errorf("load_fixture.batch_size must be a positive integer, got %v", batchSize)
+		}
+		fixture.BatchSize = int(size)
+	}
+	return fixture, nil
+}
+
+// readFixture returns the table columns and the rows to insert from the
+// fixture's inlined content. CSV files start with a header row and their empty
+// fields load as NULL. JSON files hold an array of objects, and fields an
+// object lacks load as NULL.
+func readFixture(fixture *FixtureConfig) ([]string, [][]interface{}, error) {
+	content := []byte(fixture.Content)
+	format := strings.ToLower(fixture.Format)
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fixture.File)), ".")
+	}
+
+	var fields []string
+	var rows [][]interface{}
+	switch format {
+	case "csv":
+		records, err := csv.NewReader(strings.NewReader(string(content))).ReadAll()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse fixture %s: %w", fixture.File, err)
+		}
+		if len(records) == 0 {
+			return nil, nil, fmt.Errorf("fixture %s has no header row", fixture.File)
+		}
+		fields = records[0]
+		for _, record := range records[1:] {
+			row := make([]interface{}, len(record))
+			for i, value := range record {
+				if value != "" {
+					row[i] = value
+				}
+			}
+			rows = append(rows, row)
+		}
+	case "json":
+		var objects []map[string]interface{}
+		if err := json.Unmarshal(content, &objects); err != nil {
+			return nil, nil, fmt.Errorf("fixture %s must be a JSON array of objects: %w", fixture.File, err)
+		}
+		seen := make(map[string]bool)
+		for _, object := range objects {
+			for field := range object {
+				if !seen[field] {
+					seen[field] = true
+					fields = append(fields, field)
+				}
+			}
+		}
+		sort.Strings(fields)
+		for _, object := range objects {
+			row := make([]interface{}, len(fields))
+			for i, field := range fields {
+				value, err := bindValue(object[field])
+				if err != nil {
+					return nil, nil, fmt.Errorf("failed to read %s in fixture %s: %w", field, fixture.File, err)
+				}
+				row[i] = value
+			}
+			rows = append(rows, row)
+		}
+	default:
+		return nil, nil, fmt.Errorf("unsupported fixture format %q: use csv or json", format)
+	}
+
+	if len(fixture.Columns) == 0 {
+		return fields, rows, nil
+	}
+	return mapFixtureColumns(fixture, fields, rows)
+}
+
+// mapFixtureColumns keeps the mapped fields, in fixture order, under their
+// table column names
+func mapFixtureColumns(fixture *FixtureConfig, fields []string, rows [][]interface{}) ([]string, [][]interface{}, error) {
+	var columns []string
+	var indexes []int
+	found := make(map[string]bool)
+	for i, field := range fields {
+		if column, ok := fixture.Columns[field]; ok {
+			columns = append(columns, column)
+			indexes = append(indexes, i)
+			found[field] = true
+		}
+	}
+	for field := range fixture.Columns {
+		if !found[field] {
+			return nil, nil, fmt.Errorf("load_fixture.columns maps %s, which fixture %s doesn't have", field, fixture.File)
+		}
+	}
+
+	mapped := make([][]interface{}, len(rows))
+	for r, row := range rows {
+		mapped[r] = make([]interface{}, len(indexes))
+		for c, i := range indexes {
+			if i < len(row) {
+				mapped[r][c] = row[i]
+			}
+		}
+	}
+	return columns, mapped, nil
+}
+
+// loadFixture inserts the fixture's rows in batches, in one transaction so a
+// failed load leaves the table as it was
+func loadFixture(ctx context.Context, db *sqlx.DB, driverName string, fixture *FixtureConfig) (*FixtureResult, error) {
+	startTime := time.Now()
+
+	columns, rows, err := readFixture(fixture)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("fixture %s has no columns to load", fixture.File)
+	}
+
+	batchSize := fixture.BatchSize
+	if batchSize == 0 {
+		batchSize = defaultFixtureBatchSize
+	}
+	if limit := maxFixtureParams / len(columns); batchSize > limit {
+		batchSize = max(limit, 1)
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start fixture transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback() // No-op once committed
+	}()
+
+	table := quoteIdentifier(driverName, fixture.Table)
+	if fixture.Truncate {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table); err != nil {
+			return nil, fmt.Errorf("failed to truncate %s: %w", fixture.Table, err)
+		}
+	}
+
+	result := &FixtureResult{File: fixture.File, Table: fixture.Table, Truncated: fixture.Truncate}
+	for start := 0; start < len(rows); start += batchSize {
+		batch := rows[start:min(start+batchSize, len(rows))]
+		args := make([]interface{}, 0, len(batch)*len(columns))
+		for _, row := range batch {
+			args = append(args, row...)
+		}
+		execResult, err := tx.ExecContext(ctx, insertStatement(driverName, fixture.Table, columns, len(batch)), args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load fixture rows %d-%d into %s: %w", start+1, start+len(batch), fixture.Table, err)
+		}
+		if affected, err := execResult.RowsAffected(); err == nil {
+			result.Rows += affected
+		} else {
+			result.Rows += int64(len(batch))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit fixture: %w", err)
+	}
+	result.Duration = time.Since(startTime).String()
+	return result, nil
+}
+
+// insertStatement returns a multi-row INSERT with the driver's placeholders
+func insertStatement(driverName, table string, columns []string, rowCount int) string {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = quoteIdentifier(driverName, column)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "INSERT INTO %s (%s) VALUES ", quoteIdentifier(driverName, table), strings.Join(quoted, ", "))
+	n := 0
+	for r := 0; r < rowCount; r++ {
+		if r > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("(")
+		for c := range columns {
+			if c > 0 {
+				sb.WriteString(", ")
+			}
+			n++
+			sb.WriteString(placeholder(driverName, n))
+		}
+		sb.WriteString(")")
+	}
+	return sb.String()
+}
+
+// quoteIdentifier quotes each part of a possibly schema-qualified name the
+// way the driver's database expects
+func quoteIdentifier(driverName, name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		switch driverName {
+		case "mysql":
+			parts[i] = "`" + strings.ReplaceAll(part, "`", "``") + "`"
+		case "sqlserver":
+			parts[i] = "[" + strings.ReplaceAll(part, "]", "]]") + "]"
+		default:
+			parts[i] = `"` + strings.ReplaceAll(part, `"`, `""`) + `"`
+		}
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,164 @@
+package sql
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+)
+
+// parseParams reads config.params: a list of values for positional
+// placeholders ($1, ? or @p1, as the driver expects) or an object of values
+// for :name placeholders
+func parseParams(raw interface{}) (interface{}, error) {
+	switch params := raw.(type) {
+	case nil:
+		return nil, nil
+	case []interface{}, map[string]interface{}:
+		return params, nil
+	}
+	return nil, fmt.Errorf("params must be a list of values or an object of named values, got %T", raw)
+}
+
+// processParams renders templates in string params. The results are bound by
+// the driver, never spliced into the SQL, so values need no quoting.
+func processParams(params interface{}, context dsl.TemplateContext) (interface{}, error) {
+	switch p := params.(type) {
+	case []interface{}:
+		processed := make([]interface{}, len(p))
+		for i, v := range p {
+			value, err := paramValue(v, context)
+			if err != nil {
+				return nil, fmt.Errorf("failed to process params[%d]: %w", i, err)
+			}
+			processed[i] = value
+		}
+		return processed, nil
+	case map[string]interface{}:
+		processed := make(map[string]interface{}, len(p))
+		for name, v := range p {
+			value, err := paramValue(v, context)
+			if err != nil {
+				return nil, fmt.Errorf("failed to process params.%s: %w", name, err)
+			}
+			processed[name] = value
+		}
+		return processed, nil
+	}
+	return params, nil
+}
+
+// paramValue renders a string param's templates and converts other values
+// with bindValue
+func paramValue(v interface{}, context dsl.TemplateContext) (interface{}, error) {
+	if value, ok := v.(string); ok {
+		return dsl.ProcessTemplate(value, context)
+	}
+	return bindValue(v)
+}
+
+// bindValue converts a YAML or JSON value into one a driver can bind. Whole
+// numbers become integers, and lists and objects are bound as JSON text.
+func bindValue(v interface{}) (interface{}, error) {
+	switch value := v.(type) {
+	case float64:
+		if value == math.Trunc(value) && math.Abs(value) < 1<<53 {
+			return int64(value), nil
+		}
+		return value, nil
+	case int:
+		return int64(value), nil
+	case []interface{}, map[string]interface{}:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		return string(encoded), nil
+	}
+	return v, nil
+}
+
+// bindParams returns the query and arguments to run it with. Named params are
+// rewritten to the driver's positional placeholders.
+func bindParams(driverName, query string, params interface{}) (string, []interface{}, error) {
+	switch p := params.(type) {
+	case []interface{}:
+		return query, p, nil
+	case map[string]interface{}:
+		return bindNamed(driverName, query, p)
+	}
+	return query, nil, nil
+}
+
+// bindNamed replaces each :name in the query with a placeholder and lists the
+// values in order. Quoted strings and identifiers, line comments and Postgres
+// :: casts are left alone.
+func bindNamed(driverName, query string, params map[string]interface{}) (string, []interface{}, error) {
+	var out strings.Builder
+	var args []interface{}
+	runes := []rune(query)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\'' || r == '"':
+			// Copy the quoted section through its closing quote
+			out.WriteRune(r)
+			for i++; i < len(runes); i++ {
+				out.WriteRune(runes[i])
+				if runes[i] == r {
+					break
+				}
+			}
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			// Copy a line comment through the end of the line
+			for ; i < len(runes) && runes[i] != '\n'; i++ {
+				out.WriteRune(runes[i])
+			}
+			if i < len(runes) {
+				out.WriteRune(runes[i])
+			}
+		case r == ':' && i+1 < len(runes) && runes[i+1] == ':':
+			out.WriteString("::")
+			i++
+		case r == ':' && i+1 < len(runes) && isNameStart(runes[i+1]):
+			j := i + 1
+			for j < len(runes) && isNamePart(runes[j]) {
+				j++
+			}
+			name := string(runes[i+1 : j])
+			value, ok := params[name]
+			if !ok {
+				return "", nil, fmt.Errorf("no value in params for :%s", name)
+			}
+			args = append(args, value)
+			out.WriteString(placeholder(driverName, len(args)))
+			i = j - 1
+		default:
+			out.WriteRune(r)
+		}
+	}
+	return out.String(), args, nil
+}
+
+// placeholder returns the driver's placeholder for the nth argument
+func placeholder(driverName string, n int) string {
+	switch driverName {
+	case "postgres":
+		return "$" + strconv.Itoa(n)
+	case "sqlserver":
+		return "@p" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+func isNameStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isNamePart(r rune) bool {
+	return isNameStart(r) || unicode.IsDigit(r)
+}
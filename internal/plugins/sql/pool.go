@@ -0,0 +1,98 @@
+package sql
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+)
+
+// poolIdleTimeout closes a test's pool once no step has used it for this
+// long. Steps of a test run back to back, so a finished test's pools go soon
+// after it does.
+const poolIdleTimeout = 30 * time.Second
+
+// pooledDB is a connection pool shared by the sql steps of one test
+type pooledDB struct {
+	db    *sqlx.DB
+	users int
+	idle  *time.Timer
+}
+
+// pools holds the open pools on the worker, keyed by test and DSN
+var pools = struct {
+	sync.Mutex
+	byKey map[string]*pooledDB
+}{byKey: make(map[string]*pooledDB)}
+
+// acquireDB returns the test's pool for the DSN, connecting on first use. It
+// reports whether the pool was reused. release must be called once the step is
+// done with the pool. Without a test scope the pool is the step's own.
+func acquireDB(ctx context.Context, scope, driverName, dsn string, policy *egress.Policy) (db *sqlx.DB, release func(), reused bool, err error) {
+	if scope == "" {
+		db, err := connect(ctx, driverName, dsn, policy)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		return db, func() { _ = db.Close() }, false, nil
+	}
+
+	key := scope + "\x00" + driverName + "\x00" + dsn
+	pools.Lock()
+	pooled, ok := pools.byKey[key]
+	if ok {
+		pooled.users++
+		pooled.idle.Stop()
+	}
+	pools.Unlock()
+
+	if !ok {
+		// Connect outside the lock so other tests' steps aren't held up
+		db, err := connect(ctx, driverName, dsn, policy)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		pools.Lock()
+		if existing, raced := pools.byKey[key]; raced {
+			// A parallel step connected first, so share its pool
+			_ = db.Close()
+			pooled = existing
+			pooled.users++
+			pooled.idle.Stop()
+			ok = true
+		} else {
+			pooled = &pooledDB{db: db, users: 1}
+			pooled.idle = time.AfterFunc(poolIdleTimeout, func() { closeIdlePool(key, pooled) })
+			pooled.idle.Stop()
+			pools.byKey[key] = pooled
+		}
+		pools.Unlock()
+	}
+
+	var once sync.Once
+	release = func() {
+		once.Do(func() {
+			pools.Lock()
+			defer pools.Unlock()
+			pooled.users--
+			if pooled.users == 0 {
+				pooled.idle.Reset(poolIdleTimeout)
+			}
+		})
+	}
+	return pooled.db, release, ok, nil
+}
+
+// closeIdlePool closes the pool unless a step picked it up again meanwhile
+func closeIdlePool(key string, pooled *pooledDB) {
+	pools.Lock()
+	if pooled.users > 0 || pools.byKey[key] != pooled {
+		pools.Unlock()
+		return
+	}
+	delete(pools.byKey, key)
+	pools.Unlock()
+	_ = pooled.db.Close()
+}
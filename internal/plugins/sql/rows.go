@@ -0,0 +1,256 @@
+package sql
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+)
+
+// maxRowDiffLines caps the differences listed in a failed rows assertion
+const maxRowDiffLines = 20
+
+// timestampLayouts are the forms timestamps take in expected rows and in
+// drivers that return them as text. Layouts without a zone read as UTC.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999-07",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// rowsOptions are the comparison settings of a rows assertion
+type rowsOptions struct {
+	ignoreOrder bool
+	tolerance   float64       // Largest difference at which numbers still match
+	precision   time.Duration // Timestamps are truncated to this before comparing
+}
+
+// parseRowsOptions reads ignore_order, tolerance and timestamp_precision
+func parseRowsOptions(assertion map[string]interface{}) (rowsOptions, error) {
+	var opts rowsOptions
+	if v, ok := assertion["ignore_order"]; ok {
+		if opts.ignoreOrder, ok = v.(bool); !ok {
+			return opts, fmt.Errorf("rows assertion ignore_order must be a boolean, got %T", v)
+		}
+	}
+	if v, ok := assertion["tolerance"]; ok {
+		tolerance, ok := v.(float64)
+		if !ok || tolerance < 0 {
+			return opts, fmt.Errorf("rows assertion tolerance must be a non-negative number, got %v", v)
+		}
+		opts.tolerance = tolerance
+	}
+	if v, ok := assertion["timestamp_precision"]; ok {
+		s, _ := v.(string)
+		precision, err := time.ParseDuration(s)
+		if err != nil || precision <= 0 {
+			return opts, fmt.Errorf("rows assertion timestamp_precision must be a positive duration such as 1s or 1ms, got %v", v)
+		}
+		opts.precision = precision
+	}
+	return opts, nil
+}
+
+// diffRows compares a query's rows with the expected ones and describes each
+// difference. Only the columns an expected row lists are compared.
+func diffRows(actual []map[string]interface{}, expected []interface{}, opts rowsOptions) ([]string, error) {
+	expectedRows := make([]map[string]interface{}, len(expected))
+	for i, row := range expected {
+		m, ok := row.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("rows assertion expected[%d] must be an object of column values, got %T", i, row)
+		}
+		expectedRows[i] = m
+	}
+
+	var diffs []string
+	if !opts.ignoreOrder {
+		for i := 0; i < len(actual) || i < len(expectedRows); i++ {
+			switch {
+			case i >= len(actual):
+				diffs = append(diffs, fmt.Sprintf("row %d: missing, expected %s", i, formatRow(expectedRows[i])))
+			case i >= len(expectedRows):
+				diffs = append(diffs, fmt.Sprintf("row %d: unexpected %s", i, formatRow(actual[i])))
+			default:
+				for _, cell := range diffRow(actual[i], expectedRows[i], opts) {
+					diffs = append(diffs, fmt.Sprintf("row %d: %s", i, cell))
+				}
+			}
+		}
+		return diffs, nil
+	}
+
+	// Pair each expected row with the first unclaimed row that matches it
+	claimed := make([]bool, len(actual))
+	for i, want := range expectedRows {
+		found := false
+		for j, got := range actual {
+			if !claimed[j] && len(diffRow(got, want, opts)) == 0 {
+				claimed[j], found = true, true
+				break
+			}
+		}
+		if !found {
+			diffs = append(diffs, fmt.Sprintf("expected row %d not found: %s", i, formatRow(want)))
+		}
+	}
+	for j, got := range actual {
+		if !claimed[j] {
+			diffs = append(diffs, fmt.Sprintf("row %d: unexpected %s", j, formatRow(got)))
+		}
+	}
+	return diffs, nil
+}
+
+// diffRow describes the columns of want that row doesn't match
+func diffRow(row, want map[string]interface{}, opts rowsOptions) []string {
+	var diffs []string
+	for _, column := range sortedKeys(want) {
+		got, exists := row[column]
+		if !exists {
+			diffs = append(diffs, fmt.Sprintf("%s: column not in result", column))
+			continue
+		}
+		if !cellMatches(got, want[column], opts) {
+			diffs = append(diffs, fmt.Sprintf("%s: expected %s, got %s", column, formatCell(want[column]), formatCell(got)))
+		}
+	}
+	return diffs
+}
+
+// cellMatches compares a column value with the expected one: timestamps as
+// instants after truncating to the precision, numbers within the tolerance,
+// and anything else as the shared equals assertion does
+func cellMatches(actual, expected interface{}, opts rowsOptions) bool {
+	actual = cellValue(actual)
+	if expected == nil {
+		return actual == nil
+	}
+
+	if e, ok := parseTimestamp(expected); ok {
+		if a, ok := parseTimestamp(actual); ok {
+			if opts.precision > 0 {
+				a, e = a.Truncate(opts.precision), e.Truncate(opts.precision)
+			}
+			return a.Equal(e)
+		}
+	}
+
+	if e, ok := cellNumber(expected); ok {
+		if a, ok := cellNumber(actual); ok {
+			return math.Abs(a-e) <= opts.tolerance
+		}
+	}
+
+	return assertions.Equal(actual, expected)
+}
+
+// cellValue converts driver values into the ones expected rows hold. Text
+// columns come back as []byte from most drivers.
+func cellValue(v interface{}) interface{} {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return v
+}
+
+// cellNumber reads a number, or the text of one such as a NUMERIC column
+func cellNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// parseTimestamp reads a time.Time or a timestamp string
+func parseTimestamp(v interface{}) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case string:
+		for _, layout := range timestampLayouts {
+			if parsed, err := time.Parse(layout, t); err == nil {
+				return parsed, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// formatRow renders a row as JSON with its columns in name order
+func formatRow(row map[string]interface{}) string {
+	converted := make(map[string]interface{}, len(row))
+	for column, value := range row {
+		converted[column] = cellValue(value)
+	}
+	b, err := json.Marshal(converted)
+	if err != nil {
+		return fmt.Sprint(row)
+	}
+	return string(b)
+}
+
+// formatCell quotes strings so "1" and 1 read differently
+func formatCell(v interface{}) string {
+	switch value := cellValue(v).(type) {
+	case string:
+		return strconv.Quote(value)
+	case time.Time:
+		return value.Format(time.RFC3339Nano)
+	case nil:
+		return "null"
+	default:
+		b, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return string(b)
+	}
+}
+
+// formatRowDiffs joins the differences into a failure message
+func formatRowDiffs(queryIdx int, diffs []string) string {
+	shown := diffs
+	if len(shown) > maxRowDiffLines {
+		shown = shown[:maxRowDiffLines]
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "rows assertion failed for query %d:", queryIdx)
+	for _, diff := range shown {
+		sb.WriteString("\n  ")
+		sb.WriteString(diff)
+	}
+	if more := len(diffs) - len(shown); more > 0 {
+		fmt.Fprintf(&sb, "\n  ... and %d more differences", more)
+	}
+	return sb.String()
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -9,8 +9,11 @@ import (
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/rocketship-ai/rocketship/internal/assertions"
 	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
 	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
 	"go.temporal.io/sdk/activity"
 
 	// Database drivers
@@ -46,17 +49,14 @@ func (sp *SQLPlugin) Activity(ctx context.Context, p map[string]interface{}) (in
 	}
 
 	// Validate required fields
-	if config.Driver == "" {
-		return nil, fmt.Errorf("driver is required")
-	}
 	if config.DSN == "" {
 		return nil, fmt.Errorf("dsn is required")
 	}
-	if len(config.Commands) == 0 && config.File == "" {
-		return nil, fmt.Errorf("either commands or file must be specified")
+	if len(config.Commands) == 0 && config.File == "" && config.Fixture == nil {
+		return nil, fmt.Errorf("either commands, file or load_fixture must be specified")
 	}
 
-	logger.Info("Executing SQL plugin", "driver", config.Driver, "queries", len(config.Commands))
+	logger.Info("Executing SQL plugin", "queries", len(config.Commands))
 
 	// Extract state and env from parameters
 	state, _ := p["state"].(map[string]interface{})
@@ -78,25 +78,42 @@ func (sp *SQLPlugin) Activity(ctx context.Context, p map[string]interface{}) (in
 		return nil, fmt.Errorf("variable replacement failed: %w", err)
 	}
 
+	// The DSN's scheme picks the driver when none is set, so resolve it once templated
+	driverName, dsn, err := resolveDriver(config.Driver, config.DSN)
+	if err != nil {
+		return nil, err
+	}
+	config.Driver, config.DSN = driverName, dsn
+
 	// Get queries to execute
 	queries, err := getQueries(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get queries: %w", err)
 	}
 
+	policy, err := egress.FromParams(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
+
+	// Steps of the same test share a pool per DSN, so only the first one connects
+	scope := activity.GetInfo(ctx).WorkflowExecution.RunID
+
 	// Execute SQL operations
-	response, err := executeQueries(ctx, config, queries)
+	response, err := executeQueries(ctx, config, queries, policy, scope)
 	if err != nil {
 		return nil, fmt.Errorf("SQL execution failed: %w", err)
 	}
 
 	// Process assertions
+	var assertionResults []AssertionResult
 	if assertions, ok := p["assertions"].([]interface{}); ok {
 		vars, _ := p["vars"].(map[string]interface{})
 		if err := applyVariableReplacementToAssertions(assertions, state, env, vars); err != nil {
 			return nil, fmt.Errorf("assertion variable replacement failed: %w", err)
 		}
-		if err := processAssertions(response, assertions); err != nil {
+		assertionResults, err = processAssertions(response, assertions)
+		if err != nil {
 			return nil, fmt.Errorf("assertion failed: %w", err)
 		}
 	}
@@ -104,14 +121,18 @@ func (sp *SQLPlugin) Activity(ctx context.Context, p map[string]interface{}) (in
 	// Process save configuration
 	savedValues := make(map[string]string)
 	if saveConfig, ok := p["save"].([]interface{}); ok {
-		savedValues = processSaveConfig(response, saveConfig)
+		savedValues, err = processSaveConfig(response, saveConfig)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	logger.Info("SQL execution completed", "queries", response.Stats.TotalQueries, "saved_vars", len(savedValues))
+	logger.Info("SQL execution completed", "driver", config.Driver, "queries", response.Stats.TotalQueries, "saved_vars", len(savedValues))
 
 	return &ActivityResponse{
-		Response: response,
-		Saved:    savedValues,
+		Response:         response,
+		Saved:            savedValues,
+		AssertionResults: assertionResults,
 	}, nil
 }
 
@@ -130,6 +151,18 @@ func parseConfig(configData map[string]interface{}, config *SQLConfig) error {
 		config.Timeout = timeout
 	}
 
+	params, err := parseParams(configData["params"])
+	if err != nil {
+		return err
+	}
+	config.Params = params
+
+	fixture, err := parseFixture(configData["load_fixture"])
+	if err != nil {
+		return err
+	}
+	config.Fixture = fixture
+
 	// Parse commands array
 	if commandsInterface, ok := configData["commands"]; ok {
 		if commandsSlice, ok := commandsInterface.([]interface{}); ok {
@@ -171,6 +204,21 @@ func applyVariableReplacement(config *SQLConfig, state map[string]interface{}, e
 		config.Commands[i] = processedCmd
 	}
 
+	params, err := processParams(config.Params, context)
+	if err != nil {
+		return err
+	}
+	config.Params = params
+
+	// Process the fixture's table; its file was read when the suite was loaded
+	if config.Fixture != nil {
+		processed, err := dsl.ProcessTemplate(config.Fixture.Table, context)
+		if err != nil {
+			return fmt.Errorf("failed to process load_fixture template: %w", err)
+		}
+		config.Fixture.Table = processed
+	}
+
 	return nil
 }
 
@@ -220,21 +268,11 @@ func applyVariableReplacementToAssertions(assertions []interface{}, state map[st
 		return out.String()
 	}
 
-	for _, assertionInterface := range assertions {
-		assertion, ok := assertionInterface.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
-		expected, ok := assertion["expected"].(string)
-		if !ok || expected == "" {
-			continue
-		}
-
+	replaceExpected := func(expected string) (string, error) {
 		if strings.Contains(expected, ".vars.") && vars != nil {
 			processed, err := dsl.ProcessConfigVariablesOnly(expected, vars)
 			if err != nil {
-				return fmt.Errorf("failed to process config vars in assertion expected: %w", err)
+				return "", fmt.Errorf("failed to process config vars in assertion expected: %w", err)
 			}
 			expected = processed
 		}
@@ -270,7 +308,37 @@ func applyVariableReplacementToAssertions(assertions []interface{}, state map[st
 			return match
 		})
 
-		assertion["expected"] = expected
+		return expected, nil
+	}
+
+	for _, assertionInterface := range assertions {
+		assertion, ok := assertionInterface.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		switch expected := assertion["expected"].(type) {
+		case string:
+			if expected == "" {
+				continue
+			}
+			replaced, err := replaceExpected(expected)
+			if err != nil {
+				return err
+			}
+			assertion["expected"] = replaced
+		case []interface{}:
+			// The bounds of between
+			for i, item := range expected {
+				if itemStr, ok := item.(string); ok && itemStr != "" {
+					replaced, err := replaceExpected(itemStr)
+					if err != nil {
+						return err
+					}
+					expected[i] = replaced
+				}
+			}
+		}
 	}
 
 	return nil
@@ -297,6 +365,9 @@ func getQueries(config *SQLConfig) ([]string, error) {
 		return queries, nil
 	}
 
+	if config.Fixture != nil {
+		return nil, nil
+	}
 	return nil, fmt.Errorf("no queries specified")
 }
 
@@ -414,21 +485,18 @@ func parseSQLFile(content string) ([]string, error) {
 	return queries, nil
 }
 
-// executeQueries executes SQL queries and returns results
-func executeQueries(ctx context.Context, config *SQLConfig, queries []string) (*SQLResponse, error) {
+// executeQueries executes SQL queries and returns results. scope identifies
+// the test whose steps share connection pools.
+func executeQueries(ctx context.Context, config *SQLConfig, queries []string, policy *egress.Policy, scope string) (*SQLResponse, error) {
 	logger := activity.GetLogger(ctx)
 	startTime := time.Now()
 
-	// Establish database connection
-	db, err := sqlx.Connect(config.Driver, config.DSN)
+	// Establish database connection, or reuse the test's pool
+	db, release, reused, err := acquireDB(ctx, scope, config.Driver, config.DSN, policy)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
-	defer func() {
-		if err := db.Close(); err != nil {
-			logger.Warn("Failed to close database connection", "error", err)
-		}
-	}()
+	defer release()
 
 	connectionTime := time.Since(startTime)
 
@@ -449,15 +517,26 @@ func executeQueries(ctx context.Context, config *SQLConfig, queries []string) (*
 	response := &SQLResponse{
 		Queries: make([]QueryResult, 0, len(queries)),
 		Stats: ExecutionStats{
-			TotalQueries:   len(queries),
-			ConnectionTime: connectionTime.String(),
+			TotalQueries:     len(queries),
+			ConnectionTime:   connectionTime.String(),
+			ConnectionReused: reused,
 		},
 	}
 
+	// Load the fixture before the commands, which may query it
+	if config.Fixture != nil {
+		fixture, err := loadFixture(ctx, db, config.Driver, config.Fixture)
+		if err != nil {
+			return nil, err
+		}
+		response.Fixture = fixture
+		logger.Info("Loaded fixture", "file", fixture.File, "table", fixture.Table, "rows", fixture.Rows)
+	}
+
 	// Execute each query
 	var queryErrors []string
 	for i, query := range queries {
-		queryResult := executeQuery(ctx, db, query)
+		queryResult := executeQuery(ctx, db, config.Driver, query, config.Params)
 		response.Queries = append(response.Queries, queryResult)
 
 		if queryResult.Error == "" {
@@ -479,8 +558,8 @@ func executeQueries(ctx context.Context, config *SQLConfig, queries []string) (*
 	return response, nil
 }
 
-// executeQuery executes a single SQL query
-func executeQuery(ctx context.Context, db *sqlx.DB, query string) QueryResult {
+// executeQuery executes a single SQL query, binding params to its placeholders
+func executeQuery(ctx context.Context, db *sqlx.DB, driverName, query string, params interface{}) QueryResult {
 	startTime := time.Now()
 
 	result := QueryResult{
@@ -488,6 +567,13 @@ func executeQuery(ctx context.Context, db *sqlx.DB, query string) QueryResult {
 		Rows:  make([]map[string]interface{}, 0),
 	}
 
+	query, args, err := bindParams(driverName, query, params)
+	if err != nil {
+		result.Error = err.Error()
+		result.Duration = time.Since(startTime).String()
+		return result
+	}
+
 	// Determine if this is a SELECT query or a modification query
 	trimmedQuery := strings.TrimSpace(strings.ToUpper(query))
 	isSelect := strings.HasPrefix(trimmedQuery, "SELECT") ||
@@ -496,7 +582,7 @@ func executeQuery(ctx context.Context, db *sqlx.DB, query string) QueryResult {
 
 	if isSelect {
 		// Execute SELECT query
-		rows, err := db.QueryxContext(ctx, query)
+		rows, err := db.QueryxContext(ctx, query, args...)
 		if err != nil {
 			result.Error = err.Error()
 			result.Duration = time.Since(startTime).String()
@@ -524,7 +610,7 @@ func executeQuery(ctx context.Context, db *sqlx.DB, query string) QueryResult {
 
 	} else {
 		// Execute modification query (INSERT, UPDATE, DELETE)
-		execResult, err := db.ExecContext(ctx, query)
+		execResult, err := db.ExecContext(ctx, query, args...)
 		if err != nil {
 			result.Error = err.Error()
 			result.Duration = time.Since(startTime).String()
@@ -540,10 +626,13 @@ func executeQuery(ctx context.Context, db *sqlx.DB, query string) QueryResult {
 	return result
 }
 
-// processSaveConfig processes save configuration to extract values from results
-func processSaveConfig(response *SQLResponse, saveConfig []interface{}) map[string]string {
+// processSaveConfig processes save configuration to extract values from results.
+// sql_result is a jq program over the response, e.g. ".queries[0].rows[0].id" or
+// ".queries[0].rows | map(.email) | join(\",\")".
+func processSaveConfig(response *SQLResponse, saveConfig []interface{}) (map[string]string, error) {
 	savedValues := make(map[string]string)
 
+	var data interface{}
 	for _, saveItem := range saveConfig {
 		saveMap, ok := saveItem.(map[string]interface{})
 		if !ok {
@@ -555,140 +644,220 @@ func processSaveConfig(response *SQLResponse, saveConfig []interface{}) map[stri
 			continue
 		}
 
-		// Extract value based on sql_result configuration
-		if sqlResult, ok := saveMap["sql_result"].(string); ok {
-			value := extractSQLValue(response, sqlResult)
-			if value != "" {
-				savedValues[asValue] = value
+		sqlResult, ok := saveMap["sql_result"].(string)
+		if !ok || sqlResult == "" {
+			continue
+		}
+
+		if data == nil {
+			normalized, err := sqlResultData(response)
+			if err != nil {
+				return nil, fmt.Errorf("failed to prepare SQL results for save: %w", err)
 			}
+			data = normalized
+		}
+
+		v, found, err := saves.Extract(sqlResult, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save %s: %w", asValue, err)
+		}
+		if !found || v == nil {
+			continue
+		}
+
+		value, err := saves.Format(v, saves.Type(saveMap))
+		if err != nil {
+			return nil, fmt.Errorf("failed to save %s: %w", asValue, err)
+		}
+		if value != "" {
+			savedValues[asValue] = value
 		}
 	}
 
-	return savedValues
+	return savedValues, nil
 }
 
-// extractSQLValue extracts values from SQL results using path notation
-func extractSQLValue(response *SQLResponse, path string) string {
-	// Support patterns like:
-	// ".queries[0].rows[0].id" - first query, first row, id column
-	// ".queries[0].rows_affected" - first query rows affected count
-	// ".stats.success_count" - overall success count
-
-	if len(response.Queries) == 0 {
-		return ""
+// sqlResultData converts the response into plain JSON values for jq. Drivers return
+// text columns as []byte, which would otherwise be encoded as base64.
+func sqlResultData(response *SQLResponse) (interface{}, error) {
+	queries := make([]QueryResult, len(response.Queries))
+	for i, query := range response.Queries {
+		rows := make([]map[string]interface{}, len(query.Rows))
+		for j, row := range query.Rows {
+			converted := make(map[string]interface{}, len(row))
+			for column, value := range row {
+				if b, ok := value.([]byte); ok {
+					value = string(b)
+				}
+				converted[column] = value
+			}
+			rows[j] = converted
+		}
+		query.Rows = rows
+		queries[i] = query
 	}
+	return assertions.Normalize(&SQLResponse{Queries: queries, Stats: response.Stats})
+}
 
-	// Simple path parsing for common cases
-	if strings.HasPrefix(path, ".queries[0].rows[0].") {
-		if len(response.Queries[0].Rows) > 0 {
-			columnName := strings.TrimPrefix(path, ".queries[0].rows[0].")
-			if value, ok := response.Queries[0].Rows[0][columnName]; ok {
-				return fmt.Sprintf("%v", value)
+// processAssertions validates SQL response against assertions. A failed soft
+// assertion doesn't stop the step; it's returned among the results instead.
+func processAssertions(response *SQLResponse, assertionList []interface{}) ([]AssertionResult, error) {
+	var subject interface{}
+	var results []AssertionResult
+	for _, assertionInterface := range assertionList {
+		assertion, ok := assertionInterface.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		assertionType, _ := assertion["type"].(string)
+		result := AssertionResult{
+			Type:     assertionType,
+			Expected: assertion["expected"],
+			Passed:   true,
+			Soft:     assertions.IsSoft(assertion),
+		}
+		if err := checkAssertion(response, assertion, &subject); err != nil {
+			if !result.Soft {
+				return nil, err
 			}
+			result.Passed = false
+			result.Message = strings.TrimPrefix(err.Error(), assertionType+": ")
 		}
-	} else if path == ".queries[0].rows_affected" {
-		return fmt.Sprintf("%d", response.Queries[0].RowsAffected)
-	} else if path == ".stats.success_count" {
-		return fmt.Sprintf("%d", response.Stats.SuccessCount)
+		results = append(results, result)
 	}
 
-	return ""
+	return results, nil
 }
 
-// processAssertions validates SQL response against assertions
-func processAssertions(response *SQLResponse, assertions []interface{}) error {
-	for _, assertionInterface := range assertions {
-		assertion, ok := assertionInterface.(map[string]interface{})
+// checkAssertion checks one assertion. subject caches the response as shared
+// assertion types query it.
+func checkAssertion(response *SQLResponse, assertion map[string]interface{}, subject *interface{}) error {
+	assertionType, ok := assertion["type"].(string)
+	if !ok {
+		return fmt.Errorf("assertion type is required")
+	}
+
+	expected := assertion["expected"]
+
+	switch assertionType {
+	case "query_count":
+		expectedCount, ok := expected.(float64) // JSON numbers are float64
 		if !ok {
-			continue
+			return fmt.Errorf("query_count assertion expected must be a number")
+		}
+		if float64(response.Stats.TotalQueries) != expectedCount {
+			return fmt.Errorf("query count assertion failed: expected %v, got %d", expectedCount, response.Stats.TotalQueries)
 		}
 
-		assertionType, ok := assertion["type"].(string)
+	case "success_count":
+		expectedCount, ok := expected.(float64)
 		if !ok {
-			return fmt.Errorf("assertion type is required")
+			return fmt.Errorf("success_count assertion expected must be a number")
+		}
+		if float64(response.Stats.SuccessCount) != expectedCount {
+			return fmt.Errorf("success count assertion failed: expected %v, got %d", expectedCount, response.Stats.SuccessCount)
 		}
 
-		expected := assertion["expected"]
+	case "row_count":
+		queryIndex, ok := assertion["query_index"].(float64)
+		if !ok {
+			return fmt.Errorf("row_count assertion requires query_index")
+		}
+		expectedCount, ok := expected.(float64)
+		if !ok {
+			return fmt.Errorf("row_count assertion expected must be a number")
+		}
 
-		switch assertionType {
-		case "query_count":
-			expectedCount, ok := expected.(float64) // JSON numbers are float64
-			if !ok {
-				return fmt.Errorf("query_count assertion expected must be a number")
-			}
-			if float64(response.Stats.TotalQueries) != expectedCount {
-				return fmt.Errorf("query count assertion failed: expected %v, got %d", expectedCount, response.Stats.TotalQueries)
-			}
+		queryIdx := int(queryIndex)
+		if queryIdx >= len(response.Queries) || queryIdx < 0 {
+			return fmt.Errorf("query_index %d is out of range", queryIdx)
+		}
 
-		case "success_count":
-			expectedCount, ok := expected.(float64)
-			if !ok {
-				return fmt.Errorf("success_count assertion expected must be a number")
-			}
-			if float64(response.Stats.SuccessCount) != expectedCount {
-				return fmt.Errorf("success count assertion failed: expected %v, got %d", expectedCount, response.Stats.SuccessCount)
-			}
+		actualCount := len(response.Queries[queryIdx].Rows)
+		if float64(actualCount) != expectedCount {
+			return fmt.Errorf("row count assertion failed for query %d: expected %v, got %d", queryIdx, expectedCount, actualCount)
+		}
 
-		case "row_count":
-			queryIndex, ok := assertion["query_index"].(float64)
-			if !ok {
-				return fmt.Errorf("row_count assertion requires query_index")
-			}
-			expectedCount, ok := expected.(float64)
-			if !ok {
-				return fmt.Errorf("row_count assertion expected must be a number")
-			}
+	case "column_value":
+		queryIndex, ok := assertion["query_index"].(float64)
+		if !ok {
+			return fmt.Errorf("column_value assertion requires query_index")
+		}
+		rowIndex, ok := assertion["row_index"].(float64)
+		if !ok {
+			return fmt.Errorf("column_value assertion requires row_index")
+		}
+		column, ok := assertion["column"].(string)
+		if !ok {
+			return fmt.Errorf("column_value assertion requires column")
+		}
 
-			queryIdx := int(queryIndex)
-			if queryIdx >= len(response.Queries) || queryIdx < 0 {
-				return fmt.Errorf("query_index %d is out of range", queryIdx)
-			}
+		queryIdx := int(queryIndex)
+		rowIdx := int(rowIndex)
 
-			actualCount := len(response.Queries[queryIdx].Rows)
-			if float64(actualCount) != expectedCount {
-				return fmt.Errorf("row count assertion failed for query %d: expected %v, got %d", queryIdx, expectedCount, actualCount)
-			}
+		if queryIdx >= len(response.Queries) || queryIdx < 0 {
+			return fmt.Errorf("query_index %d is out of range", queryIdx)
+		}
+		if rowIdx >= len(response.Queries[queryIdx].Rows) || rowIdx < 0 {
+			return fmt.Errorf("row_index %d is out of range for query %d", rowIdx, queryIdx)
+		}
 
-		case "column_value":
-			queryIndex, ok := assertion["query_index"].(float64)
-			if !ok {
-				return fmt.Errorf("column_value assertion requires query_index")
-			}
-			rowIndex, ok := assertion["row_index"].(float64)
-			if !ok {
-				return fmt.Errorf("column_value assertion requires row_index")
-			}
-			column, ok := assertion["column"].(string)
-			if !ok {
-				return fmt.Errorf("column_value assertion requires column")
-			}
+		actualValue, exists := response.Queries[queryIdx].Rows[rowIdx][column]
+		if !exists {
+			return fmt.Errorf("column '%s' not found in query %d, row %d", column, queryIdx, rowIdx)
+		}
 
-			queryIdx := int(queryIndex)
-			rowIdx := int(rowIndex)
+		// Convert both values to strings for comparison
+		expectedStr := fmt.Sprintf("%v", expected)
+		actualStr := fmt.Sprintf("%v", actualValue)
 
-			if queryIdx >= len(response.Queries) || queryIdx < 0 {
-				return fmt.Errorf("query_index %d is out of range", queryIdx)
-			}
-			if rowIdx >= len(response.Queries[queryIdx].Rows) || rowIdx < 0 {
-				return fmt.Errorf("row_index %d is out of range for query %d", rowIdx, queryIdx)
-			}
+		if actualStr != expectedStr {
+			return fmt.Errorf("column value assertion failed for query %d, row %d, column '%s': expected %v, got %v", queryIdx, rowIdx, column, expected, actualValue)
+		}
 
-			actualValue, exists := response.Queries[queryIdx].Rows[rowIdx][column]
-			if !exists {
-				return fmt.Errorf("column '%s' not found in query %d, row %d", column, queryIdx, rowIdx)
-			}
+	case "rows":
+		queryIndex, ok := assertion["query_index"].(float64)
+		if !ok {
+			return fmt.Errorf("rows assertion requires query_index")
+		}
+		expectedRows, ok := expected.([]interface{})
+		if !ok {
+			return fmt.Errorf("rows assertion expected must be a list of rows")
+		}
+		opts, err := parseRowsOptions(assertion)
+		if err != nil {
+			return err
+		}
 
-			// Convert both values to strings for comparison
-			expectedStr := fmt.Sprintf("%v", expected)
-			actualStr := fmt.Sprintf("%v", actualValue)
+		queryIdx := int(queryIndex)
+		if queryIdx >= len(response.Queries) || queryIdx < 0 {
+			return fmt.Errorf("query_index %d is out of range", queryIdx)
+		}
 
-			if actualStr != expectedStr {
-				return fmt.Errorf("column value assertion failed for query %d, row %d, column '%s': expected %v, got %v", queryIdx, rowIdx, column, expected, actualValue)
-			}
+		diffs, err := diffRows(response.Queries[queryIdx].Rows, expectedRows, opts)
+		if err != nil {
+			return err
+		}
+		if len(diffs) > 0 {
+			return fmt.Errorf("%s", formatRowDiffs(queryIdx, diffs))
+		}
 
-		default:
+	default:
+		if !assertions.IsShared(assertionType) {
 			return fmt.Errorf("unsupported assertion type: %s", assertionType)
 		}
+		// Shared assertion types run against the response, e.g. path ".queries[0].rows[0].email"
+		if *subject == nil {
+			normalized, err := sqlResultData(response)
+			if err != nil {
+				return fmt.Errorf("failed to read SQL response: %w", err)
+			}
+			*subject = normalized
+		}
+		if result := assertions.Evaluate(assertion, *subject, expected); !result.Passed {
+			return fmt.Errorf("%s: %s", result.Type, result.Message)
+		}
 	}
 
 	return nil
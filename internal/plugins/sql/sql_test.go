@@ -1,8 +1,16 @@
 package sql
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/dsl"
 )
 
 func TestApplyVariableReplacementToAssertions(t *testing.T) {
@@ -21,12 +29,17 @@ func TestApplyVariableReplacementToAssertions(t *testing.T) {
 			"type":     "column_value",
 			"expected": "Escaped literal: {{ placeholder }}",
 		},
+		map[string]interface{}{
+			"type":     "between",
+			"expected": []interface{}{float64(1), "{{ .vars.max_rows }}"},
+		},
 	}
 
 	state := map[string]interface{}{}
 	env := map[string]string{}
 	vars := map[string]interface{}{
-		"service": "sql",
+		"service":  "sql",
+		"max_rows": 10,
 	}
 
 	if err := applyVariableReplacementToAssertions(assertions, state, env, vars); err != nil {
@@ -47,6 +60,11 @@ func TestApplyVariableReplacementToAssertions(t *testing.T) {
 	if got2 != "Escaped literal: {{ placeholder }}" {
 		t.Fatalf("expected literal handlebars to remain unchanged, got %q", got2)
 	}
+
+	got3 := assertions[3].(map[string]interface{})["expected"].([]interface{})
+	if got3[0] != float64(1) || got3[1] != "10" {
+		t.Fatalf("expected substitution in the bounds of between, got %v", got3)
+	}
 }
 
 func TestParseSQLFile(t *testing.T) {
@@ -170,3 +188,388 @@ DELETE FROM users WHERE email = 'test;user@example.com'`
 		}
 	}
 }
+
+func TestProcessSaveConfig(t *testing.T) {
+	response := &SQLResponse{
+		Queries: []QueryResult{{
+			RowsAffected: 2,
+			Rows: []map[string]interface{}{
+				{"id": int64(7), "email": []byte("a@example.com")},
+				{"id": int64(8), "email": []byte("b@example.com")},
+			},
+		}},
+		Stats: ExecutionStats{SuccessCount: 1},
+	}
+
+	saved, err := processSaveConfig(response, []interface{}{
+		map[string]interface{}{"sql_result": ".queries[0].rows[0].id", "as": "first_id"},
+		map[string]interface{}{"sql_result": `.queries[0].rows | map(.email) | join(",")`, "as": "emails"},
+		map[string]interface{}{"sql_result": ".queries[0].rows_affected", "as": "affected", "type": "int"},
+		map[string]interface{}{"sql_result": ".queries[0].rows[5].id", "as": "missing"},
+	})
+	if err != nil {
+		t.Fatalf("processSaveConfig: %v", err)
+	}
+
+	want := map[string]string{"first_id": "7", "emails": "a@example.com,b@example.com", "affected": "2"}
+	if !reflect.DeepEqual(saved, want) {
+		t.Errorf("saved = %v, want %v", saved, want)
+	}
+
+	_, err = processSaveConfig(response, []interface{}{
+		map[string]interface{}{"sql_result": ".queries[0].rows[0].email", "as": "email", "type": "int"},
+	})
+	if err == nil {
+		t.Error("expected an error coercing an email to int")
+	}
+}
+
+func TestBigintPrecision(t *testing.T) {
+	// Past 2^53, where float64 would round 1234567890123456789 to ...800
+	response := &SQLResponse{
+		Queries: []QueryResult{{
+			Rows: []map[string]interface{}{{"id": int64(1234567890123456789)}},
+		}},
+	}
+
+	saved, err := processSaveConfig(response, []interface{}{
+		map[string]interface{}{"sql_result": ".queries[0].rows[0].id", "as": "id"},
+		map[string]interface{}{"sql_result": ".queries[0].rows[0].id", "as": "id_int", "type": "int"},
+	})
+	if err != nil {
+		t.Fatalf("processSaveConfig: %v", err)
+	}
+	if saved["id"] != "1234567890123456789" || saved["id_int"] != "1234567890123456789" {
+		t.Errorf("expected the bigint to be saved exactly, got %v", saved)
+	}
+
+	_, err = processAssertions(response, []interface{}{
+		map[string]interface{}{"type": "equals", "path": ".queries[0].rows[0].id", "expected": "1234567890123456789"},
+	})
+	if err != nil {
+		t.Errorf("expected the bigint to match exactly: %v", err)
+	}
+	_, err = processAssertions(response, []interface{}{
+		map[string]interface{}{"type": "equals", "path": ".queries[0].rows[0].id", "expected": "1234567890123456800"},
+	})
+	if err == nil {
+		t.Error("expected a neighbouring bigint not to match")
+	}
+}
+
+func TestProcessAssertionsSoft(t *testing.T) {
+	response := &SQLResponse{
+		Queries: []QueryResult{{
+			Rows: []map[string]interface{}{{"status": "pending", "total": int64(12)}},
+		}},
+		Stats: ExecutionStats{TotalQueries: 1, SuccessCount: 1},
+	}
+
+	results, err := processAssertions(response, []interface{}{
+		map[string]interface{}{"type": "equals", "path": ".queries[0].rows[0].status", "expected": "paid", "soft": true},
+		map[string]interface{}{"type": "row_count", "query_index": float64(0), "expected": float64(2), "soft": true},
+		map[string]interface{}{"type": "between", "path": ".queries[0].rows[0].total", "expected": []interface{}{float64(1), float64(20)}},
+	})
+	if err != nil {
+		t.Fatalf("expected soft failures not to fail the step: %v", err)
+	}
+	if len(results) != 3 || results[0].Passed || results[1].Passed || !results[2].Passed {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	if results[0].Message != `expected "paid", got "pending"` {
+		t.Errorf("unexpected message %q", results[0].Message)
+	}
+	if !results[1].Soft || results[2].Soft {
+		t.Errorf("expected only the soft assertions to be marked soft, got %+v", results)
+	}
+
+	_, err = processAssertions(response, []interface{}{
+		map[string]interface{}{"type": "equals", "path": ".queries[0].rows[0].status", "expected": "paid"},
+	})
+	if err == nil {
+		t.Error("expected a failed assertion that isn't soft to fail the step")
+	}
+}
+
+func TestBindParams(t *testing.T) {
+	query := "SELECT * FROM users WHERE email = :email AND created_at > :since::timestamp AND note <> ':literal' -- :comment\nAND id = :id OR owner = :email"
+	params := map[string]interface{}{"email": "o'brien@example.com", "since": "2024-01-01", "id": int64(7)}
+
+	tests := []struct {
+		driver   string
+		expected string
+	}{
+		{"postgres", "SELECT * FROM users WHERE email = $1 AND created_at > $2::timestamp AND note <> ':literal' -- :comment\nAND id = $3 OR owner = $4"},
+		{"mysql", "SELECT * FROM users WHERE email = ? AND created_at > ?::timestamp AND note <> ':literal' -- :comment\nAND id = ? OR owner = ?"},
+		{"sqlserver", "SELECT * FROM users WHERE email = @p1 AND created_at > @p2::timestamp AND note <> ':literal' -- :comment\nAND id = @p3 OR owner = @p4"},
+	}
+	for _, tt := range tests {
+		bound, args, err := bindParams(tt.driver, query, params)
+		if err != nil {
+			t.Fatalf("%s: bindParams() error = %v", tt.driver, err)
+		}
+		if bound != tt.expected {
+			t.Errorf("%s: got query %q", tt.driver, bound)
+		}
+		want := []interface{}{"o'brien@example.com", "2024-01-01", int64(7), "o'brien@example.com"}
+		if !reflect.DeepEqual(args, want) {
+			t.Errorf("%s: got args %v", tt.driver, args)
+		}
+	}
+
+	if _, _, err := bindParams("postgres", "SELECT :missing", map[string]interface{}{}); err == nil {
+		t.Error("expected an error for a name without a value")
+	}
+	positional := []interface{}{"a", int64(1)}
+	if bound, args, _ := bindParams("postgres", "SELECT $1, $2", positional); bound != "SELECT $1, $2" || !reflect.DeepEqual(args, positional) {
+		t.Errorf("expected positional params to pass through, got %q %v", bound, args)
+	}
+}
+
+func TestProcessParams(t *testing.T) {
+	context := dsl.TemplateContext{Runtime: map[string]interface{}{"user_name": "O'Brien"}}
+	raw := []interface{}{"{{ user_name }}", float64(42), 1.5, true, nil, map[string]interface{}{"tier": "gold"}}
+
+	processed, err := processParams(raw, context)
+	if err != nil {
+		t.Fatalf("processParams() error = %v", err)
+	}
+	want := []interface{}{"O'Brien", int64(42), 1.5, true, nil, `{"tier":"gold"}`}
+	if !reflect.DeepEqual(processed, want) {
+		t.Errorf("processParams() = %#v, want %#v", processed, want)
+	}
+
+	if _, err := parseParams("not params"); err == nil {
+		t.Error("expected an error for params that are neither a list nor an object")
+	}
+}
+
+func TestResolveDriver(t *testing.T) {
+	tests := []struct {
+		name       string
+		driver     string
+		dsn        string
+		wantDriver string
+		wantDSN    string
+		wantErr    bool
+	}{
+		{"postgres url", "", "postgres://u:p@db:5432/app?sslmode=disable", "postgres", "postgres://u:p@db:5432/app?sslmode=disable", false},
+		{"postgresql url", "", "postgresql://db/app", "postgres", "postgresql://db/app", false},
+		{"mysql url", "", "mysql://u:p@db/app?parseTime=true", "mysql", "u:p@tcp(db:3306)/app?parseTime=true", false},
+		{"mysql native dsn", "mysql", "u:p@tcp(db:3306)/app", "mysql", "u:p@tcp(db:3306)/app", false},
+		{"sqlserver url", "", "sqlserver://sa:p@db:1433?database=app", "sqlserver", "sqlserver://sa:p@db:1433?database=app", false},
+		{"mssql url", "", "mssql://sa:p@db:1433?database=app", "sqlserver", "sqlserver://sa:p@db:1433?database=app", false},
+		{"mssql alias", "mssql", "sqlserver://sa:p@db", "sqlserver", "sqlserver://sa:p@db", false},
+		{"sqlite url", "", "sqlite://./tmp/test.db", "sqlite", "./tmp/test.db", false},
+		{"sqlite absolute url", "", "sqlite:///var/data/test.db", "sqlite", "/var/data/test.db", false},
+		{"sqlite file uri", "", "file:test.db?mode=memory", "sqlite", "file:test.db?mode=memory", false},
+		{"sqlite path", "sqlite", "./test.db", "sqlite", "./test.db", false},
+		{"oracle", "", "oracle://u:p@db:1521/orcl", "", "", true},
+		{"no scheme or driver", "", "u:p@tcp(db:3306)/app", "", "", true},
+		{"unknown driver", "db2", "db2://db", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driverName, dsn, err := resolveDriver(tt.driver, tt.dsn)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveDriver() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if driverName != tt.wantDriver || dsn != tt.wantDSN {
+				t.Errorf("resolveDriver() = %q, %q, want %q, %q", driverName, dsn, tt.wantDriver, tt.wantDSN)
+			}
+		})
+	}
+}
+
+func TestReadFixture(t *testing.T) {
+	csvFile := "users.csv"
+	csvContent := "id,full_name,email\n1,\"O'Brien, Pat\",pat@example.com\n2,Sam,\n"
+	jsonFile := "orders.json"
+	jsonContent := `[{"id": 1, "total": 9.5, "meta": {"gift": true}}, {"id": 2}]`
+
+	columns, rows, err := readFixture(&FixtureConfig{File: csvFile, Content: csvContent})
+	if err != nil {
+		t.Fatalf("readFixture(csv) error = %v", err)
+	}
+	if !reflect.DeepEqual(columns, []string{"id", "full_name", "email"}) {
+		t.Errorf("csv columns = %v", columns)
+	}
+	want := [][]interface{}{{"1", "O'Brien, Pat", "pat@example.com"}, {"2", "Sam", nil}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("csv rows = %#v, want %#v", rows, want)
+	}
+
+	columns, rows, err = readFixture(&FixtureConfig{File: csvFile, Content: csvContent, Columns: map[string]string{"email": "email_address", "full_name": "name"}})
+	if err != nil {
+		t.Fatalf("readFixture(mapped csv) error = %v", err)
+	}
+	if !reflect.DeepEqual(columns, []string{"name", "email_address"}) {
+		t.Errorf("mapped columns = %v", columns)
+	}
+	want = [][]interface{}{{"O'Brien, Pat", "pat@example.com"}, {"Sam", nil}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("mapped rows = %#v, want %#v", rows, want)
+	}
+
+	columns, rows, err = readFixture(&FixtureConfig{File: jsonFile, Content: jsonContent})
+	if err != nil {
+		t.Fatalf("readFixture(json) error = %v", err)
+	}
+	if !reflect.DeepEqual(columns, []string{"id", "meta", "total"}) {
+		t.Errorf("json columns = %v", columns)
+	}
+	want = [][]interface{}{{int64(1), `{"gift":true}`, 9.5}, {int64(2), nil, nil}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("json rows = %#v, want %#v", rows, want)
+	}
+
+	if _, _, err := readFixture(&FixtureConfig{File: csvFile, Content: csvContent, Columns: map[string]string{"phone": "phone"}}); err == nil {
+		t.Error("expected an error mapping a field the fixture doesn't have")
+	}
+	if _, _, err := readFixture(&FixtureConfig{File: csvFile, Content: csvContent, Format: "xml"}); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+
+	if _, err := parseFixture(map[string]interface{}{"file": csvFile, "table": "users"}); err == nil {
+		t.Error("expected an error for a fixture that wasn't inlined when the suite was loaded")
+	}
+}
+
+func TestInsertStatement(t *testing.T) {
+	tests := []struct {
+		driver   string
+		expected string
+	}{
+		{"postgres", `INSERT INTO "app"."users" ("id", "name") VALUES ($1, $2), ($3, $4)`},
+		{"mysql", "INSERT INTO `app`.`users` (`id`, `name`) VALUES (?, ?), (?, ?)"},
+		{"sqlserver", "INSERT INTO [app].[users] ([id], [name]) VALUES (@p1, @p2), (@p3, @p4)"},
+		{"sqlite", `INSERT INTO "app"."users" ("id", "name") VALUES (?, ?), (?, ?)`},
+	}
+	for _, tt := range tests {
+		if got := insertStatement(tt.driver, "app.users", []string{"id", "name"}, 2); got != tt.expected {
+			t.Errorf("%s: insertStatement() = %q, want %q", tt.driver, got, tt.expected)
+		}
+	}
+}
+
+func TestDiffRows(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 30, 45, 123456000, time.UTC)
+	actual := []map[string]interface{}{
+		{"id": int64(1), "name": []byte("Ada"), "balance": []byte("10.004"), "created_at": created},
+		{"id": int64(2), "name": []byte("Bob"), "balance": []byte("3.50"), "created_at": created},
+	}
+
+	expected := []interface{}{
+		map[string]interface{}{"id": float64(1), "name": "Ada", "balance": 10.0, "created_at": "2024-03-01T12:30:45Z"},
+		map[string]interface{}{"id": float64(2), "name": "Bob", "balance": 3.5},
+	}
+	opts := rowsOptions{tolerance: 0.01, precision: time.Second}
+	if diffs, err := diffRows(actual, expected, opts); err != nil || len(diffs) != 0 {
+		t.Errorf("expected rows to match with tolerance and precision, got %v, %v", diffs, err)
+	}
+
+	diffs, _ := diffRows(actual, expected, rowsOptions{})
+	want := []string{
+		"row 0: balance: expected 10, got \"10.004\"",
+		"row 0: created_at: expected \"2024-03-01T12:30:45Z\", got 2024-03-01T12:30:45.123456Z",
+	}
+	if !reflect.DeepEqual(diffs, want) {
+		t.Errorf("exact comparison diffs = %q, want %q", diffs, want)
+	}
+
+	reordered := []interface{}{
+		map[string]interface{}{"name": "Bob"},
+		map[string]interface{}{"name": "Ada"},
+	}
+	if diffs, _ := diffRows(actual, reordered, rowsOptions{}); len(diffs) != 2 {
+		t.Errorf("expected both rows to differ in order, got %q", diffs)
+	}
+	if diffs, _ := diffRows(actual, reordered, rowsOptions{ignoreOrder: true}); len(diffs) != 0 {
+		t.Errorf("expected rows to match in any order, got %q", diffs)
+	}
+
+	diffs, _ = diffRows(actual, []interface{}{map[string]interface{}{"name": "Ada"}, map[string]interface{}{"name": "Cy"}, map[string]interface{}{"email": nil}}, rowsOptions{ignoreOrder: true})
+	want = []string{
+		`expected row 1 not found: {"name":"Cy"}`,
+		`expected row 2 not found: {"email":null}`,
+		`row 1: unexpected {"balance":"3.50","created_at":"2024-03-01T12:30:45.123456Z","id":2,"name":"Bob"}`,
+	}
+	if !reflect.DeepEqual(diffs, want) {
+		t.Errorf("unordered diffs = %q, want %q", diffs, want)
+	}
+
+	diffs, _ = diffRows(actual[:1], expected, rowsOptions{tolerance: 0.01, precision: time.Second})
+	if len(diffs) != 1 || !strings.HasPrefix(diffs[0], "row 1: missing, expected ") {
+		t.Errorf("expected a missing row, got %q", diffs)
+	}
+}
+
+// poolTestDriver opens connections that support nothing but being pinged
+type poolTestDriver struct{}
+
+func (poolTestDriver) Open(string) (driver.Conn, error) { return poolTestConn{}, nil }
+
+type poolTestConn struct{}
+
+func (poolTestConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (poolTestConn) Close() error                        { return nil }
+func (poolTestConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func init() {
+	sql.Register("pooltest", poolTestDriver{})
+}
+
+func TestAcquireDB(t *testing.T) {
+	ctx := context.Background()
+
+	first, releaseFirst, reused, err := acquireDB(ctx, "test-run-1", "pooltest", "db", nil)
+	if err != nil {
+		t.Fatalf("acquireDB() error = %v", err)
+	}
+	if reused {
+		t.Error("expected the first step to open the pool")
+	}
+	releaseFirst()
+
+	second, releaseSecond, reused, err := acquireDB(ctx, "test-run-1", "pooltest", "db", nil)
+	if err != nil {
+		t.Fatalf("acquireDB() error = %v", err)
+	}
+	if !reused || second != first {
+		t.Error("expected the second step of the test to reuse the pool")
+	}
+
+	other, releaseOther, reused, err := acquireDB(ctx, "test-run-2", "pooltest", "db", nil)
+	if err != nil {
+		t.Fatalf("acquireDB() error = %v", err)
+	}
+	if reused || other == first {
+		t.Error("expected another test to get its own pool")
+	}
+	releaseOther()
+
+	key := "test-run-1\x00pooltest\x00db"
+	pools.Lock()
+	pooled := pools.byKey[key]
+	pools.Unlock()
+	closeIdlePool(key, pooled)
+	pools.Lock()
+	_, open := pools.byKey[key]
+	pools.Unlock()
+	if !open {
+		t.Error("expected a pool in use not to be closed")
+	}
+
+	releaseSecond()
+	releaseSecond() // Releasing twice is harmless
+	closeIdlePool(key, pooled)
+	pools.Lock()
+	_, open = pools.byKey[key]
+	pools.Unlock()
+	if open {
+		t.Error("expected an idle pool to be closed")
+	}
+	if err := first.PingContext(ctx); err == nil {
+		t.Error("expected the closed pool to refuse pings")
+	}
+}
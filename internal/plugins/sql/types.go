@@ -1,5 +1,7 @@
 package sql
 
+import "github.com/rocketship-ai/rocketship/internal/assertions"
+
 // SQLPlugin represents the SQL plugin configuration
 type SQLPlugin struct {
 	Name   string    `json:"name" yaml:"name"`
@@ -14,14 +16,39 @@ type SQLConfig struct {
 	Commands []string `json:"commands,omitempty" yaml:"commands,omitempty"` // Inline SQL commands
 	File     string   `json:"file,omitempty" yaml:"file,omitempty"`         // External SQL file
 	Timeout  string   `json:"timeout,omitempty" yaml:"timeout,omitempty"`   // Query timeout (e.g., "30s")
+	// Params are bound to placeholders: a list for positional ones, an object for :name ones
+	Params interface{} `json:"params,omitempty" yaml:"params,omitempty"`
+	// Fixture rows are loaded into a table before the commands run
+	Fixture *FixtureConfig `json:"load_fixture,omitempty" yaml:"load_fixture,omitempty"`
+}
+
+// FixtureConfig defines a load_fixture operation
+type FixtureConfig struct {
+	File      string            `json:"file" yaml:"file"`                                 // CSV or JSON file of rows
+	Content   string            `json:"content" yaml:"content"`                           // The file's content, inlined when the suite is loaded
+	Table     string            `json:"table" yaml:"table"`                               // Table to insert into
+	Format    string            `json:"format,omitempty" yaml:"format,omitempty"`         // csv or json, from the file extension by default
+	Columns   map[string]string `json:"columns,omitempty" yaml:"columns,omitempty"`       // Fixture field to table column; only mapped fields load
+	Truncate  bool              `json:"truncate,omitempty" yaml:"truncate,omitempty"`     // Delete the table's rows first
+	BatchSize int               `json:"batch_size,omitempty" yaml:"batch_size,omitempty"` // Rows per INSERT statement
 }
 
 // SQLResponse represents the response from SQL operations
 type SQLResponse struct {
+	Fixture *FixtureResult `json:"fixture,omitempty"`
 	Queries []QueryResult  `json:"queries"`
 	Stats   ExecutionStats `json:"stats"`
 }
 
+// FixtureResult represents the result of a load_fixture operation
+type FixtureResult struct {
+	File      string `json:"file"`
+	Table     string `json:"table"`
+	Rows      int64  `json:"rows"`
+	Truncated bool   `json:"truncated"`
+	Duration  string `json:"duration"`
+}
+
 // QueryResult represents the result of a single SQL query
 type QueryResult struct {
 	Query        string                   `json:"query"`
@@ -38,10 +65,16 @@ type ExecutionStats struct {
 	ErrorCount     int    `json:"error_count"`
 	TotalDuration  string `json:"total_duration"`
 	ConnectionTime string `json:"connection_time"`
+	// ConnectionReused is set when an earlier step of the test opened the pool
+	ConnectionReused bool `json:"connection_reused"`
 }
 
 // ActivityResponse represents the complete activity response
 type ActivityResponse struct {
-	Response *SQLResponse      `json:"response"`
-	Saved    map[string]string `json:"saved"`
+	Response         *SQLResponse      `json:"response"`
+	Saved            map[string]string `json:"saved"`
+	AssertionResults []AssertionResult `json:"assertion_results,omitempty"`
 }
+
+// AssertionResult represents a single assertion result for UI display
+type AssertionResult = assertions.Result
@@ -0,0 +1,447 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+	"go.temporal.io/sdk/activity"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	defaultPort    = 22
+	defaultTimeout = 30 * time.Second
+)
+
+// Auto-register the plugin when the package is imported
+func init() {
+	plugins.RegisterPlugin(&SSHPlugin{})
+}
+
+// GetType returns the plugin type identifier
+func (sp *SSHPlugin) GetType() string {
+	return "ssh"
+}
+
+// Activity connects to the host, runs the command and checks its output and exit status
+func (sp *SSHPlugin) Activity(ctx context.Context, p map[string]interface{}) (interface{}, error) {
+	logger := activity.GetLogger(ctx)
+
+	// Parse configuration from parameters
+	configData, ok := p["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid config format")
+	}
+
+	config := &SSHConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		return nil, fmt.Errorf("failed to parse ssh config: %w", err)
+	}
+
+	timeout := defaultTimeout
+	if config.Timeout != "" {
+		parsed, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", config.Timeout, err)
+		}
+		timeout = parsed
+	}
+
+	// Convert state to map[string]interface{} for template processing
+	state := make(map[string]interface{})
+	if stateStr, ok := p["state"].(map[string]string); ok {
+		for k, v := range stateStr {
+			state[k] = v
+		}
+	} else if stateInt, ok := p["state"].(map[string]interface{}); ok {
+		state = stateInt
+	}
+
+	// Extract env secrets from params (for {{ .env.* }} template resolution)
+	env := make(map[string]string)
+	if envData, ok := p["env"].(map[string]interface{}); ok {
+		for k, v := range envData {
+			if strVal, ok := v.(string); ok {
+				env[k] = strVal
+			}
+		}
+	} else if envData, ok := p["env"].(map[string]string); ok {
+		env = envData
+	}
+
+	if err := applyVariableReplacement(config, state, env); err != nil {
+		return nil, fmt.Errorf("variable replacement failed: %w", err)
+	}
+
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Executing ssh plugin", "host", config.Host, "user", config.User)
+
+	policy, err := egress.FromParams(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
+
+	response, err := runCommand(ctx, config, timeout, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	// A failing command fails the step unless the test asserts on the exit code itself
+	if response.ExitCode != 0 && !hasExitCodeAssertion(p) {
+		return nil, fmt.Errorf("command exited with status %d: %s", response.ExitCode, lastLine(response.Stderr))
+	}
+
+	subject := resultSubject(response)
+
+	assertionResults, err := processAssertions(p, response, subject, state, env)
+	if err != nil {
+		return nil, err
+	}
+	if failure := assertions.Summary(assertionResults); failure != "" {
+		return nil, fmt.Errorf("%s", failure)
+	}
+
+	saved := make(map[string]string)
+	if err := saves.Process(p, subject, saved); err != nil {
+		return nil, err
+	}
+
+	logger.Info("SSH command completed", "exit_code", response.ExitCode, "duration", response.Duration)
+
+	return &ActivityResponse{
+		Response:         response,
+		Saved:            saved,
+		AssertionResults: assertionResults,
+	}, nil
+}
+
+// runCommand opens an SSH session and runs the configured command to completion
+func runCommand(ctx context.Context, config *SSHConfig, timeout time.Duration, policy *egress.Policy) (*SSHResponse, error) {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	clientConfig, err := buildClientConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	port := config.Port
+	if port == 0 {
+		port = defaultPort
+	}
+	addr := net.JoinHostPort(config.Host, strconv.Itoa(port))
+
+	conn, err := policy.DialContext(&net.Dialer{})(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	// Closing the connection unblocks the handshake or the running command on timeout
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	clientConn, chans, reqs, err := gossh.NewClientConn(conn, addr, clientConfig)
+	if err != nil {
+		_ = conn.Close()
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("ssh handshake with %s timed out after %s", addr, timeout)
+		}
+		return nil, fmt.Errorf("ssh handshake with %s failed: %w", addr, err)
+	}
+	client := gossh.NewClient(clientConn, chans, reqs)
+	defer func() { _ = client.Close() }()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ssh session: %w", err)
+	}
+	defer func() { _ = session.Close() }()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	if config.Stdin != "" {
+		session.Stdin = strings.NewReader(config.Stdin)
+	}
+
+	exitCode := 0
+	if err := session.Run(config.Command); err != nil {
+		var exitErr *gossh.ExitError
+		switch {
+		case errors.As(err, &exitErr):
+			exitCode = exitErr.ExitStatus()
+		case ctx.Err() != nil:
+			return nil, fmt.Errorf("command timed out after %s", timeout)
+		default:
+			return nil, fmt.Errorf("command failed: %w", err)
+		}
+	}
+
+	return &SSHResponse{
+		Host:     addr,
+		Command:  config.Command,
+		Stdout:   strings.TrimRight(stdout.String(), "\r\n"),
+		Stderr:   strings.TrimRight(stderr.String(), "\r\n"),
+		ExitCode: exitCode,
+		Duration: time.Since(start).String(),
+	}, nil
+}
+
+// buildClientConfig sets up authentication and host key verification
+func buildClientConfig(config *SSHConfig) (*gossh.ClientConfig, error) {
+	var methods []gossh.AuthMethod
+
+	keyPEM := []byte(config.PrivateKey)
+	if len(keyPEM) == 0 && config.PrivateKeyFile != "" {
+		data, err := os.ReadFile(config.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private_key_file: %w", err)
+		}
+		keyPEM = data
+	}
+	if len(keyPEM) > 0 {
+		var signer gossh.Signer
+		var err error
+		if config.Passphrase != "" {
+			signer, err = gossh.ParsePrivateKeyWithPassphrase(keyPEM, []byte(config.Passphrase))
+		} else {
+			signer, err = gossh.ParsePrivateKey(keyPEM)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+		methods = append(methods, gossh.PublicKeys(signer))
+	}
+	if config.Password != "" {
+		methods = append(methods, gossh.Password(config.Password))
+	}
+
+	hostKeyCallback, err := buildHostKeyCallback(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gossh.ClientConfig{
+		User:            config.User,
+		Auth:            methods,
+		HostKeyCallback: hostKeyCallback,
+	}, nil
+}
+
+func buildHostKeyCallback(config *SSHConfig) (gossh.HostKeyCallback, error) {
+	switch {
+	case config.InsecureIgnoreHostKey:
+		return gossh.InsecureIgnoreHostKey(), nil
+
+	case config.KnownHosts != "":
+		callback, err := knownhosts.New(config.KnownHosts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load known_hosts: %w", err)
+		}
+		return callback, nil
+
+	case strings.HasPrefix(config.HostKey, "SHA256:"):
+		want := config.HostKey
+		return func(_ string, _ net.Addr, key gossh.PublicKey) error {
+			if got := gossh.FingerprintSHA256(key); got != want {
+				return fmt.Errorf("host key fingerprint %s does not match %s", got, want)
+			}
+			return nil
+		}, nil
+
+	default:
+		key, _, _, _, err := gossh.ParseAuthorizedKey([]byte(config.HostKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse host_key: %w", err)
+		}
+		return gossh.FixedHostKey(key), nil
+	}
+}
+
+// validateConfig checks required fields once templates are rendered
+func validateConfig(config *SSHConfig) error {
+	switch {
+	case config.Host == "":
+		return fmt.Errorf("host is required")
+	case config.User == "":
+		return fmt.Errorf("user is required")
+	case config.Command == "":
+		return fmt.Errorf("command is required")
+	case config.Password == "" && config.PrivateKey == "" && config.PrivateKeyFile == "":
+		return fmt.Errorf("one of password, private_key or private_key_file is required")
+	}
+
+	verifiers := 0
+	for _, set := range []bool{config.HostKey != "", config.KnownHosts != "", config.InsecureIgnoreHostKey} {
+		if set {
+			verifiers++
+		}
+	}
+	if verifiers != 1 {
+		return fmt.Errorf("exactly one of host_key, known_hosts or insecure_ignore_host_key is required")
+	}
+	return nil
+}
+
+// resultSubject is what assertions with a path and saves run against. stdout is also
+// decoded into json when it holds a JSON document.
+func resultSubject(response *SSHResponse) map[string]interface{} {
+	subject := map[string]interface{}{
+		"stdout":    response.Stdout,
+		"stderr":    response.Stderr,
+		"exit_code": float64(response.ExitCode),
+	}
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(response.Stdout), &decoded); err == nil {
+		subject["json"] = decoded
+	}
+	return subject
+}
+
+func hasExitCodeAssertion(p map[string]interface{}) bool {
+	assertionList, _ := p["assertions"].([]interface{})
+	for _, assertion := range assertionList {
+		if assertionMap, ok := assertion.(map[string]interface{}); ok && assertionMap["type"] == AssertionTypeExitCode {
+			return true
+		}
+	}
+	return false
+}
+
+func lastLine(s string) string {
+	if s == "" {
+		return "(no stderr output)"
+	}
+	if i := strings.LastIndex(s, "\n"); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
+
+// processAssertions evaluates all assertions and returns their results.
+// Shared assertions without a path check stdout.
+func processAssertions(p map[string]interface{}, response *SSHResponse, subject map[string]interface{}, state map[string]interface{}, env map[string]string) ([]AssertionResult, error) {
+	context := dsl.TemplateContext{Runtime: state, Env: env}
+	return assertions.Process(p, subject, context, func(assertionMap map[string]interface{}, result *AssertionResult) bool {
+		return evaluateAssertion(response, subject, assertionMap, result)
+	})
+}
+
+// evaluateAssertion evaluates the ssh plugin's own assertion types
+func evaluateAssertion(response *SSHResponse, subject map[string]interface{}, assertionMap map[string]interface{}, result *AssertionResult) bool {
+	expected := result.Expected
+	switch result.Type {
+	case AssertionTypeExitCode:
+		result.Actual = response.ExitCode
+		if !assertions.Equal(float64(response.ExitCode), expected) {
+			result.Message = fmt.Sprintf("expected exit code %v, got %d", expected, response.ExitCode)
+			if response.Stderr != "" {
+				result.Message += ": " + lastLine(response.Stderr)
+			}
+		} else {
+			result.Passed = true
+		}
+
+	default:
+		if _, hasPath := assertionMap["path"]; hasPath {
+			*result = assertions.Evaluate(assertionMap, subject, expected)
+		} else {
+			*result = assertions.Evaluate(assertionMap, response.Stdout, expected)
+		}
+	}
+	return true
+}
+
+// applyVariableReplacement processes templates in the connection settings, credentials and command
+func applyVariableReplacement(config *SSHConfig, state map[string]interface{}, env map[string]string) error {
+	context := dsl.TemplateContext{
+		Runtime: state,
+		Env:     env,
+	}
+
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"host", &config.Host},
+		{"user", &config.User},
+		{"command", &config.Command},
+		{"stdin", &config.Stdin},
+		{"password", &config.Password},
+		{"private_key", &config.PrivateKey},
+		{"private_key_file", &config.PrivateKeyFile},
+		{"passphrase", &config.Passphrase},
+		{"host_key", &config.HostKey},
+		{"known_hosts", &config.KnownHosts},
+	}
+	for _, field := range fields {
+		if *field.value == "" {
+			continue
+		}
+		processed, err := dsl.ProcessTemplate(*field.value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process %s template: %w", field.name, err)
+		}
+		*field.value = processed
+	}
+
+	return nil
+}
+
+// parseConfig converts map[string]interface{} to SSHConfig
+func parseConfig(configData map[string]interface{}, config *SSHConfig) error {
+	stringFields := map[string]*string{
+		"host":             &config.Host,
+		"user":             &config.User,
+		"command":          &config.Command,
+		"stdin":            &config.Stdin,
+		"timeout":          &config.Timeout,
+		"password":         &config.Password,
+		"private_key":      &config.PrivateKey,
+		"private_key_file": &config.PrivateKeyFile,
+		"passphrase":       &config.Passphrase,
+		"host_key":         &config.HostKey,
+		"known_hosts":      &config.KnownHosts,
+	}
+	for key, target := range stringFields {
+		if v, ok := configData[key].(string); ok {
+			*target = v
+		}
+	}
+
+	switch port := configData["port"].(type) {
+	case nil:
+	case float64:
+		config.Port = int(port)
+	case int:
+		config.Port = port
+	default:
+		return fmt.Errorf("port must be a number, got %T", port)
+	}
+	if config.Port < 0 || config.Port > 65535 {
+		return fmt.Errorf("port %d is out of range", config.Port)
+	}
+
+	if insecure, ok := configData["insecure_ignore_host_key"].(bool); ok {
+		config.InsecureIgnoreHostKey = insecure
+	}
+
+	return nil
+}
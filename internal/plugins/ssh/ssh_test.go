@@ -0,0 +1,232 @@
+package ssh
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// startServer runs an SSH server that accepts password "secret" and answers a few fixed commands
+func startServer(t *testing.T) (string, int, gossh.PublicKey) {
+	t.Helper()
+
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostSigner, err := gossh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverConfig := &gossh.ServerConfig{
+		PasswordCallback: func(conn gossh.ConnMetadata, password []byte) (*gossh.Permissions, error) {
+			if conn.User() == "deploy" && string(password) == "secret" {
+				return nil, nil
+			}
+			return nil, io.EOF
+		},
+	}
+	serverConfig.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveConn(conn, serverConfig)
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, hostSigner.PublicKey()
+}
+
+func serveConn(conn net.Conn, config *gossh.ServerConfig) {
+	_, chans, reqs, err := gossh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go gossh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			defer func() { _ = channel.Close() }()
+			for req := range requests {
+				if req.Type != "exec" {
+					_ = req.Reply(false, nil)
+					continue
+				}
+				_ = req.Reply(true, nil)
+				command := string(req.Payload[4:])
+
+				status := 0
+				switch command {
+				case "systemctl is-active nginx":
+					_, _ = io.WriteString(channel, "active\n")
+				case "cat /etc/app/version.json":
+					_, _ = io.WriteString(channel, `{"version":"1.4.2","healthy":true}`+"\n")
+				case "cat":
+					data, _ := io.ReadAll(channel)
+					_, _ = channel.Write(data)
+				default:
+					_, _ = io.WriteString(channel.Stderr(), "sh: "+command+": not found\n")
+					status = 127
+				}
+
+				payload := make([]byte, 4)
+				binary.BigEndian.PutUint32(payload, uint32(status))
+				_, _ = channel.SendRequest("exit-status", false, payload)
+				return
+			}
+		}()
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	host, port, hostKey := startServer(t)
+	config := &SSHConfig{
+		Host:     host,
+		Port:     port,
+		User:     "deploy",
+		Password: "secret",
+		HostKey:  string(gossh.MarshalAuthorizedKey(hostKey)),
+	}
+
+	config.Command = "systemctl is-active nginx"
+	resp, err := runCommand(context.Background(), config, 5*time.Second, nil)
+	if err != nil {
+		t.Fatalf("runCommand: %v", err)
+	}
+	if resp.Stdout != "active" || resp.ExitCode != 0 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	config.Command = "cat"
+	config.Stdin = "hello\n"
+	resp, err = runCommand(context.Background(), config, 5*time.Second, nil)
+	if err != nil {
+		t.Fatalf("runCommand with stdin: %v", err)
+	}
+	if resp.Stdout != "hello" {
+		t.Errorf("expected stdin to be echoed, got %q", resp.Stdout)
+	}
+
+	config.Command = "nope"
+	config.Stdin = ""
+	resp, err = runCommand(context.Background(), config, 5*time.Second, nil)
+	if err != nil {
+		t.Fatalf("runCommand with failing command: %v", err)
+	}
+	if resp.ExitCode != 127 || resp.Stderr != "sh: nope: not found" {
+		t.Errorf("unexpected failing response: %+v", resp)
+	}
+
+	config.HostKey = "SHA256:not-the-right-key"
+	if _, err := runCommand(context.Background(), config, 5*time.Second, nil); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("expected host key mismatch, got %v", err)
+	}
+
+	config.HostKey = gossh.FingerprintSHA256(hostKey)
+	config.Password = "wrong"
+	if _, err := runCommand(context.Background(), config, 5*time.Second, nil); err == nil || !strings.Contains(err.Error(), "handshake") {
+		t.Errorf("expected authentication failure, got %v", err)
+	}
+}
+
+func TestAssertionsAndSaves(t *testing.T) {
+	response := &SSHResponse{Stdout: `{"version":"1.4.2","healthy":true}`, Stderr: "warning: cache cold", ExitCode: 0}
+	subject := resultSubject(response)
+
+	p := map[string]interface{}{
+		"assertions": []interface{}{
+			map[string]interface{}{"type": "exit_code", "expected": float64(0)},
+			map[string]interface{}{"type": "contains", "expected": "1.4.2"},
+			map[string]interface{}{"type": "equals", "path": ".json.healthy", "expected": true},
+			map[string]interface{}{"type": "regex", "path": ".stderr", "expected": "^warning"},
+		},
+		"save": []interface{}{
+			map[string]interface{}{"json_path": ".json.version", "as": "version"},
+			map[string]interface{}{"json_path": ".exit_code", "as": "code", "type": "int"},
+		},
+	}
+
+	results, err := processAssertions(p, response, subject, map[string]interface{}{}, map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failure := assertions.Summary(results); failure != "" {
+		t.Fatalf("unexpected assertion failure: %s (%+v)", failure, results)
+	}
+
+	saved := map[string]string{}
+	if err := saves.Process(p, subject, saved); err != nil {
+		t.Fatalf("saves: %v", err)
+	}
+	if saved["version"] != "1.4.2" || saved["code"] != "0" {
+		t.Errorf("unexpected saves: %v", saved)
+	}
+
+	response.ExitCode = 2
+	results, err = processAssertions(map[string]interface{}{
+		"assertions": []interface{}{map[string]interface{}{"type": "exit_code", "expected": float64(0)}},
+	}, response, resultSubject(response), map[string]interface{}{}, map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	failure := assertions.Summary(results)
+	if !strings.Contains(failure, "expected exit code 0, got 2: warning: cache cold") {
+		t.Errorf("unexpected failure message: %q", failure)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	base := SSHConfig{Host: "db-1", User: "ops", Command: "uptime", PrivateKey: "key", KnownHosts: "/etc/ssh/ssh_known_hosts"}
+	if err := validateConfig(&base); err != nil {
+		t.Fatalf("expected valid config, got %v", err)
+	}
+
+	noAuth := base
+	noAuth.PrivateKey = ""
+	if err := validateConfig(&noAuth); err == nil {
+		t.Error("expected missing credentials to be rejected")
+	}
+
+	twoVerifiers := base
+	twoVerifiers.InsecureIgnoreHostKey = true
+	if err := validateConfig(&twoVerifiers); err == nil {
+		t.Error("expected conflicting host key settings to be rejected")
+	}
+
+	config := &SSHConfig{}
+	if err := parseConfig(map[string]interface{}{"host": "db-1", "port": float64(2222), "insecure_ignore_host_key": true}, config); err != nil {
+		t.Fatalf("parseConfig: %v", err)
+	}
+	if config.Port != 2222 || !config.InsecureIgnoreHostKey {
+		t.Errorf("unexpected config: %+v", config)
+	}
+	if err := parseConfig(map[string]interface{}{"port": "22"}, &SSHConfig{}); err == nil {
+		t.Error("expected string port to be rejected")
+	}
+}
@@ -0,0 +1,56 @@
+package ssh
+
+import "github.com/rocketship-ai/rocketship/internal/assertions"
+
+// SSHPlugin represents an ssh test step
+type SSHPlugin struct {
+	Name   string    `json:"name" yaml:"name"`
+	Plugin string    `json:"plugin" yaml:"plugin"`
+	Config SSHConfig `json:"config" yaml:"config"`
+}
+
+// SSHConfig defines the host to connect to and the command to run
+type SSHConfig struct {
+	Host    string `json:"host" yaml:"host"`
+	Port    int    `json:"port,omitempty" yaml:"port,omitempty"` // Defaults to 22
+	User    string `json:"user" yaml:"user"`
+	Command string `json:"command" yaml:"command"`                     // Run by the remote user's shell
+	Stdin   string `json:"stdin,omitempty" yaml:"stdin,omitempty"`     // Written to the command's standard input
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"` // Overall step timeout (e.g., "30s")
+
+	// Authentication: a password, a private key, or both
+	Password       string `json:"password,omitempty" yaml:"password,omitempty"`
+	PrivateKey     string `json:"private_key,omitempty" yaml:"private_key,omitempty"`           // PEM encoded key
+	PrivateKeyFile string `json:"private_key_file,omitempty" yaml:"private_key_file,omitempty"` // Path to a PEM encoded key
+	Passphrase     string `json:"passphrase,omitempty" yaml:"passphrase,omitempty"`             // Passphrase for an encrypted key
+
+	// Host key verification: exactly one is required
+	HostKey               string `json:"host_key,omitempty" yaml:"host_key,omitempty"`       // Public key ("ssh-ed25519 AAAA...") or SHA256 fingerprint
+	KnownHosts            string `json:"known_hosts,omitempty" yaml:"known_hosts,omitempty"` // Path to a known_hosts file
+	InsecureIgnoreHostKey bool   `json:"insecure_ignore_host_key,omitempty" yaml:"insecure_ignore_host_key,omitempty"`
+}
+
+// Assertion types supported by the ssh plugin in addition to the shared ones
+const (
+	AssertionTypeExitCode = "exit_code"
+)
+
+// SSHResponse contains the result of the remote command
+type SSHResponse struct {
+	Host     string `json:"host"`
+	Command  string `json:"command"`
+	Stdout   string `json:"stdout"` // Trailing newlines are trimmed
+	Stderr   string `json:"stderr"` // Trailing newlines are trimmed
+	ExitCode int    `json:"exit_code"`
+	Duration string `json:"duration"`
+}
+
+// AssertionResult represents a single assertion result for UI display
+type AssertionResult = assertions.Result
+
+// ActivityResponse represents the complete activity response
+type ActivityResponse struct {
+	Response         *SSHResponse      `json:"response"`
+	Saved            map[string]string `json:"saved"`
+	AssertionResults []AssertionResult `json:"assertion_results,omitempty"`
+}
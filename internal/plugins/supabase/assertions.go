@@ -6,7 +6,8 @@ import (
 	"fmt"
 	"strconv"
 
-	"github.com/itchyny/gojq"
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/saves"
 )
 
 // processSave handles saving values from response
@@ -26,16 +27,11 @@ func processSave(ctx context.Context, response *SupabaseResponse, saveConfig map
 
 	// Check for JSON path extraction
 	if jsonPath, ok := saveConfig["json_path"].(string); ok {
-		// Parse the JSON path using gojq
-		query, err := gojq.Parse(jsonPath)
+		v, found, err := saves.Extract(jsonPath, response.Data)
 		if err != nil {
-			return fmt.Errorf("failed to parse JSON path %s: %w", jsonPath, err)
+			return fmt.Errorf("error evaluating JSON path %s: %w", jsonPath, err)
 		}
-
-		// Run the query on the response data
-		iter := query.Run(response.Data)
-		v, ok := iter.Next()
-		if !ok {
+		if !found {
 			if required {
 				responseDataJSON, _ := json.Marshal(response.Data)
 				return fmt.Errorf("no results from required JSON path %q on data %s", jsonPath, string(responseDataJSON))
@@ -43,9 +39,6 @@ func processSave(ctx context.Context, response *SupabaseResponse, saveConfig map
 			// Optional save that returned no results - skip it
 			return nil
 		}
-		if err, ok := v.(error); ok {
-			return fmt.Errorf("error evaluating JSON path %s: %w", jsonPath, err)
-		}
 		value = v
 	} else if header, ok := saveConfig["header"].(string); ok {
 		// Extract from headers
@@ -73,28 +66,17 @@ func processSave(ctx context.Context, response *SupabaseResponse, saveConfig map
 		return nil
 	}
 
-	// Convert value to string based on type
-	switch val := value.(type) {
-	case string:
-		saved[asName] = val
-	case float64:
-		saved[asName] = fmt.Sprintf("%.0f", val)
-	case bool:
-		saved[asName] = fmt.Sprintf("%t", val)
-	default:
-		// For complex types, use JSON marshaling
-		bytes, err := json.Marshal(val)
-		if err != nil {
-			return fmt.Errorf("failed to marshal value for %s: %w", asName, err)
-		}
-		saved[asName] = string(bytes)
+	formatted, err := saves.Format(value, saves.Type(saveConfig))
+	if err != nil {
+		return fmt.Errorf("failed to save %s: %w", asName, err)
 	}
+	saved[asName] = formatted
 
 	return nil
 }
 
 // processAssertions validates response against assertions
-func processAssertions(response *SupabaseResponse, assertions []interface{}, params map[string]interface{}) error {
+func processAssertions(response *SupabaseResponse, assertionList []interface{}, params map[string]interface{}) error {
 	state := make(map[string]string)
 	if stateInterface, ok := params["state"]; ok {
 		if stateMap, ok := stateInterface.(map[string]interface{}); ok {
@@ -116,7 +98,7 @@ func processAssertions(response *SupabaseResponse, assertions []interface{}, par
 		env = envData
 	}
 
-	for _, assertionInterface := range assertions {
+	for _, assertionInterface := range assertionList {
 		assertion, ok := assertionInterface.(map[string]interface{})
 		if !ok {
 			return fmt.Errorf("invalid assertion format")
@@ -140,7 +122,10 @@ func processAssertions(response *SupabaseResponse, assertions []interface{}, par
 		case "error_message":
 			err = processErrorMessageAssertion(response, assertion, state, env)
 		default:
-			return fmt.Errorf("unknown assertion type: %s", assertionType)
+			if !assertions.IsShared(assertionType) {
+				return fmt.Errorf("unknown assertion type: %s", assertionType)
+			}
+			err = processSharedAssertion(response, assertion, state, env)
 		}
 
 		if err != nil {
@@ -186,56 +171,26 @@ func processStatusCodeAssertion(response *SupabaseResponse, assertion map[string
 
 // processJSONPathAssertion validates JSON path value
 func processJSONPathAssertion(response *SupabaseResponse, assertion map[string]interface{}, state map[string]string, env map[string]string) error {
-	path, ok := assertion["path"].(string)
-	if !ok {
-		return fmt.Errorf("path is required for json_path assertion")
-	}
-
-	expectedInterface, ok := assertion["expected"]
-	if !ok {
-		return fmt.Errorf("expected value is required for json_path assertion")
-	}
-
-	// Parse JSON path
-	query, err := gojq.Parse(path)
-	if err != nil {
-		return fmt.Errorf("failed to parse JSON path %s: %w", path, err)
-	}
-
-	// Run query on response data
-	iter := query.Run(response.Data)
-	actual, ok := iter.Next()
-	if !ok {
-		return fmt.Errorf("JSON path %s returned no results", path)
-	}
-	if err, ok := actual.(error); ok {
-		return fmt.Errorf("error evaluating JSON path %s: %w", path, err)
+	if _, ok := assertion["expected"]; !ok {
+		if exists, _ := assertion["exists"].(bool); !exists {
+			return fmt.Errorf("expected value is required for json_path assertion")
+		}
 	}
+	return processSharedAssertion(response, assertion, state, env)
+}
 
-	// Process expected value with variable replacement
-	var expected = expectedInterface
-	if expectedStr, ok := expectedInterface.(string); ok {
+// processSharedAssertion runs assertion types common to all plugins against the response data
+func processSharedAssertion(response *SupabaseResponse, assertion map[string]interface{}, state map[string]string, env map[string]string) error {
+	expected := assertion["expected"]
+	if expectedStr, ok := expected.(string); ok {
 		expected = replaceVariables(expectedStr, state, env)
-
-		// Try to convert to same type as actual
-		switch actual.(type) {
-		case float64, int:
-			if num, err := strconv.ParseFloat(expected.(string), 64); err == nil {
-				expected = num
-			}
-		case bool:
-			if b, err := strconv.ParseBool(expected.(string)); err == nil {
-				expected = b
-			}
-		}
 	}
 
-	// Compare values
-	if fmt.Sprintf("%v", actual) != fmt.Sprintf("%v", expected) {
-		return fmt.Errorf("JSON path assertion failed for %s: expected %v, got %v", path, expected, actual)
+	data, err := assertions.Normalize(response.Data)
+	if err != nil {
+		return fmt.Errorf("failed to read response data: %w", err)
 	}
-
-	return nil
+	return assertions.Evaluate(assertion, data, expected).Err()
 }
 
 // processRowCountAssertion validates row count
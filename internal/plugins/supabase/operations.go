@@ -37,6 +37,10 @@ func executeSupabaseOperation(ctx context.Context, client *http.Client, config *
 		return executeStorageDownload(ctx, client, config)
 	case OpStorageDelete:
 		return executeStorageDelete(ctx, client, config)
+	case OpStorageList:
+		return executeStorageList(ctx, client, config)
+	case OpRealtimeSubscribe:
+		return executeRealtimeSubscribe(ctx, client, config)
 	default:
 		return nil, fmt.Errorf("unsupported operation: %s", config.Operation)
 	}
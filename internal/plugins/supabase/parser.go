@@ -1,5 +1,7 @@
 package supabase
 
+import "fmt"
+
 // parseConfig parses configuration from map to struct
 func parseConfig(configData map[string]interface{}, config *SupabaseConfig) error {
 	// Simple field mapping
@@ -55,6 +57,13 @@ func parseConfig(configData map[string]interface{}, config *SupabaseConfig) erro
 		parseStorageConfig(storageData, config.Storage)
 	}
 
+	if realtimeData, ok := configData["realtime"].(map[string]interface{}); ok {
+		config.Realtime = &RealtimeConfig{}
+		if err := parseRealtimeConfig(realtimeData, config.Realtime); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -177,6 +186,81 @@ func parseStorageConfig(data map[string]interface{}, config *StorageConfig) {
 	if contentType, ok := data["content_type"].(string); ok {
 		config.ContentType = contentType
 	}
+	if upsert, ok := data["upsert"].(bool); ok {
+		config.Upsert = upsert
+	}
+	if prefix, ok := data["prefix"].(string); ok {
+		config.Prefix = prefix
+	}
+	if search, ok := data["search"].(string); ok {
+		config.Search = search
+	}
+	if limit, ok := data["limit"].(float64); ok {
+		limitInt := int(limit)
+		config.Limit = &limitInt
+	}
+	if offset, ok := data["offset"].(float64); ok {
+		offsetInt := int(offset)
+		config.Offset = &offsetInt
+	}
+}
+
+// parseRealtimeConfig parses realtime operation configuration
+func parseRealtimeConfig(data map[string]interface{}, config *RealtimeConfig) error {
+	if channel, ok := data["channel"].(string); ok {
+		config.Channel = channel
+	}
+	if accessToken, ok := data["access_token"].(string); ok {
+		config.AccessToken = accessToken
+	}
+	if changes, ok := data["postgres_changes"].([]interface{}); ok {
+		for _, changeInterface := range changes {
+			if changeMap, ok := changeInterface.(map[string]interface{}); ok {
+				change := ChangeConfig{Event: "*", Schema: "public"} // defaults
+				if event, ok := changeMap["event"].(string); ok {
+					change.Event = event
+				}
+				if schema, ok := changeMap["schema"].(string); ok {
+					change.Schema = schema
+				}
+				if table, ok := changeMap["table"].(string); ok {
+					change.Table = table
+				}
+				if filter, ok := changeMap["filter"].(string); ok {
+					change.Filter = filter
+				}
+				config.Changes = append(config.Changes, change)
+			}
+		}
+	}
+	if broadcast, ok := data["broadcast"].([]interface{}); ok {
+		for _, event := range broadcast {
+			if eventStr, ok := event.(string); ok {
+				config.Broadcast = append(config.Broadcast, eventStr)
+			}
+		}
+	}
+	if send, ok := data["send"].([]interface{}); ok {
+		for _, messageInterface := range send {
+			if messageMap, ok := messageInterface.(map[string]interface{}); ok {
+				message := BroadcastConfig{Payload: messageMap["payload"]}
+				if event, ok := messageMap["event"].(string); ok {
+					message.Event = event
+				}
+				config.Send = append(config.Send, message)
+			}
+		}
+	}
+	if count, ok := data["count"].(float64); ok {
+		config.Count = int(count)
+	}
+	if triggerData, ok := data["trigger"].(map[string]interface{}); ok {
+		config.Trigger = &SupabaseConfig{}
+		if err := parseConfig(triggerData, config.Trigger); err != nil {
+			return fmt.Errorf("invalid realtime trigger: %w", err)
+		}
+	}
+	return nil
 }
 
 // parseFilters parses filter configurations
@@ -0,0 +1,366 @@
+package supabase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	ws "nhooyr.io/websocket"
+)
+
+// heartbeatInterval keeps the realtime connection alive; the server drops
+// sockets that stay silent for 60 seconds
+const heartbeatInterval = 25 * time.Second
+
+// realtimeMessage is a Phoenix channel message, the realtime server's protocol
+type realtimeMessage struct {
+	Topic   string          `json:"topic"`
+	Event   string          `json:"event"`
+	Payload json.RawMessage `json:"payload"`
+	Ref     *string         `json:"ref"`
+}
+
+// realtimeChannel is a joined channel on a realtime connection
+type realtimeChannel struct {
+	conn  *ws.Conn
+	topic string
+
+	mu  sync.Mutex // Guards ref and writes
+	ref int
+}
+
+// executeRealtimeSubscribe joins a realtime channel, runs the trigger and sends
+// the broadcasts once subscribed, and waits for the expected events
+func executeRealtimeSubscribe(ctx context.Context, client *http.Client, config *SupabaseConfig) (*SupabaseResponse, error) {
+	rt := config.Realtime
+	if rt == nil || rt.Channel == "" {
+		return nil, fmt.Errorf("channel is required for realtime subscribe operation")
+	}
+	if len(rt.Changes) == 0 && len(rt.Broadcast) == 0 {
+		return nil, fmt.Errorf("postgres_changes or broadcast is required for realtime subscribe operation")
+	}
+	if rt.Trigger != nil && rt.Trigger.Operation == OpRealtimeSubscribe {
+		return nil, fmt.Errorf("realtime trigger can't be a realtime subscription")
+	}
+	count := rt.Count
+	if count <= 0 {
+		count = 1
+	}
+
+	// The client timeout bounds the whole subscription; the websocket takes it as a deadline
+	if client.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, client.Timeout)
+		defer cancel()
+	}
+
+	channel, err := joinChannel(ctx, client.Transport, config)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = channel.conn.Close(ws.StatusNormalClosure, "") }()
+
+	// Keep the connection alive and read events in the background, so the
+	// trigger can't miss them
+	listenCtx, stopListening := context.WithCancel(ctx)
+	defer stopListening()
+	go channel.heartbeat(listenCtx)
+	events := make(chan RealtimeEvent)
+	readErr := make(chan error, 1)
+	go func() { readErr <- channel.read(listenCtx, rt.Broadcast, events) }()
+
+	if rt.Trigger != nil {
+		trigger := *rt.Trigger
+		if trigger.URL == "" {
+			trigger.URL = config.URL
+		}
+		if trigger.Key == "" {
+			trigger.Key = config.Key
+		}
+		response, err := executeSupabaseOperation(ctx, client, &trigger)
+		if err != nil {
+			return nil, fmt.Errorf("realtime trigger %s failed: %w", trigger.Operation, err)
+		}
+		if response.Error != nil {
+			return nil, fmt.Errorf("realtime trigger %s failed: %s", trigger.Operation, response.Error.Message)
+		}
+	}
+
+	for _, message := range rt.Send {
+		payload := map[string]interface{}{"type": "broadcast", "event": message.Event, "payload": message.Payload}
+		if err := channel.send(ctx, channel.topic, "broadcast", payload); err != nil {
+			return nil, fmt.Errorf("failed to send broadcast %q: %w", message.Event, err)
+		}
+	}
+
+	received := make([]RealtimeEvent, 0, count)
+	for len(received) < count {
+		select {
+		case event := <-events:
+			received = append(received, event)
+		case err := <-readErr:
+			return nil, fmt.Errorf("received %d of %d expected realtime events: %w", len(received), count, err)
+		}
+	}
+
+	// Round-trip the events, so assertions and saves see plain JSON values
+	data, err := json.Marshal(received)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal realtime events: %w", err)
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to parse realtime events: %w", err)
+	}
+	total := len(received)
+	return &SupabaseResponse{Data: decoded, Count: &total, Metadata: &ResponseMetadata{StatusCode: http.StatusOK}}, nil
+}
+
+// realtimeURL builds the realtime websocket URL from the project URL
+func realtimeURL(projectURL, key string) (string, error) {
+	u, err := url.Parse(strings.TrimSuffix(projectURL, "/"))
+	if err != nil {
+		return "", fmt.Errorf("invalid url: %w", err)
+	}
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	case "http":
+		u.Scheme = "ws"
+	default:
+		return "", fmt.Errorf("invalid url %q: expected http or https", projectURL)
+	}
+	u.Path += "/realtime/v1/websocket"
+	u.RawQuery = url.Values{"apikey": {key}, "vsn": {"1.0.0"}}.Encode()
+	return u.String(), nil
+}
+
+// joinChannel connects to the realtime server and joins the channel. It returns
+// once the server has subscribed the channel to the database changes it asks for.
+func joinChannel(ctx context.Context, transport http.RoundTripper, config *SupabaseConfig) (*realtimeChannel, error) {
+	rt := config.Realtime
+	endpoint, err := realtimeURL(config.URL, config.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, _, err := ws.Dial(ctx, endpoint, &ws.DialOptions{HTTPClient: &http.Client{Transport: transport}})
+	if err != nil {
+		// The dial error quotes the URL, key included
+		return nil, fmt.Errorf("failed to connect to realtime: %s", strings.ReplaceAll(err.Error(), url.QueryEscape(config.Key), "[REDACTED]"))
+	}
+	// Database change payloads can be larger than the default read limit
+	conn.SetReadLimit(1 << 24)
+	channel := &realtimeChannel{conn: conn, topic: "realtime:" + rt.Channel}
+
+	accessToken := rt.AccessToken
+	if accessToken == "" {
+		accessToken = config.Key
+	}
+	changes := make([]map[string]interface{}, 0, len(rt.Changes))
+	for _, change := range rt.Changes {
+		subscription := map[string]interface{}{"event": change.Event, "schema": change.Schema}
+		if change.Table != "" {
+			subscription["table"] = change.Table
+		}
+		if change.Filter != "" {
+			subscription["filter"] = change.Filter
+		}
+		changes = append(changes, subscription)
+	}
+	join := map[string]interface{}{
+		"config": map[string]interface{}{
+			"broadcast":        map[string]interface{}{"self": true, "ack": false},
+			"presence":         map[string]interface{}{"key": ""},
+			"postgres_changes": changes,
+		},
+		"access_token": accessToken,
+	}
+	if err := channel.send(ctx, channel.topic, "phx_join", join); err != nil {
+		_ = conn.Close(ws.StatusInternalError, "")
+		return nil, fmt.Errorf("failed to join channel %q: %w", rt.Channel, err)
+	}
+
+	if err := channel.awaitSubscribed(ctx, len(changes) > 0); err != nil {
+		_ = conn.Close(ws.StatusInternalError, "")
+		return nil, fmt.Errorf("failed to join channel %q: %w", rt.Channel, err)
+	}
+	return channel, nil
+}
+
+// awaitSubscribed waits for the join reply and, when the channel listens for
+// database changes, for the server to confirm their subscription
+func (c *realtimeChannel) awaitSubscribed(ctx context.Context, changes bool) error {
+	joined := false
+	for !joined || changes {
+		msg, err := c.next(ctx)
+		if err != nil {
+			return err
+		}
+		if msg.Topic != c.topic {
+			continue
+		}
+		switch msg.Event {
+		case "phx_reply":
+			var reply struct {
+				Status   string `json:"status"`
+				Response struct {
+					Reason string `json:"reason"`
+				} `json:"response"`
+			}
+			if err := json.Unmarshal(msg.Payload, &reply); err != nil {
+				return fmt.Errorf("invalid join reply: %w", err)
+			}
+			if reply.Status != "ok" {
+				return fmt.Errorf("server replied %s: %s", reply.Status, reply.Response.Reason)
+			}
+			joined = true
+		case "system":
+			var system struct {
+				Status    string `json:"status"`
+				Extension string `json:"extension"`
+				Message   string `json:"message"`
+			}
+			if err := json.Unmarshal(msg.Payload, &system); err != nil || system.Extension != "postgres_changes" {
+				continue
+			}
+			if system.Status != "ok" {
+				return fmt.Errorf("failed to subscribe to postgres_changes: %s", system.Message)
+			}
+			changes = false
+		case "phx_error", "phx_close":
+			return fmt.Errorf("channel closed by the server: %s", msg.Payload)
+		}
+	}
+	return nil
+}
+
+// read delivers the channel's database changes and the broadcasts matching
+// the events until ctx ends or the connection fails
+func (c *realtimeChannel) read(ctx context.Context, broadcast []string, events chan<- RealtimeEvent) error {
+	for {
+		msg, err := c.next(ctx)
+		if err != nil {
+			return err
+		}
+		if msg.Topic != c.topic {
+			continue
+		}
+		switch msg.Event {
+		case "postgres_changes":
+			var change struct {
+				Data struct {
+					Type            string      `json:"type"`
+					Schema          string      `json:"schema"`
+					Table           string      `json:"table"`
+					CommitTimestamp string      `json:"commit_timestamp"`
+					Record          interface{} `json:"record"`
+					OldRecord       interface{} `json:"old_record"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(msg.Payload, &change); err != nil {
+				return fmt.Errorf("invalid postgres_changes event: %w", err)
+			}
+			event := RealtimeEvent{
+				Type:            change.Data.Type,
+				Schema:          change.Data.Schema,
+				Table:           change.Data.Table,
+				CommitTimestamp: change.Data.CommitTimestamp,
+				Record:          change.Data.Record,
+				OldRecord:       change.Data.OldRecord,
+			}
+			if err := deliver(ctx, events, event); err != nil {
+				return err
+			}
+		case "broadcast":
+			var message struct {
+				Event   string      `json:"event"`
+				Payload interface{} `json:"payload"`
+			}
+			if err := json.Unmarshal(msg.Payload, &message); err != nil {
+				return fmt.Errorf("invalid broadcast event: %w", err)
+			}
+			if !matchesBroadcast(broadcast, message.Event) {
+				continue
+			}
+			event := RealtimeEvent{Type: "broadcast", Event: message.Event, Payload: message.Payload}
+			if err := deliver(ctx, events, event); err != nil {
+				return err
+			}
+		case "phx_error", "phx_close":
+			return fmt.Errorf("channel closed by the server: %s", msg.Payload)
+		}
+	}
+}
+
+// deliver hands an event to the step, unless it has stopped listening
+func deliver(ctx context.Context, events chan<- RealtimeEvent, event RealtimeEvent) error {
+	select {
+	case events <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// matchesBroadcast reports whether a broadcast event is one the step listens for
+func matchesBroadcast(events []string, event string) bool {
+	for _, e := range events {
+		if e == "*" || e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// heartbeat pings the server until ctx ends
+func (c *realtimeChannel) heartbeat(ctx context.Context) {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.send(ctx, "phoenix", "heartbeat", map[string]interface{}{}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// send writes a message with the next ref
+func (c *realtimeChannel) send(ctx context.Context, topic, event string, payload interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ref++
+	ref := strconv.Itoa(c.ref)
+	data, err := json.Marshal(map[string]interface{}{"topic": topic, "event": event, "payload": payload, "ref": ref})
+	if err != nil {
+		return err
+	}
+	return c.conn.Write(ctx, ws.MessageText, data)
+}
+
+// next reads the next message from the connection
+func (c *realtimeChannel) next(ctx context.Context) (*realtimeMessage, error) {
+	_, data, err := c.conn.Read(ctx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("timed out waiting for realtime events")
+		}
+		return nil, err
+	}
+	msg := &realtimeMessage{}
+	if err := json.Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("invalid realtime message: %w", err)
+	}
+	return msg, nil
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"strings"
 )
 
@@ -87,8 +88,17 @@ func executeStorageUpload(ctx context.Context, client *http.Client, config *Supa
 		return nil, fmt.Errorf("bucket and path are required for storage upload operation")
 	}
 
+	// Read the file content from file_path when it isn't inline
+	content := []byte(config.Storage.FileContent)
 	if config.Storage.FileContent == "" {
-		return nil, fmt.Errorf("file_content is required for storage upload operation")
+		if config.Storage.FilePath == "" {
+			return nil, fmt.Errorf("file_content or file_path is required for storage upload operation")
+		}
+		data, err := os.ReadFile(config.Storage.FilePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file_path: %w", err)
+		}
+		content = data
 	}
 
 	// Build URL
@@ -98,7 +108,7 @@ func executeStorageUpload(ctx context.Context, client *http.Client, config *Supa
 		config.Storage.Path)
 
 	// Create request with file content
-	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBufferString(config.Storage.FileContent))
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(content))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -119,6 +129,11 @@ func executeStorageUpload(ctx context.Context, client *http.Client, config *Supa
 		req.Header.Set("Cache-Control", config.Storage.CacheControl)
 	}
 
+	// Replace an existing file instead of failing
+	if config.Storage.Upsert {
+		req.Header.Set("x-upsert", "true")
+	}
+
 	// Execute request
 	resp, err := client.Do(req)
 	if err != nil {
@@ -227,3 +242,53 @@ func executeStorageDelete(ctx context.Context, client *http.Client, config *Supa
 
 	return parseSupabaseResponse(resp)
 }
+
+// executeStorageList handles listing the objects in a bucket folder
+func executeStorageList(ctx context.Context, client *http.Client, config *SupabaseConfig) (*SupabaseResponse, error) {
+	if config.Storage == nil || config.Storage.Bucket == "" {
+		return nil, fmt.Errorf("bucket name is required for storage list operation")
+	}
+
+	// Build URL
+	endpoint := fmt.Sprintf("%s/storage/v1/object/list/%s", strings.TrimSuffix(config.URL, "/"), config.Storage.Bucket)
+
+	// Build request body - the storage API requires a prefix, "" being the bucket root
+	reqBody := map[string]interface{}{
+		"prefix": config.Storage.Prefix,
+	}
+	if config.Storage.Search != "" {
+		reqBody["search"] = config.Storage.Search
+	}
+	if config.Storage.Limit != nil {
+		reqBody["limit"] = *config.Storage.Limit
+	}
+	if config.Storage.Offset != nil {
+		reqBody["offset"] = *config.Storage.Offset
+	}
+
+	// Serialize data
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal storage data: %w", err)
+	}
+
+	// Create request
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Set headers
+	req.Header.Set("apikey", config.Key)
+	req.Header.Set("Authorization", "Bearer "+config.Key)
+	req.Header.Set("Content-Type", "application/json")
+
+	// Execute request
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	return parseSupabaseResponse(resp)
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/rocketship-ai/rocketship/internal/egress"
 	"github.com/rocketship-ai/rocketship/internal/plugins"
 	"go.temporal.io/sdk/activity"
 )
@@ -73,62 +74,7 @@ func (sp *SupabasePlugin) Activity(ctx context.Context, p map[string]interface{}
 	}
 
 	// Process runtime variables in string fields
-	config.URL = replaceVariables(config.URL, state, env)
-	config.Key = replaceVariables(config.Key, state, env)
-	if config.Table != "" {
-		config.Table = replaceVariables(config.Table, state, env)
-	}
-
-	// Process runtime variables in RPC parameters
-	if config.RPC != nil && config.RPC.Params != nil {
-		config.RPC.Params = processVariablesInMap(config.RPC.Params, state, env)
-	}
-
-	// Process runtime variables in other operation types
-	if config.Insert != nil {
-		if config.Insert.Data != nil {
-			if dataMap, ok := config.Insert.Data.(map[string]interface{}); ok {
-				config.Insert.Data = processVariablesInMap(dataMap, state, env)
-			}
-		}
-	}
-
-	if config.Update != nil {
-		if config.Update.Data != nil {
-			config.Update.Data = processVariablesInMap(config.Update.Data, state, env)
-		}
-		config.Update.Filters = processFilters(config.Update.Filters, state, env)
-	}
-
-	if config.Select != nil {
-		config.Select.Filters = processFilters(config.Select.Filters, state, env)
-	}
-
-	if config.Delete != nil {
-		config.Delete.Filters = processFilters(config.Delete.Filters, state, env)
-	}
-
-	// Process runtime variables in Auth config
-	if config.Auth != nil {
-		config.Auth.Email = replaceVariables(config.Auth.Email, state, env)
-		config.Auth.Password = replaceVariables(config.Auth.Password, state, env)
-		config.Auth.UserID = replaceVariables(config.Auth.UserID, state, env)
-		if config.Auth.UserMetadata != nil {
-			config.Auth.UserMetadata = processVariablesInMap(config.Auth.UserMetadata, state, env)
-		}
-		if config.Auth.AppMetadata != nil {
-			config.Auth.AppMetadata = processVariablesInMap(config.Auth.AppMetadata, state, env)
-		}
-	}
-
-	// Process runtime variables in Storage config
-	if config.Storage != nil {
-		config.Storage.Bucket = replaceVariables(config.Storage.Bucket, state, env)
-		config.Storage.Path = replaceVariables(config.Storage.Path, state, env)
-		config.Storage.FileContent = replaceVariables(config.Storage.FileContent, state, env)
-		config.Storage.ContentType = replaceVariables(config.Storage.ContentType, state, env)
-		config.Storage.CacheControl = replaceVariables(config.Storage.CacheControl, state, env)
-	}
+	replaceConfigVariables(config, state, env)
 
 	// Log parsed config for debugging
 	logger.Info("Parsed Supabase config",
@@ -189,8 +135,13 @@ func (sp *SupabasePlugin) Activity(ctx context.Context, p map[string]interface{}
 		}
 	}
 
+	policy, err := egress.FromParams(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
+
 	// Create HTTP client with timeout
-	client := &http.Client{Timeout: timeout}
+	client := &http.Client{Timeout: timeout, Transport: policy.Transport()}
 
 	startTime := time.Now()
 	response, err := executeSupabaseOperation(ctx, client, config)
@@ -255,3 +206,84 @@ func (sp *SupabasePlugin) Activity(ctx context.Context, p map[string]interface{}
 		Saved:    saved,
 	}, nil
 }
+
+// replaceConfigVariables processes runtime variables in the config's fields,
+// including the config of a realtime trigger
+func replaceConfigVariables(config *SupabaseConfig, state map[string]string, env map[string]string) {
+	config.URL = replaceVariables(config.URL, state, env)
+	config.Key = replaceVariables(config.Key, state, env)
+	if config.Table != "" {
+		config.Table = replaceVariables(config.Table, state, env)
+	}
+
+	// Process runtime variables in RPC parameters
+	if config.RPC != nil && config.RPC.Params != nil {
+		config.RPC.Params = processVariablesInMap(config.RPC.Params, state, env)
+	}
+
+	// Process runtime variables in other operation types
+	if config.Insert != nil {
+		if config.Insert.Data != nil {
+			if dataMap, ok := config.Insert.Data.(map[string]interface{}); ok {
+				config.Insert.Data = processVariablesInMap(dataMap, state, env)
+			}
+		}
+	}
+
+	if config.Update != nil {
+		if config.Update.Data != nil {
+			config.Update.Data = processVariablesInMap(config.Update.Data, state, env)
+		}
+		config.Update.Filters = processFilters(config.Update.Filters, state, env)
+	}
+
+	if config.Select != nil {
+		config.Select.Filters = processFilters(config.Select.Filters, state, env)
+	}
+
+	if config.Delete != nil {
+		config.Delete.Filters = processFilters(config.Delete.Filters, state, env)
+	}
+
+	// Process runtime variables in Auth config
+	if config.Auth != nil {
+		config.Auth.Email = replaceVariables(config.Auth.Email, state, env)
+		config.Auth.Password = replaceVariables(config.Auth.Password, state, env)
+		config.Auth.UserID = replaceVariables(config.Auth.UserID, state, env)
+		if config.Auth.UserMetadata != nil {
+			config.Auth.UserMetadata = processVariablesInMap(config.Auth.UserMetadata, state, env)
+		}
+		if config.Auth.AppMetadata != nil {
+			config.Auth.AppMetadata = processVariablesInMap(config.Auth.AppMetadata, state, env)
+		}
+	}
+
+	// Process runtime variables in Storage config
+	if config.Storage != nil {
+		config.Storage.Bucket = replaceVariables(config.Storage.Bucket, state, env)
+		config.Storage.Path = replaceVariables(config.Storage.Path, state, env)
+		config.Storage.FileContent = replaceVariables(config.Storage.FileContent, state, env)
+		config.Storage.ContentType = replaceVariables(config.Storage.ContentType, state, env)
+		config.Storage.CacheControl = replaceVariables(config.Storage.CacheControl, state, env)
+		config.Storage.FilePath = replaceVariables(config.Storage.FilePath, state, env)
+		config.Storage.Prefix = replaceVariables(config.Storage.Prefix, state, env)
+		config.Storage.Search = replaceVariables(config.Storage.Search, state, env)
+	}
+
+	// Process runtime variables in Realtime config
+	if config.Realtime != nil {
+		config.Realtime.Channel = replaceVariables(config.Realtime.Channel, state, env)
+		config.Realtime.AccessToken = replaceVariables(config.Realtime.AccessToken, state, env)
+		for i := range config.Realtime.Changes {
+			config.Realtime.Changes[i].Filter = replaceVariables(config.Realtime.Changes[i].Filter, state, env)
+		}
+		for i, message := range config.Realtime.Send {
+			if payload, ok := message.Payload.(map[string]interface{}); ok {
+				config.Realtime.Send[i].Payload = processVariablesInMap(payload, state, env)
+			}
+		}
+		if config.Realtime.Trigger != nil {
+			replaceConfigVariables(config.Realtime.Trigger, state, env)
+		}
+	}
+}
@@ -1,9 +1,19 @@
 package supabase
 
 import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ws "nhooyr.io/websocket"
 )
 
 func TestSupabasePlugin_GetType(t *testing.T) {
@@ -72,3 +82,184 @@ func TestParseContentRange(t *testing.T) {
 		})
 	}
 }
+
+func TestParseRealtimeConfig(t *testing.T) {
+	config := &SupabaseConfig{}
+	err := parseConfig(map[string]interface{}{
+		"operation": "realtime_subscribe",
+		"realtime": map[string]interface{}{
+			"channel": "room-1",
+			"postgres_changes": []interface{}{
+				map[string]interface{}{"event": "INSERT", "table": "messages", "filter": "room_id=eq.{{ room }}"},
+			},
+			"broadcast": []interface{}{"typing"},
+			"count":     float64(2),
+			"trigger": map[string]interface{}{
+				"operation": "insert",
+				"table":     "messages",
+				"insert":    map[string]interface{}{"data": map[string]interface{}{"room_id": "{{ room }}"}},
+			},
+		},
+	}, config)
+	require.NoError(t, err)
+
+	rt := config.Realtime
+	require.NotNil(t, rt)
+	assert.Equal(t, "room-1", rt.Channel)
+	assert.Equal(t, []ChangeConfig{{Event: "INSERT", Schema: "public", Table: "messages", Filter: "room_id=eq.{{ room }}"}}, rt.Changes)
+	assert.Equal(t, []string{"typing"}, rt.Broadcast)
+	assert.Equal(t, 2, rt.Count)
+	require.NotNil(t, rt.Trigger)
+	assert.Equal(t, OpInsert, rt.Trigger.Operation)
+
+	replaceConfigVariables(config, map[string]string{"room": "7"}, nil)
+	assert.Equal(t, "room_id=eq.7", rt.Changes[0].Filter)
+	assert.Equal(t, map[string]interface{}{"room_id": "7"}, rt.Trigger.Insert.Data)
+}
+
+func TestRealtimeURL(t *testing.T) {
+	u, err := realtimeURL("https://abc.supabase.co/", "key+1")
+	require.NoError(t, err)
+	assert.Equal(t, "wss://abc.supabase.co/realtime/v1/websocket?apikey=key%2B1&vsn=1.0.0", u)
+
+	u, err = realtimeURL("http://localhost:54321", "k")
+	require.NoError(t, err)
+	assert.Equal(t, "ws://localhost:54321/realtime/v1/websocket?apikey=k&vsn=1.0.0", u)
+
+	_, err = realtimeURL("ftp://abc", "k")
+	assert.Error(t, err)
+}
+
+// newRealtimeServer fakes a Supabase project: joins are confirmed, broadcasts
+// are echoed back, and inserts into the messages table are pushed to the channel
+func newRealtimeServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var mu sync.Mutex
+	var channel *ws.Conn
+	var topic string
+	push := func(event string, payload interface{}) {
+		data, _ := json.Marshal(map[string]interface{}{"topic": topic, "event": event, "payload": payload, "ref": nil})
+		_ = channel.Write(context.Background(), ws.MessageText, data)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/realtime/v1/websocket", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.URL.Query().Get("apikey"))
+		conn, err := ws.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close(ws.StatusNormalClosure, "") }()
+		for {
+			_, data, err := conn.Read(r.Context())
+			if err != nil {
+				return
+			}
+			var msg realtimeMessage
+			require.NoError(t, json.Unmarshal(data, &msg))
+			mu.Lock()
+			switch msg.Event {
+			case "phx_join":
+				channel, topic = conn, msg.Topic
+				push("phx_reply", map[string]interface{}{"status": "ok", "response": map[string]interface{}{}})
+				if strings.Contains(string(msg.Payload), `"table":"messages"`) {
+					push("system", map[string]interface{}{"status": "ok", "extension": "postgres_changes", "message": "Subscribed to PostgreSQL"})
+				}
+			case "broadcast":
+				var payload interface{}
+				_ = json.Unmarshal(msg.Payload, &payload)
+				push("broadcast", payload)
+			}
+			mu.Unlock()
+		}
+	})
+	mux.HandleFunc("/rest/v1/messages", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var record interface{}
+		_ = json.Unmarshal(body, &record)
+		mu.Lock()
+		push("postgres_changes", map[string]interface{}{"data": map[string]interface{}{
+			"type": "INSERT", "schema": "public", "table": "messages", "record": record, "old_record": nil,
+		}})
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestExecuteRealtimeSubscribe(t *testing.T) {
+	server := newRealtimeServer(t)
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	t.Run("postgres changes from trigger", func(t *testing.T) {
+		response, err := executeSupabaseOperation(context.Background(), client, &SupabaseConfig{
+			URL: server.URL, Key: "secret", Operation: OpRealtimeSubscribe,
+			Realtime: &RealtimeConfig{
+				Channel: "room-1",
+				Changes: []ChangeConfig{{Event: "INSERT", Schema: "public", Table: "messages"}},
+				Trigger: &SupabaseConfig{Operation: OpInsert, Table: "messages", Insert: &InsertConfig{Data: map[string]interface{}{"text": "hi"}}},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, *response.Count)
+		assert.Equal(t, []interface{}{map[string]interface{}{
+			"type": "INSERT", "schema": "public", "table": "messages", "record": map[string]interface{}{"text": "hi"},
+		}}, response.Data)
+	})
+
+	t.Run("broadcasts", func(t *testing.T) {
+		response, err := executeSupabaseOperation(context.Background(), client, &SupabaseConfig{
+			URL: server.URL, Key: "secret", Operation: OpRealtimeSubscribe,
+			Realtime: &RealtimeConfig{
+				Channel:   "room-2",
+				Broadcast: []string{"typing"},
+				Send: []BroadcastConfig{
+					{Event: "ignored", Payload: map[string]interface{}{"n": 0}},
+					{Event: "typing", Payload: map[string]interface{}{"n": 1}},
+				},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{map[string]interface{}{
+			"type": "broadcast", "event": "typing", "payload": map[string]interface{}{"n": float64(1)},
+		}}, response.Data)
+	})
+
+	t.Run("times out without events", func(t *testing.T) {
+		_, err := executeSupabaseOperation(context.Background(), &http.Client{Timeout: 200 * time.Millisecond}, &SupabaseConfig{
+			URL: server.URL, Key: "secret", Operation: OpRealtimeSubscribe,
+			Realtime: &RealtimeConfig{Channel: "room-3", Broadcast: []string{"*"}},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "received 0 of 1 expected realtime events")
+	})
+
+	t.Run("requires something to listen for", func(t *testing.T) {
+		_, err := executeSupabaseOperation(context.Background(), client, &SupabaseConfig{
+			URL: server.URL, Key: "secret", Operation: OpRealtimeSubscribe,
+			Realtime: &RealtimeConfig{Channel: "room-4"},
+		})
+		assert.EqualError(t, err, "postgres_changes or broadcast is required for realtime subscribe operation")
+	})
+}
+
+func TestExecuteStorageList(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/storage/v1/object/list/uploads", r.URL.Path)
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, map[string]interface{}{"prefix": "docs", "limit": float64(10)}, body)
+		_, _ = w.Write([]byte(`[{"name":"a.txt"}]`))
+	}))
+	defer server.Close()
+
+	limit := 10
+	response, err := executeSupabaseOperation(context.Background(), server.Client(), &SupabaseConfig{
+		URL: server.URL, Key: "secret", Operation: OpStorageList,
+		Storage: &StorageConfig{Bucket: "uploads", Prefix: "docs", Limit: &limit},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "a.txt"}}, response.Data)
+}
@@ -9,18 +9,19 @@ type SupabasePlugin struct {
 
 // SupabaseConfig defines the configuration for Supabase operations
 type SupabaseConfig struct {
-	URL       string         `json:"url" yaml:"url"`                         // Supabase project URL
-	Key       string         `json:"key" yaml:"key"`                         // Supabase API key (anon or service)
-	Operation string         `json:"operation" yaml:"operation"`             // Operation type
-	Table     string         `json:"table,omitempty" yaml:"table,omitempty"` // Table name for DB operations
-	Select    *SelectConfig  `json:"select,omitempty" yaml:"select,omitempty"`
-	Insert    *InsertConfig  `json:"insert,omitempty" yaml:"insert,omitempty"`
-	Update    *UpdateConfig  `json:"update,omitempty" yaml:"update,omitempty"`
-	Delete    *DeleteConfig  `json:"delete,omitempty" yaml:"delete,omitempty"`
-	RPC       *RPCConfig     `json:"rpc,omitempty" yaml:"rpc,omitempty"`
-	Auth      *AuthConfig    `json:"auth,omitempty" yaml:"auth,omitempty"`
-	Storage   *StorageConfig `json:"storage,omitempty" yaml:"storage,omitempty"`
-	Timeout   string         `json:"timeout,omitempty" yaml:"timeout,omitempty"` // Operation timeout
+	URL       string          `json:"url" yaml:"url"`                         // Supabase project URL
+	Key       string          `json:"key" yaml:"key"`                         // Supabase API key (anon or service)
+	Operation string          `json:"operation" yaml:"operation"`             // Operation type
+	Table     string          `json:"table,omitempty" yaml:"table,omitempty"` // Table name for DB operations
+	Select    *SelectConfig   `json:"select,omitempty" yaml:"select,omitempty"`
+	Insert    *InsertConfig   `json:"insert,omitempty" yaml:"insert,omitempty"`
+	Update    *UpdateConfig   `json:"update,omitempty" yaml:"update,omitempty"`
+	Delete    *DeleteConfig   `json:"delete,omitempty" yaml:"delete,omitempty"`
+	RPC       *RPCConfig      `json:"rpc,omitempty" yaml:"rpc,omitempty"`
+	Auth      *AuthConfig     `json:"auth,omitempty" yaml:"auth,omitempty"`
+	Storage   *StorageConfig  `json:"storage,omitempty" yaml:"storage,omitempty"`
+	Realtime  *RealtimeConfig `json:"realtime,omitempty" yaml:"realtime,omitempty"`
+	Timeout   string          `json:"timeout,omitempty" yaml:"timeout,omitempty"` // Operation timeout
 }
 
 // SelectConfig defines configuration for SELECT operations
@@ -89,6 +90,52 @@ type StorageConfig struct {
 	Public       bool   `json:"public,omitempty" yaml:"public,omitempty"`               // Public access
 	CacheControl string `json:"cache_control,omitempty" yaml:"cache_control,omitempty"` // Cache control header
 	ContentType  string `json:"content_type,omitempty" yaml:"content_type,omitempty"`   // Content type
+	Upsert       bool   `json:"upsert,omitempty" yaml:"upsert,omitempty"`               // Overwrite an existing file on upload
+	Prefix       string `json:"prefix,omitempty" yaml:"prefix,omitempty"`               // Folder to list
+	Search       string `json:"search,omitempty" yaml:"search,omitempty"`               // Name filter for list
+	Limit        *int   `json:"limit,omitempty" yaml:"limit,omitempty"`                 // Max objects to list
+	Offset       *int   `json:"offset,omitempty" yaml:"offset,omitempty"`               // Objects to skip when listing
+}
+
+// RealtimeConfig defines configuration for realtime subscriptions. The step
+// joins the channel, runs its trigger and sends its broadcasts, then waits for
+// the events.
+type RealtimeConfig struct {
+	Channel     string            `json:"channel" yaml:"channel"`                                       // Channel name
+	AccessToken string            `json:"access_token,omitempty" yaml:"access_token,omitempty"`         // User JWT for RLS (defaults to key)
+	Changes     []ChangeConfig    `json:"postgres_changes,omitempty" yaml:"postgres_changes,omitempty"` // Database changes to listen for
+	Broadcast   []string          `json:"broadcast,omitempty" yaml:"broadcast,omitempty"`               // Broadcast events to listen for ("*" for all)
+	Send        []BroadcastConfig `json:"send,omitempty" yaml:"send,omitempty"`                         // Broadcasts sent once subscribed
+	Trigger     *SupabaseConfig   `json:"trigger,omitempty" yaml:"trigger,omitempty"`                   // Operation run once subscribed
+	Count       int               `json:"count,omitempty" yaml:"count,omitempty"`                       // Events to wait for (default 1)
+}
+
+// ChangeConfig selects database changes, as in a postgres_changes subscription
+type ChangeConfig struct {
+	Event  string `json:"event" yaml:"event"`                       // INSERT, UPDATE, DELETE or * (default *)
+	Schema string `json:"schema" yaml:"schema"`                     // Schema (default public)
+	Table  string `json:"table,omitempty" yaml:"table,omitempty"`   // Table (all tables when empty)
+	Filter string `json:"filter,omitempty" yaml:"filter,omitempty"` // Row filter, e.g. id=eq.1
+}
+
+// BroadcastConfig is a broadcast message sent on the channel
+type BroadcastConfig struct {
+	Event   string      `json:"event" yaml:"event"`                         // Event name
+	Payload interface{} `json:"payload,omitempty" yaml:"payload,omitempty"` // Message payload
+}
+
+// RealtimeEvent is an event received on a channel. Database changes carry
+// the change type (INSERT, UPDATE or DELETE) and rows; broadcasts have the
+// type "broadcast" and carry the event name and payload.
+type RealtimeEvent struct {
+	Type            string      `json:"type"`
+	Schema          string      `json:"schema,omitempty"`
+	Table           string      `json:"table,omitempty"`
+	CommitTimestamp string      `json:"commit_timestamp,omitempty"`
+	Record          interface{} `json:"record,omitempty"`
+	OldRecord       interface{} `json:"old_record,omitempty"`
+	Event           string      `json:"event,omitempty"`
+	Payload         interface{} `json:"payload,omitempty"`
 }
 
 // SupabaseResponse represents the response from Supabase operations
@@ -138,6 +185,8 @@ const (
 	OpStorageUpload       = "storage_upload"
 	OpStorageDownload     = "storage_download"
 	OpStorageDelete       = "storage_delete"
+	OpStorageList         = "storage_list"
+	OpRealtimeSubscribe   = "realtime_subscribe"
 )
 
 // Filter operators
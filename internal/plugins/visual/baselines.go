@@ -0,0 +1,70 @@
+package visual
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/rocketship-ai/rocketship/internal/confine"
+)
+
+// BaselinesEnv is the directory baselines are kept in on this worker. It
+// defaults to the worker's working directory, which is where a local run
+// finds the baselines committed next to its tests.
+const BaselinesEnv = "ROCKETSHIP_VISUAL_BASELINES"
+
+// Ways to handle a baseline that doesn't exist yet
+const (
+	MissingCreate = "create" // The capture becomes the baseline
+	MissingFail   = "fail"   // The step fails, since baselines should be committed
+)
+
+// ciEnv marks a worker running in CI, where missing baselines fail by default
+var ciEnv = []string{"CI", "GITHUB_ACTIONS", "GITLAB_CI", "JENKINS_URL", "BUILDKITE"}
+
+// baselineRoot returns the directory baselines are kept in, with symlinks
+// resolved
+func baselineRoot() (string, error) {
+	dir := os.Getenv(BaselinesEnv)
+	if dir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("failed to find the baselines directory: %w", err)
+		}
+		dir = wd
+	}
+	root, err := confine.Root(dir)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s: %w", BaselinesEnv, err)
+	}
+	return root, nil
+}
+
+// resolveBaseline returns where the baseline is on the worker. Baselines are
+// relative to the baselines directory and may not leave it, through .. or
+// through symlinks.
+func resolveBaseline(baseline string) (string, error) {
+	root, err := baselineRoot()
+	if err != nil {
+		return "", err
+	}
+	path, err := confine.Resolve([]string{root}, baseline)
+	if errors.Is(err, confine.ErrOutside) {
+		return "", fmt.Errorf("baseline %s is outside the baselines directory %s (see %s)", baseline, root, BaselinesEnv)
+	}
+	return path, err
+}
+
+// missingBaseline returns how to handle a missing baseline: as configured, or
+// failing in CI and creating it elsewhere
+func missingBaseline(config *VisualConfig) string {
+	if config.MissingBaseline != "" {
+		return config.MissingBaseline
+	}
+	for _, name := range ciEnv {
+		if value := os.Getenv(name); value != "" && value != "false" && value != "0" {
+			return MissingFail
+		}
+	}
+	return MissingCreate
+}
@@ -0,0 +1,83 @@
+package visual
+
+import (
+	"image"
+	"image/color"
+)
+
+// Colours of the diff image
+var (
+	diffColor    = color.NRGBA{R: 255, A: 255}
+	missingColor = color.NRGBA{R: 255, G: 0, B: 255, A: 255}
+)
+
+// comparison is the outcome of comparing a capture with its baseline
+type comparison struct {
+	DiffPixels int
+	Total      int
+	Diff       *image.NRGBA // Baseline faded to grey, with differing pixels in red
+}
+
+// Ratio is the share of pixels that differ
+func (c comparison) Ratio() float64 {
+	if c.Total == 0 {
+		return 0
+	}
+	return float64(c.DiffPixels) / float64(c.Total)
+}
+
+// compare counts the pixels whose colour differs by more than threshold, on a
+// 0 to 1 scale of the largest channel difference. Both images are flattened
+// onto white first so transparent areas compare by what is shown. When the
+// sizes differ, pixels outside either image count as different and are
+// marked in magenta.
+func compare(baseline, actual image.Image, threshold float64) comparison {
+	bb, ab := baseline.Bounds(), actual.Bounds()
+	width, height := max(bb.Dx(), ab.Dx()), max(bb.Dy(), ab.Dy())
+
+	result := comparison{
+		Total: width * height,
+		Diff:  image.NewNRGBA(image.Rect(0, 0, width, height)),
+	}
+	limit := threshold * 0xffff
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			bp := image.Pt(bb.Min.X+x, bb.Min.Y+y)
+			ap := image.Pt(ab.Min.X+x, ab.Min.Y+y)
+			if !bp.In(bb) || !ap.In(ab) {
+				result.DiffPixels++
+				result.Diff.SetNRGBA(x, y, missingColor)
+				continue
+			}
+
+			br, bg, bbl := onWhite(baseline.At(bp.X, bp.Y))
+			ar, ag, abl := onWhite(actual.At(ap.X, ap.Y))
+			delta := max(absDiff(br, ar), absDiff(bg, ag), absDiff(bbl, abl))
+			if float64(delta) > limit {
+				result.DiffPixels++
+				result.Diff.SetNRGBA(x, y, diffColor)
+				continue
+			}
+
+			// Faded greyscale keeps the page recognisable behind the red
+			grey := uint8((br*299 + bg*587 + bbl*114) / 1000 >> 8)
+			faded := 255 - (255-grey)/4
+			result.Diff.SetNRGBA(x, y, color.NRGBA{R: faded, G: faded, B: faded, A: 255})
+		}
+	}
+	return result
+}
+
+// onWhite returns c's 16-bit channels composited over a white background
+func onWhite(c color.Color) (uint32, uint32, uint32) {
+	r, g, b, a := c.RGBA()
+	return r + 0xffff - a, g + 0xffff - a, b + 0xffff - a
+}
+
+func absDiff(a, b uint32) uint32 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
@@ -0,0 +1,60 @@
+package visual
+
+import "github.com/rocketship-ai/rocketship/internal/assertions"
+
+// VisualPlugin represents a screenshot comparison test step
+type VisualPlugin struct {
+	Name   string       `json:"name" yaml:"name"`
+	Plugin string       `json:"plugin" yaml:"plugin"`
+	Config VisualConfig `json:"config" yaml:"config"`
+}
+
+// VisualConfig selects what to capture and how closely it must match the baseline
+type VisualConfig struct {
+	URL             string   `json:"url,omitempty" yaml:"url,omitempty"`                           // Page to open; the current page is used when empty
+	SessionID       string   `json:"session_id,omitempty" yaml:"session_id,omitempty"`             // Browser started by playwright role start; a new one is launched when empty
+	Executable      string   `json:"executable,omitempty" yaml:"executable,omitempty"`             // Chromium to launch (defaults to ROCKETSHIP_CHROME_PATH, then Playwright's)
+	Headless        *bool    `json:"headless,omitempty" yaml:"headless,omitempty"`                 // Defaults to true
+	Width           int      `json:"width,omitempty" yaml:"width,omitempty"`                       // Viewport width of a launched browser (defaults to 1280)
+	Height          int      `json:"height,omitempty" yaml:"height,omitempty"`                     // Viewport height of a launched browser (defaults to 720)
+	Selector        string   `json:"selector,omitempty" yaml:"selector,omitempty"`                 // Element to capture; the viewport when empty
+	FullPage        bool     `json:"full_page,omitempty" yaml:"full_page,omitempty"`               // Capture the whole scrollable page
+	Hide            []string `json:"hide,omitempty" yaml:"hide,omitempty"`                         // Selectors hidden before capture, e.g. clocks and ads
+	Baseline        string   `json:"baseline" yaml:"baseline"`                                     // PNG to compare against, relative to the worker's baselines directory
+	UpdateBaseline  bool     `json:"update_baseline,omitempty" yaml:"update_baseline,omitempty"`   // Replace the baseline with this capture
+	MissingBaseline string   `json:"missing_baseline,omitempty" yaml:"missing_baseline,omitempty"` // create or fail (defaults to fail in CI, create elsewhere)
+	Threshold       *float64 `json:"threshold,omitempty" yaml:"threshold,omitempty"`               // How far a pixel's colour may drift, 0 to 1 (defaults to 0.1)
+	MaxDiffPixels   *int     `json:"max_diff_pixels,omitempty" yaml:"max_diff_pixels,omitempty"`   // Differing pixels allowed
+	MaxDiffRatio    *float64 `json:"max_diff_ratio,omitempty" yaml:"max_diff_ratio,omitempty"`     // Share of differing pixels allowed, 0 to 1
+	WaitTimeout     string   `json:"wait_timeout,omitempty" yaml:"wait_timeout,omitempty"`         // How long to wait for the page and selector (defaults to 10s)
+	Timeout         string   `json:"timeout,omitempty" yaml:"timeout,omitempty"`                   // Overall step timeout (defaults to 2m)
+}
+
+// Comparison outcomes
+const (
+	StatusMatched = "matched" // Within the allowed difference
+	StatusCreated = "created" // No baseline existed, so the capture became it
+	StatusUpdated = "updated" // update_baseline replaced the baseline
+)
+
+// VisualResponse describes the capture and how it compared
+type VisualResponse struct {
+	URL        string  `json:"url"`
+	Baseline   string  `json:"baseline"`
+	Status     string  `json:"status"`
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+	DiffPixels int     `json:"diff_pixels"`
+	DiffRatio  float64 `json:"diff_ratio"`
+	Duration   string  `json:"duration"`
+}
+
+// AssertionResult represents a single assertion result for UI display
+type AssertionResult = assertions.Result
+
+// ActivityResponse represents the complete activity response
+type ActivityResponse struct {
+	Response         *VisualResponse   `json:"response"`
+	Saved            map[string]string `json:"saved"`
+	AssertionResults []AssertionResult `json:"assertion_results,omitempty"`
+}
@@ -0,0 +1,690 @@
+package visual
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/rocketship-ai/rocketship/internal/artifacts"
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/browser/pw"
+	"github.com/rocketship-ai/rocketship/internal/confine"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+	"go.temporal.io/sdk/activity"
+)
+
+const (
+	defaultTimeout     = 2 * time.Minute
+	defaultWaitTimeout = 10 * time.Second
+	defaultThreshold   = 0.1
+
+	// pollInterval is how often the plugin re-checks the page while it waits
+	pollInterval = 100 * time.Millisecond
+)
+
+// page is the part of pw.Tab the plugin uses
+type page interface {
+	Navigate(ctx context.Context, url string) error
+	Evaluate(ctx context.Context, expression string, out interface{}) error
+	Screenshot(ctx context.Context, clip *playwright.Rect) ([]byte, error)
+}
+
+// prepareScript freezes animations, hides the given selectors and waits for
+// web fonts, so captures don't change from run to run
+const prepareScript = `(async function(hide) {
+  const style = document.createElement('style');
+  style.setAttribute('data-rocketship-visual', '');
+  style.textContent = '*, *::before, *::after { animation: none !important; transition: none !important; caret-color: transparent !important; }' +
+    hide.map(s => s + ' { visibility: hidden !important; }').join('\n');
+  (document.head || document.documentElement).appendChild(style);
+  if (document.fonts) await document.fonts.ready;
+  await new Promise(resolve => requestAnimationFrame(() => requestAnimationFrame(resolve)));
+  return true;
+})`
+
+// restoreScript removes what prepareScript added, for pages that stay open in a session
+const restoreScript = `(function() {
+  document.querySelectorAll('style[data-rocketship-visual]').forEach(s => s.remove());
+  return true;
+})()`
+
+// regionScript reports the area to capture, in page coordinates
+const regionScript = `(function(selector, fullPage) {
+  const root = document.documentElement;
+  if (selector) {
+    const el = document.querySelector(selector);
+    if (!el) return {found: false};
+    el.scrollIntoView({block: 'center', inline: 'center'});
+    const r = el.getBoundingClientRect();
+    const style = getComputedStyle(el);
+    const visible = r.width > 0 && r.height > 0 && style.visibility !== 'hidden' && style.display !== 'none';
+    return {found: true, visible, x: r.left + scrollX, y: r.top + scrollY, width: r.width, height: r.height};
+  }
+  if (fullPage) {
+    return {found: true, visible: true, x: 0, y: 0, width: Math.max(root.scrollWidth, root.clientWidth), height: Math.max(root.scrollHeight, root.clientHeight)};
+  }
+  return {found: true, visible: true, x: scrollX, y: scrollY, width: root.clientWidth, height: root.clientHeight};
+})`
+
+// locationScript reads the page's address and load state
+const locationScript = `({url: location.href, ready: document.readyState})`
+
+// region is what regionScript reports
+type region struct {
+	Found   bool    `json:"found"`
+	Visible bool    `json:"visible"`
+	X       float64 `json:"x"`
+	Y       float64 `json:"y"`
+	Width   float64 `json:"width"`
+	Height  float64 `json:"height"`
+}
+
+// location is what locationScript reports
+type location struct {
+	URL   string `json:"url"`
+	Ready string `json:"ready"`
+}
+
+// Auto-register the plugin when the package is imported
+func init() {
+	plugins.RegisterPlugin(&VisualPlugin{})
+}
+
+// GetType returns the plugin type identifier
+func (vp *VisualPlugin) GetType() string {
+	return "visual"
+}
+
+// Activity captures a screenshot and compares it with the stored baseline
+func (vp *VisualPlugin) Activity(ctx context.Context, p map[string]interface{}) (interface{}, error) {
+	logger := activity.GetLogger(ctx)
+
+	// Parse configuration from parameters
+	configData, ok := p["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid config format")
+	}
+
+	config := &VisualConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		return nil, fmt.Errorf("failed to parse visual config: %w", err)
+	}
+
+	timeout := defaultTimeout
+	if config.Timeout != "" {
+		parsed, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", config.Timeout, err)
+		}
+		timeout = parsed
+	}
+
+	// Convert state to map[string]interface{} for template processing
+	state := make(map[string]interface{})
+	if stateStr, ok := p["state"].(map[string]string); ok {
+		for k, v := range stateStr {
+			state[k] = v
+		}
+	} else if stateInt, ok := p["state"].(map[string]interface{}); ok {
+		state = stateInt
+	}
+
+	// Add run metadata to state for template processing
+	runID := ""
+	if runData, ok := p["run"].(map[string]interface{}); ok {
+		state["run"] = runData
+		runID, _ = runData["id"].(string)
+	}
+
+	// Extract env secrets from params (for {{ .env.* }} template resolution)
+	env := make(map[string]string)
+	if envData, ok := p["env"].(map[string]interface{}); ok {
+		for k, v := range envData {
+			if strVal, ok := v.(string); ok {
+				env[k] = strVal
+			}
+		}
+	} else if envData, ok := p["env"].(map[string]string); ok {
+		env = envData
+	}
+
+	if err := applyVariableReplacement(config, state, env); err != nil {
+		return nil, fmt.Errorf("variable replacement failed: %w", err)
+	}
+
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	stepName, _ := p["name"].(string)
+	logger.Info("Executing visual plugin", "url", config.URL, "selector", config.Selector, "baseline", config.Baseline)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	policy, err := egress.FromParams(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
+
+	headless := config.Headless == nil || *config.Headless
+	tab, err := pw.Open(ctx, pw.OpenOptions{
+		SessionID:  config.SessionID,
+		Executable: config.Executable,
+		Headless:   headless,
+		Policy:     policy,
+		Context:    playwright.BrowserNewContextOptions{Viewport: viewport(config)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer tab.Close()
+
+	response, err := execute(ctx, tab, config, runID, stepName)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := assertions.Normalize(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare visual result: %w", err)
+	}
+
+	assertionResults, err := assertions.Process(p, subject, dsl.TemplateContext{Runtime: state, Env: env}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if failure := assertions.Summary(assertionResults); failure != "" {
+		return nil, fmt.Errorf("%s", failure)
+	}
+
+	saved := make(map[string]string)
+	if err := saves.Process(p, subject, saved); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Visual step completed", "status", response.Status, "diff_pixels", response.DiffPixels, "duration", response.Duration)
+
+	return &ActivityResponse{
+		Response:         response,
+		Saved:            saved,
+		AssertionResults: assertionResults,
+	}, nil
+}
+
+// execute captures the page and compares it with the baseline. When the
+// capture differs by more than allowed, the baseline, capture and diff are
+// saved as run artifacts and the error names the diff.
+func execute(ctx context.Context, pg page, config *VisualConfig, runID, stepName string) (*VisualResponse, error) {
+	start := time.Now()
+	baselinePath, err := resolveBaseline(config.Baseline)
+	if err != nil {
+		return nil, err
+	}
+
+	waitTimeout := defaultWaitTimeout
+	if config.WaitTimeout != "" {
+		waitTimeout, _ = time.ParseDuration(config.WaitTimeout)
+	}
+
+	if config.URL != "" {
+		if err := navigate(ctx, pg, config.URL, waitTimeout); err != nil {
+			return nil, err
+		}
+	}
+
+	shot, err := capture(ctx, pg, config, waitTimeout)
+	if err != nil {
+		return nil, err
+	}
+	actual, err := png.Decode(bytes.NewReader(shot))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode screenshot: %w", err)
+	}
+
+	var loc location
+	if err := pg.Evaluate(ctx, locationScript, &loc); err != nil {
+		return nil, fmt.Errorf("failed to read page location: %w", err)
+	}
+
+	response := &VisualResponse{
+		URL:      loc.URL,
+		Baseline: config.Baseline,
+		Width:    actual.Bounds().Dx(),
+		Height:   actual.Bounds().Dy(),
+	}
+	defer func() { response.Duration = time.Since(start).String() }()
+
+	expected, err := os.ReadFile(baselinePath)
+	if errors.Is(err, os.ErrNotExist) && !config.UpdateBaseline && missingBaseline(config) == MissingFail {
+		return nil, fmt.Errorf("baseline %s does not exist; commit it, or run the step with update_baseline: true or missing_baseline: create to capture it", config.Baseline)
+	}
+	if errors.Is(err, os.ErrNotExist) || config.UpdateBaseline {
+		if err := writeBaseline(baselinePath, shot); err != nil {
+			return nil, err
+		}
+		response.Status = StatusUpdated
+		if expected == nil {
+			response.Status = StatusCreated
+		}
+		return response, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+
+	baseline, err := png.Decode(bytes.NewReader(expected))
+	if err != nil {
+		return nil, fmt.Errorf("baseline %s is not a valid PNG: %w", config.Baseline, err)
+	}
+
+	threshold := defaultThreshold
+	if config.Threshold != nil {
+		threshold = *config.Threshold
+	}
+	result := compare(baseline, actual, threshold)
+	response.DiffPixels = result.DiffPixels
+	response.DiffRatio = result.Ratio()
+
+	sameSize := baseline.Bounds().Size() == actual.Bounds().Size()
+	if sameSize && withinLimits(config, result) {
+		response.Status = StatusMatched
+		return response, nil
+	}
+	var diff bytes.Buffer
+	if err := png.Encode(&diff, result.Diff); err != nil {
+		return nil, fmt.Errorf("failed to encode diff image: %w", err)
+	}
+	name := stepName
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(config.Baseline), filepath.Ext(config.Baseline))
+	}
+	files := []struct {
+		suffix, artifactType string
+		data                 []byte
+	}{
+		{"expected", "baseline", expected},
+		{"actual", "screenshot", shot},
+		{"diff", "diff", diff.Bytes()},
+	}
+	var diffPath string
+	for _, file := range files {
+		artifact, err := artifacts.Save(runID, name+"-"+file.suffix+".png", file.artifactType, "image/png", file.data)
+		if err != nil {
+			return nil, err
+		}
+		diffPath = artifact.Path
+	}
+
+	if !sameSize {
+		return nil, fmt.Errorf("screenshot is %dx%d but baseline %s is %dx%d; diff saved to %s",
+			response.Width, response.Height, config.Baseline, baseline.Bounds().Dx(), baseline.Bounds().Dy(), diffPath)
+	}
+	return nil, fmt.Errorf("screenshot differs from baseline %s: %d pixels (%.2f%%) changed, %s allowed; diff saved to %s",
+		config.Baseline, result.DiffPixels, result.Ratio()*100, describeLimits(config), diffPath)
+}
+
+// navigate loads url and waits for it to finish loading
+func navigate(ctx context.Context, pg page, url string, timeout time.Duration) error {
+	if err := pg.Navigate(ctx, url); err != nil {
+		return err
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := poll(waitCtx, func() (bool, error) {
+		var loc location
+		err := pg.Evaluate(waitCtx, locationScript, &loc)
+		return err == nil && loc.Ready == "complete", err
+	})
+	if err != nil && waitCtx.Err() != nil {
+		return fmt.Errorf("%s did not finish loading within %s", url, timeout)
+	}
+	return err
+}
+
+// capture waits for the region to show, steadies the page and takes the screenshot
+func capture(ctx context.Context, pg page, config *VisualConfig, timeout time.Duration) ([]byte, error) {
+	hide := config.Hide
+	if hide == nil {
+		hide = []string{}
+	}
+	if err := pg.Evaluate(ctx, call(prepareScript, hide), nil); err != nil {
+		return nil, fmt.Errorf("failed to prepare page: %w", err)
+	}
+	defer func() {
+		restoreCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = pg.Evaluate(restoreCtx, restoreScript, nil)
+	}()
+
+	var area region
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := poll(waitCtx, func() (bool, error) {
+		err := pg.Evaluate(waitCtx, call(regionScript, config.Selector, config.FullPage), &area)
+		return err == nil && area.Found && area.Visible, err
+	})
+	if err != nil {
+		if waitCtx.Err() == nil {
+			return nil, err
+		}
+		if !area.Found {
+			return nil, fmt.Errorf("element %q not found within %s", config.Selector, timeout)
+		}
+		return nil, fmt.Errorf("element %q not visible within %s", config.Selector, timeout)
+	}
+
+	var clip *playwright.Rect
+	if config.Selector != "" || config.FullPage {
+		// Whole pixels keep the capture the same size from run to run
+		x, y := math.Floor(area.X), math.Floor(area.Y)
+		clip = &playwright.Rect{
+			X:      x,
+			Y:      y,
+			Width:  math.Ceil(area.X+area.Width) - x,
+			Height: math.Ceil(area.Y+area.Height) - y,
+		}
+	}
+
+	shot, err := pg.Screenshot(ctx, clip)
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture screenshot: %w", err)
+	}
+	return shot, nil
+}
+
+// viewport returns the viewport of a launched browser, or nil for Playwright's
+// 1280x720 when the step doesn't size it
+func viewport(config *VisualConfig) *playwright.Size {
+	if config.Width == 0 && config.Height == 0 {
+		return nil
+	}
+	size := &playwright.Size{Width: 1280, Height: 720}
+	if config.Width != 0 {
+		size.Width = config.Width
+	}
+	if config.Height != 0 {
+		size.Height = config.Height
+	}
+	return size
+}
+
+// call renders a call of script with JSON-encoded arguments
+func call(script string, args ...interface{}) string {
+	encoded := make([]string, len(args))
+	for i, arg := range args {
+		data, _ := json.Marshal(arg)
+		encoded[i] = string(data)
+	}
+	return script + "(" + strings.Join(encoded, ", ") + ")"
+}
+
+// poll calls check every pollInterval until it reports done or ctx ends
+func poll(ctx context.Context, check func() (bool, error)) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	var lastErr error
+	for {
+		done, err := check()
+		if err == nil && done {
+			return nil
+		}
+		if err != nil {
+			lastErr = err
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			if lastErr != nil && !errors.Is(lastErr, context.DeadlineExceeded) && !errors.Is(lastErr, context.Canceled) {
+				return fmt.Errorf("%w (last error: %v)", ctx.Err(), lastErr)
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+// writeBaseline stores the capture as the new baseline
+func writeBaseline(path string, data []byte) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create baseline directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	return nil
+}
+
+// withinLimits reports whether the difference is small enough to pass. Without
+// limits, any differing pixel fails.
+func withinLimits(config *VisualConfig, result comparison) bool {
+	if config.MaxDiffPixels == nil && config.MaxDiffRatio == nil {
+		return result.DiffPixels == 0
+	}
+	if config.MaxDiffPixels != nil && result.DiffPixels > *config.MaxDiffPixels {
+		return false
+	}
+	if config.MaxDiffRatio != nil && result.Ratio() > *config.MaxDiffRatio {
+		return false
+	}
+	return true
+}
+
+// describeLimits names the allowed difference for errors, e.g. "at most 50 pixels"
+func describeLimits(config *VisualConfig) string {
+	var limits []string
+	if config.MaxDiffPixels != nil {
+		limits = append(limits, fmt.Sprintf("%d pixels", *config.MaxDiffPixels))
+	}
+	if config.MaxDiffRatio != nil {
+		limits = append(limits, fmt.Sprintf("%.2f%%", *config.MaxDiffRatio*100))
+	}
+	if len(limits) == 0 {
+		return "none"
+	}
+	return "at most " + strings.Join(limits, " and ")
+}
+
+// validateConfig checks the settings once templates are rendered
+func validateConfig(config *VisualConfig) error {
+	if config.Baseline == "" {
+		return fmt.Errorf("baseline is required")
+	}
+	if !strings.EqualFold(filepath.Ext(config.Baseline), ".png") {
+		return fmt.Errorf("baseline must be a .png file, got %q", config.Baseline)
+	}
+	if err := confine.Relative(config.Baseline); err != nil {
+		return fmt.Errorf("baseline: %w", err)
+	}
+	if config.MissingBaseline != "" && config.MissingBaseline != MissingCreate && config.MissingBaseline != MissingFail {
+		return fmt.Errorf("missing_baseline must be %q or %q, got %q", MissingCreate, MissingFail, config.MissingBaseline)
+	}
+	if config.URL == "" && config.SessionID == "" {
+		return fmt.Errorf("url is required unless session_id is set")
+	}
+	if config.SessionID != "" && (config.Executable != "" || config.Headless != nil || config.Width != 0 || config.Height != 0) {
+		return fmt.Errorf("executable, headless, width and height can't be used with session_id: the session's browser is already running")
+	}
+	if config.Width < 0 || config.Height < 0 {
+		return fmt.Errorf("width and height must be positive")
+	}
+	if config.Selector != "" && config.FullPage {
+		return fmt.Errorf("use either selector or full_page, not both")
+	}
+	if config.Threshold != nil && (*config.Threshold < 0 || *config.Threshold > 1) {
+		return fmt.Errorf("threshold must be between 0 and 1, got %v", *config.Threshold)
+	}
+	if config.MaxDiffPixels != nil && *config.MaxDiffPixels < 0 {
+		return fmt.Errorf("max_diff_pixels can't be negative")
+	}
+	if config.MaxDiffRatio != nil && (*config.MaxDiffRatio < 0 || *config.MaxDiffRatio > 1) {
+		return fmt.Errorf("max_diff_ratio must be between 0 and 1, got %v", *config.MaxDiffRatio)
+	}
+	if config.WaitTimeout != "" {
+		if d, err := time.ParseDuration(config.WaitTimeout); err != nil || d <= 0 {
+			return fmt.Errorf("invalid wait_timeout %q: must be a positive duration", config.WaitTimeout)
+		}
+	}
+	for i, selector := range config.Hide {
+		if strings.TrimSpace(selector) == "" {
+			return fmt.Errorf("hide[%d] is empty", i)
+		}
+	}
+	return nil
+}
+
+// applyVariableReplacement processes templates in the page, browser, selector
+// and baseline settings
+func applyVariableReplacement(config *VisualConfig, state map[string]interface{}, env map[string]string) error {
+	context := dsl.TemplateContext{
+		Runtime: state,
+		Env:     env,
+	}
+
+	render := func(name string, value *string) error {
+		if *value == "" {
+			return nil
+		}
+		processed, err := dsl.ProcessTemplate(*value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process %s template: %w", name, err)
+		}
+		*value = processed
+		return nil
+	}
+
+	fields := map[string]*string{
+		"url":        &config.URL,
+		"session_id": &config.SessionID,
+		"executable": &config.Executable,
+		"selector":   &config.Selector,
+		"baseline":   &config.Baseline,
+	}
+	for name, value := range fields {
+		if err := render(name, value); err != nil {
+			return err
+		}
+	}
+	for i := range config.Hide {
+		if err := render(fmt.Sprintf("hide[%d]", i), &config.Hide[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseConfig converts map[string]interface{} to VisualConfig
+func parseConfig(configData map[string]interface{}, config *VisualConfig) error {
+	stringFields := map[string]*string{
+		"url":              &config.URL,
+		"session_id":       &config.SessionID,
+		"executable":       &config.Executable,
+		"selector":         &config.Selector,
+		"baseline":         &config.Baseline,
+		"missing_baseline": &config.MissingBaseline,
+		"wait_timeout":     &config.WaitTimeout,
+		"timeout":          &config.Timeout,
+	}
+	for key, target := range stringFields {
+		if v, ok := configData[key].(string); ok {
+			*target = v
+		}
+	}
+
+	boolFields := map[string]*bool{
+		"full_page":       &config.FullPage,
+		"update_baseline": &config.UpdateBaseline,
+	}
+	for key, target := range boolFields {
+		switch v := configData[key].(type) {
+		case nil:
+		case bool:
+			*target = v
+		default:
+			return fmt.Errorf("%s must be a boolean, got %T", key, v)
+		}
+	}
+
+	switch v := configData["headless"].(type) {
+	case nil:
+	case bool:
+		config.Headless = &v
+	default:
+		return fmt.Errorf("headless must be a boolean, got %T", v)
+	}
+
+	for key, target := range map[string]*int{"width": &config.Width, "height": &config.Height} {
+		if n, ok, err := intField(configData, key); err != nil {
+			return err
+		} else if ok {
+			*target = n
+		}
+	}
+	if n, ok, err := intField(configData, "max_diff_pixels"); err != nil {
+		return err
+	} else if ok {
+		config.MaxDiffPixels = &n
+	}
+
+	for key, target := range map[string]**float64{"threshold": &config.Threshold, "max_diff_ratio": &config.MaxDiffRatio} {
+		switch v := configData[key].(type) {
+		case nil:
+		case float64:
+			*target = &v
+		case int:
+			f := float64(v)
+			*target = &f
+		default:
+			return fmt.Errorf("%s must be a number, got %T", key, v)
+		}
+	}
+
+	switch v := configData["hide"].(type) {
+	case nil:
+	case []interface{}:
+		for i, item := range v {
+			selector, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("hide[%d] must be a string, got %T", i, item)
+			}
+			config.Hide = append(config.Hide, selector)
+		}
+	case []string:
+		config.Hide = append(config.Hide, v...)
+	default:
+		return fmt.Errorf("hide must be a list of selectors, got %T", v)
+	}
+
+	return nil
+}
+
+// intField reads a whole number, which YAML and JSON decoding may deliver as a float
+func intField(configData map[string]interface{}, key string) (int, bool, error) {
+	switch v := configData[key].(type) {
+	case nil:
+		return 0, false, nil
+	case int:
+		return v, true, nil
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, false, fmt.Errorf("%s must be a whole number, got %v", key, v)
+		}
+		return int(v), true, nil
+	default:
+		return 0, false, fmt.Errorf("%s must be a number, got %T", key, v)
+	}
+}
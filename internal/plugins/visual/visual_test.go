@@ -0,0 +1,322 @@
+package visual
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// fakePage answers the plugin's scripts and returns shot for every capture
+type fakePage struct {
+	url      string
+	shot     image.Image
+	found    bool
+	prepared []string
+	restored bool
+	clip     *playwright.Rect
+}
+
+func (f *fakePage) Navigate(_ context.Context, url string) error {
+	f.url = url
+	return nil
+}
+
+func (f *fakePage) Evaluate(_ context.Context, expression string, out interface{}) error {
+	var result interface{}
+	switch {
+	case expression == locationScript:
+		result = map[string]interface{}{"url": f.url, "ready": "complete"}
+	case expression == restoreScript:
+		f.restored = true
+		result = true
+	case strings.HasPrefix(expression, prepareScript+"("):
+		if err := json.Unmarshal([]byte(strings.TrimSuffix(strings.TrimPrefix(expression, prepareScript+"("), ")")), &f.prepared); err != nil {
+			return err
+		}
+		result = true
+	case strings.HasPrefix(expression, regionScript+"("):
+		result = map[string]interface{}{"found": f.found, "visible": f.found, "x": 10.4, "y": 200.6, "width": 50.2, "height": 20}
+	default:
+		return fmt.Errorf("unexpected expression %q", expression)
+	}
+	if out == nil {
+		return nil
+	}
+	data, _ := json.Marshal(result)
+	return json.Unmarshal(data, out)
+}
+
+func (f *fakePage) Screenshot(_ context.Context, clip *playwright.Rect) ([]byte, error) {
+	f.clip = clip
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, f.shot); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// solid returns a w×h image of c with the given pixels painted red
+func solid(w, h int, c color.Color, red ...image.Point) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	for _, pt := range red {
+		img.Set(pt.X, pt.Y, color.NRGBA{R: 255, A: 255})
+	}
+	return img
+}
+
+func writePNG(t *testing.T, path string, img image.Image) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func intPtr(n int) *int { return &n }
+
+func TestCompare(t *testing.T) {
+	white := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+
+	result := compare(solid(10, 10, white), solid(10, 10, white, image.Pt(1, 1), image.Pt(2, 2)), 0.1)
+	if result.DiffPixels != 2 || result.Total != 100 || result.Ratio() != 0.02 {
+		t.Fatalf("unexpected comparison: %d of %d", result.DiffPixels, result.Total)
+	}
+	if got := result.Diff.NRGBAAt(1, 1); got != diffColor {
+		t.Fatalf("diff pixel = %v, want red", got)
+	}
+
+	// Small colour drift is within the threshold
+	nearWhite := color.NRGBA{R: 250, G: 250, B: 250, A: 255}
+	if result := compare(solid(4, 4, white), solid(4, 4, nearWhite), 0.1); result.DiffPixels != 0 {
+		t.Fatalf("drift within threshold counted %d pixels", result.DiffPixels)
+	}
+	if result := compare(solid(4, 4, white), solid(4, 4, nearWhite), 0); result.DiffPixels != 16 {
+		t.Fatalf("threshold 0 counted %d pixels, want 16", result.DiffPixels)
+	}
+
+	// Transparent pixels compare as the white they show
+	if result := compare(solid(4, 4, white), solid(4, 4, color.NRGBA{}), 0); result.DiffPixels != 0 {
+		t.Fatalf("transparent counted %d pixels", result.DiffPixels)
+	}
+
+	// Pixels outside the smaller image differ
+	result = compare(solid(4, 4, white), solid(4, 6, white), 0.1)
+	if result.DiffPixels != 8 || result.Total != 24 {
+		t.Fatalf("size change counted %d of %d", result.DiffPixels, result.Total)
+	}
+	if got := result.Diff.NRGBAAt(0, 5); got != missingColor {
+		t.Fatalf("missing pixel = %v, want magenta", got)
+	}
+}
+
+func TestExecuteCreatesAndMatchesBaseline(t *testing.T) {
+	t.Setenv("ROCKETSHIP_ARTIFACTS_DIR", t.TempDir())
+	root := t.TempDir()
+	t.Setenv(BaselinesEnv, root)
+	baseline := filepath.Join(root, "baselines", "home.png")
+	white := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	pg := &fakePage{shot: solid(20, 10, white), found: true}
+	config := &VisualConfig{URL: "https://app.test/", Baseline: "baselines/home.png", Hide: []string{".clock"}, MissingBaseline: MissingCreate}
+
+	response, err := execute(context.Background(), pg, config, "run-1", "Home")
+	if err != nil {
+		t.Fatalf("execute() error = %v", err)
+	}
+	if response.Status != StatusCreated || response.Width != 20 || response.Height != 10 || response.URL != "https://app.test/" {
+		t.Fatalf("unexpected response: %+v", response)
+	}
+	if _, err := os.Stat(baseline); err != nil {
+		t.Fatalf("baseline not written: %v", err)
+	}
+	if len(pg.prepared) != 1 || pg.prepared[0] != ".clock" || !pg.restored {
+		t.Fatalf("page not prepared and restored: %v %v", pg.prepared, pg.restored)
+	}
+	if pg.clip != nil {
+		t.Fatalf("viewport capture was clipped: %v", pg.clip)
+	}
+
+	response, err = execute(context.Background(), pg, config, "run-1", "Home")
+	if err != nil {
+		t.Fatalf("execute() error = %v", err)
+	}
+	if response.Status != StatusMatched || response.DiffPixels != 0 {
+		t.Fatalf("unexpected response: %+v", response)
+	}
+
+	config.UpdateBaseline = true
+	pg.shot = solid(20, 10, white, image.Pt(0, 0))
+	response, err = execute(context.Background(), pg, config, "run-1", "Home")
+	if err != nil || response.Status != StatusUpdated {
+		t.Fatalf("update: %+v, %v", response, err)
+	}
+}
+
+func TestExecuteSavesDiffWhenChanged(t *testing.T) {
+	artifactsDir := t.TempDir()
+	t.Setenv("ROCKETSHIP_ARTIFACTS_DIR", artifactsDir)
+	root := t.TempDir()
+	t.Setenv(BaselinesEnv, root)
+	white := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	writePNG(t, filepath.Join(root, "button.png"), solid(10, 10, white))
+
+	changed := solid(10, 10, white, image.Pt(1, 1), image.Pt(2, 2), image.Pt(3, 3))
+	pg := &fakePage{shot: changed, found: true}
+	config := &VisualConfig{URL: "https://app.test/", Selector: "#buy", Baseline: "button.png", MaxDiffPixels: intPtr(2)}
+
+	_, err := execute(context.Background(), pg, config, "run-2", "Buy button")
+	if err == nil || !strings.Contains(err.Error(), "3 pixels (3.00%) changed, at most 2 pixels allowed") {
+		t.Fatalf("execute() error = %v", err)
+	}
+	for _, name := range []string{"Buy_button-expected.png", "Buy_button-actual.png", "Buy_button-diff.png"} {
+		if _, err := os.Stat(filepath.Join(artifactsDir, "run-2", name)); err != nil {
+			t.Fatalf("artifact %s missing: %v", name, err)
+		}
+	}
+	if !strings.HasSuffix(err.Error(), filepath.Join(artifactsDir, "run-2", "Buy_button-diff.png")) {
+		t.Fatalf("error doesn't name the diff: %v", err)
+	}
+
+	// The element clip is widened to whole pixels
+	if *pg.clip != (playwright.Rect{X: 10, Y: 200, Width: 51, Height: 21}) {
+		t.Fatalf("unexpected clip: %v", pg.clip)
+	}
+
+	config.MaxDiffPixels = intPtr(3)
+	if _, err := execute(context.Background(), pg, config, "run-2", "Buy button"); err != nil {
+		t.Fatalf("within max_diff_pixels: %v", err)
+	}
+
+	pg.shot = solid(10, 12, white)
+	_, err = execute(context.Background(), pg, config, "run-2", "Buy button")
+	if err == nil || !strings.Contains(err.Error(), "screenshot is 10x12 but baseline") {
+		t.Fatalf("size change error = %v", err)
+	}
+}
+
+func TestExecuteMissingElement(t *testing.T) {
+	t.Setenv(BaselinesEnv, t.TempDir())
+	pg := &fakePage{shot: solid(1, 1, color.White)}
+	config := &VisualConfig{URL: "https://app.test/", Selector: "#gone", Baseline: "gone.png", WaitTimeout: "200ms"}
+
+	_, err := execute(context.Background(), pg, config, "run-3", "Gone")
+	if err == nil || !strings.Contains(err.Error(), `element "#gone" not found within 200ms`) {
+		t.Fatalf("execute() error = %v", err)
+	}
+}
+
+func TestExecuteMissingBaselineInCI(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(BaselinesEnv, root)
+	t.Setenv("CI", "true")
+	pg := &fakePage{shot: solid(4, 4, color.White), found: true}
+	config := &VisualConfig{URL: "https://app.test/", Baseline: "home.png"}
+
+	_, err := execute(context.Background(), pg, config, "run-4", "Home")
+	if err == nil || !strings.Contains(err.Error(), "baseline home.png does not exist") {
+		t.Fatalf("execute() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "home.png")); !os.IsNotExist(err) {
+		t.Fatalf("baseline was created in CI: %v", err)
+	}
+
+	config.MissingBaseline = MissingCreate
+	if response, err := execute(context.Background(), pg, config, "run-4", "Home"); err != nil || response.Status != StatusCreated {
+		t.Fatalf("missing_baseline create: %+v, %v", response, err)
+	}
+}
+
+func TestExecuteBaselineOutsideRoot(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	t.Setenv(BaselinesEnv, root)
+	if err := os.Symlink(outside, filepath.Join(root, "shared")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+	pg := &fakePage{shot: solid(4, 4, color.White), found: true}
+	config := &VisualConfig{URL: "https://app.test/", Baseline: "shared/home.png", MissingBaseline: MissingCreate}
+
+	_, err := execute(context.Background(), pg, config, "run-5", "Home")
+	if err == nil || !strings.Contains(err.Error(), "outside the baselines directory") {
+		t.Fatalf("execute() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "home.png")); !os.IsNotExist(err) {
+		t.Fatalf("baseline was written through the symlink: %v", err)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	ratio := 1.5
+	headless := false
+	cases := []struct {
+		name   string
+		config VisualConfig
+		want   string
+	}{
+		{"no baseline", VisualConfig{URL: "https://app.test"}, "baseline is required"},
+		{"not png", VisualConfig{URL: "https://app.test", Baseline: "home.jpg"}, "must be a .png file"},
+		{"no page", VisualConfig{Baseline: "home.png"}, "url is required unless session_id is set"},
+		{"session and launch", VisualConfig{SessionID: "s", Baseline: "home.png", Headless: &headless}, "can't be used with session_id"},
+		{"selector and full page", VisualConfig{URL: "https://app.test", Baseline: "home.png", Selector: "#a", FullPage: true}, "either selector or full_page"},
+		{"ratio", VisualConfig{URL: "https://app.test", Baseline: "home.png", MaxDiffRatio: &ratio}, "max_diff_ratio must be between 0 and 1"},
+		{"negative pixels", VisualConfig{URL: "https://app.test", Baseline: "home.png", MaxDiffPixels: intPtr(-1)}, "max_diff_pixels can't be negative"},
+		{"absolute baseline", VisualConfig{URL: "https://app.test", Baseline: "/etc/home.png"}, "must be a relative path"},
+		{"parent baseline", VisualConfig{URL: "https://app.test", Baseline: "../home.png"}, "must not leave its directory"},
+		{"missing baseline", VisualConfig{URL: "https://app.test", Baseline: "home.png", MissingBaseline: "skip"}, `missing_baseline must be "create" or "fail"`},
+		{"valid", VisualConfig{SessionID: "s", Baseline: "home.png", Selector: "#a"}, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateConfig(&tc.config)
+			if tc.want == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	config := &VisualConfig{}
+	err := parseConfig(map[string]interface{}{
+		"url":             "https://app.test",
+		"baseline":        "home.png",
+		"full_page":       true,
+		"width":           float64(390),
+		"threshold":       0.2,
+		"max_diff_pixels": 25,
+		"hide":            []interface{}{".clock", ".ad"},
+	}, config)
+	if err != nil {
+		t.Fatalf("parseConfig() error = %v", err)
+	}
+	if !config.FullPage || config.Width != 390 || *config.Threshold != 0.2 || *config.MaxDiffPixels != 25 || len(config.Hide) != 2 {
+		t.Fatalf("unexpected config: %+v", config)
+	}
+
+	if err := parseConfig(map[string]interface{}{"width": 10.5}, &VisualConfig{}); err == nil {
+		t.Fatal("expected an error for a fractional width")
+	}
+}
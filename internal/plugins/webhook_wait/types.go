@@ -0,0 +1,73 @@
+package webhook_wait
+
+import (
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/callbacks"
+)
+
+// WebhookWaitPlugin represents a step that waits for an inbound HTTP call
+type WebhookWaitPlugin struct {
+	Name   string            `json:"name" yaml:"name"`
+	Plugin string            `json:"plugin" yaml:"plugin"`
+	Config WebhookWaitConfig `json:"config" yaml:"config"`
+}
+
+// WebhookWaitConfig registers a callback URL, optionally sends a request that makes
+// the system under test call it, and waits for the call
+type WebhookWaitConfig struct {
+	ID      string         `json:"id,omitempty" yaml:"id,omitempty"` // Callback path segment; random when empty
+	Trigger *TriggerConfig `json:"trigger,omitempty" yaml:"trigger,omitempty"`
+	Match   *MatchConfig   `json:"match,omitempty" yaml:"match,omitempty"`
+	Respond *RespondConfig `json:"respond,omitempty" yaml:"respond,omitempty"`
+	Timeout string         `json:"timeout,omitempty" yaml:"timeout,omitempty"` // How long to wait for the call (defaults to 30s)
+}
+
+// TriggerConfig is the request sent once the step waits on the callback URL. Its
+// templates can use {{ webhook.url }} and {{ webhook.id }}.
+type TriggerConfig struct {
+	Method  string            `json:"method,omitempty" yaml:"method,omitempty"` // Defaults to POST
+	URL     string            `json:"url" yaml:"url"`
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body    string            `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// MatchConfig selects the call to capture. Calls that don't match are answered and
+// ignored. Every set field must match.
+type MatchConfig = callbacks.Match
+
+// RespondConfig is the reply sent to the caller
+type RespondConfig = callbacks.Respond
+
+// Assertion types supported by the webhook_wait plugin in addition to the shared ones
+const (
+	AssertionTypeHeader = "header"
+)
+
+// Request is a captured inbound call
+type Request = callbacks.Request
+
+// TriggerResult is the response to the trigger request
+type TriggerResult struct {
+	StatusCode int    `json:"status_code"`
+	Body       string `json:"body"`
+}
+
+// WebhookResponse contains the callback URL and the captured call
+type WebhookResponse struct {
+	ID       string         `json:"id"`
+	URL      string         `json:"url"`
+	Request  *Request       `json:"request"`
+	Trigger  *TriggerResult `json:"trigger,omitempty"`
+	Ignored  int            `json:"ignored"` // Calls that didn't match before the captured one
+	Duration string         `json:"duration"`
+}
+
+// AssertionResult represents a single assertion result for UI display
+type AssertionResult = assertions.Result
+
+// ActivityResponse represents the complete activity response
+type ActivityResponse struct {
+	Response         *WebhookResponse  `json:"response"`
+	Saved            map[string]string `json:"saved"`
+	AssertionResults []AssertionResult `json:"assertion_results,omitempty"`
+}
@@ -0,0 +1,481 @@
+package webhook_wait
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/callbacks"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+	"go.temporal.io/sdk/activity"
+)
+
+// PublicURLEnv sets the base URL callers reach the engine's callback listener at,
+// e.g. an ingress or a relay in front of the engine
+const PublicURLEnv = "ROCKETSHIP_WEBHOOK_URL"
+
+const (
+	defaultPublicURL = "http://localhost:7702"
+	defaultTimeout   = 30 * time.Second
+	triggerTimeout   = 30 * time.Second
+	maxTriggerBody   = 1 << 20
+)
+
+// Auto-register the plugin when the package is imported
+func init() {
+	plugins.RegisterPlugin(&WebhookWaitPlugin{})
+}
+
+// GetType returns the plugin type identifier
+func (wp *WebhookWaitPlugin) GetType() string {
+	return "webhook_wait"
+}
+
+// Activity runs one phase of a webhook_wait step, selected by the phase param.
+// The test workflow waits for the call in between, since the engine signals it
+// there.
+func (wp *WebhookWaitPlugin) Activity(ctx context.Context, p map[string]interface{}) (interface{}, error) {
+	phase, _ := p["phase"].(string)
+	switch phase {
+	case callbacks.PhaseStart:
+		return start(ctx, p)
+	case callbacks.PhaseTrigger:
+		return sendTrigger(ctx, p)
+	case callbacks.PhaseFinish:
+		return finish(ctx, p)
+	default:
+		return nil, fmt.Errorf("unknown webhook_wait phase %q", phase)
+	}
+}
+
+// start renders the config and returns the callback the workflow waits on
+func start(ctx context.Context, p map[string]interface{}) (*callbacks.Wait, error) {
+	logger := activity.GetLogger(ctx)
+
+	// Parse configuration from parameters
+	configData, ok := p["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid config format")
+	}
+
+	config := &WebhookWaitConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		return nil, fmt.Errorf("failed to parse webhook_wait config: %w", err)
+	}
+
+	timeout := defaultTimeout
+	if config.Timeout != "" {
+		parsed, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", config.Timeout, err)
+		}
+		timeout = parsed
+	}
+
+	runID := ""
+	if run, ok := p["run"].(map[string]interface{}); ok {
+		runID, _ = run["id"].(string)
+	}
+	if runID == "" {
+		return nil, fmt.Errorf("run id is missing")
+	}
+
+	state, env := templateData(p)
+	if err := applyVariableReplacement(config, state, env); err != nil {
+		return nil, fmt.Errorf("variable replacement failed: %w", err)
+	}
+	if config.ID == "" {
+		config.ID = randomID()
+	}
+	callbackURL := callbacks.URL(publicURL(), runID, config.ID)
+
+	// The trigger is rendered once the callback URL is known
+	if config.Trigger != nil {
+		if err := applyTriggerReplacement(config.Trigger, state, env, config.ID, callbackURL); err != nil {
+			return nil, fmt.Errorf("variable replacement failed: %w", err)
+		}
+	}
+
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	wait := &callbacks.Wait{ID: config.ID, URL: callbackURL, Timeout: timeout, Match: config.Match}
+	if config.Respond != nil {
+		wait.Respond = *config.Respond
+	}
+	if config.Trigger != nil {
+		wait.Trigger = config.Trigger
+	}
+
+	logger.Info("Waiting for webhook", "url", callbackURL, "trigger", config.Trigger != nil, "timeout", timeout)
+	return wait, nil
+}
+
+// sendTrigger sends the trigger start rendered, once the workflow waits on the callback
+func sendTrigger(ctx context.Context, p map[string]interface{}) (*TriggerResult, error) {
+	config := &TriggerConfig{}
+	if err := decodeParam(p, "trigger", config); err != nil {
+		return nil, err
+	}
+
+	policy, err := egress.FromParams(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
+	return trigger(ctx, config, policy)
+}
+
+// finish runs the assertions and saves against the call the workflow captured
+func finish(ctx context.Context, p map[string]interface{}) (*ActivityResponse, error) {
+	logger := activity.GetLogger(ctx)
+
+	response := &WebhookResponse{}
+	if err := decodeParam(p, "response", response); err != nil {
+		return nil, err
+	}
+	if response.Request == nil {
+		return nil, fmt.Errorf("no call was captured")
+	}
+
+	subject, err := assertions.Normalize(response.Request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare webhook result: %w", err)
+	}
+
+	state, env := templateData(p)
+	assertionResults, err := processAssertions(p, response, subject, state, env)
+	if err != nil {
+		return nil, err
+	}
+	if failure := assertions.Summary(assertionResults); failure != "" {
+		return nil, fmt.Errorf("%s", failure)
+	}
+
+	saved := make(map[string]string)
+	if err := saves.Process(p, subject, saved); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Webhook received", "method", response.Request.Method, "duration", response.Duration)
+
+	return &ActivityResponse{
+		Response:         response,
+		Saved:            saved,
+		AssertionResults: assertionResults,
+	}, nil
+}
+
+// publicURL is the base URL callers reach the engine's callback listener at
+func publicURL() string {
+	if baseURL := strings.TrimSpace(os.Getenv(PublicURLEnv)); baseURL != "" {
+		return baseURL
+	}
+	return defaultPublicURL
+}
+
+// templateData returns the state and env secrets templates render with
+func templateData(p map[string]interface{}) (map[string]interface{}, map[string]string) {
+	// Convert state to map[string]interface{} for template processing
+	state := make(map[string]interface{})
+	if stateStr, ok := p["state"].(map[string]string); ok {
+		for k, v := range stateStr {
+			state[k] = v
+		}
+	} else if stateInt, ok := p["state"].(map[string]interface{}); ok {
+		state = stateInt
+	}
+
+	// Extract env secrets from params (for {{ .env.* }} template resolution)
+	env := make(map[string]string)
+	if envData, ok := p["env"].(map[string]interface{}); ok {
+		for k, v := range envData {
+			if strVal, ok := v.(string); ok {
+				env[k] = strVal
+			}
+		}
+	} else if envData, ok := p["env"].(map[string]string); ok {
+		env = envData
+	}
+	return state, env
+}
+
+// decodeParam decodes what the workflow passed under key into out
+func decodeParam(p map[string]interface{}, key string, out interface{}) error {
+	value, ok := p[key]
+	if !ok || value == nil {
+		return fmt.Errorf("%s is missing", key)
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", key, err)
+	}
+	if err := json.Unmarshal(encoded, out); err != nil {
+		return fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return nil
+}
+
+// trigger sends the request that makes the system under test call back
+func trigger(ctx context.Context, config *TriggerConfig, policy *egress.Policy) (*TriggerResult, error) {
+	method := strings.ToUpper(config.Method)
+	if method == "" {
+		method = http.MethodPost
+	}
+	var body io.Reader
+	if config.Body != "" {
+		body = strings.NewReader(config.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, config.URL, body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trigger request: %w", err)
+	}
+	for name, value := range config.Headers {
+		req.Header.Set(name, value)
+	}
+	if json.Valid([]byte(config.Body)) && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := &http.Client{Timeout: triggerTimeout, Transport: policy.Transport()}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("trigger request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxTriggerBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trigger response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("trigger %s %s returned %s: %s", method, config.URL, resp.Status, strings.TrimSpace(string(raw)))
+	}
+	return &TriggerResult{StatusCode: resp.StatusCode, Body: string(raw)}, nil
+}
+
+func randomID() string {
+	random := make([]byte, 12)
+	_, _ = rand.Read(random)
+	return hex.EncodeToString(random)
+}
+
+// processAssertions evaluates all assertions and returns their results.
+// Shared assertions without a path check the request body.
+func processAssertions(p map[string]interface{}, response *WebhookResponse, subject interface{}, state map[string]interface{}, env map[string]string) ([]AssertionResult, error) {
+	context := dsl.TemplateContext{Runtime: state, Env: env}
+	var body interface{}
+	if subjectMap, ok := subject.(map[string]interface{}); ok {
+		body = subjectMap["body"]
+	}
+
+	return assertions.Process(p, subject, context, func(assertionMap map[string]interface{}, result *AssertionResult) bool {
+		return evaluateAssertion(response, subject, body, assertionMap, result)
+	})
+}
+
+// evaluateAssertion evaluates the webhook_wait plugin's own assertion types
+func evaluateAssertion(response *WebhookResponse, subject, body interface{}, assertionMap map[string]interface{}, result *AssertionResult) bool {
+	expected := result.Expected
+	switch result.Type {
+	case AssertionTypeHeader:
+		name, _ := assertionMap["name"].(string)
+		if name == "" {
+			result.Message = "name is required for header assertion"
+			break
+		}
+		result.Name = name
+		actual, found := callbacks.LookupHeader(response.Request.Headers, name)
+		if !found {
+			result.Message = fmt.Sprintf("header %q not present", name)
+			break
+		}
+		result.Actual = actual
+		if !assertions.Equal(actual, expected) {
+			result.Message = fmt.Sprintf("expected header %s=%v, got %v", name, expected, actual)
+		} else {
+			result.Passed = true
+		}
+
+	default:
+		if _, hasPath := assertionMap["path"]; hasPath {
+			*result = assertions.Evaluate(assertionMap, subject, expected)
+		} else {
+			*result = assertions.Evaluate(assertionMap, body, expected)
+		}
+	}
+	return true
+}
+
+// validateConfig checks the config once templates are rendered
+func validateConfig(config *WebhookWaitConfig) error {
+	if strings.ContainsAny(config.ID, "/?#") {
+		return fmt.Errorf("id must not contain /, ? or #, got %q", config.ID)
+	}
+	if trigger := config.Trigger; trigger != nil {
+		u, err := url.Parse(trigger.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("trigger.url must be an http(s) URL, got %q", trigger.URL)
+		}
+	}
+	if respond := config.Respond; respond != nil && respond.Status != 0 && (respond.Status < 100 || respond.Status > 599) {
+		return fmt.Errorf("respond.status must be a valid HTTP status, got %d", respond.Status)
+	}
+	return nil
+}
+
+// applyVariableReplacement processes templates in the callback id, the filter and the reply
+func applyVariableReplacement(config *WebhookWaitConfig, state map[string]interface{}, env map[string]string) error {
+	context := dsl.TemplateContext{
+		Runtime: state,
+		Env:     env,
+	}
+
+	fields := []*string{&config.ID}
+	var maps []map[string]string
+	if match := config.Match; match != nil {
+		fields = append(fields, &match.Method, &match.Contains)
+		maps = append(maps, match.Headers)
+	}
+	if respond := config.Respond; respond != nil {
+		fields = append(fields, &respond.Body)
+		maps = append(maps, respond.Headers)
+	}
+
+	return processTemplates(fields, maps, context)
+}
+
+// applyTriggerReplacement processes templates in the trigger, which can also use
+// {{ webhook.url }} and {{ webhook.id }}
+func applyTriggerReplacement(trigger *TriggerConfig, state map[string]interface{}, env map[string]string, id, callbackURL string) error {
+	runtime := make(map[string]interface{}, len(state)+1)
+	for k, v := range state {
+		runtime[k] = v
+	}
+	runtime["webhook"] = map[string]interface{}{"id": id, "url": callbackURL}
+
+	context := dsl.TemplateContext{
+		Runtime: runtime,
+		Env:     env,
+	}
+	return processTemplates([]*string{&trigger.Method, &trigger.URL, &trigger.Body}, []map[string]string{trigger.Headers}, context)
+}
+
+func processTemplates(fields []*string, maps []map[string]string, context dsl.TemplateContext) error {
+	for _, field := range fields {
+		if *field == "" {
+			continue
+		}
+		processed, err := dsl.ProcessTemplate(*field, context)
+		if err != nil {
+			return fmt.Errorf("failed to process template %q: %w", *field, err)
+		}
+		*field = processed
+	}
+	for _, values := range maps {
+		for name, value := range values {
+			processed, err := dsl.ProcessTemplate(value, context)
+			if err != nil {
+				return fmt.Errorf("failed to process header %s template: %w", name, err)
+			}
+			values[name] = processed
+		}
+	}
+	return nil
+}
+
+// parseConfig converts map[string]interface{} to WebhookWaitConfig
+func parseConfig(configData map[string]interface{}, config *WebhookWaitConfig) error {
+	config.ID = stringValue(configData["id"])
+	config.Timeout = stringValue(configData["timeout"])
+
+	if triggerData, ok := configData["trigger"].(map[string]interface{}); ok {
+		body, err := bodyValue(triggerData["body"], "trigger.body")
+		if err != nil {
+			return err
+		}
+		config.Trigger = &TriggerConfig{
+			Method:  stringValue(triggerData["method"]),
+			URL:     stringValue(triggerData["url"]),
+			Headers: stringMap(triggerData["headers"]),
+			Body:    body,
+		}
+	}
+
+	if matchData, ok := configData["match"].(map[string]interface{}); ok {
+		config.Match = &MatchConfig{
+			Method:   stringValue(matchData["method"]),
+			Headers:  stringMap(matchData["headers"]),
+			Contains: stringValue(matchData["contains"]),
+		}
+	}
+
+	if respondData, ok := configData["respond"].(map[string]interface{}); ok {
+		body, err := bodyValue(respondData["body"], "respond.body")
+		if err != nil {
+			return err
+		}
+		config.Respond = &RespondConfig{
+			Headers: stringMap(respondData["headers"]),
+			Body:    body,
+		}
+		switch status := respondData["status"].(type) {
+		case nil:
+		case float64:
+			config.Respond.Status = int(status)
+		case int:
+			config.Respond.Status = status
+		default:
+			return fmt.Errorf("respond.status must be a number, got %T", status)
+		}
+	}
+
+	return nil
+}
+
+// bodyValue accepts a string, or an object or list that is sent as JSON
+func bodyValue(v interface{}, field string) (string, error) {
+	switch body := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return body, nil
+	case map[string]interface{}, []interface{}:
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode %s: %w", field, err)
+		}
+		return string(encoded), nil
+	default:
+		return "", fmt.Errorf("%s must be a string or an object, got %T", field, v)
+	}
+}
+
+func stringMap(v interface{}) map[string]string {
+	data, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	out := make(map[string]string, len(data))
+	for k, value := range data {
+		out[k] = fmt.Sprint(value)
+	}
+	return out
+}
+
+func stringValue(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
@@ -0,0 +1,189 @@
+package webhook_wait
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/callbacks"
+	"github.com/rocketship-ai/rocketship/internal/plugins/plugintest"
+)
+
+// runPhase runs one phase of the activity the way the workflow does
+func runPhase(t *testing.T, params map[string]interface{}) (interface{}, error) {
+	t.Helper()
+	var result interface{}
+	if err := plugintest.Run(t, &WebhookWaitPlugin{}, params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func decodeResult(t *testing.T, result, out interface{}) {
+	t.Helper()
+	encoded, _ := json.Marshal(result)
+	if err := json.Unmarshal(encoded, out); err != nil {
+		t.Fatalf("failed to decode %s: %v", encoded, err)
+	}
+}
+
+func TestStart(t *testing.T) {
+	t.Setenv(PublicURLEnv, "https://hooks.example.com/")
+
+	result, err := runPhase(t, map[string]interface{}{
+		"phase": callbacks.PhaseStart,
+		"run":   map[string]interface{}{"id": "run-1"},
+		"state": map[string]interface{}{"order_id": "7"},
+		"config": map[string]interface{}{
+			"id":      "order-{{ order_id }}",
+			"timeout": "1m",
+			"trigger": map[string]interface{}{
+				"url":  "https://api.example.com/orders/{{ order_id }}",
+				"body": map[string]interface{}{"callback_url": "{{ webhook.url }}"},
+			},
+			"match":   map[string]interface{}{"contains": "shipped"},
+			"respond": map[string]interface{}{"status": 202.0},
+		},
+	})
+	if err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	wait := &callbacks.Wait{}
+	decodeResult(t, result, wait)
+	if wait.ID != "order-7" || wait.URL != "https://hooks.example.com/hooks/run-1/order-7" || wait.Timeout != time.Minute {
+		t.Errorf("unexpected callback %+v", wait)
+	}
+	if wait.Match == nil || wait.Match.Contains != "shipped" || wait.Respond.Status != http.StatusAccepted {
+		t.Errorf("unexpected filter or reply %+v %+v", wait.Match, wait.Respond)
+	}
+	trigger := &TriggerConfig{}
+	decodeResult(t, wait.Trigger, trigger)
+	if trigger.URL != "https://api.example.com/orders/7" || trigger.Body != `{"callback_url":"https://hooks.example.com/hooks/run-1/order-7"}` {
+		t.Errorf("unexpected trigger %+v", trigger)
+	}
+
+	// Without an id the callback gets a random one
+	result, err = runPhase(t, map[string]interface{}{
+		"phase":  callbacks.PhaseStart,
+		"run":    map[string]interface{}{"id": "run-1"},
+		"config": map[string]interface{}{},
+	})
+	if err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	wait = &callbacks.Wait{}
+	decodeResult(t, result, wait)
+	if len(wait.ID) != 24 || wait.Trigger != nil || wait.Timeout != defaultTimeout {
+		t.Errorf("unexpected callback %+v", wait)
+	}
+
+	if _, err := runPhase(t, map[string]interface{}{"config": map[string]interface{}{}}); err == nil || !strings.Contains(err.Error(), `unknown webhook_wait phase ""`) {
+		t.Errorf("expected an unknown phase error, got %v", err)
+	}
+}
+
+func TestTrigger(t *testing.T) {
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var charge map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&charge)
+		if charge["callback_url"] == "" || r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "card declined", http.StatusPaymentRequired)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"charge":"ch_1"}`))
+	}))
+	defer provider.Close()
+
+	result, err := runPhase(t, map[string]interface{}{
+		"phase":   callbacks.PhaseTrigger,
+		"trigger": map[string]interface{}{"url": provider.URL, "body": `{"callback_url":"https://hooks.example.com/hooks/run-1/charge-1"}`},
+	})
+	if err != nil {
+		t.Fatalf("trigger failed: %v", err)
+	}
+	triggerResult := &TriggerResult{}
+	decodeResult(t, result, triggerResult)
+	if triggerResult.StatusCode != http.StatusAccepted || triggerResult.Body != `{"charge":"ch_1"}` {
+		t.Errorf("unexpected trigger result %+v", triggerResult)
+	}
+
+	_, err = runPhase(t, map[string]interface{}{
+		"phase":   callbacks.PhaseTrigger,
+		"trigger": map[string]interface{}{"url": provider.URL},
+	})
+	if err == nil || !strings.Contains(err.Error(), "402 Payment Required: card declined") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestFinish(t *testing.T) {
+	request := &Request{
+		Method:  http.MethodPost,
+		Path:    "/events",
+		Query:   map[string]string{"attempt": "1"},
+		Headers: map[string]string{"X-Signature": "sig"},
+		Body:    map[string]interface{}{"status": "succeeded", "id": "ch_1"},
+		RawBody: `{"status":"succeeded","id":"ch_1"}`,
+	}
+	params := func(assertions ...interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"phase":      callbacks.PhaseFinish,
+			"response":   &WebhookResponse{ID: "charge-1", URL: "https://hooks.example.com/hooks/run-1/charge-1", Request: request, Ignored: 1, Duration: "2s"},
+			"state":      map[string]interface{}{"signature": "sig"},
+			"assertions": assertions,
+			"save":       []interface{}{map[string]interface{}{"json_path": ".body.id", "as": "charge_id"}},
+		}
+	}
+
+	result, err := runPhase(t, params(
+		map[string]interface{}{"type": "header", "name": "x-signature", "expected": "{{ signature }}"},
+		map[string]interface{}{"type": "equals", "path": ".body.status", "expected": "succeeded"},
+		map[string]interface{}{"type": "contains", "expected": "status"},
+		map[string]interface{}{"type": "equals", "path": ".query.attempt", "expected": "1"},
+	))
+	if err != nil {
+		t.Fatalf("finish failed: %v", err)
+	}
+	response := &ActivityResponse{}
+	decodeResult(t, result, response)
+	if response.Saved["charge_id"] != "ch_1" || response.Response.Ignored != 1 || len(response.AssertionResults) != 4 {
+		t.Errorf("unexpected response %+v", response)
+	}
+
+	_, err = runPhase(t, params(map[string]interface{}{"type": "header", "name": "X-Event", "expected": "charge.succeeded"}))
+	if err == nil || !strings.Contains(err.Error(), `header "X-Event" not present`) {
+		t.Errorf("expected a failed header assertion, got %v", err)
+	}
+}
+
+func TestParseAndValidateConfig(t *testing.T) {
+	config := &WebhookWaitConfig{}
+	err := parseConfig(map[string]interface{}{
+		"trigger": map[string]interface{}{"url": "https://api.example.com", "body": map[string]interface{}{"a": 1.0}},
+		"respond": map[string]interface{}{"status": 202.0, "body": "ok"},
+	}, config)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if config.Trigger.Body != `{"a":1}` || config.Respond.Status != 202 {
+		t.Errorf("unexpected config %+v %+v", config.Trigger, config.Respond)
+	}
+
+	invalid := []struct {
+		config  WebhookWaitConfig
+		message string
+	}{
+		{WebhookWaitConfig{ID: "a/b"}, "id must not contain"},
+		{WebhookWaitConfig{Trigger: &TriggerConfig{URL: "ftp://example.com"}}, "trigger.url must be an http(s) URL"},
+		{WebhookWaitConfig{Respond: &RespondConfig{Status: 42}}, "respond.status must be a valid HTTP status"},
+	}
+	for _, tc := range invalid {
+		if err := validateConfig(&tc.config); err == nil || !strings.Contains(err.Error(), tc.message) {
+			t.Errorf("expected %q, got %v", tc.message, err)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package websocket
+
+import "github.com/rocketship-ai/rocketship/internal/assertions"
+
+// WebSocketPlugin represents a websocket test step
+type WebSocketPlugin struct {
+	Name   string          `json:"name" yaml:"name"`
+	Plugin string          `json:"plugin" yaml:"plugin"`
+	Config WebSocketConfig `json:"config" yaml:"config"`
+}
+
+// WebSocketConfig defines the connection, frames to send and messages to wait for
+type WebSocketConfig struct {
+	URL          string            `json:"url" yaml:"url"`
+	Headers      map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Subprotocols []string          `json:"subprotocols,omitempty" yaml:"subprotocols,omitempty"`
+	Send         []string          `json:"send,omitempty" yaml:"send,omitempty"`       // Frames sent in order after connecting
+	Receive      *ReceiveConfig    `json:"receive,omitempty" yaml:"receive,omitempty"` // Messages to wait for after sending
+	Timeout      string            `json:"timeout,omitempty" yaml:"timeout,omitempty"` // Overall step timeout (e.g., "10s")
+}
+
+// ReceiveConfig describes which incoming messages count towards the step
+type ReceiveConfig struct {
+	Count  int           `json:"count" yaml:"count"`                       // Number of matching messages to wait for (defaults to 1)
+	Filter *FilterConfig `json:"filter,omitempty" yaml:"filter,omitempty"` // Messages not matching the filter are ignored
+}
+
+// FilterConfig selects messages by jq expression or substring
+type FilterConfig struct {
+	JSONPath string `json:"json_path,omitempty" yaml:"json_path,omitempty"` // jq expression that must produce a truthy value
+	Contains string `json:"contains,omitempty" yaml:"contains,omitempty"`   // Substring the raw message must contain
+}
+
+// Assertion types supported by the websocket plugin
+const (
+	AssertionTypeMessageCount = "message_count"
+	AssertionTypeJSONPath     = "json_path"
+	AssertionTypeContains     = "contains"
+)
+
+// WebSocketResponse contains the frames exchanged during the step
+type WebSocketResponse struct {
+	URL      string   `json:"url"`
+	Sent     []string `json:"sent"`
+	Messages []string `json:"messages"` // Matching messages, in arrival order
+	Skipped  int      `json:"skipped"`  // Messages received that did not match the filter
+	Duration string   `json:"duration"`
+}
+
+// AssertionResult represents a single assertion result for UI display
+type AssertionResult = assertions.Result
+
+// ActivityResponse represents the complete activity response
+type ActivityResponse struct {
+	Response         *WebSocketResponse `json:"response"`
+	Saved            map[string]string  `json:"saved"`
+	AssertionResults []AssertionResult  `json:"assertion_results,omitempty"`
+}
@@ -0,0 +1,378 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/itchyny/gojq"
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+	"go.temporal.io/sdk/activity"
+	ws "nhooyr.io/websocket"
+)
+
+const defaultTimeout = 10 * time.Second
+
+// Auto-register the plugin when the package is imported
+func init() {
+	plugins.RegisterPlugin(&WebSocketPlugin{})
+}
+
+// GetType returns the plugin type identifier
+func (wp *WebSocketPlugin) GetType() string {
+	return "websocket"
+}
+
+// Activity opens a websocket connection, sends the configured frames and waits for matching messages
+func (wp *WebSocketPlugin) Activity(ctx context.Context, p map[string]interface{}) (interface{}, error) {
+	logger := activity.GetLogger(ctx)
+
+	// Parse configuration from parameters
+	configData, ok := p["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid config format")
+	}
+
+	config := &WebSocketConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		return nil, fmt.Errorf("failed to parse websocket config: %w", err)
+	}
+
+	// Validate required fields
+	if config.URL == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+
+	timeout := defaultTimeout
+	if config.Timeout != "" {
+		parsed, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", config.Timeout, err)
+		}
+		timeout = parsed
+	}
+
+	// Convert state to map[string]interface{} for template processing
+	state := make(map[string]interface{})
+	if stateStr, ok := p["state"].(map[string]string); ok {
+		for k, v := range stateStr {
+			state[k] = v
+		}
+	} else if stateInt, ok := p["state"].(map[string]interface{}); ok {
+		state = stateInt
+	}
+
+	// Extract env secrets from params (for {{ .env.* }} template resolution)
+	env := make(map[string]string)
+	if envData, ok := p["env"].(map[string]interface{}); ok {
+		for k, v := range envData {
+			if strVal, ok := v.(string); ok {
+				env[k] = strVal
+			}
+		}
+	} else if envData, ok := p["env"].(map[string]string); ok {
+		env = envData
+	}
+
+	if err := applyVariableReplacement(config, state, env); err != nil {
+		return nil, fmt.Errorf("variable replacement failed: %w", err)
+	}
+
+	logger.Info("Executing websocket plugin", "url", config.URL, "send", len(config.Send))
+
+	policy, err := egress.FromParams(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
+
+	response, err := exchange(ctx, config, timeout, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	// Assertions and saves operate on the decoded matching messages
+	decoded := decodeMessages(response.Messages)
+
+	assertionResults, err := processAssertions(p, decoded, state, env)
+	if err != nil {
+		return nil, err
+	}
+	if failure := assertions.Summary(assertionResults); failure != "" {
+		return nil, fmt.Errorf("%s", failure)
+	}
+
+	saved := make(map[string]string)
+	if err := saves.Process(p, decoded, saved); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Websocket exchange completed", "messages", len(response.Messages), "skipped", response.Skipped)
+
+	return &ActivityResponse{
+		Response:         response,
+		Saved:            saved,
+		AssertionResults: assertionResults,
+	}, nil
+}
+
+// exchange dials the server, writes every frame and collects matching messages
+func exchange(ctx context.Context, config *WebSocketConfig, timeout time.Duration, policy *egress.Policy) (*WebSocketResponse, error) {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	headers := http.Header{}
+	for k, v := range config.Headers {
+		headers.Set(k, v)
+	}
+
+	conn, _, err := ws.Dial(ctx, config.URL, &ws.DialOptions{
+		HTTPClient:   &http.Client{Transport: policy.Transport()},
+		HTTPHeader:   headers,
+		Subprotocols: config.Subprotocols,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", config.URL, err)
+	}
+	defer func() { _ = conn.Close(ws.StatusNormalClosure, "") }()
+
+	response := &WebSocketResponse{
+		URL:      config.URL,
+		Sent:     []string{},
+		Messages: []string{},
+	}
+
+	for i, frame := range config.Send {
+		if err := conn.Write(ctx, ws.MessageText, []byte(frame)); err != nil {
+			return nil, fmt.Errorf("failed to send frame %d: %w", i, err)
+		}
+		response.Sent = append(response.Sent, frame)
+	}
+
+	if config.Receive != nil {
+		var filter *gojq.Query
+		if config.Receive.Filter != nil && config.Receive.Filter.JSONPath != "" {
+			filter, err = gojq.Parse(config.Receive.Filter.JSONPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse filter jq expression %q: %w", config.Receive.Filter.JSONPath, err)
+			}
+		}
+
+		for len(response.Messages) < config.Receive.Count {
+			_, data, err := conn.Read(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("received %d of %d expected messages: %w", len(response.Messages), config.Receive.Count, err)
+			}
+			msg := string(data)
+			if !matchesFilter(msg, config.Receive.Filter, filter) {
+				response.Skipped++
+				continue
+			}
+			response.Messages = append(response.Messages, msg)
+		}
+	}
+
+	response.Duration = time.Since(start).String()
+	return response, nil
+}
+
+// matchesFilter reports whether a raw message satisfies the receive filter
+func matchesFilter(msg string, filter *FilterConfig, query *gojq.Query) bool {
+	if filter == nil {
+		return true
+	}
+	if filter.Contains != "" && !strings.Contains(msg, filter.Contains) {
+		return false
+	}
+	if query == nil {
+		return true
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(msg), &data); err != nil {
+		return false
+	}
+	iter := query.Run(data)
+	v, ok := iter.Next()
+	if !ok {
+		return false
+	}
+	if _, isErr := v.(error); isErr {
+		return false
+	}
+	return v != nil && v != false
+}
+
+// decodeMessages parses each message as JSON, keeping non-JSON messages as plain strings
+func decodeMessages(messages []string) []interface{} {
+	decoded := make([]interface{}, 0, len(messages))
+	for _, msg := range messages {
+		var v interface{}
+		if err := json.Unmarshal([]byte(msg), &v); err != nil {
+			v = msg
+		}
+		decoded = append(decoded, v)
+	}
+	return decoded
+}
+
+// processAssertions evaluates all assertions and returns their results
+func processAssertions(p map[string]interface{}, messages []interface{}, state map[string]interface{}, env map[string]string) ([]AssertionResult, error) {
+	context := dsl.TemplateContext{Runtime: state, Env: env}
+	// json_path, equals, regex, numeric comparisons and exists run against the messages array
+	return assertions.Process(p, messages, context, func(assertionMap map[string]interface{}, result *AssertionResult) bool {
+		return evaluateAssertion(messages, assertionMap, result)
+	})
+}
+
+// evaluateAssertion evaluates the websocket plugin's own assertion types
+func evaluateAssertion(messages []interface{}, assertionMap map[string]interface{}, result *AssertionResult) bool {
+	expected := result.Expected
+	switch result.Type {
+	case AssertionTypeMessageCount:
+		result.Actual = len(messages)
+		if exp, ok := expected.(float64); !ok {
+			result.Message = fmt.Sprintf("message_count expected value must be a number: got type %T", expected)
+		} else if int(exp) != len(messages) {
+			result.Message = fmt.Sprintf("expected %d messages, got %d", int(exp), len(messages))
+		} else {
+			result.Passed = true
+		}
+
+	case AssertionTypeContains:
+		if _, hasPath := assertionMap["path"]; hasPath {
+			*result = assertions.Evaluate(assertionMap, messages, expected)
+			break
+		}
+		exp, ok := expected.(string)
+		if !ok {
+			result.Message = "contains expected value must be a string"
+			break
+		}
+		for _, msg := range messages {
+			raw, _ := msg.(string)
+			if raw == "" {
+				b, _ := json.Marshal(msg)
+				raw = string(b)
+			}
+			if strings.Contains(raw, exp) {
+				result.Passed = true
+				break
+			}
+		}
+		if !result.Passed {
+			result.Message = fmt.Sprintf("no message contains %q", exp)
+		}
+
+	default:
+		return false
+	}
+	return true
+}
+
+// applyVariableReplacement processes templates in the URL, headers and outgoing frames
+func applyVariableReplacement(config *WebSocketConfig, state map[string]interface{}, env map[string]string) error {
+	context := dsl.TemplateContext{
+		Runtime: state,
+		Env:     env,
+	}
+
+	processedURL, err := dsl.ProcessTemplate(config.URL, context)
+	if err != nil {
+		return fmt.Errorf("failed to process url template: %w", err)
+	}
+	config.URL = processedURL
+
+	for k, v := range config.Headers {
+		processed, err := dsl.ProcessTemplate(v, context)
+		if err != nil {
+			return fmt.Errorf("failed to process header %s template: %w", k, err)
+		}
+		config.Headers[k] = processed
+	}
+
+	for i, frame := range config.Send {
+		processed, err := dsl.ProcessTemplate(frame, context)
+		if err != nil {
+			return fmt.Errorf("failed to process send frame %d template: %w", i, err)
+		}
+		config.Send[i] = processed
+	}
+
+	if config.Receive != nil && config.Receive.Filter != nil {
+		processed, err := dsl.ProcessTemplate(config.Receive.Filter.Contains, context)
+		if err != nil {
+			return fmt.Errorf("failed to process filter template: %w", err)
+		}
+		config.Receive.Filter.Contains = processed
+	}
+
+	return nil
+}
+
+// parseConfig converts map[string]interface{} to WebSocketConfig
+func parseConfig(configData map[string]interface{}, config *WebSocketConfig) error {
+	if url, ok := configData["url"].(string); ok {
+		config.URL = url
+	}
+	if timeout, ok := configData["timeout"].(string); ok {
+		config.Timeout = timeout
+	}
+
+	if headers, ok := configData["headers"].(map[string]interface{}); ok {
+		config.Headers = make(map[string]string, len(headers))
+		for k, v := range headers {
+			config.Headers[k] = fmt.Sprint(v)
+		}
+	}
+
+	if protocols, ok := configData["subprotocols"].([]interface{}); ok {
+		for _, proto := range protocols {
+			if s, ok := proto.(string); ok {
+				config.Subprotocols = append(config.Subprotocols, s)
+			}
+		}
+	}
+
+	// Frames may be plain strings or structured values that are sent as JSON
+	if send, ok := configData["send"].([]interface{}); ok {
+		for i, frame := range send {
+			if s, ok := frame.(string); ok {
+				config.Send = append(config.Send, s)
+				continue
+			}
+			b, err := json.Marshal(frame)
+			if err != nil {
+				return fmt.Errorf("failed to encode send frame %d: %w", i, err)
+			}
+			config.Send = append(config.Send, string(b))
+		}
+	}
+
+	if receive, ok := configData["receive"].(map[string]interface{}); ok {
+		config.Receive = &ReceiveConfig{Count: 1}
+		if count, ok := receive["count"].(float64); ok {
+			config.Receive.Count = int(count)
+		} else if count, ok := receive["count"].(int); ok {
+			config.Receive.Count = count
+		}
+		if filter, ok := receive["filter"].(map[string]interface{}); ok {
+			config.Receive.Filter = &FilterConfig{}
+			if jsonPath, ok := filter["json_path"].(string); ok {
+				config.Receive.Filter.JSONPath = jsonPath
+			}
+			if contains, ok := filter["contains"].(string); ok {
+				config.Receive.Filter.Contains = contains
+			}
+		}
+	}
+
+	return nil
+}
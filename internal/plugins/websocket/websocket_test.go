@@ -0,0 +1,110 @@
+package websocket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+
+	ws "nhooyr.io/websocket"
+)
+
+// newEchoServer replies to every frame with a non-matching heartbeat followed by the frame itself
+func newEchoServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := ws.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close(ws.StatusNormalClosure, "") }()
+		for {
+			_, data, err := conn.Read(r.Context())
+			if err != nil {
+				return
+			}
+			_ = conn.Write(r.Context(), ws.MessageText, []byte(`{"type":"heartbeat"}`))
+			_ = conn.Write(r.Context(), ws.MessageText, data)
+		}
+	}))
+}
+
+func TestExchangeFiltersMessages(t *testing.T) {
+	srv := newEchoServer(t)
+	defer srv.Close()
+
+	config := &WebSocketConfig{}
+	err := parseConfig(map[string]interface{}{
+		"url": "ws" + strings.TrimPrefix(srv.URL, "http"),
+		"send": []interface{}{
+			map[string]interface{}{"type": "event", "id": float64(1)},
+			map[string]interface{}{"type": "event", "id": float64(2)},
+		},
+		"receive": map[string]interface{}{
+			"count":  float64(2),
+			"filter": map[string]interface{}{"json_path": `.type == "event"`},
+		},
+	}, config)
+	if err != nil {
+		t.Fatalf("parseConfig: %v", err)
+	}
+
+	resp, err := exchange(context.Background(), config, 5*time.Second, nil)
+	if err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+	if len(resp.Messages) != 2 {
+		t.Fatalf("expected 2 matching messages, got %d: %v", len(resp.Messages), resp.Messages)
+	}
+	if resp.Skipped < 1 {
+		t.Errorf("expected heartbeats to be skipped, got %d", resp.Skipped)
+	}
+
+	decoded := decodeMessages(resp.Messages)
+	p := map[string]interface{}{
+		"assertions": []interface{}{
+			map[string]interface{}{"type": "message_count", "expected": float64(2)},
+			map[string]interface{}{"type": "json_path", "path": ".[1].id", "expected": float64(2)},
+			map[string]interface{}{"type": "contains", "expected": `"id":1`},
+		},
+		"save": []interface{}{
+			map[string]interface{}{"json_path": ".[0].id", "as": "first_id"},
+		},
+	}
+
+	results, err := processAssertions(p, decoded, map[string]interface{}{}, map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failure := assertions.Summary(results); failure != "" {
+		t.Fatalf("unexpected assertion failure: %s (%+v)", failure, results)
+	}
+
+	saved := map[string]string{}
+	if err := saves.Process(p, decoded, saved); err != nil {
+		t.Fatalf("saves: %v", err)
+	}
+	if saved["first_id"] != "1" {
+		t.Errorf("expected first_id=1, got %q", saved["first_id"])
+	}
+}
+
+func TestExchangeTimesOutWaitingForMessages(t *testing.T) {
+	srv := newEchoServer(t)
+	defer srv.Close()
+
+	config := &WebSocketConfig{
+		URL:     "ws" + strings.TrimPrefix(srv.URL, "http"),
+		Send:    []string{"ping"},
+		Receive: &ReceiveConfig{Count: 1, Filter: &FilterConfig{Contains: "pong"}},
+	}
+
+	if _, err := exchange(context.Background(), config, 200*time.Millisecond, nil); err == nil {
+		t.Fatal("expected timeout error when no message matches the filter")
+	}
+}
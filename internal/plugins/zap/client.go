@@ -0,0 +1,78 @@
+package zap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// client calls the ZAP daemon's JSON API
+type client struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+// apiError is the body ZAP returns with a failed call
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// call sends GET /JSON/<component>/<kind>/<name>/ and decodes the reply into out
+func (c *client) call(ctx context.Context, component, kind, name string, params url.Values, out interface{}) error {
+	endpoint := fmt.Sprintf("%s/JSON/%s/%s/%s/", strings.TrimRight(c.baseURL, "/"), component, kind, name)
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("invalid ZAP URL %q: %w", c.baseURL, err)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-ZAP-API-Key", c.apiKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("ZAP %s/%s failed: %w", component, name, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read ZAP %s/%s reply: %w", component, name, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr apiError
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("ZAP %s/%s failed: %s (%s)", component, name, apiErr.Message, apiErr.Code)
+		}
+		return fmt.Errorf("ZAP %s/%s failed: %s", component, name, resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode ZAP %s/%s reply: %w", component, name, err)
+	}
+	return nil
+}
+
+// value calls a view or action that replies with a single string field, as
+// ZAP does for scan IDs, progress and counters
+func (c *client) value(ctx context.Context, component, kind, name, field string, params url.Values) (string, error) {
+	var reply map[string]interface{}
+	if err := c.call(ctx, component, kind, name, params, &reply); err != nil {
+		return "", err
+	}
+	v, ok := reply[field]
+	if !ok {
+		return "", fmt.Errorf("ZAP %s/%s reply has no %s", component, name, field)
+	}
+	return fmt.Sprint(v), nil
+}
@@ -0,0 +1,95 @@
+package zap
+
+import "github.com/rocketship-ai/rocketship/internal/assertions"
+
+// ZapPlugin represents an OWASP ZAP security scan step
+type ZapPlugin struct {
+	Name   string    `json:"name" yaml:"name"`
+	Plugin string    `json:"plugin" yaml:"plugin"`
+	Config ZapConfig `json:"config" yaml:"config"`
+}
+
+// ZapConfig selects the ZAP daemon, the scan and which alerts fail the step
+type ZapConfig struct {
+	Scan   string `json:"scan" yaml:"scan"`     // baseline or api
+	Target string `json:"target" yaml:"target"` // URL to scan
+
+	// Daemon
+	ZapURL string `json:"zap_url,omitempty" yaml:"zap_url,omitempty"` // ZAP API address (defaults to ROCKETSHIP_ZAP_URL, then http://localhost:8080)
+	APIKey string `json:"api_key,omitempty" yaml:"api_key,omitempty"` // Defaults to ROCKETSHIP_ZAP_API_KEY
+
+	// api scans
+	OpenAPI     string `json:"openapi,omitempty" yaml:"openapi,omitempty"`           // OpenAPI definition URL
+	OpenAPIFile string `json:"openapi_file,omitempty" yaml:"openapi_file,omitempty"` // OpenAPI definition on the ZAP host
+
+	// baseline scans
+	MaxChildren int `json:"max_children,omitempty" yaml:"max_children,omitempty"` // Most children the spider crawls per node (0 for no limit)
+
+	NewSession bool     `json:"new_session,omitempty" yaml:"new_session,omitempty"` // Clear ZAP's session, and its alerts, before scanning
+	Ignore     []string `json:"ignore,omitempty" yaml:"ignore,omitempty"`           // Alert plugin IDs or names left out of the results
+	FailOn     string   `json:"fail_on,omitempty" yaml:"fail_on,omitempty"`         // Lowest risk that fails the step (defaults to high)
+	Timeout    string   `json:"timeout,omitempty" yaml:"timeout,omitempty"`         // Overall step timeout (defaults to 15m)
+}
+
+// Scans supported by the zap plugin
+const (
+	ScanBaseline = "baseline" // Spider the target and report what the passive scanner finds
+	ScanAPI      = "api"      // Import an OpenAPI definition and actively scan its operations
+)
+
+// Risk levels ZAP assigns to alerts, lowest first
+const (
+	RiskInformational = "informational"
+	RiskLow           = "low"
+	RiskMedium        = "medium"
+	RiskHigh          = "high"
+	// FailOnNone reports alerts without failing the step
+	FailOnNone = "none"
+)
+
+// riskRank orders risk levels so fail_on can compare them
+var riskRank = map[string]int{
+	RiskInformational: 1,
+	RiskLow:           2,
+	RiskMedium:        3,
+	RiskHigh:          4,
+}
+
+// Assertion types supported by the zap plugin in addition to the shared ones
+const (
+	AssertionTypeAlertCount = "alert_count"
+)
+
+// Alert is one kind of issue, with the URLs it was found on
+type Alert struct {
+	PluginID   string   `json:"plugin_id"`
+	Name       string   `json:"name"`
+	Risk       string   `json:"risk"`
+	Confidence string   `json:"confidence"`
+	CWEID      string   `json:"cwe_id,omitempty"`
+	Instances  int      `json:"instances"` // Times it was found
+	URLs       []string `json:"urls"`      // First few URLs it was found on
+	Solution   string   `json:"solution,omitempty"`
+	Reference  string   `json:"reference,omitempty"`
+}
+
+// ZapResponse summarises the scan
+type ZapResponse struct {
+	Scan     string         `json:"scan"`
+	Target   string         `json:"target"`
+	URLs     int            `json:"urls"`    // URLs the spider found, or the definition added
+	Alerts   []Alert        `json:"alerts"`  // Highest risk first
+	Counts   map[string]int `json:"counts"`  // Alert kinds by risk
+	Failing  int            `json:"failing"` // Alert kinds at or above fail_on
+	Duration string         `json:"duration"`
+}
+
+// AssertionResult represents a single assertion result for UI display
+type AssertionResult = assertions.Result
+
+// ActivityResponse represents the complete activity response
+type ActivityResponse struct {
+	Response         *ZapResponse      `json:"response"`
+	Saved            map[string]string `json:"saved"`
+	AssertionResults []AssertionResult `json:"assertion_results,omitempty"`
+}
@@ -0,0 +1,563 @@
+package zap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+	"github.com/rocketship-ai/rocketship/internal/dsl"
+	"github.com/rocketship-ai/rocketship/internal/egress"
+	"github.com/rocketship-ai/rocketship/internal/plugins"
+	"github.com/rocketship-ai/rocketship/internal/saves"
+	"go.temporal.io/sdk/activity"
+)
+
+const (
+	// ZapURLEnv and ZapAPIKeyEnv configure the daemon when a step doesn't
+	ZapURLEnv    = "ROCKETSHIP_ZAP_URL"
+	ZapAPIKeyEnv = "ROCKETSHIP_ZAP_API_KEY"
+
+	defaultZapURL  = "http://localhost:8080"
+	defaultTimeout = 15 * time.Minute
+
+	// alertPageSize is how many alerts are read per call
+	alertPageSize = 500
+	// maxAlertURLs is how many URLs each alert keeps
+	maxAlertURLs = 5
+)
+
+// pollInterval is how often scan progress is checked
+var pollInterval = 2 * time.Second
+
+// Auto-register the plugin when the package is imported
+func init() {
+	plugins.RegisterPlugin(&ZapPlugin{})
+}
+
+// GetType returns the plugin type identifier
+func (zp *ZapPlugin) GetType() string {
+	return "zap"
+}
+
+// Activity runs a ZAP scan and fails on alerts at or above fail_on
+func (zp *ZapPlugin) Activity(ctx context.Context, p map[string]interface{}) (interface{}, error) {
+	logger := activity.GetLogger(ctx)
+
+	// Parse configuration from parameters
+	configData, ok := p["config"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid config format")
+	}
+
+	config := &ZapConfig{}
+	if err := parseConfig(configData, config); err != nil {
+		return nil, fmt.Errorf("failed to parse zap config: %w", err)
+	}
+
+	timeout := defaultTimeout
+	if config.Timeout != "" {
+		parsed, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", config.Timeout, err)
+		}
+		timeout = parsed
+	}
+
+	// Convert state to map[string]interface{} for template processing
+	state := make(map[string]interface{})
+	if stateStr, ok := p["state"].(map[string]string); ok {
+		for k, v := range stateStr {
+			state[k] = v
+		}
+	} else if stateInt, ok := p["state"].(map[string]interface{}); ok {
+		state = stateInt
+	}
+
+	// Extract env secrets from params (for {{ .env.* }} template resolution)
+	env := make(map[string]string)
+	if envData, ok := p["env"].(map[string]interface{}); ok {
+		for k, v := range envData {
+			if strVal, ok := v.(string); ok {
+				env[k] = strVal
+			}
+		}
+	} else if envData, ok := p["env"].(map[string]string); ok {
+		env = envData
+	}
+
+	if err := applyVariableReplacement(config, state, env); err != nil {
+		return nil, fmt.Errorf("variable replacement failed: %w", err)
+	}
+
+	if config.ZapURL == "" {
+		config.ZapURL = os.Getenv(ZapURLEnv)
+	}
+	if config.ZapURL == "" {
+		config.ZapURL = defaultZapURL
+	}
+	if config.APIKey == "" {
+		config.APIKey = os.Getenv(ZapAPIKeyEnv)
+	}
+
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	policy, err := egress.FromParams(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve egress policy: %w", err)
+	}
+
+	logger.Info("Executing zap plugin", "scan", config.Scan, "target", config.Target, "zap_url", config.ZapURL)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	c := &client{baseURL: config.ZapURL, apiKey: config.APIKey, http: &http.Client{Transport: policy.Transport()}}
+	response, err := execute(ctx, c, config, func(stage string) {
+		activity.RecordHeartbeat(ctx, stage)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := assertions.Normalize(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare zap result: %w", err)
+	}
+
+	assertionResults, err := processAssertions(p, response, subject, state, env)
+	if err != nil {
+		return nil, err
+	}
+	if failure := assertions.Summary(assertionResults); failure != "" {
+		return nil, fmt.Errorf("%s", failure)
+	}
+
+	saved := make(map[string]string)
+	if err := saves.Process(p, subject, saved); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Zap step completed", "alerts", len(response.Alerts), "failing", response.Failing, "duration", response.Duration)
+
+	return &ActivityResponse{
+		Response:         response,
+		Saved:            saved,
+		AssertionResults: assertionResults,
+	}, nil
+}
+
+// execute runs the scan, waits for ZAP to finish analysing what it saw and
+// reads the alerts for the target. progress is told about each stage.
+func execute(ctx context.Context, c *client, config *ZapConfig, progress func(stage string)) (*ZapResponse, error) {
+	start := time.Now()
+
+	if config.NewSession {
+		if err := c.call(ctx, "core", "action", "newSession", url.Values{"overwrite": {"true"}}, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	switch config.Scan {
+	case ScanBaseline:
+		// Requesting the target first gives the spider a node to start from
+		if err := c.call(ctx, "core", "action", "accessUrl", url.Values{"url": {config.Target}, "followRedirects": {"true"}}, nil); err != nil {
+			return nil, err
+		}
+		params := url.Values{"url": {config.Target}, "recurse": {"true"}}
+		if config.MaxChildren > 0 {
+			params.Set("maxChildren", strconv.Itoa(config.MaxChildren))
+		}
+		if err := runScan(ctx, c, "spider", params, progress); err != nil {
+			return nil, err
+		}
+	case ScanAPI:
+		if config.OpenAPI != "" {
+			err := c.call(ctx, "openapi", "action", "importUrl", url.Values{"url": {config.OpenAPI}, "hostOverride": {hostOf(config.Target)}}, nil)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			err := c.call(ctx, "openapi", "action", "importFile", url.Values{"file": {config.OpenAPIFile}, "target": {config.Target}}, nil)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if err := runScan(ctx, c, "ascan", url.Values{"url": {config.Target}, "recurse": {"true"}}, progress); err != nil {
+			return nil, err
+		}
+	}
+
+	// Alerts aren't complete until the passive scanner has seen every response
+	progress("passive scan")
+	err := poll(ctx, func() (bool, error) {
+		remaining, err := c.value(ctx, "pscan", "view", "recordsToScan", "recordsToScan", nil)
+		return remaining == "0", err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("passive scan did not finish: %w", err)
+	}
+
+	var urls struct {
+		URLs []string `json:"urls"`
+	}
+	if err := c.call(ctx, "core", "view", "urls", url.Values{"baseurl": {config.Target}}, &urls); err != nil {
+		return nil, err
+	}
+
+	alerts, err := readAlerts(ctx, c, config)
+	if err != nil {
+		return nil, err
+	}
+
+	failOn := config.FailOn
+	if failOn == "" {
+		failOn = RiskHigh
+	}
+	response := &ZapResponse{
+		Scan:   config.Scan,
+		Target: config.Target,
+		URLs:   len(urls.URLs),
+		Alerts: alerts,
+		Counts: map[string]int{RiskInformational: 0, RiskLow: 0, RiskMedium: 0, RiskHigh: 0},
+	}
+	var failing []string
+	for _, alert := range alerts {
+		response.Counts[alert.Risk]++
+		if failOn != FailOnNone && riskRank[alert.Risk] >= riskRank[failOn] {
+			response.Failing++
+			failing = append(failing, fmt.Sprintf("%s (%s, %d)", alert.Name, alert.Risk, alert.Instances))
+		}
+	}
+	response.Duration = time.Since(start).String()
+
+	if response.Failing > 0 {
+		return nil, fmt.Errorf("%d ZAP alerts at or above %s risk on %s: %s", response.Failing, failOn, config.Target, strings.Join(failing, ", "))
+	}
+	return response, nil
+}
+
+// runScan starts a spider or active scan and waits for it to reach 100%. The
+// scan is stopped if the step runs out of time.
+func runScan(ctx context.Context, c *client, component string, params url.Values, progress func(stage string)) error {
+	scanID, err := c.value(ctx, component, "action", "scan", "scan", params)
+	if err != nil {
+		return err
+	}
+	err = poll(ctx, func() (bool, error) {
+		status, err := c.value(ctx, component, "view", "status", "status", url.Values{"scanId": {scanID}})
+		if err == nil {
+			progress(fmt.Sprintf("%s %s%%", component, status))
+		}
+		return status == "100", err
+	})
+	if err != nil {
+		// The step's context has run out; stopping gets its own
+		stopCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = c.call(stopCtx, component, "action", "stop", url.Values{"scanId": {scanID}}, nil)
+		return fmt.Errorf("%s scan did not finish: %w", component, err)
+	}
+	return nil
+}
+
+// zapAlert is an alert as ZAP's API returns it
+type zapAlert struct {
+	PluginID   string `json:"pluginId"`
+	Name       string `json:"name"`
+	Alert      string `json:"alert"`
+	Risk       string `json:"risk"`
+	Confidence string `json:"confidence"`
+	CWEID      string `json:"cweid"`
+	URL        string `json:"url"`
+	Solution   string `json:"solution"`
+	Reference  string `json:"reference"`
+}
+
+// readAlerts reads the target's alerts and groups them by the rule that
+// raised them, highest risk first, leaving out ignored rules and false positives
+func readAlerts(ctx context.Context, c *client, config *ZapConfig) ([]Alert, error) {
+	ignored := make(map[string]bool, len(config.Ignore))
+	for _, entry := range config.Ignore {
+		ignored[strings.ToLower(entry)] = true
+	}
+
+	grouped := map[string]*Alert{}
+	var order []string
+	for start := 0; ; start += alertPageSize {
+		var page struct {
+			Alerts []zapAlert `json:"alerts"`
+		}
+		params := url.Values{
+			"baseurl": {config.Target},
+			"start":   {strconv.Itoa(start)},
+			"count":   {strconv.Itoa(alertPageSize)},
+		}
+		if err := c.call(ctx, "alert", "view", "alerts", params, &page); err != nil {
+			return nil, err
+		}
+
+		for _, a := range page.Alerts {
+			name := a.Name
+			if name == "" {
+				name = a.Alert
+			}
+			risk := strings.ToLower(a.Risk)
+			if _, ok := riskRank[risk]; !ok || strings.EqualFold(a.Confidence, "False Positive") {
+				continue
+			}
+			if ignored[a.PluginID] || ignored[strings.ToLower(name)] {
+				continue
+			}
+
+			key := a.PluginID + "|" + name
+			alert, ok := grouped[key]
+			if !ok {
+				alert = &Alert{
+					PluginID:   a.PluginID,
+					Name:       name,
+					Risk:       risk,
+					Confidence: a.Confidence,
+					CWEID:      a.CWEID,
+					URLs:       []string{},
+					Solution:   a.Solution,
+					Reference:  a.Reference,
+				}
+				grouped[key] = alert
+				order = append(order, key)
+			}
+			alert.Instances++
+			if len(alert.URLs) < maxAlertURLs && !contains(alert.URLs, a.URL) {
+				alert.URLs = append(alert.URLs, a.URL)
+			}
+		}
+
+		if len(page.Alerts) < alertPageSize {
+			break
+		}
+	}
+
+	alerts := make([]Alert, 0, len(order))
+	for _, key := range order {
+		alerts = append(alerts, *grouped[key])
+	}
+	sort.SliceStable(alerts, func(i, j int) bool {
+		return riskRank[alerts[i].Risk] > riskRank[alerts[j].Risk]
+	})
+	return alerts, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// hostOf returns the host and port of target, which imported definitions are pointed at
+func hostOf(target string) string {
+	u, err := url.Parse(target)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// poll calls check every pollInterval until it reports done or ctx ends
+func poll(ctx context.Context, check func() (bool, error)) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	var lastErr error
+	for {
+		done, err := check()
+		if err == nil && done {
+			return nil
+		}
+		if err != nil {
+			lastErr = err
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			if lastErr != nil && !errors.Is(lastErr, context.DeadlineExceeded) && !errors.Is(lastErr, context.Canceled) {
+				return fmt.Errorf("%w (last error: %v)", ctx.Err(), lastErr)
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+// processAssertions evaluates all assertions and returns their results
+func processAssertions(p map[string]interface{}, response *ZapResponse, subject interface{}, state map[string]interface{}, env map[string]string) ([]AssertionResult, error) {
+	context := dsl.TemplateContext{Runtime: state, Env: env}
+	return assertions.Process(p, subject, context, func(assertionMap map[string]interface{}, result *AssertionResult) bool {
+		return evaluateAssertion(response, assertionMap, result)
+	})
+}
+
+// evaluateAssertion evaluates the zap plugin's own assertion types
+func evaluateAssertion(response *ZapResponse, assertionMap map[string]interface{}, result *AssertionResult) bool {
+	expected := result.Expected
+	switch result.Type {
+	case AssertionTypeAlertCount:
+		// Alert kinds at the given risk, or all of them without one
+		risk, _ := assertionMap["risk"].(string)
+		risk = strings.ToLower(risk)
+		count := len(response.Alerts)
+		label := "alerts"
+		if risk != "" {
+			if _, ok := riskRank[risk]; !ok {
+				result.Message = fmt.Sprintf("risk must be informational, low, medium or high, got %q", risk)
+				break
+			}
+			count = response.Counts[risk]
+			label = risk + " risk alerts"
+		}
+		result.Actual = count
+		if !assertions.Equal(float64(count), expected) {
+			result.Message = fmt.Sprintf("expected %v %s, got %d", expected, label, count)
+		} else {
+			result.Passed = true
+		}
+
+	default:
+		return false
+	}
+	return true
+}
+
+// validateConfig checks the scan has what it needs once templates are rendered
+func validateConfig(config *ZapConfig) error {
+	switch config.Scan {
+	case ScanBaseline:
+		if config.OpenAPI != "" || config.OpenAPIFile != "" {
+			return fmt.Errorf("openapi and openapi_file are only used with scan api")
+		}
+	case ScanAPI:
+		if (config.OpenAPI == "") == (config.OpenAPIFile == "") {
+			return fmt.Errorf("set one of openapi or openapi_file with scan api")
+		}
+		if config.MaxChildren != 0 {
+			return fmt.Errorf("max_children is only used with scan baseline")
+		}
+	case "":
+		return fmt.Errorf("scan is required")
+	default:
+		return fmt.Errorf("scan must be baseline or api, got %q", config.Scan)
+	}
+
+	target, err := url.Parse(config.Target)
+	if config.Target == "" {
+		return fmt.Errorf("target is required")
+	}
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("target must be an http or https URL, got %q", config.Target)
+	}
+	if config.MaxChildren < 0 {
+		return fmt.Errorf("max_children can't be negative")
+	}
+
+	switch config.FailOn {
+	case "", FailOnNone, RiskInformational, RiskLow, RiskMedium, RiskHigh:
+	default:
+		return fmt.Errorf("fail_on must be informational, low, medium, high or none, got %q", config.FailOn)
+	}
+	return nil
+}
+
+// applyVariableReplacement processes templates in the daemon, target and
+// definition settings
+func applyVariableReplacement(config *ZapConfig, state map[string]interface{}, env map[string]string) error {
+	context := dsl.TemplateContext{
+		Runtime: state,
+		Env:     env,
+	}
+
+	fields := map[string]*string{
+		"target":       &config.Target,
+		"zap_url":      &config.ZapURL,
+		"api_key":      &config.APIKey,
+		"openapi":      &config.OpenAPI,
+		"openapi_file": &config.OpenAPIFile,
+	}
+	for name, value := range fields {
+		if *value == "" {
+			continue
+		}
+		processed, err := dsl.ProcessTemplate(*value, context)
+		if err != nil {
+			return fmt.Errorf("failed to process %s template: %w", name, err)
+		}
+		*value = processed
+	}
+
+	return nil
+}
+
+// parseConfig converts map[string]interface{} to ZapConfig
+func parseConfig(configData map[string]interface{}, config *ZapConfig) error {
+	stringFields := map[string]*string{
+		"scan":         &config.Scan,
+		"target":       &config.Target,
+		"zap_url":      &config.ZapURL,
+		"api_key":      &config.APIKey,
+		"openapi":      &config.OpenAPI,
+		"openapi_file": &config.OpenAPIFile,
+		"fail_on":      &config.FailOn,
+		"timeout":      &config.Timeout,
+	}
+	for key, target := range stringFields {
+		if v, ok := configData[key].(string); ok {
+			*target = v
+		}
+	}
+
+	switch v := configData["max_children"].(type) {
+	case nil:
+	case int:
+		config.MaxChildren = v
+	case float64:
+		config.MaxChildren = int(v)
+	default:
+		return fmt.Errorf("max_children must be a number, got %T", v)
+	}
+
+	switch v := configData["new_session"].(type) {
+	case nil:
+	case bool:
+		config.NewSession = v
+	default:
+		return fmt.Errorf("new_session must be a boolean, got %T", v)
+	}
+
+	switch v := configData["ignore"].(type) {
+	case nil:
+	case []interface{}:
+		for i, item := range v {
+			switch id := item.(type) {
+			case string:
+				config.Ignore = append(config.Ignore, id)
+			case int, float64:
+				// Plugin IDs are numbers in YAML
+				config.Ignore = append(config.Ignore, fmt.Sprint(id))
+			default:
+				return fmt.Errorf("ignore[%d] must be a plugin ID or alert name, got %T", i, item)
+			}
+		}
+	default:
+		return fmt.Errorf("ignore must be a list, got %T", v)
+	}
+
+	return nil
+}
@@ -0,0 +1,241 @@
+package zap
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+)
+
+// fakeZap serves the parts of ZAP's JSON API the plugin calls. Scans finish
+// after two status checks.
+type fakeZap struct {
+	mu     sync.Mutex
+	calls  []string
+	polls  map[string]int
+	alerts []map[string]string
+	status int // Forces every call to fail with this status when set
+}
+
+func (f *fakeZap) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("X-ZAP-API-Key") != "secret" {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"code": "bad_api_key", "message": "Missing or invalid API key"})
+		return
+	}
+	if f.status != 0 {
+		w.WriteHeader(f.status)
+		_ = json.NewEncoder(w).Encode(map[string]string{"code": "url_not_found", "message": "URL Not Found in the Scan Tree"})
+		return
+	}
+
+	path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/JSON/"), "/")
+	query := r.URL.Query()
+	f.calls = append(f.calls, path+"?"+query.Encode())
+
+	var reply interface{}
+	switch path {
+	case "core/action/newSession", "core/action/accessUrl", "openapi/action/importUrl", "openapi/action/importFile":
+		reply = map[string]string{"Result": "OK"}
+	case "spider/action/scan", "ascan/action/scan":
+		reply = map[string]string{"scan": "7"}
+	case "spider/view/status", "ascan/view/status":
+		f.polls[path]++
+		status := "50"
+		if f.polls[path] >= 2 {
+			status = "100"
+		}
+		reply = map[string]string{"status": status}
+	case "spider/action/stop", "ascan/action/stop":
+		reply = map[string]string{"Result": "OK"}
+	case "pscan/view/recordsToScan":
+		f.polls[path]++
+		remaining := "3"
+		if f.polls[path] >= 2 {
+			remaining = "0"
+		}
+		reply = map[string]string{"recordsToScan": remaining}
+	case "core/view/urls":
+		reply = map[string][]string{"urls": {"https://shop.test/", "https://shop.test/login", "https://shop.test/cart"}}
+	case "alert/view/alerts":
+		reply = map[string]interface{}{"alerts": f.alerts}
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"code": "bad_view", "message": "No Implementor for " + path})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(reply)
+}
+
+func newFakeZap(t *testing.T) (*fakeZap, *client) {
+	t.Helper()
+	pollInterval = time.Millisecond
+	fake := &fakeZap{polls: map[string]int{}, alerts: []map[string]string{
+		{"pluginId": "10038", "name": "Content Security Policy (CSP) Header Not Set", "risk": "Medium", "confidence": "High", "url": "https://shop.test/", "cweid": "693"},
+		{"pluginId": "10038", "name": "Content Security Policy (CSP) Header Not Set", "risk": "Medium", "confidence": "High", "url": "https://shop.test/login", "cweid": "693"},
+		{"pluginId": "10021", "name": "X-Content-Type-Options Header Missing", "risk": "Low", "confidence": "Medium", "url": "https://shop.test/cart"},
+		{"pluginId": "40012", "name": "Cross Site Scripting (Reflected)", "risk": "High", "confidence": "Medium", "url": "https://shop.test/search?q=x"},
+		{"pluginId": "90022", "name": "Application Error Disclosure", "risk": "Medium", "confidence": "False Positive", "url": "https://shop.test/cart"},
+		{"pluginId": "10109", "alert": "Modern Web Application", "risk": "Informational", "confidence": "Medium", "url": "https://shop.test/"},
+	}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, &client{baseURL: server.URL, apiKey: "secret", http: server.Client()}
+}
+
+func TestBaselineScan(t *testing.T) {
+	fake, c := newFakeZap(t)
+	config := &ZapConfig{Scan: ScanBaseline, Target: "https://shop.test/", MaxChildren: 10, NewSession: true, Ignore: []string{"40012"}, FailOn: RiskHigh}
+
+	var stages []string
+	response, err := execute(context.Background(), c, config, func(stage string) { stages = append(stages, stage) })
+	if err != nil {
+		t.Fatalf("execute() error = %v", err)
+	}
+
+	if response.URLs != 3 || len(response.Alerts) != 3 || response.Failing != 0 {
+		t.Fatalf("unexpected response: %+v", response)
+	}
+	csp := response.Alerts[0]
+	if csp.PluginID != "10038" || csp.Risk != RiskMedium || csp.Instances != 2 || len(csp.URLs) != 2 || csp.CWEID != "693" {
+		t.Fatalf("alerts not grouped by rule: %+v", csp)
+	}
+	if response.Alerts[2].Name != "Modern Web Application" {
+		t.Fatalf("alerts not ordered by risk: %+v", response.Alerts)
+	}
+	want := map[string]int{RiskHigh: 0, RiskMedium: 1, RiskLow: 1, RiskInformational: 1}
+	for risk, n := range want {
+		if response.Counts[risk] != n {
+			t.Fatalf("counts = %v, want %v", response.Counts, want)
+		}
+	}
+
+	wantCalls := []string{
+		"core/action/newSession?overwrite=true",
+		"core/action/accessUrl?followRedirects=true&url=https%3A%2F%2Fshop.test%2F",
+		"spider/action/scan?maxChildren=10&recurse=true&url=https%3A%2F%2Fshop.test%2F",
+	}
+	for i, call := range wantCalls {
+		if fake.calls[i] != call {
+			t.Fatalf("call %d = %q, want %q", i, fake.calls[i], call)
+		}
+	}
+	if len(stages) == 0 || stages[len(stages)-1] != "passive scan" {
+		t.Fatalf("unexpected progress stages: %v", stages)
+	}
+}
+
+func TestAPIScanFailsOnRisk(t *testing.T) {
+	fake, c := newFakeZap(t)
+	config := &ZapConfig{Scan: ScanAPI, Target: "https://shop.test/", OpenAPI: "https://shop.test/openapi.json", FailOn: RiskMedium}
+
+	_, err := execute(context.Background(), c, config, func(string) {})
+	want := "2 ZAP alerts at or above medium risk on https://shop.test/: Cross Site Scripting (Reflected) (high, 1), Content Security Policy (CSP) Header Not Set (medium, 2)"
+	if err == nil || err.Error() != want {
+		t.Fatalf("execute() error = %v\nwant %s", err, want)
+	}
+	if fake.calls[0] != "openapi/action/importUrl?hostOverride=shop.test&url=https%3A%2F%2Fshop.test%2Fopenapi.json" {
+		t.Fatalf("unexpected import call %q", fake.calls[0])
+	}
+	if fake.polls["ascan/view/status"] != 2 {
+		t.Fatalf("active scan not awaited: %v", fake.polls)
+	}
+}
+
+func TestScanStoppedOnTimeout(t *testing.T) {
+	fake, c := newFakeZap(t)
+	pollInterval = 50 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := execute(ctx, c, &ZapConfig{Scan: ScanBaseline, Target: "https://shop.test/"}, func(string) {})
+	if err == nil || !strings.Contains(err.Error(), "spider scan did not finish") {
+		t.Fatalf("execute() error = %v", err)
+	}
+	if last := fake.calls[len(fake.calls)-1]; last != "spider/action/stop?scanId=7" {
+		t.Fatalf("scan not stopped, last call %q", last)
+	}
+}
+
+func TestAPIErrors(t *testing.T) {
+	fake, c := newFakeZap(t)
+	fake.status = http.StatusBadRequest
+	_, err := execute(context.Background(), c, &ZapConfig{Scan: ScanBaseline, Target: "https://shop.test/"}, func(string) {})
+	if err == nil || err.Error() != "ZAP core/accessUrl failed: URL Not Found in the Scan Tree (url_not_found)" {
+		t.Fatalf("execute() error = %v", err)
+	}
+
+	c.apiKey = "wrong"
+	fake.status = 0
+	_, err = execute(context.Background(), c, &ZapConfig{Scan: ScanBaseline, Target: "https://shop.test/"}, func(string) {})
+	if err == nil || !strings.Contains(err.Error(), "Missing or invalid API key") {
+		t.Fatalf("execute() error = %v", err)
+	}
+}
+
+func TestAlertCountAssertion(t *testing.T) {
+	response := &ZapResponse{
+		Alerts: []Alert{{Risk: RiskMedium}, {Risk: RiskLow}},
+		Counts: map[string]int{RiskInformational: 0, RiskLow: 1, RiskMedium: 1, RiskHigh: 0},
+	}
+	p := map[string]interface{}{"assertions": []interface{}{
+		map[string]interface{}{"type": "alert_count", "risk": "high", "expected": 0},
+		map[string]interface{}{"type": "alert_count", "risk": "Medium", "expected": 1},
+		map[string]interface{}{"type": "alert_count", "expected": 2},
+		map[string]interface{}{"type": "alert_count", "risk": "low", "expected": 0},
+	}}
+
+	results, err := processAssertions(p, response, map[string]interface{}{}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failure := assertions.Summary(results)
+	for i, passed := range []bool{true, true, true, false} {
+		if results[i].Passed != passed {
+			t.Fatalf("assertion %d passed = %v: %+v", i, results[i].Passed, results[i])
+		}
+	}
+	if !strings.Contains(failure, "expected 0 low risk alerts, got 1") {
+		t.Fatalf("failure = %q", failure)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	cases := []struct {
+		name   string
+		config ZapConfig
+		want   string
+	}{
+		{"no scan", ZapConfig{Target: "https://shop.test"}, "scan is required"},
+		{"unknown scan", ZapConfig{Scan: "full", Target: "https://shop.test"}, "scan must be baseline or api"},
+		{"no target", ZapConfig{Scan: ScanBaseline}, "target is required"},
+		{"bad target", ZapConfig{Scan: ScanBaseline, Target: "shop.test"}, "target must be an http or https URL"},
+		{"api without definition", ZapConfig{Scan: ScanAPI, Target: "https://shop.test"}, "set one of openapi or openapi_file"},
+		{"baseline with definition", ZapConfig{Scan: ScanBaseline, Target: "https://shop.test", OpenAPI: "https://shop.test/openapi.json"}, "only used with scan api"},
+		{"fail_on", ZapConfig{Scan: ScanBaseline, Target: "https://shop.test", FailOn: "critical"}, "fail_on must be informational, low, medium, high or none"},
+		{"valid", ZapConfig{Scan: ScanAPI, Target: "https://shop.test", OpenAPIFile: "/zap/wrk/openapi.yaml", FailOn: "none"}, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateConfig(&tc.config)
+			if tc.want == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+// Package saves implements the parts of save blocks shared by plugins. A save's
+// json_path is a full jq program, so it can reshape the value as well as select it:
+//
+//	save:
+//	  - json_path: '.items | map(.id) | join(",")'
+//	    as: item_ids
+//	  - json_path: ".total"
+//	    as: total
+//	    type: int
+//
+// The optional type coerces the result before it is stored as a string in the
+// workflow state, failing the step when the value doesn't fit.
+package saves
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/rocketship-ai/rocketship/internal/assertions"
+)
+
+// Coercion types accepted in a save's type field
+const (
+	TypeInt   = "int"
+	TypeFloat = "float"
+	TypeBool  = "bool"
+	TypeJSON  = "json"
+)
+
+// Extract runs a jq program against data and returns its first output. found is
+// false when the program produced no output.
+func Extract(program string, data interface{}) (interface{}, bool, error) {
+	return assertions.Query(program, data)
+}
+
+// Process runs the step's save block against subject and stores each value in
+// saved under its as name. A save is required unless it sets required: false,
+// so a missing value fails the step instead of leaving the variable unset.
+func Process(p map[string]interface{}, subject interface{}, saved map[string]string) error {
+	saveConfigs, ok := p["save"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	for _, save := range saveConfigs {
+		saveMap, ok := save.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("invalid save format: got type %T", save)
+		}
+
+		as, ok := saveMap["as"].(string)
+		if !ok {
+			return fmt.Errorf("'as' field is required for save")
+		}
+
+		required := true
+		if req, ok := saveMap["required"].(bool); ok {
+			required = req
+		}
+
+		jsonPath, ok := saveMap["json_path"].(string)
+		if !ok || jsonPath == "" {
+			return fmt.Errorf("save configuration must specify json_path")
+		}
+
+		v, found, err := Extract(jsonPath, subject)
+		if err != nil {
+			return err
+		}
+		if !found || v == nil {
+			if required {
+				return fmt.Errorf("no results from required jq expression %q", jsonPath)
+			}
+			continue
+		}
+
+		value, err := Format(v, Type(saveMap))
+		if err != nil {
+			return fmt.Errorf("failed to save %s: %w", as, err)
+		}
+		saved[as] = value
+	}
+
+	return nil
+}
+
+// Type returns the coercion type configured on a save, or "" for the default formatting
+func Type(save map[string]interface{}) string {
+	typ, _ := save["type"].(string)
+	return strings.TrimSpace(typ)
+}
+
+// Format converts an extracted value into the string stored in the workflow state.
+// Without a type, strings are saved as-is, numbers and booleans as their literal
+// text, null as "" and arrays or objects as JSON.
+func Format(value interface{}, typ string) (string, error) {
+	switch typ {
+	case "":
+		return formatDefault(value)
+
+	case TypeInt:
+		if i, ok := toInteger(value); ok {
+			return i.String(), nil
+		}
+		f, ok := toFloat(value)
+		if !ok || f != math.Trunc(f) || math.IsInf(f, 0) {
+			return "", fmt.Errorf("cannot save %s as int", describe(value))
+		}
+		return strconv.FormatFloat(f, 'f', 0, 64), nil
+
+	case TypeFloat:
+		f, ok := toFloat(value)
+		if !ok {
+			return "", fmt.Errorf("cannot save %s as float", describe(value))
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+
+	case TypeBool:
+		switch v := value.(type) {
+		case bool:
+			return strconv.FormatBool(v), nil
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return strconv.FormatBool(b), nil
+			}
+		}
+		return "", fmt.Errorf("cannot save %s as bool", describe(value))
+
+	case TypeJSON:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("cannot save value as json: %w", err)
+		}
+		return string(encoded), nil
+	}
+
+	return "", fmt.Errorf("unknown save type %q (expected int, float, bool or json)", typ)
+}
+
+func formatDefault(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	if i, ok := toInteger(value); ok {
+		return i.String(), nil
+	}
+	if f, ok := toNumber(value); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal value: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// toFloat accepts numbers and numeric strings, since headers and SQL text columns are strings
+func toFloat(value interface{}) (float64, bool) {
+	if f, ok := toNumber(value); ok {
+		return f, true
+	}
+	if s, ok := value.(string); ok {
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// toInteger returns whole numbers as a big.Int, so large IDs are saved exactly
+func toInteger(value interface{}) (*big.Int, bool) {
+	switch n := value.(type) {
+	case int:
+		return big.NewInt(int64(n)), true
+	case int32:
+		return big.NewInt(int64(n)), true
+	case int64:
+		return big.NewInt(n), true
+	case uint:
+		return new(big.Int).SetUint64(uint64(n)), true
+	case uint32:
+		return big.NewInt(int64(n)), true
+	case uint64:
+		return new(big.Int).SetUint64(n), true
+	case *big.Int:
+		return n, true
+	case json.Number:
+		return new(big.Int).SetString(n.String(), 10)
+	case string:
+		return new(big.Int).SetString(strings.TrimSpace(n), 10)
+	}
+	return nil, false
+}
+
+func toNumber(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(n).Float64()
+		return f, true
+	}
+	return 0, false
+}
+
+func describe(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return strconv.Quote(s)
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(encoded)
+}
@@ -0,0 +1,98 @@
+package saves
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractPipeline(t *testing.T) {
+	data := map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{"id": "a"},
+			map[string]interface{}{"id": "b"},
+		},
+	}
+
+	v, found, err := Extract(`.items | map(.id) | join(",")`, data)
+	if err != nil || !found {
+		t.Fatalf("Extract: found=%v err=%v", found, err)
+	}
+	if v != "a,b" {
+		t.Errorf("got %v, want a,b", v)
+	}
+
+	if _, found, _ := Extract(".items[] | select(.id == \"z\")", data); found {
+		t.Error("expected no output for an empty selection")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		value   interface{}
+		typ     string
+		want    string
+		wantErr string
+	}{
+		{"abc", "", "abc", ""},
+		{float64(42), "", "42", ""},
+		{float64(1.5), "", "1.5", ""},
+		{true, "", "true", ""},
+		{nil, "", "", ""},
+		{[]interface{}{"a", float64(1)}, "", `["a",1]`, ""},
+		{float64(42), TypeInt, "42", ""},
+		{"42", TypeInt, "42", ""},
+		{float64(42.5), TypeInt, "", "cannot save 42.5 as int"},
+		{"abc", TypeInt, "", `cannot save "abc" as int`},
+		{"3.25", TypeFloat, "3.25", ""},
+		{"TRUE", TypeBool, "true", ""},
+		{float64(1), TypeBool, "", "as bool"},
+		{"abc", TypeJSON, `"abc"`, ""},
+		{map[string]interface{}{"k": "v"}, TypeJSON, `{"k":"v"}`, ""},
+		{"x", "date", "", "unknown save type"},
+	}
+
+	for _, tt := range tests {
+		got, err := Format(tt.value, tt.typ)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Format(%v, %q) error = %v, want %q", tt.value, tt.typ, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Format(%v, %q) unexpected error: %v", tt.value, tt.typ, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Format(%v, %q) = %q, want %q", tt.value, tt.typ, got, tt.want)
+		}
+	}
+}
+
+func TestProcess(t *testing.T) {
+	subject := map[string]interface{}{"id": "a1", "total": float64(3)}
+	saved := make(map[string]string)
+	err := Process(map[string]interface{}{
+		"save": []interface{}{
+			map[string]interface{}{"json_path": ".id", "as": "id"},
+			map[string]interface{}{"json_path": ".total", "as": "total", "type": "int"},
+			map[string]interface{}{"json_path": ".missing", "as": "missing", "required": false},
+		},
+	}, subject, saved)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if saved["id"] != "a1" || saved["total"] != "3" {
+		t.Errorf("unexpected saves %v", saved)
+	}
+	if _, ok := saved["missing"]; ok {
+		t.Error("expected an optional save without a value to be skipped")
+	}
+
+	err = Process(map[string]interface{}{
+		"save": []interface{}{map[string]interface{}{"json_path": ".missing", "as": "missing"}},
+	}, subject, saved)
+	if err == nil || !strings.Contains(err.Error(), `no results from required jq expression ".missing"`) {
+		t.Errorf("expected a required save to fail, got %v", err)
+	}
+}
@@ -0,0 +1,264 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rocketship-ai/rocketship/internal/egress"
+)
+
+// ReferencePrefix marks an environment secret whose value lives in Vault:
+// "vault:<mount>/<path>#<field>"
+const ReferencePrefix = "vault:"
+
+// Mask replaces resolved secret values in text
+const Mask = "***"
+
+// minMaskLength skips masking values so short they would garble unrelated text
+const minMaskLength = 4
+
+var (
+	mu     sync.RWMutex
+	active *Client
+
+	maskMu sync.RWMutex
+	masked = make(map[string]struct{})
+)
+
+// errNotFound reports a secret path that doesn't exist
+var errNotFound = errors.New("vault secret not found")
+
+// LoadFromEnv configures the worker from the environment. It returns false when VAULT_ADDR is unset.
+func LoadFromEnv() (bool, error) {
+	cfg, err := ConfigFromEnv()
+	if err != nil || cfg == nil {
+		return false, err
+	}
+	Configure(NewClient(*cfg))
+	return true, nil
+}
+
+// Configure installs c as the worker's Vault client; nil disables Vault
+func Configure(c *Client) {
+	mu.Lock()
+	defer mu.Unlock()
+	active = c
+}
+
+// ParseReference splits "vault:<mount>/<path>#<field>" into its path and field
+func ParseReference(value string) (path, field string, err error) {
+	ref := strings.TrimPrefix(value, ReferencePrefix)
+	path, field, ok := strings.Cut(ref, "#")
+	path = strings.Trim(path, "/")
+	if !ok || field == "" || !strings.Contains(path, "/") {
+		return "", "", fmt.Errorf("invalid vault reference %q: expected vault:<mount>/<path>#<field>", value)
+	}
+	return path, field, nil
+}
+
+// ResolveParams resolves the env secrets in plugin activity parameters in place
+func ResolveParams(ctx context.Context, p map[string]interface{}) error {
+	var env map[string]string
+	switch raw := p["env"].(type) {
+	case map[string]string:
+		env = raw
+	case map[string]interface{}:
+		env = make(map[string]string, len(raw))
+		for k, v := range raw {
+			if s, ok := v.(string); ok {
+				env[k] = s
+			}
+		}
+	}
+
+	resolved, err := ResolveEnv(ctx, env, egress.ScopeFromParams(p))
+	if err != nil {
+		return err
+	}
+	if resolved != nil {
+		p["env"] = resolved
+	}
+	return nil
+}
+
+// ResolveEnv returns env with Vault references replaced by their values, plus
+// the fields of the scope's secrets path for keys env doesn't set. Without a
+// configured client env is returned unchanged, unless it references Vault.
+func ResolveEnv(ctx context.Context, env map[string]string, scope egress.Scope) (map[string]string, error) {
+	mu.RLock()
+	c := active
+	mu.RUnlock()
+
+	if c == nil {
+		for key, value := range env {
+			if strings.HasPrefix(value, ReferencePrefix) {
+				return nil, fmt.Errorf("env secret %s references Vault, but this worker has no %s configured", key, AddrEnv)
+			}
+		}
+		return env, nil
+	}
+
+	resolved := make(map[string]string, len(env))
+	var values []string
+
+	if path := c.secretsPath(scope); path != "" {
+		data, err := c.Read(ctx, path)
+		if err != nil && !errors.Is(err, errNotFound) {
+			return nil, fmt.Errorf("failed to read vault secrets for the run: %w", err)
+		}
+		for key, value := range data {
+			resolved[key] = value
+			values = append(values, value)
+		}
+	}
+
+	for key, value := range env {
+		if !strings.HasPrefix(value, ReferencePrefix) {
+			resolved[key] = value // Environment secrets take precedence over the secrets path
+			continue
+		}
+		path, field, err := ParseReference(value)
+		if err != nil {
+			return nil, fmt.Errorf("env secret %s: %w", key, err)
+		}
+		data, err := c.Read(ctx, path)
+		if err != nil {
+			return nil, fmt.Errorf("env secret %s: %w", key, err)
+		}
+		secret, ok := data[field]
+		if !ok {
+			return nil, fmt.Errorf("env secret %s: vault secret %s has no field %q", key, path, field)
+		}
+		resolved[key] = secret
+		values = append(values, secret)
+	}
+
+	addMasked(values)
+	return resolved, nil
+}
+
+// secretsPath renders the configured per-run path, or "" when it needs a
+// project or environment the run doesn't have
+func (c *Client) secretsPath(scope egress.Scope) string {
+	path := c.cfg.SecretsPath
+	if path == "" {
+		return ""
+	}
+	if strings.Contains(path, "{project}") {
+		if scope.ProjectID == "" {
+			return ""
+		}
+		path = strings.ReplaceAll(path, "{project}", scope.ProjectID)
+	}
+	if strings.Contains(path, "{environment}") {
+		if scope.Environment == "" {
+			return ""
+		}
+		path = strings.ReplaceAll(path, "{environment}", scope.Environment)
+	}
+	return path
+}
+
+func addMasked(values []string) {
+	maskMu.Lock()
+	defer maskMu.Unlock()
+	for _, value := range values {
+		if len(value) >= minMaskLength {
+			masked[value] = struct{}{}
+		}
+	}
+}
+
+// maskedValues returns the values to mask, longest first so a secret containing
+// another is replaced whole
+func maskedValues() []string {
+	maskMu.RLock()
+	defer maskMu.RUnlock()
+	if len(masked) == 0 {
+		return nil
+	}
+	values := make([]string, 0, len(masked))
+	for value := range masked {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	return values
+}
+
+// MaskString replaces every value resolved from Vault in s
+func MaskString(s string) string {
+	for _, value := range maskedValues() {
+		s = strings.ReplaceAll(s, value, Mask)
+	}
+	return s
+}
+
+// MaskError masks err's message, keeping nil as nil
+func MaskError(err error) error {
+	if err == nil {
+		return nil
+	}
+	message := err.Error()
+	if masked := MaskString(message); masked != message {
+		return errors.New(masked)
+	}
+	return err
+}
+
+// MaskResponse masks the strings in a plugin response. Temporal encodes results
+// as JSON anyway, so responses that contain a secret go through a JSON round trip.
+func MaskResponse(resp interface{}) interface{} {
+	values := maskedValues()
+	if resp == nil || len(values) == 0 {
+		return resp
+	}
+	encoded, err := json.Marshal(resp)
+	if err != nil {
+		return resp
+	}
+	found := false
+	for _, value := range values {
+		// Compare against the JSON form, since quotes and newlines are escaped there
+		quoted, _ := json.Marshal(value)
+		if strings.Contains(string(encoded), strings.Trim(string(quoted), `"`)) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return resp
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return resp
+	}
+	return maskValue(decoded, values)
+}
+
+func maskValue(v interface{}, values []string) interface{} {
+	switch typed := v.(type) {
+	case string:
+		for _, value := range values {
+			typed = strings.ReplaceAll(typed, value, Mask)
+		}
+		return typed
+	case map[string]interface{}:
+		for key, item := range typed {
+			typed[key] = maskValue(item, values)
+		}
+		return typed
+	case []interface{}:
+		for i, item := range typed {
+			typed[i] = maskValue(item, values)
+		}
+		return typed
+	default:
+		return v
+	}
+}
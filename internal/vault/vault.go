@@ -0,0 +1,410 @@
+// Package vault resolves environment secrets from HashiCorp Vault on workers.
+//
+// Workers configured with VAULT_ADDR read KV v2 secrets at activity time, so
+// secret values never pass through the engine or workflow history. Environment
+// secrets whose value is a reference such as "vault:secret/payments#api_key"
+// are replaced with the field it names, and ROCKETSHIP_VAULT_SECRETS_PATH can
+// add every field of a per-project secret to {{ .env.* }}. Resolved values are
+// masked in step logs, errors and plugin responses.
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Environment variables configuring the worker's Vault client
+const (
+	AddrEnv                = "VAULT_ADDR"
+	NamespaceEnv           = "VAULT_NAMESPACE"
+	TokenEnv               = "VAULT_TOKEN"
+	AuthMethodEnv          = "ROCKETSHIP_VAULT_AUTH_METHOD"
+	AuthMountEnv           = "ROCKETSHIP_VAULT_AUTH_MOUNT"
+	RoleIDEnv              = "ROCKETSHIP_VAULT_ROLE_ID"
+	SecretIDEnv            = "ROCKETSHIP_VAULT_SECRET_ID"
+	KubernetesRoleEnv      = "ROCKETSHIP_VAULT_KUBERNETES_ROLE"
+	KubernetesTokenFileEnv = "ROCKETSHIP_VAULT_KUBERNETES_TOKEN_FILE"
+	SecretsPathEnv         = "ROCKETSHIP_VAULT_SECRETS_PATH"
+	CacheTTLEnv            = "ROCKETSHIP_VAULT_CACHE_TTL"
+)
+
+// Auth methods the worker can log in with
+const (
+	AuthToken      = "token"
+	AuthAppRole    = "approle"
+	AuthKubernetes = "kubernetes"
+)
+
+const (
+	defaultKubernetesTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	defaultCacheTTL            = time.Minute
+	requestTimeout             = 10 * time.Second
+)
+
+// Config is the worker's Vault connection
+type Config struct {
+	Addr      string
+	Namespace string
+
+	AuthMethod string // token, approle or kubernetes
+	AuthMount  string // Defaults to the method name
+	Token      string // token auth
+
+	RoleID   string // approle auth
+	SecretID string // approle auth
+
+	KubernetesRole      string // kubernetes auth
+	KubernetesTokenFile string // kubernetes auth: service account token to log in with
+
+	// SecretsPath is a KV v2 path whose fields are added to every run's env
+	// secrets. {project} and {environment} are replaced with the run's scope.
+	SecretsPath string
+
+	// CacheTTL is how long a secret read is reused across activities
+	CacheTTL time.Duration
+}
+
+// ConfigFromEnv reads the worker's Vault configuration. It returns nil when VAULT_ADDR is unset.
+func ConfigFromEnv() (*Config, error) {
+	addr := strings.TrimSpace(os.Getenv(AddrEnv))
+	if addr == "" {
+		return nil, nil
+	}
+
+	cfg := &Config{
+		Addr:                addr,
+		Namespace:           os.Getenv(NamespaceEnv),
+		AuthMethod:          strings.ToLower(strings.TrimSpace(os.Getenv(AuthMethodEnv))),
+		AuthMount:           os.Getenv(AuthMountEnv),
+		Token:               os.Getenv(TokenEnv),
+		RoleID:              os.Getenv(RoleIDEnv),
+		SecretID:            os.Getenv(SecretIDEnv),
+		KubernetesRole:      os.Getenv(KubernetesRoleEnv),
+		KubernetesTokenFile: os.Getenv(KubernetesTokenFileEnv),
+		SecretsPath:         strings.Trim(os.Getenv(SecretsPathEnv), "/"),
+		CacheTTL:            defaultCacheTTL,
+	}
+	if raw := strings.TrimSpace(os.Getenv(CacheTTLEnv)); raw != "" {
+		ttl, err := time.ParseDuration(raw)
+		if err != nil || ttl < 0 {
+			return nil, fmt.Errorf("invalid %s %q: must be a duration such as 30s, or 0 to disable caching", CacheTTLEnv, raw)
+		}
+		cfg.CacheTTL = ttl
+	}
+
+	// Infer the method from the credentials when it isn't named
+	if cfg.AuthMethod == "" {
+		switch {
+		case cfg.RoleID != "":
+			cfg.AuthMethod = AuthAppRole
+		case cfg.KubernetesRole != "":
+			cfg.AuthMethod = AuthKubernetes
+		default:
+			cfg.AuthMethod = AuthToken
+		}
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) validate() error {
+	u, err := url.Parse(c.Addr)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("invalid %s %q: must be an http or https URL", AddrEnv, c.Addr)
+	}
+	switch c.AuthMethod {
+	case AuthToken:
+		if c.Token == "" {
+			return fmt.Errorf("vault token auth needs %s", TokenEnv)
+		}
+	case AuthAppRole:
+		if c.RoleID == "" || c.SecretID == "" {
+			return fmt.Errorf("vault approle auth needs %s and %s", RoleIDEnv, SecretIDEnv)
+		}
+	case AuthKubernetes:
+		if c.KubernetesRole == "" {
+			return fmt.Errorf("vault kubernetes auth needs %s", KubernetesRoleEnv)
+		}
+	default:
+		return fmt.Errorf("invalid %s %q: must be token, approle or kubernetes", AuthMethodEnv, c.AuthMethod)
+	}
+	return nil
+}
+
+// Client reads KV v2 secrets, logging in and renewing its token as needed
+type Client struct {
+	cfg  Config
+	http *http.Client
+	now  func() time.Time
+
+	mu       sync.Mutex
+	token    string
+	renew    bool // Token is renewable
+	ttl      time.Duration
+	expires  time.Time // Zero for tokens that don't expire
+	cache    map[string]cachedSecret
+	inflight map[string]*sync.Mutex
+}
+
+type cachedSecret struct {
+	data    map[string]string
+	fetched time.Time
+}
+
+// NewClient creates a client for cfg. It logs in on first use.
+func NewClient(cfg Config) *Client {
+	if cfg.AuthMount == "" {
+		cfg.AuthMount = cfg.AuthMethod
+	}
+	if cfg.KubernetesTokenFile == "" {
+		cfg.KubernetesTokenFile = defaultKubernetesTokenFile
+	}
+	return &Client{
+		cfg:      cfg,
+		http:     &http.Client{Timeout: requestTimeout},
+		now:      time.Now,
+		cache:    make(map[string]cachedSecret),
+		inflight: make(map[string]*sync.Mutex),
+	}
+}
+
+// vaultError is Vault's error body
+type vaultError struct {
+	Errors []string `json:"errors"`
+}
+
+type authResponse struct {
+	Auth *struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int    `json:"lease_duration"`
+		Renewable     bool   `json:"renewable"`
+	} `json:"auth"`
+}
+
+// do sends a request to Vault and decodes a successful response into out
+func (c *Client) do(ctx context.Context, method, path, token string, body, out interface{}) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(c.cfg.Addr, "/")+"/v1/"+path, reader)
+	if err != nil {
+		return 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if c.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.cfg.Namespace)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("vault request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		var verr vaultError
+		if json.Unmarshal(data, &verr) == nil && len(verr.Errors) > 0 {
+			return resp.StatusCode, fmt.Errorf("vault %s %s: %s", method, path, strings.Join(verr.Errors, "; "))
+		}
+		return resp.StatusCode, fmt.Errorf("vault %s %s: %s", method, path, resp.Status)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to decode vault response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// login exchanges the configured credentials for a token. The caller holds c.mu.
+func (c *Client) login(ctx context.Context) error {
+	var body map[string]string
+	switch c.cfg.AuthMethod {
+	case AuthToken:
+		// A static token only needs its TTL, so renewal knows when to run
+		var lookup struct {
+			Data struct {
+				TTL       int  `json:"ttl"`
+				Renewable bool `json:"renewable"`
+			} `json:"data"`
+		}
+		if _, err := c.do(ctx, http.MethodGet, "auth/token/lookup-self", c.cfg.Token, nil, &lookup); err != nil {
+			return fmt.Errorf("vault token lookup failed: %w", err)
+		}
+		c.setToken(c.cfg.Token, lookup.Data.TTL, lookup.Data.Renewable)
+		return nil
+	case AuthAppRole:
+		body = map[string]string{"role_id": c.cfg.RoleID, "secret_id": c.cfg.SecretID}
+	case AuthKubernetes:
+		jwt, err := os.ReadFile(c.cfg.KubernetesTokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to read kubernetes service account token: %w", err)
+		}
+		body = map[string]string{"role": c.cfg.KubernetesRole, "jwt": strings.TrimSpace(string(jwt))}
+	}
+
+	var resp authResponse
+	if _, err := c.do(ctx, http.MethodPost, "auth/"+strings.Trim(c.cfg.AuthMount, "/")+"/login", "", body, &resp); err != nil {
+		return fmt.Errorf("vault %s login failed: %w", c.cfg.AuthMethod, err)
+	}
+	if resp.Auth == nil || resp.Auth.ClientToken == "" {
+		return fmt.Errorf("vault %s login failed: no token in response", c.cfg.AuthMethod)
+	}
+	c.setToken(resp.Auth.ClientToken, resp.Auth.LeaseDuration, resp.Auth.Renewable)
+	return nil
+}
+
+func (c *Client) setToken(token string, ttlSeconds int, renewable bool) {
+	c.token = token
+	c.renew = renewable
+	c.ttl = time.Duration(ttlSeconds) * time.Second
+	c.expires = time.Time{}
+	if ttlSeconds > 0 {
+		c.expires = c.now().Add(c.ttl)
+	}
+}
+
+// currentToken returns a usable token. It logs in the first time, renews the
+// token once two thirds of its TTL has passed, and logs in again when renewal
+// isn't possible.
+func (c *Client) currentToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token == "" {
+		if err := c.login(ctx); err != nil {
+			return "", err
+		}
+		return c.token, nil
+	}
+	if c.expires.IsZero() || c.expires.Sub(c.now()) > c.ttl/3 {
+		return c.token, nil
+	}
+
+	if c.renew && c.expires.After(c.now()) {
+		var resp authResponse
+		if _, err := c.do(ctx, http.MethodPost, "auth/token/renew-self", c.token, map[string]string{}, &resp); err == nil && resp.Auth != nil {
+			// Renewal is capped by the token's max TTL; log in again once it stops extending
+			if resp.Auth.LeaseDuration > 0 && c.now().Add(time.Duration(resp.Auth.LeaseDuration)*time.Second).After(c.expires) {
+				c.setToken(c.token, resp.Auth.LeaseDuration, resp.Auth.Renewable)
+				return c.token, nil
+			}
+		}
+	}
+	if c.cfg.AuthMethod == AuthToken {
+		if c.expires.After(c.now()) {
+			return c.token, nil // Nothing to log in with; use it until it expires
+		}
+		return "", fmt.Errorf("vault token expired and can't be renewed")
+	}
+	if err := c.login(ctx); err != nil {
+		return "", err
+	}
+	return c.token, nil
+}
+
+// Read returns the fields of the KV v2 secret at path, "<mount>/<path>".
+// Reads are cached for the configured TTL.
+func (c *Client) Read(ctx context.Context, path string) (map[string]string, error) {
+	path = strings.Trim(path, "/")
+	mount, secretPath, ok := strings.Cut(path, "/")
+	if !ok || mount == "" || secretPath == "" {
+		return nil, fmt.Errorf("invalid vault path %q: expected <mount>/<path>", path)
+	}
+
+	// One read per path at a time, so concurrent activities share it
+	c.mu.Lock()
+	lock, ok := c.inflight[path]
+	if !ok {
+		lock = &sync.Mutex{}
+		c.inflight[path] = lock
+	}
+	c.mu.Unlock()
+	lock.Lock()
+	defer lock.Unlock()
+
+	c.mu.Lock()
+	cached, ok := c.cache[path]
+	c.mu.Unlock()
+	if ok && c.now().Sub(cached.fetched) < c.cfg.CacheTTL {
+		return cached.data, nil
+	}
+
+	data, err := c.read(ctx, mount, secretPath)
+	if err != nil {
+		return nil, err
+	}
+	if c.cfg.CacheTTL > 0 {
+		c.mu.Lock()
+		c.cache[path] = cachedSecret{data: data, fetched: c.now()}
+		c.mu.Unlock()
+	}
+	return data, nil
+}
+
+func (c *Client) read(ctx context.Context, mount, secretPath string) (map[string]string, error) {
+	token, err := c.currentToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	apiPath := mount + "/data/" + secretPath
+	status, err := c.do(ctx, http.MethodGet, apiPath, token, nil, &resp)
+	if status == http.StatusForbidden && c.cfg.AuthMethod != AuthToken {
+		// The token may have been revoked; log in once more before giving up
+		c.mu.Lock()
+		c.token = ""
+		c.mu.Unlock()
+		if token, err = c.currentToken(ctx); err != nil {
+			return nil, err
+		}
+		status, err = c.do(ctx, http.MethodGet, apiPath, token, nil, &resp)
+	}
+	if status == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s/%s", errNotFound, mount, secretPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data := make(map[string]string, len(resp.Data.Data))
+	for key, value := range resp.Data.Data {
+		switch v := value.(type) {
+		case string:
+			data[key] = v
+		case nil:
+			data[key] = ""
+		default:
+			encoded, _ := json.Marshal(v)
+			data[key] = string(encoded)
+		}
+	}
+	return data, nil
+}
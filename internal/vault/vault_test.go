@@ -0,0 +1,283 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/egress"
+)
+
+// fakeVault serves AppRole logins, token renewal and KV v2 reads. Tokens are
+// numbered so tests can tell a renewal from a new login.
+type fakeVault struct {
+	secrets  map[string]map[string]interface{} // "<mount>/<path>" -> fields
+	logins   int
+	renewals int
+	reads    int
+	ttl      int
+	revoked  map[string]bool
+}
+
+func newFakeVault() *fakeVault {
+	return &fakeVault{
+		secrets: map[string]map[string]interface{}{
+			"secret/payments/stripe":               {"api_key": "sk_test_4242", "port": float64(443)},
+			"secret/rocketship/proj-1/staging":     {"DB_PASSWORD": "staging-db-pass", "API_KEY": "from-path"},
+			"kv/teams/checkout/feature-flag-token": {"token": "ff-token-xyz"},
+		},
+		ttl:     60,
+		revoked: map[string]bool{},
+	}
+}
+
+func (f *fakeVault) token() string {
+	return "s.token" + string(rune('0'+f.logins))
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	writeErr := func(status int, message string) {
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{message}})
+	}
+
+	switch path {
+	case "auth/approle/login":
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["role_id"] != "role" || body["secret_id"] != "secret" {
+			writeErr(http.StatusBadRequest, "invalid role or secret ID")
+			return
+		}
+		f.logins++
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"auth": map[string]interface{}{
+			"client_token": f.token(), "lease_duration": f.ttl, "renewable": true,
+		}})
+		return
+	case "auth/token/renew-self":
+		f.renewals++
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"auth": map[string]interface{}{
+			"client_token": r.Header.Get("X-Vault-Token"), "lease_duration": f.ttl, "renewable": true,
+		}})
+		return
+	}
+
+	token := r.Header.Get("X-Vault-Token")
+	if token == "" || f.revoked[token] {
+		writeErr(http.StatusForbidden, "permission denied")
+		return
+	}
+	mount, rest, _ := strings.Cut(path, "/data/")
+	secret, ok := f.secrets[mount+"/"+rest]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"errors":[]}`))
+		return
+	}
+	f.reads++
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"data": secret}})
+}
+
+func newTestClient(t *testing.T, fake *fakeVault, cfg Config) (*Client, *time.Time) {
+	t.Helper()
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	cfg.Addr = server.URL
+	if cfg.AuthMethod == "" {
+		cfg.AuthMethod, cfg.RoleID, cfg.SecretID = AuthAppRole, "role", "secret"
+	}
+	c := NewClient(cfg)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	Configure(c)
+	t.Cleanup(func() { Configure(nil) })
+	return c, &now
+}
+
+func TestResolveEnvReferences(t *testing.T) {
+	fake := newFakeVault()
+	newTestClient(t, fake, Config{CacheTTL: time.Minute})
+
+	env, err := ResolveEnv(context.Background(), map[string]string{
+		"STRIPE_KEY":  "vault:secret/payments/stripe#api_key",
+		"STRIPE_PORT": "vault:/secret/payments/stripe#port",
+		"BASE_URL":    "https://api.example.com",
+	}, egress.Scope{})
+	if err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+	if env["STRIPE_KEY"] != "sk_test_4242" || env["STRIPE_PORT"] != "443" || env["BASE_URL"] != "https://api.example.com" {
+		t.Errorf("unexpected env %v", env)
+	}
+	if fake.reads != 1 {
+		t.Errorf("expected one read for both fields, got %d", fake.reads)
+	}
+
+	_, err = ResolveEnv(context.Background(), map[string]string{"X": "vault:secret/payments/stripe#missing"}, egress.Scope{})
+	if err == nil || !strings.Contains(err.Error(), `env secret X: vault secret secret/payments/stripe has no field "missing"`) {
+		t.Errorf("expected a missing field error, got %v", err)
+	}
+	_, err = ResolveEnv(context.Background(), map[string]string{"X": "vault:secret/payments/paypal#key"}, egress.Scope{})
+	if err == nil || !errors.Is(err, errNotFound) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+	_, err = ResolveEnv(context.Background(), map[string]string{"X": "vault:stripe"}, egress.Scope{})
+	if err == nil || !strings.Contains(err.Error(), "expected vault:<mount>/<path>#<field>") {
+		t.Errorf("expected a reference format error, got %v", err)
+	}
+}
+
+func TestSecretsPath(t *testing.T) {
+	fake := newFakeVault()
+	newTestClient(t, fake, Config{SecretsPath: "secret/rocketship/{project}/{environment}"})
+
+	params := map[string]interface{}{
+		"env":            map[string]interface{}{"API_KEY": "from-environment"},
+		egress.ParamsKey: map[string]interface{}{"project_id": "proj-1", "environment": "staging"},
+	}
+	if err := ResolveParams(context.Background(), params); err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+	env := params["env"].(map[string]string)
+	if env["DB_PASSWORD"] != "staging-db-pass" {
+		t.Errorf("expected fields from the secrets path, got %v", env)
+	}
+	if env["API_KEY"] != "from-environment" {
+		t.Errorf("expected environment secrets to win, got %q", env["API_KEY"])
+	}
+
+	// Projects without a secret at the path, and runs without an environment, resolve without it
+	for _, scope := range []egress.Scope{{ProjectID: "proj-2", Environment: "staging"}, {ProjectID: "proj-1"}} {
+		env, err := ResolveEnv(context.Background(), map[string]string{"A": "b"}, scope)
+		if err != nil || len(env) != 1 {
+			t.Errorf("scope %+v: expected env unchanged, got %v (%v)", scope, env, err)
+		}
+	}
+}
+
+func TestTokenRenewalAndRelogin(t *testing.T) {
+	fake := newFakeVault()
+	c, now := newTestClient(t, fake, Config{CacheTTL: time.Second})
+	ctx := context.Background()
+
+	if _, err := c.Read(ctx, "secret/payments/stripe"); err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if fake.logins != 1 {
+		t.Fatalf("expected a login, got %d", fake.logins)
+	}
+
+	// Within the first two thirds of the TTL the token is reused as is
+	*now = now.Add(30 * time.Second)
+	if _, err := c.Read(ctx, "secret/payments/stripe"); err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if fake.renewals != 0 || fake.logins != 1 || fake.reads != 2 {
+		t.Fatalf("expected a plain read, got %d renewals, %d logins, %d reads", fake.renewals, fake.logins, fake.reads)
+	}
+
+	// Close to expiry the token is renewed rather than replaced
+	*now = now.Add(15 * time.Second)
+	if _, err := c.Read(ctx, "secret/payments/stripe"); err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if fake.renewals != 1 || fake.logins != 1 {
+		t.Fatalf("expected a renewal, got %d renewals, %d logins", fake.renewals, fake.logins)
+	}
+
+	// A revoked token is replaced by logging in again
+	fake.revoked[c.token] = true
+	*now = now.Add(2 * time.Second)
+	data, err := c.Read(ctx, "secret/payments/stripe")
+	if err != nil || data["api_key"] != "sk_test_4242" {
+		t.Fatalf("expected the read to recover, got %v (%v)", data, err)
+	}
+	if fake.logins != 2 {
+		t.Errorf("expected a second login, got %d", fake.logins)
+	}
+
+	// Past expiry, the client logs in instead of renewing
+	*now = now.Add(2 * time.Minute)
+	if _, err := c.Read(ctx, "secret/payments/stripe"); err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if fake.logins != 3 || fake.renewals != 1 {
+		t.Errorf("expected a fresh login, got %d logins, %d renewals", fake.logins, fake.renewals)
+	}
+}
+
+func TestMasking(t *testing.T) {
+	fake := newFakeVault()
+	newTestClient(t, fake, Config{})
+
+	if _, err := ResolveEnv(context.Background(), map[string]string{"FF": "vault:kv/teams/checkout/feature-flag-token#token"}, egress.Scope{}); err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+
+	if got := MaskString("Authorization: Bearer ff-token-xyz"); got != "Authorization: Bearer ***" {
+		t.Errorf("expected the token to be masked, got %q", got)
+	}
+	if err := MaskError(errors.New("401 for ff-token-xyz")); err.Error() != "401 for ***" {
+		t.Errorf("expected a masked error, got %v", err)
+	}
+
+	type response struct {
+		Headers map[string]string `json:"headers"`
+		Status  int               `json:"status"`
+	}
+	masked := MaskResponse(&response{Headers: map[string]string{"X-Token": "ff-token-xyz"}, Status: 200}).(map[string]interface{})
+	if masked["headers"].(map[string]interface{})["X-Token"] != Mask || masked["status"] != float64(200) {
+		t.Errorf("expected the header masked and the rest kept, got %v", masked)
+	}
+	clean := &response{Status: 204}
+	if MaskResponse(clean) != clean {
+		t.Errorf("expected responses without secrets to be returned as is")
+	}
+}
+
+func TestWithoutVault(t *testing.T) {
+	Configure(nil)
+
+	env := map[string]string{"A": "b"}
+	resolved, err := ResolveEnv(context.Background(), env, egress.Scope{})
+	if err != nil || resolved["A"] != "b" {
+		t.Fatalf("expected env unchanged, got %v (%v)", resolved, err)
+	}
+
+	_, err = ResolveEnv(context.Background(), map[string]string{"KEY": "vault:secret/app#key"}, egress.Scope{})
+	if err == nil || !strings.Contains(err.Error(), "env secret KEY references Vault, but this worker has no VAULT_ADDR configured") {
+		t.Fatalf("expected a configuration error, got %v", err)
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv(AddrEnv, "")
+	if cfg, err := ConfigFromEnv(); cfg != nil || err != nil {
+		t.Fatalf("expected Vault off without %s, got %+v (%v)", AddrEnv, cfg, err)
+	}
+
+	t.Setenv(AddrEnv, "https://vault.internal:8200")
+	t.Setenv(KubernetesRoleEnv, "rocketship-worker")
+	cfg, err := ConfigFromEnv()
+	if err != nil || cfg.AuthMethod != AuthKubernetes || cfg.CacheTTL != time.Minute {
+		t.Fatalf("expected kubernetes auth inferred, got %+v (%v)", cfg, err)
+	}
+
+	t.Setenv(AuthMethodEnv, "approle")
+	if _, err := ConfigFromEnv(); err == nil || !strings.Contains(err.Error(), "approle auth needs") {
+		t.Errorf("expected missing approle credentials to fail, got %v", err)
+	}
+
+	t.Setenv(AuthMethodEnv, "")
+	t.Setenv(CacheTTLEnv, "soon")
+	if _, err := ConfigFromEnv(); err == nil || !strings.Contains(err.Error(), CacheTTLEnv) {
+		t.Errorf("expected an invalid cache TTL to fail, got %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto copies the receiver into out
+func (in *SuiteSource) DeepCopyInto(out *SuiteSource) {
+	*out = *in
+	if in.ConfigMapKeyRef != nil {
+		out.ConfigMapKeyRef = new(corev1.ConfigMapKeySelector)
+		in.ConfigMapKeyRef.DeepCopyInto(out.ConfigMapKeyRef)
+	}
+}
+
+// DeepCopyInto copies the receiver into out
+func (in *RunOptions) DeepCopyInto(out *RunOptions) {
+	*out = *in
+	in.Suite.DeepCopyInto(&out.Suite)
+	if in.Metadata != nil {
+		out.Metadata = make(map[string]string, len(in.Metadata))
+		for k, v := range in.Metadata {
+			out.Metadata[k] = v
+		}
+	}
+}
+
+// DeepCopyInto copies the receiver into out
+func (in *RocketshipRunStatus) DeepCopyInto(out *RocketshipRunStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		out.StartTime = in.StartTime.DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		out.CompletionTime = in.CompletionTime.DeepCopy()
+	}
+	out.Conditions = copyConditions(in.Conditions)
+}
+
+// DeepCopyInto copies the receiver into out
+func (in *RocketshipRun) DeepCopyInto(out *RocketshipRun) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy returns a deep copy of the receiver
+func (in *RocketshipRun) DeepCopy() *RocketshipRun {
+	if in == nil {
+		return nil
+	}
+	out := new(RocketshipRun)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject implements runtime.Object
+func (in *RocketshipRun) DeepCopyObject() runtime.Object {
+	return in.DeepCopy()
+}
+
+// DeepCopyInto copies the receiver into out
+func (in *RocketshipRunList) DeepCopyInto(out *RocketshipRunList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		out.Items = make([]RocketshipRun, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of the receiver
+func (in *RocketshipRunList) DeepCopy() *RocketshipRunList {
+	if in == nil {
+		return nil
+	}
+	out := new(RocketshipRunList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject implements runtime.Object
+func (in *RocketshipRunList) DeepCopyObject() runtime.Object {
+	return in.DeepCopy()
+}
+
+// DeepCopyInto copies the receiver into out
+func (in *RocketshipSuiteSpec) DeepCopyInto(out *RocketshipSuiteSpec) {
+	*out = *in
+	in.RunOptions.DeepCopyInto(&out.RunOptions)
+	if in.SuccessfulRunsHistoryLimit != nil {
+		out.SuccessfulRunsHistoryLimit = new(int32)
+		*out.SuccessfulRunsHistoryLimit = *in.SuccessfulRunsHistoryLimit
+	}
+	if in.FailedRunsHistoryLimit != nil {
+		out.FailedRunsHistoryLimit = new(int32)
+		*out.FailedRunsHistoryLimit = *in.FailedRunsHistoryLimit
+	}
+}
+
+// DeepCopyInto copies the receiver into out
+func (in *RocketshipSuiteStatus) DeepCopyInto(out *RocketshipSuiteStatus) {
+	*out = *in
+	if in.Active != nil {
+		out.Active = make([]string, len(in.Active))
+		copy(out.Active, in.Active)
+	}
+	if in.LastScheduleTime != nil {
+		out.LastScheduleTime = in.LastScheduleTime.DeepCopy()
+	}
+	if in.LastSuccessfulTime != nil {
+		out.LastSuccessfulTime = in.LastSuccessfulTime.DeepCopy()
+	}
+	if in.NextScheduleTime != nil {
+		out.NextScheduleTime = in.NextScheduleTime.DeepCopy()
+	}
+	out.Conditions = copyConditions(in.Conditions)
+}
+
+// DeepCopyInto copies the receiver into out
+func (in *RocketshipSuite) DeepCopyInto(out *RocketshipSuite) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy returns a deep copy of the receiver
+func (in *RocketshipSuite) DeepCopy() *RocketshipSuite {
+	if in == nil {
+		return nil
+	}
+	out := new(RocketshipSuite)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject implements runtime.Object
+func (in *RocketshipSuite) DeepCopyObject() runtime.Object {
+	return in.DeepCopy()
+}
+
+// DeepCopyInto copies the receiver into out
+func (in *RocketshipSuiteList) DeepCopyInto(out *RocketshipSuiteList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		out.Items = make([]RocketshipSuite, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of the receiver
+func (in *RocketshipSuiteList) DeepCopy() *RocketshipSuiteList {
+	if in == nil {
+		return nil
+	}
+	out := new(RocketshipSuiteList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject implements runtime.Object
+func (in *RocketshipSuiteList) DeepCopyObject() runtime.Object {
+	return in.DeepCopy()
+}
+
+func copyConditions(in []metav1.Condition) []metav1.Condition {
+	if in == nil {
+		return nil
+	}
+	out := make([]metav1.Condition, len(in))
+	for i := range in {
+		in[i].DeepCopyInto(&out[i])
+	}
+	return out
+}
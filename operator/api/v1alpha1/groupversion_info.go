@@ -0,0 +1,23 @@
+// Package v1alpha1 contains the rocketship.sh/v1alpha1 API: RocketshipRun, a single
+// run of a suite on the engine, and RocketshipSuite, a suite run on a cron schedule.
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/scheme"
+)
+
+var (
+	// GroupVersion is the group and version of the resources in this package
+	GroupVersion = schema.GroupVersion{Group: "rocketship.sh", Version: "v1alpha1"}
+
+	// SchemeBuilder registers the resources in this package with a scheme
+	SchemeBuilder = &scheme.Builder{GroupVersion: GroupVersion}
+
+	// AddToScheme adds the resources in this package to a scheme
+	AddToScheme = SchemeBuilder.AddToScheme
+)
+
+func init() {
+	SchemeBuilder.Register(&RocketshipRun{}, &RocketshipRunList{}, &RocketshipSuite{}, &RocketshipSuiteList{})
+}
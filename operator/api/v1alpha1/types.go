@@ -0,0 +1,217 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SuiteSource locates the suite YAML. Exactly one field must be set.
+type SuiteSource struct {
+	// Inline is the suite YAML itself
+	// +optional
+	Inline string `json:"inline,omitempty"`
+
+	// ConfigMapKeyRef reads the suite YAML from a ConfigMap in the resource's namespace
+	// +optional
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+}
+
+// RunOptions are the engine-side options of a run
+type RunOptions struct {
+	// Suite is the suite YAML to run
+	Suite SuiteSource `json:"suite"`
+
+	// Environment is the slug of the project environment whose secrets and variables
+	// the run uses, like `rocketship run --env`
+	// +optional
+	Environment string `json:"environment,omitempty"`
+
+	// ProjectID attributes the run to a project
+	// +optional
+	ProjectID string `json:"projectID,omitempty"`
+
+	// Metadata is recorded with the run
+	// +optional
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// RunPhase is the lifecycle phase of a RocketshipRun. The finished phases mirror the
+// engine's run statuses.
+type RunPhase string
+
+const (
+	RunPhasePending   RunPhase = "Pending"
+	RunPhaseRunning   RunPhase = "Running"
+	RunPhasePassed    RunPhase = "Passed"
+	RunPhaseFailed    RunPhase = "Failed"
+	RunPhaseTimeout   RunPhase = "Timeout"
+	RunPhaseCancelled RunPhase = "Cancelled"
+	// RunPhaseError means the run could not be started or was lost by the engine
+	RunPhaseError RunPhase = "Error"
+)
+
+// Finished reports whether the phase is final
+func (p RunPhase) Finished() bool {
+	switch p {
+	case RunPhasePassed, RunPhaseFailed, RunPhaseTimeout, RunPhaseCancelled, RunPhaseError:
+		return true
+	}
+	return false
+}
+
+// ConditionSucceeded is Unknown while a run is in progress, then True or False once
+// it finishes. Its reason is the final phase.
+const ConditionSucceeded = "Succeeded"
+
+// TestCounts summarizes the tests of a run
+type TestCounts struct {
+	Total   int32 `json:"total"`
+	Passed  int32 `json:"passed"`
+	Failed  int32 `json:"failed"`
+	Pending int32 `json:"pending"`
+}
+
+// RocketshipRunStatus is the observed state of a RocketshipRun
+type RocketshipRunStatus struct {
+	// Phase is the run's lifecycle phase
+	// +optional
+	Phase RunPhase `json:"phase,omitempty"`
+
+	// RunID is the engine's ID for the run
+	// +optional
+	RunID string `json:"runID,omitempty"`
+
+	// SuiteName is the name from the suite YAML
+	// +optional
+	SuiteName string `json:"suiteName,omitempty"`
+
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// +optional
+	Tests TestCounts `json:"tests,omitempty"`
+
+	// Message explains the phase, e.g. why a run could not be started
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// RocketshipRun submits one run of a suite to the engine and reflects its progress.
+// Runs are not re-executed: change the suite and create a new RocketshipRun instead.
+type RocketshipRun struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RunOptions          `json:"spec"`
+	Status RocketshipRunStatus `json:"status,omitempty"`
+}
+
+// RocketshipRunList is a list of RocketshipRuns
+type RocketshipRunList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RocketshipRun `json:"items"`
+}
+
+// ConcurrencyPolicy decides what happens when a schedule fires while a run of the
+// same suite is still in progress
+type ConcurrencyPolicy string
+
+const (
+	// ForbidConcurrent skips the new run
+	ForbidConcurrent ConcurrencyPolicy = "Forbid"
+	// AllowConcurrent starts it anyway
+	AllowConcurrent ConcurrencyPolicy = "Allow"
+)
+
+// RocketshipSuiteSpec is the desired state of a RocketshipSuite
+type RocketshipSuiteSpec struct {
+	RunOptions `json:",inline"`
+
+	// Schedule is a five-field cron expression
+	Schedule string `json:"schedule"`
+
+	// TimeZone is the IANA time zone the schedule is evaluated in. Defaults to UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// Suspend stops new runs from being scheduled
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// ConcurrencyPolicy defaults to Forbid
+	// +optional
+	ConcurrencyPolicy ConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
+
+	// SuccessfulRunsHistoryLimit is how many passed runs to keep. Defaults to 3.
+	// +optional
+	SuccessfulRunsHistoryLimit *int32 `json:"successfulRunsHistoryLimit,omitempty"`
+
+	// FailedRunsHistoryLimit is how many unsuccessful runs to keep. Defaults to 1.
+	// +optional
+	FailedRunsHistoryLimit *int32 `json:"failedRunsHistoryLimit,omitempty"`
+}
+
+// Condition types of a RocketshipSuite
+const (
+	// ConditionScheduled is False when the schedule cannot be parsed
+	ConditionScheduled = "Scheduled"
+	// ConditionLastRunSucceeded tracks the outcome of the most recent finished run
+	ConditionLastRunSucceeded = "LastRunSucceeded"
+)
+
+// RocketshipSuiteStatus is the observed state of a RocketshipSuite
+type RocketshipSuiteStatus struct {
+	// Active lists the runs still in progress
+	// +optional
+	Active []string `json:"active,omitempty"`
+
+	// LastScheduleTime is when a run was last started by the schedule
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// LastRun is the most recent finished run
+	// +optional
+	LastRun string `json:"lastRun,omitempty"`
+
+	// LastRunPhase is the phase of LastRun
+	// +optional
+	LastRunPhase RunPhase `json:"lastRunPhase,omitempty"`
+
+	// LastSuccessfulTime is when the most recent passing run finished
+	// +optional
+	LastSuccessfulTime *metav1.Time `json:"lastSuccessfulTime,omitempty"`
+
+	// NextScheduleTime is when the schedule fires next
+	// +optional
+	NextScheduleTime *metav1.Time `json:"nextScheduleTime,omitempty"`
+
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// RocketshipSuite runs a suite on a cron schedule, creating a RocketshipRun for every
+// scheduled time the way a CronJob creates Jobs
+type RocketshipSuite struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RocketshipSuiteSpec   `json:"spec"`
+	Status RocketshipSuiteStatus `json:"status,omitempty"`
+}
+
+// RocketshipSuiteList is a list of RocketshipSuites
+type RocketshipSuiteList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RocketshipSuite `json:"items"`
+}
@@ -0,0 +1,279 @@
+// Package controller contains the reconcilers for the rocketship.sh resources
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	rocketship "github.com/rocketship-ai/rocketship/pkg/client"
+
+	v1alpha1 "github.com/rocketship-ai/rocketship/operator/api/v1alpha1"
+)
+
+// cancelFinalizer lets an in-progress run be cancelled on the engine when its
+// RocketshipRun is deleted
+const cancelFinalizer = "rocketship.sh/cancel-run"
+
+// Engine is the part of the engine API the controllers use. *client.Client from
+// pkg/client implements it.
+type Engine interface {
+	CreateRun(ctx context.Context, yamlData []byte, runCtx *rocketship.RunContext) (string, error)
+	GetRun(ctx context.Context, runID string) (*rocketship.RunDetails, error)
+	CancelRun(ctx context.Context, runID string) error
+}
+
+// RunReconciler submits RocketshipRuns to the engine and polls them until they finish
+type RunReconciler struct {
+	client.Client
+	Engine Engine
+	// PollInterval is how often an in-progress run is checked (default 10s)
+	PollInterval time.Duration
+}
+
+// Reconcile moves a run one step forward: submit it, refresh its status, or cancel it
+// when it is being deleted
+func (r *RunReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var run v1alpha1.RocketshipRun
+	if err := r.Get(ctx, req.NamespacedName, &run); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !run.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.finalize(ctx, &run)
+	}
+	if run.Status.Phase.Finished() {
+		return ctrl.Result{}, nil
+	}
+	if controllerutil.AddFinalizer(&run, cancelFinalizer) {
+		if err := r.Update(ctx, &run); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if run.Status.RunID == "" {
+		return r.submit(ctx, &run)
+	}
+
+	details, err := r.Engine.GetRun(ctx, run.Status.RunID)
+	if errors.Is(err, rocketship.ErrNotFound) {
+		return ctrl.Result{}, r.setError(ctx, &run, "RunNotFound", fmt.Sprintf("run %s no longer exists on the engine", run.Status.RunID))
+	}
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to get run %s: %w", run.Status.RunID, err)
+	}
+
+	base := run.DeepCopy()
+	applyRunDetails(&run.Status, details)
+	if err := r.Status().Patch(ctx, &run, client.MergeFrom(base)); err != nil {
+		return ctrl.Result{}, err
+	}
+	if run.Status.Phase.Finished() {
+		logger.Info("run finished", "runID", run.Status.RunID, "phase", run.Status.Phase)
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{RequeueAfter: r.pollInterval()}, nil
+}
+
+func (r *RunReconciler) submit(ctx context.Context, run *v1alpha1.RocketshipRun) (ctrl.Result, error) {
+	yamlData, err := loadSuite(ctx, r.Client, run.Namespace, run.Spec.Suite)
+	if err != nil {
+		// The ConfigMap may be applied after the run in the same sync, so keep waiting
+		base := run.DeepCopy()
+		run.Status.Phase = v1alpha1.RunPhasePending
+		run.Status.Message = err.Error()
+		setSucceeded(&run.Status.Conditions, metav1.ConditionUnknown, "SuiteUnavailable", err.Error(), run.Generation)
+		if patchErr := r.Status().Patch(ctx, run, client.MergeFrom(base)); patchErr != nil {
+			return ctrl.Result{}, patchErr
+		}
+		return ctrl.Result{RequeueAfter: r.pollInterval()}, nil
+	}
+
+	runID, err := r.Engine.CreateRun(ctx, yamlData, runContext(run))
+	if errors.Is(err, rocketship.ErrInvalidArgument) {
+		return ctrl.Result{}, r.setError(ctx, run, "InvalidSuite", err.Error())
+	}
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to create run: %w", err)
+	}
+
+	// A merge patch does not conflict with concurrent writers, so the run ID is not lost
+	// to a retry that would submit the suite a second time
+	base := run.DeepCopy()
+	now := metav1.Now()
+	run.Status.RunID = runID
+	run.Status.Phase = v1alpha1.RunPhasePending
+	run.Status.StartTime = &now
+	run.Status.Message = ""
+	setSucceeded(&run.Status.Conditions, metav1.ConditionUnknown, string(v1alpha1.RunPhasePending), "Run submitted to the engine", run.Generation)
+	if err := r.Status().Patch(ctx, run, client.MergeFrom(base)); err != nil {
+		return ctrl.Result{}, err
+	}
+	log.FromContext(ctx).Info("run submitted", "runID", runID)
+	return ctrl.Result{RequeueAfter: r.pollInterval()}, nil
+}
+
+func (r *RunReconciler) finalize(ctx context.Context, run *v1alpha1.RocketshipRun) error {
+	if !controllerutil.ContainsFinalizer(run, cancelFinalizer) {
+		return nil
+	}
+	if run.Status.RunID != "" && !run.Status.Phase.Finished() {
+		err := r.Engine.CancelRun(ctx, run.Status.RunID)
+		if err != nil && !errors.Is(err, rocketship.ErrNotFound) {
+			return fmt.Errorf("failed to cancel run %s: %w", run.Status.RunID, err)
+		}
+	}
+	controllerutil.RemoveFinalizer(run, cancelFinalizer)
+	return r.Update(ctx, run)
+}
+
+func (r *RunReconciler) setError(ctx context.Context, run *v1alpha1.RocketshipRun, reason, message string) error {
+	base := run.DeepCopy()
+	now := metav1.Now()
+	run.Status.Phase = v1alpha1.RunPhaseError
+	run.Status.Message = message
+	run.Status.CompletionTime = &now
+	setSucceeded(&run.Status.Conditions, metav1.ConditionFalse, reason, message, run.Generation)
+	return r.Status().Patch(ctx, run, client.MergeFrom(base))
+}
+
+func (r *RunReconciler) pollInterval() time.Duration {
+	if r.PollInterval <= 0 {
+		return 10 * time.Second
+	}
+	return r.PollInterval
+}
+
+// SetupWithManager registers the reconciler with mgr
+func (r *RunReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.RocketshipRun{}).
+		Named("rocketshiprun").
+		Complete(r)
+}
+
+// runContext describes where the run came from. Runs started by a RocketshipSuite are
+// recorded as scheduled runs named after the suite.
+func runContext(run *v1alpha1.RocketshipRun) *rocketship.RunContext {
+	metadata := map[string]string{
+		"kubernetes_namespace": run.Namespace,
+		"kubernetes_name":      run.Name,
+	}
+	for k, v := range run.Spec.Metadata {
+		metadata[k] = v
+	}
+	if run.Spec.Environment != "" {
+		metadata["env"] = run.Spec.Environment
+	}
+
+	runCtx := &rocketship.RunContext{
+		ProjectId: run.Spec.ProjectID,
+		Source:    "kubernetes",
+		Trigger:   "manual",
+		Metadata:  metadata,
+	}
+	if owner := metav1.GetControllerOf(run); owner != nil && owner.Kind == "RocketshipSuite" {
+		runCtx.Trigger = "schedule"
+		runCtx.ScheduleName = run.Namespace + "/" + owner.Name
+	}
+	return runCtx
+}
+
+// applyRunDetails copies the engine's view of a run into status
+func applyRunDetails(status *v1alpha1.RocketshipRunStatus, details *rocketship.RunDetails) {
+	status.SuiteName = details.GetSuiteName()
+	status.Phase = runPhase(details.GetStatus())
+
+	var counts v1alpha1.TestCounts
+	for _, test := range details.GetTests() {
+		counts.Total++
+		switch test.GetStatus() {
+		case rocketship.StatusPassed:
+			counts.Passed++
+		case rocketship.StatusFailed, rocketship.StatusTimeout:
+			counts.Failed++
+		case rocketship.StatusPending, rocketship.StatusRunning:
+			counts.Pending++
+		}
+	}
+	status.Tests = counts
+
+	if !status.Phase.Finished() {
+		setSucceeded(&status.Conditions, metav1.ConditionUnknown, string(status.Phase), "Run in progress", 0)
+		return
+	}
+
+	completed := metav1.Now()
+	if ended, err := time.Parse(time.RFC3339, details.GetEndedAt()); err == nil {
+		completed = metav1.NewTime(ended)
+	}
+	status.CompletionTime = &completed
+
+	message := fmt.Sprintf("%d of %d tests passed", counts.Passed, counts.Total)
+	status.Message = message
+	conditionStatus := metav1.ConditionFalse
+	if status.Phase == v1alpha1.RunPhasePassed {
+		conditionStatus = metav1.ConditionTrue
+	}
+	setSucceeded(&status.Conditions, conditionStatus, string(status.Phase), message, 0)
+}
+
+func runPhase(status string) v1alpha1.RunPhase {
+	switch status {
+	case rocketship.StatusRunning:
+		return v1alpha1.RunPhaseRunning
+	case rocketship.StatusPassed:
+		return v1alpha1.RunPhasePassed
+	case rocketship.StatusFailed, rocketship.StatusBudgetExceeded:
+		return v1alpha1.RunPhaseFailed
+	case rocketship.StatusTimeout:
+		return v1alpha1.RunPhaseTimeout
+	case rocketship.StatusCancelled:
+		return v1alpha1.RunPhaseCancelled
+	default:
+		return v1alpha1.RunPhasePending
+	}
+}
+
+func setSucceeded(conditions *[]metav1.Condition, status metav1.ConditionStatus, reason, message string, generation int64) {
+	setCondition(conditions, v1alpha1.ConditionSucceeded, status, reason, message, generation)
+}
+
+// loadSuite returns the suite YAML a SuiteSource points at
+func loadSuite(ctx context.Context, c client.Client, namespace string, source v1alpha1.SuiteSource) ([]byte, error) {
+	switch {
+	case source.Inline != "" && source.ConfigMapKeyRef != nil:
+		return nil, errors.New("suite must set only one of inline and configMapKeyRef")
+	case source.Inline != "":
+		return []byte(source.Inline), nil
+	case source.ConfigMapKeyRef != nil:
+		ref := source.ConfigMapKeyRef
+		var cm corev1.ConfigMap
+		if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, &cm); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, fmt.Errorf("configmap %s/%s not found", namespace, ref.Name)
+			}
+			return nil, fmt.Errorf("failed to get configmap %s/%s: %w", namespace, ref.Name, err)
+		}
+		if data, ok := cm.Data[ref.Key]; ok {
+			return []byte(data), nil
+		}
+		if data, ok := cm.BinaryData[ref.Key]; ok {
+			return data, nil
+		}
+		return nil, fmt.Errorf("configmap %s/%s has no key %q", namespace, ref.Name, ref.Key)
+	default:
+		return nil, errors.New("suite must set inline or configMapKeyRef")
+	}
+}
@@ -0,0 +1,209 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	rocketship "github.com/rocketship-ai/rocketship/pkg/client"
+
+	v1alpha1 "github.com/rocketship-ai/rocketship/operator/api/v1alpha1"
+)
+
+type fakeEngine struct {
+	created   [][]byte
+	contexts  []*rocketship.RunContext
+	createErr error
+	details   *rocketship.RunDetails
+	getErr    error
+	cancelled []string
+}
+
+func (f *fakeEngine) CreateRun(_ context.Context, yamlData []byte, runCtx *rocketship.RunContext) (string, error) {
+	if f.createErr != nil {
+		return "", f.createErr
+	}
+	f.created = append(f.created, yamlData)
+	f.contexts = append(f.contexts, runCtx)
+	return "run-1", nil
+}
+
+func (f *fakeEngine) GetRun(_ context.Context, _ string) (*rocketship.RunDetails, error) {
+	return f.details, f.getErr
+}
+
+func (f *fakeEngine) CancelRun(_ context.Context, runID string) error {
+	f.cancelled = append(f.cancelled, runID)
+	return nil
+}
+
+func newScheme(t *testing.T) *runtime.Scheme {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return scheme
+}
+
+func newFakeClient(t *testing.T, objs ...client.Object) client.Client {
+	t.Helper()
+	return fake.NewClientBuilder().
+		WithScheme(newScheme(t)).
+		WithObjects(objs...).
+		WithStatusSubresource(&v1alpha1.RocketshipRun{}, &v1alpha1.RocketshipSuite{}).
+		Build()
+}
+
+func reconcileRun(t *testing.T, r *RunReconciler, name string) ctrl.Result {
+	t.Helper()
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: name}})
+	if err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	return result
+}
+
+func getRun(t *testing.T, c client.Client, name string) *v1alpha1.RocketshipRun {
+	t.Helper()
+	var run v1alpha1.RocketshipRun
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: name}, &run); err != nil {
+		t.Fatalf("failed to get run: %v", err)
+	}
+	return &run
+}
+
+func TestRunReconcilerLifecycle(t *testing.T) {
+	run := &v1alpha1.RocketshipRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "smoke", Namespace: "default"},
+		Spec: v1alpha1.RunOptions{
+			Suite:       v1alpha1.SuiteSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "suites"}, Key: "smoke.yaml"}},
+			Environment: "staging",
+		},
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "suites", Namespace: "default"},
+		Data:       map[string]string{"smoke.yaml": "name: smoke"},
+	}
+	c := newFakeClient(t, run, cm)
+	engine := &fakeEngine{}
+	r := &RunReconciler{Client: c, Engine: engine}
+
+	if result := reconcileRun(t, r, "smoke"); result.RequeueAfter == 0 {
+		t.Fatal("expected a requeue after submitting")
+	}
+	if len(engine.created) != 1 || string(engine.created[0]) != "name: smoke" {
+		t.Fatalf("unexpected submissions: %q", engine.created)
+	}
+	runCtx := engine.contexts[0]
+	if runCtx.GetSource() != "kubernetes" || runCtx.GetTrigger() != "manual" || runCtx.GetMetadata()["env"] != "staging" {
+		t.Fatalf("unexpected run context: %+v", runCtx)
+	}
+	got := getRun(t, c, "smoke")
+	if got.Status.RunID != "run-1" || got.Status.Phase != v1alpha1.RunPhasePending {
+		t.Fatalf("unexpected status after submit: %+v", got.Status)
+	}
+	if len(got.Finalizers) != 1 || got.Finalizers[0] != cancelFinalizer {
+		t.Fatalf("expected the cancel finalizer, got %v", got.Finalizers)
+	}
+
+	engine.details = &rocketship.RunDetails{
+		SuiteName: "smoke",
+		Status:    rocketship.StatusPassed,
+		EndedAt:   "2026-01-02T03:04:05Z",
+		Tests: []*rocketship.TestDetails{
+			{Status: rocketship.StatusPassed},
+			{Status: rocketship.StatusPassed},
+		},
+	}
+	if result := reconcileRun(t, r, "smoke"); result.RequeueAfter != 0 {
+		t.Fatalf("expected no requeue once finished, got %v", result.RequeueAfter)
+	}
+	got = getRun(t, c, "smoke")
+	if got.Status.Phase != v1alpha1.RunPhasePassed || got.Status.Tests.Passed != 2 || got.Status.CompletionTime == nil {
+		t.Fatalf("unexpected status after finishing: %+v", got.Status)
+	}
+	if !meta.IsStatusConditionTrue(got.Status.Conditions, v1alpha1.ConditionSucceeded) {
+		t.Fatalf("expected Succeeded=True, got %+v", got.Status.Conditions)
+	}
+	if len(engine.created) != 1 {
+		t.Fatalf("run was submitted %d times", len(engine.created))
+	}
+}
+
+func TestRunReconcilerInvalidSuite(t *testing.T) {
+	run := &v1alpha1.RocketshipRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "broken", Namespace: "default"},
+		Spec:       v1alpha1.RunOptions{Suite: v1alpha1.SuiteSource{Inline: "tests: ["}},
+	}
+	c := newFakeClient(t, run)
+	r := &RunReconciler{Client: c, Engine: &fakeEngine{createErr: rocketship.ErrInvalidArgument}}
+
+	reconcileRun(t, r, "broken")
+	got := getRun(t, c, "broken")
+	if got.Status.Phase != v1alpha1.RunPhaseError {
+		t.Fatalf("expected Error phase, got %+v", got.Status)
+	}
+	cond := meta.FindStatusCondition(got.Status.Conditions, v1alpha1.ConditionSucceeded)
+	if cond == nil || cond.Reason != "InvalidSuite" {
+		t.Fatalf("expected an InvalidSuite condition, got %+v", got.Status.Conditions)
+	}
+}
+
+func TestRunReconcilerWaitsForConfigMap(t *testing.T) {
+	run := &v1alpha1.RocketshipRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "later", Namespace: "default"},
+		Spec: v1alpha1.RunOptions{
+			Suite: v1alpha1.SuiteSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "missing"}, Key: "suite.yaml"}},
+		},
+	}
+	c := newFakeClient(t, run)
+	engine := &fakeEngine{}
+	r := &RunReconciler{Client: c, Engine: engine}
+
+	if result := reconcileRun(t, r, "later"); result.RequeueAfter == 0 {
+		t.Fatal("expected a requeue while the configmap is missing")
+	}
+	if len(engine.created) != 0 {
+		t.Fatal("run should not be submitted without its suite")
+	}
+	if got := getRun(t, c, "later"); got.Status.Phase != v1alpha1.RunPhasePending {
+		t.Fatalf("expected Pending phase, got %+v", got.Status)
+	}
+}
+
+func TestRunReconcilerCancelsOnDelete(t *testing.T) {
+	run := &v1alpha1.RocketshipRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "long", Namespace: "default", Finalizers: []string{cancelFinalizer}},
+		Spec:       v1alpha1.RunOptions{Suite: v1alpha1.SuiteSource{Inline: "name: long"}},
+		Status:     v1alpha1.RocketshipRunStatus{RunID: "run-9", Phase: v1alpha1.RunPhaseRunning},
+	}
+	c := newFakeClient(t, run)
+	engine := &fakeEngine{}
+	r := &RunReconciler{Client: c, Engine: engine}
+
+	if err := c.Delete(context.Background(), getRun(t, c, "long")); err != nil {
+		t.Fatal(err)
+	}
+	reconcileRun(t, r, "long")
+
+	if len(engine.cancelled) != 1 || engine.cancelled[0] != "run-9" {
+		t.Fatalf("expected run-9 to be cancelled, got %v", engine.cancelled)
+	}
+	var gone v1alpha1.RocketshipRun
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "long"}, &gone); err == nil {
+		t.Fatal("expected the run to be deleted once the finalizer was removed")
+	}
+}
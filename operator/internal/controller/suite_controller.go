@@ -0,0 +1,282 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1alpha1 "github.com/rocketship-ai/rocketship/operator/api/v1alpha1"
+)
+
+// scheduledTimeAnnotation records which scheduled time a run was created for
+const scheduledTimeAnnotation = "rocketship.sh/scheduled-at"
+
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+
+// SuiteReconciler creates a RocketshipRun every time a RocketshipSuite's schedule
+// fires and keeps a bounded history of them
+type SuiteReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// Now returns the current time (defaults to time.Now)
+	Now func() time.Time
+}
+
+// Reconcile refreshes a suite's status from its runs, prunes old runs and starts a run
+// when one is due
+func (r *SuiteReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var suite v1alpha1.RocketshipSuite
+	if err := r.Get(ctx, req.NamespacedName, &suite); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !suite.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	runs, err := r.ownedRuns(ctx, &suite)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.pruneHistory(ctx, &suite, runs); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	base := suite.DeepCopy()
+	active := summarizeRuns(&suite.Status, runs)
+	suite.Status.ObservedGeneration = suite.Generation
+
+	now := r.now()
+	schedule, err := parseSchedule(suite.Spec.Schedule, suite.Spec.TimeZone)
+	if err != nil {
+		suite.Status.NextScheduleTime = nil
+		setCondition(&suite.Status.Conditions, v1alpha1.ConditionScheduled, metav1.ConditionFalse, "InvalidSchedule", err.Error(), suite.Generation)
+		// Nothing to retry until the spec changes
+		return ctrl.Result{}, r.Status().Patch(ctx, &suite, client.MergeFrom(base))
+	}
+
+	var result ctrl.Result
+	if suite.Spec.Suspend {
+		suite.Status.NextScheduleTime = nil
+		setCondition(&suite.Status.Conditions, v1alpha1.ConditionScheduled, metav1.ConditionFalse, "Suspended", "Schedule is suspended", suite.Generation)
+	} else {
+		due, next := dueTime(schedule, lastScheduled(&suite), now)
+		if !due.IsZero() {
+			if len(active) > 0 && suite.Spec.ConcurrencyPolicy != v1alpha1.AllowConcurrent {
+				logger.Info("skipping scheduled run while another is in progress", "scheduledAt", due, "active", active)
+			} else {
+				name, err := r.createRun(ctx, &suite, due)
+				if err != nil {
+					return ctrl.Result{}, err
+				}
+				suite.Status.Active = append(suite.Status.Active, name)
+				logger.Info("started scheduled run", "run", name, "scheduledAt", due)
+			}
+			// Skipped times are not made up later, like a CronJob without a starting deadline
+			scheduled := metav1.NewTime(due)
+			suite.Status.LastScheduleTime = &scheduled
+		}
+		nextTime := metav1.NewTime(next)
+		suite.Status.NextScheduleTime = &nextTime
+		setCondition(&suite.Status.Conditions, v1alpha1.ConditionScheduled, metav1.ConditionTrue, "Scheduled",
+			"Next run at "+next.Format(time.RFC3339), suite.Generation)
+		result.RequeueAfter = next.Sub(now)
+	}
+
+	if err := r.Status().Patch(ctx, &suite, client.MergeFrom(base)); err != nil {
+		return ctrl.Result{}, err
+	}
+	return result, nil
+}
+
+// createRun creates the run for one scheduled time. The name is derived from that time,
+// so a retry finds the existing run instead of starting a second one.
+func (r *SuiteReconciler) createRun(ctx context.Context, suite *v1alpha1.RocketshipSuite, scheduled time.Time) (string, error) {
+	run := &v1alpha1.RocketshipRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("%s-%d", suite.Name, scheduled.Unix()/60),
+			Namespace:   suite.Namespace,
+			Labels:      suite.Labels,
+			Annotations: map[string]string{scheduledTimeAnnotation: scheduled.UTC().Format(time.RFC3339)},
+		},
+	}
+	suite.Spec.RunOptions.DeepCopyInto(&run.Spec)
+	if err := controllerutil.SetControllerReference(suite, run, r.Scheme); err != nil {
+		return "", err
+	}
+	if err := r.Create(ctx, run); err != nil && !apierrors.IsAlreadyExists(err) {
+		return "", fmt.Errorf("failed to create run %s: %w", run.Name, err)
+	}
+	return run.Name, nil
+}
+
+func (r *SuiteReconciler) ownedRuns(ctx context.Context, suite *v1alpha1.RocketshipSuite) ([]v1alpha1.RocketshipRun, error) {
+	var list v1alpha1.RocketshipRunList
+	if err := r.List(ctx, &list, client.InNamespace(suite.Namespace)); err != nil {
+		return nil, err
+	}
+	var runs []v1alpha1.RocketshipRun
+	for _, run := range list.Items {
+		if owner := metav1.GetControllerOf(&run); owner != nil && owner.UID == suite.UID {
+			runs = append(runs, run)
+		}
+	}
+	// Oldest first
+	sort.Slice(runs, func(i, j int) bool {
+		return scheduledAt(&runs[i]).Before(scheduledAt(&runs[j]))
+	})
+	return runs, nil
+}
+
+// pruneHistory deletes the oldest finished runs beyond the history limits
+func (r *SuiteReconciler) pruneHistory(ctx context.Context, suite *v1alpha1.RocketshipSuite, runs []v1alpha1.RocketshipRun) error {
+	var passed, unsuccessful []*v1alpha1.RocketshipRun
+	for i := range runs {
+		switch phase := runs[i].Status.Phase; {
+		case phase == v1alpha1.RunPhasePassed:
+			passed = append(passed, &runs[i])
+		case phase.Finished():
+			unsuccessful = append(unsuccessful, &runs[i])
+		}
+	}
+
+	var stale []*v1alpha1.RocketshipRun
+	if limit := int(historyLimit(suite.Spec.SuccessfulRunsHistoryLimit, 3)); len(passed) > limit {
+		stale = append(stale, passed[:len(passed)-limit]...)
+	}
+	if limit := int(historyLimit(suite.Spec.FailedRunsHistoryLimit, 1)); len(unsuccessful) > limit {
+		stale = append(stale, unsuccessful[:len(unsuccessful)-limit]...)
+	}
+	for _, run := range stale {
+		if err := r.Delete(ctx, run, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete old run %s: %w", run.Name, err)
+		}
+	}
+	return nil
+}
+
+func (r *SuiteReconciler) now() time.Time {
+	if r.Now != nil {
+		return r.Now()
+	}
+	return time.Now()
+}
+
+// SetupWithManager registers the reconciler with mgr. Changes to owned runs requeue
+// their suite so its status follows them.
+func (r *SuiteReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.RocketshipSuite{}).
+		Owns(&v1alpha1.RocketshipRun{}).
+		Named("rocketshipsuite").
+		Complete(r)
+}
+
+// summarizeRuns fills in the run-derived parts of status and returns the active runs.
+// runs must be sorted oldest first.
+func summarizeRuns(status *v1alpha1.RocketshipSuiteStatus, runs []v1alpha1.RocketshipRun) []string {
+	var active []string
+	var lastFinished *v1alpha1.RocketshipRun
+	for i := range runs {
+		run := &runs[i]
+		if !run.Status.Phase.Finished() {
+			active = append(active, run.Name)
+			continue
+		}
+		lastFinished = run
+		if run.Status.Phase == v1alpha1.RunPhasePassed && run.Status.CompletionTime != nil &&
+			(status.LastSuccessfulTime == nil || status.LastSuccessfulTime.Before(run.Status.CompletionTime)) {
+			status.LastSuccessfulTime = run.Status.CompletionTime.DeepCopy()
+		}
+	}
+	status.Active = active
+
+	if lastFinished != nil {
+		status.LastRun = lastFinished.Name
+		status.LastRunPhase = lastFinished.Status.Phase
+		conditionStatus := metav1.ConditionFalse
+		if lastFinished.Status.Phase == v1alpha1.RunPhasePassed {
+			conditionStatus = metav1.ConditionTrue
+		}
+		message := lastFinished.Name
+		if lastFinished.Status.Message != "" {
+			message += ": " + lastFinished.Status.Message
+		}
+		setCondition(&status.Conditions, v1alpha1.ConditionLastRunSucceeded, conditionStatus, string(lastFinished.Status.Phase), message, 0)
+	}
+	return active
+}
+
+func parseSchedule(expression, timeZone string) (cron.Schedule, error) {
+	expression = strings.TrimSpace(expression)
+	if strings.HasPrefix(expression, "TZ=") || strings.HasPrefix(expression, "CRON_TZ=") {
+		return nil, fmt.Errorf("set the time zone with timeZone instead of in the schedule")
+	}
+	if timeZone != "" {
+		if _, err := time.LoadLocation(timeZone); err != nil {
+			return nil, fmt.Errorf("invalid time zone %q: %w", timeZone, err)
+		}
+		expression = "CRON_TZ=" + timeZone + " " + expression
+	}
+	schedule, err := cronParser.Parse(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", expression, err)
+	}
+	return schedule, nil
+}
+
+// dueTime returns the most recent scheduled time after last that is not after now
+// (zero when none is due) and the next scheduled time after now
+func dueTime(schedule cron.Schedule, last, now time.Time) (time.Time, time.Time) {
+	var due time.Time
+	for t := schedule.Next(last); !t.After(now); t = schedule.Next(t) {
+		due = t
+	}
+	return due, schedule.Next(now)
+}
+
+// lastScheduled is the point the schedule is evaluated from: the last scheduled time,
+// or the suite's creation so a new suite waits for its first scheduled time
+func lastScheduled(suite *v1alpha1.RocketshipSuite) time.Time {
+	if suite.Status.LastScheduleTime != nil {
+		return suite.Status.LastScheduleTime.Time
+	}
+	return suite.CreationTimestamp.Time
+}
+
+func scheduledAt(run *v1alpha1.RocketshipRun) time.Time {
+	if t, err := time.Parse(time.RFC3339, run.Annotations[scheduledTimeAnnotation]); err == nil {
+		return t
+	}
+	return run.CreationTimestamp.Time
+}
+
+func historyLimit(limit *int32, fallback int32) int32 {
+	if limit == nil || *limit < 0 {
+		return fallback
+	}
+	return *limit
+}
+
+func setCondition(conditions *[]metav1.Condition, conditionType string, status metav1.ConditionStatus, reason, message string, generation int64) {
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: generation,
+	})
+}
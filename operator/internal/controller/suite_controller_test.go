@@ -0,0 +1,198 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/rocketship-ai/rocketship/operator/api/v1alpha1"
+)
+
+func newSuite(schedule string, created time.Time) *v1alpha1.RocketshipSuite {
+	return &v1alpha1.RocketshipSuite{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "nightly",
+			Namespace:         "default",
+			UID:               "suite-uid",
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: v1alpha1.RocketshipSuiteSpec{
+			RunOptions: v1alpha1.RunOptions{Suite: v1alpha1.SuiteSource{Inline: "name: nightly"}},
+			Schedule:   schedule,
+		},
+	}
+}
+
+func reconcileSuite(t *testing.T, r *SuiteReconciler) ctrl.Result {
+	t.Helper()
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "nightly"}})
+	if err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	return result
+}
+
+func getSuite(t *testing.T, c client.Client) *v1alpha1.RocketshipSuite {
+	t.Helper()
+	var suite v1alpha1.RocketshipSuite
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "nightly"}, &suite); err != nil {
+		t.Fatalf("failed to get suite: %v", err)
+	}
+	return &suite
+}
+
+func listRuns(t *testing.T, c client.Client) []v1alpha1.RocketshipRun {
+	t.Helper()
+	var list v1alpha1.RocketshipRunList
+	if err := c.List(context.Background(), &list, client.InNamespace("default")); err != nil {
+		t.Fatal(err)
+	}
+	return list.Items
+}
+
+func TestSuiteReconcilerSchedulesRuns(t *testing.T) {
+	created := time.Date(2026, 1, 1, 0, 30, 0, 0, time.UTC)
+	now := created
+	c := newFakeClient(t, newSuite("0 * * * *", created))
+	r := &SuiteReconciler{Client: c, Scheme: c.Scheme(), Now: func() time.Time { return now }}
+
+	// Nothing is due before the first scheduled time
+	result := reconcileSuite(t, r)
+	if result.RequeueAfter != 30*time.Minute {
+		t.Fatalf("expected a requeue at the next hour, got %v", result.RequeueAfter)
+	}
+	if runs := listRuns(t, c); len(runs) != 0 {
+		t.Fatalf("expected no runs yet, got %d", len(runs))
+	}
+
+	// Several missed times only start the latest
+	now = time.Date(2026, 1, 1, 3, 0, 10, 0, time.UTC)
+	reconcileSuite(t, r)
+	runs := listRuns(t, c)
+	if len(runs) != 1 {
+		t.Fatalf("expected one run, got %d", len(runs))
+	}
+	run := runs[0]
+	if want := time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC); !scheduledAt(&run).Equal(want) {
+		t.Fatalf("expected the run scheduled at %v, got %v", want, scheduledAt(&run))
+	}
+	if owner := metav1.GetControllerOf(&run); owner == nil || owner.UID != "suite-uid" {
+		t.Fatalf("expected the run to be owned by the suite, got %+v", run.OwnerReferences)
+	}
+	if run.Spec.Suite.Inline != "name: nightly" {
+		t.Fatalf("expected the suite's options to be copied, got %+v", run.Spec)
+	}
+
+	suite := getSuite(t, c)
+	if len(suite.Status.Active) != 1 || suite.Status.Active[0] != run.Name {
+		t.Fatalf("expected %s to be active, got %v", run.Name, suite.Status.Active)
+	}
+	if !meta.IsStatusConditionTrue(suite.Status.Conditions, v1alpha1.ConditionScheduled) {
+		t.Fatalf("expected Scheduled=True, got %+v", suite.Status.Conditions)
+	}
+
+	// Forbid skips the next time while the run is still going
+	now = time.Date(2026, 1, 1, 4, 0, 5, 0, time.UTC)
+	reconcileSuite(t, r)
+	if runs := listRuns(t, c); len(runs) != 1 {
+		t.Fatalf("expected the run to be skipped, got %d runs", len(runs))
+	}
+	if got := getSuite(t, c).Status.LastScheduleTime; got == nil || !got.Time.Equal(time.Date(2026, 1, 1, 4, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the skipped time to be recorded, got %v", got)
+	}
+
+	// A finished run is reported and the next time starts a new one
+	run.Status.Phase = v1alpha1.RunPhaseFailed
+	if err := c.Status().Update(context.Background(), &run); err != nil {
+		t.Fatal(err)
+	}
+	now = time.Date(2026, 1, 1, 5, 0, 5, 0, time.UTC)
+	reconcileSuite(t, r)
+	if runs := listRuns(t, c); len(runs) != 2 {
+		t.Fatalf("expected a second run, got %d", len(runs))
+	}
+	suite = getSuite(t, c)
+	if suite.Status.LastRun != run.Name || suite.Status.LastRunPhase != v1alpha1.RunPhaseFailed {
+		t.Fatalf("expected %s to be the failed last run, got %+v", run.Name, suite.Status)
+	}
+	if !meta.IsStatusConditionFalse(suite.Status.Conditions, v1alpha1.ConditionLastRunSucceeded) {
+		t.Fatalf("expected LastRunSucceeded=False, got %+v", suite.Status.Conditions)
+	}
+}
+
+func TestSuiteReconcilerPrunesHistory(t *testing.T) {
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	suite := newSuite("0 0 * * *", created)
+	suite.Spec.Suspend = true
+	suite.Spec.SuccessfulRunsHistoryLimit = new(int32)
+	*suite.Spec.SuccessfulRunsHistoryLimit = 1
+
+	objs := []client.Object{suite}
+	phases := []v1alpha1.RunPhase{v1alpha1.RunPhasePassed, v1alpha1.RunPhaseFailed, v1alpha1.RunPhasePassed, v1alpha1.RunPhaseFailed, v1alpha1.RunPhasePassed}
+	for i, phase := range phases {
+		scheduled := created.Add(time.Duration(i+1) * 24 * time.Hour)
+		objs = append(objs, &v1alpha1.RocketshipRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "nightly-" + string(rune('a'+i)),
+				Namespace:       "default",
+				Annotations:     map[string]string{scheduledTimeAnnotation: scheduled.Format(time.RFC3339)},
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(suite, v1alpha1.GroupVersion.WithKind("RocketshipSuite"))},
+			},
+			Status: v1alpha1.RocketshipRunStatus{Phase: phase},
+		})
+	}
+	c := newFakeClient(t, objs...)
+	r := &SuiteReconciler{Client: c, Scheme: c.Scheme(), Now: func() time.Time { return created.Add(30 * 24 * time.Hour) }}
+
+	if result := reconcileSuite(t, r); result.RequeueAfter != 0 {
+		t.Fatalf("expected no requeue while suspended, got %v", result.RequeueAfter)
+	}
+	var names []string
+	for _, run := range listRuns(t, c) {
+		names = append(names, run.Name)
+	}
+	// The newest passed run and the newest failed run are kept
+	if len(names) != 2 || names[0] != "nightly-d" || names[1] != "nightly-e" {
+		t.Fatalf("unexpected runs after pruning: %v", names)
+	}
+	if got := getSuite(t, c); !meta.IsStatusConditionFalse(got.Status.Conditions, v1alpha1.ConditionScheduled) {
+		t.Fatalf("expected Scheduled=False while suspended, got %+v", got.Status.Conditions)
+	}
+}
+
+func TestSuiteReconcilerInvalidSchedule(t *testing.T) {
+	suite := newSuite("every day", time.Now())
+	c := newFakeClient(t, suite)
+	r := &SuiteReconciler{Client: c, Scheme: c.Scheme()}
+
+	if result := reconcileSuite(t, r); result.RequeueAfter != 0 {
+		t.Fatalf("expected no requeue for an invalid schedule, got %v", result.RequeueAfter)
+	}
+	cond := meta.FindStatusCondition(getSuite(t, c).Status.Conditions, v1alpha1.ConditionScheduled)
+	if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != "InvalidSchedule" {
+		t.Fatalf("expected an InvalidSchedule condition, got %+v", cond)
+	}
+}
+
+func TestParseScheduleTimeZone(t *testing.T) {
+	schedule, err := parseSchedule("0 9 * * *", "Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := schedule.Next(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	if want := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC); !next.Equal(want) {
+		t.Fatalf("expected %v, got %v", want, next.UTC())
+	}
+	if _, err := parseSchedule("CRON_TZ=UTC 0 9 * * *", ""); err == nil {
+		t.Fatal("expected an error for a time zone inside the schedule")
+	}
+	if _, err := parseSchedule("0 9 * * *", "Mars/Base"); err == nil {
+		t.Fatal("expected an error for an unknown time zone")
+	}
+}
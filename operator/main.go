@@ -0,0 +1,107 @@
+// Command operator runs the Rocketship Kubernetes operator. It reconciles
+// RocketshipRun resources into engine runs and creates runs on the schedules of
+// RocketshipSuite resources.
+package main
+
+import (
+	"flag"
+	"os"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	rocketship "github.com/rocketship-ai/rocketship/pkg/client"
+
+	v1alpha1 "github.com/rocketship-ai/rocketship/operator/api/v1alpha1"
+	"github.com/rocketship-ai/rocketship/operator/internal/controller"
+)
+
+// engineEnvVar is read for the engine address when --engine is not given
+const engineEnvVar = "ROCKETSHIP_ENGINE"
+
+func main() {
+	var (
+		engineAddress string
+		pollInterval  time.Duration
+		metricsAddr   string
+		probeAddr     string
+		leaderElect   bool
+	)
+	flag.StringVar(&engineAddress, "engine", os.Getenv(engineEnvVar), "Engine address (host:port or URL); defaults to $"+engineEnvVar)
+	flag.DurationVar(&pollInterval, "poll-interval", 10*time.Second, "How often to poll the engine for the status of running runs")
+	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "Address the metrics endpoint binds to; 0 disables it")
+	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "Address the health probe endpoint binds to")
+	flag.BoolVar(&leaderElect, "leader-elect", false, "Enable leader election so only one replica reconciles at a time")
+	opts := zap.Options{}
+	opts.BindFlags(flag.CommandLine)
+	flag.Parse()
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+	logger := ctrl.Log.WithName("setup")
+
+	if engineAddress == "" {
+		logger.Error(nil, "no engine address; set --engine or "+engineEnvVar)
+		os.Exit(1)
+	}
+	// The token is read from ROCKETSHIP_TOKEN
+	engine, err := rocketship.New(engineAddress)
+	if err != nil {
+		logger.Error(err, "failed to create engine client")
+		os.Exit(1)
+	}
+
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+		Scheme:                 scheme,
+		Metrics:                metricsserver.Options{BindAddress: metricsAddr},
+		HealthProbeBindAddress: probeAddr,
+		LeaderElection:         leaderElect,
+		LeaderElectionID:       "operator.rocketship.sh",
+	})
+	if err != nil {
+		logger.Error(err, "failed to create manager")
+		os.Exit(1)
+	}
+
+	if err := (&controller.RunReconciler{
+		Client:       mgr.GetClient(),
+		Engine:       engine,
+		PollInterval: pollInterval,
+	}).SetupWithManager(mgr); err != nil {
+		logger.Error(err, "failed to set up controller", "controller", "rocketshiprun")
+		os.Exit(1)
+	}
+	if err := (&controller.SuiteReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		logger.Error(err, "failed to set up controller", "controller", "rocketshipsuite")
+		os.Exit(1)
+	}
+
+	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
+		logger.Error(err, "failed to set up health check")
+		os.Exit(1)
+	}
+	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+		logger.Error(err, "failed to set up ready check")
+		os.Exit(1)
+	}
+
+	logger.Info("starting manager", "engine", engineAddress)
+	err = mgr.Start(ctrl.SetupSignalHandler())
+	_ = engine.Close()
+	if err != nil {
+		logger.Error(err, "manager exited")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,342 @@
+// Package client is a Go client for the Rocketship engine. Other programs can use it
+// to start runs, follow their logs and read their results without shelling out to
+// the CLI.
+//
+//	c, err := client.New("https://rocketship.example.com")
+//	if err != nil {
+//		return err
+//	}
+//	defer c.Close()
+//
+//	runID, err := c.CreateRun(ctx, suiteYAML, &client.RunContext{Source: "ci-token", Branch: "main"})
+//	if err != nil {
+//		return err
+//	}
+//	run, err := c.WaitForRun(ctx, runID, 0)
+//
+// Calls that fail because the engine rejected them return an *Error, which can be
+// matched with errors.Is against ErrUnauthenticated, ErrNotFound and friends.
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/api/generated"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// TokenEnvVar is read for a bearer token when New is not given WithToken
+const TokenEnvVar = "ROCKETSHIP_TOKEN"
+
+const (
+	defaultPort         = "7700"
+	defaultTLSPort      = "443"
+	defaultPollInterval = 2 * time.Second
+)
+
+// Run statuses reported by the engine
+const (
+	StatusPending        = "PENDING"
+	StatusRunning        = "RUNNING"
+	StatusPassed         = "PASSED"
+	StatusFailed         = "FAILED"
+	StatusTimeout        = "TIMEOUT"
+	StatusCancelled      = "CANCELLED"
+	StatusBudgetExceeded = "BUDGET_EXCEEDED"
+)
+
+// Client talks to a Rocketship engine over gRPC. It is safe for concurrent use.
+type Client struct {
+	api  generated.EngineClient
+	conn *grpc.ClientConn
+}
+
+type options struct {
+	token       string
+	tokenSet    bool
+	tlsConfig   *tls.Config
+	retry       RetryPolicy
+	dialOptions []grpc.DialOption
+}
+
+// Option configures a Client
+type Option func(*options)
+
+// WithToken sets the bearer token sent with every call. An empty token disables
+// authentication, even when ROCKETSHIP_TOKEN is set.
+func WithToken(token string) Option {
+	return func(o *options) {
+		o.token = token
+		o.tokenSet = true
+	}
+}
+
+// WithTLSConfig connects over TLS with the given configuration. Addresses with an
+// https:// or grpcs:// scheme use TLS without it.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = config
+	}
+}
+
+// WithRetryPolicy replaces DefaultRetryPolicy
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *options) {
+		o.retry = policy
+	}
+}
+
+// WithDialOptions appends raw gRPC dial options, e.g. for tracing interceptors
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.dialOptions = append(o.dialOptions, opts...)
+	}
+}
+
+// New creates a client for the engine at address. The address is host[:port] or a
+// URL with an http, https, grpc or grpcs scheme. Without a port, https and grpcs
+// default to 443 and everything else to 7700. No connection is made until the
+// first call.
+func New(address string, opts ...Option) (*Client, error) {
+	o := options{retry: DefaultRetryPolicy}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if !o.tokenSet {
+		o.token = strings.TrimSpace(os.Getenv(TokenEnvVar))
+	}
+
+	target, useTLS, serverName, err := parseAddress(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid engine address %q: %w", address, err)
+	}
+
+	creds := insecure.NewCredentials()
+	switch {
+	case o.tlsConfig != nil:
+		creds = credentials.NewTLS(o.tlsConfig)
+	case useTLS:
+		creds = credentials.NewTLS(&tls.Config{ServerName: serverName})
+	}
+
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithChainUnaryInterceptor(newRetryInterceptor(o.retry)),
+	}
+	if o.token != "" {
+		dialOpts = append(dialOpts,
+			grpc.WithChainUnaryInterceptor(newTokenUnaryInterceptor(o.token)),
+			grpc.WithChainStreamInterceptor(newTokenStreamInterceptor(o.token)),
+		)
+	}
+	dialOpts = append(dialOpts, o.dialOptions...)
+
+	conn, err := grpc.NewClient(target, dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create engine client: %w", err)
+	}
+
+	return &Client{
+		api:  generated.NewEngineClient(conn),
+		conn: conn,
+	}, nil
+}
+
+// Close releases the underlying connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Health checks that the engine is up and reports itself healthy
+func (c *Client) Health(ctx context.Context) error {
+	resp, err := c.api.Health(ctx, &generated.HealthRequest{})
+	if err != nil {
+		return wrapError("Health", err)
+	}
+	if resp.GetStatus() != "ok" {
+		return &Error{Op: "Health", Code: codes.Unavailable, Message: fmt.Sprintf("engine reported status %q", resp.GetStatus())}
+	}
+	return nil
+}
+
+// CreateRun starts a run of the suite in yamlData and returns its ID. runCtx is
+// optional and records where the run came from.
+func (c *Client) CreateRun(ctx context.Context, yamlData []byte, runCtx *RunContext) (string, error) {
+	resp, err := c.api.CreateRun(ctx, &generated.CreateRunRequest{
+		YamlPayload: yamlData,
+		Context:     runCtx,
+	})
+	if err != nil {
+		return "", wrapError("CreateRun", err)
+	}
+	return resp.GetRunId(), nil
+}
+
+// GetRun returns a run with its tests
+func (c *Client) GetRun(ctx context.Context, runID string) (*RunDetails, error) {
+	resp, err := c.api.GetRun(ctx, &generated.GetRunRequest{RunId: runID})
+	if err != nil {
+		return nil, wrapError("GetRun", err)
+	}
+	return resp.GetRun(), nil
+}
+
+// ListRuns returns one page of runs matching req. Pass the response's NextCursor
+// as req.Cursor to fetch the next page.
+func (c *Client) ListRuns(ctx context.Context, req *ListRunsRequest) (*ListRunsResponse, error) {
+	if req == nil {
+		req = &ListRunsRequest{}
+	}
+	resp, err := c.api.ListRuns(ctx, req)
+	if err != nil {
+		return nil, wrapError("ListRuns", err)
+	}
+	return resp, nil
+}
+
+// CancelRun stops a running run
+func (c *Client) CancelRun(ctx context.Context, runID string) error {
+	resp, err := c.api.CancelRun(ctx, &generated.CancelRunRequest{RunId: runID})
+	if err != nil {
+		return wrapError("CancelRun", err)
+	}
+	if !resp.GetSuccess() {
+		return &Error{Op: "CancelRun", Code: codes.FailedPrecondition, Message: resp.GetMessage()}
+	}
+	return nil
+}
+
+// StreamLogs calls fn for each log line of a run until the run finishes, ctx is
+// done or fn returns an error, which StreamLogs then returns. Streams are not
+// retried; reconnecting replays the run's logs from the start.
+func (c *Client) StreamLogs(ctx context.Context, runID string, fn func(*LogLine) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := c.api.StreamLogs(ctx, &generated.LogStreamRequest{RunId: runID})
+	if err != nil {
+		return wrapError("StreamLogs", err)
+	}
+	for {
+		line, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return wrapError("StreamLogs", err)
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+}
+
+// WaitForRun polls a run until it finishes and returns its final state. A zero
+// interval polls every two seconds.
+func (c *Client) WaitForRun(ctx context.Context, runID string, interval time.Duration) (*RunDetails, error) {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		run, err := c.GetRun(ctx, runID)
+		if err != nil {
+			return nil, err
+		}
+		if IsFinished(run.GetStatus()) {
+			return run, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for run %s: %w", runID, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// IsFinished reports whether status is a final run status
+func IsFinished(status string) bool {
+	switch status {
+	case StatusPassed, StatusFailed, StatusTimeout, StatusCancelled, StatusBudgetExceeded:
+		return true
+	}
+	return false
+}
+
+func newTokenUnaryInterceptor(token string) grpc.UnaryClientInterceptor {
+	value := "Bearer " + token
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", value)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func newTokenStreamInterceptor(token string) grpc.StreamClientInterceptor {
+	value := "Bearer " + token
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", value)
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+// parseAddress returns the dial target, whether the scheme asks for TLS, and the
+// server name to verify when it does
+func parseAddress(address string) (string, bool, string, error) {
+	if strings.Contains(address, "://") {
+		u, err := url.Parse(address)
+		if err != nil {
+			return "", false, "", err
+		}
+		if u.Hostname() == "" {
+			return "", false, "", fmt.Errorf("missing host")
+		}
+		port := u.Port()
+		switch u.Scheme {
+		case "https", "grpcs":
+			if port == "" {
+				port = defaultTLSPort
+			}
+			return net.JoinHostPort(u.Hostname(), port), true, u.Hostname(), nil
+		case "http", "grpc":
+			if port == "" {
+				port = defaultPort
+			}
+			return net.JoinHostPort(u.Hostname(), port), false, "", nil
+		default:
+			return "", false, "", fmt.Errorf("unsupported scheme %q", u.Scheme)
+		}
+	}
+
+	if address == "" {
+		return "", false, "", fmt.Errorf("missing host")
+	}
+	if !strings.Contains(address, ":") {
+		return net.JoinHostPort(address, defaultPort), false, "", nil
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", false, "", err
+	}
+	if host == "" {
+		return "", false, "", fmt.Errorf("missing host")
+	}
+	if port == "" {
+		port = defaultPort
+	}
+	return net.JoinHostPort(host, port), false, "", nil
+}
@@ -0,0 +1,181 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rocketship-ai/rocketship/internal/api/generated"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type fakeEngine struct {
+	generated.UnimplementedEngineServer
+	createFailures int // CreateRun returns Unavailable this many times first
+	createCalls    int
+	authHeaders    []string
+	statuses       []string // GetRun returns these in order, then repeats the last
+	getCalls       int
+}
+
+func (f *fakeEngine) CreateRun(ctx context.Context, req *generated.CreateRunRequest) (*generated.CreateRunResponse, error) {
+	f.createCalls++
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		f.authHeaders = md.Get("authorization")
+	}
+	if f.createCalls <= f.createFailures {
+		return nil, status.Error(codes.Unavailable, "engine restarting")
+	}
+	if len(req.GetYamlPayload()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "yaml payload is required")
+	}
+	return &generated.CreateRunResponse{RunId: "run-1"}, nil
+}
+
+func (f *fakeEngine) GetRun(_ context.Context, req *generated.GetRunRequest) (*generated.GetRunResponse, error) {
+	if req.GetRunId() != "run-1" {
+		return nil, status.Errorf(codes.NotFound, "run not found: %s", req.GetRunId())
+	}
+	runStatus := f.statuses[min(f.getCalls, len(f.statuses)-1)]
+	f.getCalls++
+	return &generated.GetRunResponse{Run: &generated.RunDetails{RunId: "run-1", Status: runStatus}}, nil
+}
+
+func (f *fakeEngine) StreamLogs(_ *generated.LogStreamRequest, stream generated.Engine_StreamLogsServer) error {
+	for _, msg := range []string{"Starting test run", "Test run completed"} {
+		if err := stream.Send(&generated.LogLine{Msg: msg}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func startEngine(t *testing.T, engine *fakeEngine) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	s := grpc.NewServer()
+	generated.RegisterEngineServer(s, engine)
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+
+	return lis.Addr().String()
+}
+
+func TestCreateRunRetriesAndAuth(t *testing.T) {
+	engine := &fakeEngine{createFailures: 2}
+	c, err := New(startEngine(t, engine),
+		WithToken("secret"),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}),
+	)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer func() { _ = c.Close() }()
+
+	runID, err := c.CreateRun(context.Background(), []byte("name: suite"), nil)
+	if err != nil {
+		t.Fatalf("CreateRun: %v", err)
+	}
+	if runID != "run-1" || engine.createCalls != 3 {
+		t.Errorf("got run %q after %d calls", runID, engine.createCalls)
+	}
+	if len(engine.authHeaders) != 1 || engine.authHeaders[0] != "Bearer secret" {
+		t.Errorf("unexpected authorization headers: %v", engine.authHeaders)
+	}
+
+	_, err = c.CreateRun(context.Background(), nil, nil)
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Op != "CreateRun" || !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("expected an invalid argument *Error, got %v", err)
+	}
+	if engine.createCalls != 4 {
+		t.Errorf("invalid arguments should not be retried, got %d calls", engine.createCalls)
+	}
+}
+
+func TestRetriesExhausted(t *testing.T) {
+	engine := &fakeEngine{createFailures: 5}
+	c, err := New(startEngine(t, engine), WithToken(""), WithRetryPolicy(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer func() { _ = c.Close() }()
+
+	_, err = c.CreateRun(context.Background(), []byte("name: suite"), nil)
+	if !errors.Is(err, ErrUnavailable) || engine.createCalls != 2 {
+		t.Errorf("expected unavailable after 2 calls, got %v after %d", err, engine.createCalls)
+	}
+	if len(engine.authHeaders) != 0 {
+		t.Errorf("expected no authorization header, got %v", engine.authHeaders)
+	}
+}
+
+func TestWaitForRunAndLogs(t *testing.T) {
+	engine := &fakeEngine{statuses: []string{StatusPending, StatusRunning, StatusPassed}}
+	c, err := New(startEngine(t, engine), WithToken(""))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer func() { _ = c.Close() }()
+
+	var lines []string
+	err = c.StreamLogs(context.Background(), "run-1", func(line *LogLine) error {
+		lines = append(lines, line.GetMsg())
+		return nil
+	})
+	if err != nil || len(lines) != 2 {
+		t.Fatalf("StreamLogs: %v, lines %v", err, lines)
+	}
+
+	run, err := c.WaitForRun(context.Background(), "run-1", time.Millisecond)
+	if err != nil {
+		t.Fatalf("WaitForRun: %v", err)
+	}
+	if run.GetStatus() != StatusPassed || engine.getCalls != 3 {
+		t.Errorf("got status %s after %d polls", run.GetStatus(), engine.getCalls)
+	}
+
+	if _, err := c.GetRun(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		address    string
+		target     string
+		useTLS     bool
+		serverName string
+	}{
+		{"localhost", "localhost:7700", false, ""},
+		{"engine.internal:9000", "engine.internal:9000", false, ""},
+		{"https://rocketship.example.com", "rocketship.example.com:443", true, "rocketship.example.com"},
+		{"grpcs://rocketship.example.com:8443", "rocketship.example.com:8443", true, "rocketship.example.com"},
+		{"http://127.0.0.1", "127.0.0.1:7700", false, ""},
+	}
+	for _, tt := range tests {
+		target, useTLS, serverName, err := parseAddress(tt.address)
+		if err != nil {
+			t.Errorf("%s: %v", tt.address, err)
+			continue
+		}
+		if target != tt.target || useTLS != tt.useTLS || serverName != tt.serverName {
+			t.Errorf("%s: got (%s, %v, %s)", tt.address, target, useTLS, serverName)
+		}
+	}
+
+	for _, address := range []string{"", "ftp://host", ":7700"} {
+		if _, _, _, err := parseAddress(address); err == nil {
+			t.Errorf("expected %q to be rejected", address)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Errors matched by *Error through errors.Is
+var (
+	ErrUnauthenticated  = errors.New("engine requires a valid token")
+	ErrPermissionDenied = errors.New("permission denied")
+	ErrNotFound         = errors.New("not found")
+	ErrInvalidArgument  = errors.New("invalid argument")
+	ErrUnavailable      = errors.New("engine unavailable")
+)
+
+var codeErrors = map[codes.Code]error{
+	codes.Unauthenticated:  ErrUnauthenticated,
+	codes.PermissionDenied: ErrPermissionDenied,
+	codes.NotFound:         ErrNotFound,
+	codes.InvalidArgument:  ErrInvalidArgument,
+	codes.Unavailable:      ErrUnavailable,
+	codes.Canceled:         context.Canceled,
+	codes.DeadlineExceeded: context.DeadlineExceeded,
+}
+
+// Error is returned when the engine rejects a call or cannot be reached
+type Error struct {
+	Op      string     // Client method that failed, e.g. "CreateRun"
+	Code    codes.Code // gRPC status code
+	Message string     // Message from the engine
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("rocketship: %s failed (%s): %s", e.Op, e.Code, e.Message)
+}
+
+// Is matches the Err* value for the error's code. Canceled and DeadlineExceeded
+// match context.Canceled and context.DeadlineExceeded.
+func (e *Error) Is(target error) bool {
+	matched, ok := codeErrors[e.Code]
+	return ok && matched == target
+}
+
+func wrapError(op string, err error) error {
+	s, ok := status.FromError(err)
+	if !ok {
+		return fmt.Errorf("rocketship: %s failed: %w", op, err)
+	}
+	return &Error{Op: op, Code: s.Code(), Message: s.Message()}
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RetryPolicy controls how unary calls are retried when the engine is unavailable
+// or overloaded. Other failures are returned right away.
+type RetryPolicy struct {
+	MaxAttempts    int           // Total attempts including the first; 1 or less disables retries
+	InitialBackoff time.Duration // Wait before the first retry, doubled after each one
+	MaxBackoff     time.Duration // Upper bound for the wait between attempts
+}
+
+// DefaultRetryPolicy retries up to three times over about two seconds, which
+// covers an engine restart behind a load balancer
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    4,
+	InitialBackoff: 250 * time.Millisecond,
+	MaxBackoff:     2 * time.Second,
+}
+
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted:
+		return true
+	}
+	return false
+}
+
+func newRetryInterceptor(policy RetryPolicy) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		backoff := policy.InitialBackoff
+		for attempt := 1; ; attempt++ {
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil || attempt >= policy.MaxAttempts || !retryable(err) {
+				return err
+			}
+
+			timer := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+
+			backoff *= 2
+			if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+				backoff = policy.MaxBackoff
+			}
+		}
+	}
+}
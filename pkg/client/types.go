@@ -0,0 +1,22 @@
+package client
+
+import "github.com/rocketship-ai/rocketship/internal/api/generated"
+
+// Messages exchanged with the engine. They are the engine's protobuf types, so
+// use the generated getters (e.g. run.GetStatus()) to read them safely.
+type (
+	// RunContext records where a run came from (source, branch, commit, metadata)
+	RunContext = generated.RunContext
+	// RunDetails is a run with the status of each of its tests
+	RunDetails = generated.RunDetails
+	// TestDetails is the result of one test in a run
+	TestDetails = generated.TestDetails
+	// RunSummary is a run as returned by ListRuns
+	RunSummary = generated.RunSummary
+	// ListRunsRequest filters, orders and pages ListRuns
+	ListRunsRequest = generated.ListRunsRequest
+	// ListRunsResponse is one page of runs
+	ListRunsResponse = generated.ListRunsResponse
+	// LogLine is a line of run output delivered by StreamLogs
+	LogLine = generated.LogLine
+)
@@ -0,0 +1,224 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// apiClient calls the control plane REST API with a bearer token
+type apiClient struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+type apiError struct {
+	Status  int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("control plane request failed (%d): %s", e.Status, e.Message)
+}
+
+func isNotFound(err error) bool {
+	var apiErr *apiError
+	return errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound
+}
+
+type project struct {
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	RepoURL       string   `json:"repo_url"`
+	DefaultBranch string   `json:"default_branch"`
+	PathScope     []string `json:"path_scope"`
+	SourceRef     string   `json:"source_ref"`
+}
+
+type environment struct {
+	ID             string                 `json:"id"`
+	ProjectID      string                 `json:"project_id"`
+	Name           string                 `json:"name"`
+	Slug           string                 `json:"slug"`
+	Fallback       string                 `json:"fallback"`
+	EnvSecretsKeys []string               `json:"env_secrets_keys"`
+	ConfigVars     map[string]interface{} `json:"config_vars"`
+}
+
+// environmentRequest creates or updates an environment. On update, secrets are merged
+// into the stored ones (an empty value deletes a key) while config vars replace the
+// stored ones as a whole. An empty fallback removes it.
+type environmentRequest struct {
+	Name       string                 `json:"name"`
+	Slug       string                 `json:"slug"`
+	Fallback   string                 `json:"fallback"`
+	EnvSecrets map[string]string      `json:"env_secrets,omitempty"`
+	ConfigVars map[string]interface{} `json:"config_vars"`
+}
+
+type projectSchedule struct {
+	ID             string `json:"id"`
+	ProjectID      string `json:"project_id"`
+	EnvironmentID  string `json:"environment_id"`
+	Name           string `json:"name"`
+	CronExpression string `json:"cron_expression"`
+	Timezone       string `json:"timezone"`
+	Enabled        bool   `json:"enabled"`
+}
+
+type projectScheduleRequest struct {
+	EnvironmentID  string `json:"environment_id,omitempty"` // Only accepted on create
+	Name           string `json:"name"`
+	CronExpression string `json:"cron_expression"`
+	Timezone       string `json:"timezone"`
+	Enabled        bool   `json:"enabled"`
+}
+
+func (c *apiClient) listProjects(ctx context.Context) ([]project, error) {
+	var projects []project
+	cursor := ""
+	for {
+		path := "/api/projects?limit=500"
+		if cursor != "" {
+			path += "&cursor=" + url.QueryEscape(cursor)
+		}
+		var page struct {
+			Items      []project `json:"items"`
+			NextCursor string    `json:"next_cursor"`
+		}
+		if err := c.do(ctx, http.MethodGet, path, nil, &page); err != nil {
+			return nil, err
+		}
+		projects = append(projects, page.Items...)
+		if page.NextCursor == "" {
+			return projects, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+func (c *apiClient) getEnvironment(ctx context.Context, projectID, id string) (*environment, error) {
+	var env environment
+	if err := c.do(ctx, http.MethodGet, environmentPath(projectID, id), nil, &env); err != nil {
+		return nil, err
+	}
+	return &env, nil
+}
+
+func (c *apiClient) createEnvironment(ctx context.Context, projectID string, req environmentRequest) (*environment, error) {
+	var env environment
+	if err := c.do(ctx, http.MethodPost, environmentPath(projectID, ""), req, &env); err != nil {
+		return nil, err
+	}
+	return &env, nil
+}
+
+func (c *apiClient) updateEnvironment(ctx context.Context, projectID, id string, req environmentRequest) (*environment, error) {
+	var env environment
+	if err := c.do(ctx, http.MethodPut, environmentPath(projectID, id), req, &env); err != nil {
+		return nil, err
+	}
+	return &env, nil
+}
+
+func (c *apiClient) deleteEnvironment(ctx context.Context, projectID, id string) error {
+	return c.do(ctx, http.MethodDelete, environmentPath(projectID, id), nil, nil)
+}
+
+func (c *apiClient) getProjectSchedule(ctx context.Context, id string) (*projectSchedule, error) {
+	var schedule projectSchedule
+	if err := c.do(ctx, http.MethodGet, "/api/project-schedules/"+url.PathEscape(id), nil, &schedule); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (c *apiClient) createProjectSchedule(ctx context.Context, projectID string, req projectScheduleRequest) (*projectSchedule, error) {
+	var schedule projectSchedule
+	path := "/api/projects/" + url.PathEscape(projectID) + "/project-schedules"
+	if err := c.do(ctx, http.MethodPost, path, req, &schedule); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (c *apiClient) updateProjectSchedule(ctx context.Context, id string, req projectScheduleRequest) (*projectSchedule, error) {
+	var schedule projectSchedule
+	if err := c.do(ctx, http.MethodPut, "/api/project-schedules/"+url.PathEscape(id), req, &schedule); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (c *apiClient) deleteProjectSchedule(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/api/project-schedules/"+url.PathEscape(id), nil, nil)
+}
+
+func environmentPath(projectID, id string) string {
+	path := "/api/projects/" + url.PathEscape(projectID) + "/environments"
+	if id != "" {
+		path += "/" + url.PathEscape(id)
+	}
+	return path
+}
+
+func (c *apiClient) do(ctx context.Context, method, path string, payload, out interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("control plane request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return decodeError(resp)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s %s response: %w", method, path, err)
+	}
+	return nil
+}
+
+func decodeError(resp *http.Response) error {
+	buf, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	var payload map[string]string
+	if err := json.Unmarshal(buf, &payload); err == nil {
+		if msg := strings.TrimSpace(payload["error"]); msg != "" {
+			return &apiError{Status: resp.StatusCode, Message: msg}
+		}
+	}
+	msg := strings.TrimSpace(string(buf))
+	if msg == "" {
+		msg = http.StatusText(resp.StatusCode)
+	}
+	return &apiError{Status: resp.StatusCode, Message: msg}
+}
@@ -0,0 +1,219 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// environmentResource manages a project environment. The control plane never returns
+// secret values, so secrets are tracked by key: state keeps the configured values for
+// keys that still exist, and a key deleted outside Terraform shows up as a change.
+type environmentResource struct{}
+
+func (environmentResource) schema() *tfprotov6.Schema {
+	return &tfprotov6.Schema{
+		Block: &tfprotov6.SchemaBlock{
+			Description: "An environment of a Rocketship project. Runs started with `--env <slug>` get its secrets as `{{ .env.KEY }}` and its config vars as `{{ .vars.KEY }}`.",
+			Attributes: []*tfprotov6.SchemaAttribute{
+				{
+					Name:        "id",
+					Type:        tftypes.String,
+					Computed:    true,
+					Description: "Environment ID.",
+				},
+				{
+					Name:        "project_id",
+					Type:        tftypes.String,
+					Required:    true,
+					Description: "ID of the project the environment belongs to. Changing it replaces the environment.",
+				},
+				{
+					Name:        "name",
+					Type:        tftypes.String,
+					Required:    true,
+					Description: "Display name.",
+				},
+				{
+					Name:        "slug",
+					Type:        tftypes.String,
+					Required:    true,
+					Description: "Lowercase identifier passed to `rocketship run --env`. Unique within the project.",
+				},
+				{
+					Name:        "fallback",
+					Type:        tftypes.String,
+					Optional:    true,
+					Description: "Slug of another environment in the project. Runs get its secrets and config vars for keys this environment doesn't set, following its own fallback in turn.",
+				},
+				{
+					Name:        "config_vars",
+					Type:        stringMapType,
+					Optional:    true,
+					Description: "Non-secret variables. Terraform owns the full set: variables added in the console are removed on the next apply.",
+				},
+				{
+					Name:        "secrets",
+					Type:        stringMapType,
+					Optional:    true,
+					Sensitive:   true,
+					Description: "Secret values. Only the keys set here are managed; secrets added in the console are left alone.",
+				},
+			},
+		},
+	}
+}
+
+func (environmentResource) validate(config attributes) []*tfprotov6.Diagnostic {
+	var diags []*tfprotov6.Diagnostic
+	// The control plane stores the slug lowercased, which would not match the plan
+	if slug := config.string("slug"); slug != strings.ToLower(strings.TrimSpace(slug)) {
+		diags = append(diags, attributeDiagnostic("slug", "Invalid slug",
+			fmt.Sprintf("Slug %q must be lowercase without surrounding spaces.", slug)))
+	}
+	if fallback := config.string("fallback"); fallback != strings.ToLower(strings.TrimSpace(fallback)) {
+		diags = append(diags, attributeDiagnostic("fallback", "Invalid fallback",
+			fmt.Sprintf("Fallback %q must be lowercase without surrounding spaces.", fallback)))
+	}
+
+	// An empty value is how the control plane deletes a secret
+	var secrets map[string]tftypes.Value
+	if v, ok := config["secrets"]; ok && v.IsKnown() && !v.IsNull() {
+		_ = v.As(&secrets)
+	}
+	for _, key := range sortedKeys(secrets) {
+		var value string
+		if v := secrets[key]; !v.IsKnown() || v.IsNull() || v.As(&value) != nil || value != "" {
+			continue
+		}
+		diags = append(diags, &tfprotov6.Diagnostic{
+			Severity:  tfprotov6.DiagnosticSeverityError,
+			Summary:   "Empty secret",
+			Detail:    fmt.Sprintf("Secret %q has an empty value. Remove the key instead.", key),
+			Attribute: attributePath("secrets").WithElementKeyString(key),
+		})
+	}
+	return diags
+}
+
+func (environmentResource) defaults() attributes { return nil }
+
+func (environmentResource) replaceOn() []string { return []string{"project_id"} }
+
+func (environmentResource) create(ctx context.Context, c *apiClient, plan attributes) (attributes, error) {
+	env, err := c.createEnvironment(ctx, plan.string("project_id"), environmentRequest{
+		Name:       plan.string("name"),
+		Slug:       plan.string("slug"),
+		Fallback:   plan.string("fallback"),
+		EnvSecrets: plan.stringMap("secrets"),
+		ConfigVars: configVarsPayload(plan.stringMap("config_vars")),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return environmentState(env, plan), nil
+}
+
+func (environmentResource) read(ctx context.Context, c *apiClient, state attributes) (attributes, error) {
+	env, err := c.getEnvironment(ctx, state.string("project_id"), state.string("id"))
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	newState := environmentState(env, state)
+
+	// Keep the known values of secrets that still exist; the values themselves cannot be read back
+	if secrets := state.stringMap("secrets"); secrets != nil {
+		remaining := make(map[string]string, len(secrets))
+		for _, key := range env.EnvSecretsKeys {
+			if v, ok := secrets[key]; ok {
+				remaining[key] = v
+			}
+		}
+		newState["secrets"] = stringMapValue(remaining)
+	}
+	return newState, nil
+}
+
+func (environmentResource) update(ctx context.Context, c *apiClient, prior, plan attributes) (attributes, error) {
+	// Secrets are merged on update, so keys dropped from the configuration are deleted
+	// by sending them with an empty value
+	secrets := plan.stringMap("secrets")
+	for key := range prior.stringMap("secrets") {
+		if _, ok := secrets[key]; !ok {
+			if secrets == nil {
+				secrets = map[string]string{}
+			}
+			secrets[key] = ""
+		}
+	}
+
+	env, err := c.updateEnvironment(ctx, plan.string("project_id"), plan.string("id"), environmentRequest{
+		Name:       plan.string("name"),
+		Slug:       plan.string("slug"),
+		Fallback:   plan.string("fallback"),
+		EnvSecrets: secrets,
+		ConfigVars: configVarsPayload(plan.stringMap("config_vars")),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return environmentState(env, plan), nil
+}
+
+func (environmentResource) delete(ctx context.Context, c *apiClient, state attributes) error {
+	return c.deleteEnvironment(ctx, state.string("project_id"), state.string("id"))
+}
+
+func (environmentResource) importState(id string) (attributes, error) {
+	projectID, envID, ok := strings.Cut(id, "/")
+	if !ok || projectID == "" || envID == "" {
+		return nil, fmt.Errorf("expected <project_id>/<environment_id>, got %q", id)
+	}
+	return attributes{
+		"id":         stringValue(envID),
+		"project_id": stringValue(projectID),
+	}, nil
+}
+
+// environmentState builds state from the API response. Secrets come from base, the
+// plan or the prior state, since the response only lists their keys.
+func environmentState(env *environment, base attributes) attributes {
+	configVars := configVarStrings(env.ConfigVars)
+	configValue := stringMapValue(configVars)
+	// An empty map and an unset attribute are the same to the control plane
+	if len(configVars) == 0 && base.stringMap("config_vars") == nil {
+		configValue = stringMapValue(nil)
+	}
+
+	// No fallback reads back as "", which must stay null when it isn't configured
+	fallback := stringValue(env.Fallback)
+	if v, ok := base["fallback"]; env.Fallback == "" && (!ok || v.IsNull()) {
+		fallback = tftypes.NewValue(tftypes.String, nil)
+	}
+
+	return attributes{
+		"id":          stringValue(env.ID),
+		"project_id":  stringValue(env.ProjectID),
+		"name":        stringValue(env.Name),
+		"slug":        stringValue(env.Slug),
+		"fallback":    fallback,
+		"config_vars": configValue,
+		"secrets":     stringMapValue(base.stringMap("secrets")),
+	}
+}
+
+// configVarsPayload always returns a non-nil map so an update clears removed variables
+func configVarsPayload(vars map[string]string) map[string]interface{} {
+	payload := make(map[string]interface{}, len(vars))
+	for k, v := range vars {
+		payload[k] = v
+	}
+	return payload
+}
@@ -0,0 +1,99 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// projectDataSource looks up a project by name. Projects are created by connecting a
+// GitHub repository in the console, so they are read-only here.
+type projectDataSource struct{}
+
+func (projectDataSource) schema() *tfprotov6.Schema {
+	return &tfprotov6.Schema{
+		Block: &tfprotov6.SchemaBlock{
+			Description: "Looks up a project connected in the Rocketship console.",
+			Attributes: []*tfprotov6.SchemaAttribute{
+				{
+					Name:        "name",
+					Type:        tftypes.String,
+					Required:    true,
+					Description: "Project name.",
+				},
+				{
+					Name:        "source_ref",
+					Type:        tftypes.String,
+					Optional:    true,
+					Computed:    true,
+					Description: "Branch the project tracks. Required when several projects share the name.",
+				},
+				{
+					Name:        "id",
+					Type:        tftypes.String,
+					Computed:    true,
+					Description: "Project ID.",
+				},
+				{
+					Name:        "repo_url",
+					Type:        tftypes.String,
+					Computed:    true,
+					Description: "Repository URL.",
+				},
+				{
+					Name:        "default_branch",
+					Type:        tftypes.String,
+					Computed:    true,
+					Description: "Default branch of the repository.",
+				},
+				{
+					Name:        "path_scope",
+					Type:        tftypes.List{ElementType: tftypes.String},
+					Computed:    true,
+					Description: "Repository paths the project's tests are discovered in.",
+				},
+			},
+		},
+	}
+}
+
+func (projectDataSource) read(ctx context.Context, c *apiClient, config attributes) (attributes, error) {
+	projects, err := c.listProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	name := config.string("name")
+	sourceRef := config.string("source_ref")
+	var matches []project
+	for _, p := range projects {
+		if p.Name == name && (sourceRef == "" || p.SourceRef == sourceRef) {
+			matches = append(matches, p)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no project named %q is visible to this token", name)
+	case 1:
+	default:
+		refs := make([]string, len(matches))
+		for i, p := range matches {
+			refs[i] = p.SourceRef
+		}
+		return nil, fmt.Errorf("%d projects are named %q; set source_ref to one of %s", len(matches), name, strings.Join(refs, ", "))
+	}
+
+	p := matches[0]
+	return attributes{
+		"name":           stringValue(p.Name),
+		"source_ref":     stringValue(p.SourceRef),
+		"id":             stringValue(p.ID),
+		"repo_url":       stringValue(p.RepoURL),
+		"default_branch": stringValue(p.DefaultBranch),
+		"path_scope":     stringListValue(p.PathScope),
+	}, nil
+}
@@ -0,0 +1,505 @@
+// Package provider implements the Rocketship Terraform provider on the Terraform
+// plugin protocol (version 6). Resources map onto the control plane REST API: the
+// same endpoints the console uses to manage environments and schedules.
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// Environment variables read when the provider block leaves a setting out
+const (
+	URLEnvVar   = "ROCKETSHIP_CONTROL_PLANE_URL"
+	TokenEnvVar = "ROCKETSHIP_TOKEN"
+)
+
+// resource is a managed resource type backed by the control plane API
+type resource interface {
+	schema() *tfprotov6.Schema
+	// validate checks a configuration, which may still contain unknown values
+	validate(config attributes) []*tfprotov6.Diagnostic
+	// defaults returns the values the control plane uses for unset optional attributes
+	defaults() attributes
+	// replaceOn lists the attributes that cannot be changed in place
+	replaceOn() []string
+	create(ctx context.Context, c *apiClient, plan attributes) (attributes, error)
+	// read returns nil attributes when the object no longer exists
+	read(ctx context.Context, c *apiClient, state attributes) (attributes, error)
+	update(ctx context.Context, c *apiClient, prior, plan attributes) (attributes, error)
+	delete(ctx context.Context, c *apiClient, state attributes) error
+	// importState turns an import ID into the state the following read starts from
+	importState(id string) (attributes, error)
+}
+
+// dataSource is a read-only lookup against the control plane API
+type dataSource interface {
+	schema() *tfprotov6.Schema
+	read(ctx context.Context, c *apiClient, config attributes) (attributes, error)
+}
+
+type provider struct {
+	version     string
+	client      *apiClient
+	resources   map[string]resource
+	dataSources map[string]dataSource
+}
+
+// New returns the provider server for the given release version
+func New(version string) tfprotov6.ProviderServer {
+	return &provider{
+		version: version,
+		resources: map[string]resource{
+			"rocketship_environment":      environmentResource{},
+			"rocketship_project_schedule": projectScheduleResource{},
+		},
+		dataSources: map[string]dataSource{
+			"rocketship_project": projectDataSource{},
+		},
+	}
+}
+
+var providerSchema = &tfprotov6.Schema{
+	Block: &tfprotov6.SchemaBlock{
+		Attributes: []*tfprotov6.SchemaAttribute{
+			{
+				Name:        "url",
+				Type:        tftypes.String,
+				Optional:    true,
+				Description: "Control plane base URL, e.g. https://app.rocketship.sh. Defaults to the " + URLEnvVar + " environment variable.",
+			},
+			{
+				Name:        "token",
+				Type:        tftypes.String,
+				Optional:    true,
+				Sensitive:   true,
+				Description: "Access token for the control plane. Defaults to the " + TokenEnvVar + " environment variable.",
+			},
+		},
+	},
+}
+
+func (p *provider) GetMetadata(_ context.Context, _ *tfprotov6.GetMetadataRequest) (*tfprotov6.GetMetadataResponse, error) {
+	resp := &tfprotov6.GetMetadataResponse{
+		ServerCapabilities: &tfprotov6.ServerCapabilities{GetProviderSchemaOptional: true},
+	}
+	for name := range p.resources {
+		resp.Resources = append(resp.Resources, tfprotov6.ResourceMetadata{TypeName: name})
+	}
+	for name := range p.dataSources {
+		resp.DataSources = append(resp.DataSources, tfprotov6.DataSourceMetadata{TypeName: name})
+	}
+	return resp, nil
+}
+
+func (p *provider) GetProviderSchema(_ context.Context, _ *tfprotov6.GetProviderSchemaRequest) (*tfprotov6.GetProviderSchemaResponse, error) {
+	resp := &tfprotov6.GetProviderSchemaResponse{
+		ServerCapabilities: &tfprotov6.ServerCapabilities{GetProviderSchemaOptional: true},
+		Provider:           providerSchema,
+		ResourceSchemas:    make(map[string]*tfprotov6.Schema, len(p.resources)),
+		DataSourceSchemas:  make(map[string]*tfprotov6.Schema, len(p.dataSources)),
+	}
+	for name, r := range p.resources {
+		resp.ResourceSchemas[name] = r.schema()
+	}
+	for name, d := range p.dataSources {
+		resp.DataSourceSchemas[name] = d.schema()
+	}
+	return resp, nil
+}
+
+func (p *provider) GetResourceIdentitySchemas(_ context.Context, _ *tfprotov6.GetResourceIdentitySchemasRequest) (*tfprotov6.GetResourceIdentitySchemasResponse, error) {
+	return &tfprotov6.GetResourceIdentitySchemasResponse{
+		IdentitySchemas: map[string]*tfprotov6.ResourceIdentitySchema{},
+	}, nil
+}
+
+func (p *provider) ValidateProviderConfig(_ context.Context, req *tfprotov6.ValidateProviderConfigRequest) (*tfprotov6.ValidateProviderConfigResponse, error) {
+	return &tfprotov6.ValidateProviderConfigResponse{PreparedConfig: req.Config}, nil
+}
+
+func (p *provider) ConfigureProvider(_ context.Context, req *tfprotov6.ConfigureProviderRequest) (*tfprotov6.ConfigureProviderResponse, error) {
+	config, err := decodeObject(providerSchema, req.Config)
+	if err != nil {
+		return &tfprotov6.ConfigureProviderResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{errorDiagnostic("Invalid provider configuration", err)},
+		}, nil
+	}
+
+	baseURL := config.string("url")
+	if baseURL == "" {
+		baseURL = os.Getenv(URLEnvVar)
+	}
+	token := config.string("token")
+	if token == "" {
+		token = os.Getenv(TokenEnvVar)
+	}
+
+	var diags []*tfprotov6.Diagnostic
+	if baseURL == "" {
+		diags = append(diags, attributeDiagnostic("url", "Missing control plane URL",
+			"Set url in the provider block or the "+URLEnvVar+" environment variable."))
+	}
+	if token == "" {
+		diags = append(diags, attributeDiagnostic("token", "Missing access token",
+			"Set token in the provider block or the "+TokenEnvVar+" environment variable."))
+	}
+	if len(diags) > 0 {
+		return &tfprotov6.ConfigureProviderResponse{Diagnostics: diags}, nil
+	}
+
+	p.client = &apiClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+	return &tfprotov6.ConfigureProviderResponse{}, nil
+}
+
+func (p *provider) StopProvider(_ context.Context, _ *tfprotov6.StopProviderRequest) (*tfprotov6.StopProviderResponse, error) {
+	return &tfprotov6.StopProviderResponse{}, nil
+}
+
+func (p *provider) ValidateResourceConfig(_ context.Context, req *tfprotov6.ValidateResourceConfigRequest) (*tfprotov6.ValidateResourceConfigResponse, error) {
+	r, diags := p.resource(req.TypeName)
+	if r == nil {
+		return &tfprotov6.ValidateResourceConfigResponse{Diagnostics: diags}, nil
+	}
+	config, err := decodeObject(r.schema(), req.Config)
+	if err != nil {
+		return &tfprotov6.ValidateResourceConfigResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{errorDiagnostic("Invalid configuration", err)},
+		}, nil
+	}
+	return &tfprotov6.ValidateResourceConfigResponse{Diagnostics: r.validate(config)}, nil
+}
+
+func (p *provider) UpgradeResourceState(_ context.Context, req *tfprotov6.UpgradeResourceStateRequest) (*tfprotov6.UpgradeResourceStateResponse, error) {
+	r, diags := p.resource(req.TypeName)
+	if r == nil {
+		return &tfprotov6.UpgradeResourceStateResponse{Diagnostics: diags}, nil
+	}
+
+	// There is a single schema version, so upgrading only drops attributes that no longer exist
+	typ := r.schema().ValueType()
+	value, err := req.RawState.UnmarshalWithOpts(typ, tfprotov6.UnmarshalOpts{
+		ValueFromJSONOpts: tftypes.ValueFromJSONOpts{IgnoreUndefinedAttributes: true},
+	})
+	if err != nil {
+		return &tfprotov6.UpgradeResourceStateResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{errorDiagnostic("Failed to read stored state", err)},
+		}, nil
+	}
+	upgraded, err := tfprotov6.NewDynamicValue(typ, value)
+	if err != nil {
+		return &tfprotov6.UpgradeResourceStateResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{errorDiagnostic("Failed to encode state", err)},
+		}, nil
+	}
+	return &tfprotov6.UpgradeResourceStateResponse{UpgradedState: &upgraded}, nil
+}
+
+func (p *provider) UpgradeResourceIdentity(_ context.Context, req *tfprotov6.UpgradeResourceIdentityRequest) (*tfprotov6.UpgradeResourceIdentityResponse, error) {
+	return &tfprotov6.UpgradeResourceIdentityResponse{
+		Diagnostics: []*tfprotov6.Diagnostic{unsupported("Resource identity", req.TypeName)},
+	}, nil
+}
+
+func (p *provider) ReadResource(ctx context.Context, req *tfprotov6.ReadResourceRequest) (*tfprotov6.ReadResourceResponse, error) {
+	r, diags := p.configuredResource(req.TypeName)
+	if r == nil {
+		return &tfprotov6.ReadResourceResponse{Diagnostics: diags}, nil
+	}
+	s := r.schema()
+
+	state, err := decodeObject(s, req.CurrentState)
+	if err != nil {
+		return &tfprotov6.ReadResourceResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{errorDiagnostic("Invalid state", err)},
+		}, nil
+	}
+	if state == nil {
+		return &tfprotov6.ReadResourceResponse{NewState: req.CurrentState}, nil
+	}
+
+	// A nil result removes the resource from state so the next plan recreates it
+	newState, err := r.read(ctx, p.client, state)
+	if err != nil {
+		return &tfprotov6.ReadResourceResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{errorDiagnostic("Failed to read "+req.TypeName, err)},
+		}, nil
+	}
+	encoded, err := encodeObject(s, newState)
+	if err != nil {
+		return &tfprotov6.ReadResourceResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{errorDiagnostic("Failed to encode state", err)},
+		}, nil
+	}
+	return &tfprotov6.ReadResourceResponse{NewState: encoded, Private: req.Private}, nil
+}
+
+func (p *provider) PlanResourceChange(_ context.Context, req *tfprotov6.PlanResourceChangeRequest) (*tfprotov6.PlanResourceChangeResponse, error) {
+	r, diags := p.resource(req.TypeName)
+	if r == nil {
+		return &tfprotov6.PlanResourceChangeResponse{Diagnostics: diags}, nil
+	}
+	s := r.schema()
+
+	proposed, err := decodeObject(s, req.ProposedNewState)
+	if err != nil {
+		return &tfprotov6.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{errorDiagnostic("Invalid proposed state", err)},
+		}, nil
+	}
+	if proposed == nil {
+		// Destroy
+		return &tfprotov6.PlanResourceChangeResponse{PlannedState: req.ProposedNewState}, nil
+	}
+	prior, err := decodeObject(s, req.PriorState)
+	if err != nil {
+		return &tfprotov6.PlanResourceChangeResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{errorDiagnostic("Invalid prior state", err)},
+		}, nil
+	}
+
+	// Terraform proposes prior values for computed attributes left out of the
+	// configuration, so only a create has computed values left to fill in
+	planned := make(attributes, len(proposed))
+	for name, v := range proposed {
+		planned[name] = v
+	}
+	if prior == nil {
+		defaults := r.defaults()
+		for _, attr := range s.Block.Attributes {
+			if !attr.Computed || !planned[attr.Name].IsNull() {
+				continue
+			}
+			if v, ok := defaults[attr.Name]; ok {
+				planned[attr.Name] = v
+			} else {
+				planned[attr.Name] = tftypes.NewValue(attr.ValueType(), tftypes.UnknownValue)
+			}
+		}
+	}
+
+	resp := &tfprotov6.PlanResourceChangeResponse{PlannedPrivate: req.PriorPrivate}
+	if prior != nil {
+		for _, name := range r.replaceOn() {
+			if !planned[name].Equal(prior[name]) {
+				resp.RequiresReplace = append(resp.RequiresReplace, attributePath(name))
+			}
+		}
+	}
+
+	resp.PlannedState, err = encodeObject(s, planned)
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, errorDiagnostic("Failed to encode plan", err))
+	}
+	return resp, nil
+}
+
+func (p *provider) ApplyResourceChange(ctx context.Context, req *tfprotov6.ApplyResourceChangeRequest) (*tfprotov6.ApplyResourceChangeResponse, error) {
+	r, diags := p.configuredResource(req.TypeName)
+	if r == nil {
+		return &tfprotov6.ApplyResourceChangeResponse{Diagnostics: diags}, nil
+	}
+	s := r.schema()
+
+	prior, err := decodeObject(s, req.PriorState)
+	if err != nil {
+		return &tfprotov6.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{errorDiagnostic("Invalid prior state", err)},
+		}, nil
+	}
+	planned, err := decodeObject(s, req.PlannedState)
+	if err != nil {
+		return &tfprotov6.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{errorDiagnostic("Invalid planned state", err)},
+		}, nil
+	}
+
+	var newState attributes
+	switch {
+	case planned == nil:
+		if err := r.delete(ctx, p.client, prior); err != nil && !isNotFound(err) {
+			return &tfprotov6.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov6.Diagnostic{errorDiagnostic("Failed to delete "+req.TypeName, err)},
+			}, nil
+		}
+	case prior == nil:
+		newState, err = r.create(ctx, p.client, planned)
+		if err != nil {
+			return &tfprotov6.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov6.Diagnostic{errorDiagnostic("Failed to create "+req.TypeName, err)},
+			}, nil
+		}
+	default:
+		newState, err = r.update(ctx, p.client, prior, planned)
+		if err != nil {
+			return &tfprotov6.ApplyResourceChangeResponse{
+				Diagnostics: []*tfprotov6.Diagnostic{errorDiagnostic("Failed to update "+req.TypeName, err)},
+			}, nil
+		}
+	}
+
+	encoded, err := encodeObject(s, newState)
+	if err != nil {
+		return &tfprotov6.ApplyResourceChangeResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{errorDiagnostic("Failed to encode state", err)},
+		}, nil
+	}
+	return &tfprotov6.ApplyResourceChangeResponse{NewState: encoded, Private: req.PlannedPrivate}, nil
+}
+
+func (p *provider) ImportResourceState(_ context.Context, req *tfprotov6.ImportResourceStateRequest) (*tfprotov6.ImportResourceStateResponse, error) {
+	r, diags := p.resource(req.TypeName)
+	if r == nil {
+		return &tfprotov6.ImportResourceStateResponse{Diagnostics: diags}, nil
+	}
+
+	state, err := r.importState(req.ID)
+	if err != nil {
+		return &tfprotov6.ImportResourceStateResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{errorDiagnostic("Invalid import ID", err)},
+		}, nil
+	}
+	encoded, err := encodeObject(r.schema(), state)
+	if err != nil {
+		return &tfprotov6.ImportResourceStateResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{errorDiagnostic("Failed to encode state", err)},
+		}, nil
+	}
+	return &tfprotov6.ImportResourceStateResponse{
+		ImportedResources: []*tfprotov6.ImportedResource{{TypeName: req.TypeName, State: encoded}},
+	}, nil
+}
+
+func (p *provider) MoveResourceState(_ context.Context, req *tfprotov6.MoveResourceStateRequest) (*tfprotov6.MoveResourceStateResponse, error) {
+	return &tfprotov6.MoveResourceStateResponse{
+		Diagnostics: []*tfprotov6.Diagnostic{unsupported("Moving state", req.TargetTypeName)},
+	}, nil
+}
+
+func (p *provider) ValidateDataResourceConfig(_ context.Context, req *tfprotov6.ValidateDataResourceConfigRequest) (*tfprotov6.ValidateDataResourceConfigResponse, error) {
+	if _, ok := p.dataSources[req.TypeName]; !ok {
+		return &tfprotov6.ValidateDataResourceConfigResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{unknownType("data source", req.TypeName)},
+		}, nil
+	}
+	return &tfprotov6.ValidateDataResourceConfigResponse{}, nil
+}
+
+func (p *provider) ReadDataSource(ctx context.Context, req *tfprotov6.ReadDataSourceRequest) (*tfprotov6.ReadDataSourceResponse, error) {
+	d, ok := p.dataSources[req.TypeName]
+	if !ok {
+		return &tfprotov6.ReadDataSourceResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{unknownType("data source", req.TypeName)},
+		}, nil
+	}
+	if p.client == nil {
+		return &tfprotov6.ReadDataSourceResponse{Diagnostics: []*tfprotov6.Diagnostic{notConfigured()}}, nil
+	}
+	s := d.schema()
+
+	config, err := decodeObject(s, req.Config)
+	if err != nil {
+		return &tfprotov6.ReadDataSourceResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{errorDiagnostic("Invalid configuration", err)},
+		}, nil
+	}
+	state, err := d.read(ctx, p.client, config)
+	if err != nil {
+		return &tfprotov6.ReadDataSourceResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{errorDiagnostic("Failed to read "+req.TypeName, err)},
+		}, nil
+	}
+	encoded, err := encodeObject(s, state)
+	if err != nil {
+		return &tfprotov6.ReadDataSourceResponse{
+			Diagnostics: []*tfprotov6.Diagnostic{errorDiagnostic("Failed to encode state", err)},
+		}, nil
+	}
+	return &tfprotov6.ReadDataSourceResponse{State: encoded}, nil
+}
+
+func (p *provider) GetFunctions(_ context.Context, _ *tfprotov6.GetFunctionsRequest) (*tfprotov6.GetFunctionsResponse, error) {
+	return &tfprotov6.GetFunctionsResponse{Functions: map[string]*tfprotov6.Function{}}, nil
+}
+
+func (p *provider) CallFunction(_ context.Context, req *tfprotov6.CallFunctionRequest) (*tfprotov6.CallFunctionResponse, error) {
+	return &tfprotov6.CallFunctionResponse{
+		Error: &tfprotov6.FunctionError{Text: fmt.Sprintf("unknown function %q", req.Name)},
+	}, nil
+}
+
+func (p *provider) ValidateEphemeralResourceConfig(_ context.Context, req *tfprotov6.ValidateEphemeralResourceConfigRequest) (*tfprotov6.ValidateEphemeralResourceConfigResponse, error) {
+	return &tfprotov6.ValidateEphemeralResourceConfigResponse{
+		Diagnostics: []*tfprotov6.Diagnostic{unknownType("ephemeral resource", req.TypeName)},
+	}, nil
+}
+
+func (p *provider) OpenEphemeralResource(_ context.Context, req *tfprotov6.OpenEphemeralResourceRequest) (*tfprotov6.OpenEphemeralResourceResponse, error) {
+	return &tfprotov6.OpenEphemeralResourceResponse{
+		Diagnostics: []*tfprotov6.Diagnostic{unknownType("ephemeral resource", req.TypeName)},
+	}, nil
+}
+
+func (p *provider) RenewEphemeralResource(_ context.Context, req *tfprotov6.RenewEphemeralResourceRequest) (*tfprotov6.RenewEphemeralResourceResponse, error) {
+	return &tfprotov6.RenewEphemeralResourceResponse{
+		Diagnostics: []*tfprotov6.Diagnostic{unknownType("ephemeral resource", req.TypeName)},
+	}, nil
+}
+
+func (p *provider) CloseEphemeralResource(_ context.Context, req *tfprotov6.CloseEphemeralResourceRequest) (*tfprotov6.CloseEphemeralResourceResponse, error) {
+	return &tfprotov6.CloseEphemeralResourceResponse{
+		Diagnostics: []*tfprotov6.Diagnostic{unknownType("ephemeral resource", req.TypeName)},
+	}, nil
+}
+
+func (p *provider) resource(typeName string) (resource, []*tfprotov6.Diagnostic) {
+	r, ok := p.resources[typeName]
+	if !ok {
+		return nil, []*tfprotov6.Diagnostic{unknownType("resource", typeName)}
+	}
+	return r, nil
+}
+
+// configuredResource is resource for the calls that reach the control plane
+func (p *provider) configuredResource(typeName string) (resource, []*tfprotov6.Diagnostic) {
+	r, diags := p.resource(typeName)
+	if r != nil && p.client == nil {
+		return nil, []*tfprotov6.Diagnostic{notConfigured()}
+	}
+	return r, diags
+}
+
+func unknownType(kind, typeName string) *tfprotov6.Diagnostic {
+	return &tfprotov6.Diagnostic{
+		Severity: tfprotov6.DiagnosticSeverityError,
+		Summary:  "Unknown " + kind,
+		Detail:   fmt.Sprintf("The rocketship provider has no %s named %q.", kind, typeName),
+	}
+}
+
+func unsupported(feature, typeName string) *tfprotov6.Diagnostic {
+	return &tfprotov6.Diagnostic{
+		Severity: tfprotov6.DiagnosticSeverityError,
+		Summary:  feature + " is not supported",
+		Detail:   fmt.Sprintf("%s is not supported for %s.", feature, typeName),
+	}
+}
+
+func notConfigured() *tfprotov6.Diagnostic {
+	return &tfprotov6.Diagnostic{
+		Severity: tfprotov6.DiagnosticSeverityError,
+		Summary:  "Provider not configured",
+		Detail:   "The rocketship provider was used before it was configured.",
+	}
+}
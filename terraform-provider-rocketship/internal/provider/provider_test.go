@@ -0,0 +1,452 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// fakeControlPlane implements the environment, schedule and project endpoints the
+// provider uses, with the same merge and replace rules as the real handlers
+type fakeControlPlane struct {
+	mu        sync.Mutex
+	nextID    int
+	projects  []project
+	envs      map[string]*fakeEnvironment
+	schedules map[string]*projectSchedule
+}
+
+type fakeEnvironment struct {
+	environment
+	secrets map[string]string
+}
+
+func newFakeControlPlane(t *testing.T) (*fakeControlPlane, *httptest.Server) {
+	t.Helper()
+	f := &fakeControlPlane{
+		envs:      map[string]*fakeEnvironment{},
+		schedules: map[string]*projectSchedule{},
+	}
+	server := httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(server.Close)
+	return f, server
+}
+
+func (f *fakeControlPlane) id(prefix string) string {
+	f.nextID++
+	return fmt.Sprintf("%s-%d", prefix, f.nextID)
+}
+
+func (f *fakeControlPlane) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer test-token" {
+		writeTestJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid token"})
+		return
+	}
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.URL.Path == "/api/projects":
+		// One project per page, so listing has to follow the cursor
+		start, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+		page := map[string]interface{}{"items": []project{}, "total_count": len(f.projects)}
+		if start < len(f.projects) {
+			page["items"] = f.projects[start : start+1]
+		}
+		if start+1 < len(f.projects) {
+			page["next_cursor"] = strconv.Itoa(start + 1)
+		}
+		writeTestJSON(w, http.StatusOK, page)
+	case len(segments) >= 4 && segments[0] == "api" && segments[1] == "projects" && segments[3] == "environments":
+		f.serveEnvironments(w, r, segments[2], segments[4:])
+	case len(segments) == 4 && segments[1] == "projects" && segments[3] == "project-schedules" && r.Method == http.MethodPost:
+		var req projectScheduleRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Timezone == "" {
+			req.Timezone = "UTC"
+		}
+		s := &projectSchedule{ID: f.id("sched"), ProjectID: segments[2], EnvironmentID: req.EnvironmentID,
+			Name: req.Name, CronExpression: req.CronExpression, Timezone: req.Timezone, Enabled: req.Enabled}
+		f.schedules[s.ID] = s
+		writeTestJSON(w, http.StatusCreated, s)
+	case len(segments) == 3 && segments[1] == "project-schedules":
+		s, ok := f.schedules[segments[2]]
+		if !ok {
+			writeTestJSON(w, http.StatusNotFound, map[string]string{"error": "schedule not found"})
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			writeTestJSON(w, http.StatusOK, s)
+		case http.MethodPut:
+			var req projectScheduleRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			s.Name, s.CronExpression, s.Timezone, s.Enabled = req.Name, req.CronExpression, req.Timezone, req.Enabled
+			writeTestJSON(w, http.StatusOK, s)
+		case http.MethodDelete:
+			delete(f.schedules, s.ID)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	default:
+		writeTestJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+	}
+}
+
+func (f *fakeControlPlane) serveEnvironments(w http.ResponseWriter, r *http.Request, projectID string, rest []string) {
+	if len(rest) == 0 {
+		var req environmentRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		env := &fakeEnvironment{
+			environment: environment{ID: f.id("env"), ProjectID: projectID, Name: req.Name, Slug: req.Slug, Fallback: req.Fallback, ConfigVars: req.ConfigVars},
+			secrets:     req.EnvSecrets,
+		}
+		f.envs[env.ID] = env
+		writeTestJSON(w, http.StatusCreated, env.response())
+		return
+	}
+
+	env, ok := f.envs[rest[0]]
+	if !ok || env.ProjectID != projectID {
+		writeTestJSON(w, http.StatusNotFound, map[string]string{"error": "environment not found"})
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeTestJSON(w, http.StatusOK, env.response())
+	case http.MethodPut:
+		var req environmentRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		env.Name, env.Slug, env.Fallback = req.Name, req.Slug, req.Fallback
+		if env.secrets == nil {
+			env.secrets = map[string]string{}
+		}
+		for k, v := range req.EnvSecrets {
+			if v == "" {
+				delete(env.secrets, k)
+			} else {
+				env.secrets[k] = v
+			}
+		}
+		if req.ConfigVars != nil {
+			env.ConfigVars = req.ConfigVars
+		}
+		writeTestJSON(w, http.StatusOK, env.response())
+	case http.MethodDelete:
+		delete(f.envs, env.ID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (e *fakeEnvironment) response() environment {
+	resp := e.environment
+	resp.EnvSecretsKeys = sortedKeys(e.secrets)
+	if resp.ConfigVars == nil {
+		resp.ConfigVars = map[string]interface{}{}
+	}
+	return resp
+}
+
+func writeTestJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func configuredProvider(t *testing.T, serverURL string) *provider {
+	t.Helper()
+	p := New("test").(*provider)
+	config := encode(t, providerSchema, attributes{"url": stringValue(serverURL + "/"), "token": stringValue("test-token")})
+	resp, err := p.ConfigureProvider(context.Background(), &tfprotov6.ConfigureProviderRequest{Config: config})
+	if err != nil || len(resp.Diagnostics) > 0 {
+		t.Fatalf("ConfigureProvider: %v %v", err, diagnostics(resp.Diagnostics))
+	}
+	return p
+}
+
+func encode(t *testing.T, schema *tfprotov6.Schema, attrs attributes) *tfprotov6.DynamicValue {
+	t.Helper()
+	dv, err := encodeObject(schema, attrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dv
+}
+
+func decode(t *testing.T, schema *tfprotov6.Schema, dv *tfprotov6.DynamicValue) attributes {
+	t.Helper()
+	attrs, err := decodeObject(schema, dv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return attrs
+}
+
+func diagnostics(diags []*tfprotov6.Diagnostic) string {
+	var parts []string
+	for _, d := range diags {
+		parts = append(parts, d.Summary+": "+d.Detail)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// plan runs PlanResourceChange the way Terraform does, proposing prior values for
+// computed attributes the configuration leaves unset
+func plan(t *testing.T, p *provider, typeName string, prior, config attributes) (attributes, *tfprotov6.PlanResourceChangeResponse) {
+	t.Helper()
+	schema := p.resources[typeName].schema()
+	proposed := attributes{}
+	for name, v := range config {
+		proposed[name] = v
+	}
+	for _, attr := range schema.Block.Attributes {
+		if v, ok := proposed[attr.Name]; attr.Computed && (!ok || v.IsNull()) && prior != nil {
+			proposed[attr.Name] = prior[attr.Name]
+		}
+	}
+	resp, err := p.PlanResourceChange(context.Background(), &tfprotov6.PlanResourceChangeRequest{
+		TypeName:         typeName,
+		PriorState:       encode(t, schema, prior),
+		ProposedNewState: encode(t, schema, proposed),
+		Config:           encode(t, schema, config),
+	})
+	if err != nil || len(resp.Diagnostics) > 0 {
+		t.Fatalf("PlanResourceChange: %v %v", err, diagnostics(resp.Diagnostics))
+	}
+	return decode(t, schema, resp.PlannedState), resp
+}
+
+func apply(t *testing.T, p *provider, typeName string, prior, planned attributes) attributes {
+	t.Helper()
+	schema := p.resources[typeName].schema()
+	resp, err := p.ApplyResourceChange(context.Background(), &tfprotov6.ApplyResourceChangeRequest{
+		TypeName:     typeName,
+		PriorState:   encode(t, schema, prior),
+		PlannedState: encode(t, schema, planned),
+	})
+	if err != nil || len(resp.Diagnostics) > 0 {
+		t.Fatalf("ApplyResourceChange: %v %v", err, diagnostics(resp.Diagnostics))
+	}
+	return decode(t, schema, resp.NewState)
+}
+
+func read(t *testing.T, p *provider, typeName string, state attributes) attributes {
+	t.Helper()
+	schema := p.resources[typeName].schema()
+	resp, err := p.ReadResource(context.Background(), &tfprotov6.ReadResourceRequest{
+		TypeName:     typeName,
+		CurrentState: encode(t, schema, state),
+	})
+	if err != nil || len(resp.Diagnostics) > 0 {
+		t.Fatalf("ReadResource: %v %v", err, diagnostics(resp.Diagnostics))
+	}
+	return decode(t, schema, resp.NewState)
+}
+
+func TestEnvironmentLifecycle(t *testing.T) {
+	fake, server := newFakeControlPlane(t)
+	p := configuredProvider(t, server.URL)
+	const typeName = "rocketship_environment"
+
+	config := attributes{
+		"project_id":  stringValue("proj-1"),
+		"name":        stringValue("Staging"),
+		"slug":        stringValue("staging"),
+		"config_vars": stringMapValue(map[string]string{"base_url": "https://staging.example.com"}),
+		"secrets":     stringMapValue(map[string]string{"API_KEY": "k1", "DB_PASSWORD": "p1"}),
+	}
+	planned, _ := plan(t, p, typeName, nil, config)
+	if planned["id"].IsKnown() {
+		t.Fatalf("expected an unknown id on create, got %v", planned["id"])
+	}
+	state := apply(t, p, typeName, nil, planned)
+	id := state.string("id")
+	if fake.envs[id] == nil || fake.envs[id].secrets["DB_PASSWORD"] != "p1" {
+		t.Fatalf("environment not created as planned: %+v", fake.envs)
+	}
+
+	// A secret deleted in the console drops out of state; one added there is ignored
+	delete(fake.envs[id].secrets, "DB_PASSWORD")
+	fake.envs[id].secrets["CONSOLE_ONLY"] = "c"
+	state = read(t, p, typeName, state)
+	if got := state.stringMap("secrets"); len(got) != 1 || got["API_KEY"] != "k1" {
+		t.Errorf("unexpected secrets after read: %v", got)
+	}
+
+	if !state["fallback"].IsNull() {
+		t.Errorf("expected an unset fallback to stay null, got %v", state["fallback"])
+	}
+
+	config["name"] = stringValue("Staging EU")
+	config["fallback"] = stringValue("default")
+	config["config_vars"] = stringMapValue(nil)
+	config["secrets"] = stringMapValue(map[string]string{"DB_PASSWORD": "p2"})
+	planned, resp := plan(t, p, typeName, state, config)
+	if len(resp.RequiresReplace) > 0 {
+		t.Fatalf("expected an in-place update, got replacements %v", resp.RequiresReplace)
+	}
+	state = apply(t, p, typeName, state, planned)
+
+	env := fake.envs[id]
+	if env.Name != "Staging EU" || env.Fallback != "default" || len(env.ConfigVars) != 0 {
+		t.Errorf("unexpected environment after update: %+v", env.environment)
+	}
+	if len(env.secrets) != 2 || env.secrets["DB_PASSWORD"] != "p2" || env.secrets["CONSOLE_ONLY"] != "c" {
+		t.Errorf("expected API_KEY removed and DB_PASSWORD set, got %v", env.secrets)
+	}
+	if !state["config_vars"].IsNull() {
+		t.Errorf("expected config_vars to stay null, got %v", state["config_vars"])
+	}
+
+	config["project_id"] = stringValue("proj-2")
+	_, resp = plan(t, p, typeName, state, config)
+	if len(resp.RequiresReplace) != 1 || !resp.RequiresReplace[0].Equal(attributePath("project_id")) {
+		t.Errorf("expected project_id to require replacement, got %v", resp.RequiresReplace)
+	}
+
+	if apply(t, p, typeName, state, nil) != nil || fake.envs[id] != nil {
+		t.Errorf("expected the environment to be deleted")
+	}
+	if read(t, p, typeName, state) != nil {
+		t.Errorf("expected a deleted environment to read as gone")
+	}
+}
+
+func TestProjectScheduleDefaultsAndImport(t *testing.T) {
+	fake, server := newFakeControlPlane(t)
+	p := configuredProvider(t, server.URL)
+	const typeName = "rocketship_project_schedule"
+
+	planned, _ := plan(t, p, typeName, nil, attributes{
+		"project_id":      stringValue("proj-1"),
+		"environment_id":  stringValue("env-9"),
+		"name":            stringValue("Nightly"),
+		"cron_expression": stringValue("0 3 * * *"),
+	})
+	if planned.string("timezone") != "UTC" || !planned.bool("enabled") {
+		t.Fatalf("expected planned defaults, got %v %v", planned["timezone"], planned["enabled"])
+	}
+	state := apply(t, p, typeName, nil, planned)
+	id := state.string("id")
+
+	resp, err := p.ImportResourceState(context.Background(), &tfprotov6.ImportResourceStateRequest{TypeName: typeName, ID: id})
+	if err != nil || len(resp.Diagnostics) > 0 {
+		t.Fatalf("ImportResourceState: %v %v", err, diagnostics(resp.Diagnostics))
+	}
+	imported := read(t, p, typeName, decode(t, p.resources[typeName].schema(), resp.ImportedResources[0].State))
+	for name, v := range state {
+		if !imported[name].Equal(v) {
+			t.Errorf("imported %s = %v, want %v", name, imported[name], v)
+		}
+	}
+
+	fake.schedules[id].Enabled = false
+	config := attributes{
+		"project_id":      stringValue("proj-1"),
+		"environment_id":  stringValue("env-9"),
+		"name":            stringValue("Nightly"),
+		"cron_expression": stringValue("0 4 * * *"),
+		"enabled":         boolValue(true),
+	}
+	state = read(t, p, typeName, state)
+	planned, _ = plan(t, p, typeName, state, config)
+	apply(t, p, typeName, state, planned)
+	if s := fake.schedules[id]; s.CronExpression != "0 4 * * *" || !s.Enabled || s.Timezone != "UTC" {
+		t.Errorf("unexpected schedule after update: %+v", s)
+	}
+}
+
+func TestProjectDataSource(t *testing.T) {
+	fake, server := newFakeControlPlane(t)
+	fake.projects = []project{
+		{ID: "p-main", Name: "checkout", RepoURL: "https://github.com/acme/shop", DefaultBranch: "main", SourceRef: "main", PathScope: []string{".rocketship"}},
+		{ID: "p-next", Name: "checkout", RepoURL: "https://github.com/acme/shop", DefaultBranch: "main", SourceRef: "next"},
+		{ID: "p-other", Name: "billing", SourceRef: "main"},
+	}
+	p := configuredProvider(t, server.URL)
+	schema := projectDataSource{}.schema()
+
+	readProject := func(config attributes) (*tfprotov6.ReadDataSourceResponse, attributes) {
+		resp, err := p.ReadDataSource(context.Background(), &tfprotov6.ReadDataSourceRequest{
+			TypeName: "rocketship_project",
+			Config:   encode(t, schema, config),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Diagnostics) > 0 {
+			return resp, nil
+		}
+		return resp, decode(t, schema, resp.State)
+	}
+
+	resp, _ := readProject(attributes{"name": stringValue("checkout")})
+	if len(resp.Diagnostics) != 1 || !strings.Contains(resp.Diagnostics[0].Detail, "set source_ref to one of main, next") {
+		t.Errorf("expected an ambiguity error, got %v", diagnostics(resp.Diagnostics))
+	}
+
+	_, state := readProject(attributes{"name": stringValue("checkout"), "source_ref": stringValue("next")})
+	if state.string("id") != "p-next" || state.string("repo_url") != "https://github.com/acme/shop" {
+		t.Errorf("unexpected project: %v", state)
+	}
+
+	_, state = readProject(attributes{"name": stringValue("billing")})
+	if state.string("id") != "p-other" {
+		t.Errorf("unexpected project: %v", state)
+	}
+}
+
+func TestValidateAndConfigure(t *testing.T) {
+	p := New("test").(*provider)
+	schema := environmentResource{}.schema()
+	config := attributes{
+		"project_id": stringValue("proj-1"),
+		"name":       stringValue("Staging"),
+		"slug":       stringValue("Staging"),
+		"secrets": tftypes.NewValue(stringMapType, map[string]tftypes.Value{
+			"EMPTY":   stringValue(""),
+			"PENDING": tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+		}),
+	}
+	resp, err := p.ValidateResourceConfig(context.Background(), &tfprotov6.ValidateResourceConfigRequest{
+		TypeName: "rocketship_environment",
+		Config:   encode(t, schema, config),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := diagnostics(resp.Diagnostics)
+	if len(resp.Diagnostics) != 2 || !strings.Contains(got, `Slug "Staging" must be lowercase`) || !strings.Contains(got, `Secret "EMPTY"`) {
+		t.Errorf("unexpected diagnostics: %s", got)
+	}
+
+	t.Setenv(URLEnvVar, "https://cp.example.com")
+	t.Setenv(TokenEnvVar, "")
+	configResp, err := p.ConfigureProvider(context.Background(), &tfprotov6.ConfigureProviderRequest{
+		Config: encode(t, providerSchema, attributes{}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configResp.Diagnostics) != 1 || configResp.Diagnostics[0].Summary != "Missing access token" {
+		t.Errorf("expected only a missing token error, got %s", diagnostics(configResp.Diagnostics))
+	}
+
+	readResp, err := p.ReadResource(context.Background(), &tfprotov6.ReadResourceRequest{
+		TypeName:     "rocketship_environment",
+		CurrentState: encode(t, schema, config),
+	})
+	if err != nil || len(readResp.Diagnostics) != 1 || readResp.Diagnostics[0].Summary != "Provider not configured" {
+		t.Errorf("expected a not configured error, got %v %s", err, diagnostics(readResp.Diagnostics))
+	}
+}
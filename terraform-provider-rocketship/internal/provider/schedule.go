@@ -0,0 +1,152 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// projectScheduleResource manages a project schedule, which runs every suite in the
+// project against one environment on a cron expression
+type projectScheduleResource struct{}
+
+func (projectScheduleResource) schema() *tfprotov6.Schema {
+	return &tfprotov6.Schema{
+		Block: &tfprotov6.SchemaBlock{
+			Description: "A schedule that runs all suites of a project against one environment. A project has at most one schedule per environment.",
+			Attributes: []*tfprotov6.SchemaAttribute{
+				{
+					Name:        "id",
+					Type:        tftypes.String,
+					Computed:    true,
+					Description: "Schedule ID.",
+				},
+				{
+					Name:        "project_id",
+					Type:        tftypes.String,
+					Required:    true,
+					Description: "ID of the project to run. Changing it replaces the schedule.",
+				},
+				{
+					Name:        "environment_id",
+					Type:        tftypes.String,
+					Required:    true,
+					Description: "ID of the environment runs use. Changing it replaces the schedule.",
+				},
+				{
+					Name:        "name",
+					Type:        tftypes.String,
+					Required:    true,
+					Description: "Display name.",
+				},
+				{
+					Name:        "cron_expression",
+					Type:        tftypes.String,
+					Required:    true,
+					Description: "Five-field cron expression, e.g. `0 */6 * * *`.",
+				},
+				{
+					Name:        "timezone",
+					Type:        tftypes.String,
+					Optional:    true,
+					Computed:    true,
+					Description: "IANA time zone the cron expression is evaluated in. Defaults to `UTC`.",
+				},
+				{
+					Name:        "enabled",
+					Type:        tftypes.Bool,
+					Optional:    true,
+					Computed:    true,
+					Description: "Whether the schedule starts runs. Defaults to `true`.",
+				},
+			},
+		},
+	}
+}
+
+func (projectScheduleResource) validate(config attributes) []*tfprotov6.Diagnostic {
+	// The control plane trims these, which would not match the plan
+	var diags []*tfprotov6.Diagnostic
+	for _, name := range []string{"name", "cron_expression", "timezone"} {
+		if v := config.string(name); v != strings.TrimSpace(v) {
+			diags = append(diags, attributeDiagnostic(name, "Invalid "+name,
+				fmt.Sprintf("%q must not have surrounding spaces.", v)))
+		}
+	}
+	return diags
+}
+
+func (projectScheduleResource) defaults() attributes {
+	return attributes{
+		"timezone": stringValue("UTC"),
+		"enabled":  boolValue(true),
+	}
+}
+
+func (projectScheduleResource) replaceOn() []string {
+	return []string{"project_id", "environment_id"}
+}
+
+func (projectScheduleResource) create(ctx context.Context, c *apiClient, plan attributes) (attributes, error) {
+	schedule, err := c.createProjectSchedule(ctx, plan.string("project_id"), projectScheduleRequest{
+		EnvironmentID:  plan.string("environment_id"),
+		Name:           plan.string("name"),
+		CronExpression: plan.string("cron_expression"),
+		Timezone:       plan.string("timezone"),
+		Enabled:        plan.bool("enabled"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return projectScheduleState(schedule), nil
+}
+
+func (projectScheduleResource) read(ctx context.Context, c *apiClient, state attributes) (attributes, error) {
+	schedule, err := c.getProjectSchedule(ctx, state.string("id"))
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return projectScheduleState(schedule), nil
+}
+
+func (projectScheduleResource) update(ctx context.Context, c *apiClient, _, plan attributes) (attributes, error) {
+	schedule, err := c.updateProjectSchedule(ctx, plan.string("id"), projectScheduleRequest{
+		Name:           plan.string("name"),
+		CronExpression: plan.string("cron_expression"),
+		Timezone:       plan.string("timezone"),
+		Enabled:        plan.bool("enabled"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return projectScheduleState(schedule), nil
+}
+
+func (projectScheduleResource) delete(ctx context.Context, c *apiClient, state attributes) error {
+	return c.deleteProjectSchedule(ctx, state.string("id"))
+}
+
+func (projectScheduleResource) importState(id string) (attributes, error) {
+	if strings.TrimSpace(id) == "" {
+		return nil, fmt.Errorf("expected a schedule ID")
+	}
+	return attributes{"id": stringValue(id)}, nil
+}
+
+func projectScheduleState(schedule *projectSchedule) attributes {
+	return attributes{
+		"id":              stringValue(schedule.ID),
+		"project_id":      stringValue(schedule.ProjectID),
+		"environment_id":  stringValue(schedule.EnvironmentID),
+		"name":            stringValue(schedule.Name),
+		"cron_expression": stringValue(schedule.CronExpression),
+		"timezone":        stringValue(schedule.Timezone),
+		"enabled":         boolValue(schedule.Enabled),
+	}
+}
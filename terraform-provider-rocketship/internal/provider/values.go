@@ -0,0 +1,176 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// attributes holds the top-level attribute values of a resource, data source or
+// provider configuration object
+type attributes map[string]tftypes.Value
+
+// decodeObject unmarshals a dynamic value of the schema's type. A null object is
+// returned as nil attributes.
+func decodeObject(schema *tfprotov6.Schema, value *tfprotov6.DynamicValue) (attributes, error) {
+	if value == nil {
+		return nil, nil
+	}
+	v, err := value.Unmarshal(schema.ValueType())
+	if err != nil {
+		return nil, err
+	}
+	return objectAttributes(v)
+}
+
+func objectAttributes(v tftypes.Value) (attributes, error) {
+	if v.IsNull() {
+		return nil, nil
+	}
+	var attrs attributes
+	if err := v.As((*map[string]tftypes.Value)(&attrs)); err != nil {
+		return nil, err
+	}
+	return attrs, nil
+}
+
+// encodeObject builds a dynamic value of the schema's type. Attributes missing from
+// attrs are set to null; nil attrs encode a null object.
+func encodeObject(schema *tfprotov6.Schema, attrs attributes) (*tfprotov6.DynamicValue, error) {
+	typ := schema.ValueType()
+	if attrs == nil {
+		dv, err := tfprotov6.NewDynamicValue(typ, tftypes.NewValue(typ, nil))
+		return &dv, err
+	}
+
+	values := make(map[string]tftypes.Value, len(schema.Block.Attributes))
+	for _, attr := range schema.Block.Attributes {
+		if v, ok := attrs[attr.Name]; ok {
+			values[attr.Name] = v
+		} else {
+			values[attr.Name] = tftypes.NewValue(attr.ValueType(), nil)
+		}
+	}
+	dv, err := tfprotov6.NewDynamicValue(typ, tftypes.NewValue(typ, values))
+	return &dv, err
+}
+
+func (a attributes) string(name string) string {
+	var s string
+	if v, ok := a[name]; ok && v.IsKnown() && !v.IsNull() {
+		_ = v.As(&s)
+	}
+	return s
+}
+
+func (a attributes) bool(name string) bool {
+	var b bool
+	if v, ok := a[name]; ok && v.IsKnown() && !v.IsNull() {
+		_ = v.As(&b)
+	}
+	return b
+}
+
+// stringMap returns a map(string) attribute, or nil when it is null or unknown
+func (a attributes) stringMap(name string) map[string]string {
+	v, ok := a[name]
+	if !ok || !v.IsKnown() || v.IsNull() {
+		return nil
+	}
+	var elems map[string]tftypes.Value
+	if err := v.As(&elems); err != nil {
+		return nil
+	}
+	m := make(map[string]string, len(elems))
+	for k, elem := range elems {
+		var s string
+		if elem.IsKnown() && !elem.IsNull() {
+			_ = elem.As(&s)
+		}
+		m[k] = s
+	}
+	return m
+}
+
+func stringValue(s string) tftypes.Value {
+	return tftypes.NewValue(tftypes.String, s)
+}
+
+func boolValue(b bool) tftypes.Value {
+	return tftypes.NewValue(tftypes.Bool, b)
+}
+
+var stringMapType = tftypes.Map{ElementType: tftypes.String}
+
+// stringMapValue returns m as a map(string) value; a nil map is null
+func stringMapValue(m map[string]string) tftypes.Value {
+	if m == nil {
+		return tftypes.NewValue(stringMapType, nil)
+	}
+	elems := make(map[string]tftypes.Value, len(m))
+	for k, s := range m {
+		elems[k] = stringValue(s)
+	}
+	return tftypes.NewValue(stringMapType, elems)
+}
+
+func stringListValue(list []string) tftypes.Value {
+	typ := tftypes.List{ElementType: tftypes.String}
+	elems := make([]tftypes.Value, len(list))
+	for i, s := range list {
+		elems[i] = stringValue(s)
+	}
+	return tftypes.NewValue(typ, elems)
+}
+
+// configVarStrings converts config vars to strings. The console can store any JSON
+// value; anything that is not a string is kept as its JSON encoding.
+func configVarStrings(vars map[string]interface{}) map[string]string {
+	m := make(map[string]string, len(vars))
+	for k, v := range vars {
+		if s, ok := v.(string); ok {
+			m[k] = s
+			continue
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			m[k] = fmt.Sprint(v)
+			continue
+		}
+		m[k] = string(data)
+	}
+	return m
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func attributePath(name string) *tftypes.AttributePath {
+	return tftypes.NewAttributePath().WithAttributeName(name)
+}
+
+func errorDiagnostic(summary string, err error) *tfprotov6.Diagnostic {
+	return &tfprotov6.Diagnostic{
+		Severity: tfprotov6.DiagnosticSeverityError,
+		Summary:  summary,
+		Detail:   err.Error(),
+	}
+}
+
+func attributeDiagnostic(name, summary, detail string) *tfprotov6.Diagnostic {
+	return &tfprotov6.Diagnostic{
+		Severity:  tfprotov6.DiagnosticSeverityError,
+		Summary:   summary,
+		Detail:    detail,
+		Attribute: attributePath(name),
+	}
+}
@@ -0,0 +1,32 @@
+// Command terraform-provider-rocketship serves the Rocketship Terraform provider.
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6/tf6server"
+
+	"github.com/rocketship-ai/rocketship/terraform-provider-rocketship/internal/provider"
+)
+
+// version is set at release time with -ldflags "-X main.version=..."
+var version = "dev"
+
+func main() {
+	debug := flag.Bool("debug", false, "run the provider in debug mode for use with a debugger")
+	flag.Parse()
+
+	var opts []tf6server.ServeOpt
+	if *debug {
+		opts = append(opts, tf6server.WithManagedDebug())
+	}
+
+	err := tf6server.Serve("registry.terraform.io/rocketship-ai/rocketship", func() tfprotov6.ProviderServer {
+		return provider.New(version)
+	}, opts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -1,5 +1,5 @@
 import { useState } from 'react';
-import { CheckCircle2, XCircle, Clock, Loader2, ChevronRight, ChevronDown } from 'lucide-react';
+import { CheckCircle2, XCircle, Clock, Loader2, SkipForward, ChevronRight, ChevronDown } from 'lucide-react';
 import type { RunStep } from '../../hooks/use-console-queries';
 import { Tabs } from '../step-ui';
 import { formatDuration } from '../../lib/format';
@@ -151,6 +151,9 @@ function StatusIcon({ status }: { status: StepUIStatus }) {
       return <CheckCircle2 className="w-5 h-5 text-[#4CBB17]" />;
     case 'failed':
       return <XCircle className="w-5 h-5 text-[#ef0000]" />;
+    case 'skipped':
+      // Skipped by its when/unless condition
+      return <SkipForward className="w-5 h-5 text-[#999999]" />;
     case 'running':
       return <Loader2 className="w-5 h-5 text-[#4CBB17] animate-spin" />;
     case 'definition':
@@ -171,6 +174,7 @@ function getBorderColor(status: StepUIStatus): string {
     case 'running':
       // Light gray border for running - spinning icon indicates activity
       return 'border-l-[#d4d4d4]';
+    case 'skipped':
     case 'definition':
     case 'pending':
     default:
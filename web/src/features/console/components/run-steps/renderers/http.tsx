@@ -161,6 +161,17 @@ function ResponseTab({ step }: { step: RunStep }) {
   if (!response_data) return null;
 
   const headerRows = headersToRows(response_data.headers);
+  const timings = response_data.timings;
+  const timingRows = timings
+    ? [
+        { key: 'DNS', value: `${timings.dns_ms} ms` },
+        { key: 'Connect', value: `${timings.connect_ms} ms` },
+        { key: 'TLS', value: `${timings.tls_ms} ms` },
+        { key: 'Time to first byte', value: `${timings.ttfb_ms} ms` },
+        { key: 'Total', value: `${timings.total_ms} ms` },
+        { key: 'Connection reused', value: timings.connection_reused ? 'Yes' : 'No' },
+      ]
+    : [];
 
   return (
     <div className="space-y-4">
@@ -172,6 +183,10 @@ function ResponseTab({ step }: { step: RunStep }) {
         />
       )}
 
+      {timingRows.length > 0 && (
+        <KeyValueTable rows={timingRows} label="Timings" />
+      )}
+
       {response_data.body && (
         <CodeBlock
           code={tryFormatJSON(response_data.body)}
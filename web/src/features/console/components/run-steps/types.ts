@@ -4,7 +4,7 @@ import type { RunStep } from '../../hooks/use-console-queries';
 export type { RunStep, AssertionResult, SavedVariable, StepConfig } from '../../hooks/use-console-queries';
 
 /** UI status for step display */
-export type StepUIStatus = 'success' | 'failed' | 'pending' | 'running' | 'definition';
+export type StepUIStatus = 'success' | 'failed' | 'skipped' | 'pending' | 'running' | 'definition';
 
 /** Map API status to UI status */
 export function mapStepStatus(status: string): StepUIStatus {
@@ -13,6 +13,8 @@ export function mapStepStatus(status: string): StepUIStatus {
       return 'success';
     case 'FAILED':
       return 'failed';
+    case 'SKIPPED':
+      return 'skipped';
     case 'RUNNING':
       return 'running';
     case 'DEFINITION':
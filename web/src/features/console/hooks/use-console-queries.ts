@@ -1,5 +1,5 @@
 import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query'
-import { apiGet, apiPost, apiPut, apiPatch, apiDelete } from '@/lib/api'
+import { apiGet, apiGetAll, apiPost, apiPut, apiPatch, apiDelete } from '@/lib/api'
 import { isLiveRunStatus, isLiveTestStatus, isLiveStepStatus } from '../lib/format'
 
 // Polling intervals (ms) for two-tier polling strategy
@@ -235,6 +235,9 @@ export interface StepConfig {
     initial_interval?: string
     maximum_interval?: string
     backoff_coefficient?: number
+    attempts?: number
+    backoff?: StepRetryBackoff
+    on?: string[]
   }
 }
 
@@ -267,6 +270,14 @@ export interface RunStep {
     body?: string
     body_truncated?: boolean
     body_bytes?: number
+    timings?: {
+      dns_ms: number
+      connect_ms: number
+      tls_ms: number
+      ttfb_ms: number
+      total_ms: number
+      connection_reused: boolean
+    }
   }
   assertions_data?: AssertionResult[]
   variables_data?: SavedVariable[]
@@ -428,7 +439,7 @@ export const consoleKeys = {
 export function useProjects() {
   return useQuery({
     queryKey: consoleKeys.projects(),
-    queryFn: () => apiGet<ProjectSummary[]>('/api/projects'),
+    queryFn: () => apiGetAll<ProjectSummary>('/api/projects'),
   })
 }
 
@@ -443,7 +454,7 @@ export function useProject(projectId: string) {
 export function useProjectSuites(projectId: string) {
   return useQuery({
     queryKey: consoleKeys.projectSuites(projectId),
-    queryFn: () => apiGet<SuiteSummary[]>(`/api/projects/${projectId}/suites`),
+    queryFn: () => apiGetAll<SuiteSummary>(`/api/projects/${projectId}/suites`),
     enabled: !!projectId,
   })
 }
@@ -742,7 +753,7 @@ export function useSyncGithubApp() {
 export function useProjectEnvironments(projectId: string) {
   return useQuery({
     queryKey: consoleKeys.projectEnvironments(projectId),
-    queryFn: () => apiGet<ProjectEnvironment[]>(`/api/projects/${projectId}/environments`),
+    queryFn: () => apiGetAll<ProjectEnvironment>(`/api/projects/${projectId}/environments`),
     enabled: !!projectId,
   })
 }
@@ -1409,6 +1420,17 @@ export interface StepRetryPolicy {
   maximum_attempts?: number
   backoff_coefficient?: number
   non_retryable_errors?: string[]
+  attempts?: number
+  backoff?: StepRetryBackoff
+  on?: string[]
+}
+
+// Wait between the attempts of a step retried by the workflow
+export interface StepRetryBackoff {
+  delay?: string
+  max_delay?: string
+  multiplier?: number
+  jitter?: number
 }
 
 // Enriched step definition from the test YAML
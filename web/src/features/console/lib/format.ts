@@ -246,3 +246,27 @@ export function isLiveStepStatus(status: string): boolean {
   return upper === 'PENDING' || upper === 'RUNNING';
 }
 
+
+// =============================================================================
+// Log Formatting
+// =============================================================================
+
+/**
+ * Format a run log line: the time, the level of log step warnings and errors,
+ * the message, and the log step's fields as sorted key=value pairs.
+ */
+export function formatRunLog(log: { level: string; message: string; logged_at: string; metadata?: Record<string, unknown> }): string {
+  let line = `[${new Date(log.logged_at).toLocaleTimeString()}]`;
+  if (log.level === 'WARN' || log.level === 'ERROR') {
+    line += ` ${log.level}`;
+  }
+  line += ` ${log.message}`;
+  const fields = log.metadata?.fields;
+  if (fields && typeof fields === 'object') {
+    for (const [key, value] of Object.entries(fields as Record<string, unknown>).sort(([a], [b]) => a.localeCompare(b))) {
+      const text = String(value);
+      line += ` ${key}=${text === '' || /[\s"=]/.test(text) ? JSON.stringify(text) : text}`;
+    }
+  }
+  return line;
+}
@@ -39,7 +39,7 @@ import { ConfirmDialog } from '../components/confirm-dialog';
 import { InviteMemberModal, type InviteMemberFormData } from '../components/invite-member-modal';
 import { InfoLabel } from '../components/info-label';
 import { Button } from '../components/ui';
-import { apiGet } from '@/lib/api';
+import { apiGet, apiGetAll } from '@/lib/api';
 
 // Helper type for All Projects view
 interface EnvWithProject extends ProjectEnvironment {
@@ -210,7 +210,7 @@ export function Environments() {
     queries: !selectedProjectId
       ? filteredProjects.map((project) => ({
           queryKey: consoleKeys.projectEnvironments(project.id),
-          queryFn: () => apiGet<ProjectEnvironment[]>(`/api/projects/${project.id}/environments`),
+          queryFn: () => apiGetAll<ProjectEnvironment>(`/api/projects/${project.id}/environments`),
         }))
       : [],
   });
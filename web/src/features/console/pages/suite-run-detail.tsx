@@ -6,7 +6,7 @@ import { useState } from 'react';
 import { useRun, useRunTests, useRunLogs, type RunTest } from '../hooks/use-console-queries';
 import { useLiveDurationMs } from '../hooks/use-live-duration';
 import { LoadingState, ErrorState } from '../components/ui';
-import { formatDuration, formatDateTime, mapRunStatus, mapTestStatusLive, mapStepStatusForSummary, isLiveRunStatus, isLiveTestStatus } from '../lib/format';
+import { formatDuration, formatDateTime, formatRunLog, mapRunStatus, mapTestStatusLive, mapStepStatusForSummary, isLiveRunStatus, isLiveTestStatus } from '../lib/format';
 
 interface SuiteRunDetailProps {
   suiteRunId: string;
@@ -122,7 +122,7 @@ export function SuiteRunDetail({ suiteRunId, onBack, onViewTestRun }: SuiteRunDe
 
   // Format logs for display
   const logs = (logsData || [])
-    .map(log => `[${new Date(log.logged_at).toLocaleTimeString()}] ${log.message}`)
+    .map(formatRunLog)
     .join('\n') || 'No logs available';
 
   return (
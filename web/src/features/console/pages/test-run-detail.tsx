@@ -5,7 +5,7 @@ import { useTestRun, useTestRunLogs, useTestRunSteps } from '../hooks/use-consol
 import { useLiveDurationMs } from '../hooks/use-live-duration';
 import { RunStepCard } from '../components/run-steps';
 import { LogsPanel } from '../components/logs-panel';
-import { formatDuration, formatDateTime, isLiveTestStatus } from '../lib/format';
+import { formatDuration, formatDateTime, formatRunLog, isLiveTestStatus } from '../lib/format';
 
 interface TestRunDetailProps {
   testRunId: string;
@@ -105,7 +105,7 @@ export function TestRunDetail({ testRunId, onBack }: TestRunDetailProps) {
 
   // Format logs for display
   const logs = (logsData || [])
-    .map(log => `[${new Date(log.logged_at).toLocaleTimeString()}] ${log.message}`)
+    .map(formatRunLog)
     .join('\n') || 'No logs available';
 
   // Use step counts from the test to show summary info
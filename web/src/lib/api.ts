@@ -69,6 +69,34 @@ export function apiGet<T = unknown>(url: string, options?: RequestOptions): Prom
   return apiFetch<T>(url, { ...options, method: 'GET' })
 }
 
+/**
+ * A page of a list endpoint
+ */
+export interface ListPage<T> {
+  items: T[]
+  next_cursor?: string
+  total_count: number
+}
+
+/**
+ * GET every item of a paginated list endpoint, following next_cursor
+ */
+export async function apiGetAll<T = unknown>(url: string, options?: RequestOptions): Promise<T[]> {
+  const items: T[] = []
+  let cursor: string | undefined
+  do {
+    const pageUrl = new URL(url, window.location.origin)
+    pageUrl.searchParams.set('limit', '500')
+    if (cursor) {
+      pageUrl.searchParams.set('cursor', cursor)
+    }
+    const page = await apiGet<ListPage<T>>(pageUrl.pathname + pageUrl.search, options)
+    items.push(...page.items)
+    cursor = page.next_cursor
+  } while (cursor)
+  return items
+}
+
 /**
  * POST request helper
  */